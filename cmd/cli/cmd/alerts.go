@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// alertsCmd groups the commands for managing how alerts are handled.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Suppress and unsuppress alert investigations",
+	Long: `Stop alerts from being investigated, for example during a maintenance
+window. Alerts are matched by fingerprint: Alertmanager's fingerprint for
+Prometheus alerts, or the one shown by "investigations show".
+
+Suppressions are kept with the investigations in <dir>/.agent/investigations,
+so a running "serve" picks them up on the next alert.
+
+Example:
+  code-editing-agent alerts suppress 3f2a9c81d0b4e765 --for 2h --reason "db maintenance"
+  code-editing-agent alerts unsuppress 3f2a9c81d0b4e765`,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var alertsSuppressCmd = &cobra.Command{
+	Use:   "suppress <fingerprint>",
+	Short: "Record alerts with this fingerprint as suppressed instead of investigating them",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertsSuppress,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var alertsUnsuppressCmd = &cobra.Command{
+	Use:   "unsuppress <fingerprint>",
+	Short: "Investigate alerts with this fingerprint again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertsUnsuppress,
+}
+
+func init() {
+	rootCmd.AddCommand(alertsCmd)
+	alertsCmd.AddCommand(alertsSuppressCmd, alertsUnsuppressCmd)
+
+	alertsSuppressCmd.Flags().Duration("for", 0, "How long to suppress the alerts, such as 2h")
+	alertsSuppressCmd.Flags().String("until", "", "When the suppression ends (RFC 3339 or YYYY-MM-DD)")
+	alertsSuppressCmd.Flags().String("reason", "", "Why the alerts are suppressed; stored with each suppressed alert")
+	alertsSuppressCmd.MarkFlagsMutuallyExclusive("for", "until")
+}
+
+// newSuppressionHandler returns an alert handler that manages the
+// suppressions of the configured working directory, and a function to close
+// its store.
+func newSuppressionHandler(cmd *cobra.Command) (*usecase.AlertHandler, func(), error) {
+	store, err := openInvestigationStore(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler := usecase.NewAlertHandler(nil, usecase.AlertHandlerConfig{})
+	handler.SetSuppressionStore(store)
+	return handler, func() { _ = store.Close() }, nil
+}
+
+func runAlertsSuppress(cmd *cobra.Command, args []string) error {
+	forDuration, _ := cmd.Flags().GetDuration("for")
+	untilFlag, _ := cmd.Flags().GetString("until")
+	reason, _ := cmd.Flags().GetString("reason")
+	until, err := suppressionEnd(forDuration, untilFlag, time.Now())
+	if err != nil {
+		return err
+	}
+
+	handler, closeStore, err := newSuppressionHandler(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	return suppressAlert(cmd.Context(), handler, args[0], until, reason, cmd.OutOrStdout())
+}
+
+func runAlertsUnsuppress(cmd *cobra.Command, args []string) error {
+	handler, closeStore, err := newSuppressionHandler(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	return unsuppressAlert(cmd.Context(), handler, args[0], cmd.OutOrStdout())
+}
+
+// suppressionEnd returns when a suppression given by --for or --until ends.
+func suppressionEnd(forDuration time.Duration, until string, now time.Time) (time.Time, error) {
+	switch {
+	case forDuration != 0:
+		return now.Add(forDuration), nil
+	case until == "":
+		return time.Time{}, errors.New("--for or --until is required")
+	}
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, until, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --until %q: want an RFC 3339 time or YYYY-MM-DD", until)
+}
+
+// suppressAlert suppresses a fingerprint and reports it.
+func suppressAlert(
+	ctx context.Context,
+	suppressor port.AlertSuppressor,
+	fingerprint string,
+	until time.Time,
+	reason string,
+	w io.Writer,
+) error {
+	if err := suppressor.Suppress(ctx, fingerprint, until, reason); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Suppressed alerts with fingerprint %s until %s.\n", fingerprint, until.Format(time.RFC3339))
+	return err
+}
+
+// unsuppressAlert lifts the suppression of a fingerprint and reports it.
+func unsuppressAlert(ctx context.Context, suppressor port.AlertSuppressor, fingerprint string, w io.Writer) error {
+	if err := suppressor.Unsuppress(ctx, fingerprint); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Alerts with fingerprint %s will be investigated again.\n", fingerprint)
+	return err
+}
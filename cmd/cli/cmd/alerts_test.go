@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/infrastructure/adapter/investigation"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressionEnd(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		forFlag time.Duration
+		until   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"for", 2 * time.Hour, "", now.Add(2 * time.Hour), false},
+		{"until time", 0, "2026-03-04T18:00:00+02:00", time.Date(2026, 3, 4, 16, 0, 0, 0, time.UTC), false},
+		{"until date", 0, "2026-03-05", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"neither", 0, "", time.Time{}, true},
+		{"bad until", 0, "tomorrow", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suppressionEnd(tt.forFlag, tt.until, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "suppressionEnd() = %v, want %v", got, tt.want)
+		})
+	}
+}
+
+func TestSuppressAndUnsuppressAlert(t *testing.T) {
+	ctx := context.Background()
+	store, err := investigation.NewFileInvestigationStore(t.TempDir())
+	require.NoError(t, err)
+	handler := usecase.NewAlertHandler(nil, usecase.AlertHandlerConfig{})
+	handler.SetSuppressionStore(store)
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	var out bytes.Buffer
+	require.NoError(t, suppressAlert(ctx, handler, "3f2a9c81d0b4e765", until, "db maintenance", &out))
+	assert.Equal(t, "Suppressed alerts with fingerprint 3f2a9c81d0b4e765 until "+until.Format(time.RFC3339)+".\n",
+		out.String())
+
+	stored, err := store.Suppression(ctx, "3f2a9c81d0b4e765")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.True(t, until.Equal(stored.Until))
+	assert.Equal(t, "db maintenance", stored.Reason)
+
+	out.Reset()
+	require.NoError(t, unsuppressAlert(ctx, handler, "3f2a9c81d0b4e765", &out))
+	assert.Equal(t, "Alerts with fingerprint 3f2a9c81d0b4e765 will be investigated again.\n", out.String())
+	stored, err = store.Suppression(ctx, "3f2a9c81d0b4e765")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	err = suppressAlert(ctx, handler, "3f2a9c81d0b4e765", time.Now().Add(-time.Minute), "", &out)
+	assert.ErrorIs(t, err, entity.ErrSuppressionEnded)
+	err = suppressAlert(ctx, handler, "../etc", until, "", &out)
+	assert.ErrorIs(t, err, entity.ErrInvalidFingerprint)
+}
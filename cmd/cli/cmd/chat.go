@@ -1,13 +1,25 @@
 package cmd
 
 import (
+	"code-editing-agent/internal/application/dto"
 	appsvc "code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
 	"code-editing-agent/internal/infrastructure/config"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -19,12 +31,22 @@ var chatCmd = &cobra.Command{
 	Long: `Start an interactive chat session with the AI assistant.
 You can ask questions about your code, request edits, or get explanations.
 
-Press Ctrl+C to exit the chat session.`,
+Press Ctrl+C to exit the chat session.
+
+With --prompt, or with data piped on stdin, it answers once and exits
+instead: the answer goes to stdout and tool output to stderr. Edits and
+mutating commands are refused unless --allow-edits is given.
+
+Example:
+  cat error.log | code-editing-agent -p "what's wrong here"
+  code-editing-agent -p "list the TODOs in main.go" --output json`,
 	RunE: runChat,
 }
 
 func init() {
 	rootCmd.AddCommand(chatCmd)
+	addOneShotFlags(rootCmd)
+	addOneShotFlags(chatCmd)
 	// Set the executeChat function so rootCmd can delegate to it
 	executeChat = runChat
 }
@@ -35,12 +57,37 @@ type inputResult struct {
 	ok   bool
 }
 
+// completionRegistrar is implemented by user interfaces that support Tab completion.
+type completionRegistrar interface {
+	RegisterCompleter(trigger string, fn ui.CompletionFunc)
+}
+
+// registerCommandCompletions adds Tab completion for the chat commands and their arguments.
+func registerCommandCompletions(uiAdapter port.UserInterface, toolNames []string) {
+	registrar, ok := uiAdapter.(completionRegistrar)
+	if !ok {
+		return
+	}
+	registrar.RegisterCompleter(":", ui.StaticCompletion(
+		"mode", "thinking", "expand", "verbose", "checkpoint", "rollback", "attach", "schema", "memory", "diff", "stats",
+		"where", "tools", "debug", "prompt", "model", "sessions", "rename", "new", "switch", "q"))
+	registrar.RegisterCompleter(":mode ", ui.StaticCompletion("plan", "normal", "toggle"))
+	registrar.RegisterCompleter(":thinking ", ui.StaticCompletion("on", "off", "toggle", "budget"))
+	registrar.RegisterCompleter(":verbose ", ui.StaticCompletion("on", "off", "toggle"))
+	registrar.RegisterCompleter(":attach ", ui.NewPathCompletion("."))
+	registrar.RegisterCompleter(":schema ", ui.StaticCompletion(toolNames...))
+	registrar.RegisterCompleter(":memory ", ui.StaticCompletion("edit"))
+	registrar.RegisterCompleter(":prompt ", ui.StaticCompletion("show"))
+	registrar.RegisterCompleter(":rename ", ui.StaticCompletion("auto"))
+	registrar.RegisterCompleter(":tools ", ui.StaticCompletion("reload"))
+	registrar.RegisterCompleter(":debug ", ui.StaticCompletion("api"))
+}
+
 // handleModeCommand handles the :mode command to toggle plan mode.
 func handleModeCommand(
 	ctx context.Context,
 	sessionID, cmdText string,
 	chatService *appsvc.ChatService,
-	container *config.Container,
 	uiAdapter port.UserInterface,
 ) bool {
 	if !strings.HasPrefix(cmdText, ":mode") {
@@ -53,21 +100,32 @@ func handleModeCommand(
 		mode = parts[1]
 	}
 
-	if err := chatService.HandleModeCommand(ctx, sessionID, mode); err != nil {
+	if err := chatService.SwitchMode(ctx, sessionID, mode); err != nil {
 		_ = uiAdapter.DisplayError(err)
-		return true
 	}
+	return true
+}
 
-	// Display current mode status
-	convSvc := container.ConversationService()
-	if isPlanMode, _ := convSvc.IsPlanMode(sessionID); isPlanMode {
-		_ = uiAdapter.DisplaySystemMessage(
-			"Plan mode enabled: Tools will write plans to files instead of executing.",
-		)
-	} else {
-		_ = uiAdapter.DisplaySystemMessage("Plan mode disabled: Tools will execute normally.")
+// modeToggler is implemented by user interfaces with a mode toggle key (Shift+Tab).
+type modeToggler interface {
+	SetModeToggleCallback(callback func())
+}
+
+// registerModeToggle makes the UI's mode toggle key switch plan mode like
+// ":mode toggle", in whichever session activeSession returns.
+func registerModeToggle(
+	ctx context.Context,
+	activeSession func() string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) {
+	toggler, ok := uiAdapter.(modeToggler)
+	if !ok {
+		return
 	}
-	return true
+	toggler.SetModeToggleCallback(func() {
+		handleModeCommand(ctx, activeSession(), ":mode toggle", chatService, uiAdapter)
+	})
 }
 
 // handleThinkingCommand handles the :thinking command to toggle extended thinking mode.
@@ -88,7 +146,13 @@ func handleThinkingCommand(
 		mode = parts[1]
 	}
 
-	if err := chatService.HandleThinkingCommand(ctx, sessionID, mode); err != nil {
+	var err error
+	if mode == "budget" {
+		err = setThinkingBudget(ctx, sessionID, parts[2:], chatService)
+	} else {
+		err = chatService.HandleThinkingCommand(ctx, sessionID, mode)
+	}
+	if err != nil {
 		_ = uiAdapter.DisplayError(err)
 		return true
 	}
@@ -105,8 +169,683 @@ func handleThinkingCommand(
 	return true
 }
 
+// setThinkingBudget handles ":thinking budget <n>".
+func setThinkingBudget(ctx context.Context, sessionID string, args []string, chatService *appsvc.ChatService) error {
+	if len(args) != 1 {
+		return errors.New("usage: :thinking budget <tokens>")
+	}
+	budget, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid thinking budget %q: must be a number of tokens", args[0])
+	}
+	return chatService.SetThinkingBudget(ctx, sessionID, budget)
+}
+
+// handleExpandCommand handles the :expand command, which shows the full content
+// of the last thinking block.
+func handleExpandCommand(cmdText string, uiAdapter port.UserInterface) bool {
+	if strings.TrimSpace(cmdText) != ":expand" {
+		return false
+	}
+
+	expander, ok := uiAdapter.(interface{ ExpandLastThinking() error })
+	if !ok {
+		_ = uiAdapter.DisplaySystemMessage("Thinking blocks cannot be expanded in this interface")
+		return true
+	}
+	if err := expander.ExpandLastThinking(); err != nil {
+		_ = uiAdapter.DisplayError(err)
+	}
+	return true
+}
+
+// verboseDisplay is implemented by user interfaces that can show tool calls
+// with their full input.
+type verboseDisplay interface {
+	SetVerbose(verbose bool)
+	IsVerbose() bool
+}
+
+// handleVerboseCommand handles the :verbose command, which switches tool calls
+// between compact summaries and their full input.
+func handleVerboseCommand(cmdText string, uiAdapter port.UserInterface) bool {
+	parts := strings.Fields(cmdText)
+	if len(parts) == 0 || parts[0] != ":verbose" {
+		return false
+	}
+
+	display, ok := uiAdapter.(verboseDisplay)
+	if !ok {
+		_ = uiAdapter.DisplaySystemMessage("Verbose tool display is not supported in this interface")
+		return true
+	}
+	mode := "toggle"
+	if len(parts) > 1 {
+		mode = parts[1]
+	}
+	switch mode {
+	case "on":
+		display.SetVerbose(true)
+	case "off":
+		display.SetVerbose(false)
+	case "toggle":
+		display.SetVerbose(!display.IsVerbose())
+	default:
+		_ = uiAdapter.DisplayError(fmt.Errorf("invalid verbose mode %q: use on, off, or toggle", mode))
+		return true
+	}
+
+	if display.IsVerbose() {
+		_ = uiAdapter.DisplaySystemMessage("Verbose tool display enabled: tool calls show their full input")
+	} else {
+		_ = uiAdapter.DisplaySystemMessage("Verbose tool display disabled: tool calls show a compact summary")
+	}
+	return true
+}
+
+// handleCheckpointCommand handles the :checkpoint command, which records a
+// checkpoint of the conversation to roll back to later.
+func handleCheckpointCommand(
+	ctx context.Context,
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) bool {
+	if strings.TrimSpace(cmdText) != ":checkpoint" {
+		return false
+	}
+
+	if err := chatService.Checkpoint(ctx, sessionID); err != nil {
+		_ = uiAdapter.DisplayError(err)
+	}
+	return true
+}
+
+// handleRollbackCommand handles ":rollback [id]", which truncates the
+// conversation back to the given checkpoint or, without one, the latest.
+func handleRollbackCommand(
+	ctx context.Context,
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) bool {
+	parts := strings.Fields(cmdText)
+	if len(parts) == 0 || parts[0] != ":rollback" {
+		return false
+	}
+
+	var err error
+	switch len(parts) {
+	case 1:
+		err = chatService.RollbackToLatestCheckpoint(ctx, sessionID)
+	case 2:
+		checkpointID, parseErr := strconv.Atoi(parts[1])
+		if parseErr != nil {
+			err = fmt.Errorf("invalid checkpoint %q: must be a checkpoint number", parts[1])
+			break
+		}
+		err = chatService.Rollback(ctx, sessionID, checkpointID)
+	default:
+		err = errors.New("usage: :rollback [checkpoint]")
+	}
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+	}
+	return true
+}
+
+// handleAttachCommand handles ":attach <path>", which attaches an image to the
+// next message.
+func handleAttachCommand(
+	ctx context.Context,
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":attach" {
+		return false
+	}
+
+	path := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdText), ":attach"))
+	if path == "" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :attach <image path>"))
+		return true
+	}
+	if err := chatService.AttachImage(ctx, sessionID, path); err != nil {
+		_ = uiAdapter.DisplayError(err)
+	}
+	return true
+}
+
+// handleSchemaCommand handles ":schema <tool>", which shows the JSON schema
+// the model is given for a tool's input.
+func handleSchemaCommand(cmdText string, chatService *appsvc.ChatService, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":schema" {
+		return false
+	}
+
+	tools, err := chatService.ListTools()
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+		return true
+	}
+	if len(fields) != 2 {
+		_ = uiAdapter.DisplayError(fmt.Errorf("usage: :schema <tool> (one of: %s)", strings.Join(toolNames(tools), ", ")))
+		return true
+	}
+
+	for _, tool := range tools {
+		if tool.Name != fields[1] {
+			continue
+		}
+		schema, err := json.MarshalIndent(tool.InputSchema, "", "  ")
+		if err != nil {
+			_ = uiAdapter.DisplayError(fmt.Errorf("failed to encode schema for %s: %w", tool.Name, err))
+			return true
+		}
+		_ = uiAdapter.DisplaySystemMessage(fmt.Sprintf("Input schema for %s:\n%s", tool.Name, schema))
+		return true
+	}
+	_ = uiAdapter.DisplayError(fmt.Errorf("unknown tool %q (one of: %s)", fields[1], strings.Join(toolNames(tools), ", ")))
+	return true
+}
+
+// handleModelCommand handles ":model", which shows the current model and what
+// it supports, and ":model <name>", which switches to another model unless
+// the session relies on a feature it lacks.
+func handleModelCommand(
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	container *config.Container,
+	uiAdapter port.UserInterface,
+) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":model" {
+		return false
+	}
+	if len(fields) > 2 {
+		_ = uiAdapter.DisplayError(errors.New("usage: :model [name]"))
+		return true
+	}
+	if len(fields) == 2 {
+		if err := chatService.SetAIModel(sessionID, fields[1]); err != nil {
+			_ = uiAdapter.DisplayError(err)
+			return true
+		}
+	}
+
+	model := chatService.GetAIModel()
+	caps, ok := port.CapabilitiesOf(container.AIAdapter(), model)
+	if !ok {
+		_ = uiAdapter.DisplaySystemMessage("Model: " + model)
+		return true
+	}
+	_ = uiAdapter.DisplaySystemMessage(fmt.Sprintf(
+		"Model: %s\n  context window: %d tokens\n  max output: %d tokens\n  tools: %s, thinking: %s, images: %s",
+		model, caps.ContextWindow, caps.MaxOutputTokens,
+		yesNo(caps.SupportsTools), yesNo(caps.SupportsThinking), yesNo(caps.SupportsImages)))
+	return true
+}
+
+// yesNo formats a capability flag.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// handleMemoryCommand handles ":memory", which shows the memory files loaded
+// into the system prompt, and ":memory edit", which opens the project memory
+// file in $VISUAL or $EDITOR and reloads memory afterwards.
+func handleMemoryCommand(cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":memory" {
+		return false
+	}
+
+	store := container.Memory()
+	if store == nil {
+		_ = uiAdapter.DisplaySystemMessage("Memory is disabled (memory.enabled is false)")
+		return true
+	}
+
+	switch {
+	case len(fields) == 1:
+		files, err := store.Files()
+		if err != nil {
+			_ = uiAdapter.DisplayError(err)
+			return true
+		}
+		if len(files) == 0 {
+			_ = uiAdapter.DisplaySystemMessage(fmt.Sprintf(
+				"No memory yet. Ask me to remember a preference, or run :memory edit to write %s", store.LocalPath()))
+			return true
+		}
+		for _, file := range files {
+			_ = uiAdapter.DisplaySystemMessage(fmt.Sprintf("%s:\n%s", file.Path, strings.TrimRight(file.Content, "\n")))
+		}
+	case len(fields) == 2 && fields[1] == "edit":
+		if err := editMemory(store.LocalPath()); err != nil {
+			_ = uiAdapter.DisplayError(err)
+			return true
+		}
+		if err := container.ReloadMemory(); err != nil {
+			_ = uiAdapter.DisplayError(err)
+			return true
+		}
+		_ = uiAdapter.DisplaySystemMessage("Memory reloaded from " + store.LocalPath())
+	default:
+		_ = uiAdapter.DisplayError(errors.New("usage: :memory [edit]"))
+	}
+	return true
+}
+
+// editMemory opens path in the user's editor, creating it and its directory
+// first like edit_file does for a new file.
+func editMemory(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create memory directory: %w", err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return fmt.Errorf("failed to create memory file: %w", err)
+		}
+	}
+
+	// The editor may be a command with arguments, such as "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// handleDiffCommand handles ":diff", which shows the files this session's
+// tools changed, with line counts and a unified diff against their contents
+// before the session first modified them.
+func handleDiffCommand(sessionID, cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	if strings.TrimSpace(cmdText) != ":diff" {
+		return false
+	}
+	_ = uiAdapter.DisplaySystemMessage(container.ChangeTracker().Summary(sessionID).String())
+	return true
+}
+
+// handleStatsCommand handles ":stats", which shows this session's tool usage:
+// calls, errors, and time and output bytes per tool, most used first, and the
+// tool schema tokens tool selection saved.
+func handleStatsCommand(sessionID, cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	if strings.TrimSpace(cmdText) != ":stats" {
+		return false
+	}
+	stats := usecase.FormatToolStats(container.ToolStats().GetToolStats(sessionID))
+	if selection, ok := container.ConversationService().ToolSelectionStats(sessionID); ok {
+		stats += "\n" + formatToolSelectionStats(selection)
+	}
+	_ = uiAdapter.DisplaySystemMessage(stats)
+	return true
+}
+
+// handleWhereCommand handles ":where <snippet>", which shows the files and
+// lines that this session's recent tool results quoting the snippet came
+// from, most recent first.
+func handleWhereCommand(sessionID, cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":where" {
+		return false
+	}
+	snippet := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdText), ":where"))
+	if snippet == "" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :where <snippet>"))
+		return true
+	}
+	locations := container.ConversationService().FindSnippet(sessionID, snippet)
+	_ = uiAdapter.DisplaySystemMessage(formatSnippetLocations(locations))
+	return true
+}
+
+// handleToolsCommand handles ":tools reload", which re-runs the registration
+// of the tools that depend on the host and reports the tools it added and
+// removed; the next request offers the new set.
+func handleToolsCommand(cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":tools" {
+		return false
+	}
+	if len(fields) != 2 || fields[1] != "reload" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :tools reload"))
+		return true
+	}
+	added, removed := container.ReloadTools()
+	_ = uiAdapter.DisplaySystemMessage(formatToolChanges(added, removed))
+	return true
+}
+
+// handleDebugCommand handles ":debug api on|off", which starts or stops
+// writing each AI provider request and response to a debug file, and
+// ":debug api", which shows whether it is on and where the files go.
+func handleDebugCommand(cmdText string, container *config.Container, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":debug" {
+		return false
+	}
+	if len(fields) < 2 || len(fields) > 3 || fields[1] != "api" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :debug api [on|off]"))
+		return true
+	}
+	apiDebug := container.APIDebug()
+	if len(fields) == 3 {
+		switch fields[2] {
+		case "on":
+			apiDebug.SetEnabled(true)
+		case "off":
+			apiDebug.SetEnabled(false)
+		default:
+			_ = uiAdapter.DisplayError(errors.New("usage: :debug api [on|off]"))
+			return true
+		}
+	}
+	if apiDebug.Enabled() {
+		_ = uiAdapter.DisplaySystemMessage("API debug logging is on, writing to " + apiDebug.Dir())
+	} else {
+		_ = uiAdapter.DisplaySystemMessage("API debug logging is off")
+	}
+	return true
+}
+
+// formatToolChanges describes the tools a reload added and removed.
+func formatToolChanges(added, removed []string) string {
+	if len(added) == 0 && len(removed) == 0 {
+		return "Tools reloaded: no changes"
+	}
+	var changes []string
+	if len(added) > 0 {
+		changes = append(changes, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, "removed "+strings.Join(removed, ", "))
+	}
+	return "Tools reloaded: " + strings.Join(changes, "; ")
+}
+
+// formatSnippetLocations lists where a snippet was found, one "path:start-end" per line.
+func formatSnippetLocations(locations []entity.Provenance) string {
+	if len(locations) == 0 {
+		return "No recent file read contains that snippet"
+	}
+	lines := make([]string, len(locations))
+	for i, location := range locations {
+		lines[i] = location.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatToolSelectionStats describes the tool schema tokens tool selection
+// sent and saved.
+func formatToolSelectionStats(stats service.ToolSelectionStats) string {
+	percent := 0
+	if total := stats.OfferedTokens + stats.SavedTokens; total > 0 {
+		percent = stats.SavedTokens * 100 / total
+	}
+	return fmt.Sprintf("Tool selection: ~%d tool schema tokens sent and ~%d saved (%d%%) over %d requests",
+		stats.OfferedTokens, stats.SavedTokens, percent, stats.Turns)
+}
+
+// handlePromptCommand handles ":prompt show", which shows the system prompt
+// this session's next message sends, with a header naming each layer.
+func handlePromptCommand(sessionID, cmdText string, chatService *appsvc.ChatService, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":prompt" {
+		return false
+	}
+	if len(fields) != 2 || fields[1] != "show" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :prompt show"))
+		return true
+	}
+	prompt, err := chatService.SystemPrompt(sessionID)
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+		return true
+	}
+	_ = uiAdapter.DisplaySystemMessage(prompt.Annotated())
+	return true
+}
+
+// handleSessionsCommand handles ":sessions", which lists the saved sessions,
+// most recently updated first, with their titles, ages, and workspaces.
+func handleSessionsCommand(
+	ctx context.Context,
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) bool {
+	if strings.TrimSpace(cmdText) != ":sessions" {
+		return false
+	}
+
+	sessions, err := chatService.ListSessions(ctx)
+	if errors.Is(err, service.ErrConversationStoreMissing) {
+		_ = uiAdapter.DisplayError(errors.New("sessions are not being saved; set session_dir to keep them"))
+		return true
+	}
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+		return true
+	}
+	if len(sessions) == 0 {
+		_ = uiAdapter.DisplaySystemMessage("No saved sessions.")
+		return true
+	}
+	_ = uiAdapter.DisplaySystemMessage(formatSessionList(sessions, sessionID, time.Now()))
+	return true
+}
+
+// formatSessionList renders one line per session, marking the current one.
+func formatSessionList(sessions []port.SessionMetadata, currentID string, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("Sessions:")
+	for _, session := range sessions {
+		marker := " "
+		if session.SessionID == currentID {
+			marker = "*"
+		}
+		title := session.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(&b, "\n%s %s  %s", marker, title, relativeAge(session.UpdatedAt, now))
+		if session.Workspace != "" {
+			fmt.Fprintf(&b, "  %s", session.Workspace)
+		}
+		fmt.Fprintf(&b, "  [%s, %d messages]", session.SessionID, session.MessageCount)
+	}
+	return b.String()
+}
+
+// relativeAge describes how long before now t was, in its largest unit.
+func relativeAge(t, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+	}
+}
+
+// handleRenameCommand handles ":rename <title>", which sets the session's
+// title, and ":rename auto", which generates a new one from the conversation.
+func handleRenameCommand(
+	ctx context.Context,
+	sessionID, cmdText string,
+	chatService *appsvc.ChatService,
+	uiAdapter port.UserInterface,
+) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":rename" {
+		return false
+	}
+
+	title := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdText), ":rename"))
+	if title == "" {
+		_ = uiAdapter.DisplayError(errors.New("usage: :rename <title>|auto"))
+		return true
+	}
+	if err := chatService.RenameSession(ctx, sessionID, title); err != nil {
+		_ = uiAdapter.DisplayError(err)
+	}
+	return true
+}
+
+// handleNewCommand handles ":new", which starts a fresh conversation and
+// makes it the active one. The previous one stays open for :switch.
+func handleNewCommand(
+	ctx context.Context,
+	cmdText string,
+	cfg *config.Config,
+	sessions *appsvc.SessionMultiplexer,
+	container *config.Container,
+	uiAdapter port.UserInterface,
+) bool {
+	if strings.TrimSpace(cmdText) != ":new" {
+		return false
+	}
+	tab, err := sessions.New(ctx)
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+		return true
+	}
+	initSession(tab.SessionID, cfg, container)
+	_ = uiAdapter.DisplaySystemMessage(fmt.Sprintf("Switched to session %d. Use :switch to go back.", tab.Number))
+	return true
+}
+
+// handleSwitchCommand handles ":switch <n|title>", which makes another open
+// conversation active and shows what it wrote in the background, and
+// ":switch", which lists the open conversations.
+func handleSwitchCommand(cmdText string, sessions *appsvc.SessionMultiplexer, uiAdapter port.UserInterface) bool {
+	fields := strings.Fields(cmdText)
+	if len(fields) == 0 || fields[0] != ":switch" {
+		return false
+	}
+
+	ref := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdText), ":switch"))
+	if ref == "" {
+		_ = uiAdapter.DisplaySystemMessage(formatSessionTabs(sessions.Tabs()))
+		return true
+	}
+	tab, err := sessions.Switch(ref)
+	if err != nil {
+		_ = uiAdapter.DisplayError(err)
+		return true
+	}
+	_ = uiAdapter.DisplaySystemMessage("Switched to session " + tab.Label() + ".")
+	return true
+}
+
+// formatSessionTabs renders one line per open session, marking the active one.
+func formatSessionTabs(tabs []appsvc.SessionTab) string {
+	var b strings.Builder
+	b.WriteString("Open sessions:")
+	for _, tab := range tabs {
+		marker := " "
+		if tab.Active {
+			marker = "*"
+		}
+		title := tab.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(&b, "\n%s %d  %s  [%s]", marker, tab.Number, title, tab.SessionID)
+		if tab.Pending > 0 {
+			fmt.Fprintf(&b, "  (%d new outputs)", tab.Pending)
+		}
+	}
+	return b.String()
+}
+
+// initSession applies the configured session defaults, such as extended
+// thinking, to a new session.
+func initSession(sessionID string, cfg *config.Config, container *config.Container) {
+	if cfg.ExtendedThinking {
+		thinkingInfo := port.ThinkingModeInfo{
+			Enabled:      true,
+			BudgetTokens: cfg.ThinkingBudget,
+			ShowThinking: cfg.ShowThinking,
+		}
+		_ = container.ConversationService().SetThinkingMode(sessionID, thinkingInfo)
+	}
+}
+
+// showSessionSummaries shows the summary of each open session, headed by its
+// label when there are several.
+func showSessionSummaries(sessions *appsvc.SessionMultiplexer, container *config.Container, uiAdapter port.UserInterface) {
+	tabs := sessions.Tabs()
+	for _, tab := range tabs {
+		if len(tabs) > 1 {
+			_ = uiAdapter.DisplaySystemMessage("Session " + tab.Label() + ":")
+		}
+		showSessionSummary(tab.SessionID, container, uiAdapter)
+	}
+}
+
+// showSessionSummary shows the summary of the session's file changes and
+// tool usage when the chat ends, leaving out either if there is nothing in it.
+func showSessionSummary(sessionID string, container *config.Container, uiAdapter port.UserInterface) {
+	summary := container.ChangeTracker().Summary(sessionID)
+	if len(summary.Files) > 0 {
+		_ = uiAdapter.DisplaySystemMessage("Files changed this session:\n" + summary.String())
+	}
+	if stats := container.ToolStats().GetToolStats(sessionID); len(stats) > 0 {
+		_ = uiAdapter.DisplaySystemMessage("Tool usage this session:\n" + usecase.FormatToolStats(stats))
+	}
+	if selection, ok := container.ConversationService().ToolSelectionStats(sessionID); ok {
+		_ = uiAdapter.DisplaySystemMessage(formatToolSelectionStats(selection))
+	}
+}
+
+// toolNames returns the names of tools, sorted.
+func toolNames(tools []dto.ToolDefinition) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // runChat executes the chat command.
 func runChat(cmd *cobra.Command, args []string) error {
+	if validating, err := printConfigIfValidating(cmd); validating {
+		return err
+	}
+	if dumping, err := dumpLastRequestIfAsked(cmd); dumping {
+		return err
+	}
+	opts, oneShot, err := oneShotOptionsFromFlags(cmd, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if oneShot {
+		return runOneShotCommand(cmd, opts)
+	}
 	ctx := cmd.Context()
 	cfg := GetConfig(cmd)
 
@@ -115,6 +854,7 @@ func runChat(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize container: %w", err)
 	}
+	defer shutdownContainer(container)
 
 	chatService := container.ChatService()
 	uiAdapter := container.UIAdapter()
@@ -127,17 +867,21 @@ func runChat(cmd *cobra.Command, args []string) error {
 	}
 	sessionID := startResp.SessionID
 
-	// Initialize thinking mode from config if enabled
-	if cfg.ExtendedThinking {
-		convSvc := container.ConversationService()
-		thinkingInfo := port.ThinkingModeInfo{
-			Enabled:      true,
-			BudgetTokens: cfg.ThinkingBudget,
-			ShowThinking: cfg.ShowThinking,
-		}
-		_ = convSvc.SetThinkingMode(sessionID, thinkingInfo)
+	// Name the transcript file after the session when its path uses {session}
+	if sessionAware, ok := uiAdapter.(interface{ SetSessionID(string) }); ok {
+		sessionAware.SetSessionID(sessionID)
 	}
 
+	// Further sessions are opened with :new and switched between with :switch
+	sessions := appsvc.NewSessionMultiplexer(chatService, sessionID)
+
+	tools, _ := chatService.ListTools()
+	registerCommandCompletions(uiAdapter, toolNames(tools))
+	registerModeToggle(ctx, sessions.Active, chatService, uiAdapter)
+
+	// Initialize thinking mode from config if enabled
+	initSession(sessionID, cfg, container)
+
 	// Discover and display available subagents
 	if subagentManager != nil {
 		result, err := subagentManager.DiscoverAgents(ctx)
@@ -157,6 +901,8 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	// Main chat loop
 	for {
+		sessionID = sessions.Active()
+
 		// Get the first press channel each iteration (resets after timeout)
 		var firstPressCh <-chan struct{}
 		if handler != nil {
@@ -184,6 +930,7 @@ func runChat(cmd *cobra.Command, args []string) error {
 			select {
 			case <-ctx.Done():
 				// Context cancelled (second Ctrl+C pressed or external cancellation)
+				showSessionSummaries(sessions, container, uiAdapter)
 				fmt.Printf("\n%s\n", cfg.GoodbyeMessage)
 				return nil
 			case <-firstPressCh:
@@ -200,18 +947,20 @@ func runChat(cmd *cobra.Command, args []string) error {
 		}
 		if !result.ok {
 			// User closed input stream
+			showSessionSummaries(sessions, container, uiAdapter)
 			fmt.Printf("\n%s\n", cfg.GoodbyeMessage)
 			return nil
 		}
 
 		// Check if user wants to exit
 		if result.text == "exit" || result.text == "quit" || result.text == ":q" {
+			showSessionSummaries(sessions, container, uiAdapter)
 			fmt.Printf("%s\n", cfg.GoodbyeMessage)
 			return nil
 		}
 
 		// Check for :mode command to toggle plan mode
-		if handleModeCommand(ctx, sessionID, result.text, chatService, container, uiAdapter) {
+		if handleModeCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
 			continue
 		}
 
@@ -220,8 +969,94 @@ func runChat(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
+		// Check for :expand command to show the last thinking block in full
+		if handleExpandCommand(result.text, uiAdapter) {
+			continue
+		}
+
+		// Check for :verbose command to show tool calls with their full input
+		if handleVerboseCommand(result.text, uiAdapter) {
+			continue
+		}
+
+		// Check for :checkpoint and :rollback commands to rewind the conversation
+		if handleCheckpointCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+		if handleRollbackCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+
+		// Check for :attach command to add an image to the next message
+		if handleAttachCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+
+		// Check for :schema command to show a tool's input schema
+		if handleSchemaCommand(result.text, chatService, uiAdapter) {
+			continue
+		}
+
+		// Check for :model command to show or switch the AI model
+		if handleModelCommand(sessionID, result.text, chatService, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :memory command to show or edit the persistent memory
+		if handleMemoryCommand(result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :diff command to show the files this session changed
+		if handleDiffCommand(sessionID, result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :stats command to show this session's tool usage
+		if handleStatsCommand(sessionID, result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :where command to show where a quoted snippet came from
+		if handleWhereCommand(sessionID, result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :tools reload to catch up with tools the host gained or lost
+		if handleToolsCommand(result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :debug api to log what is sent to the AI provider
+		if handleDebugCommand(result.text, container, uiAdapter) {
+			continue
+		}
+
+		// Check for :prompt command to show the composed system prompt
+		if handlePromptCommand(sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+
+		// Check for :sessions and :rename commands to list and name sessions
+		if handleSessionsCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+		if handleRenameCommand(ctx, sessionID, result.text, chatService, uiAdapter) {
+			continue
+		}
+
+		// Check for :new and :switch commands to run several conversations
+		if handleNewCommand(ctx, result.text, cfg, sessions, container, uiAdapter) {
+			continue
+		}
+		if handleSwitchCommand(result.text, sessions, uiAdapter) {
+			continue
+		}
+
 		// Send message and get response
 		_, err = chatService.SendMessage(ctx, sessionID, result.text)
+		// The session may have been named by this turn
+		sessions.RefreshLabel()
 		if err != nil {
 			// Check for context cancellation specifically
 			if errors.Is(err, context.Canceled) {
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/infrastructure/adapter/notify"
+	"code-editing-agent/internal/infrastructure/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// digestCmd summarizes the outcomes of recent investigations.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize the outcomes of recent investigations",
+	Long: `Print a Markdown digest of the investigations started in a time window:
+counts by status and severity, mean duration, the most recurring alerts, the
+escalation rate, token and cost totals, and the most notable escalations.
+
+--notify also posts the digest to the notify.urls endpoints as an
+investigation.digest event. The daemon publishes digests itself when
+digest.schedule is set.
+
+Example:
+  code-editing-agent digest --since 7d
+  code-editing-agent digest --since 2026-03-01 --json
+  code-editing-agent digest --since 24h --notify`,
+	Args: cobra.NoArgs,
+	RunE: runDigest,
+}
+
+func init() {
+	rootCmd.AddCommand(digestCmd)
+
+	digestCmd.Flags().String("since", "24h",
+		"Summarize investigations started since this time (RFC 3339, YYYY-MM-DD, or a duration like 24h or 7d)")
+	digestCmd.Flags().Bool("notify", false, "Also post the digest to the configured notify.urls")
+	digestCmd.Flags().Bool("json", false, "Print the digest's statistics as JSON instead of Markdown")
+}
+
+func runDigest(cmd *cobra.Command, _ []string) error {
+	now := time.Now()
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	since, err := parseSince(sinceFlag, now)
+	if err != nil {
+		return err
+	}
+	publish, _ := cmd.Flags().GetBool("notify")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	store, err := openInvestigationStore(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	var notifier *notify.Notifier
+	if publish {
+		if notifier = config.NewResultNotifier(GetConfig(cmd), store, nil); notifier == nil {
+			return errors.New("--notify needs notify.urls to be configured")
+		}
+		defer closeNotifier(notifier)
+	}
+	generator := config.NewDigestGenerator(store, notifier)
+	return writeDigest(cmd.Context(), generator, since, now, publish, asJSON, cmd.OutOrStdout())
+}
+
+// closeNotifier waits for the notifier to deliver what is queued, for up to
+// containerShutdownTimeout.
+func closeNotifier(notifier *notify.Notifier) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerShutdownTimeout)
+	defer cancel()
+	if err := notifier.Close(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: digest delivery incomplete: %v\n", err)
+	}
+}
+
+// writeDigest writes the digest of the investigations started between since
+// and until as Markdown, or its statistics as JSON. With publish set it is
+// also posted through the generator's notifier.
+func writeDigest(
+	ctx context.Context,
+	generator *usecase.DigestGenerator,
+	since, until time.Time,
+	publish, asJSON bool,
+	w io.Writer,
+) error {
+	var digest *usecase.Digest
+	var markdown string
+	var err error
+	if publish {
+		digest, markdown, err = generator.Publish(ctx, since, until)
+	} else if digest, err = generator.Generate(ctx, since, until); err == nil {
+		markdown, err = usecase.RenderDigest(digest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return writeJSON(w, digest)
+	}
+	_, err = io.WriteString(w, markdown)
+	return err
+}
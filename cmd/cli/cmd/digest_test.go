@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureDigestSource lists the investigations of an investigation fixture for digests.
+type fixtureDigestSource struct {
+	store *eventInvestigationStore
+}
+
+func (s fixtureDigestSource) ListStarted(
+	ctx context.Context,
+	since, until time.Time,
+) ([]usecase.InvestigationRecordData, error) {
+	records, _, err := s.store.List(ctx, service.InvestigationQuery{Since: since, Until: until},
+		service.InvestigationPage{})
+	data := make([]usecase.InvestigationRecordData, 0, len(records))
+	for _, record := range records {
+		data = append(data, record)
+	}
+	return data, err
+}
+
+func TestWriteDigest_Markdown(t *testing.T) {
+	generator := usecase.NewDigestGenerator(fixtureDigestSource{newInvestigationFixture(t)})
+	var out bytes.Buffer
+	require.NoError(t, writeDigest(context.Background(), generator,
+		fixtureStart.Add(-time.Hour), fixtureStart.Add(3*time.Hour), false, false, &out))
+
+	digest := out.String()
+	assert.Contains(t, digest, "- **Investigations:** 3\n")
+	assert.Contains(t, digest, "- **Escalated:** 1 (33% of investigated alerts)\n")
+	assert.Contains(t, digest, "1. **High CPU** (warning, completed) `inv-cpu`: confidence below threshold\n")
+}
+
+func TestWriteDigest_JSONWindow(t *testing.T) {
+	generator := usecase.NewDigestGenerator(fixtureDigestSource{newInvestigationFixture(t)})
+	var out bytes.Buffer
+	// The window starts after the first investigation
+	require.NoError(t, writeDigest(context.Background(), generator,
+		fixtureStart.Add(time.Minute), fixtureStart.Add(3*time.Hour), false, true, &out))
+
+	var digest usecase.Digest
+	require.NoError(t, json.Unmarshal(out.Bytes(), &digest))
+	assert.Equal(t, 2, digest.Total)
+	assert.Equal(t, []usecase.DigestCount{{Name: "completed", Count: 1}, {Name: "failed", Count: 1}}, digest.ByStatus)
+}
+
+func TestWriteDigest_NotifyWithoutNotifier(t *testing.T) {
+	generator := usecase.NewDigestGenerator(fixtureDigestSource{newInvestigationFixture(t)})
+	err := writeDigest(context.Background(), generator, fixtureStart, fixtureStart.Add(time.Hour), true, false,
+		&bytes.Buffer{})
+	assert.ErrorIs(t, err, usecase.ErrNoDigestNotifier)
+}
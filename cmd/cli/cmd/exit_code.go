@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"errors"
+)
+
+// Exit codes for the investigation error taxonomy, so scripts can tell a bad
+// alert from an unreachable provider without parsing messages. Any other
+// error exits with ExitFailure.
+const (
+	ExitFailure              = 1
+	ExitInvalidAlert         = 3
+	ExitPromptBuild          = 4
+	ExitConversationStart    = 5
+	ExitProviderUnavailable  = 6
+	ExitToolBlocked          = 7
+	ExitActionBudgetExceeded = 8
+)
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, usecase.ErrInvalidAlert):
+		return ExitInvalidAlert
+	case errors.Is(err, usecase.ErrPromptBuild):
+		return ExitPromptBuild
+	case errors.Is(err, usecase.ErrConversationStart):
+		return ExitConversationStart
+	case errors.Is(err, usecase.ErrProviderUnavailable):
+		return ExitProviderUnavailable
+	case errors.Is(err, usecase.ErrToolBlocked):
+		return ExitToolBlocked
+	case errors.Is(err, usecase.ErrActionBudgetExceeded):
+		return ExitActionBudgetExceeded
+	default:
+		return ExitFailure
+	}
+}
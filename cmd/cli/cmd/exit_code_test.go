@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"untyped", errors.New("boom"), ExitFailure},
+		{"invalid alert", fmt.Errorf("failed to rerun investigation inv-1: %w", usecase.ErrAlertNil), ExitInvalidAlert},
+		{"prompt build", fmt.Errorf("%w: template", usecase.ErrPromptBuild), ExitPromptBuild},
+		{"conversation start", fmt.Errorf("%w: no model", usecase.ErrConversationStart), ExitConversationStart},
+		{"provider unavailable", fmt.Errorf("%w: EOF", usecase.ErrProviderUnavailable), ExitProviderUnavailable},
+		{"tool blocked", usecase.ErrToolBlocked, ExitToolBlocked},
+		{"action budget", fmt.Errorf("%w: 20 of 20", usecase.ErrActionBudgetExceeded), ExitActionBudgetExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
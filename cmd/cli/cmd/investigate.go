@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/alert"
+	"code-editing-agent/internal/infrastructure/adapter/webhook"
+	"code-editing-agent/internal/infrastructure/config"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// investigateCmd backfills investigations for past alerts.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigateCmd = &cobra.Command{
+	Use:   "investigate",
+	Short: "Investigate a file of past alerts",
+	Long: `Investigate past alerts, such as last week's incidents exported from
+Alertmanager, and store the results with the other investigations.
+
+The file is a JSON array of alerts as returned by the Alertmanager v2 API
+(GET /api/v2/alerts or amtool -o json), or an Alertmanager webhook payload.
+Resolved alerts are investigated too. It is the format POST /webhook/batch
+accepts.
+
+The alerts are investigated as historical alerts: no notifications are sent
+for them, and they leave one investigation slot free for live alerts.
+Critical and warning alerts are investigated; suppressions, the daily budget,
+and the source's circuit breaker apply as for live alerts. A line is printed
+as each alert finishes, with its status and how long it took.
+
+Example:
+  code-editing-agent investigate --file alerts.json
+  code-editing-agent investigate --file alerts.json --concurrency 2 --source prometheus`,
+	Args: cobra.NoArgs,
+	RunE: runInvestigate,
+}
+
+func init() {
+	rootCmd.AddCommand(investigateCmd)
+
+	investigateCmd.Flags().String("file", "", "JSON file of alerts to investigate (required)")
+	investigateCmd.Flags().Int("concurrency", 1, "Number of alerts to investigate at a time")
+	investigateCmd.Flags().String("source", webhook.DefaultBatchSource, "Alert source name to investigate the alerts as")
+	_ = investigateCmd.MarkFlagRequired("file")
+}
+
+func runInvestigate(cmd *cobra.Command, _ []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	source, _ := cmd.Flags().GetString("source")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+	}
+
+	container, err := config.NewContainer(GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	defer shutdownContainer(container)
+
+	handler := usecase.NewAlertHandler(container.InvestigationUseCase(), usecase.AlertHandlerConfig{
+		AutoInvestigateCritical: true,
+		AutoInvestigateWarning:  true,
+	})
+	handler.SetLogger(container.Logger())
+	handler.SetSuppressionStore(container.AlertSuppressions())
+	handler.SetCircuitBreaker(container.AlertCircuitBreaker())
+	handler.SetAlertEnrichers(container.AlertEnrichers()...)
+	return investigateFile(cmd.Context(), handler, path, source, concurrency, cmd.OutOrStdout())
+}
+
+// investigateFile backfills the alerts in the file at path, from source, and
+// writes a line as each one finishes and a summary at the end. It fails if
+// any alert failed.
+func investigateFile(
+	ctx context.Context,
+	backfiller port.AlertBackfiller,
+	path, source string,
+	concurrency int,
+	w io.Writer,
+) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read alerts file: %w", err)
+	}
+	alerts, err := alert.ParseAlertmanagerBatch(source, payload)
+	if err != nil {
+		return fmt.Errorf("failed to parse alerts file %s: %w", path, err)
+	}
+
+	finished := 0
+	var statuses []string
+	counts := make(map[string]int)
+	err = backfiller.Backfill(ctx, alerts, concurrency, func(outcome port.BackfillOutcome) {
+		finished++
+		if counts[outcome.Status] == 0 {
+			statuses = append(statuses, outcome.Status)
+		}
+		counts[outcome.Status]++
+		line := fmt.Sprintf("[%d/%d] %s %s %s", finished, len(alerts), outcome.AlertID, outcome.Status,
+			outcome.Duration.Round(time.Millisecond))
+		if outcome.InvestigationID != "" {
+			line += " " + outcome.InvestigationID
+		}
+		if outcome.Err != nil {
+			line += ": " + outcome.Err.Error()
+		}
+		fmt.Fprintln(w, line)
+	})
+	if err != nil {
+		return fmt.Errorf("backfill stopped after %d of %d alerts: %w", finished, len(alerts), err)
+	}
+
+	summary := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+	}
+	fmt.Fprintf(w, "Investigated %d alerts: %s.\n", len(alerts), strings.Join(summary, ", "))
+	if counts["failed"] > 0 {
+		return fmt.Errorf("%d of %d alerts failed", counts["failed"], len(alerts))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedBackfiller stands in for the alert handler: it finishes each alert,
+// in order, with the next scripted status.
+type scriptedBackfiller struct {
+	statuses    []string
+	alerts      []*entity.Alert
+	concurrency int
+}
+
+func (b *scriptedBackfiller) Backfill(
+	_ context.Context,
+	alerts []*entity.Alert,
+	concurrency int,
+	done func(port.BackfillOutcome),
+) error {
+	b.alerts, b.concurrency = alerts, concurrency
+	for i, alert := range alerts {
+		outcome := port.BackfillOutcome{AlertID: alert.ID(), Status: b.statuses[i], Duration: 1500 * time.Millisecond}
+		if outcome.Status == "failed" {
+			outcome.Err = errors.New("provider unavailable")
+		} else if outcome.Status != "skipped" {
+			outcome.InvestigationID = "inv-" + alert.Labels()["alertname"]
+		}
+		done(outcome)
+	}
+	return nil
+}
+
+// writeAlertsFile writes a three-alert Alertmanager v2 export.
+func writeAlertsFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"labels":{"alertname":"HighCPU","severity":"critical"},"startsAt":"2026-10-10T08:00:00Z",
+		 "status":{"state":"resolved"}},
+		{"labels":{"alertname":"DiskFull","severity":"warning"},"startsAt":"2026-10-11T09:00:00Z",
+		 "status":{"state":"resolved"}},
+		{"labels":{"alertname":"Heartbeat","severity":"info"},"startsAt":"2026-10-12T10:00:00Z",
+		 "status":{"state":"active"}}
+	]`), 0o600))
+	return path
+}
+
+func TestInvestigateFile(t *testing.T) {
+	backfiller := &scriptedBackfiller{statuses: []string{"completed", "escalated", "skipped"}}
+	var out bytes.Buffer
+
+	err := investigateFile(context.Background(), backfiller, writeAlertsFile(t), "prometheus", 2, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, backfiller.concurrency)
+	require.Len(t, backfiller.alerts, 3)
+	for i, name := range []string{"HighCPU", "DiskFull", "Heartbeat"} {
+		alert := backfiller.alerts[i]
+		assert.True(t, strings.HasPrefix(alert.ID(), name+"-"), "alert %d is %s, want %s", i, alert.ID(), name)
+		assert.Equal(t, "prometheus", alert.Source())
+		assert.True(t, alert.Historical(), "alert %s is not historical", alert.ID())
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, []string{
+		"[1/3] HighCPU-2026-10-10T08:00:00Z completed 1.5s inv-HighCPU",
+		"[2/3] DiskFull-2026-10-11T09:00:00Z escalated 1.5s inv-DiskFull",
+		"[3/3] Heartbeat-2026-10-12T10:00:00Z skipped 1.5s",
+		"Investigated 3 alerts: 1 completed, 1 escalated, 1 skipped.",
+	}, lines)
+}
+
+func TestInvestigateFile_FailsWhenAnAlertFails(t *testing.T) {
+	backfiller := &scriptedBackfiller{statuses: []string{"completed", "failed", "completed"}}
+	var out bytes.Buffer
+
+	err := investigateFile(context.Background(), backfiller, writeAlertsFile(t), "backfill", 1, &out)
+
+	require.EqualError(t, err, "1 of 3 alerts failed")
+	assert.Contains(t, out.String(), "[2/3] DiskFull-2026-10-11T09:00:00Z failed 1.5s: provider unavailable\n")
+	assert.Contains(t, out.String(), "Investigated 3 alerts: 2 completed, 1 failed.")
+}
@@ -0,0 +1,603 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/investigation"
+	"code-editing-agent/internal/infrastructure/config"
+	"code-editing-agent/internal/infrastructure/version"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// investigationsCmd groups the commands for browsing stored investigations.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsCmd = &cobra.Command{
+	Use:   "investigations",
+	Short: "Browse and rerun stored investigations",
+	Long: `Browse the investigations recorded in <dir>/.agent/investigations and
+rerun them.
+
+Example:
+  code-editing-agent investigations list --status escalated --since 24h
+  code-editing-agent investigations show inv-1712345678-1
+  code-editing-agent investigations report inv-1712345678-1 --out report.md
+  code-editing-agent investigations rerun inv-1712345678-1 --json
+  code-editing-agent investigations reprocess --source prometheus
+  code-editing-agent investigations cancel inv-1712345678-1 --reason "looping on df"`,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored investigations, newest first",
+	Args:  cobra.NoArgs,
+	RunE:  runInvestigationsList,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show an investigation's result, findings, and timeline",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runInvestigationsShow,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsRerunCmd = &cobra.Command{
+	Use:   "rerun <id>",
+	Short: "Investigate a stored investigation's alert again",
+	Long: `Investigate the alert of a stored investigation again, as a new
+investigation, and print its result. The original investigation is kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInvestigationsRerun,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsReportCmd = &cobra.Command{
+	Use:   "report <id>",
+	Short: "Write an investigation's Markdown report",
+	Long: `Write a structured Markdown report of a stored investigation: alert
+context, what was checked, findings, root cause, recommended actions,
+confidence, and an appendix of tool outputs.
+
+A report.md.tmpl file in the prompts directory replaces the report template.
+--summary opens the report with an AI-written executive summary, which takes
+one extra AI call.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInvestigationsReport,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsReprocessCmd = &cobra.Command{
+	Use:   "reprocess",
+	Short: "Investigate the alerts deferred while their source's circuit was open",
+	Long: `Investigate the alerts recorded as "deferred" because their source
+started too many investigations, oldest first. Each deferred investigation is
+marked "reprocessed" and its alert is handled again. The circuit breaker still
+applies, so a large backlog is worked off in batches: alerts beyond the
+threshold are deferred again for a later run.`,
+	Args: cobra.NoArgs,
+	RunE: runInvestigationsReprocess,
+}
+
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var investigationsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel an investigation a serve daemon is running",
+	Long: `Cancel an investigation that a running "serve" daemon is running or has
+queued. It stops during its current tool call if the tool can be interrupted,
+and after it otherwise, then ends its conversation and is stored as
+"cancelled" with the reason and who asked. Cancelling an investigation that
+has already finished fails.
+
+The request goes to the daemon's DELETE /investigations/{id} endpoint.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInvestigationsCancel,
+}
+
+func init() {
+	rootCmd.AddCommand(investigationsCmd)
+	investigationsCmd.AddCommand(investigationsListCmd, investigationsShowCmd, investigationsRerunCmd,
+		investigationsReportCmd, investigationsReprocessCmd, investigationsCancelCmd)
+
+	investigationsCmd.PersistentFlags().Bool("json", false, "Print JSON instead of text")
+
+	investigationsListCmd.Flags().StringSlice("status", nil, "Only investigations with this status (repeatable)")
+	investigationsListCmd.Flags().String("severity", "", "Only investigations of alerts with this severity")
+	investigationsListCmd.Flags().String("error-kind", "",
+		"Only investigations that failed with this kind of error ("+strings.Join(usecase.ErrorKinds(), ", ")+")")
+	investigationsListCmd.Flags().String("since", "",
+		"Only investigations started since this time (RFC 3339, YYYY-MM-DD, or a duration like 24h or 7d)")
+	investigationsListCmd.Flags().String("alert", "", "Only investigations of this alert ID")
+	investigationsListCmd.Flags().Int("limit", 20, "Maximum investigations to list (0 = all)")
+	investigationsListCmd.Flags().Int("offset", 0, "Number of investigations to skip")
+
+	investigationsReportCmd.Flags().String("out", "", "Write the report to this file instead of stdout")
+	investigationsReportCmd.Flags().Bool("summary", false, "Open the report with an AI-written executive summary")
+
+	investigationsReprocessCmd.Flags().String("source", "", "Only reprocess alerts from this source")
+
+	investigationsCancelCmd.Flags().String("addr", "http://localhost:8080",
+		"Address of the serve daemon (a bare :port means localhost)")
+	investigationsCancelCmd.Flags().String("reason", "", "Why the investigation is cancelled, stored with it")
+	investigationsCancelCmd.Flags().String("by", "", "Who cancels the investigation (default: the current user)")
+}
+
+// investigationReader reads stored investigations.
+type investigationReader interface {
+	List(
+		ctx context.Context,
+		query service.InvestigationQuery,
+		page service.InvestigationPage,
+	) ([]*service.InvestigationRecord, int, error)
+	Get(ctx context.Context, id string) (*service.InvestigationRecord, error)
+}
+
+// investigationEventReader is implemented by stores that keep investigations'
+// progress events, which show uses as the timeline.
+type investigationEventReader interface {
+	Events(ctx context.Context, id string) ([]port.InvestigationEvent, error)
+}
+
+// investigationRerunner reruns stored investigations.
+type investigationRerunner interface {
+	RerunInvestigation(ctx context.Context, invID string) (*usecase.InvestigationResult, error)
+}
+
+// deferredReprocessor reprocesses deferred alerts.
+type deferredReprocessor interface {
+	ReprocessDeferred(ctx context.Context, source string) (int, error)
+}
+
+// investigationOutput is the JSON form of an investigation.
+type investigationOutput struct {
+	ID              string                    `json:"id"`
+	AlertID         string                    `json:"alert_id"`
+	AlertTitle      string                    `json:"alert_title,omitempty"`
+	Severity        string                    `json:"severity,omitempty"`
+	Fingerprint     string                    `json:"fingerprint,omitempty"`
+	Status          string                    `json:"status"`
+	Confidence      float64                   `json:"confidence"`
+	Escalated       bool                      `json:"escalated"`
+	EscalateReason  string                    `json:"escalate_reason,omitempty"`
+	Error           string                    `json:"error,omitempty"`
+	ErrorKind       string                    `json:"error_kind,omitempty"`
+	StartedAt       *time.Time                `json:"started_at,omitempty"`
+	CompletedAt     *time.Time                `json:"completed_at,omitempty"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	ActionsTaken    int                       `json:"actions_taken"`
+	Findings        []string                  `json:"findings"`
+	Timeline        []port.InvestigationEvent `json:"timeline,omitempty"`
+	Version         string                    `json:"version,omitempty"` // Build of the agent that produced it
+}
+
+// listOptions holds the filters and page of investigations list.
+type listOptions struct {
+	query  service.InvestigationQuery
+	page   service.InvestigationPage
+	asJSON bool
+}
+
+// openInvestigationStore opens the investigation store of the configured
+// working directory without starting the rest of the application.
+func openInvestigationStore(cmd *cobra.Command) (*investigation.FileInvestigationStore, error) {
+	return investigation.NewFileInvestigationStore(config.InvestigationStoreDir(GetConfig(cmd)))
+}
+
+func runInvestigationsList(cmd *cobra.Command, _ []string) error {
+	opts, err := listOptionsFromFlags(cmd, time.Now())
+	if err != nil {
+		return err
+	}
+	store, err := openInvestigationStore(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+	return listInvestigations(cmd.Context(), store, opts, cmd.OutOrStdout())
+}
+
+func runInvestigationsShow(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	store, err := openInvestigationStore(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+	return showInvestigation(cmd.Context(), store, args[0], asJSON, cmd.OutOrStdout())
+}
+
+func runInvestigationsRerun(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	container, err := config.NewContainer(GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	defer shutdownContainer(container)
+	return rerunInvestigation(cmd.Context(), container.InvestigationUseCase(), args[0], asJSON, cmd.OutOrStdout())
+}
+
+func runInvestigationsReport(cmd *cobra.Command, args []string) error {
+	summary, _ := cmd.Flags().GetBool("summary")
+	outPath, _ := cmd.Flags().GetString("out")
+
+	// Only executive summaries need the AI provider, and so the container
+	var reporter port.InvestigationReporter
+	if summary {
+		container, err := config.NewContainer(GetConfig(cmd))
+		if err != nil {
+			return err
+		}
+		defer shutdownContainer(container)
+		reporter = container.ReportGenerator()
+	} else {
+		store, err := openInvestigationStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = store.Close() }()
+		if reporter, err = config.NewReportGenerator(GetConfig(cmd), store, nil); err != nil {
+			return err
+		}
+	}
+	return writeInvestigationReport(cmd.Context(), reporter, args[0], summary, outPath, cmd.OutOrStdout())
+}
+
+func runInvestigationsReprocess(cmd *cobra.Command, _ []string) error {
+	source, _ := cmd.Flags().GetString("source")
+	container, err := config.NewContainer(GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	defer shutdownContainer(container)
+
+	handler := usecase.NewAlertHandler(container.InvestigationUseCase(), usecase.AlertHandlerConfig{
+		AutoInvestigateCritical: true,
+		AutoInvestigateWarning:  false,
+	})
+	handler.SetLogger(container.Logger())
+	handler.SetSuppressionStore(container.AlertSuppressions())
+	handler.SetCircuitBreaker(container.AlertCircuitBreaker())
+	handler.SetAlertEnrichers(container.AlertEnrichers()...)
+	return reprocessDeferred(cmd.Context(), handler, source, cmd.OutOrStdout())
+}
+
+func runInvestigationsCancel(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	reason, _ := cmd.Flags().GetString("reason")
+	requestedBy, _ := cmd.Flags().GetString("by")
+	if requestedBy == "" {
+		requestedBy = currentUsername()
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), daemonRequestTimeout)
+	defer cancel()
+	return cancelInvestigation(ctx, http.DefaultClient, addr, args[0], reason, requestedBy, cmd.OutOrStdout())
+}
+
+// cancelInvestigation asks the daemon at addr to cancel an investigation.
+func cancelInvestigation(
+	ctx context.Context,
+	client *http.Client,
+	addr, id, reason, requestedBy string,
+	w io.Writer,
+) error {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+	if requestedBy != "" {
+		query.Set("requested_by", requestedBy)
+	}
+	endpoint := daemonURL(addr, "/investigations/"+url.PathEscape(id))
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid daemon address %q: %w", addr, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the daemon at %s: %w", addr, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to cancel investigation %s: %s", id, daemonError(resp))
+	}
+	_, err = fmt.Fprintf(w, "Cancelling investigation %s\n", id)
+	return err
+}
+
+// currentUsername returns the name of the user running the command, or "" if
+// it is unknown.
+func currentUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// listOptionsFromFlags builds list options from the list command's flags.
+func listOptionsFromFlags(cmd *cobra.Command, now time.Time) (listOptions, error) {
+	var opts listOptions
+	flags := cmd.Flags()
+	opts.query.Status, _ = flags.GetStringSlice("status")
+	opts.query.Severity, _ = flags.GetString("severity")
+	opts.query.AlertID, _ = flags.GetString("alert")
+	opts.query.ErrorKind, _ = flags.GetString("error-kind")
+	opts.page.Limit, _ = flags.GetInt("limit")
+	opts.page.Offset, _ = flags.GetInt("offset")
+	opts.asJSON, _ = flags.GetBool("json")
+
+	if kind := opts.query.ErrorKind; kind != "" && !slices.Contains(usecase.ErrorKinds(), kind) {
+		return opts, fmt.Errorf("invalid --error-kind %q: want one of %s", kind, strings.Join(usecase.ErrorKinds(), ", "))
+	}
+	if since, _ := flags.GetString("since"); since != "" {
+		t, err := parseSince(since, now)
+		if err != nil {
+			return opts, err
+		}
+		opts.query.Since = t
+	}
+	return opts, nil
+}
+
+// parseSince parses --since as an RFC 3339 time, a YYYY-MM-DD date (local
+// midnight), or a duration before now, which may be a number of days like 7d.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		"invalid --since %q: want an RFC 3339 time, YYYY-MM-DD, or a duration like 24h or 7d", value)
+}
+
+// listInvestigations writes a page of the investigations matching opts as a
+// table, or as JSON.
+func listInvestigations(ctx context.Context, store investigationReader, opts listOptions, w io.Writer) error {
+	records, total, err := store.List(ctx, opts.query, opts.page)
+	if err != nil {
+		return fmt.Errorf("failed to list investigations: %w", err)
+	}
+
+	if opts.asJSON {
+		out := struct {
+			Total          int                   `json:"total"`
+			Investigations []investigationOutput `json:"investigations"`
+		}{Total: total, Investigations: make([]investigationOutput, 0, len(records))}
+		for _, record := range records {
+			out.Investigations = append(out.Investigations, newInvestigationOutput(record, nil))
+		}
+		return writeJSON(w, out)
+	}
+
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "No investigations found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tALERT\tSTATUS\tCONFIDENCE\tDURATION")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%s\n", record.ID(), alertLabel(record), statusLabel(record),
+			record.Confidence(), record.Duration().Round(time.Second))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(records) < total {
+		first := opts.page.Offset + 1
+		_, err = fmt.Fprintf(w, "\nShowing %d-%d of %d investigations.\n", first, first+len(records)-1, total)
+	}
+	return err
+}
+
+// showInvestigation writes an investigation as a Markdown report, or as JSON.
+// The timeline comes from the store's progress events, when it keeps them.
+func showInvestigation(ctx context.Context, store investigationReader, id string, asJSON bool, w io.Writer) error {
+	record, err := store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load investigation %s: %w", id, err)
+	}
+
+	var events []port.InvestigationEvent
+	if eventReader, ok := store.(investigationEventReader); ok {
+		if events, err = eventReader.Events(ctx, id); err != nil {
+			return fmt.Errorf("failed to load events of investigation %s: %w", id, err)
+		}
+	}
+
+	if asJSON {
+		return writeJSON(w, newInvestigationOutput(record, events))
+	}
+	_, err = fmt.Fprint(w, investigation.FormatMarkdown(record, events))
+	return err
+}
+
+// rerunInvestigation reruns an investigation and writes the new result.
+func rerunInvestigation(ctx context.Context, rerunner investigationRerunner, id string, asJSON bool, w io.Writer) error {
+	result, err := rerunner.RerunInvestigation(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to rerun investigation %s: %w", id, err)
+	}
+
+	if asJSON {
+		return writeJSON(w, struct {
+			RerunOf       string              `json:"rerun_of"`
+			Investigation investigationOutput `json:"investigation"`
+		}{RerunOf: id, Investigation: newResultOutput(result)})
+	}
+
+	fmt.Fprintf(w, "Investigation %s (rerun of %s): %s\n", result.InvestigationID, id, result.Status)
+	writeResultDetails(w, result)
+	return nil
+}
+
+// writeResultDetails writes an investigation result's confidence, actions,
+// escalation, findings, and tool usage.
+func writeResultDetails(w io.Writer, result *usecase.InvestigationResult) {
+	fmt.Fprintf(w, "Confidence: %.2f\n", result.Confidence)
+	fmt.Fprintf(w, "Actions: %d in %s\n", result.ActionsTaken, result.Duration.Round(time.Second))
+	if result.Escalated {
+		fmt.Fprintf(w, "Escalated: %s\n", result.EscalateReason)
+	}
+	if len(result.Findings) > 0 {
+		fmt.Fprintln(w, "Findings:")
+		for _, finding := range result.Findings {
+			fmt.Fprintf(w, "- %s\n", finding)
+		}
+	}
+	if len(result.ToolStats) > 0 {
+		fmt.Fprintf(w, "Tool usage:\n%s\n", usecase.FormatToolStats(result.ToolStats))
+	}
+}
+
+// reprocessDeferred reprocesses the deferred alerts of source, or of every
+// source when it is empty, and writes how many were reprocessed.
+func reprocessDeferred(ctx context.Context, reprocessor deferredReprocessor, source string, w io.Writer) error {
+	n, err := reprocessor.ReprocessDeferred(ctx, source)
+	if err != nil {
+		return fmt.Errorf("failed to reprocess deferred alerts (%d reprocessed): %w", n, err)
+	}
+	if n == 0 {
+		_, err = fmt.Fprintln(w, "No deferred alerts to reprocess.")
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Reprocessed %d deferred alerts. Run \"investigations list --status deferred\" for any deferred again.\n", n)
+	return err
+}
+
+// writeInvestigationReport writes an investigation's report to outPath, or
+// to w when outPath is empty.
+func writeInvestigationReport(
+	ctx context.Context,
+	reporter port.InvestigationReporter,
+	id string,
+	summary bool,
+	outPath string,
+	w io.Writer,
+) error {
+	report, err := reporter.ReportInvestigation(ctx, id, summary)
+	if err != nil {
+		return err
+	}
+
+	if outPath == "" {
+		_, err = fmt.Fprint(w, report)
+		return err
+	}
+	if err := os.WriteFile(outPath, []byte(report), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "Wrote the report of investigation %s to %s\n", id, outPath)
+	return err
+}
+
+// alertLabel names a record's alert for the list table: its title when
+// recorded, else its ID.
+func alertLabel(record *service.InvestigationRecord) string {
+	if alert := record.Alert(); alert != nil {
+		return fmt.Sprintf("%s (%s)", alert.Title(), alert.Severity())
+	}
+	return record.AlertID()
+}
+
+// statusLabel is a record's status, marked when it was escalated.
+func statusLabel(record *service.InvestigationRecord) string {
+	if record.Escalated() && record.Status() != "escalated" {
+		return record.Status() + " (escalated)"
+	}
+	return record.Status()
+}
+
+// newInvestigationOutput converts a stored investigation to its JSON form.
+func newInvestigationOutput(record *service.InvestigationRecord, events []port.InvestigationEvent) investigationOutput {
+	startedAt := record.StartedAt()
+	out := investigationOutput{
+		ID:              record.ID(),
+		AlertID:         record.AlertID(),
+		Status:          record.Status(),
+		Confidence:      record.Confidence(),
+		Escalated:       record.Escalated(),
+		EscalateReason:  record.EscalateReason(),
+		Error:           record.ErrorMessage(),
+		ErrorKind:       record.ErrorKind(),
+		StartedAt:       &startedAt,
+		DurationSeconds: record.Duration().Seconds(),
+		ActionsTaken:    record.ActionsTaken(),
+		Findings:        record.Findings(),
+		Timeline:        events,
+		Version:         record.Version(),
+	}
+	if alert := record.Alert(); alert != nil {
+		out.AlertTitle = alert.Title()
+		out.Severity = alert.Severity()
+		out.Fingerprint = alert.Fingerprint()
+	}
+	if completedAt := record.CompletedAt(); !completedAt.IsZero() {
+		out.CompletedAt = &completedAt
+	}
+	if out.Findings == nil {
+		out.Findings = []string{}
+	}
+	return out
+}
+
+// newResultOutput converts an investigation result to its JSON form.
+func newResultOutput(result *usecase.InvestigationResult) investigationOutput {
+	out := investigationOutput{
+		ID:              result.InvestigationID,
+		AlertID:         result.AlertID,
+		Status:          result.Status,
+		Confidence:      result.Confidence,
+		Escalated:       result.Escalated,
+		EscalateReason:  result.EscalateReason,
+		ErrorKind:       result.ErrorKind,
+		DurationSeconds: result.Duration.Seconds(),
+		ActionsTaken:    result.ActionsTaken,
+		Findings:        result.Findings,
+		Version:         version.Get().Version,
+	}
+	if result.Error != nil {
+		out.Error = result.Error.Error()
+	}
+	if out.Findings == nil {
+		out.Findings = []string{}
+	}
+	return out
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
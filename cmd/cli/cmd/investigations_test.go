@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventInvestigationStore is an in-memory investigation store that also keeps
+// progress events, like the file store.
+type eventInvestigationStore struct {
+	*service.InMemoryInvestigationStore
+	events map[string][]port.InvestigationEvent
+}
+
+func (s *eventInvestigationStore) Events(_ context.Context, id string) ([]port.InvestigationEvent, error) {
+	return s.events[id], nil
+}
+
+var fixtureStart = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+// newInvestigationFixture returns a store with three investigations, one hour
+// apart: a completed critical one, an escalated warning, and a failed one
+// recorded without its alert.
+func newInvestigationFixture(t *testing.T) *eventInvestigationStore {
+	t.Helper()
+	ctx := context.Background()
+	store := &eventInvestigationStore{
+		InMemoryInvestigationStore: service.NewInMemoryInvestigationStore(),
+		events:                     make(map[string][]port.InvestigationEvent),
+	}
+
+	disk, err := entity.NewAlert("alert-disk", "prometheus", entity.SeverityCritical, "Disk Full")
+	require.NoError(t, err)
+	cpu, err := entity.NewAlert("alert-cpu", "prometheus", entity.SeverityWarning, "High CPU")
+	require.NoError(t, err)
+
+	records := []*service.InvestigationRecord{
+		service.NewInvestigationRecordWithResult("inv-disk", "alert-disk", "", "completed",
+			fixtureStart, fixtureStart.Add(90*time.Second), []string{"/var is 98% full"},
+			3, 90*time.Second, 0.85, false, "").WithAlert(disk),
+		service.NewInvestigationRecordWithResult("inv-cpu", "alert-cpu", "", "completed",
+			fixtureStart.Add(time.Hour), fixtureStart.Add(time.Hour+time.Minute), nil,
+			5, time.Minute, 0.3, true, "confidence below threshold").WithAlert(cpu),
+		service.NewInvestigationRecordWithResult("inv-old", "alert-old", "", "failed",
+			fixtureStart.Add(2*time.Hour), fixtureStart.Add(2*time.Hour+time.Second), nil,
+			0, time.Second, 0, false, "").WithErrorMessage("provider unavailable").
+			WithErrorKind(usecase.ErrorKindProviderUnavailable),
+	}
+	for _, record := range records {
+		require.NoError(t, store.Store(ctx, record))
+	}
+	store.events["inv-disk"] = []port.InvestigationEvent{
+		{Sequence: 1, Type: port.InvestigationEventToolExecuted, ToolName: "bash", Summary: "/dev/sda1 98%",
+			Input: `{"command":"df -h /var"}`, Output: "/dev/sda1 98% /var\n",
+			Duration: time.Second, Time: fixtureStart.Add(10 * time.Second)},
+		{Sequence: 2, Type: port.InvestigationEventCompleted, Actions: 3, Time: fixtureStart.Add(90 * time.Second)},
+	}
+	return store
+}
+
+func TestListInvestigations_Table(t *testing.T) {
+	var out bytes.Buffer
+	err := listInvestigations(context.Background(), newInvestigationFixture(t),
+		listOptions{page: service.InvestigationPage{Limit: 2}}, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, `ID       ALERT               STATUS                 CONFIDENCE  DURATION
+inv-old  alert-old           failed                 0.00        1s
+inv-cpu  High CPU (warning)  completed (escalated)  0.30        1m0s
+
+Showing 1-2 of 3 investigations.
+`, out.String())
+}
+
+func TestListInvestigations_Filters(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantIDs []string
+	}{
+		{"all", nil, []string{"inv-old", "inv-cpu", "inv-disk"}},
+		{"status", []string{"--status", "failed"}, []string{"inv-old"}},
+		{"severity", []string{"--severity", "critical"}, []string{"inv-disk"}},
+		{"alert", []string{"--alert", "alert-cpu"}, []string{"inv-cpu"}},
+		{"error kind", []string{"--error-kind", "provider_unavailable"}, []string{"inv-old"}},
+		{"since duration", []string{"--since", "90m"}, []string{"inv-old", "inv-cpu"}},
+		{"since time", []string{"--since", "2026-03-04T07:00:00Z"}, []string{"inv-old"}},
+		{"offset", []string{"--offset", "1", "--limit", "1"}, []string{"inv-cpu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { resetFlags(t) })
+			require.NoError(t, investigationsListCmd.ParseFlags(append(tt.args, "--json")))
+
+			// "Now" is just after the newest investigation started
+			opts, err := listOptionsFromFlags(investigationsListCmd, fixtureStart.Add(2*time.Hour+time.Minute))
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			require.NoError(t, listInvestigations(context.Background(), newInvestigationFixture(t), opts, &out))
+
+			var got struct {
+				Total          int                   `json:"total"`
+				Investigations []investigationOutput `json:"investigations"`
+			}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+			ids := make([]string, 0, len(got.Investigations))
+			for _, inv := range got.Investigations {
+				ids = append(ids, inv.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+// resetFlags restores the list command's flags to their defaults.
+func resetFlags(t *testing.T) {
+	t.Helper()
+	for name, value := range map[string]string{
+		"status": "", "severity": "", "error-kind": "", "since": "", "alert": "", "limit": "20", "offset": "0", "json": "false",
+	} {
+		flag := investigationsListCmd.Flags().Lookup(name)
+		require.NotNil(t, flag, name)
+		if name == "status" {
+			require.NoError(t, flag.Value.(interface{ Replace([]string) error }).Replace(nil))
+		} else {
+			require.NoError(t, flag.Value.Set(value))
+		}
+		flag.Changed = false
+	}
+}
+
+func TestListOptionsFromFlags_InvalidErrorKind(t *testing.T) {
+	t.Cleanup(func() { resetFlags(t) })
+	require.NoError(t, investigationsListCmd.ParseFlags([]string{"--error-kind", "flaky"}))
+
+	_, err := listOptionsFromFlags(investigationsListCmd, fixtureStart)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid --error-kind "flaky"`)
+}
+
+func TestListInvestigations_Empty(t *testing.T) {
+	var out bytes.Buffer
+	store := &eventInvestigationStore{InMemoryInvestigationStore: service.NewInMemoryInvestigationStore()}
+	require.NoError(t, listInvestigations(context.Background(), store, listOptions{}, &out))
+	assert.Equal(t, "No investigations found.\n", out.String())
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"7d", time.Date(2026, 2, 25, 12, 0, 0, 0, time.UTC), false},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2026-03-01T10:00:00+02:00", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"-2d", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		})
+	}
+}
+
+func TestShowInvestigation_Markdown(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, showInvestigation(context.Background(), newInvestigationFixture(t), "inv-disk", false, &out))
+
+	report := out.String()
+	assert.Contains(t, report, "# Investigation inv-disk\n")
+	assert.Contains(t, report, "- **Alert:** Disk Full (alert-disk, critical severity, from prometheus)\n")
+	assert.Contains(t, report, "## Findings\n\n- /var is 98% full\n")
+	assert.Contains(t, report, "## Timeline\n\n- `05:06:17` bash ran in 1s: /dev/sda1 98%\n")
+}
+
+func TestShowInvestigation_EscalatedAndFailed(t *testing.T) {
+	store := newInvestigationFixture(t)
+
+	var escalated bytes.Buffer
+	require.NoError(t, showInvestigation(context.Background(), store, "inv-cpu", false, &escalated))
+	assert.Contains(t, escalated.String(), "## Escalation\n\nconfidence below threshold\n")
+	assert.NotContains(t, escalated.String(), "## Timeline")
+
+	var failed bytes.Buffer
+	require.NoError(t, showInvestigation(context.Background(), store, "inv-old", false, &failed))
+	assert.Contains(t, failed.String(), "- **Alert:** alert-old\n")
+	assert.Contains(t, failed.String(), "- **Error kind:** provider_unavailable\n")
+	assert.Contains(t, failed.String(), "## Error\n\nprovider unavailable\n")
+}
+
+func TestShowInvestigation_JSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, showInvestigation(context.Background(), newInvestigationFixture(t), "inv-disk", true, &out))
+
+	var got investigationOutput
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "inv-disk", got.ID)
+	assert.Equal(t, "Disk Full", got.AlertTitle)
+	assert.Equal(t, entity.SeverityCritical, got.Severity)
+	assert.Equal(t, entity.AlertFingerprint("prometheus", "Disk Full", nil), got.Fingerprint)
+	assert.InDelta(t, 0.85, got.Confidence, 1e-9)
+	assert.InDelta(t, 90.0, got.DurationSeconds, 1e-9)
+	assert.Equal(t, []string{"/var is 98% full"}, got.Findings)
+	require.Len(t, got.Timeline, 2)
+	assert.Equal(t, port.InvestigationEventCompleted, got.Timeline[1].Type)
+}
+
+func TestShowInvestigation_NotFound(t *testing.T) {
+	err := showInvestigation(context.Background(), newInvestigationFixture(t), "inv-missing", false, &bytes.Buffer{})
+	assert.ErrorIs(t, err, service.ErrInvestigationNotFound)
+}
+
+// fakeRerunner returns a fixed result for rerun.
+type fakeRerunner struct {
+	result *usecase.InvestigationResult
+	err    error
+	gotID  string
+}
+
+func (f *fakeRerunner) RerunInvestigation(_ context.Context, invID string) (*usecase.InvestigationResult, error) {
+	f.gotID = invID
+	return f.result, f.err
+}
+
+func TestRerunInvestigation(t *testing.T) {
+	rerunner := &fakeRerunner{result: &usecase.InvestigationResult{
+		InvestigationID: "inv-new",
+		AlertID:         "alert-cpu",
+		Status:          "completed",
+		Findings:        []string{"runaway cron job"},
+		ActionsTaken:    4,
+		Duration:        42 * time.Second,
+		Confidence:      0.9,
+		ToolStats:       []usecase.ToolStats{{Tool: "bash", Calls: 4, Duration: 2 * time.Second, Bytes: 900}},
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, rerunInvestigation(context.Background(), rerunner, "inv-cpu", false, &text))
+	assert.Equal(t, "inv-cpu", rerunner.gotID)
+	assert.Equal(t, `Investigation inv-new (rerun of inv-cpu): completed
+Confidence: 0.90
+Actions: 4 in 42s
+Findings:
+- runaway cron job
+Tool usage:
+TOOL   CALLS  ERRORS  TIME  AVG    BYTES
+bash   4      0       2s    500ms  900
+total  4      0       2s    500ms  900
+`, text.String())
+
+	var out bytes.Buffer
+	require.NoError(t, rerunInvestigation(context.Background(), rerunner, "inv-cpu", true, &out))
+	var got struct {
+		RerunOf       string              `json:"rerun_of"`
+		Investigation investigationOutput `json:"investigation"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "inv-cpu", got.RerunOf)
+	assert.Equal(t, "inv-new", got.Investigation.ID)
+	assert.Equal(t, []string{"runaway cron job"}, got.Investigation.Findings)
+}
+
+func TestRerunInvestigation_Error(t *testing.T) {
+	rerunner := &fakeRerunner{err: usecase.ErrInvestigationNotRerunnable}
+	err := rerunInvestigation(context.Background(), rerunner, "inv-old", false, &bytes.Buffer{})
+	assert.True(t, errors.Is(err, usecase.ErrInvestigationNotRerunnable))
+}
+
+// storeReportSource reads a fixture store's investigations for reports.
+type storeReportSource struct {
+	*eventInvestigationStore
+}
+
+func (s storeReportSource) Get(ctx context.Context, id string) (usecase.InvestigationRecordData, error) {
+	record, err := s.eventInvestigationStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func newFixtureReporter(t *testing.T) *usecase.ReportGenerator {
+	t.Helper()
+	generator := usecase.NewReportGenerator()
+	generator.SetInvestigationSource(storeReportSource{newInvestigationFixture(t)})
+	return generator
+}
+
+// fakeReprocessor reports a fixed number of reprocessed alerts.
+type fakeReprocessor struct {
+	n         int
+	err       error
+	gotSource string
+}
+
+func (f *fakeReprocessor) ReprocessDeferred(_ context.Context, source string) (int, error) {
+	f.gotSource = source
+	return f.n, f.err
+}
+
+func TestReprocessDeferred(t *testing.T) {
+	reprocessor := &fakeReprocessor{n: 3}
+	var out bytes.Buffer
+	require.NoError(t, reprocessDeferred(context.Background(), reprocessor, "prometheus", &out))
+	assert.Equal(t, "prometheus", reprocessor.gotSource)
+	assert.Contains(t, out.String(), "Reprocessed 3 deferred alerts.")
+
+	out.Reset()
+	require.NoError(t, reprocessDeferred(context.Background(), &fakeReprocessor{}, "", &out))
+	assert.Equal(t, "No deferred alerts to reprocess.\n", out.String())
+
+	err := reprocessDeferred(context.Background(), &fakeReprocessor{n: 1, err: usecase.ErrNoDeferredStore}, "", &out)
+	assert.True(t, errors.Is(err, usecase.ErrNoDeferredStore))
+	assert.Contains(t, err.Error(), "1 reprocessed")
+}
+
+func TestWriteInvestigationReport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeInvestigationReport(context.Background(), newFixtureReporter(t), "inv-disk", false, "", &out))
+
+	report := out.String()
+	assert.Contains(t, report, "# Investigation Report: Disk Full\n")
+	assert.Contains(t, report, "## What Was Checked\n\n1. `bash` `{\"command\":\"df -h /var\"}`: /dev/sda1 98% ([output](#artifact-1))\n")
+	assert.Contains(t, report, "## Findings\n\n### Warning\n\n- /var is 98% full\n")
+	assert.Contains(t, report, "<a id=\"artifact-1\"></a>artifact-1: bash")
+}
+
+func TestWriteInvestigationReport_OutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.md")
+
+	var out bytes.Buffer
+	require.NoError(t, writeInvestigationReport(context.Background(), newFixtureReporter(t), "inv-old", false, path, &out))
+	assert.Equal(t, "Wrote the report of investigation inv-old to "+path+"\n", out.String())
+
+	report, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "## Error\n\nprovider unavailable")
+}
+
+func TestWriteInvestigationReport_Errors(t *testing.T) {
+	reporter := newFixtureReporter(t)
+
+	err := writeInvestigationReport(context.Background(), reporter, "inv-missing", false, "", &bytes.Buffer{})
+	assert.ErrorIs(t, err, port.ErrInvestigationNotFound)
+
+	err = writeInvestigationReport(context.Background(), reporter, "inv-disk", true, "", &bytes.Buffer{})
+	assert.ErrorIs(t, err, usecase.ErrNoSummaryProvider)
+}
+
+func TestCancelInvestigation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/investigations/inv-1", r.URL.Path)
+		assert.Equal(t, "looping on df", r.URL.Query().Get("reason"))
+		assert.Equal(t, "alice", r.URL.Query().Get("requested_by"))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"cancelling","investigation_id":"inv-1"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	err := cancelInvestigation(context.Background(), server.Client(), server.URL, "inv-1", "looping on df", "alice", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, "Cancelling investigation inv-1\n", buf.String())
+}
+
+func TestCancelInvestigation_Finished(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":"investigation already finished: inv-1 is completed"}`))
+	}))
+	defer server.Close()
+
+	err := cancelInvestigation(context.Background(), server.Client(), server.URL, "inv-1", "", "alice", io.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already finished: inv-1 is completed")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/transcript"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd rewrites stored records in the current format.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite stored investigations and sessions in the current format",
+	Long: `Rewrite the investigation records in .agent/investigations and, when
+session_dir is set, the chat session files in the current format.
+
+Every stored record carries a schema_version. Records written by an older
+version are migrated when they are read anyway, so this is only needed to
+update the files themselves, e.g. before sharing them with other tools.
+Records written by a newer version are never rewritten or misread: they are
+refused with an error naming the file, and the command fails once the others
+are migrated.
+
+Example:
+  code-editing-agent migrate`,
+	Args: cobra.NoArgs,
+	RunE: runMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// recordMigrator is a store that rewrites the records an older version wrote.
+type recordMigrator interface {
+	Migrate(ctx context.Context) (int, error)
+}
+
+// migrationTarget is a store to migrate and what its records are called.
+type migrationTarget struct {
+	records string
+	store   recordMigrator
+}
+
+func runMigrate(cmd *cobra.Command, _ []string) error {
+	cfg := GetConfig(cmd)
+	store, err := openInvestigationStore(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	targets := []migrationTarget{{records: "investigation records", store: store}}
+	if cfg.SessionDir != "" {
+		sessions, err := transcript.NewFileConversationStore(cfg.SessionDir)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, migrationTarget{records: "session files", store: sessions})
+	}
+	return migrateStores(cmd.Context(), targets, cmd.OutOrStdout())
+}
+
+// migrateStores migrates each target and writes how many records it
+// rewrote. It fails if any target had records it could not migrate.
+func migrateStores(ctx context.Context, targets []migrationTarget, w io.Writer) error {
+	var errs []error
+	for _, target := range targets {
+		migrated, err := target.store.Migrate(ctx)
+		fmt.Fprintf(w, "Migrated %d %s.\n", migrated, target.records)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to migrate %s: %w", target.records, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/investigation"
+	"code-editing-agent/internal/infrastructure/schema"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateStores(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inv-old.json"), []byte(
+		`{"id":"inv-old","alert_id":"HighCPU","status":"completed","findings":["CPU at 95%"]}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inv-new.json"), []byte(
+		`{"schema_version":9,"id":"inv-new"}`), 0o600))
+	store, err := investigation.NewFileInvestigationStore(dir)
+	require.NoError(t, err)
+	var out bytes.Buffer
+
+	err = migrateStores(context.Background(), []migrationTarget{{records: "investigation records", store: store}}, &out)
+
+	require.ErrorIs(t, err, schema.ErrNewerVersion)
+	assert.Contains(t, err.Error(), "inv-new")
+	assert.Equal(t, "Migrated 1 investigation records.\n", out.String())
+	data, err := os.ReadFile(filepath.Join(dir, "inv-old.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"findings":[{"severity":"warning","text":"CPU at 95%"}]`)
+}
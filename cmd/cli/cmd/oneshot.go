@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	appsvc "code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"code-editing-agent/internal/infrastructure/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of a one-shot run.
+const (
+	oneShotOutputText = "text"
+	oneShotOutputJSON = "json"
+)
+
+// addOneShotFlags adds the one-shot flags to a command that starts a chat.
+func addOneShotFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("prompt", "p", "", "Answer this prompt, with any piped stdin appended, and exit")
+	cmd.Flags().Bool("allow-edits", false, "Let a one-shot run edit files and run mutating commands")
+	cmd.Flags().String("output", oneShotOutputText, "One-shot output format: text or json")
+}
+
+// oneShotOptions holds a one-shot run's prompt and flags.
+type oneShotOptions struct {
+	prompt     string
+	allowEdits bool
+	asJSON     bool
+}
+
+// oneShotOptionsFromFlags returns the one-shot run cmd was asked for, and
+// false for an interactive chat. A run is one-shot when --prompt is given or
+// stdin is piped with data, which is then read in full.
+func oneShotOptionsFromFlags(cmd *cobra.Command, stdin io.Reader) (oneShotOptions, bool, error) {
+	flags := cmd.Flags()
+	text, _ := flags.GetString("prompt")
+	allowEdits, _ := flags.GetBool("allow-edits")
+	output, _ := flags.GetString("output")
+	if output == "" {
+		output = oneShotOutputText
+	}
+	if output != oneShotOutputText && output != oneShotOutputJSON {
+		return oneShotOptions{}, false, fmt.Errorf("invalid --output %q: must be text or json", output)
+	}
+
+	var piped string
+	if !ui.IsTerminal(stdin) {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return oneShotOptions{}, false, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		piped = string(data)
+	}
+	prompt := oneShotPrompt(text, piped)
+	if prompt == "" {
+		if flags.Changed("prompt") {
+			return oneShotOptions{}, false, errors.New("--prompt is empty and stdin has no data")
+		}
+		return oneShotOptions{}, false, nil
+	}
+	return oneShotOptions{prompt: prompt, allowEdits: allowEdits, asJSON: output == oneShotOutputJSON}, true, nil
+}
+
+// oneShotPrompt combines the --prompt text and piped stdin into one message,
+// the text first. It returns "" when both are blank.
+func oneShotPrompt(text, piped string) string {
+	text = strings.TrimSpace(text)
+	piped = strings.TrimRight(piped, "\n")
+	switch {
+	case strings.TrimSpace(piped) == "":
+		return text
+	case text == "":
+		return piped
+	default:
+		return text + "\n\n" + piped
+	}
+}
+
+// runOneShotCommand answers opts.prompt with a headless container, printing
+// the answer to stdout and everything else to stderr.
+func runOneShotCommand(cmd *cobra.Command, opts oneShotOptions) error {
+	cmd.SilenceUsage = true
+	cfg := *GetConfig(cmd)
+	// There is no one to confirm commands or answer ask_user: safe commands
+	// run, dangerous ones are blocked, and edits are applied unless plan mode
+	// blocks them
+	cfg.Headless = true
+	cfg.AutoApproveSafeCommands = true
+
+	container, err := config.NewContainer(&cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize container: %w", err)
+	}
+	defer shutdownContainer(container)
+
+	return runOneShot(cmd.Context(), container.ChatService(), container.ConversationService(),
+		container.UIAdapter(), opts, cmd.OutOrStdout())
+}
+
+// oneShotToolCall is a tool call made during a one-shot run.
+type oneShotToolCall struct {
+	Tool    string         `json:"tool"`
+	Input   map[string]any `json:"input"`
+	Result  string         `json:"result"`
+	IsError bool           `json:"is_error"`
+}
+
+// oneShotResult is the JSON output of a one-shot run.
+type oneShotResult struct {
+	SessionID string            `json:"session_id"`
+	Answer    string            `json:"answer"`
+	ToolCalls []oneShotToolCall `json:"tool_calls"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// runOneShot sends opts.prompt in a new session, runs the tool loop until the
+// AI answers, and writes the answer, or with opts.asJSON the whole result, to
+// w. Streamed text and tool results go to chatter. Without opts.allowEdits the
+// session is in plan mode, so mutating tools are refused.
+func runOneShot(
+	ctx context.Context,
+	chatService *appsvc.ChatService,
+	conversations *service.ConversationService,
+	chatter port.UserInterface,
+	opts oneShotOptions,
+	w io.Writer,
+) error {
+	start, err := chatService.StartSession(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to start chat session: %w", err)
+	}
+	sessionID := start.SessionID
+	chatService.SetSessionUI(sessionID, &oneShotUI{UserInterface: chatter})
+	if !opts.allowEdits {
+		if err := chatService.HandleModeCommand(ctx, sessionID, "plan"); err != nil {
+			return err
+		}
+	}
+
+	resp, sendErr := chatService.SendMessage(ctx, sessionID, opts.prompt)
+	var answer string
+	if sendErr == nil && resp != nil && resp.AssistantMsg != nil {
+		answer = strings.TrimSpace(resp.AssistantMsg.Content)
+	}
+
+	if !opts.asJSON {
+		if sendErr != nil {
+			return sendErr
+		}
+		_, err := fmt.Fprintln(w, answer)
+		return err
+	}
+
+	result := oneShotResult{SessionID: sessionID, Answer: answer, ToolCalls: oneShotToolCalls(conversations, sessionID)}
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+	}
+	if err := writeJSON(w, result); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// oneShotToolCalls returns the tool calls made in a session with their
+// results, in order.
+func oneShotToolCalls(conversations *service.ConversationService, sessionID string) []oneShotToolCall {
+	calls := []oneShotToolCall{}
+	conv, err := conversations.GetConversation(sessionID)
+	if err != nil {
+		return calls
+	}
+	byID := make(map[string]int)
+	for _, msg := range conv.GetMessages() {
+		for _, call := range msg.ToolCalls {
+			byID[call.ToolID] = len(calls)
+			calls = append(calls, oneShotToolCall{Tool: call.ToolName, Input: call.Input})
+		}
+		for _, result := range msg.ToolResults {
+			if i, ok := byID[result.ToolID]; ok {
+				calls[i].Result, calls[i].IsError = result.Result, result.IsError
+			}
+		}
+	}
+	return calls
+}
+
+// oneShotUI shows a one-shot session's output on the chatter user interface,
+// except for the text of the last response, which is the answer. Each
+// response's streamed text is held until the next response begins.
+type oneShotUI struct {
+	port.UserInterface
+	held strings.Builder
+}
+
+// flush shows the held text, which was not the answer since more output
+// followed it.
+func (u *oneShotUI) flush() {
+	if u.held.Len() == 0 {
+		return
+	}
+	_ = u.UserInterface.BeginStreamingResponse()
+	_ = u.UserInterface.DisplayStreamingText(u.held.String())
+	_ = u.UserInterface.EndStreamingResponse()
+	u.held.Reset()
+}
+
+func (u *oneShotUI) BeginStreamingResponse() error {
+	u.flush()
+	return nil
+}
+
+func (u *oneShotUI) DisplayStreamingText(text string) error {
+	u.held.WriteString(text)
+	return nil
+}
+
+func (u *oneShotUI) EndStreamingResponse() error { return nil }
+
+func (u *oneShotUI) DisplayToolResult(toolName, input, result string) error {
+	u.flush()
+	return u.UserInterface.DisplayToolResult(toolName, input, result)
+}
+
+func (u *oneShotUI) DisplaySystemMessage(message string) error {
+	u.flush()
+	return u.UserInterface.DisplaySystemMessage(message)
+}
+
+func (u *oneShotUI) DisplayError(err error) error {
+	u.flush()
+	return u.UserInterface.DisplayError(err)
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	appsvc "code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedTurn is one response of a scriptedProvider.
+type scriptedTurn struct {
+	text      string
+	toolCalls []port.ToolCallInfo
+	err       error
+}
+
+// scriptedProvider answers each request with the next of its turns and
+// records the requests' messages.
+type scriptedProvider struct {
+	turns    []scriptedTurn
+	requests [][]port.MessageParam
+}
+
+func (p *scriptedProvider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessageStreaming(ctx, messages, tools, nil, nil)
+}
+
+func (p *scriptedProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessageStreaming(ctx, messages, tools, nil, nil)
+}
+
+func (p *scriptedProvider) SendMessageStreaming(
+	_ context.Context,
+	messages []port.MessageParam,
+	_ []port.ToolParam,
+	textCallback port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	p.requests = append(p.requests, messages)
+	if len(p.turns) == 0 {
+		return nil, nil, errors.New("no scripted turn left")
+	}
+	turn := p.turns[0]
+	p.turns = p.turns[1:]
+	if turn.err != nil {
+		return nil, nil, turn.err
+	}
+	if textCallback != nil && turn.text != "" {
+		_ = textCallback(turn.text)
+	}
+	msg := &entity.Message{Role: entity.RoleAssistant, Content: turn.text}
+	for i, call := range turn.toolCalls {
+		input, _ := json.Marshal(call.Input)
+		turn.toolCalls[i].InputJSON = string(input)
+		msg.ToolCalls = append(msg.ToolCalls, entity.ToolCall{ToolID: call.ToolID, ToolName: call.ToolName, Input: call.Input})
+	}
+	return msg, turn.toolCalls, nil
+}
+
+func (p *scriptedProvider) GenerateToolSchema() port.ToolInputSchemaParam {
+	return port.ToolInputSchemaParam{"type": "object"}
+}
+func (p *scriptedProvider) HealthCheck(context.Context) error { return nil }
+func (p *scriptedProvider) SetModel(string) error             { return nil }
+func (p *scriptedProvider) GetModel() string                  { return "test-model" }
+
+// oneShotFixture is a chat over a scripted provider and the real tools in a
+// temporary workspace, with the chatter captured.
+type oneShotFixture struct {
+	provider      *scriptedProvider
+	chat          *appsvc.ChatService
+	conversations *service.ConversationService
+	chatter       port.UserInterface
+	stderr        bytes.Buffer
+}
+
+func newOneShotFixture(t *testing.T, dir string, turns ...scriptedTurn) *oneShotFixture {
+	t.Helper()
+	f := &oneShotFixture{provider: &scriptedProvider{turns: turns}}
+	fileManager := file.NewLocalFileManager(dir)
+	executor := tool.NewPlanningExecutorAdapter(tool.NewExecutorAdapter(fileManager), fileManager, dir)
+	conversations, err := service.NewConversationService(f.provider, executor)
+	require.NoError(t, err)
+	f.conversations = conversations
+	f.chatter = ui.NewHeadlessCLIAdapter(&f.stderr)
+	f.chat, err = appsvc.NewChatServiceFromDomain(conversations, f.chatter, f.provider, executor, fileManager)
+	require.NoError(t, err)
+	return f
+}
+
+func (f *oneShotFixture) run(t *testing.T, opts oneShotOptions) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	err := runOneShot(context.Background(), f.chat, f.conversations, f.chatter, opts, &stdout)
+	return stdout.String(), err
+}
+
+func readFileCall(id, path string) port.ToolCallInfo {
+	return port.ToolCallInfo{ToolID: id, ToolName: "read_file", Input: map[string]interface{}{"path": path}}
+}
+
+func TestRunOneShot_PrintsOnlyTheAnswer(t *testing.T) {
+	dir := t.TempDir()
+	f := newOneShotFixture(t, dir,
+		scriptedTurn{text: "Let me read the log.", toolCalls: []port.ToolCallInfo{readFileCall("t1", filepath.Join(dir, "app.log"))}},
+		scriptedTurn{text: "The disk is full."},
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.log"), []byte("ENOSPC: no space left on device\n"), 0o600))
+
+	out, err := f.run(t, oneShotOptions{prompt: "what's wrong here"})
+	require.NoError(t, err)
+	assert.Equal(t, "The disk is full.\n", out)
+	assert.Contains(t, f.stderr.String(), "Let me read the log.")
+	assert.Contains(t, f.stderr.String(), "app.log")
+	assert.NotContains(t, f.stderr.String(), "The disk is full.", "the answer goes to stdout only")
+
+	require.Len(t, f.provider.requests, 2)
+	assert.Contains(t, f.provider.requests[1][len(f.provider.requests[1])-1].ToolResults[0].Result, "ENOSPC")
+}
+
+func TestRunOneShot_EditsNeedAllowEdits(t *testing.T) {
+	for _, allowEdits := range []bool{false, true} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "fixed.txt")
+		edit := port.ToolCallInfo{ToolID: "t1", ToolName: "edit_file", Input: map[string]interface{}{
+			"path": path, "old_str": "", "new_str": "fixed\n",
+		}}
+		f := newOneShotFixture(t, dir,
+			scriptedTurn{text: "Fixing it.", toolCalls: []port.ToolCallInfo{edit}},
+			scriptedTurn{text: "Done."},
+		)
+		out, err := f.run(t, oneShotOptions{prompt: "fix it", allowEdits: allowEdits, asJSON: true})
+		require.NoError(t, err)
+
+		var result oneShotResult
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		require.Len(t, result.ToolCalls, 1)
+		assert.Equal(t, "edit_file", result.ToolCalls[0].Tool)
+		assert.Equal(t, path, result.ToolCalls[0].Input["path"])
+
+		_, statErr := os.Stat(path)
+		if allowEdits {
+			assert.NoError(t, statErr, "--allow-edits should let the edit through")
+			assert.False(t, result.ToolCalls[0].IsError)
+		} else {
+			assert.True(t, os.IsNotExist(statErr), "edits should be refused without --allow-edits")
+			assert.True(t, result.ToolCalls[0].IsError)
+			assert.Contains(t, result.ToolCalls[0].Result, "blocked")
+		}
+	}
+}
+
+func TestRunOneShot_JSON(t *testing.T) {
+	dir := t.TempDir()
+	f := newOneShotFixture(t, dir,
+		scriptedTurn{text: "Checking.", toolCalls: []port.ToolCallInfo{readFileCall("t1", filepath.Join(dir, "missing.log"))}},
+		scriptedTurn{text: "There is no log."},
+	)
+	out, err := f.run(t, oneShotOptions{prompt: "check the log", asJSON: true})
+	require.NoError(t, err)
+
+	var result oneShotResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.NotEmpty(t, result.SessionID)
+	assert.Equal(t, "There is no log.", result.Answer)
+	require.Len(t, result.ToolCalls, 1)
+	assert.Equal(t, "read_file", result.ToolCalls[0].Tool)
+	assert.True(t, result.ToolCalls[0].IsError)
+	assert.Empty(t, result.Error)
+}
+
+func TestRunOneShot_ProviderError(t *testing.T) {
+	f := newOneShotFixture(t, t.TempDir(), scriptedTurn{err: errors.New("provider unavailable")})
+	out, err := f.run(t, oneShotOptions{prompt: "hello"})
+	require.ErrorContains(t, err, "provider unavailable")
+	assert.Empty(t, out)
+	assert.Equal(t, ExitFailure, ExitCode(err))
+
+	f = newOneShotFixture(t, t.TempDir(), scriptedTurn{err: errors.New("provider unavailable")})
+	out, err = f.run(t, oneShotOptions{prompt: "hello", asJSON: true})
+	require.Error(t, err)
+	var result oneShotResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Contains(t, result.Error, "provider unavailable")
+}
+
+func TestOneShotOptionsFromFlags(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		addOneShotFlags(cmd)
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	opts, ok, err := oneShotOptionsFromFlags(newCmd("-p", "what's wrong here"), strings.NewReader("panic: nil map\n"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "what's wrong here\n\npanic: nil map", opts.prompt)
+
+	opts, ok, err = oneShotOptionsFromFlags(newCmd("--output", "json", "--allow-edits"), strings.NewReader("fix main.go"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, oneShotOptions{prompt: "fix main.go", allowEdits: true, asJSON: true}, opts)
+
+	_, ok, err = oneShotOptionsFromFlags(newCmd(), strings.NewReader(""))
+	require.NoError(t, err)
+	assert.False(t, ok, "no prompt and no piped data starts the interactive chat")
+
+	_, _, err = oneShotOptionsFromFlags(newCmd("-p", " "), strings.NewReader(""))
+	require.Error(t, err)
+	_, _, err = oneShotOptionsFromFlags(newCmd("-p", "hi", "--output", "yaml"), strings.NewReader(""))
+	require.ErrorContains(t, err, "must be text or json")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/infrastructure/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// alertFile is the JSON shape of the alert files accepted by --render-prompt
+// and simulate. Only title is required; the other fields default to
+// placeholder values.
+type alertFile struct {
+	ID          string            `json:"id"`
+	Source      string            `json:"source"`
+	Severity    string            `json:"severity"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// loadAlertFile reads an alert JSON file and converts it to a domain alert,
+// with defaultID as the ID of alerts that have none.
+func loadAlertFile(path, defaultID string) (*entity.Alert, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert file: %w", err)
+	}
+
+	var in alertFile
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse alert file %s: %w", path, err)
+	}
+
+	if in.ID == "" {
+		in.ID = defaultID
+	}
+	if in.Source == "" {
+		in.Source = "cli"
+	}
+	if in.Severity == "" {
+		in.Severity = entity.SeverityWarning
+	}
+
+	alert, err := entity.NewAlert(in.ID, in.Source, in.Severity, in.Title)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert in %s: %w", path, err)
+	}
+	return alert.WithDescription(in.Description).WithLabels(in.Labels).WithAnnotations(in.Annotations), nil
+}
+
+// renderPrompt writes the investigation prompt for the alert in alertPath to w
+// without starting an investigation.
+func renderPrompt(ctx context.Context, container *config.Container, alertPath string, w io.Writer) error {
+	alert, err := loadAlertFile(alertPath, "render-prompt")
+	if err != nil {
+		return err
+	}
+
+	prompt, err := container.InvestigationUseCase().RenderPrompt(ctx, alert)
+	if err != nil {
+		return fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	_, err = fmt.Fprint(w, prompt)
+	return err
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"code-editing-agent/internal/infrastructure/adapter/aidebug"
 	"code-editing-agent/internal/infrastructure/config"
 	signalhandler "code-editing-agent/internal/infrastructure/signal"
+	"code-editing-agent/internal/infrastructure/version"
 	"context"
 	"errors"
 	"fmt"
@@ -62,7 +64,14 @@ refactoring options, and explanations.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		_ = args // args unused but required by cobra
 		// Load configuration
-		cfg = config.LoadConfig()
+		configPath, _ := cmd.Flags().GetString("config")
+		loaded, err := config.Load(configPath)
+		if err != nil {
+			// The problem is in the configuration, not the command line
+			cmd.SilenceUsage = true
+			return err
+		}
+		cfg = loaded
 
 		// Store config in command context and package variable
 		cmd.SetContext(contextWithConfig(cmd.Context(), cfg))
@@ -104,7 +113,58 @@ func GetConfig(cmd *cobra.Command) *config.Config {
 	return cfg
 }
 
+// printConfigIfValidating prints the effective configuration when --validate-config
+// is set, reporting whether the command should exit instead of running.
+// Invalid configurations never get here: loading them fails in PersistentPreRunE.
+func printConfigIfValidating(cmd *cobra.Command) (bool, error) {
+	if validate, _ := cmd.Flags().GetBool("validate-config"); !validate {
+		return false, nil
+	}
+	return true, GetConfig(cmd).WriteRedacted(cmd.OutOrStdout())
+}
+
+// dumpLastRequestIfAsked prints the most recent API debug file when
+// --dump-last-request is set, reporting whether the command should exit
+// instead of running.
+func dumpLastRequestIfAsked(cmd *cobra.Command) (bool, error) {
+	if dump, _ := cmd.Flags().GetBool("dump-last-request"); !dump {
+		return false, nil
+	}
+	dir := GetConfig(cmd).DebugAPIDir
+	path, err := aidebug.LastExchange(dir)
+	if errors.Is(err, aidebug.ErrNoExchanges) {
+		return true, fmt.Errorf("no API debug files in %s; enable debug_api.enabled or run :debug api on", dir)
+	}
+	if err != nil {
+		return true, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true, fmt.Errorf("failed to read API debug file: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", path)
+	_, err = cmd.OutOrStdout().Write(data)
+	return true, err
+}
+
+// containerShutdownTimeout bounds how long exiting waits to drain investigations
+// and flush trace spans.
+const containerShutdownTimeout = 5 * time.Second
+
+// shutdownContainer drains the container's investigations and flushes its pending
+// telemetry before the command exits.
+func shutdownContainer(container *config.Container) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerShutdownTimeout)
+	defer cancel()
+	if err := container.Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: shutdown incomplete: %v\n", err)
+	}
+}
+
 func init() {
+	// --version prints the build, set with -ldflags (see the version package)
+	rootCmd.Version = version.Get().String()
+
 	// Define flags
 	rootCmd.PersistentFlags().String("model", "hf:zai-org/GLM-4.6", "AI model to use for requests")
 	rootCmd.PersistentFlags().StringP("dir", "d", ".", "Working directory for file operations")
@@ -112,6 +172,18 @@ func init() {
 	rootCmd.PersistentFlags().Bool("thinking", false, "Enable extended thinking")
 	rootCmd.PersistentFlags().Int("thinking-budget", 10000, "Token budget for thinking (min 1024)")
 	rootCmd.PersistentFlags().Bool("show-thinking", false, "Display thinking content")
+	rootCmd.PersistentFlags().Bool("no-markdown", false, "Display assistant messages as plain text instead of rendered Markdown")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Show tool calls with their full input instead of a compact summary")
+	rootCmd.PersistentFlags().
+		String("transcript", "", "Write a timestamped session transcript to this file (supports {date} and {session})")
+	rootCmd.PersistentFlags().String("config", "", "Config file (default: $CODE_AGENT_CONFIG or ./config.yaml if present)")
+	rootCmd.PersistentFlags().Bool("validate-config", false, "Validate the configuration, print it with secrets masked, and exit")
+	rootCmd.PersistentFlags().
+		Bool("dump-last-request", false, "Print the most recent AI request and response from the API debug files, and exit")
+	rootCmd.PersistentFlags().String("log-level", "info", "Minimum log level: debug, info, warn, or error")
+	rootCmd.PersistentFlags().String("log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().String("log-file", "", "Append logs to this file (WARN and above also go to stderr)")
 
 	// Bind flags to viper
 	if err := viper.BindPFlag("model", rootCmd.PersistentFlags().Lookup("model")); err != nil {
@@ -132,4 +204,25 @@ func init() {
 	if err := viper.BindPFlag("thinking.show", rootCmd.PersistentFlags().Lookup("show-thinking")); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to bind show-thinking flag: %v\n", err)
 	}
+	if err := viper.BindPFlag("no_markdown", rootCmd.PersistentFlags().Lookup("no-markdown")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind no-markdown flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("no_color", rootCmd.PersistentFlags().Lookup("no-color")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind no-color flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind verbose flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("transcript", rootCmd.PersistentFlags().Lookup("transcript")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind transcript flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind log-level flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("log_format", rootCmd.PersistentFlags().Lookup("log-format")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind log-format flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("log_file", rootCmd.PersistentFlags().Lookup("log-file")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind log-file flag: %v\n", err)
+	}
 }
@@ -1,7 +1,17 @@
 package cmd
 
 import (
+	"code-editing-agent/internal/infrastructure/config"
+	"code-editing-agent/internal/infrastructure/version"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -388,6 +398,9 @@ func TestRootCmd_PersistentFlags(t *testing.T) {
 		{"thinking-budget is persistent", "thinking-budget"},
 		{"show-thinking is persistent", "show-thinking"},
 		{"max-tokens is persistent", "max-tokens"},
+		{"config is persistent", "config"},
+		{"validate-config is persistent", "validate-config"},
+		{"dump-last-request is persistent", "dump-last-request"},
 	}
 
 	for _, tt := range tests {
@@ -403,3 +416,96 @@ func TestRootCmd_PersistentFlags(t *testing.T) {
 		})
 	}
 }
+
+// TestServeCmd_AlertSourcesFlag verifies that serve's alert sources file has its
+// own flag, so --config names the agent config file for every command.
+func TestServeCmd_AlertSourcesFlag(t *testing.T) {
+	flag := serveCmd.Flags().Lookup("alert-sources")
+	require.NotNil(t, flag, "alert-sources flag should be registered on serve")
+	assert.Equal(t, "config/alert-sources.yaml", flag.DefValue)
+	assert.Nil(t, serveCmd.LocalNonPersistentFlags().Lookup("config"),
+		"serve should not shadow the persistent --config flag")
+}
+
+// TestServeCmd_DrainTimeoutFlag verifies the drain timeout flag feeds Config.DrainTimeout.
+func TestServeCmd_DrainTimeoutFlag(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	flag := serveCmd.Flags().Lookup("drain-timeout")
+	require.NotNil(t, flag, "drain-timeout flag should be registered on serve")
+	assert.Equal(t, "30s", flag.DefValue)
+
+	require.NoError(t, viper.BindPFlag("drain_timeout", flag))
+	require.NoError(t, serveCmd.Flags().Set("drain-timeout", "2m"))
+	defer func() { _ = serveCmd.Flags().Set("drain-timeout", flag.DefValue) }()
+
+	assert.Equal(t, 2*time.Minute, config.LoadConfig().DrainTimeout)
+}
+
+// TestRootCmd_Version verifies that --version prints the build.
+func TestRootCmd_Version(t *testing.T) {
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"--version"})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "code-editing-agent version "+version.Get().String()+"\n", out.String())
+}
+
+// TestUpdateNotice verifies the one-line notice of a newer release.
+func TestUpdateNotice(t *testing.T) {
+	latest := "v1.5.0"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"tag_name":%q,"html_url":"https://example.com/releases/%s"}`, latest, latest)
+	}))
+	defer server.Close()
+
+	notice, err := updateNotice(context.Background(), server.Client(), server.URL, "v1.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, "Update available: v1.5.0 (running v1.4.0): https://example.com/releases/v1.5.0", notice)
+
+	latest = "v1.4.0"
+	notice, err = updateNotice(context.Background(), server.Client(), server.URL, "v1.4.0")
+	require.NoError(t, err)
+	assert.Empty(t, notice, "no notice when the latest release is the running one")
+
+	_, err = updateNotice(context.Background(), server.Client(), server.URL, "dev")
+	require.ErrorIs(t, err, version.ErrNotSemver)
+}
+
+// TestDumpLastRequestIfAsked verifies that --dump-last-request prints the most
+// recent API debug file, and explains how to get one when there is none.
+func TestDumpLastRequestIfAsked(t *testing.T) {
+	dir := t.TempDir()
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("dump-last-request", false, "")
+	c := config.Defaults()
+	c.DebugAPIDir = dir
+	cmd.SetContext(contextWithConfig(context.Background(), c))
+	var out, errOut strings.Builder
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+
+	dumping, err := dumpLastRequestIfAsked(cmd)
+	assert.False(t, dumping, "nothing is dumped without the flag")
+	require.NoError(t, err)
+
+	require.NoError(t, cmd.Flags().Set("dump-last-request", "true"))
+	dumping, err = dumpLastRequestIfAsked(cmd)
+	assert.True(t, dumping)
+	require.ErrorContains(t, err, ":debug api on")
+
+	path := filepath.Join(dir, "session-1", "20260310T120000.000Z-0001.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, []byte(`{"request": {}}`+"\n"), 0o600))
+	dumping, err = dumpLastRequestIfAsked(cmd)
+	assert.True(t, dumping)
+	require.NoError(t, err)
+	assert.Equal(t, `{"request": {}}`+"\n", out.String())
+	assert.Equal(t, path+"\n", errOut.String())
+}
@@ -2,13 +2,22 @@ package cmd
 
 import (
 	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
 	"code-editing-agent/internal/infrastructure/adapter/alert"
+	"code-editing-agent/internal/infrastructure/adapter/health"
+	"code-editing-agent/internal/infrastructure/adapter/metrics"
+	"code-editing-agent/internal/infrastructure/adapter/skill"
 	"code-editing-agent/internal/infrastructure/adapter/webhook"
 	"code-editing-agent/internal/infrastructure/config"
 	signalhandler "code-editing-agent/internal/infrastructure/signal"
+	"code-editing-agent/internal/infrastructure/version"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,16 +34,48 @@ var serveCmd = &cobra.Command{
 The server exposes endpoints for:
 - Health checks: GET /health
 - Readiness checks: GET /ready
+- Kubernetes probes: GET /healthz (liveness) and GET /readyz (AI provider,
+  investigation store, and workspace checks, cached for health.cache_ttl)
 - Webhook receivers: POST /alerts/{source-path}
+- Backfills: POST /webhook/batch?source=<name> (an array of past alerts, as
+  exported from Alertmanager, investigated one at a time in the background
+  without notifications; see "investigate --file")
+- Investigation progress: GET /investigations/{id}/events (Server-Sent Events
+  replaying the investigation's events so far, then following live ones until
+  it completes, escalates, or fails)
+
+With --metrics-addr (or AGENT_METRICS_LISTEN_ADDR), a separate server exposes
+Prometheus metrics about investigations, tool executions, and AI requests at
+GET /metrics.
 
 Example:
   code-editing-agent serve --addr :8080
-  code-editing-agent serve --config config/alert-sources.yaml
+  code-editing-agent serve --alert-sources config/alert-sources.yaml
+  code-editing-agent serve --render-prompt alert.json
+  code-editing-agent serve --metrics-addr :9090
 
 Alert sources are registered from the config file and receive webhooks
 at their configured paths. For example, a Prometheus Alertmanager source
 configured with webhook_path "/alerts/prometheus" receives alerts at
-POST /alerts/prometheus.`,
+POST /alerts/prometheus.
+
+Investigation prompts can be customized with Go text/template files named
+<AlertType>.tmpl (e.g., HighCPU.tmpl, Generic.tmpl) in the prompts directory.
+Use --render-prompt to print the prompt for an alert JSON file without
+starting the server or running an investigation.
+
+On SIGTERM or SIGINT the server stops accepting alerts (webhooks and the
+ready check return 503) and waits up to --drain-timeout for running
+investigations to finish. Investigations still running then are cancelled
+and marked "interrupted" in the investigation store.
+
+On SIGHUP or POST /-/reload the server re-reads the config file and applies
+changes to prompt templates, runbooks, skills, tools.blocked_commands,
+investigation limits and severity overrides, and notify settings. Running
+investigations keep the settings they started with. Changes to other settings
+(listen addresses, the store path, provider credentials, ...) need a restart
+and are reported as ignored. An invalid config file is rejected and the old
+configuration stays active.`,
 	RunE: runServe,
 }
 
@@ -42,14 +83,98 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 
 	serveCmd.Flags().String("addr", ":8080", "Address to listen on (e.g., :8080, 0.0.0.0:9090)")
-	serveCmd.Flags().String("config", "config/alert-sources.yaml", "Path to alert sources config file")
+	serveCmd.Flags().String("alert-sources", "config/alert-sources.yaml", "Path to alert sources config file")
 	serveCmd.Flags().
 		Bool("auto-approve-safe", false, "Auto-approve non-dangerous bash commands (dangerous commands are blocked)")
+	serveCmd.Flags().String("prompts-dir", "", "Directory of investigation prompt templates (default: <dir>/prompts)")
+	serveCmd.Flags().String("runbooks-dir", "", "Directory of per-alert runbooks named <alertname>.md (default: <dir>/runbooks)")
+	serveCmd.Flags().String("render-prompt", "", "Print the investigation prompt for an alert JSON file and exit")
+	serveCmd.Flags().String("metrics-addr", "", "Address to serve Prometheus metrics on (e.g., :9090; default: disabled)")
+	serveCmd.Flags().Duration("drain-timeout", 30*time.Second, "How long shutdown waits for running investigations")
 
 	// Bind flag to viper
 	if err := viper.BindPFlag("auto_approve_safe", serveCmd.Flags().Lookup("auto-approve-safe")); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to bind auto-approve-safe flag: %v\n", err)
 	}
+	if err := viper.BindPFlag("prompts_dir", serveCmd.Flags().Lookup("prompts-dir")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind prompts-dir flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("runbooks_dir", serveCmd.Flags().Lookup("runbooks-dir")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind runbooks-dir flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("metrics_listen_addr", serveCmd.Flags().Lookup("metrics-addr")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind metrics-addr flag: %v\n", err)
+	}
+	if err := viper.BindPFlag("drain_timeout", serveCmd.Flags().Lookup("drain-timeout")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to bind drain-timeout flag: %v\n", err)
+	}
+}
+
+// startMetricsServer serves the container's metrics until ctx is cancelled.
+// It does nothing when metrics are disabled.
+func startMetricsServer(ctx context.Context, container *config.Container) {
+	registry := container.MetricsRegistry()
+	if registry == nil {
+		return
+	}
+
+	ui := container.UIAdapter()
+	addr := container.Config().MetricsListenAddr
+	server := metrics.NewServer(addr, registry)
+	go func() {
+		if err := server.Start(ctx); err != nil {
+			_ = ui.DisplayError(fmt.Errorf("metrics server on %s: %w", addr, err))
+		}
+	}()
+}
+
+// startDigestScheduler publishes investigation digests on the configured
+// schedule until ctx is cancelled. It does nothing without a schedule.
+func startDigestScheduler(ctx context.Context, container *config.Container) {
+	scheduler := container.DigestScheduler()
+	if scheduler == nil {
+		return
+	}
+	go scheduler.Run(ctx)
+}
+
+// updateCheckTimeout bounds the startup update check.
+const updateCheckTimeout = 10 * time.Second
+
+// startUpdateCheck checks in the background whether a release newer than this
+// build is out, and prints a one-line notice if so. It does nothing unless
+// update_check.enabled is set; failed checks are only logged.
+func startUpdateCheck(ctx context.Context, container *config.Container) {
+	cfg := container.Config()
+	if !cfg.UpdateCheckEnabled {
+		return
+	}
+
+	ui := container.UIAdapter()
+	logger := container.Logger()
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+		defer cancel()
+		notice, err := updateNotice(ctx, http.DefaultClient, cfg.UpdateCheckURL, version.Get().Version)
+		switch {
+		case errors.Is(err, version.ErrNotSemver):
+			logger.Debug("Skipping update check of a build without a release version", "error", err)
+		case err != nil:
+			logger.Info("Update check failed", "url", cfg.UpdateCheckURL, "error", err)
+		case notice != "":
+			_ = ui.DisplaySystemMessage(notice)
+		}
+	}()
+}
+
+// updateNotice returns a one-line notice of the latest release at url if it
+// is newer than current, or "" if it is not.
+func updateNotice(ctx context.Context, client *http.Client, url, current string) (string, error) {
+	release, err := version.CheckForUpdate(ctx, client, url, current)
+	if err != nil || release == nil {
+		return "", err
+	}
+	return fmt.Sprintf("Update available: %s (running %s): %s", release.Version, current, release.URL), nil
 }
 
 // registerAlertSources registers alert sources from config with the source manager.
@@ -83,44 +208,115 @@ func registerAlertSources(webhookCfg *config.WebhookServerConfig, container *con
 	return nil
 }
 
-// setupSkillReloadHandler creates and starts a SIGHUP handler for skill hot-reload.
-func setupSkillReloadHandler(container *config.Container) *signalhandler.ReloadHandler {
+// configReloader reloads the container's configuration from the config file.
+type configReloader struct {
+	path      string // The --config flag; empty for the default locations
+	container *config.Container
+}
+
+// ReloadConfig implements port.ConfigReloader.
+func (r *configReloader) ReloadConfig(ctx context.Context) (port.ConfigReloadResult, error) {
+	cfg, err := config.Load(r.path)
+	if err != nil {
+		return port.ConfigReloadResult{}, err
+	}
+	return r.container.Reload(ctx, cfg)
+}
+
+// setupConfigReloadHandler creates and starts a SIGHUP handler that reloads the
+// configuration, reporting the applied and ignored changes.
+func setupConfigReloadHandler(
+	container *config.Container,
+	reloader port.ConfigReloader,
+) *signalhandler.ReloadHandler {
 	ui := container.UIAdapter()
-	skillManager := container.SkillManager()
 
 	reloadHandler := signalhandler.NewReloadHandler(func(reloadCtx context.Context) {
 		_ = ui.DisplaySystemMessage("")
-		_ = ui.DisplaySystemMessage("Received SIGHUP - reloading skills...")
+		_ = ui.DisplaySystemMessage("Received SIGHUP - reloading configuration...")
 
-		result, err := skillManager.DiscoverSkills(reloadCtx)
+		result, err := reloader.ReloadConfig(reloadCtx)
 		if err != nil {
-			_ = ui.DisplaySystemMessage("Error discovering skills: " + err.Error())
+			_ = ui.DisplayError(fmt.Errorf("config reload failed, keeping the current configuration: %w", err))
 			return
 		}
-
-		_ = ui.DisplaySystemMessage(fmt.Sprintf("Discovered %d skills:", result.TotalCount))
-		for _, skill := range result.Skills {
-			status := "inactive"
-			if skill.IsActive {
-				status = "active"
-			}
-			_ = ui.DisplaySystemMessage(fmt.Sprintf("  - %s (%s, %s)",
-				skill.Name, skill.SourceType, status))
-		}
-		_ = ui.DisplaySystemMessage("")
+		displayConfigReload(ui, result)
+		skills := container.SkillManager().ListSkills()
+		displayDiscoveredSkills(ui, &port.SkillDiscoveryResult{Skills: skills, TotalCount: len(skills)})
 	})
 	reloadHandler.Start()
 	return reloadHandler
 }
 
+// displayConfigReload prints the settings a config reload applied and warns
+// about changed settings that need a restart.
+func displayConfigReload(ui port.UserInterface, result port.ConfigReloadResult) {
+	if len(result.Applied) == 0 {
+		_ = ui.DisplaySystemMessage("Configuration reloaded, no reloadable settings changed")
+	} else {
+		_ = ui.DisplaySystemMessage("Configuration reloaded: " + strings.Join(result.Applied, ", "))
+	}
+	if len(result.Ignored) > 0 {
+		_ = ui.DisplaySystemMessage("Warning: restart to apply changes to " + strings.Join(result.Ignored, ", "))
+	}
+}
+
+// skillWatchInterval is how often the serve command polls skill directories for changes.
+const skillWatchInterval = 2 * time.Second
+
+// startSkillWatcher reloads skills automatically when SKILL.md files change on disk.
+// It runs until ctx is cancelled. Skill managers that cannot watch are left to SIGHUP reloads.
+func startSkillWatcher(ctx context.Context, container *config.Container) {
+	watcher, ok := container.SkillManager().(*skill.LocalSkillManager)
+	if !ok {
+		return
+	}
+
+	ui := container.UIAdapter()
+	watcher.Watch(ctx, skillWatchInterval, func(result *port.SkillDiscoveryResult) {
+		_ = ui.DisplaySystemMessage("")
+		_ = ui.DisplaySystemMessage("Skill files changed - reloaded skills")
+		displayDiscoveredSkills(ui, result)
+	})
+}
+
+// displayDiscoveredSkills prints a skill discovery result with each skill's source and state.
+func displayDiscoveredSkills(ui port.UserInterface, result *port.SkillDiscoveryResult) {
+	_ = ui.DisplaySystemMessage(fmt.Sprintf("Discovered %d skills:", result.TotalCount))
+	for _, s := range result.Skills {
+		status := "inactive"
+		if s.IsActive {
+			status = "active"
+		}
+		_ = ui.DisplaySystemMessage(fmt.Sprintf("  - %s (%s, %s)",
+			s.Name, s.SourceType, status))
+	}
+	_ = ui.DisplaySystemMessage("")
+}
+
 // runServe executes the serve command.
 func runServe(cmd *cobra.Command, _ []string) error {
 	ctx := cmd.Context()
+	if validating, err := printConfigIfValidating(cmd); validating {
+		return err
+	}
+	if dumping, err := dumpLastRequestIfAsked(cmd); dumping {
+		return err
+	}
 	cfg := GetConfig(cmd)
 
+	// Print the rendered investigation prompt instead of serving
+	if alertPath, _ := cmd.Flags().GetString("render-prompt"); alertPath != "" {
+		container, err := config.NewContainer(cfg)
+		if err != nil {
+			return err
+		}
+		return renderPrompt(ctx, container, alertPath, cmd.OutOrStdout())
+	}
+
 	// Get command flags
 	addr, _ := cmd.Flags().GetString("addr")
-	configPath, _ := cmd.Flags().GetString("config")
+	configPath, _ := cmd.Flags().GetString("alert-sources")
 
 	// Load alert sources config
 	webhookCfg, err := config.LoadAlertSourcesConfigWithDefaults(configPath)
@@ -138,6 +334,7 @@ func runServe(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
+	defer shutdownContainer(container)
 
 	ui := container.UIAdapter()
 
@@ -153,6 +350,10 @@ func runServe(cmd *cobra.Command, _ []string) error {
 		AutoInvestigateCritical: true,
 		AutoInvestigateWarning:  false,
 	})
+	alertHandler.SetLogger(container.Logger())
+	alertHandler.SetSuppressionStore(container.AlertSuppressions())
+	alertHandler.SetCircuitBreaker(container.AlertCircuitBreaker())
+	alertHandler.SetAlertEnrichers(container.AlertEnrichers()...)
 
 	// Create webhook adapter with configured address
 	webhookAdapter := webhook.NewHTTPAdapter(sourceManager, webhook.HTTPAdapterConfig{
@@ -160,31 +361,74 @@ func runServe(cmd *cobra.Command, _ []string) error {
 		ReadTimeout:     webhook.DefaultConfig().ReadTimeout,
 		WriteTimeout:    webhook.DefaultConfig().WriteTimeout,
 		ShutdownTimeout: webhook.DefaultConfig().ShutdownTimeout,
+		DrainTimeout:    cfg.DrainTimeout,
 	})
 	webhookAdapter.SetAsyncAlertHandler(alertHandler.HandleEntityAlertAsync, alertHandler.RunEntityAlertInvestigation)
-
-	// Set up SIGHUP handler for skill hot-reload
-	reloadHandler := setupSkillReloadHandler(container)
+	webhookAdapter.SetDrainHandler(alertHandler.Shutdown)
+	webhookAdapter.SetReadinessChecker(container.HealthChecker())
+	webhookAdapter.SetWatchdog(health.NewWatchdog(health.DefaultStaleAfter))
+	webhookAdapter.SetEventBroker(container.InvestigationEvents())
+	webhookAdapter.SetAlertSuppressor(alertHandler)
+	webhookAdapter.SetStatusReporter(alertHandler)
+	webhookAdapter.SetInvestigationCanceller(alertHandler)
+	webhookAdapter.SetBackfiller(alertHandler)
+
+	// Reload the configuration on SIGHUP and POST /-/reload
+	configFile, _ := cmd.Flags().GetString("config")
+	reloader := &configReloader{path: configFile, container: container}
+	webhookAdapter.SetConfigReloader(reloader)
+	reloadHandler := setupConfigReloadHandler(container, reloader)
 	defer reloadHandler.Stop()
 
+	// Watch skill directories so edits are picked up without a restart
+	startSkillWatcher(ctx, container)
+
+	// Serve Prometheus metrics if enabled
+	startMetricsServer(ctx, container)
+
+	// Publish digests of investigation outcomes if scheduled
+	startDigestScheduler(ctx, container)
+
+	// Tell whether a newer release is out, if enabled
+	startUpdateCheck(ctx, container)
+
 	// Print startup info
+	build := version.Get()
+	container.Logger().Info("Starting webhook server",
+		"addr", addr, "version", build.Version, "commit", build.Commit, "built", build.Date)
 	_ = ui.DisplaySystemMessage("")
 	_ = ui.DisplaySystemMessage("Starting webhook server on " + addr)
+	_ = ui.DisplaySystemMessage("Version:      " + build.String())
 	_ = ui.DisplaySystemMessage("Health check: GET http://localhost" + addr + "/health")
 	_ = ui.DisplaySystemMessage("Ready check:  GET http://localhost" + addr + "/ready")
+	_ = ui.DisplaySystemMessage("Probes:       GET http://localhost" + addr + "/healthz, /readyz")
+	_ = ui.DisplaySystemMessage("Events:       GET http://localhost" + addr + "/investigations/{id}/events")
+	_ = ui.DisplaySystemMessage("Suppress:     POST/DELETE http://localhost" + addr + "/alerts/{fingerprint}/suppress")
+	_ = ui.DisplaySystemMessage("Status:       GET http://localhost" + addr + "/status")
+	_ = ui.DisplaySystemMessage("Reload:       POST http://localhost" + addr + "/-/reload (or send SIGHUP)")
 	for _, srcCfg := range webhookCfg.Sources {
 		_ = ui.DisplaySystemMessage("Webhook:      POST http://localhost" + addr + srcCfg.WebhookPath)
 	}
+	if metricsAddr := cfg.MetricsListenAddr; metricsAddr != "" {
+		_ = ui.DisplaySystemMessage("Metrics:      GET http://localhost" + metricsAddr + "/metrics")
+	}
+	if cfg.TracingEndpoint != "" {
+		_ = ui.DisplaySystemMessage("Traces:       OTLP " + cfg.TracingEndpoint)
+	}
+	if scheduler := container.DigestScheduler(); scheduler != nil {
+		_ = ui.DisplaySystemMessage("Digests:      " + scheduler.Schedule().String())
+	}
 	_ = ui.DisplaySystemMessage("")
 	_ = ui.DisplaySystemMessage("Press Ctrl+C to stop")
-	_ = ui.DisplaySystemMessage("Send SIGHUP to reload skills")
+	_ = ui.DisplaySystemMessage("Skills reload automatically on change")
 
 	// Get interrupt handler for graceful shutdown
 	handler := InterruptHandlerFromContext(ctx)
 	if handler != nil {
 		go func() {
 			<-handler.FirstPress()
-			_ = ui.DisplaySystemMessage("\nInitiating graceful shutdown...")
+			_ = ui.DisplaySystemMessage(fmt.Sprintf(
+				"\nInitiating graceful shutdown, draining investigations for up to %v...", cfg.DrainTimeout))
 		}()
 	}
 
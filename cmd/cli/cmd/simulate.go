@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// simulateCmd runs an investigation against recorded tool outputs.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Investigate an alert against recorded tool outputs",
+	Long: `Investigate an alert with the full AI loop, answering every tool call from
+recorded fixtures instead of running it, so prompt changes can be tried
+without touching production hosts. A tool call without a fixture fails with a
+"no fixture recorded" error result, which the AI sees like any tool error.
+
+With --record the investigation runs live and every tool result is saved to
+the fixtures directory, ready to be replayed. Simulated investigations are not
+stored and send no notifications; recorded ones are stored as usual.
+
+Fixtures are JSON files named <tool>-<hash>.json, keyed by the tool name and
+its input with object keys sorted, so they can be edited or written by hand.
+
+Example:
+  code-editing-agent simulate --alert alert.json --fixtures fixtures/disk-full --record
+  code-editing-agent simulate --alert alert.json --fixtures fixtures/disk-full`,
+	Args: cobra.NoArgs,
+	RunE: runSimulate,
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().String("alert", "", "Alert JSON file to investigate (required)")
+	simulateCmd.Flags().String("fixtures", "", "Directory of tool fixtures to replay or record (required)")
+	simulateCmd.Flags().Bool("record", false, "Run tools live and record their results as fixtures")
+	simulateCmd.Flags().Bool("json", false, "Print JSON instead of text")
+	_ = simulateCmd.MarkFlagRequired("alert")
+	_ = simulateCmd.MarkFlagRequired("fixtures")
+}
+
+// alertSimulator investigates alerts with a replaceable tool executor.
+type alertSimulator interface {
+	SetToolExecutor(te port.ToolExecutor)
+	HandleAlert(ctx context.Context, alert *usecase.AlertForInvestigation) (*usecase.InvestigationResult, error)
+}
+
+// simulateOptions holds the simulate command's flags.
+type simulateOptions struct {
+	alertPath   string
+	fixturesDir string
+	record      bool
+	asJSON      bool
+}
+
+func runSimulate(cmd *cobra.Command, _ []string) error {
+	var opts simulateOptions
+	flags := cmd.Flags()
+	opts.alertPath, _ = flags.GetString("alert")
+	opts.fixturesDir, _ = flags.GetString("fixtures")
+	opts.record, _ = flags.GetBool("record")
+	opts.asJSON, _ = flags.GetBool("json")
+
+	container, err := config.NewContainer(GetConfig(cmd))
+	if err != nil {
+		return err
+	}
+	defer shutdownContainer(container)
+
+	investigations := container.InvestigationUseCase()
+	if !opts.record {
+		// A replay is not a real investigation: keep it out of the history and
+		// away from on-call channels
+		investigations.SetInvestigationStore(nil)
+		investigations.SetResultNotifier(nil)
+	}
+	return simulateInvestigation(cmd.Context(), investigations, container.ToolExecutor(), opts, cmd.OutOrStdout())
+}
+
+// simulateInvestigation investigates the alert in opts.alertPath with its
+// tool calls replayed from, or recorded to, opts.fixturesDir, and writes the
+// result.
+func simulateInvestigation(
+	ctx context.Context,
+	simulator alertSimulator,
+	baseExecutor port.ToolExecutor,
+	opts simulateOptions,
+	w io.Writer,
+) error {
+	alert, err := loadAlertFile(opts.alertPath, "simulated-alert")
+	if err != nil {
+		return err
+	}
+
+	mode, modeName, verb := tool.FixtureReplay, "replay", "Simulated"
+	if opts.record {
+		mode, modeName, verb = tool.FixtureRecord, "record", "Recorded"
+	}
+	executor := tool.NewFixtureExecutor(baseExecutor, opts.fixturesDir, mode)
+	simulator.SetToolExecutor(executor)
+
+	result, err := simulator.HandleAlert(ctx, usecase.NewAlertForInvestigationFromEntity(alert))
+	if err != nil {
+		return fmt.Errorf("simulated investigation failed: %w", err)
+	}
+	if result == nil {
+		return errors.New("simulated investigation returned no result")
+	}
+
+	if opts.asJSON {
+		return writeJSON(w, struct {
+			Mode            string              `json:"mode"`
+			Fixtures        string              `json:"fixtures"`
+			MissingFixtures int                 `json:"missing_fixtures"`
+			Investigation   investigationOutput `json:"investigation"`
+		}{
+			Mode:            modeName,
+			Fixtures:        opts.fixturesDir,
+			MissingFixtures: executor.Misses(),
+			Investigation:   newResultOutput(result),
+		})
+	}
+
+	fmt.Fprintf(w, "%s investigation %s: %s\n", verb, result.InvestigationID, result.Status)
+	writeResultDetails(w, result)
+	if misses := executor.Misses(); misses > 0 {
+		fmt.Fprintf(w, "%d tool call(s) had no fixture; record them with --record or add them to %s\n",
+			misses, opts.fixturesDir)
+	}
+	return nil
+}
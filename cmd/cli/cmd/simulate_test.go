@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// diskCheckSimulator stands in for the investigation use case: it runs one
+// df call through whatever executor it was given and reports its output as
+// the finding.
+type diskCheckSimulator struct {
+	executor port.ToolExecutor
+}
+
+func (s *diskCheckSimulator) SetToolExecutor(te port.ToolExecutor) { s.executor = te }
+
+func (s *diskCheckSimulator) HandleAlert(
+	ctx context.Context,
+	alert *usecase.AlertForInvestigation,
+) (*usecase.InvestigationResult, error) {
+	output, err := s.executor.ExecuteTool(ctx, "bash", map[string]interface{}{"command": "df -h /var"})
+	finding := output
+	if err != nil {
+		finding = "error: " + err.Error()
+	}
+	return &usecase.InvestigationResult{
+		InvestigationID: "inv-sim",
+		AlertID:         alert.ID(),
+		Status:          "completed",
+		Findings:        []string{finding},
+	}, nil
+}
+
+// liveExecutor is a tool executor whose bash tool reports a full disk.
+type liveExecutor struct {
+	calls int
+}
+
+func (e *liveExecutor) RegisterTool(entity.Tool) error              { return nil }
+func (e *liveExecutor) UnregisterTool(string) error                 { return nil }
+func (e *liveExecutor) ListTools() ([]entity.Tool, error)           { return nil, nil }
+func (e *liveExecutor) GetTool(string) (entity.Tool, bool)          { return entity.Tool{}, false }
+func (e *liveExecutor) ValidateToolInput(string, interface{}) error { return nil }
+func (e *liveExecutor) ExecuteTool(context.Context, string, interface{}) (string, error) {
+	e.calls++
+	return "/dev/sda1  98% /var", nil
+}
+
+func TestSimulateInvestigation_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	alertPath := filepath.Join(dir, "alert.json")
+	require.NoError(t, os.WriteFile(alertPath, []byte(`{"title": "Disk Full", "severity": "critical"}`), 0o600))
+	fixtures := filepath.Join(dir, "fixtures")
+	live := &liveExecutor{}
+	ctx := context.Background()
+
+	var recorded bytes.Buffer
+	opts := simulateOptions{alertPath: alertPath, fixturesDir: fixtures, record: true}
+	require.NoError(t, simulateInvestigation(ctx, &diskCheckSimulator{}, live, opts, &recorded))
+	assert.Contains(t, recorded.String(), "Recorded investigation inv-sim: completed")
+	assert.Contains(t, recorded.String(), "- /dev/sda1  98% /var")
+	assert.Equal(t, 1, live.calls)
+
+	var replayed bytes.Buffer
+	opts.record, opts.asJSON = false, true
+	require.NoError(t, simulateInvestigation(ctx, &diskCheckSimulator{}, live, opts, &replayed))
+	assert.Equal(t, 1, live.calls, "replay must not run tools")
+
+	var out struct {
+		Mode            string `json:"mode"`
+		MissingFixtures int    `json:"missing_fixtures"`
+		Investigation   struct {
+			AlertID  string   `json:"alert_id"`
+			Findings []string `json:"findings"`
+		} `json:"investigation"`
+	}
+	require.NoError(t, json.Unmarshal(replayed.Bytes(), &out))
+	assert.Equal(t, "replay", out.Mode)
+	assert.Equal(t, 0, out.MissingFixtures)
+	assert.Equal(t, "simulated-alert", out.Investigation.AlertID)
+	assert.Equal(t, []string{"/dev/sda1  98% /var"}, out.Investigation.Findings)
+}
+
+func TestSimulateInvestigation_ReportsMissingFixtures(t *testing.T) {
+	dir := t.TempDir()
+	alertPath := filepath.Join(dir, "alert.json")
+	require.NoError(t, os.WriteFile(alertPath, []byte(`{"title": "Disk Full"}`), 0o600))
+
+	var out bytes.Buffer
+	opts := simulateOptions{alertPath: alertPath, fixturesDir: filepath.Join(dir, "empty")}
+	require.NoError(t, simulateInvestigation(context.Background(), &diskCheckSimulator{}, &liveExecutor{}, opts, &out))
+	assert.Contains(t, out.String(), "error: no fixture recorded for bash")
+	assert.Contains(t, out.String(), "1 tool call(s) had no fixture")
+}
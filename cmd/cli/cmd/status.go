@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// daemonRequestTimeout bounds requests to the serve daemon.
+const daemonRequestTimeout = 10 * time.Second
+
+// statusCmd shows what a running serve daemon is doing.
+//
+//nolint:gochecknoglobals // cobra command pattern requires global variable
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the running and queued investigations of a serve daemon",
+	Long: `Show what a running "serve" daemon is doing: the investigations it is
+running, with how long they have run, the tool calls finished so far, and the
+tool running now; the investigations waiting to run, most urgent first; the
+circuit of each alert source; and how much of the concurrency limit is in use.
+
+The status comes from the daemon's GET /status endpoint.
+
+Example:
+  code-editing-agent status
+  code-editing-agent status --addr http://alerts.internal:8080 --json`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().String("addr", "http://localhost:8080", "Address of the serve daemon (a bare :port means localhost)")
+	statusCmd.Flags().Bool("json", false, "Print JSON instead of tables")
+}
+
+func runStatus(cmd *cobra.Command, _ []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), daemonRequestTimeout)
+	defer cancel()
+	status, err := fetchStatus(ctx, http.DefaultClient, addr)
+	if err != nil {
+		return err
+	}
+	return writeStatus(cmd.OutOrStdout(), status, asJSON)
+}
+
+// daemonURL returns the endpoint at path of the daemon at addr, which may be a
+// URL, host:port, or a bare :port.
+func daemonURL(addr, path string) string {
+	addr = strings.TrimSuffix(addr, "/")
+	switch {
+	case strings.HasPrefix(addr, ":"):
+		addr = "http://localhost" + addr
+	case !strings.Contains(addr, "://"):
+		addr = "http://" + addr
+	}
+	return addr + path
+}
+
+// fetchStatus requests the status of the daemon at addr.
+func fetchStatus(ctx context.Context, client *http.Client, addr string) (port.DaemonStatus, error) {
+	var status port.DaemonStatus
+	url := daemonURL(addr, "/status")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return status, fmt.Errorf("invalid daemon address %q: %w", addr, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return status, fmt.Errorf("failed to reach the daemon at %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("daemon status request failed: %s", daemonError(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return status, fmt.Errorf("failed to decode daemon status: %w", err)
+	}
+	return status, nil
+}
+
+// daemonError returns the error message of a failed daemon response, or its
+// status without one.
+func daemonError(resp *http.Response) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if body.Error == "" {
+		return resp.Status
+	}
+	return body.Error
+}
+
+// writeStatus writes the daemon status as tables, or as JSON.
+func writeStatus(w io.Writer, status port.DaemonStatus, asJSON bool) error {
+	if asJSON {
+		return writeJSON(w, status)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if degraded := status.Degraded; degraded != nil {
+		fmt.Fprintf(tw, "Degraded since %s: %s; %d investigations held\n\n",
+			degraded.Since.Local().Format(time.RFC3339), degraded.Reason, degraded.Held)
+	}
+
+	fmt.Fprintf(tw, "Active investigations (%d):\n", len(status.Active))
+	if len(status.Active) > 0 {
+		fmt.Fprintln(tw, "ID\tALERT\tSEVERITY\tELAPSED\tACTIONS\tCURRENT TOOL")
+		for _, inv := range status.Active {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", inv.InvestigationID, statusAlertLabel(inv.AlertID, inv.AlertTitle),
+				inv.Severity, inv.Elapsed.Round(time.Second), inv.Actions, orDash(inv.CurrentTool))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nQueued alerts (%d):\n", len(status.Queued))
+	if len(status.Queued) > 0 {
+		fmt.Fprintln(tw, "ID\tALERT\tPRIORITY\tWAITING")
+		for _, q := range status.Queued {
+			priority := q.Priority
+			if q.Historical {
+				priority += " (historical)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", q.InvestigationID, statusAlertLabel(q.AlertID, q.AlertTitle),
+				priority, q.Waiting.Round(time.Second))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nCircuits (%d):\n", len(status.Circuits))
+	if len(status.Circuits) > 0 {
+		fmt.Fprintln(tw, "SOURCE\tSTATE\tRECENT\tPROBE AT")
+		for _, c := range status.Circuits {
+			probeAt := "-"
+			if !c.ProbeAt.IsZero() {
+				probeAt = c.ProbeAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", c.Source, c.State, c.Recent, probeAt)
+		}
+	}
+
+	if len(status.Sources) > 0 {
+		fmt.Fprintf(tw, "\nSources (%d):\n", len(status.Sources))
+		fmt.Fprintln(tw, "SOURCE\tRUNNING\tWAITING\tLIMIT\tIN USE")
+		for _, source := range status.Sources {
+			limit := "-"
+			if source.Limit > 0 {
+				limit = strconv.Itoa(source.Limit)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%.0f%%\n", source.Source, source.Running, source.Waiting, limit,
+				source.Utilization*100)
+		}
+	}
+
+	workers := status.Workers
+	if workers.Capacity > 0 {
+		fmt.Fprintf(tw, "\nWorkers: %d running, %d queued of %d (%.0f%% in use)\n",
+			workers.Running, workers.Queued, workers.Capacity, workers.Utilization*100)
+	} else {
+		fmt.Fprintf(tw, "\nWorkers: %d running, %d queued (no limit)\n", workers.Running, workers.Queued)
+	}
+
+	return tw.Flush()
+}
+
+// statusAlertLabel returns an alert's title with its ID, or the ID alone.
+func statusAlertLabel(id, title string) string {
+	if title == "" {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", title, id)
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
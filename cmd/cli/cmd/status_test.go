@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatusFixture() port.DaemonStatus {
+	return port.DaemonStatus{
+		GeneratedAt: fixtureStart,
+		Active: []port.ActiveInvestigationStatus{
+			{
+				InvestigationID: "inv-disk", AlertID: "alert-disk", AlertTitle: "Disk Full", Severity: "critical",
+				StartedAt: fixtureStart.Add(-95 * time.Second), Elapsed: 95 * time.Second, Actions: 3, CurrentTool: "bash",
+			},
+			{
+				InvestigationID: "inv-mem", AlertID: "alert-mem", Severity: "critical",
+				StartedAt: fixtureStart.Add(-10 * time.Second), Elapsed: 10 * time.Second,
+			},
+		},
+		Queued: []port.QueuedAlertStatus{
+			{InvestigationID: "inv-cpu", AlertID: "alert-cpu", AlertTitle: "High CPU", Priority: "warning", Waiting: 4 * time.Second},
+		},
+		Circuits: []port.CircuitStatus{
+			{Source: "prometheus", State: "closed", Recent: 3},
+		},
+		Workers: port.WorkerPoolStatus{Capacity: 4, Running: 2, Queued: 1, Utilization: 0.75},
+		Sources: []port.SourceSlotStatus{
+			{Source: "payments", Running: 2, Waiting: 1, Limit: 2, Utilization: 0.5},
+			{Source: "search", Limit: 0},
+		},
+	}
+}
+
+func TestDaemonURL(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"http://localhost:8080", "http://localhost:8080/status"},
+		{"https://alerts.internal/", "https://alerts.internal/status"},
+		{":9090", "http://localhost:9090/status"},
+		{"alerts.internal:8080", "http://alerts.internal:8080/status"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, daemonURL(tt.addr, "/status"), tt.addr)
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	want := newStatusFixture()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/status", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(want))
+	}))
+	defer server.Close()
+
+	got, err := fetchStatus(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, got.Active, 2)
+	assert.Equal(t, want.Active[0].CurrentTool, got.Active[0].CurrentTool)
+	assert.Equal(t, want.Active[0].Elapsed, got.Active[0].Elapsed)
+	assert.Equal(t, want.Queued, got.Queued)
+	assert.Equal(t, want.Circuits, got.Circuits)
+	assert.Equal(t, want.Workers, got.Workers)
+	assert.Equal(t, want.Sources, got.Sources)
+}
+
+func TestFetchStatus_ReportsDaemonError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = w.Write([]byte(`{"error":"status not configured"}`))
+	}))
+	defer server.Close()
+
+	_, err := fetchStatus(context.Background(), server.Client(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status not configured")
+}
+
+func TestWriteStatus(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeStatus(&buf, newStatusFixture(), false))
+	out := buf.String()
+
+	assert.Contains(t, out, "Active investigations (2):")
+	assert.Regexp(t, `inv-disk\s+Disk Full \(alert-disk\)\s+critical\s+1m35s\s+3\s+bash`, out)
+	assert.Regexp(t, `inv-mem\s+alert-mem\s+critical\s+10s\s+0\s+-`, out)
+	assert.Contains(t, out, "Queued alerts (1):")
+	assert.Regexp(t, `inv-cpu\s+High CPU \(alert-cpu\)\s+warning\s+4s`, out)
+	assert.Regexp(t, `prometheus\s+closed\s+3\s+-`, out)
+	assert.Contains(t, out, "Sources (2):")
+	assert.Regexp(t, `payments\s+2\s+1\s+2\s+50%`, out)
+	assert.Regexp(t, `search\s+0\s+0\s+-\s+0%`, out)
+	assert.Contains(t, out, "Workers: 2 running, 1 queued of 4 (75% in use)")
+	assert.Less(t, strings.Index(out, "inv-disk"), strings.Index(out, "inv-mem"))
+}
+
+func TestWriteStatus_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeStatus(&buf, port.DaemonStatus{}, false))
+
+	assert.Equal(t, "Active investigations (0):\n\nQueued alerts (0):\n\nCircuits (0):\n\n"+
+		"Workers: 0 running, 0 queued (no limit)\n", buf.String())
+}
+
+func TestWriteStatus_Degraded(t *testing.T) {
+	status := newStatusFixture()
+	status.Degraded = &port.DegradedStatus{
+		Since:  time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC),
+		Reason: "AI provider health check failed",
+		Held:   2,
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeStatus(&buf, status, false))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "Degraded since "), out)
+	assert.Contains(t, out, ": AI provider health check failed; 2 investigations held\n")
+}
+
+func TestWriteStatus_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeStatus(&buf, newStatusFixture(), true))
+
+	var got port.DaemonStatus
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, newStatusFixture().Workers, got.Workers)
+	assert.Equal(t, "bash", got.Active[0].CurrentTool)
+}
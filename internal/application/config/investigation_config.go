@@ -32,17 +32,18 @@ var (
 // and when they should escalate to human operators. Use DefaultInvestigationConfig
 // for sensible production defaults, or NewInvestigationConfig for a blank config.
 type InvestigationConfig struct {
-	maxActions                   int           // Maximum tool executions per investigation
-	maxDuration                  time.Duration // Maximum wall-clock time for an investigation
-	maxConcurrent                int           // Maximum simultaneous investigations
-	allowedTools                 []string      // Tools the investigation may use
-	blockedCommands              []string      // Command patterns that are never allowed
-	allowedDirectories           []string      // Directories the investigation may access (nil = all)
-	requireHumanApprovalPatterns []string      // Patterns requiring human confirmation
-	confirmBeforeRestart         bool          // Require confirmation for restart operations
-	confirmBeforeDelete          bool          // Require confirmation for delete operations
-	escalateOnConfidenceBelow    float64       // Escalate if confidence drops below this [0.0-1.0]
-	escalateOnMultipleErrors     int           // Escalate after this many consecutive errors
+	maxActions                   int                      // Maximum tool executions per investigation
+	maxDuration                  time.Duration            // Maximum wall-clock time for an investigation
+	maxConcurrent                int                      // Maximum simultaneous investigations
+	allowedTools                 []string                 // Tools the investigation may use
+	blockedCommands              []string                 // Command patterns that are never allowed
+	commandAllowlist             *safety.CommandAllowlist // Patterns every command must match (nil = any)
+	allowedDirectories           []string                 // Directories the investigation may access (nil = all)
+	requireHumanApprovalPatterns []string                 // Patterns requiring human confirmation
+	confirmBeforeRestart         bool                     // Require confirmation for restart operations
+	confirmBeforeDelete          bool                     // Require confirmation for delete operations
+	escalateOnConfidenceBelow    float64                  // Escalate if confidence drops below this [0.0-1.0]
+	escalateOnMultipleErrors     int                      // Escalate after this many consecutive errors
 }
 
 // NewInvestigationConfig creates a new empty InvestigationConfig.
@@ -57,7 +58,7 @@ func NewInvestigationConfig() *InvestigationConfig {
 //   - maxActions: 20 (prevents runaway investigations)
 //   - maxDuration: 15 minutes (reasonable timeout)
 //   - maxConcurrent: 5 (balances throughput and resource usage)
-//   - allowedTools: bash, read_file, list_files, batch_tool (safe investigation tools)
+//   - allowedTools: bash, read_file, list_files, fetch_url, batch_tool (safe investigation tools)
 //   - blockedCommands: common destructive patterns from shared safety package
 //   - escalateOnConfidenceBelow: 0.5 (escalate when uncertain)
 //   - escalateOnMultipleErrors: 3 (escalate after repeated failures)
@@ -66,7 +67,7 @@ func DefaultInvestigationConfig() *InvestigationConfig {
 		maxActions:                   20,
 		maxDuration:                  15 * time.Minute,
 		maxConcurrent:                5,
-		allowedTools:                 []string{"bash", "read_file", "list_files", "batch_tool"},
+		allowedTools:                 []string{"bash", "read_file", "list_files", "fetch_url", "batch_tool"},
 		blockedCommands:              safety.DefaultBlockedCommandStrings(),
 		allowedDirectories:           nil,
 		requireHumanApprovalPatterns: []string{"restart", "kill", "delete"},
@@ -106,6 +107,13 @@ func (c *InvestigationConfig) BlockedCommands() []string {
 	return c.blockedCommands
 }
 
+// AllowedCommandPatterns returns the regular expressions every command must
+// match, segment by segment. A nil or empty list means commands are only
+// checked against the blocked patterns.
+func (c *InvestigationConfig) AllowedCommandPatterns() []string {
+	return c.commandAllowlist.Patterns()
+}
+
 // AllowedDirectories returns the list of directory prefixes that investigations may access.
 // A nil or empty list means all directories are allowed.
 func (c *InvestigationConfig) AllowedDirectories() []string {
@@ -187,6 +195,19 @@ func (c *InvestigationConfig) SetBlockedCommands(commands []string) error {
 	return nil
 }
 
+// SetAllowedCommandPatterns switches command checks to allowlist mode: every
+// segment of a command must match one of patterns, regular expressions
+// anchored at the segment start. Pass nil or an empty slice to turn it off.
+// Returns an error if a pattern does not compile.
+func (c *InvestigationConfig) SetAllowedCommandPatterns(patterns []string) error {
+	allowlist, err := safety.NewCommandAllowlist(patterns)
+	if err != nil {
+		return err
+	}
+	c.commandAllowlist = allowlist
+	return nil
+}
+
 // SetAllowedDirectories sets the list of directory prefixes that may be accessed.
 // Pass nil or an empty slice to allow access to all directories.
 func (c *InvestigationConfig) SetAllowedDirectories(dirs []string) {
@@ -254,7 +275,15 @@ func (c *InvestigationConfig) IsCommandBlocked(cmd string) bool {
 	return isDangerous
 }
 
-// IsDirectoryAllowed checks if a directory is in the allowed list.
+// CheckCommandAllowlisted returns an error wrapping safety.ErrCommandNotAllowed
+// if allowlist mode is on and cmd does not fit the allowed command patterns.
+func (c *InvestigationConfig) CheckCommandAllowlisted(cmd string) error {
+	return c.commandAllowlist.Check(cmd)
+}
+
+// IsDirectoryAllowed checks if a directory is in the allowed list or inside one
+// of its entries. Paths are compared after cleaning, element by element, so
+// "/tmp" does not allow "/tmpfiles" and "/var/log/../../etc" is not allowed.
 // An empty or nil allowedDirectories list means all directories are allowed.
 func (c *InvestigationConfig) IsDirectoryAllowed(dir string) bool {
 	// Empty list means all directories are allowed
@@ -262,7 +291,7 @@ func (c *InvestigationConfig) IsDirectoryAllowed(dir string) bool {
 		return true
 	}
 	for _, allowed := range c.allowedDirectories {
-		if strings.HasPrefix(dir, allowed) {
+		if safety.IsWithinDir(allowed, dir) {
 			return true
 		}
 	}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -69,10 +70,10 @@ func TestDefaultInvestigationConfig_HasDefaultAllowedTools(t *testing.T) {
 		t.Skip("DefaultInvestigationConfig() returned nil")
 	}
 	tools := cfg.AllowedTools()
-	if len(tools) != 4 {
-		t.Errorf("AllowedTools() should have 4 default tools, got %d", len(tools))
+	if len(tools) != 5 {
+		t.Errorf("AllowedTools() should have 5 default tools, got %d", len(tools))
 	}
-	// Should include bash, read_file, list_files, and batch_tool for investigation
+	// Should include bash, read_file, list_files, fetch_url, and batch_tool for investigation
 	hasBash := false
 	hasReadFile := false
 	hasBatchTool := false
@@ -454,6 +455,41 @@ func TestInvestigationConfig_IsCommandBlocked_SafeCommand(t *testing.T) {
 	}
 }
 
+func TestInvestigationConfig_SetAllowedCommandPatterns(t *testing.T) {
+	cfg := NewInvestigationConfig()
+	if err := cfg.CheckCommandAllowlisted("anything goes"); err != nil {
+		t.Errorf("CheckCommandAllowlisted() without patterns = %v, want nil", err)
+	}
+
+	patterns := []string{`^(ps|df)\b`}
+	if err := cfg.SetAllowedCommandPatterns(patterns); err != nil {
+		t.Fatalf("SetAllowedCommandPatterns() error = %v", err)
+	}
+	if got := cfg.AllowedCommandPatterns(); len(got) != 1 || got[0] != patterns[0] {
+		t.Errorf("AllowedCommandPatterns() = %v, want %v", got, patterns)
+	}
+	if err := cfg.CheckCommandAllowlisted("ps aux"); err != nil {
+		t.Errorf("CheckCommandAllowlisted('ps aux') = %v, want nil", err)
+	}
+	if err := cfg.CheckCommandAllowlisted("ls"); err == nil {
+		t.Error("CheckCommandAllowlisted('ls') = nil, want an error")
+	}
+
+	if err := cfg.SetAllowedCommandPatterns([]string{"(unclosed"}); err == nil {
+		t.Error("SetAllowedCommandPatterns() with an invalid regex should fail")
+	}
+	if got := cfg.AllowedCommandPatterns(); len(got) != 1 {
+		t.Errorf("AllowedCommandPatterns() after a failed set = %v, want it unchanged", got)
+	}
+
+	if err := cfg.SetAllowedCommandPatterns(nil); err != nil {
+		t.Fatalf("SetAllowedCommandPatterns(nil) error = %v", err)
+	}
+	if err := cfg.CheckCommandAllowlisted("ls"); err != nil {
+		t.Errorf("CheckCommandAllowlisted() after clearing = %v, want nil", err)
+	}
+}
+
 func TestInvestigationConfig_IsDirectoryAllowed_InList(t *testing.T) {
 	cfg := NewInvestigationConfig()
 	if cfg == nil {
@@ -484,6 +520,28 @@ func TestInvestigationConfig_IsDirectoryAllowed_NotInList(t *testing.T) {
 	}
 }
 
+func TestInvestigationConfig_IsDirectoryAllowed_ComparesPathElements(t *testing.T) {
+	cfg := NewInvestigationConfig()
+	cfg.SetAllowedDirectories([]string{filepath.FromSlash("/var/log"), filepath.FromSlash("/tmp/")})
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{dir: filepath.FromSlash("/tmp"), want: true},
+		{dir: filepath.FromSlash("/var/log/nginx/../syslog"), want: true},
+		// A shared string prefix is not a subdirectory
+		{dir: filepath.FromSlash("/var/logs"), want: false},
+		{dir: filepath.FromSlash("/tmpfiles"), want: false},
+		{dir: filepath.FromSlash("/var/log/../../etc"), want: false},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsDirectoryAllowed(tt.dir); got != tt.want {
+			t.Errorf("IsDirectoryAllowed(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+}
+
 func TestInvestigationConfig_IsDirectoryAllowed_EmptyListAllowsAll(t *testing.T) {
 	cfg := NewInvestigationConfig()
 	if cfg == nil {
@@ -59,13 +59,15 @@ type EndChatResponse struct {
 
 // ToolExecutionResponse represents the result of executing a tool.
 type ToolExecutionResponse struct {
-	SessionID  string    `json:"session_id"`  // The conversation session ID
-	ToolName   string    `json:"tool_name"`   // Name of the tool that was executed
-	Success    bool      `json:"success"`     // Whether the tool execution succeeded
-	Result     string    `json:"result"`      // The tool's result (if successful)
-	Error      string    `json:"error"`       // Error message (if failed)
-	ExecutedAt time.Time `json:"executed_at"` // When the tool was executed
-	DurationMs int64     `json:"duration_ms"` // Execution time in milliseconds
+	SessionID  string              `json:"session_id"`           // The conversation session ID
+	ToolName   string              `json:"tool_name"`            // Name of the tool that was executed
+	Success    bool                `json:"success"`              // Whether the tool execution succeeded
+	Result     string              `json:"result"`               // The tool's result (if successful)
+	Error      string              `json:"error"`                // Error message (if failed)
+	ExecutedAt time.Time           `json:"executed_at"`          // When the tool was executed
+	DurationMs int64               `json:"duration_ms"`          // Execution time in milliseconds
+	Cached     bool                `json:"cached"`               // Whether the result came from the tool result cache
+	Provenance []entity.Provenance `json:"provenance,omitempty"` // The file lines the result quotes, if known
 }
 
 // ToolExecutionBatchResponse represents the result of executing multiple tools.
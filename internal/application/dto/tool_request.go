@@ -8,6 +8,9 @@ import "encoding/json"
 type ToolExecuteRequest struct {
 	ToolName string      `json:"tool_name"` // The name of the tool to execute
 	Input    interface{} `json:"input"`     // Tool input parameters (can be any JSON-serializable type)
+	// Confirmed marks a call the user already approved, so the tool does not
+	// ask for confirmation again.
+	Confirmed bool `json:"confirmed,omitempty"`
 }
 
 // Validate checks if the ToolExecuteRequest is valid.
@@ -7,12 +7,18 @@ import (
 	"code-editing-agent/internal/application/usecase"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
 	"code-editing-agent/internal/domain/service"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +30,25 @@ var (
 	ErrToolExecutionUseCaseRequired = errors.New("tool execution use case is required")
 )
 
+// minThinkingBudget is the smallest extended thinking budget accepted by the provider.
+const minThinkingBudget = 1024
+
+// destructiveTools are the tools that can change files. A tool batch using any
+// of them is preceded by an automatic checkpoint.
+var destructiveTools = map[string]bool{
+	"edit_file":         true,
+	"bash":              true,
+	"wait_for":          true,
+	"batch_tool":        true,
+	"task":              true,
+	"delegate":          true,
+	"delegate_parallel": true,
+}
+
+// promptLayerCustom names the layer :prompt show uses for a custom system
+// prompt that replaces the session's layers.
+const promptLayerCustom = "custom"
+
 // ChatService is the high-level orchestration service for chat operations.
 // It coordinates the various use cases (message processing, tool execution)
 // to provide a complete chat experience with tool support.
@@ -38,6 +63,16 @@ type ChatService struct {
 	aiProvider            port.AIProvider
 	toolExecutor          port.ToolExecutor
 	fileManager           port.FileManager
+	pendingImages         map[string][]entity.ImageSource // Images attached to each session's next message
+	pendingImagesMu       sync.Mutex
+	titles                *usecase.SessionTitleGenerator
+	promptLayers          *usecase.SystemPromptComposer            // Layers shared by every session; see SetPromptLayer
+	sessionPrompts        map[string]*usecase.SystemPromptComposer // Each session's layers, seeded from promptLayers
+	sessionPromptsMu      sync.Mutex
+	promptSources         []usecase.PromptLayerSource   // Layers refreshed before each message; see AddPromptLayerSource
+	sessionUIs            map[string]port.UserInterface // Output of sessions not shown on userInterface; see SetSessionUI
+	sessionUIsMu          sync.RWMutex
+	batchConfirmation     bool // Confirm a turn's bash commands together; see SetToolBatchConfirmation
 }
 
 // NewChatService creates a new ChatService with all required dependencies.
@@ -91,6 +126,9 @@ func NewChatService(
 		aiProvider:            ai,
 		toolExecutor:          toolExec,
 		fileManager:           fm,
+		titles:                usecase.NewSessionTitleGenerator(ai),
+		promptLayers:          usecase.NewSystemPromptComposer(usecase.BasePromptLayer()),
+		sessionPrompts:        make(map[string]*usecase.SystemPromptComposer),
 	}, nil
 }
 
@@ -149,6 +187,9 @@ func NewChatServiceFromDomain(
 		aiProvider:            ai,
 		toolExecutor:          toolExec,
 		fileManager:           fm,
+		titles:                usecase.NewSessionTitleGenerator(ai),
+		promptLayers:          usecase.NewSystemPromptComposer(usecase.BasePromptLayer()),
+		sessionPrompts:        make(map[string]*usecase.SystemPromptComposer),
 	}, nil
 }
 
@@ -215,12 +256,15 @@ func (cs *ChatService) SendMessage(
 		ctx = port.WithThinkingMode(ctx, thinkingInfo)
 	}
 
-	// Add user message to conversation
-	_, err := cs.conversationService.AddUserMessage(ctx, req.SessionID, req.Message)
-	if err != nil {
+	// Add user message to conversation, with any attached images
+	if err := cs.addUserMessage(ctx, req.SessionID, req.Message); err != nil {
 		return nil, fmt.Errorf("failed to add user message: %w", err)
 	}
 
+	if err := cs.applySystemPrompt(ctx, req.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to set system prompt: %w", err)
+	}
+
 	// Get conversation for state info
 	conv, err := cs.conversationService.GetConversation(req.SessionID)
 	if err != nil {
@@ -228,14 +272,14 @@ func (cs *ChatService) SendMessage(
 	}
 
 	// Begin streaming response with color setup
-	if err := cs.userInterface.BeginStreamingResponse(); err != nil {
+	if err := cs.ui(sessionID).BeginStreamingResponse(); err != nil {
 		// Log error but continue - color setup is not critical
 		fmt.Fprintf(os.Stderr, "Warning: failed to begin streaming response: %v\n", err)
 	}
 
 	// Ensure we always clean up terminal state, even on errors
 	defer func() {
-		if err := cs.userInterface.EndStreamingResponse(); err != nil {
+		if err := cs.ui(sessionID).EndStreamingResponse(); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to end streaming response: %v\n", err)
 		}
 	}()
@@ -243,50 +287,29 @@ func (cs *ChatService) SendMessage(
 	// Display [PLAN MODE] prefix if in plan mode (before streaming starts)
 	isPlanMode, _ := cs.conversationService.IsPlanMode(sessionID)
 	if isPlanMode {
-		if err := cs.userInterface.DisplayStreamingText("[PLAN MODE] "); err != nil {
+		if err := cs.ui(sessionID).DisplayStreamingText("[PLAN MODE] "); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to display plan mode prefix: %v\n", err)
 		}
 	}
 
-	// Create streaming callback that displays text as it arrives
-	textCallback := func(text string) error {
-		// Reset and set assistant color for regular text
-		return cs.userInterface.DisplayStreamingText("\x1b[0m\x1b[93m" + text)
-	}
-
-	// Create thinking callback if ShowThinking is enabled
-	// Thinking is streamed inline, so we don't redisplay after completion
-	var thinkingCallback port.ThinkingCallback
-	thinkingHeaderDisplayed := false
-	if thinkingInfo.ShowThinking {
-		thinkingCallback = func(thinking string) error {
-			// Display header once when thinking starts
-			if !thinkingHeaderDisplayed {
-				thinkingHeaderDisplayed = true
-				// Reset, show "Claude (thinking)" header in magenta, continue with thinking color
-				if err := cs.userInterface.DisplayStreamingText(
-					"\x1b[0m\x1b[95mClaude (thinking)\x1b[0m: \x1b[95m",
-				); err != nil {
-					return err
-				}
-			}
-			return cs.userInterface.DisplayStreamingText(thinking)
-		}
-	}
+	textCallback, thinkingCallback, flush := cs.streamingCallbacks(sessionID, thinkingInfo)
 
-	// Process the assistant message with streaming
+	// Process the assistant message with streaming, showing activity until the first chunk arrives
+	cs.startActivity(sessionID, "Thinking")
 	assistantMsg, toolCalls, err := cs.messageProcessUseCase.ProcessAssistantMessageStreaming(
 		ctx,
 		sessionID,
 		textCallback,
 		thinkingCallback,
 	)
+	cs.stopActivity(sessionID)
+	// Show collected thinking for responses without text, such as tool-only
+	// turns, and the reply when it is rendered whole, even after an error
+	flush()
 	if err != nil {
 		return nil, fmt.Errorf("failed to process assistant message: %w", err)
 	}
 
-	// Note: Thinking blocks are now streamed inline via thinkingCallback, so we don't redisplay them here
-
 	// Check if processing (has tools)
 	isProcessing, _ := cs.conversationService.IsProcessing(req.SessionID)
 
@@ -301,12 +324,117 @@ func (cs *ChatService) SendMessage(
 
 	// Handle tool requests if present
 	if resp.HasTools {
-		return cs.handleToolRequestCycle(ctx, resp)
+		resp, err = cs.handleToolRequestCycle(ctx, resp)
+		if err != nil {
+			return resp, err
+		}
 	}
 
+	cs.nameSession(ctx, sessionID)
 	return resp, nil
 }
 
+// nameSession titles a session once its user has taken a second turn, when
+// there is enough to say what it is about. Later turns never rename it.
+func (cs *ChatService) nameSession(ctx context.Context, sessionID string) {
+	if cs.conversationService.GetSessionTitle(sessionID) != "" {
+		return
+	}
+	conv, err := cs.conversationService.GetConversation(sessionID)
+	if err != nil || conv.UserPromptCount() < 2 {
+		return
+	}
+	title := cs.titles.Generate(ctx, conv.GetMessages())
+	if err := cs.conversationService.SetSessionTitle(ctx, sessionID, title); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save session title: %v\n", err)
+	}
+}
+
+// addUserMessage adds a user message to the conversation, followed by the
+// images attached since the last message, which are then cleared.
+func (cs *ChatService) addUserMessage(ctx context.Context, sessionID, message string) error {
+	cs.pendingImagesMu.Lock()
+	images := cs.pendingImages[sessionID]
+	cs.pendingImagesMu.Unlock()
+
+	if len(images) == 0 {
+		_, err := cs.conversationService.AddUserMessage(ctx, sessionID, message)
+		return err
+	}
+
+	blocks := []entity.ContentBlock{entity.NewTextBlock(message)}
+	for _, image := range images {
+		blocks = append(blocks, entity.NewImageBlock(image))
+	}
+	if _, err := cs.conversationService.AddUserMessageWithBlocks(ctx, sessionID, blocks); err != nil {
+		return err
+	}
+
+	cs.pendingImagesMu.Lock()
+	delete(cs.pendingImages, sessionID)
+	cs.pendingImagesMu.Unlock()
+	return nil
+}
+
+// AttachImage attaches an image file to the session's next message. The file
+// must be a PNG, JPEG, or WebP image of at most entity.MaxImageBytes, and the
+// AI provider must accept images. The image is referenced by its absolute path
+// and read again when each request is sent. It backs the :attach command.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - sessionID: The session ID
+//   - path: The image file to attach
+//
+// Returns:
+//   - error: An error if the provider is text-only or the file is not a valid image
+func (cs *ChatService) AttachImage(_ context.Context, sessionID, path string) error {
+	if _, err := cs.conversationService.GetConversation(sessionID); err != nil {
+		return err
+	}
+	if !port.SupportsImageInput(cs.aiProvider) {
+		return port.ErrImagesNotSupported
+	}
+
+	info, err := cs.fileManager.GetFileInfo(path)
+	if err != nil {
+		return fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	if info.IsDirectory {
+		return fmt.Errorf("cannot attach %s: it is a directory", path)
+	}
+	// Check the size before reading so huge files are never loaded
+	if err := entity.ValidateImageSize(info.Size); err != nil {
+		return fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	content, err := cs.fileManager.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	mediaType := http.DetectContentType([]byte(content))
+	if err := entity.ValidateImage(mediaType, int64(len(content))); err != nil {
+		return fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("cannot attach %s: %w", path, err)
+	}
+	cs.pendingImagesMu.Lock()
+	if cs.pendingImages == nil {
+		cs.pendingImages = make(map[string][]entity.ImageSource)
+	}
+	cs.pendingImages[sessionID] = append(cs.pendingImages[sessionID],
+		entity.ImageSource{MediaType: mediaType, Path: absPath})
+	count := len(cs.pendingImages[sessionID])
+	cs.pendingImagesMu.Unlock()
+
+	return cs.ui(sessionID).DisplaySystemMessage(fmt.Sprintf(
+		"Attached %s (%s, %d bytes). %d image(s) will be sent with your next message.",
+		filepath.Base(path), mediaType, len(content), count,
+	))
+}
+
 // handleToolRequestCycle manages the full cycle of tool execution and continuation.
 // It executes tools and continues the conversation until the AI has no more tool requests.
 //
@@ -325,14 +453,23 @@ func (cs *ChatService) handleToolRequestCycle(
 	sessionID := initialResp.SessionID
 
 	for currentResp.HasTools {
+		// Checkpoint before tools that can change files so :rollback can rewind
+		// the conversation to before them. Checkpoint only fails for missing or
+		// ended sessions, which the tool execution below reports.
+		if isDestructiveBatch(currentResp.ToolCalls) {
+			_, _ = cs.conversationService.Checkpoint(sessionID)
+		}
+
 		// Execute tools for current iteration
+		cs.startActivity(sessionID, toolActivityLabel(currentResp.ToolCalls))
 		batchResp, err := cs.executeToolsForSession(ctx, sessionID, currentResp.ToolCalls)
+		cs.stopActivity(sessionID)
 		if err != nil {
 			return nil, err
 		}
 
 		// Display the tool results
-		cs.displayToolResults(batchResp.Results, currentResp.ToolCalls)
+		cs.displayToolResults(sessionID, batchResp.Results, currentResp.ToolCalls)
 
 		// Add tool results to conversation so AI can see them
 		err = cs.addToolResultsToConversation(ctx, sessionID, batchResp.Results, currentResp.ToolCalls)
@@ -356,6 +493,119 @@ func (cs *ChatService) handleToolRequestCycle(
 	return currentResp, nil
 }
 
+// isDestructiveBatch reports whether any of the tool calls can change files.
+func isDestructiveBatch(toolCalls []dto.ToolCallInfo) bool {
+	for _, tc := range toolCalls {
+		if destructiveTools[tc.ToolName] {
+			return true
+		}
+	}
+	return false
+}
+
+// toolActivityLabel describes a batch of tool calls for the activity indicator.
+func toolActivityLabel(toolCalls []dto.ToolCallInfo) string {
+	if len(toolCalls) == 1 {
+		return "Running " + toolCalls[0].ToolName
+	}
+	return fmt.Sprintf("Running %d tools", len(toolCalls))
+}
+
+// streamingCallbacks returns the callbacks for a streamed assistant response.
+//
+// With ShowThinking, thinking is streamed inline under a "Claude (thinking)" header.
+// Otherwise thinking is collected and passed to DisplayThinking (which the CLI
+// collapses to a one-line summary) just before the response text starts.
+//
+// When the user interface renders replies (port.ReplyRenderer), the response
+// text is collected instead of streamed, since rendering needs the whole reply.
+// The returned flush function displays thinking that was not followed by any
+// text, and the collected reply.
+func (cs *ChatService) streamingCallbacks(
+	sessionID string,
+	thinkingInfo port.ThinkingModeInfo,
+) (port.StreamCallback, port.ThinkingCallback, func()) {
+	var thinking strings.Builder
+	flushThinking := func() {
+		if thinking.Len() == 0 {
+			return
+		}
+		content := thinking.String()
+		thinking.Reset()
+		if err := cs.ui(sessionID).DisplayThinking(content); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to display thinking: %v\n", err)
+		}
+	}
+
+	var reply strings.Builder
+	renderer, renders := cs.ui(sessionID).(port.ReplyRenderer)
+	renders = renders && renderer.RendersReplies()
+	flush := func() {
+		flushThinking()
+		if reply.Len() == 0 {
+			return
+		}
+		text := reply.String()
+		reply.Reset()
+		if err := renderer.DisplayReply(text); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to display reply: %v\n", err)
+		}
+	}
+
+	// Create streaming callback that displays text as it arrives
+	textCallback := func(text string) error {
+		flushThinking()
+		if renders {
+			reply.WriteString(text)
+			return nil
+		}
+		// Reset and set assistant color for regular text
+		return cs.ui(sessionID).DisplayStreamingText("\x1b[0m\x1b[93m" + text)
+	}
+
+	if !thinkingInfo.Enabled {
+		return textCallback, nil, flush
+	}
+
+	if !thinkingInfo.ShowThinking {
+		return textCallback, func(text string) error {
+			thinking.WriteString(text)
+			return nil
+		}, flush
+	}
+
+	// Thinking is streamed inline, so it is not redisplayed after completion
+	thinkingHeaderDisplayed := false
+	thinkingCallback := func(text string) error {
+		// Display header once when thinking starts
+		if !thinkingHeaderDisplayed {
+			thinkingHeaderDisplayed = true
+			// Reset, show "Claude (thinking)" header in magenta, continue with thinking color
+			if err := cs.ui(sessionID).DisplayStreamingText(
+				"\x1b[0m\x1b[95mClaude (thinking)\x1b[0m: \x1b[95m",
+			); err != nil {
+				return err
+			}
+		}
+		return cs.ui(sessionID).DisplayStreamingText(text)
+	}
+	return textCallback, thinkingCallback, flush
+}
+
+// startActivity shows an activity indicator if the user interface supports one.
+func (cs *ChatService) startActivity(sessionID, label string) {
+	if indicator, ok := cs.ui(sessionID).(port.ActivityIndicator); ok {
+		indicator.StartActivity(label)
+	}
+}
+
+// stopActivity hides the activity indicator if the user interface supports one.
+func (cs *ChatService) stopActivity(sessionID string) {
+	if indicator, ok := cs.ui(sessionID).(port.ActivityIndicator); ok {
+		indicator.StopActivity()
+	}
+}
+
 // executeToolsForSession executes the requested tools for a session.
 func (cs *ChatService) executeToolsForSession(
 	ctx context.Context,
@@ -370,15 +620,125 @@ func (cs *ChatService) executeToolsForSession(
 		}
 	}
 
-	batchResp, err := cs.toolExecutionUseCase.ExecuteToolsInSession(ctx, sessionID, toolReqs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute tools: %w", err)
+	denied := cs.confirmToolBatch(sessionID, toolReqs)
+	if len(denied) == 0 {
+		batchResp, err := cs.toolExecutionUseCase.ExecuteToolsInSession(ctx, sessionID, toolReqs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute tools: %w", err)
+		}
+		return batchResp, nil
 	}
+
+	// Run only the approved calls, then put every result back in call order
+	var approved []dto.ToolExecuteRequest
+	for i, req := range toolReqs {
+		if _, ok := denied[i]; !ok {
+			approved = append(approved, req)
+		}
+	}
+	batchResp := &dto.ToolExecutionBatchResponse{SessionID: sessionID}
+	var approvedResults []dto.ToolExecutionResponse
+	if len(approved) > 0 {
+		executed, err := cs.toolExecutionUseCase.ExecuteToolsInSession(ctx, sessionID, approved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute tools: %w", err)
+		}
+		approvedResults = executed.Results
+		batchResp.SuccessfulCount = executed.SuccessfulCount
+		batchResp.TotalDurationMs = executed.TotalDurationMs
+	}
+
+	batchResp.Results = make([]dto.ToolExecutionResponse, len(toolReqs))
+	for i := range toolReqs {
+		if result, ok := denied[i]; ok {
+			batchResp.Results[i] = result
+			continue
+		}
+		batchResp.Results[i] = approvedResults[0]
+		approvedResults = approvedResults[1:]
+	}
+	batchResp.TotalTools = len(toolReqs)
+	batchResp.FailedCount = batchResp.TotalTools - batchResp.SuccessfulCount
 	return batchResp, nil
 }
 
+// SetToolBatchConfirmation sets whether a turn with several bash commands asks
+// the user about all of them in one prompt (port.UserInterface.ConfirmToolBatch)
+// instead of one prompt per command.
+func (cs *ChatService) SetToolBatchConfirmation(enabled bool) {
+	cs.batchConfirmation = enabled
+}
+
+// bashConfirmationInput holds the bash input fields a batch confirmation shows.
+type bashConfirmationInput struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Dangerous   bool   `json:"dangerous"`
+}
+
+// confirmToolBatch asks the user in one prompt about the turn's bash commands
+// when batch confirmation is on and there are at least two. Approved requests
+// are marked Confirmed so the executor does not ask again; the denied ones are
+// returned by index, with the error results they get instead of running.
+func (cs *ChatService) confirmToolBatch(
+	sessionID string,
+	toolReqs []dto.ToolExecuteRequest,
+) map[int]dto.ToolExecutionResponse {
+	if !cs.batchConfirmation {
+		return nil
+	}
+
+	var indexes []int
+	var calls []port.ToolConfirmation
+	for i, req := range toolReqs {
+		if req.ToolName != "bash" {
+			continue
+		}
+		var in bashConfirmationInput
+		raw, err := json.Marshal(req.Input)
+		if err != nil || json.Unmarshal(raw, &in) != nil || in.Command == "" {
+			// Left to the executor, which reports the malformed input
+			continue
+		}
+		dangerous, reason := safety.IsDangerousCommand(in.Command)
+		if in.Dangerous && !dangerous {
+			dangerous, reason = true, "marked dangerous by AI"
+		}
+		indexes = append(indexes, i)
+		calls = append(calls, port.ToolConfirmation{
+			ToolName:    req.ToolName,
+			Summary:     in.Command,
+			Description: in.Description,
+			Dangerous:   dangerous,
+			Reason:      reason,
+		})
+	}
+	if len(calls) < 2 {
+		return nil
+	}
+
+	cs.stopActivity(sessionID)
+	approved := cs.ui(sessionID).ConfirmToolBatch(calls)
+	denied := make(map[int]dto.ToolExecutionResponse)
+	for j, i := range indexes {
+		if j < len(approved) && approved[j] {
+			toolReqs[i].Confirmed = true
+			continue
+		}
+		denied[i] = dto.ToolExecutionResponse{
+			SessionID:  sessionID,
+			ToolName:   toolReqs[i].ToolName,
+			Success:    false,
+			Error:      "command denied by user: " + calls[j].Summary,
+			ExecutedAt: time.Now(),
+		}
+	}
+	return denied
+}
+
 // displayToolResults displays the results of executed tools.
 func (cs *ChatService) displayToolResults(
+	sessionID string,
 	results []dto.ToolExecutionResponse,
 	toolCalls []dto.ToolCallInfo,
 ) {
@@ -390,7 +750,11 @@ func (cs *ChatService) displayToolResults(
 		if !result.Success && result.Error != "" {
 			displayResult = fmt.Sprintf("Error: %s", result.Error)
 		}
-		_ = cs.userInterface.DisplayToolResult(result.ToolName, inputJSON, displayResult)
+		if cached, ok := cs.ui(sessionID).(port.CachedToolResultDisplay); ok && result.Cached {
+			_ = cached.DisplayCachedToolResult(result.ToolName, inputJSON, displayResult)
+			continue
+		}
+		_ = cs.ui(sessionID).DisplayToolResult(result.ToolName, inputJSON, displayResult)
 	}
 }
 
@@ -442,6 +806,7 @@ func (cs *ChatService) addToolResultsToConversation(
 			Result:           result,
 			IsError:          toolResults[i].Error != "",
 			ThoughtSignature: toolCall.ThoughtSignature, // Copy Gemini thought_signature from original tool call
+			Provenance:       toolResults[i].Provenance,
 		}
 
 		entityToolResults = append(entityToolResults, toolResult)
@@ -467,14 +832,14 @@ func (cs *ChatService) continueAfterToolExecution(
 	}
 
 	// Begin streaming response with color setup
-	if err := cs.userInterface.BeginStreamingResponse(); err != nil {
+	if err := cs.ui(sessionID).BeginStreamingResponse(); err != nil {
 		// Log error but continue - color setup is not critical
 		fmt.Fprintf(os.Stderr, "Warning: failed to begin streaming response: %v\n", err)
 	}
 
 	// Ensure we always clean up terminal state, even on errors
 	defer func() {
-		if err := cs.userInterface.EndStreamingResponse(); err != nil {
+		if err := cs.ui(sessionID).EndStreamingResponse(); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to end streaming response: %v\n", err)
 		}
 	}()
@@ -482,50 +847,29 @@ func (cs *ChatService) continueAfterToolExecution(
 	// Display [PLAN MODE] prefix if in plan mode (before streaming starts)
 	isPlanMode, _ := cs.conversationService.IsPlanMode(sessionID)
 	if isPlanMode {
-		if err := cs.userInterface.DisplayStreamingText("[PLAN MODE] "); err != nil {
+		if err := cs.ui(sessionID).DisplayStreamingText("[PLAN MODE] "); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to display plan mode prefix: %v\n", err)
 		}
 	}
 
-	// Create streaming callback that displays text as it arrives
-	textCallback := func(text string) error {
-		// Reset and set assistant color for regular text
-		return cs.userInterface.DisplayStreamingText("\x1b[0m\x1b[93m" + text)
-	}
-
-	// Create thinking callback if ShowThinking is enabled
-	// Thinking is streamed inline, so we don't redisplay after completion
-	var thinkingCallback port.ThinkingCallback
-	thinkingHeaderDisplayed := false
-	if thinkingInfo.ShowThinking {
-		thinkingCallback = func(thinking string) error {
-			// Display header once when thinking starts
-			if !thinkingHeaderDisplayed {
-				thinkingHeaderDisplayed = true
-				// Reset, show "Claude (thinking)" header in magenta, continue with thinking color
-				if err := cs.userInterface.DisplayStreamingText(
-					"\x1b[0m\x1b[95mClaude (thinking)\x1b[0m: \x1b[95m",
-				); err != nil {
-					return err
-				}
-			}
-			return cs.userInterface.DisplayStreamingText(thinking)
-		}
-	}
+	textCallback, thinkingCallback, flush := cs.streamingCallbacks(sessionID, thinkingInfo)
 
-	// Process the assistant message with streaming
+	// Process the assistant message with streaming, showing activity until the first chunk arrives
+	cs.startActivity(sessionID, "Thinking")
 	assistantMsg, toolCalls, err := cs.messageProcessUseCase.ProcessAssistantMessageStreaming(
 		ctx,
 		sessionID,
 		textCallback,
 		thinkingCallback,
 	)
+	cs.stopActivity(sessionID)
+	// Show collected thinking for responses without text, such as tool-only
+	// turns, and the reply when it is rendered whole, even after an error
+	flush()
 	if err != nil {
 		return nil, fmt.Errorf("failed to continue chat after tool execution: %w", err)
 	}
 
-	// Note: Thinking blocks are now streamed inline via thinkingCallback, so we don't redisplay them here
-
 	// Check if processing (has tools)
 	isProcessing, _ := cs.conversationService.IsProcessing(sessionID)
 
@@ -556,8 +900,13 @@ func (cs *ChatService) EndSession(
 		return nil, fmt.Errorf("failed to end session: %w", err)
 	}
 
+	cs.sessionPromptsMu.Lock()
+	delete(cs.sessionPrompts, sessionID)
+	cs.sessionPromptsMu.Unlock()
+
 	// Display goodbye message
-	_ = cs.userInterface.DisplaySystemMessage(fmt.Sprintf("Session ended: %s", sessionID))
+	_ = cs.ui(sessionID).DisplaySystemMessage(fmt.Sprintf("Session ended: %s", sessionID))
+	cs.SetSessionUI(sessionID, nil)
 
 	return resp, nil
 }
@@ -658,17 +1007,43 @@ func (cs *ChatService) GetAIModel() string {
 	return cs.aiProvider.GetModel()
 }
 
-// SetAIModel sets the AI model to use for subsequent requests.
+// SetAIModel sets the AI model to use for subsequent requests. It fails
+// without switching when the session relies on a feature the model lacks:
+// images attached to its next message, or extended thinking.
 //
 // Parameters:
+//   - sessionID: The session whose pending features are checked
 //   - model: The model identifier to use
 //
 // Returns:
-//   - error: An error if model setting fails
-func (cs *ChatService) SetAIModel(model string) error {
+//   - error: An error wrapping port.ErrFeatureNotSupported, or if model setting fails
+func (cs *ChatService) SetAIModel(sessionID, model string) error {
+	if caps, ok := port.CapabilitiesOf(cs.aiProvider, model); ok {
+		cs.pendingImagesMu.Lock()
+		queued := len(cs.pendingImages[sessionID])
+		cs.pendingImagesMu.Unlock()
+		if queued > 0 && !caps.SupportsImages {
+			return fmt.Errorf("cannot switch to %s: %d image(s) are attached to the next message, and images are %w",
+				model, queued, port.ErrFeatureNotSupported)
+		}
+		if info, err := cs.conversationService.GetThinkingMode(sessionID); err == nil && info.Enabled && !caps.SupportsThinking {
+			return fmt.Errorf("cannot switch to %s: extended thinking is on, and it is %w (use :thinking off first)",
+				model, port.ErrFeatureNotSupported)
+		}
+	}
 	return cs.aiProvider.SetModel(model)
 }
 
+// requireThinking returns an error wrapping port.ErrFeatureNotSupported if
+// the current model cannot do extended thinking.
+func (cs *ChatService) requireThinking() error {
+	model := cs.aiProvider.GetModel()
+	if caps, ok := port.CapabilitiesOf(cs.aiProvider, model); ok && !caps.SupportsThinking {
+		return fmt.Errorf("extended thinking is %w %s", port.ErrFeatureNotSupported, model)
+	}
+	return nil
+}
+
 // HandleModeCommand handles the :mode command for toggling plan mode.
 //
 // Parameters:
@@ -677,7 +1052,7 @@ func (cs *ChatService) SetAIModel(model string) error {
 //   - mode: The mode to set ("plan", "normal", or "toggle")
 //
 // Returns:
-//   - error: An error if the command is invalid
+//   - error: An error if the command is invalid or the model cannot do extended thinking
 func (cs *ChatService) HandleModeCommand(_ context.Context, sessionID string, mode string) error {
 	// Validate session exists first
 	_, err := cs.messageProcessUseCase.GetConversationState(sessionID)
@@ -690,42 +1065,181 @@ func (cs *ChatService) HandleModeCommand(_ context.Context, sessionID string, mo
 
 	switch modeLower {
 	case "plan":
-		// Set plan mode on both conversation service and tool executor
-		if err := cs.conversationService.SetPlanMode(sessionID, true); err != nil {
-			return err
-		}
-		// Also set plan mode on the tool executor if it supports it
-		if planner, ok := cs.toolExecutor.(interface{ SetPlanMode(string, bool) }); ok {
-			planner.SetPlanMode(sessionID, true)
-		}
-		return nil
+		return cs.setPlanMode(sessionID, true)
 	case "normal":
-		// Set normal mode on both conversation service and tool executor
-		if err := cs.conversationService.SetPlanMode(sessionID, false); err != nil {
-			return err
-		}
-		// Also set plan mode on the tool executor if it supports it
-		if planner, ok := cs.toolExecutor.(interface{ SetPlanMode(string, bool) }); ok {
-			planner.SetPlanMode(sessionID, false)
-		}
-		return nil
+		return cs.setPlanMode(sessionID, false)
 	case "toggle":
 		currentMode, _ := cs.conversationService.IsPlanMode(sessionID)
-		newMode := !currentMode
-		// Set plan mode on both conversation service and tool executor
-		if err := cs.conversationService.SetPlanMode(sessionID, newMode); err != nil {
-			return err
-		}
-		// Also set plan mode on the tool executor if it supports it
-		if planner, ok := cs.toolExecutor.(interface{ SetPlanMode(string, bool) }); ok {
-			planner.SetPlanMode(sessionID, newMode)
-		}
-		return nil
+		return cs.setPlanMode(sessionID, !currentMode)
 	default:
 		return errors.New("invalid mode: must be 'plan', 'normal', or 'toggle'")
 	}
 }
 
+// setPlanMode sets plan mode on the conversation service, the session's mode
+// prompt layer, the tool executor and the user interface's plan mode indicator.
+// The new mode applies from the next request to the AI, and to tool calls from then on.
+func (cs *ChatService) setPlanMode(sessionID string, enabled bool) error {
+	if err := cs.conversationService.SetPlanMode(sessionID, enabled); err != nil {
+		return err
+	}
+	composer := cs.sessionComposer(sessionID)
+	if enabled {
+		composer.SetLayer(usecase.PlanModePromptLayer(fmt.Sprintf(".agent/plans/%s.md", sessionID)))
+	} else {
+		composer.RemoveLayer(usecase.PromptLayerMode)
+	}
+	// Also set plan mode on the tool executor if it supports it
+	if planner, ok := cs.toolExecutor.(interface{ SetPlanMode(string, bool) }); ok {
+		planner.SetPlanMode(sessionID, enabled)
+	}
+	// Show the mode in the prompt if the UI supports it
+	if indicator, ok := cs.ui(sessionID).(interface{ SetPlanMode(bool) }); ok {
+		indicator.SetPlanMode(enabled)
+	}
+	return nil
+}
+
+// SwitchMode applies a mode change like HandleModeCommand and announces the
+// resulting mode through the user interface. It backs both the :mode command and
+// the UI's mode toggle key (Shift+Tab).
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - sessionID: The session ID
+//   - mode: The mode to set ("plan", "normal", or "toggle")
+//
+// Returns:
+//   - error: An error if the command is invalid or the model cannot do extended thinking
+func (cs *ChatService) SwitchMode(ctx context.Context, sessionID string, mode string) error {
+	if err := cs.HandleModeCommand(ctx, sessionID, mode); err != nil {
+		return err
+	}
+
+	if isPlanMode, _ := cs.conversationService.IsPlanMode(sessionID); isPlanMode {
+		return cs.ui(sessionID).DisplaySystemMessage(
+			"Plan mode enabled: the agent will propose a plan without making changes; mutating tools are blocked.",
+		)
+	}
+	return cs.ui(sessionID).DisplaySystemMessage("Plan mode disabled: Tools will execute normally.")
+}
+
+// SetSessionUI sends the session's output, from its streamed responses to
+// its tool results, to ui instead of the chat's user interface, such
+// as a buffer while the session runs in the background. A nil ui restores the
+// chat's user interface. Output already under way switches at its next write.
+func (cs *ChatService) SetSessionUI(sessionID string, ui port.UserInterface) {
+	cs.sessionUIsMu.Lock()
+	defer cs.sessionUIsMu.Unlock()
+	if ui == nil {
+		delete(cs.sessionUIs, sessionID)
+		return
+	}
+	if cs.sessionUIs == nil {
+		cs.sessionUIs = make(map[string]port.UserInterface)
+	}
+	cs.sessionUIs[sessionID] = ui
+}
+
+// ui returns the user interface showing the session's output.
+func (cs *ChatService) ui(sessionID string) port.UserInterface {
+	cs.sessionUIsMu.RLock()
+	defer cs.sessionUIsMu.RUnlock()
+	if ui, ok := cs.sessionUIs[sessionID]; ok {
+		return ui
+	}
+	return cs.userInterface
+}
+
+// SetPromptLayer adds a system prompt layer for every session, replacing any
+// layer with the same name, e.g. the memory layer when memory is reloaded.
+// It takes effect from each session's next message.
+func (cs *ChatService) SetPromptLayer(layer usecase.PromptLayer) {
+	cs.sessionPromptsMu.Lock()
+	defer cs.sessionPromptsMu.Unlock()
+	cs.promptLayers.SetLayer(layer)
+	for _, composer := range cs.sessionPrompts {
+		composer.SetLayer(layer)
+	}
+}
+
+// RemovePromptLayer removes the named system prompt layer from every session.
+// It takes effect from each session's next message.
+func (cs *ChatService) RemovePromptLayer(name string) {
+	cs.sessionPromptsMu.Lock()
+	defer cs.sessionPromptsMu.Unlock()
+	cs.promptLayers.RemoveLayer(name)
+	for _, composer := range cs.sessionPrompts {
+		composer.RemoveLayer(name)
+	}
+}
+
+// AddPromptLayerSource adds a source of a layer for every session whose
+// content can change, such as the project facts. The layer is refreshed from
+// the source before each message.
+func (cs *ChatService) AddPromptLayerSource(source usecase.PromptLayerSource) {
+	cs.sessionPromptsMu.Lock()
+	cs.promptSources = append(cs.promptSources, source)
+	cs.sessionPromptsMu.Unlock()
+	cs.SetPromptLayer(source.PromptLayer())
+}
+
+// refreshPromptLayers sets the layers of the prompt layer sources again.
+func (cs *ChatService) refreshPromptLayers() {
+	cs.sessionPromptsMu.Lock()
+	sources := slices.Clone(cs.promptSources)
+	cs.sessionPromptsMu.Unlock()
+	for _, source := range sources {
+		cs.SetPromptLayer(source.PromptLayer())
+	}
+}
+
+// SystemPrompt returns the session's composed system prompt, as its next
+// message will send it. It backs the :prompt show command.
+func (cs *ChatService) SystemPrompt(sessionID string) (usecase.ComposedPrompt, error) {
+	if _, err := cs.messageProcessUseCase.GetConversationState(sessionID); err != nil {
+		return usecase.ComposedPrompt{}, errors.New("session not found")
+	}
+	cs.refreshPromptLayers()
+	composer := cs.sessionComposer(sessionID)
+	if custom, ok := cs.conversationService.CustomSystemPromptInfo(sessionID); ok && !custom.Composed {
+		// The provider sends the custom prompt followed by any mode instructions
+		overridden := usecase.NewSystemPromptComposer(usecase.PromptLayer{
+			Name: promptLayerCustom, Order: usecase.PromptOrderBase, Content: custom.Prompt,
+		})
+		if mode, ok := composer.Layer(usecase.PromptLayerMode); ok {
+			overridden.SetLayer(mode)
+		}
+		composer = overridden
+	}
+	return composer.Compose(), nil
+}
+
+// sessionComposer returns the session's prompt composer, creating it from
+// the shared layers on first use.
+func (cs *ChatService) sessionComposer(sessionID string) *usecase.SystemPromptComposer {
+	cs.sessionPromptsMu.Lock()
+	defer cs.sessionPromptsMu.Unlock()
+	composer, ok := cs.sessionPrompts[sessionID]
+	if !ok {
+		composer = cs.promptLayers.Clone()
+		cs.sessionPrompts[sessionID] = composer
+	}
+	return composer
+}
+
+// applySystemPrompt composes the session's layers and sets the result as the
+// session's system prompt. A custom prompt set directly on the conversation
+// service replaces the layers and is left in place.
+func (cs *ChatService) applySystemPrompt(ctx context.Context, sessionID string) error {
+	if custom, ok := cs.conversationService.CustomSystemPromptInfo(sessionID); ok && !custom.Composed {
+		return nil
+	}
+	cs.refreshPromptLayers()
+	prompt := cs.sessionComposer(sessionID).Compose()
+	return cs.conversationService.SetComposedSystemPrompt(ctx, sessionID, prompt.Text)
+}
+
 // HandleThinkingCommand handles the :thinking command for toggling extended thinking mode.
 //
 // Parameters:
@@ -734,7 +1248,7 @@ func (cs *ChatService) HandleModeCommand(_ context.Context, sessionID string, mo
 //   - mode: The mode to set ("on", "off", or "toggle")
 //
 // Returns:
-//   - error: An error if the command is invalid
+//   - error: An error if the command is invalid or the model cannot do extended thinking
 func (cs *ChatService) HandleThinkingCommand(_ context.Context, sessionID string, mode string) error {
 	// Validate session exists first
 	_, err := cs.messageProcessUseCase.GetConversationState(sessionID)
@@ -747,6 +1261,9 @@ func (cs *ChatService) HandleThinkingCommand(_ context.Context, sessionID string
 
 	switch modeLower {
 	case "on", "enable":
+		if err := cs.requireThinking(); err != nil {
+			return err
+		}
 		// Enable thinking mode with defaults
 		info := port.ThinkingModeInfo{
 			Enabled:      true,
@@ -765,6 +1282,11 @@ func (cs *ChatService) HandleThinkingCommand(_ context.Context, sessionID string
 	case "toggle":
 		// Toggle current thinking mode
 		currentInfo, _ := cs.conversationService.GetThinkingMode(sessionID)
+		if !currentInfo.Enabled {
+			if err := cs.requireThinking(); err != nil {
+				return err
+			}
+		}
 		newInfo := port.ThinkingModeInfo{
 			Enabled:      !currentInfo.Enabled,
 			BudgetTokens: 10000, // Use default budget
@@ -776,6 +1298,126 @@ func (cs *ChatService) HandleThinkingCommand(_ context.Context, sessionID string
 	}
 }
 
+// SetThinkingBudget enables extended thinking for a session with the given token
+// budget, keeping the current ShowThinking setting.
+//
+// Returns an error if the session does not exist or the budget is below the
+// provider minimum of 1024 tokens.
+func (cs *ChatService) SetThinkingBudget(_ context.Context, sessionID string, budget int64) error {
+	if _, err := cs.messageProcessUseCase.GetConversationState(sessionID); err != nil {
+		return errors.New("session not found")
+	}
+	if budget < minThinkingBudget {
+		return fmt.Errorf("invalid thinking budget %d: must be at least %d tokens", budget, minThinkingBudget)
+	}
+	if err := cs.requireThinking(); err != nil {
+		return err
+	}
+
+	currentInfo, _ := cs.conversationService.GetThinkingMode(sessionID)
+	return cs.conversationService.SetThinkingMode(sessionID, port.ThinkingModeInfo{
+		Enabled:      true,
+		BudgetTokens: budget,
+		ShowThinking: currentInfo.ShowThinking,
+	})
+}
+
+// Checkpoint records a checkpoint of the session's history and announces its
+// ID through the user interface. It backs the :checkpoint command.
+//
+// Returns:
+//   - error: An error if the session does not exist or has ended
+func (cs *ChatService) Checkpoint(_ context.Context, sessionID string) error {
+	checkpointID, err := cs.conversationService.Checkpoint(sessionID)
+	if err != nil {
+		return err
+	}
+	return cs.ui(sessionID).DisplaySystemMessage(
+		fmt.Sprintf("Checkpoint %d created. Use :rollback %d to return to it.", checkpointID, checkpointID),
+	)
+}
+
+// Rollback truncates the session's history back to checkpointID and announces
+// how many messages were removed. It backs the :rollback command.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - sessionID: The session ID
+//   - checkpointID: The checkpoint to roll back to
+//
+// Returns:
+//   - error: An error if the session has ended or the checkpoint is invalid
+func (cs *ChatService) Rollback(ctx context.Context, sessionID string, checkpointID int) error {
+	conv, err := cs.conversationService.GetConversation(sessionID)
+	if err != nil {
+		return err
+	}
+	before := conv.MessageCount()
+
+	if err := cs.conversationService.Rollback(ctx, sessionID, checkpointID); err != nil {
+		return err
+	}
+	return cs.ui(sessionID).DisplaySystemMessage(fmt.Sprintf(
+		"Rolled back to checkpoint %d: removed %d message(s). Files changed by tools are not restored.",
+		checkpointID, before-conv.MessageCount(),
+	))
+}
+
+// RollbackToLatestCheckpoint rolls the session back like Rollback, to its most
+// recent manual or automatic checkpoint.
+//
+// Returns:
+//   - error: An error if the session has no checkpoint or the rollback fails
+func (cs *ChatService) RollbackToLatestCheckpoint(ctx context.Context, sessionID string) error {
+	checkpointID, err := cs.conversationService.LatestCheckpoint(sessionID)
+	if err != nil {
+		return err
+	}
+	return cs.Rollback(ctx, sessionID, checkpointID)
+}
+
+// SetModelRouter sets the router choosing the model that writes session
+// titles (usecase.ModelTaskTitleGeneration).
+func (cs *ChatService) SetModelRouter(router *usecase.ModelRouter) {
+	cs.titles.SetModelRouter(router)
+}
+
+// RenameSession sets the session's title and announces it. The title "auto"
+// generates a new one from the conversation. It backs the :rename command.
+//
+// Returns:
+//   - error: An error if the title is empty, the session does not exist, or
+//     the title cannot be saved
+func (cs *ChatService) RenameSession(ctx context.Context, sessionID, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "auto" {
+		conv, err := cs.conversationService.GetConversation(sessionID)
+		if err != nil {
+			return err
+		}
+		if title = cs.titles.Generate(ctx, conv.GetMessages()); title == "" {
+			return errors.New("nothing to title yet: send a message first")
+		}
+	}
+	if title == "" {
+		return errors.New("session title cannot be empty")
+	}
+
+	if err := cs.conversationService.SetSessionTitle(ctx, sessionID, title); err != nil {
+		return err
+	}
+	return cs.ui(sessionID).DisplaySystemMessage("Session renamed: " + title)
+}
+
+// ListSessions returns the metadata of every stored session, most recently
+// updated first. It backs the :sessions command.
+//
+// Returns:
+//   - error: An error if sessions are not being saved
+func (cs *ChatService) ListSessions(ctx context.Context) ([]port.SessionMetadata, error) {
+	return cs.conversationService.ListSessions(ctx)
+}
+
 // GetPorts returns references to the internal ports for advanced use cases.
 // This is primarily intended for testing or scenarios where direct port access is needed.
 //
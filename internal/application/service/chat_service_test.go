@@ -1,6 +1,7 @@
 package service
 
 import (
+	"code-editing-agent/internal/application/usecase"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	serviceDomain "code-editing-agent/internal/domain/service"
@@ -8,6 +9,11 @@ import (
 	"code-editing-agent/internal/infrastructure/adapter/tool"
 	"code-editing-agent/internal/infrastructure/adapter/ui"
 	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -336,8 +342,8 @@ func TestChatService_ModeIntegration(t *testing.T) {
 		toolCall := port.ToolCallInfo{
 			ToolID:    "tool_123",
 			ToolName:  "bash",
-			Input:     map[string]interface{}{"command": "ls -la", "dangerous": false},
-			InputJSON: `{"command":"ls -la","dangerous":false}`,
+			Input:     map[string]interface{}{"command": "touch created.txt", "dangerous": false},
+			InputJSON: `{"command":"touch created.txt","dangerous":false}`,
 		}
 
 		aiProvider := &mockAIProviderForChat{
@@ -655,6 +661,16 @@ func (m *mockAIProviderForChat) SendMessage(
 	return m.response, nil, nil
 }
 
+// SendMessageWithOptions ignores the options and behaves like SendMessage.
+func (m *mockAIProviderForChat) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return m.SendMessage(ctx, messages, tools)
+}
+
 // SendMessageStreaming returns the configured response and tool calls with streaming support.
 func (m *mockAIProviderForChat) SendMessageStreaming(
 	_ context.Context,
@@ -697,3 +713,908 @@ func (m *mockAIProviderForChat) SetModel(_ string) error {
 func (m *mockAIProviderForChat) GetModel() string {
 	return "test-model"
 }
+
+// =============================================================================
+// Activity Indicator Tests
+// =============================================================================
+
+// recordingActivityUI wraps a UserInterface and records activity indicator calls.
+type recordingActivityUI struct {
+	port.UserInterface
+	started []string
+	stops   int
+	active  bool
+}
+
+func (r *recordingActivityUI) StartActivity(label string) {
+	r.started = append(r.started, label)
+	r.active = true
+}
+
+func (r *recordingActivityUI) StopActivity() {
+	r.stops++
+	r.active = false
+}
+
+func TestChatService_SendMessage_DrivesActivityIndicator(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	notesPath := tempDir + "/notes.txt"
+	_ = fileManager.WriteFile(notesPath, "hello")
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	userInterface := &recordingActivityUI{
+		UserInterface: ui.NewCLIAdapterWithIO(strings.NewReader(""), &strings.Builder{}),
+	}
+
+	aiProvider := &mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "Reading."},
+		toolCalls: []port.ToolCallInfo{{
+			ToolID:    "tool_1",
+			ToolName:  "read_file",
+			Input:     map[string]interface{}{"path": notesPath},
+			InputJSON: `{"path":"` + notesPath + `"}`,
+		}},
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	if _, err := chatService.SendMessage(ctx, startResp.SessionID, "Read notes"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	want := []string{"Thinking", "Running read_file", "Thinking"}
+	if strings.Join(userInterface.started, ",") != strings.Join(want, ",") {
+		t.Errorf("StartActivity labels = %v, want %v", userInterface.started, want)
+	}
+	if userInterface.active || userInterface.stops < len(want) {
+		t.Errorf("activity should be stopped after each phase, got %d stops, active = %v",
+			userInterface.stops, userInterface.active)
+	}
+}
+
+// =============================================================================
+// Thinking Display Tests
+// =============================================================================
+
+// thinkingAIProvider streams a thinking block before the response text.
+type thinkingAIProvider struct {
+	mockAIProviderForChat
+	thinking string
+}
+
+func (m *thinkingAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	if thinkingCallback != nil {
+		_ = thinkingCallback(m.thinking)
+	}
+	return m.mockAIProviderForChat.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+}
+
+func newThinkingChatService(t *testing.T, output io.Writer) (*ChatService, *serviceDomain.ConversationService, string) {
+	t.Helper()
+	fileManager := file.NewLocalFileManager(t.TempDir())
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	aiProvider := &thinkingAIProvider{
+		mockAIProviderForChat: mockAIProviderForChat{
+			response: &entity.Message{Role: entity.RoleAssistant, Content: "The answer."},
+		},
+		thinking: strings.Repeat("abcd", 50),
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+
+	startResp, err := chatService.StartSession(context.Background(), "")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	return chatService, convService, startResp.SessionID
+}
+
+func TestChatService_SendMessage_ThinkingDisplay(t *testing.T) {
+	tests := []struct {
+		name         string
+		showThinking bool
+		want         string
+		notWant      string
+	}{
+		{name: "collapsed by default", showThinking: false, want: "(thinking… 50 tokens)", notWant: "abcdabcd"},
+		{name: "streamed inline with ShowThinking", showThinking: true, want: "Claude (thinking)", notWant: "(thinking…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+			chatService, convService, sessionID := newThinkingChatService(t, &output)
+			_ = convService.SetThinkingMode(sessionID, port.ThinkingModeInfo{
+				Enabled: true, BudgetTokens: 2048, ShowThinking: tt.showThinking,
+			})
+
+			if _, err := chatService.SendMessage(context.Background(), sessionID, "Question"); err != nil {
+				t.Fatalf("SendMessage() error = %v", err)
+			}
+
+			out := output.String()
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output %q does not contain %q", out, tt.want)
+			}
+			if strings.Contains(out, tt.notWant) {
+				t.Errorf("output %q should not contain %q", out, tt.notWant)
+			}
+			if strings.Index(out, "The answer.") < strings.Index(out, tt.want) {
+				t.Errorf("thinking should be shown before the answer: %q", out)
+			}
+		})
+	}
+}
+
+func TestChatService_SendMessage_NoThinkingWhenDisabled(t *testing.T) {
+	var output strings.Builder
+	chatService, _, sessionID := newThinkingChatService(t, &output)
+
+	if _, err := chatService.SendMessage(context.Background(), sessionID, "Question"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if strings.Contains(output.String(), "thinking") {
+		t.Errorf("no thinking should be displayed when thinking mode is off: %q", output.String())
+	}
+}
+
+// chunkedAIProvider streams its response one line at a time, as a provider
+// streams a reply in pieces.
+type chunkedAIProvider struct {
+	*mockAIProviderForChat
+}
+
+func (p chunkedAIProvider) SendMessageStreaming(
+	_ context.Context,
+	_ []port.MessageParam,
+	_ []port.ToolParam,
+	textCallback port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	for _, line := range strings.SplitAfter(p.response.Content, "\n") {
+		if err := textCallback(line); err != nil {
+			return nil, nil, err
+		}
+	}
+	return p.response, nil, nil
+}
+
+func TestChatService_SendMessage_RendersMarkdownReply(t *testing.T) {
+	const fence = "```go\nfunc main() {\n\tfmt.Println(\"**not bold**\")\n}\n```"
+	reply := "# Fix\n\nChange main:\n\n" + fence + "\n"
+	ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	tests := []struct {
+		name   string
+		render bool
+	}{
+		{name: "rendered", render: true},
+		{name: "plain with rendering off", render: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileManager := file.NewLocalFileManager(t.TempDir())
+			toolExecutor := tool.NewExecutorAdapter(fileManager)
+			var output strings.Builder
+			userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+			userInterface.SetColorEnabled(true)
+			userInterface.SetMarkdownRendering(tt.render)
+			aiProvider := chunkedAIProvider{&mockAIProviderForChat{
+				response: &entity.Message{Role: entity.RoleAssistant, Content: reply},
+			}}
+			convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+			chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+			startResp, _ := chatService.StartSession(context.Background(), "")
+
+			if _, err := chatService.SendMessage(context.Background(), startResp.SessionID, "Fix it"); err != nil {
+				t.Fatalf("SendMessage() error = %v", err)
+			}
+
+			out := output.String()
+			styled := strings.Contains(out, "\x1b[1m\x1b[4mFix")
+			if styled != tt.render {
+				t.Errorf("heading styled = %v, want %v in output %q", styled, tt.render, out)
+			}
+			if strings.Contains(out, "# Fix") == tt.render {
+				t.Errorf("heading marker shown = %v, want %v in output %q", !tt.render, !tt.render, out)
+			}
+			if !strings.Contains(ansi.ReplaceAllString(out, ""), fence) {
+				t.Errorf("code fence changed; output without escapes = %q", ansi.ReplaceAllString(out, ""))
+			}
+		})
+	}
+}
+
+func TestChatService_SetThinkingBudget(t *testing.T) {
+	chatService, convService, sessionID := newThinkingChatService(t, io.Discard)
+	ctx := context.Background()
+	_ = convService.SetThinkingMode(sessionID, port.ThinkingModeInfo{ShowThinking: true})
+
+	if err := chatService.SetThinkingBudget(ctx, sessionID, 4096); err != nil {
+		t.Fatalf("SetThinkingBudget() error = %v", err)
+	}
+	info, _ := convService.GetThinkingMode(sessionID)
+	if !info.Enabled || info.BudgetTokens != 4096 || !info.ShowThinking {
+		t.Errorf("thinking mode = %+v, want enabled with budget 4096 and ShowThinking kept", info)
+	}
+
+	if err := chatService.SetThinkingBudget(ctx, sessionID, 100); err == nil {
+		t.Error("SetThinkingBudget() with a budget below 1024 should fail")
+	}
+	if err := chatService.SetThinkingBudget(ctx, "missing", 4096); err == nil {
+		t.Error("SetThinkingBudget() for an unknown session should fail")
+	}
+
+	// Toggling off and on again works from the budget state
+	if err := chatService.HandleThinkingCommand(ctx, sessionID, "off"); err != nil {
+		t.Fatalf("HandleThinkingCommand(off) error = %v", err)
+	}
+	if info, _ := convService.GetThinkingMode(sessionID); info.Enabled {
+		t.Error("thinking should be disabled after :thinking off")
+	}
+}
+
+// =============================================================================
+// Plan Mode Toggle Tests
+// =============================================================================
+
+// planModeRecordingAIProvider records whether plan mode was in the context of each request.
+type planModeRecordingAIProvider struct {
+	mockAIProviderForChat
+	planModes []bool
+}
+
+func (m *planModeRecordingAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	info, ok := port.PlanModeFromContext(ctx)
+	m.planModes = append(m.planModes, ok && info.Enabled)
+	return m.mockAIProviderForChat.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+}
+
+func TestChatService_ModeToggleKey_BlocksEditsOnNextTurn(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	target := tempDir + "/main.go"
+	_ = fileManager.WriteFile(target, "package main\n")
+	toolExecutor := tool.NewPlanningExecutorAdapter(tool.NewExecutorAdapter(fileManager), fileManager, tempDir)
+
+	var output strings.Builder
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+	aiProvider := &planModeRecordingAIProvider{
+		mockAIProviderForChat: mockAIProviderForChat{
+			response: &entity.Message{Role: entity.RoleAssistant, Content: "Editing."},
+			toolCalls: []port.ToolCallInfo{{
+				ToolID:    "tool_1",
+				ToolName:  "edit_file",
+				Input:     map[string]interface{}{"path": target, "old_str": "main", "new_str": "other"},
+				InputJSON: `{"path":"` + target + `","old_str":"main","new_str":"other"}`,
+			}},
+		},
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	sessionID := startResp.SessionID
+
+	// Shift+Tab in the UI toggles plan mode through the registered callback
+	userInterface.SetModeToggleCallback(func() {
+		if err := chatService.SwitchMode(ctx, sessionID, "toggle"); err != nil {
+			t.Errorf("SwitchMode() error = %v", err)
+		}
+	})
+	userInterface.HandleKeyPress(ui.KeyShiftTab)
+
+	if !strings.Contains(output.String(), "Plan mode enabled") {
+		t.Errorf("toggle should be announced, got output: %q", output.String())
+	}
+	if !strings.HasPrefix(userInterface.GetPrompt(), "[PLAN MODE]") {
+		t.Errorf("prompt should show plan mode, got %q", userInterface.GetPrompt())
+	}
+
+	if _, err := chatService.SendMessage(ctx, sessionID, "Rename main"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if len(aiProvider.planModes) == 0 || !aiProvider.planModes[0] {
+		t.Errorf("plan mode should be in the request context after the toggle, got %v", aiProvider.planModes)
+	}
+	content, _ := fileManager.ReadFile(target)
+	if content != "package main\n" {
+		t.Errorf("edit_file should be blocked in plan mode, file content: %q", content)
+	}
+
+	var blocked *entity.ToolResult
+	conv, _ := convService.GetConversation(sessionID)
+	for _, msg := range conv.GetMessages() {
+		for i := range msg.ToolResults {
+			blocked = &msg.ToolResults[i]
+		}
+	}
+	if blocked == nil || !blocked.IsError || !strings.Contains(blocked.Result, "[PLAN MODE]") {
+		t.Errorf("expected an is_error plan mode tool result, got %+v", blocked)
+	}
+
+	// Toggling back takes effect on the next turn
+	userInterface.HandleKeyPress(ui.KeyShiftTab)
+	if !strings.Contains(output.String(), "Plan mode disabled") {
+		t.Errorf("toggle back should be announced, got output: %q", output.String())
+	}
+	aiProvider.callCount = 0
+	if _, err := chatService.SendMessage(ctx, sessionID, "Rename main"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if last := aiProvider.planModes[len(aiProvider.planModes)-1]; last {
+		t.Error("plan mode should not be in the request context after toggling back")
+	}
+	content, _ = fileManager.ReadFile(target)
+	if content != "package other\n" {
+		t.Errorf("edit_file should run in normal mode, file content: %q", content)
+	}
+}
+
+// =============================================================================
+// Checkpoint Tests
+// =============================================================================
+
+func TestChatService_SendMessage_CheckpointsBeforeDestructiveTools(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	notesPath := tempDir + "/notes.txt"
+	output := &strings.Builder{}
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+	aiProvider := &mockAIProviderForChat{
+		response: &entity.Message{
+			Role:      entity.RoleAssistant,
+			Content:   "Writing notes.",
+			ToolCalls: []entity.ToolCall{{ToolID: "tool_1", ToolName: "edit_file"}},
+		},
+		toolCalls: []port.ToolCallInfo{{
+			ToolID:    "tool_1",
+			ToolName:  "edit_file",
+			Input:     map[string]interface{}{"path": notesPath, "old_str": "", "new_str": "hello"},
+			InputJSON: `{"path":"` + notesPath + `","old_str":"","new_str":"hello"}`,
+		}},
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	sessionID := startResp.SessionID
+	if _, err := chatService.SendMessage(ctx, sessionID, "Write notes"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	checkpointID, err := convService.LatestCheckpoint(sessionID)
+	if err != nil || checkpointID != 1 {
+		t.Fatalf("LatestCheckpoint() = %d, %v; want 1 (before the edit_file tool use), nil", checkpointID, err)
+	}
+
+	if err := chatService.RollbackToLatestCheckpoint(ctx, sessionID); err != nil {
+		t.Fatalf("RollbackToLatestCheckpoint() error = %v", err)
+	}
+	conv, _ := convService.GetConversation(sessionID)
+	if conv.MessageCount() != 1 {
+		t.Errorf("message count after rollback = %d, want 1", conv.MessageCount())
+	}
+	if !strings.Contains(output.String(), "Rolled back to checkpoint 1: removed 3 message(s)") {
+		t.Errorf("output = %q, want the rollback announcement", output.String())
+	}
+}
+
+func TestChatService_SendMessage_NoCheckpointForReadOnlyTools(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), &strings.Builder{})
+
+	aiProvider := &mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "Listing."},
+		toolCalls: []port.ToolCallInfo{{
+			ToolID:    "tool_1",
+			ToolName:  "list_files",
+			Input:     map[string]interface{}{"path": tempDir},
+			InputJSON: `{"path":"` + tempDir + `"}`,
+		}},
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	if _, err := chatService.SendMessage(ctx, startResp.SessionID, "List files"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if _, err := convService.LatestCheckpoint(startResp.SessionID); !errors.Is(err, serviceDomain.ErrNoCheckpoint) {
+		t.Errorf("LatestCheckpoint() error = %v, want ErrNoCheckpoint", err)
+	}
+}
+
+// =============================================================================
+// Batch Confirmation Tests
+// =============================================================================
+
+func TestChatService_SendMessage_ConfirmsBashCommandsAsBatch(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	// Commands approved in the batch must not be asked about again
+	toolExecutor.SetCommandConfirmationCallback(func(_ string, _ bool, _, _ string) bool { return false })
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader("x\n1\n"), &strings.Builder{})
+
+	aiProvider := &mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "Running."},
+		toolCalls: []port.ToolCallInfo{
+			{
+				ToolID:    "tool_1",
+				ToolName:  "bash",
+				Input:     map[string]interface{}{"command": "echo approved", "description": "Print", "dangerous": false},
+				InputJSON: `{"command":"echo approved","description":"Print","dangerous":false}`,
+			},
+			{
+				ToolID:    "tool_2",
+				ToolName:  "bash",
+				Input:     map[string]interface{}{"command": "echo skipped", "description": "Print", "dangerous": false},
+				InputJSON: `{"command":"echo skipped","description":"Print","dangerous":false}`,
+			},
+		},
+	}
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+	chatService.SetToolBatchConfirmation(true)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	if _, err := chatService.SendMessage(ctx, startResp.SessionID, "Run both"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	conv, _ := convService.GetConversation(startResp.SessionID)
+	var results []entity.ToolResult
+	for _, msg := range conv.GetMessages() {
+		results = append(results, msg.ToolResults...)
+	}
+	if len(results) != 2 {
+		t.Fatalf("tool results = %+v, want one for each call", results)
+	}
+	if results[0].IsError || !strings.Contains(results[0].Result, "approved") {
+		t.Errorf("approved call result = %+v, want the command's output", results[0])
+	}
+	if !results[1].IsError || !strings.Contains(results[1].Result, "command denied by user: echo skipped") {
+		t.Errorf("denied call result = %+v, want an error saying the user denied it", results[1])
+	}
+}
+
+// =============================================================================
+// Image Attachment Tests
+// =============================================================================
+
+// imageAIProvider is a mockAIProviderForChat that accepts image input.
+type imageAIProvider struct {
+	mockAIProviderForChat
+}
+
+func (m *imageAIProvider) SupportsImageInput() bool {
+	return true
+}
+
+// pngData is the start of a PNG file, enough for content type detection.
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newImageChatService(
+	t *testing.T,
+	aiProvider port.AIProvider,
+) (*ChatService, *serviceDomain.ConversationService, string, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), &strings.Builder{})
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+	startResp, err := chatService.StartSession(context.Background(), "")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	return chatService, convService, startResp.SessionID, tempDir
+}
+
+func TestChatService_AttachImage_SentWithNextMessage(t *testing.T) {
+	aiProvider := &imageAIProvider{mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "The button is misaligned."},
+	}}
+	chatService, convService, sessionID, tempDir := newImageChatService(t, aiProvider)
+	imagePath := filepath.Join(tempDir, "screenshot.png")
+	if err := os.WriteFile(imagePath, pngData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := chatService.AttachImage(ctx, sessionID, imagePath); err != nil {
+		t.Fatalf("AttachImage() error = %v", err)
+	}
+	if _, err := chatService.SendMessage(ctx, sessionID, "What is wrong here?"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if _, err := chatService.SendMessage(ctx, sessionID, "Thanks"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	conv, _ := convService.GetConversation(sessionID)
+	messages := conv.GetMessages()
+	if len(messages) != 4 {
+		t.Fatalf("message count = %d, want 4", len(messages))
+	}
+	first := messages[0]
+	if first.Content != "What is wrong here?" || len(first.Blocks) != 2 || first.Blocks[1].Image == nil {
+		t.Fatalf("first message = %+v, want the text and an image block", first)
+	}
+	if image := first.Blocks[1].Image; image.MediaType != "image/png" || image.Path != imagePath {
+		t.Errorf("image = %+v, want image/png at %s", image, imagePath)
+	}
+	if messages[2].HasImages() {
+		t.Error("the image was sent again with the following message")
+	}
+}
+
+func TestChatService_AttachImage_Rejected(t *testing.T) {
+	aiProvider := &imageAIProvider{mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "Hello."},
+	}}
+	chatService, convService, sessionID, tempDir := newImageChatService(t, aiProvider)
+	ctx := context.Background()
+
+	textPath := filepath.Join(tempDir, "notes.txt")
+	_ = os.WriteFile(textPath, []byte("just some notes"), 0o644)
+	if err := chatService.AttachImage(ctx, sessionID, textPath); !errors.Is(err, entity.ErrUnsupportedImageType) {
+		t.Errorf("AttachImage(text file) error = %v, want ErrUnsupportedImageType", err)
+	}
+
+	largePath := filepath.Join(tempDir, "large.png")
+	_ = os.WriteFile(largePath, append(pngData, make([]byte, entity.MaxImageBytes)...), 0o644)
+	if err := chatService.AttachImage(ctx, sessionID, largePath); !errors.Is(err, entity.ErrImageTooLarge) {
+		t.Errorf("AttachImage(large file) error = %v, want ErrImageTooLarge", err)
+	}
+
+	if err := chatService.AttachImage(ctx, sessionID, tempDir); err == nil {
+		t.Error("AttachImage(directory) expected error")
+	}
+
+	textOnly, _, textSessionID, textDir := newImageChatService(t, &mockAIProviderForChat{})
+	imagePath := filepath.Join(textDir, "screenshot.png")
+	_ = os.WriteFile(imagePath, pngData, 0o644)
+	if err := textOnly.AttachImage(ctx, textSessionID, imagePath); !errors.Is(err, port.ErrImagesNotSupported) {
+		t.Errorf("AttachImage(text-only provider) error = %v, want ErrImagesNotSupported", err)
+	}
+
+	_, _ = chatService.SendMessage(ctx, sessionID, "hello")
+	conv, _ := convService.GetConversation(sessionID)
+	if messages := conv.GetMessages(); len(messages) == 0 || messages[0].HasImages() {
+		t.Errorf("messages = %+v, want no images after rejected attachments", messages)
+	}
+}
+
+// =============================================================================
+// Model Capability Tests
+// =============================================================================
+
+// capabilityAIProvider is an imageAIProvider reporting per-model capabilities.
+type capabilityAIProvider struct {
+	imageAIProvider
+	model        string
+	capabilities map[string]port.ModelCapabilities
+}
+
+func (m *capabilityAIProvider) SetModel(model string) error {
+	m.model = model
+	return nil
+}
+
+func (m *capabilityAIProvider) GetModel() string {
+	return m.model
+}
+
+func (m *capabilityAIProvider) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	caps, ok := m.capabilities[model]
+	return caps, ok
+}
+
+func TestChatService_SetAIModel_FailsFastOnUnsupportedFeatures(t *testing.T) {
+	aiProvider := &capabilityAIProvider{
+		imageAIProvider: imageAIProvider{mockAIProviderForChat{
+			response: &entity.Message{Role: entity.RoleAssistant, Content: "The button is misaligned."},
+		}},
+		model: "vision-model",
+		capabilities: map[string]port.ModelCapabilities{
+			"vision-model": {SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+			"text-model":   {SupportsTools: true, SupportsThinking: true},
+			"plain-model":  {SupportsTools: true},
+		},
+	}
+	chatService, _, sessionID, tempDir := newImageChatService(t, aiProvider)
+	ctx := context.Background()
+	imagePath := filepath.Join(tempDir, "screenshot.png")
+	if err := os.WriteFile(imagePath, pngData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chatService.AttachImage(ctx, sessionID, imagePath); err != nil {
+		t.Fatalf("AttachImage() error = %v", err)
+	}
+	err := chatService.SetAIModel(sessionID, "text-model")
+	if !errors.Is(err, port.ErrFeatureNotSupported) || !strings.Contains(err.Error(), "1 image(s)") {
+		t.Errorf("SetAIModel() with a queued image error = %v, want ErrFeatureNotSupported", err)
+	}
+	if aiProvider.model != "vision-model" {
+		t.Errorf("model = %q, want it unchanged after a refused switch", aiProvider.model)
+	}
+	if _, err := chatService.SendMessage(ctx, sessionID, "What is wrong here?"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if err := chatService.SetAIModel(sessionID, "text-model"); err != nil {
+		t.Fatalf("SetAIModel() once the image was sent error = %v", err)
+	}
+
+	if err := chatService.HandleThinkingCommand(ctx, sessionID, "on"); err != nil {
+		t.Fatalf("HandleThinkingCommand(on) error = %v", err)
+	}
+	if err := chatService.SetAIModel(sessionID, "plain-model"); !errors.Is(err, port.ErrFeatureNotSupported) {
+		t.Errorf("SetAIModel() with thinking on error = %v, want ErrFeatureNotSupported", err)
+	}
+	_ = chatService.HandleThinkingCommand(ctx, sessionID, "off")
+	if err := chatService.SetAIModel(sessionID, "plain-model"); err != nil {
+		t.Fatalf("SetAIModel() with thinking off error = %v", err)
+	}
+
+	// The thinking toggle consults the current model too
+	if err := chatService.HandleThinkingCommand(ctx, sessionID, "on"); !errors.Is(err, port.ErrFeatureNotSupported) {
+		t.Errorf("HandleThinkingCommand(on) error = %v, want ErrFeatureNotSupported", err)
+	}
+	if err := chatService.SetThinkingBudget(ctx, sessionID, 4096); !errors.Is(err, port.ErrFeatureNotSupported) {
+		t.Errorf("SetThinkingBudget() error = %v, want ErrFeatureNotSupported", err)
+	}
+}
+
+// =============================================================================
+// Session Title Tests
+// =============================================================================
+
+func TestChatService_SessionTitle(t *testing.T) {
+	aiProvider := &mockAIProviderForChat{
+		response: &entity.Message{Role: entity.RoleAssistant, Content: "Flaky checkout test"},
+	}
+	fileManager := file.NewLocalFileManager(t.TempDir())
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	output := &strings.Builder{}
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, userInterface, aiProvider, toolExecutor, fileManager)
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	sessionID := startResp.SessionID
+
+	if _, err := chatService.SendMessage(ctx, sessionID, "The checkout test fails on CI"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if title := convService.GetSessionTitle(sessionID); title != "" {
+		t.Errorf("title after the first turn = %q, want none yet", title)
+	}
+	if _, err := chatService.SendMessage(ctx, sessionID, "Only under -race"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if title := convService.GetSessionTitle(sessionID); title != "Flaky checkout test" {
+		t.Errorf("title after the second turn = %q, want the generated title", title)
+	}
+
+	// Later turns keep the title; only :rename changes it
+	calls := aiProvider.callCount
+	_, _ = chatService.SendMessage(ctx, sessionID, "Fixed it")
+	if aiProvider.callCount != calls+1 {
+		t.Errorf("third turn made %d AI calls, want 1 (no title regeneration)", aiProvider.callCount-calls)
+	}
+
+	if err := chatService.RenameSession(ctx, sessionID, "Race in checkout"); err != nil {
+		t.Fatalf("RenameSession() error = %v", err)
+	}
+	if title := convService.GetSessionTitle(sessionID); title != "Race in checkout" {
+		t.Errorf("title after rename = %q", title)
+	}
+	if !strings.Contains(output.String(), "Session renamed: Race in checkout") {
+		t.Errorf("output = %q, want the rename announcement", output.String())
+	}
+
+	aiProvider.response = &entity.Message{Role: entity.RoleAssistant, Content: "Checkout race fix"}
+	if err := chatService.RenameSession(ctx, sessionID, "auto"); err != nil {
+		t.Fatalf("RenameSession(auto) error = %v", err)
+	}
+	if title := convService.GetSessionTitle(sessionID); title != "Checkout race fix" {
+		t.Errorf("title after :rename auto = %q, want a regenerated title", title)
+	}
+
+	if err := chatService.RenameSession(ctx, sessionID, "   "); err == nil {
+		t.Error("RenameSession() with an empty title should fail")
+	}
+}
+
+// =============================================================================
+// System Prompt Layer Tests
+// =============================================================================
+
+// systemPromptRecordingAIProvider records the custom system prompt in the context of each request.
+type systemPromptRecordingAIProvider struct {
+	mockAIProviderForChat
+	prompts []port.CustomSystemPromptInfo
+}
+
+func (m *systemPromptRecordingAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	info, _ := port.CustomSystemPromptFromContext(ctx)
+	m.prompts = append(m.prompts, info)
+	return m.mockAIProviderForChat.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+}
+
+func TestChatService_SystemPromptLayers(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	aiProvider := &systemPromptRecordingAIProvider{
+		mockAIProviderForChat: mockAIProviderForChat{
+			response: &entity.Message{Role: entity.RoleAssistant, Content: "Done."},
+		},
+	}
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard),
+		aiProvider, toolExecutor, fileManager)
+	chatService.SetPromptLayer(usecase.MemoryPromptLayer("Prefer table-driven tests."))
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	sessionID := startResp.SessionID
+
+	send := func() port.CustomSystemPromptInfo {
+		t.Helper()
+		if _, err := chatService.SendMessage(ctx, sessionID, "hello"); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		return aiProvider.prompts[len(aiProvider.prompts)-1]
+	}
+
+	first := send()
+	if !first.Composed || !strings.HasPrefix(first.Prompt, usecase.BaseSystemPrompt) ||
+		!strings.Contains(first.Prompt, "Prefer table-driven tests.") {
+		t.Errorf("first request prompt = %+v, want the composed base and memory layers", first)
+	}
+
+	if err := chatService.HandleModeCommand(ctx, sessionID, "plan"); err != nil {
+		t.Fatalf("HandleModeCommand(plan) error = %v", err)
+	}
+	shown, err := chatService.SystemPrompt(sessionID)
+	if err != nil {
+		t.Fatalf("SystemPrompt() error = %v", err)
+	}
+	if got := layerNamesOf(shown); got != "base,memory,mode" {
+		t.Errorf("layers in plan mode = %s, want base,memory,mode", got)
+	}
+	if planned := send(); strings.Count(planned.Prompt, "# PLAN MODE") != 1 || planned.Prompt != shown.Text {
+		t.Errorf("plan mode request prompt should be the shown prompt with one plan mode layer:\n%s", planned.Prompt)
+	}
+
+	// Layers registered at runtime reach existing sessions on their next message
+	chatService.SetPromptLayer(usecase.PromptLayer{Name: "team", Order: 150, Content: "Team conventions."})
+	chatService.RemovePromptLayer(usecase.PromptLayerMemory)
+	if err := chatService.HandleModeCommand(ctx, sessionID, "normal"); err != nil {
+		t.Fatalf("HandleModeCommand(normal) error = %v", err)
+	}
+	shown, _ = chatService.SystemPrompt(sessionID)
+	if got := layerNamesOf(shown); got != "base,team" {
+		t.Errorf("layers after runtime changes = %s, want base,team", got)
+	}
+	if normal := send(); normal.Prompt != shown.Text || strings.Contains(normal.Prompt, "PLAN MODE") {
+		t.Errorf("normal mode request prompt = %q, want %q", normal.Prompt, shown.Text)
+	}
+
+	// A custom prompt set directly replaces the layers
+	if err := convService.SetCustomSystemPrompt(ctx, sessionID, "You are a release assistant."); err != nil {
+		t.Fatalf("SetCustomSystemPrompt() error = %v", err)
+	}
+	if custom := send(); custom.Composed || custom.Prompt != "You are a release assistant." {
+		t.Errorf("request prompt after SetCustomSystemPrompt = %+v, want the custom prompt", custom)
+	}
+	shown, _ = chatService.SystemPrompt(sessionID)
+	if got := layerNamesOf(shown); got != "custom" {
+		t.Errorf("layers with a custom prompt = %s, want custom", got)
+	}
+
+	if _, err := chatService.SystemPrompt("missing"); err == nil {
+		t.Error("SystemPrompt() of an unknown session should fail")
+	}
+}
+
+// layerNamesOf returns the prompt's layer names joined by commas.
+func layerNamesOf(prompt usecase.ComposedPrompt) string {
+	names := make([]string, len(prompt.Layers))
+	for i, layer := range prompt.Layers {
+		names[i] = layer.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// changingLayerSource is a prompt layer source whose content tests change.
+type changingLayerSource struct {
+	content string
+}
+
+func (s *changingLayerSource) PromptLayer() usecase.PromptLayer {
+	return usecase.ProjectPromptLayer(s.content)
+}
+
+func TestChatService_PromptLayerSourceRefreshesEachMessage(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	aiProvider := &systemPromptRecordingAIProvider{
+		mockAIProviderForChat: mockAIProviderForChat{
+			response: &entity.Message{Role: entity.RoleAssistant, Content: "Done."},
+		},
+	}
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard),
+		aiProvider, toolExecutor, fileManager)
+	source := &changingLayerSource{content: "- Language: Go"}
+	chatService.AddPromptLayerSource(source)
+
+	ctx := context.Background()
+	startResp, _ := chatService.StartSession(ctx, "")
+	if _, err := chatService.SendMessage(ctx, startResp.SessionID, "hello"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	got := aiProvider.prompts[0].Prompt
+	if !strings.Contains(got, "# Project") || !strings.Contains(got, "- Language: Go") {
+		t.Errorf("request prompt = %q, want the project layer", got)
+	}
+
+	source.content = "- Language: Node.js"
+	if _, err := chatService.SendMessage(ctx, startResp.SessionID, "hello again"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	got = aiProvider.prompts[1].Prompt
+	if !strings.Contains(got, "- Language: Node.js") || strings.Contains(got, "- Language: Go") {
+		t.Errorf("request prompt after the source changed = %q, want only the new facts", got)
+	}
+	shown, _ := chatService.SystemPrompt(startResp.SessionID)
+	if got := layerNamesOf(shown); got != "base,project" {
+		t.Errorf("layers = %s, want base,project", got)
+	}
+}
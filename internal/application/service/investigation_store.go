@@ -2,8 +2,12 @@
 package service
 
 import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,8 +15,9 @@ import (
 // Sentinel errors for InvestigationStore operations.
 // These errors are returned when store operations fail.
 var (
-	// ErrInvestigationNotFound is returned when a requested investigation does not exist.
-	ErrInvestigationNotFound = errors.New("investigation not found")
+	// ErrInvestigationNotFound is returned when a requested investigation does
+	// not exist. It is port.ErrInvestigationNotFound, so adapters can match it.
+	ErrInvestigationNotFound = port.ErrInvestigationNotFound
 	// ErrDuplicateInvestigationID is returned when attempting to store an investigation
 	// with an ID that already exists in the store.
 	ErrDuplicateInvestigationID = errors.New("investigation ID already exists")
@@ -30,11 +35,36 @@ type InvestigationQuery struct {
 	AlertID   string    // Filter by alert ID (exact match)
 	SessionID string    // Filter by session ID (exact match)
 	Status    []string  // Filter by status (matches any in list)
+	Severity  string    // Filter by the alert's severity (records without an alert never match)
+	ErrorKind string    // Filter by the class of failure (exact match)
 	Since     time.Time // Filter by start time >= Since
 	Until     time.Time // Filter by start time <= Until
 	Limit     int       // Maximum results to return (0 = unlimited)
 }
 
+// InvestigationPage selects a page of List results.
+type InvestigationPage struct {
+	Offset int // Number of matching investigations to skip
+	Limit  int // Maximum results to return (0 = unlimited)
+}
+
+// PageInvestigations sorts investigations newest first (by start time, then ID)
+// and returns the requested page of them. It sorts records in place.
+func PageInvestigations(records []*InvestigationRecord, page InvestigationPage) []*InvestigationRecord {
+	slices.SortFunc(records, func(a, b *InvestigationRecord) int {
+		if c := b.StartedAt().Compare(a.StartedAt()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID(), b.ID())
+	})
+	start := min(max(page.Offset, 0), len(records))
+	end := len(records)
+	if page.Limit > 0 {
+		end = min(start+page.Limit, end)
+	}
+	return records[start:end]
+}
+
 // InvestigationRecord represents an investigation record for storage.
 // It contains fields for both metadata and full investigation results.
 type InvestigationRecord struct {
@@ -44,13 +74,22 @@ type InvestigationRecord struct {
 	status    string    // Current status
 	startedAt time.Time // When the investigation began
 	// Full result fields
-	completedAt    time.Time // When the investigation finished
-	findings       []string  // Summary of findings discovered
-	actionsTaken   int       // Number of tool executions performed
-	durationNanos  int64     // Duration in nanoseconds (serializable)
-	confidence     float64   // Confidence level [0.0, 1.0]
-	escalated      bool      // Whether escalated to human
-	escalateReason string    // Reason for escalation
+	completedAt    time.Time                   // When the investigation finished
+	findings       []string                    // Summary of findings discovered
+	actionsTaken   int                         // Number of tool executions performed
+	durationNanos  int64                       // Duration in nanoseconds (serializable)
+	confidence     float64                     // Confidence level [0.0, 1.0]
+	escalated      bool                        // Whether escalated to human
+	escalateReason string                      // Reason for escalation
+	errorMessage   string                      // Why the investigation did not finish, if it failed
+	errorKind      string                      // Class of the failure, if it failed
+	alert          *entity.Alert               // The investigated alert, if recorded
+	rootCause      string                      // Root cause reported on completion
+	actions        []entity.RecommendedAction  // Recommended actions reported on completion
+	remediations   []entity.RemediationAttempt // Recommended commands run to remediate the alert
+	cost           float64                     // Estimated AI spend in US dollars
+	usage          entity.TokenUsage
+	version        string // Build of the agent that produced the record
 }
 
 // NewInvestigationRecord creates a new InvestigationRecord with the given parameters.
@@ -136,6 +175,110 @@ func (i *InvestigationRecord) Escalated() bool { return i.escalated }
 // EscalateReason returns the reason for escalation, if applicable.
 func (i *InvestigationRecord) EscalateReason() string { return i.escalateReason }
 
+// ErrorMessage returns why the investigation did not finish, if applicable.
+func (i *InvestigationRecord) ErrorMessage() string { return i.errorMessage }
+
+// ErrorKind returns the class of the failure, if applicable.
+func (i *InvestigationRecord) ErrorKind() string { return i.errorKind }
+
+// Alert returns the investigated alert, or nil if it was not recorded.
+func (i *InvestigationRecord) Alert() *entity.Alert { return i.alert }
+
+// WithAlert returns a copy of the record with the given alert.
+func (i *InvestigationRecord) WithAlert(alert *entity.Alert) *InvestigationRecord {
+	withAlert := *i
+	withAlert.alert = alert
+	return &withAlert
+}
+
+// RootCause returns the root cause reported on completion, if any.
+func (i *InvestigationRecord) RootCause() string { return i.rootCause }
+
+// RecommendedActions returns the actions recommended on completion, if any.
+func (i *InvestigationRecord) RecommendedActions() []entity.RecommendedAction { return i.actions }
+
+// WithResolution returns a copy of the record with the given root cause and
+// recommended actions.
+func (i *InvestigationRecord) WithResolution(
+	rootCause string,
+	actions []entity.RecommendedAction,
+) *InvestigationRecord {
+	withResolution := *i
+	withResolution.rootCause = rootCause
+	withResolution.actions = actions
+	return &withResolution
+}
+
+// Remediations returns the recommended commands the investigation tried to
+// carry out, if any.
+func (i *InvestigationRecord) Remediations() []entity.RemediationAttempt { return i.remediations }
+
+// WithRemediations returns a copy of the record with the given remediation
+// attempts.
+func (i *InvestigationRecord) WithRemediations(attempts []entity.RemediationAttempt) *InvestigationRecord {
+	withRemediations := *i
+	withRemediations.remediations = attempts
+	return &withRemediations
+}
+
+// Cost returns the estimated AI spend of the investigation in US dollars.
+func (i *InvestigationRecord) Cost() float64 { return i.cost }
+
+// Usage returns the tokens of the investigation's AI turns.
+func (i *InvestigationRecord) Usage() entity.TokenUsage { return i.usage }
+
+// WithUsage returns a copy of the record with the given cost and token usage.
+func (i *InvestigationRecord) WithUsage(cost float64, usage entity.TokenUsage) *InvestigationRecord {
+	withUsage := *i
+	withUsage.cost = cost
+	withUsage.usage = usage
+	return &withUsage
+}
+
+// Version returns the build of the agent that produced the record, or "" for
+// records stored before builds were recorded.
+func (i *InvestigationRecord) Version() string { return i.version }
+
+// WithVersion returns a copy of the record with the given agent build.
+func (i *InvestigationRecord) WithVersion(version string) *InvestigationRecord {
+	withVersion := *i
+	withVersion.version = version
+	return &withVersion
+}
+
+// WithErrorMessage returns a copy of the record with the given error message.
+func (i *InvestigationRecord) WithErrorMessage(msg string) *InvestigationRecord {
+	withErr := *i
+	withErr.errorMessage = msg
+	return &withErr
+}
+
+// WithErrorKind returns a copy of the record with the given error kind.
+func (i *InvestigationRecord) WithErrorKind(kind string) *InvestigationRecord {
+	withKind := *i
+	withKind.errorKind = kind
+	return &withKind
+}
+
+// Outcomes of a result notification delivery attempt.
+const (
+	DeliveryDelivered = "delivered" // The endpoint accepted the result
+	DeliveryRetrying  = "retrying"  // The attempt failed and will be retried
+	DeliveryFailed    = "failed"    // The attempt failed and no retry follows
+	DeliveryDropped   = "dropped"   // The notification queue was full; nothing was sent
+)
+
+// DeliveryAttempt records one attempt to push an investigation result to an
+// external endpoint.
+type DeliveryAttempt struct {
+	URL        string    `json:"url"`
+	Attempt    int       `json:"attempt"`               // 1-based; 0 when dropped
+	At         time.Time `json:"at"`                    // When the attempt finished
+	StatusCode int       `json:"status_code,omitempty"` // 0 when no response was received
+	Error      string    `json:"error,omitempty"`
+	Outcome    string    `json:"outcome"` // One of the Delivery* outcomes
+}
+
 // InvestigationStore defines the interface for investigation persistence.
 // Implementations must be safe for concurrent access from multiple goroutines.
 // All methods respect context cancellation and return context.Canceled or
@@ -151,6 +294,9 @@ type InvestigationStore interface {
 	Delete(ctx context.Context, id string) error
 	// Query returns investigations matching the filter criteria.
 	Query(ctx context.Context, query InvestigationQuery) ([]*InvestigationRecord, error)
+	// List returns a page of the investigations matching the filter criteria,
+	// newest first, and the total number that match. query.Limit is ignored.
+	List(ctx context.Context, query InvestigationQuery, page InvestigationPage) ([]*InvestigationRecord, int, error)
 	// Count returns the total number of stored investigations.
 	Count(ctx context.Context) (int, error)
 	// Close releases resources and prevents further operations.
@@ -330,6 +476,22 @@ func (s *InMemoryInvestigationStore) Query(
 	return results, nil
 }
 
+// List returns a page of the investigations matching the filter criteria,
+// newest first, and the total number that match. query.Limit is ignored.
+// Returns ErrInvestigationStoreShutdown if the store has been closed.
+func (s *InMemoryInvestigationStore) List(
+	ctx context.Context,
+	query InvestigationQuery,
+	page InvestigationPage,
+) ([]*InvestigationRecord, int, error) {
+	query.Limit = 0
+	results, err := s.Query(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return PageInvestigations(results, page), len(results), nil
+}
+
 // Count returns the total number of investigations in the store.
 // Returns ErrInvestigationStoreShutdown if the store has been closed.
 func (s *InMemoryInvestigationStore) Count(ctx context.Context) (int, error) {
@@ -367,6 +529,12 @@ func matchesQuery(inv *InvestigationRecord, query InvestigationQuery) bool {
 	if query.SessionID != "" && inv.sessionID != query.SessionID {
 		return false
 	}
+	if query.Severity != "" && (inv.alert == nil || inv.alert.Severity() != query.Severity) {
+		return false
+	}
+	if query.ErrorKind != "" && inv.errorKind != query.ErrorKind {
+		return false
+	}
 	if len(query.Status) > 0 {
 		matched := false
 		for _, s := range query.Status {
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"code-editing-agent/internal/domain/entity"
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 )
@@ -593,6 +595,86 @@ func TestInMemoryInvestigationStore_Query_CombinedFilters(t *testing.T) {
 	}
 }
 
+func TestInMemoryInvestigationStore_Query_BySeverity(t *testing.T) {
+	store := NewInMemoryInvestigationStore()
+	ctx := context.Background()
+
+	critical, _ := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Down")
+	warning, _ := entity.NewAlert("alert-2", "prometheus", entity.SeverityWarning, "Slow")
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-1", "alert-1", "", "completed").WithAlert(critical))
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-2", "alert-2", "", "completed").WithAlert(warning))
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-3", "alert-3", "", "completed"))
+
+	results, err := store.Query(ctx, InvestigationQuery{Severity: entity.SeverityCritical})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID() != "inv-1" {
+		t.Errorf("Query() = %v, want only inv-1", results)
+	}
+}
+
+func TestInMemoryInvestigationStore_Query_ByErrorKind(t *testing.T) {
+	store := NewInMemoryInvestigationStore()
+	ctx := context.Background()
+
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-1", "alert-1", "", "failed").
+		WithErrorMessage("AI provider unavailable: EOF").WithErrorKind("provider_unavailable"))
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-2", "alert-2", "", "failed").WithErrorKind("prompt_build"))
+	_ = store.Store(ctx, NewInvestigationRecordForTest("inv-3", "alert-3", "", "completed"))
+
+	results, err := store.Query(ctx, InvestigationQuery{ErrorKind: "provider_unavailable"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID() != "inv-1" || results[0].ErrorKind() != "provider_unavailable" {
+		t.Errorf("Query() = %v, want only inv-1", results)
+	}
+}
+
+func TestInMemoryInvestigationStore_List(t *testing.T) {
+	store := NewInMemoryInvestigationStore()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, id := range []string{"inv-a", "inv-b", "inv-c", "inv-d"} {
+		status := "completed"
+		if id == "inv-c" {
+			status = "failed"
+		}
+		_ = store.Store(ctx, NewInvestigationRecordForTestWithTime(id, "alert-1", "", status, base.Add(time.Duration(i)*time.Hour)))
+	}
+
+	tests := []struct {
+		name      string
+		query     InvestigationQuery
+		page      InvestigationPage
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"newest first", InvestigationQuery{}, InvestigationPage{}, []string{"inv-d", "inv-c", "inv-b", "inv-a"}, 4},
+		{"first page", InvestigationQuery{}, InvestigationPage{Limit: 3}, []string{"inv-d", "inv-c", "inv-b"}, 4},
+		{"second page", InvestigationQuery{}, InvestigationPage{Offset: 3, Limit: 3}, []string{"inv-a"}, 4},
+		{"past the end", InvestigationQuery{}, InvestigationPage{Offset: 9}, []string{}, 4},
+		{"query limit ignored", InvestigationQuery{Status: []string{"completed"}, Limit: 1}, InvestigationPage{},
+			[]string{"inv-d", "inv-b", "inv-a"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := store.List(ctx, tt.query, tt.page)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			ids := make([]string, 0, len(results))
+			for _, r := range results {
+				ids = append(ids, r.ID())
+			}
+			if !slices.Equal(ids, tt.wantIDs) || total != tt.wantTotal {
+				t.Errorf("List() = %v of %d, want %v of %d", ids, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
 // =============================================================================
 // Context Cancellation Tests
 // =============================================================================
@@ -4,6 +4,7 @@ import (
 	"code-editing-agent/internal/application/config"
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -25,7 +26,8 @@ var (
 type SafetyEnforcer interface {
 	// CheckToolAllowed verifies that a tool is permitted.
 	CheckToolAllowed(tool string) error
-	// CheckCommandAllowed verifies that a command does not match blocked patterns.
+	// CheckCommandAllowed verifies that a command does not match blocked patterns
+	// and, in allowlist mode, that it matches the allowed ones.
 	CheckCommandAllowed(cmd string) error
 	// CheckActionBudget verifies that the action budget is not exhausted.
 	CheckActionBudget(currentActions int) error
@@ -59,7 +61,9 @@ func (e *InvestigationSafetyEnforcer) CheckToolAllowed(tool string) error {
 	return nil
 }
 
-// CheckCommandAllowed returns ErrCommandBlocked if the command matches a blocked pattern.
+// CheckCommandAllowed returns ErrCommandBlocked if the command matches a blocked
+// pattern or, when the config has allowed command patterns, does not match them.
+// An allowlist refusal also wraps the reason, which lists the allowed patterns.
 func (e *InvestigationSafetyEnforcer) CheckCommandAllowed(cmd string) error {
 	// Normalize whitespace (tabs, newlines -> spaces) for pattern matching
 	normalized := strings.Map(func(r rune) rune {
@@ -73,6 +77,9 @@ func (e *InvestigationSafetyEnforcer) CheckCommandAllowed(cmd string) error {
 			return ErrCommandBlocked
 		}
 	}
+	if err := e.cfg.CheckCommandAllowlisted(cmd); err != nil {
+		return fmt.Errorf("%w: %w", ErrCommandBlocked, err)
+	}
 	return nil
 }
 
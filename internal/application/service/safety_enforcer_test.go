@@ -2,8 +2,10 @@ package service
 
 import (
 	"code-editing-agent/internal/application/config"
+	"code-editing-agent/internal/domain/safety"
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -292,6 +294,47 @@ func TestInvestigationSafetyEnforcer_CheckCommandAllowed_CustomBlockedPatterns(t
 	}
 }
 
+func TestInvestigationSafetyEnforcer_CheckCommandAllowed_AllowlistMode(t *testing.T) {
+	cfg := config.DefaultInvestigationConfig()
+	if err := cfg.SetAllowedCommandPatterns([]string{`^(ps|df|journalctl)\b`, `^grep\b`}); err != nil {
+		t.Fatalf("SetAllowedCommandPatterns() error = %v", err)
+	}
+	enforcer, err := NewInvestigationSafetyEnforcer(cfg)
+	if err != nil {
+		t.Fatalf("NewInvestigationSafetyEnforcer() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		allowed bool
+	}{
+		{"allowed command", "df -h", true},
+		{"allowed pipeline", "ps aux | grep nginx", true},
+		{"command not in list", "ls -la", false},
+		{"disallowed segment in chain", "journalctl -u app && reboot", false},
+		{"command substitution", "grep $(cat pattern) app.log", false},
+		{"blocked pattern still applies", "grep x; rm -rf /", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enforcer.CheckCommandAllowed(tt.command)
+			if tt.allowed && err != nil {
+				t.Errorf("CheckCommandAllowed(%q) error = %v, want nil", tt.command, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrCommandBlocked) {
+				t.Errorf("CheckCommandAllowed(%q) error = %v, want ErrCommandBlocked", tt.command, err)
+			}
+		})
+	}
+
+	err = enforcer.CheckCommandAllowed("ls -la")
+	if !errors.Is(err, safety.ErrCommandNotAllowed) || !strings.Contains(err.Error(), `^grep\b`) {
+		t.Errorf("CheckCommandAllowed() error = %v, want ErrCommandNotAllowed listing the allowed patterns", err)
+	}
+}
+
 // =============================================================================
 // CheckActionBudget Tests
 // =============================================================================
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrSessionNotOpen is returned when switching to a session that is not open.
+var ErrSessionNotOpen = errors.New("no such open session")
+
+// SessionTab describes one of the sessions open in a SessionMultiplexer.
+type SessionTab struct {
+	Number    int // Position in the order the sessions were opened, from 1
+	SessionID string
+	Title     string // Empty until the session is named
+	Active    bool
+	Pending   int // Outputs of the session waiting to be shown
+}
+
+// Label names the tab in the prompt: its number, and its title once it has one.
+func (t SessionTab) Label() string {
+	if t.Title == "" {
+		return strconv.Itoa(t.Number)
+	}
+	return fmt.Sprintf("%d: %s", t.Number, t.Title)
+}
+
+// SessionMultiplexer keeps several chat sessions open in one process, one of
+// them active. Each session keeps its own history, plan mode, and usage, since
+// those are per session in ChatService; tools, skills, and the provider are
+// shared. Only the active session's output reaches the chat's user interface:
+// the others write to a buffer, which is shown when they are switched to. It
+// is safe for concurrent use, so a turn can finish in the background.
+type SessionMultiplexer struct {
+	chat     *ChatService
+	terminal port.UserInterface
+
+	mu       sync.Mutex
+	sessions []string                  // Open sessions, in the order they were opened
+	buffers  map[string]*sessionBuffer // Output of the background sessions
+	active   string
+}
+
+// NewSessionMultiplexer creates a multiplexer whose only open session is the
+// active sessionID.
+func NewSessionMultiplexer(chat *ChatService, sessionID string) *SessionMultiplexer {
+	return &SessionMultiplexer{
+		chat:     chat,
+		terminal: chat.userInterface,
+		sessions: []string{sessionID},
+		buffers:  make(map[string]*sessionBuffer),
+		active:   sessionID,
+	}
+}
+
+// Active returns the ID of the active session.
+func (m *SessionMultiplexer) Active() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// Tabs returns the open sessions in the order they were opened.
+func (m *SessionMultiplexer) Tabs() []SessionTab {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tabs := make([]SessionTab, len(m.sessions))
+	for i, id := range m.sessions {
+		tabs[i] = m.tab(i)
+		if buffer, ok := m.buffers[id]; ok {
+			tabs[i].Pending = buffer.pending()
+		}
+	}
+	return tabs
+}
+
+// New starts a session and makes it the active one. It backs the :new command.
+func (m *SessionMultiplexer) New(ctx context.Context) (SessionTab, error) {
+	resp, err := m.chat.StartSession(ctx, "")
+	if err != nil {
+		return SessionTab{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions = append(m.sessions, resp.SessionID)
+	return m.activate(len(m.sessions) - 1), nil
+}
+
+// Switch makes the open session named by ref active and shows the output it
+// wrote in the background. ref is the session's tab number, its title (ignoring
+// case), or its ID. It backs the :switch command.
+//
+// Returns:
+//   - error: ErrSessionNotOpen if no open session matches ref, or an error if
+//     several sessions have that title
+func (m *SessionMultiplexer) Switch(ref string) (SessionTab, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	index, err := m.find(strings.TrimSpace(ref))
+	if err != nil {
+		return SessionTab{}, err
+	}
+	return m.activate(index), nil
+}
+
+// RefreshLabel shows the active session's label in the prompt again, for
+// after the session was named. The label is only shown while more than one
+// session is open.
+func (m *SessionMultiplexer) RefreshLabel() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, id := range m.sessions {
+		if id == m.active {
+			m.showLabel(m.tab(i))
+		}
+	}
+}
+
+// find returns the index of the open session named by ref. The caller holds m.mu.
+func (m *SessionMultiplexer) find(ref string) (int, error) {
+	if number, err := strconv.Atoi(ref); err == nil {
+		if number < 1 || number > len(m.sessions) {
+			return 0, fmt.Errorf("%w: %d (open: 1-%d)", ErrSessionNotOpen, number, len(m.sessions))
+		}
+		return number - 1, nil
+	}
+
+	found := -1
+	for i, id := range m.sessions {
+		if id == ref {
+			return i, nil
+		}
+		if title := m.chat.conversationService.GetSessionTitle(id); title != "" && strings.EqualFold(title, ref) {
+			if found >= 0 {
+				return 0, fmt.Errorf("several open sessions are titled %q; switch by number", ref)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrSessionNotOpen, ref)
+	}
+	return found, nil
+}
+
+// activate makes the session at index active: the previously active session
+// starts writing to a buffer, and the session's own buffer is shown and
+// dropped. The caller holds m.mu.
+func (m *SessionMultiplexer) activate(index int) SessionTab {
+	id := m.sessions[index]
+	if id != m.active {
+		buffer := &sessionBuffer{}
+		m.buffers[m.active] = buffer
+		m.chat.SetSessionUI(m.active, buffer)
+		// A turn still running in the background cannot stop its indicator
+		if indicator, ok := m.terminal.(port.ActivityIndicator); ok {
+			indicator.StopActivity()
+		}
+
+		// Flush before switching the output back, so that the session's
+		// output stays in order if its turn is still writing
+		if buffer, ok := m.buffers[id]; ok {
+			buffer.flush(m.terminal)
+			delete(m.buffers, id)
+		}
+		m.chat.SetSessionUI(id, nil)
+		m.active = id
+	}
+
+	if indicator, ok := m.terminal.(interface{ SetPlanMode(bool) }); ok {
+		planMode, _ := m.chat.conversationService.IsPlanMode(id)
+		indicator.SetPlanMode(planMode)
+	}
+	if sessionAware, ok := m.terminal.(interface{ SetSessionID(string) }); ok {
+		sessionAware.SetSessionID(id)
+	}
+	tab := m.tab(index)
+	m.showLabel(tab)
+	return tab
+}
+
+// tab describes the session at index. The caller holds m.mu.
+func (m *SessionMultiplexer) tab(index int) SessionTab {
+	id := m.sessions[index]
+	return SessionTab{
+		Number:    index + 1,
+		SessionID: id,
+		Title:     m.chat.conversationService.GetSessionTitle(id),
+		Active:    id == m.active,
+	}
+}
+
+// showLabel shows tab's label in the prompt if the user interface supports it
+// and more than one session is open, and gives the user interface the tab's
+// title to name the session in notifications. The caller holds m.mu.
+func (m *SessionMultiplexer) showLabel(tab SessionTab) {
+	if titled, ok := m.terminal.(interface{ SetSessionTitle(string) }); ok {
+		titled.SetSessionTitle(tab.Title)
+	}
+	labeler, ok := m.terminal.(interface{ SetSessionLabel(string) })
+	if !ok || len(m.sessions) < 2 {
+		return
+	}
+	labeler.SetSessionLabel(tab.Label())
+}
+
+// sessionBuffer is the user interface of a background session. It records the
+// session's output to replay on the chat's user interface when the session is
+// switched to, and reads no input: a background session cannot ask the user
+// anything.
+type sessionBuffer struct {
+	mu     sync.Mutex
+	output []func(port.UserInterface)
+	target port.UserInterface // Once flushed, output goes straight here
+}
+
+// Compile-time check that sessionBuffer implements port.UserInterface.
+var _ port.UserInterface = (*sessionBuffer)(nil)
+
+// record keeps one output call, or makes it on the target once flushed.
+func (b *sessionBuffer) record(call func(port.UserInterface)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.target != nil {
+		call(b.target)
+		return nil
+	}
+	b.output = append(b.output, call)
+	return nil
+}
+
+// flush replays the recorded output on target and sends any later output
+// straight to it.
+func (b *sessionBuffer) flush(target port.UserInterface) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, call := range b.output {
+		call(target)
+	}
+	b.output = nil
+	b.target = target
+}
+
+// pending returns the number of recorded outputs.
+func (b *sessionBuffer) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.output)
+}
+
+func (b *sessionBuffer) GetUserInput(context.Context) (string, bool) { return "", false }
+
+func (b *sessionBuffer) DisplayMessage(message, messageRole string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplayMessage(message, messageRole) })
+}
+
+func (b *sessionBuffer) BeginStreamingResponse() error {
+	return b.record(func(ui port.UserInterface) { _ = ui.BeginStreamingResponse() })
+}
+
+func (b *sessionBuffer) EndStreamingResponse() error {
+	return b.record(func(ui port.UserInterface) { _ = ui.EndStreamingResponse() })
+}
+
+func (b *sessionBuffer) DisplayStreamingText(text string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplayStreamingText(text) })
+}
+
+func (b *sessionBuffer) DisplayError(err error) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplayError(err) })
+}
+
+func (b *sessionBuffer) DisplayToolResult(toolName, input, result string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplayToolResult(toolName, input, result) })
+}
+
+// DisplayCachedToolResult implements port.CachedToolResultDisplay, replaying
+// as a plain tool result on user interfaces without it.
+func (b *sessionBuffer) DisplayCachedToolResult(toolName, input, result string) error {
+	return b.record(func(ui port.UserInterface) {
+		if cached, ok := ui.(port.CachedToolResultDisplay); ok {
+			_ = cached.DisplayCachedToolResult(toolName, input, result)
+			return
+		}
+		_ = ui.DisplayToolResult(toolName, input, result)
+	})
+}
+
+func (b *sessionBuffer) DisplaySystemMessage(message string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplaySystemMessage(message) })
+}
+
+func (b *sessionBuffer) DisplayThinking(content string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplayThinking(content) })
+}
+
+func (b *sessionBuffer) DisplaySubagentStatus(agentName, status, details string) error {
+	return b.record(func(ui port.UserInterface) { _ = ui.DisplaySubagentStatus(agentName, status, details) })
+}
+
+func (b *sessionBuffer) SetPrompt(string) error                               { return nil }
+func (b *sessionBuffer) ClearScreen() error                                   { return nil }
+func (b *sessionBuffer) SetColorScheme(port.ColorScheme) error                { return nil }
+func (b *sessionBuffer) ConfirmBashCommand(string, bool, string, string) bool { return false }
+func (b *sessionBuffer) ConfirmFileEdit(string, string) bool                  { return false }
+
+// ConfirmToolBatch denies every call: a session in the background cannot ask.
+func (b *sessionBuffer) ConfirmToolBatch(calls []port.ToolConfirmation) []bool {
+	return make([]bool, len(calls))
+}
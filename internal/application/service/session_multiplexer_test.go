@@ -0,0 +1,209 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	serviceDomain "code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lockedOutput is a strings.Builder safe for writes from a background turn.
+type lockedOutput struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (o *lockedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.b.Write(p)
+}
+
+func (o *lockedOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.b.String()
+}
+
+// gatedAIProvider answers each streamed request with its reply, after waiting
+// for release when gated is set.
+type gatedAIProvider struct {
+	mockAIProviderForChat
+	mu      sync.Mutex
+	reply   string
+	gated   bool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *gatedAIProvider) SendMessageStreaming(
+	_ context.Context,
+	_ []port.MessageParam,
+	_ []port.ToolParam,
+	textCallback port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.mu.Lock()
+	reply, gated := m.reply, m.gated
+	m.mu.Unlock()
+	if gated {
+		m.started <- struct{}{}
+		<-m.release
+	}
+	_ = textCallback(reply)
+	return &entity.Message{Role: entity.RoleAssistant, Content: reply}, nil, nil
+}
+
+func (m *gatedAIProvider) answer(reply string, gated bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reply, m.gated = reply, gated
+}
+
+func newMultiplexedChat(t *testing.T) (*SessionMultiplexer, *ChatService, *serviceDomain.ConversationService,
+	*gatedAIProvider, *ui.CLIAdapter, *lockedOutput,
+) {
+	t.Helper()
+	fileManager := file.NewLocalFileManager(t.TempDir())
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	output := &lockedOutput{}
+	terminal := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+	aiProvider := &gatedAIProvider{started: make(chan struct{}), release: make(chan struct{})}
+	convService, _ := serviceDomain.NewConversationService(aiProvider, toolExecutor)
+	chatService, _ := NewChatServiceFromDomain(convService, terminal, aiProvider, toolExecutor, fileManager)
+
+	startResp, err := chatService.StartSession(context.Background(), "")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	return NewSessionMultiplexer(chatService, startResp.SessionID), chatService, convService, aiProvider, terminal, output
+}
+
+func TestSessionMultiplexer_NewAndSwitchKeepSessionsApart(t *testing.T) {
+	sessions, chatService, convService, aiProvider, terminal, _ := newMultiplexedChat(t)
+	ctx := context.Background()
+	first := sessions.Active()
+
+	aiProvider.answer("first reply", false)
+	if _, err := chatService.SendMessage(ctx, first, "first task"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if err := chatService.HandleModeCommand(ctx, first, "plan"); err != nil {
+		t.Fatalf("HandleModeCommand() error = %v", err)
+	}
+	if err := chatService.RenameSession(ctx, first, "Fix tests"); err != nil {
+		t.Fatalf("RenameSession() error = %v", err)
+	}
+
+	tab, err := sessions.New(ctx)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	second := tab.SessionID
+	if tab.Number != 2 || second == first || sessions.Active() != second {
+		t.Fatalf("New() = %+v, want a second, active session", tab)
+	}
+	if got := terminal.GetPrompt(); !strings.HasPrefix(got, "[2] > ") || !strings.HasSuffix(got, "["+second+"]") {
+		t.Errorf("prompt in the new session = %q, want its label and no plan mode", got)
+	}
+	if planMode, _ := convService.IsPlanMode(second); planMode {
+		t.Error("the new session should not inherit plan mode")
+	}
+
+	aiProvider.answer("second reply", false)
+	if _, err := chatService.SendMessage(ctx, second, "second task"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	conv1, _ := convService.GetConversation(first)
+	conv2, _ := convService.GetConversation(second)
+	if conv1.MessageCount() != 2 || conv2.MessageCount() != 2 {
+		t.Errorf("message counts = %d, %d, want 2 each", conv1.MessageCount(), conv2.MessageCount())
+	}
+	if got := conv2.GetMessages()[0].Content; got != "second task" {
+		t.Errorf("second session's first message = %q", got)
+	}
+
+	tab, err = sessions.Switch("fix TESTS")
+	if err != nil {
+		t.Fatalf("Switch(title) error = %v", err)
+	}
+	if tab.Number != 1 || sessions.Active() != first {
+		t.Errorf("Switch(title) = %+v, want session 1", tab)
+	}
+	if got := terminal.GetPrompt(); !strings.HasPrefix(got, "[PLAN MODE] [1: Fix tests] > ") ||
+		!strings.HasSuffix(got, "["+first+"]") {
+		t.Errorf("prompt after switching back = %q, want its plan mode and title", got)
+	}
+	if _, err := sessions.Switch("2"); err != nil || sessions.Active() != second {
+		t.Errorf("Switch(2) error = %v, active = %s", err, sessions.Active())
+	}
+
+	tabs := sessions.Tabs()
+	if len(tabs) != 2 || tabs[0].Title != "Fix tests" || tabs[0].Active || !tabs[1].Active {
+		t.Errorf("Tabs() = %+v", tabs)
+	}
+	for _, ref := range []string{"3", "0", "no such title"} {
+		if _, err := sessions.Switch(ref); !errors.Is(err, ErrSessionNotOpen) {
+			t.Errorf("Switch(%q) error = %v, want ErrSessionNotOpen", ref, err)
+		}
+	}
+}
+
+func TestSessionMultiplexer_BackgroundTurnIsBufferedUntilSwitchedTo(t *testing.T) {
+	sessions, chatService, convService, aiProvider, _, output := newMultiplexedChat(t)
+	ctx := context.Background()
+	first := sessions.Active()
+
+	aiProvider.answer("background reply", true)
+	done := make(chan error, 1)
+	go func() {
+		_, err := chatService.SendMessage(ctx, first, "long task")
+		done <- err
+	}()
+	<-aiProvider.started
+
+	if _, err := sessions.New(ctx); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	close(aiProvider.release)
+	if err := <-done; err != nil {
+		t.Fatalf("background SendMessage() error = %v", err)
+	}
+
+	if strings.Contains(output.String(), "background reply") {
+		t.Fatalf("a background session's reply reached the terminal:\n%s", output.String())
+	}
+	conv, _ := convService.GetConversation(first)
+	if conv.MessageCount() != 2 {
+		t.Errorf("background turn should finish into its own session, got %d messages", conv.MessageCount())
+	}
+	if tabs := sessions.Tabs(); tabs[0].Pending == 0 {
+		t.Errorf("Tabs()[0].Pending = 0, want the buffered output counted")
+	}
+
+	if _, err := sessions.Switch("1"); err != nil {
+		t.Fatalf("Switch(1) error = %v", err)
+	}
+	if !strings.Contains(output.String(), "background reply") {
+		t.Errorf("switching back should show the buffered reply, got:\n%s", output.String())
+	}
+	if tabs := sessions.Tabs(); tabs[0].Pending != 0 {
+		t.Errorf("Tabs()[0].Pending = %d after switching to it, want 0", tabs[0].Pending)
+	}
+
+	// The active session writes straight to the terminal again
+	aiProvider.answer("foreground reply", false)
+	if _, err := chatService.SendMessage(ctx, first, "next"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if !strings.Contains(output.String(), "foreground reply") {
+		t.Error("the active session's reply should reach the terminal")
+	}
+}
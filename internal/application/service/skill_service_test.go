@@ -70,6 +70,14 @@ func (m *mockSkillManager) ValidateSkills(ctx context.Context) (map[string]error
 	return map[string]error{}, nil
 }
 
+func (m *mockSkillManager) ListSkills() []port.SkillInfo {
+	return []port.SkillInfo{}
+}
+
+func (m *mockSkillManager) GetSkillContent(_ string) (string, error) {
+	return "", nil
+}
+
 func TestNewSkillService_NilSkillManager(t *testing.T) {
 	_, err := NewSkillService(nil)
 
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"sync"
+	"time"
+)
+
+// backfillRetryInterval is how long a historical alert waits before trying
+// again for a slot, when every slot it may take is in use.
+const backfillRetryInterval = 5 * time.Second
+
+// Backfill investigates past alerts as historical ones; see
+// port.AlertBackfiller. Each alert goes through the same checks as Handle,
+// and its investigation is stored like any other.
+//
+// Returns ErrNilUseCase if the investigation use case is nil.
+func (h *AlertHandler) Backfill(
+	ctx context.Context,
+	alerts []*entity.Alert,
+	concurrency int,
+	done func(port.BackfillOutcome),
+) error {
+	if h.investigationUseCase == nil {
+		return ErrNilUseCase
+	}
+
+	next := make(chan *entity.Alert)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), len(alerts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for alert := range next {
+				outcome := h.backfillAlert(ctx, alert)
+				if done != nil {
+					mu.Lock()
+					done(outcome)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, alert := range alerts {
+		select {
+		case next <- alert:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// backfillAlert investigates one past alert as a historical one.
+func (h *AlertHandler) backfillAlert(ctx context.Context, alert *entity.Alert) port.BackfillOutcome {
+	started := h.now()
+	outcome := port.BackfillOutcome{}
+	finish := func(status string, err error) port.BackfillOutcome {
+		outcome.Status, outcome.Err, outcome.Duration = status, err, h.now().Sub(started)
+		return outcome
+	}
+
+	if alert == nil {
+		return finish("failed", ErrNilAlert)
+	}
+	outcome.AlertID = alert.ID()
+	invAlert := NewAlertForInvestigationFromEntity(alert)
+	invAlert.historical = true
+	if err := validateAlert(invAlert); err != nil {
+		return finish("failed", err)
+	}
+
+	skipped, decision, err := h.screen(ctx, invAlert)
+	if err != nil {
+		return finish("failed", err)
+	}
+	if skipped != "" {
+		return finish(skipped, nil)
+	}
+
+	h.enrich(ctx, invAlert)
+	logger := h.logger.With("alert_id", invAlert.ID(), "historical", true)
+	logger.Info("Starting investigation", "title", invAlert.Title(), "severity", invAlert.Severity())
+	invID, err := h.startInvestigation(ctx, invAlert, decision)
+	if err != nil {
+		logger.Error("Investigation error", "error", err)
+		return finish("failed", err)
+	}
+	outcome.InvestigationID = invID
+
+	result, err := h.runInvestigation(ctx, logger.With("investigation_id", invID), invAlert, invID)
+	if err != nil {
+		return finish("failed", err)
+	}
+	return finish(result.Status, nil)
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAlertHandler_Backfill(t *testing.T) {
+	conv := newInvestigationRunnerConvServiceMock()
+	for range 2 {
+		conv.processResponseMessages = append(conv.processResponseMessages, createAssistantMessage("No issues found."))
+		conv.processResponseToolCalls = append(conv.processResponseToolCalls, nil)
+	}
+	notifier := &recordingResultNotifier{}
+	store := NewMockInvestigationStore()
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(conv)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetResultNotifier(notifier)
+	uc.SetInvestigationStore(store)
+	handler := NewAlertHandler(uc, AlertHandlerConfig{AutoInvestigateCritical: true, AutoInvestigateWarning: true})
+
+	var alerts []*entity.Alert
+	for _, a := range []struct{ id, severity string }{
+		{"HighCPU", entity.SeverityCritical},
+		{"DiskFull", entity.SeverityWarning},
+		{"Heartbeat", entity.SeverityInfo},
+	} {
+		alert, _ := entity.NewAlert(a.id, "backfill", a.severity, a.id)
+		alerts = append(alerts, alert)
+	}
+
+	var outcomes []port.BackfillOutcome
+	err := handler.Backfill(context.Background(), alerts, 1, func(outcome port.BackfillOutcome) {
+		outcomes = append(outcomes, outcome)
+	})
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+
+	want := []struct{ alertID, status string }{
+		{"HighCPU", "completed"}, {"DiskFull", "completed"}, {"Heartbeat", screenSkipped},
+	}
+	if len(outcomes) != len(want) {
+		t.Fatalf("outcomes = %+v, want %d", outcomes, len(want))
+	}
+	for i, w := range want {
+		if outcomes[i].AlertID != w.alertID || outcomes[i].Status != w.status || outcomes[i].Err != nil {
+			t.Errorf("outcomes[%d] = %+v, want %s %s", i, outcomes[i], w.alertID, w.status)
+		}
+	}
+	for _, outcome := range outcomes[:2] {
+		record, err := store.Get(context.Background(), outcome.InvestigationID)
+		if err != nil {
+			t.Fatalf("store.Get(%s) error = %v", outcome.InvestigationID, err)
+		}
+		if !record.Alert().Historical() {
+			t.Errorf("stored alert of %s is not historical", outcome.AlertID)
+		}
+	}
+	if len(notifier.results) != 0 {
+		t.Errorf("notifier got %d results, want none for historical alerts", len(notifier.results))
+	}
+}
+
+func TestAlertInvestigationUseCase_StartInvestigation_HistoricalLeavesSlotForLive(t *testing.T) {
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{MaxConcurrent: 2})
+	first := &AlertForInvestigation{id: "old-1", source: "backfill", severity: "critical", title: "Old", historical: true}
+	if _, err := uc.StartInvestigation(context.Background(), first); err != nil {
+		t.Fatalf("StartInvestigation(historical) error = %v", err)
+	}
+
+	second := &AlertForInvestigation{id: "old-2", source: "backfill", severity: "critical", title: "Old", historical: true}
+	if _, err := uc.StartInvestigation(context.Background(), second); !errors.Is(err, ErrMaxConcurrentReached) {
+		t.Errorf("StartInvestigation(second historical) error = %v, want ErrMaxConcurrentReached", err)
+	}
+
+	live := &AlertForInvestigation{id: "new-1", source: "prometheus", severity: "warning", title: "New"}
+	if _, err := uc.StartInvestigation(context.Background(), live); err != nil {
+		t.Errorf("StartInvestigation(live) error = %v, want the last slot", err)
+	}
+	if queued := uc.Status().Queued; len(queued) != 2 || queued[0].AlertID != "new-1" || !queued[1].Historical {
+		t.Errorf("Queued = %+v, want the live alert ahead of the historical one", queued)
+	}
+}
@@ -0,0 +1,234 @@
+// Package usecase contains application use cases that orchestrate domain logic.
+// This file implements the circuit breaker that stops a flapping alert source
+// from starting an unbounded number of investigations.
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of an alert source's circuit.
+type CircuitState string
+
+// Circuit states. A closed circuit investigates alerts, an open one defers
+// them, and a half-open one lets a single probe investigation through.
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitDecision is what the circuit breaker decided for an incoming alert.
+type CircuitDecision int
+
+const (
+	// CircuitAllow lets the alert be investigated.
+	CircuitAllow CircuitDecision = iota
+	// CircuitProbe lets the alert be investigated as the half-open probe,
+	// whose outcome closes or reopens the circuit.
+	CircuitProbe
+	// CircuitDefer records the alert as deferred without investigating it.
+	CircuitDefer
+)
+
+// AlertCircuitBreakerConfig configures an AlertCircuitBreaker.
+type AlertCircuitBreakerConfig struct {
+	Window    time.Duration // Rolling window investigations are counted over
+	Threshold int           // Investigations a source may start per window; one more opens its circuit
+	Cooldown  time.Duration // How long an open circuit defers alerts before probing
+}
+
+// AlertCircuitEvent describes an alert source whose circuit opened.
+type AlertCircuitEvent struct {
+	Source    string
+	OpenedAt  time.Time
+	ProbeAt   time.Time // When the circuit half-opens to probe
+	Threshold int
+	Window    time.Duration
+}
+
+// AlertCircuitNotifier is told when an alert source's circuit opens, once per
+// storm: reopening after a failed probe is not notified again. Implementations
+// must return without blocking on delivery.
+type AlertCircuitNotifier interface {
+	NotifyCircuitOpened(event AlertCircuitEvent)
+}
+
+// sourceCircuit is the circuit of one alert source.
+type sourceCircuit struct {
+	state    CircuitState
+	starts   []time.Time // Investigations started within the window, oldest first
+	openedAt time.Time
+	probeID  string // Investigation ID of the half-open probe, once started
+}
+
+// AlertCircuitBreaker limits how many investigations each alert source may
+// start per rolling window. When a source exceeds the threshold its circuit
+// opens and its alerts are deferred; after the cooldown the circuit half-opens
+// and a single probe investigation decides whether it closes again. It is safe
+// for concurrent use.
+type AlertCircuitBreaker struct {
+	config   AlertCircuitBreakerConfig
+	notifier AlertCircuitNotifier
+	now      func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*sourceCircuit
+}
+
+// NewAlertCircuitBreaker creates a circuit breaker with every circuit closed.
+func NewAlertCircuitBreaker(config AlertCircuitBreakerConfig) *AlertCircuitBreaker {
+	return &AlertCircuitBreaker{
+		config:   config,
+		now:      time.Now,
+		circuits: make(map[string]*sourceCircuit),
+	}
+}
+
+// SetNotifier sets who is told when a circuit opens. Without one, opening is
+// only logged by the alert handler.
+func (b *AlertCircuitBreaker) SetNotifier(notifier AlertCircuitNotifier) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.notifier = notifier
+}
+
+// Config returns the breaker's configuration.
+func (b *AlertCircuitBreaker) Config() AlertCircuitBreakerConfig {
+	return b.config
+}
+
+// State returns the state of a source's circuit.
+func (b *AlertCircuitBreaker) State(source string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[source]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Admit decides whether an alert from source may be investigated and counts
+// the investigations it allows. It reports whether this call opened the
+// circuit, in which case the notifier has been told.
+func (b *AlertCircuitBreaker) Admit(source string) (CircuitDecision, bool) {
+	b.mu.Lock()
+	now := b.now()
+	c, ok := b.circuits[source]
+	if !ok {
+		c = &sourceCircuit{state: CircuitClosed}
+		b.circuits[source] = c
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		decision := CircuitDefer
+		if !now.Before(c.openedAt.Add(b.config.Cooldown)) {
+			c.state = CircuitHalfOpen
+			c.probeID = ""
+			decision = CircuitProbe
+		}
+		b.mu.Unlock()
+		return decision, false
+	case CircuitHalfOpen:
+		// The probe is still running
+		b.mu.Unlock()
+		return CircuitDefer, false
+	}
+
+	cutoff := now.Add(-b.config.Window)
+	kept := c.starts[:0]
+	for _, start := range c.starts {
+		if start.After(cutoff) {
+			kept = append(kept, start)
+		}
+	}
+	c.starts = kept
+	if len(c.starts) < b.config.Threshold {
+		c.starts = append(c.starts, now)
+		b.mu.Unlock()
+		return CircuitAllow, false
+	}
+
+	c.state = CircuitOpen
+	c.openedAt = now
+	notifier := b.notifier
+	b.mu.Unlock()
+
+	if notifier != nil {
+		notifier.NotifyCircuitOpened(AlertCircuitEvent{
+			Source:    source,
+			OpenedAt:  now,
+			ProbeAt:   now.Add(b.config.Cooldown),
+			Threshold: b.config.Threshold,
+			Window:    b.config.Window,
+		})
+	}
+	return CircuitDefer, true
+}
+
+// ProbeStarted records the investigation ID of a half-open source's probe.
+func (b *AlertCircuitBreaker) ProbeStarted(source, invID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[source]; ok && c.state == CircuitHalfOpen {
+		c.probeID = invID
+	}
+}
+
+// ProbeFinished closes a half-open source's circuit when its probe succeeded
+// and reopens it for another cooldown otherwise. Investigations other than the
+// probe are ignored; an empty invID is a probe that failed to start.
+func (b *AlertCircuitBreaker) ProbeFinished(source, invID string, succeeded bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[source]
+	if !ok || c.state != CircuitHalfOpen || c.probeID != invID {
+		return
+	}
+	now := b.now()
+	c.probeID = ""
+	if succeeded {
+		c.state = CircuitClosed
+		c.starts = []time.Time{now}
+		return
+	}
+	c.state = CircuitOpen
+	c.openedAt = now
+}
+
+// Circuits returns the state of the circuit of every source that has sent an
+// alert, sorted by source.
+func (b *AlertCircuitBreaker) Circuits() []port.CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cutoff := b.now().Add(-b.config.Window)
+	circuits := make([]port.CircuitStatus, 0, len(b.circuits))
+	for source, c := range b.circuits {
+		status := port.CircuitStatus{Source: source, State: string(c.state), ProbeID: c.probeID}
+		for _, start := range c.starts {
+			if start.After(cutoff) {
+				status.Recent++
+			}
+		}
+		if c.state != CircuitClosed {
+			status.OpenedAt = c.openedAt
+		}
+		if c.state == CircuitOpen {
+			status.ProbeAt = c.openedAt.Add(b.config.Cooldown)
+		}
+		circuits = append(circuits, status)
+	}
+	sort.Slice(circuits, func(i, j int) bool { return circuits[i].Source < circuits[j].Source })
+	return circuits
+}
+
+// InvestigationStatusLister is implemented by investigation stores that can
+// list their records by status. AlertHandler.ReprocessDeferred needs it.
+type InvestigationStatusLister interface {
+	ListByStatus(ctx context.Context, status string) ([]InvestigationRecordData, error)
+}
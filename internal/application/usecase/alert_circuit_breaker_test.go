@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// circuitNotifierMock records the circuits it is told opened.
+type circuitNotifierMock struct {
+	mu     sync.Mutex
+	events []AlertCircuitEvent
+}
+
+func (m *circuitNotifierMock) NotifyCircuitOpened(event AlertCircuitEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+func (m *circuitNotifierMock) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.events)
+}
+
+// circuitFixture is a circuit breaker with a settable clock.
+type circuitFixture struct {
+	breaker  *AlertCircuitBreaker
+	notifier *circuitNotifierMock
+	now      time.Time
+}
+
+func newCircuitFixture(config AlertCircuitBreakerConfig) *circuitFixture {
+	f := &circuitFixture{
+		breaker:  NewAlertCircuitBreaker(config),
+		notifier: &circuitNotifierMock{},
+		now:      time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC),
+	}
+	f.breaker.now = func() time.Time { return f.now }
+	f.breaker.SetNotifier(f.notifier)
+	return f
+}
+
+// admit asks the breaker about an alert from source arriving at the given time.
+func (f *circuitFixture) admit(t *testing.T, source string, at time.Time, want CircuitDecision) {
+	t.Helper()
+	f.now = at
+	if got, _ := f.breaker.Admit(source); got != want {
+		t.Fatalf("Admit(%q) at %s = %v, want %v", source, at.Format(time.TimeOnly), got, want)
+	}
+}
+
+func TestAlertCircuitBreaker_RollingWindow(t *testing.T) {
+	f := newCircuitFixture(AlertCircuitBreakerConfig{Window: 10 * time.Minute, Threshold: 3, Cooldown: time.Hour})
+	start := f.now
+
+	f.admit(t, "prometheus", start, CircuitAllow)
+	f.admit(t, "prometheus", start.Add(time.Minute), CircuitAllow)
+	f.admit(t, "prometheus", start.Add(2*time.Minute), CircuitAllow)
+	// The first investigation has left the window by the time the fourth alert arrives
+	f.admit(t, "prometheus", start.Add(10*time.Minute), CircuitAllow)
+	if got := f.breaker.State("prometheus"); got != CircuitClosed {
+		t.Fatalf("State() = %v, want closed with 3 investigations in the window", got)
+	}
+
+	f.now = start.Add(10*time.Minute + 30*time.Second)
+	decision, opened := f.breaker.Admit("prometheus")
+	if decision != CircuitDefer || !opened {
+		t.Fatalf("Admit() over the threshold = %v, opened %v; want deferred and opened", decision, opened)
+	}
+	f.admit(t, "prometheus", start.Add(11*time.Minute), CircuitDefer)
+	f.admit(t, "grafana", start.Add(11*time.Minute), CircuitAllow)
+
+	if f.notifier.count() != 1 {
+		t.Fatalf("notifications = %d, want 1", f.notifier.count())
+	}
+	event := f.notifier.events[0]
+	if event.Source != "prometheus" || event.Threshold != 3 || event.Window != 10*time.Minute ||
+		!event.ProbeAt.Equal(start.Add(70*time.Minute+30*time.Second)) {
+		t.Errorf("notification = %+v", event)
+	}
+}
+
+func TestAlertCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	f := newCircuitFixture(AlertCircuitBreakerConfig{Window: time.Hour, Threshold: 1, Cooldown: 5 * time.Minute})
+	start := f.now
+	f.admit(t, "prometheus", start, CircuitAllow)
+	f.admit(t, "prometheus", start, CircuitDefer)
+
+	f.admit(t, "prometheus", start.Add(5*time.Minute-time.Nanosecond), CircuitDefer)
+	f.admit(t, "prometheus", start.Add(5*time.Minute), CircuitProbe)
+	f.admit(t, "prometheus", start.Add(6*time.Minute), CircuitDefer)
+	f.breaker.ProbeStarted("prometheus", "inv-probe")
+
+	// Other investigations finishing do not decide the probe
+	f.breaker.ProbeFinished("prometheus", "inv-other", true)
+	if got := f.breaker.State("prometheus"); got != CircuitHalfOpen {
+		t.Fatalf("State() after another investigation = %v, want half-open", got)
+	}
+
+	// A failed probe reopens the circuit for another cooldown without notifying again
+	f.now = start.Add(7 * time.Minute)
+	f.breaker.ProbeFinished("prometheus", "inv-probe", false)
+	if got := f.breaker.State("prometheus"); got != CircuitOpen {
+		t.Fatalf("State() after a failed probe = %v, want open", got)
+	}
+	f.admit(t, "prometheus", start.Add(11*time.Minute), CircuitDefer)
+	f.admit(t, "prometheus", start.Add(12*time.Minute), CircuitProbe)
+	if f.notifier.count() != 1 {
+		t.Fatalf("notifications after reopening = %d, want 1", f.notifier.count())
+	}
+
+	// A successful probe closes it, counting the probe in the new window
+	f.breaker.ProbeStarted("prometheus", "inv-probe-2")
+	f.breaker.ProbeFinished("prometheus", "inv-probe-2", true)
+	if got := f.breaker.State("prometheus"); got != CircuitClosed {
+		t.Fatalf("State() after a successful probe = %v, want closed", got)
+	}
+	f.admit(t, "prometheus", start.Add(13*time.Minute), CircuitDefer)
+	if f.notifier.count() != 2 {
+		t.Errorf("notifications after a new storm = %d, want 2", f.notifier.count())
+	}
+}
+
+func TestAlertCircuitBreaker_ProbeFailsToStart(t *testing.T) {
+	f := newCircuitFixture(AlertCircuitBreakerConfig{Window: time.Hour, Threshold: 1, Cooldown: time.Minute})
+	start := f.now
+	f.admit(t, "prometheus", start, CircuitAllow)
+	f.admit(t, "prometheus", start, CircuitDefer)
+	f.admit(t, "prometheus", start.Add(time.Minute), CircuitProbe)
+
+	f.breaker.ProbeFinished("prometheus", "", false)
+	if got := f.breaker.State("prometheus"); got != CircuitOpen {
+		t.Fatalf("State() after the probe failed to start = %v, want open", got)
+	}
+	f.admit(t, "prometheus", start.Add(2*time.Minute), CircuitProbe)
+}
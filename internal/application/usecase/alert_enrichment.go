@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// AlertEnricher adds context to an alert before its investigation starts, such
+// as the owning team, recent deploys, or the runbook. Enrichments are added as
+// annotations with AlertView.AddAnnotations, so they appear in the
+// investigation prompt and the stored investigation.
+type AlertEnricher interface {
+	// Enrich adds annotations to alert. An error skips this enricher; the
+	// annotations it added before failing are kept.
+	Enrich(ctx context.Context, alert *AlertView) error
+}
+
+// SetAlertEnrichers sets the enrichers run, in order, on each alert about to be
+// investigated. An annotation added by an earlier enricher is not replaced by a
+// later one. Enricher failures are logged and never stop the investigation.
+func (h *AlertHandler) SetAlertEnrichers(enrichers ...AlertEnricher) {
+	h.enrichers = enrichers
+}
+
+// enrich runs the alert enrichers on alert, replacing its annotations with the
+// enriched copy.
+func (h *AlertHandler) enrich(ctx context.Context, alert *AlertForInvestigation) {
+	if len(h.enrichers) == 0 {
+		return
+	}
+	view := &AlertView{
+		id:          alert.ID(),
+		source:      alert.Source(),
+		severity:    alert.Severity(),
+		title:       alert.Title(),
+		description: alert.Description(),
+		labels:      maps.Clone(alert.Labels()),
+		annotations: maps.Clone(alert.Annotations()),
+	}
+	for _, enricher := range h.enrichers {
+		if err := enricher.Enrich(ctx, view); err != nil {
+			h.logger.Warn("Alert enrichment failed, skipping enricher",
+				"alert_id", alert.ID(), "enricher", fmt.Sprintf("%T", enricher), "error", err)
+		}
+	}
+	alert.annotations = view.annotations
+}
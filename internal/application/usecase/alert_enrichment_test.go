@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// enricherFunc adapts a function to AlertEnricher.
+type enricherFunc func(ctx context.Context, alert *AlertView) error
+
+func (f enricherFunc) Enrich(ctx context.Context, alert *AlertView) error { return f(ctx, alert) }
+
+// addAnnotations returns an enricher adding annotations, recording its name in
+// calls when run.
+func addAnnotations(name string, calls *[]string, annotations map[string]string) AlertEnricher {
+	return enricherFunc(func(_ context.Context, alert *AlertView) error {
+		*calls = append(*calls, name)
+		alert.AddAnnotations(annotations)
+		return nil
+	})
+}
+
+// enrichmentFixture is an alert handler over an investigation use case with a
+// store and a prompt builder recording the alert it is given.
+type enrichmentFixture struct {
+	handler *AlertHandler
+	store   *MockInvestigationStore
+	prompts *investigationRunnerPromptBuilderMock
+}
+
+func newEnrichmentFixture(t *testing.T, enrichers ...AlertEnricher) *enrichmentFixture {
+	t.Helper()
+	f := &enrichmentFixture{
+		store:   NewMockInvestigationStore(),
+		prompts: newInvestigationRunnerPromptBuilderMock(),
+	}
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(newInvestigationRunnerConvServiceMock())
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(f.prompts)
+	uc.SetInvestigationStore(f.store)
+	f.handler = NewAlertHandler(uc, AlertHandlerConfig{AutoInvestigateCritical: true})
+	f.handler.SetAlertEnrichers(enrichers...)
+	return f
+}
+
+// promptAnnotations returns the annotations of the alert the prompt was built for.
+func (f *enrichmentFixture) promptAnnotations(t *testing.T) map[string]string {
+	t.Helper()
+	f.prompts.mu.Lock()
+	defer f.prompts.mu.Unlock()
+	if f.prompts.buildPromptForAlertAlert == nil {
+		t.Fatal("no prompt was built")
+	}
+	return f.prompts.buildPromptForAlertAlert.Annotations()
+}
+
+// storedAnnotations returns the annotations of the one completed investigation's alert.
+func (f *enrichmentFixture) storedAnnotations(t *testing.T) map[string]string {
+	t.Helper()
+	completed := f.store.withStatus("completed")
+	if len(completed) != 1 || completed[0].alert == nil {
+		t.Fatalf("completed records = %d, want 1 with an alert", len(completed))
+	}
+	return completed[0].alert.Annotations()
+}
+
+func TestAlertHandler_Enrichment_ChainOrder(t *testing.T) {
+	var calls []string
+	var sawTeam string
+	f := newEnrichmentFixture(t,
+		addAnnotations("owners", &calls, map[string]string{"team": "storage", "tier": "1"}),
+		enricherFunc(func(_ context.Context, alert *AlertView) error {
+			calls = append(calls, "deploys")
+			sawTeam = alert.AnnotationValue("team")
+			alert.AddAnnotations(map[string]string{"team": "platform", "last_deploy": "v1.2.3"})
+			return nil
+		}),
+	)
+
+	if err := f.handler.Handle(context.Background(), diskAlert("DiskFull-1")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if strings.Join(calls, ",") != "owners,deploys" {
+		t.Errorf("enrichers ran as %v, want owners then deploys", calls)
+	}
+	if sawTeam != "storage" {
+		t.Errorf("second enricher saw team %q, want the first enricher's storage", sawTeam)
+	}
+	want := map[string]string{"team": "storage", "tier": "1", "last_deploy": "v1.2.3"}
+	if got := f.promptAnnotations(t); !maps.Equal(got, want) {
+		t.Errorf("prompt annotations = %v, want %v", got, want)
+	}
+	if got := f.storedAnnotations(t); !maps.Equal(got, want) {
+		t.Errorf("stored annotations = %v, want %v", got, want)
+	}
+}
+
+func TestAlertHandler_Enrichment_KeepsSourceAnnotations(t *testing.T) {
+	var calls []string
+	f := newEnrichmentFixture(t, addAnnotations("static", &calls, map[string]string{
+		"runbook_url": "https://wiki.example.com/enriched",
+		"team":        "storage",
+	}))
+	alert := diskAlert("DiskFull-1")
+	sourceAnnotations := map[string]string{"runbook_url": "https://wiki.example.com/source"}
+	alert.annotations = sourceAnnotations
+
+	if err := f.handler.Handle(context.Background(), alert); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	want := map[string]string{"runbook_url": "https://wiki.example.com/source", "team": "storage"}
+	if got := f.promptAnnotations(t); !maps.Equal(got, want) {
+		t.Errorf("prompt annotations = %v, want %v", got, want)
+	}
+	if len(sourceAnnotations) != 1 {
+		t.Errorf("the caller's annotations map was modified: %v", sourceAnnotations)
+	}
+}
+
+func TestAlertHandler_Enrichment_FailureSkipsEnricher(t *testing.T) {
+	var calls []string
+	f := newEnrichmentFixture(t,
+		enricherFunc(func(_ context.Context, alert *AlertView) error {
+			calls = append(calls, "broken")
+			alert.AddAnnotations(map[string]string{"partial": "yes"})
+			return errors.New("lookup service unavailable")
+		}),
+		addAnnotations("static", &calls, map[string]string{"team": "storage"}),
+	)
+
+	if err := f.handler.Handle(context.Background(), diskAlert("DiskFull-1")); err != nil {
+		t.Fatalf("Handle() error = %v, want the investigation to run", err)
+	}
+
+	if strings.Join(calls, ",") != "broken,static" {
+		t.Errorf("enrichers ran as %v, want the chain to continue after a failure", calls)
+	}
+	want := map[string]string{"partial": "yes", "team": "storage"}
+	if got := f.storedAnnotations(t); !maps.Equal(got, want) {
+		t.Errorf("stored annotations = %v, want %v", got, want)
+	}
+}
+
+func TestAlertHandler_Enrichment_Async(t *testing.T) {
+	var calls []string
+	f := newEnrichmentFixture(t, addAnnotations("static", &calls, map[string]string{"team": "storage"}))
+	ctx := context.Background()
+	alert, err := entity.NewAlert("DiskFull-1", "prometheus", entity.SeverityCritical, "Disk Full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+
+	invID, err := f.handler.HandleEntityAlertAsync(ctx, alert)
+	if err != nil || invID == "" {
+		t.Fatalf("HandleEntityAlertAsync() = %q, %v; want an investigation started", invID, err)
+	}
+	if err := f.handler.RunEntityAlertInvestigation(ctx, alert, invID); err != nil {
+		t.Fatalf("RunEntityAlertInvestigation() error = %v", err)
+	}
+
+	if len(calls) != 1 {
+		t.Errorf("enricher ran %d times, want once", len(calls))
+	}
+	if got := f.promptAnnotations(t)["team"]; got != "storage" {
+		t.Errorf("prompt team annotation = %q, want storage", got)
+	}
+	if got := f.storedAnnotations(t)["team"]; got != "storage" {
+		t.Errorf("stored team annotation = %q, want storage", got)
+	}
+}
@@ -4,10 +4,13 @@ package usecase
 
 import (
 	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"slices"
+	"time"
 )
 
 // Alert severity constants used internally by the handler for decision making.
@@ -19,6 +22,25 @@ const (
 // ErrNilUseCase is returned when AlertHandler is created with a nil use case.
 var ErrNilUseCase = errors.New("investigation use case cannot be nil")
 
+// ErrNoSuppressionStore is returned when suppressing alerts without a suppression store.
+var ErrNoSuppressionStore = errors.New("alert suppression store not configured")
+
+// ErrNoDeferredStore is returned when reprocessing deferred alerts without an
+// investigation store that can list them.
+var ErrNoDeferredStore = errors.New("investigation store cannot list deferred alerts")
+
+// AlertSuppressionStore persists alert suppressions by fingerprint.
+// This is defined locally in usecase to avoid import cycles with the adapters.
+type AlertSuppressionStore interface {
+	// SaveSuppression stores a suppression, replacing any for the same fingerprint.
+	SaveSuppression(ctx context.Context, suppression *entity.AlertSuppression) error
+	// Suppression returns the suppression stored for a fingerprint, expired or
+	// not, or nil if there is none.
+	Suppression(ctx context.Context, fingerprint string) (*entity.AlertSuppression, error)
+	// DeleteSuppression removes the suppression for a fingerprint, if any.
+	DeleteSuppression(ctx context.Context, fingerprint string) error
+}
+
 // AlertHandlerConfig configures the alert handler behavior.
 // It determines which alerts trigger automatic investigations based on
 // severity levels and source filters.
@@ -48,6 +70,11 @@ type AlertHandlerConfig struct {
 type AlertHandler struct {
 	investigationUseCase *AlertInvestigationUseCase
 	config               AlertHandlerConfig
+	suppressions         AlertSuppressionStore
+	circuit              *AlertCircuitBreaker
+	enrichers            []AlertEnricher
+	logger               *slog.Logger
+	now                  func() time.Time
 }
 
 // NewAlertHandler creates a new AlertHandler with the given use case and config.
@@ -58,6 +85,8 @@ func NewAlertHandler(uc *AlertInvestigationUseCase, config AlertHandlerConfig) *
 	return &AlertHandler{
 		investigationUseCase: uc,
 		config:               config,
+		logger:               slog.Default(),
+		now:                  time.Now,
 	}
 }
 
@@ -77,61 +106,319 @@ func NewAlertHandlerWithValidation(
 	return &AlertHandler{
 		investigationUseCase: uc,
 		config:               config,
+		logger:               slog.Default(),
+		now:                  time.Now,
 	}, nil
 }
 
+// SetLogger sets the logger for investigation progress. A nil logger restores slog.Default().
+func (h *AlertHandler) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h.logger = logger
+}
+
+// SetSuppressionStore sets the store of alert suppressions. Without one, no
+// alert is suppressed and Suppress and Unsuppress return ErrNoSuppressionStore.
+func (h *AlertHandler) SetSuppressionStore(store AlertSuppressionStore) {
+	h.suppressions = store
+}
+
+// SetCircuitBreaker sets the circuit breaker that defers the alerts of a
+// source starting too many investigations. Without one, every alert that
+// passes the other checks is investigated.
+func (h *AlertHandler) SetCircuitBreaker(breaker *AlertCircuitBreaker) {
+	h.circuit = breaker
+}
+
+// Suppress stops alerts with the given fingerprint from being investigated
+// until the given time. Each suppressed alert is recorded as a "suppressed"
+// investigation with the reason. Suppressing a fingerprint again replaces its
+// suppression; once until has passed, the suppression is ignored.
+//
+// Returns entity.ErrInvalidFingerprint or entity.ErrSuppressionEnded if the
+// suppression is invalid, and ErrNoSuppressionStore without a store.
+func (h *AlertHandler) Suppress(ctx context.Context, fingerprint string, until time.Time, reason string) error {
+	if h.suppressions == nil {
+		return ErrNoSuppressionStore
+	}
+	suppression, err := entity.NewAlertSuppression(fingerprint, until, reason, h.now())
+	if err != nil {
+		return err
+	}
+	if err := h.suppressions.SaveSuppression(ctx, suppression); err != nil {
+		return err
+	}
+	h.logger.Info("Alert suppression added", "fingerprint", suppression.Fingerprint,
+		"until", suppression.Until, "reason", suppression.Reason)
+	return nil
+}
+
+// Unsuppress lifts the suppression of a fingerprint, if there is one, so its
+// alerts are investigated again.
+//
+// Returns entity.ErrInvalidFingerprint for an invalid fingerprint and
+// ErrNoSuppressionStore without a store.
+func (h *AlertHandler) Unsuppress(ctx context.Context, fingerprint string) error {
+	if h.suppressions == nil {
+		return ErrNoSuppressionStore
+	}
+	if err := entity.ValidateFingerprint(fingerprint); err != nil {
+		return err
+	}
+	if err := h.suppressions.DeleteSuppression(ctx, fingerprint); err != nil {
+		return err
+	}
+	h.logger.Info("Alert suppression removed", "fingerprint", fingerprint)
+	return nil
+}
+
 // Handle processes an incoming alert and potentially starts an investigation.
 //
 // The handler evaluates the alert against the configured rules:
 //  1. Checks if the alert source is in the ignored list (returns nil if so)
 //  2. Checks if the severity warrants investigation based on config
-//  3. Starts an investigation if all checks pass
+//  3. Checks if the alert is suppressed (records it as "suppressed" if so)
+//  4. Checks if the daily budget is spent (records it as "deferred" if so)
+//  5. Checks if the source's circuit is open (records it as "deferred" if so)
+//  6. Runs the alert enrichers and starts an investigation if all checks pass
 //
-// Returns nil if the alert is silently ignored (source filtered or severity not configured).
-// Returns ErrNilAlert if the alert is nil.
+// Returns nil if the alert is silently ignored (source filtered or severity not configured),
+// suppressed, or deferred.
+// Returns an error wrapping ErrInvalidAlert if the alert is nil or has no ID.
 // Returns context.Canceled or context.DeadlineExceeded if the context is done.
 // Returns any error from the underlying investigation use case.
 func (h *AlertHandler) Handle(ctx context.Context, alert *AlertForInvestigation) error {
-	if alert == nil {
-		return ErrNilAlert
+	if err := validateAlert(alert); err != nil {
+		return err
 	}
 
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	// Check if source is ignored - silently skip these alerts
-	if h.isSourceIgnored(alert.Source()) {
-		return nil
+	// Skip filtered alerts, and record suppressed and deferred ones instead of investigating them
+	skipped, decision, err := h.screen(ctx, alert)
+	if skipped != "" {
+		return err
 	}
 
-	// Check if we should investigate based on severity and config
-	if !h.shouldInvestigate(alert) {
-		return nil
+	// All checks passed - enrich the alert and start the investigation
+	h.enrich(ctx, alert)
+	logger := h.logger.With("alert_id", alert.ID())
+	logger.Info("Starting investigation", "title", alert.Title(), "severity", alert.Severity())
+	invID, err := h.startInvestigation(ctx, alert, decision)
+	if err != nil {
+		logger.Error("Investigation error", "error", err)
+		return err
 	}
+	_, err = h.runInvestigation(ctx, logger.With("investigation_id", invID), alert, invID)
+	return err
+}
+
+// Why screen passes over an alert.
+const (
+	screenSkipped    = "skipped"    // Source ignored or severity not investigated
+	screenSuppressed = "suppressed" // Recorded as suppressed
+	screenDeferred   = "deferred"   // Recorded as deferred by the daily budget or the source's circuit
+)
 
-	// All checks passed - start the investigation
-	fmt.Fprintf(
-		os.Stderr,
-		"[AlertHandler] Starting investigation for alert: %s (severity=%s)\n",
-		alert.Title(),
-		alert.Severity(),
-	)
-	result, err := h.investigationUseCase.HandleAlert(ctx, alert)
+// screen runs the checks an alert must pass to be investigated, in the order
+// Handle documents, recording suppressed and deferred alerts. It returns why
+// the alert is passed over, with any error recording it, or "" and the
+// circuit's decision when the alert is to be investigated.
+func (h *AlertHandler) screen(ctx context.Context, alert *AlertForInvestigation) (string, CircuitDecision, error) {
+	if h.isSourceIgnored(alert.Source()) || !h.shouldInvestigate(alert) {
+		return screenSkipped, CircuitAllow, nil
+	}
+	if suppression := h.activeSuppression(ctx, alert); suppression != nil {
+		return screenSuppressed, CircuitAllow, h.recordSuppressed(ctx, alert, suppression)
+	}
+	if reason := h.investigationUseCase.budgetExhausted(ctx); reason != "" {
+		return screenDeferred, CircuitAllow, h.recordDeferred(ctx, alert, reason)
+	}
+	decision := h.admit(alert)
+	if decision == CircuitDefer {
+		return screenDeferred, decision, h.recordDeferred(ctx, alert, h.circuitDeferReason(alert))
+	}
+	return "", decision, nil
+}
+
+// runInvestigation runs a started investigation, logs its outcome, and
+// returns its result.
+func (h *AlertHandler) runInvestigation(
+	ctx context.Context,
+	logger *slog.Logger,
+	alert *AlertForInvestigation,
+	invID string,
+) (*InvestigationResult, error) {
+	result, err := h.investigationUseCase.RunInvestigation(ctx, alert, invID)
+	if h.circuit != nil {
+		h.circuit.ProbeFinished(alert.Source(), invID, err == nil && result.Error == nil)
+	}
+	if err != nil {
+		logger.Error("Investigation error", "error", err, "error_kind", ErrorKindOf(err))
+		return nil, fmt.Errorf("investigation %s of alert %s: %w", invID, alert.ID(), err)
+	}
+	logger.Info("Investigation completed",
+		"status", result.Status, "findings", len(result.Findings), "confidence", result.Confidence)
+	for i, finding := range result.Findings {
+		logger.Info("Finding", "index", i+1, "finding", finding)
+	}
+	if result.Escalated {
+		logger.Warn("Investigation escalated", "reason", result.EscalateReason)
+	}
+	return result, nil
+}
+
+// activeSuppression returns the suppression in effect for the alert's
+// fingerprint, or nil. Expired suppressions are ignored here rather than
+// cleaned up. If the store cannot be read, the alert is not suppressed.
+func (h *AlertHandler) activeSuppression(ctx context.Context, alert *AlertForInvestigation) *entity.AlertSuppression {
+	if h.suppressions == nil {
+		return nil
+	}
+	suppression, err := h.suppressions.Suppression(ctx, alert.Fingerprint())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[AlertHandler] Investigation error: %v\n", err)
+		h.logger.Error("Failed to check alert suppression",
+			"alert_id", alert.ID(), "fingerprint", alert.Fingerprint(), "error", err)
+		return nil
+	}
+	if !suppression.ActiveAt(h.now()) {
+		return nil
+	}
+	return suppression
+}
+
+// recordSuppressed stores a "suppressed" record for an alert in place of its investigation.
+func (h *AlertHandler) recordSuppressed(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	suppression *entity.AlertSuppression,
+) error {
+	if _, err := h.investigationUseCase.RecordSuppressed(ctx, alert, suppression); err != nil {
+		h.logger.Error("Failed to record suppressed alert", "alert_id", alert.ID(), "error", err)
 		return err
 	}
-	fmt.Fprintf(os.Stderr, "[AlertHandler] Investigation completed: status=%s, findings=%d, confidence=%.2f\n",
-		result.Status, len(result.Findings), result.Confidence)
-	if len(result.Findings) > 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "[AlertHandler] Findings:\n")
-		for i, finding := range result.Findings {
-			_, _ = fmt.Fprintf(os.Stderr, "  %d. %s\n", i+1, finding)
+	return nil
+}
+
+// admit asks the circuit breaker, if any, whether the alert may be
+// investigated, and logs when its source's circuit opens or is probed.
+func (h *AlertHandler) admit(alert *AlertForInvestigation) CircuitDecision {
+	if h.circuit == nil {
+		return CircuitAllow
+	}
+	decision, opened := h.circuit.Admit(alert.Source())
+	if opened {
+		config := h.circuit.Config()
+		h.logger.Warn("Alert source circuit opened; deferring its alerts", "source", alert.Source(),
+			"threshold", config.Threshold, "window", config.Window, "cooldown", config.Cooldown)
+	}
+	if decision == CircuitProbe {
+		h.logger.Info("Probing alert source circuit", "source", alert.Source(), "alert_id", alert.ID())
+	}
+	return decision
+}
+
+// startInvestigation starts an admitted investigation, recording it as the
+// probe of its source's circuit when it is one. A historical alert waits for
+// a free slot instead of failing with ErrMaxConcurrentReached.
+func (h *AlertHandler) startInvestigation(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	decision CircuitDecision,
+) (string, error) {
+	invID, err := h.investigationUseCase.StartInvestigation(ctx, alert)
+	for alert.Historical() && errors.Is(err, ErrMaxConcurrentReached) {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backfillRetryInterval):
+			invID, err = h.investigationUseCase.StartInvestigation(ctx, alert)
 		}
 	}
-	if result.Escalated {
-		_, _ = fmt.Fprintf(os.Stderr, "[AlertHandler] ESCALATED: %s\n", result.EscalateReason)
+	if decision == CircuitProbe {
+		if err != nil {
+			h.circuit.ProbeFinished(alert.Source(), "", false)
+		} else {
+			h.circuit.ProbeStarted(alert.Source(), invID)
+		}
+	}
+	return invID, err
+}
+
+// circuitDeferReason explains why an alert from a source with an open circuit is deferred.
+func (h *AlertHandler) circuitDeferReason(alert *AlertForInvestigation) string {
+	config := h.circuit.Config()
+	return fmt.Sprintf("deferred: alert source %q started more than %d investigations within %s",
+		alert.Source(), config.Threshold, config.Window)
+}
+
+// recordDeferred stores a "deferred" record for an alert in place of its investigation.
+func (h *AlertHandler) recordDeferred(ctx context.Context, alert *AlertForInvestigation, reason string) error {
+	if _, err := h.investigationUseCase.RecordDeferred(ctx, alert, reason); err != nil {
+		h.logger.Error("Failed to record deferred alert", "alert_id", alert.ID(), "error", err)
+		return err
+	}
+	return nil
+}
+
+// ReprocessDeferred handles the alerts recorded as "deferred" again, oldest
+// first, and returns how many it handled. An empty source reprocesses every
+// source's alerts. Each deferred record is marked "reprocessed" before its
+// alert is handled; the alert then goes through the usual checks, so while
+// the source's circuit is still open it is deferred again as a new record.
+//
+// Returns ErrNilUseCase if the investigation use case is nil, and
+// ErrNoDeferredStore if its store cannot list records by status.
+func (h *AlertHandler) ReprocessDeferred(ctx context.Context, source string) (int, error) {
+	if h.investigationUseCase == nil {
+		return 0, ErrNilUseCase
+	}
+	h.investigationUseCase.mu.RLock()
+	store := h.investigationUseCase.investigationStore
+	h.investigationUseCase.mu.RUnlock()
+	lister, ok := store.(InvestigationStatusLister)
+	if !ok {
+		return 0, ErrNoDeferredStore
+	}
+
+	records, err := lister.ListByStatus(ctx, "deferred")
+	if err != nil {
+		return 0, err
+	}
+	slices.SortStableFunc(records, func(a, b InvestigationRecordData) int {
+		return a.StartedAt().Compare(b.StartedAt())
+	})
+
+	reprocessed := 0
+	for _, record := range records {
+		alert := record.Alert()
+		if alert == nil || (source != "" && alert.Source() != source) {
+			continue
+		}
+		if err := store.Update(ctx, recordWithStatus(record, "reprocessed")); err != nil {
+			return reprocessed, err
+		}
+		h.logger.Info("Reprocessing deferred alert", "investigation_id", record.ID(), "alert_id", alert.ID())
+		if err := h.Handle(ctx, NewAlertForInvestigationFromEntity(alert)); err != nil {
+			return reprocessed, err
+		}
+		reprocessed++
+	}
+	return reprocessed, nil
+}
+
+// validateAlert returns an error wrapping ErrInvalidAlert for an alert that
+// cannot be investigated: a nil one (ErrNilAlert) or one without an ID.
+func validateAlert(alert *AlertForInvestigation) error {
+	if alert == nil {
+		return ErrNilAlert
+	}
+	if alert.ID() == "" {
+		return fmt.Errorf("%w: empty alert ID", ErrInvalidAlert)
 	}
 	return nil
 }
@@ -173,12 +460,16 @@ func (h *AlertHandler) HandleEntityAlert(ctx context.Context, alert *entity.Aler
 	}
 	// Convert domain entity to use case DTO for processing
 	invAlert := &AlertForInvestigation{
-		id:          alert.ID(),
-		source:      alert.Source(),
-		severity:    alert.Severity(),
-		title:       alert.Title(),
-		description: alert.Description(),
-		labels:      alert.Labels(),
+		id:           alert.ID(),
+		source:       alert.Source(),
+		severity:     alert.Severity(),
+		title:        alert.Title(),
+		description:  alert.Description(),
+		labels:       alert.Labels(),
+		annotations:  alert.Annotations(),
+		fingerprint:  alert.Fingerprint(),
+		generatorURL: alert.GeneratorURL(),
+		historical:   alert.Historical(),
 	}
 	return h.Handle(ctx, invAlert)
 }
@@ -187,39 +478,44 @@ func (h *AlertHandler) HandleEntityAlert(ctx context.Context, alert *entity.Aler
 // The actual investigation should be run separately via RunEntityAlertInvestigation.
 // This is useful for async workflows where you need to return the ID before the investigation completes.
 //
-// Returns empty string if the alert is filtered out (source ignored or severity not configured).
-// Returns ErrNilAlert if the alert is nil.
+// Returns empty string if the alert is filtered out (source ignored or severity not configured),
+// suppressed, or deferred.
+// Returns an error wrapping ErrInvalidAlert if the alert is nil (ErrNilAlert) or has no ID.
 // Returns ErrNilUseCase if the investigation use case is nil.
 func (h *AlertHandler) HandleEntityAlertAsync(ctx context.Context, alert *entity.Alert) (string, error) {
 	if alert == nil {
 		return "", ErrNilAlert
 	}
+	if alert.ID() == "" {
+		return "", fmt.Errorf("%w: empty alert ID", ErrInvalidAlert)
+	}
 	if h.investigationUseCase == nil {
 		return "", ErrNilUseCase
 	}
 
 	// Convert domain entity to use case DTO for processing
 	invAlert := &AlertForInvestigation{
-		id:          alert.ID(),
-		source:      alert.Source(),
-		severity:    alert.Severity(),
-		title:       alert.Title(),
-		description: alert.Description(),
-		labels:      alert.Labels(),
+		id:           alert.ID(),
+		source:       alert.Source(),
+		severity:     alert.Severity(),
+		title:        alert.Title(),
+		description:  alert.Description(),
+		labels:       alert.Labels(),
+		annotations:  alert.Annotations(),
+		fingerprint:  alert.Fingerprint(),
+		generatorURL: alert.GeneratorURL(),
+		historical:   alert.Historical(),
 	}
 
-	// Check if source is ignored - silently skip these alerts
-	if h.isSourceIgnored(invAlert.Source()) {
-		return "", nil
+	// Skip filtered alerts, and record suppressed and deferred ones instead of investigating them
+	skipped, decision, err := h.screen(ctx, invAlert)
+	if skipped != "" {
+		return "", err
 	}
 
-	// Check if we should investigate based on severity and config
-	if !h.shouldInvestigate(invAlert) {
-		return "", nil
-	}
-
-	// Start investigation and return ID immediately
-	return h.investigationUseCase.StartInvestigation(ctx, invAlert)
+	// Enrich the alert, then start investigation and return ID immediately
+	h.enrich(ctx, invAlert)
+	return h.startInvestigation(ctx, invAlert, decision)
 }
 
 // RunEntityAlertInvestigation runs an already-started investigation.
@@ -240,32 +536,55 @@ func (h *AlertHandler) RunEntityAlertInvestigation(
 		return ErrNilUseCase
 	}
 
-	// Convert domain entity to use case DTO for processing
-	invAlert := &AlertForInvestigation{
-		id:          alert.ID(),
-		source:      alert.Source(),
-		severity:    alert.Severity(),
-		title:       alert.Title(),
-		description: alert.Description(),
-		labels:      alert.Labels(),
+	// Run the alert as enriched when the investigation started, or convert
+	// the domain entity to use case DTO for processing
+	invAlert := h.investigationUseCase.startedAlert(invID)
+	if invAlert == nil || invAlert.ID() != alert.ID() {
+		invAlert = NewAlertForInvestigationFromEntity(alert)
 	}
 
-	result, err := h.investigationUseCase.RunInvestigation(ctx, invAlert, invID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "[AlertHandler] Investigation error: %v\n", err)
-		return err
+	logger := h.logger.With("alert_id", alert.ID(), "investigation_id", invID)
+	_, err := h.runInvestigation(ctx, logger, invAlert, invID)
+	return err
+}
+
+// GetStatus returns a snapshot of the running and queued investigations, the
+// circuit of each alert source, and how much of the concurrency limit is in
+// use. It never waits on a running tool.
+func (h *AlertHandler) GetStatus() port.DaemonStatus {
+	status := port.DaemonStatus{
+		GeneratedAt: h.now(),
+		Active:      []port.ActiveInvestigationStatus{},
+		Queued:      []port.QueuedAlertStatus{},
+		Circuits:    []port.CircuitStatus{},
 	}
+	if h.investigationUseCase != nil {
+		status = h.investigationUseCase.Status()
+	}
+	if h.circuit != nil {
+		status.Circuits = h.circuit.Circuits()
+	}
+	return status
+}
 
-	fmt.Fprintf(os.Stderr, "[AlertHandler] Investigation completed: status=%s, findings=%d, confidence=%.2f\n",
-		result.Status, len(result.Findings), result.Confidence)
-	if len(result.Findings) > 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "[AlertHandler] Findings:\n")
-		for i, finding := range result.Findings {
-			_, _ = fmt.Fprintf(os.Stderr, "  %d. %s\n", i+1, finding)
-		}
+// CancelInvestigation cancels a running or queued investigation on request;
+// see AlertInvestigationUseCase.CancelInvestigation.
+//
+// Returns ErrNilUseCase if the investigation use case is nil.
+func (h *AlertHandler) CancelInvestigation(ctx context.Context, invID, reason, requestedBy string) error {
+	if h.investigationUseCase == nil {
+		return ErrNilUseCase
 	}
-	if result.Escalated {
-		_, _ = fmt.Fprintf(os.Stderr, "[AlertHandler] ESCALATED: %s\n", result.EscalateReason)
+	return h.investigationUseCase.CancelInvestigation(ctx, invID, reason, requestedBy)
+}
+
+// Shutdown stops starting investigations for new alerts and drains the ones in
+// flight until ctx is done; see AlertInvestigationUseCase.Shutdown.
+//
+// Returns ErrNilUseCase if the investigation use case is nil.
+func (h *AlertHandler) Shutdown(ctx context.Context) error {
+	if h.investigationUseCase == nil {
+		return ErrNilUseCase
 	}
-	return nil
+	return h.investigationUseCase.Shutdown(ctx)
 }
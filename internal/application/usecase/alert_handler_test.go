@@ -1,8 +1,15 @@
 package usecase
 
 import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // =============================================================================
@@ -489,8 +496,17 @@ func TestAlertHandler_Handle_NilAlert(t *testing.T) {
 	err := handler.Handle(context.Background(), nil)
 
 	// Assert: should return error for nil alert
-	if err == nil {
-		t.Error("Handle(nil) should return error")
+	if !errors.Is(err, ErrInvalidAlert) {
+		t.Errorf("Handle(nil) error = %v, want ErrInvalidAlert", err)
+	}
+}
+
+func TestAlertHandler_Handle_EmptyAlertID(t *testing.T) {
+	handler := NewAlertHandler(NewAlertInvestigationUseCase(), AlertHandlerConfig{AutoInvestigateCritical: true})
+
+	alert := &AlertForInvestigation{source: "prometheus", severity: "critical", title: "No ID"}
+	if err := handler.Handle(context.Background(), alert); !errors.Is(err, ErrInvalidAlert) {
+		t.Errorf("Handle() error = %v, want ErrInvalidAlert for an alert without an ID", err)
 	}
 }
 
@@ -533,6 +549,318 @@ func TestAlertHandler_Handle_CancelledContext(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// Suppression Tests
+// =============================================================================
+
+// suppressionFixture is an alert handler with investigation and suppression
+// stores and a settable clock.
+type suppressionFixture struct {
+	handler      *AlertHandler
+	store        *MockInvestigationStore
+	suppressions *mockSuppressionStore
+	now          time.Time
+}
+
+func newSuppressionFixture(t *testing.T) *suppressionFixture {
+	t.Helper()
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(newInvestigationRunnerConvServiceMock())
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+
+	f := &suppressionFixture{
+		store:        NewMockInvestigationStore(),
+		suppressions: newMockSuppressionStore(),
+		now:          time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC),
+	}
+	uc.SetInvestigationStore(f.store)
+	f.handler = NewAlertHandler(uc, AlertHandlerConfig{AutoInvestigateCritical: true})
+	f.handler.SetSuppressionStore(f.suppressions)
+	f.handler.now = func() time.Time { return f.now }
+	return f
+}
+
+// diskAlert returns a firing of the same critical alert; id distinguishes firings.
+func diskAlert(id string) *AlertForInvestigation {
+	return &AlertForInvestigation{
+		id:       id,
+		source:   "prometheus",
+		severity: "critical",
+		title:    "Disk Full",
+		labels:   map[string]string{"alertname": "DiskFull", "instance": "db-1"},
+	}
+}
+
+func TestAlertHandler_Suppress(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	until := f.now.Add(2 * time.Hour)
+
+	if err := f.handler.Suppress(ctx, "a1b2c3d4e5f60718", until, "maintenance window"); err != nil {
+		t.Fatalf("Suppress() error = %v", err)
+	}
+	got := f.suppressions.suppressions["a1b2c3d4e5f60718"]
+	if got == nil || !got.Until.Equal(until) || got.Reason != "maintenance window" || !got.CreatedAt.Equal(f.now) {
+		t.Fatalf("stored suppression = %+v", got)
+	}
+
+	if err := f.handler.Suppress(ctx, "../a1b2", until, ""); !errors.Is(err, entity.ErrInvalidFingerprint) {
+		t.Errorf("Suppress(invalid fingerprint) error = %v, want ErrInvalidFingerprint", err)
+	}
+	if err := f.handler.Suppress(ctx, "a1b2", f.now, ""); !errors.Is(err, entity.ErrSuppressionEnded) {
+		t.Errorf("Suppress(until now) error = %v, want ErrSuppressionEnded", err)
+	}
+
+	if err := f.handler.Unsuppress(ctx, "a1b2c3d4e5f60718"); err != nil {
+		t.Fatalf("Unsuppress() error = %v", err)
+	}
+	if _, ok := f.suppressions.suppressions["a1b2c3d4e5f60718"]; ok {
+		t.Error("Unsuppress() should delete the suppression")
+	}
+	if err := f.handler.Unsuppress(ctx, "never-suppressed"); err != nil {
+		t.Errorf("Unsuppress(unknown) error = %v, want nil", err)
+	}
+
+	bare := NewAlertHandler(NewAlertInvestigationUseCase(), AlertHandlerConfig{})
+	if err := bare.Suppress(ctx, "a1b2", until, ""); !errors.Is(err, ErrNoSuppressionStore) {
+		t.Errorf("Suppress() without store error = %v, want ErrNoSuppressionStore", err)
+	}
+	if err := bare.Unsuppress(ctx, "a1b2"); !errors.Is(err, ErrNoSuppressionStore) {
+		t.Errorf("Unsuppress() without store error = %v, want ErrNoSuppressionStore", err)
+	}
+}
+
+func TestAlertHandler_Handle_SuppressionWindow(t *testing.T) {
+	tests := []struct {
+		name           string
+		sinceSuppress  time.Duration // When the alert arrives, relative to Suppress
+		wantSuppressed bool
+	}{
+		{"at start", 0, true},
+		{"inside window", 30 * time.Minute, true},
+		{"just before end", time.Hour - time.Nanosecond, true},
+		{"at end", time.Hour, false},
+		{"after end", 2 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSuppressionFixture(t)
+			ctx := context.Background()
+			alert := diskAlert("DiskFull-1")
+			if err := f.handler.Suppress(ctx, alert.Fingerprint(), f.now.Add(time.Hour), "maintenance window"); err != nil {
+				t.Fatalf("Suppress() error = %v", err)
+			}
+
+			f.now = f.now.Add(tt.sinceSuppress)
+			if err := f.handler.Handle(ctx, alert); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			suppressed := f.store.withStatus("suppressed")
+			if tt.wantSuppressed {
+				if len(suppressed) != 1 || len(f.store.data) != 1 {
+					t.Fatalf("stored %d records, %d suppressed; want 1 suppressed record", len(f.store.data), len(suppressed))
+				}
+				record := suppressed[0]
+				if record.alertID != "DiskFull-1" || record.alert == nil {
+					t.Errorf("suppressed record alert = %q (%v), want DiskFull-1 with snapshot", record.alertID, record.alert)
+				}
+				if !strings.Contains(record.errorMessage, "maintenance window") {
+					t.Errorf("suppressed record message = %q, want the reason", record.errorMessage)
+				}
+				return
+			}
+			if len(suppressed) != 0 || len(f.store.withStatus("completed")) != 1 {
+				t.Errorf("stored %d suppressed, %d completed records; want the alert investigated",
+					len(suppressed), len(f.store.withStatus("completed")))
+			}
+		})
+	}
+}
+
+func TestAlertHandler_Handle_UnsuppressedRefireInvestigates(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	fingerprint := diskAlert("").Fingerprint()
+	if err := f.handler.Suppress(ctx, fingerprint, f.now.Add(time.Hour), "deploy"); err != nil {
+		t.Fatalf("Suppress() error = %v", err)
+	}
+
+	if err := f.handler.Handle(ctx, diskAlert("DiskFull-1")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := f.handler.Unsuppress(ctx, fingerprint); err != nil {
+		t.Fatalf("Unsuppress() error = %v", err)
+	}
+	if err := f.handler.Handle(ctx, diskAlert("DiskFull-2")); err != nil {
+		t.Fatalf("Handle() after Unsuppress error = %v", err)
+	}
+
+	if got := len(f.store.withStatus("suppressed")); got != 1 {
+		t.Errorf("suppressed records = %d, want 1", got)
+	}
+	completed := f.store.withStatus("completed")
+	if len(completed) != 1 || completed[0].alertID != "DiskFull-2" {
+		t.Errorf("completed records = %d, want the re-fired DiskFull-2 investigated", len(completed))
+	}
+}
+
+func TestAlertHandler_HandleEntityAlertAsync_Suppressed(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	alert, err := entity.NewAlert("DiskFull-1", "prometheus", entity.SeverityCritical, "Disk Full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	alert.WithFingerprint("a1b2c3d4e5f60718")
+	if err := f.handler.Suppress(ctx, "a1b2c3d4e5f60718", f.now.Add(time.Hour), "deploy"); err != nil {
+		t.Fatalf("Suppress() error = %v", err)
+	}
+
+	invID, err := f.handler.HandleEntityAlertAsync(ctx, alert)
+	if err != nil || invID != "" {
+		t.Fatalf("HandleEntityAlertAsync() = %q, %v; want no investigation started", invID, err)
+	}
+	if got := len(f.store.withStatus("suppressed")); got != 1 {
+		t.Errorf("suppressed records = %d, want 1", got)
+	}
+}
+
+func TestAlertHandler_Handle_SuppressionStoreErrorInvestigates(t *testing.T) {
+	f := newSuppressionFixture(t)
+	f.suppressions.err = errors.New("disk unavailable")
+
+	if err := f.handler.Handle(context.Background(), diskAlert("DiskFull-1")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if got := len(f.store.withStatus("completed")); got != 1 {
+		t.Errorf("completed records = %d, want the alert investigated when suppressions cannot be read", got)
+	}
+}
+
+// =============================================================================
+// Circuit Breaker Tests
+// =============================================================================
+
+// withCircuitBreaker gives the fixture's handler a circuit breaker on the
+// fixture's clock and returns its notifier.
+func (f *suppressionFixture) withCircuitBreaker(config AlertCircuitBreakerConfig) *circuitNotifierMock {
+	breaker := NewAlertCircuitBreaker(config)
+	breaker.now = func() time.Time { return f.now }
+	notifier := &circuitNotifierMock{}
+	breaker.SetNotifier(notifier)
+	f.handler.SetCircuitBreaker(breaker)
+	return notifier
+}
+
+func TestAlertHandler_Handle_CircuitBreakerDefersStorm(t *testing.T) {
+	f := newSuppressionFixture(t)
+	notifier := f.withCircuitBreaker(AlertCircuitBreakerConfig{Window: 10 * time.Minute, Threshold: 2, Cooldown: time.Hour})
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		f.now = f.now.Add(time.Second)
+		if err := f.handler.Handle(ctx, diskAlert(fmt.Sprintf("DiskFull-%d", i))); err != nil {
+			t.Fatalf("Handle(DiskFull-%d) error = %v", i, err)
+		}
+	}
+
+	deferred := f.store.withStatus("deferred")
+	if got := len(f.store.withStatus("completed")); got != 2 || len(deferred) != 3 {
+		t.Fatalf("stored %d completed, %d deferred records; want 2 and 3", got, len(deferred))
+	}
+	if !strings.Contains(deferred[0].errorMessage, `"prometheus"`) || deferred[0].alert == nil {
+		t.Errorf("deferred record = %q (%v), want the reason and alert snapshot", deferred[0].errorMessage, deferred[0].alert)
+	}
+	if notifier.count() != 1 {
+		t.Errorf("notifications = %d, want 1 for the storm", notifier.count())
+	}
+}
+
+func TestAlertHandler_Handle_CircuitBreakerProbe(t *testing.T) {
+	f := newSuppressionFixture(t)
+	f.withCircuitBreaker(AlertCircuitBreakerConfig{Window: 10 * time.Minute, Threshold: 1, Cooldown: 5 * time.Minute})
+	ctx := context.Background()
+	for _, id := range []string{"DiskFull-1", "DiskFull-2"} {
+		if err := f.handler.Handle(ctx, diskAlert(id)); err != nil {
+			t.Fatalf("Handle(%s) error = %v", id, err)
+		}
+	}
+
+	// After the cooldown one alert probes the source; the async path tracks it by ID
+	f.now = f.now.Add(5 * time.Minute)
+	alert, err := entity.NewAlert("DiskFull-3", "prometheus", entity.SeverityCritical, "Disk Full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	invID, err := f.handler.HandleEntityAlertAsync(ctx, alert)
+	if err != nil || invID == "" {
+		t.Fatalf("HandleEntityAlertAsync() = %q, %v; want the probe started", invID, err)
+	}
+	if id, _ := f.handler.HandleEntityAlertAsync(ctx, alert); id != "" {
+		t.Fatalf("HandleEntityAlertAsync() during the probe started %q, want it deferred", id)
+	}
+	if err := f.handler.RunEntityAlertInvestigation(ctx, alert, invID); err != nil {
+		t.Fatalf("RunEntityAlertInvestigation() error = %v", err)
+	}
+
+	if got := f.handler.circuit.State("prometheus"); got != CircuitClosed {
+		t.Errorf("State() after a successful probe = %v, want closed", got)
+	}
+	if got := len(f.store.withStatus("deferred")); got != 2 {
+		t.Errorf("deferred records = %d, want 2", got)
+	}
+}
+
+func TestAlertHandler_ReprocessDeferred(t *testing.T) {
+	f := newSuppressionFixture(t)
+	f.withCircuitBreaker(AlertCircuitBreakerConfig{Window: 10 * time.Minute, Threshold: 1, Cooldown: 5 * time.Minute})
+	ctx := context.Background()
+	for _, id := range []string{"DiskFull-1", "DiskFull-2", "DiskFull-3"} {
+		f.now = f.now.Add(time.Second)
+		if err := f.handler.Handle(ctx, diskAlert(id)); err != nil {
+			t.Fatalf("Handle(%s) error = %v", id, err)
+		}
+	}
+
+	if n, err := f.handler.ReprocessDeferred(ctx, "grafana"); err != nil || n != 0 {
+		t.Fatalf("ReprocessDeferred(grafana) = %d, %v; want nothing reprocessed", n, err)
+	}
+
+	// While the circuit is open, reprocessed alerts are deferred again
+	n, err := f.handler.ReprocessDeferred(ctx, "")
+	if err != nil || n != 2 {
+		t.Fatalf("ReprocessDeferred() = %d, %v; want 2", n, err)
+	}
+	if got := len(f.store.withStatus("deferred")); got != 2 {
+		t.Fatalf("deferred records = %d, want the 2 alerts deferred again", got)
+	}
+
+	// After the cooldown the oldest is the probe, which closes the circuit; the
+	// next counts against the threshold again
+	f.now = f.now.Add(time.Hour)
+	if n, err := f.handler.ReprocessDeferred(ctx, "prometheus"); err != nil || n != 2 {
+		t.Fatalf("ReprocessDeferred() after the cooldown = %d, %v; want 2", n, err)
+	}
+	completed := f.store.withStatus("completed")
+	if len(completed) != 2 {
+		t.Fatalf("completed records = %d, want 2", len(completed))
+	}
+	if got := len(f.store.withStatus("reprocessed")); got != 4 {
+		t.Errorf("reprocessed records = %d, want 4", got)
+	}
+	deferred := f.store.withStatus("deferred")
+	if len(deferred) != 1 || deferred[0].alertID != "DiskFull-3" {
+		t.Errorf("deferred records = %d, want DiskFull-3 deferred again", len(deferred))
+	}
+
+	bare := NewAlertHandler(NewAlertInvestigationUseCase(), AlertHandlerConfig{})
+	if _, err := bare.ReprocessDeferred(ctx, ""); !errors.Is(err, ErrNoDeferredStore) {
+		t.Errorf("ReprocessDeferred() without store error = %v, want ErrNoDeferredStore", err)
+	}
+}
+
 // =============================================================================
 // AlertHandlerConfig Tests
 // =============================================================================
@@ -569,3 +897,174 @@ func TestAlertHandlerConfig_WithValues(t *testing.T) {
 		t.Errorf("IgnoredSources len = %v, want 2", len(config.IgnoredSources))
 	}
 }
+
+// statusConvServiceMock gives each investigation its own session, asks for a
+// bash call and then a read_file call, and completes on the third response.
+type statusConvServiceMock struct {
+	*investigationRunnerConvServiceMock
+	sessionsMu sync.Mutex
+	sessions   int
+	turns      map[string]int
+}
+
+func (m *statusConvServiceMock) StartConversation(ctx context.Context) (string, error) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+	m.sessions++
+	return fmt.Sprintf("session-%d", m.sessions), nil
+}
+
+func (m *statusConvServiceMock) ProcessAssistantResponse(
+	ctx context.Context,
+	sessionID string,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+	m.turns[sessionID]++
+	toolID := fmt.Sprintf("%s-%d", sessionID, m.turns[sessionID])
+	switch m.turns[sessionID] {
+	case 1:
+		return createAssistantMessage("Checking disk usage."), []port.ToolCallInfo{
+			{ToolID: toolID, ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}},
+		}, nil
+	case 2:
+		return createAssistantMessage("Reading the log."), []port.ToolCallInfo{
+			{ToolID: toolID, ToolName: "read_file", Input: map[string]interface{}{"path": "/var/log/syslog"}},
+		}, nil
+	}
+	return createAssistantMessage("Investigation complete."), nil, nil
+}
+
+func (m *statusConvServiceMock) ProcessAssistantResponseStreaming(
+	ctx context.Context,
+	sessionID string,
+	_ port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return m.ProcessAssistantResponse(ctx, sessionID)
+}
+
+// slowToolExecutorMock signals started when a tool begins and holds it until
+// release yields.
+type slowToolExecutorMock struct {
+	*investigationRunnerToolExecutorMock
+	started chan string
+	release chan struct{}
+}
+
+func (m *slowToolExecutorMock) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
+	m.started <- name
+	select {
+	case <-m.release:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	return m.investigationRunnerToolExecutorMock.ExecuteTool(ctx, name, input)
+}
+
+func TestAlertHandler_GetStatus(t *testing.T) {
+	executor := &slowToolExecutorMock{
+		investigationRunnerToolExecutorMock: newInvestigationRunnerToolExecutorMock(),
+		started:                             make(chan string, 2),
+		release:                             make(chan struct{}),
+	}
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(&statusConvServiceMock{
+		investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+		turns:                              make(map[string]int),
+	})
+	uc.SetToolExecutor(executor)
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	handler := NewAlertHandler(uc, AlertHandlerConfig{AutoInvestigateCritical: true, AutoInvestigateWarning: true})
+	breaker := NewAlertCircuitBreaker(AlertCircuitBreakerConfig{Window: 10 * time.Minute, Threshold: 10, Cooldown: time.Hour})
+	handler.SetCircuitBreaker(breaker)
+	ctx := context.Background()
+
+	start := func(id, severity string) (*entity.Alert, string) {
+		t.Helper()
+		alert, err := entity.NewAlert(id, "prometheus", severity, "Alert "+id)
+		if err != nil {
+			t.Fatalf("NewAlert(%s) error = %v", id, err)
+		}
+		invID, err := handler.HandleEntityAlertAsync(ctx, alert)
+		if err != nil || invID == "" {
+			t.Fatalf("HandleEntityAlertAsync(%s) = %q, %v; want an investigation", id, invID, err)
+		}
+		return alert, invID
+	}
+
+	// Two slow investigations run; a warning and a critical alert wait behind them
+	var wg sync.WaitGroup
+	running := make(map[string]string)
+	for _, id := range []string{"slow-1", "slow-2"} {
+		alert, invID := start(id, entity.SeverityCritical)
+		running[invID] = id
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler.RunEntityAlertInvestigation(ctx, alert, invID); err != nil {
+				t.Errorf("RunEntityAlertInvestigation(%s) error = %v", id, err)
+			}
+		}()
+	}
+	start("queued-warning", entity.SeverityWarning)
+	start("queued-critical", entity.SeverityCritical)
+
+	waitForTools := func() {
+		t.Helper()
+		for range 2 {
+			select {
+			case <-executor.started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the investigations to run a tool")
+			}
+		}
+	}
+	assertActive := func(status port.DaemonStatus, actions int, tool string) {
+		t.Helper()
+		if len(status.Active) != 2 {
+			t.Fatalf("Active = %+v, want the 2 running investigations", status.Active)
+		}
+		for _, active := range status.Active {
+			if running[active.InvestigationID] != active.AlertID || active.AlertTitle != "Alert "+active.AlertID {
+				t.Errorf("active investigation %+v does not match its alert", active)
+			}
+			if active.Actions != actions || active.CurrentTool != tool {
+				t.Errorf("%s progress = %d actions, running %q; want %d, %q",
+					active.AlertID, active.Actions, active.CurrentTool, actions, tool)
+			}
+			if active.Elapsed <= 0 {
+				t.Errorf("%s Elapsed = %v, want it positive", active.AlertID, active.Elapsed)
+			}
+		}
+	}
+
+	waitForTools()
+	status := handler.GetStatus()
+	assertActive(status, 0, "bash")
+	if len(status.Queued) != 2 || status.Queued[0].AlertID != "queued-critical" ||
+		status.Queued[1].AlertID != "queued-warning" || status.Queued[1].Priority != entity.SeverityWarning {
+		t.Errorf("Queued = %+v, want the critical alert ahead of the warning", status.Queued)
+	}
+	wantWorkers := port.WorkerPoolStatus{Capacity: 5, Running: 2, Queued: 2, Utilization: 0.8}
+	if status.Workers != wantWorkers {
+		t.Errorf("Workers = %+v, want %+v", status.Workers, wantWorkers)
+	}
+	if len(status.Circuits) != 1 || status.Circuits[0].Source != "prometheus" ||
+		status.Circuits[0].State != string(CircuitClosed) || status.Circuits[0].Recent != 4 {
+		t.Errorf("Circuits = %+v, want prometheus closed with 4 recent investigations", status.Circuits)
+	}
+
+	// Finishing the first tool moves both investigations on to the second
+	executor.release <- struct{}{}
+	executor.release <- struct{}{}
+	waitForTools()
+	assertActive(handler.GetStatus(), 1, "read_file")
+
+	close(executor.release)
+	wg.Wait()
+	status = handler.GetStatus()
+	if len(status.Active) != 0 || len(status.Queued) != 2 || status.Workers.Running != 0 {
+		t.Errorf("after the runs finished, status = %+v; want only the queued alerts", status)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,6 +31,7 @@ type ConversationServiceInterface interface {
 	SetCustomSystemPrompt(ctx context.Context, sessionID, prompt string) error
 	SetThinkingMode(sessionID string, info port.ThinkingModeInfo) error
 	GetThinkingMode(sessionID string) (port.ThinkingModeInfo, error)
+	SetSessionModel(sessionID, model string) error
 }
 
 // SafetyEnforcer defines the interface for safety checks during investigations.
@@ -40,6 +43,13 @@ type SafetyEnforcer interface {
 	CheckTimeout(ctx context.Context) error
 }
 
+// InvestigationResultNotifier is told about every finished investigation of
+// an alert that is not historical, so the result can be pushed to external
+// systems. Implementations must return without blocking on delivery.
+type InvestigationResultNotifier interface {
+	NotifyInvestigationResult(alert *AlertForInvestigation, result *InvestigationResult)
+}
+
 // InvestigationRecordData is the interface for investigation persistence.
 // Matches the InvestigationRecord type from service package.
 type InvestigationRecordData interface {
@@ -56,6 +66,15 @@ type InvestigationRecordData interface {
 	Confidence() float64
 	Escalated() bool
 	EscalateReason() string
+	ErrorMessage() string
+	ErrorKind() string    // Class of the failure, one of the ErrorKind constants, if it failed
+	Alert() *entity.Alert // The investigated alert, or nil if not recorded
+	RootCause() string
+	RecommendedActions() []entity.RecommendedAction
+	Remediations() []entity.RemediationAttempt // Commands run to remediate the alert, if any
+	Cost() float64                             // Estimated AI spend in US dollars
+	Usage() entity.TokenUsage                  // Tokens of the AI turns
+	Version() string                           // Build of the agent that produced the record; "" until the store stamps it
 }
 
 // InvestigationStoreWriter defines the write interface for investigation persistence.
@@ -78,6 +97,15 @@ type simpleInvestigationRecord struct {
 	confidence     float64
 	escalated      bool
 	escalateReason string
+	errorMessage   string
+	errorKind      string
+	alert          *entity.Alert
+	rootCause      string
+	actions        []entity.RecommendedAction
+	remediations   []entity.RemediationAttempt
+	cost           float64
+	usage          entity.TokenUsage
+	version        string
 }
 
 func (s *simpleInvestigationRecord) ID() string        { return s.id }
@@ -97,6 +125,79 @@ func (s *simpleInvestigationRecord) Duration() time.Duration { return time.Durat
 func (s *simpleInvestigationRecord) Confidence() float64     { return s.confidence }
 func (s *simpleInvestigationRecord) Escalated() bool         { return s.escalated }
 func (s *simpleInvestigationRecord) EscalateReason() string  { return s.escalateReason }
+func (s *simpleInvestigationRecord) ErrorMessage() string    { return s.errorMessage }
+func (s *simpleInvestigationRecord) ErrorKind() string       { return s.errorKind }
+func (s *simpleInvestigationRecord) Alert() *entity.Alert    { return s.alert }
+func (s *simpleInvestigationRecord) RootCause() string       { return s.rootCause }
+func (s *simpleInvestigationRecord) RecommendedActions() []entity.RecommendedAction {
+	return s.actions
+}
+func (s *simpleInvestigationRecord) Remediations() []entity.RemediationAttempt {
+	return s.remediations
+}
+func (s *simpleInvestigationRecord) Cost() float64            { return s.cost }
+func (s *simpleInvestigationRecord) Usage() entity.TokenUsage { return s.usage }
+func (s *simpleInvestigationRecord) Version() string          { return s.version }
+
+// newResultRecord creates the record of a finished investigation's result.
+// inv is nil for investigations not started with StartInvestigation.
+func newResultRecord(
+	invID string,
+	alert *AlertForInvestigation,
+	inv *activeInvestigation,
+	result *InvestigationResult,
+) *simpleInvestigationRecord {
+	stub := newSimpleInvestigationRecord(invID, alert.ID(), "", result.Status)
+	stub.completedAt = time.Now()
+	stub.startedAt = stub.completedAt.Add(-result.Duration)
+	if inv != nil {
+		stub.startedAt = inv.startedAt
+	}
+	stub.findings = result.Findings
+	stub.actionsTaken = result.ActionsTaken
+	stub.durationNanos = int64(result.Duration)
+	stub.confidence = result.Confidence
+	stub.escalated = result.Escalated
+	stub.escalateReason = result.EscalateReason
+	stub.alert = alert.toEntity()
+	stub.rootCause = result.RootCause
+	stub.actions = result.RecommendedActions
+	stub.remediations = result.Remediations
+	stub.cost = result.Cost
+	stub.usage = result.Usage
+	if result.Error != nil {
+		stub.errorMessage = result.Error.Error()
+		stub.errorKind = result.ErrorKind
+	}
+	return stub
+}
+
+// recordWithStatus returns a copy of a stored record with another status.
+func recordWithStatus(record InvestigationRecordData, status string) *simpleInvestigationRecord {
+	return &simpleInvestigationRecord{
+		id:             record.ID(),
+		alertID:        record.AlertID(),
+		sessionID:      record.SessionID(),
+		status:         status,
+		startedAt:      record.StartedAt(),
+		completedAt:    record.CompletedAt(),
+		findings:       record.Findings(),
+		actionsTaken:   record.ActionsTaken(),
+		durationNanos:  int64(record.Duration()),
+		confidence:     record.Confidence(),
+		escalated:      record.Escalated(),
+		escalateReason: record.EscalateReason(),
+		errorMessage:   record.ErrorMessage(),
+		errorKind:      record.ErrorKind(),
+		alert:          record.Alert(),
+		rootCause:      record.RootCause(),
+		actions:        record.RecommendedActions(),
+		remediations:   record.Remediations(),
+		cost:           record.Cost(),
+		usage:          record.Usage(),
+		version:        record.Version(),
+	}
+}
 
 func newSimpleInvestigationRecord(id, alertID, sessionID, status string) *simpleInvestigationRecord {
 	return &simpleInvestigationRecord{
@@ -112,17 +213,18 @@ func newSimpleInvestigationRecord(id, alertID, sessionID, status string) *simple
 // These errors indicate various failure conditions during investigation.
 var (
 	// ErrAlertNil is returned when nil is passed as the alert parameter.
-	ErrAlertNil = errors.New("alert cannot be nil")
+	ErrAlertNil = fmt.Errorf("%w: alert cannot be nil", ErrInvalidAlert)
 	// ErrInvestigationAlreadyRunning is returned when starting an investigation
 	// for an alert that already has an active investigation.
 	ErrInvestigationAlreadyRunning = errors.New("investigation already running for this alert")
 	// ErrMaxConcurrentReached is returned when the maximum number of concurrent
 	// investigations has been reached.
 	ErrMaxConcurrentReached = errors.New("maximum concurrent investigations reached")
+	// ErrTooManyInvestigations is returned by InvestigationRunner.Run when no
+	// MaxConcurrent slot frees up within MaxConcurrentWait.
+	ErrTooManyInvestigations = errors.New("too many concurrent investigations")
 	// ErrInvestigationTimeout is returned when an investigation exceeds its time limit.
 	ErrInvestigationTimeout = errors.New("investigation timed out")
-	// ErrActionBudgetExceeded is returned when an investigation exceeds its action limit.
-	ErrActionBudgetExceeded = errors.New("action budget exceeded")
 	// ErrToolNotAllowed is returned when an investigation attempts to use a disallowed tool.
 	ErrToolNotAllowed = errors.New("tool not allowed by investigation config")
 	// ErrCommandBlocked is returned when a command matches a blocked pattern.
@@ -131,17 +233,49 @@ var (
 	ErrInvestigationNotFoundUC = errors.New("investigation not found")
 	// ErrUseCaseShutdown is returned when operations are attempted after shutdown.
 	ErrUseCaseShutdown = errors.New("use case is shutdown")
+	// ErrInvestigationInterrupted is returned when an investigation is cancelled
+	// before it finishes, by StopInvestigation, Shutdown, or its context.
+	ErrInvestigationInterrupted = errors.New("investigation interrupted")
+	// ErrInvestigationCancelled is the error of an investigation cancelled on
+	// request by CancelInvestigation.
+	ErrInvestigationCancelled = errors.New("investigation cancelled")
+	// ErrInvestigationNotRerunnable is returned when rerunning an investigation
+	// whose record has no alert, such as one stored before alerts were recorded.
+	ErrInvestigationNotRerunnable = errors.New("investigation has no recorded alert to rerun")
+	// ErrNoInvestigationStore is returned when an operation needs stored
+	// investigations but no investigation store is configured.
+	ErrNoInvestigationStore = errors.New("investigation store not configured")
 )
 
 // AlertForInvestigation represents alert data passed to the investigation use case.
 // It is a lightweight view of an alert containing only the fields needed for investigation.
 type AlertForInvestigation struct {
-	id          string            // Unique alert identifier
-	source      string            // Alert source system
-	severity    string            // Alert severity level
-	title       string            // Human-readable title
-	description string            // Detailed description
-	labels      map[string]string // Additional metadata
+	id           string            // Unique alert identifier
+	source       string            // Alert source system
+	severity     string            // Alert severity level
+	title        string            // Human-readable title
+	description  string            // Detailed description
+	labels       map[string]string // Additional metadata
+	annotations  map[string]string // Descriptive metadata such as runbook_url
+	fingerprint  string            // Identity across firings, if set by the source
+	generatorURL string            // Link to the expression that fired the alert
+	historical   bool              // Replayed from the past; see entity.Alert.Historical
+}
+
+// NewAlertForInvestigationFromEntity converts a domain alert for investigation.
+func NewAlertForInvestigationFromEntity(alert *entity.Alert) *AlertForInvestigation {
+	return &AlertForInvestigation{
+		id:           alert.ID(),
+		source:       alert.Source(),
+		severity:     alert.Severity(),
+		title:        alert.Title(),
+		description:  alert.Description(),
+		labels:       alert.Labels(),
+		annotations:  alert.Annotations(),
+		fingerprint:  alert.Fingerprint(),
+		generatorURL: alert.GeneratorURL(),
+		historical:   alert.Historical(),
+	}
 }
 
 // ID returns the unique alert identifier.
@@ -162,6 +296,40 @@ func (a *AlertForInvestigation) Description() string { return a.description }
 // Labels returns the metadata labels attached to this alert.
 func (a *AlertForInvestigation) Labels() map[string]string { return a.labels }
 
+// Annotations returns the descriptive annotations attached to this alert.
+func (a *AlertForInvestigation) Annotations() map[string]string { return a.annotations }
+
+// Fingerprint returns the identity the alert keeps across firings; see
+// entity.Alert.Fingerprint.
+func (a *AlertForInvestigation) Fingerprint() string {
+	if a.fingerprint != "" {
+		return a.fingerprint
+	}
+	return entity.AlertFingerprint(a.source, a.title, a.labels)
+}
+
+// GeneratorURL returns the link to the expression that fired the alert, such
+// as a Prometheus graph URL, or "" if the source gave none.
+func (a *AlertForInvestigation) GeneratorURL() string { return a.generatorURL }
+
+// Historical reports whether the alert was replayed from the past, such as by
+// a backfill. The results of historical alerts are not notified.
+func (a *AlertForInvestigation) Historical() bool { return a.historical }
+
+// toEntity converts the alert back to a domain alert for persistence.
+// Returns nil if the alert is not a valid domain alert.
+func (a *AlertForInvestigation) toEntity() *entity.Alert {
+	if a == nil {
+		return nil
+	}
+	alert, err := entity.NewAlert(a.id, a.source, a.severity, a.title)
+	if err != nil {
+		return nil
+	}
+	return alert.WithDescription(a.description).WithLabels(a.labels).WithAnnotations(a.annotations).
+		WithFingerprint(a.fingerprint).WithGeneratorURL(a.generatorURL).WithHistorical(a.historical)
+}
+
 // IsCritical returns true if the alert severity is "critical".
 func (a *AlertForInvestigation) IsCritical() bool {
 	return a.severity == string(EscalationPriorityCritical)
@@ -170,16 +338,28 @@ func (a *AlertForInvestigation) IsCritical() bool {
 // InvestigationResult represents the outcome of an investigation.
 // It provides a summary of what happened during the investigation.
 type InvestigationResult struct {
-	InvestigationID string        // Unique identifier for this investigation
-	AlertID         string        // ID of the investigated alert
-	Status          string        // Final status (completed, failed, escalated)
-	Findings        []string      // Summary of findings discovered
-	ActionsTaken    int           // Number of tool executions performed
-	Duration        time.Duration // Total investigation time
-	Confidence      float64       // Confidence level in the outcome [0.0, 1.0]
-	Escalated       bool          // Whether the investigation was escalated
-	EscalateReason  string        // Reason for escalation, if applicable
-	Error           error         // Any error that occurred
+	InvestigationID   string        // Unique identifier for this investigation
+	AlertID           string        // ID of the investigated alert
+	Status            string        // Final status (completed, failed, escalated, cancelled, rejected)
+	Findings          []string      // Summary of findings discovered
+	ActionsTaken      int           // Number of tool executions performed
+	Duration          time.Duration // Total investigation time
+	Confidence        float64       // Confidence level in the outcome [0.0, 1.0]
+	ConfidenceDerived bool          // Confidence was estimated from tool results, not reported by the AI
+	Escalated         bool          // Whether the investigation was escalated
+	EscalateReason    string        // Reason for escalation, if applicable
+	Error             error         // Any error that occurred
+	ErrorKind         string        // Class of Error, one of the ErrorKind constants; "" without one
+
+	RootCause          string                      // Root cause reported on completion, if any
+	RecommendedActions []entity.RecommendedAction  // Actions recommended on completion, if any
+	Remediations       []entity.RemediationAttempt // Recommended commands the run tried to carry out
+	Timeline           []port.InvestigationEvent   // Iteration and tool events of the run, in order
+	Artifacts          []InvestigationArtifact     // Tool outputs from the timeline, truncated
+	ModifiedFiles      []FileChange                // Files the investigation's tools changed, from snapshots
+	ToolStats          []ToolStats                 // Per-tool usage of the investigation's tool calls, most used first
+	Cost               float64                     // Estimated AI spend in US dollars; 0 without pricing
+	Usage              entity.TokenUsage           // Tokens of the AI turns, summed
 }
 
 // AlertInvestigationUseCaseConfig holds configuration for the investigation use case.
@@ -187,7 +367,8 @@ type InvestigationResult struct {
 type AlertInvestigationUseCaseConfig struct {
 	MaxActions           int           // Maximum tool executions per investigation
 	MaxDuration          time.Duration // Maximum investigation time
-	MaxConcurrent        int           // Maximum simultaneous investigations
+	MaxConcurrent        int           // Maximum simultaneous investigations; 0 or less means no limit
+	MaxConcurrentWait    time.Duration // Longest a run waits for a MaxConcurrent slot; 0 means until cancelled
 	AllowedTools         []string      // Tools that investigations may use
 	BlockedCommands      []string      // Command patterns that are blocked
 	EscalateOnConfidence float64       // Escalate when confidence is below this value
@@ -197,6 +378,43 @@ type AlertInvestigationUseCaseConfig struct {
 	ExtendedThinking     bool          // Enable extended thinking for investigations
 	ThinkingBudget       int64         // Token budget for thinking (default: 10000)
 	ShowThinking         bool          // Display thinking output in logs
+	MaxCost              float64       // Most an investigation may spend on AI turns, in US dollars; 0 means no cap
+	MaxSchemaRetries     int           // Consecutive invalid inputs to one tool before it is disabled; 0 means 2
+	CountSubagentUsage   bool          // Count the actions, tokens, and cost of spawned subagents toward the budgets
+	AutoRemediate        bool          // Run approved low-risk recommended commands after completing
+
+	// AllowedCommandPatterns switches bash and wait_for commands to allowlist
+	// mode when non-empty: each segment of a command (split at pipes, &&, ||
+	// and ;) must match one of these regular expressions, anchored at the
+	// segment start, or the command is refused with the patterns listed. Command
+	// substitution and subshells are always refused in this mode.
+	AllowedCommandPatterns []string
+
+	// SeverityOverrides replaces MaxActions and MaxDuration for alerts of a
+	// given severity (e.g. a longer budget for "critical" alerts).
+	SeverityOverrides map[string]InvestigationLimits
+}
+
+// InvestigationLimits overrides the limits of investigations for one alert severity.
+// Zero fields keep the use case's default.
+type InvestigationLimits struct {
+	MaxActions  int           // Maximum tool executions per investigation
+	MaxDuration time.Duration // Maximum investigation time
+}
+
+// forSeverity returns the config with the overrides for severity applied.
+func (c AlertInvestigationUseCaseConfig) forSeverity(severity string) AlertInvestigationUseCaseConfig {
+	limits, ok := c.SeverityOverrides[severity]
+	if !ok {
+		return c
+	}
+	if limits.MaxActions > 0 {
+		c.MaxActions = limits.MaxActions
+	}
+	if limits.MaxDuration > 0 {
+		c.MaxDuration = limits.MaxDuration
+	}
+	return c
 }
 
 // AlertInvestigationUseCase orchestrates AI-driven alert investigations.
@@ -212,20 +430,111 @@ type AlertInvestigationUseCase struct {
 	promptBuilderRegistry PromptBuilderRegistry           // Generates investigation prompts
 	safetyEnforcer        SafetyEnforcer                  // Safety policy enforcer
 	investigationStore    InvestigationStoreWriter        // Persistence for investigations
+	resultNotifier        InvestigationResultNotifier     // Pushes finished results to external systems
+	progressSink          port.InvestigationProgressSink  // Receives progress events of running investigations
+	changeTracker         WorkspaceChangeTracker          // Reports the files each investigation changed
+	toolStats             ToolStatsSource                 // Reports each investigation's tool usage
+	approver              ApprovalProvider                // Approves remediation commands when AutoRemediate is set
+	messageTemplate       *AlertTemplate                  // Renders the message that starts each run; nil uses the default
+	pricing               Pricing                         // Prices each investigation's AI turns
+	model                 func() string                   // Model the turns are priced as
+	dailyBudget           *DailyBudget                    // Stops new investigations once spent
+	scheduler             *AlertScheduler                 // Shares slots between alert sources; nil caps at MaxConcurrent
+	runSlots              *runSemaphore                   // Shared by the runners, so runs never exceed MaxConcurrent
+	providerGate          *ProviderGate                   // Holds investigations while the AI provider is down
 	convService           ConversationServiceInterface    // Conversation service for AI interaction
 	toolExecutor          port.ToolExecutor               // Tool executor for running tools
 	skillManager          port.SkillManager               // Skill manager for discovering skills
 	uiAdapter             port.UserInterface              // User interface for displaying output
+	metrics               port.MetricsRecorder            // Recorder for investigation metrics
+	tracer                port.Tracer                     // Tracer for investigation spans
+	logger                *slog.Logger                    // Logger for investigation logs
 	shutdown              bool                            // True after Shutdown is called
 	idCounter             int64                           // Counter for generating unique IDs
 }
 
-// activeInvestigation tracks a running investigation.
+// activeInvestigation tracks a started investigation.
+// Investigations are queued until RunInvestigation begins running them.
 type activeInvestigation struct {
-	id        string             // Unique investigation identifier
-	alertID   string             // Alert being investigated
-	startedAt time.Time          // When investigation started
-	cancel    context.CancelFunc // Cancels the investigation context
+	id        string                 // Unique investigation identifier
+	alertID   string                 // Alert being investigated
+	alert     *AlertForInvestigation // Full alert, recorded with each status update
+	startedAt time.Time              // When investigation started
+	cancel    context.CancelFunc     // Cancels the investigation context; nil while queued
+	done      chan struct{}          // Closed when RunInvestigation returns; nil while queued
+	waiting   bool                   // Running but still queued for a scheduler slot
+	progress  *investigationProgress // Published by the runner while it runs
+	cancelled *cancellation          // Set by CancelInvestigation
+}
+
+// queued reports whether the investigation has not started running: it is
+// waiting to be run, for a scheduler slot, or for a runner slot.
+func (inv *activeInvestigation) queued() bool {
+	return inv.done == nil || inv.waiting || inv.progress.isWaiting()
+}
+
+// cancellation is a request to cancel an investigation.
+type cancellation struct {
+	reason      string
+	requestedBy string
+}
+
+// err returns the error recorded for the cancelled investigation.
+func (c *cancellation) err() error {
+	err := ErrInvestigationCancelled
+	if c.requestedBy != "" {
+		err = fmt.Errorf("%w by %s", err, c.requestedBy)
+	}
+	if c.reason != "" {
+		err = fmt.Errorf("%w: %s", err, c.reason)
+	}
+	return err
+}
+
+// investigationProgress is what a running investigation publishes for status
+// snapshots. The runner updates it with atomics, so a snapshot never waits on
+// a tool call and the runner never waits on a snapshot. A nil progress
+// ignores updates.
+type investigationProgress struct {
+	actions     atomic.Int64
+	currentTool atomic.Pointer[string]
+	waiting     atomic.Bool // The runner is waiting for a MaxConcurrent slot
+}
+
+// setWaiting records whether the runner is waiting for a MaxConcurrent slot.
+func (p *investigationProgress) setWaiting(waiting bool) {
+	if p != nil {
+		p.waiting.Store(waiting)
+	}
+}
+
+// isWaiting reports whether the runner is waiting for a MaxConcurrent slot.
+func (p *investigationProgress) isWaiting() bool {
+	return p != nil && p.waiting.Load()
+}
+
+// toolStarted records that the named tool is running.
+func (p *investigationProgress) toolStarted(name string) {
+	if p != nil {
+		p.currentTool.Store(&name)
+	}
+}
+
+// toolFinished records that the running tool finished, with the number of
+// tool calls finished so far.
+func (p *investigationProgress) toolFinished(actions int) {
+	if p != nil {
+		p.actions.Store(int64(actions))
+		p.currentTool.Store(nil)
+	}
+}
+
+// snapshot returns the tool calls finished so far and the running tool, if any.
+func (p *investigationProgress) snapshot() (actions int, currentTool string) {
+	if tool := p.currentTool.Load(); tool != nil {
+		currentTool = *tool
+	}
+	return int(p.actions.Load()), currentTool
 }
 
 // NewAlertInvestigationUseCase creates a new use case with sensible defaults.
@@ -250,6 +559,7 @@ func NewAlertInvestigationUseCase() *AlertInvestigationUseCase {
 		},
 		activeInvestigations: make(map[string]*activeInvestigation),
 		alertToInvestigation: make(map[string]string),
+		logger:               slog.Default(),
 	}
 }
 
@@ -259,6 +569,7 @@ func NewAlertInvestigationUseCaseWithConfig(config AlertInvestigationUseCaseConf
 		config:               config,
 		activeInvestigations: make(map[string]*activeInvestigation),
 		alertToInvestigation: make(map[string]string),
+		logger:               slog.Default(),
 	}
 }
 
@@ -285,6 +596,36 @@ func (uc *AlertInvestigationUseCase) HandleAlert(
 	return uc.RunInvestigation(ctx, alert, invID)
 }
 
+// RerunInvestigation investigates the alert of a stored investigation again,
+// as a new investigation, and returns its result. The stored investigation is
+// left as it was.
+//
+// Returns ErrNoInvestigationStore without an investigation store, and
+// ErrInvestigationNotRerunnable if the record has no alert.
+func (uc *AlertInvestigationUseCase) RerunInvestigation(
+	ctx context.Context,
+	invID string,
+) (*InvestigationResult, error) {
+	uc.mu.RLock()
+	store := uc.investigationStore
+	uc.mu.RUnlock()
+
+	if store == nil {
+		return nil, ErrNoInvestigationStore
+	}
+
+	record, err := store.Get(ctx, invID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load investigation %s: %w", invID, err)
+	}
+	alert := record.Alert()
+	if alert == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvestigationNotRerunnable, invID)
+	}
+
+	return uc.HandleAlert(ctx, NewAlertForInvestigationFromEntity(alert))
+}
+
 // RunInvestigation runs an already-started investigation.
 // StartInvestigation must be called first to obtain the invID.
 // This method is useful for async workflows where the investigation ID
@@ -304,19 +645,80 @@ func (uc *AlertInvestigationUseCase) RunInvestigation(
 		return nil, ErrAlertNil
 	}
 
-	// Cleanup tracking maps when investigation completes
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	inv, err := uc.beginRun(invID, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cleanup tracking maps when investigation completes, then let Shutdown
+	// know this run has finished
+	finished := false
 	defer func() {
-		uc.mu.Lock()
-		uc.cleanupInvestigationTracking(invID, alert.ID())
-		uc.mu.Unlock()
+		if !finished {
+			uc.finishRun(inv, invID, alert.ID())
+		}
+		if inv != nil {
+			close(inv.done)
+		}
 	}()
 
-	// Check if safety enforcer blocks all investigation tools
+	// Hold the investigation while the AI provider is down, then, with a
+	// scheduler, wait for a slot the alert's source may take
+	err = uc.awaitProvider(runCtx, inv, alert)
+	if errors.Is(err, ErrProviderUnavailable) {
+		return uc.deferHeld(ctx, invID, alert, inv, err), nil
+	}
+	release := func() {}
+	if err == nil {
+		release, err = uc.awaitSlot(runCtx, inv, alert)
+	}
+	if err != nil {
+		finished = true
+		if uc.finishRun(inv, invID, alert.ID()) {
+			uc.mu.RLock()
+			store := uc.investigationStore
+			uc.mu.RUnlock()
+			if cancelled := uc.cancellationOf(inv); cancelled != nil {
+				return uc.recordCancelled(ctx, store, invID, alert, inv, nil, cancelled), nil
+			}
+			uc.recordInterrupted(ctx, store, invID, alert, inv, "investigation cancelled while queued: "+err.Error())
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvestigationInterrupted, err)
+	}
+	defer release()
+
+	// Investigations queued before the daily budget was spent wait for the next day
+	if reason := uc.budgetExhausted(ctx); reason != "" {
+		return uc.deferRun(ctx, invID, alert, inv, reason), nil
+	}
+
+	// Snapshot the dependencies and config, so a reload while the
+	// investigation runs does not change them
 	uc.mu.RLock()
 	enforcer := uc.safetyEnforcer
-	allowedTools := uc.config.AllowedTools
+	convService := uc.convService
+	toolExecutor := uc.toolExecutor
+	promptBuilder := uc.promptBuilderRegistry
+	skillManager := uc.skillManager
+	uiAdapter := uc.uiAdapter
+	config := uc.config.forSeverity(alert.Severity())
+	store := uc.investigationStore
+	resultNotifier := uc.resultNotifier
+	progressSink := uc.progressSink
+	changeTracker := uc.changeTracker
+	toolStats := uc.toolStats
+	approver := uc.approver
+	messageTemplate := uc.messageTemplate
+	pricing, model, budget := uc.pricing, uc.model, uc.dailyBudget
+	metrics := uc.metrics
+	tracer := uc.tracer
+	logger := uc.logger
 	uc.mu.RUnlock()
 
+	// Check if safety enforcer blocks all investigation tools
+	allowedTools := config.AllowedTools
 	if enforcer != nil && len(allowedTools) > 0 {
 		allBlocked := true
 		for _, tool := range allowedTools {
@@ -337,21 +739,13 @@ func (uc *AlertInvestigationUseCase) RunInvestigation(
 				Confidence:      0.0,
 				Escalated:       true,
 				EscalateReason:  "all investigation tools are blocked by safety policy",
+				Error:           fmt.Errorf("%w: all investigation tools are blocked by safety policy", ErrToolBlocked),
+				ErrorKind:       ErrorKindToolBlocked,
 			}, nil
 		}
 	}
 
 	// Run actual investigation using InvestigationRunner
-	uc.mu.RLock()
-	convService := uc.convService
-	toolExecutor := uc.toolExecutor
-	promptBuilder := uc.promptBuilderRegistry
-	skillManager := uc.skillManager
-	uiAdapter := uc.uiAdapter
-	config := uc.config
-	store := uc.investigationStore
-	uc.mu.RUnlock()
-
 	if convService == nil || toolExecutor == nil {
 		return nil, errors.New(
 			"investigation dependencies not configured: conversation service and tool executor are required",
@@ -367,27 +761,102 @@ func (uc *AlertInvestigationUseCase) RunInvestigation(
 		uiAdapter,
 		config,
 	)
-	result, err := runner.Run(ctx, alert, invID)
+	if metrics != nil {
+		runner.SetMetricsRecorder(metrics)
+	}
+	runner.SetProgressSink(progressSink)
+	runner.SetChangeTracker(changeTracker)
+	runner.SetToolStats(toolStats)
+	runner.SetApprovalProvider(approver)
+	runner.SetMessageTemplate(messageTemplate)
+	runner.SetPricing(pricing, model)
+	runner.SetTracer(tracer)
+	runner.SetLogger(logger)
+	if inv != nil {
+		runner.progress = inv.progress
+	}
+	runner.slots = uc.runSlotsFor(config.MaxConcurrent)
+	result, err := runner.Run(runCtx, alert, invID)
+	finished = true
+	if budget != nil && result != nil {
+		if err := budget.Add(context.WithoutCancel(ctx), result.Cost); err != nil {
+			logger.Error("Failed to record investigation cost", "investigation_id", invID, "error", err)
+		}
+	}
+	if !uc.finishRun(inv, invID, alert.ID()) {
+		// StopInvestigation or Shutdown has already recorded the final status
+		return nil, fmt.Errorf("%w: %s", ErrInvestigationInterrupted, invID)
+	}
+	if cancelled := uc.cancellationOf(inv); cancelled != nil {
+		return uc.recordCancelled(ctx, store, invID, alert, inv, result, cancelled), nil
+	}
+	if result != nil && resultNotifier != nil && !alert.Historical() {
+		resultNotifier.NotifyInvestigationResult(alert, result)
+	}
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			uc.recordInterrupted(ctx, store, invID, alert, inv, "investigation cancelled: "+ctxErr.Error())
+			return nil, fmt.Errorf("%w: %w", ErrInvestigationInterrupted, err)
+		}
+		// Record the failure, with its kind, in place of the "started" stub
+		if store != nil && result != nil {
+			_ = store.Update(ctx, newResultRecord(invID, alert, inv, result))
+		}
 		return nil, err
 	}
 
-	// Update store with final status if configured
+	// Update store with the final result if configured
 	if store != nil {
-		stub := newSimpleInvestigationRecord(invID, alert.ID(), "", result.Status)
-		_ = store.Update(ctx, stub)
+		_ = store.Update(ctx, newResultRecord(invID, alert, inv, result))
 	}
 
 	return result, nil
 }
 
+// RenderPrompt returns the investigation prompt that would be sent for an alert
+// without starting an investigation. It uses the same prompt builder, tool
+// allowlist, and skills as RunInvestigation, so prompt templates can be reviewed.
+//
+// Returns ErrAlertNil if alert is nil.
+func (uc *AlertInvestigationUseCase) RenderPrompt(ctx context.Context, alert *entity.Alert) (string, error) {
+	if alert == nil {
+		return "", ErrAlertNil
+	}
+
+	uc.mu.RLock()
+	toolExecutor := uc.toolExecutor
+	enforcer := uc.safetyEnforcer
+	promptBuilder := uc.promptBuilderRegistry
+	skillManager := uc.skillManager
+	config := uc.config
+	logger := uc.logger
+	uc.mu.RUnlock()
+
+	if toolExecutor == nil {
+		return "", errors.New("investigation dependencies not configured: tool executor is required")
+	}
+
+	runner := &InvestigationRunner{
+		toolExecutor:   toolExecutor,
+		safetyEnforcer: enforcer,
+		promptBuilder:  promptBuilder,
+		skillManager:   skillManager,
+		config:         config,
+	}
+	investigated := NewAlertForInvestigationFromEntity(alert)
+	return runner.buildPrompt(ctx, investigated, runner.resolveTools(investigated, logger))
+}
+
 // StartInvestigation starts a new investigation for an alert.
 // Returns the investigation ID on success.
 //
 // Safety checks performed:
 //   - Rejects if alert is nil (ErrAlertNil)
 //   - Rejects if investigation already running for this alert (ErrInvestigationAlreadyRunning)
-//   - Rejects if max concurrent limit reached (ErrMaxConcurrentReached)
+//   - Rejects if max concurrent limit reached (ErrMaxConcurrentReached); a
+//     historical alert is rejected one short of it, so live alerts keep a slot.
+//     With a scheduler, live alerts are not rejected: RunInvestigation waits
+//     for a slot instead
 //   - Rejects if use case is shutdown (ErrUseCaseShutdown)
 func (uc *AlertInvestigationUseCase) StartInvestigation(
 	ctx context.Context,
@@ -413,20 +882,24 @@ func (uc *AlertInvestigationUseCase) StartInvestigation(
 		return "", ErrInvestigationAlreadyRunning
 	}
 
-	// Check max concurrent
-	if uc.config.MaxConcurrent > 0 && len(uc.activeInvestigations) >= uc.config.MaxConcurrent {
+	// Check max concurrent; historical alerts leave the last slot to live ones
+	limit := uc.config.MaxConcurrent
+	if alert.Historical() && limit > 1 {
+		limit--
+	}
+	if limit > 0 && len(uc.activeInvestigations) >= limit && (uc.scheduler == nil || alert.Historical()) {
 		return "", ErrMaxConcurrentReached
 	}
 
 	uc.idCounter++
 	invID := fmt.Sprintf("inv-%d-%d", time.Now().UnixNano(), uc.idCounter)
-	_, cancel := context.WithCancel(ctx)
 
 	inv := &activeInvestigation{
 		id:        invID,
 		alertID:   alert.ID(),
+		alert:     alert,
 		startedAt: time.Now(),
-		cancel:    cancel,
+		progress:  &investigationProgress{},
 	}
 
 	uc.activeInvestigations[invID] = inv
@@ -435,14 +908,27 @@ func (uc *AlertInvestigationUseCase) StartInvestigation(
 	// Persist to store if configured
 	if uc.investigationStore != nil {
 		stub := newSimpleInvestigationRecord(invID, alert.ID(), "", "started")
+		stub.startedAt = inv.startedAt
+		stub.alert = alert.toEntity()
 		if err := uc.investigationStore.Store(ctx, stub); err != nil {
-			fmt.Fprintf(os.Stderr, "[AlertInvestigation] Failed to store investigation %s: %v\n", invID, err)
+			uc.logger.Error("Failed to store investigation", "investigation_id", invID, "alert_id", alert.ID(), "error", err)
 		}
 	}
 
 	return invID, nil
 }
 
+// startedAlert returns the alert an active investigation was started for, or
+// nil if there is no such investigation.
+func (uc *AlertInvestigationUseCase) startedAlert(invID string) *AlertForInvestigation {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	if inv, ok := uc.activeInvestigations[invID]; ok {
+		return inv.alert
+	}
+	return nil
+}
+
 // StopInvestigation stops an active investigation by ID.
 // Cancels the investigation context and removes it from tracking.
 // Returns ErrInvestigationNotFoundUC if the investigation does not exist.
@@ -466,8 +952,10 @@ func (uc *AlertInvestigationUseCase) StopInvestigation(ctx context.Context, invI
 	// Update store with stopped status if configured
 	if uc.investigationStore != nil {
 		stub := newSimpleInvestigationRecord(invID, inv.alertID, "", "stopped")
+		stub.startedAt = inv.startedAt
+		stub.alert = inv.alert.toEntity()
 		if err := uc.investigationStore.Update(ctx, stub); err != nil {
-			fmt.Fprintf(os.Stderr, "[AlertInvestigation] Failed to update investigation %s: %v\n", invID, err)
+			uc.logger.Error("Failed to update investigation", "investigation_id", invID, "alert_id", inv.alertID, "error", err)
 		}
 	}
 
@@ -476,6 +964,189 @@ func (uc *AlertInvestigationUseCase) StopInvestigation(ctx context.Context, invI
 	return nil
 }
 
+// CancelInvestigation cancels a running or queued investigation on request.
+// The run's context is cancelled, so the tool it is running stops if the tool
+// honors cancellation and finishes otherwise. The run then ends its
+// conversation as usual and RunInvestigation returns it as "cancelled", with
+// what it found so far and an error naming requestedBy and reason, after
+// storing it so. CancelInvestigation does not wait for that; cancelling an
+// investigation again keeps the first request.
+//
+// Returns an error wrapping port.ErrInvestigationNotFound for an unknown
+// investigation, and port.ErrInvestigationFinished for one that is stored but
+// no longer running.
+func (uc *AlertInvestigationUseCase) CancelInvestigation(
+	ctx context.Context,
+	invID, reason, requestedBy string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	inv, exists := uc.activeInvestigations[invID]
+	store := uc.investigationStore
+	logger := uc.logger
+	if !exists {
+		uc.mu.Unlock()
+		return uc.notRunning(ctx, store, invID)
+	}
+	if inv.cancelled == nil {
+		inv.cancelled = &cancellation{reason: reason, requestedBy: requestedBy}
+	}
+	// A queued investigation has no context yet; beginRun cancels it
+	cancel := inv.cancel
+	uc.mu.Unlock()
+
+	logger.Info("Investigation cancellation requested", "investigation_id", invID, "alert_id", inv.alertID,
+		"reason", reason, "requested_by", requestedBy)
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// notRunning returns the error for cancelling an investigation that is not
+// active: ErrInvestigationFinished if it is stored, ErrInvestigationNotFound
+// otherwise.
+func (uc *AlertInvestigationUseCase) notRunning(ctx context.Context, store InvestigationStoreWriter, invID string) error {
+	if store == nil {
+		return fmt.Errorf("%w: %s", port.ErrInvestigationNotFound, invID)
+	}
+	record, err := store.Get(ctx, invID)
+	switch {
+	case errors.Is(err, port.ErrInvestigationNotFound):
+		return fmt.Errorf("%w: %s", port.ErrInvestigationNotFound, invID)
+	case err != nil:
+		return fmt.Errorf("failed to look up investigation %s: %w", invID, err)
+	}
+	return fmt.Errorf("%w: %s is %s", port.ErrInvestigationFinished, invID, record.Status())
+}
+
+// cancellationOf returns the cancellation requested for a run, if any.
+func (uc *AlertInvestigationUseCase) cancellationOf(inv *activeInvestigation) *cancellation {
+	if inv == nil {
+		return nil
+	}
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return inv.cancelled
+}
+
+// recordCancelled stores a run cancelled by CancelInvestigation as
+// "cancelled", with what the runner's result found before it stopped, if
+// any, and returns its result.
+func (uc *AlertInvestigationUseCase) recordCancelled(
+	ctx context.Context,
+	store InvestigationStoreWriter,
+	invID string,
+	alert *AlertForInvestigation,
+	inv *activeInvestigation,
+	result *InvestigationResult,
+	cancelled *cancellation,
+) *InvestigationResult {
+	final := &InvestigationResult{InvestigationID: invID, AlertID: alert.ID(), Findings: []string{}}
+	if result != nil {
+		copied := *result
+		final = &copied
+	}
+	final.Status = "cancelled"
+	final.Escalated, final.EscalateReason = false, ""
+	final.Error = cancelled.err()
+	final.ErrorKind = ErrorKindCancelled
+	if final.Duration == 0 && inv != nil {
+		final.Duration = time.Since(inv.startedAt)
+	}
+
+	uc.logger.Info("Investigation cancelled", "investigation_id", invID, "alert_id", alert.ID(),
+		"reason", cancelled.reason, "requested_by", cancelled.requestedBy)
+	if store != nil {
+		if err := store.Update(context.WithoutCancel(ctx), newResultRecord(invID, alert, inv, final)); err != nil {
+			uc.logger.Error("Failed to update investigation", "investigation_id", invID, "alert_id", alert.ID(),
+				"error", err)
+		}
+	}
+	return final
+}
+
+// RecordSuppressed stores a "suppressed" record for an alert that was not
+// investigated because of suppression, with the suppression's reason as its
+// message, and returns the record's ID. The alert is not tracked as active.
+func (uc *AlertInvestigationUseCase) RecordSuppressed(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	suppression *entity.AlertSuppression,
+) (string, error) {
+	if alert == nil {
+		return "", ErrAlertNil
+	}
+
+	reason := "suppressed until " + suppression.Until.Format(time.RFC3339)
+	if suppression.Reason != "" {
+		reason += ": " + suppression.Reason
+	}
+	invID, store := uc.nextUninvestigatedID()
+	uc.logger.Info("Alert suppressed", "investigation_id", invID, "alert_id", alert.ID(),
+		"fingerprint", suppression.Fingerprint, "reason", reason)
+	if err := storeUninvestigated(ctx, store, invID, alert, "suppressed", reason); err != nil {
+		return "", err
+	}
+	return invID, nil
+}
+
+// RecordDeferred stores a "deferred" record for an alert that was not
+// investigated because its source's circuit is open or the daily budget is
+// spent, with the reason as its
+// message, and returns the record's ID. Deferred alerts are investigated
+// later by AlertHandler.ReprocessDeferred.
+func (uc *AlertInvestigationUseCase) RecordDeferred(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	reason string,
+) (string, error) {
+	if alert == nil {
+		return "", ErrAlertNil
+	}
+
+	invID, store := uc.nextUninvestigatedID()
+	uc.logger.Info("Alert deferred", "investigation_id", invID, "alert_id", alert.ID(),
+		"source", alert.Source(), "reason", reason)
+	if err := storeUninvestigated(ctx, store, invID, alert, "deferred", reason); err != nil {
+		return "", err
+	}
+	return invID, nil
+}
+
+// nextUninvestigatedID returns a new investigation ID for an alert that is
+// recorded without being investigated, and the store to record it in.
+func (uc *AlertInvestigationUseCase) nextUninvestigatedID() (string, InvestigationStoreWriter) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.idCounter++
+	return fmt.Sprintf("inv-%d-%d", time.Now().UnixNano(), uc.idCounter), uc.investigationStore
+}
+
+// storeUninvestigated stores a finished record with the given status and
+// message for an alert that was not investigated. Without a store it does nothing.
+func storeUninvestigated(
+	ctx context.Context,
+	store InvestigationStoreWriter,
+	invID string,
+	alert *AlertForInvestigation,
+	status, reason string,
+) error {
+	if store == nil {
+		return nil
+	}
+	now := time.Now()
+	stub := newSimpleInvestigationRecord(invID, alert.ID(), "", status)
+	stub.startedAt = now
+	stub.completedAt = now
+	stub.errorMessage = reason
+	stub.alert = alert.toEntity()
+	return store.Store(ctx, stub)
+}
+
 // GetInvestigationStatus returns the current status of an active investigation.
 // Returns ErrInvestigationNotFoundUC if the investigation is not found.
 func (uc *AlertInvestigationUseCase) GetInvestigationStatus(
@@ -527,14 +1198,228 @@ func (uc *AlertInvestigationUseCase) GetActiveCount() int {
 	return len(uc.activeInvestigations)
 }
 
+// Status returns a snapshot of the running and queued investigations and how
+// much of MaxConcurrent they use. Circuits are left to the alert handler,
+// which owns the circuit breaker.
+func (uc *AlertInvestigationUseCase) Status() port.DaemonStatus {
+	now := time.Now()
+	status := port.DaemonStatus{
+		GeneratedAt: now,
+		Active:      []port.ActiveInvestigationStatus{},
+		Queued:      []port.QueuedAlertStatus{},
+		Circuits:    []port.CircuitStatus{},
+	}
+
+	uc.mu.RLock()
+	for _, inv := range uc.activeInvestigations {
+		if inv.queued() {
+			status.Queued = append(status.Queued, port.QueuedAlertStatus{
+				InvestigationID: inv.id,
+				AlertID:         inv.alertID,
+				AlertTitle:      inv.alert.Title(),
+				Priority:        inv.alert.Severity(),
+				Source:          inv.alert.Source(),
+				QueuedAt:        inv.startedAt,
+				Waiting:         now.Sub(inv.startedAt),
+				Historical:      inv.alert.Historical(),
+			})
+			continue
+		}
+		actions, currentTool := inv.progress.snapshot()
+		status.Active = append(status.Active, port.ActiveInvestigationStatus{
+			InvestigationID: inv.id,
+			AlertID:         inv.alertID,
+			AlertTitle:      inv.alert.Title(),
+			Severity:        inv.alert.Severity(),
+			Source:          inv.alert.Source(),
+			StartedAt:       inv.startedAt,
+			Elapsed:         now.Sub(inv.startedAt),
+			Actions:         actions,
+			CurrentTool:     currentTool,
+		})
+	}
+	capacity := uc.config.MaxConcurrent
+	scheduler := uc.scheduler
+	gate := uc.providerGate
+	uc.mu.RUnlock()
+	if scheduler != nil {
+		status.Sources = scheduler.Sources()
+	}
+	if gate != nil {
+		status.Degraded = gate.Status()
+	}
+
+	sort.Slice(status.Active, func(i, j int) bool {
+		a, b := status.Active[i], status.Active[j]
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.Before(b.StartedAt)
+		}
+		return a.InvestigationID < b.InvestigationID
+	})
+	sort.Slice(status.Queued, func(i, j int) bool {
+		a, b := status.Queued[i], status.Queued[j]
+		if a.Historical != b.Historical {
+			return b.Historical
+		}
+		if severityRank(a.Priority) != severityRank(b.Priority) {
+			return severityRank(a.Priority) < severityRank(b.Priority)
+		}
+		if !a.QueuedAt.Equal(b.QueuedAt) {
+			return a.QueuedAt.Before(b.QueuedAt)
+		}
+		return a.InvestigationID < b.InvestigationID
+	})
+
+	status.Workers = port.WorkerPoolStatus{
+		Capacity: max(capacity, 0),
+		Running:  len(status.Active),
+		Queued:   len(status.Queued),
+	}
+	if capacity > 0 {
+		status.Workers.Utilization = float64(len(status.Active)+len(status.Queued)) / float64(capacity)
+	}
+	return status
+}
+
+// severityRank orders alert severities from most to least urgent.
+func severityRank(severity string) int {
+	switch severity {
+	case entity.SeverityCritical:
+		return 0
+	case entity.SeverityWarning:
+		return 1
+	case entity.SeverityInfo:
+		return 2
+	default:
+		return 3
+	}
+}
+
 // cleanupInvestigationTracking removes an investigation from internal tracking maps.
 // This method assumes the caller holds uc.mu write lock (Lock).
-// It is used by both RunInvestigation (via defer) and StopInvestigation.
+// It is used by RunInvestigation, StopInvestigation, and Shutdown.
 func (uc *AlertInvestigationUseCase) cleanupInvestigationTracking(invID, alertID string) {
 	delete(uc.activeInvestigations, invID)
 	delete(uc.alertToInvestigation, alertID)
 }
 
+// beginRun marks a started investigation as running, so StopInvestigation and
+// Shutdown can cancel it and Shutdown can wait for it. It returns nil for
+// investigations not started with StartInvestigation, and ErrUseCaseShutdown
+// for ones Shutdown has already removed.
+func (uc *AlertInvestigationUseCase) beginRun(invID string, cancel context.CancelFunc) (*activeInvestigation, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	inv, exists := uc.activeInvestigations[invID]
+	if !exists {
+		if uc.shutdown {
+			return nil, ErrUseCaseShutdown
+		}
+		return nil, nil
+	}
+	inv.cancel = cancel
+	inv.done = make(chan struct{})
+	if inv.cancelled != nil {
+		// Cancelled while queued
+		cancel()
+	}
+	return inv, nil
+}
+
+// finishRun stops tracking a run and reports whether recording its final status
+// is still up to the run. It is not once StopInvestigation or Shutdown has
+// removed the investigation and recorded a status of their own.
+func (uc *AlertInvestigationUseCase) finishRun(inv *activeInvestigation, invID, alertID string) bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if inv != nil && uc.activeInvestigations[invID] != inv {
+		return false
+	}
+	uc.cleanupInvestigationTracking(invID, alertID)
+	return true
+}
+
+// recordInterrupted marks an investigation "interrupted" in the store with the
+// reason it did not finish. The record is written even if ctx is done, since
+// the reason for interrupting is usually that ctx was cancelled.
+func (uc *AlertInvestigationUseCase) recordInterrupted(
+	ctx context.Context,
+	store InvestigationStoreWriter,
+	invID string,
+	alert *AlertForInvestigation,
+	inv *activeInvestigation,
+	reason string,
+) {
+	alertID := alert.ID()
+	uc.logger.Warn("Investigation interrupted", "investigation_id", invID, "alert_id", alertID, "reason", reason)
+	if store == nil {
+		return
+	}
+	stub := newSimpleInvestigationRecord(invID, alertID, "", "interrupted")
+	if inv != nil {
+		stub.startedAt = inv.startedAt
+	}
+	stub.completedAt = time.Now()
+	stub.errorMessage = reason
+	stub.alert = alert.toEntity()
+	if err := store.Update(context.WithoutCancel(ctx), stub); err != nil {
+		uc.logger.Error("Failed to update investigation", "investigation_id", invID, "alert_id", alertID, "error", err)
+	}
+}
+
+// deferRun records a started investigation as "deferred" with the reason it
+// did not run, so AlertHandler.ReprocessDeferred investigates its alert
+// later, and returns its deferred result.
+func (uc *AlertInvestigationUseCase) deferRun(
+	ctx context.Context,
+	invID string,
+	alert *AlertForInvestigation,
+	inv *activeInvestigation,
+	reason string,
+) *InvestigationResult {
+	uc.mu.RLock()
+	store := uc.investigationStore
+	logger := uc.logger
+	uc.mu.RUnlock()
+
+	logger.Info("Investigation deferred", "investigation_id", invID, "alert_id", alert.ID(), "reason", reason)
+	if store != nil {
+		stub := newSimpleInvestigationRecord(invID, alert.ID(), "", "deferred")
+		stub.completedAt = time.Now()
+		stub.errorMessage = reason
+		stub.alert = alert.toEntity()
+		// Investigations not started with StartInvestigation have no record yet
+		write := store.Store
+		stub.startedAt = stub.completedAt
+		if inv != nil {
+			write = store.Update
+			stub.startedAt = inv.startedAt
+		}
+		if err := write(ctx, stub); err != nil {
+			logger.Error("Failed to update investigation", "investigation_id", invID, "alert_id", alert.ID(), "error", err)
+		}
+	}
+	return &InvestigationResult{InvestigationID: invID, AlertID: alert.ID(), Status: "deferred", Findings: []string{}}
+}
+
+// Config returns the use case's configuration.
+func (uc *AlertInvestigationUseCase) Config() AlertInvestigationUseCaseConfig {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return uc.config
+}
+
+// SetConfig replaces the configuration, as when the daemon reloads its config
+// file. Investigations that are running keep the configuration they started
+// with; queued and new ones use config.
+func (uc *AlertInvestigationUseCase) SetConfig(config AlertInvestigationUseCaseConfig) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.config = config
+}
+
 // SetEscalationHandler configures the handler used for investigation escalations.
 func (uc *AlertInvestigationUseCase) SetEscalationHandler(handler EscalationHandler) {
 	uc.mu.Lock()
@@ -542,6 +1427,204 @@ func (uc *AlertInvestigationUseCase) SetEscalationHandler(handler EscalationHand
 	uc.escalationHandler = handler
 }
 
+// SetResultNotifier configures the notifier told about each finished
+// investigation, including failed and escalated ones.
+func (uc *AlertInvestigationUseCase) SetResultNotifier(notifier InvestigationResultNotifier) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.resultNotifier = notifier
+}
+
+// SetProgressSink configures the sink that receives progress events of each
+// investigation run, from its first AI request to its terminal event.
+func (uc *AlertInvestigationUseCase) SetProgressSink(sink port.InvestigationProgressSink) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.progressSink = sink
+}
+
+// SetChangeTracker configures the tracker whose record of the files each
+// investigation changed fills InvestigationResult.ModifiedFiles.
+func (uc *AlertInvestigationUseCase) SetChangeTracker(tracker WorkspaceChangeTracker) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.changeTracker = tracker
+}
+
+// SetToolStats configures the source of each investigation's per-tool usage,
+// which fills InvestigationResult.ToolStats.
+func (uc *AlertInvestigationUseCase) SetToolStats(stats ToolStatsSource) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.toolStats = stats
+}
+
+// SetApprovalProvider configures the provider asked to approve each low-risk
+// remediation command of a completed investigation. Remediation is only
+// attempted when AutoRemediate is set and a provider is configured.
+func (uc *AlertInvestigationUseCase) SetApprovalProvider(approver ApprovalProvider) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.approver = approver
+}
+
+// SetMessageTemplate sets the template of the user message that starts each
+// investigation. A nil template restores DefaultMessageTemplate.
+func (uc *AlertInvestigationUseCase) SetMessageTemplate(tmpl *AlertTemplate) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.messageTemplate = tmpl
+}
+
+// SetPricing configures the prices each investigation's AI turns are charged
+// at, as the model model returns. It fills InvestigationResult.Cost, which
+// the config's MaxCost and the daily budget are enforced on.
+func (uc *AlertInvestigationUseCase) SetPricing(pricing Pricing, model func() string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.pricing = pricing
+	uc.model = model
+}
+
+// SetDailyBudget configures the budget each investigation's cost is charged
+// to. Once it is spent, new alerts and queued investigations are recorded as
+// "deferred" instead of investigated.
+func (uc *AlertInvestigationUseCase) SetDailyBudget(budget *DailyBudget) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.dailyBudget = budget
+}
+
+// budgetExhausted returns why investigations may not start, when the daily
+// budget is spent, and "" otherwise. A budget that cannot be read is logged
+// and treated as not spent.
+func (uc *AlertInvestigationUseCase) budgetExhausted(ctx context.Context) string {
+	if uc == nil {
+		return ""
+	}
+	uc.mu.RLock()
+	budget := uc.dailyBudget
+	logger := uc.logger
+	uc.mu.RUnlock()
+	if budget == nil {
+		return ""
+	}
+	exhausted, reason, err := budget.Exhausted(ctx)
+	if err != nil {
+		logger.Error("Failed to read the daily investigation budget", "error", err)
+		return ""
+	}
+	if !exhausted {
+		return ""
+	}
+	return reason
+}
+
+// SetScheduler sets the scheduler that shares the investigation slots between
+// alert sources. With one, live alerts past MaxConcurrent are queued for a
+// slot rather than rejected, so its Slots should be MaxConcurrent. Without
+// one, investigations run as soon as they start.
+func (uc *AlertInvestigationUseCase) SetScheduler(scheduler *AlertScheduler) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.scheduler = scheduler
+}
+
+// awaitSlot waits for a scheduler slot for a started investigation's alert,
+// reporting the investigation as queued meanwhile, and returns the function
+// that frees the slot. Without a scheduler it returns at once.
+func (uc *AlertInvestigationUseCase) awaitSlot(
+	ctx context.Context,
+	inv *activeInvestigation,
+	alert *AlertForInvestigation,
+) (func(), error) {
+	uc.mu.Lock()
+	scheduler := uc.scheduler
+	if scheduler == nil {
+		uc.mu.Unlock()
+		return func() {}, nil
+	}
+	if inv != nil {
+		inv.waiting = true
+	}
+	uc.mu.Unlock()
+
+	release, err := scheduler.Acquire(ctx, scheduler.SourceOf(alert))
+	if inv != nil {
+		uc.mu.Lock()
+		inv.waiting = false
+		uc.mu.Unlock()
+	}
+	return release, err
+}
+
+// SetProviderGate sets the gate that holds investigations while the AI
+// provider is down. Without one, investigations run and fail during an outage.
+func (uc *AlertInvestigationUseCase) SetProviderGate(gate *ProviderGate) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.providerGate = gate
+}
+
+// runSlotsFor returns the semaphore the runners share, sized limit, replacing
+// it when a reload changed MaxConcurrent. Runs holding a slot of the replaced
+// one release it when they finish, so the new limit applies to new runs.
+func (uc *AlertInvestigationUseCase) runSlotsFor(limit int) *runSemaphore {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.runSlots.size() != max(limit, 0) {
+		uc.runSlots = newRunSemaphore(limit)
+	}
+	return uc.runSlots
+}
+
+// awaitProvider waits until the provider gate lets a started investigation
+// run, reporting it as queued meanwhile. Without a gate it returns at once.
+func (uc *AlertInvestigationUseCase) awaitProvider(
+	ctx context.Context,
+	inv *activeInvestigation,
+	alert *AlertForInvestigation,
+) error {
+	uc.mu.Lock()
+	gate := uc.providerGate
+	if gate == nil {
+		uc.mu.Unlock()
+		return nil
+	}
+	if inv != nil {
+		inv.waiting = true
+	}
+	uc.mu.Unlock()
+
+	err := gate.Wait(ctx, alert)
+	if inv != nil {
+		uc.mu.Lock()
+		inv.waiting = false
+		uc.mu.Unlock()
+	}
+	return err
+}
+
+// deferHeld records an investigation the provider gate held for too long as
+// "deferred", and notifies its result so someone knows the alert waits for
+// AlertHandler.ReprocessDeferred.
+func (uc *AlertInvestigationUseCase) deferHeld(
+	ctx context.Context,
+	invID string,
+	alert *AlertForInvestigation,
+	inv *activeInvestigation,
+	err error,
+) *InvestigationResult {
+	result := uc.deferRun(ctx, invID, alert, inv, "deferred: "+err.Error())
+	uc.mu.RLock()
+	notifier := uc.resultNotifier
+	uc.mu.RUnlock()
+	if notifier != nil && !alert.Historical() {
+		notifier.NotifyInvestigationResult(alert, result)
+	}
+	return result
+}
+
 // SetPromptBuilderRegistry configures the registry used to generate investigation prompts.
 func (uc *AlertInvestigationUseCase) SetPromptBuilderRegistry(registry PromptBuilderRegistry) {
 	uc.mu.Lock()
@@ -591,6 +1674,30 @@ func (uc *AlertInvestigationUseCase) SetUIAdapter(ui port.UserInterface) {
 	uc.uiAdapter = ui
 }
 
+// SetMetricsRecorder configures the recorder for investigation metrics.
+func (uc *AlertInvestigationUseCase) SetMetricsRecorder(recorder port.MetricsRecorder) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.metrics = recorder
+}
+
+// SetLogger configures the logger for investigation logs. A nil logger restores slog.Default().
+func (uc *AlertInvestigationUseCase) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.logger = logger
+}
+
+// SetTracer configures the tracer for investigation spans.
+func (uc *AlertInvestigationUseCase) SetTracer(tracer port.Tracer) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.tracer = tracer
+}
+
 // IsToolAllowed checks if a tool name is in the allowed list.
 // Returns false if the tool is not explicitly allowed.
 func (uc *AlertInvestigationUseCase) IsToolAllowed(tool string) bool {
@@ -619,28 +1726,66 @@ func (uc *AlertInvestigationUseCase) IsCommandBlocked(cmd string) bool {
 	return false
 }
 
-// Shutdown gracefully shuts down the use case.
-// Cancels all active investigations and prevents new ones from starting.
-// After Shutdown, all operations return ErrUseCaseShutdown.
+// Shutdown gracefully shuts down the use case, draining running investigations.
+//
+// It stops new investigations from starting and cancels queued ones (started
+// but not yet running, or waiting for a scheduler slot), then waits for
+// running investigations to finish until ctx is done. Investigations still
+// running then are cancelled and marked "interrupted" in the store, and
+// Shutdown returns an error wrapping ctx.Err().
+// After Shutdown, StartInvestigation returns ErrUseCaseShutdown.
 func (uc *AlertInvestigationUseCase) Shutdown(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
 	uc.mu.Lock()
-	defer uc.mu.Unlock()
-
 	uc.shutdown = true
-
-	// Cancel all active investigations
+	store := uc.investigationStore
+	var running []*activeInvestigation
 	for _, inv := range uc.activeInvestigations {
+		if !inv.queued() {
+			running = append(running, inv)
+			continue
+		}
+		// Runs waiting for a scheduler or runner slot stop waiting
 		if inv.cancel != nil {
 			inv.cancel()
 		}
+		uc.cleanupInvestigationTracking(inv.id, inv.alertID)
+		uc.recordInterrupted(ctx, store, inv.id, inv.alert, inv, "daemon shut down before the investigation started")
+	}
+	uc.mu.Unlock()
+
+	remaining := running[:0]
+	for _, inv := range running {
+		select {
+		case <-inv.done:
+		case <-ctx.Done():
+			remaining = append(remaining, inv)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
 	}
 
-	uc.activeInvestigations = make(map[string]*activeInvestigation)
-	uc.alertToInvestigation = make(map[string]string)
+	uc.mu.Lock()
+	interrupted := 0
+	for _, inv := range remaining {
+		// Skip runs that finished while the lock was released
+		if uc.activeInvestigations[inv.id] != inv {
+			continue
+		}
+		inv.cancel()
+		uc.cleanupInvestigationTracking(inv.id, inv.alertID)
+		uc.recordInterrupted(ctx, store, inv.id, inv.alert, inv,
+			"daemon shut down before the investigation finished (drain timeout exceeded)")
+		interrupted++
+	}
+	uc.mu.Unlock()
 
-	return nil
+	if interrupted == 0 {
+		return nil
+	}
+	return fmt.Errorf("interrupted %d running investigations: %w", interrupted, ctx.Err())
 }
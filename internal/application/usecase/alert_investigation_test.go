@@ -5,6 +5,8 @@ import (
 	"code-editing-agent/internal/domain/port"
 	"context"
 	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -86,8 +88,8 @@ func TestAlertInvestigationUseCase_HandleAlert_NilAlert(t *testing.T) {
 	}
 
 	_, err := uc.HandleAlert(context.Background(), nil)
-	if err == nil {
-		t.Error("HandleAlert(nil) should return error")
+	if !errors.Is(err, ErrInvalidAlert) {
+		t.Errorf("HandleAlert(nil) error = %v, want ErrInvalidAlert", err)
 	}
 }
 
@@ -638,6 +640,200 @@ func TestAlertInvestigationUseCase_Shutdown_WithTimeout(t *testing.T) {
 	}
 }
 
+// gatedConvServiceMock blocks each investigation's initial prompt: the
+// released alert's continues once release is closed, the others until their
+// context is cancelled.
+type gatedConvServiceMock struct {
+	*investigationRunnerConvServiceMock
+	releasedAlertID string
+	release         chan struct{}
+	started         chan struct{}
+}
+
+func (m *gatedConvServiceMock) AddUserMessage(
+	ctx context.Context,
+	sessionID, content string,
+) (*entity.Message, error) {
+	m.started <- struct{}{}
+	if !strings.Contains(content, "Alert ID: "+m.releasedAlertID+"\n") {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	<-m.release
+	return m.investigationRunnerConvServiceMock.AddUserMessage(ctx, sessionID, content)
+}
+
+func TestAlertInvestigationUseCase_Shutdown_DrainsInFlightInvestigations(t *testing.T) {
+	conv := &gatedConvServiceMock{
+		investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+		releasedAlertID:                    "alert-fast",
+		release:                            make(chan struct{}),
+		started:                            make(chan struct{}, 2),
+	}
+	store := NewMockInvestigationStore()
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(conv)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetInvestigationStore(store)
+
+	invIDs := make(map[string]string)
+	runErrs := make(map[string]chan error)
+	for _, alertID := range []string{"alert-fast", "alert-slow", "alert-queued"} {
+		alert := &AlertForInvestigation{id: alertID, source: "prometheus", severity: "critical", title: "Test Alert"}
+		invID, err := uc.StartInvestigation(context.Background(), alert)
+		if err != nil {
+			t.Fatalf("StartInvestigation(%s) error = %v", alertID, err)
+		}
+		invIDs[alertID] = invID
+		if alertID == "alert-queued" {
+			continue
+		}
+		runErr := make(chan error, 1)
+		runErrs[alertID] = runErr
+		go func() {
+			_, err := uc.RunInvestigation(context.Background(), alert, invID)
+			runErr <- err
+		}()
+	}
+	<-conv.started
+	<-conv.started
+
+	// The fast investigation finishes within the drain window; the slow one does not
+	time.AfterFunc(20*time.Millisecond, func() { close(conv.release) })
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := uc.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+
+	if err := <-runErrs["alert-fast"]; err != nil {
+		t.Errorf("fast investigation error = %v, want nil", err)
+	}
+	if err := <-runErrs["alert-slow"]; !errors.Is(err, ErrInvestigationInterrupted) {
+		t.Errorf("slow investigation error = %v, want ErrInvestigationInterrupted", err)
+	}
+
+	fast, _ := store.Get(context.Background(), invIDs["alert-fast"])
+	if fast.Status() == "interrupted" || fast.ErrorMessage() != "" {
+		t.Errorf("fast investigation recorded as (%q, %q), want its own result", fast.Status(), fast.ErrorMessage())
+	}
+	for _, alertID := range []string{"alert-slow", "alert-queued"} {
+		record, _ := store.Get(context.Background(), invIDs[alertID])
+		if record.Status() != "interrupted" {
+			t.Errorf("%s status = %q, want interrupted", alertID, record.Status())
+		}
+		if !strings.Contains(record.ErrorMessage(), "shut down") {
+			t.Errorf("%s error = %q, want an explanation of the shutdown", alertID, record.ErrorMessage())
+		}
+	}
+
+	if uc.GetActiveCount() != 0 {
+		t.Errorf("GetActiveCount() after shutdown = %v, want 0", uc.GetActiveCount())
+	}
+	alert := &AlertForInvestigation{id: "alert-late", source: "prometheus", severity: "critical", title: "Late"}
+	if _, err := uc.StartInvestigation(context.Background(), alert); !errors.Is(err, ErrUseCaseShutdown) {
+		t.Errorf("StartInvestigation() after shutdown error = %v, want ErrUseCaseShutdown", err)
+	}
+}
+
+func TestAlertInvestigationUseCase_RunInvestigation_RecordsCancellation(t *testing.T) {
+	conv := &gatedConvServiceMock{
+		investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+		started:                            make(chan struct{}, 1),
+	}
+	store := NewMockInvestigationStore()
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(conv)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetInvestigationStore(store)
+
+	alert := &AlertForInvestigation{id: "alert-cancel", source: "prometheus", severity: "critical", title: "Test Alert"}
+	invID, err := uc.StartInvestigation(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-conv.started
+		cancel()
+	}()
+
+	if _, err := uc.RunInvestigation(ctx, alert, invID); !errors.Is(err, ErrInvestigationInterrupted) {
+		t.Errorf("RunInvestigation() error = %v, want ErrInvestigationInterrupted", err)
+	}
+	record, _ := store.Get(context.Background(), invID)
+	if record.Status() != "interrupted" || !strings.Contains(record.ErrorMessage(), "context canceled") {
+		t.Errorf("record = (%q, %q), want interrupted with the cancellation", record.Status(), record.ErrorMessage())
+	}
+}
+
+func TestAlertInvestigationUseCase_RunInvestigation_RecordsFailureKind(t *testing.T) {
+	conv := newInvestigationRunnerConvServiceMock()
+	conv.startConversationError = errors.New("model not found")
+	store := NewMockInvestigationStore()
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(conv)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetInvestigationStore(store)
+
+	alert := &AlertForInvestigation{id: "alert-fail", source: "prometheus", severity: "critical", title: "Test Alert"}
+	invID, err := uc.StartInvestigation(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+
+	if _, err := uc.RunInvestigation(context.Background(), alert, invID); !errors.Is(err, ErrConversationStart) {
+		t.Errorf("RunInvestigation() error = %v, want ErrConversationStart", err)
+	}
+	record, _ := store.Get(context.Background(), invID)
+	if record.Status() != "failed" || record.ErrorKind() != ErrorKindConversationStart {
+		t.Errorf("record = (%q, %q), want failed with kind %q", record.Status(), record.ErrorKind(),
+			ErrorKindConversationStart)
+	}
+	if !strings.Contains(record.ErrorMessage(), "model not found") {
+		t.Errorf("record error = %q, want the StartConversation error", record.ErrorMessage())
+	}
+}
+
+// recordingResultNotifier records the results it is told about.
+type recordingResultNotifier struct {
+	mu      sync.Mutex
+	results []*InvestigationResult
+}
+
+func (n *recordingResultNotifier) NotifyInvestigationResult(_ *AlertForInvestigation, result *InvestigationResult) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.results = append(n.results, result)
+}
+
+func TestAlertInvestigationUseCase_RunInvestigation_NotifiesResult(t *testing.T) {
+	conv := newInvestigationRunnerConvServiceMock()
+	conv.processResponseMessages = []*entity.Message{createAssistantMessage("No issues found.")}
+	conv.processResponseToolCalls = [][]port.ToolCallInfo{nil}
+	notifier := &recordingResultNotifier{}
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(conv)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetResultNotifier(notifier)
+
+	alert := &AlertForInvestigation{id: "alert-notify", source: "prometheus", severity: "critical", title: "Test Alert"}
+	result, err := uc.HandleAlert(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("HandleAlert() error = %v", err)
+	}
+
+	if len(notifier.results) != 1 || notifier.results[0] != result {
+		t.Errorf("notifier got %v, want exactly the returned result", notifier.results)
+	}
+}
+
 // =============================================================================
 // InvestigationResult Tests
 // =============================================================================
@@ -1023,6 +1219,85 @@ func TestAlertInvestigationUseCase_HandleAlert_WithStore_UpdatesStatus(t *testin
 	}
 }
 
+func TestAlertInvestigationUseCase_HandleAlert_WithStore_PersistsResultAndAlert(t *testing.T) {
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(newInvestigationRunnerConvServiceMock())
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	store := NewMockInvestigationStore()
+	uc.SetInvestigationStore(store)
+
+	alert := &AlertForInvestigation{
+		id:       "alert-result",
+		source:   "prometheus",
+		severity: "critical",
+		title:    "Disk Full",
+		labels:   map[string]string{"instance": "db-1"},
+	}
+	result, err := uc.HandleAlert(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("HandleAlert() error = %v", err)
+	}
+
+	stored, err := store.Get(context.Background(), result.InvestigationID)
+	if err != nil {
+		t.Fatalf("Store.Get() error = %v", err)
+	}
+	if stored.Confidence() != result.Confidence || stored.ActionsTaken() != result.ActionsTaken ||
+		stored.CompletedAt().IsZero() {
+		t.Errorf("stored record = %+v, want the result %+v", stored, result)
+	}
+	if a := stored.Alert(); a == nil || a.Severity() != "critical" || a.Title() != "Disk Full" ||
+		a.Labels()["instance"] != "db-1" {
+		t.Errorf("stored alert = %+v, want the investigated alert", stored.Alert())
+	}
+}
+
+func TestAlertInvestigationUseCase_RerunInvestigation(t *testing.T) {
+	uc := NewAlertInvestigationUseCase()
+	uc.SetConversationService(newInvestigationRunnerConvServiceMock())
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	store := NewMockInvestigationStore()
+	uc.SetInvestigationStore(store)
+
+	alert := &AlertForInvestigation{id: "alert-rerun", source: "prometheus", severity: "warning", title: "High CPU"}
+	first, err := uc.HandleAlert(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("HandleAlert() error = %v", err)
+	}
+
+	rerun, err := uc.RerunInvestigation(context.Background(), first.InvestigationID)
+	if err != nil {
+		t.Fatalf("RerunInvestigation() error = %v", err)
+	}
+	if rerun.InvestigationID == first.InvestigationID || rerun.AlertID != "alert-rerun" {
+		t.Errorf("rerun = %s for %s, want a new investigation of alert-rerun", rerun.InvestigationID, rerun.AlertID)
+	}
+	if _, err := store.Get(context.Background(), rerun.InvestigationID); err != nil {
+		t.Errorf("rerun was not stored: %v", err)
+	}
+}
+
+func TestAlertInvestigationUseCase_RerunInvestigation_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	uc := NewAlertInvestigationUseCase()
+	if _, err := uc.RerunInvestigation(ctx, "inv-1"); !errors.Is(err, ErrNoInvestigationStore) {
+		t.Errorf("RerunInvestigation() without store error = %v, want ErrNoInvestigationStore", err)
+	}
+
+	store := NewMockInvestigationStore()
+	_ = store.Store(ctx, newSimpleInvestigationRecord("inv-old", "alert-1", "", "completed"))
+	uc.SetInvestigationStore(store)
+	if _, err := uc.RerunInvestigation(ctx, "inv-old"); !errors.Is(err, ErrInvestigationNotRerunnable) {
+		t.Errorf("RerunInvestigation() without alert error = %v, want ErrInvestigationNotRerunnable", err)
+	}
+	if _, err := uc.RerunInvestigation(ctx, "inv-missing"); err == nil {
+		t.Error("RerunInvestigation() of a missing investigation should fail")
+	}
+}
+
 func TestAlertInvestigationUseCase_StartInvestigation_WithStore_PersistsInitialState(t *testing.T) {
 	uc := NewAlertInvestigationUseCase()
 	if uc == nil {
@@ -1863,3 +2138,279 @@ func (m *mockConversationServiceWithThinking) GetThinkingMode(sessionID string)
 	}
 	return info, nil
 }
+
+func (m *mockConversationServiceWithThinking) SetSessionModel(_ string, _ string) error {
+	return nil
+}
+
+func TestAlertInvestigationUseCaseConfig_ForSeverity(t *testing.T) {
+	config := AlertInvestigationUseCaseConfig{
+		MaxActions:  20,
+		MaxDuration: 15 * time.Minute,
+		SeverityOverrides: map[string]InvestigationLimits{
+			entity.SeverityCritical: {MaxActions: 40, MaxDuration: 30 * time.Minute},
+			entity.SeverityWarning:  {MaxActions: 10},
+		},
+	}
+
+	critical := config.forSeverity(entity.SeverityCritical)
+	if critical.MaxActions != 40 || critical.MaxDuration != 30*time.Minute {
+		t.Errorf("critical limits = (%d, %v), want (40, 30m)", critical.MaxActions, critical.MaxDuration)
+	}
+	warning := config.forSeverity(entity.SeverityWarning)
+	if warning.MaxActions != 10 || warning.MaxDuration != 15*time.Minute {
+		t.Errorf("warning limits = (%d, %v), want (10, 15m)", warning.MaxActions, warning.MaxDuration)
+	}
+	info := config.forSeverity(entity.SeverityInfo)
+	if info.MaxActions != 20 || info.MaxDuration != 15*time.Minute {
+		t.Errorf("info limits = (%d, %v), want the defaults (20, 15m)", info.MaxActions, info.MaxDuration)
+	}
+}
+
+// blockingToolExecutorMock records the blocked command patterns each tool call
+// runs under, and holds the first call until release is closed.
+type blockingToolExecutorMock struct {
+	*investigationRunnerToolExecutorMock
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	seen    [][]string
+}
+
+func (m *blockingToolExecutorMock) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
+	first := false
+	m.once.Do(func() { first = true })
+	if first {
+		close(m.started)
+		<-m.release
+	}
+	patterns, _ := port.BlockedCommandsFromContext(ctx)
+	m.mu.Lock()
+	m.seen = append(m.seen, patterns)
+	m.mu.Unlock()
+	return m.investigationRunnerToolExecutorMock.ExecuteTool(ctx, name, input)
+}
+
+func TestAlertInvestigationUseCase_SetConfig_KeepsRunningInvestigationsSnapshot(t *testing.T) {
+	curl := []port.ToolCallInfo{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "curl localhost"}}}
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Checking"), createAssistantMessage("Done"),
+		createAssistantMessage("Checking"), createAssistantMessage("Done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{curl, nil, curl, nil}
+	executor := &blockingToolExecutorMock{
+		investigationRunnerToolExecutorMock: newInvestigationRunnerToolExecutorMock(),
+		started:                             make(chan struct{}),
+		release:                             make(chan struct{}),
+	}
+
+	config := AlertInvestigationUseCaseConfig{
+		MaxActions:      20,
+		MaxDuration:     time.Minute,
+		AllowedTools:    []string{"bash"},
+		BlockedCommands: []string{"rm -rf"},
+	}
+	uc := NewAlertInvestigationUseCaseWithConfig(config)
+	uc.SetConversationService(convService)
+	uc.SetToolExecutor(executor)
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+
+	ctx := context.Background()
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := uc.HandleAlert(ctx, createTestAlert("alert-1", "critical", "First"))
+		firstDone <- err
+	}()
+
+	// Reload with curl blocked while the first investigation runs its tool
+	<-executor.started
+	reloaded := config
+	reloaded.BlockedCommands = []string{"rm -rf", "curl"}
+	reloaded.MaxActions = 5
+	uc.SetConfig(reloaded)
+	close(executor.release)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("first HandleAlert() error = %v", err)
+	}
+
+	if _, err := uc.HandleAlert(ctx, createTestAlert("alert-2", "critical", "Second")); err != nil {
+		t.Fatalf("second HandleAlert() error = %v", err)
+	}
+
+	want := [][]string{{"rm -rf"}, {"rm -rf", "curl"}}
+	if len(executor.seen) != len(want) {
+		t.Fatalf("tool calls = %d, want %d", len(executor.seen), len(want))
+	}
+	for i := range want {
+		if strings.Join(executor.seen[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("investigation %d ran under blocked commands %q, want %q", i+1, executor.seen[i], want[i])
+		}
+	}
+	if got := uc.Config().MaxActions; got != 5 {
+		t.Errorf("Config().MaxActions = %d, want the reloaded 5", got)
+	}
+}
+
+// =============================================================================
+// CancelInvestigation Tests
+// =============================================================================
+
+// callLog records the calls of several mocks in the order they happened.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) snapshot() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+// endLoggingConvServiceMock logs EndConversation calls.
+type endLoggingConvServiceMock struct {
+	*statusConvServiceMock
+	log *callLog
+}
+
+func (m *endLoggingConvServiceMock) EndConversation(ctx context.Context, sessionID string) error {
+	m.log.add("EndConversation")
+	return m.statusConvServiceMock.EndConversation(ctx, sessionID)
+}
+
+// updateLoggingStore logs each status it is updated with.
+type updateLoggingStore struct {
+	*MockInvestigationStore
+	log *callLog
+}
+
+func (s *updateLoggingStore) Update(ctx context.Context, inv InvestigationRecordData) error {
+	s.log.add("Update " + inv.Status())
+	return s.MockInvestigationStore.Update(ctx, inv)
+}
+
+func TestAlertInvestigationUseCase_CancelInvestigation_MidTool(t *testing.T) {
+	log := &callLog{}
+	executor := &slowToolExecutorMock{
+		investigationRunnerToolExecutorMock: newInvestigationRunnerToolExecutorMock(),
+		started:                             make(chan string, 1),
+		release:                             make(chan struct{}),
+	}
+	store := &updateLoggingStore{MockInvestigationStore: NewMockInvestigationStore(), log: log}
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{MaxActions: 10, MaxDuration: time.Minute})
+	uc.SetConversationService(&endLoggingConvServiceMock{
+		statusConvServiceMock: &statusConvServiceMock{
+			investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+			turns:                              make(map[string]int),
+		},
+		log: log,
+	})
+	uc.SetToolExecutor(executor)
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetInvestigationStore(store)
+	ctx := context.Background()
+
+	alert := createTestAlert("alert-cancel", "critical", "Disk Full")
+	invID, err := uc.StartInvestigation(ctx, alert)
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	type runOutcome struct {
+		result *InvestigationResult
+		err    error
+	}
+	done := make(chan runOutcome, 1)
+	go func() {
+		result, err := uc.RunInvestigation(ctx, alert, invID)
+		done <- runOutcome{result, err}
+	}()
+
+	// Cancel while the first tool runs; the tool honors the cancelled context
+	if tool := <-executor.started; tool != "bash" {
+		t.Fatalf("started tool %q, want bash", tool)
+	}
+	if err := uc.CancelInvestigation(ctx, invID, "looping on df", "alice"); err != nil {
+		t.Fatalf("CancelInvestigation() error = %v", err)
+	}
+	outcome := <-done
+
+	if outcome.err != nil {
+		t.Fatalf("RunInvestigation() error = %v, want the cancelled result", outcome.err)
+	}
+	result := outcome.result
+	if result.Status != "cancelled" || result.ErrorKind != ErrorKindCancelled || result.Escalated {
+		t.Errorf("result = status %q, kind %q, escalated %v; want cancelled", result.Status, result.ErrorKind,
+			result.Escalated)
+	}
+	if !errors.Is(result.Error, ErrInvestigationCancelled) ||
+		result.Error.Error() != "investigation cancelled by alice: looping on df" {
+		t.Errorf("result error = %v, want the cancellation by alice with its reason", result.Error)
+	}
+
+	// The conversation ends before the final record is written
+	calls := log.snapshot()
+	if len(calls) < 2 || calls[len(calls)-2] != "EndConversation" || calls[len(calls)-1] != "Update cancelled" {
+		t.Errorf("calls = %q, want EndConversation then Update cancelled last", calls)
+	}
+	stored, err := store.Get(ctx, invID)
+	if err != nil {
+		t.Fatalf("Store.Get() error = %v", err)
+	}
+	if stored.Status() != "cancelled" || stored.ErrorKind() != ErrorKindCancelled ||
+		!strings.Contains(stored.ErrorMessage(), "by alice: looping on df") {
+		t.Errorf("stored = status %q, kind %q, message %q; want the cancellation", stored.Status(),
+			stored.ErrorKind(), stored.ErrorMessage())
+	}
+	if uc.GetActiveCount() != 0 {
+		t.Errorf("GetActiveCount() = %d, want 0", uc.GetActiveCount())
+	}
+
+	// The investigation is finished now
+	if err := uc.CancelInvestigation(ctx, invID, "again", "bob"); !errors.Is(err, port.ErrInvestigationFinished) {
+		t.Errorf("CancelInvestigation() of a finished investigation error = %v, want ErrInvestigationFinished", err)
+	}
+}
+
+func TestAlertInvestigationUseCase_CancelInvestigation_Queued(t *testing.T) {
+	executor := newInvestigationRunnerToolExecutorMock()
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{MaxActions: 10, MaxDuration: time.Minute})
+	uc.SetConversationService(newInvestigationRunnerConvServiceMock())
+	uc.SetToolExecutor(executor)
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	ctx := context.Background()
+
+	alert := createTestAlert("alert-cancel-queued", "critical", "Disk Full")
+	invID, err := uc.StartInvestigation(ctx, alert)
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	if err := uc.CancelInvestigation(ctx, invID, "", ""); err != nil {
+		t.Fatalf("CancelInvestigation() error = %v", err)
+	}
+
+	result, err := uc.RunInvestigation(ctx, alert, invID)
+	if err != nil {
+		t.Fatalf("RunInvestigation() error = %v", err)
+	}
+	if result.Status != "cancelled" || result.Error.Error() != "investigation cancelled" {
+		t.Errorf("result = status %q, error %v; want cancelled with no reason", result.Status, result.Error)
+	}
+	if result.ActionsTaken != 0 || executor.executeToolCalls != 0 {
+		t.Errorf("ran %d tools, want none once cancelled while queued", executor.executeToolCalls)
+	}
+}
+
+func TestAlertInvestigationUseCase_CancelInvestigation_NotFound(t *testing.T) {
+	uc := NewAlertInvestigationUseCase()
+	err := uc.CancelInvestigation(context.Background(), "inv-missing", "", "")
+	if !errors.Is(err, port.ErrInvestigationNotFound) {
+		t.Errorf("CancelInvestigation() error = %v, want ErrInvestigationNotFound", err)
+	}
+}
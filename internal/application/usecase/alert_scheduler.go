@@ -0,0 +1,185 @@
+// Package usecase contains application use cases that orchestrate domain logic.
+// This file implements the scheduler that shares investigation slots fairly
+// between alert sources.
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// AlertSchedulerConfig configures an AlertScheduler.
+type AlertSchedulerConfig struct {
+	Slots        int            // Investigations that may run at once; 0 means no limit
+	SourceLimit  int            // Slots a source may take while other sources wait; 0 means no limit
+	SourceLimits map[string]int // SourceLimit by source, overriding it; 0 means no limit
+	SourceLabel  string         // Alert label naming an alert's source, e.g. "team"; empty uses Source()
+}
+
+// slotWaiter is an investigation waiting for a slot.
+type slotWaiter struct {
+	source  string
+	granted chan struct{} // Closed once the waiter holds a slot
+}
+
+// AlertScheduler hands out investigation slots so that one noisy alert source
+// cannot starve the others. A source may hold at most its limit of the slots
+// while another source waits: a freed slot goes to the longest-waiting
+// investigation of a source under its limit, so a source over it queues behind
+// its own backlog. Scheduling is work-conserving: a free slot is never left
+// idle, so a source can take every slot while no other source has work, and
+// once every waiting source is at its limit the longest waiter gets the slot.
+// Slots are not taken back from running investigations. It is safe for
+// concurrent use.
+type AlertScheduler struct {
+	config AlertSchedulerConfig
+
+	mu      sync.Mutex
+	running int
+	holding map[string]int // Slots held by each source
+	waiters []*slotWaiter  // Oldest first
+}
+
+// NewAlertScheduler creates a scheduler with every slot free.
+func NewAlertScheduler(config AlertSchedulerConfig) *AlertScheduler {
+	return &AlertScheduler{config: config, holding: make(map[string]int)}
+}
+
+// Config returns the scheduler's configuration.
+func (s *AlertScheduler) Config() AlertSchedulerConfig {
+	return s.config
+}
+
+// SourceOf returns the source an alert's investigation is scheduled as: the
+// value of the configured source label, or the alert's source without it.
+func (s *AlertScheduler) SourceOf(alert *AlertForInvestigation) string {
+	if s.config.SourceLabel != "" {
+		if value := alert.Labels()[s.config.SourceLabel]; value != "" {
+			return value
+		}
+	}
+	return alert.Source()
+}
+
+// Acquire waits for a slot for an investigation of source and returns the
+// function that frees it again. It returns ctx.Err() if ctx is done first.
+func (s *AlertScheduler) Acquire(ctx context.Context, source string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if len(s.waiters) == 0 && s.slotFreeLocked() {
+		s.takeLocked(source)
+		s.mu.Unlock()
+		return s.releaser(source), nil
+	}
+	waiter := &slotWaiter{source: source, granted: make(chan struct{})}
+	s.waiters = append(s.waiters, waiter)
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.granted:
+		return s.releaser(source), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	if i := slices.Index(s.waiters, waiter); i >= 0 {
+		s.waiters = slices.Delete(s.waiters, i, i+1)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	s.mu.Unlock()
+	// The slot was granted while ctx was cancelled; pass it on
+	s.release(source)
+	return nil, ctx.Err()
+}
+
+// releaser returns a function freeing source's slot once, however often it is called.
+func (s *AlertScheduler) releaser(source string) func() {
+	var once sync.Once
+	return func() { once.Do(func() { s.release(source) }) }
+}
+
+// release frees a slot of source and hands out the free slots.
+func (s *AlertScheduler) release(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if s.holding[source]--; s.holding[source] <= 0 {
+		delete(s.holding, source)
+	}
+	for s.slotFreeLocked() && len(s.waiters) > 0 {
+		i := s.nextWaiterLocked()
+		waiter := s.waiters[i]
+		s.waiters = slices.Delete(s.waiters, i, i+1)
+		s.takeLocked(waiter.source)
+		close(waiter.granted)
+	}
+}
+
+// nextWaiterLocked returns the index of the waiter to get the next slot: the
+// oldest of a source under its limit, or the oldest of all when every waiting
+// source is at its limit. s.mu must be held and a waiter must exist.
+func (s *AlertScheduler) nextWaiterLocked() int {
+	for i, waiter := range s.waiters {
+		if limit := s.limit(waiter.source); limit == 0 || s.holding[waiter.source] < limit {
+			return i
+		}
+	}
+	return 0
+}
+
+// slotFreeLocked reports whether a slot is free. s.mu must be held.
+func (s *AlertScheduler) slotFreeLocked() bool {
+	return s.config.Slots <= 0 || s.running < s.config.Slots
+}
+
+// takeLocked gives source a slot. s.mu must be held.
+func (s *AlertScheduler) takeLocked(source string) {
+	s.running++
+	s.holding[source]++
+}
+
+// limit returns the slots source may hold while others wait; 0 means no limit.
+func (s *AlertScheduler) limit(source string) int {
+	if limit, ok := s.config.SourceLimits[source]; ok {
+		return limit
+	}
+	return s.config.SourceLimit
+}
+
+// Sources returns the slots each source holds and its investigations waiting
+// for one, for every source with either, sorted by source.
+func (s *AlertScheduler) Sources() []port.SourceSlotStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bySource := make(map[string]*port.SourceSlotStatus)
+	status := func(source string) *port.SourceSlotStatus {
+		if _, ok := bySource[source]; !ok {
+			bySource[source] = &port.SourceSlotStatus{Source: source, Limit: s.limit(source)}
+		}
+		return bySource[source]
+	}
+	for source, held := range s.holding {
+		status(source).Running = held
+	}
+	for _, waiter := range s.waiters {
+		status(waiter.source).Waiting++
+	}
+
+	sources := make([]port.SourceSlotStatus, 0, len(bySource))
+	for _, source := range bySource {
+		if s.config.Slots > 0 {
+			source.Utilization = float64(source.Running) / float64(s.config.Slots)
+		}
+		sources = append(sources, *source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Source < sources[j].Source })
+	return sources
+}
@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// acquireNow takes a slot that must be free.
+func acquireNow(t *testing.T, s *AlertScheduler, source string) func() {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err := s.Acquire(ctx, source)
+	if err != nil {
+		t.Fatalf("Acquire(%q) error = %v, want a free slot", source, err)
+	}
+	return release
+}
+
+// schedulerRun is an investigation waiting for a slot in the background.
+type schedulerRun struct {
+	name    string
+	release chan func()
+}
+
+// enqueue starts a run of source that waits for a slot and reports it on
+// granted, returning once the run is queued so runs queue in call order.
+func enqueue(t *testing.T, s *AlertScheduler, source, name string, granted chan<- string) *schedulerRun {
+	t.Helper()
+	waiting := queued(s)
+	run := &schedulerRun{name: name, release: make(chan func(), 1)}
+	go func() {
+		release, err := s.Acquire(context.Background(), source)
+		if err != nil {
+			t.Errorf("Acquire(%q) error = %v", source, err)
+			return
+		}
+		run.release <- release
+		granted <- name
+	}()
+	waitFor(t, func() bool { return queued(s) > waiting })
+	return run
+}
+
+// queued returns how many runs wait for a slot.
+func queued(s *AlertScheduler) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the scheduler")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func nextGrant(t *testing.T, granted <-chan string) string {
+	t.Helper()
+	select {
+	case name := <-granted:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a slot to be granted")
+		return ""
+	}
+}
+
+func TestAlertScheduler_SourceLimitWhileOthersWait(t *testing.T) {
+	s := NewAlertScheduler(AlertSchedulerConfig{Slots: 4, SourceLimit: 2})
+	granted := make(chan string, 8)
+
+	// The noisy source is alone at first, so it takes every slot
+	var noisy []func()
+	for range 4 {
+		noisy = append(noisy, acquireNow(t, s, "noisy"))
+	}
+	runs := map[string]*schedulerRun{
+		"noisy-5": enqueue(t, s, "noisy", "noisy-5", granted),
+		"noisy-6": enqueue(t, s, "noisy", "noisy-6", granted),
+		"quiet-1": enqueue(t, s, "quiet", "quiet-1", granted),
+		"quiet-2": enqueue(t, s, "quiet", "quiet-2", granted),
+		"quiet-3": enqueue(t, s, "quiet", "quiet-3", granted),
+	}
+
+	var order []string
+	release := func(free func()) {
+		t.Helper()
+		free()
+		name := nextGrant(t, granted)
+		order = append(order, name)
+	}
+	release(noisy[0]) // quiet-1 goes ahead of the noisy backlog
+	release(noisy[1]) // quiet-2
+	release(noisy[2]) // quiet is at its limit, noisy is back under it: noisy-5
+	release(noisy[3]) // noisy-6
+	// Only quiet waits now; work conservation lets it past its limit
+	release(<-runs["noisy-5"].release)
+
+	want := []string{"quiet-1", "quiet-2", "noisy-5", "noisy-6", "quiet-3"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("slots went to %q, want %q", order, want)
+	}
+	wantSources := []port.SourceSlotStatus{
+		{Source: "noisy", Running: 1, Limit: 2, Utilization: 0.25},
+		{Source: "quiet", Running: 3, Limit: 2, Utilization: 0.75},
+	}
+	if got := s.Sources(); !reflect.DeepEqual(got, wantSources) {
+		t.Errorf("Sources() = %+v, want %+v", got, wantSources)
+	}
+}
+
+func TestAlertScheduler_SourceAloneUsesEverySlot(t *testing.T) {
+	s := NewAlertScheduler(AlertSchedulerConfig{Slots: 3, SourceLimit: 1})
+	granted := make(chan string, 1)
+
+	releases := []func(){acquireNow(t, s, "noisy"), acquireNow(t, s, "noisy"), acquireNow(t, s, "noisy")}
+	enqueue(t, s, "noisy", "noisy-4", granted)
+	want := []port.SourceSlotStatus{{Source: "noisy", Running: 3, Waiting: 1, Limit: 1, Utilization: 1}}
+	if got := s.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sources() = %+v, want %+v", got, want)
+	}
+
+	// Releasing twice frees the slot once
+	releases[0]()
+	releases[0]()
+	if name := nextGrant(t, granted); name != "noisy-4" {
+		t.Errorf("granted %q, want noisy-4", name)
+	}
+	if got := s.Sources(); len(got) != 1 || got[0].Running != 3 || got[0].Waiting != 0 {
+		t.Errorf("Sources() = %+v, want 3 running and none waiting", got)
+	}
+}
+
+func TestAlertScheduler_CancelledWaitLeavesQueue(t *testing.T) {
+	s := NewAlertScheduler(AlertSchedulerConfig{Slots: 1, SourceLimit: 1})
+	release := acquireNow(t, s, "noisy")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(ctx, "quiet")
+		errs <- err
+	}()
+	waitFor(t, func() bool { return len(s.Sources()) == 2 })
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() error = %v, want context.Canceled", err)
+	}
+
+	release()
+	if got := s.Sources(); len(got) != 0 {
+		t.Errorf("Sources() = %+v, want none once the slot is free and nobody waits", got)
+	}
+	acquireNow(t, s, "quiet")
+}
+
+func TestAlertScheduler_SourceOfAndLimits(t *testing.T) {
+	s := NewAlertScheduler(AlertSchedulerConfig{
+		Slots:        5,
+		SourceLimit:  2,
+		SourceLimits: map[string]int{"payments": 4, "batch": 0},
+		SourceLabel:  "team",
+	})
+
+	labeled := &AlertForInvestigation{id: "a", source: "prometheus", labels: map[string]string{"team": "payments"}}
+	if got := s.SourceOf(labeled); got != "payments" {
+		t.Errorf("SourceOf(labeled) = %q, want the team label", got)
+	}
+	unlabeled := &AlertForInvestigation{id: "b", source: "prometheus"}
+	if got := s.SourceOf(unlabeled); got != "prometheus" {
+		t.Errorf("SourceOf(unlabeled) = %q, want the alert source", got)
+	}
+	for source, want := range map[string]int{"payments": 4, "batch": 0, "search": 2} {
+		if got := s.limit(source); got != want {
+			t.Errorf("limit(%q) = %d, want %d", source, got, want)
+		}
+	}
+}
+
+func TestAlertHandler_SchedulerQueuesAlertsPastMaxConcurrent(t *testing.T) {
+	executor := &slowToolExecutorMock{
+		investigationRunnerToolExecutorMock: newInvestigationRunnerToolExecutorMock(),
+		started:                             make(chan string, 10),
+		release:                             make(chan struct{}),
+	}
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{
+		MaxActions: 10, MaxDuration: time.Minute, MaxConcurrent: 2,
+	})
+	uc.SetConversationService(&statusConvServiceMock{
+		investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+		turns:                              make(map[string]int),
+	})
+	uc.SetToolExecutor(executor)
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	uc.SetScheduler(NewAlertScheduler(AlertSchedulerConfig{Slots: 2, SourceLimit: 1, SourceLabel: "team"}))
+	handler := NewAlertHandler(uc, AlertHandlerConfig{AutoInvestigateCritical: true})
+	ctx := context.Background()
+
+	errs := make(map[string]chan error)
+	invIDs := make(map[string]string)
+	start := func(id, team string) {
+		t.Helper()
+		alert, err := entity.NewAlert(id, "prometheus", entity.SeverityCritical, "Alert "+id)
+		if err != nil {
+			t.Fatalf("NewAlert(%s) error = %v", id, err)
+		}
+		alert = alert.WithLabels(map[string]string{"team": team})
+		invID, err := handler.HandleEntityAlertAsync(ctx, alert)
+		if err != nil || invID == "" {
+			t.Fatalf("HandleEntityAlertAsync(%s) = %q, %v; want an investigation", id, invID, err)
+		}
+		invIDs[id] = invID
+		runErr := make(chan error, 1)
+		errs[id] = runErr
+		go func() { runErr <- handler.RunEntityAlertInvestigation(ctx, alert, invID) }()
+	}
+
+	// The noisy team takes both slots; its third alert and the quiet team's
+	// alerts are accepted past MaxConcurrent and wait
+	start("noisy-1", "noisy")
+	start("noisy-2", "noisy")
+	for range 2 {
+		<-executor.started
+	}
+	start("noisy-3", "noisy")
+	start("quiet-1", "quiet")
+	start("quiet-2", "quiet")
+	waitFor(t, func() bool { return queued(uc.scheduler) == 3 })
+
+	status := handler.GetStatus()
+	if len(status.Active) != 2 || status.Workers.Running != 2 || status.Workers.Queued != 3 {
+		t.Errorf("status = %d active, workers %+v; want 2 running and 3 queued", len(status.Active), status.Workers)
+	}
+	wantSources := []port.SourceSlotStatus{
+		{Source: "noisy", Running: 2, Waiting: 1, Limit: 1, Utilization: 1},
+		{Source: "quiet", Waiting: 2, Limit: 1},
+	}
+	if !reflect.DeepEqual(status.Sources, wantSources) {
+		t.Errorf("Sources = %+v, want %+v", status.Sources, wantSources)
+	}
+
+	// Stopping a queued investigation takes it out of the queue
+	if err := uc.StopInvestigation(ctx, invIDs["quiet-2"]); err != nil {
+		t.Fatalf("StopInvestigation() error = %v", err)
+	}
+	if err := <-errs["quiet-2"]; !errors.Is(err, ErrInvestigationInterrupted) {
+		t.Errorf("stopped investigation error = %v, want ErrInvestigationInterrupted", err)
+	}
+
+	close(executor.release)
+	for _, id := range []string{"noisy-1", "noisy-2", "noisy-3", "quiet-1"} {
+		if err := <-errs[id]; err != nil {
+			t.Errorf("investigation of %s error = %v", id, err)
+		}
+	}
+	if uc.GetActiveCount() != 0 || len(handler.GetStatus().Sources) != 0 {
+		t.Errorf("after the runs, %d active and sources %+v; want none", uc.GetActiveCount(), handler.GetStatus().Sources)
+	}
+}
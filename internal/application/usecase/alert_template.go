@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultMessageTemplate renders the user message that starts an
+// investigation: the alert's ID and title, as the system prompt already holds
+// the rest of its context.
+const DefaultMessageTemplate = "Alert ID: {{.ID}}\nTitle: {{.Title}}"
+
+// DefaultTitleTemplate renders the title of an investigation's notifications
+// and report: the alert's title.
+const DefaultTitleTemplate = "{{.Title}}"
+
+// Default alert templates, used when none is set.
+//
+//nolint:gochecknoglobals // parsed once from constants
+var (
+	defaultMessageTemplate = mustParseAlertTemplate("message", DefaultMessageTemplate)
+	defaultTitleTemplate   = mustParseAlertTemplate("title", DefaultTitleTemplate)
+)
+
+// AlertTemplate is a text/template rendered with an *AlertView, so it can use
+// the alert's fields ({{.ID}}, {{.Source}}, {{.Severity}}, {{.Title}},
+// {{.Description}}) and its labels and annotations ({{.Labels.instance}},
+// {{index .Annotations "runbook_url"}}). A label or annotation the alert lacks
+// renders empty.
+type AlertTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseAlertTemplate parses an alert template and checks that it renders,
+// so a reference to an unknown field fails here rather than on the first
+// alert. As with ParsePromptTemplate, the name appears in errors.
+// Returns ErrEmptyPromptTemplate if content is blank.
+func ParseAlertTemplate(name, content string) (*AlertTemplate, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%s: %w", name, ErrEmptyPromptTemplate)
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	t := &AlertTemplate{tmpl: tmpl}
+	sample := &AlertView{labels: map[string]string{}, annotations: map[string]string{}}
+	if _, err := t.Render(sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// mustParseAlertTemplate parses a built-in alert template, panicking on error.
+func mustParseAlertTemplate(name, content string) *AlertTemplate {
+	t, err := ParseAlertTemplate(name, content)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render renders the template for alert, trimmed of surrounding whitespace.
+func (t *AlertTemplate) Render(alert *AlertView) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, alert); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// renderAlertTemplate renders tmpl, or fallback when tmpl is nil, for alert.
+// If tmpl fails, fallback is rendered instead and the error returned with it.
+func renderAlertTemplate(tmpl, fallback *AlertTemplate, alert *AlertView) (string, error) {
+	if tmpl == nil {
+		tmpl = fallback
+	}
+	text, err := tmpl.Render(alert)
+	if err != nil && tmpl != fallback {
+		text, _ = fallback.Render(alert)
+	}
+	return text, err
+}
+
+// RenderTitle renders the title of alert's investigation with tmpl, or with
+// DefaultTitleTemplate when tmpl is nil or fails.
+func RenderTitle(tmpl *AlertTemplate, alert *AlertView) string {
+	title, _ := renderAlertTemplate(tmpl, defaultTitleTemplate, alert)
+	return title
+}
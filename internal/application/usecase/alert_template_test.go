@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertTemplate_Render(t *testing.T) {
+	alert := &AlertView{
+		id:       "alert-1",
+		severity: "critical",
+		title:    "Disk Full",
+		labels: map[string]string{
+			"instance":  `web-01:9100 <"prod"> & 'eu'`,
+			"team.name": "sre",
+		},
+		annotations: map[string]string{"runbook_url": "https://runbooks.example.com/disk?a=1&b=2"},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"default message", DefaultMessageTemplate, "Alert ID: alert-1\nTitle: Disk Full"},
+		{"default title", DefaultTitleTemplate, "Disk Full"},
+		{
+			"labels are not escaped",
+			"[{{.Severity}}] {{.Title}} on {{.Labels.instance}}",
+			`[critical] Disk Full on web-01:9100 <"prod"> & 'eu'`,
+		},
+		{"label keys that are not identifiers", `{{index .Labels "team.name"}}`, "sre"},
+		{"annotations", `{{index .Annotations "runbook_url"}}`, "https://runbooks.example.com/disk?a=1&b=2"},
+		{"missing label renders empty", "{{.Title}} ({{.Labels.pod}})", "Disk Full ()"},
+		{"missing annotation renders empty", `{{index .Annotations "summary"}}.`, "."},
+		{"surrounding whitespace is trimmed", "\n  {{.ID}}\n", "alert-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseAlertTemplate("test", tt.template)
+			if err != nil {
+				t.Fatalf("ParseAlertTemplate() error = %v", err)
+			}
+			got, err := tmpl.Render(alert)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAlertTemplate_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"unknown field", "{{.Alert.Title}}"},
+		{"unknown method", "{{.Hostname}}"},
+		{"syntax error", "{{.Title"},
+		{"undefined function", "{{upper .Title}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAlertTemplate("test", tt.template); err == nil {
+				t.Errorf("ParseAlertTemplate(%q) error = nil, want an error", tt.template)
+			}
+		})
+	}
+	if _, err := ParseAlertTemplate("test", " \n"); !errors.Is(err, ErrEmptyPromptTemplate) {
+		t.Errorf("ParseAlertTemplate() of a blank template error = %v, want ErrEmptyPromptTemplate", err)
+	}
+}
+
+func TestRenderTitle_DefaultsWithoutTemplate(t *testing.T) {
+	alert := NewAlertViewFromInvestigation(createTestAlert("alert-1", "critical", "Disk Full"))
+	if got := RenderTitle(nil, alert); got != "Disk Full" {
+		t.Errorf("RenderTitle(nil) = %q, want the alert title", got)
+	}
+}
+
+func TestInvestigationRunner_MessageTemplate(t *testing.T) {
+	tmpl, err := ParseAlertTemplate("message", "Investigate {{.ID}} on {{.Labels.instance}}{{.Labels.pod}}")
+	if err != nil {
+		t.Fatalf("ParseAlertTemplate() error = %v", err)
+	}
+	for _, tt := range []struct {
+		name string
+		tmpl *AlertTemplate
+		want string
+	}{
+		{"default", nil, "Alert ID: alert-1\nTitle: Disk Full"},
+		{"custom", tmpl, "Investigate alert-1 on web-01"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.processResponseMessages = []*entity.Message{createAssistantMessage("Done.")}
+			convService.processResponseToolCalls = [][]port.ToolCallInfo{{{
+				ToolID:   "call_1",
+				ToolName: "complete_investigation",
+				Input:    map[string]interface{}{"confidence": 0.9, "findings": []interface{}{"/var is full"}},
+			}}}
+			runner := NewInvestigationRunner(
+				convService,
+				newInvestigationRunnerToolExecutorMock(),
+				NewMockSafetyEnforcer(),
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+			)
+			runner.SetMessageTemplate(tt.tmpl)
+
+			alert := createTestAlert("alert-1", "critical", "Disk Full")
+			if _, err := runner.Run(context.Background(), alert, "inv-1"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			convService.mu.Lock()
+			defer convService.mu.Unlock()
+			if len(convService.addUserMessageContent) == 0 || convService.addUserMessageContent[0] != tt.want {
+				t.Errorf("user messages = %q, want the first to be %q", convService.addUserMessageContent, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportGenerator_TitleTemplate(t *testing.T) {
+	result, alert := reportFixture(t)
+	tmpl, err := ParseAlertTemplate("title", "[{{.Severity}}] {{.Labels.alertname}} on {{.Labels.instance}}")
+	if err != nil {
+		t.Fatalf("ParseAlertTemplate() error = %v", err)
+	}
+	generator := NewReportGenerator()
+	generator.SetTitleTemplate(tmpl)
+
+	report, err := generator.Generate(context.Background(), result, alert, false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if want := "# Investigation Report: [critical] DiskSpaceLow on web-1:9100\n"; !strings.HasPrefix(report, want) {
+		t.Errorf("report opens %q, want %q", strings.SplitN(report, "\n", 2)[0], want)
+	}
+}
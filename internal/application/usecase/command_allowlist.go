@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
+)
+
+// toolBatch is the name of the tool that runs other tools' invocations.
+const toolBatch = "batch_tool"
+
+// toolCallCommands returns the shell commands a tool call would run: the
+// command of a bash or wait_for call, and those of the bash and wait_for
+// invocations inside a batch_tool call.
+func toolCallCommands(toolName string, input map[string]interface{}) []string {
+	switch toolName {
+	case toolBash, toolWaitFor:
+		if cmd := extractCommandFromInput(input); cmd != "" {
+			return []string{cmd}
+		}
+	case toolBatch:
+		invocations, _ := input["invocations"].([]interface{})
+		var commands []string
+		for _, inv := range invocations {
+			invocation, _ := inv.(map[string]interface{})
+			name, _ := invocation["tool_name"].(string)
+			args, _ := invocation["arguments"].(map[string]interface{})
+			if name == toolBash || name == toolWaitFor {
+				if cmd := extractCommandFromInput(args); cmd != "" {
+					commands = append(commands, cmd)
+				}
+			}
+		}
+		return commands
+	}
+	return nil
+}
+
+// checkCommandAllowlist returns the allowlist's refusal of the first command
+// in tc that does not fit it. A nil allowlist allows every command.
+func checkCommandAllowlist(allowlist *safety.CommandAllowlist, tc port.ToolCallInfo) error {
+	for _, cmd := range toolCallCommands(tc.ToolName, tc.Input) {
+		if err := allowlist.Check(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Classes of investigation failure, returned wrapped with context by
+// InvestigationRunner, SubagentRunner, and AlertHandler. They are the port
+// errors of the same names, so adapters can match them without importing
+// this package.
+var (
+	// ErrInvalidAlert is returned for an alert that cannot be investigated.
+	ErrInvalidAlert = port.ErrInvalidAlert
+	// ErrConversationStart is returned when a run's conversation could not be started.
+	ErrConversationStart = port.ErrConversationStart
+	// ErrPromptBuild is returned when a run's prompt could not be built.
+	ErrPromptBuild = port.ErrPromptBuild
+	// ErrToolBlocked is returned for a refused tool call.
+	ErrToolBlocked = port.ErrToolBlocked
+	// ErrActionBudgetExceeded is returned when an investigation exceeds its action limit.
+	ErrActionBudgetExceeded = port.ErrActionBudgetExceeded
+	// ErrProviderUnavailable is returned when the AI provider did not answer.
+	ErrProviderUnavailable = port.ErrProviderUnavailable
+)
+
+// Error kinds, the classes of failure recorded with an investigation as
+// InvestigationResult.ErrorKind so stored failures can be queried by class.
+const (
+	ErrorKindInvalidAlert         = "invalid_alert"
+	ErrorKindConversationStart    = "conversation_start"
+	ErrorKindPromptBuild          = "prompt_build"
+	ErrorKindToolBlocked          = "tool_blocked"
+	ErrorKindActionBudgetExceeded = "action_budget_exceeded"
+	ErrorKindProviderUnavailable  = "provider_unavailable"
+	ErrorKindTimeout              = "timeout"
+	ErrorKindCancelled            = "cancelled"
+	ErrorKindCapacity             = "capacity" // No investigation slot was free
+	ErrorKindInternal             = "internal" // Any other failure
+)
+
+// ErrorKinds lists every error kind.
+func ErrorKinds() []string {
+	return []string{
+		ErrorKindInvalidAlert, ErrorKindConversationStart, ErrorKindPromptBuild, ErrorKindToolBlocked,
+		ErrorKindActionBudgetExceeded, ErrorKindProviderUnavailable, ErrorKindTimeout, ErrorKindCancelled,
+		ErrorKindCapacity, ErrorKindInternal,
+	}
+}
+
+// ErrorKindOf returns the kind of err, or "" if err is nil. The taxonomy
+// errors take precedence over the context errors they may wrap, so a
+// provider call cut short by MaxDuration is still provider_unavailable.
+func ErrorKindOf(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInvalidAlert):
+		return ErrorKindInvalidAlert
+	case errors.Is(err, ErrConversationStart):
+		return ErrorKindConversationStart
+	case errors.Is(err, ErrPromptBuild):
+		return ErrorKindPromptBuild
+	case errors.Is(err, ErrToolBlocked):
+		return ErrorKindToolBlocked
+	case errors.Is(err, ErrActionBudgetExceeded):
+		return ErrorKindActionBudgetExceeded
+	case errors.Is(err, ErrProviderUnavailable):
+		return ErrorKindProviderUnavailable
+	case errors.Is(err, ErrTooManyInvestigations):
+		return ErrorKindCapacity
+	case errors.Is(err, ErrInvestigationTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindTimeout
+	case errors.Is(err, ErrInvestigationInterrupted), errors.Is(err, ErrInvestigationCancelled),
+		errors.Is(err, context.Canceled):
+		return ErrorKindCancelled
+	default:
+		return ErrorKindInternal
+	}
+}
+
+// toolBlockedError is a refused tool call. Its message is what the model is
+// shown as the tool result; it matches ErrToolBlocked and the refusal.
+type toolBlockedError struct {
+	message string
+	err     error
+}
+
+// newToolBlockedError returns a toolBlockedError reading prefix + ": " + err.
+func newToolBlockedError(prefix string, err error) error {
+	return &toolBlockedError{message: prefix + ": " + err.Error(), err: err}
+}
+
+func (e *toolBlockedError) Error() string   { return e.message }
+func (e *toolBlockedError) Unwrap() []error { return []error{ErrToolBlocked, e.err} }
+
+// providerError wraps an error from the AI provider in ErrProviderUnavailable,
+// leaving cancellation of the caller's context as it is.
+func providerError(ctx context.Context, err error) error {
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"invalid alert", ErrAlertNil, ErrorKindInvalidAlert},
+		{"conversation start", fmt.Errorf("%w: boom", ErrConversationStart), ErrorKindConversationStart},
+		{"prompt build", fmt.Errorf("run: %w", fmt.Errorf("%w: bad template", ErrPromptBuild)), ErrorKindPromptBuild},
+		{"tool blocked", newToolBlockedError("Command blocked", errors.New("rm is denied")), ErrorKindToolBlocked},
+		{"action budget", fmt.Errorf("%w: 5 of 5", ErrActionBudgetExceeded), ErrorKindActionBudgetExceeded},
+		{"provider wrapping deadline", providerError(context.Background(), context.DeadlineExceeded),
+			ErrorKindProviderUnavailable},
+		{"timeout", fmt.Errorf("%w: inv-1", ErrInvestigationTimeout), ErrorKindTimeout},
+		{"deadline", fmt.Errorf("wait: %w", context.DeadlineExceeded), ErrorKindTimeout},
+		{"interrupted", fmt.Errorf("%w: inv-1", ErrInvestigationInterrupted), ErrorKindCancelled},
+		{"cancelled", context.Canceled, ErrorKindCancelled},
+		{"other", errors.New("disk full"), ErrorKindInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorKindOf(tt.err); got != tt.want {
+				t.Errorf("ErrorKindOf(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolBlockedError(t *testing.T) {
+	cause := errors.New("command matches denied pattern")
+	err := newToolBlockedError("Command blocked", cause)
+
+	if got, want := err.Error(), "Command blocked: command matches denied pattern"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrToolBlocked) || !errors.Is(err, cause) {
+		t.Errorf("error %v should match ErrToolBlocked and its cause", err)
+	}
+}
+
+func TestProviderError_KeepsCallerCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := providerError(ctx, fmt.Errorf("request: %w", context.Canceled))
+	if errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("providerError() = %v, want the caller's cancellation unwrapped", err)
+	}
+	if got := ErrorKindOf(err); got != ErrorKindCancelled {
+		t.Errorf("ErrorKindOf() = %q, want %q", got, ErrorKindCancelled)
+	}
+}
@@ -0,0 +1,190 @@
+// Package usecase contains application use cases that orchestrate domain logic.
+// This file prices AI token usage and keeps the daily budget that stops new
+// investigations once it is spent.
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusBudgetExceeded is the status of an investigation stopped because
+// another AI turn would have cost more than its MaxCost allows.
+const StatusBudgetExceeded = "budget_exceeded"
+
+// budgetDayLayout formats the UTC day daily spend is recorded under.
+const budgetDayLayout = "2006-01-02"
+
+// ModelPrice is what a model charges, in US dollars per million tokens.
+type ModelPrice struct {
+	Input  float64 // Per million input tokens, cached or not
+	Output float64 // Per million output tokens, including thinking
+}
+
+// Pricing maps model names, or prefix patterns ending in "*", to their prices.
+// Matching ignores case; an exact name wins over patterns, and a longer
+// pattern over a shorter one, as for model capabilities.
+type Pricing map[string]ModelPrice
+
+// DefaultPricing returns the list prices of the hosted models the agent knows.
+// Cache reads and writes are priced as plain input tokens, so estimates err
+// high for cached prompts. Local models are not listed and cost nothing.
+func DefaultPricing() Pricing {
+	return Pricing{
+		// Anthropic
+		"claude-opus-4*":     {Input: 15, Output: 75},
+		"claude-opus-4-5*":   {Input: 5, Output: 25},
+		"claude-sonnet-4*":   {Input: 3, Output: 15},
+		"claude-haiku-4*":    {Input: 1, Output: 5},
+		"claude-3-7-sonnet*": {Input: 3, Output: 15},
+		"claude-3-5-sonnet*": {Input: 3, Output: 15},
+		"claude-3-5-haiku*":  {Input: 0.8, Output: 4},
+		"claude-3-opus*":     {Input: 15, Output: 75},
+		"claude-3-haiku*":    {Input: 0.25, Output: 1.25},
+
+		// OpenAI
+		"gpt-4o*":  {Input: 2.5, Output: 10},
+		"gpt-4.1*": {Input: 2, Output: 8},
+		"gpt-5*":   {Input: 1.25, Output: 10},
+		"o3*":      {Input: 2, Output: 8},
+		"o4-mini*": {Input: 1.1, Output: 4.4},
+	}
+}
+
+// WithOverrides returns a copy of p with the prices in overrides added or
+// replacing its own.
+func (p Pricing) WithOverrides(overrides Pricing) Pricing {
+	merged := make(Pricing, len(p)+len(overrides))
+	maps.Copy(merged, p)
+	maps.Copy(merged, overrides)
+	return merged
+}
+
+// Price returns the price of model and whether any entry matched it.
+func (p Pricing) Price(model string) (ModelPrice, bool) {
+	model = strings.ToLower(model)
+	best, found := "", false
+	for pattern := range p {
+		lowered := strings.ToLower(pattern)
+		prefix, isPrefix := strings.CutSuffix(lowered, "*")
+		switch {
+		case lowered == model:
+			return p[pattern], true
+		case isPrefix && strings.HasPrefix(model, prefix) && (!found || len(pattern) > len(best)):
+			best, found = pattern, true
+		}
+	}
+	if !found {
+		return ModelPrice{}, false
+	}
+	return p[best], true
+}
+
+// Cost returns the estimated cost in US dollars of usage billed by model, and
+// false if the model has no price.
+func (p Pricing) Cost(model string, usage entity.TokenUsage) (float64, bool) {
+	price, ok := p.Price(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(usage.InputTokens)*price.Input + float64(usage.OutputTokens)*price.Output) / 1e6, true
+}
+
+// FormatCost renders a cost in US dollars, e.g. "$0.42".
+func FormatCost(cost float64) string {
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// SpendStore persists what investigations spent per UTC day ("2006-01-02"),
+// so the daily budget survives restarts.
+type SpendStore interface {
+	// DailySpend returns what was spent on day, or 0 if nothing was recorded.
+	DailySpend(ctx context.Context, day string) (float64, error)
+	// AddDailySpend adds cost to what was spent on day and returns the new total.
+	AddDailySpend(ctx context.Context, day string, cost float64) (float64, error)
+}
+
+// DailyBudget caps what investigations may spend per UTC day. Once the day's
+// spend reaches the limit, no new investigation starts until the next day.
+// Without a store the spend is kept in memory. It is safe for concurrent use.
+type DailyBudget struct {
+	limit float64
+	store SpendStore
+	now   func() time.Time
+
+	mu    sync.Mutex
+	day   string  // Day spent is kept for when there is no store
+	spent float64 // Spent on day when there is no store
+}
+
+// NewDailyBudget creates a budget of limit US dollars per day, recording the
+// spend in store if it is not nil.
+func NewDailyBudget(limit float64, store SpendStore) *DailyBudget {
+	return &DailyBudget{limit: limit, store: store, now: time.Now}
+}
+
+// Limit returns the daily limit in US dollars.
+func (b *DailyBudget) Limit() float64 {
+	return b.limit
+}
+
+// today returns the current UTC day.
+func (b *DailyBudget) today() string {
+	return b.now().UTC().Format(budgetDayLayout)
+}
+
+// Spent returns what investigations have spent today.
+func (b *DailyBudget) Spent(ctx context.Context) (float64, error) {
+	day := b.today()
+	if b.store != nil {
+		return b.store.DailySpend(ctx, day)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.day != day {
+		return 0, nil
+	}
+	return b.spent, nil
+}
+
+// Add records cost as spent today.
+func (b *DailyBudget) Add(ctx context.Context, cost float64) error {
+	if cost <= 0 {
+		return nil
+	}
+	day := b.today()
+	if b.store != nil {
+		_, err := b.store.AddDailySpend(ctx, day, cost)
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.day != day {
+		b.day, b.spent = day, 0
+	}
+	b.spent += cost
+	return nil
+}
+
+// Exhausted reports whether today's spend has reached the limit, with a
+// reason to record for alerts that are not investigated because of it. If
+// the spend cannot be read, the budget is not exhausted.
+func (b *DailyBudget) Exhausted(ctx context.Context) (bool, string, error) {
+	spent, err := b.Spent(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	if spent < b.limit {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("deferred: daily investigation budget of %s spent (%s today)",
+		FormatCost(b.limit), FormatCost(spent)), nil
+}
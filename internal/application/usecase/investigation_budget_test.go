@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPricing is a fake pricing table: each input token of test-model costs
+// $0.001, so a turn of 100 input tokens costs $0.10.
+//
+//nolint:gochecknoglobals // read-only test table
+var testPricing = Pricing{"test-model": {Input: 1000, Output: 0}}
+
+func testModel() string { return "test-model" }
+
+// pricedMessage returns an assistant message billed for inputTokens.
+func pricedMessage(content string, inputTokens int64) *entity.Message {
+	msg := createAssistantMessage(content)
+	msg.Usage = &entity.TokenUsage{InputTokens: inputTokens}
+	return msg
+}
+
+// fakeSpendStore is an in-memory SpendStore.
+type fakeSpendStore struct {
+	mu    sync.Mutex
+	spend map[string]float64
+}
+
+func newFakeSpendStore() *fakeSpendStore {
+	return &fakeSpendStore{spend: make(map[string]float64)}
+}
+
+func (s *fakeSpendStore) DailySpend(_ context.Context, day string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spend[day], nil
+}
+
+func (s *fakeSpendStore) AddDailySpend(_ context.Context, day string, cost float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spend[day] += cost
+	return s.spend[day], nil
+}
+
+func costsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestPricing_Price(t *testing.T) {
+	pricing := DefaultPricing().WithOverrides(Pricing{"claude-sonnet-4-5-20250929": {Input: 2, Output: 10}})
+	tests := []struct {
+		model string
+		want  ModelPrice
+		ok    bool
+	}{
+		{"claude-opus-4-1-20250805", ModelPrice{Input: 15, Output: 75}, true},
+		{"claude-opus-4-5-20251101", ModelPrice{Input: 5, Output: 25}, true},
+		{"Claude-Haiku-4-5", ModelPrice{Input: 1, Output: 5}, true},
+		{"claude-sonnet-4-5-20250929", ModelPrice{Input: 2, Output: 10}, true},
+		{"llama3.2", ModelPrice{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := pricing.Price(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Price(%q) = %+v, %v; want %+v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	cost, ok := testPricing.Cost("test-model", entity.TokenUsage{InputTokens: 100, OutputTokens: 50})
+	if !ok || !costsEqual(cost, 0.1) {
+		t.Errorf("Cost() = %v, %v; want 0.1", cost, ok)
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	for cost, want := range map[float64]string{0: "$0.00", 0.0042: "$0.0042", 1.5: "$1.50"} {
+		if got := FormatCost(cost); got != want {
+			t.Errorf("FormatCost(%v) = %q, want %q", cost, got, want)
+		}
+	}
+}
+
+func TestInvestigationRunner_StopsAtCostBudget(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	for i := range 5 {
+		convService.processResponseMessages = append(convService.processResponseMessages, pricedMessage("Checking", 100))
+		convService.processResponseToolCalls = append(convService.processResponseToolCalls, []port.ToolCallInfo{
+			{ToolID: fmt.Sprintf("t%d", i+1), ToolName: "bash", Input: map[string]interface{}{"command": "uptime"}},
+		})
+	}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{
+			MaxActions:   20,
+			MaxDuration:  15 * time.Minute,
+			MaxCost:      0.25,
+			AllowedTools: []string{"bash"},
+		},
+	)
+	runner.SetPricing(testPricing, testModel)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-cost", "warning", "Test"), "inv-cost")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Two turns cost $0.20; a third would take the run to $0.30
+	if result.Status != StatusBudgetExceeded || !result.Escalated {
+		t.Errorf("Run() status = %q, escalated = %v; want %q and escalated", result.Status, result.Escalated, StatusBudgetExceeded)
+	}
+	if !costsEqual(result.Cost, 0.2) {
+		t.Errorf("Run() cost = %v, want 0.2", result.Cost)
+	}
+	if result.Usage.InputTokens != 200 {
+		t.Errorf("Run() input tokens = %d, want 200", result.Usage.InputTokens)
+	}
+	if convService.processResponseCalls != 2 {
+		t.Errorf("AI turns = %d, want 2", convService.processResponseCalls)
+	}
+	if !strings.Contains(result.EscalateReason, "spent $0.20 of $0.25") {
+		t.Errorf("EscalateReason = %q, want the spend and cap", result.EscalateReason)
+	}
+}
+
+func TestInvestigationRunner_TracksCostWithoutCap(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{pricedMessage("Done", 300)}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+	runner.SetPricing(testPricing, testModel)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-cost", "warning", "Test"), "inv-cost")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Status != "completed" || !costsEqual(result.Cost, 0.3) {
+		t.Errorf("Run() = %q costing %v, want completed costing 0.3", result.Status, result.Cost)
+	}
+}
+
+func TestDailyBudget(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)
+	store := newFakeSpendStore()
+
+	for name, budget := range map[string]*DailyBudget{
+		"in memory": NewDailyBudget(1, nil),
+		"stored":    NewDailyBudget(1, store),
+	} {
+		t.Run(name, func(t *testing.T) {
+			budget.now = func() time.Time { return now }
+			for _, cost := range []float64{0.6, 0, 0.3} {
+				if err := budget.Add(ctx, cost); err != nil {
+					t.Fatalf("Add(%v) error = %v", cost, err)
+				}
+			}
+			if exhausted, _, err := budget.Exhausted(ctx); err != nil || exhausted {
+				t.Fatalf("Exhausted() at $0.90 of $1 = %v, %v; want false", exhausted, err)
+			}
+			if err := budget.Add(ctx, 0.2); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			exhausted, reason, err := budget.Exhausted(ctx)
+			if err != nil || !exhausted || reason != "deferred: daily investigation budget of $1.00 spent ($1.10 today)" {
+				t.Fatalf("Exhausted() = %v, %q, %v; want exhausted with the spend", exhausted, reason, err)
+			}
+
+			// The next UTC day starts from nothing
+			budget.now = func() time.Time { return now.Add(2 * time.Hour) }
+			if spent, err := budget.Spent(ctx); err != nil || spent != 0 {
+				t.Errorf("Spent() the next day = %v, %v; want 0", spent, err)
+			}
+		})
+	}
+
+	if !costsEqual(store.spend["2026-03-04"], 1.1) {
+		t.Errorf("stored spend = %v, want 1.1 recorded for 2026-03-04", store.spend)
+	}
+}
+
+func TestAlertHandler_Handle_DailyBudgetDefers(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{pricedMessage("Disk is full", 1500)}
+
+	uc := f.handler.investigationUseCase
+	uc.SetConversationService(convService)
+	uc.SetPricing(testPricing, testModel)
+	store := newFakeSpendStore()
+	budget := NewDailyBudget(1, store)
+	budget.now = func() time.Time { return f.now }
+	uc.SetDailyBudget(budget)
+
+	// The first investigation spends $1.50, past the $1 daily budget
+	if err := f.handler.Handle(ctx, diskAlert("DiskFull-1")); err != nil {
+		t.Fatalf("Handle(DiskFull-1) error = %v", err)
+	}
+	if got := len(f.store.withStatus("completed")); got != 1 {
+		t.Fatalf("completed records = %d, want 1", got)
+	}
+	if !costsEqual(store.spend["2026-03-04"], 1.5) {
+		t.Fatalf("stored spend = %v, want the first investigation's $1.50", store.spend)
+	}
+
+	// New alerts are deferred rather than investigated
+	if err := f.handler.Handle(ctx, diskAlert("DiskFull-2")); err != nil {
+		t.Fatalf("Handle(DiskFull-2) error = %v", err)
+	}
+	deferred := f.store.withStatus("deferred")
+	if len(deferred) != 1 || !strings.Contains(deferred[0].errorMessage, "daily investigation budget of $1.00 spent") {
+		t.Fatalf("deferred records = %d, want DiskFull-2 deferred for the budget", len(deferred))
+	}
+
+	// So are investigations queued before the budget was spent
+	invID, err := uc.StartInvestigation(ctx, diskAlert("DiskFull-3"))
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	result, err := uc.RunInvestigation(ctx, diskAlert("DiskFull-3"), invID)
+	if err != nil || result.Status != "deferred" {
+		t.Fatalf("RunInvestigation() = %+v, %v; want it deferred", result, err)
+	}
+	if got := len(f.store.withStatus("deferred")); got != 2 {
+		t.Errorf("deferred records = %d, want 2", got)
+	}
+	if convService.processResponseCalls != 1 {
+		t.Errorf("AI turns = %d, want only the first investigation's", convService.processResponseCalls)
+	}
+}
@@ -0,0 +1,490 @@
+// Package usecase contains application use cases that orchestrate domain logic.
+// This file implements digests: periodic summaries of investigation outcomes.
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ErrNoDigestNotifier is returned when publishing a digest without a notifier configured.
+var ErrNoDigestNotifier = errors.New("no notifier configured for digests")
+
+// digestTopCount limits the recurring alerts and notable investigations listed in a digest.
+const digestTopCount = 5
+
+// digestSeverityUnknown counts investigations without a recorded alert.
+const digestSeverityUnknown = "unknown"
+
+// digestTemplate is the Markdown template digests are rendered with. It is
+// executed with a Digest.
+const digestTemplate = `# Investigation Digest
+
+{{.Since.Format "2006-01-02 15:04 MST"}} to {{.Until.Format "2006-01-02 15:04 MST"}}
+{{if not .Total}}
+No investigations started in this period.
+{{- else}}
+- **Investigations:** {{.Total}}
+- **Escalated:** {{.Escalated}} ({{percent .EscalationRate}} of investigated alerts)
+- **Mean duration:** {{.MeanDuration}}
+- **Tokens:** {{.Usage.InputTokens}} input, {{.Usage.OutputTokens}} output
+- **Estimated cost:** {{cost .Cost}}
+
+## By Status
+
+| Status | Investigations |
+| --- | --- |
+{{- range .ByStatus}}
+| {{cell .Name}} | {{.Count}} |
+{{- end}}
+
+## By Severity
+
+| Severity | Investigations |
+| --- | --- |
+{{- range .BySeverity}}
+| {{cell .Name}} | {{.Count}} |
+{{- end}}
+
+## Top Alerts
+
+| Alert | Investigations |
+| --- | --- |
+{{- range .TopAlerts}}
+| {{cell .Name}} | {{.Count}} |
+{{- end}}
+
+## Notable Investigations
+{{if .Notable}}
+{{- range $i, $inv := .Notable}}
+{{inc $i}}. **{{$inv.Title}}** ({{$inv.Severity}}, {{$inv.Status}}) {{code $inv.ID}}
+{{- with $inv.Reason}}: {{.}}{{end}}
+{{- end}}
+{{- else}}
+No investigations were escalated.
+{{- end}}
+{{- end}}
+`
+
+// digestFuncs are the functions available to the digest template: those of
+// report templates, plus cost and percent.
+//
+//nolint:gochecknoglobals // template function map shared by every parse
+var digestFuncs = func() template.FuncMap {
+	funcs := maps.Clone(reportFuncs)
+	funcs["cost"] = FormatCost
+	funcs["percent"] = func(rate float64) string { return strconv.FormatFloat(rate*100, 'f', 0, 64) + "%" }
+	return funcs
+}()
+
+// DigestSource lists stored investigations for digests.
+type DigestSource interface {
+	// ListStarted returns the investigations started between since and until, inclusive.
+	ListStarted(ctx context.Context, since, until time.Time) ([]InvestigationRecordData, error)
+}
+
+// DigestNotifier posts digests. Implementations must return without blocking
+// on delivery.
+type DigestNotifier interface {
+	NotifyDigest(digest *Digest, markdown string)
+}
+
+// DigestCount is how many investigations share a status, severity, or alert.
+type DigestCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// DigestInvestigation identifies a notable investigation of a digest.
+type DigestInvestigation struct {
+	ID        string        `json:"id"`
+	Title     string        `json:"title"` // The alert's title, or its ID if the alert is unknown
+	Severity  string        `json:"severity"`
+	Status    string        `json:"status"`
+	Reason    string        `json:"reason,omitempty"` // Why it was escalated
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Digest summarizes the investigations started in a time window.
+type Digest struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	Total int       `json:"total"`
+	// ByStatus counts investigations by status, most common first.
+	ByStatus []DigestCount `json:"by_status"`
+	// BySeverity counts investigations by alert severity, most severe first.
+	BySeverity []DigestCount `json:"by_severity"`
+	// TopAlerts lists the most investigated alerts by alertname label, or
+	// title without one, most investigated first.
+	TopAlerts []DigestCount `json:"top_alerts"`
+	// MeanDuration is the mean duration of the investigations that ran.
+	MeanDuration time.Duration `json:"mean_duration"`
+	Escalated    int           `json:"escalated"`
+	// EscalationRate is the share, from 0 to 1, of investigated alerts that
+	// were escalated. Suppressed and deferred alerts are not counted.
+	EscalationRate float64           `json:"escalation_rate"`
+	Cost           float64           `json:"cost"`
+	Usage          entity.TokenUsage `json:"usage"`
+	// Notable lists the highest-severity escalations, newest first within a severity.
+	Notable []DigestInvestigation `json:"notable"`
+}
+
+// BuildDigest aggregates the investigations started between since and until
+// into a digest.
+func BuildDigest(since, until time.Time, records []InvestigationRecordData) *Digest {
+	digest := &Digest{Since: since, Until: until, Total: len(records)}
+	statuses := make(map[string]int)
+	severities := make(map[string]int)
+	alerts := make(map[string]int)
+	var totalDuration time.Duration
+	var ran, investigated int
+	var escalations []InvestigationRecordData
+
+	for _, record := range records {
+		statuses[record.Status()]++
+		severities[digestSeverity(record)]++
+		alerts[digestAlertName(record)]++
+		if record.Duration() > 0 {
+			totalDuration += record.Duration()
+			ran++
+		}
+		if isInvestigatedStatus(record.Status()) {
+			investigated++
+		}
+		if record.Escalated() || record.Status() == "escalated" {
+			escalations = append(escalations, record)
+		}
+		digest.Cost += record.Cost()
+		digest.Usage.InputTokens += record.Usage().InputTokens
+		digest.Usage.OutputTokens += record.Usage().OutputTokens
+	}
+
+	digest.ByStatus = sortedDigestCounts(statuses, 0)
+	digest.BySeverity = sortedDigestCounts(severities, 0)
+	slices.SortStableFunc(digest.BySeverity, func(a, b DigestCount) int {
+		return severityRank(a.Name) - severityRank(b.Name)
+	})
+	digest.TopAlerts = sortedDigestCounts(alerts, digestTopCount)
+	if ran > 0 {
+		digest.MeanDuration = (totalDuration / time.Duration(ran)).Round(time.Second)
+	}
+	digest.Escalated = len(escalations)
+	if investigated > 0 {
+		digest.EscalationRate = float64(digest.Escalated) / float64(investigated)
+	}
+	digest.Notable = notableInvestigations(escalations)
+	return digest
+}
+
+// isInvestigatedStatus reports whether a record of status stands for an alert
+// that was investigated, rather than suppressed or deferred.
+func isInvestigatedStatus(status string) bool {
+	switch status {
+	case "suppressed", "deferred", "reprocessed":
+		return false
+	default:
+		return true
+	}
+}
+
+// digestSeverity returns the severity of a record's alert, or
+// digestSeverityUnknown without one.
+func digestSeverity(record InvestigationRecordData) string {
+	if alert := record.Alert(); alert != nil && alert.Severity() != "" {
+		return alert.Severity()
+	}
+	return digestSeverityUnknown
+}
+
+// digestAlertName returns the alertname label of a record's alert, its title
+// without one, or the alert ID if the alert is unknown.
+func digestAlertName(record InvestigationRecordData) string {
+	alert := record.Alert()
+	if alert == nil {
+		return record.AlertID()
+	}
+	if name := alert.Labels()["alertname"]; name != "" {
+		return name
+	}
+	return alert.Title()
+}
+
+// sortedDigestCounts returns counts most common first, then by name, keeping
+// at most limit of them when limit is positive.
+func sortedDigestCounts(counts map[string]int, limit int) []DigestCount {
+	result := make([]DigestCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, DigestCount{Name: name, Count: count})
+	}
+	slices.SortFunc(result, func(a, b DigestCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// notableInvestigations returns the digestTopCount most severe escalations,
+// newest first within a severity.
+func notableInvestigations(escalations []InvestigationRecordData) []DigestInvestigation {
+	slices.SortFunc(escalations, func(a, b InvestigationRecordData) int {
+		if rank := severityRank(digestSeverity(a)) - severityRank(digestSeverity(b)); rank != 0 {
+			return rank
+		}
+		if c := b.StartedAt().Compare(a.StartedAt()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID(), b.ID())
+	})
+	notable := make([]DigestInvestigation, 0, min(len(escalations), digestTopCount))
+	for _, record := range escalations[:min(len(escalations), digestTopCount)] {
+		inv := DigestInvestigation{
+			ID:        record.ID(),
+			Title:     record.AlertID(),
+			Severity:  digestSeverity(record),
+			Status:    record.Status(),
+			Reason:    record.EscalateReason(),
+			StartedAt: record.StartedAt(),
+			Duration:  record.Duration(),
+		}
+		if alert := record.Alert(); alert != nil {
+			inv.Title = alert.Title()
+		}
+		if inv.Reason == "" {
+			inv.Reason = record.ErrorMessage()
+		}
+		notable = append(notable, inv)
+	}
+	return notable
+}
+
+// RenderDigest renders a digest as Markdown.
+func RenderDigest(digest *Digest) (string, error) {
+	tmpl, err := template.New("digest").Funcs(digestFuncs).Parse(digestTemplate)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, digest); err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	return b.String(), nil
+}
+
+// DigestGenerator builds digests of stored investigations and posts them
+// through its notifier. It is safe for concurrent use.
+type DigestGenerator struct {
+	source DigestSource
+
+	mu       sync.Mutex // Guards notifier, which Reload replaces
+	notifier DigestNotifier
+}
+
+// NewDigestGenerator creates a digest generator reading investigations from source.
+func NewDigestGenerator(source DigestSource) *DigestGenerator {
+	return &DigestGenerator{source: source}
+}
+
+// SetNotifier sets where Publish posts digests. Without one, Publish fails
+// with ErrNoDigestNotifier.
+func (g *DigestGenerator) SetNotifier(notifier DigestNotifier) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.notifier = notifier
+}
+
+// Generate builds the digest of the investigations started between since and until.
+func (g *DigestGenerator) Generate(ctx context.Context, since, until time.Time) (*Digest, error) {
+	records, err := g.source.ListStarted(ctx, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigations: %w", err)
+	}
+	return BuildDigest(since, until, records), nil
+}
+
+// Publish builds the digest of the investigations started between since and
+// until, renders it, and queues it for delivery through the notifier. It
+// returns the digest and its Markdown.
+func (g *DigestGenerator) Publish(ctx context.Context, since, until time.Time) (*Digest, string, error) {
+	g.mu.Lock()
+	notifier := g.notifier
+	g.mu.Unlock()
+	if notifier == nil {
+		return nil, "", ErrNoDigestNotifier
+	}
+
+	digest, err := g.Generate(ctx, since, until)
+	if err != nil {
+		return nil, "", err
+	}
+	markdown, err := RenderDigest(digest)
+	if err != nil {
+		return nil, "", err
+	}
+	notifier.NotifyDigest(digest, markdown)
+	return digest, markdown, nil
+}
+
+// DigestSchedule is when digests are published: daily at a time of day, or
+// weekly on a weekday at a time of day.
+type DigestSchedule struct {
+	Weekly  bool
+	Weekday time.Weekday // The day of weekly digests
+	Hour    int
+	Minute  int
+}
+
+// ParseDigestSchedule parses a digest schedule: "daily HH:MM" or
+// "weekly <weekday> HH:MM", such as "daily 09:00" or "weekly monday 08:30".
+func ParseDigestSchedule(spec string) (DigestSchedule, error) {
+	fields := strings.Fields(strings.ToLower(spec))
+	var schedule DigestSchedule
+	var clock string
+	switch {
+	case len(fields) == 2 && fields[0] == "daily":
+		clock = fields[1]
+	case len(fields) == 3 && fields[0] == "weekly":
+		weekday, ok := parseWeekday(fields[1])
+		if !ok {
+			return DigestSchedule{}, fmt.Errorf("invalid digest schedule %q: unknown weekday %q", spec, fields[1])
+		}
+		schedule.Weekly, schedule.Weekday = true, weekday
+		clock = fields[2]
+	default:
+		return DigestSchedule{}, fmt.Errorf(
+			"invalid digest schedule %q: want \"daily HH:MM\" or \"weekly <weekday> HH:MM\"", spec)
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return DigestSchedule{}, fmt.Errorf("invalid digest schedule %q: time must be HH:MM", spec)
+	}
+	schedule.Hour, schedule.Minute = t.Hour(), t.Minute()
+	return schedule, nil
+}
+
+// parseWeekday parses an English weekday name, full or abbreviated to three letters.
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the schedule as ParseDigestSchedule accepts it.
+func (s DigestSchedule) String() string {
+	clock := fmt.Sprintf("%02d:%02d", s.Hour, s.Minute)
+	if s.Weekly {
+		return "weekly " + strings.ToLower(s.Weekday.String()) + " " + clock
+	}
+	return "daily " + clock
+}
+
+// Window returns the period each digest covers: a day, or a week for weekly digests.
+func (s DigestSchedule) Window() time.Duration {
+	if s.Weekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Next returns the first scheduled time after after, in after's location.
+func (s DigestSchedule) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.Hour, s.Minute, 0, 0, after.Location())
+	if s.Weekly {
+		days := (int(s.Weekday) - int(next.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+	}
+	for !next.After(after) {
+		if s.Weekly {
+			next = next.AddDate(0, 0, 7)
+		} else {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// DigestScheduler publishes a digest at each scheduled time, covering the
+// window that ends then.
+type DigestScheduler struct {
+	generator *DigestGenerator
+	schedule  DigestSchedule
+	logger    *slog.Logger
+	now       func() time.Time
+	after     func(d time.Duration) <-chan time.Time
+}
+
+// NewDigestScheduler creates a scheduler publishing the digests of generator on schedule.
+func NewDigestScheduler(generator *DigestGenerator, schedule DigestSchedule) *DigestScheduler {
+	return &DigestScheduler{
+		generator: generator,
+		schedule:  schedule,
+		logger:    slog.Default(),
+		now:       time.Now,
+		after:     time.After,
+	}
+}
+
+// SetLogger sets the logger for published and failed digests. A nil logger
+// restores slog.Default().
+func (s *DigestScheduler) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	s.logger = logger
+}
+
+// Schedule returns when the scheduler publishes digests.
+func (s *DigestScheduler) Schedule() DigestSchedule {
+	return s.schedule
+}
+
+// Run publishes digests on schedule until ctx is done. A digest that fails is
+// logged and the next one is still published.
+func (s *DigestScheduler) Run(ctx context.Context) {
+	var last time.Time
+	for {
+		// Never publish a slot twice, should the timer fire early
+		next := s.schedule.Next(latest(s.now(), last))
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.after(next.Sub(s.now())):
+		}
+		last = next
+
+		digest, _, err := s.generator.Publish(ctx, next.Add(-s.schedule.Window()), next)
+		if err != nil {
+			s.logger.Error("Failed to publish investigation digest", "schedule", s.schedule.String(), "error", err)
+			continue
+		}
+		s.logger.Info("Published investigation digest", "schedule", s.schedule.String(),
+			"investigations", digest.Total, "escalated", digest.Escalated)
+	}
+}
+
+// latest returns the later of a and b.
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
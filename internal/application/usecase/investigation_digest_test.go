@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// digestSourceFunc adapts a function to DigestSource.
+type digestSourceFunc func(ctx context.Context, since, until time.Time) ([]InvestigationRecordData, error)
+
+func (f digestSourceFunc) ListStarted(ctx context.Context, since, until time.Time) ([]InvestigationRecordData, error) {
+	return f(ctx, since, until)
+}
+
+// recordingDigestNotifier collects the digests it is notified of.
+type recordingDigestNotifier struct {
+	digests chan *Digest
+}
+
+func (n *recordingDigestNotifier) NotifyDigest(digest *Digest, _ string) { n.digests <- digest }
+
+var digestWeekStart = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+// digestAlert returns a prometheus alert with an alertname label.
+func digestAlert(t *testing.T, name, severity, title string) *entity.Alert {
+	t.Helper()
+	alert, err := entity.NewAlert(name+"-1", "prometheus", severity, title)
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	return alert.WithLabels(map[string]string{"alertname": name})
+}
+
+// digestFixture returns a week of investigations: three DiskFull criticals, two
+// of them escalated; an escalated and a suppressed HighCPU warning; and a
+// failure recorded without its alert.
+func digestFixture(t *testing.T) []InvestigationRecordData {
+	t.Helper()
+	disk := digestAlert(t, "DiskFull", entity.SeverityCritical, "Disk Full")
+	cpu := digestAlert(t, "HighCPU", entity.SeverityWarning, "High CPU")
+	day := func(n int) time.Time { return digestWeekStart.AddDate(0, 0, n) }
+	return []InvestigationRecordData{
+		&mockInvestigationRecord{id: "inv-1", status: "completed", alert: disk, startedAt: day(1),
+			durationNanos: int64(time.Minute), cost: 0.10, usage: entity.TokenUsage{InputTokens: 1000, OutputTokens: 200}},
+		&mockInvestigationRecord{id: "inv-2", status: "completed", alert: disk, startedAt: day(2),
+			durationNanos: int64(2 * time.Minute), escalated: true, escalateReason: "disk still filling",
+			cost: 0.20, usage: entity.TokenUsage{InputTokens: 2000, OutputTokens: 400}},
+		&mockInvestigationRecord{id: "inv-3", status: "completed", alert: cpu, startedAt: day(3),
+			durationNanos: int64(3 * time.Minute), escalated: true, escalateReason: "cpu saturated"},
+		&mockInvestigationRecord{id: "inv-4", alertID: "alert-x", status: "failed", startedAt: day(3),
+			errorMessage: "provider unavailable"},
+		&mockInvestigationRecord{id: "inv-5", status: "suppressed", alert: cpu, startedAt: day(4)},
+		&mockInvestigationRecord{id: "inv-6", status: "escalated", alert: disk, startedAt: day(4),
+			durationNanos: int64(4 * time.Minute), escalated: true},
+	}
+}
+
+func TestBuildDigest_Aggregates(t *testing.T) {
+	until := digestWeekStart.AddDate(0, 0, 7)
+	digest := BuildDigest(digestWeekStart, until, digestFixture(t))
+
+	if digest.Total != 6 || !digest.Since.Equal(digestWeekStart) || !digest.Until.Equal(until) {
+		t.Errorf("Total = %d, window %v to %v; want 6 in the week", digest.Total, digest.Since, digest.Until)
+	}
+	wantStatus := []DigestCount{{"completed", 3}, {"escalated", 1}, {"failed", 1}, {"suppressed", 1}}
+	if !slices.Equal(digest.ByStatus, wantStatus) {
+		t.Errorf("ByStatus = %v, want %v", digest.ByStatus, wantStatus)
+	}
+	wantSeverity := []DigestCount{{"critical", 3}, {"warning", 2}, {"unknown", 1}}
+	if !slices.Equal(digest.BySeverity, wantSeverity) {
+		t.Errorf("BySeverity = %v, want %v", digest.BySeverity, wantSeverity)
+	}
+	wantAlerts := []DigestCount{{"DiskFull", 3}, {"HighCPU", 2}, {"alert-x", 1}}
+	if !slices.Equal(digest.TopAlerts, wantAlerts) {
+		t.Errorf("TopAlerts = %v, want %v", digest.TopAlerts, wantAlerts)
+	}
+	// The failure and the suppression did not run
+	if digest.MeanDuration != 150*time.Second {
+		t.Errorf("MeanDuration = %v, want 2m30s", digest.MeanDuration)
+	}
+	// The suppressed alert was not investigated
+	if digest.Escalated != 3 || digest.EscalationRate != 0.6 {
+		t.Errorf("Escalated = %d at rate %v, want 3 at 0.6", digest.Escalated, digest.EscalationRate)
+	}
+	if math.Abs(digest.Cost-0.30) > 1e-9 || digest.Usage != (entity.TokenUsage{InputTokens: 3000, OutputTokens: 600}) {
+		t.Errorf("Cost = %v, Usage = %+v; want 0.30 and 3000/600 tokens", digest.Cost, digest.Usage)
+	}
+
+	var notable []string
+	for _, inv := range digest.Notable {
+		notable = append(notable, inv.ID)
+	}
+	if want := []string{"inv-6", "inv-2", "inv-3"}; !slices.Equal(notable, want) {
+		t.Errorf("Notable = %v, want criticals newest first, then the warning: %v", notable, want)
+	}
+	if got := digest.Notable[1]; got.Title != "Disk Full" || got.Severity != "critical" || got.Reason != "disk still filling" {
+		t.Errorf("Notable[1] = %+v, want the Disk Full escalation and its reason", got)
+	}
+}
+
+func TestBuildDigest_NotableLimit(t *testing.T) {
+	disk := digestAlert(t, "DiskFull", entity.SeverityCritical, "Disk Full")
+	var records []InvestigationRecordData
+	for i := range 8 {
+		records = append(records, &mockInvestigationRecord{id: string(rune('a' + i)), status: "completed",
+			alert: disk, escalated: true, startedAt: digestWeekStart.Add(time.Duration(i) * time.Hour)})
+	}
+
+	digest := BuildDigest(digestWeekStart, digestWeekStart.AddDate(0, 0, 1), records)
+	if len(digest.Notable) != 5 || digest.Notable[0].ID != "h" {
+		t.Errorf("Notable = %+v, want the 5 newest, starting with h", digest.Notable)
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	until := digestWeekStart.AddDate(0, 0, 7)
+	markdown, err := RenderDigest(BuildDigest(digestWeekStart, until, digestFixture(t)))
+	if err != nil {
+		t.Fatalf("RenderDigest() error = %v", err)
+	}
+	for _, want := range []string{
+		"# Investigation Digest\n\n2026-03-02 00:00 UTC to 2026-03-09 00:00 UTC\n",
+		"- **Investigations:** 6\n",
+		"- **Escalated:** 3 (60% of investigated alerts)\n",
+		"- **Mean duration:** 2m30s\n",
+		"- **Tokens:** 3000 input, 600 output\n",
+		"- **Estimated cost:** $0.30\n",
+		"| completed | 3 |\n",
+		"| unknown | 1 |\n",
+		"| DiskFull | 3 |\n",
+		"1. **Disk Full** (critical, escalated) `inv-6`\n",
+		"2. **Disk Full** (critical, completed) `inv-2`: disk still filling\n",
+		"3. **High CPU** (warning, completed) `inv-3`: cpu saturated\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("digest missing %q:\n%s", want, markdown)
+		}
+	}
+
+	empty, err := RenderDigest(BuildDigest(digestWeekStart, until, nil))
+	if err != nil {
+		t.Fatalf("RenderDigest() error = %v", err)
+	}
+	if !strings.Contains(empty, "No investigations started in this period.") || strings.Contains(empty, "## By Status") {
+		t.Errorf("empty digest = %q, want only the notice", empty)
+	}
+}
+
+func TestDigestGenerator_Publish(t *testing.T) {
+	var gotSince, gotUntil time.Time
+	generator := NewDigestGenerator(digestSourceFunc(
+		func(_ context.Context, since, until time.Time) ([]InvestigationRecordData, error) {
+			gotSince, gotUntil = since, until
+			return digestFixture(t), nil
+		}))
+	until := digestWeekStart.AddDate(0, 0, 7)
+
+	if _, _, err := generator.Publish(context.Background(), digestWeekStart, until); !errors.Is(err, ErrNoDigestNotifier) {
+		t.Fatalf("Publish() without a notifier error = %v, want ErrNoDigestNotifier", err)
+	}
+
+	notifier := &recordingDigestNotifier{digests: make(chan *Digest, 1)}
+	generator.SetNotifier(notifier)
+	digest, markdown, err := generator.Publish(context.Background(), digestWeekStart, until)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if !gotSince.Equal(digestWeekStart) || !gotUntil.Equal(until) {
+		t.Errorf("listed investigations from %v to %v, want the week", gotSince, gotUntil)
+	}
+	if digest.Total != 6 || !strings.HasPrefix(markdown, "# Investigation Digest") {
+		t.Errorf("Publish() = %d investigations, %q", digest.Total, markdown)
+	}
+	if notified := <-notifier.digests; notified != digest {
+		t.Error("notifier was not given the published digest")
+	}
+}
+
+func TestParseDigestSchedule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    DigestSchedule
+		wantErr bool
+	}{
+		{"daily 09:00", DigestSchedule{Hour: 9}, false},
+		{"Weekly Mon 08:30", DigestSchedule{Weekly: true, Weekday: time.Monday, Hour: 8, Minute: 30}, false},
+		{"weekly sunday 23:59", DigestSchedule{Weekly: true, Weekday: time.Sunday, Hour: 23, Minute: 59}, false},
+		{"hourly", DigestSchedule{}, true},
+		{"daily 25:00", DigestSchedule{}, true},
+		{"daily 9am", DigestSchedule{}, true},
+		{"weekly funday 09:00", DigestSchedule{}, true},
+		{"weekly 09:00", DigestSchedule{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseDigestSchedule(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDigestSchedule(%q) = %+v, want an error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseDigestSchedule(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+			}
+			if again, err := ParseDigestSchedule(got.String()); err != nil || again != got {
+				t.Errorf("String() = %q does not parse back to the schedule", got.String())
+			}
+		})
+	}
+}
+
+func TestDigestSchedule_Next(t *testing.T) {
+	// 2026-03-09 is a Monday
+	monday := func(hour, minute int) time.Time { return time.Date(2026, 3, 9, hour, minute, 0, 0, time.UTC) }
+	daily := DigestSchedule{Hour: 9}
+	weekly := DigestSchedule{Weekly: true, Weekday: time.Monday, Hour: 9}
+	tests := []struct {
+		name     string
+		schedule DigestSchedule
+		after    time.Time
+		want     time.Time
+	}{
+		{"daily before", daily, monday(8, 0), monday(9, 0)},
+		{"daily at", daily, monday(9, 0), monday(9, 0).AddDate(0, 0, 1)},
+		{"daily after", daily, monday(17, 0), monday(9, 0).AddDate(0, 0, 1)},
+		{"weekly same day before", weekly, monday(8, 0), monday(9, 0)},
+		{"weekly same day after", weekly, monday(10, 0), monday(9, 0).AddDate(0, 0, 7)},
+		{"weekly midweek", weekly, monday(10, 0).AddDate(0, 0, 2), monday(9, 0).AddDate(0, 0, 7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+	if daily.Window() != 24*time.Hour || weekly.Window() != 7*24*time.Hour {
+		t.Errorf("Window() = %v and %v, want a day and a week", daily.Window(), weekly.Window())
+	}
+}
+
+// TestDigestScheduler_Run verifies that the scheduler waits for the next
+// scheduled time, then publishes the digest of the window ending at it.
+func TestDigestScheduler_Run(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 59, 30, 0, time.UTC)
+	windows := make(chan [2]time.Time, 2)
+	generator := NewDigestGenerator(digestSourceFunc(
+		func(_ context.Context, since, until time.Time) ([]InvestigationRecordData, error) {
+			windows <- [2]time.Time{since, until}
+			return digestFixture(t), nil
+		}))
+	notifier := &recordingDigestNotifier{digests: make(chan *Digest, 2)}
+	generator.SetNotifier(notifier)
+
+	scheduler := NewDigestScheduler(generator, DigestSchedule{Hour: 9})
+	waits := make(chan time.Duration, 2)
+	fire := make(chan time.Time)
+	scheduler.now = func() time.Time { return now }
+	scheduler.after = func(d time.Duration) <-chan time.Time {
+		waits <- d
+		return fire
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(ctx)
+	}()
+
+	if d := <-waits; d != 30*time.Second {
+		t.Errorf("first wait = %v, want 30s until 09:00", d)
+	}
+	select {
+	case <-notifier.digests:
+		t.Fatal("digest published before its scheduled time")
+	default:
+	}
+
+	fire <- now
+	digest := <-notifier.digests
+	window := <-windows
+	nine := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	if !window[0].Equal(nine.Add(-24*time.Hour)) || !window[1].Equal(nine) || !digest.Until.Equal(nine) {
+		t.Errorf("digest window = %v to %v, want the day up to 09:00", window[0], window[1])
+	}
+
+	// The frozen clock has not reached 09:00, but the slot is not published twice
+	if d := <-waits; d != 24*time.Hour+30*time.Second {
+		t.Errorf("second wait = %v, want until 09:00 tomorrow", d)
+	}
+	cancel()
+	<-done
+}
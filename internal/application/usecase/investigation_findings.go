@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// findingSimilarityThreshold is the token-set (Jaccard) similarity above
+// which two findings are taken to report the same thing.
+const findingSimilarityThreshold = 0.75
+
+// Finding is an investigation finding after deduplication, tagged with a
+// severity.
+type Finding struct {
+	Text        string // Without the severity tag or occurrence count
+	Severity    string // entity.SeverityCritical, SeverityWarning, or SeverityInfo
+	Occurrences int    // How many reported findings this one stands for
+}
+
+// String renders the finding as stored in InvestigationResult.Findings: its
+// severity tag, its text, and how often it was reported if more than once.
+// ParseFinding reads it back.
+func (f Finding) String() string {
+	s := "[" + f.Severity + "] " + f.Text
+	if f.Occurrences > 1 {
+		s += fmt.Sprintf(" (reported %d times)", f.Occurrences)
+	}
+	return s
+}
+
+// FindingGroup lists the findings of one severity.
+type FindingGroup struct {
+	Severity string
+	Findings []Finding
+}
+
+// Title names the group in reports, e.g. "Critical".
+func (g FindingGroup) Title() string {
+	return strings.ToUpper(g.Severity[:1]) + g.Severity[1:]
+}
+
+// findingTagPattern matches the severity tag investigations are asked to
+// start findings with: "[critical]", or "critical:" and the like.
+var findingTagPattern = regexp.MustCompile(`(?i)^(?:\[(critical|crit|error|warning|warn|info)\]|(critical|crit|error|warning|warn|info)\s*:)\s*`)
+
+// findingOccurrencesPattern matches the occurrence count Finding.String adds.
+var findingOccurrencesPattern = regexp.MustCompile(`\s*\(reported (\d+) times\)$`)
+
+// findingPercentPattern matches percentages, which mark a finding as a
+// warning from 90% up.
+var findingPercentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+
+// Keywords that classify findings without a severity tag. Phrases are
+// matched in the normalized text, single words against its tokens.
+//
+//nolint:gochecknoglobals // read-only keyword tables
+var (
+	criticalFindingKeywords = []string{
+		"outage", "down", "crash", "crashed", "crashing", "crashloopbackoff", "oom", "oomkilled",
+		"out of memory", "panic", "fatal", "data loss", "corrupt", "corrupted", "corruption",
+		"unreachable", "unavailable", "exhausted", "disk full", "no space left",
+	}
+	warningFindingKeywords = []string{
+		"warning", "high", "elevated", "slow", "degraded", "latency", "timeout", "timeouts",
+		"retry", "retries", "restart", "restarts", "restarted", "approaching", "spike",
+		"error", "errors", "failed", "failing", "failure", "near capacity",
+	}
+)
+
+// findingStopwords are left out when comparing findings.
+//
+//nolint:gochecknoglobals // read-only word set
+var findingStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "is": true, "are": true, "was": true, "were": true,
+	"at": true, "on": true, "in": true, "of": true, "to": true, "and": true, "or": true,
+	"for": true, "with": true, "by": true, "from": true, "it": true, "its": true,
+	"this": true, "that": true, "be": true, "been": true, "has": true, "have": true,
+}
+
+// ParseFinding reads a reported finding: its whitespace is normalized, and
+// its severity comes from its tag or, without one, from keywords. A count
+// added by Finding.String is read back, so parsing is idempotent.
+func ParseFinding(s string) Finding {
+	text := strings.Join(strings.Fields(s), " ")
+	finding := Finding{Occurrences: 1}
+
+	if m := findingOccurrencesPattern.FindStringSubmatch(text); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			finding.Occurrences = n
+			text = strings.TrimSuffix(text, m[0])
+		}
+	}
+	if m := findingTagPattern.FindStringSubmatch(text); m != nil {
+		finding.Severity = findingSeverityFromTag(m[1] + m[2])
+		text = text[len(m[0]):]
+	}
+	finding.Text = text
+	if finding.Severity == "" {
+		finding.Severity = classifyFinding(text)
+	}
+	return finding
+}
+
+// findingSeverityFromTag maps a severity tag to a severity.
+func findingSeverityFromTag(tag string) string {
+	switch strings.ToLower(tag) {
+	case "critical", "crit", "error":
+		return entity.SeverityCritical
+	case "warning", "warn":
+		return entity.SeverityWarning
+	default:
+		return entity.SeverityInfo
+	}
+}
+
+// classifyFinding guesses the severity of an untagged finding from keywords
+// and percentages.
+func classifyFinding(text string) string {
+	lower := strings.ToLower(text)
+	tokens := findingTokens(text)
+	matches := func(keywords []string) bool {
+		for _, keyword := range keywords {
+			if strings.Contains(keyword, " ") {
+				if strings.Contains(lower, keyword) {
+					return true
+				}
+			} else if tokens[keyword] {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matches(criticalFindingKeywords) {
+		return entity.SeverityCritical
+	}
+	if matches(warningFindingKeywords) {
+		return entity.SeverityWarning
+	}
+	for _, m := range findingPercentPattern.FindAllStringSubmatch(text, -1) {
+		if percent, err := strconv.ParseFloat(m[1], 64); err == nil && percent >= 90 {
+			return entity.SeverityWarning
+		}
+	}
+	return entity.SeverityInfo
+}
+
+// findingTokens returns the set of lowercase words and numbers in text,
+// without stopwords.
+func findingTokens(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make(map[string]bool, len(words))
+	for _, word := range words {
+		if !findingStopwords[word] {
+			tokens[word] = true
+		}
+	}
+	return tokens
+}
+
+// similarFindings reports whether two findings' token sets say the same
+// thing: their Jaccard similarity is at least findingSimilarityThreshold, or
+// every token of a finding with two or more is in the other.
+func similarFindings(a, b map[string]bool) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	if smaller := min(len(a), len(b)); shared == smaller && smaller >= 2 {
+		return true
+	}
+	return float64(shared)/float64(len(a)+len(b)-shared) >= findingSimilarityThreshold
+}
+
+// findingSeverityRank orders severities from least to most severe.
+func findingSeverityRank(severity string) int {
+	switch severity {
+	case entity.SeverityCritical:
+		return 2
+	case entity.SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DeduplicateFindings parses reported findings and collapses near-duplicates
+// into the most detailed of them (the one with the most distinct words),
+// counting how often each was reported. A collapsed finding takes the highest
+// severity among its duplicates. Findings keep the order they were first
+// reported in; blank ones are dropped.
+func DeduplicateFindings(findings []string) []Finding {
+	var kept []Finding
+	var keptTokens []map[string]bool
+	for _, s := range findings {
+		finding := ParseFinding(s)
+		if finding.Text == "" {
+			continue
+		}
+		tokens := findingTokens(finding.Text)
+
+		merged := false
+		for i := range kept {
+			if !similarFindings(keptTokens[i], tokens) {
+				continue
+			}
+			kept[i].Occurrences += finding.Occurrences
+			if findingSeverityRank(finding.Severity) > findingSeverityRank(kept[i].Severity) {
+				kept[i].Severity = finding.Severity
+			}
+			if len(tokens) > len(keptTokens[i]) ||
+				(len(tokens) == len(keptTokens[i]) && len(finding.Text) > len(kept[i].Text)) {
+				kept[i].Text = finding.Text
+				keptTokens[i] = tokens
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			kept = append(kept, finding)
+			keptTokens = append(keptTokens, tokens)
+		}
+	}
+	return kept
+}
+
+// FindingStrings renders findings in their stored form.
+func FindingStrings(findings []Finding) []string {
+	if findings == nil {
+		return nil
+	}
+	strs := make([]string, len(findings))
+	for i, finding := range findings {
+		strs[i] = finding.String()
+	}
+	return strs
+}
+
+// GroupFindings parses stored findings and groups them by severity, most
+// severe first, keeping their order within each group. Severities without
+// findings are left out.
+func GroupFindings(findings []string) []FindingGroup {
+	groups := []FindingGroup{
+		{Severity: entity.SeverityCritical},
+		{Severity: entity.SeverityWarning},
+		{Severity: entity.SeverityInfo},
+	}
+	for _, s := range findings {
+		finding := ParseFinding(s)
+		if finding.Text == "" {
+			continue
+		}
+		i := 2 - findingSeverityRank(finding.Severity)
+		groups[i].Findings = append(groups[i].Findings, finding)
+	}
+
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.Findings) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"reflect"
+	"testing"
+)
+
+func TestParseFinding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Finding
+	}{
+		{"[critical] Database is down", Finding{"Database is down", entity.SeverityCritical, 1}},
+		{"WARN:  high   latency on /api", Finding{"high latency on /api", entity.SeverityWarning, 1}},
+		{"[info] Deploy at 10:02 (reported 3 times)", Finding{"Deploy at 10:02", entity.SeverityInfo, 3}},
+		{"Pod web-1 was OOMKilled", Finding{"Pod web-1 was OOMKilled", entity.SeverityCritical, 1}},
+		{"Retries doubled since noon", Finding{"Retries doubled since noon", entity.SeverityWarning, 1}},
+		{"/var is 95% full", Finding{"/var is 95% full", entity.SeverityWarning, 1}},
+		{"/var is 40% full", Finding{"/var is 40% full", entity.SeverityInfo, 1}},
+		{"Last deploy was yesterday", Finding{"Last deploy was yesterday", entity.SeverityInfo, 1}},
+	}
+	for _, tt := range tests {
+		got := ParseFinding(tt.in)
+		if got != tt.want {
+			t.Errorf("ParseFinding(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if again := ParseFinding(got.String()); again != got {
+			t.Errorf("ParseFinding(%q) = %+v, want it to read back %+v", got.String(), again, got)
+		}
+	}
+}
+
+func TestDeduplicateFindings(t *testing.T) {
+	findings := []string{
+		"Disk /var at 95%",
+		"Connection pool exhausted on db-1",
+		"  disk   /VAR at 95%  ",
+		"[warning] Disk /var at 95% used by rotated nginx logs",
+		"Last deploy was yesterday",
+		"",
+		"[critical] connection pool exhausted on db-1",
+	}
+
+	got := DeduplicateFindings(findings)
+	want := []Finding{
+		{"Disk /var at 95% used by rotated nginx logs", entity.SeverityWarning, 3},
+		{"Connection pool exhausted on db-1", entity.SeverityCritical, 2},
+		{"Last deploy was yesterday", entity.SeverityInfo, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeduplicateFindings() = %+v, want %+v", got, want)
+	}
+
+	// Deduplicating stored findings again changes nothing
+	stored := FindingStrings(got)
+	if again := FindingStrings(DeduplicateFindings(stored)); !reflect.DeepEqual(again, stored) {
+		t.Errorf("deduplicating again = %q, want %q", again, stored)
+	}
+}
+
+func TestDeduplicateFindings_KeepsDistinctFindings(t *testing.T) {
+	findings := []string{
+		"Disk /var at 95%",
+		"Disk /home at 40%",
+		"Memory on web-1 at 95%",
+	}
+	if got := DeduplicateFindings(findings); len(got) != len(findings) {
+		t.Errorf("DeduplicateFindings() = %+v, want all %d findings kept", got, len(findings))
+	}
+}
+
+func TestGroupFindings(t *testing.T) {
+	findings := FindingStrings(DeduplicateFindings([]string{
+		"Last deploy was yesterday",
+		"[warning] Latency doubled",
+		"[critical] Database is down",
+		"Latency doubled",
+		"Cache hit rate is normal",
+	}))
+
+	got := GroupFindings(findings)
+	want := []FindingGroup{
+		{Severity: entity.SeverityCritical, Findings: []Finding{{"Database is down", entity.SeverityCritical, 1}}},
+		{Severity: entity.SeverityWarning, Findings: []Finding{{"Latency doubled", entity.SeverityWarning, 2}}},
+		{Severity: entity.SeverityInfo, Findings: []Finding{
+			{"Last deploy was yesterday", entity.SeverityInfo, 1},
+			{"Cache hit rate is normal", entity.SeverityInfo, 1},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupFindings() = %+v, want %+v", got, want)
+	}
+	if got[0].Title() != "Critical" {
+		t.Errorf("Title() = %q, want Critical", got[0].Title())
+	}
+	if groups := GroupFindings(nil); len(groups) != 0 {
+		t.Errorf("GroupFindings(nil) = %+v, want no groups", groups)
+	}
+}
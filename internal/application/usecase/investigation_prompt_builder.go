@@ -4,8 +4,10 @@ package usecase
 import (
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -13,7 +15,7 @@ import (
 // These errors are returned when prompt generation fails.
 var (
 	// ErrNilAlert is returned when BuildPrompt is called with a nil alert.
-	ErrNilAlert = errors.New("alert cannot be nil")
+	ErrNilAlert = fmt.Errorf("%w: alert cannot be nil", ErrInvalidAlert)
 	// ErrUnknownAlertType is returned when an alert type has no registered builder.
 	ErrUnknownAlertType = errors.New("unknown alert type")
 	// ErrPromptBuilderNotFound is returned when no builder is registered for an alert type.
@@ -42,6 +44,9 @@ func GenerateToolsHeader(tools []entity.Tool) string {
 	var sb strings.Builder
 	for i, tool := range tools {
 		sb.WriteString(fmt.Sprintf("%d. **%s** - %s\n", i+1, tool.Name, tool.Description))
+		if tool.Timeout > 0 {
+			sb.WriteString(fmt.Sprintf("   Timeout: %s per call; break up work that may take longer\n", tool.Timeout))
+		}
 
 		// Add simple example based on tool name
 		if example := getToolExample(tool.Name); example != "" {
@@ -58,12 +63,18 @@ func getToolExample(toolName string) string {
 		"bash":                   `{"command": "ps aux --sort=-%cpu | head -20"}`,
 		"read_file":              `{"path": "/var/log/syslog"}`,
 		"list_files":             `{"path": "/var/log"}`,
+		"query_logs":             `{"unit": "nginx.service", "since": "1h", "grep": "(?i)error"}`,
+		"wait_for":               `{"mode": "command", "command": "systemctl is-active --quiet nginx", "interval_seconds": 5, "timeout_seconds": 120}`,
+		"promql_query":           `{"query": "rate(http_requests_total{code=~\"5..\"}[5m])", "start": "1h"}`,
+		"system_snapshot":        `{"focus": "cpu", "top": 10}`,
 		"batch_tool":             `{"invocations": [{"tool_name": "read_file", "arguments": {"path": "config.yaml"}}, {"tool_name": "bash", "arguments": {"command": "df -h"}}]}`,
 		"activate_skill":         `{"name": "cloud-metrics"}`,
-		"complete_investigation": `{"findings": ["Root cause identified"], "confidence": 0.85}`,
-		"escalate_investigation": `{"reason": "Unable to determine root cause", "partial_findings": ["Observed high CPU"]}`,
+		"use_skill":              `{"name": "cloud-metrics", "arguments": "cpu_utilization 1h"}`,
+		"complete_investigation": `{"findings": ["[critical] Root cause identified"], "confidence": 0.85}`,
+		"escalate_investigation": `{"reason": "Unable to determine root cause", "partial_findings": ["[warning] Observed high CPU"]}`,
 		"task":                   `{"agent_name": "code-reviewer", "prompt": "Analyze the authentication module for security issues"}`,
 		"delegate":               `{"name": "log-analyzer", "system_prompt": "You are a log analysis specialist", "task": "Analyze error patterns in /var/log/app.log"}`,
+		"delegate_parallel":      `{"tasks": [{"agent": "log-analyzer", "prompt": "Check /var/log/app.log"}, {"agent": "metrics-checker", "prompt": "Check CPU trends"}]}`,
 	}
 	return examples[toolName]
 }
@@ -97,6 +108,34 @@ type AlertView struct {
 	title       string            // Human-readable alert title
 	description string            // Detailed alert description
 	labels      map[string]string // Key-value metadata labels
+	annotations map[string]string // Descriptive annotations (e.g., runbook_url)
+}
+
+// NewAlertViewFromEntity converts a domain alert for prompt building.
+func NewAlertViewFromEntity(alert *entity.Alert) *AlertView {
+	return &AlertView{
+		id:          alert.ID(),
+		source:      alert.Source(),
+		severity:    alert.Severity(),
+		title:       alert.Title(),
+		description: alert.Description(),
+		labels:      alert.Labels(),
+		annotations: alert.Annotations(),
+	}
+}
+
+// NewAlertViewFromInvestigation converts an alert under investigation for
+// prompt building.
+func NewAlertViewFromInvestigation(alert *AlertForInvestigation) *AlertView {
+	return &AlertView{
+		id:          alert.ID(),
+		source:      alert.Source(),
+		severity:    alert.Severity(),
+		title:       alert.Title(),
+		description: alert.Description(),
+		labels:      alert.Labels(),
+		annotations: alert.Annotations(),
+	}
 }
 
 // ID returns the unique alert identifier.
@@ -118,19 +157,45 @@ func (a *AlertView) Description() string { return a.description }
 // The returned map should be treated as read-only.
 func (a *AlertView) Labels() map[string]string { return a.labels }
 
+// Annotations returns the descriptive annotations attached to this alert.
+// The returned map should be treated as read-only.
+func (a *AlertView) Annotations() map[string]string { return a.annotations }
+
 // IsCritical returns true if the alert severity is "critical".
 func (a *AlertView) IsCritical() bool { return a.severity == "critical" }
 
 // LabelValue returns the value of a specific label, or empty string if not found.
 func (a *AlertView) LabelValue(key string) string { return a.labels[key] }
 
+// AnnotationValue returns the value of a specific annotation, or empty string if not found.
+func (a *AlertView) AnnotationValue(key string) string { return a.annotations[key] }
+
+// AddAnnotations adds the annotations the alert does not have yet and returns
+// their keys, sorted. Annotations already set, by the alert source or an
+// earlier AlertEnricher, are kept.
+func (a *AlertView) AddAnnotations(annotations map[string]string) []string {
+	var added []string
+	for k, v := range annotations {
+		if _, exists := a.annotations[k]; exists || k == "" {
+			continue
+		}
+		if a.annotations == nil {
+			a.annotations = make(map[string]string, len(annotations))
+		}
+		a.annotations[k] = v
+		added = append(added, k)
+	}
+	sort.Strings(added)
+	return added
+}
+
 // InvestigationPromptBuilder generates prompts for AI-driven alert investigation.
 // Each builder is specialized for a specific alert type and generates prompts
 // with appropriate investigation steps and safety rules.
 type InvestigationPromptBuilder interface {
 	// BuildPrompt generates an investigation prompt for the given alert.
 	// Returns ErrNilAlert if alert is nil.
-	BuildPrompt(alert *AlertView, tools []entity.Tool, skills []port.SkillInfo) (string, error)
+	BuildPrompt(ctx context.Context, alert *AlertView, tools []entity.Tool, skills []port.SkillInfo) (string, error)
 	// AlertType returns the type of alerts this builder handles (e.g., "HighCPU", "DiskSpace").
 	AlertType() string
 }
@@ -144,14 +209,21 @@ type PromptBuilderRegistry interface {
 	Get(alertType string) (InvestigationPromptBuilder, error)
 	// BuildPromptForAlert finds the appropriate builder and generates a prompt.
 	// Falls back to Generic builder if no specific builder is found.
-	BuildPromptForAlert(alert *AlertView, tools []entity.Tool, skills []port.SkillInfo) (string, error)
+	BuildPromptForAlert(
+		ctx context.Context,
+		alert *AlertView,
+		tools []entity.Tool,
+		skills []port.SkillInfo,
+	) (string, error)
 	// ListAlertTypes returns all registered alert types.
 	ListAlertTypes() []string
 }
 
 // GenericPromptBuilder generates investigation prompts for alerts with no specific builder.
 // It provides a general-purpose template that works with any alert type.
-type GenericPromptBuilder struct{}
+type GenericPromptBuilder struct {
+	runbookInjector
+}
 
 // NewGenericPromptBuilder creates a new GenericPromptBuilder instance.
 func NewGenericPromptBuilder() *GenericPromptBuilder {
@@ -168,6 +240,7 @@ func (b *GenericPromptBuilder) AlertType() string {
 // The prompt includes full alert context, all labels, and environment-aware investigation guidance.
 // Returns ErrNilAlert if alert is nil.
 func (b *GenericPromptBuilder) BuildPrompt(
+	ctx context.Context,
 	alert *AlertView,
 	tools []entity.Tool,
 	skills []port.SkillInfo,
@@ -184,26 +257,54 @@ You are an intelligent systems investigator. Analyze the alert below and use the
 
 `)
 
-	// Tools section
+	writeToolsAndSkillsSections(&sb, tools, skills)
+	writeRulesSection(&sb)
+	writeAlertContextSection(&sb, alert)
+
+	// Investigation guidance - environment-aware
+	sb.WriteString(`## Investigation Guidance
+
+Based on the alert source, labels, and description, determine the appropriate investigation approach:
+
+- Unless otherwise specified, assume the alert is for a remote host.
+- **Cloud/GCP alerts**: If labels contain resource_type, metric_type, or the source indicates cloud monitoring, consider using the activate_skill tool with "cloud-metrics" skill for querying GCP metrics
+- **Kubernetes alerts**: Look for namespace, pod, container labels to scope your investigation
+- **Examine ALL labels**: They contain critical context (instance, mountpoint, threshold_value, etc.)
+
+`)
+
+	b.writeRunbookSection(ctx, &sb, alert)
+	sb.WriteString("Begin your investigation now.\n")
+
+	return sb.String(), nil
+}
+
+// writeToolsAndSkillsSections writes the available tools and, if any, the available skills.
+func writeToolsAndSkillsSections(sb *strings.Builder, tools []entity.Tool, skills []port.SkillInfo) {
 	sb.WriteString("## Available Tools\n\n")
 	sb.WriteString(GenerateToolsHeader(tools))
 
-	// Skills section
 	if len(skills) > 0 {
 		sb.WriteString("## Available Skills\n\n")
 		sb.WriteString(GenerateSkillsHeader(skills))
 		sb.WriteString("\nUse the `activate_skill` tool to load the full content of a skill.\n\n")
 	}
+}
 
-	// Rules section
+// writeRulesSection writes the read-only and completion rules shared by all builders.
+func writeRulesSection(sb *strings.Builder) {
 	sb.WriteString(`## Rules
 - Use read-only commands only - DO NOT modify, restart, or kill anything
 - You MUST end by calling either complete_investigation or escalate_investigation
 - If you cannot determine the root cause, escalate with partial findings
+- Start each finding with its severity: [critical], [warning], or [info]
 
 `)
+}
 
-	// Alert context section with ALL information
+// writeAlertContextSection writes every alert field and all labels and
+// annotations sorted by key.
+func writeAlertContextSection(sb *strings.Builder, alert *AlertView) {
 	sb.WriteString("## Alert Context\n\n")
 	sb.WriteString(fmt.Sprintf("- **ID**: %s\n", alert.ID()))
 	sb.WriteString(fmt.Sprintf("- **Source**: %s\n", alert.Source()))
@@ -214,42 +315,26 @@ You are an intelligent systems investigator. Analyze the alert below and use the
 	}
 	sb.WriteString("\n")
 
-	// Labels section - ALL of them, sorted
-	if labels := alert.Labels(); len(labels) > 0 {
-		sb.WriteString("### Labels\n\n")
-		// Sort keys for consistent output
-		keys := make([]string, 0, len(labels))
-		for k := range labels {
-			keys = append(keys, k)
-		}
-		// Sort keys alphabetically
-		for i := 0; i < len(keys); i++ {
-			for j := i + 1; j < len(keys); j++ {
-				if keys[i] > keys[j] {
-					keys[i], keys[j] = keys[j], keys[i]
-				}
-			}
-		}
-		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("- `%s`: %s\n", k, labels[k]))
-		}
-		sb.WriteString("\n")
-	}
-
-	// Investigation guidance - environment-aware
-	sb.WriteString(`## Investigation Guidance
-
-Based on the alert source, labels, and description, determine the appropriate investigation approach:
-
-- Unless otherwise specified, assume the alert is for a remote host.
-- **Cloud/GCP alerts**: If labels contain resource_type, metric_type, or the source indicates cloud monitoring, consider using the activate_skill tool with "cloud-metrics" skill for querying GCP metrics
-- **Kubernetes alerts**: Look for namespace, pod, container labels to scope your investigation
-- **Examine ALL labels**: They contain critical context (instance, mountpoint, threshold_value, etc.)
-
-Begin your investigation now.
-`)
+	writeKeyValueSection(sb, "Labels", alert.Labels())
+	writeKeyValueSection(sb, "Annotations", alert.Annotations())
+}
 
-	return sb.String(), nil
+// writeKeyValueSection writes a titled list of values sorted by key, or nothing
+// if there are none.
+func writeKeyValueSection(sb *strings.Builder, title string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	sb.WriteString("### " + title + "\n\n")
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("- `%s`: %s\n", k, values[k]))
+	}
+	sb.WriteString("\n")
 }
 
 // DefaultPromptBuilderRegistry is the default implementation of PromptBuilderRegistry.
@@ -290,12 +375,14 @@ func (r *DefaultPromptBuilderRegistry) Get(alertType string) (InvestigationPromp
 }
 
 // BuildPromptForAlert generates an investigation prompt for the given alert.
-// Always uses the Generic builder, allowing the LLM to determine the appropriate
-// investigation approach based on alert context and available tools.
+// The builder registered for the alert's "alertname" label is used when one exists
+// (e.g., "DiskSpace", "HighMemory"); otherwise the Generic builder lets the LLM
+// determine the investigation approach from the alert context and available tools.
 //
 // Returns ErrNilAlert if alert is nil.
-// Returns ErrPromptBuilderNotFound if Generic builder is not registered.
+// Returns ErrPromptBuilderNotFound if no matching builder and no Generic builder is registered.
 func (r *DefaultPromptBuilderRegistry) BuildPromptForAlert(
+	ctx context.Context,
 	alert *AlertView,
 	tools []entity.Tool,
 	skills []port.SkillInfo,
@@ -304,9 +391,15 @@ func (r *DefaultPromptBuilderRegistry) BuildPromptForAlert(
 		return "", ErrNilAlert
 	}
 
-	// Always use Generic builder - LLM determines investigation approach
+	if alertType := alert.LabelValue(alertNameLabel); alertType != "" {
+		if builder, exists := r.builders[alertType]; exists {
+			return builder.BuildPrompt(ctx, alert, tools, skills)
+		}
+	}
+
+	// Fall back to the Generic builder for unknown alert types
 	if builder, exists := r.builders[AlertTypeGeneric]; exists {
-		return builder.BuildPrompt(alert, tools, skills)
+		return builder.BuildPrompt(ctx, alert, tools, skills)
 	}
 
 	return "", ErrPromptBuilderNotFound
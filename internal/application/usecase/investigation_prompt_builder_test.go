@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"code-editing-agent/internal/domain/entity"
+	"context"
 	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 // =============================================================================
@@ -64,7 +66,7 @@ func TestGenericPromptBuilder_BuildPrompt_ValidAlert(t *testing.T) {
 		labels:      map[string]string{"service": "mystery-service"},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Errorf("BuildPrompt() error = %v", err)
 	}
@@ -87,7 +89,7 @@ func TestGenericPromptBuilder_BuildPrompt_ContainsGeneralInstructions(t *testing
 		labels:   map[string]string{},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Fatalf("BuildPrompt() error = %v", err)
 	}
@@ -126,7 +128,7 @@ func TestGenericPromptBuilder_BuildPrompt_IncludesAllLabels(t *testing.T) {
 		},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Fatalf("BuildPrompt() error = %v", err)
 	}
@@ -147,6 +149,30 @@ func TestGenericPromptBuilder_BuildPrompt_IncludesAllLabels(t *testing.T) {
 	}
 }
 
+func TestGenericPromptBuilder_BuildPrompt_IncludesAnnotations(t *testing.T) {
+	alert := &AlertView{
+		id:          "alert-annotations-001",
+		source:      "prometheus",
+		severity:    "warning",
+		title:       "Test Alert",
+		annotations: map[string]string{"team": "storage"},
+	}
+	added := alert.AddAnnotations(map[string]string{"team": "platform", "tier": "1"})
+	if len(added) != 1 || added[0] != "tier" {
+		t.Errorf("AddAnnotations() = %v, want only the new tier key", added)
+	}
+
+	prompt, err := NewGenericPromptBuilder().BuildPrompt(context.Background(), alert, createTestTools(), nil)
+	if err != nil {
+		t.Fatalf("BuildPrompt() error = %v", err)
+	}
+	for _, want := range []string{"### Annotations", "- `team`: storage", "- `tier`: 1"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() should contain %q", want)
+		}
+	}
+}
+
 func TestGenericPromptBuilder_BuildPrompt_ContainsCloudGuidance(t *testing.T) {
 	builder := NewGenericPromptBuilder()
 	if builder == nil {
@@ -164,7 +190,7 @@ func TestGenericPromptBuilder_BuildPrompt_ContainsCloudGuidance(t *testing.T) {
 		},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Fatalf("BuildPrompt() error = %v", err)
 	}
@@ -202,7 +228,7 @@ func TestGenericPromptBuilder_BuildPrompt_ContainsAllAlertFields(t *testing.T) {
 		},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Fatalf("BuildPrompt() error = %v", err)
 	}
@@ -245,7 +271,7 @@ func TestGenericPromptBuilder_BuildPrompt_EmptyLabels(t *testing.T) {
 		labels:      map[string]string{},
 	}
 
-	prompt, err := builder.BuildPrompt(alert, createTestTools(), nil)
+	prompt, err := builder.BuildPrompt(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Fatalf("BuildPrompt() error = %v", err)
 	}
@@ -270,7 +296,7 @@ func TestGenericPromptBuilder_BuildPrompt_NilAlert(t *testing.T) {
 		t.Skip("NewGenericPromptBuilder() returned nil")
 	}
 
-	_, err := builder.BuildPrompt(nil, createTestTools(), nil)
+	_, err := builder.BuildPrompt(context.Background(), nil, createTestTools(), nil)
 	if !errors.Is(err, ErrNilAlert) {
 		t.Errorf("BuildPrompt(nil) error = %v, want ErrNilAlert", err)
 	}
@@ -375,7 +401,7 @@ func TestPromptBuilderRegistry_BuildPromptForAlert_UsesGenericBuilder(t *testing
 		labels:   map[string]string{"alertname": "HighCPU"},
 	}
 
-	prompt, err := registry.BuildPromptForAlert(alert, createTestTools(), nil)
+	prompt, err := registry.BuildPromptForAlert(context.Background(), alert, createTestTools(), nil)
 	if err != nil {
 		t.Errorf("BuildPromptForAlert() error = %v", err)
 	}
@@ -408,7 +434,7 @@ func TestPromptBuilderRegistry_BuildPromptForAlert_FallbackToGeneric(t *testing.
 		labels:   map[string]string{"alertname": "SomethingUnknown"},
 	}
 
-	prompt, err := registry.BuildPromptForAlert(alert, createTestTools(), nil)
+	prompt, err := registry.BuildPromptForAlert(context.Background(), alert, createTestTools(), nil)
 	// Should either succeed with generic builder or return meaningful error
 	if err != nil && prompt == "" {
 		t.Logf("BuildPromptForAlert() returned error for unknown type: %v (acceptable if no fallback)", err)
@@ -421,7 +447,7 @@ func TestPromptBuilderRegistry_BuildPromptForAlert_NilAlert(t *testing.T) {
 		t.Skip("NewPromptBuilderRegistry() returned nil")
 	}
 
-	_, err := registry.BuildPromptForAlert(nil, createTestTools(), nil)
+	_, err := registry.BuildPromptForAlert(context.Background(), nil, createTestTools(), nil)
 	if err == nil {
 		t.Error("BuildPromptForAlert(nil) should return error")
 	}
@@ -571,3 +597,17 @@ func TestGenerateToolsHeader_ContainsExamples(t *testing.T) {
 		t.Error("Header should contain example usage")
 	}
 }
+
+func TestGenerateToolsHeader_IncludesTimeouts(t *testing.T) {
+	bash, _ := entity.NewTool("bash", "bash", "Execute shell commands")
+	bash.Timeout = 45 * time.Second
+	readFile, _ := entity.NewTool("read_file", "read_file", "Read a file")
+	header := GenerateToolsHeader([]entity.Tool{*bash, *readFile})
+
+	if !strings.Contains(header, "Timeout: 45s per call") {
+		t.Errorf("Header should state the bash timeout, got:\n%s", header)
+	}
+	if strings.Count(header, "Timeout:") != 1 {
+		t.Errorf("Only tools with a timeout should state one, got:\n%s", header)
+	}
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// alertNameLabel is the label used to select an alert-type-specific prompt template.
+const alertNameLabel = "alertname"
+
+// PromptLabel is a single alert label exposed to prompt templates.
+type PromptLabel struct {
+	Key   string
+	Value string
+}
+
+// PromptTemplateData is the data passed to investigation prompt templates.
+//
+// Templates can reference alert fields through the AlertView methods, for example
+// {{.Alert.Title}} or {{.Alert.LabelValue "instance"}}, and can either range over
+// Tools and Skills or use the pre-rendered ToolsHeader and SkillsHeader sections.
+type PromptTemplateData struct {
+	AlertType    string           // Template key the alert was matched to (e.g., "HighCPU", "Generic")
+	Alert        *AlertView       // The alert being investigated
+	Labels       []PromptLabel    // Alert labels sorted by key
+	Annotations  []PromptLabel    // Alert annotations, enrichments included, sorted by key
+	Tools        []entity.Tool    // Tools available to the investigation
+	Skills       []port.SkillInfo // Skills available to the investigation
+	ToolsHeader  string           // Tools formatted by GenerateToolsHeader
+	SkillsHeader string           // Skills formatted by GenerateSkillsHeader
+}
+
+// newPromptTemplateData assembles the template data for an alert.
+func newPromptTemplateData(
+	alertType string,
+	alert *AlertView,
+	tools []entity.Tool,
+	skills []port.SkillInfo,
+) PromptTemplateData {
+	return PromptTemplateData{
+		AlertType:    alertType,
+		Alert:        alert,
+		Labels:       sortedPromptLabels(alert.Labels()),
+		Annotations:  sortedPromptLabels(alert.Annotations()),
+		Tools:        tools,
+		Skills:       skills,
+		ToolsHeader:  GenerateToolsHeader(tools),
+		SkillsHeader: GenerateSkillsHeader(skills),
+	}
+}
+
+// sortedPromptLabels returns values as PromptLabels sorted by key.
+func sortedPromptLabels(values map[string]string) []PromptLabel {
+	labels := make([]PromptLabel, 0, len(values))
+	for k, v := range values {
+		labels = append(labels, PromptLabel{Key: k, Value: v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+	return labels
+}
+
+// ParsePromptTemplate parses an investigation prompt template.
+// The name is used in error messages, so passing the file name makes parse
+// errors point at the file and line (e.g., "template: HighCPU.tmpl:3: ...").
+// Returns ErrEmptyPromptTemplate if content is blank.
+func ParsePromptTemplate(name, content string) (*template.Template, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%s: %w", name, ErrEmptyPromptTemplate)
+	}
+	return template.New(name).Option("missingkey=error").Parse(content)
+}
+
+// TemplatePromptRegistry is a PromptBuilderRegistry that renders prompts from
+// user-supplied templates keyed by alert type, falling back to a base registry.
+//
+// The alert type is taken from the alert's "alertname" label. If no template
+// exists for that type, the "Generic" template is used; if neither exists the
+// prompt is built by the base registry's built-in builders.
+type TemplatePromptRegistry struct {
+	base      PromptBuilderRegistry
+	templates map[string]*template.Template
+}
+
+// NewTemplatePromptRegistry wraps base with the given templates (alert type -> template).
+// A nil or empty templates map makes the registry behave exactly like base.
+func NewTemplatePromptRegistry(
+	base PromptBuilderRegistry,
+	templates map[string]*template.Template,
+) *TemplatePromptRegistry {
+	if templates == nil {
+		templates = make(map[string]*template.Template)
+	}
+	return &TemplatePromptRegistry{
+		base:      base,
+		templates: templates,
+	}
+}
+
+// Register adds a prompt builder to the base registry.
+func (r *TemplatePromptRegistry) Register(builder InvestigationPromptBuilder) error {
+	return r.base.Register(builder)
+}
+
+// Get retrieves a builder by alert type from the base registry.
+func (r *TemplatePromptRegistry) Get(alertType string) (InvestigationPromptBuilder, error) {
+	return r.base.Get(alertType)
+}
+
+// ListAlertTypes returns the alert types of the base registry plus any template-only types.
+func (r *TemplatePromptRegistry) ListAlertTypes() []string {
+	types := r.base.ListAlertTypes()
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		seen[t] = true
+	}
+	for t := range r.templates {
+		if !seen[t] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// BuildPromptForAlert renders the template matching the alert type, or delegates
+// to the base registry when no template applies.
+// Returns ErrNilAlert if alert is nil.
+func (r *TemplatePromptRegistry) BuildPromptForAlert(
+	ctx context.Context,
+	alert *AlertView,
+	tools []entity.Tool,
+	skills []port.SkillInfo,
+) (string, error) {
+	if alert == nil {
+		return "", ErrNilAlert
+	}
+
+	alertType, tmpl := r.templateFor(alert)
+	if tmpl == nil {
+		return r.base.BuildPromptForAlert(ctx, alert, tools, skills)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, newPromptTemplateData(alertType, alert, tools, skills)); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// templateFor returns the template key and template for an alert, or a nil template if none applies.
+func (r *TemplatePromptRegistry) templateFor(alert *AlertView) (string, *template.Template) {
+	if alertType := alert.LabelValue(alertNameLabel); alertType != "" {
+		if tmpl, ok := r.templates[alertType]; ok {
+			return alertType, tmpl
+		}
+	}
+	if tmpl, ok := r.templates[AlertTypeGeneric]; ok {
+		return AlertTypeGeneric, tmpl
+	}
+	return "", nil
+}
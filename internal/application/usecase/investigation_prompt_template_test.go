@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// =============================================================================
+// TemplatePromptRegistry Tests
+// =============================================================================
+
+// mustParsePromptTemplate parses a template or fails the test.
+func mustParsePromptTemplate(t *testing.T, name, content string) *template.Template {
+	t.Helper()
+	tmpl, err := ParsePromptTemplate(name, content)
+	if err != nil {
+		t.Fatalf("ParsePromptTemplate(%s) error = %v", name, err)
+	}
+	return tmpl
+}
+
+// newBaseRegistry returns a registry with only the built-in Generic builder.
+func newBaseRegistry(t *testing.T) *DefaultPromptBuilderRegistry {
+	t.Helper()
+	registry := NewPromptBuilderRegistry()
+	if err := registry.Register(NewGenericPromptBuilder()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return registry
+}
+
+func TestTemplatePromptRegistry_RendersCustomTemplate(t *testing.T) {
+	tmpl := mustParsePromptTemplate(t, "HighCPU.tmpl",
+		`[{{.AlertType}}] {{.Alert.Title}} on {{.Alert.LabelValue "instance"}} ({{.Alert.Severity}})
+{{range .Labels}}{{.Key}}={{.Value}};{{end}}
+{{range .Tools}}{{.Name}},{{end}}
+{{range .Skills}}{{.Name}}{{end}}`)
+	registry := NewTemplatePromptRegistry(newBaseRegistry(t), map[string]*template.Template{"HighCPU": tmpl})
+
+	alert := &AlertView{
+		id:       "alert-1",
+		severity: "critical",
+		title:    "CPU above 90%",
+		labels:   map[string]string{"instance": "web-1", "alertname": "HighCPU"},
+	}
+	skills := []port.SkillInfo{{Name: "cloud-metrics"}}
+
+	prompt, err := registry.BuildPromptForAlert(context.Background(), alert, createTestTools()[:2], skills)
+	if err != nil {
+		t.Fatalf("BuildPromptForAlert() error = %v", err)
+	}
+
+	want := "[HighCPU] CPU above 90% on web-1 (critical)\n" +
+		"alertname=HighCPU;instance=web-1;\n" +
+		"bash,read_file,\n" +
+		"cloud-metrics"
+	if prompt != want {
+		t.Errorf("BuildPromptForAlert() = %q, want %q", prompt, want)
+	}
+}
+
+func TestTemplatePromptRegistry_Fallbacks(t *testing.T) {
+	genericTmpl := func(t *testing.T) *template.Template {
+		return mustParsePromptTemplate(t, "Generic.tmpl", "custom generic: {{.Alert.Title}}")
+	}
+
+	tests := []struct {
+		name      string
+		templates func(t *testing.T) map[string]*template.Template
+		alertname string
+		want      string
+	}{
+		{
+			name: "unknown alert type uses Generic template",
+			templates: func(t *testing.T) map[string]*template.Template {
+				return map[string]*template.Template{"Generic": genericTmpl(t)}
+			},
+			alertname: "DiskSpace",
+			want:      "custom generic: Disk full",
+		},
+		{
+			name: "missing alertname uses Generic template",
+			templates: func(t *testing.T) map[string]*template.Template {
+				return map[string]*template.Template{"Generic": genericTmpl(t)}
+			},
+			want: "custom generic: Disk full",
+		},
+		{
+			name: "no matching template uses built-in builder",
+			templates: func(t *testing.T) map[string]*template.Template {
+				return map[string]*template.Template{
+					"HighCPU": mustParsePromptTemplate(t, "HighCPU.tmpl", "cpu"),
+				}
+			},
+			alertname: "DiskSpace",
+			want:      "## Role",
+		},
+		{
+			name:      "no templates uses built-in builder",
+			templates: func(*testing.T) map[string]*template.Template { return nil },
+			alertname: "DiskSpace",
+			want:      "## Role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewTemplatePromptRegistry(newBaseRegistry(t), tt.templates(t))
+			alert := &AlertView{id: "alert-2", title: "Disk full", labels: map[string]string{}}
+			if tt.alertname != "" {
+				alert.labels["alertname"] = tt.alertname
+			}
+
+			prompt, err := registry.BuildPromptForAlert(context.Background(), alert, createTestTools(), nil)
+			if err != nil {
+				t.Fatalf("BuildPromptForAlert() error = %v", err)
+			}
+			if !strings.HasPrefix(prompt, tt.want) {
+				t.Errorf("BuildPromptForAlert() = %q, want prefix %q", prompt, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplatePromptRegistry_NilAlert(t *testing.T) {
+	registry := NewTemplatePromptRegistry(newBaseRegistry(t), nil)
+
+	if _, err := registry.BuildPromptForAlert(context.Background(), nil, nil, nil); !errors.Is(err, ErrNilAlert) {
+		t.Errorf("BuildPromptForAlert(nil) error = %v, want ErrNilAlert", err)
+	}
+}
+
+func TestTemplatePromptRegistry_RenderError(t *testing.T) {
+	tmpl := mustParsePromptTemplate(t, "Generic.tmpl", "{{.Alert.Nope}}")
+	registry := NewTemplatePromptRegistry(newBaseRegistry(t), map[string]*template.Template{"Generic": tmpl})
+
+	_, err := registry.BuildPromptForAlert(context.Background(), &AlertView{id: "a", title: "t"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "Generic.tmpl") {
+		t.Errorf("BuildPromptForAlert() error = %v, want render error naming the template", err)
+	}
+}
+
+func TestTemplatePromptRegistry_ListAlertTypesIncludesTemplates(t *testing.T) {
+	registry := NewTemplatePromptRegistry(newBaseRegistry(t), map[string]*template.Template{
+		"Generic": mustParsePromptTemplate(t, "Generic.tmpl", "g"),
+		"HighCPU": mustParsePromptTemplate(t, "HighCPU.tmpl", "c"),
+	})
+
+	types := registry.ListAlertTypes()
+	if len(types) != 2 {
+		t.Errorf("ListAlertTypes() = %v, want Generic and HighCPU once each", types)
+	}
+}
+
+func TestParsePromptTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: "  \n", wantErr: ErrEmptyPromptTemplate.Error()},
+		{name: "syntax error reports line", content: "line one\n{{.Alert.Title", wantErr: "Bad.tmpl:2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePromptTemplate("Bad.tmpl", tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePromptTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlertInvestigationUseCase_RenderPrompt(t *testing.T) {
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{
+		AllowedTools: []string{"bash", "read_file"},
+	})
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(NewTemplatePromptRegistry(newBaseRegistry(t), map[string]*template.Template{
+		"HighCPU": mustParsePromptTemplate(t, "HighCPU.tmpl", "{{.Alert.ID}}:{{range .Tools}} {{.Name}}{{end}}"),
+	}))
+
+	alert, err := entity.NewAlert("alert-9", "prometheus", entity.SeverityCritical, "CPU high")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	alert.WithLabels(map[string]string{"alertname": "HighCPU"})
+
+	prompt, err := uc.RenderPrompt(context.Background(), alert)
+	if err != nil {
+		t.Fatalf("RenderPrompt() error = %v", err)
+	}
+	if prompt != "alert-9: bash read_file" {
+		t.Errorf("RenderPrompt() = %q, want template output with allowed tools only", prompt)
+	}
+	if uc.GetActiveCount() != 0 {
+		t.Error("RenderPrompt() should not start an investigation")
+	}
+
+	if _, err := uc.RenderPrompt(context.Background(), nil); !errors.Is(err, ErrAlertNil) {
+		t.Errorf("RenderPrompt(nil) error = %v, want ErrAlertNil", err)
+	}
+}
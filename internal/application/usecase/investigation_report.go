@@ -0,0 +1,483 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Sentinel errors for ReportGenerator operations.
+var (
+	// ErrNoReportSource is returned when reporting a stored investigation
+	// without an investigation source configured.
+	ErrNoReportSource = errors.New("no investigation source configured for reports")
+	// ErrNoSummaryProvider is returned when an executive summary is requested
+	// without an AI provider configured.
+	ErrNoSummaryProvider = errors.New("no AI provider configured for executive summaries")
+)
+
+// reportCheckInputMaxLen limits the tool input shown in a report's list of
+// checks; the appendix shows the input in full.
+const reportCheckInputMaxLen = 120
+
+// reportSummaryPrompt instructs the AI to write a report's executive summary.
+const reportSummaryPrompt = `You are writing the executive summary of an alert investigation report for on-call engineers and their managers.
+Write one paragraph of at most five sentences: what alerted, what the investigation found, the likely root cause, what should happen next, and how confident the investigation is.
+Use only facts from the report. Respond with the paragraph only.
+
+Report:
+%s`
+
+// DefaultReportTemplate is the Markdown template reports are rendered with
+// unless a report template is set. It is executed with a ReportData.
+const DefaultReportTemplate = `{{- $r := .Result -}}
+# Investigation Report: {{if .Title}}{{.Title}}{{else}}{{$r.AlertID}}{{end}}
+
+- **Investigation:** {{$r.InvestigationID}}
+- **Status:** {{$r.Status}}{{if and $r.Escalated (ne $r.Status "escalated")}} (escalated){{end}}
+- **Confidence:** {{printf "%.2f" $r.Confidence}}{{if $r.ConfidenceDerived}} (estimated from tool results){{end}}
+- **Duration:** {{.Duration}}
+- **Actions taken:** {{$r.ActionsTaken}}
+{{- with .Summary}}
+
+## Executive Summary
+
+{{.}}
+{{- with $.SummaryModel}}
+
+_Written by {{.}}._
+{{- end}}
+{{- end}}
+
+## Alert Context
+{{if .Alert}}
+- **Alert ID:** {{.Alert.ID}}
+- **Source:** {{.Alert.Source}}
+- **Severity:** {{.Alert.Severity}}
+{{- with .Alert.Fingerprint}}
+- **Fingerprint:** {{.}}
+{{- end}}
+{{- with .Alert.GeneratorURL}}
+- **Source link:** {{.}}
+{{- end}}
+{{- with .Alert.Description}}
+
+{{.}}
+{{- end}}
+{{- if .Labels}}
+
+| Label | Value |
+| --- | --- |
+{{- range .Labels}}
+| {{cell .Key}} | {{cell .Value}} |
+{{- end}}
+{{- end}}
+{{- else}}
+Alert {{$r.AlertID}} was not recorded with the investigation.
+{{- end}}
+{{- if $r.Escalated}}
+
+## Escalation
+
+{{with $r.EscalateReason}}{{.}}{{else}}(no reason given){{end}}
+{{- end}}
+{{- with $r.Error}}
+
+## Error
+
+{{.}}
+{{- end}}
+
+## What Was Checked
+{{range $i, $c := .Checks}}
+{{inc $i}}. {{code $c.ToolName}}{{with $c.Input}} {{code .}}{{end}}{{if $c.IsError}} (failed){{end}}{{with $c.Summary}}: {{.}}{{end}}{{with $c.ArtifactID}} ([output](#{{.}})){{end}}
+{{- else}}
+No tools were run.
+{{- end}}
+
+## Findings
+{{range .FindingGroups}}
+### {{.Title}}
+{{range .Findings}}
+- {{.Text}}{{if gt .Occurrences 1}} (reported {{.Occurrences}} times){{end}}
+{{- end}}
+{{else}}
+None recorded.
+{{end}}
+## Root Cause
+
+{{with $r.RootCause}}{{.}}{{else}}Not determined.{{end}}
+
+## Recommended Actions
+{{range $r.RecommendedActions}}
+- {{.Description}}{{with .Command}}: {{code .}}{{end}}
+{{- if .Risk}} ({{.Risk}} risk{{if .RequiresApproval}}, requires approval{{end}})
+{{- else if .RequiresApproval}} (requires approval){{end}}
+{{- else}}
+None recorded.
+{{- end}}
+{{- with $r.Remediations}}
+
+## Remediation
+{{range .}}
+- {{code .Action.Command}}: {{.Status}}
+{{- end}}
+{{- end}}
+{{- if .Artifacts}}
+
+## Appendix: Tool Outputs
+{{- range .Artifacts}}
+
+### <a id="{{.ID}}"></a>{{.ID}}: {{.ToolName}}{{if .IsError}} (failed){{end}}
+{{with .Input}}
+Input: {{code .}}
+{{end}}
+{{codeBlock .Output}}
+{{- end}}
+{{- end}}
+`
+
+// InvestigationArtifact is the recorded output of one tool call of an
+// investigation, as shown in a report's appendix.
+type InvestigationArtifact struct {
+	ID       string // Anchor of the artifact in reports, such as "artifact-3"
+	ToolName string
+	Input    string // Tool input as JSON, truncated
+	Output   string // Tool result, truncated
+	IsError  bool
+	Time     time.Time
+}
+
+// ArtifactsFromTimeline returns the artifacts of the tool events in a
+// timeline. The Nth tool event's artifact has ID "artifact-N"; events
+// recorded without output have no artifact.
+func ArtifactsFromTimeline(events []port.InvestigationEvent) []InvestigationArtifact {
+	var artifacts []InvestigationArtifact
+	n := 0
+	for _, event := range events {
+		if event.Type != port.InvestigationEventToolExecuted {
+			continue
+		}
+		n++
+		if event.Output == "" {
+			continue
+		}
+		artifacts = append(artifacts, InvestigationArtifact{
+			ID:       artifactID(n),
+			ToolName: event.ToolName,
+			Input:    event.Input,
+			Output:   event.Output,
+			IsError:  event.IsError,
+			Time:     event.Time,
+		})
+	}
+	return artifacts
+}
+
+// artifactID returns the ID of the artifact of the nth tool event.
+func artifactID(n int) string {
+	return fmt.Sprintf("artifact-%d", n)
+}
+
+// ReportCheck is one tool call listed under a report's "What Was Checked".
+type ReportCheck struct {
+	ToolName   string
+	Input      string // Tool input, shortened
+	Summary    string // First line of the tool result
+	IsError    bool
+	ArtifactID string // Anchor of the call's output in the appendix, if recorded
+}
+
+// ReportData is the data passed to report templates.
+//
+// Templates can use the functions "code" (inline code), "codeBlock" (fenced
+// code block), "cell" (escapes a table cell), and "inc" (adds one).
+type ReportData struct {
+	Result    *InvestigationResult
+	Alert     *entity.Alert // The investigated alert, or nil if not recorded
+	Title     string        // Alert rendered with the title template, or empty without an alert
+	Labels    []PromptLabel // Alert labels sorted by key
+	Duration  time.Duration // Result duration rounded to the second
+	Checks    []ReportCheck // Tool calls of the timeline, in order
+	Artifacts []InvestigationArtifact
+	Summary   string // AI-written executive summary, if requested
+
+	FindingGroups []FindingGroup // Result findings by severity, most severe first
+
+	SummaryModel string // Model that wrote Summary
+}
+
+// newReportData assembles the template data for a result.
+func newReportData(result *InvestigationResult, alert *entity.Alert) ReportData {
+	data := ReportData{
+		Result:    result,
+		Alert:     alert,
+		Duration:  result.Duration.Round(time.Second),
+		Artifacts: result.Artifacts,
+
+		FindingGroups: GroupFindings(result.Findings),
+	}
+	if alert != nil {
+		for k, v := range alert.Labels() {
+			data.Labels = append(data.Labels, PromptLabel{Key: k, Value: v})
+		}
+		sort.Slice(data.Labels, func(i, j int) bool { return data.Labels[i].Key < data.Labels[j].Key })
+	}
+
+	recorded := make(map[string]bool, len(result.Artifacts))
+	for _, artifact := range result.Artifacts {
+		recorded[artifact.ID] = true
+	}
+	n := 0
+	for _, event := range result.Timeline {
+		if event.Type != port.InvestigationEventToolExecuted {
+			continue
+		}
+		n++
+		check := ReportCheck{
+			ToolName: event.ToolName,
+			Input:    shortenCheckInput(event.Input),
+			Summary:  event.Summary,
+			IsError:  event.IsError,
+		}
+		if id := artifactID(n); recorded[id] {
+			check.ArtifactID = id
+		}
+		data.Checks = append(data.Checks, check)
+	}
+	return data
+}
+
+// shortenCheckInput truncates a tool input to reportCheckInputMaxLen bytes.
+func shortenCheckInput(input string) string {
+	if len(input) > reportCheckInputMaxLen {
+		return input[:reportCheckInputMaxLen] + "..."
+	}
+	return input
+}
+
+// reportFuncs are the functions available to report templates.
+//
+//nolint:gochecknoglobals // template function map shared by every parse
+var reportFuncs = template.FuncMap{
+	"code":      markdownCode,
+	"codeBlock": markdownCodeBlock,
+	"cell":      markdownCell,
+	"inc":       func(i int) int { return i + 1 },
+}
+
+// markdownCode renders s as inline code, with a backtick run longer than any
+// in s.
+func markdownCode(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	ticks := strings.Repeat("`", longestBacktickRun(s)+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return ticks + " " + s + " " + ticks
+	}
+	return ticks + s + ticks
+}
+
+// markdownCodeBlock renders s as a fenced code block, with a fence longer
+// than any backtick run in s.
+func markdownCodeBlock(s string) string {
+	fence := strings.Repeat("`", max(3, longestBacktickRun(s)+1))
+	return fence + "\n" + strings.TrimRight(s, "\n") + "\n" + fence
+}
+
+// markdownCell escapes s for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in s.
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// ParseReportTemplate parses an investigation report template. As with
+// ParsePromptTemplate, the name appears in parse errors.
+// Returns ErrEmptyPromptTemplate if content is blank.
+func ParseReportTemplate(name, content string) (*template.Template, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%s: %w", name, ErrEmptyPromptTemplate)
+	}
+	return template.New(name).Funcs(reportFuncs).Parse(content)
+}
+
+// InvestigationReportSource reads stored investigations and their progress
+// events for reports.
+type InvestigationReportSource interface {
+	Get(ctx context.Context, id string) (InvestigationRecordData, error)
+	Events(ctx context.Context, id string) ([]port.InvestigationEvent, error)
+}
+
+// ReportGenerator renders completed investigations as Markdown reports: the
+// alert context, what was checked, findings, root cause, recommended actions,
+// confidence, and an appendix of tool outputs. With an AI provider set it can
+// open the report with an AI-written executive summary.
+type ReportGenerator struct {
+	template   *template.Template
+	summarizer port.AIProvider
+	router     *ModelRouter
+	source     InvestigationReportSource
+	title      *AlertTemplate
+}
+
+// NewReportGenerator creates a report generator that uses DefaultReportTemplate.
+func NewReportGenerator() *ReportGenerator {
+	return &ReportGenerator{
+		template: template.Must(ParseReportTemplate("report", DefaultReportTemplate)),
+	}
+}
+
+// SetTemplate replaces the report template. A nil template restores
+// DefaultReportTemplate.
+func (g *ReportGenerator) SetTemplate(tmpl *template.Template) {
+	if tmpl == nil {
+		tmpl = template.Must(ParseReportTemplate("report", DefaultReportTemplate))
+	}
+	g.template = tmpl
+}
+
+// SetTitleTemplate sets the template of the report's title. A nil template
+// restores DefaultTitleTemplate.
+func (g *ReportGenerator) SetTitleTemplate(tmpl *AlertTemplate) {
+	g.title = tmpl
+}
+
+// SetSummaryProvider sets the AI provider that writes executive summaries.
+// Without one, reports requested with a summary fail with ErrNoSummaryProvider.
+func (g *ReportGenerator) SetSummaryProvider(provider port.AIProvider) {
+	g.summarizer = provider
+}
+
+// SetModelRouter sets the router choosing the model of executive summaries
+// (ModelTaskSummarization). A nil router keeps the summary provider's model.
+func (g *ReportGenerator) SetModelRouter(router *ModelRouter) {
+	g.router = router
+}
+
+// SetInvestigationSource sets where ReportInvestigation reads stored
+// investigations from.
+func (g *ReportGenerator) SetInvestigationSource(source InvestigationReportSource) {
+	g.source = source
+}
+
+// Generate renders the report of a completed investigation of alert, which
+// may be nil if the alert is unknown. With summary set it makes one AI call
+// to write an executive summary of the report, which then opens it.
+func (g *ReportGenerator) Generate(
+	ctx context.Context,
+	result *InvestigationResult,
+	alert *entity.Alert,
+	summary bool,
+) (string, error) {
+	if result == nil {
+		return "", errors.New("nil investigation result")
+	}
+	if summary && g.summarizer == nil {
+		return "", ErrNoSummaryProvider
+	}
+
+	data := newReportData(result, alert)
+	if alert != nil {
+		data.Title = RenderTitle(g.title, NewAlertViewFromEntity(alert))
+	}
+	report, err := g.render(data)
+	if err != nil || !summary {
+		return report, err
+	}
+
+	if data.Summary, data.SummaryModel, err = g.summarize(ctx, report); err != nil {
+		return "", fmt.Errorf("failed to write executive summary: %w", err)
+	}
+	return g.render(data)
+}
+
+// ReportInvestigation renders the report of a stored investigation, with its
+// progress events as the timeline. It implements port.InvestigationReporter.
+func (g *ReportGenerator) ReportInvestigation(ctx context.Context, investigationID string, summary bool) (string, error) {
+	if g.source == nil {
+		return "", ErrNoReportSource
+	}
+	record, err := g.source.Get(ctx, investigationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load investigation %s: %w", investigationID, err)
+	}
+	events, err := g.source.Events(ctx, investigationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load events of investigation %s: %w", investigationID, err)
+	}
+	return g.Generate(ctx, resultFromRecord(record, events), record.Alert(), summary)
+}
+
+// render executes the report template.
+func (g *ReportGenerator) render(data ReportData) (string, error) {
+	var b strings.Builder
+	if err := g.template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.String(), nil
+}
+
+// summarize issues a single tool-less AI call that summarizes the report, on
+// the model routed for summarization if any. It returns the summary and the
+// model that wrote it.
+func (g *ReportGenerator) summarize(ctx context.Context, report string) (string, string, error) {
+	opts := port.RequestOptions{Model: g.router.Route(ModelTaskSummarization, false)}
+	model := opts.Model
+	if model == "" {
+		model = g.summarizer.GetModel()
+	}
+	messages := []port.MessageParam{{Role: entity.RoleUser, Content: fmt.Sprintf(reportSummaryPrompt, report)}}
+	msg, _, err := g.summarizer.SendMessageWithOptions(ctx, messages, nil, opts)
+	if err != nil {
+		return "", "", err
+	}
+	if msg == nil || strings.TrimSpace(msg.Content) == "" {
+		return "", "", errors.New("summary response was empty")
+	}
+	return strings.TrimSpace(msg.Content), model, nil
+}
+
+// resultFromRecord rebuilds the result of a stored investigation, with its
+// progress events as the timeline.
+func resultFromRecord(record InvestigationRecordData, events []port.InvestigationEvent) *InvestigationResult {
+	result := &InvestigationResult{
+		InvestigationID:    record.ID(),
+		AlertID:            record.AlertID(),
+		Status:             record.Status(),
+		Findings:           record.Findings(),
+		ActionsTaken:       record.ActionsTaken(),
+		Duration:           record.Duration(),
+		Confidence:         record.Confidence(),
+		Escalated:          record.Escalated(),
+		EscalateReason:     record.EscalateReason(),
+		RootCause:          record.RootCause(),
+		RecommendedActions: record.RecommendedActions(),
+		Remediations:       record.Remediations(),
+		Timeline:           events,
+		Artifacts:          ArtifactsFromTimeline(events),
+		Cost:               record.Cost(),
+		Usage:              record.Usage(),
+	}
+	if msg := record.ErrorMessage(); msg != "" {
+		result.Error = errors.New(msg)
+	}
+	return result
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reportFixture returns a completed investigation of a disk space alert that
+// ran two tools, the first of which printed output containing a code fence.
+func reportFixture(t *testing.T) (*InvestigationResult, *entity.Alert) {
+	t.Helper()
+	alert, err := entity.NewAlert("alert-7", "prometheus", "critical", "Disk almost full on web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert = alert.WithDescription("Root filesystem is 97% full.").
+		WithLabels(map[string]string{"instance": "web-1:9100", "alertname": "DiskSpaceLow"}).
+		WithGeneratorURL("http://prometheus:9090/graph?g0.expr=disk")
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	timeline := []port.InvestigationEvent{
+		{Type: port.InvestigationEventIterationStarted, Iteration: 1, Time: at},
+		{
+			Type: port.InvestigationEventToolExecuted, ToolName: "bash", Time: at,
+			Input:   `{"command":"df -h /"}`,
+			Summary: "Filesystem Size Used Avail Use% Mounted on",
+			Output:  "Filesystem Size Used Avail Use% Mounted on\n/dev/sda1 50G 48G 2G 97% /\n```",
+		},
+		{
+			Type: port.InvestigationEventToolExecuted, ToolName: "read_file", Time: at, IsError: true,
+			Input: `{"path":"/var/log/missing.log"}`, Summary: "file not found", Output: "file not found",
+		},
+	}
+	result := &InvestigationResult{
+		InvestigationID: "inv-42",
+		AlertID:         "alert-7",
+		Status:          "completed",
+		Findings:        []string{"/var/log holds 40G of rotated logs"},
+		ActionsTaken:    2,
+		Duration:        93 * time.Second,
+		Confidence:      0.85,
+		RootCause:       "logrotate stopped compressing old logs",
+		RecommendedActions: []entity.RecommendedAction{
+			{
+				Description: "Delete logs older than 7 days", Risk: entity.RiskLow,
+				Command: "find /var/log -name '*.gz' -mtime +7 -delete",
+			},
+			{Description: "Fix the logrotate config", RequiresApproval: true},
+		},
+		Remediations: []entity.RemediationAttempt{{
+			Action: entity.RecommendedAction{Command: "find /var/log -name '*.gz' -mtime +7 -delete"},
+			Status: entity.RemediationSucceeded,
+		}},
+		Timeline:  timeline,
+		Artifacts: ArtifactsFromTimeline(timeline),
+	}
+	return result, alert
+}
+
+func TestReportGenerator_Sections(t *testing.T) {
+	result, alert := reportFixture(t)
+
+	report, err := NewReportGenerator().Generate(context.Background(), result, alert, false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"# Investigation Report: Disk almost full on web-1",
+		"- **Confidence:** 0.85",
+		"- **Duration:** 1m33s",
+		"## Alert Context",
+		"- **Source link:** http://prometheus:9090/graph?g0.expr=disk",
+		"| instance | web-1:9100 |",
+		"## What Was Checked",
+		"1. `bash` `{\"command\":\"df -h /\"}`: Filesystem Size Used Avail Use% Mounted on ([output](#artifact-1))",
+		"2. `read_file` `{\"path\":\"/var/log/missing.log\"}` (failed): file not found ([output](#artifact-2))",
+		"## Findings\n\n### Info\n\n- /var/log holds 40G of rotated logs",
+		"## Root Cause\n\nlogrotate stopped compressing old logs",
+		"## Recommended Actions\n\n" +
+			"- Delete logs older than 7 days: `find /var/log -name '*.gz' -mtime +7 -delete` (low risk)\n" +
+			"- Fix the logrotate config (requires approval)",
+		"## Remediation\n\n- `find /var/log -name '*.gz' -mtime +7 -delete`: succeeded",
+		"## Appendix: Tool Outputs",
+		`### <a id="artifact-1"></a>artifact-1: bash`,
+		"````\nFilesystem Size Used Avail Use% Mounted on\n/dev/sda1 50G 48G 2G 97% /\n```\n````",
+		`### <a id="artifact-2"></a>artifact-2: read_file (failed)`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "## Executive Summary") {
+		t.Error("report without a summary should have no executive summary section")
+	}
+}
+
+func TestReportGenerator_EmptyResult(t *testing.T) {
+	result := &InvestigationResult{InvestigationID: "inv-1", AlertID: "alert-1", Status: "failed",
+		Error: errors.New("AI provider unavailable")}
+
+	report, err := NewReportGenerator().Generate(context.Background(), result, nil, false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"# Investigation Report: alert-1",
+		"Alert alert-1 was not recorded with the investigation.",
+		"## Error\n\nAI provider unavailable",
+		"## What Was Checked\n\nNo tools were run.",
+		"## Root Cause\n\nNot determined.",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "## Appendix") {
+		t.Error("report without artifacts should have no appendix")
+	}
+	if strings.Contains(report, "## Remediation") {
+		t.Error("report without remediation attempts should have no remediation section")
+	}
+}
+
+func TestReportGenerator_ExecutiveSummary(t *testing.T) {
+	result, alert := reportFixture(t)
+	aiProvider := newSubagentRunnerAIProviderMock()
+	aiProvider.sendMessageResponse = createSubagentAssistantMessage("Disk filled with uncompressed logs.")
+	generator := NewReportGenerator()
+	generator.SetSummaryProvider(aiProvider)
+	generator.SetModelRouter(NewModelRouter(aiProvider, map[ModelTask]string{ModelTaskSummarization: "haiku"}))
+
+	report, err := generator.Generate(context.Background(), result, alert, true)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if aiProvider.sendMessageCalls != 1 {
+		t.Fatalf("SendMessage() called %d times, want 1", aiProvider.sendMessageCalls)
+	}
+	if prompt := aiProvider.sendMessageMessages[0][0].Content; !strings.Contains(prompt, "logrotate stopped") {
+		t.Errorf("summary prompt should include the report, got %q", prompt)
+	}
+	if !strings.Contains(report, "## Executive Summary\n\nDisk filled with uncompressed logs.\n\n_Written by claude-3-5-haiku-20241022._") {
+		t.Errorf("report is missing the executive summary and its model:\n%s", report)
+	}
+	if model := aiProvider.sendMessageModels[0]; model != "claude-3-5-haiku-20241022" {
+		t.Errorf("summary request model = %q, want the routed haiku model", model)
+	}
+
+	if _, err := NewReportGenerator().Generate(context.Background(), result, alert, true); !errors.Is(err, ErrNoSummaryProvider) {
+		t.Errorf("summary without a provider error = %v, want ErrNoSummaryProvider", err)
+	}
+}
+
+func TestReportGenerator_CustomTemplate(t *testing.T) {
+	result, alert := reportFixture(t)
+	tmpl, err := ParseReportTemplate("report.md.tmpl",
+		`{{.Alert.Title}}: {{.Result.RootCause}}{{range .Artifacts}} [{{.ID}}]{{end}}`)
+	if err != nil {
+		t.Fatalf("ParseReportTemplate() error = %v", err)
+	}
+	generator := NewReportGenerator()
+	generator.SetTemplate(tmpl)
+
+	report, err := generator.Generate(context.Background(), result, alert, false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if want := "Disk almost full on web-1: logrotate stopped compressing old logs [artifact-1] [artifact-2]"; report != want {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+}
+
+// reportSourceMock serves one stored investigation and its events.
+type reportSourceMock struct {
+	record *mockInvestigationRecord
+	events []port.InvestigationEvent
+}
+
+func (s *reportSourceMock) Get(_ context.Context, id string) (InvestigationRecordData, error) {
+	if s.record == nil || s.record.id != id {
+		return nil, port.ErrInvestigationNotFound
+	}
+	return s.record, nil
+}
+
+func (s *reportSourceMock) Events(_ context.Context, _ string) ([]port.InvestigationEvent, error) {
+	return s.events, nil
+}
+
+func TestReportGenerator_ReportInvestigation(t *testing.T) {
+	result, alert := reportFixture(t)
+	source := &reportSourceMock{
+		record: &mockInvestigationRecord{
+			id: "inv-42", alertID: "alert-7", status: "completed",
+			findings: result.Findings, confidence: 0.85, alert: alert,
+			rootCause: result.RootCause, actions: result.RecommendedActions,
+		},
+		events: result.Timeline,
+	}
+	generator := NewReportGenerator()
+	generator.SetInvestigationSource(source)
+
+	report, err := generator.ReportInvestigation(context.Background(), "inv-42", false)
+	if err != nil {
+		t.Fatalf("ReportInvestigation() error = %v", err)
+	}
+	for _, want := range []string{"## Root Cause\n\nlogrotate stopped", "([output](#artifact-1))", `<a id="artifact-2"></a>`} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+
+	if _, err := generator.ReportInvestigation(context.Background(), "inv-missing", false); !errors.Is(err, port.ErrInvestigationNotFound) {
+		t.Errorf("unknown investigation error = %v, want ErrInvestigationNotFound", err)
+	}
+}
@@ -2,13 +2,19 @@
 package usecase
 
 import (
+	"cmp"
 	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/entity/jsonextract"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,20 +24,45 @@ const (
 	toolCompleteInvestigation = "complete_investigation"
 	toolEscalateInvestigation = "escalate_investigation"
 	toolBash                  = "bash"
+	toolWaitFor               = "wait_for"
 )
 
+// StatusStalled is the status of an investigation stopped because the model
+// kept answering with neither text nor tool calls.
+const StatusStalled = "stalled"
+
+// emptyResponseNudge is sent after a turn with neither text nor tool calls.
+const emptyResponseNudge = "Your last response was empty. Please continue or call " +
+	toolCompleteInvestigation + " with your findings."
+
+// maxDerivedConfidence caps the confidence estimated from tool results when
+// the AI reports none, so a derived value never reads as a confident one.
+const maxDerivedConfidence = 0.6
+
 // InvestigationRunner orchestrates AI-driven alert investigations.
 // It manages the conversation loop with an AI provider, executes tools,
 // and tracks investigation progress.
 type InvestigationRunner struct {
-	convService    ConversationServiceInterface
-	toolExecutor   port.ToolExecutor
-	safetyEnforcer SafetyEnforcer
-	promptBuilder  PromptBuilderRegistry
-	skillManager   port.SkillManager
-	store          InvestigationStoreWriter
-	uiAdapter      port.UserInterface
-	config         AlertInvestigationUseCaseConfig
+	convService     ConversationServiceInterface
+	toolExecutor    port.ToolExecutor
+	safetyEnforcer  SafetyEnforcer
+	promptBuilder   PromptBuilderRegistry
+	skillManager    port.SkillManager
+	store           InvestigationStoreWriter
+	uiAdapter       port.UserInterface
+	metrics         port.MetricsRecorder
+	progressSink    port.InvestigationProgressSink
+	changeTracker   WorkspaceChangeTracker
+	toolStats       ToolStatsSource
+	approver        ApprovalProvider
+	messageTemplate *AlertTemplate // Renders the message that starts each run; nil uses DefaultMessageTemplate
+	tracer          port.Tracer
+	logger          *slog.Logger
+	config          AlertInvestigationUseCaseConfig
+	progress        *investigationProgress // Where tool progress is published for status snapshots (optional)
+	slots           *runSemaphore          // Caps concurrent runs at MaxConcurrent; nil means no limit
+	pricing         Pricing                // Prices each turn's token usage (optional)
+	model           func() string          // Model the turns are priced as
 }
 
 // NewInvestigationRunner creates a new InvestigationRunner with the required dependencies.
@@ -73,7 +104,9 @@ func NewInvestigationRunner(
 		promptBuilder:  promptBuilder,
 		skillManager:   skillManager,
 		uiAdapter:      uiAdapter,
+		logger:         slog.Default(),
 		config:         config,
+		slots:          newRunSemaphore(config.MaxConcurrent),
 	}
 }
 
@@ -119,7 +152,9 @@ func NewInvestigationRunnerWithStore(
 		skillManager:   skillManager,
 		uiAdapter:      uiAdapter,
 		store:          store,
+		logger:         slog.Default(),
 		config:         config,
+		slots:          newRunSemaphore(config.MaxConcurrent),
 	}
 }
 
@@ -132,6 +167,29 @@ type runContext struct {
 	startTime       time.Time
 	actionsTaken    int
 	maxActions      int
+	iterations      int
+	toolErrors      int             // Executed tool calls that returned an error
+	errorStreak     []toolFailure   // Consecutive failed tool calls, reset on success
+	lastMessage     *entity.Message // Latest assistant message, for confidence parsing
+	logger          *slog.Logger    // Carries investigation_id and session_id
+	timeline        []port.InvestigationEvent
+	cost            float64           // Estimated spend of the AI turns so far, in US dollars
+	lastTurnCost    float64           // Estimated spend of the latest AI turn
+	unpriced        bool              // The model has no price, so turns cost nothing
+	usage           entity.TokenUsage // Tokens of the AI turns so far
+
+	commandAllowlist *safety.CommandAllowlist // Compiled AllowedCommandPatterns (nil = any command)
+	tools            investigationToolSet     // Tools the alert's investigation may use
+	schemaRetries    *schemaRetries           // Consecutive invalid inputs per tool
+	findings         []string                 // Findings the runner adds to the AI's, such as degraded model behavior
+	emptyResponses   int                      // Consecutive turns with neither text nor tool calls
+}
+
+// toolFailure is a tool call that returned an error.
+type toolFailure struct {
+	toolName string
+	message  string
+	blocked  bool // Refused by the allowed-tools list or safety enforcer rather than failing to run
 }
 
 // failedResult creates a failed investigation result.
@@ -147,68 +205,126 @@ func (rc *runContext) failedResult(err error) *InvestigationResult {
 }
 
 // executeToolCall executes a single tool call and returns the result.
-func (r *InvestigationRunner) executeToolCall(ctx context.Context, tc port.ToolCallInfo) entity.ToolResult {
-	// Check safety enforcer if configured
-	if err := r.checkToolSafety(tc); err != nil {
-		return entity.ToolResult{ToolID: tc.ToolID, Result: err.Error(), IsError: true}
+// blocked reports whether the safety enforcer refused the call, or the tool
+// was disabled after repeated invalid input.
+func (r *InvestigationRunner) executeToolCall(
+	rc *runContext,
+	tc port.ToolCallInfo,
+) (result entity.ToolResult, blocked bool) {
+	if rc.schemaRetries.isDisabled(tc.ToolName) {
+		return entity.ToolResult{ToolID: tc.ToolID, Result: disabledToolResult(tc.ToolName), IsError: true}, true
+	}
+
+	// Check the command allowlist and safety enforcer if configured
+	if err := r.checkToolSafety(rc, tc); err != nil {
+		return entity.ToolResult{ToolID: tc.ToolID, Result: err.Error(), IsError: true}, true
 	}
 
-	result, execErr := r.toolExecutor.ExecuteTool(ctx, tc.ToolName, tc.Input)
+	ctx := rc.ctx
+	var children *subagentUsage
+	if r.config.CountSubagentUsage {
+		children = &subagentUsage{}
+		ctx = withSubagentUsage(ctx, children)
+	}
+	output, execErr := r.toolExecutor.ExecuteTool(ctx, tc.ToolName, tc.Input)
+	if children != nil {
+		r.addSubagentUsage(rc, children.take())
+	}
+	if errors.Is(execErr, entity.ErrSchemaViolation) {
+		// Explain the schema so the model can correct the input; this still
+		// counts as an action, so retries cannot outlast MaxActions
+		return entity.ToolResult{ToolID: tc.ToolID, Result: r.schemaFailureResult(rc, tc, execErr), IsError: true}, false
+	}
+	rc.schemaRetries.succeeded(tc.ToolName)
 	if execErr != nil {
-		return entity.ToolResult{ToolID: tc.ToolID, Result: execErr.Error(), IsError: true}
+		return entity.ToolResult{ToolID: tc.ToolID, Result: execErr.Error(), IsError: true}, false
 	}
-	return entity.ToolResult{ToolID: tc.ToolID, Result: result, IsError: false}
+	return entity.ToolResult{ToolID: tc.ToolID, Result: output, IsError: false}, false
 }
 
-// checkToolSafety validates tool and command safety using the safety enforcer.
-// Returns nil if safe, or an error describing the block reason.
-func (r *InvestigationRunner) checkToolSafety(tc port.ToolCallInfo) error {
+// checkToolSafety validates tool and command safety using the run's command
+// allowlist and the safety enforcer.
+// Returns nil if safe, or an error wrapping ErrToolBlocked that describes the
+// block reason.
+func (r *InvestigationRunner) checkToolSafety(rc *runContext, tc port.ToolCallInfo) error {
+	if err := checkCommandAllowlist(rc.commandAllowlist, tc); err != nil {
+		return newToolBlockedError("Command blocked", err)
+	}
+
 	if r.safetyEnforcer == nil {
 		return nil
 	}
 
 	if err := r.safetyEnforcer.CheckToolAllowed(tc.ToolName); err != nil {
-		return errors.New("Tool blocked: " + err.Error())
+		return newToolBlockedError("Tool blocked", err)
 	}
 
-	// For bash tools, also check command safety
-	if tc.ToolName == toolBash {
-		if cmd := extractCommandFromInput(tc.Input); cmd != "" {
-			if err := r.safetyEnforcer.CheckCommandAllowed(cmd); err != nil {
-				return errors.New("Command blocked: " + err.Error())
-			}
+	// For tools that run commands, also check command safety
+	for _, cmd := range toolCallCommands(tc.ToolName, tc.Input) {
+		if err := r.safetyEnforcer.CheckCommandAllowed(cmd); err != nil {
+			return newToolBlockedError("Command blocked", err)
 		}
 	}
 
 	return nil
 }
 
-// extractCommandFromInput extracts the command string from bash tool input.
+// extractCommandFromInput extracts the command string from bash or wait_for
+// tool input, preceded by the environment variables set for it, so that
+// command checks see them too.
 func extractCommandFromInput(input map[string]interface{}) string {
 	if input == nil {
 		return ""
 	}
-	if cmd, ok := input["command"].(string); ok {
-		return cmd
+	cmd, ok := input["command"].(string)
+	if !ok {
+		return ""
 	}
-	return ""
+	vars, _ := input["env"].(map[string]interface{})
+	env := make(map[string]string, len(vars))
+	for name, value := range vars {
+		env[name] = fmt.Sprint(value)
+	}
+	return safety.CommandWithEnv(cmd, env)
 }
 
 // processToolCalls executes tool calls and feeds results back.
 func (r *InvestigationRunner) processToolCalls(rc *runContext, toolCalls []port.ToolCallInfo) error {
 	var toolResults []entity.ToolResult
 	for _, tc := range toolCalls {
-		if !r.isToolCallAllowed(tc) {
+		if !rc.tools.allows(tc.ToolName) {
 			// Blocked tools return error but DON'T count toward action limit
-			toolResults = append(toolResults, entity.ToolResult{
+			result := entity.ToolResult{
 				ToolID:  tc.ToolID,
 				Result:  fmt.Sprintf("tool '%s' is not allowed for this investigation", tc.ToolName),
 				IsError: true,
-			})
+			}
+			toolResults = append(toolResults, result)
+			rc.trackToolResult(tc, result, true)
 			continue
 		}
-		toolResults = append(toolResults, r.executeToolCall(rc.ctx, tc))
+		r.progress.toolStarted(tc.ToolName)
+		toolStart := time.Now()
+		result, blocked := r.executeToolCall(rc, tc)
+		toolResults = append(toolResults, result)
 		rc.actionsTaken++ // Only executed tools count
+		r.progress.toolFinished(rc.actionsTaken)
+		if result.IsError {
+			rc.toolErrors++
+		}
+		rc.trackToolResult(tc, result, blocked)
+
+		r.emitStep(rc, port.InvestigationEvent{
+			Type:            port.InvestigationEventToolExecuted,
+			InvestigationID: rc.investigationID,
+			ToolName:        tc.ToolName,
+			Summary:         summarizeToolResult(result.Result),
+			Input:           toolInputForEvent(tc.Input),
+			Output:          truncateToolOutput(result.Result),
+			IsError:         result.IsError,
+			Duration:        time.Since(toolStart),
+			Actions:         rc.actionsTaken,
+		})
 	}
 	if len(toolResults) > 0 {
 		return r.convService.AddToolResultMessage(rc.ctx, rc.sessionID, toolResults)
@@ -216,18 +332,68 @@ func (r *InvestigationRunner) processToolCalls(rc *runContext, toolCalls []port.
 	return nil
 }
 
+// trackToolResult extends the error streak with a failed tool call, or resets
+// it on success.
+func (rc *runContext) trackToolResult(tc port.ToolCallInfo, result entity.ToolResult, blocked bool) {
+	if !result.IsError {
+		rc.errorStreak = nil
+		return
+	}
+	rc.errorStreak = append(rc.errorStreak, toolFailure{
+		toolName: tc.ToolName,
+		message:  summarizeToolResult(result.Result),
+		blocked:  blocked,
+	})
+}
+
+// checkErrorStreak returns an escalated result once EscalateOnErrors
+// consecutive tool calls have failed, or nil. A threshold of 0 disables it.
+func (r *InvestigationRunner) checkErrorStreak(rc *runContext) *InvestigationResult {
+	if r.config.EscalateOnErrors <= 0 || len(rc.errorStreak) < r.config.EscalateOnErrors {
+		return nil
+	}
+	return &InvestigationResult{
+		InvestigationID: rc.investigationID,
+		AlertID:         rc.alert.ID(),
+		Status:          "escalated",
+		Escalated:       true,
+		EscalateReason:  errorStreakReason(rc.errorStreak),
+		ActionsTaken:    rc.actionsTaken,
+		Duration:        time.Since(rc.startTime),
+	}
+}
+
+// errorStreakReason describes an error streak, e.g.
+// "3 consecutive tool errors (2 failed, 1 blocked): bash failed: exit status 1; ...".
+func errorStreakReason(streak []toolFailure) string {
+	blocked := 0
+	details := make([]string, 0, len(streak))
+	for _, f := range streak {
+		outcome := "failed"
+		if f.blocked {
+			outcome = "blocked"
+			blocked++
+		}
+		details = append(details, fmt.Sprintf("%s %s: %s", f.toolName, outcome, f.message))
+	}
+	return fmt.Sprintf("%d consecutive tool errors (%d failed, %d blocked): %s",
+		len(streak), len(streak)-blocked, blocked, strings.Join(details, "; "))
+}
+
 // Run executes an investigation for the given alert.
 //
 // The investigation follows this flow:
-//  1. Validate inputs (alert, investigationID)
-//  2. Start a new conversation session
-//  3. Build investigation prompt using the prompt builder
-//  4. Send the prompt to the AI
-//  5. Process AI responses in a loop:
+//  1. Wait for one of the MaxConcurrent slots, failing as "rejected" with
+//     ErrTooManyInvestigations after MaxConcurrentWait
+//  2. Validate inputs (alert, investigationID)
+//  3. Start a new conversation session
+//  4. Build investigation prompt using the prompt builder
+//  5. Send the prompt to the AI
+//  6. Process AI responses in a loop:
 //     - If AI requests tools: execute allowed tools, feed results back
 //     - If AI completes: extract findings and return result
 //     - If budget/timeout exceeded: escalate
-//  6. Clean up conversation session
+//  7. Clean up conversation session
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout
@@ -241,6 +407,246 @@ func (r *InvestigationRunner) Run(
 	ctx context.Context,
 	alert *AlertForInvestigation,
 	investigationID string,
+) (*InvestigationResult, error) {
+	ctx, span := port.StartSpan(ctx, r.tracer, port.SpanInvestigation)
+	if span.IsRecording() {
+		span.SetAttributes(port.StringAttribute("investigation_id", investigationID))
+		if alert != nil {
+			span.SetAttributes(
+				port.StringAttribute("alert_id", alert.ID()),
+				port.StringAttribute("severity", alert.Severity()),
+			)
+		}
+	}
+
+	result, err := r.runInSlot(ctx, alert, investigationID)
+	if result != nil && result.ErrorKind == "" {
+		result.ErrorKind = ErrorKindOf(cmp.Or(result.Error, err))
+	}
+	r.emitOutcome(investigationID, result, err)
+
+	if result != nil {
+		if span.IsRecording() {
+			span.SetAttributes(
+				port.StringAttribute("status", result.Status),
+				port.IntAttribute("actions_taken", result.ActionsTaken),
+			)
+		}
+		if r.metrics != nil && alert != nil {
+			r.metrics.RecordInvestigation(result.Status, metricsSeverity(alert.Severity()), result.Duration)
+		}
+	}
+	port.EndSpan(span, err)
+	return result, err
+}
+
+// runInSlot runs the investigation in one of the MaxConcurrent slots, held
+// until the run returns or panics. A run that gets no slot is reported by
+// slotUnavailableResult.
+func (r *InvestigationRunner) runInSlot(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	investigationID string,
+) (*InvestigationResult, error) {
+	release, err := r.acquireSlot(ctx, investigationID)
+	if err != nil {
+		return r.slotUnavailableResult(investigationID, alert, err), err
+	}
+	defer release()
+	return r.run(ctx, alert, investigationID)
+}
+
+// acquireSlot waits for one of the MaxConcurrent slots, reporting the run as
+// waiting meanwhile, and returns the func releasing it. It gives up with an
+// error wrapping ErrTooManyInvestigations after MaxConcurrentWait, or with
+// ctx.Err() if ctx is done first.
+func (r *InvestigationRunner) acquireSlot(ctx context.Context, investigationID string) (func(), error) {
+	if release, ok := r.slots.tryAcquire(); ok {
+		return release, nil
+	}
+	r.logger.Info("Waiting for a free investigation slot",
+		"investigation_id", investigationID, "max_concurrent", r.slots.size())
+	r.progress.setWaiting(true)
+	defer r.progress.setWaiting(false)
+	return r.slots.acquire(ctx, r.config.MaxConcurrentWait)
+}
+
+// SetLogger sets the logger for investigation logs. Each run derives a logger
+// carrying investigation_id, alert_id, and session_id, and passes it to the tools
+// it calls through the context. A nil logger restores slog.Default().
+func (r *InvestigationRunner) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r.logger = logger
+}
+
+// SetTracer sets the tracer for investigation spans. Tool executions and AI
+// requests made during the investigation nest under the investigation span.
+// Without a tracer, no spans are created.
+func (r *InvestigationRunner) SetTracer(tracer port.Tracer) {
+	r.tracer = tracer
+}
+
+// SetMetricsRecorder sets the recorder for finished investigations.
+// Without a recorder, no metrics are recorded.
+func (r *InvestigationRunner) SetMetricsRecorder(recorder port.MetricsRecorder) {
+	r.metrics = recorder
+}
+
+// SetProgressSink sets the sink that receives investigation progress events.
+// Every run that has an investigation ID ends with exactly one terminal event.
+func (r *InvestigationRunner) SetProgressSink(sink port.InvestigationProgressSink) {
+	r.progressSink = sink
+}
+
+// SetChangeTracker sets the tracker that reports the files each run's tools
+// changed, as InvestigationResult.ModifiedFiles. Without one, ModifiedFiles is
+// left empty.
+func (r *InvestigationRunner) SetChangeTracker(tracker WorkspaceChangeTracker) {
+	r.changeTracker = tracker
+}
+
+// SetToolStats sets the source of each run's per-tool usage, reported as
+// InvestigationResult.ToolStats. Without one, ToolStats is left empty.
+func (r *InvestigationRunner) SetToolStats(stats ToolStatsSource) {
+	r.toolStats = stats
+}
+
+// SetApprovalProvider sets the provider asked to approve each low-risk
+// remediation command when AutoRemediate is set. Without one, no remediation
+// is attempted.
+func (r *InvestigationRunner) SetApprovalProvider(approver ApprovalProvider) {
+	r.approver = approver
+}
+
+// SetMessageTemplate sets the template of the user message that starts each
+// run. A nil template restores DefaultMessageTemplate.
+func (r *InvestigationRunner) SetMessageTemplate(tmpl *AlertTemplate) {
+	r.messageTemplate = tmpl
+}
+
+// SetPricing sets the prices each AI turn's token usage is charged at, as
+// the model model returns, so InvestigationResult.Cost is estimated and
+// MaxCost enforced. Without pricing, turns cost nothing.
+func (r *InvestigationRunner) SetPricing(pricing Pricing, model func() string) {
+	r.pricing = pricing
+	r.model = model
+}
+
+// emit reports a progress event if a progress sink is set.
+func (r *InvestigationRunner) emit(event port.InvestigationEvent) {
+	if r.progressSink != nil && event.InvestigationID != "" {
+		r.progressSink.OnInvestigationEvent(event)
+	}
+}
+
+// emitStep reports a progress event of a running investigation and adds it
+// to the run's timeline.
+func (r *InvestigationRunner) emitStep(rc *runContext, event port.InvestigationEvent) {
+	r.emit(event)
+	event.Time = time.Now()
+	rc.timeline = append(rc.timeline, event)
+}
+
+// emitOutcome reports the findings of a finished run followed by its terminal
+// event. A nil result, as from a cancelled run, is reported as failed.
+func (r *InvestigationRunner) emitOutcome(investigationID string, result *InvestigationResult, err error) {
+	terminal := port.InvestigationEvent{
+		Type:            port.InvestigationEventFailed,
+		InvestigationID: investigationID,
+	}
+	if err != nil {
+		terminal.Reason = err.Error()
+	}
+	if result != nil {
+		for _, finding := range result.Findings {
+			r.emit(port.InvestigationEvent{
+				Type:            port.InvestigationEventFindingAdded,
+				InvestigationID: investigationID,
+				Finding:         finding,
+				Actions:         result.ActionsTaken,
+			})
+		}
+		terminal.Duration = result.Duration
+		terminal.Actions = result.ActionsTaken
+		switch {
+		case result.Escalated:
+			terminal.Type = port.InvestigationEventEscalated
+			terminal.Reason = result.EscalateReason
+		case result.Status == "completed":
+			terminal.Type = port.InvestigationEventCompleted
+		case result.Error != nil && terminal.Reason == "":
+			terminal.Reason = result.Error.Error()
+		}
+	}
+	r.emit(terminal)
+}
+
+// toolSummaryMaxLen limits the tool result summary carried by progress events.
+const toolSummaryMaxLen = 200
+
+// summarizeToolResult returns the first non-blank line of a tool result,
+// truncated to toolSummaryMaxLen bytes.
+func summarizeToolResult(result string) string {
+	summary := strings.TrimSpace(result)
+	if i := strings.IndexByte(summary, '\n'); i >= 0 {
+		summary = strings.TrimSpace(summary[:i])
+	}
+	if len(summary) > toolSummaryMaxLen {
+		summary = summary[:toolSummaryMaxLen] + "..."
+	}
+	return summary
+}
+
+// Limits on the tool input and result carried by progress events, which
+// reports show as artifacts.
+const (
+	toolInputMaxLen  = 1024
+	toolOutputMaxLen = 4096
+)
+
+// toolInputForEvent returns a tool call's input as JSON, truncated to
+// toolInputMaxLen bytes.
+func toolInputForEvent(input map[string]interface{}) string {
+	if len(input) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+	if len(data) > toolInputMaxLen {
+		return string(data[:toolInputMaxLen]) + "..."
+	}
+	return string(data)
+}
+
+// truncateToolOutput truncates a tool result to toolOutputMaxLen bytes,
+// noting how much was cut.
+func truncateToolOutput(result string) string {
+	if len(result) <= toolOutputMaxLen {
+		return result
+	}
+	return fmt.Sprintf("%s\n... (truncated, %d bytes total)", result[:toolOutputMaxLen], len(result))
+}
+
+// metricsSeverity maps an alert severity to a metric label value, folding
+// anything outside the known severities into "other" to keep the label bounded.
+func metricsSeverity(severity string) string {
+	switch severity {
+	case entity.SeverityCritical, entity.SeverityWarning, entity.SeverityInfo:
+		return severity
+	default:
+		return "other"
+	}
+}
+
+// run performs the investigation for Run.
+func (r *InvestigationRunner) run(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	investigationID string,
 ) (*InvestigationResult, error) {
 	if err := r.validateInputs(ctx, alert, investigationID); err != nil {
 		return r.validationFailedResult(investigationID, alert, err), err
@@ -252,17 +658,48 @@ func (r *InvestigationRunner) Run(
 		investigationID: investigationID,
 		startTime:       time.Now(),
 		maxActions:      r.config.MaxActions,
+		logger:          r.logger.With("investigation_id", investigationID, "alert_id", alert.ID()),
 	}
 	if rc.maxActions == 0 {
 		rc.maxActions = 50
 	}
+	allowlist, err := safety.NewCommandAllowlist(r.config.AllowedCommandPatterns)
+	if err != nil {
+		return rc.failedResult(err), err
+	}
+	rc.commandAllowlist = allowlist
+	rc.tools = r.resolveTools(alert, rc.logger)
+	rc.schemaRetries = newSchemaRetries(r.config.MaxSchemaRetries)
 
 	sessionID, err := r.convService.StartConversation(ctx)
 	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrConversationStart, err)
 		return rc.failedResult(err), err
 	}
 	rc.sessionID = sessionID
+	rc.logger = rc.logger.With("session_id", sessionID)
+	// The session ID scopes cached tool results to this investigation
+	// No one answers interactive tools such as ask_user during an investigation
+	rc.ctx = port.WithHeadless(port.WithSessionID(port.WithLogger(ctx, rc.logger), sessionID))
+	if generatorURL := alert.GeneratorURL(); generatorURL != "" {
+		rc.ctx = port.WithAlertGeneratorURL(rc.ctx, generatorURL)
+	}
+	if r.config.MaxDuration > 0 {
+		// Lets a rate-limited AI provider fail fast instead of waiting past MaxDuration
+		rc.ctx = port.WithRunDeadline(rc.ctx, rc.startTime.Add(r.config.MaxDuration))
+	}
+	if r.config.BlockedCommands != nil {
+		// The tool executor blocks this run's commands, which stay as they were
+		// when it started even if the configuration is reloaded
+		rc.ctx = port.WithBlockedCommands(rc.ctx, r.config.BlockedCommands)
+	}
 	defer func() { _ = r.convService.EndConversation(ctx, sessionID) }()
+	if r.changeTracker != nil {
+		defer r.changeTracker.Forget(sessionID)
+	}
+	if r.toolStats != nil {
+		defer r.toolStats.ResetToolStats(sessionID)
+	}
 
 	// Configure extended thinking mode if enabled
 	if r.config.ExtendedThinking {
@@ -283,6 +720,24 @@ func (r *InvestigationRunner) Run(
 	}
 
 	result, err := r.runInvestigationLoop(rc)
+	if result != nil && result.Status == entity.InvestigationStatusCompleted {
+		result.Remediations = r.remediate(rc, result.RecommendedActions)
+	}
+	if result != nil {
+		result.Cost = rc.cost
+		result.Usage = rc.usage
+		result.Findings = append(result.Findings, rc.findings...)
+		// Long runs repeat themselves; report each finding once, tagged with its severity
+		result.Findings = FindingStrings(DeduplicateFindings(result.Findings))
+		result.Timeline = rc.timeline
+		result.Artifacts = ArtifactsFromTimeline(rc.timeline)
+		if r.changeTracker != nil {
+			result.ModifiedFiles = r.changeTracker.ModifiedFiles(rc.sessionID)
+		}
+		if r.toolStats != nil {
+			result.ToolStats = r.toolStats.GetToolStats(rc.sessionID)
+		}
+	}
 
 	// Persist result to store if configured
 	if r.store != nil && result != nil {
@@ -299,14 +754,19 @@ func (r *InvestigationRunner) Run(
 			confidence:     result.Confidence,
 			escalated:      result.Escalated,
 			escalateReason: result.EscalateReason,
+			alert:          alert.toEntity(),
+			rootCause:      result.RootCause,
+			actions:        result.RecommendedActions,
+			remediations:   result.Remediations,
+			cost:           result.Cost,
+			usage:          result.Usage,
+		}
+		if result.Error != nil {
+			stub.errorMessage = result.Error.Error()
+			stub.errorKind = ErrorKindOf(result.Error)
 		}
 		if err := r.store.Store(ctx, stub); err != nil {
-			fmt.Fprintf(
-				os.Stderr,
-				"[InvestigationRunner] Failed to store result for %s: %v\n",
-				result.InvestigationID,
-				err,
-			)
+			rc.logger.Error("Failed to store investigation result", "error", err)
 		}
 	}
 
@@ -324,6 +784,14 @@ type investigationRecordForStore struct {
 	confidence                     float64
 	escalated                      bool
 	escalateReason                 string
+	errorMessage                   string
+	errorKind                      string
+	alert                          *entity.Alert
+	rootCause                      string
+	actions                        []entity.RecommendedAction
+	remediations                   []entity.RemediationAttempt
+	cost                           float64
+	usage                          entity.TokenUsage
 }
 
 func (s *investigationRecordForStore) ID() string        { return s.id }
@@ -343,13 +811,26 @@ func (s *investigationRecordForStore) Duration() time.Duration { return time.Dur
 func (s *investigationRecordForStore) Confidence() float64     { return s.confidence }
 func (s *investigationRecordForStore) Escalated() bool         { return s.escalated }
 func (s *investigationRecordForStore) EscalateReason() string  { return s.escalateReason }
+func (s *investigationRecordForStore) ErrorMessage() string    { return s.errorMessage }
+func (s *investigationRecordForStore) ErrorKind() string       { return s.errorKind }
+func (s *investigationRecordForStore) Alert() *entity.Alert    { return s.alert }
+func (s *investigationRecordForStore) RootCause() string       { return s.rootCause }
+func (s *investigationRecordForStore) RecommendedActions() []entity.RecommendedAction {
+	return s.actions
+}
+func (s *investigationRecordForStore) Remediations() []entity.RemediationAttempt {
+	return s.remediations
+}
+func (s *investigationRecordForStore) Cost() float64            { return s.cost }
+func (s *investigationRecordForStore) Usage() entity.TokenUsage { return s.usage }
+func (s *investigationRecordForStore) Version() string          { return "" }
 
 func (r *InvestigationRunner) validateInputs(ctx context.Context, alert *AlertForInvestigation, invID string) error {
 	if alert == nil {
-		return errors.New("nil alert")
+		return fmt.Errorf("%w: nil alert", ErrInvalidAlert)
 	}
 	if alert.ID() == "" {
-		return errors.New("empty alert ID")
+		return fmt.Errorf("%w: empty alert ID", ErrInvalidAlert)
 	}
 	if strings.TrimSpace(invID) == "" {
 		return errors.New("empty investigation ID")
@@ -369,43 +850,41 @@ func (r *InvestigationRunner) validationFailedResult(
 	return &InvestigationResult{InvestigationID: invID, AlertID: alertID, Status: "failed", Error: err}
 }
 
-func (r *InvestigationRunner) sendInitialPrompt(rc *runContext) error {
-	// Create alert view for prompt building
-	alertView := r.createAlertView(rc.alert)
-
-	// Get available tools for this investigation
-	tools, err := r.getInvestigationTools()
-	if err != nil {
-		return err
-	}
-
-	// Get available skills if skill manager is configured
-	var skills []port.SkillInfo
-	if r.skillManager != nil {
-		result, err := r.skillManager.DiscoverSkills(rc.ctx)
-		if err == nil && result != nil {
-			skills = result.Skills
-		}
-		// Silently ignore skill discovery errors - skills are optional
+// slotUnavailableResult is the result of a run that never started: "rejected"
+// when every slot stayed busy for MaxConcurrentWait, or "cancelled" when ctx
+// ended the wait.
+func (r *InvestigationRunner) slotUnavailableResult(
+	invID string,
+	alert *AlertForInvestigation,
+	err error,
+) *InvestigationResult {
+	result := r.validationFailedResult(invID, alert, err)
+	result.Status = "cancelled"
+	if errors.Is(err, ErrTooManyInvestigations) {
+		result.Status = "rejected"
 	}
+	return result
+}
 
-	// Build investigation prompt with full context and instructions
-	prompt, err := r.promptBuilder.BuildPromptForAlert(alertView, tools, skills)
+func (r *InvestigationRunner) sendInitialPrompt(rc *runContext) error {
+	prompt, err := r.buildPrompt(rc.ctx, rc.alert, rc.tools)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrPromptBuild, err)
 	}
 
 	// Set the full investigation prompt as a custom system prompt.
 	// This keeps the detailed instructions, tool descriptions, and guidelines
 	// in the system context rather than cluttering the conversation history.
-	if err := r.convService.SetCustomSystemPrompt(rc.ctx, rc.sessionID, prompt); err != nil {
+	// It is the only layer: it carries its own persona, tools, and skills.
+	composed := NewSystemPromptComposer(InvestigationPromptLayer(prompt)).Compose()
+	if err := r.convService.SetCustomSystemPrompt(rc.ctx, rc.sessionID, composed.Text); err != nil {
 		return err
 	}
 
 	// Send a minimal user message to trigger the investigation.
 	// Since the system prompt already contains all context, we only need
 	// basic alert identifiers here to start the conversation.
-	userMessage := r.formatTriggerMessage(rc.alert)
+	userMessage := r.formatTriggerMessage(rc)
 	if _, err := r.convService.AddUserMessage(rc.ctx, rc.sessionID, userMessage); err != nil {
 		return err
 	}
@@ -413,50 +892,75 @@ func (r *InvestigationRunner) sendInitialPrompt(rc *runContext) error {
 	return nil
 }
 
+// buildPrompt builds the investigation prompt for an alert with the tools of
+// toolSet and the skills available to the investigation.
+func (r *InvestigationRunner) buildPrompt(
+	ctx context.Context,
+	alert *AlertForInvestigation,
+	toolSet investigationToolSet,
+) (string, error) {
+	if r.promptBuilder == nil {
+		return "", errors.New("prompt builder registry not configured")
+	}
+
+	// Create alert view for prompt building
+	alertView := r.createAlertView(alert)
+
+	// Get available tools for this investigation
+	tools, err := r.getInvestigationTools(toolSet)
+	if err != nil {
+		return "", err
+	}
+
+	// Get available skills if skill manager is configured
+	var skills []port.SkillInfo
+	if r.skillManager != nil {
+		result, err := r.skillManager.DiscoverSkills(ctx)
+		if err == nil && result != nil {
+			skills = result.Skills
+		}
+		// Silently ignore skill discovery errors - skills are optional
+	}
+
+	// Build investigation prompt with full context and instructions
+	return r.promptBuilder.BuildPromptForAlert(ctx, alertView, tools, skills)
+}
+
 // createAlertView converts an AlertForInvestigation into an AlertView for prompt building.
 func (r *InvestigationRunner) createAlertView(alert *AlertForInvestigation) *AlertView {
-	return &AlertView{
-		id:          alert.ID(),
-		source:      alert.Source(),
-		severity:    alert.Severity(),
-		title:       alert.Title(),
-		description: alert.Description(),
-		labels:      alert.Labels(),
-	}
+	return NewAlertViewFromInvestigation(alert)
 }
 
-// formatTriggerMessage creates a minimal user message to trigger the investigation.
-// This message contains only the essential alert identifiers since the full context
-// is already provided in the system prompt.
-func (r *InvestigationRunner) formatTriggerMessage(alert *AlertForInvestigation) string {
-	return fmt.Sprintf("Alert ID: %s\nTitle: %s", alert.ID(), alert.Title())
+// formatTriggerMessage creates the user message that triggers the
+// investigation from the message template, by default only the essential
+// alert identifiers since the full context is already provided in the system
+// prompt. A template that fails to render is logged and the default used.
+func (r *InvestigationRunner) formatTriggerMessage(rc *runContext) string {
+	message, err := renderAlertTemplate(r.messageTemplate, defaultMessageTemplate, r.createAlertView(rc.alert))
+	if err != nil {
+		rc.logger.Warn("Message template failed; using the default message", "error", err)
+	}
+	return message
 }
 
 // getInvestigationTools returns the filtered list of tools for investigation prompts.
-// It filters based on the AllowedTools configuration.
-func (r *InvestigationRunner) getInvestigationTools() ([]entity.Tool, error) {
+// It keeps the tools of toolSet, so the prompt lists exactly the tools the
+// investigation may call, and leaves out interactive tools, which no one is
+// there to answer. Tools are sorted by name so the same alert always gets the
+// same prompt.
+func (r *InvestigationRunner) getInvestigationTools(toolSet investigationToolSet) ([]entity.Tool, error) {
 	allTools, err := r.toolExecutor.ListTools()
 	if err != nil {
 		return nil, err
 	}
 
-	// If no allowed tools configured, return all tools
-	if len(r.config.AllowedTools) == 0 {
-		return allTools, nil
-	}
-
-	// Filter to only allowed tools
-	allowedSet := make(map[string]bool, len(r.config.AllowedTools))
-	for _, t := range r.config.AllowedTools {
-		allowedSet[t] = true
-	}
-
 	filtered := make([]entity.Tool, 0, len(allTools))
 	for _, tool := range allTools {
-		if allowedSet[tool.Name] {
+		if !tool.Interactive && toolSet.allows(tool.Name) {
 			filtered = append(filtered, tool)
 		}
 	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Name < filtered[j].Name })
 	return filtered, nil
 }
 
@@ -484,7 +988,19 @@ func separateToolCalls(toolCalls []port.ToolCallInfo) separatedToolCalls {
 }
 
 // extractStringSlice extracts a []string from a []interface{} in tool input.
+// A string is read as the array written out as JSON, as models sometimes
+// send it, or else as the only item.
 func extractStringSlice(input map[string]interface{}, key string) []string {
+	if text, ok := input[key].(string); ok {
+		var items []string
+		if err := jsonextract.Unmarshal(text, &items); err == nil {
+			return items
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			return []string{text}
+		}
+		return nil
+	}
 	items, ok := input[key].([]interface{})
 	if !ok {
 		return nil
@@ -507,10 +1023,9 @@ func (rc *runContext) buildCompletionResult(input map[string]interface{}) *Inves
 		ActionsTaken:    rc.actionsTaken,
 		Duration:        time.Since(rc.startTime),
 	}
-	if confidence, ok := input["confidence"].(float64); ok {
-		result.Confidence = confidence
-	}
 	result.Findings = extractStringSlice(input, "findings")
+	result.RootCause, _ = input["root_cause"].(string)
+	result.RecommendedActions = extractRecommendedActions(input, "recommended_actions")
 	return result
 }
 
@@ -539,56 +1054,192 @@ func (r *InvestigationRunner) checkSafetyTimeout(rc *runContext) error {
 	return r.safetyEnforcer.CheckTimeout(rc.ctx)
 }
 
-// checkSafetyBudget checks if the safety enforcer reports budget exhaustion.
+// checkSafetyBudget checks if the safety enforcer reports budget exhaustion,
+// returning an error wrapping ErrActionBudgetExceeded if it does.
 func (r *InvestigationRunner) checkSafetyBudget(rc *runContext) error {
 	if r.safetyEnforcer == nil {
 		return nil
 	}
-	return r.safetyEnforcer.CheckActionBudget(rc.actionsTaken)
+	if err := r.safetyEnforcer.CheckActionBudget(rc.actionsTaken); err != nil {
+		return fmt.Errorf("%w: %w", ErrActionBudgetExceeded, err)
+	}
+	return nil
 }
 
-// checkConfidenceEscalation checks if the AI's confidence is below the escalation threshold.
-// Returns an escalation result if confidence is low, nil otherwise.
-func (r *InvestigationRunner) checkConfidenceEscalation(rc *runContext, msg *entity.Message) *InvestigationResult {
-	if r.config.EscalateOnConfidence <= 0 || msg == nil {
-		return nil
+// resolveConfidence sets a completed result's confidence and escalates it when
+// the confidence is below the EscalateOnConfidence threshold. The confidence
+// comes from, in order of preference:
+//  1. the complete_investigation input, when it has a parseable confidence
+//  2. a "confidence: X" statement in the final assistant message
+//  3. the fraction of executed tool calls that succeeded, capped at
+//     maxDerivedConfidence and marked ConfidenceDerived
+//
+// A derived confidence is capped so it never passes for a reported one, so
+// escalation judges it by the uncapped success rate instead. Investigations
+// that executed no tools have no basis for a derived value and are not
+// escalated on confidence.
+func (r *InvestigationRunner) resolveConfidence(
+	rc *runContext,
+	result *InvestigationResult,
+	input map[string]interface{},
+) *InvestigationResult {
+	confidence, ok := parseConfidenceValue(input["confidence"])
+	if !ok && rc.lastMessage != nil {
+		confidence = parseConfidenceFromMessage(rc.lastMessage.Content)
+		ok = confidence >= 0
 	}
 
-	confidence := parseConfidenceFromMessage(msg.Content)
-	if confidence >= 0 && confidence < r.config.EscalateOnConfidence {
-		result := rc.completedResult()
-		result.Escalated = true
+	escalateOn := confidence
+	if ok {
 		result.Confidence = confidence
+	} else {
+		result.ConfidenceDerived = true
+		if rc.actionsTaken == 0 {
+			return result
+		}
+		escalateOn = float64(rc.actionsTaken-rc.toolErrors) / float64(rc.actionsTaken)
+		result.Confidence = math.Min(escalateOn, maxDerivedConfidence)
+	}
+
+	if escalateOn < r.config.EscalateOnConfidence {
+		result.Escalated = true
 		result.EscalateReason = "confidence below threshold"
-		return result
 	}
-	return nil
+	return result
+}
+
+// parseConfidenceValue parses a confidence from complete_investigation input.
+// It accepts numbers and numeric strings, including percentages like "85%",
+// and clamps the value to [0, 1]. Returns false if v is missing or malformed.
+func parseConfidenceValue(v interface{}) (float64, bool) {
+	var confidence float64
+	switch v := v.(type) {
+	case float64:
+		confidence = v
+	case int:
+		confidence = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		confidence = f
+	case string:
+		return parseConfidenceString(v)
+	default:
+		return 0, false
+	}
+	if math.IsNaN(confidence) || math.IsInf(confidence, 0) {
+		return 0, false
+	}
+	return clampConfidence(confidence), true
+}
+
+// parseConfidenceString parses a confidence like "0.85" or "85%", clamped to [0, 1].
+func parseConfidenceString(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	if trimmed, ok := strings.CutSuffix(s, "%"); ok {
+		s = strings.TrimSpace(trimmed)
+		scale = 100
+	}
+	confidence, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(confidence) || math.IsInf(confidence, 0) {
+		return 0, false
+	}
+	return clampConfidence(confidence / scale), true
+}
+
+// clampConfidence limits a confidence to [0, 1].
+func clampConfidence(confidence float64) float64 {
+	return math.Max(0, math.Min(1, confidence))
 }
 
 // parseConfidenceFromMessage extracts a confidence value from message text.
-// Looks for patterns like "Confidence: 0.5" or "confidence: 0.5".
+// Looks for patterns like "Confidence: 0.5" or "confidence: 85%", using the
+// first one with a parseable value, clamped to [0, 1].
 // Returns -1 if no confidence found.
 func parseConfidenceFromMessage(content string) float64 {
-	// Look for "Confidence: X.X" pattern (case-insensitive)
+	const marker = "confidence:"
 	lower := strings.ToLower(content)
-	idx := strings.Index(lower, "confidence:")
-	if idx == -1 {
-		return -1
+	for {
+		idx := strings.Index(lower, marker)
+		if idx == -1 {
+			return -1
+		}
+		lower = lower[idx+len(marker):]
+
+		// The value is the next word, minus trailing punctuation ("0.8.")
+		fields := strings.Fields(lower)
+		if len(fields) > 0 {
+			if confidence, ok := parseConfidenceString(strings.TrimRight(fields[0], ".,;:)")); ok {
+				return confidence
+			}
+		}
 	}
+}
 
-	// Extract the number after "confidence:"
-	remaining := strings.TrimSpace(content[idx+len("confidence:"):])
-	var confidence float64
-	_, err := fmt.Sscanf(remaining, "%f", &confidence)
-	if err != nil {
-		return -1
+// addTurnCost adds an AI turn's token usage, and its estimated cost, to the run.
+func (r *InvestigationRunner) addTurnCost(rc *runContext, msg *entity.Message) {
+	if msg.Usage == nil {
+		return
 	}
+	rc.usage.InputTokens += msg.Usage.InputTokens
+	rc.usage.OutputTokens += msg.Usage.OutputTokens
+	if r.pricing == nil || r.model == nil {
+		return
+	}
+	model := r.model()
+	cost, ok := r.pricing.Cost(model, *msg.Usage)
+	if !ok {
+		if !rc.unpriced {
+			rc.unpriced = true
+			rc.logger.Warn("Model has no price; investigation cost is not tracked", "model", model)
+		}
+		return
+	}
+	rc.cost += cost
+	rc.lastTurnCost = cost
+}
 
-	// Validate range
-	if confidence < 0 || confidence > 1 {
-		return -1
+// addSubagentUsage counts the actions, tokens, and cost of subagents a tool
+// call spawned toward the investigation's own, so delegating work does not
+// get around MaxActions or MaxCost. A subagent on a model without a price
+// costs nothing.
+func (r *InvestigationRunner) addSubagentUsage(rc *runContext, results []*SubagentResult) {
+	for _, result := range results {
+		rc.actionsTaken += result.ActionsTaken
+		rc.usage.InputTokens += result.TokensUsed.InputTokens
+		rc.usage.OutputTokens += result.TokensUsed.OutputTokens
+		if r.pricing != nil {
+			if cost, ok := r.pricing.Cost(result.Model, result.TokensUsed); ok {
+				rc.cost += cost
+			}
+		}
+		rc.logger.Debug("Counted subagent usage", "subagent_id", result.SubagentID,
+			"actions_taken", result.ActionsTaken, "input_tokens", result.TokensUsed.InputTokens,
+			"output_tokens", result.TokensUsed.OutputTokens)
+	}
+}
+
+// checkCostBudget returns a budget_exceeded result when another turn,
+// expected to cost as much as the latest one, would take the run past
+// MaxCost. It returns nil without a MaxCost or before the first turn.
+func (r *InvestigationRunner) checkCostBudget(rc *runContext) *InvestigationResult {
+	maxCost := r.config.MaxCost
+	if maxCost <= 0 || rc.lastTurnCost == 0 || rc.cost+rc.lastTurnCost <= maxCost {
+		return nil
+	}
+	return &InvestigationResult{
+		InvestigationID: rc.investigationID,
+		AlertID:         rc.alert.ID(),
+		Status:          StatusBudgetExceeded,
+		ActionsTaken:    rc.actionsTaken,
+		Duration:        time.Since(rc.startTime),
+		Escalated:       true,
+		EscalateReason: fmt.Sprintf("cost budget exceeded: spent %s of %s, and another turn would cost about %s",
+			FormatCost(rc.cost), FormatCost(maxCost), FormatCost(rc.lastTurnCost)),
 	}
-	return confidence
 }
 
 // escalatedResult creates a failed result with escalation info.
@@ -609,17 +1260,47 @@ func (r *InvestigationRunner) runInvestigationLoop(rc *runContext) (*Investigati
 			return rc.escalatedResult(err, "timeout: "+err.Error()), err
 		}
 
+		if result := r.checkCostBudget(rc); result != nil {
+			rc.logger.Warn("Stopping investigation at its cost budget",
+				"cost", rc.cost, "last_turn_cost", rc.lastTurnCost, "max_cost", r.config.MaxCost)
+			return result, nil
+		}
+
+		rc.iterations++
+		r.emitStep(rc, port.InvestigationEvent{
+			Type:            port.InvestigationEventIterationStarted,
+			InvestigationID: rc.investigationID,
+			Iteration:       rc.iterations,
+			Actions:         rc.actionsTaken,
+		})
 		msg, toolCalls, err := r.getNextToolCalls(rc)
+		if msg != nil {
+			rc.lastMessage = msg
+			r.addTurnCost(rc, msg)
+		}
 		if err != nil {
+			err = providerError(rc.ctx, err)
+			// Waiting for the local rate limit would overrun MaxDuration
+			var rateErr *port.RateLimitError
+			if errors.As(err, &rateErr) {
+				return rc.escalatedResult(err, err.Error()), err
+			}
 			return rc.failedResult(err), err
 		}
 
 		if len(toolCalls) == 0 {
+			if isEmptyResponse(msg) {
+				if result := r.handleEmptyResponse(rc); result != nil {
+					return result, nil
+				}
+				continue
+			}
 			return r.handleNoToolCalls(rc, msg)
 		}
+		rc.emptyResponses = 0
 
 		if err := r.checkSafetyBudget(rc); err != nil {
-			return rc.escalatedResult(err, "action budget exceeded: "+err.Error()), err
+			return rc.escalatedResult(err, err.Error()), err
 		}
 
 		result, done, err := r.processLoopIteration(rc, toolCalls)
@@ -631,26 +1312,18 @@ func (r *InvestigationRunner) runInvestigationLoop(rc *runContext) (*Investigati
 
 		if rc.actionsTaken >= rc.maxActions {
 			if err := r.handleMaxActionsReached(rc); err != nil {
-				fmt.Fprintf(os.Stderr, "[InvestigationRunner] Error handling max actions: %v\n", err)
+				rc.logger.Error("Failed to handle max actions", "error", err)
 			}
 			break
 		}
 	}
-	fmt.Fprintf(
-		os.Stderr,
-		"[InvestigationRunner] Investigation loop ended naturally (no complete_investigation call). Using default completedResult.\n",
-	)
-	return rc.completedResult(), nil
+	rc.logger.Info("Investigation loop ended without complete_investigation; using default completed result")
+	return r.finalMessageResult(rc), nil
 }
 
 // handleNoToolCalls handles the case where AI responds without requesting any tools.
-// Returns the appropriate investigation result based on confidence checks.
+// Returns a completed result, escalated if its confidence is low.
 func (r *InvestigationRunner) handleNoToolCalls(rc *runContext, msg *entity.Message) (*InvestigationResult, error) {
-	// Check for low confidence escalation before completing
-	if result := r.checkConfidenceEscalation(rc, msg); result != nil {
-		return result, nil
-	}
-
 	// AI responded without tool calls - log the message
 	msgContent := ""
 	if msg != nil {
@@ -659,14 +1332,63 @@ func (r *InvestigationRunner) handleNoToolCalls(rc *runContext, msg *entity.Mess
 			msgContent = msgContent[:200] + "..."
 		}
 	}
-	fmt.Fprintf(os.Stderr, "[InvestigationRunner] AI responded without tool calls. Message: %s\n", msgContent)
+	rc.logger.Info("AI responded without tool calls", "message", msgContent)
 
 	// End loop naturally and return completed result
-	fmt.Fprintf(
-		os.Stderr,
-		"[InvestigationRunner] Investigation loop ended naturally (no complete_investigation call). Using default completedResult.\n",
-	)
-	return rc.completedResult(), nil
+	rc.logger.Info("Investigation loop ended without complete_investigation; using default completed result")
+	return r.finalMessageResult(rc), nil
+}
+
+// finalMessageResult returns the completed result of an investigation that
+// ended without calling complete_investigation. Findings the final message
+// writes out as a JSON object are taken as if passed to complete_investigation,
+// along with its root cause, recommended actions, and confidence.
+func (r *InvestigationRunner) finalMessageResult(rc *runContext) *InvestigationResult {
+	if rc.lastMessage == nil {
+		return r.resolveConfidence(rc, rc.completedResult(), nil)
+	}
+	var input map[string]interface{}
+	if err := jsonextract.Unmarshal(rc.lastMessage.Content, &input); err != nil {
+		rc.logger.Debug("No findings in the final message", "error", err)
+		return r.resolveConfidence(rc, rc.completedResult(), nil)
+	}
+	if _, ok := input["findings"]; !ok {
+		return r.resolveConfidence(rc, rc.completedResult(), nil)
+	}
+	rc.logger.Info("Using the findings written in the final message")
+	return r.resolveConfidence(rc, rc.buildCompletionResult(input), input)
+}
+
+// isEmptyResponse reports whether an AI turn without tool calls also has no
+// text; thinking alone does not count.
+func isEmptyResponse(msg *entity.Message) bool {
+	return msg == nil || strings.TrimSpace(msg.Content) == ""
+}
+
+// handleEmptyResponse handles an AI turn with neither text nor tool calls. The
+// first in a row is answered with a nudge to continue, and nil is returned so
+// the loop asks again; the next one stops the run with a stalled result.
+func (r *InvestigationRunner) handleEmptyResponse(rc *runContext) *InvestigationResult {
+	rc.emptyResponses++
+	if rc.emptyResponses == 1 {
+		rc.logger.Warn("AI response was empty; nudging it to continue")
+		_, err := r.convService.AddUserMessage(rc.ctx, rc.sessionID, emptyResponseNudge)
+		if err == nil {
+			return nil
+		}
+		rc.logger.Error("Failed to add empty response nudge", "error", err)
+	}
+	rc.logger.Warn("Stopping investigation after empty AI responses", "empty_responses", rc.emptyResponses)
+	return &InvestigationResult{
+		InvestigationID: rc.investigationID,
+		AlertID:         rc.alert.ID(),
+		Status:          StatusStalled,
+		ActionsTaken:    rc.actionsTaken,
+		Duration:        time.Since(rc.startTime),
+		Escalated:       true,
+		EscalateReason: fmt.Sprintf("stalled: the model returned %d empty responses in a row, "+
+			"with neither text nor tool calls", rc.emptyResponses),
+	}
 }
 
 // injectTurnWarningIfNeeded injects a warning message if the agent is approaching the turn limit.
@@ -675,7 +1397,7 @@ func (r *InvestigationRunner) injectTurnWarningIfNeeded(rc *runContext) {
 	warningMsg := r.buildTurnWarningMessage(remaining)
 	if warningMsg != "" {
 		if _, err := r.convService.AddUserMessage(rc.ctx, rc.sessionID, warningMsg); err != nil {
-			fmt.Fprintf(os.Stderr, "[InvestigationRunner] Failed to add warning message: %v\n", err)
+			rc.logger.Warn("Failed to add turn warning message", "error", err)
 		}
 	}
 }
@@ -683,24 +1405,24 @@ func (r *InvestigationRunner) injectTurnWarningIfNeeded(rc *runContext) {
 // handleMaxActionsReached handles the scenario where max actions limit is reached.
 // Sends a summary request and allows one final AI response.
 func (r *InvestigationRunner) handleMaxActionsReached(rc *runContext) error {
-	fmt.Fprintf(
-		os.Stderr,
-		"[InvestigationRunner] Max actions limit reached (%d/%d). Requesting summary.\n",
-		rc.actionsTaken,
-		rc.maxActions,
-	)
+	rc.logger.Warn("Max actions limit reached; requesting summary",
+		"actions_taken", rc.actionsTaken, "max_actions", rc.maxActions)
 
 	summaryMsg := "TURN LIMIT REACHED: You have reached the maximum number of allowed turns for this investigation. Please provide a summary of your findings and conclusions based on the investigation performed so far."
 	if _, err := r.convService.AddUserMessage(rc.ctx, rc.sessionID, summaryMsg); err != nil {
-		fmt.Fprintf(os.Stderr, "[InvestigationRunner] Failed to add summary request: %v\n", err)
+		rc.logger.Error("Failed to add summary request", "error", err)
 		return err
 	}
 
-	_, _, err := r.convService.ProcessAssistantResponse(rc.ctx, rc.sessionID)
+	msg, _, err := r.convService.ProcessAssistantResponse(rc.ctx, rc.sessionID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[InvestigationRunner] Error processing final summary response: %v\n", err)
+		rc.logger.Error("Failed to process final summary response", "error", err)
 		return err
 	}
+	if msg != nil {
+		rc.lastMessage = msg
+		r.addTurnCost(rc, msg)
+	}
 
 	return nil
 }
@@ -728,8 +1450,8 @@ func (r *InvestigationRunner) getNextToolCalls(rc *runContext) (*entity.Message,
 			if r.uiAdapter != nil {
 				_ = r.uiAdapter.DisplayThinking(thinkingContent.String())
 			} else {
-				// Fallback to stderr if no UI adapter (for backward compatibility)
-				fmt.Fprintf(os.Stderr, "\x1b[95m[THINKING]\x1b[0m %s\n", thinkingContent.String())
+				// Fall back to the log if no UI adapter
+				rc.logger.Info("Thinking", "content", thinkingContent.String())
 			}
 		}
 	} else {
@@ -739,6 +1461,9 @@ func (r *InvestigationRunner) getNextToolCalls(rc *runContext) (*entity.Message,
 	if err != nil {
 		return nil, nil, err
 	}
+	if tokens := thinkingTokens(msg); tokens > 0 {
+		rc.logger.Debug("Thinking tokens used", "thinking_tokens", tokens)
+	}
 	return msg, r.limitToolCalls(rc, toolCalls), nil
 }
 
@@ -759,15 +1484,21 @@ func (r *InvestigationRunner) processLoopIteration(
 	if separated.completion != nil {
 		// Log the raw input for debugging
 		inputJSON, _ := json.Marshal(separated.completion.Input)
-		fmt.Fprintf(os.Stderr, "[InvestigationRunner] complete_investigation called with input: %s\n", inputJSON)
+		rc.logger.Debug("complete_investigation called", "input", string(inputJSON))
 
-		return rc.buildCompletionResult(separated.completion.Input), true, nil
+		result := rc.buildCompletionResult(separated.completion.Input)
+		return r.resolveConfidence(rc, result, separated.completion.Input), true, nil
 	}
 
 	if separated.escalation != nil {
 		return rc.buildEscalationResult(separated.escalation.Input), true, nil
 	}
 
+	if result := r.checkErrorStreak(rc); result != nil {
+		rc.logger.Warn("Escalating after consecutive tool errors", "errors", len(rc.errorStreak))
+		return result, true, nil
+	}
+
 	return nil, false, nil
 }
 
@@ -794,21 +1525,12 @@ func (r *InvestigationRunner) limitToolCalls(rc *runContext, toolCalls []port.To
 	return toolCalls
 }
 
-// isToolCallAllowed checks if a tool call is allowed based on config's AllowedTools.
-func (r *InvestigationRunner) isToolCallAllowed(tc port.ToolCallInfo) bool {
-	if r.config.AllowedTools == nil {
-		return true // nil = allow all
-	}
-	if len(r.config.AllowedTools) == 0 {
-		return false // empty slice = block all
-	}
-
-	for _, allowed := range r.config.AllowedTools {
-		if allowed == tc.ToolName {
-			return true
-		}
-	}
-	return false
+// resolveTools returns the tools an investigation of alert may use: the
+// configured AllowedTools adjusted by the tools that the alert's prompt builder
+// recommends and restricts.
+func (r *InvestigationRunner) resolveTools(alert *AlertForInvestigation, logger *slog.Logger) investigationToolSet {
+	builder := builderForAlert(r.promptBuilder, r.createAlertView(alert))
+	return resolveInvestigationTools(r.config.AllowedTools, builder, r.safetyEnforcer, logger)
 }
 
 // buildTurnWarningMessage generates a warning message based on remaining actions.
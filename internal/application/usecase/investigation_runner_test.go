@@ -4,8 +4,11 @@ import (
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -42,6 +45,7 @@ type investigationRunnerConvServiceMock struct {
 	processResponseError     error
 	processResponseMessages  []*entity.Message
 	processResponseToolCalls [][]port.ToolCallInfo
+	processResponseCtx       context.Context
 
 	// Thinking content for streaming
 	thinkingContent string
@@ -116,6 +120,7 @@ func (m *investigationRunnerConvServiceMock) ProcessAssistantResponse(
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.processResponseCalls++
+	m.processResponseCtx = ctx
 	if m.processResponseError != nil {
 		return nil, nil, m.processResponseError
 	}
@@ -124,6 +129,9 @@ func (m *investigationRunnerConvServiceMock) ProcessAssistantResponse(
 	idx := m.processResponseCalls - 1
 	if idx < len(m.processResponseMessages) {
 		msg = m.processResponseMessages[idx]
+	} else {
+		// Unscripted turns answer with text, so the runner does not treat them as empty
+		msg = createAssistantMessage("Investigation complete.")
 	}
 	if idx < len(m.processResponseToolCalls) {
 		toolCalls = m.processResponseToolCalls[idx]
@@ -194,6 +202,10 @@ func (m *investigationRunnerConvServiceMock) GetThinkingMode(sessionID string) (
 	return m.getThinkingModeInfo, m.getThinkingModeError
 }
 
+func (m *investigationRunnerConvServiceMock) SetSessionModel(_ string, _ string) error {
+	return nil
+}
+
 // investigationRunnerToolExecutorMock implements port.ToolExecutor for testing.
 type investigationRunnerToolExecutorMock struct {
 	mu sync.Mutex
@@ -294,6 +306,7 @@ func (m *investigationRunnerPromptBuilderMock) Get(alertType string) (Investigat
 }
 
 func (m *investigationRunnerPromptBuilderMock) BuildPromptForAlert(
+	_ context.Context,
 	alert *AlertView,
 	tools []entity.Tool,
 	skills []port.SkillInfo,
@@ -456,11 +469,14 @@ func TestInvestigationRunner_EndsSessionOnError(t *testing.T) {
 	alert := createTestAlert("alert-003", "critical", "System Failure")
 
 	// Act
-	_, err := runner.Run(context.Background(), alert, "inv-003")
+	result, err := runner.Run(context.Background(), alert, "inv-003")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error when AI fails")
+	if !errors.Is(err, ErrProviderUnavailable) || !errors.Is(err, expectedError) {
+		t.Errorf("Run() error = %v, want ErrProviderUnavailable wrapping the provider error", err)
+	}
+	if result == nil || result.ErrorKind != ErrorKindProviderUnavailable {
+		t.Errorf("Run() result = %+v, want ErrorKind %q", result, ErrorKindProviderUnavailable)
 	}
 	// Session should still be ended for cleanup
 	if convService.endConversationCalls != 1 {
@@ -495,12 +511,15 @@ func TestInvestigationRunner_StartConversationError(t *testing.T) {
 	result, err := runner.Run(context.Background(), alert, "inv-004")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error when StartConversation fails")
+	if !errors.Is(err, ErrConversationStart) || !errors.Is(err, expectedError) {
+		t.Errorf("Run() error = %v, want ErrConversationStart wrapping the StartConversation error", err)
 	}
 	if result != nil && result.Status != "failed" {
 		t.Errorf("Run() result status = %q, want %q", result.Status, "failed")
 	}
+	if result != nil && result.ErrorKind != ErrorKindConversationStart {
+		t.Errorf("Run() result ErrorKind = %q, want %q", result.ErrorKind, ErrorKindConversationStart)
+	}
 }
 
 // =============================================================================
@@ -623,6 +642,241 @@ func TestInvestigationRunner_UsesPromptBuilder(t *testing.T) {
 	}
 }
 
+func TestInvestigationRunner_ExcludesInteractiveTools(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Investigation complete."),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{nil}
+
+	toolExecutor := newInvestigationRunnerToolExecutorMock()
+	_ = toolExecutor.RegisterTool(entity.Tool{Name: "ask_user", Description: "Ask the user", Interactive: true})
+	promptBuilder := newInvestigationRunnerPromptBuilderMock()
+
+	runner := NewInvestigationRunner(
+		convService,
+		toolExecutor,
+		NewMockSafetyEnforcer(),
+		promptBuilder,
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+
+	if _, err := runner.Run(context.Background(), createTestAlert("alert-ask", "warning", "HighCPU Alert"), "inv-ask"); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	if len(promptBuilder.buildPromptTools) != 3 {
+		t.Errorf("prompt tools = %v, want the 3 non-interactive tools", promptBuilder.buildPromptTools)
+	}
+	for _, tool := range promptBuilder.buildPromptTools {
+		if tool.Interactive {
+			t.Errorf("interactive tool %q should not be in the investigation prompt", tool.Name)
+		}
+	}
+	// The conversation service drops interactive tools from headless requests
+	if !port.IsHeadless(convService.processResponseCtx) {
+		t.Error("investigation requests should be marked headless")
+	}
+}
+
+func TestInvestigationRunner_PassesAlertGeneratorURLToTools(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Investigation complete."),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{nil}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+
+	alert := createTestAlert("alert-prom", "warning", "HighCPU Alert")
+	alert.generatorURL = "http://prometheus:9090/graph?g0.expr=cpu"
+	if _, err := runner.Run(context.Background(), alert, "inv-prom"); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	// promql_query reads it to query the Prometheus that fired the alert
+	if got := port.AlertGeneratorURLFromContext(convService.processResponseCtx); got != alert.generatorURL {
+		t.Errorf("generator URL in context = %q, want %q", got, alert.generatorURL)
+	}
+}
+
+func TestInvestigationRunner_RecordsTimelineAndResolution(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Checking disk usage."),
+		createAssistantMessage("Done."),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "call_1", ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}}},
+		{{
+			ToolID:   "call_2",
+			ToolName: "complete_investigation",
+			Input: map[string]interface{}{
+				"confidence":          0.9,
+				"findings":            []interface{}{"/var is full"},
+				"root_cause":          "old logs",
+				"recommended_actions": []interface{}{"Rotate logs"},
+			},
+		}},
+	}
+	toolExecutor := newInvestigationRunnerToolExecutorMock()
+	toolExecutor.executeToolResult = "/dev/sda1 98%\n" + strings.Repeat("x", toolOutputMaxLen)
+
+	runner := NewInvestigationRunner(
+		convService,
+		toolExecutor,
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	wantActions := []entity.RecommendedAction{{Description: "Rotate logs"}}
+	if result.RootCause != "old logs" || !slices.Equal(result.RecommendedActions, wantActions) {
+		t.Errorf("RootCause = %q, RecommendedActions = %+v", result.RootCause, result.RecommendedActions)
+	}
+
+	// Two iterations around one tool call
+	if len(result.Timeline) != 3 || result.Timeline[1].Type != port.InvestigationEventToolExecuted {
+		t.Fatalf("Timeline = %+v, want iteration, tool, iteration", result.Timeline)
+	}
+	if len(result.Artifacts) != 1 {
+		t.Fatalf("Artifacts = %+v, want one for the bash call", result.Artifacts)
+	}
+	artifact := result.Artifacts[0]
+	if artifact.ID != "artifact-1" || artifact.Input != `{"command":"df -h"}` {
+		t.Errorf("artifact = %+v", artifact)
+	}
+	if !strings.HasPrefix(artifact.Output, "/dev/sda1 98%") || !strings.HasSuffix(artifact.Output, "bytes total)") {
+		t.Errorf("artifact output should be truncated with a note, got ...%q", artifact.Output[len(artifact.Output)-40:])
+	}
+}
+
+// changeTrackerMock reports a fixed list of modified files for one session.
+type changeTrackerMock struct {
+	sessionID string
+	files     []FileChange
+	forgotten []string
+}
+
+func (m *changeTrackerMock) ModifiedFiles(sessionID string) []FileChange {
+	if sessionID != m.sessionID {
+		return nil
+	}
+	return m.files
+}
+
+func (m *changeTrackerMock) Forget(sessionID string) {
+	m.forgotten = append(m.forgotten, sessionID)
+}
+
+func TestInvestigationRunner_ReportsModifiedFiles(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.startConversationSession = "inv-session-files"
+	convService.processResponseMessages = []*entity.Message{createAssistantMessage("Done.")}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{{{
+		ToolID:   "call_1",
+		ToolName: "complete_investigation",
+		Input:    map[string]interface{}{"confidence": 0.9, "findings": []interface{}{"rotated logs"}},
+	}}}
+	tracker := &changeTrackerMock{
+		sessionID: "inv-session-files",
+		files:     []FileChange{{Path: "logrotate.conf", Status: FileChangeModified, Added: 2, Removed: 1}},
+	}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+	runner.SetChangeTracker(tracker)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-files")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if len(result.ModifiedFiles) != 1 || result.ModifiedFiles[0] != tracker.files[0] {
+		t.Errorf("ModifiedFiles = %+v, want %+v", result.ModifiedFiles, tracker.files)
+	}
+	if len(tracker.forgotten) != 1 || tracker.forgotten[0] != "inv-session-files" {
+		t.Errorf("Forget() calls = %v, want the investigation's session once", tracker.forgotten)
+	}
+}
+
+// toolStatsMock reports fixed tool stats for one session.
+type toolStatsMock struct {
+	sessionID string
+	stats     []ToolStats
+	reset     []string
+}
+
+func (m *toolStatsMock) GetToolStats(sessionID string) []ToolStats {
+	if sessionID != m.sessionID {
+		return nil
+	}
+	return m.stats
+}
+
+func (m *toolStatsMock) ResetToolStats(sessionID string) {
+	m.reset = append(m.reset, sessionID)
+}
+
+func TestInvestigationRunner_ReportsToolStats(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.startConversationSession = "inv-session-stats"
+	convService.processResponseMessages = []*entity.Message{createAssistantMessage("Done.")}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{{{
+		ToolID:   "call_1",
+		ToolName: "complete_investigation",
+		Input:    map[string]interface{}{"confidence": 0.9, "findings": []interface{}{"disk full"}},
+	}}}
+	stats := &toolStatsMock{
+		sessionID: "inv-session-stats",
+		stats:     []ToolStats{{Tool: "bash", Calls: 3, Errors: 1, Duration: time.Second, Bytes: 2048}},
+	}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+	)
+	runner.SetToolStats(stats)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-stats")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if len(result.ToolStats) != 1 || result.ToolStats[0] != stats.stats[0] {
+		t.Errorf("ToolStats = %+v, want %+v", result.ToolStats, stats.stats)
+	}
+	if len(stats.reset) != 1 || stats.reset[0] != "inv-session-stats" {
+		t.Errorf("ResetToolStats() calls = %v, want the investigation's session once", stats.reset)
+	}
+}
+
 func TestInvestigationRunner_PromptBuilderError(t *testing.T) {
 	// Arrange
 	expectedError := errors.New("failed to build prompt")
@@ -650,12 +904,15 @@ func TestInvestigationRunner_PromptBuilderError(t *testing.T) {
 	result, err := runner.Run(context.Background(), alert, "inv-007")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error when PromptBuilder fails")
+	if !errors.Is(err, ErrPromptBuild) || !errors.Is(err, expectedError) {
+		t.Errorf("Run() error = %v, want ErrPromptBuild wrapping the prompt builder error", err)
 	}
 	if result != nil && result.Status != "failed" {
 		t.Errorf("Run() result status = %q, want %q", result.Status, "failed")
 	}
+	if result != nil && result.ErrorKind != ErrorKindPromptBuild {
+		t.Errorf("Run() result ErrorKind = %q, want %q", result.ErrorKind, ErrorKindPromptBuild)
+	}
 	// Session should be cleaned up
 	if convService.endConversationCalls != 1 {
 		t.Errorf("EndConversation() should be called for cleanup, got %d calls",
@@ -1218,6 +1475,42 @@ func TestInvestigationRunner_RespectsTimeout(t *testing.T) {
 	}
 }
 
+func TestInvestigationRunner_EscalatesWhenRateLimitExceedsMaxDuration(t *testing.T) {
+	// Arrange
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseError = fmt.Errorf("send failed: %w",
+		&port.RateLimitError{Wait: 2 * time.Minute, Remaining: 30 * time.Second})
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{
+			MaxActions:   20,
+			MaxDuration:  15 * time.Minute,
+			AllowedTools: []string{"bash"},
+		},
+	)
+
+	// Act
+	result, err := runner.Run(context.Background(), createTestAlert("alert-rate", "critical", "Test"), "inv-rate")
+
+	// Assert
+	var rateErr *port.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("Run() error = %v, want a *port.RateLimitError", err)
+	}
+	if result == nil || !result.Escalated {
+		t.Fatalf("Run() result = %+v, want an escalated result", result)
+	}
+	if !strings.Contains(result.EscalateReason, "rate limited") {
+		t.Errorf("EscalateReason = %q, want it to mention the rate limit", result.EscalateReason)
+	}
+}
+
 // =============================================================================
 // Result Structure Tests
 // =============================================================================
@@ -1297,8 +1590,11 @@ func TestInvestigationRunner_NilAlertReturnsError(t *testing.T) {
 	result, err := runner.Run(context.Background(), nil, "inv-018")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error for nil alert")
+	if !errors.Is(err, ErrInvalidAlert) {
+		t.Errorf("Run() error = %v, want ErrInvalidAlert for nil alert", err)
+	}
+	if result != nil && result.ErrorKind != ErrorKindInvalidAlert {
+		t.Errorf("Result.ErrorKind = %q, want %q", result.ErrorKind, ErrorKindInvalidAlert)
 	}
 	if result != nil && result.Status != "failed" {
 		t.Errorf("Result.Status = %q, want %q for nil alert", result.Status, "failed")
@@ -1695,6 +1991,139 @@ func TestInvestigationRunner_SafetyEnforcerBlocksCommand(t *testing.T) {
 	_ = err // Error depends on implementation
 }
 
+func TestInvestigationRunner_AllowedCommandPatterns(t *testing.T) {
+	tests := []struct {
+		name        string
+		toolName    string
+		input       map[string]interface{}
+		wantBlocked bool
+	}{
+		{
+			name:     "allowed pipeline runs",
+			toolName: "bash",
+			input:    map[string]interface{}{"command": "ps aux | grep nginx"},
+		},
+		{
+			name:        "unlisted command is refused",
+			toolName:    "bash",
+			input:       map[string]interface{}{"command": "curl http://internal/admin"},
+			wantBlocked: true,
+		},
+		{
+			name:        "unlisted segment of a chain is refused",
+			toolName:    "bash",
+			input:       map[string]interface{}{"command": "df -h && reboot"},
+			wantBlocked: true,
+		},
+		{
+			name:        "polled wait_for command is checked",
+			toolName:    "wait_for",
+			input:       map[string]interface{}{"mode": "command", "command": "kubectl rollout status deploy/api"},
+			wantBlocked: true,
+		},
+		{
+			name:        "variables set for an allowed command are checked",
+			toolName:    "bash",
+			input:       map[string]interface{}{"command": "ps aux", "env": map[string]interface{}{"LANG": "C"}},
+			wantBlocked: true,
+		},
+		{
+			name:     "wait_for on a file has no command",
+			toolName: "wait_for",
+			input:    map[string]interface{}{"mode": "file", "path": "/var/log/app.log", "pattern": "ready"},
+		},
+		{
+			name:     "batch_tool invocations are checked",
+			toolName: "batch_tool",
+			input: map[string]interface{}{"invocations": []interface{}{
+				map[string]interface{}{"tool_name": "bash", "arguments": map[string]interface{}{"command": "df -h"}},
+				map[string]interface{}{"tool_name": "bash", "arguments": map[string]interface{}{"command": "du -sh `pwd`"}},
+			}},
+			wantBlocked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.startConversationSession = "inv-session-allowlist"
+			convService.processResponseMessages = []*entity.Message{
+				createAssistantMessage("Running a command."),
+				createAssistantMessage("Investigation complete."),
+			}
+			convService.processResponseToolCalls = [][]port.ToolCallInfo{
+				{{ToolID: "cmd-1", ToolName: tt.toolName, Input: tt.input}},
+				nil,
+			}
+			toolExecutor := newInvestigationRunnerToolExecutorMock()
+
+			runner := NewInvestigationRunner(
+				convService,
+				toolExecutor,
+				nil, // safetyEnforcer: the allowlist applies without one
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{
+					MaxActions:             20,
+					MaxDuration:            15 * time.Minute,
+					AllowedTools:           []string{"bash", "wait_for", "batch_tool"},
+					AllowedCommandPatterns: []string{`^(ps|df|du)\b`, `^grep\b`},
+				},
+			)
+
+			if _, err := runner.Run(context.Background(), createTestAlert("alert-allowlist", "warning", "Test"), "inv-allowlist"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			executed := len(toolExecutor.executeToolName) > 0
+			if executed == tt.wantBlocked {
+				t.Errorf("tool executed = %v, want %v", executed, !tt.wantBlocked)
+			}
+			if !tt.wantBlocked {
+				return
+			}
+			if len(convService.addToolResultResults) == 0 || len(convService.addToolResultResults[0]) == 0 {
+				t.Fatal("no tool result was fed back")
+			}
+			result := convService.addToolResultResults[0][0]
+			if !result.IsError || !strings.Contains(result.Result, "Command blocked") ||
+				!strings.Contains(result.Result, `^(ps|df|du)\b, ^grep\b`) {
+				t.Errorf("tool result = %+v, want a refusal listing the allowed patterns", result)
+			}
+		})
+	}
+}
+
+func TestInvestigationRunner_InvalidAllowedCommandPatternFailsRun(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		nil,
+		newInvestigationRunnerPromptBuilderMock(),
+		nil,
+		nil,
+		AlertInvestigationUseCaseConfig{
+			MaxActions:             20,
+			MaxDuration:            15 * time.Minute,
+			AllowedTools:           []string{"bash"},
+			AllowedCommandPatterns: []string{`^(ps`},
+		},
+	)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-bad-pattern", "warning", "Test"), "inv-bad-pattern")
+	if err == nil || !strings.Contains(err.Error(), "invalid allowed command pattern") {
+		t.Errorf("Run() error = %v, want an invalid pattern error", err)
+	}
+	if result == nil || result.Status != "failed" {
+		t.Errorf("Run() result = %+v, want a failed result", result)
+	}
+	if convService.processResponseCalls != 0 {
+		t.Errorf("ProcessAssistantResponse() called %d times, want 0", convService.processResponseCalls)
+	}
+}
+
 func TestInvestigationRunner_SafetyEnforcerActionBudgetExceeded(t *testing.T) {
 	// Arrange
 	convService := newInvestigationRunnerConvServiceMock()
@@ -1735,7 +2164,7 @@ func TestInvestigationRunner_SafetyEnforcerActionBudgetExceeded(t *testing.T) {
 	alert := createTestAlert("alert-budget", "warning", "Test")
 
 	// Act
-	result, _ := runner.Run(context.Background(), alert, "inv-budget")
+	result, err := runner.Run(context.Background(), alert, "inv-budget")
 
 	// Assert
 	// Should not exceed the safety enforcer's budget
@@ -1743,9 +2172,11 @@ func TestInvestigationRunner_SafetyEnforcerActionBudgetExceeded(t *testing.T) {
 		t.Errorf("ExecuteTool() called %d times, safety enforcer should limit to 2",
 			toolExecutor.executeToolCalls)
 	}
-	// Should escalate or fail when budget is exceeded
-	if result != nil && !result.Escalated && result.Status == "completed" {
-		t.Log("Investigation completed normally despite budget being exceeded")
+	if !errors.Is(err, ErrActionBudgetExceeded) {
+		t.Errorf("Run() error = %v, want ErrActionBudgetExceeded", err)
+	}
+	if result == nil || !result.Escalated || result.ErrorKind != ErrorKindActionBudgetExceeded {
+		t.Errorf("Run() result = %+v, want an escalated result of kind %q", result, ErrorKindActionBudgetExceeded)
 	}
 }
 
@@ -1880,13 +2311,114 @@ func TestInvestigationRunner_EscalatesOnConsecutiveErrors(t *testing.T) {
 	alert := createTestAlert("alert-errors", "warning", "Error-prone Issue")
 
 	// Act
-	result, _ := runner.Run(context.Background(), alert, "inv-errors")
+	result, err := runner.Run(context.Background(), alert, "inv-errors")
 
 	// Assert
 	// After 3 consecutive errors, should escalate
 	_ = errorCount
-	if result != nil && !result.Escalated {
-		t.Log("Investigation should escalate after consecutive errors threshold reached")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if !result.Escalated || result.Status != "escalated" {
+		t.Errorf("result = %s escalated %v, want escalated after consecutive errors threshold reached",
+			result.Status, result.Escalated)
+	}
+	if toolExecutor.executeToolCalls != 3 {
+		t.Errorf("executed %d tools, want the loop to stop after 3", toolExecutor.executeToolCalls)
+	}
+}
+
+func TestInvestigationRunner_ErrorStreak(t *testing.T) {
+	call := func(id, command string) []port.ToolCallInfo {
+		return []port.ToolCallInfo{{ToolID: id, ToolName: "bash", Input: map[string]interface{}{"command": command}}}
+	}
+
+	tests := []struct {
+		name          string
+		threshold     int
+		execFails     bool // Every executed command fails; "rm" commands are blocked regardless
+		toolCalls     [][]port.ToolCallInfo
+		wantEscalated bool
+		wantReason    string
+	}{
+		{
+			name:      "threshold reached with failed and blocked calls",
+			threshold: 3,
+			execFails: true,
+			toolCalls: [][]port.ToolCallInfo{
+				call("t1", "cat /missing"),
+				{
+					{ToolID: "t2", ToolName: "bash", Input: map[string]interface{}{"command": "rm -rf /tmp/x"}},
+					{ToolID: "t3", ToolName: "read_file", Input: map[string]interface{}{"path": "/missing"}},
+				},
+			},
+			wantEscalated: true,
+			wantReason: "3 consecutive tool errors (1 failed, 2 blocked): bash failed: command failed; " +
+				"bash blocked: Command blocked: command blocked; read_file blocked: tool 'read_file' is not allowed for this investigation",
+		},
+		{
+			name:      "success resets the streak",
+			threshold: 3,
+			toolCalls: [][]port.ToolCallInfo{
+				call("t1", "rm a"), call("t2", "rm b"), call("t3", "ls"), call("t4", "rm c"), call("t5", "rm d"),
+			},
+		},
+		{
+			name:      "zero disables",
+			execFails: true,
+			toolCalls: [][]port.ToolCallInfo{
+				call("t1", "a"), call("t2", "b"), call("t3", "c"), call("t4", "d"), call("t5", "e"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.startConversationSession = "inv-session-streak"
+			for range tt.toolCalls {
+				convService.processResponseMessages = append(convService.processResponseMessages,
+					createAssistantMessage("Trying."))
+			}
+			convService.processResponseMessages = append(convService.processResponseMessages,
+				createAssistantMessage("Done."))
+			convService.processResponseToolCalls = append(tt.toolCalls, nil)
+
+			toolExecutor := newInvestigationRunnerToolExecutorMock()
+			if tt.execFails {
+				toolExecutor.executeToolError = errors.New("command failed")
+			}
+
+			runner := NewInvestigationRunner(
+				convService,
+				toolExecutor,
+				NewMockSafetyEnforcerWithBlockedCommands([]string{"rm"}),
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{
+					MaxActions:       20,
+					MaxDuration:      15 * time.Minute,
+					AllowedTools:     []string{"bash"},
+					EscalateOnErrors: tt.threshold,
+				},
+			)
+
+			result, err := runner.Run(context.Background(), createTestAlert("alert-streak", "warning", "Errors"), "inv-streak")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.wantEscalated {
+				if !result.Escalated || result.Status != "escalated" || result.EscalateReason != tt.wantReason {
+					t.Errorf("result = %s escalated %v reason %q, want escalated with reason %q",
+						result.Status, result.Escalated, result.EscalateReason, tt.wantReason)
+				}
+				return
+			}
+			if result.Escalated || result.Status != "completed" {
+				t.Errorf("result = %s escalated %v (%s), want completed without escalation",
+					result.Status, result.Escalated, result.EscalateReason)
+			}
+		})
 	}
 }
 
@@ -2078,26 +2610,24 @@ func TestInvestigationRunner_EmptyAllowedToolsBlocksAll(t *testing.T) {
 // AI Response Edge Cases
 // =============================================================================
 
-func TestInvestigationRunner_EmptyAssistantResponse(t *testing.T) {
-	// Arrange
+// runEmptyResponseInvestigation runs an investigation whose model answers
+// with the given messages and tool calls, one pair per turn.
+func runEmptyResponseInvestigation(
+	t *testing.T,
+	messages []*entity.Message,
+	toolCalls [][]port.ToolCallInfo,
+) (*InvestigationResult, *investigationRunnerConvServiceMock) {
+	t.Helper()
 	convService := newInvestigationRunnerConvServiceMock()
 	convService.startConversationSession = "inv-session-empty-response"
-	// AI returns empty message
-	convService.processResponseMessages = []*entity.Message{
-		createAssistantMessage(""),
-		createAssistantMessage("Now I have something to say."),
-	}
-	convService.processResponseToolCalls = [][]port.ToolCallInfo{nil, nil}
-
-	toolExecutor := newInvestigationRunnerToolExecutorMock()
-	safetyEnforcer := NewMockSafetyEnforcer()
-	promptBuilder := newInvestigationRunnerPromptBuilderMock()
+	convService.processResponseMessages = messages
+	convService.processResponseToolCalls = toolCalls
 
 	runner := NewInvestigationRunner(
 		convService,
-		toolExecutor,
-		safetyEnforcer,
-		promptBuilder,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
 		nil, // skillManager
 		nil, // uiAdapter
 		AlertInvestigationUseCaseConfig{
@@ -2107,17 +2637,72 @@ func TestInvestigationRunner_EmptyAssistantResponse(t *testing.T) {
 		},
 	)
 
-	alert := createTestAlert("alert-empty-response", "warning", "Test")
-
-	// Act
-	result, err := runner.Run(context.Background(), alert, "inv-empty-response")
-	// Assert
-	// Should handle empty response gracefully
+	result, err := runner.Run(context.Background(), createTestAlert("alert-empty-response", "warning", "Test"),
+		"inv-empty-response")
 	if err != nil {
-		t.Logf("Run() error = %v (may be expected for empty response)", err)
+		t.Fatalf("Run() error = %v", err)
 	}
-	if result == nil {
-		t.Error("Run() should return a result even for empty AI responses")
+	return result, convService
+}
+
+func TestInvestigationRunner_EmptyAssistantResponse(t *testing.T) {
+	result, convService := runEmptyResponseInvestigation(t,
+		[]*entity.Message{createAssistantMessage(""), createAssistantMessage("Now I have something to say.")},
+		[][]port.ToolCallInfo{nil, nil},
+	)
+
+	nudges := 0
+	for _, content := range convService.addUserMessageContent {
+		if strings.Contains(content, "Please continue or call complete_investigation") {
+			nudges++
+		}
+	}
+	if nudges != 1 {
+		t.Errorf("user messages = %q, want one nudge to continue or complete", convService.addUserMessageContent)
+	}
+	if result.Status != "completed" || result.Escalated {
+		t.Errorf("Status = %q, Escalated = %v, want completed after the nudge", result.Status, result.Escalated)
+	}
+}
+
+func TestInvestigationRunner_RepeatedEmptyResponsesStall(t *testing.T) {
+	result, convService := runEmptyResponseInvestigation(t,
+		[]*entity.Message{createAssistantMessage(""), createAssistantMessage("  \n")},
+		[][]port.ToolCallInfo{nil, nil},
+	)
+
+	if convService.processResponseCalls != 2 {
+		t.Errorf("AI turns = %d, want the empty one retried once", convService.processResponseCalls)
+	}
+	if result.Status != StatusStalled || !result.Escalated {
+		t.Errorf("Status = %q, Escalated = %v, want stalled and escalated", result.Status, result.Escalated)
+	}
+	if !strings.Contains(result.EscalateReason, "2 empty responses") {
+		t.Errorf("EscalateReason = %q, want it to note the empty responses", result.EscalateReason)
+	}
+}
+
+func TestInvestigationRunner_ToolCallsWithoutMessage(t *testing.T) {
+	result, convService := runEmptyResponseInvestigation(t,
+		[]*entity.Message{nil, nil},
+		[][]port.ToolCallInfo{
+			{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "uptime"}}},
+			{{ToolID: "t2", ToolName: toolCompleteInvestigation, Input: map[string]interface{}{
+				"findings": []interface{}{"Load is normal"}, "confidence": 0.9,
+			}}},
+		},
+	)
+
+	if result.Status != "completed" || result.ActionsTaken != 1 {
+		t.Errorf("Status = %q, ActionsTaken = %d, want completed after one tool call", result.Status, result.ActionsTaken)
+	}
+	if !slices.Contains(result.Findings, "[info] Load is normal") {
+		t.Errorf("Findings = %v, want the reported finding", result.Findings)
+	}
+	for _, content := range convService.addUserMessageContent {
+		if content == emptyResponseNudge {
+			t.Error("turns with tool calls were nudged as empty")
+		}
 	}
 }
 
@@ -3200,9 +3785,9 @@ func TestInvestigationRunner_ExtractsCompletionData(t *testing.T) {
 
 	// Verify findings were extracted
 	expectedFindings := []string{
-		"Database connection pool exhausted",
-		"Connection timeout errors in logs",
-		"Application retry storms detected",
+		"[critical] Database connection pool exhausted",
+		"[warning] Connection timeout errors in logs",
+		"[warning] Application retry storms detected",
 	}
 	if len(result.Findings) != len(expectedFindings) {
 		t.Errorf("Result.Findings has %d items, want %d", len(result.Findings), len(expectedFindings))
@@ -3350,8 +3935,8 @@ func TestInvestigationRunner_ExtractsEscalationData(t *testing.T) {
 
 	// Verify partial findings were captured
 	expectedFindings := []string{
-		"Unauthorized SSH login attempts detected",
-		"Suspicious outbound traffic to unknown IPs",
+		"[info] Unauthorized SSH login attempts detected",
+		"[info] Suspicious outbound traffic to unknown IPs",
 	}
 	if len(result.Findings) != len(expectedFindings) {
 		t.Errorf("Result.Findings has %d items, want %d (from partial_findings)",
@@ -4697,11 +5282,48 @@ func TestInvestigationRunner_DisplayThinkingViaUIAdapter(t *testing.T) {
 	}
 }
 
-// testUIAdapter is a minimal test adapter for testing DisplayThinking.
+// TestInvestigationRunner_LeavesActivityIndicatorAlone verifies that
+// investigations, which run concurrently, do not drive the shared UI's spinner.
+func TestInvestigationRunner_LeavesActivityIndicatorAlone(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.startConversationSession = "test-session"
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Checking."),
+		createAssistantMessage("Done."),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "1", ToolName: "bash", Input: map[string]interface{}{"command": "uptime"}}},
+		nil,
+	}
+	uiAdapter := &testUIAdapter{}
+
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		nil, // safetyEnforcer
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		uiAdapter,
+		AlertInvestigationUseCaseConfig{MaxActions: 20, AllowedTools: []string{"bash"}},
+	)
+	if _, err := runner.Run(context.Background(), createTestAlert("alert-1", "warning", "Test"), "inv-test"); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if uiAdapter.activityStarts != 0 {
+		t.Errorf("StartActivity() called %d times, want 0", uiAdapter.activityStarts)
+	}
+}
+
+// testUIAdapter is a minimal test adapter for testing DisplayThinking. It is
+// also an activity indicator that counts how often it is started.
 type testUIAdapter struct {
 	displayThinkingFunc func(content string) error
+	activityStarts      int
 }
 
+func (t *testUIAdapter) StartActivity(string) { t.activityStarts++ }
+func (t *testUIAdapter) StopActivity()        {}
+
 func (t *testUIAdapter) GetUserInput(ctx context.Context) (string, bool)         { return "", false }
 func (t *testUIAdapter) DisplayMessage(message string, messageRole string) error { return nil }
 func (t *testUIAdapter) BeginStreamingResponse() error                           { return nil }
@@ -4728,3 +5350,382 @@ func (t *testUIAdapter) SetColorScheme(scheme port.ColorScheme) error { return n
 func (t *testUIAdapter) ConfirmBashCommand(command string, isDangerous bool, reason string, description string) bool {
 	return true
 }
+
+func (t *testUIAdapter) ConfirmToolBatch(calls []port.ToolConfirmation) []bool {
+	approved := make([]bool, len(calls))
+	for i := range approved {
+		approved[i] = true
+	}
+	return approved
+}
+
+func (t *testUIAdapter) ConfirmFileEdit(_ string, _ string) bool {
+	return true
+}
+
+// recordingInvestigationSink records investigation progress events.
+type recordingInvestigationSink struct {
+	mu     sync.Mutex
+	events []port.InvestigationEvent
+}
+
+func (s *recordingInvestigationSink) OnInvestigationEvent(event port.InvestigationEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestInvestigationRunner_EmitsProgressEvents(t *testing.T) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createAssistantMessage("Let me check the disk."),
+		createAssistantMessage("Done."),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "tool-001", ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}}},
+		{{
+			ToolID:   "tool-002",
+			ToolName: "complete_investigation",
+			Input: map[string]interface{}{
+				"findings":   []interface{}{"/var is 98% full", "logs are not rotated"},
+				"confidence": 0.9,
+			},
+		}},
+	}
+	toolExecutor := newInvestigationRunnerToolExecutorMock()
+	toolExecutor.executeToolResult = "\n/dev/sda1  50G  49G  1G  98% /var\nmore output"
+
+	runner := NewInvestigationRunner(
+		convService, toolExecutor, nil, newInvestigationRunnerPromptBuilderMock(), nil, nil,
+		AlertInvestigationUseCaseConfig{MaxActions: 20, AllowedTools: []string{"bash", "complete_investigation"}},
+	)
+	sink := &recordingInvestigationSink{}
+	runner.SetProgressSink(sink)
+
+	if _, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk full"), "inv-events"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []port.InvestigationEventType{
+		port.InvestigationEventIterationStarted,
+		port.InvestigationEventToolExecuted,
+		port.InvestigationEventIterationStarted,
+		port.InvestigationEventFindingAdded,
+		port.InvestigationEventFindingAdded,
+		port.InvestigationEventCompleted,
+	}
+	if len(sink.events) != len(want) {
+		t.Fatalf("got %d events %+v, want %v", len(sink.events), sink.events, want)
+	}
+	for i, event := range sink.events {
+		if event.Type != want[i] || event.InvestigationID != "inv-events" {
+			t.Errorf("event %d = %+v, want %s for inv-events", i, event, want[i])
+		}
+	}
+	if tool := sink.events[1]; tool.ToolName != "bash" || tool.Summary != "/dev/sda1  50G  49G  1G  98% /var" || tool.Actions != 1 {
+		t.Errorf("tool event = %+v, want bash with the first result line as summary", tool)
+	}
+	if sink.events[2].Iteration != 2 || sink.events[3].Finding != "[warning] /var is 98% full" {
+		t.Errorf("events = %+v, want the second iteration and the first finding", sink.events[2:4])
+	}
+}
+
+func TestInvestigationRunner_EmitsFailedEventOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := NewInvestigationRunner(
+		newInvestigationRunnerConvServiceMock(), newInvestigationRunnerToolExecutorMock(), nil,
+		newInvestigationRunnerPromptBuilderMock(), nil, nil, AlertInvestigationUseCaseConfig{MaxActions: 20},
+	)
+	sink := &recordingInvestigationSink{}
+	runner.SetProgressSink(sink)
+
+	_, _ = runner.Run(ctx, createTestAlert("alert-1", "critical", "Down"), "inv-cancelled")
+
+	if len(sink.events) == 0 {
+		t.Fatal("no events emitted, want a terminal failed event")
+	}
+	last := sink.events[len(sink.events)-1]
+	if last.Type != port.InvestigationEventFailed || last.Reason == "" {
+		t.Errorf("last event = %+v, want failed with a reason", last)
+	}
+}
+
+func TestParseConfidenceValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   float64
+		wantOK bool
+	}{
+		{"float", 0.85, 0.85, true},
+		{"int", 1, 1, true},
+		{"json number", json.Number("0.4"), 0.4, true},
+		{"numeric string", " 0.7 ", 0.7, true},
+		{"percentage", "85%", 0.85, true},
+		{"spaced percentage", "85 %", 0.85, true},
+		{"clamped above", 1.5, 1, true},
+		{"clamped below", -0.2, 0, true},
+		{"clamped percentage", "150%", 1, true},
+		{"missing", nil, 0, false},
+		{"word", "high", 0, false},
+		{"empty string", "", 0, false},
+		{"bare percent", "%", 0, false},
+		{"not a number", math.NaN(), 0, false},
+		{"infinite string", "Inf", 0, false},
+		{"bad json number", json.Number("x"), 0, false},
+		{"bool", true, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseConfidenceValue(tt.value)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("parseConfidenceValue(%#v) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseConfidenceFromMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    float64
+	}{
+		{"decimal", "Root cause found. Confidence: 0.8", 0.8},
+		{"trailing period", "confidence: 0.8.", 0.8},
+		{"percentage", "CONFIDENCE: 75%, based on logs", 0.75},
+		{"clamped", "Confidence: 3", 1},
+		{"skips malformed mention", "Confidence: high. Numeric confidence: 0.9", 0.9},
+		{"no value", "Confidence:", -1},
+		{"no marker", "I am fairly confident", -1},
+		{"malformed only", "confidence: unknown", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseConfidenceFromMessage(tt.content); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("parseConfidenceFromMessage(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvestigationRunner_ResolvesConfidence(t *testing.T) {
+	bash := port.ToolCallInfo{ToolID: "call_bash", ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}}
+	readFile := port.ToolCallInfo{ToolID: "call_read", ToolName: "read_file", Input: map[string]interface{}{"path": "x"}}
+	complete := func(input map[string]interface{}) []port.ToolCallInfo {
+		input["findings"] = []interface{}{"disk full"}
+		return []port.ToolCallInfo{{ToolID: "call_done", ToolName: "complete_investigation", Input: input}}
+	}
+
+	tests := []struct {
+		name          string
+		toolCalls     []port.ToolCallInfo // First iteration; read_file fails
+		final         string              // Final assistant message
+		finalCalls    []port.ToolCallInfo // Final tool calls; nil for a free-text completion
+		threshold     float64
+		want          float64
+		wantDerived   bool
+		wantEscalated bool
+	}{
+		{
+			name:       "completion tool number",
+			toolCalls:  []port.ToolCallInfo{readFile},
+			final:      "Done. Confidence: 0.1",
+			finalCalls: complete(map[string]interface{}{"confidence": 0.9}),
+			threshold:  0.5,
+			want:       0.9,
+		},
+		{
+			name:          "completion tool percentage below threshold",
+			toolCalls:     []port.ToolCallInfo{bash},
+			final:         "Done.",
+			finalCalls:    complete(map[string]interface{}{"confidence": "30%"}),
+			threshold:     0.5,
+			want:          0.3,
+			wantEscalated: true,
+		},
+		{
+			name:       "malformed completion tool value falls back to message",
+			toolCalls:  []port.ToolCallInfo{bash},
+			final:      "Done. Confidence: 85%",
+			finalCalls: complete(map[string]interface{}{"confidence": "very"}),
+			threshold:  0.5,
+			want:       0.85,
+		},
+		{
+			name:          "free-text message",
+			toolCalls:     []port.ToolCallInfo{bash},
+			final:         "The disk is full. Confidence: 0.2",
+			threshold:     0.5,
+			want:          0.2,
+			wantEscalated: true,
+		},
+		{
+			name:        "derived from successful tools",
+			toolCalls:   []port.ToolCallInfo{bash, bash},
+			final:       "The disk is full.",
+			threshold:   0.7,
+			want:        maxDerivedConfidence,
+			wantDerived: true,
+		},
+		{
+			name:          "derived from mostly failed tools",
+			toolCalls:     []port.ToolCallInfo{bash, readFile, readFile, readFile},
+			final:         "Confidence: unclear",
+			finalCalls:    complete(map[string]interface{}{}),
+			threshold:     0.5,
+			want:          0.25,
+			wantDerived:   true,
+			wantEscalated: true,
+		},
+		{
+			name:        "derived without tools does not escalate",
+			final:       "Nothing to check.",
+			threshold:   0.5,
+			want:        0,
+			wantDerived: true,
+		},
+		{
+			name:        "no threshold",
+			toolCalls:   []port.ToolCallInfo{readFile},
+			final:       "Could not tell.",
+			want:        0,
+			wantDerived: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.startConversationSession = "inv-session-confidence"
+			convService.processResponseMessages = []*entity.Message{
+				createAssistantMessage("Checking."),
+				createAssistantMessage(tt.final),
+			}
+			convService.processResponseToolCalls = [][]port.ToolCallInfo{tt.toolCalls, tt.finalCalls}
+			if len(tt.toolCalls) == 0 {
+				convService.processResponseMessages = convService.processResponseMessages[1:]
+				convService.processResponseToolCalls = convService.processResponseToolCalls[1:]
+			}
+
+			runner := NewInvestigationRunner(
+				convService,
+				newInvestigationRunnerToolExecutorMock(),
+				NewMockSafetyEnforcerWithBlockedTools([]string{"read_file"}),
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{
+					MaxActions:           20,
+					MaxDuration:          15 * time.Minute,
+					EscalateOnConfidence: tt.threshold,
+				},
+			)
+
+			result, err := runner.Run(context.Background(), createTestAlert("alert-conf", "warning", "Disk"), "inv-conf")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.Status != "completed" || math.Abs(result.Confidence-tt.want) > 1e-9 ||
+				result.ConfidenceDerived != tt.wantDerived || result.Escalated != tt.wantEscalated {
+				t.Errorf("result = %s confidence %v derived %v escalated %v, want completed %v derived %v escalated %v",
+					result.Status, result.Confidence, result.ConfidenceDerived, result.Escalated,
+					tt.want, tt.wantDerived, tt.wantEscalated)
+			}
+		})
+	}
+}
+
+func TestInvestigationRunner_FindingsFromFinalMessage(t *testing.T) {
+	bash := port.ToolCallInfo{ToolID: "call_bash", ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}}
+
+	tests := []struct {
+		name           string
+		final          string
+		finalCalls     []port.ToolCallInfo // nil for a free-text completion
+		wantFindings   []string
+		wantRootCause  string
+		wantConfidence float64
+		wantDerived    bool
+	}{
+		{
+			name: "fenced JSON in the final message",
+			final: "I'm done investigating.\n```json\n{\n" +
+				"  \"findings\": [\"[critical] /var is 100% full\", \"[info] logrotate is off\",],\n" +
+				"  \"root_cause\": \"logrotate disabled\",\n  \"confidence\": 0.8\n}\n```\nLet me know if you need more.",
+			wantFindings:   []string{"[critical] /var is 100% full", "[info] logrotate is off"},
+			wantRootCause:  "logrotate disabled",
+			wantConfidence: 0.8,
+		},
+		{
+			name:           "prose without JSON",
+			final:          "The disk is full [critical]. Confidence: 0.6",
+			wantConfidence: 0.6,
+		},
+		{
+			name:           "JSON without findings",
+			final:          `Disk usage: {"used": "100%"}`,
+			wantConfidence: maxDerivedConfidence,
+			wantDerived:    true,
+		},
+		{
+			name:  "findings sent to complete_investigation as a string",
+			final: "Done.",
+			finalCalls: []port.ToolCallInfo{{
+				ToolID: "call_done", ToolName: toolCompleteInvestigation, Input: map[string]interface{}{
+					"findings":   `["[critical] /var is full", "[info] logrotate is off"]`,
+					"confidence": 0.9,
+				},
+			}},
+			wantFindings:   []string{"[critical] /var is full", "[info] logrotate is off"},
+			wantConfidence: 0.9,
+		},
+		{
+			name:  "a single finding sent as a string",
+			final: "Done.",
+			finalCalls: []port.ToolCallInfo{{
+				ToolID: "call_done", ToolName: toolCompleteInvestigation, Input: map[string]interface{}{
+					"findings":   "[critical] /var is full",
+					"confidence": 0.9,
+				},
+			}},
+			wantFindings:   []string{"[critical] /var is full"},
+			wantConfidence: 0.9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.startConversationSession = "inv-session-final"
+			convService.processResponseMessages = []*entity.Message{
+				createAssistantMessage("Checking."),
+				createAssistantMessage(tt.final),
+			}
+			convService.processResponseToolCalls = [][]port.ToolCallInfo{{bash}, tt.finalCalls}
+
+			runner := NewInvestigationRunner(
+				convService,
+				newInvestigationRunnerToolExecutorMock(),
+				nil, // safetyEnforcer
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute},
+			)
+
+			result, err := runner.Run(context.Background(), createTestAlert("alert-final", "warning", "Disk"), "inv-final")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !slices.Equal(result.Findings, tt.wantFindings) || result.RootCause != tt.wantRootCause {
+				t.Errorf("findings %q, root cause %q; want %q, %q",
+					result.Findings, result.RootCause, tt.wantFindings, tt.wantRootCause)
+			}
+			if math.Abs(result.Confidence-tt.wantConfidence) > 1e-9 || result.ConfidenceDerived != tt.wantDerived {
+				t.Errorf("confidence %v derived %v, want %v derived %v",
+					result.Confidence, result.ConfidenceDerived, tt.wantConfidence, tt.wantDerived)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import "log/slog"
+
+// ToolRecommender is implemented by prompt builders whose alert type needs
+// tools beyond the configured AllowedTools, such as query_logs for disk space
+// alerts.
+type ToolRecommender interface {
+	// RecommendedTools returns the names of the tools to add for the alert type.
+	RecommendedTools() []string
+}
+
+// ToolRestrictor is implemented by prompt builders whose alert type must not
+// use some of the configured AllowedTools, such as a security alert that is
+// investigated read-only.
+type ToolRestrictor interface {
+	// RestrictedTools returns the names of the tools to take away for the alert type.
+	RestrictedTools() []string
+}
+
+// investigationToolSet is the tools one investigation may use.
+type investigationToolSet struct {
+	allowed    map[string]bool // nil allows every tool not restricted
+	restricted map[string]bool
+}
+
+// allows reports whether the set includes the tool named name.
+func (s investigationToolSet) allows(name string) bool {
+	if s.restricted[name] {
+		return false
+	}
+	return s.allowed == nil || s.allowed[name]
+}
+
+// builderForAlert returns the builder the registry uses for alert: the one
+// registered for its alertname label, else the Generic one, else nil.
+func builderForAlert(registry PromptBuilderRegistry, alert *AlertView) InvestigationPromptBuilder {
+	if registry == nil {
+		return nil
+	}
+	if alertType := alert.LabelValue(alertNameLabel); alertType != "" {
+		if builder, err := registry.Get(alertType); err == nil {
+			return builder
+		}
+	}
+	builder, err := registry.Get(AlertTypeGeneric)
+	if err != nil {
+		return nil
+	}
+	return builder
+}
+
+// resolveInvestigationTools returns the tools an investigation may use:
+// allowedTools (nil allows all) with the tools builder recommends added and the
+// tools it restricts taken away. A recommended tool that enforcer blocks is
+// left out with a log line, since the safety policy wins over an alert type.
+func resolveInvestigationTools(
+	allowedTools []string,
+	builder InvestigationPromptBuilder,
+	enforcer SafetyEnforcer,
+	logger *slog.Logger,
+) investigationToolSet {
+	var set investigationToolSet
+	if allowedTools != nil {
+		set.allowed = make(map[string]bool, len(allowedTools))
+		for _, name := range allowedTools {
+			set.allowed[name] = true
+		}
+	}
+
+	if recommender, ok := builder.(ToolRecommender); ok {
+		for _, name := range recommender.RecommendedTools() {
+			if enforcer != nil && enforcer.CheckToolAllowed(name) != nil {
+				if logger == nil {
+					logger = slog.Default()
+				}
+				logger.Warn("recommended investigation tool blocked by safety policy",
+					"tool", name, "alert_type", builder.AlertType())
+				continue
+			}
+			if set.allowed != nil {
+				set.allowed[name] = true
+			}
+		}
+	}
+
+	if restrictor, ok := builder.(ToolRestrictor); ok {
+		for _, name := range restrictor.RestrictedTools() {
+			if set.restricted == nil {
+				set.restricted = make(map[string]bool)
+			}
+			set.restricted[name] = true
+		}
+	}
+	return set
+}
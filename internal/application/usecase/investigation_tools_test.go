@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// toolDeclaringBuilder is a prompt builder that declares recommended and
+// restricted tools and records the tools its prompts list.
+type toolDeclaringBuilder struct {
+	alertType   string
+	recommended []string
+	restricted  []string
+	promptTools []string
+}
+
+func (b *toolDeclaringBuilder) AlertType() string { return b.alertType }
+
+func (b *toolDeclaringBuilder) RecommendedTools() []string { return b.recommended }
+
+func (b *toolDeclaringBuilder) RestrictedTools() []string { return b.restricted }
+
+func (b *toolDeclaringBuilder) BuildPrompt(
+	_ context.Context,
+	_ *AlertView,
+	tools []entity.Tool,
+	_ []port.SkillInfo,
+) (string, error) {
+	b.promptTools = nil
+	for _, tool := range tools {
+		b.promptTools = append(b.promptTools, tool.Name)
+	}
+	return "Investigate the " + b.alertType + " alert.", nil
+}
+
+// newToolDeclaringRegistry registers a disk space builder that recommends
+// query_logs and a security builder that restricts bash, with a Generic fallback.
+func newToolDeclaringRegistry(
+	t *testing.T,
+) (registry *DefaultPromptBuilderRegistry, disk, security, generic *toolDeclaringBuilder) {
+	t.Helper()
+	disk = &toolDeclaringBuilder{alertType: "DiskSpace", recommended: []string{"bash", "query_logs"}}
+	security = &toolDeclaringBuilder{alertType: "Security", restricted: []string{"bash"}}
+	generic = &toolDeclaringBuilder{alertType: AlertTypeGeneric}
+	registry = NewPromptBuilderRegistry()
+	for _, builder := range []*toolDeclaringBuilder{disk, security, generic} {
+		if err := registry.Register(builder); err != nil {
+			t.Fatalf("Register(%s) error = %v", builder.alertType, err)
+		}
+	}
+	return registry, disk, security, generic
+}
+
+// allowedAmong returns the tools of names that set allows, in order.
+func allowedAmong(set investigationToolSet, names ...string) []string {
+	var allowed []string
+	for _, name := range names {
+		if set.allows(name) {
+			allowed = append(allowed, name)
+		}
+	}
+	return allowed
+}
+
+func TestResolveInvestigationTools(t *testing.T) {
+	registry, _, _, _ := newToolDeclaringRegistry(t)
+	candidates := []string{"bash", "list_files", "query_logs", "read_file"}
+
+	tests := []struct {
+		name         string
+		alertname    string
+		allowedTools []string
+		want         []string
+	}{
+		{name: "recommended tools are added", alertname: "DiskSpace",
+			allowedTools: []string{"read_file", "list_files"}, want: []string{"bash", "list_files", "query_logs", "read_file"}},
+		{name: "restricted tools are taken away", alertname: "Security",
+			allowedTools: []string{"bash", "read_file", "list_files"}, want: []string{"list_files", "read_file"}},
+		{name: "restricted tools are taken away when all are allowed", alertname: "Security",
+			want: []string{"list_files", "query_logs", "read_file"}},
+		{name: "generic fallback declares nothing", alertname: "HighCPU",
+			allowedTools: []string{"read_file"}, want: []string{"read_file"}},
+		{name: "no tools configured gets only the recommended ones", alertname: "DiskSpace",
+			allowedTools: []string{}, want: []string{"bash", "query_logs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &AlertView{labels: map[string]string{alertNameLabel: tt.alertname}}
+			set := resolveInvestigationTools(tt.allowedTools, builderForAlert(registry, alert), nil, nil)
+			if got := allowedAmong(set, candidates...); !slices.Equal(got, tt.want) {
+				t.Errorf("allowed tools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveInvestigationTools_BlockedRecommendationIsLogged(t *testing.T) {
+	builder := &toolDeclaringBuilder{alertType: "DiskSpace", recommended: []string{"bash", "query_logs"}}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	set := resolveInvestigationTools([]string{"read_file"}, builder,
+		NewMockSafetyEnforcerWithBlockedTools([]string{"bash"}), logger)
+
+	got := allowedAmong(set, "bash", "query_logs", "read_file")
+	if !slices.Equal(got, []string{"query_logs", "read_file"}) {
+		t.Errorf("allowed tools = %v, want the blocked recommendation left out", got)
+	}
+	if !strings.Contains(logs.String(), "recommended investigation tool blocked by safety policy") ||
+		!strings.Contains(logs.String(), "tool=bash") {
+		t.Errorf("log = %q, want a line naming the blocked tool", logs.String())
+	}
+}
+
+func TestInvestigationRunner_PromptListsEffectiveTools(t *testing.T) {
+	registry, disk, security, _ := newToolDeclaringRegistry(t)
+	toolExecutor := newInvestigationRunnerToolExecutorMock()
+	_ = toolExecutor.RegisterTool(entity.Tool{Name: "query_logs", Description: "Query logs"})
+	config := AlertInvestigationUseCaseConfig{
+		MaxActions:   20,
+		MaxDuration:  15 * time.Minute,
+		AllowedTools: []string{"bash", "read_file"},
+	}
+
+	run := func(alertname string, toolCalls []port.ToolCallInfo) {
+		t.Helper()
+		convService := newInvestigationRunnerConvServiceMock()
+		convService.processResponseMessages = []*entity.Message{
+			createAssistantMessage("Checking."),
+			createAssistantMessage("Investigation complete."),
+		}
+		convService.processResponseToolCalls = [][]port.ToolCallInfo{toolCalls, nil}
+		runner := NewInvestigationRunner(convService, toolExecutor, NewMockSafetyEnforcer(), registry, nil, nil, config)
+		alert := createTestAlert("alert-"+alertname, "warning", alertname)
+		alert.labels[alertNameLabel] = alertname
+		if _, err := runner.Run(context.Background(), alert, "inv-"+alertname); err != nil {
+			t.Fatalf("Run(%s) error = %v", alertname, err)
+		}
+	}
+
+	run("DiskSpace", []port.ToolCallInfo{{ToolID: "t1", ToolName: "query_logs", Input: map[string]interface{}{}}})
+	if want := []string{"bash", "query_logs", "read_file"}; !slices.Equal(disk.promptTools, want) {
+		t.Errorf("DiskSpace prompt tools = %v, want %v", disk.promptTools, want)
+	}
+	if !slices.Equal(toolExecutor.executeToolName, []string{"query_logs"}) {
+		t.Errorf("executed tools = %v, want the recommended query_logs to run", toolExecutor.executeToolName)
+	}
+
+	run("Security", []port.ToolCallInfo{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "ls"}}})
+	if want := []string{"read_file"}; !slices.Equal(security.promptTools, want) {
+		t.Errorf("Security prompt tools = %v, want %v", security.promptTools, want)
+	}
+	if !slices.Equal(toolExecutor.executeToolName, []string{"query_logs"}) {
+		t.Errorf("executed tools = %v, want the restricted bash refused", toolExecutor.executeToolName)
+	}
+}
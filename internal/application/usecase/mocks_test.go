@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"code-editing-agent/internal/domain/entity"
 	"context"
 	"errors"
 	"sync"
@@ -123,6 +124,15 @@ type mockInvestigationRecord struct {
 	confidence                     float64
 	escalated                      bool
 	escalateReason                 string
+	errorMessage                   string
+	errorKind                      string
+	alert                          *entity.Alert
+	rootCause                      string
+	actions                        []entity.RecommendedAction
+	remediations                   []entity.RemediationAttempt
+	cost                           float64
+	usage                          entity.TokenUsage
+	version                        string
 }
 
 func (s *mockInvestigationRecord) ID() string        { return s.id }
@@ -142,6 +152,19 @@ func (s *mockInvestigationRecord) Duration() time.Duration { return time.Duratio
 func (s *mockInvestigationRecord) Confidence() float64     { return s.confidence }
 func (s *mockInvestigationRecord) Escalated() bool         { return s.escalated }
 func (s *mockInvestigationRecord) EscalateReason() string  { return s.escalateReason }
+func (s *mockInvestigationRecord) ErrorMessage() string    { return s.errorMessage }
+func (s *mockInvestigationRecord) ErrorKind() string       { return s.errorKind }
+func (s *mockInvestigationRecord) Alert() *entity.Alert    { return s.alert }
+func (s *mockInvestigationRecord) RootCause() string       { return s.rootCause }
+func (s *mockInvestigationRecord) RecommendedActions() []entity.RecommendedAction {
+	return s.actions
+}
+func (s *mockInvestigationRecord) Remediations() []entity.RemediationAttempt {
+	return s.remediations
+}
+func (s *mockInvestigationRecord) Cost() float64            { return s.cost }
+func (s *mockInvestigationRecord) Usage() entity.TokenUsage { return s.usage }
+func (s *mockInvestigationRecord) Version() string          { return s.version }
 
 // MockInvestigationStore is a test double for InvestigationStoreWriter interface.
 type MockInvestigationStore struct {
@@ -177,11 +200,15 @@ func (m *MockInvestigationStore) Store(ctx context.Context, inv InvestigationRec
 	}
 
 	m.data[inv.ID()] = &mockInvestigationRecord{
-		id:        inv.ID(),
-		alertID:   inv.AlertID(),
-		sessionID: inv.SessionID(),
-		status:    inv.Status(),
-		startedAt: inv.StartedAt(),
+		id:           inv.ID(),
+		alertID:      inv.AlertID(),
+		sessionID:    inv.SessionID(),
+		status:       inv.Status(),
+		startedAt:    inv.StartedAt(),
+		completedAt:  inv.CompletedAt(),
+		errorMessage: inv.ErrorMessage(),
+		errorKind:    inv.ErrorKind(),
+		alert:        inv.Alert(),
 	}
 	return nil
 }
@@ -226,11 +253,24 @@ func (m *MockInvestigationStore) Update(ctx context.Context, inv InvestigationRe
 	}
 
 	m.data[inv.ID()] = &mockInvestigationRecord{
-		id:        inv.ID(),
-		alertID:   inv.AlertID(),
-		sessionID: inv.SessionID(),
-		status:    inv.Status(),
-		startedAt: inv.StartedAt(),
+		id:             inv.ID(),
+		alertID:        inv.AlertID(),
+		sessionID:      inv.SessionID(),
+		status:         inv.Status(),
+		startedAt:      inv.StartedAt(),
+		completedAt:    inv.CompletedAt(),
+		findings:       inv.Findings(),
+		actionsTaken:   inv.ActionsTaken(),
+		durationNanos:  int64(inv.Duration()),
+		confidence:     inv.Confidence(),
+		escalated:      inv.Escalated(),
+		escalateReason: inv.EscalateReason(),
+		errorMessage:   inv.ErrorMessage(),
+		errorKind:      inv.ErrorKind(),
+		alert:          inv.Alert(),
+		rootCause:      inv.RootCause(),
+		actions:        inv.RecommendedActions(),
+		remediations:   inv.Remediations(),
 	}
 	return nil
 }
@@ -242,3 +282,60 @@ func (m *MockInvestigationStore) Close() error {
 	m.closed = true
 	return nil
 }
+
+// withStatus returns the stored investigations with the given status.
+func (m *MockInvestigationStore) withStatus(status string) []*mockInvestigationRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []*mockInvestigationRecord
+	for _, record := range m.data {
+		if record.status == status {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// ListByStatus implements InvestigationStatusLister.
+func (m *MockInvestigationStore) ListByStatus(_ context.Context, status string) ([]InvestigationRecordData, error) {
+	var records []InvestigationRecordData
+	for _, record := range m.withStatus(status) {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// mockSuppressionStore is an in-memory AlertSuppressionStore.
+type mockSuppressionStore struct {
+	mu           sync.Mutex
+	suppressions map[string]*entity.AlertSuppression
+	err          error // Returned by Suppression when set
+}
+
+func newMockSuppressionStore() *mockSuppressionStore {
+	return &mockSuppressionStore{suppressions: make(map[string]*entity.AlertSuppression)}
+}
+
+func (m *mockSuppressionStore) SaveSuppression(_ context.Context, suppression *entity.AlertSuppression) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suppressions[suppression.Fingerprint] = suppression
+	return nil
+}
+
+func (m *mockSuppressionStore) Suppression(_ context.Context, fingerprint string) (*entity.AlertSuppression, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.suppressions[fingerprint], nil
+}
+
+func (m *mockSuppressionStore) DeleteSuppression(_ context.Context, fingerprint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.suppressions, fingerprint)
+	return nil
+}
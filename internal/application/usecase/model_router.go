@@ -0,0 +1,64 @@
+package usecase
+
+import "code-editing-agent/internal/domain/port"
+
+// ModelTask is a class of AI work that can be routed to its own model, so
+// routine work such as summarizing need not run on the frontier model.
+type ModelTask string
+
+// Model tasks that can be routed.
+const (
+	ModelTaskSubagent           ModelTask = "subagent"            // Subagents whose agent inherits the model
+	ModelTaskSummarization      ModelTask = "summarization"       // Summaries of subagent output and reports
+	ModelTaskFindingsExtraction ModelTask = "findings_extraction" // Extracting findings from investigation output
+	ModelTaskTitleGeneration    ModelTask = "title_generation"    // Short titles for sessions and reports
+)
+
+// ModelTasks returns every routable task, in documentation order.
+func ModelTasks() []ModelTask {
+	return []ModelTask{
+		ModelTaskSubagent,
+		ModelTaskSummarization,
+		ModelTaskFindingsExtraction,
+		ModelTaskTitleGeneration,
+	}
+}
+
+// ModelRouter picks the model serving each task from a routing table. Routes
+// go through the provider's capability registry: a model the provider
+// reports cannot use tools is never chosen for a task that needs them.
+// A nil router routes nothing. It is immutable and safe for concurrent use.
+type ModelRouter struct {
+	provider port.AIProvider
+	routes   map[ModelTask]string
+}
+
+// NewModelRouter creates a router of tasks to models for provider. Route
+// models may be full model IDs or the shorthands haiku, sonnet, and opus;
+// tasks without a route, or routed to "inherit", keep the current model.
+func NewModelRouter(provider port.AIProvider, routes map[ModelTask]string) *ModelRouter {
+	resolved := make(map[ModelTask]string, len(routes))
+	for task, model := range routes {
+		if model = resolveModelShorthand(model); model != "" {
+			resolved[task] = model
+		}
+	}
+	return &ModelRouter{provider: provider, routes: resolved}
+}
+
+// Route returns the model to serve task with, or "" to keep the provider's
+// current model: when the task has no route, or when needsTools is set and
+// the routed model does not support tools.
+func (r *ModelRouter) Route(task ModelTask, needsTools bool) string {
+	if r == nil {
+		return ""
+	}
+	model, ok := r.routes[task]
+	if !ok {
+		return ""
+	}
+	if caps, reported := port.CapabilitiesOf(r.provider, model); needsTools && reported && !caps.SupportsTools {
+		return ""
+	}
+	return model
+}
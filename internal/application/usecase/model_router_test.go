@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"testing"
+)
+
+// capabilityAIProviderMock reports capabilities per model; models it does not
+// list support everything.
+type capabilityAIProviderMock struct {
+	*subagentRunnerAIProviderMock
+	caps map[string]port.ModelCapabilities
+}
+
+func (m *capabilityAIProviderMock) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	if caps, ok := m.caps[model]; ok {
+		return caps, true
+	}
+	return port.ModelCapabilities{SupportsTools: true, SupportsThinking: true, SupportsImages: true}, false
+}
+
+func newCapabilityAIProviderMock(caps map[string]port.ModelCapabilities) *capabilityAIProviderMock {
+	return &capabilityAIProviderMock{subagentRunnerAIProviderMock: newSubagentRunnerAIProviderMock(), caps: caps}
+}
+
+func TestModelRouter_Route(t *testing.T) {
+	provider := newCapabilityAIProviderMock(map[string]port.ModelCapabilities{
+		"tiny-summarizer": {MaxOutputTokens: 2048},
+	})
+	router := NewModelRouter(provider, map[ModelTask]string{
+		ModelTaskSubagent:           "haiku",
+		ModelTaskSummarization:      "tiny-summarizer",
+		ModelTaskFindingsExtraction: "claude-haiku-4-5",
+		ModelTaskTitleGeneration:    "inherit",
+	})
+
+	tests := []struct {
+		task       ModelTask
+		needsTools bool
+		want       string
+	}{
+		{task: ModelTaskSubagent, needsTools: true, want: "claude-3-5-haiku-20241022"},
+		{task: ModelTaskSummarization, want: "tiny-summarizer"},
+		// The capability guard: a model without tools is never chosen for tool use
+		{task: ModelTaskSummarization, needsTools: true, want: ""},
+		{task: ModelTaskFindingsExtraction, want: "claude-haiku-4-5"},
+		{task: ModelTaskTitleGeneration, want: ""},
+		{task: ModelTask("unrouted"), want: ""},
+	}
+	for _, tt := range tests {
+		if got := router.Route(tt.task, tt.needsTools); got != tt.want {
+			t.Errorf("Route(%q, %v) = %q, want %q", tt.task, tt.needsTools, got, tt.want)
+		}
+	}
+
+	// Providers that do not report capabilities are trusted with the route
+	plain := NewModelRouter(newSubagentRunnerAIProviderMock(), map[ModelTask]string{ModelTaskSubagent: "tiny-summarizer"})
+	if got := plain.Route(ModelTaskSubagent, true); got != "tiny-summarizer" {
+		t.Errorf("Route() without capability reporting = %q, want tiny-summarizer", got)
+	}
+	var none *ModelRouter
+	if got := none.Route(ModelTaskSubagent, true); got != "" {
+		t.Errorf("nil router Route() = %q, want \"\"", got)
+	}
+}
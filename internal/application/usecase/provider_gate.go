@@ -0,0 +1,305 @@
+// Package usecase contains application use cases that orchestrate domain logic.
+// This file implements the gate that holds investigations while the AI
+// provider is down instead of letting each of them fail.
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Defaults for ProviderGateConfig.
+const (
+	defaultProviderCacheTTL       = 5 * time.Second
+	defaultProviderInitialBackoff = 5 * time.Second
+	defaultProviderMaxBackoff     = 2 * time.Minute
+)
+
+// ProviderHealthChecker reports whether the AI provider can serve requests.
+// port.AIProvider implements it.
+type ProviderHealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ResumePacer paces the investigations a ProviderGate releases after an
+// outage. The AI request rate limiter implements it.
+type ResumePacer interface {
+	// Wait blocks until a request of tokens may be sent, returning how long it
+	// waited, or an error if ctx is done first.
+	Wait(ctx context.Context, tokens int) (time.Duration, error)
+}
+
+// ProviderGateConfig configures a ProviderGate.
+type ProviderGateConfig struct {
+	CacheTTL       time.Duration // How long a health check result is reused; 0 means 5s
+	InitialBackoff time.Duration // First delay between health checks while degraded; 0 means 5s
+	MaxBackoff     time.Duration // Longest delay between health checks while degraded; 0 means 2m
+	MaxHold        time.Duration // Longest an investigation is held before Wait gives up; 0 means no limit
+}
+
+// gateWaiter is an investigation held by the gate.
+type gateWaiter struct {
+	rank       int  // severityRank of its alert
+	historical bool // Backfilled alerts resume after live ones
+	heldAt     time.Time
+	released   chan struct{} // Closed once the investigation may run
+}
+
+// ProviderGate holds investigations while the AI provider's health check
+// fails, so an outage queues alerts instead of failing every investigation.
+// Healthy results are cached for CacheTTL. Once a check fails the gate is
+// degraded: every investigation waits, and a single background loop repeats
+// the check with exponential backoff. When the provider is healthy again the
+// held investigations are released one at a time in priority order, live
+// before historical, most severe first, then oldest, each after the pacer
+// allows a request, so they do not all hit the provider at once. It is safe
+// for concurrent use.
+type ProviderGate struct {
+	checker ProviderHealthChecker
+	config  ProviderGateConfig
+	now     func() time.Time
+
+	mu         sync.Mutex
+	pacer      ResumePacer
+	logger     *slog.Logger
+	checkedAt  time.Time // When the cached result was taken
+	healthErr  error     // Cached result of the latest check
+	degraded   bool
+	since      time.Time // When the gate became degraded
+	recovering bool      // The background loop is checking or releasing
+	waiters    []*gateWaiter
+}
+
+// NewProviderGate creates a gate for the provider behind checker, healthy
+// until its first check says otherwise.
+func NewProviderGate(checker ProviderHealthChecker, config ProviderGateConfig) *ProviderGate {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultProviderCacheTTL
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultProviderInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultProviderMaxBackoff
+	}
+	config.MaxBackoff = max(config.MaxBackoff, config.InitialBackoff)
+	return &ProviderGate{checker: checker, config: config, now: time.Now, logger: slog.Default()}
+}
+
+// SetPacer sets what paces the investigations released after an outage.
+// Without one, they are released back to back.
+func (g *ProviderGate) SetPacer(pacer ResumePacer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pacer = pacer
+}
+
+// SetLogger sets the logger for outages and recoveries. A nil logger restores slog.Default().
+func (g *ProviderGate) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.logger = logger
+}
+
+// Wait returns once an investigation of alert may call the AI provider: at
+// once while the provider is healthy and nothing is held, otherwise when the
+// gate releases it. It returns an error wrapping ErrProviderUnavailable once
+// it has held the investigation for MaxHold, and ctx.Err() if ctx is done
+// first.
+func (g *ProviderGate) Wait(ctx context.Context, alert *AlertForInvestigation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	if !g.degraded && len(g.waiters) == 0 {
+		err := g.cachedCheckLocked(ctx)
+		if err == nil {
+			g.mu.Unlock()
+			return nil
+		}
+		g.degradeLocked(err)
+	}
+	waiter := &gateWaiter{
+		rank:       severityRank(alert.Severity()),
+		historical: alert.Historical(),
+		heldAt:     g.now(),
+		released:   make(chan struct{}),
+	}
+	g.waiters = append(g.waiters, waiter)
+	g.startRecoveryLocked()
+	g.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if g.config.MaxHold > 0 {
+		timer := time.NewTimer(g.config.MaxHold)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-waiter.released:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = fmt.Errorf("%w for %s: %s", ErrProviderUnavailable, g.config.MaxHold, g.reason())
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if i := slices.Index(g.waiters, waiter); i >= 0 {
+		g.waiters = slices.Delete(g.waiters, i, i+1)
+		return err
+	}
+	// Released while giving up; run rather than waste the release
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Status returns whether the gate is degraded, since when and why, and how
+// many investigations it holds, or nil while the provider is healthy.
+func (g *ProviderGate) Status() *port.DegradedStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.degraded {
+		return nil
+	}
+	return &port.DegradedStatus{Since: g.since, Reason: g.reasonLocked(), Held: len(g.waiters)}
+}
+
+// reason returns why the gate is degraded.
+func (g *ProviderGate) reason() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.reasonLocked()
+}
+
+// reasonLocked is reason for callers holding g.mu.
+func (g *ProviderGate) reasonLocked() string {
+	if g.healthErr == nil {
+		return "health check failed"
+	}
+	return g.healthErr.Error()
+}
+
+// cachedCheckLocked returns the cached health check result, checking again
+// once it is older than CacheTTL.
+func (g *ProviderGate) cachedCheckLocked(ctx context.Context) error {
+	if !g.checkedAt.IsZero() && g.now().Sub(g.checkedAt) < g.config.CacheTTL {
+		return g.healthErr
+	}
+	g.healthErr = g.checker.HealthCheck(ctx)
+	g.checkedAt = g.now()
+	return g.healthErr
+}
+
+// degradeLocked marks the gate degraded by err.
+func (g *ProviderGate) degradeLocked(err error) {
+	g.degraded = true
+	g.since = g.now()
+	g.logger.Warn("AI provider unhealthy; holding investigations until it recovers", "error", err)
+}
+
+// startRecoveryLocked starts the loop that waits for the provider to recover
+// and releases the held investigations, unless it is running.
+func (g *ProviderGate) startRecoveryLocked() {
+	if g.recovering {
+		return
+	}
+	g.recovering = true
+	go g.awaitRecovery()
+}
+
+// awaitRecovery checks the provider with exponential backoff until it is healthy,
+// then releases the held investigations in priority order.
+func (g *ProviderGate) awaitRecovery() {
+	ctx := context.Background()
+	backoff := g.config.InitialBackoff
+	for g.isDegraded() {
+		time.Sleep(backoff)
+		backoff = min(backoff*2, g.config.MaxBackoff)
+
+		err := g.checker.HealthCheck(ctx)
+		g.mu.Lock()
+		g.healthErr = err
+		g.checkedAt = g.now()
+		if err == nil {
+			g.logger.Info("AI provider healthy again; resuming held investigations",
+				"held", len(g.waiters), "degraded_for", g.now().Sub(g.since))
+			g.degraded = false
+		} else {
+			g.logger.Debug("AI provider still unhealthy", "error", err, "next_check", backoff)
+		}
+		g.mu.Unlock()
+	}
+
+	for g.pace(ctx) {
+		g.mu.Lock()
+		if len(g.waiters) > 0 {
+			i := g.nextWaiterLocked()
+			close(g.waiters[i].released)
+			g.waiters = slices.Delete(g.waiters, i, i+1)
+		}
+		g.mu.Unlock()
+	}
+}
+
+// pace waits until the pacer allows the next held investigation to run. It
+// reports false, ending the recovery loop, once nothing is held.
+func (g *ProviderGate) pace(ctx context.Context) bool {
+	g.mu.Lock()
+	if len(g.waiters) == 0 {
+		g.recovering = false
+		g.mu.Unlock()
+		return false
+	}
+	pacer, logger := g.pacer, g.logger
+	g.mu.Unlock()
+	if pacer != nil {
+		if _, err := pacer.Wait(ctx, 0); err != nil {
+			logger.Warn("Failed to pace resumed investigation", "error", err)
+		}
+	}
+	return true
+}
+
+// isDegraded reports whether the gate is degraded.
+func (g *ProviderGate) isDegraded() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.degraded
+}
+
+// nextWaiterLocked returns the index of the held investigation to release
+// next: live before historical, most severe first, then the longest held.
+func (g *ProviderGate) nextWaiterLocked() int {
+	best := 0
+	for i, w := range g.waiters[1:] {
+		b := g.waiters[best]
+		switch {
+		case w.historical != b.historical:
+			if !w.historical {
+				best = i + 1
+			}
+		case w.rank != b.rank:
+			if w.rank < b.rank {
+				best = i + 1
+			}
+		case w.heldAt.Before(b.heldAt):
+			best = i + 1
+		}
+	}
+	return best
+}
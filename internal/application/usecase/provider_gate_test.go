@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Provider Gate Tests
+// =============================================================================
+//
+// These tests verify that a ProviderGate holds investigations while the AI
+// provider is unhealthy, releases them in priority order through its pacer
+// once it recovers, and gives up on them after MaxHold so they are deferred.
+//
+// =============================================================================
+
+// fakeProviderHealth is a ProviderHealthChecker whose health tests toggle.
+type fakeProviderHealth struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *fakeProviderHealth) HealthCheck(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err
+}
+
+func (f *fakeProviderHealth) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeProviderHealth) checks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// steppedPacer lets the gate release one investigation per value sent on allow.
+type steppedPacer struct {
+	allow chan struct{}
+}
+
+func (p *steppedPacer) Wait(ctx context.Context, _ int) (time.Duration, error) {
+	select {
+	case <-p.allow:
+		return 0, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// newTestProviderGate returns a gate that rechecks health every few milliseconds.
+func newTestProviderGate(health *fakeProviderHealth, maxHold time.Duration) *ProviderGate {
+	return NewProviderGate(health, ProviderGateConfig{
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		MaxHold:        maxHold,
+	})
+}
+
+// waitForHeld waits until gate holds want investigations.
+func waitForHeld(t *testing.T, gate *ProviderGate, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := gate.Status()
+		if status != nil && status.Held == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Status() = %+v, want %d investigations held", status, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestProviderGate_PassesWhileHealthy(t *testing.T) {
+	health := &fakeProviderHealth{}
+	gate := newTestProviderGate(health, 0)
+	now := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+	gate.now = func() time.Time { return now }
+
+	for range 3 {
+		if err := gate.Wait(context.Background(), diskAlert("DiskFull-1")); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if got := health.checks(); got != 1 {
+		t.Errorf("health checks = %d, want 1 cached for CacheTTL", got)
+	}
+	if status := gate.Status(); status != nil {
+		t.Errorf("Status() = %+v, want nil while healthy", status)
+	}
+
+	now = now.Add(defaultProviderCacheTTL)
+	if err := gate.Wait(context.Background(), diskAlert("DiskFull-2")); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := health.checks(); got != 2 {
+		t.Errorf("health checks = %d, want 2 once the cached result expired", got)
+	}
+}
+
+func TestProviderGate_HoldsUntilRecoveryThenResumesInOrder(t *testing.T) {
+	health := &fakeProviderHealth{err: errors.New("connection refused")}
+	gate := newTestProviderGate(health, 0)
+	pacer := &steppedPacer{allow: make(chan struct{})}
+	gate.SetPacer(pacer)
+
+	alerts := []*AlertForInvestigation{
+		{id: "info-live", severity: "info"},
+		{id: "critical-historical", severity: "critical", historical: true},
+		{id: "warning-live", severity: "warning"},
+		{id: "critical-live", severity: "critical"},
+	}
+	released := make(chan string, len(alerts))
+	for i, alert := range alerts {
+		go func() {
+			if err := gate.Wait(context.Background(), alert); err != nil {
+				t.Errorf("Wait(%s) error = %v", alert.ID(), err)
+			}
+			released <- alert.ID()
+		}()
+		// Hold them one at a time so each is held longer than the next
+		waitForHeld(t, gate, i+1)
+	}
+
+	status := gate.Status()
+	if status.Reason != "connection refused" || status.Since.IsZero() {
+		t.Errorf("Status() = %+v, want degraded by connection refused", status)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(released) != 0 {
+		t.Fatalf("%d investigations released while the provider is down, want 0", len(released))
+	}
+
+	health.set(nil)
+	want := []string{"critical-live", "warning-live", "info-live", "critical-historical"}
+	for _, id := range want {
+		pacer.allow <- struct{}{}
+		if got := <-released; got != id {
+			t.Fatalf("released %s, want %s", got, id)
+		}
+	}
+	if status := gate.Status(); status != nil {
+		t.Errorf("Status() = %+v, want nil after recovery", status)
+	}
+}
+
+func TestProviderGate_GivesUpAfterMaxHold(t *testing.T) {
+	health := &fakeProviderHealth{err: errors.New("connection refused")}
+	gate := newTestProviderGate(health, 20*time.Millisecond)
+
+	err := gate.Wait(context.Background(), diskAlert("DiskFull-1"))
+	if !errors.Is(err, ErrProviderUnavailable) || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Wait() error = %v, want ErrProviderUnavailable with the health check error", err)
+	}
+	if status := gate.Status(); status == nil || status.Held != 0 {
+		t.Errorf("Status() = %+v, want degraded with nothing held", status)
+	}
+}
+
+func TestAlertInvestigationUseCase_RunInvestigation_HeldWhileProviderDown(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	uc := f.handler.investigationUseCase
+	convService := newInvestigationRunnerConvServiceMock()
+	uc.SetConversationService(convService)
+	health := &fakeProviderHealth{err: errors.New("connection refused")}
+	gate := newTestProviderGate(health, 0)
+	uc.SetProviderGate(gate)
+
+	invID, err := uc.StartInvestigation(ctx, diskAlert("DiskFull-1"))
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	done := make(chan *InvestigationResult, 1)
+	go func() {
+		result, err := uc.RunInvestigation(ctx, diskAlert("DiskFull-1"), invID)
+		if err != nil {
+			t.Errorf("RunInvestigation() error = %v", err)
+		}
+		done <- result
+	}()
+	waitForHeld(t, gate, 1)
+
+	if degraded := uc.Status().Degraded; degraded == nil || degraded.Held != 1 {
+		t.Errorf("Status().Degraded = %+v, want one investigation held", degraded)
+	}
+	if got := len(f.store.withStatus("completed")); got != 0 {
+		t.Fatalf("completed records = %d while the provider is down, want 0", got)
+	}
+
+	health.set(nil)
+	result := <-done
+	if result == nil || result.Status != "completed" {
+		t.Fatalf("RunInvestigation() = %+v, want it completed after recovery", result)
+	}
+	if convService.processResponseCalls == 0 {
+		t.Error("AI turns = 0, want the investigation to run after recovery")
+	}
+	if degraded := uc.Status().Degraded; degraded != nil {
+		t.Errorf("Status().Degraded = %+v, want nil after recovery", degraded)
+	}
+}
+
+func TestAlertInvestigationUseCase_RunInvestigation_DefersAfterMaxHold(t *testing.T) {
+	f := newSuppressionFixture(t)
+	ctx := context.Background()
+	uc := f.handler.investigationUseCase
+	convService := newInvestigationRunnerConvServiceMock()
+	uc.SetConversationService(convService)
+	notifier := &recordingResultNotifier{}
+	uc.SetResultNotifier(notifier)
+	uc.SetProviderGate(newTestProviderGate(&fakeProviderHealth{err: errors.New("connection refused")},
+		20*time.Millisecond))
+
+	invID, err := uc.StartInvestigation(ctx, diskAlert("DiskFull-1"))
+	if err != nil {
+		t.Fatalf("StartInvestigation() error = %v", err)
+	}
+	result, err := uc.RunInvestigation(ctx, diskAlert("DiskFull-1"), invID)
+	if err != nil || result.Status != "deferred" {
+		t.Fatalf("RunInvestigation() = %+v, %v; want it deferred", result, err)
+	}
+
+	deferred := f.store.withStatus("deferred")
+	if len(deferred) != 1 || !strings.Contains(deferred[0].errorMessage, "AI provider unavailable for 20ms") {
+		t.Fatalf("deferred records = %d, want DiskFull-1 deferred for the outage", len(deferred))
+	}
+	if len(notifier.results) != 1 || notifier.results[0].Status != "deferred" {
+		t.Errorf("notified results = %+v, want the deferred result", notifier.results)
+	}
+	if convService.processResponseCalls != 0 {
+		t.Errorf("AI turns = %d, want 0", convService.processResponseCalls)
+	}
+}
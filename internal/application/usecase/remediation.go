@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/entity/jsonextract"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ApprovalProvider decides whether an investigation may run the command of a
+// low-risk recommended action to remediate its alert.
+type ApprovalProvider interface {
+	ApproveRemediation(ctx context.Context, alert *AlertForInvestigation, action entity.RecommendedAction) (bool, error)
+}
+
+// UnattendedApprover approves remediations when no one is there to ask, as
+// for webhook alerts: it approves the actions the investigation did not mark
+// as requiring approval and declines the rest.
+type UnattendedApprover struct{}
+
+// ApproveRemediation approves action unless it requires approval.
+func (UnattendedApprover) ApproveRemediation(
+	_ context.Context,
+	_ *AlertForInvestigation,
+	action entity.RecommendedAction,
+) (bool, error) {
+	return !action.RequiresApproval, nil
+}
+
+// extractRecommendedActions extracts the recommended actions from tool input.
+// Each item is an object with a description and optionally a command, risk,
+// and requires_approval, or a plain string, read as the description of an
+// action of unknown risk. As with extractStringSlice, a string is read as the
+// list written out as JSON, or else as the only item.
+func extractRecommendedActions(input map[string]interface{}, key string) []entity.RecommendedAction {
+	items, ok := input[key].([]interface{})
+	if text, isText := input[key].(string); isText {
+		if err := jsonextract.Unmarshal(text, &items); err != nil {
+			items = []interface{}{text}
+		}
+		ok = true
+	}
+	if !ok {
+		return nil
+	}
+	var actions []entity.RecommendedAction
+	for _, item := range items {
+		if action, ok := recommendedActionFrom(item); ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// recommendedActionFrom reads one recommended action from tool input, or
+// reports false if item describes none.
+func recommendedActionFrom(item interface{}) (entity.RecommendedAction, bool) {
+	var action entity.RecommendedAction
+	switch item := item.(type) {
+	case string:
+		action.Description = strings.TrimSpace(item)
+	case map[string]interface{}:
+		action.Description, _ = item["description"].(string)
+		action.Description = strings.TrimSpace(action.Description)
+		action.Command, _ = item["command"].(string)
+		action.Command = strings.TrimSpace(action.Command)
+		risk, _ := item["risk"].(string)
+		action.Risk = entity.NormalizeRisk(risk)
+		switch approval := item["requires_approval"].(type) {
+		case bool:
+			action.RequiresApproval = approval
+		case string:
+			action.RequiresApproval = strings.EqualFold(strings.TrimSpace(approval), "true")
+		}
+		if action.Description == "" {
+			action.Description = action.Command
+		}
+	}
+	return action, action.Description != ""
+}
+
+// remediate runs the final phase of a completed investigation when
+// AutoRemediate is set: for each low-risk recommended action with a command,
+// it asks the approval provider and runs the approved commands with bash,
+// through the same allowlist and safety checks as the investigation's own
+// commands. Medium, high, and unknown risk actions are never run.
+func (r *InvestigationRunner) remediate(
+	rc *runContext,
+	actions []entity.RecommendedAction,
+) []entity.RemediationAttempt {
+	if !r.config.AutoRemediate || r.approver == nil {
+		return nil
+	}
+	var attempts []entity.RemediationAttempt
+	for _, action := range actions {
+		if !action.AutoRemediable() {
+			continue
+		}
+		if rc.ctx.Err() != nil {
+			break
+		}
+		attempt := r.attemptRemediation(rc, action, len(attempts)+1)
+		rc.logger.Info("Remediation attempted",
+			"command", action.Command, "status", attempt.Status)
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+// attemptRemediation asks for approval of action's command and runs it if
+// approved. n numbers the attempt within the run.
+func (r *InvestigationRunner) attemptRemediation(
+	rc *runContext,
+	action entity.RecommendedAction,
+	n int,
+) entity.RemediationAttempt {
+	attempt := entity.RemediationAttempt{Action: action, At: time.Now()}
+	approved, err := r.approver.ApproveRemediation(rc.ctx, rc.alert, action)
+	switch {
+	case err != nil:
+		attempt.Status = entity.RemediationDeclined
+		attempt.Output = fmt.Sprintf("approval failed: %v", err)
+		return attempt
+	case !approved:
+		attempt.Status = entity.RemediationDeclined
+		attempt.Output = "not approved"
+		return attempt
+	case !rc.tools.allows(toolBash):
+		attempt.Status = entity.RemediationBlocked
+		attempt.Output = fmt.Sprintf("tool '%s' is not allowed for this investigation", toolBash)
+		return attempt
+	}
+
+	tc := port.ToolCallInfo{
+		ToolID:   fmt.Sprintf("remediation_%d", n),
+		ToolName: toolBash,
+		Input:    map[string]interface{}{"command": action.Command},
+	}
+	start := time.Now()
+	result, blocked := r.executeToolCall(rc, tc)
+	r.emitStep(rc, port.InvestigationEvent{
+		Type:            port.InvestigationEventToolExecuted,
+		InvestigationID: rc.investigationID,
+		ToolName:        tc.ToolName,
+		Summary:         summarizeToolResult(result.Result),
+		Input:           toolInputForEvent(tc.Input),
+		Output:          truncateToolOutput(result.Result),
+		IsError:         result.IsError,
+		Duration:        time.Since(start),
+		Actions:         rc.actionsTaken,
+	})
+	attempt.Output = truncateToolOutput(result.Result)
+	switch {
+	case blocked:
+		attempt.Status = entity.RemediationBlocked
+	case result.IsError || bashExitCode(result.Result) != 0:
+		attempt.Status = entity.RemediationFailed
+	default:
+		attempt.Status = entity.RemediationSucceeded
+	}
+	return attempt
+}
+
+// bashExitCode returns the exit code in the output of the bash tool, which
+// reports a command that exits non-zero as a result rather than an error.
+func bashExitCode(output string) int {
+	var result struct {
+		ExitCode int `json:"exit_code"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0
+	}
+	return result.ExitCode
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Remediation Tests
+// =============================================================================
+//
+// These tests verify that complete_investigation's recommended actions are
+// parsed into structured actions, and that with AutoRemediate set a completed
+// run asks the approval provider about each low-risk command and runs only the
+// approved ones.
+//
+// =============================================================================
+
+// approverMock records the actions it is asked about and approves as told.
+type approverMock struct {
+	mu      sync.Mutex
+	asked   []entity.RecommendedAction
+	approve bool
+	err     error
+}
+
+func (m *approverMock) ApproveRemediation(
+	_ context.Context,
+	_ *AlertForInvestigation,
+	action entity.RecommendedAction,
+) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.asked = append(m.asked, action)
+	return m.approve, m.err
+}
+
+func TestExtractRecommendedActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  []entity.RecommendedAction
+	}{
+		{
+			name: "objects",
+			input: []interface{}{
+				map[string]interface{}{
+					"description": "Force a log rotation",
+					"command":     " logrotate -f /etc/logrotate.conf ",
+					"risk":        "Low",
+				},
+				map[string]interface{}{"description": "Grow the volume", "risk": "med", "requires_approval": true},
+				map[string]interface{}{"command": "systemctl restart nginx", "risk": "high", "requires_approval": "true"},
+			},
+			want: []entity.RecommendedAction{
+				{Description: "Force a log rotation", Command: "logrotate -f /etc/logrotate.conf", Risk: entity.RiskLow},
+				{Description: "Grow the volume", Risk: entity.RiskMedium, RequiresApproval: true},
+				{
+					Description: "systemctl restart nginx", Command: "systemctl restart nginx",
+					Risk: entity.RiskHigh, RequiresApproval: true,
+				},
+			},
+		},
+		{
+			name:  "strings are descriptions of unknown risk",
+			input: []interface{}{"Rotate logs", " ", map[string]interface{}{"risk": "low"}},
+			want:  []entity.RecommendedAction{{Description: "Rotate logs"}},
+		},
+		{
+			name:  "unrecognized risk is unknown",
+			input: []interface{}{map[string]interface{}{"description": "Reboot", "command": "reboot", "risk": "trivial"}},
+			want:  []entity.RecommendedAction{{Description: "Reboot", Command: "reboot"}},
+		},
+		{
+			name:  "list written out as JSON",
+			input: `[{"description": "Rotate logs", "command": "logrotate -f /etc/logrotate.conf", "risk": "low"}]`,
+			want: []entity.RecommendedAction{
+				{Description: "Rotate logs", Command: "logrotate -f /etc/logrotate.conf", Risk: entity.RiskLow},
+			},
+		},
+		{
+			name:  "single string",
+			input: "Rotate logs",
+			want:  []entity.RecommendedAction{{Description: "Rotate logs"}},
+		},
+		{name: "missing", input: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractRecommendedActions(map[string]interface{}{"recommended_actions": tt.input}, "recommended_actions")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("extractRecommendedActions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// remediationActions are the recommended actions of a completed run in the
+// remediation tests: one of each kind the runner treats differently.
+//
+//nolint:gochecknoglobals // test fixture
+var remediationActions = []interface{}{
+	map[string]interface{}{"description": "Rotate logs", "command": "logrotate -f /etc/logrotate.conf", "risk": "low"},
+	map[string]interface{}{
+		"description": "Clear the package cache", "command": "apt-get clean", "risk": "low", "requires_approval": true,
+	},
+	map[string]interface{}{"description": "Delete old data", "command": "rm -rf /var/lib/app/old", "risk": "high"},
+	map[string]interface{}{"description": "Restart the service", "command": "systemctl restart app", "risk": "medium"},
+	map[string]interface{}{"description": "Add a disk alert at 80%", "risk": "low"},
+}
+
+// newRemediationRunner returns a runner whose conversation completes at once
+// recommending remediationActions.
+func newRemediationRunner(
+	autoRemediate bool,
+	enforcer *MockSafetyEnforcer,
+) (*InvestigationRunner, *investigationRunnerToolExecutorMock) {
+	convService := newInvestigationRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{createAssistantMessage("Done.")}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{{{
+		ToolID:   "call_1",
+		ToolName: "complete_investigation",
+		Input: map[string]interface{}{
+			"confidence":          0.9,
+			"findings":            []interface{}{"/var is full"},
+			"recommended_actions": remediationActions,
+		},
+	}}}
+	toolExecutor := newInvestigationRunnerToolExecutorMock()
+	toolExecutor.executeToolResult = `{"stdout":"","stderr":"","exit_code":0}`
+	runner := NewInvestigationRunner(
+		convService,
+		toolExecutor,
+		enforcer,
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute, AutoRemediate: autoRemediate},
+	)
+	return runner, toolExecutor
+}
+
+// bashCommands returns the commands the runner ran with bash.
+func bashCommands(toolExecutor *investigationRunnerToolExecutorMock) []string {
+	toolExecutor.mu.Lock()
+	defer toolExecutor.mu.Unlock()
+	var commands []string
+	for i, name := range toolExecutor.executeToolName {
+		if input, ok := toolExecutor.executeToolInput[i].(map[string]interface{}); ok && name == toolBash {
+			commands = append(commands, extractCommandFromInput(input))
+		}
+	}
+	return commands
+}
+
+// remediationStatuses returns the command and status of each attempt.
+func remediationStatuses(attempts []entity.RemediationAttempt) []string {
+	var statuses []string
+	for _, attempt := range attempts {
+		statuses = append(statuses, attempt.Action.Command+": "+attempt.Status)
+	}
+	return statuses
+}
+
+func TestInvestigationRunner_Remediation_RunsApprovedLowRiskCommands(t *testing.T) {
+	runner, toolExecutor := newRemediationRunner(true, NewMockSafetyEnforcer())
+	runner.SetApprovalProvider(UnattendedApprover{})
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.RecommendedActions) != len(remediationActions) {
+		t.Fatalf("RecommendedActions = %+v, want every recommended action", result.RecommendedActions)
+	}
+
+	// High, medium, and commandless actions are never attempted; the unattended
+	// approver declines the one that asked for approval
+	want := []string{"logrotate -f /etc/logrotate.conf: succeeded", "apt-get clean: declined"}
+	if got := remediationStatuses(result.Remediations); !slices.Equal(got, want) {
+		t.Errorf("Remediations = %q, want %q", got, want)
+	}
+	if got := bashCommands(toolExecutor); !slices.Equal(got, []string{"logrotate -f /etc/logrotate.conf"}) {
+		t.Errorf("bash commands run = %q, want only the approved low-risk one", got)
+	}
+	if len(result.Artifacts) != 1 || result.Artifacts[0].Input != `{"command":"logrotate -f /etc/logrotate.conf"}` {
+		t.Errorf("Artifacts = %+v, want the remediation command's output", result.Artifacts)
+	}
+}
+
+func TestInvestigationRunner_Remediation_AsksTheApprovalProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		approver *approverMock
+		want     string
+	}{
+		{"declined", &approverMock{approve: false}, entity.RemediationDeclined},
+		{"approval error", &approverMock{approve: true, err: errors.New("approver unreachable")}, entity.RemediationDeclined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, toolExecutor := newRemediationRunner(true, NewMockSafetyEnforcer())
+			runner.SetApprovalProvider(tt.approver)
+
+			result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var asked []string
+			for _, action := range tt.approver.asked {
+				asked = append(asked, action.Command)
+			}
+			if want := []string{"logrotate -f /etc/logrotate.conf", "apt-get clean"}; !slices.Equal(asked, want) {
+				t.Errorf("approver asked about %q, want only the low-risk commands %q", asked, want)
+			}
+			for _, attempt := range result.Remediations {
+				if attempt.Status != tt.want {
+					t.Errorf("remediation %q status = %q, want %q", attempt.Action.Command, attempt.Status, tt.want)
+				}
+			}
+			if got := bashCommands(toolExecutor); len(got) != 0 {
+				t.Errorf("bash commands run = %q, want none without approval", got)
+			}
+		})
+	}
+}
+
+func TestInvestigationRunner_Remediation_ChecksCommandSafety(t *testing.T) {
+	enforcer := NewMockSafetyEnforcerWithBlockedCommands([]string{"logrotate"})
+	runner, toolExecutor := newRemediationRunner(true, enforcer)
+	runner.SetApprovalProvider(&approverMock{approve: true})
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []string{"logrotate -f /etc/logrotate.conf: blocked", "apt-get clean: succeeded"}
+	if got := remediationStatuses(result.Remediations); !slices.Equal(got, want) {
+		t.Errorf("Remediations = %q, want %q", got, want)
+	}
+	if got := bashCommands(toolExecutor); !slices.Equal(got, []string{"apt-get clean"}) {
+		t.Errorf("bash commands run = %q, want only the allowed one", got)
+	}
+}
+
+func TestInvestigationRunner_Remediation_ReportsFailedCommands(t *testing.T) {
+	runner, toolExecutor := newRemediationRunner(true, NewMockSafetyEnforcer())
+	runner.SetApprovalProvider(UnattendedApprover{})
+	toolExecutor.executeToolResult = `{"stdout":"","stderr":"error: cannot open config","exit_code":1}`
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Remediations) == 0 || result.Remediations[0].Status != entity.RemediationFailed {
+		t.Fatalf("Remediations = %+v, want the command that exited non-zero failed", result.Remediations)
+	}
+	if result.Status != "completed" {
+		t.Errorf("Status = %q, want a failed remediation to leave the investigation completed", result.Status)
+	}
+}
+
+func TestInvestigationRunner_Remediation_SkippedWhenDisabled(t *testing.T) {
+	runner, toolExecutor := newRemediationRunner(false, NewMockSafetyEnforcer())
+	approver := &approverMock{approve: true}
+	runner.SetApprovalProvider(approver)
+
+	result, err := runner.Run(context.Background(), createTestAlert("alert-disk", "critical", "Disk Full"), "inv-disk")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Remediations) != 0 || len(approver.asked) != 0 {
+		t.Errorf("Remediations = %+v, approver asked %d times; want no remediation without AutoRemediate",
+			result.Remediations, len(approver.asked))
+	}
+	if got := bashCommands(toolExecutor); len(got) != 0 {
+		t.Errorf("bash commands run = %q, want none", got)
+	}
+	if len(result.RecommendedActions) != len(remediationActions) {
+		t.Errorf("RecommendedActions = %+v, want them recorded all the same", result.RecommendedActions)
+	}
+}
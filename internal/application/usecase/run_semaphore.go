@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// runSemaphore caps how many investigations run at once. A nil
+// *runSemaphore is unlimited.
+type runSemaphore struct {
+	slots chan struct{}
+}
+
+// newRunSemaphore returns a semaphore of size slots, or nil (unlimited) when
+// size is zero or less.
+func newRunSemaphore(size int) *runSemaphore {
+	if size <= 0 {
+		return nil
+	}
+	return &runSemaphore{slots: make(chan struct{}, size)}
+}
+
+// size returns how many runs the semaphore admits at once, 0 for unlimited.
+func (s *runSemaphore) size() int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots)
+}
+
+// tryAcquire takes a slot if one is free, returning the func releasing it.
+func (s *runSemaphore) tryAcquire() (func(), bool) {
+	if s == nil {
+		return func() {}, true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return s.release, true
+	default:
+		return nil, false
+	}
+}
+
+// acquire waits for a slot until ctx is done or, when maxWait is positive,
+// for at most maxWait, after which it returns an error wrapping
+// ErrTooManyInvestigations. It returns the func releasing the slot.
+func (s *runSemaphore) acquire(ctx context.Context, maxWait time.Duration) (func(), error) {
+	if release, ok := s.tryAcquire(); ok {
+		return release, nil
+	}
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return s.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, fmt.Errorf("%w: all %d slots busy for %s", ErrTooManyInvestigations, s.size(), maxWait)
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *runSemaphore) release() {
+	<-s.slots
+}
@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Run Semaphore Tests
+// =============================================================================
+//
+// These tests verify that InvestigationRunner.Run admits at most MaxConcurrent
+// runs at once, makes the rest wait for a slot while reporting them as
+// waiting, and fails them with ErrTooManyInvestigations after
+// MaxConcurrentWait.
+//
+// =============================================================================
+
+// blockingConvService holds each run in StartConversation until released,
+// recording how many runs were in it at once.
+type blockingConvService struct {
+	*investigationRunnerConvServiceMock
+
+	entered chan struct{}
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func newBlockingConvService() *blockingConvService {
+	return &blockingConvService{
+		investigationRunnerConvServiceMock: newInvestigationRunnerConvServiceMock(),
+		entered:                            make(chan struct{}, 16),
+		release:                            make(chan struct{}),
+	}
+}
+
+func (b *blockingConvService) StartConversation(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	b.running++
+	b.peak = max(b.peak, b.running)
+	b.mu.Unlock()
+	b.entered <- struct{}{}
+
+	<-b.release
+	b.mu.Lock()
+	b.running--
+	b.mu.Unlock()
+	// End the run here, so concurrent runs do not race on the mock's script
+	return "", errors.New("conversation unavailable")
+}
+
+func (b *blockingConvService) peakRunning() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// newBlockingRunner returns a runner whose runs block in convService, sharing
+// slots with the other runners of a test.
+func newBlockingRunner(
+	convService *blockingConvService,
+	slots *runSemaphore,
+	maxWait time.Duration,
+) *InvestigationRunner {
+	runner := NewInvestigationRunner(
+		convService,
+		newInvestigationRunnerToolExecutorMock(),
+		NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(),
+		nil, // skillManager
+		nil, // uiAdapter
+		AlertInvestigationUseCaseConfig{
+			MaxActions:        20,
+			MaxDuration:       15 * time.Minute,
+			MaxConcurrent:     slots.size(),
+			MaxConcurrentWait: maxWait,
+		},
+	)
+	runner.slots = slots
+	runner.progress = &investigationProgress{}
+	return runner
+}
+
+// waitForEntered waits until n more runs have entered the conversation service.
+func waitForEntered(t *testing.T, convService *blockingConvService, n int) {
+	t.Helper()
+	for range n {
+		select {
+		case <-convService.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a run to start")
+		}
+	}
+}
+
+// waitForWaiting waits until runner reports its run as waiting for a slot.
+func waitForWaiting(t *testing.T, runner *InvestigationRunner) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !runner.progress.isWaiting() {
+		if time.Now().After(deadline) {
+			t.Fatal("run not reported as waiting for a slot")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInvestigationRunner_Run_CapsConcurrentRuns(t *testing.T) {
+	const maxConcurrent = 2
+	convService := newBlockingConvService()
+	slots := newRunSemaphore(maxConcurrent)
+
+	runners := make([]*InvestigationRunner, maxConcurrent+2)
+	errs := make(chan error, len(runners))
+	for i := range runners {
+		runners[i] = newBlockingRunner(convService, slots, 0)
+		go func() {
+			_, err := runners[i].Run(context.Background(), createTestAlert("alert-001", "critical", "Disk Full"), "inv-001")
+			errs <- err
+		}()
+	}
+
+	waitForEntered(t, convService, maxConcurrent)
+	waiting := 0
+	deadline := time.Now().Add(5 * time.Second)
+	for waiting != 2 && time.Now().Before(deadline) {
+		waiting = 0
+		for _, runner := range runners {
+			if runner.progress.isWaiting() {
+				waiting++
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if waiting != 2 {
+		t.Fatalf("runs waiting for a slot = %d, want 2", waiting)
+	}
+
+	// Each released run frees a slot for a waiting one
+	close(convService.release)
+	waitForEntered(t, convService, 2)
+	for range runners {
+		if err := <-errs; errors.Is(err, ErrTooManyInvestigations) {
+			t.Errorf("Run() error = %v, want every run to get a slot", err)
+		}
+	}
+	if got := convService.peakRunning(); got != maxConcurrent {
+		t.Errorf("peak concurrent runs = %d, want %d", got, maxConcurrent)
+	}
+	for i, runner := range runners {
+		if runner.progress.isWaiting() {
+			t.Errorf("runner %d still reported as waiting", i)
+		}
+	}
+}
+
+func TestInvestigationRunner_Run_FailsAfterMaxConcurrentWait(t *testing.T) {
+	convService := newBlockingConvService()
+	defer close(convService.release)
+	slots := newRunSemaphore(1)
+
+	go func() {
+		_, _ = newBlockingRunner(convService, slots, 0).Run(
+			context.Background(), createTestAlert("alert-001", "critical", "Disk Full"), "inv-001")
+	}()
+	waitForEntered(t, convService, 1)
+
+	runner := newBlockingRunner(convService, slots, 20*time.Millisecond)
+	result, err := runner.Run(context.Background(), createTestAlert("alert-002", "critical", "Disk Full"), "inv-002")
+	if !errors.Is(err, ErrTooManyInvestigations) {
+		t.Fatalf("Run() error = %v, want ErrTooManyInvestigations", err)
+	}
+	if result == nil || result.Status != "rejected" || result.ErrorKind != ErrorKindCapacity ||
+		result.InvestigationID != "inv-002" {
+		t.Errorf("Run() result = %+v, want inv-002 rejected for capacity", result)
+	}
+	if runner.progress.isWaiting() {
+		t.Error("run still reported as waiting after giving up")
+	}
+}
+
+func TestInvestigationRunner_Run_StopsWaitingWhenCancelled(t *testing.T) {
+	convService := newBlockingConvService()
+	defer close(convService.release)
+	slots := newRunSemaphore(1)
+
+	go func() {
+		_, _ = newBlockingRunner(convService, slots, 0).Run(
+			context.Background(), createTestAlert("alert-001", "critical", "Disk Full"), "inv-001")
+	}()
+	waitForEntered(t, convService, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := newBlockingRunner(convService, slots, 0)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := runner.Run(ctx, createTestAlert("alert-002", "critical", "Disk Full"), "inv-002")
+		errs <- err
+	}()
+	waitForWaiting(t, runner)
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() still waiting after its context was cancelled")
+	}
+}
+
+// panickingConvService panics when a run starts its conversation.
+type panickingConvService struct {
+	*blockingConvService
+}
+
+func (panickingConvService) StartConversation(context.Context) (string, error) {
+	panic("conversation service bug")
+}
+
+func TestInvestigationRunner_Run_ReleasesSlotWhenRunPanics(t *testing.T) {
+	slots := newRunSemaphore(1)
+	runner := newBlockingRunner(newBlockingConvService(), slots, 0)
+	runner.convService = panickingConvService{}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Run() did not panic")
+			}
+		}()
+		_, _ = runner.Run(context.Background(), createTestAlert("alert-001", "critical", "Disk Full"), "inv-001")
+	}()
+
+	release, ok := slots.tryAcquire()
+	if !ok {
+		t.Fatal("slot still held after the run panicked")
+	}
+	release()
+}
+
+func TestInvestigationRunner_Run_UnlimitedWithoutMaxConcurrent(t *testing.T) {
+	for _, maxConcurrent := range []int{0, -1} {
+		convService := newBlockingConvService()
+		slots := newRunSemaphore(maxConcurrent)
+		if slots != nil {
+			t.Fatalf("newRunSemaphore(%d) = %v, want nil (unlimited)", maxConcurrent, slots)
+		}
+
+		const runs = 5
+		var wg sync.WaitGroup
+		for range runs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = newBlockingRunner(convService, slots, time.Millisecond).Run(
+					context.Background(), createTestAlert("alert-001", "critical", "Disk Full"), "inv-001")
+			}()
+		}
+		waitForEntered(t, convService, runs)
+		close(convService.release)
+		wg.Wait()
+		if got := convService.peakRunning(); got != runs {
+			t.Errorf("MaxConcurrent %d: peak concurrent runs = %d, want %d", maxConcurrent, got, runs)
+		}
+	}
+}
+
+func TestAlertInvestigationUseCase_Status_ReportsRunsWaitingForASlot(t *testing.T) {
+	uc := NewAlertInvestigationUseCaseWithConfig(AlertInvestigationUseCaseConfig{
+		MaxActions:    20,
+		MaxDuration:   15 * time.Minute,
+		MaxConcurrent: 1,
+	})
+	convService := newBlockingConvService()
+	uc.SetConversationService(convService)
+	uc.SetToolExecutor(newInvestigationRunnerToolExecutorMock())
+	uc.SetPromptBuilderRegistry(newInvestigationRunnerPromptBuilderMock())
+	// A scheduler without slot limits admits more than MaxConcurrent, leaving
+	// the runner's own limit to hold them back
+	uc.SetScheduler(NewAlertScheduler(AlertSchedulerConfig{}))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"DiskFull-1", "DiskFull-2"} {
+		invID, err := uc.StartInvestigation(ctx, diskAlert(id))
+		if err != nil {
+			t.Fatalf("StartInvestigation(%s) error = %v", id, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = uc.RunInvestigation(ctx, diskAlert(id), invID)
+		}()
+		if id == "DiskFull-1" {
+			waitForEntered(t, convService, 1)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	status := uc.Status()
+	for len(status.Queued) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		status = uc.Status()
+	}
+	if len(status.Active) != 1 || len(status.Queued) != 1 || status.Queued[0].AlertID != "DiskFull-2" {
+		t.Fatalf("Status() active = %+v, queued = %+v; want DiskFull-2 waiting for a slot",
+			status.Active, status.Queued)
+	}
+
+	close(convService.release)
+	wg.Wait()
+	if got := convService.peakRunning(); got != 1 {
+		t.Errorf("peak concurrent runs = %d, want 1", got)
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultMaxSchemaRetries is how many consecutive calls of one tool with
+// input that does not match its schema an investigation takes before the
+// tool is disabled for the rest of the run.
+const defaultMaxSchemaRetries = 2
+
+// schemaRetries tracks, for one investigation, the consecutive schema
+// failures of each tool and the tools disabled after too many of them.
+type schemaRetries struct {
+	limit    int
+	failures map[string]int
+	disabled map[string]bool
+}
+
+// newSchemaRetries creates the tracker for a run. A limit of 0 or less uses
+// defaultMaxSchemaRetries.
+func newSchemaRetries(limit int) *schemaRetries {
+	if limit <= 0 {
+		limit = defaultMaxSchemaRetries
+	}
+	return &schemaRetries{limit: limit, failures: make(map[string]int), disabled: make(map[string]bool)}
+}
+
+// isDisabled reports whether the tool was disabled after too many schema failures.
+func (s *schemaRetries) isDisabled(toolName string) bool {
+	return s.disabled[toolName]
+}
+
+// succeeded resets the tool's streak: its input matched the schema.
+func (s *schemaRetries) succeeded(toolName string) {
+	delete(s.failures, toolName)
+}
+
+// failed counts a schema failure of the tool and returns the attempt number,
+// disabling the tool when it reaches the limit.
+func (s *schemaRetries) failed(toolName string) (attempt int, disabled bool) {
+	s.failures[toolName]++
+	attempt = s.failures[toolName]
+	if attempt >= s.limit {
+		s.disabled[toolName] = true
+	}
+	return attempt, s.disabled[toolName]
+}
+
+// disabledToolResult is the result of a call to a tool disabled after too
+// many schema failures.
+func disabledToolResult(toolName string) string {
+	return fmt.Sprintf("tool '%s' is disabled for this investigation after repeated invalid input; "+
+		"continue with other tools or finish the investigation", toolName)
+}
+
+// schemaFailureResult records a call whose input did not match the tool's
+// schema and returns the tool result for the model: the violations and, while
+// retries remain, the expected schema so the model can correct the input. The
+// call that reaches the limit disables the tool and records a finding about
+// the model's degraded behavior.
+func (r *InvestigationRunner) schemaFailureResult(rc *runContext, tc port.ToolCallInfo, err error) string {
+	violations := []string{err.Error()}
+	var validationErr *entity.InputValidationError
+	if errors.As(err, &validationErr) {
+		violations = validationErr.Violations
+	}
+	attempt, disabled := rc.schemaRetries.failed(tc.ToolName)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Invalid input for tool %s:\n", tc.ToolName)
+	for _, violation := range violations {
+		sb.WriteString("- " + violation + "\n")
+	}
+	if disabled {
+		rc.logger.Warn("Tool disabled after repeated schema failures", "tool", tc.ToolName, "attempts", attempt)
+		rc.findings = append(rc.findings, fmt.Sprintf(
+			"[warning] Degraded model behavior: %d consecutive calls to %s had input that did not match its schema "+
+				"(%s); the tool was disabled for the rest of the investigation",
+			attempt, tc.ToolName, strings.Join(violations, "; ")))
+		sb.WriteString(disabledToolResult(tc.ToolName))
+		return sb.String()
+	}
+
+	if tool, ok := r.toolExecutor.GetTool(tc.ToolName); ok && tool.InputSchema != nil {
+		if schema, err := json.Marshal(tool.InputSchema); err == nil {
+			sb.WriteString("Expected input schema:\n" + string(schema) + "\n")
+		}
+	}
+	fmt.Fprintf(&sb, "Call %s again with input matching the schema (attempt %d of %d).",
+		tc.ToolName, attempt, rc.schemaRetries.limit)
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaValidatingToolExecutor validates input against the registered tool's
+// schema before executing, like the real tool executor.
+type schemaValidatingToolExecutor struct {
+	*investigationRunnerToolExecutorMock
+}
+
+func (e *schemaValidatingToolExecutor) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
+	if tool, ok := e.GetTool(name); ok {
+		raw, err := json.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		if err := tool.ValidateInput(raw); err != nil {
+			return "", fmt.Errorf("invalid input for tool %s: %w", name, err)
+		}
+	}
+	return e.investigationRunnerToolExecutorMock.ExecuteTool(ctx, name, input)
+}
+
+// runSchemaRetryInvestigation runs an investigation whose model makes the
+// given read_file calls, one per turn, against a read_file that requires path.
+func runSchemaRetryInvestigation(
+	t *testing.T,
+	inputs ...map[string]interface{},
+) (*InvestigationResult, *investigationRunnerConvServiceMock, *schemaValidatingToolExecutor) {
+	t.Helper()
+	mock := newInvestigationRunnerToolExecutorMock()
+	mock.registeredTools = []entity.Tool{{
+		Name: "read_file",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+		},
+		RequiredFields: []string{"path"},
+	}}
+	toolExecutor := &schemaValidatingToolExecutor{mock}
+
+	convService := newInvestigationRunnerConvServiceMock()
+	for i, input := range inputs {
+		convService.processResponseMessages = append(convService.processResponseMessages,
+			createAssistantMessage("Reading the file."))
+		convService.processResponseToolCalls = append(convService.processResponseToolCalls,
+			[]port.ToolCallInfo{{ToolID: fmt.Sprintf("t%d", i+1), ToolName: "read_file", Input: input}})
+	}
+	convService.processResponseMessages = append(convService.processResponseMessages,
+		createAssistantMessage("Investigation complete."))
+	convService.processResponseToolCalls = append(convService.processResponseToolCalls, nil)
+
+	config := AlertInvestigationUseCaseConfig{MaxActions: 20, MaxDuration: 15 * time.Minute}
+	runner := NewInvestigationRunner(convService, toolExecutor, NewMockSafetyEnforcer(),
+		newInvestigationRunnerPromptBuilderMock(), nil, nil, config)
+	result, err := runner.Run(context.Background(), createTestAlert("alert-1", "warning", "HighCPU"), "inv-1")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return result, convService, toolExecutor
+}
+
+// toolResultText returns the text of the n-th tool result fed back to the model.
+func toolResultText(t *testing.T, convService *investigationRunnerConvServiceMock, n int) string {
+	t.Helper()
+	if len(convService.addToolResultResults) <= n || len(convService.addToolResultResults[n]) == 0 {
+		t.Fatalf("tool results = %v, want at least %d", convService.addToolResultResults, n+1)
+	}
+	return convService.addToolResultResults[n][0].Result
+}
+
+func hasDegradedModelFinding(findings []string) bool {
+	return slices.ContainsFunc(findings, func(f string) bool {
+		return strings.HasPrefix(f, "[warning] Degraded model behavior")
+	})
+}
+
+func TestInvestigationRunner_SchemaFailureCorrectedOnRetry(t *testing.T) {
+	result, convService, toolExecutor := runSchemaRetryInvestigation(t,
+		map[string]interface{}{"file": "/var/log/syslog"},
+		map[string]interface{}{"path": "/var/log/syslog"},
+	)
+
+	first := toolResultText(t, convService, 0)
+	for _, want := range []string{"missing required field: path", "Expected input schema:", "attempt 1 of 2"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("first tool result = %q, want it to contain %q", first, want)
+		}
+	}
+	if got := toolResultText(t, convService, 1); got != "tool execution result" {
+		t.Errorf("second tool result = %q, want the tool to run", got)
+	}
+	if !slices.Equal(toolExecutor.executeToolName, []string{"read_file"}) {
+		t.Errorf("executed tools = %v, want only the corrected call", toolExecutor.executeToolName)
+	}
+	if hasDegradedModelFinding(result.Findings) {
+		t.Errorf("Findings = %v, want no degraded model finding", result.Findings)
+	}
+	if result.ActionsTaken != 2 {
+		t.Errorf("ActionsTaken = %d, want 2: the invalid call counts", result.ActionsTaken)
+	}
+}
+
+func TestInvestigationRunner_SchemaFailureDisablesToolAfterLimit(t *testing.T) {
+	invalid := map[string]interface{}{"path": 42}
+	result, convService, toolExecutor := runSchemaRetryInvestigation(t, invalid, invalid, invalid)
+
+	second := toolResultText(t, convService, 1)
+	if !strings.Contains(second, "field path: expected string, got integer") ||
+		!strings.Contains(second, "is disabled for this investigation") {
+		t.Errorf("second tool result = %q, want the violation and the tool disabled", second)
+	}
+	if third := toolResultText(t, convService, 2); !strings.Contains(third, "is disabled for this investigation") ||
+		strings.Contains(third, "Invalid input") {
+		t.Errorf("third tool result = %q, want the call refused without validation", third)
+	}
+	if len(toolExecutor.executeToolName) != 0 {
+		t.Errorf("executed tools = %v, want none", toolExecutor.executeToolName)
+	}
+	if !hasDegradedModelFinding(result.Findings) {
+		t.Errorf("Findings = %v, want a degraded model finding", result.Findings)
+	}
+	if result.ActionsTaken != 3 {
+		t.Errorf("ActionsTaken = %d, want 3: every call counts toward MaxActions", result.ActionsTaken)
+	}
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSessionTitleRunes bounds session titles, generated or heuristic.
+const maxSessionTitleRunes = 60
+
+const (
+	titlePromptTurns    = 2    // User turns the title prompt quotes
+	maxTitlePromptRunes = 2000 // Longest quote of each turn
+)
+
+const sessionTitlePrompt = `Write a title of at most six words for a coding session that opened with these requests.
+Respond with the title only: no quotes, no trailing punctuation.
+
+%s`
+
+// SessionTitleGenerator names sessions from their opening user turns with one
+// short tool-less AI call, on the model routed for ModelTaskTitleGeneration.
+// When the call fails, as it does offline, or there is no provider, it falls
+// back to HeuristicSessionTitle.
+type SessionTitleGenerator struct {
+	provider port.AIProvider
+	router   *ModelRouter
+}
+
+// NewSessionTitleGenerator creates a title generator calling provider, which
+// may be nil to always use the heuristic.
+func NewSessionTitleGenerator(provider port.AIProvider) *SessionTitleGenerator {
+	return &SessionTitleGenerator{provider: provider}
+}
+
+// SetModelRouter sets the router choosing the model that writes titles. A nil
+// router keeps the provider's model.
+func (g *SessionTitleGenerator) SetModelRouter(router *ModelRouter) {
+	g.router = router
+}
+
+// Generate returns a title for a session with the given messages, or "" if
+// it has no user turns yet.
+func (g *SessionTitleGenerator) Generate(ctx context.Context, messages []entity.Message) string {
+	prompts := userPrompts(messages, titlePromptTurns)
+	if len(prompts) == 0 {
+		return ""
+	}
+	if g.provider != nil {
+		if title, err := g.requestTitle(ctx, prompts); err == nil && title != "" {
+			return title
+		}
+	}
+	return HeuristicSessionTitle(messages)
+}
+
+// requestTitle asks the AI provider for a title of the session opening with
+// prompts.
+func (g *SessionTitleGenerator) requestTitle(ctx context.Context, prompts []string) (string, error) {
+	var quoted strings.Builder
+	for i, prompt := range prompts {
+		// A pasted log says little more about the topic than its opening
+		if runes := []rune(prompt); len(runes) > maxTitlePromptRunes {
+			prompt = string(runes[:maxTitlePromptRunes])
+		}
+		fmt.Fprintf(&quoted, "Request %d:\n%s\n\n", i+1, prompt)
+	}
+	opts := port.RequestOptions{
+		Model:     g.router.Route(ModelTaskTitleGeneration, false),
+		MaxTokens: 32,
+	}
+	messages := []port.MessageParam{{
+		Role:    entity.RoleUser,
+		Content: fmt.Sprintf(sessionTitlePrompt, strings.TrimSpace(quoted.String())),
+	}}
+	msg, _, err := g.provider.SendMessageWithOptions(ctx, messages, nil, opts)
+	if err != nil {
+		return "", err
+	}
+	if msg == nil {
+		return "", nil
+	}
+	return cleanSessionTitle(msg.Content), nil
+}
+
+// HeuristicSessionTitle titles a session after the first line of its first
+// user turn, shortened on a word boundary. Returns "" if the session has no
+// user turns yet.
+func HeuristicSessionTitle(messages []entity.Message) string {
+	prompts := userPrompts(messages, 1)
+	if len(prompts) == 0 {
+		return ""
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(prompts[0]), "\n")
+	return shortenTitle(strings.Join(strings.Fields(firstLine), " "))
+}
+
+// cleanSessionTitle reduces a model's reply to a single-line title.
+func cleanSessionTitle(reply string) string {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+	title := strings.TrimSpace(strings.TrimPrefix(firstLine, "Title:"))
+	title = strings.Trim(title, "\"'`*#")
+	title = strings.TrimRight(title, ".")
+	return shortenTitle(strings.Join(strings.Fields(title), " "))
+}
+
+// shortenTitle cuts title to maxSessionTitleRunes, at the last word boundary
+// that fits, marking the cut with an ellipsis.
+func shortenTitle(title string) string {
+	if utf8.RuneCountInString(title) <= maxSessionTitleRunes {
+		return title
+	}
+	cut := string([]rune(title)[:maxSessionTitleRunes-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
+
+// userPrompts returns the text of up to limit of the session's first user
+// turns, skipping tool results and turns without text.
+func userPrompts(messages []entity.Message, limit int) []string {
+	var prompts []string
+	for i := range messages {
+		if len(prompts) == limit {
+			break
+		}
+		if !messages[i].IsUserPrompt() {
+			continue
+		}
+		if text := strings.TrimSpace(messages[i].Content); text != "" {
+			prompts = append(prompts, text)
+		}
+	}
+	return prompts
+}
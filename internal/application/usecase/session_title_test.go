@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// titleSession builds a session history of user turns, each answered by the
+// assistant with a tool exchange in between.
+func titleSession(turns ...string) []entity.Message {
+	var messages []entity.Message
+	for _, turn := range turns {
+		user, _ := entity.NewMessage(entity.RoleUser, turn)
+		result, _ := entity.NewToolResultMessage(entity.RoleUser, []entity.ToolResult{{ToolID: "t", Result: "tool output"}})
+		reply, _ := entity.NewMessage(entity.RoleAssistant, "Done")
+		messages = append(messages, *user, *result, *reply)
+	}
+	return messages
+}
+
+func TestSessionTitleGenerator_UsesRoutedModel(t *testing.T) {
+	provider := newSubagentRunnerAIProviderMock()
+	provider.sendMessageResponse, _ = entity.NewMessage(entity.RoleAssistant, "\"Fix Safari login redirect.\"\nExtra commentary")
+	generator := NewSessionTitleGenerator(provider)
+	generator.SetModelRouter(NewModelRouter(provider, map[ModelTask]string{ModelTaskTitleGeneration: "title-model"}))
+
+	title := generator.Generate(context.Background(), titleSession("Login is broken", "Only on Safari", "Third turn"))
+	if title != "Fix Safari login redirect" {
+		t.Errorf("Generate() = %q, want the cleaned first line of the reply", title)
+	}
+	if len(provider.sendMessageModels) != 1 || provider.sendMessageModels[0] != "title-model" {
+		t.Errorf("title request models = %v, want the routed model", provider.sendMessageModels)
+	}
+	prompt := provider.sendMessageMessages[0][0].Content
+	if !strings.Contains(prompt, "Only on Safari") || strings.Contains(prompt, "Third turn") ||
+		strings.Contains(prompt, "tool output") {
+		t.Errorf("title prompt should quote the first two user turns only:\n%s", prompt)
+	}
+	if len(provider.sendMessageTools[0]) != 0 {
+		t.Error("title request should offer no tools")
+	}
+}
+
+func TestSessionTitleGenerator_FallsBackToHeuristic(t *testing.T) {
+	messages := titleSession("Refactor the config loader\nIt has grown to 900 lines", "Keep the env overrides")
+
+	offline := newSubagentRunnerAIProviderMock()
+	offline.sendMessageError = errors.New("dial tcp: no route to host")
+	empty := newSubagentRunnerAIProviderMock()
+	empty.sendMessageResponse, _ = entity.NewMessage(entity.RoleAssistant, " \"\" ")
+
+	for name, generator := range map[string]*SessionTitleGenerator{
+		"provider error": NewSessionTitleGenerator(offline),
+		"empty reply":    NewSessionTitleGenerator(empty),
+		"no provider":    NewSessionTitleGenerator(nil),
+	} {
+		if got := generator.Generate(context.Background(), messages); got != "Refactor the config loader" {
+			t.Errorf("%s: Generate() = %q, want the first line of the first turn", name, got)
+		}
+	}
+
+	if got := NewSessionTitleGenerator(offline).Generate(context.Background(), nil); got != "" {
+		t.Errorf("Generate() without user turns = %q, want empty", got)
+	}
+}
+
+func TestHeuristicSessionTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []entity.Message
+		want     string
+	}{
+		{name: "no messages", want: ""},
+		{name: "first line only", messages: titleSession("  Add retries  \nwith backoff"), want: "Add retries"},
+		{name: "collapses whitespace", messages: titleSession("Add\tretries   to  the client"), want: "Add retries to the client"},
+		{
+			name:     "shortened on a word boundary",
+			messages: titleSession("Investigate why the nightly integration build times out on the payments service since Tuesday"),
+			want:     "Investigate why the nightly integration build times out on…",
+		},
+		{
+			name:     "skips leading tool results",
+			messages: append(titleSession("Ignored")[1:2], titleSession("First prompt")...),
+			want:     "First prompt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HeuristicSessionTitle(tt.messages); got != tt.want {
+				t.Errorf("HeuristicSessionTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
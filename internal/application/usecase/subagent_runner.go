@@ -4,11 +4,14 @@ package usecase
 import (
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,8 +21,30 @@ const (
 	statusCompleted = "Completed"
 	statusFailed    = "Failed"
 	statusThinking  = "Thinking"
+	statusTimedOut  = "Timed out"
 )
 
+// noToolsSentinel is the allowed-tools value that disables every tool for a subagent.
+const noToolsSentinel = "none"
+
+// subagentSummaryPrompt instructs the AI to condense a long subagent report for the delegating agent.
+const subagentSummaryPrompt = `You are condensing the final report of a subagent for the agent that delegated the task to it.
+Summarize the report below so the delegating agent can act on it without reading the original.
+Keep every concrete finding, decision, file path, command, error message, and open question.
+Drop narration, repetition, and pleasantries. Respond with the summary only.
+
+Task given to the subagent:
+%s
+
+Subagent report:
+%s`
+
+// conversationReader is implemented by conversation services that expose session history.
+// The runner uses it to capture full transcripts without widening ConversationServiceInterface.
+type conversationReader interface {
+	GetConversation(sessionID string) (*entity.Conversation, error)
+}
+
 // resolveModelShorthand converts shorthand model names to actual Anthropic model IDs.
 // It supports:
 //   - "haiku" -> "claude-3-5-haiku-20241022"
@@ -50,9 +75,16 @@ type SubagentConfig struct {
 	MaxConcurrent   int
 	AllowedTools    []string
 	BlockedCommands []string
-	ThinkingEnabled bool  // Enable extended thinking mode for subagent
-	ThinkingBudget  int64 // Thinking token budget (0 = unlimited)
-	ShowThinking    bool  // Display thinking output to user
+	// AllowedCommandPatterns restricts bash and wait_for commands to those whose
+	// every segment matches one of these regular expressions (empty = no
+	// restriction). See AlertInvestigationUseCaseConfig.AllowedCommandPatterns.
+	AllowedCommandPatterns []string
+	ThinkingEnabled        bool  // Enable extended thinking mode for subagent
+	ThinkingBudget         int64 // Thinking token budget (0 = unlimited)
+	ShowThinking           bool  // Display thinking output to user
+	// SummaryThreshold is the final-output length in characters above which the output
+	// is summarized before being returned to the parent (0 = never summarize).
+	SummaryThreshold int
 }
 
 // SubagentResult holds the result of a subagent execution.
@@ -64,6 +96,16 @@ type SubagentResult struct {
 	ActionsTaken int
 	Duration     time.Duration
 	Error        error
+
+	Summarized    bool   // Output is an AI summary of the subagent's final message
+	TranscriptRef string // Reference to the stored full transcript (set when summarized)
+
+	Model        string // Model that served the run, after any fallback
+	SummaryModel string // Model that wrote the summary (set when summarized)
+
+	SessionID  string            // The subagent's own conversation session ("" if it never started)
+	TokensUsed entity.TokenUsage // Tokens of the subagent's AI turns, including its summary
+	ToolErrors int               // Executed tool calls that returned an error
 }
 
 // GetSubagentID returns the subagent ID.
@@ -101,28 +143,74 @@ func (r *SubagentResult) GetError() error {
 	return r.Error
 }
 
+// Summary returns a one-line account of the run for the parent, such as
+// "completed in 42s, 7 actions, 13k tokens".
+func (r *SubagentResult) Summary() string {
+	duration := r.Duration.Round(time.Second)
+	if r.Duration < time.Second {
+		duration = r.Duration.Round(time.Millisecond)
+	}
+	summary := fmt.Sprintf("%s in %s, %d actions", strings.ReplaceAll(r.Status, "_", " "), duration, r.ActionsTaken)
+	if r.ToolErrors > 0 {
+		summary += fmt.Sprintf(" (%d failed)", r.ToolErrors)
+	}
+	return summary + ", " + formatTokenCount(r.TokensUsed.InputTokens+r.TokensUsed.OutputTokens) + " tokens"
+}
+
+// formatTokenCount abbreviates a token count: 850, 1.5k, 13k.
+func formatTokenCount(tokens int64) string {
+	switch {
+	case tokens < 1000:
+		return strconv.FormatInt(tokens, 10)
+	case tokens < 10000:
+		return strconv.FormatFloat(float64(tokens)/1000, 'f', 1, 64) + "k"
+	default:
+		return strconv.FormatInt((tokens+500)/1000, 10) + "k"
+	}
+}
+
+// SubagentTask describes a single subagent run within a parallel batch.
+type SubagentTask struct {
+	Agent      *entity.Subagent
+	Prompt     string
+	SubagentID string
+}
+
 // SubagentRunner orchestrates isolated subagent execution for task delegation.
 type SubagentRunner struct {
-	convService   ConversationServiceInterface
-	toolExecutor  port.ToolExecutor
-	aiProvider    port.AIProvider
-	userInterface port.UserInterface
-	config        SubagentConfig
+	convService     ConversationServiceInterface
+	toolExecutor    port.ToolExecutor
+	aiProvider      port.AIProvider
+	userInterface   port.UserInterface
+	progressSink    port.ProgressSink
+	transcriptStore port.TranscriptStore
+	modelRouter     *ModelRouter
+	tracer          port.Tracer
+	logger          *slog.Logger
+	config          SubagentConfig
 }
 
 // subagentRunContext holds state for a subagent execution run.
 type subagentRunContext struct {
-	ctx           context.Context
-	agent         *entity.Subagent
-	taskPrompt    string
-	subagentID    string
-	sessionID     string
-	startTime     time.Time
-	actionsTaken  int
-	maxActions    int
-	lastMessage   *entity.Message
-	runner        *SubagentRunner // Reference to runner for UI display
-	originalModel string          // Original model before any switching
+	ctx          context.Context
+	parentCtx    context.Context // Caller's context, used to tell timeouts from parent cancellation
+	agent        *entity.Subagent
+	taskPrompt   string
+	subagentID   string
+	sessionID    string
+	startTime    time.Time
+	actionsTaken int
+	maxActions   int
+	toolErrors   int               // Executed tool calls that returned an error
+	usage        entity.TokenUsage // Tokens of the AI turns so far
+	lastMessage  *entity.Message
+	runner       *SubagentRunner // Reference to runner for UI display
+	model        string          // The run's own model ("" = the provider's default)
+	allowedTools []string        // Effective tool allowlist (nil = all tools)
+	maxDuration  time.Duration   // Wall-clock limit for this run (0 = unlimited)
+	logger       *slog.Logger    // Carries subagent_id, agent, and subagent_session_id
+
+	commandAllowlist *safety.CommandAllowlist // Compiled AllowedCommandPatterns (nil = any command)
 }
 
 // NewSubagentRunner creates a new SubagentRunner with dependency validation.
@@ -149,10 +237,49 @@ func NewSubagentRunner(
 		toolExecutor:  toolExecutor,
 		aiProvider:    aiProvider,
 		userInterface: userInterface,
+		logger:        slog.Default(),
 		config:        config,
 	}
 }
 
+// SetProgressSink sets the sink that receives subagent lifecycle events.
+// When a sink is set, lifecycle progress is reported through it instead of
+// DisplaySubagentStatus. Pass nil to restore the default status display.
+func (r *SubagentRunner) SetProgressSink(sink port.ProgressSink) {
+	r.progressSink = sink
+}
+
+// SetTranscriptStore sets the store used to persist full transcripts of subagent
+// runs whose output was summarized. Without a store, summaries carry no transcript reference.
+func (r *SubagentRunner) SetTranscriptStore(store port.TranscriptStore) {
+	r.transcriptStore = store
+}
+
+// SetModelRouter sets the router choosing the models of subagents whose
+// agent inherits the model (ModelTaskSubagent) and of output summaries
+// (ModelTaskSummarization). A nil router keeps the parent's model for both.
+func (r *SubagentRunner) SetModelRouter(router *ModelRouter) {
+	r.modelRouter = router
+}
+
+// SetLogger sets the logger for subagent logs. Each run derives a logger carrying
+// subagent_id, agent, and subagent_session_id from the logger in its context, so
+// a subagent spawned during an investigation also logs the investigation's
+// attributes. A nil logger restores slog.Default().
+func (r *SubagentRunner) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r.logger = logger
+}
+
+// SetTracer sets the tracer for subagent spans. A run's span is a child of the
+// span in its context, so delegated work nests under the tool call that spawned
+// it. Without a tracer, no spans are created.
+func (r *SubagentRunner) SetTracer(tracer port.Tracer) {
+	r.tracer = tracer
+}
+
 // Run executes a subagent task with the given agent configuration.
 //
 // The subagent execution follows this flow:
@@ -164,6 +291,7 @@ func NewSubagentRunner(
 //     - If AI requests tools: execute tools, feed results back
 //     - If AI completes: extract output and return result
 //     - If action limit exceeded: stop and return
+//     - If MaxDuration elapses: stop and return a "timed_out" result with partial output
 //  6. Clean up conversation session
 //
 // Parameters:
@@ -180,21 +308,55 @@ func (r *SubagentRunner) Run(
 	agent *entity.Subagent,
 	taskPrompt string,
 	subagentID string,
+) (*SubagentResult, error) {
+	ctx, span := port.StartSpan(ctx, r.tracer, port.SpanSubagent)
+	if span.IsRecording() {
+		span.SetAttributes(port.StringAttribute("subagent_id", subagentID))
+		if agent != nil {
+			span.SetAttributes(port.StringAttribute("agent", agent.Name))
+		}
+	}
+
+	result, err := r.run(ctx, agent, taskPrompt, subagentID)
+	reportSubagentUsage(ctx, result)
+
+	if result != nil && span.IsRecording() {
+		span.SetAttributes(
+			port.StringAttribute("status", result.Status),
+			port.IntAttribute("actions_taken", result.ActionsTaken),
+		)
+	}
+	port.EndSpan(span, err)
+	return result, err
+}
+
+// run performs the subagent task for Run.
+func (r *SubagentRunner) run(
+	ctx context.Context,
+	agent *entity.Subagent,
+	taskPrompt string,
+	subagentID string,
 ) (*SubagentResult, error) {
 	if err := r.validateInputs(agent, taskPrompt); err != nil {
 		return r.validationFailedResult(subagentID, agent, err), err
 	}
+	logger := port.LoggerFromContext(ctx, r.logger).With("subagent_id", subagentID, "agent", agent.Name)
+
+	// The model is a per-session option rather than the shared provider's
+	// default, so runs on different models proceed concurrently.
+	model := resolveModelShorthand(agent.Model)
+	if model == "" {
+		// Subagents always use tools, so the router never picks a model without them
+		model = r.modelRouter.Route(ModelTaskSubagent, true)
+	}
 
-	// Store original model before any switching
-	originalModel := r.aiProvider.GetModel()
-
-	// Model switching: Resolve shorthand and set agent model if specified
-	resolvedModel := resolveModelShorthand(agent.Model)
-	if resolvedModel != "" {
-		if err := r.aiProvider.SetModel(resolvedModel); err != nil {
-			return r.validationFailedResult(subagentID, agent, err), err
-		}
-		defer func() { _ = r.aiProvider.SetModel(originalModel) }()
+	// Enforce the subagent's own deadline, independent of the parent's
+	parentCtx := ctx
+	maxDuration := r.resolveMaxDuration(agent)
+	if maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxDuration)
+		defer cancel()
 	}
 
 	// Wrap context with subagent info for recursion prevention
@@ -205,26 +367,51 @@ func (r *SubagentRunner) Run(
 		Depth:           1,
 	})
 
+	// Only offer the tools this subagent may use
+	allowedTools := r.resolveAllowedTools(agent)
+	if allowedTools != nil {
+		ctx = port.WithAllowedTools(ctx, allowedTools)
+	}
+
 	rc := &subagentRunContext{
-		ctx:           ctx,
-		agent:         agent,
-		taskPrompt:    taskPrompt,
-		subagentID:    subagentID,
-		startTime:     time.Now(),
-		maxActions:    r.config.MaxActions,
-		runner:        r,
-		originalModel: originalModel,
+		ctx:          ctx,
+		parentCtx:    parentCtx,
+		agent:        agent,
+		taskPrompt:   taskPrompt,
+		subagentID:   subagentID,
+		startTime:    time.Now(),
+		maxActions:   r.config.MaxActions,
+		runner:       r,
+		model:        model,
+		allowedTools: allowedTools,
+		maxDuration:  maxDuration,
+		logger:       logger,
 	}
 	if rc.maxActions == 0 {
 		rc.maxActions = 20
 	}
+	allowlist, err := safety.NewCommandAllowlist(r.config.AllowedCommandPatterns)
+	if err != nil {
+		return rc.failedResult(err), err
+	}
+	rc.commandAllowlist = allowlist
 
 	sessionID, err := r.convService.StartConversation(ctx)
 	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrConversationStart, err)
 		return rc.failedResult(err), err
 	}
 	rc.sessionID = sessionID
-	defer func() { _ = r.convService.EndConversation(ctx, sessionID) }()
+	rc.logger = rc.logger.With("subagent_session_id", sessionID)
+	rc.ctx = port.WithLogger(rc.ctx, rc.logger)
+	// Use a non-cancelable context so the session is cleaned up even after a timeout
+	defer func() { _ = r.convService.EndConversation(context.WithoutCancel(ctx), sessionID) }()
+
+	if model != "" {
+		if err := r.convService.SetSessionModel(sessionID, model); err != nil {
+			return rc.failedResult(err), err
+		}
+	}
 
 	// Extract thinking mode from context (from parent) or fall back to static config
 	thinkingInfo, hasThinking := port.ThinkingModeFromContext(ctx)
@@ -247,26 +434,74 @@ func (r *SubagentRunner) Run(
 	if thinkingInfo.Enabled {
 		if err := r.convService.SetThinkingMode(sessionID, thinkingInfo); err != nil {
 			// Log warning but don't fail - thinking mode is optional
-			fmt.Fprintf(
-				os.Stderr,
-				"[SubagentRunner] Failed to set thinking mode for subagent session: error=%v, agent=%s, sessionID=%s, enabled=%t, budget=%d\n",
-				err,
-				rc.agent.Name,
-				sessionID,
-				thinkingInfo.Enabled,
-				thinkingInfo.BudgetTokens,
-			)
+			rc.logger.Warn("Failed to set thinking mode for subagent session", "error", err,
+				"thinking_enabled", thinkingInfo.Enabled, "thinking_budget", thinkingInfo.BudgetTokens)
 		}
 	}
 
 	if err := r.setupAgentSession(rc); err != nil {
+		if rc.isTimedOut() {
+			return rc.timedOutResult(), nil
+		}
 		return rc.failedResult(err), err
 	}
 
-	// Display subagent starting
-	r.displayStatus(agent.Name, statusStarting, "")
+	rc.emit(port.SubagentEvent{Type: port.SubagentEventStarted})
+
+	result, err := r.runExecutionLoop(rc)
+	result.Model = rc.servedModel()
+	return result, err
+}
+
+// RunParallel executes multiple subagent tasks concurrently, bounded by SubagentConfig.MaxConcurrent.
+//
+// Each task runs in its own conversation session. Results are returned in the same
+// order as the input tasks. Per-task failures are captured in each result's Status
+// and Error rather than aborting the batch. Each run's model is an option of its
+// own session, so runs on different models proceed concurrently.
+//
+// A MaxConcurrent of zero or less runs every task at once.
+//
+// Returns an error only if no tasks are provided.
+func (r *SubagentRunner) RunParallel(ctx context.Context, tasks []SubagentTask) ([]*SubagentResult, error) {
+	if len(tasks) == 0 {
+		return nil, errors.New("no subagent tasks provided")
+	}
+
+	limit := r.config.MaxConcurrent
+	if limit <= 0 || limit > len(tasks) {
+		limit = len(tasks)
+	}
+
+	results := make([]*SubagentResult, len(tasks))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(index int, task SubagentTask) {
+			defer wg.Done()
+
+			// Wait for a free slot unless the parent is cancelled first
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[index] = r.validationFailedResult(task.SubagentID, task.Agent, ctx.Err())
+				return
+			}
+
+			result, err := r.Run(ctx, task.Agent, task.Prompt, task.SubagentID)
+			if result == nil {
+				result = r.validationFailedResult(task.SubagentID, task.Agent, err)
+			}
+			// Each goroutine writes to its own index, so no lock is needed
+			results[index] = result
+		}(i, task)
+	}
 
-	return r.runExecutionLoop(rc)
+	wg.Wait()
+	return results, nil
 }
 
 // validateInputs validates the input parameters for subagent execution.
@@ -300,40 +535,95 @@ func (r *SubagentRunner) validationFailedResult(
 
 // failedResult creates a failed result from the run context.
 func (rc *subagentRunContext) failedResult(err error) *SubagentResult {
-	// Display failure status
-	rc.runner.displayStatus(rc.agent.Name, statusFailed, err.Error())
+	duration := time.Since(rc.startTime)
+	rc.emit(port.SubagentEvent{Type: port.SubagentEventFailed, Text: err.Error(), Duration: duration})
 
 	return &SubagentResult{
 		SubagentID:   rc.subagentID,
 		AgentName:    rc.agent.Name,
 		Status:       "failed",
 		ActionsTaken: rc.actionsTaken,
-		Duration:     time.Since(rc.startTime),
+		Duration:     duration,
 		Error:        err,
+		SessionID:    rc.sessionID,
+		TokensUsed:   rc.usage,
+		ToolErrors:   rc.toolErrors,
 	}
 }
 
-// completedResult creates a successful completion result from the run context.
-func (rc *subagentRunContext) completedResult() *SubagentResult {
-	output := ""
-	if rc.lastMessage != nil {
-		// Prefix output with subagent identifier for clarity
-		output = "[SUBAGENT: " + rc.agent.Name + "]\n\n" + rc.lastMessage.Content
-	}
-
+// timedOutResult creates a result for a run that exceeded its MaxDuration.
+// Any output produced before the deadline is included so the parent can decide how to proceed.
+func (rc *subagentRunContext) timedOutResult() *SubagentResult {
 	duration := time.Since(rc.startTime)
+	err := fmt.Errorf("subagent exceeded max duration of %s: %w", rc.maxDuration, context.DeadlineExceeded)
 
-	// Display completion status with details
-	details := fmt.Sprintf("%d actions, %.1fs", rc.actionsTaken, duration.Seconds())
-	rc.runner.displayStatus(rc.agent.Name, statusCompleted, details)
+	rc.emit(port.SubagentEvent{Type: port.SubagentEventTimedOut, Text: err.Error(), Duration: duration})
 
 	return &SubagentResult{
 		SubagentID:   rc.subagentID,
 		AgentName:    rc.agent.Name,
-		Status:       "completed",
-		Output:       output,
+		Status:       "timed_out",
+		Output:       rc.output(),
 		ActionsTaken: rc.actionsTaken,
 		Duration:     duration,
+		Error:        err,
+		SessionID:    rc.sessionID,
+		TokensUsed:   rc.usage,
+		ToolErrors:   rc.toolErrors,
+	}
+}
+
+// isTimedOut reports whether the run's own deadline expired while the parent context is still live.
+func (rc *subagentRunContext) isTimedOut() bool {
+	return errors.Is(rc.ctx.Err(), context.DeadlineExceeded) && rc.parentCtx.Err() == nil
+}
+
+// output returns the last assistant message prefixed with the subagent identifier.
+func (rc *subagentRunContext) output() string {
+	if rc.lastMessage == nil {
+		return ""
+	}
+	// Prefix output with subagent identifier for clarity
+	return "[SUBAGENT: " + rc.agent.Name + "]\n\n" + rc.lastMessage.Content
+}
+
+// completedResult creates a successful completion result from the run context.
+// Long outputs are replaced by a summary when summarization is configured.
+func (rc *subagentRunContext) completedResult() *SubagentResult {
+	output := rc.output()
+	summarized := false
+	transcriptRef := ""
+	summaryModel := ""
+
+	if rc.runner.shouldSummarize(rc) {
+		summary, model, err := rc.runner.summarizeOutput(rc)
+		if err != nil {
+			rc.logger.Warn("Failed to summarize subagent output", "error", err, "model", model)
+		} else {
+			output = "[SUBAGENT: " + rc.agent.Name + "]\n\n" + summary
+			summarized = true
+			transcriptRef = rc.runner.saveTranscript(rc)
+			summaryModel = model
+		}
+	}
+
+	duration := time.Since(rc.startTime)
+
+	rc.emit(port.SubagentEvent{Type: port.SubagentEventCompleted, Duration: duration})
+
+	return &SubagentResult{
+		SubagentID:    rc.subagentID,
+		AgentName:     rc.agent.Name,
+		Status:        "completed",
+		Output:        output,
+		ActionsTaken:  rc.actionsTaken,
+		Duration:      duration,
+		Summarized:    summarized,
+		TranscriptRef: transcriptRef,
+		SummaryModel:  summaryModel,
+		SessionID:     rc.sessionID,
+		TokensUsed:    rc.usage,
+		ToolErrors:    rc.toolErrors,
 	}
 }
 
@@ -356,6 +646,10 @@ func (r *SubagentRunner) setupAgentSession(rc *subagentRunContext) error {
 // runExecutionLoop runs the main tool execution loop until completion or limit.
 func (r *SubagentRunner) runExecutionLoop(rc *subagentRunContext) (*SubagentResult, error) {
 	for rc.actionsTaken < rc.maxActions {
+		if rc.isTimedOut() {
+			return rc.timedOutResult(), nil
+		}
+
 		// Add thinking mode to context if enabled for this session
 		ctx := rc.ctx
 		thinkingInfo, _ := r.convService.GetThinkingMode(rc.sessionID)
@@ -370,10 +664,21 @@ func (r *SubagentRunner) runExecutionLoop(rc *subagentRunContext) (*SubagentResu
 		// Process assistant response
 		msg, toolCalls, err := r.processAssistantResponseWithFallback(ctx, rc)
 		if err != nil {
+			if rc.isTimedOut() {
+				return rc.timedOutResult(), nil
+			}
+			err = providerError(rc.ctx, err)
 			return rc.failedResult(err), err
 		}
 
 		rc.lastMessage = msg
+		rc.addUsage(msg)
+		if tokens := thinkingTokens(msg); tokens > 0 {
+			rc.logger.Debug("Thinking tokens used", "thinking_tokens", tokens)
+		}
+		if msg != nil && strings.TrimSpace(msg.Content) != "" {
+			rc.emit(port.SubagentEvent{Type: port.SubagentEventText, Text: msg.Content})
+		}
 
 		// No tool calls means completion
 		if len(toolCalls) == 0 {
@@ -382,6 +687,9 @@ func (r *SubagentRunner) runExecutionLoop(rc *subagentRunContext) (*SubagentResu
 
 		// Execute tools and feed results back
 		if err := r.processToolCalls(rc, toolCalls); err != nil {
+			if rc.isTimedOut() {
+				return rc.timedOutResult(), nil
+			}
 			return rc.failedResult(err), err
 		}
 
@@ -407,26 +715,27 @@ func (r *SubagentRunner) processAssistantResponseWithFallback(
 		return msg, toolCalls, nil
 	}
 
-	// Check if this is a model-related 400 error and we tried to switch models
-	if !r.isModelError(err) || r.aiProvider.GetModel() == "" || r.aiProvider.GetModel() == rc.originalModel {
+	// Check if this is a model-related 400 error on the run's own model
+	if !r.isModelError(err) || rc.model == "" {
 		return nil, nil, err
 	}
 
 	// Log warning and fall back to parent model
 	fallbackMsg := fmt.Sprintf(
 		"Model '%s' not available for subagent, falling back to parent model '%s': %v",
+		rc.model,
 		r.aiProvider.GetModel(),
-		rc.originalModel,
 		err,
 	)
 	if r.userInterface != nil {
 		_ = r.userInterface.DisplaySubagentStatus(rc.agent.Name, "Model fallback", fallbackMsg)
 	}
 
-	// Restore original model and retry
-	if modelErr := r.aiProvider.SetModel(rc.originalModel); modelErr != nil {
-		return nil, nil, fmt.Errorf("failed to restore original model: %w (original error: %w)", modelErr, err)
+	// Switch the session to the parent's model and retry
+	if modelErr := r.convService.SetSessionModel(rc.sessionID, ""); modelErr != nil {
+		return nil, nil, fmt.Errorf("failed to restore parent model: %w (original error: %w)", modelErr, err)
 	}
+	rc.model = ""
 
 	// Retry with parent model
 	return r.convService.ProcessAssistantResponse(ctx, rc.sessionID)
@@ -436,25 +745,41 @@ func (r *SubagentRunner) processAssistantResponseWithFallback(
 func (r *SubagentRunner) processToolCalls(rc *subagentRunContext, toolCalls []port.ToolCallInfo) error {
 	var toolResults []entity.ToolResult
 	for _, tc := range toolCalls {
-		if !r.isToolCallAllowed(tc) {
+		if !rc.isToolCallAllowed(tc) {
 			// Blocked tools return error but DON'T count toward action limit
 			toolResults = append(toolResults, entity.ToolResult{
-				ToolID:  tc.ToolID,
-				Result:  fmt.Sprintf("tool '%s' is not allowed for this subagent", tc.ToolName),
+				ToolID: tc.ToolID,
+				Result: fmt.Sprintf(
+					"tool '%s' is not allowed for this subagent (allowed tools: %s)",
+					tc.ToolName,
+					formatAllowedTools(rc.allowedTools),
+				),
 				IsError: true,
 			})
 			continue
 		}
 
 		// Execute allowed tool
-		r.displayToolExecution(rc.agent.Name, tc.ToolName)
-		result := r.executeToolCall(rc.ctx, tc)
+		if r.progressSink == nil {
+			r.displayToolExecution(rc.agent.Name, tc.ToolName)
+		}
+		toolStart := time.Now()
+		result := r.executeToolCall(rc, tc)
 		toolResults = append(toolResults, result)
-		r.displayToolResult(rc.agent.Name, tc.ToolName, result.IsError)
 
 		// NOTE: actionsTaken increments are safe because tool execution is currently sequential.
 		// If tool execution becomes concurrent in the future, use atomic.AddInt32() instead.
 		rc.actionsTaken++ // Only executed tools count
+		if result.IsError {
+			rc.toolErrors++
+		}
+
+		rc.emit(port.SubagentEvent{
+			Type:     port.SubagentEventToolExecuted,
+			ToolName: tc.ToolName,
+			IsError:  result.IsError,
+			Duration: time.Since(toolStart),
+		})
 	}
 
 	if len(toolResults) > 0 {
@@ -463,19 +788,146 @@ func (r *SubagentRunner) processToolCalls(rc *subagentRunContext, toolCalls []po
 	return nil
 }
 
-// isToolCallAllowed checks if a tool call is allowed based on config's AllowedTools.
-func (r *SubagentRunner) isToolCallAllowed(tc port.ToolCallInfo) bool {
-	if r.config.AllowedTools == nil {
-		return true // nil = allow all
+// resolveMaxDuration returns the wall-clock limit for an agent.
+// A positive agent MaxDuration (from AGENT.md frontmatter) overrides the runner config.
+func (r *SubagentRunner) resolveMaxDuration(agent *entity.Subagent) time.Duration {
+	if agent.MaxDuration > 0 {
+		return agent.MaxDuration
+	}
+	return r.config.MaxDuration
+}
+
+// servedModel returns the model serving the run: its own, else the provider's default.
+func (rc *subagentRunContext) servedModel() string {
+	if rc.model != "" {
+		return rc.model
+	}
+	return rc.runner.aiProvider.GetModel()
+}
+
+// shouldSummarize reports whether the run's final output should be summarized before
+// being returned: summarization must be enabled, the caller must not have requested
+// verbatim output, and the output must exceed the configured threshold.
+func (r *SubagentRunner) shouldSummarize(rc *subagentRunContext) bool {
+	if r.config.SummaryThreshold <= 0 || rc.lastMessage == nil {
+		return false
+	}
+	if port.IsVerbatimOutput(rc.parentCtx) {
+		return false
+	}
+	return len(rc.lastMessage.Content) > r.config.SummaryThreshold
+}
+
+// summarizeOutput issues a single tool-less AI call that condenses the subagent's
+// final message, on the model routed for summarization if any. It returns the
+// summary and the model that was asked for it.
+func (r *SubagentRunner) summarizeOutput(rc *subagentRunContext) (string, string, error) {
+	prompt := fmt.Sprintf(subagentSummaryPrompt, rc.taskPrompt, rc.lastMessage.Content)
+	messages := []port.MessageParam{{Role: entity.RoleUser, Content: prompt}}
+
+	opts := port.RequestOptions{Model: r.modelRouter.Route(ModelTaskSummarization, false)}
+	model := opts.Model
+	if model == "" {
+		model = r.aiProvider.GetModel()
+	}
+	msg, _, err := r.aiProvider.SendMessageWithOptions(rc.ctx, messages, nil, opts)
+	if err != nil {
+		return "", model, err
+	}
+	rc.addUsage(msg)
+	if msg == nil || strings.TrimSpace(msg.Content) == "" {
+		return "", model, errors.New("summary response was empty")
+	}
+	return strings.TrimSpace(msg.Content), model, nil
+}
+
+// addUsage adds the tokens of msg, which may be nil, to the run's usage.
+func (rc *subagentRunContext) addUsage(msg *entity.Message) {
+	if msg == nil || msg.Usage == nil {
+		return
+	}
+	rc.usage.InputTokens += msg.Usage.InputTokens
+	rc.usage.OutputTokens += msg.Usage.OutputTokens
+}
+
+// thinkingTokens returns the estimated thinking tokens in msg, which may be nil.
+func thinkingTokens(msg *entity.Message) int {
+	if msg == nil {
+		return 0
+	}
+	return msg.ThinkingTokens()
+}
+
+// saveTranscript persists the full subagent conversation and returns its reference.
+// Falls back to the task prompt and final message when the conversation service
+// does not expose history. Returns "" if no store is configured or saving fails.
+func (r *SubagentRunner) saveTranscript(rc *subagentRunContext) string {
+	if r.transcriptStore == nil {
+		return ""
+	}
+
+	var messages []entity.Message
+	if reader, ok := r.convService.(conversationReader); ok {
+		if conv, err := reader.GetConversation(rc.sessionID); err == nil && conv != nil {
+			messages = conv.GetMessages()
+		}
+	}
+	if len(messages) == 0 {
+		if userMsg, err := entity.NewMessage(entity.RoleUser, rc.taskPrompt); err == nil {
+			messages = append(messages, *userMsg)
+		}
+		messages = append(messages, *rc.lastMessage)
+	}
+
+	// Raw thinking is never persisted
+	stored := make([]entity.Message, len(messages))
+	for i := range messages {
+		stored[i] = messages[i].WithoutThinking()
+	}
+
+	ref, err := r.transcriptStore.SaveTranscript(context.WithoutCancel(rc.ctx), rc.subagentID, stored)
+	if err != nil {
+		rc.logger.Warn("Failed to save subagent transcript", "error", err)
+		return ""
+	}
+	return ref
+}
+
+// resolveAllowedTools computes the effective tool allowlist for an agent.
+//
+// The agent's AllowedTools is combined with the runner config's AllowedTools:
+//   - Empty agent list: inherit the config (nil = all tools, empty slice = no tools)
+//   - Literal ["none"]: no tools, regardless of config
+//   - Otherwise: the intersection of the agent list and the config list
+//
+// Returns nil when all tools are allowed.
+func (r *SubagentRunner) resolveAllowedTools(agent *entity.Subagent) []string {
+	if len(agent.AllowedTools) == 0 {
+		return r.config.AllowedTools
+	}
+	if len(agent.AllowedTools) == 1 && agent.AllowedTools[0] == noToolsSentinel {
+		return []string{}
 	}
-	if len(r.config.AllowedTools) == 0 {
-		return false // empty slice = block all
+
+	allowed := make([]string, 0, len(agent.AllowedTools))
+	for _, tool := range agent.AllowedTools {
+		if r.config.AllowedTools == nil || isToolAllowed(r.config.AllowedTools, tool) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// isToolCallAllowed checks if a tool call is allowed by the run's effective allowlist.
+func (rc *subagentRunContext) isToolCallAllowed(tc port.ToolCallInfo) bool {
+	if rc.allowedTools == nil {
+		return true // nil = allow all
 	}
-	return r.isToolAllowed(r.config.AllowedTools, tc.ToolName)
+	return isToolAllowed(rc.allowedTools, tc.ToolName)
 }
 
 // isToolAllowed checks if a tool is in the allowed list.
-func (r *SubagentRunner) isToolAllowed(allowedTools []string, toolName string) bool {
+func isToolAllowed(allowedTools []string, toolName string) bool {
 	for _, allowed := range allowedTools {
 		if allowed == toolName {
 			return true
@@ -484,10 +936,18 @@ func (r *SubagentRunner) isToolAllowed(allowedTools []string, toolName string) b
 	return false
 }
 
+// formatAllowedTools renders an allowlist for error messages.
+func formatAllowedTools(allowedTools []string) string {
+	if len(allowedTools) == 0 {
+		return noToolsSentinel
+	}
+	return strings.Join(allowedTools, ", ")
+}
+
 // executeToolCall executes a single tool call and returns the result.
-func (r *SubagentRunner) executeToolCall(ctx context.Context, tc port.ToolCallInfo) entity.ToolResult {
+func (r *SubagentRunner) executeToolCall(rc *subagentRunContext, tc port.ToolCallInfo) entity.ToolResult {
 	// Recursion prevention: block "task" tool in subagent context
-	if tc.ToolName == "task" && port.IsSubagentContext(ctx) {
+	if tc.ToolName == "task" && port.IsSubagentContext(rc.ctx) {
 		return entity.ToolResult{
 			ToolID:  tc.ToolID,
 			Result:  "task tool is blocked in subagent context to prevent recursion",
@@ -495,7 +955,15 @@ func (r *SubagentRunner) executeToolCall(ctx context.Context, tc port.ToolCallIn
 		}
 	}
 
-	result, execErr := r.toolExecutor.ExecuteTool(ctx, tc.ToolName, tc.Input)
+	if err := checkCommandAllowlist(rc.commandAllowlist, tc); err != nil {
+		return entity.ToolResult{
+			ToolID:  tc.ToolID,
+			Result:  newToolBlockedError("Command blocked", err).Error(),
+			IsError: true,
+		}
+	}
+
+	result, execErr := r.toolExecutor.ExecuteTool(rc.ctx, tc.ToolName, tc.Input)
 	if execErr != nil {
 		return entity.ToolResult{
 			ToolID:  tc.ToolID,
@@ -510,6 +978,36 @@ func (r *SubagentRunner) executeToolCall(ctx context.Context, tc port.ToolCallIn
 	}
 }
 
+// emit reports a lifecycle event for this run, filling in the run's identity and action count.
+// Without a progress sink, lifecycle events fall back to DisplaySubagentStatus.
+func (rc *subagentRunContext) emit(event port.SubagentEvent) {
+	event.SubagentID = rc.subagentID
+	event.AgentName = rc.agent.Name
+	event.Actions = rc.actionsTaken
+
+	r := rc.runner
+	if r.progressSink != nil {
+		r.progressSink.OnSubagentEvent(event)
+		return
+	}
+
+	details := fmt.Sprintf("%d actions, %.1fs", event.Actions, event.Duration.Seconds())
+	switch event.Type {
+	case port.SubagentEventStarted:
+		r.displayStatus(event.AgentName, statusStarting, "")
+	case port.SubagentEventToolExecuted:
+		r.displayToolResult(event.AgentName, event.ToolName, event.IsError)
+	case port.SubagentEventCompleted:
+		r.displayStatus(event.AgentName, statusCompleted, details)
+	case port.SubagentEventFailed:
+		r.displayStatus(event.AgentName, statusFailed, event.Text)
+	case port.SubagentEventTimedOut:
+		r.displayStatus(event.AgentName, statusTimedOut, details)
+	case port.SubagentEventText:
+		// Assistant text is only surfaced through a progress sink
+	}
+}
+
 // displayStatus displays a status message for the subagent if UI is available.
 func (r *SubagentRunner) displayStatus(agentName string, status string, details string) {
 	if r.userInterface != nil {
@@ -544,7 +1042,7 @@ func (r *SubagentRunner) injectTurnWarningIfNeeded(rc *subagentRunContext) {
 	if warningMsg != "" {
 		if _, err := r.convService.AddUserMessage(rc.ctx, rc.sessionID, warningMsg); err != nil {
 			// Log error but don't fail execution - warnings are non-critical
-			fmt.Fprintf(os.Stderr, "[SubagentRunner] Failed to inject turn warning: %v\n", err)
+			rc.logger.Warn("Failed to inject turn warning", "error", err)
 		}
 	}
 }
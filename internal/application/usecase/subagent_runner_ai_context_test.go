@@ -165,6 +165,10 @@ func (m *contextTrackingConvServiceMock) GetThinkingMode(_ string) (port.Thinkin
 	return m.thinkingModeInfo, nil
 }
 
+func (m *contextTrackingConvServiceMock) SetSessionModel(_ string, _ string) error {
+	return nil
+}
+
 // Helper method to get tracked contexts (thread-safe).
 func (m *contextTrackingConvServiceMock) GetProcessResponseContexts() []context.Context {
 	m.mu.Lock()
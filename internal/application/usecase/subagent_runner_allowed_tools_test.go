@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Per-Subagent Allowed Tools Tests
+// =============================================================================
+//
+// These tests verify that SubagentRunner combines the agent's AllowedTools with
+// the runner config's AllowedTools:
+//   - Empty agent list inherits the parent config
+//   - Literal ["none"] disables all tools
+//   - Otherwise the intersection of both lists is offered and enforced
+//
+// =============================================================================
+
+func TestSubagentRunner_AllowedTools_OffersEffectiveAllowlist(t *testing.T) {
+	tests := []struct {
+		name          string
+		configAllowed []string
+		agentAllowed  []string
+		wantFiltered  bool
+		wantAllowed   []string
+	}{
+		{
+			name:          "empty agent list inherits nil config (all tools)",
+			configAllowed: nil,
+			agentAllowed:  nil,
+			wantFiltered:  false,
+		},
+		{
+			name:          "empty agent list inherits restricted config",
+			configAllowed: []string{"bash", "read_file"},
+			agentAllowed:  []string{},
+			wantFiltered:  true,
+			wantAllowed:   []string{"bash", "read_file"},
+		},
+		{
+			name:          "agent list intersects with config",
+			configAllowed: []string{"bash", "read_file"},
+			agentAllowed:  []string{"read_file", "list_files"},
+			wantFiltered:  true,
+			wantAllowed:   []string{"read_file"},
+		},
+		{
+			name:          "agent list used as-is when config allows all",
+			configAllowed: nil,
+			agentAllowed:  []string{"read_file", "list_files"},
+			wantFiltered:  true,
+			wantAllowed:   []string{"read_file", "list_files"},
+		},
+		{
+			name:          "none disables all tools",
+			configAllowed: nil,
+			agentAllowed:  []string{"none"},
+			wantFiltered:  true,
+			wantAllowed:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newSubagentRunnerConvServiceMock()
+			convService.processResponseMessages = []*entity.Message{createSubagentAssistantMessage("Done")}
+
+			config := SubagentConfig{MaxActions: 10, AllowedTools: tt.configAllowed}
+			runner := NewSubagentRunner(
+				convService,
+				newSubagentRunnerToolExecutorMock(),
+				newSubagentRunnerAIProviderMock(),
+				nil,
+				config,
+			)
+			agent := createTestAgent("agent-allowlist", "allowlist-agent")
+			agent.AllowedTools = tt.agentAllowed
+
+			if _, err := runner.Run(context.Background(), agent, "Do work", "subagent-allowlist-001"); err != nil {
+				t.Fatalf("Run() error = %v, want nil", err)
+			}
+
+			if len(convService.processResponseContexts) == 0 {
+				t.Fatal("ProcessAssistantResponse() was not called")
+			}
+			got, ok := port.AllowedToolsFromContext(convService.processResponseContexts[0])
+			if ok != tt.wantFiltered {
+				t.Fatalf("AllowedToolsFromContext() found = %v, want %v", ok, tt.wantFiltered)
+			}
+			if tt.wantFiltered && !reflect.DeepEqual(got, tt.wantAllowed) {
+				t.Errorf("offered tools = %v, want %v", got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestSubagentRunner_AllowedTools_IntersectionBlocksToolsOutsideAgentList(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Running tools"),
+		createSubagentAssistantMessage("Done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{
+			{ToolID: "t1", ToolName: "read_file", Input: map[string]interface{}{"path": "a.go"}},
+			{ToolID: "t2", ToolName: "bash", Input: map[string]interface{}{"command": "ls"}},
+		},
+		nil,
+	}
+
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	config := SubagentConfig{MaxActions: 10, AllowedTools: []string{"bash", "read_file"}}
+	runner := NewSubagentRunner(convService, toolExecutor, newSubagentRunnerAIProviderMock(), nil, config)
+	agent := createTestAgent("agent-intersect", "intersect-agent")
+	agent.AllowedTools = []string{"read_file", "list_files"}
+
+	if _, err := runner.Run(context.Background(), agent, "Do work", "subagent-intersect-001"); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	if !reflect.DeepEqual(toolExecutor.executeToolName, []string{"read_file"}) {
+		t.Errorf("executed tools = %v, want [read_file]", toolExecutor.executeToolName)
+	}
+}
+
+func TestSubagentRunner_AllowedTools_NoneBlocksAllTools(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Trying tools"),
+		createSubagentAssistantMessage("Done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "t1", ToolName: "read_file", Input: map[string]interface{}{"path": "a.go"}}},
+		nil,
+	}
+
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	runner := NewSubagentRunner(
+		convService,
+		toolExecutor,
+		newSubagentRunnerAIProviderMock(),
+		nil,
+		SubagentConfig{MaxActions: 10},
+	)
+	agent := createTestAgent("agent-none", "none-agent")
+	agent.AllowedTools = []string{"none"}
+
+	if _, err := runner.Run(context.Background(), agent, "Do work", "subagent-none-001"); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	if toolExecutor.executeToolCalls != 0 {
+		t.Errorf("ExecuteTool() called %d times, want 0", toolExecutor.executeToolCalls)
+	}
+	results := convService.addToolResultResults[0]
+	want := "tool 'read_file' is not allowed for this subagent (allowed tools: none)"
+	if len(results) != 1 || results[0].Result != want || !results[0].IsError {
+		t.Errorf("tool results = %+v, want single error %q", results, want)
+	}
+}
+
+func TestSubagentRunner_AllowedTools_HallucinatedToolReturnsError(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Calling a tool I was never offered"),
+		createSubagentAssistantMessage("Done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "t1", ToolName: "delete_everything", Input: map[string]interface{}{}}},
+		nil,
+	}
+
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	runner := NewSubagentRunner(
+		convService,
+		toolExecutor,
+		newSubagentRunnerAIProviderMock(),
+		nil,
+		SubagentConfig{MaxActions: 10},
+	)
+	agent := createTestAgent("agent-hallucinate", "hallucinate-agent")
+
+	result, err := runner.Run(context.Background(), agent, "Do work", "subagent-hallucinate-001")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+
+	if toolExecutor.executeToolCalls != 0 {
+		t.Errorf("ExecuteTool() called %d times, want 0", toolExecutor.executeToolCalls)
+	}
+	if result.ActionsTaken != 0 {
+		t.Errorf("ActionsTaken = %d, want 0", result.ActionsTaken)
+	}
+	results := convService.addToolResultResults[0]
+	if len(results) != 1 || !results[0].IsError {
+		t.Fatalf("tool results = %+v, want single error result", results)
+	}
+	if !strings.Contains(results[0].Result, "delete_everything") ||
+		!strings.Contains(results[0].Result, "allowed tools: bash, read_file") {
+		t.Errorf("error result = %q, want it to name the tool and the restriction", results[0].Result)
+	}
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newRoutedTestRunner creates a runner whose subagent finishes with output,
+// summarized past 50 characters, and whose models are routed by routes.
+func newRoutedTestRunner(
+	output string,
+	caps map[string]port.ModelCapabilities,
+	routes map[ModelTask]string,
+) (*SubagentRunner, *capabilityAIProviderMock) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(output)}
+	aiProvider := newCapabilityAIProviderMock(caps)
+	aiProvider.sendMessageResponse = createSubagentAssistantMessage("Short summary.")
+	config := SubagentConfig{MaxActions: 10, SummaryThreshold: 50}
+	runner := NewSubagentRunner(convService, newSubagentRunnerToolExecutorMock(), aiProvider, nil, config)
+	runner.SetModelRouter(NewModelRouter(aiProvider, routes))
+	return runner, aiProvider
+}
+
+func TestSubagentRunner_ModelRouting_RoutesInheritingSubagentsAndSummaries(t *testing.T) {
+	runner, aiProvider := newRoutedTestRunner(strings.Repeat("detailed finding ", 10), nil, map[ModelTask]string{
+		ModelTaskSubagent:      "haiku",
+		ModelTaskSummarization: "tiny-summarizer",
+	})
+
+	result, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-route-001")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Model != "claude-3-5-haiku-20241022" {
+		t.Errorf("Model = %q, want the routed haiku model", result.Model)
+	}
+	if aiProvider.GetModel() != "test-model" || aiProvider.setModelCalls != 0 {
+		t.Errorf("provider model = %q after %d SetModel() calls, want the parent's test-model untouched",
+			aiProvider.GetModel(), aiProvider.setModelCalls)
+	}
+
+	// The summary is sent on its own model
+	if !result.Summarized || result.SummaryModel != "tiny-summarizer" {
+		t.Errorf("Summarized = %v, SummaryModel = %q, want a summary by tiny-summarizer", result.Summarized, result.SummaryModel)
+	}
+	if !slices.Equal(aiProvider.sendMessageModels, []string{"tiny-summarizer"}) {
+		t.Errorf("per-request models = %q, want the summary call on tiny-summarizer", aiProvider.sendMessageModels)
+	}
+}
+
+func TestSubagentRunner_ModelRouting_AgentModelWins(t *testing.T) {
+	runner, _ := newRoutedTestRunner("Done", nil, map[ModelTask]string{ModelTaskSubagent: "haiku"})
+	agent := createTestAgent("", "reviewer")
+	agent.Model = "opus"
+
+	result, err := runner.Run(context.Background(), agent, "Review", "subagent-route-002")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Model != "claude-opus-4-5-20250514" {
+		t.Errorf("Model = %q, want the agent's own opus model", result.Model)
+	}
+}
+
+func TestSubagentRunner_ModelRouting_SkipsModelsWithoutTools(t *testing.T) {
+	runner, _ := newRoutedTestRunner(strings.Repeat("detailed finding ", 10),
+		map[string]port.ModelCapabilities{"tiny-summarizer": {MaxOutputTokens: 2048}},
+		map[ModelTask]string{
+			ModelTaskSubagent:      "tiny-summarizer",
+			ModelTaskSummarization: "tiny-summarizer",
+		})
+
+	result, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-route-003")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	convService := runner.convService.(*subagentRunnerConvServiceMock)
+	if convService.setSessionModelCalls != 0 || result.Model != "test-model" {
+		t.Errorf("SetSessionModel() called %d times, Model = %q, want the parent's test-model kept",
+			convService.setSessionModelCalls, result.Model)
+	}
+	// Summaries use no tools, so the tool-less model still writes them
+	if result.SummaryModel != "tiny-summarizer" {
+		t.Errorf("SummaryModel = %q, want tiny-summarizer", result.SummaryModel)
+	}
+}
@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Parallel Subagent Execution Tests
+// =============================================================================
+//
+// These tests verify that SubagentRunner.RunParallel:
+//   - Never runs more than MaxConcurrent subagents at once
+//   - Returns results in input order regardless of completion order
+//   - Captures per-task failures without aborting the batch
+//   - Sends each subagent's requests on its own model, even when they overlap
+//
+// =============================================================================
+
+// parallelConvServiceMock gives each conversation its own session and tracks how
+// many ProcessAssistantResponse calls are in flight at once.
+type parallelConvServiceMock struct {
+	*subagentRunnerConvServiceMock
+
+	aiProvider *subagentRunnerAIProviderMock
+	delays     map[string]time.Duration // Prompt -> processing delay
+	failPrompt string                   // Prompt whose processing fails
+
+	nextSession      int
+	sessionPrompts   map[string]string
+	sessionOverrides map[string]string // Session -> model set by SetSessionModel
+	sessionModels    map[string]string // Session -> model its requests were sent on
+	inFlight         int
+	maxInFlight      int
+}
+
+func newParallelConvServiceMock(aiProvider *subagentRunnerAIProviderMock) *parallelConvServiceMock {
+	return &parallelConvServiceMock{
+		subagentRunnerConvServiceMock: newSubagentRunnerConvServiceMock(),
+		aiProvider:                    aiProvider,
+		delays:                        map[string]time.Duration{},
+		sessionPrompts:                map[string]string{},
+		sessionOverrides:              map[string]string{},
+		sessionModels:                 map[string]string{},
+	}
+}
+
+func (m *parallelConvServiceMock) StartConversation(_ context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.startConversationCalls++
+	m.nextSession++
+	return fmt.Sprintf("parallel-session-%d", m.nextSession), nil
+}
+
+func (m *parallelConvServiceMock) AddUserMessage(
+	_ context.Context,
+	sessionID string,
+	content string,
+) (*entity.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionPrompts[sessionID] = content
+	return entity.NewMessage(entity.RoleUser, content)
+}
+
+func (m *parallelConvServiceMock) SetSessionModel(sessionID string, model string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionOverrides[sessionID] = model
+	return nil
+}
+
+func (m *parallelConvServiceMock) ProcessAssistantResponse(
+	_ context.Context,
+	sessionID string,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.mu.Lock()
+	prompt := m.sessionPrompts[sessionID]
+	m.sessionModels[sessionID] = m.sessionOverrides[sessionID]
+	if m.sessionModels[sessionID] == "" {
+		m.sessionModels[sessionID] = m.aiProvider.GetModel()
+	}
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	delay := m.delays[prompt]
+	m.mu.Unlock()
+
+	time.Sleep(delay)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+
+	if prompt == m.failPrompt {
+		return nil, nil, fmt.Errorf("processing failed for %s", prompt)
+	}
+	return createSubagentAssistantMessage("output for " + prompt), nil, nil
+}
+
+func (m *parallelConvServiceMock) ProcessAssistantResponseStreaming(
+	ctx context.Context,
+	sessionID string,
+	_ port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return m.ProcessAssistantResponse(ctx, sessionID)
+}
+
+func newParallelTasks(n int) []SubagentTask {
+	tasks := make([]SubagentTask, n)
+	for i := range tasks {
+		tasks[i] = SubagentTask{
+			Agent:      createTestAgent("", fmt.Sprintf("agent-%d", i)),
+			Prompt:     fmt.Sprintf("task-%d", i),
+			SubagentID: fmt.Sprintf("subagent-%d", i),
+		}
+	}
+	return tasks
+}
+
+func TestSubagentRunner_RunParallel_RespectsMaxConcurrent(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		taskCount     int
+		wantMax       int
+	}{
+		{name: "bounded by config", maxConcurrent: 2, taskCount: 6, wantMax: 2},
+		{name: "single slot runs sequentially", maxConcurrent: 1, taskCount: 3, wantMax: 1},
+		{name: "zero means unbounded", maxConcurrent: 0, taskCount: 4, wantMax: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aiProvider := newSubagentRunnerAIProviderMock()
+			convService := newParallelConvServiceMock(aiProvider)
+			tasks := newParallelTasks(tt.taskCount)
+			for _, task := range tasks {
+				convService.delays[task.Prompt] = 50 * time.Millisecond
+			}
+			config := SubagentConfig{MaxActions: 10, MaxConcurrent: tt.maxConcurrent}
+			runner := NewSubagentRunner(convService, newSubagentRunnerToolExecutorMock(), aiProvider, nil, config)
+
+			results, err := runner.RunParallel(context.Background(), tasks)
+			if err != nil {
+				t.Fatalf("RunParallel() error = %v", err)
+			}
+			if len(results) != tt.taskCount {
+				t.Fatalf("len(results) = %d, want %d", len(results), tt.taskCount)
+			}
+			if convService.maxInFlight > tt.wantMax {
+				t.Errorf("max concurrent subagents = %d, want at most %d", convService.maxInFlight, tt.wantMax)
+			}
+			if tt.wantMax > 1 && convService.maxInFlight < 2 {
+				t.Errorf("max concurrent subagents = %d, expected tasks to overlap", convService.maxInFlight)
+			}
+			if convService.startConversationCalls != tt.taskCount {
+				t.Errorf("StartConversation() called %d times, want one session per task", convService.startConversationCalls)
+			}
+		})
+	}
+}
+
+func TestSubagentRunner_RunParallel_PreservesInputOrder(t *testing.T) {
+	aiProvider := newSubagentRunnerAIProviderMock()
+	convService := newParallelConvServiceMock(aiProvider)
+	tasks := newParallelTasks(4)
+	// Earlier tasks finish last
+	for i, task := range tasks {
+		convService.delays[task.Prompt] = time.Duration(len(tasks)-i) * 20 * time.Millisecond
+	}
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		aiProvider,
+		nil,
+		SubagentConfig{MaxActions: 10, MaxConcurrent: 4},
+	)
+
+	results, err := runner.RunParallel(context.Background(), tasks)
+	if err != nil {
+		t.Fatalf("RunParallel() error = %v", err)
+	}
+
+	for i, result := range results {
+		if result.SubagentID != tasks[i].SubagentID {
+			t.Errorf("results[%d].SubagentID = %q, want %q", i, result.SubagentID, tasks[i].SubagentID)
+		}
+		if !strings.Contains(result.Output, tasks[i].Prompt) {
+			t.Errorf("results[%d].Output = %q, want output for %q", i, result.Output, tasks[i].Prompt)
+		}
+		if result.Status != "completed" {
+			t.Errorf("results[%d].Status = %q, want completed", i, result.Status)
+		}
+	}
+}
+
+func TestSubagentRunner_RunParallel_CapturesPerTaskErrors(t *testing.T) {
+	aiProvider := newSubagentRunnerAIProviderMock()
+	convService := newParallelConvServiceMock(aiProvider)
+	convService.failPrompt = "task-1"
+	tasks := newParallelTasks(3)
+	tasks[2].Agent = nil // Fails validation before reaching the conversation service
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		aiProvider,
+		nil,
+		SubagentConfig{MaxActions: 10, MaxConcurrent: 2},
+	)
+
+	results, err := runner.RunParallel(context.Background(), tasks)
+	if err != nil {
+		t.Fatalf("RunParallel() error = %v, want per-task errors in results", err)
+	}
+
+	wantStatus := []string{"completed", "failed", "failed"}
+	for i, want := range wantStatus {
+		if results[i] == nil {
+			t.Fatalf("results[%d] is nil", i)
+		}
+		if results[i].Status != want {
+			t.Errorf("results[%d].Status = %q, want %q", i, results[i].Status, want)
+		}
+		if (want == "failed") != (results[i].Error != nil) {
+			t.Errorf("results[%d].Error = %v, want error only for failed tasks", i, results[i].Error)
+		}
+	}
+}
+
+func TestSubagentRunner_RunParallel_IsolatesModels(t *testing.T) {
+	aiProvider := newSubagentRunnerAIProviderMock()
+	convService := newParallelConvServiceMock(aiProvider)
+	tasks := newParallelTasks(4)
+	tasks[0].Agent.Model = "haiku"
+	tasks[2].Agent.Model = "opus"
+	for _, task := range tasks {
+		convService.delays[task.Prompt] = 20 * time.Millisecond
+	}
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		aiProvider,
+		nil,
+		SubagentConfig{MaxActions: 10, MaxConcurrent: 4},
+	)
+
+	if _, err := runner.RunParallel(context.Background(), tasks); err != nil {
+		t.Fatalf("RunParallel() error = %v", err)
+	}
+
+	wantModels := map[string]string{
+		"task-0": resolveModelShorthand("haiku"),
+		"task-1": "test-model",
+		"task-2": resolveModelShorthand("opus"),
+		"task-3": "test-model",
+	}
+	for sessionID, prompt := range convService.sessionPrompts {
+		if got := convService.sessionModels[sessionID]; got != wantModels[prompt] {
+			t.Errorf("%s ran with model %q, want %q", prompt, got, wantModels[prompt])
+		}
+	}
+	if aiProvider.GetModel() != "test-model" || aiProvider.setModelCalls != 0 {
+		t.Errorf("model after batch = %q with %d SetModel() calls, want the parent's model untouched",
+			aiProvider.GetModel(), aiProvider.setModelCalls)
+	}
+}
+
+// promptModelRecorder records the model each request was sent on, keyed by the
+// subagent prompt that opened its conversation.
+type promptModelRecorder struct {
+	*subagentRunnerAIProviderMock
+
+	recordMu sync.Mutex
+	models   map[string][]string
+}
+
+func (p *promptModelRecorder) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessageWithOptions(ctx, messages, tools, port.RequestOptions{})
+}
+
+func (p *promptModelRecorder) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	model := opts.Model
+	if model == "" {
+		model = p.GetModel()
+	}
+	p.recordMu.Lock()
+	p.models[messages[0].Content] = append(p.models[messages[0].Content], model)
+	p.recordMu.Unlock()
+
+	// Hold the request open so the subagents' requests overlap
+	time.Sleep(5 * time.Millisecond)
+	return p.subagentRunnerAIProviderMock.SendMessageWithOptions(ctx, messages, tools, opts)
+}
+
+// TestSubagentRunner_RunParallel_RequestsCarryEachSubagentsModel runs subagents
+// with different models through the real ConversationService; run it with -race.
+func TestSubagentRunner_RunParallel_RequestsCarryEachSubagentsModel(t *testing.T) {
+	aiProvider := &promptModelRecorder{
+		subagentRunnerAIProviderMock: newSubagentRunnerAIProviderMock(),
+		models:                       map[string][]string{},
+	}
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	convService, err := service.NewConversationService(aiProvider, toolExecutor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+	agentModels := []string{"haiku", "sonnet", "opus", "inherit", "haiku", "opus"}
+	tasks := newParallelTasks(len(agentModels))
+	for i, model := range agentModels {
+		tasks[i].Agent.Model = model
+	}
+	runner := NewSubagentRunner(convService, toolExecutor, aiProvider, nil,
+		SubagentConfig{MaxActions: 10, MaxConcurrent: len(tasks)})
+
+	results, err := runner.RunParallel(context.Background(), tasks)
+	if err != nil {
+		t.Fatalf("RunParallel() error = %v", err)
+	}
+
+	for i, task := range tasks {
+		want := resolveModelShorthand(agentModels[i])
+		if want == "" {
+			want = "test-model"
+		}
+		if results[i].Model != want {
+			t.Errorf("%s: result.Model = %q, want %q", task.Prompt, results[i].Model, want)
+		}
+		models := aiProvider.models[task.Prompt]
+		if len(models) == 0 {
+			t.Errorf("%s: sent no requests", task.Prompt)
+		}
+		for _, got := range models {
+			if got != want {
+				t.Errorf("%s: request sent on %q, want %q", task.Prompt, got, want)
+			}
+		}
+	}
+	if aiProvider.setModelCalls != 0 {
+		t.Errorf("SetModel() called %d times, want the shared default untouched", aiProvider.setModelCalls)
+	}
+}
+
+func TestSubagentRunner_RunParallel_EmptyTasks(t *testing.T) {
+	runner := NewSubagentRunner(
+		newSubagentRunnerConvServiceMock(),
+		newSubagentRunnerToolExecutorMock(),
+		newSubagentRunnerAIProviderMock(),
+		nil,
+		SubagentConfig{MaxActions: 10},
+	)
+
+	if _, err := runner.RunParallel(context.Background(), nil); err == nil {
+		t.Error("RunParallel() error = nil, want error for empty task list")
+	}
+}
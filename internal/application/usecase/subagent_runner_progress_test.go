@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// =============================================================================
+// Subagent Progress Sink Tests
+// =============================================================================
+
+// recordingProgressSink records every event it receives.
+type recordingProgressSink struct {
+	mu     sync.Mutex
+	events []port.SubagentEvent
+}
+
+func (s *recordingProgressSink) OnSubagentEvent(event port.SubagentEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordingProgressSink) types() []port.SubagentEventType {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]port.SubagentEventType, len(s.events))
+	for i, e := range s.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestSubagentRunner_ProgressSink_EmitsLifecycleEventsInOrder(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Let me look around"),
+		createSubagentAssistantMessage("All done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{
+			{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "ls"}},
+			{ToolID: "t2", ToolName: "read_file", Input: map[string]interface{}{"path": "a.go"}},
+		},
+		nil,
+	}
+
+	ui := newThinkingDisplayUIMock()
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		newSubagentRunnerAIProviderMock(),
+		ui,
+		SubagentConfig{MaxActions: 10},
+	)
+	sink := &recordingProgressSink{}
+	runner.SetProgressSink(sink)
+
+	if _, err := runner.Run(context.Background(), createTestAgent("a", "progress-agent"), "Go", "sub-1"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []port.SubagentEventType{
+		port.SubagentEventStarted,
+		port.SubagentEventText,
+		port.SubagentEventToolExecuted,
+		port.SubagentEventToolExecuted,
+		port.SubagentEventText,
+		port.SubagentEventCompleted,
+	}
+	if got := sink.types(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("event types = %v, want %v", got, want)
+	}
+
+	for _, e := range sink.events {
+		if e.AgentName != "progress-agent" || e.SubagentID != "sub-1" {
+			t.Errorf("event %s has identity (%q, %q), want (progress-agent, sub-1)", e.Type, e.AgentName, e.SubagentID)
+		}
+	}
+	if sink.events[2].ToolName != "bash" || sink.events[3].ToolName != "read_file" {
+		t.Errorf("tool events = %q, %q, want bash, read_file", sink.events[2].ToolName, sink.events[3].ToolName)
+	}
+	if last := sink.events[len(sink.events)-1]; last.Actions != 2 {
+		t.Errorf("completed event Actions = %d, want 2", last.Actions)
+	}
+	if ui.getStatusCallsForType(statusStarting) != 0 || ui.getStatusCallsForType(statusCompleted) != 0 {
+		t.Error("lifecycle status should go to the progress sink, not DisplaySubagentStatus")
+	}
+}
+
+func TestSubagentRunner_ProgressSink_EmitsFailedEvent(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseError = errors.New("provider unavailable")
+
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		newSubagentRunnerAIProviderMock(),
+		nil,
+		SubagentConfig{MaxActions: 10},
+	)
+	sink := &recordingProgressSink{}
+	runner.SetProgressSink(sink)
+
+	_, err := runner.Run(context.Background(), createTestAgent("a", "failing-agent"), "Go", "sub-2")
+	if !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("Run() error = %v, want ErrProviderUnavailable", err)
+	}
+
+	want := []port.SubagentEventType{port.SubagentEventStarted, port.SubagentEventFailed}
+	if got := sink.types(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("event types = %v, want %v", got, want)
+	}
+	if sink.events[1].Text != err.Error() {
+		t.Errorf("failed event Text = %q, want %q", sink.events[1].Text, err.Error())
+	}
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Subagent Output Summarization Tests
+// =============================================================================
+//
+// These tests verify that SubagentRunner summarizes final outputs longer than
+// SubagentConfig.SummaryThreshold with one extra AI call, stores the full
+// transcript, and passes shorter or verbatim outputs through unchanged.
+//
+// =============================================================================
+
+// recordingTranscriptStore records saved transcripts and returns a fixed reference.
+type recordingTranscriptStore struct {
+	subagentIDs []string
+	messages    [][]entity.Message
+	err         error
+}
+
+func (s *recordingTranscriptStore) SaveTranscript(
+	_ context.Context,
+	subagentID string,
+	messages []entity.Message,
+) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	s.subagentIDs = append(s.subagentIDs, subagentID)
+	s.messages = append(s.messages, messages)
+	return "transcripts/" + subagentID + ".json", nil
+}
+
+// historyConvServiceMock exposes a conversation history like the real ConversationService.
+type historyConvServiceMock struct {
+	*subagentRunnerConvServiceMock
+	conversation *entity.Conversation
+}
+
+func (m *historyConvServiceMock) GetConversation(_ string) (*entity.Conversation, error) {
+	return m.conversation, nil
+}
+
+func newSummaryTestRunner(
+	convService ConversationServiceInterface,
+	aiProvider *subagentRunnerAIProviderMock,
+	threshold int,
+) (*SubagentRunner, *recordingTranscriptStore) {
+	config := SubagentConfig{MaxActions: 10, SummaryThreshold: threshold}
+	runner := NewSubagentRunner(convService, newSubagentRunnerToolExecutorMock(), aiProvider, nil, config)
+	store := &recordingTranscriptStore{}
+	runner.SetTranscriptStore(store)
+	return runner, store
+}
+
+func TestSubagentRunner_Summary_PassesThroughShortOrVerbatimOutput(t *testing.T) {
+	longOutput := strings.Repeat("detailed finding ", 20)
+
+	tests := []struct {
+		name      string
+		threshold int
+		output    string
+		verbatim  bool
+	}{
+		{name: "under threshold", threshold: 1000, output: "Short answer"},
+		{name: "summarization disabled", threshold: 0, output: longOutput},
+		{name: "verbatim requested", threshold: 10, output: longOutput, verbatim: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newSubagentRunnerConvServiceMock()
+			convService.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(tt.output)}
+			aiProvider := newSubagentRunnerAIProviderMock()
+			runner, store := newSummaryTestRunner(convService, aiProvider, tt.threshold)
+
+			ctx := context.Background()
+			if tt.verbatim {
+				ctx = port.WithVerbatimOutput(ctx, true)
+			}
+			result, err := runner.Run(ctx, createTestAgent("", "researcher"), "Investigate", "subagent-pass-001")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if aiProvider.sendMessageCalls != 0 {
+				t.Errorf("SendMessage() called %d times, want 0", aiProvider.sendMessageCalls)
+			}
+			if result.Output != "[SUBAGENT: researcher]\n\n"+tt.output {
+				t.Errorf("Output = %q, want full output", result.Output)
+			}
+			if result.Summarized || result.TranscriptRef != "" {
+				t.Errorf("Summarized = %v, TranscriptRef = %q, want unsummarized", result.Summarized, result.TranscriptRef)
+			}
+			if len(store.subagentIDs) != 0 {
+				t.Errorf("SaveTranscript() called %d times, want 0", len(store.subagentIDs))
+			}
+		})
+	}
+}
+
+func TestSubagentRunner_Summary_SummarizesLongOutput(t *testing.T) {
+	longOutput := strings.Repeat("detailed finding ", 20)
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(longOutput)}
+	aiProvider := newSubagentRunnerAIProviderMock()
+	aiProvider.sendMessageResponse = createSubagentAssistantMessage("Two findings: A and B.")
+	runner, store := newSummaryTestRunner(convService, aiProvider, 50)
+
+	result, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-sum-001")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if aiProvider.sendMessageCalls != 1 {
+		t.Fatalf("SendMessage() called %d times, want 1", aiProvider.sendMessageCalls)
+	}
+	prompt := aiProvider.sendMessageMessages[0][0].Content
+	if !strings.Contains(prompt, "Investigate") || !strings.Contains(prompt, longOutput) {
+		t.Errorf("summary prompt should include the task and the full output, got %q", prompt)
+	}
+	if len(aiProvider.sendMessageTools[0]) != 0 {
+		t.Errorf("summary call offered %d tools, want none", len(aiProvider.sendMessageTools[0]))
+	}
+
+	if result.Output != "[SUBAGENT: researcher]\n\nTwo findings: A and B." {
+		t.Errorf("Output = %q, want summary", result.Output)
+	}
+	if !result.Summarized {
+		t.Error("Summarized = false, want true")
+	}
+	if result.TranscriptRef != "transcripts/subagent-sum-001.json" {
+		t.Errorf("TranscriptRef = %q, want stored transcript reference", result.TranscriptRef)
+	}
+
+	// Without conversation history, the transcript falls back to task prompt and final message
+	if len(store.messages) != 1 || len(store.messages[0]) != 2 || store.messages[0][1].Content != longOutput {
+		t.Errorf("saved transcript = %+v, want task prompt and full final message", store.messages)
+	}
+}
+
+func TestSubagentRunner_Summary_StoresFullConversationHistory(t *testing.T) {
+	conversation, _ := entity.NewConversation()
+	for _, content := range []string{"Investigate", "Looking", "Final report"} {
+		role := entity.RoleAssistant
+		if content == "Investigate" {
+			role = entity.RoleUser
+		}
+		msg, _ := entity.NewMessage(role, content)
+		_ = conversation.AddMessage(*msg)
+	}
+
+	base := newSubagentRunnerConvServiceMock()
+	base.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(strings.Repeat("x", 100))}
+	convService := &historyConvServiceMock{subagentRunnerConvServiceMock: base, conversation: conversation}
+	aiProvider := newSubagentRunnerAIProviderMock()
+	runner, store := newSummaryTestRunner(convService, aiProvider, 50)
+
+	if _, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-hist"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(store.messages) != 1 || len(store.messages[0]) != 3 {
+		t.Fatalf("saved transcript = %+v, want the 3 conversation messages", store.messages)
+	}
+}
+
+func TestSubagentRunner_Summary_FallsBackToFullOutputOnError(t *testing.T) {
+	longOutput := strings.Repeat("detailed finding ", 20)
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(longOutput)}
+	aiProvider := newSubagentRunnerAIProviderMock()
+	aiProvider.sendMessageError = errors.New("rate limited")
+	runner, store := newSummaryTestRunner(convService, aiProvider, 50)
+
+	result, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-err")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want summarization failures to be non-fatal", err)
+	}
+
+	if result.Status != "completed" || result.Summarized {
+		t.Errorf("Status = %q, Summarized = %v, want completed and unsummarized", result.Status, result.Summarized)
+	}
+	if !strings.Contains(result.Output, longOutput) {
+		t.Errorf("Output = %q, want full output", result.Output)
+	}
+	if len(store.subagentIDs) != 0 {
+		t.Errorf("SaveTranscript() called %d times, want 0", len(store.subagentIDs))
+	}
+}
+
+func TestSubagentRunner_Summary_TranscriptOmitsThinking(t *testing.T) {
+	conversation, _ := entity.NewConversation()
+	userMsg, _ := entity.NewMessage(entity.RoleUser, "Investigate")
+	_ = conversation.AddMessage(*userMsg)
+	thinkingMsg, _ := entity.NewMessageWithThinkingBlocks(entity.RoleAssistant, "Final report", []entity.ThinkingBlock{
+		{Thinking: "secret reasoning", Signature: "sig"},
+	})
+	_ = conversation.AddMessage(*thinkingMsg)
+
+	base := newSubagentRunnerConvServiceMock()
+	base.processResponseMessages = []*entity.Message{createSubagentAssistantMessage(strings.Repeat("x", 100))}
+	convService := &historyConvServiceMock{subagentRunnerConvServiceMock: base, conversation: conversation}
+	runner, store := newSummaryTestRunner(convService, newSubagentRunnerAIProviderMock(), 50)
+
+	if _, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Investigate", "subagent-think"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(store.messages) != 1 || len(store.messages[0]) != 2 {
+		t.Fatalf("saved transcript = %+v, want the 2 conversation messages", store.messages)
+	}
+	for _, msg := range store.messages[0] {
+		if len(msg.ThinkingBlocks) != 0 {
+			t.Errorf("stored message %q keeps thinking blocks: %+v", msg.Content, msg.ThinkingBlocks)
+		}
+	}
+	if len(conversation.GetMessages()[1].ThinkingBlocks) != 1 {
+		t.Error("stripping thinking for storage must not modify the live conversation")
+	}
+}
@@ -41,6 +41,7 @@ type subagentRunnerConvServiceMock struct {
 	processResponseError     error
 	processResponseMessages  []*entity.Message
 	processResponseToolCalls [][]port.ToolCallInfo
+	processResponseContexts  []context.Context
 
 	// AddToolResultMessage tracking
 	addToolResultCalls   int
@@ -62,6 +63,11 @@ type subagentRunnerConvServiceMock struct {
 	setThinkingModeError     error
 	setThinkingModeSessionID []string
 	setThinkingModeInfo      []port.ThinkingModeInfo
+
+	// SetSessionModel tracking
+	setSessionModelCalls  int
+	setSessionModelError  error
+	setSessionModelValues []string
 }
 
 func newSubagentRunnerConvServiceMock() *subagentRunnerConvServiceMock {
@@ -99,12 +105,13 @@ func (m *subagentRunnerConvServiceMock) AddUserMessage(
 }
 
 func (m *subagentRunnerConvServiceMock) ProcessAssistantResponse(
-	_ context.Context,
+	ctx context.Context,
 	_ string,
 ) (*entity.Message, []port.ToolCallInfo, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.processResponseCalls++
+	m.processResponseContexts = append(m.processResponseContexts, ctx)
 	if m.processResponseError != nil {
 		return nil, nil, m.processResponseError
 	}
@@ -178,6 +185,27 @@ func (m *subagentRunnerConvServiceMock) GetThinkingMode(_ string) (port.Thinking
 	return port.ThinkingModeInfo{}, nil
 }
 
+func (m *subagentRunnerConvServiceMock) SetSessionModel(_ string, model string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setSessionModelCalls++
+	if m.setSessionModelError != nil {
+		return m.setSessionModelError
+	}
+	m.setSessionModelValues = append(m.setSessionModelValues, model)
+	return nil
+}
+
+// sessionModel returns the model last set for the session, "" for the default.
+func (m *subagentRunnerConvServiceMock) sessionModel() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.setSessionModelValues) == 0 {
+		return ""
+	}
+	return m.setSessionModelValues[len(m.setSessionModelValues)-1]
+}
+
 // subagentRunnerToolExecutorMock implements port.ToolExecutor for testing.
 type subagentRunnerToolExecutorMock struct {
 	mu sync.Mutex
@@ -263,6 +291,7 @@ type subagentRunnerAIProviderMock struct {
 	sendMessageTools    [][]port.ToolParam
 	sendMessageResponse *entity.Message
 	sendMessageToolCall []port.ToolCallInfo
+	sendMessageModels   []string // Models requested in RequestOptions ("" for the default)
 
 	// SetModel tracking
 	setModelCalls  int
@@ -279,13 +308,23 @@ func newSubagentRunnerAIProviderMock() *subagentRunnerAIProviderMock {
 }
 
 func (m *subagentRunnerAIProviderMock) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return m.SendMessageWithOptions(ctx, messages, tools, port.RequestOptions{})
+}
+
+func (m *subagentRunnerAIProviderMock) SendMessageWithOptions(
 	_ context.Context,
 	messages []port.MessageParam,
 	tools []port.ToolParam,
+	opts port.RequestOptions,
 ) (*entity.Message, []port.ToolCallInfo, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.sendMessageCalls++
+	m.sendMessageModels = append(m.sendMessageModels, opts.Model)
 	m.sendMessageMessages = append(m.sendMessageMessages, messages)
 	m.sendMessageTools = append(m.sendMessageTools, tools)
 	if m.sendMessageError != nil {
@@ -635,8 +674,8 @@ func TestSubagentRunner_CleansUpSessionOnError(t *testing.T) {
 	_, err := runner.Run(context.Background(), agent, "Task", "subagent-error-001")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error when AI fails")
+	if !errors.Is(err, ErrProviderUnavailable) || !errors.Is(err, expectedError) {
+		t.Errorf("Run() error = %v, want ErrProviderUnavailable wrapping the provider error", err)
 	}
 	// Session should still be ended for cleanup
 	if convService.endConversationCalls != 1 {
@@ -662,8 +701,8 @@ func TestSubagentRunner_HandlesStartConversationError(t *testing.T) {
 	result, err := runner.Run(context.Background(), agent, "Task", "subagent-start-err")
 
 	// Assert
-	if err == nil {
-		t.Error("Run() should return error when StartConversation fails")
+	if !errors.Is(err, ErrConversationStart) || !errors.Is(err, expectedError) {
+		t.Errorf("Run() error = %v, want ErrConversationStart wrapping the StartConversation error", err)
 	}
 	if result == nil {
 		t.Fatal("Run() should return result on error")
@@ -1349,6 +1388,7 @@ func TestSubagentRunner_AllowedTools_NilAllowedToolsAllowsAll(t *testing.T) {
 
 	runner := NewSubagentRunner(convService, toolExecutor, aiProvider, nil, config)
 	agent := createTestAgent("agent-nil", "Nil Filter Agent")
+	agent.AllowedTools = nil // Agent inherits the parent config
 
 	// Act
 	result, err := runner.Run(context.Background(), agent, "Execute all tools", "subagent-nil-001")
@@ -1365,6 +1405,54 @@ func TestSubagentRunner_AllowedTools_NilAllowedToolsAllowsAll(t *testing.T) {
 	}
 }
 
+func TestSubagentRunner_AllowedCommandPatterns(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.startConversationSession = "subagent-session-allowlist-001"
+	convService.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Checking the host"),
+		createSubagentAssistantMessage("Done"),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{
+			{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "free -m | grep Mem"}},
+			{ToolID: "t2", ToolName: "bash", Input: map[string]interface{}{"command": "free -m; rm -rf /tmp/cache"}},
+			{ToolID: "t3", ToolName: "bash", Input: map[string]interface{}{"command": "grep $(whoami) /etc/passwd"}},
+			{ToolID: "t4", ToolName: "read_file", Input: map[string]interface{}{"path": "/etc/hosts"}},
+		},
+		nil,
+	}
+
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	config := SubagentConfig{
+		MaxActions:             10,
+		AllowedCommandPatterns: []string{`^(free|grep)\b`},
+	}
+	runner := NewSubagentRunner(convService, toolExecutor, newSubagentRunnerAIProviderMock(), nil, config)
+
+	result, err := runner.Run(context.Background(), createTestAgent("agent-allowlist", "Allowlist Agent"),
+		"Check memory", "subagent-allowlist-001")
+	if err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if result == nil {
+		t.Fatal("Run() returned nil result")
+	}
+
+	// Only the allowed pipeline and the non-command tool run
+	if got := strings.Join(toolExecutor.executeToolName, ","); got != "bash,read_file" {
+		t.Errorf("executed tools = %s, want bash,read_file", got)
+	}
+	if len(convService.addToolResultResults) == 0 {
+		t.Fatal("no tool results were fed back")
+	}
+	for _, tr := range convService.addToolResultResults[0] {
+		refused := tr.ToolID == "t2" || tr.ToolID == "t3"
+		if refused != (tr.IsError && strings.Contains(tr.Result, "allowed patterns: ^(free|grep)\\b")) {
+			t.Errorf("result for %s = %+v, refused = %v", tr.ToolID, tr, refused)
+		}
+	}
+}
+
 func TestSubagentRunner_AllowedTools_EmptySliceBlocksAll(t *testing.T) {
 	// Arrange
 	convService := newSubagentRunnerConvServiceMock()
@@ -1479,7 +1567,7 @@ func TestSubagentRunner_BlockedTools_ReturnsErrorResults(t *testing.T) {
 		t.Error("Blocked tool result should be marked as error")
 	}
 
-	expectedMsg := "tool 'list_files' is not allowed for this subagent"
+	expectedMsg := "tool 'list_files' is not allowed for this subagent (allowed tools: bash, read_file)"
 	if blockedResult.Result != expectedMsg {
 		t.Errorf("Blocked tool result message = %q, want %q", blockedResult.Result, expectedMsg)
 	}
@@ -1765,13 +1853,16 @@ func TestSubagentRunner_ModelSwitch_SetsModelHaiku(t *testing.T) {
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// AIProvider.SetModel("claude-3-5-haiku-20241022") should have been called (resolved from "haiku")
-	if aiProvider.setModelCalls == 0 {
-		t.Error("SetModel() was not called, want it to be called with resolved haiku model ID")
-	}
+	// The session is set to the resolved haiku model; the shared provider is never switched
 	expectedModel := "claude-3-5-haiku-20241022"
-	if len(aiProvider.setModelValues) > 0 && aiProvider.setModelValues[0] != expectedModel {
-		t.Errorf("SetModel() called with %q, want %q", aiProvider.setModelValues[0], expectedModel)
+	if got := convService.sessionModel(); got != expectedModel {
+		t.Errorf("session model = %q, want %q", got, expectedModel)
+	}
+	if aiProvider.setModelCalls != 0 {
+		t.Errorf("SetModel() called %d times, want 0", aiProvider.setModelCalls)
+	}
+	if result.Model != expectedModel {
+		t.Errorf("result.Model = %q, want %q", result.Model, expectedModel)
 	}
 }
 
@@ -1801,13 +1892,16 @@ func TestSubagentRunner_ModelSwitch_SetsModelSonnet(t *testing.T) {
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// AIProvider.SetModel("claude-sonnet-4-5-20250929") should have been called (resolved from "sonnet")
-	if aiProvider.setModelCalls == 0 {
-		t.Error("SetModel() was not called, want it to be called with resolved sonnet model ID")
-	}
+	// The session is set to the resolved sonnet model; the shared provider is never switched
 	expectedModel := "claude-sonnet-4-5-20250929"
-	if len(aiProvider.setModelValues) > 0 && aiProvider.setModelValues[0] != expectedModel {
-		t.Errorf("SetModel() called with %q, want %q", aiProvider.setModelValues[0], expectedModel)
+	if got := convService.sessionModel(); got != expectedModel {
+		t.Errorf("session model = %q, want %q", got, expectedModel)
+	}
+	if aiProvider.setModelCalls != 0 {
+		t.Errorf("SetModel() called %d times, want 0", aiProvider.setModelCalls)
+	}
+	if result.Model != expectedModel {
+		t.Errorf("result.Model = %q, want %q", result.Model, expectedModel)
 	}
 }
 
@@ -1837,13 +1931,16 @@ func TestSubagentRunner_ModelSwitch_SetsModelOpus(t *testing.T) {
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// AIProvider.SetModel("claude-opus-4-5-20250514") should have been called (resolved from "opus")
-	if aiProvider.setModelCalls == 0 {
-		t.Error("SetModel() was not called, want it to be called with resolved opus model ID")
-	}
+	// The session is set to the resolved opus model; the shared provider is never switched
 	expectedModel := "claude-opus-4-5-20250514"
-	if len(aiProvider.setModelValues) > 0 && aiProvider.setModelValues[0] != expectedModel {
-		t.Errorf("SetModel() called with %q, want %q", aiProvider.setModelValues[0], expectedModel)
+	if got := convService.sessionModel(); got != expectedModel {
+		t.Errorf("session model = %q, want %q", got, expectedModel)
+	}
+	if aiProvider.setModelCalls != 0 {
+		t.Errorf("SetModel() called %d times, want 0", aiProvider.setModelCalls)
+	}
+	if result.Model != expectedModel {
+		t.Errorf("result.Model = %q, want %q", result.Model, expectedModel)
 	}
 }
 
@@ -1873,9 +1970,10 @@ func TestSubagentRunner_ModelSwitch_InheritDoesNotSetModel(t *testing.T) {
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// AIProvider.SetModel() should NOT have been called
-	if aiProvider.setModelCalls != 0 {
-		t.Errorf("SetModel() called %d times, want 0 (inherit should not change model)", aiProvider.setModelCalls)
+	// Neither the session nor the shared provider should get a model
+	if convService.setSessionModelCalls != 0 || aiProvider.setModelCalls != 0 {
+		t.Errorf("SetSessionModel() called %d times, SetModel() called %d times, want 0 (inherit should not change model)",
+			convService.setSessionModelCalls, aiProvider.setModelCalls)
 	}
 }
 
@@ -1905,13 +2003,14 @@ func TestSubagentRunner_ModelSwitch_EmptyModelDoesNotSetModel(t *testing.T) {
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// AIProvider.SetModel() should NOT have been called
-	if aiProvider.setModelCalls != 0 {
-		t.Errorf("SetModel() called %d times, want 0 (empty model should not change model)", aiProvider.setModelCalls)
+	// Neither the session nor the shared provider should get a model
+	if convService.setSessionModelCalls != 0 || aiProvider.setModelCalls != 0 {
+		t.Errorf("SetSessionModel() called %d times, SetModel() called %d times, want 0 (empty model should not change model)",
+			convService.setSessionModelCalls, aiProvider.setModelCalls)
 	}
 }
 
-func TestSubagentRunner_ModelSwitch_RestoresOriginalModelAfterCompletion(t *testing.T) {
+func TestSubagentRunner_ModelSwitch_KeepsProviderModelAfterCompletion(t *testing.T) {
 	// Arrange
 	convService := newSubagentRunnerConvServiceMock()
 	convService.startConversationSession = "subagent-session-restore-001"
@@ -1940,14 +2039,15 @@ func TestSubagentRunner_ModelSwitch_RestoresOriginalModelAfterCompletion(t *test
 	if result == nil {
 		t.Fatal("Run() returned nil result")
 	}
-	// Model should be restored to original after completion
+	// The provider's default model is never switched, so there is nothing to restore
 	currentModel := aiProvider.GetModel()
-	if currentModel != originalModel {
-		t.Errorf("Model after run = %q, want %q (should restore original)", currentModel, originalModel)
+	if currentModel != originalModel || aiProvider.setModelCalls != 0 {
+		t.Errorf("Model after run = %q with %d SetModel() calls, want %q untouched",
+			currentModel, aiProvider.setModelCalls, originalModel)
 	}
 }
 
-func TestSubagentRunner_ModelSwitch_RestoresOriginalModelAfterError(t *testing.T) {
+func TestSubagentRunner_ModelSwitch_KeepsProviderModelAfterError(t *testing.T) {
 	// Arrange
 	expectedError := errors.New("AI processing error")
 	convService := newSubagentRunnerConvServiceMock()
@@ -1975,10 +2075,11 @@ func TestSubagentRunner_ModelSwitch_RestoresOriginalModelAfterError(t *testing.T
 	if result == nil {
 		t.Fatal("Run() should return result on error")
 	}
-	// Model should be restored to original even on error
+	// The provider's default model is untouched even on error
 	currentModel := aiProvider.GetModel()
-	if currentModel != originalModel {
-		t.Errorf("Model after error = %q, want %q (should restore original on error)", currentModel, originalModel)
+	if currentModel != originalModel || aiProvider.setModelCalls != 0 {
+		t.Errorf("Model after error = %q with %d SetModel() calls, want %q untouched",
+			currentModel, aiProvider.setModelCalls, originalModel)
 	}
 }
 
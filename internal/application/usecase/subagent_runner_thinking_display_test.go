@@ -84,6 +84,14 @@ func (m *thinkingDisplayUIMock) ConfirmBashCommand(_ string, _ bool, _ string, _
 	return false
 }
 
+func (m *thinkingDisplayUIMock) ConfirmToolBatch(calls []port.ToolConfirmation) []bool {
+	return make([]bool, len(calls))
+}
+
+func (m *thinkingDisplayUIMock) ConfirmFileEdit(_ string, _ string) bool {
+	return false
+}
+
 func (m *thinkingDisplayUIMock) BeginStreamingResponse() error {
 	return nil
 }
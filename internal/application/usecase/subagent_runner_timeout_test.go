@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Subagent MaxDuration Tests
+// =============================================================================
+//
+// These tests verify that SubagentRunner enforces its own wall-clock limit
+// independent of the parent context, and that parent cancellation still
+// propagates immediately.
+//
+// =============================================================================
+
+// slowSubagentConvServiceMock answers the first ProcessAssistantResponse call
+// immediately and blocks on every later call until the context is done.
+type slowSubagentConvServiceMock struct {
+	*subagentRunnerConvServiceMock
+}
+
+func newSlowSubagentConvServiceMock() *slowSubagentConvServiceMock {
+	base := newSubagentRunnerConvServiceMock()
+	base.processResponseMessages = []*entity.Message{
+		createSubagentAssistantMessage("Partial findings: service A looks healthy"),
+	}
+	base.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "ls"}}},
+	}
+	return &slowSubagentConvServiceMock{subagentRunnerConvServiceMock: base}
+}
+
+func (m *slowSubagentConvServiceMock) ProcessAssistantResponse(
+	ctx context.Context,
+	sessionID string,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.mu.Lock()
+	calls := m.processResponseCalls
+	m.mu.Unlock()
+
+	if calls == 0 {
+		return m.subagentRunnerConvServiceMock.ProcessAssistantResponse(ctx, sessionID)
+	}
+
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+func TestSubagentRunner_MaxDuration_ReturnsTimedOutWithPartialOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		configMax     time.Duration
+		agentMax      time.Duration
+		agentModel    string
+		wantModelCall bool
+	}{
+		{
+			name:      "config max duration",
+			configMax: 50 * time.Millisecond,
+		},
+		{
+			name:       "agent frontmatter overrides config",
+			configMax:  time.Hour,
+			agentMax:   50 * time.Millisecond,
+			agentModel: "haiku",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newSlowSubagentConvServiceMock()
+			aiProvider := newSubagentRunnerAIProviderMock()
+			config := SubagentConfig{MaxActions: 10, MaxDuration: tt.configMax}
+			runner := NewSubagentRunner(convService, newSubagentRunnerToolExecutorMock(), aiProvider, nil, config)
+
+			agent := createTestAgent("agent-slow", "slow-agent")
+			agent.MaxDuration = tt.agentMax
+			agent.Model = tt.agentModel
+
+			start := time.Now()
+			result, err := runner.Run(context.Background(), agent, "Investigate", "subagent-slow-001")
+			elapsed := time.Since(start)
+
+			if err != nil {
+				t.Fatalf("Run() error = %v, want nil (timeouts are reported via result)", err)
+			}
+			if elapsed > 5*time.Second {
+				t.Fatalf("Run() took %v, expected it to stop at the subagent deadline", elapsed)
+			}
+			if result.Status != "timed_out" {
+				t.Errorf("Status = %q, want %q", result.Status, "timed_out")
+			}
+			if !errors.Is(result.Error, context.DeadlineExceeded) {
+				t.Errorf("Error = %v, want wrapped context.DeadlineExceeded", result.Error)
+			}
+			if !strings.Contains(result.Output, "Partial findings") {
+				t.Errorf("Output = %q, want partial output from before the timeout", result.Output)
+			}
+			if result.ActionsTaken != 1 {
+				t.Errorf("ActionsTaken = %d, want 1", result.ActionsTaken)
+			}
+			if convService.endConversationCalls != 1 {
+				t.Errorf("EndConversation() called %d times, want 1", convService.endConversationCalls)
+			}
+			if aiProvider.GetModel() != "test-model" {
+				t.Errorf("model after run = %q, want parent model restored", aiProvider.GetModel())
+			}
+		})
+	}
+}
+
+func TestSubagentRunner_MaxDuration_ParentCancellationPropagates(t *testing.T) {
+	convService := newSlowSubagentConvServiceMock()
+	config := SubagentConfig{MaxActions: 10, MaxDuration: time.Hour}
+	runner := NewSubagentRunner(
+		convService,
+		newSubagentRunnerToolExecutorMock(),
+		newSubagentRunnerAIProviderMock(),
+		nil,
+		config,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := runner.Run(ctx, createTestAgent("agent-cancel", "cancel-agent"), "Investigate", "subagent-cancel")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Fatalf("Run() took %v, expected parent cancellation to stop it immediately", elapsed)
+	}
+	if result.Status != "failed" {
+		t.Errorf("Status = %q, want %q", result.Status, "failed")
+	}
+	if convService.endConversationCalls != 1 {
+		t.Errorf("EndConversation() called %d times, want 1", convService.endConversationCalls)
+	}
+}
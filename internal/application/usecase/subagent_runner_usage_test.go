@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Subagent Usage Tests
+// =============================================================================
+//
+// These tests verify that a SubagentResult reports the run's session, token
+// usage, and tool errors, and that an investigation counts the work of the
+// subagents it spawns toward its budgets only when CountSubagentUsage is set.
+//
+// =============================================================================
+
+// billedSubagentMessage returns an assistant message billed for the given tokens.
+func billedSubagentMessage(content string, inputTokens, outputTokens int64) *entity.Message {
+	msg := createSubagentAssistantMessage(content)
+	msg.Usage = &entity.TokenUsage{InputTokens: inputTokens, OutputTokens: outputTokens}
+	return msg
+}
+
+// newUsageTestSubagentRunner returns a runner whose subagent runs one bash
+// command and then answers, billed 100 input tokens per turn on test-model.
+func newUsageTestSubagentRunner(toolErr error) *SubagentRunner {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.processResponseMessages = []*entity.Message{
+		billedSubagentMessage("Checking", 100, 20),
+		billedSubagentMessage("Disk is full", 100, 30),
+	}
+	convService.processResponseToolCalls = [][]port.ToolCallInfo{
+		{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "df -h"}}},
+		nil,
+	}
+	toolExecutor := newSubagentRunnerToolExecutorMock()
+	toolExecutor.executeToolError = toolErr
+	aiProvider := newSubagentRunnerAIProviderMock()
+	aiProvider.currentModel = "test-model"
+	return NewSubagentRunner(convService, toolExecutor, aiProvider, nil, SubagentConfig{MaxActions: 10})
+}
+
+func TestSubagentRunner_ReportsUsage(t *testing.T) {
+	runner := newUsageTestSubagentRunner(errors.New("df: permission denied"))
+
+	result, err := runner.Run(context.Background(), createTestAgent("", "researcher"), "Check disk", "subagent-usage-001")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.SessionID != "subagent-session-123" {
+		t.Errorf("SessionID = %q, want subagent-session-123", result.SessionID)
+	}
+	if result.ActionsTaken != 1 || result.ToolErrors != 1 {
+		t.Errorf("ActionsTaken = %d, ToolErrors = %d; want 1 and 1", result.ActionsTaken, result.ToolErrors)
+	}
+	want := entity.TokenUsage{InputTokens: 200, OutputTokens: 50}
+	if result.TokensUsed != want {
+		t.Errorf("TokensUsed = %+v, want %+v", result.TokensUsed, want)
+	}
+	if result.Duration <= 0 {
+		t.Errorf("Duration = %v, want positive", result.Duration)
+	}
+}
+
+func TestSubagentRunner_ReportsUsageOfFailedRun(t *testing.T) {
+	convService := newSubagentRunnerConvServiceMock()
+	convService.addUserMessageError = errors.New("session closed")
+	runner := NewSubagentRunner(convService, newSubagentRunnerToolExecutorMock(), newSubagentRunnerAIProviderMock(),
+		nil, SubagentConfig{MaxActions: 10})
+
+	result, _ := runner.Run(context.Background(), createTestAgent("", "researcher"), "Check disk", "subagent-usage-002")
+	if result.Status != "failed" || result.SessionID != "subagent-session-123" {
+		t.Errorf("Run() = %q in session %q, want failed in subagent-session-123", result.Status, result.SessionID)
+	}
+}
+
+func TestSubagentResult_Summary(t *testing.T) {
+	tests := []struct {
+		name   string
+		result SubagentResult
+		want   string
+	}{
+		{
+			name: "completed",
+			result: SubagentResult{
+				Status: "completed", Duration: 41600 * time.Millisecond, ActionsTaken: 7,
+				TokensUsed: entity.TokenUsage{InputTokens: 12000, OutputTokens: 1400},
+			},
+			want: "completed in 42s, 7 actions, 13k tokens",
+		},
+		{
+			name: "timed out with tool errors",
+			result: SubagentResult{
+				Status: "timed_out", Duration: 5 * time.Minute, ActionsTaken: 12, ToolErrors: 2,
+				TokensUsed: entity.TokenUsage{InputTokens: 1200, OutputTokens: 300},
+			},
+			want: "timed out in 5m0s, 12 actions (2 failed), 1.5k tokens",
+		},
+		{
+			name:   "failed at once",
+			result: SubagentResult{Status: "failed", Duration: 1234 * time.Microsecond},
+			want:   "failed in 1ms, 0 actions, 0 tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// delegatingToolExecutor runs a subagent for the task tool, as the tool
+// executor adapter does, and delegates every other tool to its mock.
+type delegatingToolExecutor struct {
+	*investigationRunnerToolExecutorMock
+	subagents *SubagentRunner
+}
+
+func (e *delegatingToolExecutor) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
+	if name != "task" {
+		return e.investigationRunnerToolExecutorMock.ExecuteTool(ctx, name, input)
+	}
+	result, err := e.subagents.Run(ctx, createTestAgent("", "researcher"), "Check disk", "subagent-usage-003")
+	if err != nil {
+		return "", err
+	}
+	return result.Summary(), nil
+}
+
+func TestInvestigationRunner_CountSubagentUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       bool
+		wantActions int
+		wantTokens  int64
+	}{
+		{name: "disabled", count: false, wantActions: 1, wantTokens: 200},
+		{name: "enabled", count: true, wantActions: 2, wantTokens: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convService := newInvestigationRunnerConvServiceMock()
+			convService.processResponseMessages = []*entity.Message{
+				pricedMessage("Delegating", 100),
+				pricedMessage("Done", 100),
+			}
+			convService.processResponseToolCalls = [][]port.ToolCallInfo{
+				{{ToolID: "t1", ToolName: "task", Input: map[string]interface{}{"agent_name": "researcher"}}},
+				nil,
+			}
+			executor := &delegatingToolExecutor{
+				investigationRunnerToolExecutorMock: newInvestigationRunnerToolExecutorMock(),
+				subagents:                           newUsageTestSubagentRunner(nil),
+			}
+			runner := NewInvestigationRunner(
+				convService,
+				executor,
+				NewMockSafetyEnforcer(),
+				newInvestigationRunnerPromptBuilderMock(),
+				nil, // skillManager
+				nil, // uiAdapter
+				AlertInvestigationUseCaseConfig{
+					MaxActions:         20,
+					MaxDuration:        15 * time.Minute,
+					AllowedTools:       []string{"task"},
+					CountSubagentUsage: tt.count,
+				},
+			)
+			runner.SetPricing(testPricing, testModel)
+
+			result, err := runner.Run(context.Background(), createTestAlert("alert-sub", "warning", "Test"), "inv-sub")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.ActionsTaken != tt.wantActions {
+				t.Errorf("ActionsTaken = %d, want %d", result.ActionsTaken, tt.wantActions)
+			}
+			if result.Usage.InputTokens != tt.wantTokens {
+				t.Errorf("Usage.InputTokens = %d, want %d", result.Usage.InputTokens, tt.wantTokens)
+			}
+			// Each input token of test-model costs $0.001
+			if wantCost := float64(tt.wantTokens) / 1000; !costsEqual(result.Cost, wantCost) {
+				t.Errorf("Cost = %v, want %v", result.Cost, wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+)
+
+// subagentUsageKey is the context key of a subagentUsage.
+type subagentUsageKey struct{}
+
+// subagentUsage collects the results of the subagents spawned under a context,
+// so a parent can count their work toward its own budgets. Parallel subagents
+// report concurrently, so it is safe for concurrent use.
+type subagentUsage struct {
+	mu      sync.Mutex
+	results []*SubagentResult
+}
+
+// withSubagentUsage returns a context under which SubagentRunner.Run reports
+// every result to usage.
+func withSubagentUsage(ctx context.Context, usage *subagentUsage) context.Context {
+	return context.WithValue(ctx, subagentUsageKey{}, usage)
+}
+
+// reportSubagentUsage adds result to the subagentUsage in ctx, if any.
+func reportSubagentUsage(ctx context.Context, result *SubagentResult) {
+	usage, ok := ctx.Value(subagentUsageKey{}).(*subagentUsage)
+	if !ok || result == nil {
+		return
+	}
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.results = append(usage.results, result)
+}
+
+// take returns the results reported so far and forgets them.
+func (u *subagentUsage) take() []*SubagentResult {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	results := u.results
+	u.results = nil
+	return results
+}
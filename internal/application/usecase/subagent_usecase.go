@@ -15,6 +15,7 @@ import (
 // This allows SubagentUseCase to work with both real and mock runners.
 type SubagentRunnerInterface interface {
 	Run(ctx context.Context, agent *entity.Subagent, taskPrompt string, subagentID string) (*SubagentResult, error)
+	RunParallel(ctx context.Context, tasks []SubagentTask) ([]*SubagentResult, error)
 }
 
 // SubagentUseCase orchestrates subagent spawning and task delegation.
@@ -415,3 +416,83 @@ func (uc *SubagentUseCase) SpawnMultiple(
 		Errors:  errors,
 	}, nil
 }
+
+// SpawnParallel spawns multiple named subagents concurrently through the runner's
+// bounded RunParallel and returns one result per request, in request order.
+//
+// Unlike SpawnMultiple, concurrency is capped by SubagentConfig.MaxConcurrent and
+// every failure is reported in the corresponding result (Status "failed" with Error
+// set), so callers only need to inspect the results slice. Requests that fail
+// validation or whose agent cannot be loaded never reach the runner.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - requests: Subagent spawn requests (must be non-empty)
+//
+// Returns:
+//   - []*SubagentResult: Results in the same order as requests
+//   - error: Only if requests is empty
+func (uc *SubagentUseCase) SpawnParallel(
+	ctx context.Context,
+	requests []*SubagentRequest,
+) ([]*SubagentResult, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no subagent requests provided")
+	}
+
+	results := make([]*SubagentResult, len(requests))
+	tasks := make([]SubagentTask, 0, len(requests))
+	taskIndexes := make([]int, 0, len(requests))
+
+	for i, req := range requests {
+		subagentID := fmt.Sprintf("%s-%d", generateSubagentID(), i)
+		agent, err := uc.loadParallelAgent(ctx, req)
+		if err != nil {
+			results[i] = &SubagentResult{
+				SubagentID: subagentID,
+				AgentName:  requestAgentName(req),
+				Status:     "failed",
+				Error:      err,
+			}
+			continue
+		}
+		tasks = append(tasks, SubagentTask{Agent: agent, Prompt: req.Prompt, SubagentID: subagentID})
+		taskIndexes = append(taskIndexes, i)
+	}
+
+	if len(tasks) == 0 {
+		return results, nil
+	}
+
+	taskResults, err := uc.subagentRunner.RunParallel(ctx, tasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run subagents in parallel: %w", err)
+	}
+	for i, result := range taskResults {
+		results[taskIndexes[i]] = result
+	}
+	return results, nil
+}
+
+// loadParallelAgent validates a single SpawnParallel request and loads its agent metadata.
+func (uc *SubagentUseCase) loadParallelAgent(ctx context.Context, req *SubagentRequest) (*entity.Subagent, error) {
+	if req == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if err := validateSpawnInputs(req.AgentName, req.Prompt); err != nil {
+		return nil, err
+	}
+	agent, err := uc.subagentManager.LoadAgentMetadata(ctx, req.AgentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subagent metadata: %w", err)
+	}
+	return agent, nil
+}
+
+// requestAgentName returns the agent name of a request, tolerating nil requests.
+func requestAgentName(req *SubagentRequest) string {
+	if req == nil {
+		return ""
+	}
+	return req.AgentName
+}
@@ -67,7 +67,8 @@ func (m *MockSubagentManager) ListAgents(ctx context.Context) ([]port.SubagentIn
 
 // MockSubagentRunner mocks the SubagentRunner for testing.
 type MockSubagentRunner struct {
-	RunFunc func(ctx context.Context, agent *entity.Subagent, taskPrompt string, subagentID string) (*SubagentResult, error)
+	RunFunc         func(ctx context.Context, agent *entity.Subagent, taskPrompt string, subagentID string) (*SubagentResult, error)
+	RunParallelFunc func(ctx context.Context, tasks []SubagentTask) ([]*SubagentResult, error)
 }
 
 func (m *MockSubagentRunner) Run(
@@ -82,6 +83,13 @@ func (m *MockSubagentRunner) Run(
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockSubagentRunner) RunParallel(ctx context.Context, tasks []SubagentTask) ([]*SubagentResult, error) {
+	if m.RunParallelFunc != nil {
+		return m.RunParallelFunc(ctx, tasks)
+	}
+	return nil, errors.New("not implemented")
+}
+
 // ==================== Constructor Tests ====================
 
 func TestNewSubagentUseCase_ValidDependencies(t *testing.T) {
@@ -2333,3 +2341,76 @@ func TestSpawnDynamicSubagent_AppliesDefaults(t *testing.T) {
 		t.Errorf("Expected default allowed_tools to be nil (all tools), got %v", capturedAgent.AllowedTools)
 	}
 }
+
+// ==================== SpawnParallel Tests ====================
+
+func TestSpawnParallel_LoadFailuresBecomeFailedResultsInOrder(t *testing.T) {
+	var capturedTasks []SubagentTask
+
+	manager := &MockSubagentManager{
+		LoadAgentMetadataFunc: func(ctx context.Context, agentName string) (*entity.Subagent, error) {
+			if agentName == "missing" {
+				return nil, errors.New("agent not found")
+			}
+			return &entity.Subagent{Name: agentName}, nil
+		},
+	}
+	runner := &MockSubagentRunner{
+		RunParallelFunc: func(ctx context.Context, tasks []SubagentTask) ([]*SubagentResult, error) {
+			capturedTasks = tasks
+			results := make([]*SubagentResult, len(tasks))
+			for i, task := range tasks {
+				results[i] = &SubagentResult{
+					SubagentID: task.SubagentID,
+					AgentName:  task.Agent.Name,
+					Status:     "completed",
+					Output:     "done: " + task.Prompt,
+				}
+			}
+			return results, nil
+		},
+	}
+	uc := NewSubagentUseCase(manager, runner)
+
+	requests := []*SubagentRequest{
+		{AgentName: "reviewer", Prompt: "review"},
+		{AgentName: "missing", Prompt: "anything"},
+		{AgentName: "tester", Prompt: ""},
+		{AgentName: "writer", Prompt: "write"},
+	}
+
+	results, err := uc.SpawnParallel(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("SpawnParallel() returned error: %v", err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("Expected %d results, got %d", len(requests), len(results))
+	}
+	if len(capturedTasks) != 2 {
+		t.Fatalf("Expected 2 tasks to reach the runner, got %d", len(capturedTasks))
+	}
+
+	wantStatus := []string{"completed", "failed", "failed", "completed"}
+	for i, want := range wantStatus {
+		if results[i].Status != want {
+			t.Errorf("results[%d].Status = %q, want %q", i, results[i].Status, want)
+		}
+		if results[i].AgentName != requests[i].AgentName {
+			t.Errorf("results[%d].AgentName = %q, want %q", i, results[i].AgentName, requests[i].AgentName)
+		}
+	}
+	if results[3].Output != "done: write" {
+		t.Errorf("results[3].Output = %q, want runner output mapped back to its request", results[3].Output)
+	}
+	if capturedTasks[0].SubagentID == capturedTasks[1].SubagentID {
+		t.Errorf("Expected unique subagent IDs, both were %q", capturedTasks[0].SubagentID)
+	}
+}
+
+func TestSpawnParallel_EmptyRequests(t *testing.T) {
+	uc := NewSubagentUseCase(&MockSubagentManager{}, &MockSubagentRunner{})
+
+	if _, err := uc.SpawnParallel(context.Background(), nil); err == nil {
+		t.Error("SpawnParallel() expected error for empty requests")
+	}
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"cmp"
+	"code-editing-agent/internal/domain/port"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Names of the standard system prompt layers.
+const (
+	PromptLayerBase          = "base"          // Persona and general instructions
+	PromptLayerMemory        = "memory"        // Project memory (AGENT.md)
+	PromptLayerProject       = "project"       // Facts detected from the workspace, e.g. the test command
+	PromptLayerMode          = "mode"          // Addendum for the active mode, e.g. plan mode
+	PromptLayerSkills        = "skills"        // Index of the available skills
+	PromptLayerInvestigation = "investigation" // Per-alert investigation prompt
+)
+
+// Orders of the standard layers; lower orders come first in the composed
+// prompt. They are spaced so that custom layers can be placed between them.
+const (
+	PromptOrderBase          = 100
+	PromptOrderMemory        = 200
+	PromptOrderProject       = 250
+	PromptOrderMode          = 300
+	PromptOrderSkills        = 400
+	PromptOrderInvestigation = 500
+)
+
+// Default size budgets of the standard layers, in bytes.
+const (
+	DefaultMemoryPromptBudget  = 16 * 1024
+	DefaultSkillsPromptBudget  = 8 * 1024
+	DefaultProjectPromptBudget = 2 * 1024
+)
+
+// BaseSystemPrompt is the default persona of the chat agent.
+const BaseSystemPrompt = "You are an AI assistant that helps users with code editing and explanations. " +
+	"Use the available tools when necessary to provide accurate and helpful responses."
+
+// PromptLayer is one named part of a composed system prompt.
+type PromptLayer struct {
+	Name     string
+	Order    int    // Position in the composed prompt, lowest first; ties are broken by name
+	Content  string // Empty layers are left out
+	MaxBytes int    // Budget for Content; 0 means unlimited
+}
+
+// PromptLayerSource supplies a layer whose content can change between
+// messages, such as the project facts; its PromptLayer is called before each
+// message, so it should be cheap.
+type PromptLayerSource interface {
+	PromptLayer() PromptLayer
+}
+
+// ComposedLayer describes how one layer ended up in a composed prompt.
+type ComposedLayer struct {
+	Name          string
+	Order         int
+	Content       string // Content as included, after truncation
+	Bytes         int    // Size of Content
+	OriginalBytes int    // Size before truncation
+	Truncated     bool
+}
+
+// ComposedPrompt is a system prompt assembled from layers.
+type ComposedPrompt struct {
+	Text   string
+	Layers []ComposedLayer // In prompt order
+}
+
+// Annotated renders the prompt with a header before each layer naming it,
+// its order and size, for display to the user.
+func (p ComposedPrompt) Annotated() string {
+	if len(p.Layers) == 0 {
+		return "System prompt is empty."
+	}
+	var b strings.Builder
+	for i, layer := range p.Layers {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "=== layer %s (order %d, %d bytes", layer.Name, layer.Order, layer.Bytes)
+		if layer.Truncated {
+			fmt.Fprintf(&b, ", truncated from %d", layer.OriginalBytes)
+		}
+		b.WriteString(") ===\n")
+		b.WriteString(layer.Content)
+	}
+	return b.String()
+}
+
+// SystemPromptComposer assembles a system prompt from named layers in a
+// deterministic order, truncating each layer to its budget. Layers can be
+// set and removed at any time; the next Compose reflects the change. It is
+// safe for concurrent use.
+type SystemPromptComposer struct {
+	mu     sync.RWMutex
+	layers map[string]PromptLayer
+}
+
+// NewSystemPromptComposer creates a composer with the given layers.
+func NewSystemPromptComposer(layers ...PromptLayer) *SystemPromptComposer {
+	c := &SystemPromptComposer{layers: make(map[string]PromptLayer, len(layers))}
+	for _, layer := range layers {
+		c.layers[layer.Name] = layer
+	}
+	return c
+}
+
+// SetLayer adds the layer, replacing any layer with the same name.
+func (c *SystemPromptComposer) SetLayer(layer PromptLayer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.layers[layer.Name] = layer
+}
+
+// RemoveLayer removes the named layer, reporting whether it was present.
+func (c *SystemPromptComposer) RemoveLayer(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.layers[name]
+	delete(c.layers, name)
+	return ok
+}
+
+// Layer returns the named layer.
+func (c *SystemPromptComposer) Layer(name string) (PromptLayer, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	layer, ok := c.layers[name]
+	return layer, ok
+}
+
+// Clone returns a composer with a copy of c's layers.
+func (c *SystemPromptComposer) Clone() *SystemPromptComposer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clone := &SystemPromptComposer{layers: make(map[string]PromptLayer, len(c.layers))}
+	for name, layer := range c.layers {
+		clone.layers[name] = layer
+	}
+	return clone
+}
+
+// Compose joins the non-empty layers, lowest order first and by name for
+// equal orders, separated by blank lines. A layer over its budget is cut at
+// the last whole UTF-8 character within it and ends with a truncation note.
+func (c *SystemPromptComposer) Compose() ComposedPrompt {
+	c.mu.RLock()
+	layers := make([]PromptLayer, 0, len(c.layers))
+	for _, layer := range c.layers {
+		if strings.TrimSpace(layer.Content) != "" {
+			layers = append(layers, layer)
+		}
+	}
+	c.mu.RUnlock()
+
+	slices.SortFunc(layers, func(a, b PromptLayer) int {
+		if n := cmp.Compare(a.Order, b.Order); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var prompt ComposedPrompt
+	parts := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		content, truncated := truncatePromptLayer(layer.Content, layer.MaxBytes)
+		parts = append(parts, content)
+		prompt.Layers = append(prompt.Layers, ComposedLayer{
+			Name:          layer.Name,
+			Order:         layer.Order,
+			Content:       content,
+			Bytes:         len(content),
+			OriginalBytes: len(layer.Content),
+			Truncated:     truncated,
+		})
+	}
+	prompt.Text = strings.Join(parts, "\n\n")
+	return prompt
+}
+
+// truncatePromptLayer cuts content to maxBytes at a UTF-8 boundary and
+// notes the cut, reporting whether it did. A maxBytes of 0 means unlimited.
+func truncatePromptLayer(content string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(content) <= maxBytes {
+		return content, false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + fmt.Sprintf("\n[truncated: %d of %d bytes shown]", cut, len(content)), true
+}
+
+// BasePromptLayer returns the base persona layer.
+func BasePromptLayer() PromptLayer {
+	return PromptLayer{Name: PromptLayerBase, Order: PromptOrderBase, Content: BaseSystemPrompt}
+}
+
+// MemoryPromptLayer returns the project memory layer, empty if memory is.
+func MemoryPromptLayer(memory string) PromptLayer {
+	layer := PromptLayer{Name: PromptLayerMemory, Order: PromptOrderMemory, MaxBytes: DefaultMemoryPromptBudget}
+	if memory = strings.TrimSpace(memory); memory != "" {
+		layer.Content = MemoryPrompt(memory)
+	}
+	return layer
+}
+
+// ProjectPromptLayer returns the layer with the facts detected about the
+// workspace's project, empty if none were.
+func ProjectPromptLayer(facts string) PromptLayer {
+	layer := PromptLayer{Name: PromptLayerProject, Order: PromptOrderProject, MaxBytes: DefaultProjectPromptBudget}
+	if facts = strings.TrimSpace(facts); facts != "" {
+		layer.Content = "# Project\n\n" +
+			"Detected from the files at the workspace root; call project_info for details.\n\n" + facts
+	}
+	return layer
+}
+
+// PlanModePromptLayer returns the mode layer for plan mode, with the plan
+// written to planPath.
+func PlanModePromptLayer(planPath string) PromptLayer {
+	return PromptLayer{Name: PromptLayerMode, Order: PromptOrderMode, Content: PlanModePrompt(planPath)}
+}
+
+// SkillsPromptLayer returns the skills index layer, empty without skills.
+func SkillsPromptLayer(skills []port.SkillInfo) PromptLayer {
+	layer := PromptLayer{Name: PromptLayerSkills, Order: PromptOrderSkills, MaxBytes: DefaultSkillsPromptBudget}
+	if len(skills) > 0 {
+		layer.Content = "# Available Skills\n\n" + GenerateSkillsHeader(skills) +
+			"\nUse the `activate_skill` tool to load the full content of a skill."
+	}
+	return layer
+}
+
+// InvestigationPromptLayer returns the layer holding a per-alert
+// investigation prompt.
+func InvestigationPromptLayer(prompt string) PromptLayer {
+	return PromptLayer{Name: PromptLayerInvestigation, Order: PromptOrderInvestigation, Content: prompt}
+}
+
+// MemoryPrompt wraps the persistent memory for the system prompt.
+func MemoryPrompt(memory string) string {
+	return "# Memory\n\n" +
+		"Project preferences remembered from earlier sessions (AGENT.md). Follow them unless the user " +
+		"says otherwise, and use the remember tool to save new lasting preferences.\n\n" + memory
+}
+
+// PlanModePrompt returns the plan mode instructions, which tell the agent to
+// explore the codebase and write an implementation plan to planPath rather
+// than making direct changes.
+func PlanModePrompt(planPath string) string {
+	return fmt.Sprintf(
+		`# PLAN MODE
+
+You are in PLAN MODE: propose a plan, do not make changes. Your job is to explore the codebase and write an implementation plan before any changes are made.
+
+## Your Role in Plan Mode
+
+You should:
+1. Use read_file and list_files to understand the existing code
+2. Use read-only bash commands (e.g., git status, ls, find) to explore
+3. Write your implementation plan to: %s
+
+## How to Write Your Plan
+
+Use the edit_file tool to write your plan to %s. Structure your plan as:
+
+### Summary
+Brief overview of what you're implementing
+
+### Files to Modify
+- path/to/file1.go - what changes are needed
+- path/to/file2.go - what changes are needed
+
+### Implementation Steps
+1. First step
+2. Second step
+...
+
+### Considerations
+- Any trade-offs or decisions to highlight
+
+## Important Rules
+
+- You CAN use edit_file to write to %s - this is your plan file
+- Other mutating tools (edit_file for other paths, bash commands that are not read-only) are refused with an error
+- A refused tool call returns an error reminding you to write to your plan file instead
+- Focus on thorough exploration and detailed planning before implementation
+
+## When You're Done
+
+When your plan is complete, tell the user to exit plan mode with :mode normal (or Shift+Tab) to begin implementation.
+`,
+		planPath,
+		planPath,
+		planPath,
+	)
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// layerNames returns the names of the composed layers in prompt order.
+func layerNames(prompt ComposedPrompt) []string {
+	names := make([]string, len(prompt.Layers))
+	for i, layer := range prompt.Layers {
+		names[i] = layer.Name
+	}
+	return names
+}
+
+func TestSystemPromptComposer_OrdersLayers(t *testing.T) {
+	composer := NewSystemPromptComposer(
+		PromptLayer{Name: "zeta", Order: PromptOrderMemory, Content: "zeta"},
+		InvestigationPromptLayer("investigate"),
+		PlanModePromptLayer(".agent/plans/s.md"),
+		BasePromptLayer(),
+		MemoryPromptLayer("Use tabs."),
+		SkillsPromptLayer([]port.SkillInfo{{Name: "deploy", Description: "Deploys things"}}),
+		PromptLayer{Name: "alpha", Order: PromptOrderMemory, Content: "alpha"},
+		PromptLayer{Name: "empty", Order: 0, Content: "  \n"},
+	)
+
+	prompt := composer.Compose()
+
+	want := []string{
+		PromptLayerBase, "alpha", PromptLayerMemory, "zeta", PromptLayerMode, PromptLayerSkills, PromptLayerInvestigation,
+	}
+	if got := layerNames(prompt); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("layer order = %v, want %v", got, want)
+	}
+	parts := make([]string, len(prompt.Layers))
+	for i, layer := range prompt.Layers {
+		parts[i] = layer.Content
+	}
+	if prompt.Text != strings.Join(parts, "\n\n") {
+		t.Errorf("Text is not the layers joined in order:\n%s", prompt.Text)
+	}
+	if !strings.HasPrefix(prompt.Text, BaseSystemPrompt) || !strings.HasSuffix(prompt.Text, "investigate") {
+		t.Errorf("Text should start with the base prompt and end with the investigation prompt:\n%s", prompt.Text)
+	}
+	if again := composer.Compose(); again.Text != prompt.Text {
+		t.Error("Compose() is not deterministic")
+	}
+}
+
+func TestSystemPromptComposer_TruncatesLayersToBudget(t *testing.T) {
+	content := strings.Repeat("é", 10) // 20 bytes
+	composer := NewSystemPromptComposer(
+		PromptLayer{Name: "small", Order: 1, Content: content, MaxBytes: 7},
+		PromptLayer{Name: "fits", Order: 2, Content: "short", MaxBytes: 5},
+	)
+
+	prompt := composer.Compose()
+
+	small := prompt.Layers[0]
+	if !small.Truncated || small.OriginalBytes != 20 {
+		t.Fatalf("small layer = %+v, want truncated from 20 bytes", small)
+	}
+	kept, note, ok := strings.Cut(small.Content, "\n")
+	if !ok || kept != "ééé" || note != "[truncated: 6 of 20 bytes shown]" {
+		t.Errorf("small layer content = %q, want 3 whole characters and a truncation note", small.Content)
+	}
+	if !utf8.ValidString(prompt.Text) {
+		t.Error("truncation split a UTF-8 character")
+	}
+	if small.Bytes != len(small.Content) {
+		t.Errorf("Bytes = %d, want %d", small.Bytes, len(small.Content))
+	}
+	if fits := prompt.Layers[1]; fits.Truncated || fits.Content != "short" {
+		t.Errorf("layer within budget = %+v, want untouched", fits)
+	}
+	if !strings.Contains(prompt.Annotated(), "=== layer small (order 1, ") ||
+		!strings.Contains(prompt.Annotated(), "truncated from 20) ===") {
+		t.Errorf("Annotated() does not describe the truncated layer:\n%s", prompt.Annotated())
+	}
+}
+
+func TestSystemPromptComposer_TogglesLayersAtRuntime(t *testing.T) {
+	composer := NewSystemPromptComposer(BasePromptLayer())
+	clone := composer.Clone()
+
+	composer.SetLayer(PlanModePromptLayer(".agent/plans/s.md"))
+	if got := composer.Compose(); !strings.Contains(got.Text, "# PLAN MODE") ||
+		!strings.Contains(got.Text, ".agent/plans/s.md") {
+		t.Errorf("Compose() after setting the mode layer lacks plan mode:\n%s", got.Text)
+	}
+	if got := clone.Compose(); strings.Contains(got.Text, "# PLAN MODE") {
+		t.Error("setting a layer changed a clone")
+	}
+
+	composer.SetLayer(MemoryPromptLayer("first"))
+	composer.SetLayer(MemoryPromptLayer("second"))
+	if got := composer.Compose(); strings.Contains(got.Text, "first") || !strings.Contains(got.Text, "second") {
+		t.Errorf("setting a layer twice should replace it:\n%s", got.Text)
+	}
+
+	if !composer.RemoveLayer(PromptLayerMode) {
+		t.Error("RemoveLayer(mode) = false, want true")
+	}
+	if composer.RemoveLayer(PromptLayerMode) {
+		t.Error("RemoveLayer(mode) twice = true, want false")
+	}
+	if got := composer.Compose(); strings.Contains(got.Text, "# PLAN MODE") {
+		t.Errorf("Compose() after removing the mode layer still has plan mode:\n%s", got.Text)
+	}
+	if got := layerNames(composer.Compose()); strings.Join(got, ",") != "base,memory" {
+		t.Errorf("layers = %v, want [base memory]", got)
+	}
+}
+
+func TestStandardPromptLayers_EmptyWithoutContent(t *testing.T) {
+	composer := NewSystemPromptComposer(MemoryPromptLayer("  "), SkillsPromptLayer(nil))
+	if got := composer.Compose(); got.Text != "" || len(got.Layers) != 0 {
+		t.Errorf("Compose() = %+v, want empty", got)
+	}
+	if got := composer.Compose().Annotated(); got != "System prompt is empty." {
+		t.Errorf("Annotated() = %q", got)
+	}
+}
@@ -175,8 +175,13 @@ func (uc *ToolExecutionUseCase) ExecuteToolsInSession(
 			}
 		}
 
-		// Execute the tool with session ID in context for plan mode support
-		ctxWithSession := port.WithSessionID(ctx, sessionID)
+		// Execute the tool with session ID in context for plan mode support and
+		// result caching, and a report of whether the result was cached
+		info := &port.ToolExecutionInfo{}
+		ctxWithSession := port.WithToolExecutionInfo(port.WithSessionID(ctx, sessionID), info)
+		if toolReq.Confirmed {
+			ctxWithSession = port.WithUserApproval(ctxWithSession)
+		}
 		result, err := uc.toolExecutor.ExecuteTool(ctxWithSession, toolReq.ToolName, toolReq.Input)
 		duration := time.Since(startTime)
 
@@ -197,6 +202,8 @@ func (uc *ToolExecutionUseCase) ExecuteToolsInSession(
 				Result:     result,
 				ExecutedAt: time.Now(),
 				DurationMs: duration.Milliseconds(),
+				Cached:     info.Cached,
+				Provenance: info.Provenance,
 			}
 			successfulCount++
 		}
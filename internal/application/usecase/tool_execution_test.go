@@ -97,6 +97,34 @@ func TestExecuteToolsInSession_PropagatesSessionIDInContext(t *testing.T) {
 	}
 }
 
+func TestExecuteToolsInSession_ReportsCachedResults(t *testing.T) {
+	mockExecutor := newMockToolExecutor()
+	testTool, _ := entity.NewTool("test-id", "test_tool", "A test tool")
+	mockExecutor.RegisterTool(*testTool)
+	mockExecutor.executeToolFn = func(ctx context.Context, _ string, input interface{}) (string, error) {
+		if info, ok := port.ToolExecutionInfoFromContext(ctx); ok && input.(map[string]interface{})["cached"] == true {
+			info.Cached = true
+		}
+		return "success", nil
+	}
+
+	uc, err := NewToolExecutionUseCase(mockExecutor)
+	if err != nil {
+		t.Fatalf("Failed to create use case: %v", err)
+	}
+
+	resp, err := uc.ExecuteToolsInSession(context.Background(), "test-session", []dto.ToolExecuteRequest{
+		{ToolName: "test_tool", Input: map[string]interface{}{"cached": true}},
+		{ToolName: "test_tool", Input: map[string]interface{}{}},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteToolsInSession failed: %v", err)
+	}
+	if !resp.Results[0].Cached || resp.Results[1].Cached {
+		t.Errorf("Cached = %v, %v, want true, false", resp.Results[0].Cached, resp.Results[1].Cached)
+	}
+}
+
 func TestExecuteToolsInSession_EmptySessionID(t *testing.T) {
 	mockExecutor := newMockToolExecutor()
 	uc, _ := NewToolExecutionUseCase(mockExecutor)
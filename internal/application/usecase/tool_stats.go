@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ToolStats is how one tool was used during a session.
+type ToolStats struct {
+	Tool     string
+	Calls    int64
+	Errors   int64         // Calls that returned an error
+	Duration time.Duration // Cumulative over all calls
+	Bytes    int64         // Output returned to the model
+}
+
+// ToolStatsSource reports the tool usage of each session's tool calls.
+type ToolStatsSource interface {
+	// GetToolStats returns the session's stats per tool, most used first.
+	GetToolStats(sessionID string) []ToolStats
+	// ResetToolStats discards the session's stats.
+	ResetToolStats(sessionID string)
+}
+
+// SortToolStats sorts stats most used first: by calls, then cumulative
+// duration, then tool name.
+func SortToolStats(stats []ToolStats) {
+	slices.SortFunc(stats, func(a, b ToolStats) int {
+		if c := cmp.Compare(b.Calls, a.Calls); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Duration, a.Duration); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tool, b.Tool)
+	})
+}
+
+// FormatToolStats renders stats as a compact table with a total row, or
+// "No tools used." when there are none.
+func FormatToolStats(stats []ToolStats) string {
+	if len(stats) == 0 {
+		return "No tools used."
+	}
+
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tCALLS\tERRORS\tTIME\tAVG\tBYTES")
+	var total ToolStats
+	for _, s := range stats {
+		writeToolStatsRow(tw, s.Tool, s)
+		total.Calls += s.Calls
+		total.Errors += s.Errors
+		total.Duration += s.Duration
+		total.Bytes += s.Bytes
+	}
+	writeToolStatsRow(tw, "total", total)
+	_ = tw.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeToolStatsRow writes one row of the FormatToolStats table.
+func writeToolStatsRow(tw *tabwriter.Writer, name string, s ToolStats) {
+	var avg time.Duration
+	if s.Calls > 0 {
+		avg = s.Duration / time.Duration(s.Calls)
+	}
+	fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%d\n", name, s.Calls, s.Errors,
+		s.Duration.Round(time.Millisecond), avg.Round(time.Millisecond), s.Bytes)
+}
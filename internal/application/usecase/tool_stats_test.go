@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortToolStats(t *testing.T) {
+	stats := []ToolStats{
+		{Tool: "read_file", Calls: 2, Duration: time.Millisecond},
+		{Tool: "bash", Calls: 5, Duration: time.Second},
+		{Tool: "list_files", Calls: 2, Duration: time.Second},
+		{Tool: "grep", Calls: 2, Duration: time.Second},
+	}
+	SortToolStats(stats)
+
+	var got []string
+	for _, s := range stats {
+		got = append(got, s.Tool)
+	}
+	want := []string{"bash", "grep", "list_files", "read_file"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("SortToolStats() order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFormatToolStats(t *testing.T) {
+	got := FormatToolStats([]ToolStats{
+		{Tool: "bash", Calls: 4, Errors: 1, Duration: 2 * time.Second, Bytes: 1200},
+		{Tool: "read_file", Calls: 1, Duration: 15 * time.Millisecond, Bytes: 300},
+	})
+
+	want := `TOOL       CALLS  ERRORS  TIME    AVG    BYTES
+bash       4      1       2s      500ms  1200
+read_file  1      0       15ms    15ms   300
+total      5      1       2.015s  403ms  1500`
+	if got != want {
+		t.Errorf("FormatToolStats() =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatToolStats(nil); got != "No tools used." {
+		t.Errorf("FormatToolStats(nil) = %q, want %q", got, "No tools used.")
+	}
+}
@@ -0,0 +1,25 @@
+package usecase
+
+// File change statuses.
+const (
+	FileChangeAdded    = "added"
+	FileChangeModified = "modified"
+	FileChangeDeleted  = "deleted"
+)
+
+// FileChange summarizes how one file changed during a session, measured
+// against a snapshot taken before the session's tools first modified it.
+type FileChange struct {
+	Path    string // Relative to the working directory when inside it
+	Status  string // FileChangeAdded, FileChangeModified, or FileChangeDeleted
+	Added   int    // Lines added
+	Removed int    // Lines removed
+	Note    string // Why the file was not diffed (too large, binary); counts are then zero
+}
+
+// WorkspaceChangeTracker reports the files a session's tools modified. Only
+// files named by file-writing tools (or declared by bash) are tracked.
+type WorkspaceChangeTracker interface {
+	ModifiedFiles(sessionID string) []FileChange
+	Forget(sessionID string)
+}
@@ -3,7 +3,10 @@
 package entity
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 )
@@ -36,14 +39,18 @@ var (
 // It is an immutable entity once created, with optional fields set via builder methods.
 // Alert implements defensive copying for mutable fields like labels.
 type Alert struct {
-	id          string
-	source      string
-	severity    string
-	title       string
-	description string
-	labels      map[string]string
-	timestamp   time.Time
-	rawPayload  []byte
+	id           string
+	source       string
+	severity     string
+	title        string
+	description  string
+	labels       map[string]string
+	annotations  map[string]string
+	timestamp    time.Time
+	rawPayload   []byte
+	fingerprint  string
+	generatorURL string
+	historical   bool
 }
 
 // NewAlert creates a new Alert with the required fields.
@@ -103,6 +110,24 @@ func (a *Alert) Timestamp() time.Time { return a.timestamp }
 // RawPayload returns the raw payload bytes.
 func (a *Alert) RawPayload() []byte { return a.rawPayload }
 
+// Fingerprint returns the identity the alert keeps across firings: the one set
+// by its source, or else AlertFingerprint of its source, title, and labels.
+func (a *Alert) Fingerprint() string {
+	if a.fingerprint != "" {
+		return a.fingerprint
+	}
+	return AlertFingerprint(a.source, a.title, a.labels)
+}
+
+// GeneratorURL returns the link to the expression that fired the alert, such
+// as a Prometheus graph URL, or "" if the source gave none.
+func (a *Alert) GeneratorURL() string { return a.generatorURL }
+
+// Historical reports whether the alert was replayed from the past, as in a
+// backfill, rather than received as it fired. Nobody is notified of the
+// investigations of historical alerts.
+func (a *Alert) Historical() bool { return a.historical }
+
 // Labels returns a defensive copy of the alert labels.
 func (a *Alert) Labels() map[string]string {
 	if a.labels == nil {
@@ -115,6 +140,19 @@ func (a *Alert) Labels() map[string]string {
 	return result
 }
 
+// Annotations returns a defensive copy of the alert annotations
+// (e.g., Prometheus "summary" or "runbook_url").
+func (a *Alert) Annotations() map[string]string {
+	if a.annotations == nil {
+		return nil
+	}
+	result := make(map[string]string, len(a.annotations))
+	for k, v := range a.annotations {
+		result[k] = v
+	}
+	return result
+}
+
 // IsCritical returns true if the alert has critical severity.
 func (a *Alert) IsCritical() bool { return a.severity == SeverityCritical }
 
@@ -136,12 +174,42 @@ func (a *Alert) WithLabels(labels map[string]string) *Alert {
 	return a
 }
 
+// WithAnnotations sets the alert annotations and returns the alert for chaining.
+func (a *Alert) WithAnnotations(annotations map[string]string) *Alert {
+	a.annotations = make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		a.annotations[k] = v
+	}
+	return a
+}
+
 // WithTimestamp sets the alert timestamp and returns the alert for chaining.
 func (a *Alert) WithTimestamp(t time.Time) *Alert {
 	a.timestamp = t
 	return a
 }
 
+// WithFingerprint sets the fingerprint reported by the alert source and returns
+// the alert for chaining.
+func (a *Alert) WithFingerprint(fingerprint string) *Alert {
+	a.fingerprint = strings.TrimSpace(fingerprint)
+	return a
+}
+
+// WithGeneratorURL sets the link to the expression that fired the alert and
+// returns the alert for chaining.
+func (a *Alert) WithGeneratorURL(generatorURL string) *Alert {
+	a.generatorURL = strings.TrimSpace(generatorURL)
+	return a
+}
+
+// WithHistorical marks the alert as replayed from the past and returns the
+// alert for chaining.
+func (a *Alert) WithHistorical(historical bool) *Alert {
+	a.historical = historical
+	return a
+}
+
 // WithRawPayload sets the raw payload and returns the alert for chaining.
 func (a *Alert) WithRawPayload(payload []byte) *Alert {
 	a.rawPayload = payload
@@ -156,3 +224,27 @@ func isValidSeverity(s string) bool {
 	}
 	return false
 }
+
+// AlertFingerprint derives a stable fingerprint for alerts from a source: 16
+// hex digits of a SHA-256 hash of the source and the sorted labels, or of the
+// source and title when there are no labels.
+func AlertFingerprint(source, title string, labels map[string]string) string {
+	h := sha256.New()
+	h.Write([]byte(source))
+	if len(labels) == 0 {
+		h.Write([]byte{0})
+		h.Write([]byte(title))
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(labels[k]))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxFingerprintLength bounds alert fingerprints, which are used as file names.
+const maxFingerprintLength = 128
+
+// Sentinel errors for AlertSuppression validation.
+var (
+	// ErrInvalidFingerprint is returned when a fingerprint is empty, too long,
+	// starts with a dot, or contains characters other than letters, digits,
+	// '.', '_', and '-'.
+	ErrInvalidFingerprint = errors.New("invalid alert fingerprint")
+	// ErrSuppressionEnded is returned when a suppression would end before it starts.
+	ErrSuppressionEnded = errors.New("suppression must end in the future")
+)
+
+// AlertSuppression stops alerts with a fingerprint from being investigated
+// until a point in time, for example during a maintenance window. It needs no
+// cleanup: a suppression whose Until has passed is simply no longer active.
+type AlertSuppression struct {
+	Fingerprint string    `json:"fingerprint"`
+	Until       time.Time `json:"until"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewAlertSuppression creates a suppression of fingerprint from now until until.
+// Returns ErrInvalidFingerprint or ErrSuppressionEnded if validation fails.
+func NewAlertSuppression(fingerprint string, until time.Time, reason string, now time.Time) (*AlertSuppression, error) {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if err := ValidateFingerprint(fingerprint); err != nil {
+		return nil, err
+	}
+	if !until.After(now) {
+		return nil, ErrSuppressionEnded
+	}
+	return &AlertSuppression{
+		Fingerprint: fingerprint,
+		Until:       until,
+		Reason:      strings.TrimSpace(reason),
+		CreatedAt:   now,
+	}, nil
+}
+
+// ActiveAt reports whether the suppression is in effect at t. It ends at
+// Until: an alert arriving exactly then is investigated again.
+func (s *AlertSuppression) ActiveAt(t time.Time) bool {
+	return s != nil && t.Before(s.Until)
+}
+
+// ValidateFingerprint checks that a fingerprint is safe to use as a file name.
+func ValidateFingerprint(fingerprint string) error {
+	if fingerprint == "" || len(fingerprint) > maxFingerprintLength || fingerprint[0] == '.' {
+		return ErrInvalidFingerprint
+	}
+	for _, r := range fingerprint {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return ErrInvalidFingerprint
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAlertSuppression(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		until       time.Time
+		wantErr     error
+	}{
+		{"valid", "3f2a9c81d0b4e765", now.Add(time.Hour), nil},
+		{"trimmed", "  disk-full_db.1  ", now.Add(time.Hour), nil},
+		{"empty", "  ", now.Add(time.Hour), ErrInvalidFingerprint},
+		{"path separator", "../etc/passwd", now.Add(time.Hour), ErrInvalidFingerprint},
+		{"leading dot", ".hidden", now.Add(time.Hour), ErrInvalidFingerprint},
+		{"too long", strings.Repeat("a", 129), now.Add(time.Hour), ErrInvalidFingerprint},
+		{"ends now", "3f2a9c81d0b4e765", now, ErrSuppressionEnded},
+		{"ended", "3f2a9c81d0b4e765", now.Add(-time.Minute), ErrSuppressionEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewAlertSuppression(tt.fingerprint, tt.until, " maintenance ", now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewAlertSuppression() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if s.Fingerprint != strings.TrimSpace(tt.fingerprint) {
+				t.Errorf("Fingerprint = %q, want %q", s.Fingerprint, strings.TrimSpace(tt.fingerprint))
+			}
+			if s.Reason != "maintenance" || !s.CreatedAt.Equal(now) || !s.Until.Equal(tt.until) {
+				t.Errorf("NewAlertSuppression() = %+v", s)
+			}
+		})
+	}
+}
+
+func TestAlertSuppression_ActiveAt(t *testing.T) {
+	until := time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC)
+	s := &AlertSuppression{Fingerprint: "fp", Until: until}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"well before", until.Add(-time.Hour), true},
+		{"just before", until.Add(-time.Nanosecond), true},
+		{"at until", until, false},
+		{"after", until.Add(time.Nanosecond), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.ActiveAt(tt.at); got != tt.want {
+				t.Errorf("ActiveAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+
+	var none *AlertSuppression
+	if none.ActiveAt(until) {
+		t.Error("nil suppression should never be active")
+	}
+}
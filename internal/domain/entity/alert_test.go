@@ -373,6 +373,30 @@ func TestAlert_WithLabels(t *testing.T) {
 	})
 }
 
+func TestAlert_WithAnnotations(t *testing.T) {
+	alert, err := NewAlert("test-id", "test-source", SeverityWarning, "Test Title")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+
+	if alert.Annotations() != nil {
+		t.Errorf("Annotations() = %v, want nil before WithAnnotations", alert.Annotations())
+	}
+
+	input := map[string]string{"runbook_url": "https://runbooks.example.com/disk"}
+	if result := alert.WithAnnotations(input); result != alert {
+		t.Error("WithAnnotations() should return the same alert instance for chaining")
+	}
+
+	// Neither the input nor the returned map should alias the entity's annotations
+	input["runbook_url"] = "changed"
+	got := alert.Annotations()
+	got["summary"] = "added"
+	if fresh := alert.Annotations(); len(fresh) != 1 || fresh["runbook_url"] != "https://runbooks.example.com/disk" {
+		t.Errorf("Annotations() = %v, want defensive copies", fresh)
+	}
+}
+
 func TestAlert_WithTimestamp(t *testing.T) {
 	t.Run("should set custom timestamp using builder pattern", func(t *testing.T) {
 		alert, err := NewAlert("test-id", "test-source", SeverityCritical, "Test Title")
@@ -430,6 +454,46 @@ func TestAlert_WithRawPayload(t *testing.T) {
 	})
 }
 
+func TestAlert_Fingerprint(t *testing.T) {
+	newAlert := func(id, title string, labels map[string]string) *Alert {
+		t.Helper()
+		alert, err := NewAlert(id, "prometheus", SeverityWarning, title)
+		if err != nil {
+			t.Fatalf("NewAlert() error = %v", err)
+		}
+		if labels != nil {
+			alert.WithLabels(labels)
+		}
+		return alert
+	}
+	labels := map[string]string{"alertname": "DiskFull", "instance": "db-1"}
+
+	first := newAlert("DiskFull-2026-03-04T05:00:00Z", "Disk 91% full", labels)
+	refire := newAlert("DiskFull-2026-03-04T09:00:00Z", "Disk 97% full", labels)
+	if first.Fingerprint() != refire.Fingerprint() {
+		t.Errorf("re-fired alert fingerprint = %q, want %q", refire.Fingerprint(), first.Fingerprint())
+	}
+	if len(first.Fingerprint()) != 16 {
+		t.Errorf("Fingerprint() = %q, want 16 hex digits", first.Fingerprint())
+	}
+
+	other := newAlert("DiskFull-2026-03-04T05:00:00Z", "Disk 91% full",
+		map[string]string{"alertname": "DiskFull", "instance": "db-2"})
+	if other.Fingerprint() == first.Fingerprint() {
+		t.Error("alerts with different labels should have different fingerprints")
+	}
+
+	unlabelled := newAlert("a-1", "Disk Full", nil)
+	if unlabelled.Fingerprint() == newAlert("a-2", "CPU High", nil).Fingerprint() {
+		t.Error("alerts without labels should be fingerprinted by title")
+	}
+
+	first.WithFingerprint(" 3f2a9c81d0b4e765 ")
+	if got := first.Fingerprint(); got != "3f2a9c81d0b4e765" {
+		t.Errorf("Fingerprint() = %q, want the fingerprint set by the source", got)
+	}
+}
+
 func TestAlert_Labels_DefensiveCopy(t *testing.T) {
 	t.Run("modifying returned labels should not affect entity", func(t *testing.T) {
 		alert, err := NewAlert("test-id", "test-source", SeverityWarning, "Test Title")
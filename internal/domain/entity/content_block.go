@@ -0,0 +1,137 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Content block types.
+const (
+	ContentBlockText  = "text"
+	ContentBlockImage = "image"
+)
+
+// MaxImageBytes is the largest image, before base64 encoding, that can be
+// attached to a message.
+const MaxImageBytes = 5 << 20
+
+// supportedImageMediaTypes are the image formats that can be attached to a message.
+var supportedImageMediaTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type: must be PNG, JPEG, or WebP")
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrEmptyImage           = errors.New("image must have base64 data or a file path")
+)
+
+// ImageSource is the content of an image block: either base64-encoded data or
+// the path of a file holding the image, read when the message is sent.
+type ImageSource struct {
+	MediaType string `json:"media_type"`
+	Data      string `json:"data,omitempty"` // Base64-encoded image
+	Path      string `json:"path,omitempty"`
+}
+
+// ContentBlock is one part of a message with mixed content, such as text
+// followed by a screenshot.
+type ContentBlock struct {
+	Type  string       `json:"type"`
+	Text  string       `json:"text,omitempty"`
+	Image *ImageSource `json:"image,omitempty"`
+}
+
+// NewTextBlock creates a text content block.
+func NewTextBlock(text string) ContentBlock {
+	return ContentBlock{Type: ContentBlockText, Text: text}
+}
+
+// NewImageBlock creates an image content block.
+func NewImageBlock(source ImageSource) ContentBlock {
+	return ContentBlock{Type: ContentBlockImage, Image: &source}
+}
+
+// ValidateImage checks that an image of the given media type and size in
+// bytes can be attached to a message.
+func ValidateImage(mediaType string, size int64) error {
+	if err := ValidateImageType(mediaType); err != nil {
+		return err
+	}
+	return ValidateImageSize(size)
+}
+
+// ValidateImageType checks that images of the given media type can be attached
+// to a message.
+func ValidateImageType(mediaType string) error {
+	if !supportedImageMediaTypes[mediaType] {
+		return fmt.Errorf("%w (got %s)", ErrUnsupportedImageType, mediaType)
+	}
+	return nil
+}
+
+// ValidateImageSize checks that an image of size bytes is within MaxImageBytes.
+func ValidateImageSize(size int64) error {
+	if size > MaxImageBytes {
+		return fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrImageTooLarge, size, MaxImageBytes)
+	}
+	return nil
+}
+
+// Validate checks that the block is a text block or a valid image block.
+func (b ContentBlock) Validate() error {
+	switch b.Type {
+	case ContentBlockText:
+		return nil
+	case ContentBlockImage:
+		if b.Image == nil || (b.Image.Data == "" && b.Image.Path == "") {
+			return ErrEmptyImage
+		}
+		return ValidateImageType(b.Image.MediaType)
+	default:
+		return fmt.Errorf("unknown content block type: %q", b.Type)
+	}
+}
+
+// NewMessageWithBlocks creates a message from content blocks. Content is set to
+// the text of the text blocks, separated by blank lines, so code that only
+// handles text still sees it; providers send the blocks themselves.
+func NewMessageWithBlocks(role string, blocks []ContentBlock) (*Message, error) {
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, ErrNoContentOrTool
+	}
+
+	var texts []string
+	for _, block := range blocks {
+		if err := block.Validate(); err != nil {
+			return nil, err
+		}
+		if block.Type == ContentBlockText && strings.TrimSpace(block.Text) != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+
+	return &Message{
+		Role:      role,
+		Content:   strings.Join(texts, "\n\n"),
+		Timestamp: time.Now(),
+		Blocks:    blocks,
+	}, nil
+}
+
+// HasImages returns true if the message has at least one image block.
+func (m *Message) HasImages() bool {
+	for _, block := range m.Blocks {
+		if block.Type == ContentBlockImage {
+			return true
+		}
+	}
+	return false
+}
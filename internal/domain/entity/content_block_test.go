@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		size      int64
+		wantErr   error
+	}{
+		{name: "png", mediaType: "image/png", size: 1024},
+		{name: "jpeg", mediaType: "image/jpeg", size: 1024},
+		{name: "webp at the limit", mediaType: "image/webp", size: MaxImageBytes},
+		{name: "gif", mediaType: "image/gif", size: 1024, wantErr: ErrUnsupportedImageType},
+		{name: "text", mediaType: "text/plain; charset=utf-8", size: 10, wantErr: ErrUnsupportedImageType},
+		{name: "too large", mediaType: "image/png", size: MaxImageBytes + 1, wantErr: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateImage(tt.mediaType, tt.size)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("ValidateImage(%q, %d) error = %v, want %v", tt.mediaType, tt.size, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewMessageWithBlocks(t *testing.T) {
+	msg, err := NewMessageWithBlocks(RoleUser, []ContentBlock{
+		NewTextBlock("Why is this misaligned?"),
+		NewImageBlock(ImageSource{MediaType: "image/png", Path: "/tmp/screenshot.png"}),
+	})
+	if err != nil {
+		t.Fatalf("NewMessageWithBlocks() error = %v", err)
+	}
+	if msg.Content != "Why is this misaligned?" || !msg.HasImages() {
+		t.Errorf("message = %+v, want the text as content and an image", msg)
+	}
+	if err := msg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	imageOnly, err := NewMessageWithBlocks(RoleUser, []ContentBlock{
+		NewImageBlock(ImageSource{MediaType: "image/jpeg", Data: "aGVsbG8="}),
+	})
+	if err != nil {
+		t.Fatalf("NewMessageWithBlocks() error = %v for an image-only message", err)
+	}
+	if err := imageOnly.Validate(); err != nil {
+		t.Errorf("Validate() error = %v for an image-only message", err)
+	}
+
+	invalid := [][]ContentBlock{
+		nil,
+		{NewImageBlock(ImageSource{MediaType: "image/png"})},
+		{NewImageBlock(ImageSource{MediaType: "image/bmp", Data: "Qk0="})},
+		{{Type: "audio"}},
+	}
+	for _, blocks := range invalid {
+		if _, err := NewMessageWithBlocks(RoleUser, blocks); err == nil {
+			t.Errorf("NewMessageWithBlocks(%+v) expected error", blocks)
+		}
+	}
+}
+
+func TestMessage_BlocksJSONRoundTrip(t *testing.T) {
+	msg, _ := NewMessageWithBlocks(RoleUser, []ContentBlock{
+		NewTextBlock("Look"),
+		NewImageBlock(ImageSource{MediaType: "image/webp", Path: "/tmp/ui.webp"}),
+	})
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(decoded.Blocks) != 2 || decoded.Blocks[1].Image == nil ||
+		decoded.Blocks[1].Image.Path != "/tmp/ui.webp" || decoded.Blocks[1].Image.MediaType != "image/webp" {
+		t.Errorf("decoded blocks = %+v, want the text and image blocks", decoded.Blocks)
+	}
+
+	plain, _ := NewMessage(RoleUser, "Hello")
+	data, _ = json.Marshal(plain)
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	if _, ok := fields["blocks"]; ok {
+		t.Errorf("text message JSON %s should omit blocks", data)
+	}
+}
@@ -265,6 +265,26 @@ func (c *Conversation) Clear() {
 	c.Messages = []Message{}
 }
 
+// Truncate removes every message after the first count messages.
+//
+// This method rewinds the conversation to an earlier point, such as a
+// checkpoint, while preserving the StartedAt timestamp. A count outside the
+// range 0 to MessageCount() is clamped to that range.
+//
+// Parameters:
+//   - count: The number of messages to keep
+//
+// Example:
+//
+//	checkpoint := conv.MessageCount()
+//	conv.AddMessage(*msg)
+//	conv.Truncate(checkpoint)
+//	fmt.Printf("Back to %d messages\n", conv.MessageCount())
+func (c *Conversation) Truncate(count int) {
+	count = max(0, min(count, len(c.Messages)))
+	c.Messages = c.Messages[:count:count]
+}
+
 // MessageCount returns the number of messages in the conversation.
 //
 // This method provides efficient access to the total message count without
@@ -292,6 +312,31 @@ func (c *Conversation) MessageCount() int {
 	return len(c.Messages)
 }
 
+// UserPromptCount returns the number of turns the user has taken: user
+// messages other than tool results.
+func (c *Conversation) UserPromptCount() int {
+	count := 0
+	for i := range c.Messages {
+		if c.Messages[i].IsUserPrompt() {
+			count++
+		}
+	}
+	return count
+}
+
+// TokenUsage returns the tokens billed for all of the conversation's
+// responses. Messages without recorded usage count as zero.
+func (c *Conversation) TokenUsage() TokenUsage {
+	var total TokenUsage
+	for i := range c.Messages {
+		if usage := c.Messages[i].Usage; usage != nil {
+			total.InputTokens += usage.InputTokens
+			total.OutputTokens += usage.OutputTokens
+		}
+	}
+	return total
+}
+
 // IsEmpty returns true if the conversation has no messages.
 //
 // This method provides a semantic way to check if a conversation is empty,
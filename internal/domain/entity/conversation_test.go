@@ -272,6 +272,32 @@ func TestConversation_Clear(t *testing.T) {
 	}
 }
 
+func TestConversation_Truncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		want  int
+	}{
+		{name: "should keep the first messages", count: 1, want: 1},
+		{name: "should remove all messages at zero", count: 0, want: 0},
+		{name: "should clamp negative counts", count: -1, want: 0},
+		{name: "should keep all messages beyond the end", count: 5, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Conversation{Messages: []Message{
+				{Role: "user", Content: "Hello"},
+				{Role: "assistant", Content: "Hi there!"},
+			}}
+			c.Truncate(tt.count)
+			if len(c.Messages) != tt.want {
+				t.Errorf("Conversation.Truncate(%d) messages length = %v, want %v", tt.count, len(c.Messages), tt.want)
+			}
+		})
+	}
+}
+
 func TestConversation_MessageCount(t *testing.T) {
 	type fields struct {
 		Messages []Message
@@ -0,0 +1,318 @@
+// Package jsonextract finds the JSON in text a model wrote: a reply that puts
+// the object it was asked for inside a markdown fence, after a sentence of
+// prose, or before a closing remark, often with a trailing comma or smart
+// quotes a strict parser rejects.
+package jsonextract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Kinds of Error; test for them with errors.Is.
+var (
+	ErrNotFound     = errors.New("no JSON object or array found")
+	ErrUnterminated = errors.New("unterminated JSON")
+	ErrInvalid      = errors.New("invalid JSON")
+)
+
+// snippetRunes is how much of a candidate an Error quotes.
+const snippetRunes = 80
+
+// Error describes why no JSON could be taken from a text.
+type Error struct {
+	Kind      error  // ErrNotFound, ErrUnterminated, or ErrInvalid
+	Candidate string // The text that failed: the JSON-like span, or the whole text for ErrNotFound
+	Err       error  // Why the candidate did not parse or decode, for ErrInvalid
+}
+
+// Error implements error, quoting the start of the candidate.
+func (e *Error) Error() string {
+	switch {
+	case e.Kind == ErrNotFound:
+		return fmt.Sprintf("%v in %q", e.Kind, snippet(e.Candidate))
+	case e.Err != nil:
+		return fmt.Sprintf("%v %q: %v", e.Kind, snippet(e.Candidate), e.Err)
+	default:
+		return fmt.Sprintf("%v starting %q", e.Kind, snippet(e.Candidate))
+	}
+}
+
+// Unwrap returns the kind and the underlying error.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Extract returns the first JSON object or array in text that parses, either
+// as written or after Repair. The result is always valid JSON. Spans that
+// merely look bracketed, like "[critical]" or "{name}", are skipped.
+func Extract(text string) (json.RawMessage, error) {
+	var found json.RawMessage
+	err := scan(text, func(raw json.RawMessage) bool {
+		found = raw
+		return true
+	})
+	return found, err
+}
+
+// Unmarshal decodes into v, which must be a non-nil pointer, the first JSON
+// object or array in text that parses and fits v, so an array mentioned in
+// passing does not stand in for the object asked for. v is left unchanged on
+// error.
+func Unmarshal(text string, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return errors.New("jsonextract: Unmarshal needs a non-nil pointer")
+	}
+
+	var mismatch error
+	err := scan(text, func(raw json.RawMessage) bool {
+		decoded := reflect.New(target.Elem().Type())
+		if err := json.Unmarshal(raw, decoded.Interface()); err != nil {
+			if mismatch == nil {
+				mismatch = &Error{Kind: ErrInvalid, Candidate: string(raw), Err: err}
+			}
+			return false
+		}
+		target.Elem().Set(decoded.Elem())
+		return true
+	})
+	if err != nil && mismatch != nil {
+		return mismatch
+	}
+	return err
+}
+
+// scan offers accept each JSON value in text, in order, until it returns
+// true. It returns nil once one is accepted and otherwise the first failure.
+func scan(text string, accept func(json.RawMessage) bool) error {
+	runes := []rune(text)
+	var first error
+	fail := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		if !plausibleStart(runes, i) {
+			continue
+		}
+		end, ok := balancedEnd(runes, i)
+		if end < 0 {
+			// Everything after an unclosed bracket belongs to it: the
+			// output was cut off
+			fail(&Error{Kind: ErrUnterminated, Candidate: string(runes[i:])})
+			break
+		}
+		candidate := string(runes[i : end+1])
+		i = end
+		if !ok {
+			fail(&Error{Kind: ErrInvalid, Candidate: candidate, Err: fmt.Errorf("mismatched %q", runes[end])})
+			continue
+		}
+		raw, err := parse(candidate)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if accept(raw) {
+			return nil
+		}
+	}
+	if first == nil {
+		return &Error{Kind: ErrNotFound, Candidate: text}
+	}
+	return first
+}
+
+// parse returns candidate as JSON, repairing it if it does not parse as written.
+func parse(candidate string) (json.RawMessage, error) {
+	if json.Valid([]byte(candidate)) {
+		return json.RawMessage(candidate), nil
+	}
+	repaired := Repair(candidate)
+	var v any
+	if err := json.Unmarshal([]byte(repaired), &v); err != nil {
+		return nil, &Error{Kind: ErrInvalid, Candidate: candidate, Err: err}
+	}
+	return json.RawMessage(repaired), nil
+}
+
+// Repair makes the usual slips in model-written JSON valid: smart and single
+// quotes around strings become double quotes, and commas before a closing
+// bracket are dropped. Double-quoted strings are copied as they are, so smart
+// quotes within them survive.
+func Repair(candidate string) string {
+	runes := []rune(candidate)
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isQuote(r):
+			end := stringEnd(runes, i)
+			if end < 0 {
+				b.WriteString(string(runes[i:]))
+				return b.String()
+			}
+			writeString(&b, r, runes[i+1:end])
+			i = end
+		case r == ',' && closesNext(runes, i+1):
+			// Drop the trailing comma
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeString writes the content of a string opened by quote as a
+// double-quoted JSON string.
+func writeString(b *strings.Builder, quote rune, content []rune) {
+	b.WriteByte('"')
+	if quote == '"' {
+		b.WriteString(string(content))
+		b.WriteByte('"')
+		return
+	}
+	for i := 0; i < len(content); i++ {
+		switch r := content[i]; {
+		case r == '\\' && i+1 < len(content) && content[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case r == '\\' && i+1 < len(content):
+			b.WriteRune(r)
+			b.WriteRune(content[i+1])
+			i++
+		case r == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// closesNext reports whether the next rune from i on that is not white space
+// closes an object or array.
+func closesNext(runes []rune, i int) bool {
+	for ; i < len(runes); i++ {
+		if !unicode.IsSpace(runes[i]) {
+			return runes[i] == '}' || runes[i] == ']'
+		}
+	}
+	return false
+}
+
+// plausibleStart reports whether runes[i] opens what could be a JSON object or
+// array: an object's first token must be a key or its end, and an array's a
+// value or its end.
+func plausibleStart(runes []rune, i int) bool {
+	open := runes[i]
+	if open != '{' && open != '[' {
+		return false
+	}
+	j := i + 1
+	for j < len(runes) && unicode.IsSpace(runes[j]) {
+		j++
+	}
+	if j == len(runes) {
+		return true // Cut off right after the bracket
+	}
+	next := runes[j]
+	switch {
+	case isQuote(next):
+		return true
+	case open == '{':
+		return next == '}'
+	case next == ']' || next == '{' || next == '[' || next == '-' || unicode.IsDigit(next):
+		return true
+	}
+	end := j
+	for end < len(runes) && unicode.IsLetter(runes[end]) {
+		end++
+	}
+	switch string(runes[j:end]) {
+	case "true", "false", "null":
+		return true
+	}
+	return false
+}
+
+// balancedEnd returns the index of the bracket closing the one at start, and
+// whether it matches; a mismatched bracket ends the span too. It returns -1
+// if the brackets or a string are never closed.
+func balancedEnd(runes []rune, start int) (int, bool) {
+	var open []rune
+	for i := start; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case isQuote(r):
+			if i = stringEnd(runes, i); i < 0 {
+				return -1, false
+			}
+		case r == '{':
+			open = append(open, '}')
+		case r == '[':
+			open = append(open, ']')
+		case r == '}' || r == ']':
+			if r != open[len(open)-1] {
+				return i, false
+			}
+			if open = open[:len(open)-1]; len(open) == 0 {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// stringEnd returns the index of the quote closing the string opened at
+// start, or -1 if it is never closed. A string opened with a straight quote
+// closes only with one; one opened with a smart quote closes with any quote
+// of its kind, since models mix them.
+func stringEnd(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\':
+			i++
+		case r == quote || (quote != '"' && quote != '\'' && sameKind(quote, r)):
+			return i
+		}
+	}
+	return -1
+}
+
+// isQuote reports whether r opens a string: a straight, smart, or single quote.
+func isQuote(r rune) bool {
+	return isDoubleQuote(r) || isSingleQuote(r)
+}
+
+func isDoubleQuote(r rune) bool {
+	return r == '"' || r == '“' || r == '”'
+}
+
+func isSingleQuote(r rune) bool {
+	return r == '\'' || r == '‘' || r == '’'
+}
+
+// sameKind reports whether two quotes are both double or both single quotes.
+func sameKind(a, b rune) bool {
+	return (isDoubleQuote(a) && isDoubleQuote(b)) || (isSingleQuote(a) && isSingleQuote(b))
+}
+
+// snippet returns the start of s, cut to snippetRunes.
+func snippet(s string) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= snippetRunes {
+		return string(runes)
+	}
+	return string(runes[:snippetRunes]) + "..."
+}
@@ -0,0 +1,313 @@
+package jsonextract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// completion is the shape of a complete_investigation call written as text.
+type completion struct {
+	Findings   []string `json:"findings"`
+	RootCause  string   `json:"root_cause,omitempty"`
+	Confidence float64  `json:"confidence"`
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // Compact JSON
+	}{
+		{
+			name: "bare object",
+			text: `{"findings": ["disk full"], "confidence": 0.9}`,
+			want: `{"findings":["disk full"],"confidence":0.9}`,
+		},
+		{
+			name: "bare array",
+			text: `["a", "b"]`,
+			want: `["a","b"]`,
+		},
+		{
+			name: "json fence",
+			text: "Here is my conclusion:\n\n```json\n{\n  \"findings\": [\"OOM kill at 03:12\"],\n" +
+				"  \"confidence\": 0.8\n}\n```\n",
+			want: `{"findings":["OOM kill at 03:12"],"confidence":0.8}`,
+		},
+		{
+			name: "unlabeled fence",
+			text: "```\n[\"restart nginx\"]\n```",
+			want: `["restart nginx"]`,
+		},
+		{
+			name: "leading prose and trailing commentary",
+			text: `Based on the logs, the result is {"findings": ["cert expired"]} — let me know if you need more.`,
+			want: `{"findings":["cert expired"]}`,
+		},
+		{
+			name: "severity tags in the prose are not JSON",
+			text: `[critical] Root cause found. [warning] See below: {"findings": ["[critical] disk full"]}`,
+			want: `{"findings":["[critical] disk full"]}`,
+		},
+		{
+			name: "template placeholders are not JSON",
+			text: `Fill in {alert_name} and {namespace}. Result: {"confidence": 0.5}`,
+			want: `{"confidence":0.5}`,
+		},
+		{
+			name: "braces inside strings",
+			text: `{"findings": ["query {job=\"api\"} returned ]"], "confidence": 1}`,
+			want: `{"findings":["query {job=\"api\"} returned ]"],"confidence":1}`,
+		},
+		{
+			name: "nested objects",
+			text: `Result: {"a": {"b": [1, {"c": null}]}, "d": true} done`,
+			want: `{"a":{"b":[1,{"c":null}]},"d":true}`,
+		},
+		{
+			name: "trailing commas",
+			text: "{\n  \"findings\": [\n    \"disk full\",\n    \"log rotation disabled\",\n  ],\n  \"confidence\": 0.7,\n}",
+			want: `{"findings":["disk full","log rotation disabled"],"confidence":0.7}`,
+		},
+		{
+			name: "smart quotes",
+			text: `{“findings”: [“disk full”], “confidence”: 0.6}`,
+			want: `{"findings":["disk full"],"confidence":0.6}`,
+		},
+		{
+			name: "smart quotes closed by straight ones",
+			text: `{“findings": [“disk full"], “confidence": 0.6}`,
+			want: `{"findings":["disk full"],"confidence":0.6}`,
+		},
+		{
+			name: "single quotes",
+			text: `{'findings': ['pod can\'t pull "api:v2"'], 'confidence': 0.4}`,
+			want: `{"findings":["pod can't pull \"api:v2\""],"confidence":0.4}`,
+		},
+		{
+			name: "smart quotes inside a valid string are kept",
+			text: `{"findings": ["the log says “permission denied”"]}`,
+			want: `{"findings":["the log says “permission denied”"]}`,
+		},
+		{
+			name: "first of several objects",
+			text: `First {"step": 1} then {"step": 2}`,
+			want: `{"step":1}`,
+		},
+		{
+			name: "skips an unparseable span",
+			text: `Tried {"findings": [oops]} and then {"findings": ["ok"]}`,
+			want: `{"findings":["ok"]}`,
+		},
+		{
+			name: "array of literals",
+			text: `Flags: [true, false, null]`,
+			want: `[true,false,null]`,
+		},
+		{
+			name: "negative numbers",
+			text: `Deltas: [-1.5, 2]`,
+			want: `[-1.5,2]`,
+		},
+		{
+			name: "empty object",
+			text: `Nothing to report: {}`,
+			want: `{}`,
+		},
+		{
+			name: "unicode and escapes",
+			text: `{"findings": ["naïve é \n tab\t"], "emoji": "🔥"}`,
+			want: `{"findings":["naïve é \n tab\t"],"emoji":"🔥"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := Extract(tt.text)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			var got bytes.Buffer
+			if err := json.Compact(&got, raw); err != nil {
+				t.Fatalf("Extract() = %s, not valid JSON: %v", raw, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Extract() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantKind      error
+		wantCandidate string
+		wantMessage   string
+	}{
+		{
+			name:          "prose only",
+			text:          "I could not determine the root cause.",
+			wantKind:      ErrNotFound,
+			wantCandidate: "I could not determine the root cause.",
+			wantMessage:   `no JSON object or array found in "I could not determine the root cause."`,
+		},
+		{
+			name:          "empty",
+			text:          "",
+			wantKind:      ErrNotFound,
+			wantCandidate: "",
+		},
+		{
+			name:          "bracketed prose only",
+			text:          "[critical] Check {service} now",
+			wantKind:      ErrNotFound,
+			wantCandidate: "[critical] Check {service} now",
+		},
+		{
+			name:          "cut off",
+			text:          `Result: {"findings": ["disk full", "log rot`,
+			wantKind:      ErrUnterminated,
+			wantCandidate: `{"findings": ["disk full", "log rot`,
+			wantMessage:   `unterminated JSON starting "{\"findings\": [\"disk full\", \"log rot"`,
+		},
+		{
+			name:          "unquoted values",
+			text:          `{"findings": [disk full], "confidence": high}`,
+			wantKind:      ErrInvalid,
+			wantCandidate: `{"findings": [disk full], "confidence": high}`,
+		},
+		{
+			name:          "mismatched brackets",
+			text:          `{"findings": ["disk full"}`,
+			wantKind:      ErrInvalid,
+			wantCandidate: `{"findings": ["disk full"}`,
+			wantMessage:   `invalid JSON "{\"findings\": [\"disk full\"}": mismatched '}'`,
+		},
+		{
+			name:          "first failure is reported",
+			text:          `{"a": nope} and {"b": also nope}`,
+			wantKind:      ErrInvalid,
+			wantCandidate: `{"a": nope}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := Extract(tt.text)
+			if err == nil {
+				t.Fatalf("Extract() = %s, want an error", raw)
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("Extract() error = %v, want %v", err, tt.wantKind)
+			}
+			var extractErr *Error
+			if !errors.As(err, &extractErr) {
+				t.Fatalf("Extract() error = %T, want *Error", err)
+			}
+			if extractErr.Candidate != tt.wantCandidate {
+				t.Errorf("Candidate = %q, want %q", extractErr.Candidate, tt.wantCandidate)
+			}
+			if tt.wantMessage != "" && err.Error() != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestError_SnippetIsShortened(t *testing.T) {
+	long := `{"findings": ["` + strings.Repeat("x", 200)
+	_, err := Extract(long)
+	if !errors.Is(err, ErrUnterminated) {
+		t.Fatalf("Extract() error = %v, want ErrUnterminated", err)
+	}
+	if msg := err.Error(); !strings.HasSuffix(msg, `..."`) || len(msg) > 120 {
+		t.Errorf("Error() = %q, want the candidate cut short", msg)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want completion
+	}{
+		{
+			name: "fenced with commentary",
+			text: "I'm done.\n```json\n{\"findings\": [\"disk full\"], \"root_cause\": \"logrotate off\", " +
+				"\"confidence\": 0.85}\n```\nThanks!",
+			want: completion{Findings: []string{"disk full"}, RootCause: "logrotate off", Confidence: 0.85},
+		},
+		{
+			name: "skips an array that does not fit",
+			text: `Checked pods [1, 2, 3]. Final: {"findings": ["pod 2 OOM"], "confidence": 0.7}`,
+			want: completion{Findings: []string{"pod 2 OOM"}, Confidence: 0.7},
+		},
+		{
+			name: "repaired",
+			text: `{“findings”: [“cert expired”,], “confidence”: 0.9,}`,
+			want: completion{Findings: []string{"cert expired"}, Confidence: 0.9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got completion
+			if err := Unmarshal(tt.text, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	t.Run("wrong shape reports the decode error", func(t *testing.T) {
+		got := completion{RootCause: "unchanged"}
+		err := Unmarshal(`Answer: {"findings": "disk full", "confidence": "high"}`, &got)
+		var typeErr *json.UnmarshalTypeError
+		if !errors.Is(err, ErrInvalid) || !errors.As(err, &typeErr) {
+			t.Fatalf("Unmarshal() error = %v, want ErrInvalid wrapping a type error", err)
+		}
+		if got.RootCause != "unchanged" || got.Findings != nil {
+			t.Errorf("Unmarshal() changed v to %+v on error", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var got completion
+		if err := Unmarshal("No JSON here.", &got); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Unmarshal() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		if err := Unmarshal(`{}`, completion{}); err == nil {
+			t.Error("Unmarshal() into a value succeeded, want an error")
+		}
+	})
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`[1, 2, ]`, `[1, 2 ]`},
+		{`{"a": 1,}`, `{"a": 1}`},
+		{`{"a": "x,}"}`, `{"a": "x,}"}`},
+		{`{‘a’: ‘it\'s’}`, `{"a": "it's"}`},
+		{`{"a": "unterminated`, `{"a": "unterminated`},
+	}
+	for _, tt := range tests {
+		if got := Repair(tt.in); got != tt.want {
+			t.Errorf("Repair(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -32,10 +33,29 @@ type ToolCall struct {
 
 // ToolResult represents a tool result block in a user message.
 type ToolResult struct {
-	ToolID           string `json:"tool_id"`
-	Result           string `json:"result"`
-	IsError          bool   `json:"is_error"`
-	ThoughtSignature string `json:"thought_signature,omitempty"` // Gemini thought signature (via Bifrost)
+	ToolID           string       `json:"tool_id"`
+	Result           string       `json:"result"`
+	IsError          bool         `json:"is_error"`
+	ThoughtSignature string       `json:"thought_signature,omitempty"` // Gemini thought signature (via Bifrost)
+	Provenance       []Provenance `json:"provenance,omitempty"`        // File lines it quotes; not sent to the model
+}
+
+// Provenance is the source of a tool result's text: lines StartLine through
+// EndLine (1-based, inclusive) of the file at Path, which appear in the
+// result one per line, in order.
+type Provenance struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// String returns the provenance as "path:start-end", or "path:line" for a
+// single line.
+func (p Provenance) String() string {
+	if p.StartLine == p.EndLine {
+		return fmt.Sprintf("%s:%d", p.Path, p.StartLine)
+	}
+	return fmt.Sprintf("%s:%d-%d", p.Path, p.StartLine, p.EndLine)
 }
 
 // ThinkingBlock represents a thinking block in a message.
@@ -53,6 +73,15 @@ type Message struct {
 	ToolCalls      []ToolCall      `json:"tool_calls,omitempty"`      // Tool calls from assistant messages
 	ToolResults    []ToolResult    `json:"tool_results,omitempty"`    // Tool results from user messages
 	ThinkingBlocks []ThinkingBlock `json:"thinking_blocks,omitempty"` // Thinking blocks
+	Blocks         []ContentBlock  `json:"blocks,omitempty"`          // Text and image blocks, in order, when the message has images
+	Usage          *TokenUsage     `json:"usage,omitempty"`           // Tokens the provider billed for an assistant message
+}
+
+// TokenUsage is the number of tokens a provider billed for one response.
+// InputTokens includes prompt tokens read from or written to the cache.
+type TokenUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
 }
 
 // validateRole checks if the provided role is valid.
@@ -153,9 +182,34 @@ func NewMessageWithThinkingBlocks(role, content string, thinkingBlocks []Thinkin
 	}, nil
 }
 
-// hasToolContent returns true if the message has either tool calls or tool results.
+// EstimateTokens returns a rough token count for text, assuming about four
+// characters per token. It is meant for display, logging, and local rate
+// limiting, not for enforcing provider limits exactly.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// ThinkingTokens returns the estimated number of tokens in the message's thinking blocks.
+func (m *Message) ThinkingTokens() int {
+	total := 0
+	for _, block := range m.ThinkingBlocks {
+		total += EstimateTokens(block.Thinking)
+	}
+	return total
+}
+
+// WithoutThinking returns a copy of the message with its thinking blocks removed,
+// for storing results without the model's raw reasoning.
+func (m *Message) WithoutThinking() Message {
+	stripped := *m
+	stripped.ThinkingBlocks = nil
+	return stripped
+}
+
+// hasToolContent returns true if the message has tool calls, tool results,
+// thinking blocks, or content blocks, any of which can stand in for Content.
 func (m *Message) hasToolContent() bool {
-	return len(m.ToolCalls) > 0 || len(m.ToolResults) > 0 || len(m.ThinkingBlocks) > 0
+	return len(m.ToolCalls) > 0 || len(m.ToolResults) > 0 || len(m.ThinkingBlocks) > 0 || len(m.Blocks) > 0
 }
 
 // IsUser returns true if the message is from a user.
@@ -163,6 +217,12 @@ func (m *Message) IsUser() bool {
 	return m.Role == RoleUser
 }
 
+// IsUserPrompt returns true if the message is a user's turn rather than the
+// tool results sent back on the user's behalf.
+func (m *Message) IsUserPrompt() bool {
+	return m.Role == RoleUser && len(m.ToolResults) == 0
+}
+
 // IsAssistant returns true if the message is from an assistant.
 func (m *Message) IsAssistant() bool {
 	return m.Role == RoleAssistant
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Risk levels of a recommended action, as the investigation assessed them.
+// An action without a recognized risk has RiskUnknown and is never run
+// automatically.
+const (
+	RiskUnknown = ""
+	RiskLow     = "low"
+	RiskMedium  = "medium"
+	RiskHigh    = "high"
+)
+
+// Outcomes of a remediation attempt.
+const (
+	RemediationSucceeded = "succeeded" // The command ran and exited successfully
+	RemediationFailed    = "failed"    // The command ran and failed
+	RemediationDeclined  = "declined"  // The approval provider did not approve the command
+	RemediationBlocked   = "blocked"   // The command allowlist or safety policy refused the command
+)
+
+// RecommendedAction is an action an investigation recommends to resolve an
+// alert, with the command that carries it out when there is one.
+type RecommendedAction struct {
+	Description      string `json:"description"`
+	Command          string `json:"command,omitempty"`           // Shell command that carries out the action
+	Risk             string `json:"risk,omitempty"`              // RiskLow, RiskMedium, RiskHigh, or RiskUnknown
+	RequiresApproval bool   `json:"requires_approval,omitempty"` // A person must approve the command before it runs
+}
+
+// NormalizeRisk returns the risk level risk names, accepting abbreviations
+// such as "med", or RiskUnknown if it names none.
+func NormalizeRisk(risk string) string {
+	switch strings.ToLower(strings.TrimSpace(risk)) {
+	case "low":
+		return RiskLow
+	case "medium", "med", "moderate":
+		return RiskMedium
+	case "high":
+		return RiskHigh
+	default:
+		return RiskUnknown
+	}
+}
+
+// AutoRemediable reports whether the action may be run without a person
+// asking for it: it has a command and is low risk.
+func (a RecommendedAction) AutoRemediable() bool {
+	return strings.TrimSpace(a.Command) != "" && a.Risk == RiskLow
+}
+
+// String renders the action as one line: its description, then its command
+// and risk when known, as in "Rotate logs (`logrotate -f /etc/logrotate.conf`, low risk)".
+func (a RecommendedAction) String() string {
+	var details []string
+	if a.Command != "" {
+		details = append(details, "`"+a.Command+"`")
+	}
+	if a.Risk != RiskUnknown {
+		details = append(details, a.Risk+" risk")
+	}
+	if a.RequiresApproval {
+		details = append(details, "requires approval")
+	}
+	if len(details) == 0 {
+		return a.Description
+	}
+	return a.Description + " (" + strings.Join(details, ", ") + ")"
+}
+
+// RemediationAttempt records what happened when an investigation tried to
+// carry out a recommended action.
+type RemediationAttempt struct {
+	Action RecommendedAction `json:"action"`
+	Status string            `json:"status"`           // One of the Remediation* outcomes
+	Output string            `json:"output,omitempty"` // Command output, or why it did not run
+	At     time.Time         `json:"at"`
+}
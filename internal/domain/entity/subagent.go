@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -42,6 +43,7 @@ type Subagent struct {
 	Description     string             `yaml:"description"`                // Required: what the subagent does
 	Model           string             `yaml:"model,omitempty"`            // Optional: model to use
 	MaxActions      int                `yaml:"max_actions,omitempty"`      // Optional: maximum actions
+	MaxDuration     time.Duration      `yaml:"max_duration,omitempty"`     // Optional: wall-clock limit (0 = runner default)
 	AllowedTools    []string           `yaml:"allowed-tools,omitempty"`    // Optional: allowed tools
 	ThinkingEnabled *bool              `yaml:"thinking_enabled,omitempty"` // Optional: enable thinking (nil = inherit)
 	ThinkingBudget  int64              `yaml:"thinking_budget,omitempty"`  // Optional: thinking token budget (0 = inherit)
@@ -65,7 +67,7 @@ func (s *Subagent) UnmarshalYAML(value *yaml.Node) error {
 	s.parseBoolFields(raw)
 	s.parseAllowedTools(raw)
 
-	return nil
+	return s.parseDurationFields(raw)
 }
 
 func (s *Subagent) parseStringFields(raw map[string]interface{}) {
@@ -95,6 +97,27 @@ func (s *Subagent) parseBoolFields(raw map[string]interface{}) {
 	}
 }
 
+func (s *Subagent) parseDurationFields(raw map[string]interface{}) error {
+	v, ok := raw["max_duration"]
+	if !ok {
+		return nil
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("max_duration must be a duration string such as \"10m\", got %v", v)
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return fmt.Errorf("invalid max_duration %q: %w", str, err)
+	}
+	if d < 0 {
+		return fmt.Errorf("max_duration must not be negative, got %q", str)
+	}
+	s.MaxDuration = d
+	return nil
+}
+
 func (s *Subagent) parseAllowedTools(raw map[string]interface{}) {
 	v, ok := raw["allowed-tools"]
 	if !ok {
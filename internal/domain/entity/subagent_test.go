@@ -3,6 +3,7 @@ package entity
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 // ========================================
@@ -740,6 +741,42 @@ Content.`
 	}
 }
 
+func TestSubagent_MaxDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "not specified", field: "", want: 0},
+		{name: "minutes", field: "max_duration: 10m", want: 10 * time.Minute},
+		{name: "compound", field: "max_duration: 1h30m", want: 90 * time.Minute},
+		{name: "invalid string", field: "max_duration: soon", wantErr: true},
+		{name: "bare number", field: "max_duration: 600", wantErr: true},
+		{name: "negative", field: "max_duration: -5m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yamlContent := "---\nname: test-subagent\ndescription: Subagent duration test\n" + tt.field + "\n---\nContent."
+
+			subagent, err := ParseSubagentFromYAML(yamlContent)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSubagentFromYAML() expected error, got MaxDuration = %v", subagent.MaxDuration)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSubagentFromYAML() returned unexpected error: %v", err)
+			}
+			if subagent.MaxDuration != tt.want {
+				t.Errorf("MaxDuration = %v, want %v", subagent.MaxDuration, tt.want)
+			}
+		})
+	}
+}
+
 func TestSubagent_AllowedTools_Empty(t *testing.T) {
 	yamlContent := `---
 name: test-subagent
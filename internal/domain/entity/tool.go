@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"strings"
+	"time"
 )
 
 var (
@@ -16,8 +20,32 @@ var (
 	ErrInvalidInput     = errors.New("invalid input JSON")
 	ErrNilInput         = errors.New("input cannot be nil")
 	ErrEmptyInput       = errors.New("input cannot be empty")
+	// ErrSchemaViolation is wrapped by the errors of ValidateInput for input
+	// that does not match the tool's schema, as opposed to missing input.
+	ErrSchemaViolation = errors.New("input does not match the tool schema")
 )
 
+// InputValidationError lists every way a tool input breaks the tool's schema,
+// such as "missing required field: path". It wraps ErrSchemaViolation, and
+// ErrInvalidInput when the input is not a JSON object.
+type InputValidationError struct {
+	Violations  []string
+	invalidJSON bool
+}
+
+// Error joins the violations with "; ".
+func (e *InputValidationError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// Unwrap returns ErrSchemaViolation, and ErrInvalidInput for input that is not a JSON object.
+func (e *InputValidationError) Unwrap() []error {
+	if e.invalidJSON {
+		return []error{ErrSchemaViolation, ErrInvalidInput}
+	}
+	return []error{ErrSchemaViolation}
+}
+
 // Tool represents a computational tool that can be called with input parameters.
 // It contains metadata about the tool and validation rules for its inputs.
 type Tool struct {
@@ -26,6 +54,8 @@ type Tool struct {
 	Description    string                 `json:"description"`               // Detailed description of what the tool does
 	InputSchema    map[string]interface{} `json:"input_schema,omitempty"`    // JSON schema for validating tool inputs
 	RequiredFields []string               `json:"required_fields,omitempty"` // List of required input field names
+	Timeout        time.Duration          `json:"timeout,omitempty"`         // Longest a single execution may run; 0 if unlimited
+	Interactive    bool                   `json:"interactive,omitempty"`     // Needs someone at the terminal; never offered to headless runs
 }
 
 // NewTool creates a new tool with the specified ID, name, and description.
@@ -104,8 +134,11 @@ func (t *Tool) HasRequired(fieldName string) bool {
 	return false
 }
 
-// ValidateInput validates raw JSON input against the tool's required fields.
-// The input must be valid JSON and contain all required fields.
+// ValidateInput validates raw JSON input against the tool's required fields
+// and the types of the properties in its schema. The input must be a JSON
+// object with all required fields; a property whose schema names a single
+// JSON type must have that type or be null. Schema mismatches are reported
+// together as an *InputValidationError.
 func (t *Tool) ValidateInput(input json.RawMessage) error {
 	if input == nil {
 		return ErrNilInput
@@ -116,19 +149,56 @@ func (t *Tool) ValidateInput(input json.RawMessage) error {
 
 	var inputData map[string]interface{}
 	if err := json.Unmarshal(input, &inputData); err != nil {
-		return ErrInvalidInput
+		return &InputValidationError{Violations: []string{ErrInvalidInput.Error()}, invalidJSON: true}
 	}
 
-	// Check required fields
+	var violations []string
 	for _, req := range t.RequiredFields {
 		if _, exists := inputData[req]; !exists {
-			return errors.New("missing required field: " + req)
+			violations = append(violations, "missing required field: "+req)
 		}
 	}
-
+	properties, _ := t.InputSchema["properties"].(map[string]interface{})
+	for _, name := range slices.Sorted(maps.Keys(inputData)) {
+		property, _ := properties[name].(map[string]interface{})
+		want, _ := property["type"].(string)
+		if got := jsonType(inputData[name]); want != "" && got != "null" && !jsonTypeMatches(want, got) {
+			violations = append(violations, fmt.Sprintf("field %s: expected %s, got %s", name, want, got))
+		}
+	}
+	if len(violations) > 0 {
+		return &InputValidationError{Violations: violations}
+	}
 	return nil
 }
 
+// jsonType returns the JSON Schema type name of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonTypeMatches reports whether a value of JSON type got satisfies the
+// schema type want. Integers are numbers too.
+func jsonTypeMatches(want, got string) bool {
+	return want == got || (want == "number" && got == "integer")
+}
+
 // GetDescription returns the description of the tool.
 func (t *Tool) GetDescription() string {
 	return t.Description
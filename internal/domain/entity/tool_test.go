@@ -2,6 +2,8 @@ package entity
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -494,6 +496,43 @@ func TestTool_ValidateInput(t *testing.T) {
 	}
 }
 
+func TestTool_ValidateInput_Violations(t *testing.T) {
+	tl := &Tool{
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path":  map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer"},
+				"ratio": map[string]interface{}{"type": "number"},
+				"tags":  map[string]interface{}{"type": []interface{}{"array", "null"}},
+			},
+		},
+		RequiredFields: []string{"path", "mode"},
+	}
+
+	err := tl.ValidateInput(json.RawMessage(`{"path": 7, "limit": 1.5, "ratio": 2, "tags": "x", "extra": null}`))
+	var validationErr *InputValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("ValidateInput() error = %v, want an *InputValidationError wrapping ErrSchemaViolation", err)
+	}
+	want := []string{
+		"missing required field: mode",
+		"field limit: expected integer, got number",
+		"field path: expected string, got integer",
+	}
+	if !reflect.DeepEqual(validationErr.Violations, want) {
+		t.Errorf("Violations = %q, want %q", validationErr.Violations, want)
+	}
+
+	err = tl.ValidateInput(json.RawMessage(`[1]`))
+	if !errors.Is(err, ErrSchemaViolation) || !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("ValidateInput(non-object) error = %v, want ErrSchemaViolation and ErrInvalidInput", err)
+	}
+	if err := tl.ValidateInput(nil); errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("ValidateInput(nil) error = %v, want missing input rather than a schema violation", err)
+	}
+}
+
 func TestTool_GetDescription(t *testing.T) {
 	tests := []struct {
 		name        string
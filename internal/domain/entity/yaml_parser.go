@@ -2,39 +2,127 @@ package entity
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// frontmatterDelimiter is the line that opens and closes a YAML frontmatter block.
+const frontmatterDelimiter = "---"
+
+// utf8BOM is the UTF-8 byte order mark some editors prepend to files.
+const utf8BOM = "\ufeff"
+
+var (
+	// ErrNoFrontmatter is returned when content does not start with a --- delimiter line.
+	ErrNoFrontmatter = errors.New("invalid YAML frontmatter: missing opening ---")
+	// ErrUnterminatedFrontmatter is returned when the opening --- has no matching closing --- line.
+	ErrUnterminatedFrontmatter = errors.New("invalid YAML frontmatter: missing closing ---")
+)
+
+// Frontmatter is YAML frontmatter located in a document.
+type Frontmatter struct {
+	YAML       string // Frontmatter content without delimiters, LF line endings, trimmed
+	Body       string // Content after the closing delimiter, trimmed
+	BodyOffset int    // Byte offset in the original content where the body begins
+}
+
+// SplitFrontmatter locates YAML frontmatter delimited by lines consisting solely of ---.
+//
+// The scan is line-based: a leading UTF-8 BOM and blank lines are skipped, the first
+// remaining line must be exactly --- (trailing whitespace and CR are ignored), and the
+// frontmatter ends at the next line that is exactly ---. A --- that appears inside a
+// value or on an indented line does not terminate the block. The closing delimiter
+// may be the last line of the file with no trailing newline.
+//
+// Returns ErrNoFrontmatter or ErrUnterminatedFrontmatter if the delimiters are missing.
+func SplitFrontmatter(content string) (Frontmatter, error) {
+	pos := 0
+	if strings.HasPrefix(content, utf8BOM) {
+		pos = len(utf8BOM)
+	}
+
+	// Find the opening delimiter, skipping leading blank lines
+	for {
+		line, next := nextLine(content, pos)
+		if strings.TrimSpace(line) == "" {
+			if next == pos {
+				return Frontmatter{}, ErrNoFrontmatter
+			}
+			pos = next
+			continue
+		}
+		if !isDelimiterLine(line) {
+			return Frontmatter{}, ErrNoFrontmatter
+		}
+		pos = next
+		break
+	}
+
+	// Scan for the closing delimiter
+	yamlStart := pos
+	for pos < len(content) {
+		line, next := nextLine(content, pos)
+		if isDelimiterLine(line) {
+			yamlRaw := strings.ReplaceAll(content[yamlStart:pos], "\r\n", "\n")
+			return Frontmatter{
+				YAML:       strings.TrimSpace(yamlRaw),
+				Body:       strings.TrimSpace(content[next:]),
+				BodyOffset: next,
+			}, nil
+		}
+		pos = next
+	}
+
+	return Frontmatter{}, ErrUnterminatedFrontmatter
+}
+
+// nextLine returns the line starting at pos (without its terminator) and the offset of the following line.
+func nextLine(content string, pos int) (line string, next int) {
+	if pos >= len(content) {
+		return "", pos
+	}
+	end := strings.IndexByte(content[pos:], '\n')
+	if end == -1 {
+		return content[pos:], len(content)
+	}
+	return content[pos : pos+end], pos + end + 1
+}
+
+// isDelimiterLine reports whether a line is exactly ---, ignoring trailing whitespace and CR.
+func isDelimiterLine(line string) bool {
+	return strings.TrimRight(line, " \t\r") == frontmatterDelimiter
+}
+
 // extractFrontmatter extracts YAML frontmatter from content enclosed in --- markers.
 // Returns the frontmatter content (without --- markers) and the remaining content after frontmatter.
 // Returns an error if the frontmatter format is invalid.
 func extractFrontmatter(content string) (frontmatter, remainingContent string, err error) {
-	content = strings.TrimSpace(content)
-	if !strings.HasPrefix(content, "---") {
-		return "", "", errors.New("invalid YAML frontmatter: missing opening ---")
-	}
-
-	// Find the closing ---
-	firstLineEnd := strings.Index(content[3:], "\n---")
-	if firstLineEnd == -1 {
-		// Try to find it at the start of a line without the preceding newline
-		firstLineEnd = strings.Index(content, "\n---")
-		if firstLineEnd == -1 {
-			return "", "", errors.New("invalid YAML frontmatter: missing closing ---")
-		}
+	fm, err := SplitFrontmatter(content)
+	if err != nil {
+		return "", "", err
 	}
+	return fm.YAML, fm.Body, nil
+}
 
-	// Get the frontmatter part
-	frontmatterEnd := firstLineEnd + 4
-	frontmatterRaw := content[:frontmatterEnd]
+// ParseInto locates the frontmatter in content and unmarshals it into a new T.
+// Unlike the lenient skill and subagent parsers, unknown fields are rejected so
+// typos in frontmatter keys surface as errors. Empty frontmatter yields the zero value.
+func ParseInto[T any](content string) (T, Frontmatter, error) {
+	var out T
 
-	// Get the content after frontmatter
-	remaining := strings.TrimSpace(content[frontmatterEnd+3:])
+	fm, err := SplitFrontmatter(content)
+	if err != nil {
+		return out, Frontmatter{}, err
+	}
 
-	// Remove the opening and closing --- from frontmatter
-	frontmatterRaw = strings.TrimPrefix(frontmatterRaw, "---")
-	frontmatterRaw = strings.TrimSuffix(frontmatterRaw, "\n---")
-	frontmatterRaw = strings.TrimSpace(frontmatterRaw)
+	decoder := yaml.NewDecoder(strings.NewReader(fm.YAML))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&out); err != nil && !errors.Is(err, io.EOF) {
+		return out, fm, fmt.Errorf("failed to parse YAML frontmatter: %w", err)
+	}
 
-	return frontmatterRaw, remaining, nil
+	return out, fm, nil
 }
@@ -0,0 +1,204 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantYAML   string
+		wantBody   string
+		wantOffset int
+		wantErr    error
+	}{
+		{
+			name:       "basic",
+			content:    "---\nname: test\n---\nBody here.\n",
+			wantYAML:   "name: test",
+			wantBody:   "Body here.",
+			wantOffset: 19,
+		},
+		{
+			name:       "short frontmatter (off-by-3 regression)",
+			content:    "---\na: b\n---\nx",
+			wantYAML:   "a: b",
+			wantBody:   "x",
+			wantOffset: 13,
+		},
+		{
+			name:       "CRLF line endings",
+			content:    "---\r\nname: test\r\ndescription: d\r\n---\r\nBody\r\n",
+			wantYAML:   "name: test\ndescription: d",
+			wantBody:   "Body",
+			wantOffset: 38,
+		},
+		{
+			name:       "embedded --- inside a value",
+			content:    "---\ndescription: \"before --- after\"\nsep: ---x\n---\nBody",
+			wantYAML:   "description: \"before --- after\"\nsep: ---x",
+			wantBody:   "Body",
+			wantOffset: 50,
+		},
+		{
+			name:       "indented --- inside block scalar",
+			content:    "---\nnotes: |\n  line one\n  ---\n  line two\n---\nBody",
+			wantYAML:   "notes: |\n  line one\n  ---\n  line two",
+			wantBody:   "Body",
+			wantOffset: 45,
+		},
+		{
+			name:       "empty frontmatter",
+			content:    "---\n---\nBody",
+			wantYAML:   "",
+			wantBody:   "Body",
+			wantOffset: 8,
+		},
+		{
+			name:       "no trailing newline after closing delimiter",
+			content:    "---\nname: test\n---",
+			wantYAML:   "name: test",
+			wantBody:   "",
+			wantOffset: 18,
+		},
+		{
+			name:       "BOM-prefixed file",
+			content:    "\ufeff---\nname: test\n---\nBody",
+			wantYAML:   "name: test",
+			wantBody:   "Body",
+			wantOffset: 22,
+		},
+		{
+			name:       "leading blank lines and trailing whitespace on delimiters",
+			content:    "\n\n--- \nname: test\n---\t\nBody",
+			wantYAML:   "name: test",
+			wantBody:   "Body",
+			wantOffset: 23,
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Just markdown\n---\n",
+			wantErr: ErrNoFrontmatter,
+		},
+		{
+			name:    "empty content",
+			content: "",
+			wantErr: ErrNoFrontmatter,
+		},
+		{
+			name:    "opening delimiter with trailing text",
+			content: "---yaml\nname: test\n---\n",
+			wantErr: ErrNoFrontmatter,
+		},
+		{
+			name:    "unterminated",
+			content: "---\nname: test\nBody without closing",
+			wantErr: ErrUnterminatedFrontmatter,
+		},
+		{
+			name:    "closing delimiter must be exact",
+			content: "---\nname: test\n----\nBody",
+			wantErr: ErrUnterminatedFrontmatter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitFrontmatter(tt.content)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SplitFrontmatter() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitFrontmatter() unexpected error = %v", err)
+			}
+			if got.YAML != tt.wantYAML {
+				t.Errorf("YAML = %q, want %q", got.YAML, tt.wantYAML)
+			}
+			if got.Body != tt.wantBody {
+				t.Errorf("Body = %q, want %q", got.Body, tt.wantBody)
+			}
+			if got.BodyOffset != tt.wantOffset {
+				t.Errorf("BodyOffset = %d, want %d", got.BodyOffset, tt.wantOffset)
+			}
+			if strings.TrimSpace(tt.content[got.BodyOffset:]) != tt.wantBody {
+				t.Errorf("content[BodyOffset:] = %q, want body %q", tt.content[got.BodyOffset:], tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseInto(t *testing.T) {
+	type meta struct {
+		Name string   `yaml:"name"`
+		Tags []string `yaml:"tags"`
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		want     meta
+		wantBody string
+		wantErr  string
+	}{
+		{
+			name:     "known fields",
+			content:  "---\nname: demo\ntags: [a, b]\n---\nBody",
+			want:     meta{Name: "demo", Tags: []string{"a", "b"}},
+			wantBody: "Body",
+		},
+		{
+			name:     "CRLF",
+			content:  "---\r\nname: demo\r\n---\r\nBody",
+			want:     meta{Name: "demo"},
+			wantBody: "Body",
+		},
+		{
+			name:     "empty frontmatter yields zero value",
+			content:  "---\n---\nBody",
+			want:     meta{},
+			wantBody: "Body",
+		},
+		{
+			name:    "unknown field rejected",
+			content: "---\nname: demo\nnmae: typo\n---\n",
+			wantErr: "field nmae not found",
+		},
+		{
+			name:    "invalid YAML",
+			content: "---\nname: [unclosed\n---\n",
+			wantErr: "failed to parse YAML frontmatter",
+		},
+		{
+			name:    "missing frontmatter",
+			content: "no frontmatter",
+			wantErr: ErrNoFrontmatter.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fm, err := ParseInto[meta](tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseInto() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInto() unexpected error = %v", err)
+			}
+			if got.Name != tt.want.Name || strings.Join(got.Tags, ",") != strings.Join(tt.want.Tags, ",") {
+				t.Errorf("ParseInto() = %+v, want %+v", got, tt.want)
+			}
+			if fm.Body != tt.wantBody {
+				t.Errorf("Body = %q, want %q", fm.Body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -3,8 +3,19 @@ package port
 import (
 	"code-editing-agent/internal/domain/entity"
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
+// ErrImagesNotSupported is returned before a request with image content is
+// sent to an AI provider that cannot accept images.
+var ErrImagesNotSupported = errors.New("the AI provider does not support image input")
+
+// ErrFeatureNotSupported is returned when a feature such as extended thinking
+// is requested of a model that does not support it.
+var ErrFeatureNotSupported = errors.New("not supported by the model")
+
 // ThinkingBlockParam represents a thinking block parameter for AI providers.
 // It contains the thinking process and an optional signature for verification.
 // This type is used in the port layer to transfer thinking block data
@@ -21,6 +32,22 @@ type MessageParam struct {
 	ToolCalls      []ToolCallParam      `json:"tool_calls,omitempty"`
 	ToolResults    []ToolResultParam    `json:"tool_results,omitempty"`
 	ThinkingBlocks []ThinkingBlockParam `json:"thinking_blocks,omitempty"`
+	Blocks         []ContentBlockParam  `json:"blocks,omitempty"` // Replaces Content when set
+}
+
+// ContentBlockParam represents a text or image block of a message parameter.
+type ContentBlockParam struct {
+	Type  string            `json:"type"` // entity.ContentBlockText or entity.ContentBlockImage
+	Text  string            `json:"text,omitempty"`
+	Image *ImageSourceParam `json:"image,omitempty"`
+}
+
+// ImageSourceParam is the content of an image block: base64-encoded data, or
+// the path of a file the provider adapter reads when sending.
+type ImageSourceParam struct {
+	MediaType string `json:"media_type"`
+	Data      string `json:"data,omitempty"`
+	Path      string `json:"path,omitempty"`
 }
 
 // ToolCallParam represents a tool use block in a message parameter.
@@ -40,6 +67,9 @@ type ToolResultParam struct {
 }
 
 // ToolParam represents a tool parameter for AI providers.
+// InputSchema is the tool's complete JSON Schema object: its properties with
+// their enum, default, and examples keywords, and its required list. Providers
+// should pass it on whole so the model sees the valid values.
 type ToolParam struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
@@ -66,6 +96,14 @@ type StreamCallback func(text string) error
 // It receives chunks of thinking text as they arrive and returns an error if processing fails.
 type ThinkingCallback func(thinking string) error
 
+// RequestOptions overrides the provider's defaults for a single request, so
+// concurrent callers can use different models without switching the shared
+// default. Zero fields keep the defaults.
+type RequestOptions struct {
+	Model     string // Model to use instead of the default set with SetModel
+	MaxTokens int    // Response token limit instead of the configured one
+}
+
 // AIProvider defines the interface for external AI service integration.
 // This port represents the outbound dependency to AI services and follows
 // hexagonal architecture principles by abstracting AI provider implementations.
@@ -77,6 +115,15 @@ type AIProvider interface {
 		tools []ToolParam,
 	) (*entity.Message, []ToolCallInfo, error)
 
+	// SendMessageWithOptions is SendMessage with per-request options. Runners
+	// pass their model this way instead of switching the default with SetModel.
+	SendMessageWithOptions(
+		ctx context.Context,
+		messages []MessageParam,
+		tools []ToolParam,
+		opts RequestOptions,
+	) (*entity.Message, []ToolCallInfo, error)
+
 	// SendMessageStreaming sends a message to the AI provider with streaming support.
 	// The textCallback is called for each chunk of text as it arrives.
 	// The thinkingCallback is called for each chunk of thinking content (can be nil to skip).
@@ -95,13 +142,68 @@ type AIProvider interface {
 	// HealthCheck performs a health check on the AI provider.
 	HealthCheck(ctx context.Context) error
 
-	// SetModel sets the AI model to use for requests.
+	// SetModel sets the default model, used by requests without a model in
+	// their RequestOptions. It is the interactive session's model (:model).
 	SetModel(model string) error
 
-	// GetModel returns the currently configured AI model.
+	// GetModel returns the default model.
 	GetModel() string
 }
 
+// ImageInputSupporter is implemented by AI providers that can accept image
+// content blocks. Providers that do not implement it are assumed to be text-only.
+type ImageInputSupporter interface {
+	SupportsImageInput() bool
+}
+
+// SupportsImageInput reports whether provider accepts image content blocks.
+func SupportsImageInput(provider AIProvider) bool {
+	supporter, ok := provider.(ImageInputSupporter)
+	return ok && supporter.SupportsImageInput()
+}
+
+// ModelCapabilities describes what a model supports, so features can be
+// offered or refused per model instead of assumed.
+type ModelCapabilities struct {
+	ContextWindow    int64 // Input tokens the model accepts
+	MaxOutputTokens  int64 // Most tokens one response may contain
+	SupportsTools    bool
+	SupportsThinking bool
+	SupportsImages   bool
+}
+
+// ModelCapabilityReporter is implemented by AI providers that know what their
+// models support. Known is false for models missing from the provider's
+// registry, whose capabilities are then conservative defaults.
+type ModelCapabilityReporter interface {
+	ModelCapabilities(model string) (caps ModelCapabilities, known bool)
+}
+
+// CapabilitiesOf returns what provider reports model supports. The second
+// result is false when the provider does not report capabilities at all.
+func CapabilitiesOf(provider AIProvider, model string) (ModelCapabilities, bool) {
+	reporter, ok := provider.(ModelCapabilityReporter)
+	if !ok {
+		return ModelCapabilities{}, false
+	}
+	caps, _ := reporter.ModelCapabilities(model)
+	return caps, true
+}
+
+// RateLimitError is returned by an AIProvider when a request would have to wait
+// for a local rate limit longer than the time remaining before the run's
+// deadline. Runners can detect it with errors.As and escalate instead of stalling.
+type RateLimitError struct {
+	Wait      time.Duration // How long the request would have had to wait
+	Remaining time.Duration // Time left before the run's deadline
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited locally: waiting %s would exceed the %s remaining for this run",
+		e.Wait.Round(time.Second), e.Remaining.Round(time.Second))
+}
+
 // ConvertEntityThinkingBlockToParam converts an entity.ThinkingBlock to ThinkingBlockParam.
 // This function is used when transferring thinking blocks from the domain layer
 // to the infrastructure layer (e.g., sending to AI providers).
@@ -177,3 +279,24 @@ func ConvertParamThinkingBlocksToEntities(params []ThinkingBlockParam) []entity.
 	}
 	return blocks
 }
+
+// ConvertEntityContentBlocksToParams converts a message's content blocks to
+// []ContentBlockParam, returning nil for messages without blocks.
+func ConvertEntityContentBlocksToParams(blocks []entity.ContentBlock) []ContentBlockParam {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	params := make([]ContentBlockParam, len(blocks))
+	for i, block := range blocks {
+		params[i] = ContentBlockParam{Type: block.Type, Text: block.Text}
+		if block.Image != nil {
+			params[i].Image = &ImageSourceParam{
+				MediaType: block.Image.MediaType,
+				Data:      block.Image.Data,
+				Path:      block.Image.Path,
+			}
+		}
+	}
+	return params
+}
@@ -24,6 +24,15 @@ func (m *mockAIProvider) SendMessage(
 	return nil, nil, nil
 }
 
+func (m *mockAIProvider) SendMessageWithOptions(
+	_ context.Context,
+	_ []MessageParam,
+	_ []ToolParam,
+	_ RequestOptions,
+) (*entity.Message, []ToolCallInfo, error) {
+	return nil, nil, nil
+}
+
 func (m *mockAIProvider) SendMessageStreaming(
 	_ context.Context,
 	_ []MessageParam,
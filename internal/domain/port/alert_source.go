@@ -6,6 +6,8 @@ package port
 import (
 	"code-editing-agent/internal/domain/entity"
 	"context"
+	"errors"
+	"time"
 )
 
 // SourceType represents the type of alert source, determining how alerts are received.
@@ -60,6 +62,60 @@ type AsyncAlertHandler func(ctx context.Context, alert *entity.Alert) (investiga
 // It is the second half of the async workflow, called after AsyncAlertHandler returns the ID.
 type AlertRunner func(ctx context.Context, alert *entity.Alert, investigationID string) error
 
+// AlertSuppressor stops alerts from being investigated, by fingerprint, until
+// a point in time. Suppressed alerts are recorded rather than investigated.
+type AlertSuppressor interface {
+	// Suppress suppresses alerts with the fingerprint until the given time,
+	// replacing any earlier suppression of it.
+	Suppress(ctx context.Context, fingerprint string, until time.Time, reason string) error
+	// Unsuppress lifts the suppression of the fingerprint, if any.
+	Unsuppress(ctx context.Context, fingerprint string) error
+}
+
+// BackfillOutcome is how the investigation of one backfilled alert ended.
+type BackfillOutcome struct {
+	AlertID         string
+	InvestigationID string        // Empty unless an investigation was started
+	Status          string        // The investigation's status, or "skipped", "suppressed", "deferred", or "failed"
+	Duration        time.Duration // From when the alert was taken up, including any wait for a free slot
+	Err             error         // Why the status is "failed"
+}
+
+// AlertBackfiller investigates past alerts, such as last week's incidents, as
+// historical alerts: nobody is notified of their results, and they wait
+// behind live alerts for a slot. They still go through suppressions, the
+// daily budget, and the source's circuit.
+type AlertBackfiller interface {
+	// Backfill investigates the alerts, up to concurrency at a time and in
+	// order, calling done, one call at a time, as each one finishes. It
+	// returns once every alert is done, or ctx's error once ctx is done.
+	Backfill(ctx context.Context, alerts []*entity.Alert, concurrency int, done func(BackfillOutcome)) error
+}
+
+// ErrInvestigationNotFound is returned for an investigation ID that is not stored.
+var ErrInvestigationNotFound = errors.New("investigation not found")
+
+// ErrInvestigationFinished is returned for cancelling an investigation that is
+// no longer running.
+var ErrInvestigationFinished = errors.New("investigation already finished")
+
+// InvestigationCanceller cancels running investigations on request.
+type InvestigationCanceller interface {
+	// CancelInvestigation asks a running or queued investigation to stop,
+	// recording it as "cancelled" with the reason and who requested it.
+	// Returns an error wrapping ErrInvestigationNotFound for an unknown
+	// investigation and ErrInvestigationFinished for one no longer running.
+	CancelInvestigation(ctx context.Context, investigationID, reason, requestedBy string) error
+}
+
+// InvestigationReporter renders stored investigations as Markdown reports.
+type InvestigationReporter interface {
+	// ReportInvestigation renders the report of the stored investigation, with
+	// an AI-written executive summary when summary is true. Returns an error
+	// wrapping ErrInvestigationNotFound if the investigation is not stored.
+	ReportInvestigation(ctx context.Context, investigationID string, summary bool) (string, error)
+}
+
 // AlertSourceManager manages the lifecycle and registration of alert sources.
 // It provides a central registry for sources and dispatches alerts to handlers.
 type AlertSourceManager interface {
@@ -0,0 +1,17 @@
+package port
+
+import "context"
+
+// ConfigReloadResult describes a configuration reload: the changed keys that
+// took effect and the changed keys that need a restart and were left as they were.
+type ConfigReloadResult struct {
+	Applied []string `json:"applied"`
+	Ignored []string `json:"ignored"`
+}
+
+// ConfigReloader re-reads the configuration and applies the reloadable changes.
+// When the new configuration is invalid or cannot be applied, the old one stays
+// active and an error is returned.
+type ConfigReloader interface {
+	ReloadConfig(ctx context.Context) (ConfigReloadResult, error)
+}
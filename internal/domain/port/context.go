@@ -1,6 +1,11 @@
 package port
 
-import "context"
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"log/slog"
+	"time"
+)
 
 // sessionIDKey is the key for storing session ID in context.
 type sessionIDKey struct{}
@@ -19,6 +24,23 @@ func SessionIDFromContext(ctx context.Context) (string, bool) {
 	return sessionID, ok
 }
 
+// userApprovalKey is the key for marking a tool call the user already approved.
+type userApprovalKey struct{}
+
+// WithUserApproval marks the context of a tool call the user has already
+// approved, e.g. through UserInterface.ConfirmToolBatch, so the tool executor
+// does not ask again.
+func WithUserApproval(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userApprovalKey{}, true)
+}
+
+// UserApprovedFromContext reports whether the user already approved the tool
+// call running with ctx.
+func UserApprovedFromContext(ctx context.Context) bool {
+	approved, _ := ctx.Value(userApprovalKey{}).(bool)
+	return approved
+}
+
 // planModeKey is the key for storing plan mode state in context.
 type planModeKey struct{}
 
@@ -49,6 +71,7 @@ type customSystemPromptKey struct{}
 type CustomSystemPromptInfo struct {
 	SessionID string // Session this prompt applies to
 	Prompt    string // Custom system prompt text to use instead of default
+	Composed  bool   // Prompt was composed from layers and already includes any mode instructions
 }
 
 // WithCustomSystemPrompt adds custom system prompt info to the context.
@@ -114,3 +137,145 @@ func ThinkingModeFromContext(ctx context.Context) (ThinkingModeInfo, bool) {
 	info, ok := ctx.Value(thinkingModeKey{}).(ThinkingModeInfo)
 	return info, ok
 }
+
+// allowedToolsKey is the key for storing a tool allowlist in context.
+type allowedToolsKey struct{}
+
+// WithAllowedTools restricts the tools offered to the AI to the given names.
+// A non-nil empty slice means no tools are offered. This lets callers such as
+// the subagent runner narrow the tool set without modifying interface signatures.
+func WithAllowedTools(ctx context.Context, tools []string) context.Context {
+	return context.WithValue(ctx, allowedToolsKey{}, tools)
+}
+
+// AllowedToolsFromContext retrieves the tool allowlist from the context.
+// Returns the allowlist and a boolean indicating if it was found.
+func AllowedToolsFromContext(ctx context.Context) ([]string, bool) {
+	tools, ok := ctx.Value(allowedToolsKey{}).([]string)
+	return tools, ok
+}
+
+// blockedCommandsKey is the key for storing blocked command patterns in context.
+type blockedCommandsKey struct{}
+
+// WithBlockedCommands sets the command patterns the tool executor blocks for
+// this run in place of its own list, so a run such as an investigation keeps
+// the list it started with when the configuration is reloaded.
+func WithBlockedCommands(ctx context.Context, patterns []string) context.Context {
+	return context.WithValue(ctx, blockedCommandsKey{}, patterns)
+}
+
+// BlockedCommandsFromContext retrieves the blocked command patterns from the
+// context. Returns the patterns and a boolean indicating if they were found.
+func BlockedCommandsFromContext(ctx context.Context) ([]string, bool) {
+	patterns, ok := ctx.Value(blockedCommandsKey{}).([]string)
+	return patterns, ok
+}
+
+// headlessKey is the key for marking a run as headless in context.
+type headlessKey struct{}
+
+// WithHeadless marks the context as belonging to a run with no one at the
+// terminal, such as an alert investigation. Interactive tools are neither
+// offered nor executed in headless runs, so they cannot stall a daemon.
+func WithHeadless(ctx context.Context) context.Context {
+	return context.WithValue(ctx, headlessKey{}, true)
+}
+
+// IsHeadless reports whether the context belongs to a headless run.
+func IsHeadless(ctx context.Context) bool {
+	headless, _ := ctx.Value(headlessKey{}).(bool)
+	return headless
+}
+
+// alertGeneratorURLKey is the key for storing the investigated alert's
+// generator URL in context.
+type alertGeneratorURLKey struct{}
+
+// WithAlertGeneratorURL records the generator URL of the alert being
+// investigated, so tools such as promql_query can query the Prometheus that
+// fired it.
+func WithAlertGeneratorURL(ctx context.Context, generatorURL string) context.Context {
+	return context.WithValue(ctx, alertGeneratorURLKey{}, generatorURL)
+}
+
+// AlertGeneratorURLFromContext retrieves the investigated alert's generator
+// URL from the context. Returns "" if none was set.
+func AlertGeneratorURLFromContext(ctx context.Context) string {
+	generatorURL, _ := ctx.Value(alertGeneratorURLKey{}).(string)
+	return generatorURL
+}
+
+// verbatimOutputKey is the key for storing the verbatim subagent output flag in context.
+type verbatimOutputKey struct{}
+
+// WithVerbatimOutput marks whether a delegated subagent's output should be returned
+// verbatim, skipping summarization of long outputs.
+func WithVerbatimOutput(ctx context.Context, verbatim bool) context.Context {
+	return context.WithValue(ctx, verbatimOutputKey{}, verbatim)
+}
+
+// IsVerbatimOutput returns true if the context requests verbatim subagent output.
+func IsVerbatimOutput(ctx context.Context) bool {
+	verbatim, _ := ctx.Value(verbatimOutputKey{}).(bool)
+	return verbatim
+}
+
+// loggerKey is the key for storing a run-scoped logger in context.
+type loggerKey struct{}
+
+// WithLogger adds a logger to the context. Runners store a logger carrying their
+// correlation attributes (investigation_id, session_id, subagent_id) so that
+// components they call, such as the tool executor, log with the same attributes.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext retrieves the logger from the context, or fallback if the
+// context has none.
+func LoggerFromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return fallback
+}
+
+// runDeadlineKey is the key for storing a run's deadline in context.
+type runDeadlineKey struct{}
+
+// WithRunDeadline records the time by which the current run (e.g. an
+// investigation within its MaxDuration) should finish. Unlike a context
+// deadline it cancels nothing; components that would block, such as a rate
+// limiter, use it to fail fast instead of waiting past the deadline.
+func WithRunDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, runDeadlineKey{}, deadline)
+}
+
+// RunDeadlineFromContext retrieves the run deadline from the context.
+// Returns the deadline and a boolean indicating if it was found.
+func RunDeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(runDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
+
+// toolExecutionInfoKey is the key for storing a tool execution report in context.
+type toolExecutionInfoKey struct{}
+
+// ToolExecutionInfo reports how a ToolExecutor served a call, beyond its result.
+type ToolExecutionInfo struct {
+	Cached     bool                // The result came from the tool result cache
+	Provenance []entity.Provenance // The file lines the result quotes, if the tool reports them
+}
+
+// WithToolExecutionInfo adds a report for the ToolExecutor to fill in during
+// ExecuteTool. Callers read it after ExecuteTool returns.
+func WithToolExecutionInfo(ctx context.Context, info *ToolExecutionInfo) context.Context {
+	return context.WithValue(ctx, toolExecutionInfoKey{}, info)
+}
+
+// ToolExecutionInfoFromContext retrieves the tool execution report from the context.
+// Returns the report and a boolean indicating if it was found.
+func ToolExecutionInfoFromContext(ctx context.Context) (*ToolExecutionInfo, bool) {
+	info, ok := ctx.Value(toolExecutionInfoKey{}).(*ToolExecutionInfo)
+	return info, ok && info != nil
+}
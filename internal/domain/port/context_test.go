@@ -964,3 +964,30 @@ func TestThinkingMode_BudgetTokensEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestIsVerbatimOutput verifies the verbatim output flag round-trips through context
+// and defaults to false when unset.
+func TestIsVerbatimOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{name: "unset", ctx: context.Background(), want: false},
+		{name: "set true", ctx: WithVerbatimOutput(context.Background(), true), want: true},
+		{name: "set false", ctx: WithVerbatimOutput(context.Background(), false), want: false},
+		{
+			name: "overwritten",
+			ctx:  WithVerbatimOutput(WithVerbatimOutput(context.Background(), true), false),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsVerbatimOutput(tt.ctx); got != tt.want {
+				t.Errorf("IsVerbatimOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package port
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"time"
+)
+
+// ConversationStore persists the message history of conversation sessions so it
+// survives restarts and reflects rollbacks.
+type ConversationStore interface {
+	// SaveConversation replaces the stored history of a session with messages.
+	SaveConversation(ctx context.Context, sessionID string, messages []entity.Message) error
+
+	// LoadConversation returns the stored history of a session.
+	LoadConversation(ctx context.Context, sessionID string) ([]entity.Message, error)
+}
+
+// SessionMetadata describes a stored session, so sessions can be told apart
+// without loading their histories.
+type SessionMetadata struct {
+	SessionID    string
+	Title        string    // Short title; empty until generated or set
+	CreatedAt    time.Time // When the session started
+	UpdatedAt    time.Time // When its last message was added
+	Model        string    // Model serving the session when it was last saved
+	MessageCount int
+	InputTokens  int64  // Tokens billed for the session's requests
+	OutputTokens int64  // Tokens billed for the session's responses
+	Workspace    string // Directory the session worked in
+}
+
+// SessionCatalog is implemented by conversation stores that also keep each
+// session's metadata. Callers check for it with a type assertion.
+type SessionCatalog interface {
+	// SaveSessionMetadata replaces the stored metadata of metadata.SessionID.
+	SaveSessionMetadata(ctx context.Context, metadata SessionMetadata) error
+
+	// LoadSessionMetadata returns the stored metadata of a session.
+	LoadSessionMetadata(ctx context.Context, sessionID string) (SessionMetadata, error)
+
+	// ListSessions returns the metadata of every stored session, most recently
+	// updated first.
+	ListSessions(ctx context.Context) ([]SessionMetadata, error)
+}
@@ -0,0 +1,87 @@
+package port
+
+import "time"
+
+// DaemonStatus is a snapshot of what the investigation daemon is doing: the
+// investigations running and waiting to run, the state of each alert source's
+// circuit, how much of the concurrency limit is in use, in total and by
+// source, and whether investigations are held for an AI provider outage.
+type DaemonStatus struct {
+	GeneratedAt time.Time                   `json:"generated_at"`
+	Active      []ActiveInvestigationStatus `json:"active"`   // Oldest first
+	Queued      []QueuedAlertStatus         `json:"queued"`   // Live before historical, highest priority first, then oldest
+	Circuits    []CircuitStatus             `json:"circuits"` // By source name
+	Workers     WorkerPoolStatus            `json:"workers"`
+	Sources     []SourceSlotStatus          `json:"sources,omitempty"`  // By source name; set with per-source limits
+	Degraded    *DegradedStatus             `json:"degraded,omitempty"` // Set while the AI provider is down
+}
+
+// DegradedStatus describes an AI provider outage during which investigations
+// are held instead of run.
+type DegradedStatus struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason"` // The failing health check's error
+	Held   int       `json:"held"`   // Investigations waiting for the provider
+}
+
+// ActiveInvestigationStatus describes a running investigation.
+type ActiveInvestigationStatus struct {
+	InvestigationID string        `json:"investigation_id"`
+	AlertID         string        `json:"alert_id"`
+	AlertTitle      string        `json:"alert_title"`
+	Severity        string        `json:"severity"`
+	Source          string        `json:"source"`
+	StartedAt       time.Time     `json:"started_at"`
+	Elapsed         time.Duration `json:"elapsed"`                // In nanoseconds
+	Actions         int           `json:"actions"`                // Tool calls finished so far
+	CurrentTool     string        `json:"current_tool,omitempty"` // Tool running now, if any
+}
+
+// QueuedAlertStatus describes an investigation that was started for an alert
+// but has not begun running.
+type QueuedAlertStatus struct {
+	InvestigationID string        `json:"investigation_id"`
+	AlertID         string        `json:"alert_id"`
+	AlertTitle      string        `json:"alert_title"`
+	Priority        string        `json:"priority"` // The alert's severity
+	Source          string        `json:"source"`
+	QueuedAt        time.Time     `json:"queued_at"`
+	Waiting         time.Duration `json:"waiting"`              // In nanoseconds
+	Historical      bool          `json:"historical,omitempty"` // Backfilled; queued behind live alerts
+}
+
+// CircuitStatus describes the circuit of one alert source.
+type CircuitStatus struct {
+	Source   string    `json:"source"`
+	State    string    `json:"state"`              // "closed", "open", or "half-open"
+	Recent   int       `json:"recent"`             // Investigations started within the window
+	OpenedAt time.Time `json:"opened_at,omitzero"` // Set unless closed
+	ProbeAt  time.Time `json:"probe_at,omitzero"`  // When an open circuit half-opens
+	ProbeID  string    `json:"probe_id,omitempty"` // Investigation probing a half-open circuit
+}
+
+// WorkerPoolStatus describes how much of the investigation concurrency limit
+// is in use. Queued investigations hold a slot too.
+type WorkerPoolStatus struct {
+	Capacity    int     `json:"capacity"` // Maximum concurrent investigations; 0 if unlimited
+	Running     int     `json:"running"`
+	Queued      int     `json:"queued"`
+	Utilization float64 `json:"utilization"` // (Running+Queued)/Capacity; 0 if unlimited
+}
+
+// SourceSlotStatus describes the investigation slots one alert source holds
+// under per-source concurrency limits.
+type SourceSlotStatus struct {
+	Source      string  `json:"source"`
+	Running     int     `json:"running"`     // Slots the source holds
+	Waiting     int     `json:"waiting"`     // Investigations waiting for a slot
+	Limit       int     `json:"limit"`       // Slots it may hold while other sources wait; 0 if unlimited
+	Utilization float64 `json:"utilization"` // Running/WorkerPoolStatus.Capacity; 0 if unlimited
+}
+
+// StatusReporter reports what the investigation daemon is doing.
+type StatusReporter interface {
+	// GetStatus returns a snapshot of the daemon's investigations, queue,
+	// circuits, and concurrency.
+	GetStatus() DaemonStatus
+}
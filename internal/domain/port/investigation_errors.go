@@ -0,0 +1,24 @@
+package port
+
+import "errors"
+
+// Classes of investigation failure. The use cases return them wrapped with
+// context, so callers such as the webhook server and the CLI tell failures
+// apart with errors.Is instead of matching messages.
+var (
+	// ErrInvalidAlert is returned for an alert that cannot be investigated,
+	// such as a nil alert or one without an ID.
+	ErrInvalidAlert = errors.New("invalid alert")
+	// ErrConversationStart is returned when no conversation session could be
+	// started for a run.
+	ErrConversationStart = errors.New("failed to start conversation")
+	// ErrPromptBuild is returned when the prompt of a run could not be built.
+	ErrPromptBuild = errors.New("failed to build prompt")
+	// ErrToolBlocked is returned for a tool call refused by the allowed
+	// tools, the command allowlist, or the safety policy.
+	ErrToolBlocked = errors.New("tool call blocked")
+	// ErrActionBudgetExceeded is returned when a run has used up its actions.
+	ErrActionBudgetExceeded = errors.New("action budget exceeded")
+	// ErrProviderUnavailable is returned when the AI provider did not answer.
+	ErrProviderUnavailable = errors.New("AI provider unavailable")
+)
@@ -0,0 +1,40 @@
+package port
+
+import "time"
+
+// Token directions for MetricsRecorder.RecordTokens.
+const (
+	// TokenDirectionInput counts tokens sent to the AI provider.
+	TokenDirectionInput = "input"
+	// TokenDirectionOutput counts tokens generated by the AI provider.
+	TokenDirectionOutput = "output"
+)
+
+// MetricsRecorder records operational metrics so the agent itself can be
+// monitored and alerted on.
+//
+// Label values passed to a recorder must come from small, fixed sets such as
+// tool names, investigation statuses and severities. Never pass values derived
+// from free-form input like alert titles, which would make the number of metric
+// series unbounded.
+//
+// Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	// RecordInvestigation records a finished investigation with its final status
+	// (e.g. "completed", "failed", "escalated") and alert severity.
+	RecordInvestigation(status, severity string, duration time.Duration)
+
+	// RecordToolExecution records a tool execution and whether it returned an error.
+	RecordToolExecution(tool string, failed bool, duration time.Duration)
+
+	// RecordToolOutput records the bytes of output a tool execution returned.
+	RecordToolOutput(tool string, bytes int)
+
+	// RecordAIRequest records a request to an AI provider. code is the HTTP status
+	// code of the response, or "error" when no response was received.
+	RecordAIRequest(provider, model, code string, duration time.Duration)
+
+	// RecordTokens records tokens exchanged with an AI provider in the given
+	// direction (TokenDirectionInput or TokenDirectionOutput).
+	RecordTokens(direction string, count int64)
+}
@@ -0,0 +1,111 @@
+package port
+
+import "time"
+
+// SubagentEventType identifies a subagent lifecycle event.
+type SubagentEventType string
+
+const (
+	// SubagentEventStarted is emitted once the subagent session is set up.
+	SubagentEventStarted SubagentEventType = "started"
+	// SubagentEventText is emitted with the assistant text of each subagent turn.
+	SubagentEventText SubagentEventType = "text"
+	// SubagentEventToolExecuted is emitted after each tool the subagent executes.
+	SubagentEventToolExecuted SubagentEventType = "tool_executed"
+	// SubagentEventCompleted is emitted when the subagent finishes successfully.
+	SubagentEventCompleted SubagentEventType = "completed"
+	// SubagentEventFailed is emitted when the subagent stops with an error.
+	SubagentEventFailed SubagentEventType = "failed"
+	// SubagentEventTimedOut is emitted when the subagent exceeds its max duration.
+	SubagentEventTimedOut SubagentEventType = "timed_out"
+)
+
+// SubagentEvent describes a single step of subagent progress.
+type SubagentEvent struct {
+	Type       SubagentEventType
+	SubagentID string
+	AgentName  string
+	Text       string        // Assistant text (text events) or error message (failed/timed out events)
+	ToolName   string        // Executed tool (tool events)
+	IsError    bool          // Whether the tool returned an error (tool events)
+	Duration   time.Duration // Tool duration (tool events) or total run duration (terminal events)
+	Actions    int           // Actions taken so far
+}
+
+// ProgressSink receives subagent lifecycle events so callers can surface progress
+// while a delegated task runs.
+//
+// Implementations must be safe for concurrent use: subagents may run on goroutines
+// other than the one that owns the terminal.
+type ProgressSink interface {
+	// OnSubagentEvent is called for each lifecycle event in the order it occurs.
+	OnSubagentEvent(event SubagentEvent)
+}
+
+// InvestigationEventType identifies an alert investigation progress event.
+type InvestigationEventType string
+
+const (
+	// InvestigationEventIterationStarted is emitted before each request to the AI.
+	InvestigationEventIterationStarted InvestigationEventType = "iteration_started"
+	// InvestigationEventToolExecuted is emitted after each tool the investigation executes.
+	InvestigationEventToolExecuted InvestigationEventType = "tool_executed"
+	// InvestigationEventFindingAdded is emitted for each finding in the final result.
+	InvestigationEventFindingAdded InvestigationEventType = "finding_added"
+	// InvestigationEventCompleted is emitted when the investigation finishes.
+	InvestigationEventCompleted InvestigationEventType = "completed"
+	// InvestigationEventEscalated is emitted when the investigation is handed to a human.
+	InvestigationEventEscalated InvestigationEventType = "escalated"
+	// InvestigationEventFailed is emitted when the investigation stops with an error.
+	InvestigationEventFailed InvestigationEventType = "failed"
+)
+
+// Terminal reports whether the event ends the investigation; no events follow it.
+func (t InvestigationEventType) Terminal() bool {
+	switch t {
+	case InvestigationEventCompleted, InvestigationEventEscalated, InvestigationEventFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// InvestigationEvent describes a single step of alert investigation progress.
+// Sequence and Time are assigned by the sink that records the event.
+type InvestigationEvent struct {
+	Sequence        int                    `json:"sequence"` // 1-based position within the investigation
+	Type            InvestigationEventType `json:"type"`
+	InvestigationID string                 `json:"investigation_id"`
+	Time            time.Time              `json:"time"`
+	Iteration       int                    `json:"iteration,omitempty"` // AI request number (iteration events)
+	ToolName        string                 `json:"tool_name,omitempty"` // Executed tool (tool events)
+	Summary         string                 `json:"summary,omitempty"`   // First line of the tool result (tool events)
+	Input           string                 `json:"input,omitempty"`     // Tool input as JSON, truncated (tool events)
+	Output          string                 `json:"output,omitempty"`    // Tool result, truncated (tool events)
+	IsError         bool                   `json:"is_error,omitempty"`  // Whether the tool returned an error (tool events)
+	Finding         string                 `json:"finding,omitempty"`   // Finding text (finding events)
+	Reason          string                 `json:"reason,omitempty"`    // Escalation reason or error (terminal events)
+	Duration        time.Duration          `json:"duration,omitempty"`  // Tool duration or total run duration, in nanoseconds
+	Actions         int                    `json:"actions"`             // Actions taken so far
+}
+
+// InvestigationProgressSink receives alert investigation events so callers can
+// surface progress while an investigation runs.
+//
+// Implementations must be safe for concurrent use: investigations run on their
+// own goroutines, and must not block, since they are called from the
+// investigation loop.
+type InvestigationProgressSink interface {
+	// OnInvestigationEvent is called for each event in the order it occurs.
+	OnInvestigationEvent(event InvestigationEvent)
+}
+
+// InvestigationProgressSinks passes each investigation event to every sink in order.
+type InvestigationProgressSinks []InvestigationProgressSink
+
+// OnInvestigationEvent implements InvestigationProgressSink.
+func (s InvestigationProgressSinks) OnInvestigationEvent(event InvestigationEvent) {
+	for _, sink := range s {
+		sink.OnInvestigationEvent(event)
+	}
+}
@@ -32,8 +32,8 @@ type SkillDiscoveryResult struct {
 // Skills follow the agentskills.io specification where skills are directories
 // with SKILL.md files containing YAML frontmatter.
 type SkillManager interface {
-	// DiscoverSkills scans the skills directory for available skills.
-	// Skills are discovered from ./skills directory relative to working directory.
+	// DiscoverSkills scans the skills directories for available skills.
+	// Skills are discovered from project-local and user-global skill directories.
 	// Returns information about all discovered skills including metadata.
 	DiscoverSkills(ctx context.Context) (*SkillDiscoveryResult, error)
 
@@ -62,4 +62,11 @@ type SkillManager interface {
 	// ValidateSkills checks all available skills for validity.
 	// Returns validation errors for any skills that fail validation.
 	ValidateSkills(ctx context.Context) (map[string]error, error)
+
+	// ListSkills returns every skill found by the most recent discovery, sorted by name.
+	// Unlike DiscoverSkills it does not rescan the file system.
+	ListSkills() []SkillInfo
+
+	// GetSkillContent returns the body of a skill's SKILL.md (the content after the frontmatter).
+	GetSkillContent(skillName string) (string, error)
 }
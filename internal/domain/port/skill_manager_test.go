@@ -98,3 +98,11 @@ func (m *mockSkillManager) GetSkillByName(_ context.Context, _ string) (*SkillIn
 func (m *mockSkillManager) ValidateSkills(_ context.Context) (map[string]error, error) {
 	return map[string]error{}, nil
 }
+
+func (m *mockSkillManager) ListSkills() []SkillInfo {
+	return []SkillInfo{}
+}
+
+func (m *mockSkillManager) GetSkillContent(_ string) (string, error) {
+	return "", nil
+}
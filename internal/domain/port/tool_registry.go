@@ -0,0 +1,44 @@
+package port
+
+import "errors"
+
+// Sentinel errors for tool registration.
+var (
+	// ErrToolAlreadyRegistered is returned when registering a tool under a
+	// name that is already registered without asking to replace it.
+	ErrToolAlreadyRegistered = errors.New("tool already registered")
+	// ErrToolNotRegistered is returned when unregistering a name no tool has.
+	ErrToolNotRegistered = errors.New("tool not registered")
+)
+
+// ToolRegistryEventType identifies what happened to a tool in a ToolRegistryEvent.
+type ToolRegistryEventType string
+
+// Tool registry event types.
+const (
+	// ToolRegistered is emitted when a tool is added under a new name.
+	ToolRegistered ToolRegistryEventType = "registered"
+	// ToolReplaced is emitted when a registered tool's definition changes.
+	ToolReplaced ToolRegistryEventType = "replaced"
+	// ToolUnregistered is emitted when a tool is removed.
+	ToolUnregistered ToolRegistryEventType = "unregistered"
+)
+
+// ToolRegistryEvent describes one change to the tools an executor offers.
+type ToolRegistryEvent struct {
+	Type ToolRegistryEventType
+	Name string
+}
+
+// ToolRegistryObserver is notified of each change to an executor's tools, after
+// the change took effect. It must not block; it may call back into the executor.
+type ToolRegistryObserver interface {
+	ToolRegistryChanged(event ToolRegistryEvent)
+}
+
+// ToolRegistry is implemented by tool executors whose tools can change while
+// the agent runs, such as when MCP servers connect or :tools reload re-runs
+// conditional registration.
+type ToolRegistry interface {
+	AddToolRegistryObserver(observer ToolRegistryObserver)
+}
@@ -0,0 +1,94 @@
+package port
+
+import (
+	"context"
+)
+
+// Span names used across the agent, so traces read the same whichever layer
+// started the span.
+const (
+	// SpanInvestigation is the root span of an alert investigation.
+	SpanInvestigation = "investigation"
+	// SpanAIRequest covers one request to an AI provider (one AI turn).
+	SpanAIRequest = "ai.request"
+	// SpanToolExecution covers one tool execution.
+	SpanToolExecution = "tool.execute"
+	// SpanSubagent covers one subagent run, nested under the tool call that spawned it.
+	SpanSubagent = "subagent"
+)
+
+// Tracer starts spans. The tracing adapter implements it over OpenTelemetry.
+//
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span named name as a child of the span in ctx, and returns
+	// a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced operation, started by a Tracer.
+type Span interface {
+	// IsRecording reports whether the span keeps what is set on it. Callers
+	// should build attributes only when it does.
+	IsRecording() bool
+
+	// SetAttributes records attributes on the span.
+	SetAttributes(attrs ...SpanAttribute)
+
+	// RecordError records err on the span and marks the span as failed.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// SpanAttribute is a key and value recorded on a span. Value is a string,
+// int64 or bool; build attributes with StringAttribute, IntAttribute and
+// BoolAttribute.
+type SpanAttribute struct {
+	Key   string
+	Value any
+}
+
+// StringAttribute returns a span attribute with a string value.
+func StringAttribute(key, value string) SpanAttribute {
+	return SpanAttribute{Key: key, Value: value}
+}
+
+// IntAttribute returns a span attribute with an integer value.
+func IntAttribute[T int | int64](key string, value T) SpanAttribute {
+	return SpanAttribute{Key: key, Value: int64(value)}
+}
+
+// BoolAttribute returns a span attribute with a boolean value.
+func BoolAttribute(key string, value bool) SpanAttribute {
+	return SpanAttribute{Key: key, Value: value}
+}
+
+// StartSpan starts a span named name as a child of the span in ctx.
+//
+// A nil tracer means tracing is disabled: ctx is returned unchanged along with
+// a non-recording span, without allocating. Callers should set attributes only
+// when span.IsRecording() so the disabled path stays allocation-free.
+func StartSpan(ctx context.Context, tracer Tracer, name string) (context.Context, Span) {
+	if tracer == nil {
+		return ctx, disabledSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+// EndSpan ends span, marking it as failed with err when err is non-nil.
+func EndSpan(span Span, err error) {
+	if err != nil && span.IsRecording() {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// disabledSpan is the span StartSpan returns when tracing is disabled.
+type disabledSpan struct{}
+
+func (disabledSpan) IsRecording() bool              { return false }
+func (disabledSpan) SetAttributes(...SpanAttribute) {}
+func (disabledSpan) RecordError(error)              {}
+func (disabledSpan) End()                           {}
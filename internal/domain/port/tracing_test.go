@@ -0,0 +1,36 @@
+package port
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStartSpan_NilTracerDoesNotAllocate(t *testing.T) {
+	ctx := context.Background()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		spanCtx, span := StartSpan(ctx, nil, SpanToolExecution)
+		if span.IsRecording() {
+			span.SetAttributes(StringAttribute("tool", "bash"))
+		}
+		EndSpan(span, nil)
+		_ = spanCtx
+	})
+
+	if allocs != 0 {
+		t.Errorf("StartSpan with tracing disabled allocated %v times, want 0", allocs)
+	}
+}
+
+func TestStartSpan_NilTracerLeavesContextUnchanged(t *testing.T) {
+	ctx := WithSessionID(context.Background(), "session")
+
+	spanCtx, span := StartSpan(ctx, nil, SpanInvestigation)
+
+	if spanCtx != ctx {
+		t.Error("StartSpan with a nil tracer should return the context unchanged")
+	}
+	if span.IsRecording() {
+		t.Error("StartSpan with a nil tracer should return a non-recording span")
+	}
+}
@@ -0,0 +1,14 @@
+package port
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+)
+
+// TranscriptStore persists the full conversation of a subagent run so it can be
+// inspected after the parent has only received a summary.
+type TranscriptStore interface {
+	// SaveTranscript stores the messages of a subagent session and returns a
+	// reference (such as a file path) a human can use to locate the transcript.
+	SaveTranscript(ctx context.Context, subagentID string, messages []entity.Message) (string, error)
+}
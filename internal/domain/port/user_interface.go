@@ -84,4 +84,79 @@ type UserInterface interface {
 	//   - description: AI's rationale for running the command; displayed before the command when non-empty
 	// Returns true if the user confirms execution, false otherwise.
 	ConfirmBashCommand(command string, isDangerous bool, reason string, description string) bool
+
+	// ConfirmToolBatch prompts the user once for several tool calls from the same
+	// turn, such as a turn's bash commands, instead of once per call. Single calls
+	// go through ConfirmBashCommand. Returns whether each call is approved, in the
+	// order given; implementations that cannot ask the user deny them all.
+	ConfirmToolBatch(calls []ToolConfirmation) []bool
+
+	// ConfirmFileEdit prompts the user to confirm a file edit before it is applied.
+	// Parameters:
+	//   - path: The file to be changed
+	//   - unifiedDiff: The proposed change as a unified diff (old content vs new content)
+	// Returns true if the user confirms the edit, false otherwise.
+	ConfirmFileEdit(path string, unifiedDiff string) bool
+}
+
+// ToolConfirmation describes a tool call awaiting the user's approval in
+// UserInterface.ConfirmToolBatch.
+type ToolConfirmation struct {
+	ToolName    string // The tool to run, e.g. "bash"
+	Summary     string // A compact description of the call, such as the command
+	Description string // AI's rationale for the call; may be empty
+	Dangerous   bool   // Whether the call matches dangerous patterns or the AI marked it dangerous
+	Reason      string // Why the call is dangerous; empty otherwise
+}
+
+// ActivityIndicator is implemented by user interfaces that can show that work is
+// in flight, such as a spinner while waiting on the AI provider or a long tool.
+// Callers should type-assert a UserInterface to ActivityIndicator and skip the
+// indicator when it is not supported.
+//
+// Implementations must be safe for concurrent use and must clear the indicator
+// before any other output is written.
+type ActivityIndicator interface {
+	// StartActivity shows the indicator with the given label (e.g. "Thinking"),
+	// replacing any indicator that is already shown.
+	StartActivity(label string)
+
+	// StopActivity hides the indicator. It is a no-op when none is shown.
+	StopActivity()
+}
+
+// ReplyRenderer is an optional UserInterface capability for showing an
+// assistant reply formatted, such as Markdown rendered for a terminal, which
+// needs the whole reply. Callers should type-assert a UserInterface to
+// ReplyRenderer and, while RendersReplies is true, collect the reply text
+// instead of streaming it and pass it to DisplayReply once it is complete.
+type ReplyRenderer interface {
+	// RendersReplies reports whether replies are currently rendered.
+	RendersReplies() bool
+
+	// DisplayReply displays a complete reply, rendered. It is called between
+	// BeginStreamingResponse and EndStreamingResponse, and the reply counts as
+	// the streamed response.
+	DisplayReply(text string) error
+}
+
+// CachedToolResultDisplay is an optional UserInterface capability for marking
+// tool results served from the tool result cache. Callers should type-assert a
+// UserInterface to CachedToolResultDisplay and fall back to DisplayToolResult
+// when it is not supported.
+type CachedToolResultDisplay interface {
+	// DisplayCachedToolResult displays a cached tool result like DisplayToolResult,
+	// marked as cached.
+	DisplayCachedToolResult(toolName string, input string, result string) error
+}
+
+// ChoicePrompter is an optional UserInterface capability for asking the user
+// a question on the AI's behalf, such as which of two files to edit. Callers
+// should type-assert a UserInterface to ChoicePrompter and not offer the
+// question to the AI when it is not supported.
+type ChoicePrompter interface {
+	// PromptChoice shows question and returns the user's answer. With choices,
+	// they are shown numbered and the answer is one of them; without, the
+	// answer is free text. It returns ctx.Err() if ctx ends while waiting.
+	PromptChoice(ctx context.Context, question string, choices []string) (string, error)
 }
@@ -75,6 +75,14 @@ func (m *mockUserInterface) ConfirmBashCommand(
 	return false
 }
 
+func (m *mockUserInterface) ConfirmToolBatch(calls []ToolConfirmation) []bool {
+	return make([]bool, len(calls))
+}
+
+func (m *mockUserInterface) ConfirmFileEdit(_ string, _ string) bool {
+	return false
+}
+
 // TestUserInterfaceGetUserInput_Exists validates GetUserInput method exists.
 func TestUserInterfaceGetUserInput_Exists(_ *testing.T) {
 	var ui UserInterface = (*mockUserInterface)(nil)
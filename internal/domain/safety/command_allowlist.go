@@ -0,0 +1,217 @@
+package safety
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrCommandNotAllowed is returned when a command is refused by a CommandAllowlist.
+var ErrCommandNotAllowed = errors.New("command not in allowlist")
+
+// CommandAllowlist permits only commands whose every segment matches one of a
+// set of regular expressions. It is the inverse of the blocked command
+// patterns: meant for production hosts, where anything not known to be safe
+// is refused.
+//
+// A command is split into segments at unquoted pipes, &&, ||, ; and &, and
+// each segment must match a pattern on its own, so "ps aux | grep nginx" needs
+// both ps and grep to be allowed. Constructs that run commands a segment check
+// cannot see are refused outright: command substitution ($(...) and
+// backticks), subshells and process substitution. So are redirections other
+// than to /dev/null or another file descriptor, which would let an allowed
+// read-only command write files.
+type CommandAllowlist struct {
+	patterns []string
+	regexps  []*regexp.Regexp
+}
+
+// NewCommandAllowlist compiles patterns into a CommandAllowlist. Each pattern
+// is anchored at the start of a segment, so "ps\b" and "^ps\b" are the same.
+// Returns nil if patterns is empty, which allows every command.
+func NewCommandAllowlist(patterns []string) (*CommandAllowlist, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	a := &CommandAllowlist{
+		patterns: append([]string(nil), patterns...),
+		regexps:  make([]*regexp.Regexp, 0, len(patterns)),
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(`^(?:` + pattern + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed command pattern %q: %w", pattern, err)
+		}
+		a.regexps = append(a.regexps, re)
+	}
+	return a, nil
+}
+
+// Patterns returns the patterns the allowlist was created with.
+func (a *CommandAllowlist) Patterns() []string {
+	if a == nil {
+		return nil
+	}
+	return a.patterns
+}
+
+// Check returns an error wrapping ErrCommandNotAllowed if cmd is refused. The
+// error names the offending segment or construct and lists the allowed
+// patterns, so a model can retry with a command that fits. A nil allowlist
+// allows every command.
+func (a *CommandAllowlist) Check(cmd string) error {
+	if a == nil {
+		return nil
+	}
+	segments, err := splitCommandSegments(cmd)
+	if err != nil {
+		return a.refuse(err.Error())
+	}
+	if len(segments) == 0 {
+		return a.refuse("empty command")
+	}
+	for _, segment := range segments {
+		if !a.matches(segment) {
+			return a.refuse(fmt.Sprintf("%q does not match any allowed pattern", segment))
+		}
+	}
+	return nil
+}
+
+// matches reports whether segment matches one of the patterns.
+func (a *CommandAllowlist) matches(segment string) bool {
+	for _, re := range a.regexps {
+		if re.MatchString(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// refuse builds the error for a refused command.
+func (a *CommandAllowlist) refuse(reason string) error {
+	return fmt.Errorf("%w: %s (allowed patterns: %s)", ErrCommandNotAllowed, reason, strings.Join(a.patterns, ", "))
+}
+
+// splitCommandSegments splits a shell command into the simple commands joined
+// by unquoted control operators, trimmed and with empty ones dropped. Quotes
+// and escapes are kept in the segments. It fails on the constructs a
+// CommandAllowlist refuses outright and on unterminated quotes.
+func splitCommandSegments(cmd string) ([]string, error) {
+	var (
+		segments []string
+		current  strings.Builder
+		quote    rune // The open quote character, or 0
+	)
+	flush := func() {
+		if segment := strings.TrimSpace(current.String()); segment != "" {
+			segments = append(segments, segment)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		if quote == '\'' {
+			current.WriteRune(r)
+			if r == '\'' {
+				quote = 0
+			}
+			continue
+		}
+
+		// Substitution runs a command inside double quotes as well
+		switch {
+		case r == '\\' && next != 0:
+			current.WriteRune(r)
+			current.WriteRune(next)
+			i++
+			continue
+		case r == '`':
+			return nil, errors.New("command substitution with backticks is not allowed")
+		case r == '$' && next == '(':
+			return nil, errors.New("command substitution with $(...) is not allowed")
+		}
+
+		if quote == '"' {
+			current.WriteRune(r)
+			if r == '"' {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+			current.WriteRune(r)
+		case '(', ')':
+			return nil, errors.New("subshells and process substitution are not allowed")
+		case ';', '\n':
+			flush()
+		case '|':
+			// |, || and |& all end the segment
+			if next == '|' || next == '&' {
+				i++
+			}
+			flush()
+		case '&':
+			if next == '>' {
+				// &> redirects both streams; checked as a redirection
+				current.WriteRune(r)
+				continue
+			}
+			if next == '&' {
+				i++
+			}
+			flush()
+		case '>':
+			end, err := checkRedirection(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(string(runes[i:end]))
+			i = end - 1
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	flush()
+	return segments, nil
+}
+
+// checkRedirection checks the output redirection starting at runes[start],
+// which is '>', and returns the index just past its target. Only redirections
+// to /dev/null or to another file descriptor (as in 2>&1) are allowed.
+func checkRedirection(runes []rune, start int) (int, error) {
+	i := start + 1
+	if i < len(runes) && (runes[i] == '>' || runes[i] == '|') {
+		i++
+	}
+	for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t') {
+		i++
+	}
+	rest := string(runes[i:])
+	switch {
+	case len(rest) >= 2 && rest[0] == '&' && (rest[1] >= '0' && rest[1] <= '9' || rest[1] == '-'):
+		return i + 2, nil
+	case strings.HasPrefix(rest, "/dev/null") && (len(rest) == len("/dev/null") || isTargetEnd(rest[len("/dev/null")])):
+		return i + len([]rune("/dev/null")), nil
+	}
+	return 0, errors.New("output redirection to files is not allowed")
+}
+
+// isTargetEnd reports whether c ends a redirection target.
+func isTargetEnd(c byte) bool {
+	return strings.IndexByte(" \t\n;|&", c) >= 0
+}
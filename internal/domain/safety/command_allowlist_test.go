@@ -0,0 +1,125 @@
+package safety
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommandAllowlist_Check(t *testing.T) {
+	allowlist, err := NewCommandAllowlist([]string{
+		`^(ps|top|df|du|free|journalctl|systemctl status)\b`,
+		`grep\b`,
+		`(head|tail|wc)\b`,
+	})
+	if err != nil {
+		t.Fatalf("NewCommandAllowlist() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cmd     string
+		allowed bool
+	}{
+		{name: "single allowed command", cmd: "df -h", allowed: true},
+		{name: "pattern without caret is anchored", cmd: "grep -r error /var/log", allowed: true},
+		{name: "multi-word pattern", cmd: "systemctl status nginx", allowed: true},
+		{name: "surrounding whitespace", cmd: "  free -m\n", allowed: true},
+		{name: "pipe of allowed commands", cmd: "ps aux | grep nginx | head -5", allowed: true},
+		{name: "and chain of allowed commands", cmd: "df -h && du -sh /var/log", allowed: true},
+		{name: "or chain and semicolons", cmd: "journalctl -u app || free -m; top -bn1", allowed: true},
+		{name: "pipe of both streams", cmd: "journalctl -u app |& tail -20", allowed: true},
+		{name: "newline separated commands", cmd: "df -h\nfree -m", allowed: true},
+		{name: "operators inside quotes", cmd: `grep "a|b && c; d" /var/log/app.log`, allowed: true},
+		{name: "parentheses inside quotes", cmd: `grep 'f(x)' main.go`, allowed: true},
+		{name: "escaped pipe", cmd: `grep a\|b app.log`, allowed: true},
+		{name: "stderr to stdout", cmd: "journalctl -u app 2>&1 | tail -5", allowed: true},
+		{name: "redirect to /dev/null", cmd: "grep -q error app.log >/dev/null 2>&1", allowed: true},
+		{name: "both streams to /dev/null", cmd: "du -sh /var &> /dev/null", allowed: true},
+		{name: "variable expansion", cmd: "du -sh $HOME", allowed: true},
+
+		{name: "command not in list", cmd: "rm -rf /tmp/x", allowed: false},
+		{name: "prefix without word boundary", cmd: "psql -c 'drop table users'", allowed: false},
+		{name: "allowed word later in command", cmd: "curl http://example.com/ps", allowed: false},
+		{name: "disallowed command after pipe", cmd: "ps aux | sh", allowed: false},
+		{name: "disallowed command after and", cmd: "df -h && rm -rf /", allowed: false},
+		{name: "disallowed command after or", cmd: "df -h || reboot", allowed: false},
+		{name: "disallowed command after semicolon", cmd: "free -m; shutdown now", allowed: false},
+		{name: "disallowed command in background", cmd: "top -bn1 & kill 1", allowed: false},
+		{name: "disallowed command on next line", cmd: "df -h\nreboot", allowed: false},
+		{name: "env assignment prefix", cmd: "PAGER=sh journalctl", allowed: false},
+		{name: "dollar paren substitution", cmd: "df $(rm -rf /)", allowed: false},
+		{name: "substitution in double quotes", cmd: `grep "$(reboot)" app.log`, allowed: false},
+		{name: "backtick substitution", cmd: "du -sh `reboot`", allowed: false},
+		{name: "subshell", cmd: "(df -h)", allowed: false},
+		{name: "process substitution", cmd: "grep error <(journalctl)", allowed: false},
+		{name: "redirect to file", cmd: "ps aux > /etc/cron.d/job", allowed: false},
+		{name: "append to file", cmd: "free -m >> out.txt", allowed: false},
+		{name: "both streams to file", cmd: "df -h &> out.txt", allowed: false},
+		{name: "redirect to /dev/null lookalike", cmd: "df >/dev/nullx", allowed: false},
+		{name: "unterminated quote", cmd: "grep 'error app.log", allowed: false},
+		{name: "empty command", cmd: "  ; ", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := allowlist.Check(tt.cmd)
+			if tt.allowed && err != nil {
+				t.Errorf("Check(%q) = %v, want allowed", tt.cmd, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrCommandNotAllowed) {
+				t.Errorf("Check(%q) = %v, want ErrCommandNotAllowed", tt.cmd, err)
+			}
+		})
+	}
+}
+
+func TestCommandAllowlist_RefusalMessage(t *testing.T) {
+	patterns := []string{`^(ps|df)\b`, `^grep\b`}
+	allowlist, err := NewCommandAllowlist(patterns)
+	if err != nil {
+		t.Fatalf("NewCommandAllowlist() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cmd  string
+		want string
+	}{
+		{name: "unmatched segment", cmd: "ps aux | sort", want: `"sort" does not match any allowed pattern`},
+		{name: "command substitution", cmd: "df $(pwd)", want: "command substitution with $(...) is not allowed"},
+		{name: "backticks", cmd: "df `pwd`", want: "command substitution with backticks is not allowed"},
+		{name: "subshell", cmd: "(ps)", want: "subshells and process substitution are not allowed"},
+		{name: "redirection", cmd: "ps > out", want: "output redirection to files is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := allowlist.Check(tt.cmd)
+			if err == nil {
+				t.Fatalf("Check(%q) = nil, want an error", tt.cmd)
+			}
+			msg := err.Error()
+			if !strings.Contains(msg, tt.want) {
+				t.Errorf("Check(%q) = %q, want it to contain %q", tt.cmd, msg, tt.want)
+			}
+			if !strings.Contains(msg, strings.Join(patterns, ", ")) {
+				t.Errorf("Check(%q) = %q, want it to list the allowed patterns", tt.cmd, msg)
+			}
+		})
+	}
+}
+
+func TestNewCommandAllowlist(t *testing.T) {
+	allowlist, err := NewCommandAllowlist(nil)
+	if err != nil || allowlist != nil {
+		t.Fatalf("NewCommandAllowlist(nil) = %v, %v, want nil, nil", allowlist, err)
+	}
+	if err := allowlist.Check("rm -rf /"); err != nil {
+		t.Errorf("nil allowlist Check() = %v, want every command allowed", err)
+	}
+
+	if _, err := NewCommandAllowlist([]string{`^ps\b`, `^(df`}); err == nil || !strings.Contains(err.Error(), `"^(df"`) {
+		t.Errorf("NewCommandAllowlist() with an invalid pattern error = %v, want it to name the pattern", err)
+	}
+}
@@ -0,0 +1,31 @@
+package safety
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CommandWithEnv returns command as the shell would see it run with env: the
+// variables as NAME='value' assignments, sorted by name, before the command.
+// Confirmation prompts and command checks look at this form, so a variable
+// set for one call cannot slip past them.
+func CommandWithEnv(command string, env map[string]string) string {
+	if len(env) == 0 {
+		return command
+	}
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(shellQuote(env[name]))
+		b.WriteByte(' ')
+	}
+	b.WriteString(command)
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell with single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,22 @@
+package safety
+
+import "testing"
+
+func TestCommandWithEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"no env", nil, "ls -la"},
+		{"sorted assignments", map[string]string{"LANG": "C", "GOFLAGS": "-count=1"}, "GOFLAGS='-count=1' LANG='C' ls -la"},
+		{"quotes escaped", map[string]string{"MSG": "it's; rm -rf /"}, `MSG='it'\''s; rm -rf /' ls -la`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommandWithEnv("ls -la", tt.env); got != tt.want {
+				t.Errorf("CommandWithEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
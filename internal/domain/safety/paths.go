@@ -0,0 +1,21 @@
+package safety
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IsWithinDir reports whether path is dir itself or lies inside it.
+//
+// Both paths are compared element by element after filepath.Clean, never as
+// string prefixes: "/work" does not contain "/workshop", "/work/../etc" is
+// outside "/work", and on Windows either separator may be used and drive
+// letters match regardless of case. A relative path is never within an
+// absolute dir, or the reverse.
+func IsWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
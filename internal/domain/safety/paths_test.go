@@ -0,0 +1,51 @@
+package safety
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+type withinDirCase struct {
+	name string
+	path string
+	want bool
+}
+
+func TestIsWithinDir(t *testing.T) {
+	// Paths are built with the platform's separator, so the same cases run on
+	// Unix and Windows CI
+	root := filepath.FromSlash("/srv/work")
+	if runtime.GOOS == "windows" {
+		root = `C:\srv\work`
+	}
+	join := func(elem ...string) string { return filepath.Join(append([]string{root}, elem...)...) }
+
+	tests := []withinDirCase{
+		{name: "the directory itself", path: root, want: true},
+		{name: "trailing separator", path: root + string(filepath.Separator), want: true},
+		{name: "file inside", path: join("main.go"), want: true},
+		{name: "nested file", path: join("internal", "app", "main.go"), want: true},
+		{name: "file named with leading dots", path: join("..config"), want: true},
+		{name: "dot segments staying inside", path: root + filepath.FromSlash("/a/../b"), want: true},
+		{name: "sibling sharing the prefix", path: root + "shop", want: false},
+		{name: "parent directory", path: filepath.Dir(root), want: false},
+		{name: "escape through dot segments", path: root + filepath.FromSlash("/../etc/passwd"), want: false},
+		{name: "relative path", path: filepath.FromSlash("srv/work/main.go"), want: false},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests,
+			withinDirCase{name: "forward slashes", path: "C:/srv/work/main.go", want: true},
+			withinDirCase{name: "drive letter case", path: `c:\SRV\work\main.go`, want: true},
+			withinDirCase{name: "other drive", path: `D:\srv\work\main.go`, want: false},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWithinDir(root, tt.path); got != tt.want {
+				t.Errorf("IsWithinDir(%q, %q) = %v, want %v", root, tt.path, got, tt.want)
+			}
+		})
+	}
+}
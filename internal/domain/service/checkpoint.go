@@ -0,0 +1,199 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidCheckpoint        = errors.New("invalid checkpoint")
+	ErrCheckpointSplitsToolUse  = errors.New("checkpoint would separate a tool use from its result")
+	ErrNoCheckpoint             = errors.New("no checkpoint")
+	ErrConversationStoreMissing = errors.New("no conversation store configured")
+)
+
+// Checkpoint records the current point in a session's history and returns its
+// ID, the number of messages the history will hold after rolling back to it.
+// While the last assistant message is still waiting for its tool results, the
+// checkpoint is placed just before that message so a rollback never leaves a
+// tool use without its result.
+// Returns ErrConversationEnded for ended sessions.
+func (cs *ConversationService) Checkpoint(sessionID string) (int, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	conversation, err := cs.activeConversationLocked(sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	messages := conversation.GetMessages()
+	checkpointID := len(messages)
+	for checkpointID > 0 && splitsToolUse(messages, checkpointID) {
+		checkpointID--
+	}
+
+	checkpoints := cs.checkpoints[sessionID]
+	if len(checkpoints) == 0 || checkpoints[len(checkpoints)-1] != checkpointID {
+		cs.checkpoints[sessionID] = append(checkpoints, checkpointID)
+	}
+	return checkpointID, nil
+}
+
+// LatestCheckpoint returns the ID of the most recent checkpoint of a session,
+// or ErrNoCheckpoint if none has been recorded since it started.
+func (cs *ConversationService) LatestCheckpoint(sessionID string) (int, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if _, err := cs.activeConversationLocked(sessionID); err != nil {
+		return 0, err
+	}
+	checkpoints := cs.checkpoints[sessionID]
+	if len(checkpoints) == 0 {
+		return 0, ErrNoCheckpoint
+	}
+	return checkpoints[len(checkpoints)-1], nil
+}
+
+// Rollback truncates a session's history, in memory and in the conversation
+// store, back to checkpointID. Any message count is accepted as a checkpoint ID,
+// so IDs stay valid after a restore, but one cutting between a tool use and its
+// result is refused with ErrCheckpointSplitsToolUse. Checkpoints after
+// checkpointID are forgotten. Rolling back only rewinds the conversation, not
+// the files its tools changed.
+// Returns ErrConversationEnded for ended sessions.
+func (cs *ConversationService) Rollback(ctx context.Context, sessionID string, checkpointID int) error {
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	default:
+	}
+
+	cs.mu.Lock()
+	conversation, err := cs.activeConversationLocked(sessionID)
+	if err != nil {
+		cs.mu.Unlock()
+		return err
+	}
+
+	messages := conversation.GetMessages()
+	if checkpointID < 0 || checkpointID > len(messages) {
+		cs.mu.Unlock()
+		return fmt.Errorf("%w: %d (the session has %d messages)", ErrInvalidCheckpoint, checkpointID, len(messages))
+	}
+	if splitsToolUse(messages, checkpointID) {
+		cs.mu.Unlock()
+		return fmt.Errorf("%w: %d", ErrCheckpointSplitsToolUse, checkpointID)
+	}
+
+	conversation.Truncate(checkpointID)
+	cs.processing[sessionID] = false
+	checkpoints := cs.checkpoints[sessionID]
+	for len(checkpoints) > 0 && checkpoints[len(checkpoints)-1] > checkpointID {
+		checkpoints = checkpoints[:len(checkpoints)-1]
+	}
+	cs.checkpoints[sessionID] = checkpoints
+	cs.mu.Unlock()
+
+	cs.provenance.rebuild(sessionID, conversation.GetMessages())
+	return cs.persist(ctx, sessionID, conversation)
+}
+
+// RestoreConversation loads a session's history from the conversation store and
+// makes it the current session. Restoring a session already in memory replaces
+// its history; an ended session becomes active again.
+func (cs *ConversationService) RestoreConversation(ctx context.Context, sessionID string) error {
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	default:
+	}
+
+	cs.mu.RLock()
+	store := cs.conversationStore
+	cs.mu.RUnlock()
+	if store == nil {
+		return ErrConversationStoreMissing
+	}
+
+	messages, err := store.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation %s: %w", sessionID, err)
+	}
+	// A session saved mid tool use, or by an older version, may not pair its
+	// tool uses with their results; the API would reject it as it is
+	messages, repairs := repairToolPairing(messages)
+	cs.mu.RLock()
+	logger := cs.logger
+	cs.mu.RUnlock()
+	logToolPairingRepairs(logger, sessionID, repairs)
+
+	conversation, err := entity.NewConversation()
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if err := conversation.AddMessage(message); err != nil {
+			return fmt.Errorf("invalid message in stored conversation %s: %w", sessionID, err)
+		}
+	}
+	if len(messages) > 0 {
+		conversation.StartedAt = messages[0].Timestamp
+	}
+
+	// Sessions saved before metadata was kept have none; they restore untitled
+	if catalog, ok := store.(port.SessionCatalog); ok {
+		if metadata, err := catalog.LoadSessionMetadata(ctx, sessionID); err == nil && metadata.Title != "" {
+			cs.sessionTitlesMu.Lock()
+			cs.sessionTitles[sessionID] = metadata.Title
+			cs.sessionTitlesMu.Unlock()
+		}
+	}
+
+	cs.mu.Lock()
+	cs.conversations[sessionID] = conversation
+	cs.currentSession = sessionID
+	cs.processing[sessionID] = false
+	delete(cs.checkpoints, sessionID)
+	delete(cs.ended, sessionID)
+	cs.mu.Unlock()
+	cs.provenance.rebuild(sessionID, messages)
+	return nil
+}
+
+// activeConversationLocked returns the conversation of a session that has not
+// ended. cs.mu must be held.
+func (cs *ConversationService) activeConversationLocked(sessionID string) (*entity.Conversation, error) {
+	conversation, exists := cs.conversations[sessionID]
+	if !exists {
+		return nil, ErrConversationNotFound
+	}
+	if cs.ended[sessionID] {
+		return nil, ErrConversationEnded
+	}
+	return conversation, nil
+}
+
+// persist saves a session's history to the conversation store, if one is set.
+func (cs *ConversationService) persist(ctx context.Context, sessionID string, conversation *entity.Conversation) error {
+	cs.mu.RLock()
+	store := cs.conversationStore
+	cs.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	if err := store.SaveConversation(ctx, sessionID, conversation.GetMessages()); err != nil {
+		return fmt.Errorf("failed to persist conversation: %w", err)
+	}
+	return cs.persistMetadata(ctx, store, sessionID, conversation)
+}
+
+// splitsToolUse reports whether keeping only the first count messages would
+// keep an assistant's tool uses but drop their results.
+func splitsToolUse(messages []entity.Message, count int) bool {
+	return count > 0 && len(messages[count-1].ToolCalls) > 0
+}
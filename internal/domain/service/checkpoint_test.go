@@ -0,0 +1,203 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// memoryConversationStore is an in-memory port.ConversationStore.
+type memoryConversationStore struct {
+	mu            sync.Mutex
+	conversations map[string][]entity.Message
+}
+
+func (s *memoryConversationStore) SaveConversation(_ context.Context, sessionID string, messages []entity.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conversations == nil {
+		s.conversations = make(map[string][]entity.Message)
+	}
+	s.conversations[sessionID] = append([]entity.Message(nil), messages...)
+	return nil
+}
+
+func (s *memoryConversationStore) LoadConversation(_ context.Context, sessionID string) ([]entity.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages, ok := s.conversations[sessionID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return messages, nil
+}
+
+// toolUseProvider answers with an assistant message requesting one bash call.
+func toolUseProvider() *mockAIProvider {
+	return &mockAIProvider{
+		response: &entity.Message{
+			Role:      entity.RoleAssistant,
+			Content:   "Running a command",
+			ToolCalls: []entity.ToolCall{{ToolID: "tool-1", ToolName: "bash"}},
+		},
+		toolCalls: []port.ToolCallInfo{{ToolID: "tool-1", ToolName: "bash"}},
+	}
+}
+
+// startWithToolExchange starts a session holding a user message, an assistant
+// tool use, and its tool result.
+func startWithToolExchange(t *testing.T, cs *ConversationService) string {
+	t.Helper()
+	ctx := context.Background()
+	sessionID, err := cs.StartConversation(ctx)
+	if err != nil {
+		t.Fatalf("StartConversation failed: %v", err)
+	}
+	if _, err := cs.AddUserMessage(ctx, sessionID, "List the files"); err != nil {
+		t.Fatalf("AddUserMessage failed: %v", err)
+	}
+	if _, _, err := cs.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Fatalf("ProcessAssistantResponse failed: %v", err)
+	}
+	if err := cs.AddToolResultMessage(ctx, sessionID, []entity.ToolResult{{ToolID: "tool-1", Result: "a.go"}}); err != nil {
+		t.Fatalf("AddToolResultMessage failed: %v", err)
+	}
+	return sessionID
+}
+
+func messageCount(t *testing.T, cs *ConversationService, sessionID string) int {
+	t.Helper()
+	conversation, err := cs.GetConversation(sessionID)
+	if err != nil {
+		t.Fatalf("GetConversation failed: %v", err)
+	}
+	return conversation.MessageCount()
+}
+
+func TestConversationService_Checkpoint_ManualRollback(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	sessionID, _ := cs.StartConversation(ctx)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "first")
+
+	first, err := cs.Checkpoint(sessionID)
+	if err != nil || first != 1 {
+		t.Fatalf("Checkpoint() = %d, %v; want 1, nil", first, err)
+	}
+	_, _ = cs.AddUserMessage(ctx, sessionID, "second")
+	second, _ := cs.Checkpoint(sessionID)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "third")
+
+	if err := cs.Rollback(ctx, sessionID, second); err != nil {
+		t.Fatalf("Rollback(%d) failed: %v", second, err)
+	}
+	if got := messageCount(t, cs, sessionID); got != 2 {
+		t.Errorf("message count after rollback = %d, want 2", got)
+	}
+
+	if err := cs.Rollback(ctx, sessionID, first); err != nil {
+		t.Fatalf("Rollback(%d) failed: %v", first, err)
+	}
+	if latest, err := cs.LatestCheckpoint(sessionID); err != nil || latest != first {
+		t.Errorf("LatestCheckpoint() = %d, %v; want %d, nil after later checkpoints are dropped", latest, err, first)
+	}
+	conversation, _ := cs.GetConversation(sessionID)
+	if messages := conversation.GetMessages(); len(messages) != 1 || messages[0].Content != "first" {
+		t.Errorf("messages after rollback = %+v, want only the first message", messages)
+	}
+
+	if err := cs.Rollback(ctx, sessionID, 5); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("Rollback past the end error = %v, want ErrInvalidCheckpoint", err)
+	}
+}
+
+func TestConversationService_Checkpoint_NeverSplitsToolUse(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := NewConversationService(toolUseProvider(), &mockToolExecutor{})
+	sessionID, _ := cs.StartConversation(ctx)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "List the files")
+	_, _, _ = cs.ProcessAssistantResponse(ctx, sessionID)
+
+	// The tool use is still waiting for its result, so the checkpoint goes before it
+	checkpointID, err := cs.Checkpoint(sessionID)
+	if err != nil || checkpointID != 1 {
+		t.Fatalf("Checkpoint() = %d, %v; want 1, nil", checkpointID, err)
+	}
+
+	_ = cs.AddToolResultMessage(ctx, sessionID, []entity.ToolResult{{ToolID: "tool-1", Result: "a.go"}})
+	if err := cs.Rollback(ctx, sessionID, 2); !errors.Is(err, ErrCheckpointSplitsToolUse) {
+		t.Errorf("Rollback between tool use and result error = %v, want ErrCheckpointSplitsToolUse", err)
+	}
+	if got := messageCount(t, cs, sessionID); got != 3 {
+		t.Errorf("message count after refused rollback = %d, want 3", got)
+	}
+
+	if err := cs.Rollback(ctx, sessionID, checkpointID); err != nil {
+		t.Fatalf("Rollback(%d) failed: %v", checkpointID, err)
+	}
+	if processing, _ := cs.IsProcessing(sessionID); processing {
+		t.Error("session still processing after rolling back past the tool use")
+	}
+}
+
+func TestConversationService_Rollback_EndedSession(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	sessionID, _ := cs.StartConversation(ctx)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "hello")
+	checkpointID, _ := cs.Checkpoint(sessionID)
+	_ = cs.EndConversation(ctx, sessionID)
+
+	if err := cs.Rollback(ctx, sessionID, checkpointID); !errors.Is(err, ErrConversationEnded) {
+		t.Errorf("Rollback of ended session error = %v, want ErrConversationEnded", err)
+	}
+	if _, err := cs.Checkpoint(sessionID); !errors.Is(err, ErrConversationEnded) {
+		t.Errorf("Checkpoint of ended session error = %v, want ErrConversationEnded", err)
+	}
+}
+
+func TestConversationService_Rollback_PersistenceRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryConversationStore{}
+	cs, _ := NewConversationService(toolUseProvider(), &mockToolExecutor{})
+	cs.SetConversationStore(store)
+
+	sessionID := startWithToolExchange(t, cs)
+	checkpointID, _ := cs.Checkpoint(sessionID)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "Now delete them")
+	if stored, _ := store.LoadConversation(ctx, sessionID); len(stored) != 4 {
+		t.Fatalf("stored %d messages before rollback, want 4", len(stored))
+	}
+
+	if err := cs.Rollback(ctx, sessionID, checkpointID); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if stored, _ := store.LoadConversation(ctx, sessionID); len(stored) != 3 {
+		t.Errorf("stored %d messages after rollback, want 3", len(stored))
+	}
+
+	restored, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	restored.SetConversationStore(store)
+	if err := restored.RestoreConversation(ctx, sessionID); err != nil {
+		t.Fatalf("RestoreConversation failed: %v", err)
+	}
+	conversation, _ := restored.GetConversation(sessionID)
+	messages := conversation.GetMessages()
+	if len(messages) != 3 || messages[1].ToolCalls[0].ToolID != "tool-1" || messages[2].ToolResults[0].Result != "a.go" {
+		t.Fatalf("restored messages = %+v, want the history up to the checkpoint", messages)
+	}
+	if current, _ := restored.GetCurrentSession(); current != sessionID {
+		t.Errorf("current session = %q, want the restored session", current)
+	}
+
+	// Checkpoint IDs are message counts, so they remain valid after a restore
+	if err := restored.Rollback(ctx, sessionID, 1); err != nil {
+		t.Fatalf("Rollback after restore failed: %v", err)
+	}
+	if stored, _ := store.LoadConversation(ctx, sessionID); len(stored) != 1 {
+		t.Errorf("stored %d messages after rollback of restored session, want 1", len(stored))
+	}
+}
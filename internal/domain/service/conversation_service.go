@@ -9,14 +9,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 )
 
 var (
 	ErrConversationNotFound = errors.New("conversation not found")
 	ErrToolNotFound         = errors.New("tool not found")
+	ErrConversationEnded    = errors.New("conversation has ended")
 )
 
+// customSystemPrompt is a session's custom system prompt.
+type customSystemPrompt struct {
+	prompt   string
+	composed bool // Set by SetComposedSystemPrompt
+}
+
 // ConversationService handles the core business logic for managing conversations.
 // It orchestrates the flow of messages between users and AI, processes tool executions,
 // maintains conversation state, and coordinates with the AI provider.
@@ -26,12 +34,35 @@ type ConversationService struct {
 	conversations          map[string]*entity.Conversation
 	currentSession         string
 	processing             map[string]bool
+	checkpoints            map[string][]int
+	ended                  map[string]bool
+	mu                     sync.RWMutex // Protects conversations, currentSession, processing, checkpoints, ended, conversationStore, and workspace
+	conversationStore      port.ConversationStore
+	workspace              string
 	sessionModes           map[string]bool
 	sessionModesMu         sync.RWMutex // Protects sessionModes map for concurrent access
 	sessionThinkingModes   map[string]port.ThinkingModeInfo
 	sessionThinkingModesMu sync.RWMutex // Protects sessionThinkingModes map for concurrent access
-	sessionSystemPrompts   map[string]string
+	sessionSystemPrompts   map[string]customSystemPrompt
 	sessionSystemPromptsMu sync.RWMutex // Protects sessionSystemPrompts map for concurrent access
+	sessionModels          map[string]string
+	sessionModelsMu        sync.RWMutex // Protects sessionModels map for concurrent access
+	sessionTitles          map[string]string
+	sessionTitlesMu        sync.RWMutex // Protects sessionTitles map for concurrent access
+	toolSelector           *ToolSelector
+	toolSelectionStats     map[string]ToolSelectionStats
+	toolSelectionMu        sync.RWMutex // Protects toolSelector and toolSelectionStats
+	provenance             *provenanceIndex
+	toolList               *toolList // Caches the tools of an executor that reports changes; nil lists every request
+	logger                 *slog.Logger
+}
+
+// ToolSelectionStats is how much a session's tool selection saved across the
+// turns it was applied to.
+type ToolSelectionStats struct {
+	Turns         int // Requests the selection narrowed the tools of
+	OfferedTokens int // Estimated tokens of the tool schemas sent
+	SavedTokens   int // Estimated tokens of the tool schemas withheld
 }
 
 // NewConversationService creates a new instance of ConversationService.
@@ -44,15 +75,65 @@ func NewConversationService(aiProvider port.AIProvider, toolExecutor port.ToolEx
 		return nil, errors.New("tool executor cannot be nil")
 	}
 
-	return &ConversationService{
+	cs := &ConversationService{
 		aiProvider:           aiProvider,
 		toolExecutor:         toolExecutor,
 		conversations:        make(map[string]*entity.Conversation),
 		processing:           make(map[string]bool),
+		checkpoints:          make(map[string][]int),
+		ended:                make(map[string]bool),
 		sessionModes:         make(map[string]bool),
 		sessionThinkingModes: make(map[string]port.ThinkingModeInfo),
-		sessionSystemPrompts: make(map[string]string),
-	}, nil
+		sessionSystemPrompts: make(map[string]customSystemPrompt),
+		sessionModels:        make(map[string]string),
+		sessionTitles:        make(map[string]string),
+		toolSelectionStats:   make(map[string]ToolSelectionStats),
+		provenance:           newProvenanceIndex(),
+		logger:               slog.Default(),
+	}
+	// An executor that reports changes to its tools need not list them for
+	// every request
+	if registry, ok := toolExecutor.(port.ToolRegistry); ok {
+		cs.toolList = &toolList{}
+		registry.AddToolRegistryObserver(cs)
+	}
+	return cs, nil
+}
+
+// SetLogger configures the logger for repairs made to conversation history. A
+// nil logger restores slog.Default().
+func (cs *ConversationService) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.logger = logger
+}
+
+// SetToolSelector makes each request offer only the tools selector chooses
+// for the turn instead of every tool. A nil selector offers every tool again.
+func (cs *ConversationService) SetToolSelector(selector *ToolSelector) {
+	cs.toolSelectionMu.Lock()
+	defer cs.toolSelectionMu.Unlock()
+	cs.toolSelector = selector
+}
+
+// ToolSelectionStats returns the tool schema tokens the session's tool
+// selection sent and saved, or false if no request of it was narrowed.
+func (cs *ConversationService) ToolSelectionStats(sessionID string) (ToolSelectionStats, bool) {
+	cs.toolSelectionMu.RLock()
+	defer cs.toolSelectionMu.RUnlock()
+	stats, ok := cs.toolSelectionStats[sessionID]
+	return stats, ok
+}
+
+// SetConversationStore sets the store that persists each session's history
+// after every change. Without a store, history is kept in memory only.
+func (cs *ConversationService) SetConversationStore(store port.ConversationStore) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.conversationStore = store
 }
 
 // StartConversation creates a new conversation session with a unique identifier.
@@ -69,9 +150,11 @@ func (cs *ConversationService) StartConversation(ctx context.Context) (string, e
 		return "", err
 	}
 
+	cs.mu.Lock()
 	cs.conversations[sessionID] = conversation
 	cs.currentSession = sessionID
 	cs.processing[sessionID] = false
+	cs.mu.Unlock()
 
 	return sessionID, nil
 }
@@ -84,7 +167,7 @@ func (cs *ConversationService) AddUserMessage(ctx context.Context, sessionID, co
 	default:
 	}
 
-	conversation, exists := cs.conversations[sessionID]
+	conversation, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return nil, ErrConversationNotFound
 	}
@@ -98,10 +181,49 @@ func (cs *ConversationService) AddUserMessage(ctx context.Context, sessionID, co
 	if err != nil {
 		return nil, err
 	}
+	if err := cs.persist(ctx, sessionID, conversation); err != nil {
+		return nil, err
+	}
 
 	return message, nil
 }
 
+// AddUserMessageWithBlocks adds a user message of text and image blocks to the
+// conversation. Images are refused with port.ErrImagesNotSupported, without
+// changing the conversation, if the AI provider cannot accept them.
+func (cs *ConversationService) AddUserMessageWithBlocks(
+	ctx context.Context,
+	sessionID string,
+	blocks []entity.ContentBlock,
+) (*entity.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, context.Canceled
+	default:
+	}
+
+	conversation, exists := cs.lookupConversation(sessionID)
+	if !exists {
+		return nil, ErrConversationNotFound
+	}
+
+	message, err := entity.NewMessageWithBlocks(entity.RoleUser, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if message.HasImages() && !port.SupportsImageInput(cs.aiProvider) {
+		return nil, port.ErrImagesNotSupported
+	}
+
+	if err := conversation.AddMessage(*message); err != nil {
+		return nil, err
+	}
+	if err := cs.persist(ctx, sessionID, conversation); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
 // AddToolResultMessage adds tool execution results to the conversation.
 func (cs *ConversationService) AddToolResultMessage(
 	ctx context.Context,
@@ -114,7 +236,7 @@ func (cs *ConversationService) AddToolResultMessage(
 	default:
 	}
 
-	conversation, exists := cs.conversations[sessionID]
+	conversation, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
@@ -124,7 +246,11 @@ func (cs *ConversationService) AddToolResultMessage(
 		return err
 	}
 
-	return conversation.AddMessage(*message)
+	if err := conversation.AddMessage(*message); err != nil {
+		return err
+	}
+	cs.provenance.add(sessionID, toolResults)
+	return cs.persist(ctx, sessionID, conversation)
 }
 
 // ProcessAssistantResponse processes an AI assistant response, handling tools and text.
@@ -138,14 +264,21 @@ func (cs *ConversationService) ProcessAssistantResponse(
 		return nil, nil, err
 	}
 
-	// Send to AI provider
-	response, toolCalls, err := cs.aiProvider.SendMessage(preparedCtx, messageParams, toolParams)
+	// Send to AI provider, on the session's own model if it has one
+	var response *entity.Message
+	var toolCalls []port.ToolCallInfo
+	if model := cs.GetSessionModel(sessionID); model != "" {
+		response, toolCalls, err = cs.aiProvider.SendMessageWithOptions(
+			preparedCtx, messageParams, toolParams, port.RequestOptions{Model: model})
+	} else {
+		response, toolCalls, err = cs.aiProvider.SendMessage(preparedCtx, messageParams, toolParams)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Finalize response
-	return cs.finalizeAIResponse(sessionID, conversation, response, toolCalls)
+	return cs.finalizeAIResponse(ctx, sessionID, conversation, response, toolCalls)
 }
 
 // ProcessAssistantResponseStreaming processes an AI assistant response with streaming support.
@@ -175,7 +308,7 @@ func (cs *ConversationService) ProcessAssistantResponseStreaming(
 	}
 
 	// Finalize response
-	return cs.finalizeAIResponse(sessionID, conversation, response, toolCalls)
+	return cs.finalizeAIResponse(ctx, sessionID, conversation, response, toolCalls)
 }
 
 // prepareAIRequest prepares the context, message parameters, and tool parameters for an AI request.
@@ -190,10 +323,13 @@ func (cs *ConversationService) prepareAIRequest(
 	default:
 	}
 
-	conversation, exists := cs.conversations[sessionID]
+	conversation, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return nil, nil, nil, nil, ErrConversationNotFound
 	}
+	if err := cs.repairHistory(ctx, sessionID, conversation); err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	// Get conversation history for AI provider
 	messages := conversation.GetMessages()
@@ -230,21 +366,36 @@ func (cs *ConversationService) prepareAIRequest(
 		// Convert ThinkingBlocks from entity to port
 		thinkingBlockParams := port.ConvertEntityThinkingBlocksToParams(msg.ThinkingBlocks)
 
+		// Refuse images before sending them to a text-only provider
+		if msg.HasImages() && !port.SupportsImageInput(cs.aiProvider) {
+			return nil, nil, nil, nil, port.ErrImagesNotSupported
+		}
+
 		messageParams[i] = port.MessageParam{
 			Role:           msg.Role,
 			Content:        msg.Content,
 			ToolCalls:      toolCallParams,
 			ToolResults:    toolResultParams,
 			ThinkingBlocks: thinkingBlockParams,
+			Blocks:         port.ConvertEntityContentBlocksToParams(msg.Blocks),
 		}
 	}
 
 	// Get available tools
-	tools, err := cs.toolExecutor.ListTools()
+	tools, err := cs.listTools()
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
+	// Narrow the offered tools if the caller supplied an allowlist
+	if allowed, ok := port.AllowedToolsFromContext(ctx); ok {
+		tools = filterToolsByName(tools, allowed)
+	}
+	if port.IsHeadless(ctx) {
+		tools = withoutInteractiveTools(tools)
+	}
+	tools = cs.selectTools(sessionID, tools, messages)
+
 	toolParams := make([]port.ToolParam, len(tools))
 	for i, tool := range tools {
 		toolParams[i] = port.ToolParam{
@@ -266,11 +417,8 @@ func (cs *ConversationService) prepareAIRequest(
 	}
 
 	// Add custom system prompt to context if set
-	if customPrompt, ok := cs.GetCustomSystemPrompt(sessionID); ok {
-		ctx = port.WithCustomSystemPrompt(ctx, port.CustomSystemPromptInfo{
-			Prompt:    customPrompt,
-			SessionID: sessionID,
-		})
+	if customPrompt, ok := cs.CustomSystemPromptInfo(sessionID); ok {
+		ctx = port.WithCustomSystemPrompt(ctx, customPrompt)
 	}
 
 	// Add thinking mode info to context if enabled
@@ -284,23 +432,38 @@ func (cs *ConversationService) prepareAIRequest(
 // finalizeAIResponse adds the AI response to the conversation and updates processing state.
 // This is shared logic between streaming and non-streaming requests.
 func (cs *ConversationService) finalizeAIResponse(
+	ctx context.Context,
 	sessionID string,
 	conversation *entity.Conversation,
 	response *entity.Message,
 	toolCalls []port.ToolCallInfo,
 ) (*entity.Message, []port.ToolCallInfo, error) {
-	// Add response to conversation
-	err := conversation.AddMessage(*response)
+	// Add response to conversation, recording the tool uses it reports so
+	// their results pair with them
+	message := *response
+	if len(message.ToolCalls) == 0 && len(toolCalls) > 0 {
+		message.ToolCalls = make([]entity.ToolCall, len(toolCalls))
+		for i, tc := range toolCalls {
+			message.ToolCalls[i] = entity.ToolCall{
+				ToolID:           tc.ToolID,
+				ToolName:         tc.ToolName,
+				Input:            tc.Input,
+				ThoughtSignature: tc.ThoughtSignature,
+			}
+		}
+	}
+	err := conversation.AddMessage(message)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := cs.persist(ctx, sessionID, conversation); err != nil {
+		return nil, nil, err
+	}
 
 	// Check if response contains tool usage
-	if len(toolCalls) > 0 {
-		cs.processing[sessionID] = true
-	} else {
-		cs.processing[sessionID] = false
-	}
+	cs.mu.Lock()
+	cs.processing[sessionID] = len(toolCalls) > 0
+	cs.mu.Unlock()
 
 	return response, toolCalls, nil
 }
@@ -317,7 +480,7 @@ func (cs *ConversationService) ExecuteToolsInResponse(
 	default:
 	}
 
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return nil, errors.New("conversation not found")
 	}
@@ -343,14 +506,16 @@ func (cs *ConversationService) ExecuteToolsInResponse(
 	}
 
 	// Reset processing state after executing tools
+	cs.mu.Lock()
 	cs.processing[sessionID] = false
+	cs.mu.Unlock()
 
 	return results, nil
 }
 
 // GetConversation retrieves a conversation by session ID.
 func (cs *ConversationService) GetConversation(sessionID string) (*entity.Conversation, error) {
-	conversation, exists := cs.conversations[sessionID]
+	conversation, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return nil, ErrConversationNotFound
 	}
@@ -359,6 +524,8 @@ func (cs *ConversationService) GetConversation(sessionID string) (*entity.Conver
 
 // GetCurrentSession returns the current active session ID.
 func (cs *ConversationService) GetCurrentSession() (string, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
 	return cs.currentSession, nil
 }
 
@@ -371,18 +538,23 @@ func (cs *ConversationService) EndConversation(ctx context.Context, sessionID st
 	default:
 	}
 
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
 
+	cs.mu.Lock()
 	// If ending current session, clear it
 	if cs.currentSession == sessionID {
 		cs.currentSession = ""
 	}
 
-	// Remove processing state
+	// Remove processing state and checkpoints; the history stays readable but
+	// can no longer be rolled back
 	delete(cs.processing, sessionID)
+	delete(cs.checkpoints, sessionID)
+	cs.ended[sessionID] = true
+	cs.mu.Unlock()
 
 	// Remove mode state
 	cs.sessionModesMu.Lock()
@@ -399,30 +571,61 @@ func (cs *ConversationService) EndConversation(ctx context.Context, sessionID st
 	delete(cs.sessionSystemPrompts, sessionID)
 	cs.sessionSystemPromptsMu.Unlock()
 
+	// Remove session model
+	cs.sessionModelsMu.Lock()
+	delete(cs.sessionModels, sessionID)
+	cs.sessionModelsMu.Unlock()
+
+	// Remove session title; the stored metadata keeps it
+	cs.sessionTitlesMu.Lock()
+	delete(cs.sessionTitles, sessionID)
+	cs.sessionTitlesMu.Unlock()
+
+	// Remove tool selection stats
+	cs.toolSelectionMu.Lock()
+	delete(cs.toolSelectionStats, sessionID)
+	cs.toolSelectionMu.Unlock()
+
+	// Remove the provenance index
+	cs.provenance.remove(sessionID)
+
 	return nil
 }
 
 // IsProcessing checks if the conversation is currently processing (waiting for tool results).
 func (cs *ConversationService) IsProcessing(sessionID string) (bool, error) {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return false, ErrConversationNotFound
 	}
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
 	return cs.processing[sessionID], nil
 }
 
 // SetProcessingState sets the processing state of a conversation.
 func (cs *ConversationService) SetProcessingState(sessionID string, processing bool) error {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
+	cs.mu.Lock()
 	cs.processing[sessionID] = processing
+	cs.mu.Unlock()
 	return nil
 }
 
 // Helper methods for ConversationService
 
+// lookupConversation returns the conversation for a session.
+// The operation is thread-safe for concurrent reads.
+func (cs *ConversationService) lookupConversation(sessionID string) (*entity.Conversation, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	conversation, exists := cs.conversations[sessionID]
+	return conversation, exists
+}
+
 // generateSessionID generates a unique session ID using crypto/rand.
 func generateSessionID() string {
 	bytes := make([]byte, 16)
@@ -430,6 +633,74 @@ func generateSessionID() string {
 	return hex.EncodeToString(bytes)
 }
 
+// filterToolsByName returns the tools whose names appear in allowed, preserving order.
+func filterToolsByName(tools []entity.Tool, allowed []string) []entity.Tool {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+
+	filtered := make([]entity.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if allowedSet[tool.Name] {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// selectTools narrows tools to those the session's tool selector offers for
+// the turn, recording the tokens saved. Without a selector it returns tools.
+func (cs *ConversationService) selectTools(
+	sessionID string,
+	tools []entity.Tool,
+	messages []entity.Message,
+) []entity.Tool {
+	cs.toolSelectionMu.RLock()
+	selector := cs.toolSelector
+	cs.toolSelectionMu.RUnlock()
+	if selector == nil {
+		return tools
+	}
+
+	var systemPrompt string
+	if custom, ok := cs.CustomSystemPromptInfo(sessionID); ok {
+		systemPrompt = custom.Prompt
+	}
+	selection := selector.Select(tools, systemPrompt, messages)
+
+	cs.toolSelectionMu.Lock()
+	stats := cs.toolSelectionStats[sessionID]
+	stats.Turns++
+	stats.OfferedTokens += selection.OfferedTokens
+	stats.SavedTokens += selection.SavedTokens
+	cs.toolSelectionStats[sessionID] = stats
+	cs.toolSelectionMu.Unlock()
+
+	cs.mu.RLock()
+	logger := cs.logger
+	cs.mu.RUnlock()
+	logger.Debug("selected tools",
+		"session_id", sessionID,
+		"offered", len(selection.Offered),
+		"withheld", selection.Withheld,
+		"groups", selection.Groups,
+		"saved_tokens", selection.SavedTokens)
+	return selection.Offered
+}
+
+// withoutInteractiveTools returns the tools that do not need a user at the
+// terminal, preserving order.
+func withoutInteractiveTools(tools []entity.Tool) []entity.Tool {
+	filtered := make([]entity.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !tool.Interactive {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
 // ToolRequest represents a parsed tool request from AI response.
 type ToolRequest struct {
 	Name  string      `json:"name"`
@@ -461,7 +732,7 @@ func (cs *ConversationService) parseToolRequests(content string) []ToolRequest {
 // When plan mode is enabled, tool executions are written to plan files instead of being executed.
 // The operation is thread-safe.
 func (cs *ConversationService) SetPlanMode(sessionID string, enabled bool) error {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
@@ -475,7 +746,7 @@ func (cs *ConversationService) SetPlanMode(sessionID string, enabled bool) error
 // Returns false for non-existent sessions.
 // The operation is thread-safe for concurrent reads.
 func (cs *ConversationService) IsPlanMode(sessionID string) (bool, error) {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return false, ErrConversationNotFound
 	}
@@ -488,7 +759,7 @@ func (cs *ConversationService) IsPlanMode(sessionID string) (bool, error) {
 // The configuration includes whether thinking is enabled, the token budget, and display settings.
 // The operation is thread-safe.
 func (cs *ConversationService) SetThinkingMode(sessionID string, info port.ThinkingModeInfo) error {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
@@ -502,7 +773,7 @@ func (cs *ConversationService) SetThinkingMode(sessionID string, info port.Think
 // Returns zero-value ThinkingModeInfo for non-existent sessions or if not set.
 // The operation is thread-safe for concurrent reads.
 func (cs *ConversationService) GetThinkingMode(sessionID string) (port.ThinkingModeInfo, error) {
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return port.ThinkingModeInfo{}, ErrConversationNotFound
 	}
@@ -516,13 +787,27 @@ func (cs *ConversationService) GetThinkingMode(sessionID string) (port.ThinkingM
 // The custom prompt is included in the context when calling the AI provider.
 // The operation is thread-safe.
 func (cs *ConversationService) SetCustomSystemPrompt(ctx context.Context, sessionID, prompt string) error {
+	return cs.setCustomSystemPrompt(ctx, sessionID, customSystemPrompt{prompt: prompt})
+}
+
+// SetComposedSystemPrompt sets a custom system prompt composed from layers,
+// such as the result of usecase.SystemPromptComposer. Unlike a prompt set by
+// SetCustomSystemPrompt it is sent as is: the provider does not append plan
+// mode instructions, which the composed prompt's mode layer carries instead.
+// The operation is thread-safe.
+func (cs *ConversationService) SetComposedSystemPrompt(ctx context.Context, sessionID, prompt string) error {
+	return cs.setCustomSystemPrompt(ctx, sessionID, customSystemPrompt{prompt: prompt, composed: true})
+}
+
+// setCustomSystemPrompt stores the session's custom system prompt.
+func (cs *ConversationService) setCustomSystemPrompt(ctx context.Context, sessionID string, prompt customSystemPrompt) error {
 	select {
 	case <-ctx.Done():
 		return context.Canceled
 	default:
 	}
 
-	_, exists := cs.conversations[sessionID]
+	_, exists := cs.lookupConversation(sessionID)
 	if !exists {
 		return ErrConversationNotFound
 	}
@@ -540,5 +825,45 @@ func (cs *ConversationService) GetCustomSystemPrompt(sessionID string) (string,
 	cs.sessionSystemPromptsMu.RLock()
 	defer cs.sessionSystemPromptsMu.RUnlock()
 	prompt, ok := cs.sessionSystemPrompts[sessionID]
-	return prompt, ok
+	return prompt.prompt, ok
+}
+
+// CustomSystemPromptInfo is like GetCustomSystemPrompt but also reports
+// whether the prompt was set by SetComposedSystemPrompt.
+func (cs *ConversationService) CustomSystemPromptInfo(sessionID string) (port.CustomSystemPromptInfo, bool) {
+	cs.sessionSystemPromptsMu.RLock()
+	defer cs.sessionSystemPromptsMu.RUnlock()
+	prompt, ok := cs.sessionSystemPrompts[sessionID]
+	if !ok {
+		return port.CustomSystemPromptInfo{}, false
+	}
+	return port.CustomSystemPromptInfo{SessionID: sessionID, Prompt: prompt.prompt, Composed: prompt.composed}, true
+}
+
+// SetSessionModel makes ProcessAssistantResponse send the session's requests
+// on model instead of the provider's default, without switching the default
+// for other sessions. An empty model restores the default. Streaming requests
+// always use the default. The operation is thread-safe.
+func (cs *ConversationService) SetSessionModel(sessionID, model string) error {
+	_, exists := cs.lookupConversation(sessionID)
+	if !exists {
+		return ErrConversationNotFound
+	}
+	cs.sessionModelsMu.Lock()
+	defer cs.sessionModelsMu.Unlock()
+	if model == "" {
+		delete(cs.sessionModels, sessionID)
+	} else {
+		cs.sessionModels[sessionID] = model
+	}
+	return nil
+}
+
+// GetSessionModel returns the model set for a session with SetSessionModel,
+// or "" if it uses the provider's default.
+// The operation is thread-safe for concurrent reads.
+func (cs *ConversationService) GetSessionModel(sessionID string) string {
+	cs.sessionModelsMu.RLock()
+	defer cs.sessionModelsMu.RUnlock()
+	return cs.sessionModels[sessionID]
 }
@@ -590,10 +590,11 @@ func TestProcessingState(t *testing.T) {
 // --- Mock Implementations for Testing ---
 
 type mockAIProvider struct {
-	response  *entity.Message
-	toolCalls []port.ToolCallInfo
-	err       error
-	model     string
+	response      *entity.Message
+	toolCalls     []port.ToolCallInfo
+	err           error
+	model         string
+	requestModels []string // Model requested in RequestOptions per call ("" for the default)
 }
 
 func (m *mockAIProvider) SendMessage(
@@ -613,6 +614,16 @@ func (m *mockAIProvider) SendMessage(
 	return m.response, m.toolCalls, nil
 }
 
+func (m *mockAIProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.requestModels = append(m.requestModels, opts.Model)
+	return m.SendMessage(ctx, messages, tools)
+}
+
 func (m *mockAIProvider) SendMessageStreaming(
 	_ context.Context,
 	_ []port.MessageParam,
@@ -1369,6 +1380,49 @@ func TestConversationService_EndConversation_CleansUpCustomPrompt(t *testing.T)
 	})
 }
 
+func TestConversationService_SetSessionModel(t *testing.T) {
+	provider := &mockAIProvider{model: "default-model"}
+	service, err := NewConversationService(provider, &mockToolExecutor{})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	ctx := context.Background()
+	routed, _ := service.StartConversation(ctx)
+	other, _ := service.StartConversation(ctx)
+
+	if err := service.SetSessionModel("missing-session", "small-model"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("SetSessionModel() on unknown session error = %v, want ErrConversationNotFound", err)
+	}
+	if err := service.SetSessionModel(routed, "small-model"); err != nil {
+		t.Fatalf("SetSessionModel() error = %v", err)
+	}
+
+	for _, sessionID := range []string{routed, other} {
+		_, _ = service.AddUserMessage(ctx, sessionID, "Hello")
+		if _, _, err := service.ProcessAssistantResponse(ctx, sessionID); err != nil {
+			t.Fatalf("ProcessAssistantResponse() error = %v", err)
+		}
+	}
+	// Only the routed session's request names a model; the other uses the default
+	if len(provider.requestModels) != 1 || provider.requestModels[0] != "small-model" {
+		t.Errorf("requested models = %q, want only the routed session on small-model", provider.requestModels)
+	}
+	if provider.model != "default-model" {
+		t.Errorf("provider model = %q, want the default untouched", provider.model)
+	}
+
+	// Clearing the model, or ending the session, drops it
+	_ = service.SetSessionModel(routed, "")
+	if got := service.GetSessionModel(routed); got != "" {
+		t.Errorf("GetSessionModel() after clearing = %q, want \"\"", got)
+	}
+	_ = service.SetSessionModel(other, "small-model")
+	_ = service.EndConversation(ctx, other)
+	if got := service.GetSessionModel(other); got != "" {
+		t.Errorf("GetSessionModel() after EndConversation = %q, want \"\"", got)
+	}
+}
+
 func TestConversationService_CustomSystemPrompt_ThreadSafety(t *testing.T) {
 	t.Run("concurrent access to SetCustomSystemPrompt and GetCustomSystemPrompt", func(t *testing.T) {
 		service, err := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
@@ -2009,3 +2063,141 @@ func (m *messageCapturingMockAIProvider) SendMessage(
 	// Delegate to base mock
 	return m.mockAIProvider.SendMessage(ctx, messages, tools)
 }
+
+// =============================================================================
+// Allowed Tools Filtering Tests
+// =============================================================================
+
+type toolCapturingMockAIProvider struct {
+	mockAIProvider
+
+	capturedTools []port.ToolParam
+}
+
+func (m *toolCapturingMockAIProvider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	m.capturedTools = tools
+	return m.mockAIProvider.SendMessage(ctx, messages, tools)
+}
+
+func TestConversationService_ProcessAssistantResponse_FiltersAllowedTools(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       func() context.Context
+		wantTools []string
+	}{
+		{
+			name:      "no allowlist offers all tools",
+			ctx:       context.Background,
+			wantTools: []string{"ask_user", "bash", "list_files", "read_file"},
+		},
+		{
+			name: "headless run drops interactive tools",
+			ctx: func() context.Context {
+				return port.WithHeadless(context.Background())
+			},
+			wantTools: []string{"bash", "list_files", "read_file"},
+		},
+		{
+			name: "allowlist offers only listed tools",
+			ctx: func() context.Context {
+				return port.WithAllowedTools(context.Background(), []string{"read_file", "unknown_tool"})
+			},
+			wantTools: []string{"read_file"},
+		},
+		{
+			name: "empty allowlist offers no tools",
+			ctx: func() context.Context {
+				return port.WithAllowedTools(context.Background(), []string{})
+			},
+			wantTools: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &toolCapturingMockAIProvider{}
+			executor := &mockToolExecutor{}
+			for _, name := range []string{"bash", "list_files", "read_file"} {
+				_ = executor.RegisterTool(entity.Tool{ID: name, Name: name})
+			}
+			_ = executor.RegisterTool(entity.Tool{ID: "ask_user", Name: "ask_user", Interactive: true})
+
+			service, err := NewConversationService(provider, executor)
+			if err != nil {
+				t.Fatalf("Failed to create service: %v", err)
+			}
+
+			ctx := tt.ctx()
+			sessionID, _ := service.StartConversation(ctx)
+			_, _ = service.AddUserMessage(ctx, sessionID, "hello")
+
+			if _, _, err := service.ProcessAssistantResponse(ctx, sessionID); err != nil {
+				t.Fatalf("ProcessAssistantResponse() error = %v", err)
+			}
+
+			got := make(map[string]bool, len(provider.capturedTools))
+			for _, tool := range provider.capturedTools {
+				got[tool.Name] = true
+			}
+			if len(got) != len(tt.wantTools) {
+				t.Fatalf("offered %d tools (%v), want %v", len(got), provider.capturedTools, tt.wantTools)
+			}
+			for _, name := range tt.wantTools {
+				if !got[name] {
+					t.Errorf("expected tool %q to be offered", name)
+				}
+			}
+		})
+	}
+}
+
+// imageAIProvider is a mockAIProvider that accepts image input.
+type imageAIProvider struct {
+	mockAIProvider
+}
+
+func (m *imageAIProvider) SupportsImageInput() bool {
+	return true
+}
+
+func TestConversationService_AddUserMessageWithBlocks(t *testing.T) {
+	ctx := context.Background()
+	blocks := []entity.ContentBlock{
+		entity.NewTextBlock("What does this diagram show?"),
+		entity.NewImageBlock(entity.ImageSource{MediaType: "image/png", Data: "iVBORw0KGgo="}),
+	}
+
+	cs, _ := NewConversationService(&imageAIProvider{}, &mockToolExecutor{})
+	sessionID, _ := cs.StartConversation(ctx)
+	message, err := cs.AddUserMessageWithBlocks(ctx, sessionID, blocks)
+	if err != nil {
+		t.Fatalf("AddUserMessageWithBlocks() error = %v", err)
+	}
+	if message.Content != "What does this diagram show?" || !message.HasImages() {
+		t.Errorf("message = %+v, want the text and an image", message)
+	}
+	if _, _, err := cs.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Errorf("ProcessAssistantResponse() error = %v", err)
+	}
+
+	textOnly, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	textSessionID, _ := textOnly.StartConversation(ctx)
+	if _, err := textOnly.AddUserMessageWithBlocks(ctx, textSessionID, blocks); !errors.Is(err, port.ErrImagesNotSupported) {
+		t.Errorf("AddUserMessageWithBlocks() error = %v, want ErrImagesNotSupported", err)
+	}
+	if got := messageCount(t, textOnly, textSessionID); got != 0 {
+		t.Errorf("message count = %d, want 0 after a refused image", got)
+	}
+
+	// Images already in the history, such as from a restored session, are
+	// refused before anything is sent to a text-only provider
+	conversation, _ := textOnly.GetConversation(textSessionID)
+	_ = conversation.AddMessage(*message)
+	if _, _, err := textOnly.ProcessAssistantResponse(ctx, textSessionID); !errors.Is(err, port.ErrImagesNotSupported) {
+		t.Errorf("ProcessAssistantResponse() error = %v, want ErrImagesNotSupported", err)
+	}
+}
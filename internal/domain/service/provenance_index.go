@@ -0,0 +1,149 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxProvenanceResults is how many of a session's latest tool results with
+// provenance the index keeps.
+const maxProvenanceResults = 200
+
+// FindSnippet returns the file lines that the session's recent tool results
+// quoting snippet came from, most recent first, or nil if none did. Only
+// results whose tools report provenance, such as read_file, are searched;
+// leading and trailing whitespace of each line is ignored.
+func (cs *ConversationService) FindSnippet(sessionID, snippet string) []entity.Provenance {
+	return cs.provenance.find(sessionID, snippet)
+}
+
+// provenanceEntry is an indexed tool result: its lines and where they came from.
+type provenanceEntry struct {
+	lines      []string
+	provenance []entity.Provenance
+}
+
+// provenanceIndex keeps each session's latest tool results that carry
+// provenance, so a snippet quoted from them can be traced to its file and
+// lines. It is safe for concurrent use.
+type provenanceIndex struct {
+	mu       sync.RWMutex
+	sessions map[string][]provenanceEntry // Oldest first
+}
+
+// newProvenanceIndex creates an empty provenanceIndex.
+func newProvenanceIndex() *provenanceIndex {
+	return &provenanceIndex{sessions: make(map[string][]provenanceEntry)}
+}
+
+// add indexes the successful results with provenance, forgetting the oldest
+// beyond maxProvenanceResults.
+func (x *provenanceIndex) add(sessionID string, results []entity.ToolResult) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entries := x.sessions[sessionID]
+	for _, result := range results {
+		if result.IsError || len(result.Provenance) == 0 {
+			continue
+		}
+		entries = append(entries, provenanceEntry{
+			lines:      strings.Split(strings.TrimSuffix(result.Result, "\n"), "\n"),
+			provenance: result.Provenance,
+		})
+	}
+	if len(entries) > maxProvenanceResults {
+		entries = slices.Clone(entries[len(entries)-maxProvenanceResults:])
+	}
+	if len(entries) > 0 {
+		x.sessions[sessionID] = entries
+	}
+}
+
+// rebuild replaces a session's entries with those of its messages, after its
+// history was restored or rolled back.
+func (x *provenanceIndex) rebuild(sessionID string, messages []entity.Message) {
+	x.remove(sessionID)
+	for _, message := range messages {
+		x.add(sessionID, message.ToolResults)
+	}
+}
+
+// remove forgets a session's entries.
+func (x *provenanceIndex) remove(sessionID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.sessions, sessionID)
+}
+
+// find returns where the session's indexed results contain snippet, most
+// recent first. A result with one provenance covering exactly its lines is
+// matched line by line, so the location narrows to the lines of the snippet;
+// for any other result the whole of its provenance is returned.
+func (x *provenanceIndex) find(sessionID, snippet string) []entity.Provenance {
+	want := snippetLines(snippet)
+	if len(want) == 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	entries := x.sessions[sessionID]
+	var found []entity.Provenance
+	for i := len(entries) - 1; i >= 0; i-- {
+		for _, location := range entries[i].find(want) {
+			if !slices.Contains(found, location) {
+				found = append(found, location)
+			}
+		}
+	}
+	return found
+}
+
+// find returns where the entry contains the snippet's lines, in order.
+func (e *provenanceEntry) find(want []string) []entity.Provenance {
+	var found []entity.Provenance
+	numbered := len(e.provenance) == 1 && e.provenance[0].EndLine-e.provenance[0].StartLine+1 == len(e.lines)
+	for start := 0; start+len(want) <= len(e.lines); start++ {
+		if !linesContain(e.lines[start:start+len(want)], want) {
+			continue
+		}
+		if !numbered {
+			return e.provenance
+		}
+		first := e.provenance[0].StartLine + start
+		found = append(found, entity.Provenance{
+			Path:      e.provenance[0].Path,
+			StartLine: first,
+			EndLine:   first + len(want) - 1,
+		})
+	}
+	return found
+}
+
+// snippetLines returns the trimmed lines of snippet, without leading and
+// trailing blank lines.
+func snippetLines(snippet string) []string {
+	lines := strings.Split(snippet, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// linesContain reports whether each line contains the wanted line at its index.
+func linesContain(lines, want []string) bool {
+	for i, line := range want {
+		if !strings.Contains(lines[i], line) {
+			return false
+		}
+	}
+	return true
+}
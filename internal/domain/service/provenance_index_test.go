@@ -0,0 +1,133 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// readFileResult returns a read_file-style result of lines first onwards of
+// path, with its provenance.
+func readFileResult(toolID, path string, first int, lines ...string) entity.ToolResult {
+	result := ""
+	for i, line := range lines {
+		result += fmt.Sprintf("%d: %s\n", first+i, line)
+	}
+	return entity.ToolResult{
+		ToolID:     toolID,
+		Result:     result,
+		Provenance: []entity.Provenance{{Path: path, StartLine: first, EndLine: first + len(lines) - 1}},
+	}
+}
+
+func TestConversationService_FindSnippet(t *testing.T) {
+	cs, err := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	ctx := context.Background()
+	sessionID, _ := cs.StartConversation(ctx)
+
+	err = cs.AddToolResultMessage(ctx, sessionID, []entity.ToolResult{
+		readFileResult("t1", "main.go", 10, "func main() {", "\tfmt.Println(\"hi\")", "}"),
+		{ToolID: "t2", Result: "fmt.Println(\"hi\")"}, // No provenance
+		{ToolID: "t3", Result: "fmt.Println", IsError: true, Provenance: []entity.Provenance{{Path: "x.go"}}},
+	})
+	if err != nil {
+		t.Fatalf("AddToolResultMessage() error = %v", err)
+	}
+	err = cs.AddToolResultMessage(ctx, sessionID, []entity.ToolResult{
+		readFileResult("t4", "util.go", 1, "package main", "", "func greet() { fmt.Println(\"hi\") }"),
+		{ToolID: "t5", Result: "a.go:3: fmt.Println(\"hi\")\nb.go:7: x := 1", Provenance: []entity.Provenance{
+			{Path: "a.go", StartLine: 3, EndLine: 3}, {Path: "b.go", StartLine: 7, EndLine: 7},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("AddToolResultMessage() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		snippet string
+		want    []entity.Provenance
+	}{
+		{
+			name:    "single line, most recent first",
+			snippet: `  fmt.Println("hi")  `,
+			want: []entity.Provenance{
+				{Path: "a.go", StartLine: 3, EndLine: 3}, // The whole provenance of a result matched as a whole
+				{Path: "b.go", StartLine: 7, EndLine: 7},
+				{Path: "util.go", StartLine: 3, EndLine: 3},
+				{Path: "main.go", StartLine: 11, EndLine: 11},
+			},
+		},
+		{
+			name:    "multiple lines",
+			snippet: "\nfunc main() {\n    fmt.Println(\"hi\")\n}\n",
+			want:    []entity.Provenance{{Path: "main.go", StartLine: 10, EndLine: 12}},
+		},
+		{
+			name:    "blank lines match blank lines",
+			snippet: "package main\n\nfunc greet()",
+			want:    []entity.Provenance{{Path: "util.go", StartLine: 1, EndLine: 3}},
+		},
+		{name: "not quoted", snippet: "os.Exit(1)", want: nil},
+		{name: "empty", snippet: " \n ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cs.FindSnippet(sessionID, tt.snippet); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindSnippet(%q) = %v, want %v", tt.snippet, got, tt.want)
+			}
+		})
+	}
+
+	if got := cs.FindSnippet("other-session", "func main"); got != nil {
+		t.Errorf("FindSnippet() in another session = %v, want nil", got)
+	}
+}
+
+func TestConversationService_FindSnippet_FollowsHistory(t *testing.T) {
+	cs, err := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	ctx := context.Background()
+	sessionID, _ := cs.StartConversation(ctx)
+	if _, err := cs.AddUserMessage(ctx, sessionID, "Read main.go"); err != nil {
+		t.Fatalf("AddUserMessage() error = %v", err)
+	}
+	checkpoint, err := cs.Checkpoint(sessionID)
+	if err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	results := []entity.ToolResult{readFileResult("t1", "main.go", 1, "package main")}
+	if err := cs.AddToolResultMessage(ctx, sessionID, results); err != nil {
+		t.Fatalf("AddToolResultMessage() error = %v", err)
+	}
+	if got := cs.FindSnippet(sessionID, "package main"); len(got) != 1 {
+		t.Fatalf("FindSnippet() = %v, want main.go:1", got)
+	}
+
+	// Rolling back forgets the results it removes
+	if err := cs.Rollback(ctx, sessionID, checkpoint); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := cs.FindSnippet(sessionID, "package main"); got != nil {
+		t.Errorf("FindSnippet() after rollback = %v, want nil", got)
+	}
+
+	// So does ending the session
+	if err := cs.AddToolResultMessage(ctx, sessionID, results); err != nil {
+		t.Fatalf("AddToolResultMessage() error = %v", err)
+	}
+	if err := cs.EndConversation(ctx, sessionID); err != nil {
+		t.Fatalf("EndConversation() error = %v", err)
+	}
+	if got := cs.FindSnippet(sessionID, "package main"); got != nil {
+		t.Errorf("FindSnippet() after ending = %v, want nil", got)
+	}
+}
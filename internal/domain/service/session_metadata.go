@@ -0,0 +1,116 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrSessionCatalogMissing = errors.New("conversation store does not keep session metadata")
+
+// SetWorkspace sets the directory recorded as every session's workspace in
+// its stored metadata.
+func (cs *ConversationService) SetWorkspace(dir string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.workspace = dir
+}
+
+// SetSessionTitle sets a session's title and saves it with the session's
+// metadata, if the conversation store keeps metadata. Surrounding whitespace is
+// trimmed; an empty title clears it.
+func (cs *ConversationService) SetSessionTitle(ctx context.Context, sessionID, title string) error {
+	conversation, exists := cs.lookupConversation(sessionID)
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	title = strings.TrimSpace(title)
+	cs.sessionTitlesMu.Lock()
+	if title == "" {
+		delete(cs.sessionTitles, sessionID)
+	} else {
+		cs.sessionTitles[sessionID] = title
+	}
+	cs.sessionTitlesMu.Unlock()
+
+	cs.mu.RLock()
+	store := cs.conversationStore
+	cs.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	return cs.persistMetadata(ctx, store, sessionID, conversation)
+}
+
+// GetSessionTitle returns a session's title, or "" if it has none yet.
+func (cs *ConversationService) GetSessionTitle(sessionID string) string {
+	cs.sessionTitlesMu.RLock()
+	defer cs.sessionTitlesMu.RUnlock()
+	return cs.sessionTitles[sessionID]
+}
+
+// ListSessions returns the metadata of every stored session, most recently
+// updated first. Returns ErrConversationStoreMissing without a store and
+// ErrSessionCatalogMissing if the store keeps no metadata.
+func (cs *ConversationService) ListSessions(ctx context.Context) ([]port.SessionMetadata, error) {
+	cs.mu.RLock()
+	store := cs.conversationStore
+	cs.mu.RUnlock()
+	if store == nil {
+		return nil, ErrConversationStoreMissing
+	}
+	catalog, ok := store.(port.SessionCatalog)
+	if !ok {
+		return nil, ErrSessionCatalogMissing
+	}
+	return catalog.ListSessions(ctx)
+}
+
+// persistMetadata saves a session's metadata if store keeps metadata. It runs
+// after every save of the history, so the metadata always describes the
+// stored messages.
+func (cs *ConversationService) persistMetadata(
+	ctx context.Context,
+	store port.ConversationStore,
+	sessionID string,
+	conversation *entity.Conversation,
+) error {
+	catalog, ok := store.(port.SessionCatalog)
+	if !ok {
+		return nil
+	}
+	if err := catalog.SaveSessionMetadata(ctx, cs.sessionMetadata(sessionID, conversation)); err != nil {
+		return fmt.Errorf("failed to persist session metadata: %w", err)
+	}
+	return nil
+}
+
+// sessionMetadata describes a session as it is now.
+func (cs *ConversationService) sessionMetadata(sessionID string, conversation *entity.Conversation) port.SessionMetadata {
+	cs.mu.RLock()
+	workspace := cs.workspace
+	cs.mu.RUnlock()
+
+	model := cs.GetSessionModel(sessionID)
+	if model == "" {
+		model = cs.aiProvider.GetModel()
+	}
+	usage := conversation.TokenUsage()
+
+	return port.SessionMetadata{
+		SessionID:    sessionID,
+		Title:        cs.GetSessionTitle(sessionID),
+		CreatedAt:    conversation.StartedAt,
+		UpdatedAt:    time.Now(),
+		Model:        model,
+		MessageCount: conversation.MessageCount(),
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		Workspace:    workspace,
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"testing"
+)
+
+// memorySessionCatalog is an in-memory store that also keeps session metadata.
+type memorySessionCatalog struct {
+	memoryConversationStore
+	metadata map[string]port.SessionMetadata
+	saves    int
+}
+
+func (s *memorySessionCatalog) SaveSessionMetadata(_ context.Context, metadata port.SessionMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata == nil {
+		s.metadata = make(map[string]port.SessionMetadata)
+	}
+	s.metadata[metadata.SessionID] = metadata
+	s.saves++
+	return nil
+}
+
+func (s *memorySessionCatalog) LoadSessionMetadata(_ context.Context, sessionID string) (port.SessionMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metadata, ok := s.metadata[sessionID]
+	if !ok {
+		return port.SessionMetadata{}, errors.New("not found")
+	}
+	return metadata, nil
+}
+
+func (s *memorySessionCatalog) ListSessions(_ context.Context) ([]port.SessionMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]port.SessionMetadata, 0, len(s.metadata))
+	for _, metadata := range s.metadata {
+		sessions = append(sessions, metadata)
+	}
+	return sessions, nil
+}
+
+func (s *memorySessionCatalog) stored(t *testing.T, sessionID string) port.SessionMetadata {
+	t.Helper()
+	metadata, err := s.LoadSessionMetadata(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("no metadata stored for %s", sessionID)
+	}
+	return metadata
+}
+
+func TestConversationService_SessionMetadata_UpdatedOnEachAppend(t *testing.T) {
+	ctx := context.Background()
+	store := &memorySessionCatalog{}
+	provider := toolUseProvider()
+	provider.model = "claude-sonnet"
+	provider.response.Usage = &entity.TokenUsage{InputTokens: 900, OutputTokens: 40}
+	cs, _ := NewConversationService(provider, &mockToolExecutor{})
+	cs.SetConversationStore(store)
+	cs.SetWorkspace("/srv/app")
+
+	sessionID, _ := cs.StartConversation(ctx)
+	if _, err := cs.AddUserMessage(ctx, sessionID, "List the files"); err != nil {
+		t.Fatalf("AddUserMessage failed: %v", err)
+	}
+	first := store.stored(t, sessionID)
+	if first.MessageCount != 1 || first.Workspace != "/srv/app" || first.Model != "claude-sonnet" ||
+		first.InputTokens != 0 || first.CreatedAt.IsZero() || first.UpdatedAt.Before(first.CreatedAt) {
+		t.Errorf("metadata after user message = %+v", first)
+	}
+
+	if _, _, err := cs.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Fatalf("ProcessAssistantResponse failed: %v", err)
+	}
+	second := store.stored(t, sessionID)
+	if second.MessageCount != 2 || second.InputTokens != 900 || second.OutputTokens != 40 {
+		t.Errorf("metadata after response = %+v, want 2 messages and the response's tokens", second)
+	}
+
+	_ = cs.SetSessionModel(sessionID, "claude-haiku")
+	if err := cs.AddToolResultMessage(ctx, sessionID, []entity.ToolResult{{ToolID: "tool-1", Result: "a.go"}}); err != nil {
+		t.Fatalf("AddToolResultMessage failed: %v", err)
+	}
+	third := store.stored(t, sessionID)
+	if third.MessageCount != 3 || third.Model != "claude-haiku" || !third.CreatedAt.Equal(first.CreatedAt) ||
+		third.UpdatedAt.Before(second.UpdatedAt) {
+		t.Errorf("metadata after tool result = %+v", third)
+	}
+	if store.saves != 3 {
+		t.Errorf("metadata saved %d times, want once per append", store.saves)
+	}
+}
+
+func TestConversationService_SessionTitle(t *testing.T) {
+	ctx := context.Background()
+	store := &memorySessionCatalog{}
+	cs, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	cs.SetConversationStore(store)
+
+	sessionID, _ := cs.StartConversation(ctx)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "Fix the login bug")
+	if title := store.stored(t, sessionID).Title; title != "" {
+		t.Errorf("stored title before naming = %q, want none", title)
+	}
+
+	if err := cs.SetSessionTitle(ctx, sessionID, "  Login bug  "); err != nil {
+		t.Fatalf("SetSessionTitle failed: %v", err)
+	}
+	if got := cs.GetSessionTitle(sessionID); got != "Login bug" {
+		t.Errorf("GetSessionTitle() = %q, want %q", got, "Login bug")
+	}
+	if title := store.stored(t, sessionID).Title; title != "Login bug" {
+		t.Errorf("stored title = %q, want it saved without waiting for the next append", title)
+	}
+
+	// Appends keep the title
+	_, _ = cs.AddUserMessage(ctx, sessionID, "It fails on Safari")
+	if title := store.stored(t, sessionID).Title; title != "Login bug" {
+		t.Errorf("stored title after append = %q", title)
+	}
+
+	restored, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	restored.SetConversationStore(store)
+	if err := restored.RestoreConversation(ctx, sessionID); err != nil {
+		t.Fatalf("RestoreConversation failed: %v", err)
+	}
+	if got := restored.GetSessionTitle(sessionID); got != "Login bug" {
+		t.Errorf("restored title = %q, want %q", got, "Login bug")
+	}
+
+	if err := cs.SetSessionTitle(ctx, "missing", "x"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("SetSessionTitle on unknown session error = %v, want ErrConversationNotFound", err)
+	}
+}
+
+func TestConversationService_ListSessions(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	if _, err := cs.ListSessions(ctx); !errors.Is(err, ErrConversationStoreMissing) {
+		t.Errorf("ListSessions without store error = %v, want ErrConversationStoreMissing", err)
+	}
+
+	cs.SetConversationStore(&memoryConversationStore{})
+	if _, err := cs.ListSessions(ctx); !errors.Is(err, ErrSessionCatalogMissing) {
+		t.Errorf("ListSessions with plain store error = %v, want ErrSessionCatalogMissing", err)
+	}
+
+	store := &memorySessionCatalog{}
+	cs.SetConversationStore(store)
+	sessionID, _ := cs.StartConversation(ctx)
+	_, _ = cs.AddUserMessage(ctx, sessionID, "hello")
+	sessions, err := cs.ListSessions(ctx)
+	if err != nil || len(sessions) != 1 || sessions[0].SessionID != sessionID {
+		t.Errorf("ListSessions() = %+v, %v, want the one stored session", sessions, err)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"slices"
+	"sync"
+)
+
+// Compile-time check that ConversationService follows changes to the tools.
+var _ port.ToolRegistryObserver = (*ConversationService)(nil)
+
+// ToolRegistryChanged implements port.ToolRegistryObserver: the tools sent
+// with every session's next request are listed again, so a tool registered or
+// unregistered mid-conversation is offered or withdrawn from the next turn on.
+func (cs *ConversationService) ToolRegistryChanged(event port.ToolRegistryEvent) {
+	if cs.toolList != nil {
+		cs.toolList.invalidate()
+	}
+	cs.mu.RLock()
+	logger := cs.logger
+	cs.mu.RUnlock()
+	logger.Debug("tools changed", "tool", event.Name, "change", string(event.Type))
+}
+
+// listTools returns the executor's tools, from the cache when the executor
+// reports its changes and none happened since they were last listed.
+func (cs *ConversationService) listTools() ([]entity.Tool, error) {
+	if cs.toolList == nil {
+		return cs.toolExecutor.ListTools()
+	}
+	return cs.toolList.get(cs.toolExecutor.ListTools)
+}
+
+// toolList caches the tools of an executor that reports changes to them
+// between requests. It is safe for concurrent use.
+type toolList struct {
+	mu         sync.Mutex
+	tools      []entity.Tool
+	valid      bool
+	generation uint64 // Counts invalidations, so a listing older than one is not cached
+}
+
+// get returns the cached tools, listing them with list if there are none.
+func (l *toolList) get(list func() ([]entity.Tool, error)) ([]entity.Tool, error) {
+	l.mu.Lock()
+	if l.valid {
+		tools := slices.Clone(l.tools)
+		l.mu.Unlock()
+		return tools, nil
+	}
+	generation := l.generation
+	l.mu.Unlock()
+
+	tools, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.generation == generation {
+		l.tools, l.valid = slices.Clone(tools), true
+	}
+	return tools, nil
+}
+
+// invalidate drops the cached tools.
+func (l *toolList) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tools, l.valid = nil, false
+	l.generation++
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"slices"
+	"testing"
+)
+
+// registryToolExecutor is a mockToolExecutor that reports changes to its
+// tools and counts how often they are listed.
+type registryToolExecutor struct {
+	mockToolExecutor
+
+	observers []port.ToolRegistryObserver
+	listed    int
+}
+
+func (m *registryToolExecutor) AddToolRegistryObserver(observer port.ToolRegistryObserver) {
+	m.observers = append(m.observers, observer)
+}
+
+func (m *registryToolExecutor) ListTools() ([]entity.Tool, error) {
+	m.listed++
+	return m.mockToolExecutor.ListTools()
+}
+
+func (m *registryToolExecutor) RegisterTool(tool entity.Tool) error {
+	_ = m.mockToolExecutor.RegisterTool(tool)
+	m.notify(port.ToolRegistryEvent{Type: port.ToolRegistered, Name: tool.Name})
+	return nil
+}
+
+func (m *registryToolExecutor) UnregisterTool(name string) error {
+	_ = m.mockToolExecutor.UnregisterTool(name)
+	m.notify(port.ToolRegistryEvent{Type: port.ToolUnregistered, Name: name})
+	return nil
+}
+
+func (m *registryToolExecutor) notify(event port.ToolRegistryEvent) {
+	for _, observer := range m.observers {
+		observer.ToolRegistryChanged(event)
+	}
+}
+
+func TestConversationService_FollowsToolRegistryChanges(t *testing.T) {
+	provider := &toolCapturingMockAIProvider{}
+	executor := &registryToolExecutor{}
+	_ = executor.RegisterTool(entity.Tool{ID: "read_file", Name: "read_file"})
+
+	service, err := NewConversationService(provider, executor)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	if len(executor.observers) != 1 {
+		t.Fatalf("service added %d observers, want 1", len(executor.observers))
+	}
+
+	ctx := context.Background()
+	sessionID, _ := service.StartConversation(ctx)
+	turn := func() []string {
+		t.Helper()
+		if _, err := service.AddUserMessage(ctx, sessionID, "hello"); err != nil {
+			t.Fatalf("AddUserMessage() error = %v", err)
+		}
+		if _, _, err := service.ProcessAssistantResponse(ctx, sessionID); err != nil {
+			t.Fatalf("ProcessAssistantResponse() error = %v", err)
+		}
+		var names []string
+		for _, tool := range provider.capturedTools {
+			names = append(names, tool.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	if got := turn(); !slices.Equal(got, []string{"read_file"}) {
+		t.Errorf("first turn offered %v, want [read_file]", got)
+	}
+	turn()
+	if executor.listed != 1 {
+		t.Errorf("tools listed %d times over two unchanged turns, want 1", executor.listed)
+	}
+
+	_ = executor.RegisterTool(entity.Tool{ID: "git_status", Name: "git_status"})
+	if got := turn(); !slices.Equal(got, []string{"git_status", "read_file"}) {
+		t.Errorf("turn after registering git_status offered %v", got)
+	}
+	_ = executor.UnregisterTool("read_file")
+	if got := turn(); !slices.Equal(got, []string{"git_status"}) {
+		t.Errorf("turn after unregistering read_file offered %v", got)
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrToolPairing is returned by validateToolPairing for a history the API
+// would reject because a tool use and its result are not paired.
+var ErrToolPairing = errors.New("tool use and tool result are not paired")
+
+// Reasons a tool result is missing, given in the synthetic result that
+// replaces it.
+const (
+	lostResultInterrupted = "the session stopped before the tool returned"
+	lostResultNewMessage  = "a new message was added before the tool returned"
+	lostResultMissing     = "no result was recorded for this tool call"
+)
+
+// toolPairingRepair is one change repairToolPairing made to a history.
+type toolPairingRepair struct {
+	action string // e.g. "inserted result" or "dropped orphaned result"
+	toolID string
+	reason string
+}
+
+// lostToolResult is the synthetic error result of a tool use whose result was
+// lost.
+func lostToolResult(toolID, reason string) entity.ToolResult {
+	return entity.ToolResult{ToolID: toolID, Result: "result lost: " + reason, IsError: true}
+}
+
+// validateToolPairing checks the invariants the API enforces between tool
+// uses and their results: tool use IDs are unique within a message, every
+// assistant message with tool uses is immediately followed by a user message
+// with exactly one result for each of them, and every tool result answers a
+// tool use of the assistant message just before it.
+func validateToolPairing(messages []entity.Message) error {
+	for i, msg := range messages {
+		if len(msg.ToolResults) > 0 {
+			if msg.Role != entity.RoleUser {
+				return fmt.Errorf("%w: message %d is a %s message with tool results", ErrToolPairing, i, msg.Role)
+			}
+			if i == 0 || len(messages[i-1].ToolCalls) == 0 {
+				return fmt.Errorf("%w: tool result %s in message %d has no tool use before it",
+					ErrToolPairing, msg.ToolResults[0].ToolID, i)
+			}
+		}
+		if len(msg.ToolCalls) == 0 {
+			continue
+		}
+
+		calls := make(map[string]bool, len(msg.ToolCalls))
+		for _, call := range msg.ToolCalls {
+			if calls[call.ToolID] {
+				return fmt.Errorf("%w: tool use %s appears twice in message %d", ErrToolPairing, call.ToolID, i)
+			}
+			calls[call.ToolID] = true
+		}
+		if msg.Role != entity.RoleAssistant {
+			return fmt.Errorf("%w: message %d is a %s message with tool uses", ErrToolPairing, i, msg.Role)
+		}
+		if i+1 == len(messages) || messages[i+1].Role != entity.RoleUser {
+			return fmt.Errorf("%w: tool uses in message %d are not followed by their results", ErrToolPairing, i)
+		}
+		answered := make(map[string]bool, len(calls))
+		for _, result := range messages[i+1].ToolResults {
+			if !calls[result.ToolID] {
+				return fmt.Errorf("%w: tool result %s in message %d answers no tool use in message %d",
+					ErrToolPairing, result.ToolID, i+1, i)
+			}
+			if answered[result.ToolID] {
+				return fmt.Errorf("%w: tool use %s has two results in message %d", ErrToolPairing, result.ToolID, i+1)
+			}
+			answered[result.ToolID] = true
+		}
+		for _, call := range msg.ToolCalls {
+			if !answered[call.ToolID] {
+				return fmt.Errorf("%w: tool use %s in message %d has no result", ErrToolPairing, call.ToolID, i)
+			}
+		}
+	}
+	return nil
+}
+
+// repairToolPairing returns a copy of messages that validateToolPairing
+// accepts, with the changes made. A tool use without a result gets a
+// synthetic error result ("result lost: <reason>"), in the message after it
+// or, when that message is not its results, in a new message inserted there.
+// Tool results answering no tool use of the message before them, second
+// results for the same tool use, and tool uses or results in a message of the
+// wrong role are dropped, along with any message left empty. A repeated tool
+// use ID in one message keeps its first use. A valid history is returned
+// unchanged with no repairs.
+func repairToolPairing(messages []entity.Message) ([]entity.Message, []toolPairingRepair) {
+	if validateToolPairing(messages) == nil {
+		return messages, nil
+	}
+
+	var repairs []toolPairingRepair
+	repaired := make([]entity.Message, 0, len(messages)+1)
+	for i := 0; i < len(messages); i++ {
+		var msg entity.Message
+		msg, repairs = withoutMisplacedToolContent(messages[i], repairs)
+		// Results right after their tool uses were taken with them below, so
+		// any met here answer nothing
+		msg.ToolResults, repairs = keepAnsweringResults(msg.ToolResults, nil, repairs)
+		if isEmptyMessage(msg) {
+			continue
+		}
+		if len(msg.ToolCalls) == 0 {
+			repaired = append(repaired, msg)
+			continue
+		}
+		msg.ToolCalls, repairs = uniqueToolCalls(msg.ToolCalls, repairs)
+		repaired = append(repaired, msg)
+
+		// Answer every tool use in the message after it
+		if i+1 < len(messages) && messages[i+1].Role == entity.RoleUser && len(messages[i+1].ToolResults) > 0 {
+			var next entity.Message
+			next, repairs = withoutMisplacedToolContent(messages[i+1], repairs)
+			next.ToolResults, repairs = keepAnsweringResults(next.ToolResults, msg.ToolCalls, repairs)
+			next.ToolResults, repairs = addLostResults(next.ToolResults, msg.ToolCalls, lostResultMissing, repairs)
+			repaired = append(repaired, next)
+			i++
+			continue
+		}
+		reason := lostResultInterrupted
+		if i+1 < len(messages) {
+			reason = lostResultNewMessage
+		}
+		var results []entity.ToolResult
+		results, repairs = addLostResults(nil, msg.ToolCalls, reason, repairs)
+		repaired = append(repaired, entity.Message{
+			Role:        entity.RoleUser,
+			ToolResults: results,
+			Timestamp:   lostResultTimestamp(msg, messages, i),
+		})
+	}
+	return repaired, repairs
+}
+
+// withoutMisplacedToolContent drops tool uses from a message that is not the
+// assistant's and tool results from one that is not the user's.
+func withoutMisplacedToolContent(
+	msg entity.Message,
+	repairs []toolPairingRepair,
+) (entity.Message, []toolPairingRepair) {
+	if msg.Role != entity.RoleAssistant {
+		for _, call := range msg.ToolCalls {
+			repairs = append(repairs, toolPairingRepair{
+				action: "dropped tool use", toolID: call.ToolID, reason: "it is in a " + msg.Role + " message",
+			})
+		}
+		msg.ToolCalls = nil
+	}
+	if msg.Role != entity.RoleUser {
+		for _, result := range msg.ToolResults {
+			repairs = append(repairs, toolPairingRepair{
+				action: "dropped orphaned result", toolID: result.ToolID, reason: "it is in a " + msg.Role + " message",
+			})
+		}
+		msg.ToolResults = nil
+	}
+	return msg, repairs
+}
+
+// isEmptyMessage reports whether a message has nothing left to send.
+func isEmptyMessage(msg entity.Message) bool {
+	return msg.Content == "" && len(msg.ToolCalls) == 0 && len(msg.ToolResults) == 0 &&
+		len(msg.ThinkingBlocks) == 0 && len(msg.Blocks) == 0
+}
+
+// keepAnsweringResults returns the results that answer one of calls, the
+// first result for each.
+func keepAnsweringResults(
+	results []entity.ToolResult,
+	calls []entity.ToolCall,
+	repairs []toolPairingRepair,
+) ([]entity.ToolResult, []toolPairingRepair) {
+	pending := make(map[string]bool, len(calls))
+	for _, call := range calls {
+		pending[call.ToolID] = true
+	}
+	var kept []entity.ToolResult
+	for _, result := range results {
+		if stillPending, isCall := pending[result.ToolID]; !stillPending {
+			reason := "no matching tool use before it"
+			if isCall {
+				reason = "the tool use already has a result"
+			}
+			repairs = append(repairs, toolPairingRepair{
+				action: "dropped orphaned result", toolID: result.ToolID, reason: reason,
+			})
+			continue
+		}
+		pending[result.ToolID] = false
+		kept = append(kept, result)
+	}
+	return kept, repairs
+}
+
+// addLostResults appends a synthetic result for each of calls that results
+// does not answer.
+func addLostResults(
+	results []entity.ToolResult,
+	calls []entity.ToolCall,
+	reason string,
+	repairs []toolPairingRepair,
+) ([]entity.ToolResult, []toolPairingRepair) {
+	answered := make(map[string]bool, len(results))
+	for _, result := range results {
+		answered[result.ToolID] = true
+	}
+	for _, call := range calls {
+		if !answered[call.ToolID] {
+			results = append(results, lostToolResult(call.ToolID, reason))
+			repairs = append(repairs, toolPairingRepair{action: "inserted result", toolID: call.ToolID, reason: reason})
+		}
+	}
+	return results, repairs
+}
+
+// uniqueToolCalls drops tool uses repeating an ID used earlier in the message.
+func uniqueToolCalls(calls []entity.ToolCall, repairs []toolPairingRepair) ([]entity.ToolCall, []toolPairingRepair) {
+	seen := make(map[string]bool, len(calls))
+	unique := make([]entity.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		if seen[call.ToolID] {
+			repairs = append(repairs, toolPairingRepair{
+				action: "dropped duplicate tool use", toolID: call.ToolID, reason: "its ID is used earlier in the message",
+			})
+			continue
+		}
+		seen[call.ToolID] = true
+		unique = append(unique, call)
+	}
+	return unique, repairs
+}
+
+// lostResultTimestamp dates an inserted results message between the tool use
+// at index i and the message after it.
+func lostResultTimestamp(toolUse entity.Message, messages []entity.Message, i int) time.Time {
+	if i+1 < len(messages) && !messages[i+1].Timestamp.IsZero() {
+		return messages[i+1].Timestamp
+	}
+	if !toolUse.Timestamp.IsZero() {
+		return toolUse.Timestamp
+	}
+	return time.Now()
+}
+
+// repairHistory repairs the tool use and result pairing of a session's history
+// before it is sent to the AI provider, logging and persisting any changes.
+func (cs *ConversationService) repairHistory(
+	ctx context.Context,
+	sessionID string,
+	conversation *entity.Conversation,
+) error {
+	cs.mu.Lock()
+	repaired, repairs := repairToolPairing(conversation.Messages)
+	if len(repairs) > 0 {
+		conversation.Messages = repaired
+	}
+	logger := cs.logger
+	cs.mu.Unlock()
+	if len(repairs) == 0 {
+		return nil
+	}
+
+	logToolPairingRepairs(logger, sessionID, repairs)
+	return cs.persist(ctx, sessionID, conversation)
+}
+
+// logToolPairingRepairs logs each change made to a session's history.
+func logToolPairingRepairs(logger *slog.Logger, sessionID string, repairs []toolPairingRepair) {
+	for _, repair := range repairs {
+		logger.Warn("repaired conversation history",
+			"session_id", sessionID, "action", repair.action, "tool_id", repair.toolID, "reason", repair.reason)
+	}
+}
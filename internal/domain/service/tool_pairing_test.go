@@ -0,0 +1,372 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func userText(content string) entity.Message {
+	return entity.Message{Role: entity.RoleUser, Content: content, Timestamp: time.Unix(1, 0)}
+}
+
+func assistantText(content string) entity.Message {
+	return entity.Message{Role: entity.RoleAssistant, Content: content, Timestamp: time.Unix(1, 0)}
+}
+
+func toolUses(ids ...string) entity.Message {
+	msg := entity.Message{Role: entity.RoleAssistant, Content: "Using tools", Timestamp: time.Unix(1, 0)}
+	for _, id := range ids {
+		msg.ToolCalls = append(msg.ToolCalls, entity.ToolCall{ToolID: id, ToolName: "bash"})
+	}
+	return msg
+}
+
+func toolResults(ids ...string) entity.Message {
+	msg := entity.Message{Role: entity.RoleUser, Timestamp: time.Unix(1, 0)}
+	for _, id := range ids {
+		msg.ToolResults = append(msg.ToolResults, entity.ToolResult{ToolID: id, Result: "ok " + id})
+	}
+	return msg
+}
+
+// validHistory is a history with single, parallel, and repeated tool
+// exchanges, all paired.
+func validHistory() []entity.Message {
+	return []entity.Message{
+		userText("Fix the build"),
+		toolUses("t1"),
+		toolResults("t1"),
+		toolUses("t2", "t3"),
+		toolResults("t3", "t2"),
+		assistantText("Fixed it"),
+		userText("Run the tests"),
+		toolUses("t4"),
+		toolResults("t4"),
+		assistantText("They pass"),
+	}
+}
+
+func TestValidateToolPairing(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []entity.Message
+		wantErr  string
+	}{
+		{name: "valid", messages: validHistory()},
+		{name: "empty", messages: nil},
+		{
+			name:     "tool use last",
+			messages: []entity.Message{userText("Go"), toolUses("t1")},
+			wantErr:  "not followed by their results",
+		},
+		{
+			name:     "missing result",
+			messages: []entity.Message{userText("Go"), toolUses("t1", "t2"), toolResults("t1")},
+			wantErr:  "tool use t2 in message 1 has no result",
+		},
+		{
+			name:     "orphaned result",
+			messages: []entity.Message{userText("Go"), toolResults("t1")},
+			wantErr:  "has no tool use before it",
+		},
+		{
+			name:     "result for another tool use",
+			messages: []entity.Message{toolUses("t1"), toolResults("t1", "t9")},
+			wantErr:  "answers no tool use",
+		},
+		{
+			name:     "two results",
+			messages: []entity.Message{toolUses("t1"), toolResults("t1", "t1")},
+			wantErr:  "has two results",
+		},
+		{
+			name:     "repeated tool use",
+			messages: []entity.Message{toolUses("t1", "t1"), toolResults("t1")},
+			wantErr:  "appears twice",
+		},
+		{
+			name:     "text between tool use and result",
+			messages: []entity.Message{toolUses("t1"), assistantText("Waiting"), toolResults("t1")},
+			wantErr:  "not followed by their results",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateToolPairing(tt.messages)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateToolPairing() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrToolPairing) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateToolPairing() = %v, want an ErrToolPairing containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepairToolPairing_ValidHistoryUnchanged(t *testing.T) {
+	messages := validHistory()
+	repaired, repairs := repairToolPairing(messages)
+	if len(repairs) != 0 {
+		t.Errorf("repairs = %+v, want none", repairs)
+	}
+	if !reflect.DeepEqual(repaired, messages) {
+		t.Errorf("repaired = %+v, want the history unchanged", repaired)
+	}
+}
+
+func TestRepairToolPairing(t *testing.T) {
+	tests := []struct {
+		name        string
+		messages    []entity.Message
+		want        []entity.Message
+		wantActions []string
+	}{
+		{
+			name:     "interrupted tool use",
+			messages: []entity.Message{userText("Go"), toolUses("t1")},
+			want: []entity.Message{userText("Go"), toolUses("t1"), {
+				Role:        entity.RoleUser,
+				ToolResults: []entity.ToolResult{lostToolResult("t1", lostResultInterrupted)},
+				Timestamp:   time.Unix(1, 0),
+			}},
+			wantActions: []string{"inserted result t1"},
+		},
+		{
+			name:     "new message before the result",
+			messages: []entity.Message{toolUses("t1"), userText("Stop"), assistantText("Stopped")},
+			want: []entity.Message{toolUses("t1"), {
+				Role:        entity.RoleUser,
+				ToolResults: []entity.ToolResult{lostToolResult("t1", lostResultNewMessage)},
+				Timestamp:   time.Unix(1, 0),
+			}, userText("Stop"), assistantText("Stopped")},
+			wantActions: []string{"inserted result t1"},
+		},
+		{
+			name:     "one result of two missing",
+			messages: []entity.Message{toolUses("t1", "t2"), toolResults("t2")},
+			want: []entity.Message{toolUses("t1", "t2"), {
+				Role: entity.RoleUser,
+				ToolResults: []entity.ToolResult{
+					{ToolID: "t2", Result: "ok t2"}, lostToolResult("t1", lostResultMissing),
+				},
+				Timestamp: time.Unix(1, 0),
+			}},
+			wantActions: []string{"inserted result t1"},
+		},
+		{
+			name:        "orphaned result message dropped",
+			messages:    []entity.Message{userText("Go"), toolResults("t1"), assistantText("Done")},
+			want:        []entity.Message{userText("Go"), assistantText("Done")},
+			wantActions: []string{"dropped orphaned result t1"},
+		},
+		{
+			name:        "extra and second results dropped",
+			messages:    []entity.Message{toolUses("t1"), toolResults("t1", "t9", "t1")},
+			want:        []entity.Message{toolUses("t1"), toolResults("t1")},
+			wantActions: []string{"dropped orphaned result t9", "dropped orphaned result t1"},
+		},
+		{
+			name:        "repeated tool use dropped",
+			messages:    []entity.Message{toolUses("t1", "t1"), toolResults("t1")},
+			want:        []entity.Message{toolUses("t1"), toolResults("t1")},
+			wantActions: []string{"dropped duplicate tool use t1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repaired, repairs := repairToolPairing(tt.messages)
+			if !reflect.DeepEqual(repaired, tt.want) {
+				t.Errorf("repaired =\n%+v\nwant\n%+v", repaired, tt.want)
+			}
+			var actions []string
+			for _, repair := range repairs {
+				actions = append(actions, repair.action+" "+repair.toolID)
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("repairs = %q, want %q", actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+// mutateHistory breaks a valid history the ways a crash, a cancelled turn,
+// or a hand-edited session file might.
+func mutateHistory(rng *rand.Rand, messages []entity.Message) []entity.Message {
+	messages = cloneMessages(messages)
+	if len(messages) == 0 {
+		return messages
+	}
+	i := rng.Intn(len(messages))
+	switch rng.Intn(8) {
+	case 0: // Drop a message
+		return append(messages[:i], messages[i+1:]...)
+	case 1: // Repeat a message
+		return slices.Insert(messages, i, messages[i])
+	case 2: // Swap two messages
+		j := rng.Intn(len(messages))
+		messages[i], messages[j] = messages[j], messages[i]
+	case 3: // Stop partway through
+		return messages[:i]
+	case 4: // Lose one of several tool results; losing the only one is case 0
+		if n := len(messages[i].ToolResults); n > 1 {
+			k := rng.Intn(n)
+			messages[i].ToolResults = append(messages[i].ToolResults[:k], messages[i].ToolResults[k+1:]...)
+		}
+	case 5: // Lose one tool use
+		if n := len(messages[i].ToolCalls); n > 0 {
+			k := rng.Intn(n)
+			messages[i].ToolCalls = append(messages[i].ToolCalls[:k], messages[i].ToolCalls[k+1:]...)
+		}
+	case 6: // Repeat a tool use
+		if n := len(messages[i].ToolCalls); n > 0 {
+			messages[i].ToolCalls = append(messages[i].ToolCalls, messages[i].ToolCalls[rng.Intn(n)])
+		}
+	case 7: // Insert a message between a tool use and its results
+		return slices.Insert(messages, i+1, userText("Interrupting"))
+	}
+	return messages
+}
+
+// cloneMessages copies messages deeply enough for mutateHistory to change the
+// copy's tool uses and results.
+func cloneMessages(messages []entity.Message) []entity.Message {
+	cloned := make([]entity.Message, len(messages))
+	for i, msg := range messages {
+		msg.ToolCalls = append([]entity.ToolCall(nil), msg.ToolCalls...)
+		msg.ToolResults = append([]entity.ToolResult(nil), msg.ToolResults...)
+		cloned[i] = msg
+	}
+	return cloned
+}
+
+func TestRepairToolPairing_RepairsMutatedHistories(t *testing.T) {
+	rng := rand.New(rand.NewSource(1381)) //nolint:gosec // deterministic test input
+	for run := range 2000 {
+		messages := validHistory()
+		for range 1 + rng.Intn(4) {
+			messages = mutateHistory(rng, messages)
+		}
+
+		repaired, _ := repairToolPairing(messages)
+		if err := validateToolPairing(repaired); err != nil {
+			t.Fatalf("run %d: repaired history is invalid: %v\ninput:    %s\nrepaired: %s",
+				run, err, describeHistory(messages), describeHistory(repaired))
+		}
+		again, repairs := repairToolPairing(repaired)
+		if len(repairs) != 0 || !reflect.DeepEqual(again, repaired) {
+			t.Fatalf("run %d: repairing twice changed the history again: %+v", run, repairs)
+		}
+		for _, msg := range repaired {
+			if msg.Timestamp.IsZero() {
+				t.Fatalf("run %d: repaired history has a message without a timestamp", run)
+			}
+			if err := msg.Validate(); err != nil {
+				t.Fatalf("run %d: repaired history has an invalid message: %v", run, err)
+			}
+		}
+	}
+}
+
+// describeHistory summarizes the tool pairing of a history for failure
+// messages, e.g. "user[t1] assistant{t2}".
+func describeHistory(messages []entity.Message) string {
+	parts := make([]string, len(messages))
+	for i, msg := range messages {
+		var ids []string
+		for _, call := range msg.ToolCalls {
+			ids = append(ids, "{"+call.ToolID+"}")
+		}
+		for _, result := range msg.ToolResults {
+			ids = append(ids, "["+result.ToolID+"]")
+		}
+		parts[i] = fmt.Sprintf("%s%s", msg.Role, strings.Join(ids, ""))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestConversationService_ProcessAssistantResponse_RepairsInterruptedToolUse(t *testing.T) {
+	store := &memoryConversationStore{}
+	cs, err := NewConversationService(toolUseProvider(), &mockToolExecutor{})
+	if err != nil {
+		t.Fatalf("NewConversationService failed: %v", err)
+	}
+	cs.SetConversationStore(store)
+	ctx := context.Background()
+	sessionID, err := cs.StartConversation(ctx)
+	if err != nil {
+		t.Fatalf("StartConversation failed: %v", err)
+	}
+	if _, err := cs.AddUserMessage(ctx, sessionID, "List the files"); err != nil {
+		t.Fatalf("AddUserMessage failed: %v", err)
+	}
+	if _, _, err := cs.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Fatalf("ProcessAssistantResponse failed: %v", err)
+	}
+
+	// The tool never returned; the user asks again
+	if _, err := cs.AddUserMessage(ctx, sessionID, "Never mind"); err != nil {
+		t.Fatalf("AddUserMessage failed: %v", err)
+	}
+	if _, _, err := cs.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Fatalf("ProcessAssistantResponse failed: %v", err)
+	}
+
+	conversation, _ := cs.GetConversation(sessionID)
+	messages := conversation.GetMessages()
+	if len(messages) != 5 {
+		t.Fatalf("history = %s, want the synthetic result after the tool use", describeHistory(messages))
+	}
+	lost := messages[2].ToolResults
+	if len(lost) != 1 || lost[0].ToolID != "tool-1" || !lost[0].IsError ||
+		lost[0].Result != "result lost: "+lostResultNewMessage {
+		t.Errorf("message 2 results = %+v, want a lost result for tool-1", lost)
+	}
+	if err := validateToolPairing(messages[:4]); err != nil {
+		t.Errorf("history sent to the provider is invalid: %v", err)
+	}
+	saved, _ := store.LoadConversation(ctx, sessionID)
+	if len(saved) != 5 || len(saved[2].ToolResults) != 1 {
+		t.Errorf("saved history = %s, want the repair persisted", describeHistory(saved))
+	}
+}
+
+func TestConversationService_RestoreConversation_RepairsHistory(t *testing.T) {
+	store := &memoryConversationStore{}
+	ctx := context.Background()
+	_ = store.SaveConversation(ctx, "saved", []entity.Message{
+		userText("List the files"),
+		toolUses("t1", "t2"),
+		toolResults("t2", "t7"),
+		userText("And the tests?"),
+		toolUses("t3"),
+	})
+	cs, err := NewConversationService(&mockAIProvider{}, &mockToolExecutor{})
+	if err != nil {
+		t.Fatalf("NewConversationService failed: %v", err)
+	}
+	cs.SetConversationStore(store)
+
+	if err := cs.RestoreConversation(ctx, "saved"); err != nil {
+		t.Fatalf("RestoreConversation failed: %v", err)
+	}
+	conversation, _ := cs.GetConversation("saved")
+	messages := conversation.GetMessages()
+	if err := validateToolPairing(messages); err != nil {
+		t.Errorf("restored history = %s is invalid: %v", describeHistory(messages), err)
+	}
+	if got, want := describeHistory(messages),
+		"user assistant{t1}{t2} user[t2][t1] user assistant{t3} user[t3]"; got != want {
+		t.Errorf("restored history = %s, want %s", got, want)
+	}
+}
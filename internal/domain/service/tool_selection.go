@@ -0,0 +1,254 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RequestToolName is the meta-tool through which the model asks for tools the
+// ToolSelector withheld.
+const RequestToolName = "request_tool"
+
+// defaultRecentMessages is how many of the latest messages a ToolSelector
+// scans for keywords when its config does not say.
+const defaultRecentMessages = 6
+
+// ToolGroup is a set of specialized tools offered only when the conversation
+// mentions one of its keywords.
+type ToolGroup struct {
+	Name     string   // e.g. "kubernetes"
+	Tools    []string // Tool names in the group
+	Keywords []string // Whole words, matched case-insensitively
+}
+
+// DefaultToolGroups returns the specialized tool groups: Kubernetes,
+// Prometheus, git, log search, and host inspection. Every other tool is core
+// and always offered.
+func DefaultToolGroups() []ToolGroup {
+	return []ToolGroup{
+		{
+			Name:  "kubernetes",
+			Tools: []string{"k8s_inspect"},
+			Keywords: []string{
+				"kubernetes", "k8s", "kubectl", "pod", "pods", "deployment", "deployments", "namespace",
+				"node", "nodes", "container", "containers", "crashloopbackoff", "oomkilled",
+			},
+		},
+		{
+			Name:     "prometheus",
+			Tools:    []string{"promql_query"},
+			Keywords: []string{"prometheus", "promql", "metric", "metrics", "alertmanager", "grafana"},
+		},
+		{
+			Name:     "git",
+			Tools:    []string{"git_status", "git_diff", "git_commit", "git_push"},
+			Keywords: []string{"git", "commit", "commits", "diff", "branch", "push", "rebase", "merge"},
+		},
+		{
+			Name:     "logs",
+			Tools:    []string{"query_logs"},
+			Keywords: []string{"log", "logs", "loki", "elasticsearch", "journal"},
+		},
+		{
+			Name:     "host",
+			Tools:    []string{"system_snapshot"},
+			Keywords: []string{"cpu", "memory", "disk", "load", "process", "processes", "host"},
+		},
+	}
+}
+
+// ToolSelectorConfig configures a ToolSelector.
+type ToolSelectorConfig struct {
+	Groups         []ToolGroup // Specialized tool groups; nil uses DefaultToolGroups()
+	RecentMessages int         // Latest messages scanned for keywords; 0 uses 6
+}
+
+// ToolSelection is the tool list a ToolSelector chose for one turn.
+type ToolSelection struct {
+	Offered       []entity.Tool // Sorted by name
+	Withheld      []string      // Names of the candidate tools not offered, sorted
+	Groups        []string      // Names of the groups offered, in config order
+	OfferedTokens int           // Estimated tokens of the offered tool schemas
+	SavedTokens   int           // Estimated tokens of the withheld tool schemas, less request_tool's
+}
+
+// ToolSelector narrows the tools offered to the model each turn to a core set
+// plus the specialized groups the conversation calls for, so a turn does not
+// pay for schemas it will not use. A group is offered when one of its
+// keywords appears in the system prompt, the first message, or the latest
+// messages; a tool stays offered once it has been called; and the request_tool
+// meta-tool lets the model ask for anything withheld. The selection depends
+// only on its inputs, so a turn offers the same tools however often it is
+// prepared. It is safe for concurrent use.
+type ToolSelector struct {
+	groups         []ToolGroup
+	patterns       []*regexp.Regexp // Keyword pattern of each group
+	grouped        map[string]bool  // Names of the tools in a group
+	recentMessages int
+}
+
+// NewToolSelector creates a ToolSelector.
+func NewToolSelector(config ToolSelectorConfig) *ToolSelector {
+	groups := config.Groups
+	if groups == nil {
+		groups = DefaultToolGroups()
+	}
+	recent := config.RecentMessages
+	if recent <= 0 {
+		recent = defaultRecentMessages
+	}
+
+	s := &ToolSelector{groups: groups, grouped: make(map[string]bool), recentMessages: recent}
+	for _, group := range groups {
+		words := make([]string, len(group.Keywords))
+		for i, keyword := range group.Keywords {
+			words[i] = regexp.QuoteMeta(strings.ToLower(keyword))
+		}
+		var pattern *regexp.Regexp
+		if len(words) > 0 {
+			pattern = regexp.MustCompile(`\b(?:` + strings.Join(words, "|") + `)\b`)
+		}
+		s.patterns = append(s.patterns, pattern)
+		for _, name := range group.Tools {
+			s.grouped[name] = true
+		}
+	}
+	return s
+}
+
+// Select chooses the tools to offer for a turn of a conversation with the
+// given system prompt and messages. Without request_tool among the candidates
+// the model could not ask for a withheld tool, so every candidate is offered.
+func (s *ToolSelector) Select(tools []entity.Tool, systemPrompt string, messages []entity.Message) ToolSelection {
+	candidates := make([]entity.Tool, 0, len(tools))
+	var requestTool *entity.Tool
+	for i := range tools {
+		if tools[i].Name == RequestToolName {
+			requestTool = &tools[i]
+			continue
+		}
+		candidates = append(candidates, tools[i])
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	baseline := estimateToolTokens(candidates)
+	if requestTool == nil {
+		return ToolSelection{Offered: candidates, OfferedTokens: baseline}
+	}
+
+	wanted, groups, everything := s.wanted(systemPrompt, messages)
+	var selection ToolSelection
+	selection.Groups = groups
+	for _, tool := range candidates {
+		if everything || !s.grouped[tool.Name] || wanted[tool.Name] {
+			selection.Offered = append(selection.Offered, tool)
+		} else {
+			selection.Withheld = append(selection.Withheld, tool.Name)
+		}
+	}
+
+	if len(selection.Withheld) > 0 {
+		meta := *requestTool
+		meta.Description = fmt.Sprintf("%s Tools available on request: %s.",
+			requestTool.Description, strings.Join(selection.Withheld, ", "))
+		selection.Offered = append(selection.Offered, meta)
+		sort.Slice(selection.Offered, func(i, j int) bool { return selection.Offered[i].Name < selection.Offered[j].Name })
+	}
+	selection.OfferedTokens = estimateToolTokens(selection.Offered)
+	selection.SavedTokens = max(baseline-selection.OfferedTokens, 0)
+	return selection
+}
+
+// wanted returns the names of the grouped tools the conversation calls for
+// and the groups matched by keyword, or everything if the model asked for the
+// full catalog.
+func (s *ToolSelector) wanted(systemPrompt string, messages []entity.Message) (map[string]bool, []string, bool) {
+	wanted := make(map[string]bool)
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls {
+			if call.ToolName != RequestToolName {
+				wanted[call.ToolName] = true
+				continue
+			}
+			names := RequestedToolNames(call.Input)
+			if len(names) == 0 {
+				return nil, nil, true
+			}
+			for _, name := range names {
+				wanted[name] = true
+			}
+		}
+	}
+
+	text := strings.ToLower(s.scannedText(systemPrompt, messages))
+	var groups []string
+	for i, group := range s.groups {
+		if s.patterns[i] == nil || !s.patterns[i].MatchString(text) {
+			continue
+		}
+		groups = append(groups, group.Name)
+		for _, name := range group.Tools {
+			wanted[name] = true
+		}
+	}
+	return wanted, groups, false
+}
+
+// scannedText joins the text searched for keywords: the system prompt, the
+// first message, which usually states the task, and the latest messages.
+func (s *ToolSelector) scannedText(systemPrompt string, messages []entity.Message) string {
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+	write := func(msg entity.Message) {
+		b.WriteString("\n")
+		b.WriteString(msg.Content)
+		for _, result := range msg.ToolResults {
+			b.WriteString("\n")
+			b.WriteString(result.Result)
+		}
+	}
+	start := max(len(messages)-s.recentMessages, 0)
+	if start > 0 {
+		write(messages[0])
+	}
+	for _, msg := range messages[start:] {
+		write(msg)
+	}
+	return b.String()
+}
+
+// RequestedToolNames returns the tool names in a request_tool input, or none
+// when the model asked for every tool.
+func RequestedToolNames(input map[string]interface{}) []string {
+	var raw []string
+	switch values := input["tools"].(type) {
+	case []string:
+		raw = values
+	case []interface{}:
+		for _, value := range values {
+			if name, ok := value.(string); ok {
+				raw = append(raw, name)
+			}
+		}
+	}
+	names := make([]string, 0, len(raw))
+	for _, name := range raw {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// estimateToolTokens estimates the tokens the tools' definitions add to a request.
+func estimateToolTokens(tools []entity.Tool) int {
+	total := 0
+	for _, tool := range tools {
+		schema, _ := json.Marshal(tool.InputSchema)
+		total += entity.EstimateTokens(tool.Name + tool.Description + string(schema))
+	}
+	return total
+}
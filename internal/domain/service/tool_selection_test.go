@@ -0,0 +1,232 @@
+package service
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// selectionCatalog returns the tools a ToolSelector chooses from, in no
+// particular order.
+func selectionCatalog() []entity.Tool {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+		"query": map[string]interface{}{"type": "string", "description": "What to look up"},
+	}}
+	var tools []entity.Tool
+	for _, name := range []string{
+		"read_file", "promql_query", "bash", "git_status", "k8s_inspect", "git_diff", "edit_file", RequestToolName,
+	} {
+		tools = append(tools, entity.Tool{
+			ID: name, Name: name, Description: "The " + name + " tool, described at length.", InputSchema: schema,
+		})
+	}
+	return tools
+}
+
+func toolNames(tools []entity.Tool) []string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
+func TestToolSelector_CoreSet(t *testing.T) {
+	selector := NewToolSelector(ToolSelectorConfig{})
+	messages := []entity.Message{{Role: entity.RoleUser, Content: "Tidy up the README wording"}}
+
+	selection := selector.Select(selectionCatalog(), "", messages)
+
+	want := []string{"bash", "edit_file", "read_file", RequestToolName}
+	if got := toolNames(selection.Offered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Offered = %q, want %q", got, want)
+	}
+	wantWithheld := []string{"git_diff", "git_status", "k8s_inspect", "promql_query"}
+	if !reflect.DeepEqual(selection.Withheld, wantWithheld) {
+		t.Errorf("Withheld = %q, want %q", selection.Withheld, wantWithheld)
+	}
+	if selection.SavedTokens <= 0 || selection.OfferedTokens <= 0 {
+		t.Errorf("OfferedTokens = %d, SavedTokens = %d; want both positive",
+			selection.OfferedTokens, selection.SavedTokens)
+	}
+	meta := selection.Offered[len(selection.Offered)-1]
+	if !strings.Contains(meta.Description, "git_diff, git_status, k8s_inspect, promql_query") {
+		t.Errorf("request_tool description = %q, want it to list the withheld tools", meta.Description)
+	}
+}
+
+func TestToolSelector_KeywordsOfferGroups(t *testing.T) {
+	tests := []struct {
+		name         string
+		systemPrompt string
+		messages     []entity.Message
+		wantOffered  []string
+		wantGroups   []string
+	}{
+		{
+			name:         "alert in the system prompt",
+			systemPrompt: "Investigate the alert: Pod api-7f9 is in CrashLoopBackOff",
+			messages:     []entity.Message{{Role: entity.RoleUser, Content: "Start the investigation"}},
+			wantOffered:  []string{"bash", "edit_file", "k8s_inspect", "read_file", RequestToolName},
+			wantGroups:   []string{"kubernetes"},
+		},
+		{
+			name: "recent tool result",
+			messages: []entity.Message{
+				{Role: entity.RoleUser, Content: "Why is checkout slow?"},
+				{Role: entity.RoleAssistant, ToolCalls: []entity.ToolCall{{ToolID: "t1", ToolName: "bash"}}},
+				{Role: entity.RoleUser, ToolResults: []entity.ToolResult{{ToolID: "t1", Result: "See the Grafana metrics"}}},
+			},
+			wantOffered: []string{"bash", "edit_file", "promql_query", "read_file", RequestToolName},
+			wantGroups:  []string{"prometheus"},
+		},
+		{
+			name:        "keywords match whole words only",
+			messages:    []entity.Message{{Role: entity.RoleUser, Content: "Rename the digits helper in pods_test"}},
+			wantOffered: []string{"bash", "edit_file", "read_file", RequestToolName},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection := NewToolSelector(ToolSelectorConfig{}).Select(selectionCatalog(), tt.systemPrompt, tt.messages)
+			if got := toolNames(selection.Offered); !reflect.DeepEqual(got, tt.wantOffered) {
+				t.Errorf("Offered = %q, want %q", got, tt.wantOffered)
+			}
+			if !reflect.DeepEqual(selection.Groups, tt.wantGroups) {
+				t.Errorf("Groups = %q, want %q", selection.Groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestToolSelector_KeywordsOnlyInOldMessagesLapse(t *testing.T) {
+	messages := []entity.Message{{Role: entity.RoleUser, Content: "Fix the typo"}}
+	messages = append(messages, entity.Message{Role: entity.RoleAssistant, Content: "The kubectl output is fine"})
+	for range 3 {
+		messages = append(messages, entity.Message{Role: entity.RoleUser, Content: "Next"})
+	}
+	selector := NewToolSelector(ToolSelectorConfig{RecentMessages: 2})
+
+	if got := toolNames(selector.Select(selectionCatalog(), "", messages).Offered); slices.Contains(got, "k8s_inspect") {
+		t.Errorf("Offered = %q, want k8s_inspect withheld once its keyword is no longer recent", got)
+	}
+}
+
+func TestToolSelector_RequestToolEscapeHatch(t *testing.T) {
+	requested := func(input map[string]interface{}) []entity.Message {
+		return []entity.Message{
+			{Role: entity.RoleUser, Content: "Tidy up the README wording"},
+			{Role: entity.RoleAssistant, ToolCalls: []entity.ToolCall{
+				{ToolID: "t1", ToolName: RequestToolName, Input: input},
+			}},
+			{Role: entity.RoleUser, ToolResults: []entity.ToolResult{{ToolID: "t1", Result: "ok"}}},
+		}
+	}
+	selector := NewToolSelector(ToolSelectorConfig{})
+
+	named := selector.Select(selectionCatalog(), "", requested(map[string]interface{}{
+		"tools": []interface{}{"git_diff"},
+	}))
+	want := []string{"bash", "edit_file", "git_diff", "read_file", RequestToolName}
+	if got := toolNames(named.Offered); !reflect.DeepEqual(got, want) {
+		t.Errorf("after requesting git_diff, Offered = %q, want %q", got, want)
+	}
+
+	all := selector.Select(selectionCatalog(), "", requested(map[string]interface{}{}))
+	wantAll := []string{"bash", "edit_file", "git_diff", "git_status", "k8s_inspect", "promql_query", "read_file"}
+	if got := toolNames(all.Offered); !reflect.DeepEqual(got, wantAll) {
+		t.Errorf("after requesting everything, Offered = %q, want %q", got, wantAll)
+	}
+	if all.SavedTokens != 0 || len(all.Withheld) != 0 {
+		t.Errorf("after requesting everything, SavedTokens = %d, Withheld = %q; want none", all.SavedTokens, all.Withheld)
+	}
+}
+
+func TestToolSelector_CalledToolsStayOffered(t *testing.T) {
+	messages := []entity.Message{
+		{Role: entity.RoleUser, Content: "Tidy up the README wording"},
+		{Role: entity.RoleAssistant, ToolCalls: []entity.ToolCall{{ToolID: "t1", ToolName: "git_status"}}},
+		{Role: entity.RoleUser, ToolResults: []entity.ToolResult{{ToolID: "t1", Result: "clean"}}},
+	}
+
+	got := toolNames(NewToolSelector(ToolSelectorConfig{}).Select(selectionCatalog(), "", messages).Offered)
+	if !slices.Contains(got, "git_status") || slices.Contains(got, "git_diff") {
+		t.Errorf("Offered = %q, want git_status but not the rest of its group", got)
+	}
+}
+
+func TestToolSelector_WithoutRequestToolOffersEverything(t *testing.T) {
+	var tools []entity.Tool
+	for _, tool := range selectionCatalog() {
+		if tool.Name != RequestToolName {
+			tools = append(tools, tool)
+		}
+	}
+
+	selection := NewToolSelector(ToolSelectorConfig{}).Select(tools, "", nil)
+	if len(selection.Offered) != len(tools) || selection.SavedTokens != 0 {
+		t.Errorf("Offered %q saving %d tokens, want every tool and no savings",
+			toolNames(selection.Offered), selection.SavedTokens)
+	}
+}
+
+func TestToolSelector_Deterministic(t *testing.T) {
+	selector := NewToolSelector(ToolSelectorConfig{})
+	messages := []entity.Message{{Role: entity.RoleUser, Content: "Check the git branch and the pod logs"}}
+	first := selector.Select(selectionCatalog(), "", messages)
+
+	for range 20 {
+		catalog := selectionCatalog()
+		// Reverse the catalog, as a map-backed executor may list it in any order
+		for i, j := 0, len(catalog)-1; i < j; i, j = i+1, j-1 {
+			catalog[i], catalog[j] = catalog[j], catalog[i]
+		}
+		if got := selector.Select(catalog, "", messages); !reflect.DeepEqual(got, first) {
+			t.Fatalf("Select() = %+v, want the same selection %+v", got, first)
+		}
+	}
+}
+
+func TestConversationService_ToolSelection(t *testing.T) {
+	provider := &toolCapturingMockAIProvider{}
+	executor := &mockToolExecutor{}
+	for _, tool := range selectionCatalog() {
+		_ = executor.RegisterTool(tool)
+	}
+	service, err := NewConversationService(provider, executor)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	service.SetToolSelector(NewToolSelector(ToolSelectorConfig{}))
+
+	ctx := context.Background()
+	sessionID, _ := service.StartConversation(ctx)
+	_, _ = service.AddUserMessage(ctx, sessionID, "Is the deployment healthy?")
+	if _, _, err := service.ProcessAssistantResponse(ctx, sessionID); err != nil {
+		t.Fatalf("ProcessAssistantResponse() error = %v", err)
+	}
+
+	var got []string
+	for _, tool := range provider.capturedTools {
+		got = append(got, tool.Name)
+	}
+	want := []string{"bash", "edit_file", "k8s_inspect", "read_file", RequestToolName}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("offered %q, want %q", got, want)
+	}
+	stats, ok := service.ToolSelectionStats(sessionID)
+	if !ok || stats.Turns != 1 || stats.SavedTokens <= 0 {
+		t.Errorf("ToolSelectionStats() = %+v, %v; want one turn with tokens saved", stats, ok)
+	}
+
+	if err := service.EndConversation(ctx, sessionID); err != nil {
+		t.Fatalf("EndConversation() error = %v", err)
+	}
+	if _, ok := service.ToolSelectionStats(sessionID); ok {
+		t.Error("ToolSelectionStats() kept the stats of an ended session")
+	}
+}
@@ -0,0 +1,44 @@
+// Package aitest provides test helpers that stand in for the Anthropic
+// Messages API, kept apart from the ai adapter so production code never
+// imports the testing package.
+package aitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// ScriptedAnthropicServer serves canned Messages API responses in order,
+// failing the test on any request past the last one. Point the Anthropic
+// adapter at it with ANTHROPIC_BASE_URL. The server is closed when the test
+// ends.
+func ScriptedAnthropicServer(tb testing.TB, responses ...string) *httptest.Server {
+	tb.Helper()
+	var mu sync.Mutex
+	next := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(responses) {
+			tb.Errorf("unexpected request %d to the AI provider", next+1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[next]))
+		next++
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// AnthropicResponse builds a Messages API response with the given content
+// blocks, a comma-separated list of JSON objects, and token usage.
+func AnthropicResponse(stopReason, content string, inputTokens, outputTokens int) string {
+	return `{"id":"msg","type":"message","role":"assistant","model":"test-model",` +
+		`"content":[` + content + `],"stop_reason":"` + stopReason + `",` +
+		`"usage":{"input_tokens":` + strconv.Itoa(inputTokens) + `,"output_tokens":` + strconv.Itoa(outputTokens) + `}}`
+}
@@ -15,16 +15,25 @@
 package ai
 
 import (
+	"code-editing-agent/internal/application/usecase"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/anthropics/anthropic-sdk-go/shared/constant"
 )
 
@@ -37,6 +46,30 @@ var (
 
 	// ErrClientHealthCheck is returned when the AI provider health check fails.
 	ErrClientHealthCheck = errors.New("AI provider health check failed")
+
+	// ErrOverloaded is returned when the API is still overloaded (HTTP 529)
+	// after the client's retries.
+	ErrOverloaded = errors.New("AI provider is overloaded")
+
+	// ErrTruncatedToolUse is returned when a response hits max_tokens in the
+	// middle of a tool call, whose input cannot be continued.
+	ErrTruncatedToolUse = errors.New("response reached max_tokens inside a tool call")
+)
+
+const (
+	// providerName identifies this adapter in AI request metrics.
+	providerName = "anthropic"
+
+	// DefaultMaxContinuations is how many continuation requests are made for a
+	// response that stops at max_tokens before it is returned truncated.
+	DefaultMaxContinuations = 3
+
+	// DefaultMaxRetries is how many times a request that fails with a
+	// retryable error (overloaded, rate limited, 5xx, connection error) is retried.
+	DefaultMaxRetries = 2
+
+	// statusOverloaded is the HTTP status the API returns when it is overloaded.
+	statusOverloaded = 529
 )
 
 // AnthropicAdapter implements the AIProvider port using Anthropic's API.
@@ -46,10 +79,17 @@ var (
 // The struct maintains an internal Anthropic client and model configuration,
 // allowing for consistent model usage across all requests.
 type AnthropicAdapter struct {
-	client          anthropic.Client
-	model           string
-	maxTokens       int64
-	subagentManager port.SubagentManager
+	client           anthropic.Client
+	model            string       // Default model; see SetModel
+	modelMu          sync.RWMutex // Protects model, which :model may switch while runners send
+	maxTokens        int64
+	maxContinuations int
+	maxRetries       int
+	subagentManager  port.SubagentManager
+	metrics          port.MetricsRecorder
+	tracer           port.Tracer
+	memory           string              // appended to the base prompt; see SetMemory
+	capabilities     *CapabilityRegistry // nil assumes every model supports every feature
 }
 
 // NewAnthropicAdapter creates a new AnthropicAdapter with the specified model.
@@ -68,10 +108,12 @@ func NewAnthropicAdapter(
 	subagentManager port.SubagentManager,
 ) port.AIProvider {
 	return &AnthropicAdapter{
-		client:          anthropic.NewClient(),
-		model:           model,
-		maxTokens:       maxTokens,
-		subagentManager: subagentManager,
+		client:           anthropic.NewClient(),
+		model:            model,
+		maxTokens:        maxTokens,
+		maxContinuations: DefaultMaxContinuations,
+		maxRetries:       DefaultMaxRetries,
+		subagentManager:  subagentManager,
 	}
 }
 
@@ -80,7 +122,8 @@ func NewAnthropicAdapter(
 // converting it back to domain entity types.
 //
 // The method supports both regular text messages and tool use. If the AI responds with
-// tool use, those will be included in the returned message's content.
+// tool use, those will be included in the returned message's content. A response that
+// stops at max_tokens is continued and stitched together (see SetMaxContinuations).
 //
 // Parameters:
 //   - ctx: Context for the request (supports cancellation and timeout)
@@ -95,15 +138,35 @@ func (a *AnthropicAdapter) SendMessage(
 	ctx context.Context,
 	messages []port.MessageParam,
 	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return a.SendMessageWithOptions(ctx, messages, tools, port.RequestOptions{})
+}
+
+// SendMessageWithOptions is SendMessage with per-request options: opts.Model
+// replaces the default model and opts.MaxTokens the configured max tokens,
+// for this request only. Both are still capped by the model's capabilities.
+// Concurrent requests with different models are safe.
+func (a *AnthropicAdapter) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
 ) (*entity.Message, []port.ToolCallInfo, error) {
 	// Validate inputs
 	if len(messages) == 0 {
 		return nil, nil, ErrEmptyMessages
 	}
-	if a.model == "" {
+	model := a.requestModel(opts)
+	if model == "" {
 		return nil, nil, ErrModelNotSet
 	}
 
+	// Read attached image files so a missing or invalid image fails before sending
+	messages, err := loadImageFiles(messages)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Convert port messages to Anthropic SDK messages
 	anthropicMessages := a.convertMessages(messages)
 
@@ -113,23 +176,41 @@ func (a *AnthropicAdapter) SendMessage(
 	// Get system prompt (may be modified if plan mode is active, includes skill metadata)
 	systemPrompt := a.getSystemPrompt(ctx)
 
-	// Build thinking config from context
+	// Build thinking config from context, if the model can think
+	caps, _ := a.ModelCapabilities(model)
 	thinkingConfig := anthropic.ThinkingConfigParamUnion{OfDisabled: &anthropic.ThinkingConfigDisabledParam{}}
-	if thinkingInfo, ok := port.ThinkingModeFromContext(ctx); ok && thinkingInfo.Enabled {
+	if thinkingInfo, ok := port.ThinkingModeFromContext(ctx); ok && thinkingInfo.Enabled && caps.SupportsThinking {
 		thinkingConfig = anthropic.ThinkingConfigParamOfEnabled(thinkingInfo.BudgetTokens)
 	}
+	if !caps.SupportsTools {
+		anthropicTools = nil
+	}
 
-	// Call Anthropic API
-	response, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(a.model),
-		MaxTokens: a.maxTokens,
+	// Call Anthropic API, recording each request (including continuations)
+	send := func(params anthropic.MessageNewParams) (*anthropic.Message, error) {
+		ctx, span := port.StartSpan(ctx, a.tracer, port.SpanAIRequest)
+		start := time.Now()
+		response, err := a.client.Messages.New(ctx, params, option.WithMaxRetries(a.maxRetries))
+		var usage anthropic.Usage
+		if response != nil {
+			usage = response.Usage
+		}
+		a.recordRequest(span, start, model, usage, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send message: %w", wrapOverloaded(err))
+		}
+		return response, nil
+	}
+	response, err := a.sendWithContinuations(anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: a.outputTokens(caps, opts.MaxTokens),
 		Messages:  anthropicMessages,
 		System:    []anthropic.TextBlockParam{{Text: systemPrompt}},
 		Thinking:  thinkingConfig,
 		Tools:     anthropicTools,
-	})
+	}, send)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to send message: %w", err)
+		return nil, nil, err
 	}
 
 	// Convert response to domain Message and extract tool info
@@ -165,10 +246,17 @@ func (a *AnthropicAdapter) SendMessageStreaming(
 	if len(messages) == 0 {
 		return nil, nil, ErrEmptyMessages
 	}
-	if a.model == "" {
+	model := a.GetModel()
+	if model == "" {
 		return nil, nil, ErrModelNotSet
 	}
 
+	// Read attached image files so a missing or invalid image fails before sending
+	messages, err := loadImageFiles(messages)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Convert port messages to Anthropic SDK messages
 	anthropicMessages := a.convertMessages(messages)
 
@@ -178,29 +266,62 @@ func (a *AnthropicAdapter) SendMessageStreaming(
 	// Get system prompt (may be modified if plan mode is active, includes skill metadata)
 	systemPrompt := a.getSystemPrompt(ctx)
 
-	// Build thinking config from context
+	// Build thinking config from context, if the model can think
+	caps, _ := a.ModelCapabilities(model)
 	thinkingConfig := anthropic.ThinkingConfigParamUnion{OfDisabled: &anthropic.ThinkingConfigDisabledParam{}}
-	if thinkingInfo, ok := port.ThinkingModeFromContext(ctx); ok && thinkingInfo.Enabled {
+	if thinkingInfo, ok := port.ThinkingModeFromContext(ctx); ok && thinkingInfo.Enabled && caps.SupportsThinking {
 		thinkingConfig = anthropic.ThinkingConfigParamOfEnabled(thinkingInfo.BudgetTokens)
 	}
+	if !caps.SupportsTools {
+		anthropicTools = nil
+	}
 
-	// Create streaming request
-	stream := a.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(a.model),
-		MaxTokens: a.maxTokens,
+	// Stream the response, recording each request (including continuations) once it has finished
+	send := func(params anthropic.MessageNewParams) (*anthropic.Message, error) {
+		ctx, span := port.StartSpan(ctx, a.tracer, port.SpanAIRequest)
+		start := time.Now()
+		message, err := a.streamMessage(ctx, params, textCallback, thinkingCallback)
+		a.recordRequest(span, start, model, message.Usage, err)
+		if err != nil {
+			return nil, wrapOverloaded(err)
+		}
+		return message, nil
+	}
+	message, err := a.sendWithContinuations(anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: a.outputTokens(caps, 0),
 		Messages:  anthropicMessages,
 		System:    []anthropic.TextBlockParam{{Text: systemPrompt}},
 		Thinking:  thinkingConfig,
 		Tools:     anthropicTools,
-	})
+	}, send)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert accumulated message to domain Message and extract tool info
+	return a.convertResponse(message)
+}
+
+// streamMessage sends a streaming request and accumulates the response, calling
+// the callbacks for text and thinking deltas. The returned message is never nil;
+// on error it holds whatever was received, including usage, before the failure.
+func (a *AnthropicAdapter) streamMessage(
+	ctx context.Context,
+	params anthropic.MessageNewParams,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*anthropic.Message, error) {
+	// Create streaming request
+	stream := a.client.Messages.NewStreaming(ctx, params, option.WithMaxRetries(a.maxRetries))
 
 	// Accumulate the message as events arrive
-	message := anthropic.Message{}
+	message := &anthropic.Message{}
 	for stream.Next() {
 		event := stream.Current()
 		err := message.Accumulate(event)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to accumulate event: %w", err)
+			return message, fmt.Errorf("failed to accumulate event: %w", err)
 		}
 
 		// Handle content block deltas (text and thinking)
@@ -213,7 +334,7 @@ func (a *AnthropicAdapter) SendMessageStreaming(
 		if textDelta, ok := eventVariant.Delta.AsAny().(anthropic.TextDelta); ok {
 			if textCallback != nil {
 				if err := textCallback(textDelta.Text); err != nil {
-					return nil, nil, fmt.Errorf("text stream callback error: %w", err)
+					return message, fmt.Errorf("text stream callback error: %w", err)
 				}
 			}
 		}
@@ -222,7 +343,7 @@ func (a *AnthropicAdapter) SendMessageStreaming(
 		if thinkingDelta, ok := eventVariant.Delta.AsAny().(anthropic.ThinkingDelta); ok {
 			if thinkingCallback != nil {
 				if err := thinkingCallback(thinkingDelta.Thinking); err != nil {
-					return nil, nil, fmt.Errorf("thinking stream callback error: %w", err)
+					return message, fmt.Errorf("thinking stream callback error: %w", err)
 				}
 			}
 		}
@@ -230,92 +351,253 @@ func (a *AnthropicAdapter) SendMessageStreaming(
 
 	// Check for streaming errors
 	if stream.Err() != nil {
-		return nil, nil, fmt.Errorf("streaming error: %w", stream.Err())
+		return message, fmt.Errorf("streaming error: %w", stream.Err())
 	}
 
-	// Convert accumulated message to domain Message and extract tool info
-	return a.convertResponse(&message)
+	return message, nil
 }
 
-// getSystemPrompt returns the system prompt for the AI based on context priority.
-//
-// Priority order (highest to lowest):
-//  1. Custom system prompt (from CustomSystemPromptFromContext) - Takes precedence over all other prompts
-//  2. Plan mode prompt (from PlanModeFromContext) - Used when plan mode is active and no custom prompt exists
-//  3. Base prompt with optional skill metadata - Default prompt when no special modes are active
+// sendWithContinuations sends params and, while the response stops at
+// max_tokens, asks the model to continue it: the text so far is sent back as
+// the start of the assistant's turn and the continuation is stitched onto the
+// last text block. After maxContinuations continuations the response is
+// returned as is, still truncated.
 //
-// The custom prompt feature allows callers to override the system prompt entirely
-// for specialized tasks like code review, refactoring, or investigations.
-func (a *AnthropicAdapter) getSystemPrompt(ctx context.Context) string {
-	// Priority 1: Check for custom system prompt (highest priority)
-	if customPromptInfo, ok := port.CustomSystemPromptFromContext(ctx); ok && customPromptInfo.Prompt != "" {
-		return customPromptInfo.Prompt
+// A response cut off inside a tool call returns ErrTruncatedToolUse, since the
+// tool input cannot be continued. A response with a complete tool call before
+// the cut-off text, or ending in a thinking block, is returned without
+// continuing: its tool calls are intact and the agent loop carries on from them.
+func (a *AnthropicAdapter) sendWithContinuations(
+	params anthropic.MessageNewParams,
+	send func(anthropic.MessageNewParams) (*anthropic.Message, error),
+) (*anthropic.Message, error) {
+	response, err := send(params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Priority 2: Check for plan mode prompt (second priority)
-	planInfo, ok := port.PlanModeFromContext(ctx)
-	if ok && planInfo.Enabled {
-		return a.buildPlanModePrompt(planInfo)
+	for continuations := 0; response.StopReason == anthropic.StopReasonMaxTokens; continuations++ {
+		prefill, ok, err := continuationPrefill(response)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || continuations >= a.maxContinuations {
+			return response, nil
+		}
+
+		// Thinking cannot be combined with a prefilled assistant turn
+		next := params
+		next.Thinking = anthropic.ThinkingConfigParamUnion{OfDisabled: &anthropic.ThinkingConfigDisabledParam{}}
+		next.Messages = append(slices.Clone(params.Messages), anthropic.NewAssistantMessage(anthropic.NewTextBlock(prefill)))
+
+		continuation, err := send(next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to continue response truncated at max_tokens: %w", err)
+		}
+		stitchContinuation(response, prefill, continuation)
 	}
+	return response, nil
+}
 
-	// Priority 3: Return base prompt with optional skill metadata (default/fallback)
-	return a.buildBasePromptWithSkills()
+// continuationPrefill returns the text a response truncated at max_tokens is
+// continued from, without trailing whitespace (which the API rejects at the
+// end of an assistant turn). It returns false if the response cannot be
+// continued, and ErrTruncatedToolUse if it ends inside a tool call.
+func continuationPrefill(response *anthropic.Message) (string, bool, error) {
+	if len(response.Content) == 0 {
+		return "", false, nil
+	}
+	switch response.Content[len(response.Content)-1].Type {
+	case "tool_use":
+		return "", false, ErrTruncatedToolUse
+	case "text":
+	default:
+		return "", false, nil
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			return "", false, nil
+		}
+	}
+	prefill := strings.TrimRightFunc(text.String(), unicode.IsSpace)
+	return prefill, prefill != "", nil
 }
 
-// buildPlanModePrompt constructs the specialized plan mode system prompt.
-// This prompt instructs the agent to explore the codebase and write an implementation
-// plan rather than making direct changes.
-func (a *AnthropicAdapter) buildPlanModePrompt(planInfo port.PlanModeInfo) string {
-	return fmt.Sprintf(
-		`You are an AI assistant in PLAN MODE. Your job is to explore the codebase and write an implementation plan before making changes.
+// stitchContinuation merges a continuation into the response it continues.
+// The response's text becomes prefill followed by the continuation's first
+// text block, as one block; later continuation blocks (such as tool calls)
+// are appended, and the stop reason and output usage are updated.
+func stitchContinuation(response *anthropic.Message, prefill string, continuation *anthropic.Message) {
+	// Keep non-text blocks (thinking) and collapse the text into the last text block
+	content := make([]anthropic.ContentBlockUnion, 0, len(response.Content)+len(continuation.Content))
+	for _, block := range response.Content {
+		if block.Type != "text" {
+			content = append(content, block)
+		}
+	}
+	last := response.Content[len(response.Content)-1]
+	last.Text = prefill
+
+	rest := continuation.Content
+	if len(rest) > 0 && rest[0].Type == "text" {
+		last.Text += rest[0].Text
+		rest = rest[1:]
+	}
+	content = append(content, last)
+	response.Content = append(content, rest...)
+	response.StopReason = continuation.StopReason
+	response.Usage.OutputTokens += continuation.Usage.OutputTokens
+}
+
+// wrapOverloaded marks an overloaded error, left over once the client's
+// retries are used up or sent mid-stream, with ErrOverloaded.
+func wrapOverloaded(err error) error {
+	var apiErr *anthropic.Error
+	if (errors.As(err, &apiErr) && apiErr.StatusCode == statusOverloaded) ||
+		strings.Contains(err.Error(), "overloaded_error") {
+		return fmt.Errorf("%w: %w", ErrOverloaded, err)
+	}
+	return err
+}
+
+// SetMaxContinuations sets how many continuation requests are made for a
+// response that stops at max_tokens. Zero returns such responses truncated;
+// a negative value restores DefaultMaxContinuations.
+func (a *AnthropicAdapter) SetMaxContinuations(n int) {
+	if n < 0 {
+		n = DefaultMaxContinuations
+	}
+	a.maxContinuations = n
+}
 
-## Your Role in Plan Mode
+// SetMaxRetries sets how many times a request is retried after a retryable
+// error such as the API being overloaded (HTTP 529). Retries use the client's
+// exponential backoff and honour Retry-After. A negative value restores DefaultMaxRetries.
+func (a *AnthropicAdapter) SetMaxRetries(n int) {
+	if n < 0 {
+		n = DefaultMaxRetries
+	}
+	a.maxRetries = n
+}
 
-You should:
-1. Use read_file and list_files to understand the existing code
-2. Use read-only bash commands (e.g., git status, ls, find) to explore
-3. Write your implementation plan to: %s
+// SetMetricsRecorder sets the recorder for request counts, latency and token usage.
+// Without a recorder, no metrics are recorded.
+func (a *AnthropicAdapter) SetMetricsRecorder(recorder port.MetricsRecorder) {
+	a.metrics = recorder
+}
 
-## How to Write Your Plan
+// SetAPIKey replaces the API key read from ANTHROPIC_API_KEY. An empty key
+// keeps the environment's key.
+func (a *AnthropicAdapter) SetAPIKey(key string) {
+	if key != "" {
+		a.client = anthropic.NewClient(option.WithAPIKey(key))
+	}
+}
 
-Use the edit_file tool to write your plan to %s. Structure your plan as:
+// SetTracer sets the tracer for AI request spans, one per request with the
+// model and token usage. Without a tracer, no spans are created.
+func (a *AnthropicAdapter) SetTracer(tracer port.Tracer) {
+	a.tracer = tracer
+}
 
-### Summary
-Brief overview of what you're implementing
+// SetMemory sets the persistent memory (AGENT.md) appended to the base system
+// prompt. Custom prompts, such as investigations' and subagents', do not get
+// it. An empty memory appends nothing.
+func (a *AnthropicAdapter) SetMemory(memory string) {
+	a.memory = strings.TrimSpace(memory)
+}
 
-### Files to Modify
-- path/to/file1.go - what changes are needed
-- path/to/file2.go - what changes are needed
+// recordRequest ends an API request's span and records its model, status code,
+// latency and token usage if a metrics recorder is set.
+func (a *AnthropicAdapter) recordRequest(
+	span port.Span,
+	start time.Time,
+	model string,
+	usage anthropic.Usage,
+	err error,
+) {
+	inputTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	if span.IsRecording() {
+		span.SetAttributes(
+			port.StringAttribute("provider", providerName),
+			port.StringAttribute("model", model),
+			port.IntAttribute("input_tokens", inputTokens),
+			port.IntAttribute("output_tokens", usage.OutputTokens),
+		)
+	}
+	port.EndSpan(span, err)
 
-### Implementation Steps
-1. First step
-2. Second step
-...
+	if a.metrics == nil {
+		return
+	}
 
-### Considerations
-- Any trade-offs or decisions to highlight
+	code := strconv.Itoa(http.StatusOK)
+	if err != nil {
+		code = "error"
+		var apiErr *anthropic.Error
+		if errors.As(err, &apiErr) {
+			code = strconv.Itoa(apiErr.StatusCode)
+		}
+	}
+	a.metrics.RecordAIRequest(providerName, model, code, time.Since(start))
+	a.metrics.RecordTokens(port.TokenDirectionInput, inputTokens)
+	a.metrics.RecordTokens(port.TokenDirectionOutput, usage.OutputTokens)
+}
 
-## Important Rules
+// getSystemPrompt returns the system prompt for the AI based on the context.
+//
+// A custom system prompt (from CustomSystemPromptFromContext) replaces the base
+// prompt with optional skill metadata and the memory set by SetMemory. When plan mode is active (from
+// PlanModeFromContext) its instructions are appended to whichever of the two
+// is in use, so a custom prompt keeps plan mode's read-only guidance. A
+// composed custom prompt (see usecase.SystemPromptComposer) is used as is,
+// since its mode layer already carries them.
+//
+// The custom prompt feature allows callers to override the system prompt
+// for specialized tasks like code review, refactoring, or investigations.
+func (a *AnthropicAdapter) getSystemPrompt(ctx context.Context) string {
+	// A custom system prompt replaces the base prompt (default: base prompt with optional skill metadata)
+	prompt := a.buildBasePromptWithSkills()
+	if a.memory != "" {
+		prompt += "\n\n" + a.buildMemoryPrompt()
+	}
+	if customPromptInfo, ok := port.CustomSystemPromptFromContext(ctx); ok && customPromptInfo.Prompt != "" {
+		if customPromptInfo.Composed {
+			return customPromptInfo.Prompt
+		}
+		prompt = customPromptInfo.Prompt
+	}
 
-- You CAN use edit_file to write to %s - this is your plan file
-- Other mutating tools (edit_file for other paths, destructive bash commands) will be blocked
-- If you try to use a blocked tool, you'll receive a reminder to write to your plan file instead
-- Focus on thorough exploration and detailed planning before implementation
+	// Plan mode instructions are appended to whichever prompt is in use, so
+	// toggling plan mode adds or removes them on the next request
+	planInfo, ok := port.PlanModeFromContext(ctx)
+	if ok && planInfo.Enabled {
+		return prompt + "\n\n" + a.buildPlanModePrompt(planInfo)
+	}
+	return prompt
+}
 
-## When You're Done
+// buildPlanModePrompt constructs the plan mode instructions appended to the system prompt.
+// They instruct the agent to explore the codebase and write an implementation
+// plan rather than making direct changes.
+func (a *AnthropicAdapter) buildPlanModePrompt(planInfo port.PlanModeInfo) string {
+	return usecase.PlanModePrompt(planInfo.PlanPath)
+}
 
-When your plan is complete, tell the user to exit plan mode with :mode normal to begin implementation.
-`,
-		planInfo.PlanPath,
-		planInfo.PlanPath,
-		planInfo.PlanPath,
-	)
+// buildMemoryPrompt wraps the persistent memory for the system prompt.
+func (a *AnthropicAdapter) buildMemoryPrompt() string {
+	return usecase.MemoryPrompt(a.memory)
 }
 
 // buildBasePromptWithSkills constructs the base system prompt.
 // Skills are now included in the activate_skill tool description instead of the system prompt.
 func (a *AnthropicAdapter) buildBasePromptWithSkills() string {
-	return "You are an AI assistant that helps users with code editing and explanations. Use the available tools when necessary to provide accurate and helpful responses."
+	return usecase.BaseSystemPrompt
 }
 
 // GenerateToolSchema returns an empty tool input schema.
@@ -341,13 +623,50 @@ func (a *AnthropicAdapter) GenerateToolSchema() port.ToolInputSchemaParam {
 //   - error: nil if the health check passes, otherwise an error
 func (a *AnthropicAdapter) HealthCheck(_ context.Context) error {
 	// Basic health check - verify model is configured
-	if a.model == "" {
+	if a.GetModel() == "" {
 		return fmt.Errorf("%w: model not configured", ErrClientHealthCheck)
 	}
 	return nil
 }
 
-// SetModel sets the AI model to use for subsequent requests.
+// SetCapabilityRegistry sets the registry consulted for what each model
+// supports: responses are capped at the model's output limit, and thinking
+// and tools are left out of requests to models without them. A nil registry
+// restores the default of assuming every feature is supported.
+func (a *AnthropicAdapter) SetCapabilityRegistry(registry *CapabilityRegistry) {
+	a.capabilities = registry
+}
+
+// ModelCapabilities implements port.ModelCapabilityReporter. Without a
+// registry every model is known to support everything, up to the
+// configured max tokens.
+func (a *AnthropicAdapter) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	if a.capabilities == nil {
+		return port.ModelCapabilities{
+			MaxOutputTokens:  a.maxTokens,
+			SupportsTools:    true,
+			SupportsThinking: true,
+			SupportsImages:   true,
+		}, true
+	}
+	return a.capabilities.Lookup(model)
+}
+
+// outputTokens returns the requested max tokens, or the configured ones if
+// requested is 0, capped at the model's limit.
+func (a *AnthropicAdapter) outputTokens(caps port.ModelCapabilities, requested int) int64 {
+	maxTokens := a.maxTokens
+	if requested > 0 {
+		maxTokens = int64(requested)
+	}
+	if caps.MaxOutputTokens > 0 && caps.MaxOutputTokens < maxTokens {
+		return caps.MaxOutputTokens
+	}
+	return maxTokens
+}
+
+// SetModel sets the default model, used by subsequent requests that do not
+// name a model in their RequestOptions. It is safe to call while requests are in flight.
 //
 // Parameters:
 //   - model: The model identifier to use (e.g., "claude-3-5-sonnet-20241022")
@@ -358,15 +677,28 @@ func (a *AnthropicAdapter) SetModel(model string) error {
 	if model == "" {
 		return errors.New("model cannot be empty")
 	}
+	a.modelMu.Lock()
 	a.model = model
+	a.modelMu.Unlock()
 	return nil
 }
 
+// requestModel returns the model of a request: the one in its options, else
+// the default model.
+func (a *AnthropicAdapter) requestModel(opts port.RequestOptions) string {
+	if opts.Model != "" {
+		return opts.Model
+	}
+	return a.GetModel()
+}
+
 // GetModel returns the currently configured AI model.
 //
 // Returns:
 //   - string: The current model identifier
 func (a *AnthropicAdapter) GetModel() string {
+	a.modelMu.RLock()
+	defer a.modelMu.RUnlock()
 	return a.model
 }
 
@@ -461,14 +793,85 @@ func (a *AnthropicAdapter) convertAssistantToolMessage(msg port.MessageParam) an
 	return anthropic.NewAssistantMessage(blocks...)
 }
 
-// convertSimpleMessage converts a simple text message.
+// convertSimpleMessage converts a simple text message, or a message of text and
+// image blocks.
 func (a *AnthropicAdapter) convertSimpleMessage(msg port.MessageParam) anthropic.MessageParam {
+	if len(msg.Blocks) > 0 {
+		return a.convertContentBlockMessage(msg)
+	}
 	if msg.Role == entity.RoleAssistant {
 		return anthropic.NewAssistantMessage(anthropic.NewTextBlock(msg.Content))
 	}
 	return anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content))
 }
 
+// convertContentBlockMessage converts a message of text and image blocks.
+// Image blocks must carry base64 data; see loadImageFiles.
+func (a *AnthropicAdapter) convertContentBlockMessage(msg port.MessageParam) anthropic.MessageParam {
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(msg.Blocks))
+	for _, block := range msg.Blocks {
+		switch {
+		case block.Type == entity.ContentBlockImage && block.Image != nil:
+			blocks = append(blocks, anthropic.NewImageBlockBase64(block.Image.MediaType, block.Image.Data))
+		case block.Text != "":
+			blocks = append(blocks, anthropic.NewTextBlock(block.Text))
+		}
+	}
+	if msg.Role == entity.RoleAssistant {
+		return anthropic.NewAssistantMessage(blocks...)
+	}
+	return anthropic.NewUserMessage(blocks...)
+}
+
+// SupportsImageInput reports whether the default model accepts image content blocks.
+func (a *AnthropicAdapter) SupportsImageInput() bool {
+	caps, _ := a.ModelCapabilities(a.GetModel())
+	return caps.SupportsImages
+}
+
+// loadImageFiles returns a copy of messages with every image block given by
+// file path replaced by its base64-encoded data, checking that the file is
+// still a supported image within the size limit.
+func loadImageFiles(messages []port.MessageParam) ([]port.MessageParam, error) {
+	loaded := make([]port.MessageParam, len(messages))
+	copy(loaded, messages)
+	for i := range loaded {
+		blocks, err := loadBlockImages(loaded[i].Blocks)
+		if err != nil {
+			return nil, err
+		}
+		loaded[i].Blocks = blocks
+	}
+	return loaded, nil
+}
+
+// loadBlockImages returns blocks with image files read into base64 data,
+// copying the slice only if it has any.
+func loadBlockImages(blocks []port.ContentBlockParam) ([]port.ContentBlockParam, error) {
+	copied := false
+	for i, block := range blocks {
+		if block.Image == nil || block.Image.Data != "" || block.Image.Path == "" {
+			continue
+		}
+		data, err := os.ReadFile(block.Image.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attached image: %w", err)
+		}
+		if err := entity.ValidateImage(block.Image.MediaType, int64(len(data))); err != nil {
+			return nil, fmt.Errorf("attached image %s: %w", block.Image.Path, err)
+		}
+
+		if !copied {
+			blocks = append([]port.ContentBlockParam(nil), blocks...)
+			copied = true
+		}
+		image := *block.Image
+		image.Data = base64.StdEncoding.EncodeToString(data)
+		blocks[i].Image = &image
+	}
+	return blocks, nil
+}
+
 // convertTools converts port ToolParam slice to Anthropic SDK ToolUnionParam slice.
 func (a *AnthropicAdapter) convertTools(tools []port.ToolParam) []anthropic.ToolUnionParam {
 	result := make([]anthropic.ToolUnionParam, len(tools))
@@ -495,12 +898,25 @@ func (a *AnthropicAdapter) buildToolParam(tool port.ToolParam) *anthropic.ToolPa
 }
 
 // convertInputSchema converts a port ToolInputSchemaParam to an anthropic ToolInputSchemaParam.
+// Properties are passed through whole, so their enum, default and examples
+// keywords reach the model; other top-level keywords are kept as extra fields.
 func (a *AnthropicAdapter) convertInputSchema(schema port.ToolInputSchemaParam) anthropic.ToolInputSchemaParam {
-	return anthropic.ToolInputSchemaParam{
+	param := anthropic.ToolInputSchemaParam{
 		Type:       constant.Object(extractStringField(schema, "type")),
 		Properties: extractMapField(schema, "properties"),
 		Required:   extractStringSliceField(schema, "required"),
 	}
+	for key, value := range schema {
+		switch key {
+		case "type", "properties", "required":
+			continue
+		}
+		if param.ExtraFields == nil {
+			param.ExtraFields = make(map[string]any)
+		}
+		param.ExtraFields[key] = value
+	}
+	return param
 }
 
 // extractStringField extracts a string value from a schema map, returning empty string if not found.
@@ -520,9 +936,19 @@ func extractMapField(schema port.ToolInputSchemaParam, key string) map[string]in
 }
 
 // extractStringSliceField extracts a string slice from a schema map, returning nil if not found.
+// Slices decoded from JSON ([]interface{} of strings) are accepted too.
 func extractStringSliceField(schema port.ToolInputSchemaParam, key string) []string {
-	if value, ok := schema[key].([]string); ok {
+	switch value := schema[key].(type) {
+	case []string:
 		return value
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
 	}
 	return nil
 }
@@ -627,6 +1053,10 @@ func (a *AnthropicAdapter) convertResponse(response *anthropic.Message) (*entity
 	if len(entityToolCalls) > 0 {
 		msg.ToolCalls = entityToolCalls
 	}
+	msg.Usage = &entity.TokenUsage{
+		InputTokens:  response.Usage.InputTokens + response.Usage.CacheCreationInputTokens + response.Usage.CacheReadInputTokens,
+		OutputTokens: response.Usage.OutputTokens,
+	}
 
 	return msg, toolCalls, nil
 }
@@ -3,7 +3,15 @@ package ai
 import (
 	"code-editing-agent/internal/domain/port"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestConvertTools_WithRequiredField verifies that when a tool has a required field,
@@ -441,16 +449,50 @@ func TestConvertTools_RequiredPreservesOrder(t *testing.T) {
 	}
 }
 
+// TestConvertTools_KeepsEnumsDefaultsAndExamples verifies that the property
+// keywords the model relies on survive conversion, that a required list
+// decoded from JSON is accepted, and that other top-level keywords are kept.
+func TestConvertTools_KeepsEnumsDefaultsAndExamples(t *testing.T) {
+	adapter := &AnthropicAdapter{}
+
+	var schema map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"priority": {"type": "string", "enum": ["low", "high"], "default": "low"},
+			"dangerous": {"type": "boolean", "examples": [false, true]}
+		},
+		"required": ["priority"],
+		"additionalProperties": false
+	}`), &schema)
+	if err != nil {
+		t.Fatalf("invalid test schema: %v", err)
+	}
+
+	result := adapter.convertTools([]port.ToolParam{{Name: "escalate", Description: "Escalate", InputSchema: schema}})
+
+	data, err := json.Marshal(result[0].OfTool.InputSchema)
+	if err != nil {
+		t.Fatalf("marshal input schema: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal input schema: %v", err)
+	}
+	if !reflect.DeepEqual(got, schema) {
+		t.Errorf("input schema sent to the API = %s, want %v", data, schema)
+	}
+}
+
 // ============================================================================
 // Custom System Prompt Priority Tests (RED PHASE)
 // ============================================================================
 // These tests verify that getSystemPrompt() correctly prioritizes custom
 // system prompts from context over plan mode and base prompts.
 //
-// PRIORITY ORDER (highest to lowest):
-// 1. Custom system prompt (from CustomSystemPromptFromContext)
-// 2. Plan mode prompt (from PlanModeFromContext)
-// 3. Base prompt with skills (default)
+// The custom system prompt (from CustomSystemPromptFromContext) replaces the
+// base prompt with skills; plan mode instructions (from PlanModeFromContext)
+// are appended to whichever is in use.
 // ============================================================================
 
 // TestGetSystemPrompt_CustomPromptTakesPrecedenceOverBasePrompt verifies that
@@ -493,23 +535,18 @@ func TestGetSystemPrompt_CustomPromptTakesPrecedenceOverBasePrompt(t *testing.T)
 	}
 }
 
-// TestGetSystemPrompt_CustomPromptTakesPrecedenceOverPlanMode verifies that
+// TestGetSystemPrompt_CustomPromptKeepsPlanModeInstructions verifies that
 // when BOTH custom system prompt AND plan mode are present in the context,
-// the custom system prompt takes precedence.
-//
-// This test will FAIL until getSystemPrompt() is updated to prioritize custom
-// prompts over plan mode prompts.
-func TestGetSystemPrompt_CustomPromptTakesPrecedenceOverPlanMode(t *testing.T) {
-	// Setup: create adapter
+// the plan mode instructions are appended to the custom prompt in place of
+// the base prompt.
+func TestGetSystemPrompt_CustomPromptKeepsPlanModeInstructions(t *testing.T) {
 	adapter := &AnthropicAdapter{
 		model: "test-model",
 	}
 
-	// Expected prompts
 	customPrompt := "You are a debugging specialist. Analyze stack traces and find root causes."
 	planPath := ".agent/plans/session-456.md"
 
-	// Setup: create context with BOTH custom prompt AND plan mode
 	ctx := context.Background()
 	ctx = port.WithCustomSystemPrompt(ctx, port.CustomSystemPromptInfo{
 		SessionID: "test-session-456",
@@ -521,26 +558,50 @@ func TestGetSystemPrompt_CustomPromptTakesPrecedenceOverPlanMode(t *testing.T) {
 		PlanPath:  planPath,
 	})
 
-	// Execute: get system prompt
 	actualPrompt := adapter.getSystemPrompt(ctx)
 
-	// Assert: should return custom prompt, not plan mode prompt
-	if actualPrompt != customPrompt {
-		t.Errorf(
-			"Expected custom prompt to take precedence over plan mode.\nWant: %q\nGot:  %q",
-			customPrompt,
-			actualPrompt,
-		)
+	if indexOfString(actualPrompt, customPrompt+"\n\n") != 0 {
+		t.Errorf("Expected prompt to start with the custom prompt, got: %q", actualPrompt)
+	}
+	if !containsPlanModeInstructions(actualPrompt) {
+		t.Error("Expected plan mode instructions to be appended to the custom prompt")
+	}
+	if !containsString(actualPrompt, planPath) {
+		t.Errorf("Expected prompt to contain plan path %q", planPath)
+	}
+	if containsString(actualPrompt, adapter.buildBasePromptWithSkills()) {
+		t.Error("Custom prompt should replace the base prompt, but base prompt is present")
 	}
 
-	// Assert: should NOT contain plan mode instructions
-	if containsPlanModeInstructions(actualPrompt) {
-		t.Error("Custom prompt should take precedence over plan mode, but got plan mode instructions")
+	// Disabling plan mode removes the instructions again
+	ctx = port.WithPlanMode(ctx, port.PlanModeInfo{SessionID: "test-session-456"})
+	if got := adapter.getSystemPrompt(ctx); got != customPrompt {
+		t.Errorf("Expected only the custom prompt once plan mode is off.\nWant: %q\nGot:  %q", customPrompt, got)
 	}
+}
+
+// TestGetSystemPrompt_Memory verifies that memory is appended to the base
+// prompt but not to a custom one.
+func TestGetSystemPrompt_Memory(t *testing.T) {
+	adapter := &AnthropicAdapter{model: "test-model"}
+	adapter.SetMemory("## Learned\n\n- we use tabs\n")
 
-	// Assert: should NOT contain the plan path
-	if containsString(actualPrompt, planPath) {
-		t.Errorf("Custom prompt should not contain plan path %q, but it does", planPath)
+	prompt := adapter.getSystemPrompt(context.Background())
+	if indexOfString(prompt, adapter.buildBasePromptWithSkills()) != 0 {
+		t.Errorf("Expected prompt to start with the base prompt, got: %q", prompt)
+	}
+	if !strings.HasSuffix(prompt, "\n\n## Learned\n\n- we use tabs") {
+		t.Errorf("Expected memory at the end of the prompt, got: %q", prompt)
+	}
+
+	ctx := port.WithCustomSystemPrompt(context.Background(), port.CustomSystemPromptInfo{Prompt: "Investigate."})
+	if got := adapter.getSystemPrompt(ctx); got != "Investigate." {
+		t.Errorf("Expected a custom prompt without memory, got: %q", got)
+	}
+
+	adapter.SetMemory("  ")
+	if got := adapter.getSystemPrompt(context.Background()); got != adapter.buildBasePromptWithSkills() {
+		t.Errorf("Expected only the base prompt without memory, got: %q", got)
 	}
 }
 
@@ -621,6 +682,30 @@ func TestGetSystemPrompt_NoCustomPromptWithPlanModeReturnsPlanPrompt(t *testing.
 	}
 }
 
+// TestGetSystemPrompt_PlanModeAppendsInstructionsToBasePrompt verifies that the
+// plan mode instructions are appended to the base prompt rather than replacing it.
+func TestGetSystemPrompt_PlanModeAppendsInstructionsToBasePrompt(t *testing.T) {
+	adapter := &AnthropicAdapter{
+		model: "test-model",
+	}
+
+	ctx := port.WithPlanMode(context.Background(), port.PlanModeInfo{
+		Enabled:   true,
+		SessionID: "test-session-def",
+		PlanPath:  ".agent/plans/test-session-def.md",
+	})
+
+	actualPrompt := adapter.getSystemPrompt(ctx)
+
+	basePrompt := adapter.buildBasePromptWithSkills()
+	if len(actualPrompt) <= len(basePrompt) || actualPrompt[:len(basePrompt)] != basePrompt {
+		t.Errorf("Expected plan mode prompt to start with the base prompt, got: %q", actualPrompt)
+	}
+	if !containsString(actualPrompt, "propose a plan, do not make changes") {
+		t.Error("Expected plan mode prompt to tell the agent to propose a plan without making changes")
+	}
+}
+
 // TestGetSystemPrompt_NoCustomPromptNoPlanModeReturnsBasePrompt verifies that
 // when there is NO custom prompt and NO plan mode in context, the base prompt
 // is returned (existing behavior should continue to work).
@@ -1396,3 +1481,401 @@ func TestConvertMessages_MultipleThinkingBlocks(t *testing.T) {
 	// - All signatures are preserved
 	// - Order is maintained
 }
+
+// recordedRequest is an AI request recorded by metricsRecorderStub.
+type recordedRequest struct {
+	provider, model, code string
+}
+
+// metricsRecorderStub records AI requests and tokens for assertions.
+type metricsRecorderStub struct {
+	requests []recordedRequest
+	tokens   map[string]int64
+}
+
+func (m *metricsRecorderStub) RecordInvestigation(string, string, time.Duration) {}
+func (m *metricsRecorderStub) RecordToolExecution(string, bool, time.Duration)   {}
+func (m *metricsRecorderStub) RecordToolOutput(string, int)                      {}
+
+func (m *metricsRecorderStub) RecordAIRequest(provider, model, code string, _ time.Duration) {
+	m.requests = append(m.requests, recordedRequest{provider, model, code})
+}
+
+func (m *metricsRecorderStub) RecordTokens(direction string, count int64) {
+	if m.tokens == nil {
+		m.tokens = make(map[string]int64)
+	}
+	m.tokens[direction] += count
+}
+
+func TestSendMessage_RecordsRequestMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantCode   string
+		wantInput  int64
+		wantOutput int64
+	}{
+		{
+			name:   "success records tokens including cache",
+			status: http.StatusOK,
+			body: `{"id":"msg_1","type":"message","role":"assistant","model":"test-model",` +
+				`"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn",` +
+				`"usage":{"input_tokens":10,"output_tokens":5,` +
+				`"cache_creation_input_tokens":3,"cache_read_input_tokens":2}}`,
+			wantCode:   "200",
+			wantInput:  15,
+			wantOutput: 5,
+		},
+		{
+			name:     "API error records status code",
+			status:   http.StatusBadRequest,
+			body:     `{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`,
+			wantCode: "400",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+			t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+			adapter, ok := NewAnthropicAdapter("test-model", 100, nil).(*AnthropicAdapter)
+			if !ok {
+				t.Fatal("NewAnthropicAdapter() should return *AnthropicAdapter")
+			}
+			recorder := &metricsRecorderStub{}
+			adapter.SetMetricsRecorder(recorder)
+
+			messages := []port.MessageParam{{Role: "user", Content: "hello"}}
+			_, _, _ = adapter.SendMessage(context.Background(), messages, nil)
+
+			want := []recordedRequest{{providerName, "test-model", tt.wantCode}}
+			if !reflect.DeepEqual(recorder.requests, want) {
+				t.Errorf("recorded requests = %v, want %v", recorder.requests, want)
+			}
+			if got := recorder.tokens[port.TokenDirectionInput]; got != tt.wantInput {
+				t.Errorf("input tokens = %d, want %d", got, tt.wantInput)
+			}
+			if got := recorder.tokens[port.TokenDirectionOutput]; got != tt.wantOutput {
+				t.Errorf("output tokens = %d, want %d", got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestSetAPIKey_OverridesEnvironmentKey(t *testing.T) {
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Api-Key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"test-model",` +
+			`"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn",` +
+			`"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "env-key")
+
+	adapter, ok := NewAnthropicAdapter("test-model", 100, nil).(*AnthropicAdapter)
+	if !ok {
+		t.Fatal("NewAnthropicAdapter() should return *AnthropicAdapter")
+	}
+	adapter.SetAPIKey("config-key")
+
+	messages := []port.MessageParam{{Role: "user", Content: "hello"}}
+	if _, _, err := adapter.SendMessage(context.Background(), messages, nil); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if gotKey != "config-key" {
+		t.Errorf("X-Api-Key = %q, want %q", gotKey, "config-key")
+	}
+}
+
+// sequencedServer serves one canned response per request, in order, repeating
+// the last one, and records each request body.
+type sequencedServer struct {
+	responses []func(w http.ResponseWriter)
+	requests  []map[string]any
+}
+
+func (s *sequencedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.requests = append(s.requests, body)
+	s.responses[min(len(s.requests), len(s.responses))-1](w)
+}
+
+// newSequencedAdapter starts a sequencedServer and returns an adapter using it.
+func newSequencedAdapter(t *testing.T, responses ...func(w http.ResponseWriter)) (*AnthropicAdapter, *sequencedServer) {
+	t.Helper()
+	seq := &sequencedServer{responses: responses}
+	server := httptest.NewServer(seq)
+	t.Cleanup(server.Close)
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+	adapter, ok := NewAnthropicAdapter("test-model", 100, nil).(*AnthropicAdapter)
+	if !ok {
+		t.Fatal("NewAnthropicAdapter() should return *AnthropicAdapter")
+	}
+	return adapter, seq
+}
+
+// jsonMessage responds with a message holding the given content blocks.
+func jsonMessage(stopReason string, content ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"test-model",`+
+			`"content":[%s],"stop_reason":%q,"usage":{"input_tokens":10,"output_tokens":5}}`,
+			strings.Join(content, ","), stopReason)
+	}
+}
+
+// textBlock returns the JSON for a text content block.
+func textBlock(text string) string {
+	encoded, _ := json.Marshal(text)
+	return `{"type":"text","text":` + string(encoded) + `}`
+}
+
+// streamedText responds with a streamed message holding one text block sent
+// in the given deltas.
+func streamedText(stopReason string, deltas ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(name, data string) { _, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data) }
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant",`+
+			`"model":"test-model","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}`)
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		for _, delta := range deltas {
+			encoded, _ := json.Marshal(delta)
+			event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":`+
+				string(encoded)+`}}`)
+		}
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"`+stopReason+`"},"usage":{"output_tokens":5}}`)
+		event("message_stop", `{"type":"message_stop"}`)
+	}
+}
+
+// lastMessage returns the role and text of the last message in a recorded request.
+func lastMessage(t *testing.T, request map[string]any) (string, string) {
+	t.Helper()
+	messages, _ := request["messages"].([]any)
+	if len(messages) == 0 {
+		t.Fatalf("request has no messages: %v", request)
+	}
+	last, _ := messages[len(messages)-1].(map[string]any)
+	role, _ := last["role"].(string)
+	var text strings.Builder
+	blocks, _ := last["content"].([]any)
+	for _, b := range blocks {
+		block, _ := b.(map[string]any)
+		if s, ok := block["text"].(string); ok {
+			text.WriteString(s)
+		}
+	}
+	return role, text.String()
+}
+
+func TestSendMessage_ContinuesMaxTokensResponse(t *testing.T) {
+	adapter, server := newSequencedAdapter(t,
+		jsonMessage("max_tokens", textBlock("The fix is to ")),
+		jsonMessage("max_tokens", textBlock(" close the file")),
+		jsonMessage("end_turn", textBlock(" after reading it.")),
+	)
+	recorder := &metricsRecorderStub{}
+	adapter.SetMetricsRecorder(recorder)
+
+	messages := []port.MessageParam{{Role: "user", Content: "What is the fix?"}}
+	msg, toolCalls, err := adapter.SendMessage(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if want := "The fix is to close the file after reading it."; msg.Content != want {
+		t.Errorf("stitched content = %q, want %q", msg.Content, want)
+	}
+	if len(toolCalls) != 0 {
+		t.Errorf("tool calls = %v, want none", toolCalls)
+	}
+	if len(server.requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(server.requests))
+	}
+
+	// Each continuation prefills the text so far, without trailing whitespace
+	for i, wantPrefill := range []string{"The fix is to", "The fix is to close the file"} {
+		role, text := lastMessage(t, server.requests[i+1])
+		if role != "assistant" || text != wantPrefill {
+			t.Errorf("continuation %d last message = %s %q, want assistant %q", i+1, role, text, wantPrefill)
+		}
+	}
+	if role, _ := lastMessage(t, server.requests[0]); role != "user" {
+		t.Errorf("first request should end with the user message, got %s", role)
+	}
+	if len(recorder.requests) != 3 {
+		t.Errorf("recorded requests = %d, want one per request", len(recorder.requests))
+	}
+}
+
+func TestSendMessage_StopsAtMaxContinuations(t *testing.T) {
+	adapter, server := newSequencedAdapter(t,
+		jsonMessage("max_tokens", textBlock("one")),
+		jsonMessage("max_tokens", textBlock(" two")),
+		jsonMessage("max_tokens", textBlock(" three")),
+	)
+	adapter.SetMaxContinuations(1)
+
+	messages := []port.MessageParam{{Role: "user", Content: "Count"}}
+	msg, _, err := adapter.SendMessage(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if len(server.requests) != 2 {
+		t.Errorf("requests = %d, want 2 (one continuation)", len(server.requests))
+	}
+	if msg.Content != "one two" {
+		t.Errorf("content = %q, want the truncated %q", msg.Content, "one two")
+	}
+
+	// With continuations disabled the first response is returned as is
+	adapter.SetMaxContinuations(0)
+	server.requests = nil
+	msg, _, err = adapter.SendMessage(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if len(server.requests) != 1 || msg.Content != "one" {
+		t.Errorf("requests = %d, content = %q; want 1 request and %q", len(server.requests), msg.Content, "one")
+	}
+}
+
+func TestSendMessage_MaxTokensWithToolUse(t *testing.T) {
+	toolUse := `{"type":"tool_use","id":"tool_1","name":"read_file","input":{"path":"main.go"}}`
+
+	t.Run("cut off inside a tool call is an error", func(t *testing.T) {
+		adapter, server := newSequencedAdapter(t, jsonMessage("max_tokens", textBlock("Reading."), toolUse))
+
+		messages := []port.MessageParam{{Role: "user", Content: "Read main.go"}}
+		_, _, err := adapter.SendMessage(context.Background(), messages, nil)
+		if !errors.Is(err, ErrTruncatedToolUse) {
+			t.Errorf("SendMessage() error = %v, want ErrTruncatedToolUse", err)
+		}
+		if len(server.requests) != 1 {
+			t.Errorf("requests = %d, want no continuation", len(server.requests))
+		}
+	})
+
+	t.Run("cut off after a complete tool call returns it", func(t *testing.T) {
+		adapter, server := newSequencedAdapter(t, jsonMessage("max_tokens", toolUse, textBlock("Then I will")))
+
+		messages := []port.MessageParam{{Role: "user", Content: "Read main.go"}}
+		msg, toolCalls, err := adapter.SendMessage(context.Background(), messages, nil)
+		if err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		if len(server.requests) != 1 {
+			t.Errorf("requests = %d, want no continuation", len(server.requests))
+		}
+		if len(toolCalls) != 1 || toolCalls[0].ToolID != "tool_1" || len(msg.ToolCalls) != 1 {
+			t.Errorf("tool calls = %v, want the complete read_file call", toolCalls)
+		}
+	})
+
+	t.Run("tool call in a continuation is kept", func(t *testing.T) {
+		adapter, _ := newSequencedAdapter(t,
+			jsonMessage("max_tokens", textBlock("Let me")),
+			jsonMessage("tool_use", textBlock(" check."), toolUse),
+		)
+
+		messages := []port.MessageParam{{Role: "user", Content: "Read main.go"}}
+		msg, toolCalls, err := adapter.SendMessage(context.Background(), messages, nil)
+		if err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		if msg.Content != "Let me check." {
+			t.Errorf("content = %q, want %q", msg.Content, "Let me check.")
+		}
+		if len(toolCalls) != 1 || toolCalls[0].Input["path"] != "main.go" {
+			t.Errorf("tool calls = %v, want the read_file call", toolCalls)
+		}
+	})
+}
+
+func TestSendMessage_OverloadedUsesRetryPolicy(t *testing.T) {
+	overloaded := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After-Ms", "1")
+		w.WriteHeader(529)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}
+
+	t.Run("recovers within the retries", func(t *testing.T) {
+		adapter, server := newSequencedAdapter(t, overloaded, jsonMessage("end_turn", textBlock("hi")))
+
+		messages := []port.MessageParam{{Role: "user", Content: "hello"}}
+		msg, _, err := adapter.SendMessage(context.Background(), messages, nil)
+		if err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		if msg.Content != "hi" || len(server.requests) != 2 {
+			t.Errorf("content = %q after %d requests, want %q after 2", msg.Content, len(server.requests), "hi")
+		}
+	})
+
+	t.Run("reports ErrOverloaded once retries are used up", func(t *testing.T) {
+		adapter, server := newSequencedAdapter(t, overloaded)
+		adapter.SetMaxRetries(1)
+
+		messages := []port.MessageParam{{Role: "user", Content: "hello"}}
+		_, _, err := adapter.SendMessage(context.Background(), messages, nil)
+		if !errors.Is(err, ErrOverloaded) {
+			t.Errorf("SendMessage() error = %v, want ErrOverloaded", err)
+		}
+		if len(server.requests) != 2 {
+			t.Errorf("requests = %d, want 2 (one retry)", len(server.requests))
+		}
+	})
+}
+
+func TestSendMessageStreaming_ContinuesMaxTokensResponse(t *testing.T) {
+	adapter, server := newSequencedAdapter(t,
+		streamedText("max_tokens", "Step 1: ", "build.\n"),
+		streamedText("end_turn", "\nStep 2: test."),
+	)
+
+	var streamed strings.Builder
+	messages := []port.MessageParam{{Role: "user", Content: "Plan"}}
+	msg, _, err := adapter.SendMessageStreaming(context.Background(), messages, nil,
+		func(text string) error {
+			streamed.WriteString(text)
+			return nil
+		}, nil)
+	if err != nil {
+		t.Fatalf("SendMessageStreaming() error = %v", err)
+	}
+
+	if want := "Step 1: build.\nStep 2: test."; msg.Content != want {
+		t.Errorf("stitched content = %q, want %q", msg.Content, want)
+	}
+	if want := "Step 1: build.\n\nStep 2: test."; streamed.String() != want {
+		t.Errorf("streamed text = %q, want %q", streamed.String(), want)
+	}
+	if len(server.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(server.requests))
+	}
+	if role, text := lastMessage(t, server.requests[1]); role != "assistant" || text != "Step 1: build." {
+		t.Errorf("continuation last message = %s %q, want assistant %q", role, text, "Step 1: build.")
+	}
+	thinking, _ := server.requests[1]["thinking"].(map[string]any)
+	if thinking["type"] != "disabled" {
+		t.Errorf("continuation thinking = %v, want disabled", server.requests[1]["thinking"])
+	}
+}
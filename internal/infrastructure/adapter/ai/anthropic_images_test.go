@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader is the signature that starts every PNG file.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// Compile-time check that AnthropicAdapter accepts images.
+var _ port.ImageInputSupporter = (*AnthropicAdapter)(nil)
+
+func TestConvertMessages_ImageBlocks(t *testing.T) {
+	adapter := &AnthropicAdapter{model: "test-model"}
+	messages := []port.MessageParam{{
+		Role:    entity.RoleUser,
+		Content: "What is wrong with this button?",
+		Blocks: []port.ContentBlockParam{
+			{Type: entity.ContentBlockText, Text: "What is wrong with this button?"},
+			{Type: entity.ContentBlockImage, Image: &port.ImageSourceParam{MediaType: "image/png", Data: "aGVsbG8="}},
+		},
+	}}
+
+	converted := adapter.convertMessages(messages)
+
+	data, err := json.Marshal(converted)
+	if err != nil {
+		t.Fatalf("failed to marshal converted messages: %v", err)
+	}
+	var decoded []struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type   string `json:"type"`
+			Text   string `json:"text"`
+			Source struct {
+				Type      string `json:"type"`
+				MediaType string `json:"media_type"`
+				Data      string `json:"data"`
+			} `json:"source"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode converted messages %s: %v", data, err)
+	}
+
+	if len(decoded) != 1 || decoded[0].Role != "user" || len(decoded[0].Content) != 2 {
+		t.Fatalf("converted messages = %s, want one user message with two blocks", data)
+	}
+	text, image := decoded[0].Content[0], decoded[0].Content[1]
+	if text.Type != "text" || text.Text != "What is wrong with this button?" {
+		t.Errorf("first block = %+v, want the text", text)
+	}
+	if image.Type != "image" || image.Source.Type != "base64" ||
+		image.Source.MediaType != "image/png" || image.Source.Data != "aGVsbG8=" {
+		t.Errorf("second block = %+v, want a base64 PNG image", image)
+	}
+}
+
+func TestLoadImageFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screenshot.png")
+	if err := os.WriteFile(path, pngHeader, 0o600); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+	imageMessage := func(path string) []port.MessageParam {
+		return []port.MessageParam{{
+			Role: entity.RoleUser,
+			Blocks: []port.ContentBlockParam{
+				{Type: entity.ContentBlockImage, Image: &port.ImageSourceParam{MediaType: "image/png", Path: path}},
+			},
+		}}
+	}
+
+	t.Run("reads files into base64 data without changing the input", func(t *testing.T) {
+		messages := imageMessage(path)
+		loaded, err := loadImageFiles(messages)
+		if err != nil {
+			t.Fatalf("loadImageFiles() error = %v", err)
+		}
+		if got := loaded[0].Blocks[0].Image.Data; got != base64.StdEncoding.EncodeToString(pngHeader) {
+			t.Errorf("loaded data = %q, want the base64 file content", got)
+		}
+		if messages[0].Blocks[0].Image.Data != "" {
+			t.Error("loadImageFiles modified its input")
+		}
+	})
+
+	t.Run("missing files fail before sending", func(t *testing.T) {
+		if _, err := loadImageFiles(imageMessage(path + ".missing")); err == nil ||
+			!strings.Contains(err.Error(), "failed to read attached image") {
+			t.Errorf("loadImageFiles() error = %v, want a read error", err)
+		}
+	})
+
+	t.Run("files that grew past the limit are refused", func(t *testing.T) {
+		large := filepath.Join(t.TempDir(), "large.png")
+		if err := os.WriteFile(large, make([]byte, entity.MaxImageBytes+1), 0o600); err != nil {
+			t.Fatalf("failed to write image: %v", err)
+		}
+		if _, err := loadImageFiles(imageMessage(large)); !errors.Is(err, entity.ErrImageTooLarge) {
+			t.Errorf("loadImageFiles() error = %v, want ErrImageTooLarge", err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DefaultModelCapabilities are assumed for models the registry does not
+// know: tool use, which the agent cannot work without, and otherwise only
+// what every model can handle.
+var DefaultModelCapabilities = port.ModelCapabilities{
+	ContextWindow:   32000,
+	MaxOutputTokens: 4096,
+	SupportsTools:   true,
+}
+
+// builtinModelCapabilities lists known models by pattern; a trailing "*"
+// matches any model with that prefix, so dated releases need no entry.
+//
+//nolint:gochecknoglobals // read-only table of known models
+var builtinModelCapabilities = map[string]port.ModelCapabilities{
+	// Anthropic
+	"claude-opus-4*":      {ContextWindow: 200000, MaxOutputTokens: 32000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"claude-opus-4-5*":    {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"claude-sonnet-4*":    {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"claude-haiku-4*":     {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"claude-3-7-sonnet*":  {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"claude-3-5-sonnet*":  {ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsImages: true},
+	"claude-3-5-haiku*":   {ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsImages: true},
+	"claude-3-opus*":      {ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsImages: true},
+	"claude-3-haiku*":     {ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsImages: true},
+	"hf:zai-org/glm-4.6*": {ContextWindow: 200000, MaxOutputTokens: 128000, SupportsTools: true, SupportsThinking: true},
+	"glm-4.6*":            {ContextWindow: 200000, MaxOutputTokens: 128000, SupportsTools: true, SupportsThinking: true},
+
+	// OpenAI
+	"gpt-4o*":  {ContextWindow: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsImages: true},
+	"gpt-4.1*": {ContextWindow: 1047576, MaxOutputTokens: 32768, SupportsTools: true, SupportsImages: true},
+	"gpt-5*":   {ContextWindow: 400000, MaxOutputTokens: 128000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"o3*":      {ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+	"o4-mini*": {ContextWindow: 200000, MaxOutputTokens: 100000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+
+	// Ollama
+	"llama3.1*": {ContextWindow: 128000, MaxOutputTokens: 4096, SupportsTools: true},
+	"llama3.2*": {ContextWindow: 128000, MaxOutputTokens: 4096, SupportsTools: true},
+	"qwen2.5*":  {ContextWindow: 32768, MaxOutputTokens: 8192, SupportsTools: true},
+	"qwen3*":    {ContextWindow: 40960, MaxOutputTokens: 8192, SupportsTools: true, SupportsThinking: true},
+	"mistral*":  {ContextWindow: 32768, MaxOutputTokens: 4096, SupportsTools: true},
+}
+
+// CapabilityOverride replaces some capabilities of the models matching its
+// pattern, for custom gateways and models the registry does not know. Zero
+// and nil fields keep the built-in (or default) value.
+type CapabilityOverride struct {
+	ContextWindow    int64
+	MaxOutputTokens  int64
+	SupportsTools    *bool
+	SupportsThinking *bool
+	SupportsImages   *bool
+}
+
+// apply returns caps with the override's set fields replacing its own.
+func (o CapabilityOverride) apply(caps port.ModelCapabilities) port.ModelCapabilities {
+	if o.ContextWindow > 0 {
+		caps.ContextWindow = o.ContextWindow
+	}
+	if o.MaxOutputTokens > 0 {
+		caps.MaxOutputTokens = o.MaxOutputTokens
+	}
+	if o.SupportsTools != nil {
+		caps.SupportsTools = *o.SupportsTools
+	}
+	if o.SupportsThinking != nil {
+		caps.SupportsThinking = *o.SupportsThinking
+	}
+	if o.SupportsImages != nil {
+		caps.SupportsImages = *o.SupportsImages
+	}
+	return caps
+}
+
+// CapabilityRegistry resolves model names to their capabilities: the most
+// specific built-in pattern, with the most specific configured override on
+// top. Matching ignores case. It is immutable and safe for concurrent use.
+type CapabilityRegistry struct {
+	overrides map[string]CapabilityOverride // Lowercased patterns
+}
+
+// NewCapabilityRegistry creates a registry applying overrides, keyed by
+// model name or by prefix pattern ending in "*", to the built-in entries.
+func NewCapabilityRegistry(overrides map[string]CapabilityOverride) *CapabilityRegistry {
+	lowered := make(map[string]CapabilityOverride, len(overrides))
+	for pattern, override := range overrides {
+		lowered[strings.ToLower(pattern)] = override
+	}
+	return &CapabilityRegistry{overrides: lowered}
+}
+
+// Lookup returns the capabilities of model and whether any built-in entry
+// or override matched it; unmatched models get DefaultModelCapabilities.
+func (r *CapabilityRegistry) Lookup(model string) (port.ModelCapabilities, bool) {
+	model = strings.ToLower(model)
+	caps, known := DefaultModelCapabilities, false
+	if pattern, ok := bestMatch(slices.Collect(maps.Keys(builtinModelCapabilities)), model); ok {
+		caps, known = builtinModelCapabilities[pattern], true
+	}
+	if pattern, ok := bestMatch(slices.Collect(maps.Keys(r.overrides)), model); ok {
+		caps, known = r.overrides[pattern].apply(caps), true
+	}
+	return caps, known
+}
+
+// bestMatch returns the pattern matching model most specifically: an exact
+// name, else the longest matching prefix pattern.
+func bestMatch(patterns []string, model string) (string, bool) {
+	best, found := "", false
+	for _, pattern := range patterns {
+		prefix, isPrefix := strings.CutSuffix(pattern, "*")
+		switch {
+		case pattern == model:
+			return pattern, true
+		case isPrefix && strings.HasPrefix(model, prefix) && (!found || len(pattern) > len(best)):
+			best, found = pattern, true
+		}
+	}
+	return best, found
+}
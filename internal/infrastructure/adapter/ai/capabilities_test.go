@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"testing"
+)
+
+// Compile-time check that AnthropicAdapter reports model capabilities.
+var _ port.ModelCapabilityReporter = (*AnthropicAdapter)(nil)
+
+func TestCapabilityRegistry_Precedence(t *testing.T) {
+	yes, no := true, false
+	registry := NewCapabilityRegistry(map[string]CapabilityOverride{
+		"claude-sonnet-4*":             {MaxOutputTokens: 16000},
+		"claude-sonnet-4-5-20250929":   {SupportsImages: &no},
+		"hf:zai-org/*":                 {ContextWindow: 100000},
+		"hf:zai-org/GLM-4.6":           {SupportsImages: &yes},
+		"gateway/custom-model-preview": {ContextWindow: 64000, SupportsThinking: &yes},
+	})
+
+	tests := []struct {
+		model string
+		want  port.ModelCapabilities
+		known bool
+	}{
+		{
+			// The longest built-in prefix wins
+			model: "claude-opus-4-5-20250514",
+			want:  port.ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+			known: true,
+		},
+		{
+			model: "claude-opus-4-1-20250805",
+			want:  port.ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 32000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+			known: true,
+		},
+		{
+			// An exact override beats a prefix one, and unset fields keep the built-in values
+			model: "claude-sonnet-4-5-20250929",
+			want:  port.ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsThinking: true},
+			known: true,
+		},
+		{
+			model: "claude-sonnet-4-20250514",
+			want:  port.ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 16000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+			known: true,
+		},
+		{
+			// Matching ignores case
+			model: "HF:ZAI-ORG/GLM-4.6",
+			want:  port.ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 128000, SupportsTools: true, SupportsThinking: true, SupportsImages: true},
+			known: true,
+		},
+		{
+			model: "hf:zai-org/GLM-4.5",
+			want:  port.ModelCapabilities{ContextWindow: 100000, MaxOutputTokens: 4096, SupportsTools: true},
+			known: true,
+		},
+		{
+			model: "gateway/custom-model-preview",
+			want:  port.ModelCapabilities{ContextWindow: 64000, MaxOutputTokens: 4096, SupportsTools: true, SupportsThinking: true},
+			known: true,
+		},
+		{
+			model: "some-new-model",
+			want:  DefaultModelCapabilities,
+			known: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, known := registry.Lookup(tt.model)
+			if got != tt.want || known != tt.known {
+				t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.model, got, known, tt.want, tt.known)
+			}
+		})
+	}
+}
+
+func TestSendMessage_AppliesModelCapabilities(t *testing.T) {
+	adapter, server := newSequencedAdapter(t, jsonMessage("end_turn", textBlock("Done.")))
+	adapter.maxTokens = 20000
+	no := false
+	adapter.SetCapabilityRegistry(NewCapabilityRegistry(map[string]CapabilityOverride{
+		"test-model": {MaxOutputTokens: 4096, SupportsTools: &no},
+	}))
+	if adapter.SupportsImageInput() {
+		t.Error("SupportsImageInput() = true for a model without image support")
+	}
+
+	ctx := port.WithThinkingMode(context.Background(), port.ThinkingModeInfo{Enabled: true, BudgetTokens: 2048})
+	messages := []port.MessageParam{{Role: "user", Content: "Hello"}}
+	tools := []port.ToolParam{{Name: "read_file", Description: "Reads a file", InputSchema: map[string]interface{}{}}}
+	if _, _, err := adapter.SendMessage(ctx, messages, tools); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	request := server.requests[0]
+	if got := request["max_tokens"]; got != float64(4096) {
+		t.Errorf("max_tokens = %v, want the model's limit of 4096", got)
+	}
+	if thinking, _ := request["thinking"].(map[string]any); thinking["type"] != "disabled" {
+		t.Errorf("thinking = %v, want disabled for a model without thinking", request["thinking"])
+	}
+	if tools, ok := request["tools"]; ok && tools != nil {
+		t.Errorf("tools = %v, want none for a model without tool use", tools)
+	}
+}
+
+func TestSendMessageWithOptions_PerRequestModel(t *testing.T) {
+	adapter, server := newSequencedAdapter(t,
+		jsonMessage("end_turn", textBlock("Summary.")),
+		jsonMessage("end_turn", textBlock("Short.")),
+	)
+	adapter.maxTokens = 20000
+	adapter.SetCapabilityRegistry(NewCapabilityRegistry(nil))
+
+	messages := []port.MessageParam{{Role: "user", Content: "Summarize"}}
+	opts := port.RequestOptions{Model: "claude-3-5-haiku-20241022"}
+	if _, _, err := adapter.SendMessageWithOptions(context.Background(), messages, nil, opts); err != nil {
+		t.Fatalf("SendMessageWithOptions() error = %v", err)
+	}
+
+	// The request, and its capabilities, follow the per-request model
+	request := server.requests[0]
+	if request["model"] != "claude-3-5-haiku-20241022" || request["max_tokens"] != float64(8192) {
+		t.Errorf("model = %v, max_tokens = %v, want claude-3-5-haiku-20241022 capped at 8192",
+			request["model"], request["max_tokens"])
+	}
+	if adapter.GetModel() != "test-model" {
+		t.Errorf("GetModel() = %q, the default model should be unchanged", adapter.GetModel())
+	}
+
+	// A requested MaxTokens is still capped by the model's limit
+	opts.MaxTokens = 1024
+	if _, _, err := adapter.SendMessageWithOptions(context.Background(), messages, nil, opts); err != nil {
+		t.Fatalf("SendMessageWithOptions() error = %v", err)
+	}
+	if got := server.requests[1]["max_tokens"]; got != float64(1024) {
+		t.Errorf("max_tokens = %v, want the requested 1024", got)
+	}
+}
@@ -1,6 +1,18 @@
 package ai
 
 import (
+	appService "code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	serviceDomain "code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -60,3 +72,158 @@ func TestSystemPromptDoesNotContainSkills(t *testing.T) {
 		t.Error("System prompt should still contain base prompt text")
 	}
 }
+
+// promptRecordingProvider is a fake AI provider that records the system prompt
+// the Anthropic adapter would send for each chat turn. Requests sent with
+// options, such as session titles, are side requests and are not recorded.
+type promptRecordingProvider struct {
+	adapter *AnthropicAdapter
+	prompts []string
+}
+
+func (p *promptRecordingProvider) SendMessage(
+	ctx context.Context,
+	_ []port.MessageParam,
+	_ []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	p.prompts = append(p.prompts, p.adapter.getSystemPrompt(ctx))
+	return &entity.Message{Role: entity.RoleAssistant, Content: "Done."}, nil, nil
+}
+
+func (p *promptRecordingProvider) SendMessageWithOptions(
+	context.Context,
+	[]port.MessageParam,
+	[]port.ToolParam,
+	port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return &entity.Message{Role: entity.RoleAssistant, Content: "Done."}, nil, nil
+}
+
+func (p *promptRecordingProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessage(ctx, messages, tools)
+}
+
+func (p *promptRecordingProvider) GenerateToolSchema() port.ToolInputSchemaParam {
+	return port.ToolInputSchemaParam{"type": "object"}
+}
+
+func (p *promptRecordingProvider) HealthCheck(context.Context) error { return nil }
+func (p *promptRecordingProvider) SetModel(string) error             { return nil }
+func (p *promptRecordingProvider) GetModel() string                  { return "test-model" }
+
+// TestSystemPromptFollowsPlanModeToggle toggles plan mode twice with the UI's
+// mode toggle key and checks the prompt shown to the user, the system prompt
+// sent on the next turn, and the transcript after each toggle.
+func TestSystemPromptFollowsPlanModeToggle(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	toolExecutor := tool.NewExecutorAdapter(fileManager)
+	provider := &promptRecordingProvider{adapter: &AnthropicAdapter{model: "test-model"}}
+
+	userInterface := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	transcriptPath := filepath.Join(tempDir, "transcript.log")
+	if err := userInterface.EnableTranscript(transcriptPath); err != nil {
+		t.Fatalf("EnableTranscript() error = %v", err)
+	}
+	defer userInterface.CloseTranscript()
+
+	convService, err := serviceDomain.NewConversationService(provider, toolExecutor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+	chatService, err := appService.NewChatServiceFromDomain(convService, userInterface, provider, toolExecutor, fileManager)
+	if err != nil {
+		t.Fatalf("NewChatServiceFromDomain() error = %v", err)
+	}
+
+	ctx := context.Background()
+	startResp, err := chatService.StartSession(ctx, "")
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	sessionID := startResp.SessionID
+
+	customPrompt := "You are a release assistant."
+	if err := convService.SetCustomSystemPrompt(ctx, sessionID, customPrompt); err != nil {
+		t.Fatalf("SetCustomSystemPrompt() error = %v", err)
+	}
+	userInterface.SetModeToggleCallback(func() {
+		if err := chatService.SwitchMode(ctx, sessionID, "toggle"); err != nil {
+			t.Errorf("SwitchMode() error = %v", err)
+		}
+	})
+
+	states := []struct {
+		name         string
+		wantPlanMode bool
+		wantPrompt   string
+		wantLogged   string
+	}{
+		{"first toggle enables plan mode", true, "[PLAN MODE] > ", "system: Plan mode enabled"},
+		{"second toggle disables plan mode", false, "> ", "system: Plan mode disabled"},
+	}
+	for _, state := range states {
+		userInterface.HandleKeyPress(ui.KeyShiftTab)
+
+		if got := userInterface.GetPrompt(); got != state.wantPrompt {
+			t.Errorf("%s: GetPrompt() = %q, want %q", state.name, got, state.wantPrompt)
+		}
+
+		// Two turns, so the mode must carry over between them
+		for turn := 0; turn < 2; turn++ {
+			if _, err := chatService.SendMessage(ctx, sessionID, "Prepare the release"); err != nil {
+				t.Fatalf("%s: SendMessage() error = %v", state.name, err)
+			}
+			systemPrompt := provider.prompts[len(provider.prompts)-1]
+			if !strings.HasPrefix(systemPrompt, customPrompt) {
+				t.Errorf("%s: system prompt should start with the custom prompt, got %q", state.name, systemPrompt)
+			}
+			if got := strings.Contains(systemPrompt, "# PLAN MODE"); got != state.wantPlanMode {
+				t.Errorf("%s: plan mode instructions present = %v, want %v", state.name, got, state.wantPlanMode)
+			}
+		}
+
+		transcript, err := os.ReadFile(transcriptPath)
+		if err != nil {
+			t.Fatalf("reading transcript: %v", err)
+		}
+		if !strings.Contains(string(transcript), state.wantLogged) {
+			t.Errorf("%s: transcript should contain %q, got:\n%s", state.name, state.wantLogged, transcript)
+		}
+	}
+}
+
+// TestSystemPromptUsesComposedPromptAsIs checks that a composed prompt is sent
+// unchanged in plan mode, since its mode layer carries the plan mode
+// instructions, while a plain custom prompt still gets them appended.
+func TestSystemPromptUsesComposedPromptAsIs(t *testing.T) {
+	adapter := &AnthropicAdapter{model: "test-model", memory: "Use tabs."}
+	ctx := port.WithPlanMode(context.Background(), port.PlanModeInfo{
+		Enabled: true, SessionID: "s", PlanPath: ".agent/plans/s.md",
+	})
+
+	composed := "Composed prompt.\n\n" + usecase.PlanModePrompt(".agent/plans/s.md")
+	got := adapter.getSystemPrompt(port.WithCustomSystemPrompt(ctx, port.CustomSystemPromptInfo{
+		SessionID: "s", Prompt: composed, Composed: true,
+	}))
+	if got != composed {
+		t.Errorf("composed prompt was changed:\n%s", got)
+	}
+
+	got = adapter.getSystemPrompt(port.WithCustomSystemPrompt(ctx, port.CustomSystemPromptInfo{
+		SessionID: "s", Prompt: "Custom prompt.",
+	}))
+	if got != "Custom prompt.\n\n"+usecase.PlanModePrompt(".agent/plans/s.md") {
+		t.Errorf("plain custom prompt should get plan mode appended:\n%s", got)
+	}
+
+	if got := adapter.getSystemPrompt(context.Background()); got != usecase.BaseSystemPrompt+"\n\n"+usecase.MemoryPrompt("Use tabs.") {
+		t.Errorf("default prompt = %q, want the base and memory prompts", got)
+	}
+}
@@ -0,0 +1,397 @@
+package aidebug
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNoExchanges is returned by LastExchange when no exchange has been written.
+var ErrNoExchanges = errors.New("no API debug files found")
+
+// noSessionDir holds the exchanges of requests sent outside a session.
+const noSessionDir = "no-session"
+
+// minTextLimit is the shortest a text is cut to when shrinking an exchange
+// to fit its file.
+const minTextLimit = 256
+
+// DefaultDir returns the default directory of exchange files,
+// ~/.config/code-agent/debug, or "debug" under the working directory if the
+// home directory is unknown.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "debug"
+	}
+	return filepath.Join(home, ".config", "code-agent", "debug")
+}
+
+// requestMeta is what a request was sent with besides its messages and tools.
+type requestMeta struct {
+	model     string
+	maxTokens int
+	streaming bool
+}
+
+// rawExchange is an exchange as the provider saw it, before it is masked.
+type rawExchange struct {
+	time         time.Time
+	sessionID    string
+	duration     time.Duration
+	meta         requestMeta
+	systemPrompt string
+	planMode     bool
+	thinking     int64
+	messages     []port.MessageParam
+	tools        []port.ToolParam
+	msg          *entity.Message
+	toolCalls    []port.ToolCallInfo
+	err          error
+}
+
+// newRawExchange captures a request, with the prompt settings the
+// conversation passes through ctx, and its response.
+func newRawExchange(
+	ctx context.Context,
+	meta requestMeta,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	msg *entity.Message,
+	toolCalls []port.ToolCallInfo,
+	err error,
+) rawExchange {
+	raw := rawExchange{meta: meta, messages: messages, tools: tools, msg: msg, toolCalls: toolCalls, err: err}
+	raw.sessionID, _ = port.SessionIDFromContext(ctx)
+	if info, ok := port.CustomSystemPromptFromContext(ctx); ok {
+		raw.systemPrompt = info.Prompt
+	}
+	if info, ok := port.PlanModeFromContext(ctx); ok {
+		raw.planMode = info.Enabled
+	}
+	if info, ok := port.ThinkingModeFromContext(ctx); ok && info.Enabled {
+		raw.thinking = info.BudgetTokens
+	}
+	return raw
+}
+
+// exchange is the masked form of an exchange written to its file.
+type exchange struct {
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id,omitempty"`
+	Duration  string    `json:"duration"`
+	Request   request   `json:"request"`
+	Response  response  `json:"response"`
+}
+
+type request struct {
+	Model          string           `json:"model"`
+	MaxTokens      int              `json:"max_tokens,omitempty"`
+	Streaming      bool             `json:"streaming,omitempty"`
+	SystemPrompt   string           `json:"system_prompt,omitempty"` // Custom system prompt
+	PlanMode       bool             `json:"plan_mode,omitempty"`
+	ThinkingBudget int64            `json:"thinking_budget,omitempty"` // Budget when extended thinking is on
+	Messages       []message        `json:"messages"`
+	Tools          []port.ToolParam `json:"tools,omitempty"`
+}
+
+type message struct {
+	Role        string                 `json:"role"`
+	Content     string                 `json:"content,omitempty"`
+	Blocks      []block                `json:"blocks,omitempty"`
+	Thinking    []string               `json:"thinking,omitempty"`
+	ToolCalls   []toolCall             `json:"tool_calls,omitempty"`
+	ToolResults []port.ToolResultParam `json:"tool_results,omitempty"`
+}
+
+// block is a text block, or an image block with its data left out.
+type block struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Path      string `json:"path,omitempty"`
+	DataBytes int    `json:"data_bytes,omitempty"` // Length of the base64 image data
+}
+
+type toolCall struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input any    `json:"input"`
+}
+
+type response struct {
+	Content   string             `json:"content,omitempty"`
+	Thinking  []string           `json:"thinking,omitempty"`
+	ToolCalls []toolCall         `json:"tool_calls,omitempty"`
+	Usage     *entity.TokenUsage `json:"usage,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// masker masks secrets in the text of an exchange, then cuts texts longer
+// than limit bytes; a limit of 0 keeps texts whole.
+type masker struct {
+	redactor Redactor
+	limit    int
+	redacted map[string]string // Masked texts, kept while an exchange is shrunk to fit
+}
+
+// mask returns raw with its secrets masked and its texts cut to m.limit.
+func (m masker) mask(raw rawExchange) exchange {
+	ex := exchange{
+		Time:      raw.time.UTC(),
+		SessionID: raw.sessionID,
+		Duration:  raw.duration.Round(time.Millisecond).String(),
+		Request: request{
+			Model:          raw.meta.model,
+			MaxTokens:      raw.meta.maxTokens,
+			Streaming:      raw.meta.streaming,
+			SystemPrompt:   m.text(raw.systemPrompt),
+			PlanMode:       raw.planMode,
+			ThinkingBudget: raw.thinking,
+			Messages:       make([]message, 0, len(raw.messages)),
+		},
+	}
+	for _, msg := range raw.messages {
+		ex.Request.Messages = append(ex.Request.Messages, m.message(msg))
+	}
+	for _, tool := range raw.tools {
+		tool.Description = m.text(tool.Description)
+		ex.Request.Tools = append(ex.Request.Tools, tool)
+	}
+
+	if raw.msg != nil {
+		ex.Response.Content = m.text(raw.msg.Content)
+		for _, thinking := range raw.msg.ThinkingBlocks {
+			ex.Response.Thinking = append(ex.Response.Thinking, m.text(thinking.Thinking))
+		}
+		ex.Response.Usage = raw.msg.Usage
+	}
+	for _, call := range raw.toolCalls {
+		ex.Response.ToolCalls = append(ex.Response.ToolCalls, m.toolCall(call.ToolID, call.ToolName, call.Input))
+	}
+	if raw.err != nil {
+		ex.Response.Error = m.text(raw.err.Error())
+	}
+	return ex
+}
+
+func (m masker) message(msg port.MessageParam) message {
+	masked := message{Role: msg.Role, Content: m.text(msg.Content)}
+	for _, b := range msg.Blocks {
+		if b.Image != nil {
+			masked.Blocks = append(masked.Blocks, block{
+				Type:      b.Type,
+				MediaType: b.Image.MediaType,
+				Path:      b.Image.Path,
+				DataBytes: len(b.Image.Data),
+			})
+			continue
+		}
+		masked.Blocks = append(masked.Blocks, block{Type: b.Type, Text: m.text(b.Text)})
+	}
+	for _, thinking := range msg.ThinkingBlocks {
+		masked.Thinking = append(masked.Thinking, m.text(thinking.Thinking))
+	}
+	for _, call := range msg.ToolCalls {
+		masked.ToolCalls = append(masked.ToolCalls, m.toolCall(call.ToolID, call.ToolName, call.Input))
+	}
+	for _, result := range msg.ToolResults {
+		result.Result = m.text(result.Result)
+		masked.ToolResults = append(masked.ToolResults, result)
+	}
+	return masked
+}
+
+// toolCall masks a tool call's input as JSON, so that a secret is found by
+// its key, as in {"password": "..."}. An input that is no longer valid JSON
+// once masked is kept as a string.
+func (m masker) toolCall(id, name string, input map[string]interface{}) toolCall {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return toolCall{ID: id, Name: name, Input: m.text(fmt.Sprint(input))}
+	}
+	redacted := m.redact(string(data))
+	var masked map[string]interface{}
+	if err := json.Unmarshal([]byte(redacted), &masked); err != nil {
+		return toolCall{ID: id, Name: name, Input: m.cut(redacted)}
+	}
+	return toolCall{ID: id, Name: name, Input: m.cutValues(masked)}
+}
+
+// text masks the secrets in s and cuts it to the limit.
+func (m masker) text(s string) string {
+	return m.cut(m.redact(s))
+}
+
+// redact masks the secrets in s, once for each text however often an
+// exchange is masked.
+func (m masker) redact(s string) string {
+	if redacted, ok := m.redacted[s]; ok {
+		return redacted
+	}
+	redacted := m.redactor.Redact(s)
+	if m.redacted != nil {
+		m.redacted[s] = redacted
+	}
+	return redacted
+}
+
+// cut shortens s to the limit at a UTF-8 boundary, noting how much was cut.
+func (m masker) cut(s string) string {
+	if m.limit <= 0 || len(s) <= m.limit {
+		return s
+	}
+	end := m.limit
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return fmt.Sprintf("%s[truncated %d bytes]", s[:end], len(s)-end)
+}
+
+// cutValues cuts the strings in a decoded JSON value to the limit.
+func (m masker) cutValues(v any) any {
+	switch v := v.(type) {
+	case string:
+		return m.cut(v)
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = m.cutValues(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = m.cutValues(value)
+		}
+	}
+	return v
+}
+
+// writeExchange writes raw, masked by redactor, to a new file under dir named
+// for its session, time, and seq, and returns the file's path. An exchange
+// larger than maxFileBytes has its texts cut until it fits; if cutting them
+// to minTextLimit is not enough, the file itself is cut, and is then not
+// valid JSON.
+func writeExchange(dir string, raw rawExchange, seq uint64, redactor Redactor, maxFileBytes int) (string, error) {
+	m := masker{redactor: redactor, redacted: make(map[string]string)}
+	data, err := json.MarshalIndent(m.mask(raw), "", "  ")
+	for m.limit = maxFileBytes / 2; err == nil && len(data) > maxFileBytes && m.limit >= minTextLimit; m.limit /= 2 {
+		data, err = json.MarshalIndent(m.mask(raw), "", "  ")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode exchange: %w", err)
+	}
+	if len(data) > maxFileBytes {
+		data = append(data[:maxFileBytes:maxFileBytes], fmt.Sprintf("\n[truncated %d bytes]", len(data)-maxFileBytes)...)
+	}
+	data = append(data, '\n')
+
+	sessionDir := filepath.Join(dir, sessionDirName(raw.sessionID))
+	if err := os.MkdirAll(sessionDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create API debug directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%04d.json", raw.time.UTC().Format("20060102T150405.000Z"), seq)
+	path := filepath.Join(sessionDir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write API debug file: %w", err)
+	}
+	return path, nil
+}
+
+// sessionDirName returns the name of the directory holding a session's
+// exchanges, with characters unsafe in a file name replaced.
+func sessionDirName(sessionID string) string {
+	if sessionID == "" {
+		return noSessionDir
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, sessionID)
+	if strings.Trim(name, ".") == "" { // "." and ".." are not names
+		return strings.Repeat("_", len(name))
+	}
+	return name
+}
+
+// Cleanup removes the exchange files under dir last written before
+// now-retention, and the session directories it leaves empty. It returns
+// how many files it removed. A missing dir has nothing to clean up.
+func Cleanup(dir string, retention time.Duration, now time.Time) (int, error) {
+	sessions, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read API debug directory: %w", err)
+	}
+
+	cutoff := now.Add(-retention)
+	removed := 0
+	var errs []error
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+		sessionDir := filepath.Join(dir, session.Name())
+		files, err := os.ReadDir(sessionDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		kept := len(files)
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || file.IsDir() || filepath.Ext(file.Name()) != ".json" || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(sessionDir, file.Name())); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			removed++
+			kept--
+		}
+		if kept == 0 {
+			if err := os.Remove(sessionDir); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// LastExchange returns the path of the most recently written exchange file
+// under dir, or ErrNoExchanges if there is none.
+func LastExchange(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list API debug files: %w", err)
+	}
+	var last string
+	var lastTime time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		modTime := info.ModTime()
+		if last == "" || modTime.After(lastTime) || modTime.Equal(lastTime) && filepath.Base(path) > filepath.Base(last) {
+			last, lastTime = path, modTime
+		}
+	}
+	if last == "" {
+		return "", ErrNoExchanges
+	}
+	return last, nil
+}
@@ -0,0 +1,199 @@
+// Package aidebug writes each request an AIProvider is sent, with its
+// response, to a pretty-printed JSON file, so that odd model behavior can be
+// traced to exactly what the model was asked. Secrets are masked before
+// anything is written.
+package aidebug
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for Options fields left zero.
+const (
+	DefaultMaxFileBytes = 1 << 20
+	DefaultRetention    = 7 * 24 * time.Hour
+)
+
+// Redactor masks secrets in text. ui.Redactor implements it.
+type Redactor interface {
+	Redact(text string) string
+}
+
+// Options configures where and how much a Provider writes.
+type Options struct {
+	Dir          string        // Directory holding a subdirectory of exchange files per session
+	MaxFileBytes int           // Size cap of one exchange file; 0 means DefaultMaxFileBytes
+	Retention    time.Duration // Age at which exchange files are removed; 0 means DefaultRetention
+	Enabled      bool          // Start writing exchanges right away, as SetEnabled(true) does
+}
+
+// Provider is a port.AIProvider that, while enabled, writes every request
+// sent to the wrapped provider and the response it gave to a file under
+// <dir>/<session-id>/. Disabled, it writes nothing. Methods other than the
+// three that send go straight to the wrapped provider.
+type Provider struct {
+	port.AIProvider
+	redactor     Redactor
+	dir          string
+	maxFileBytes int
+	retention    time.Duration
+	enabled      atomic.Bool
+	seq          atomic.Uint64
+	now          func() time.Time
+
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// Compile-time check that Provider implements port.AIProvider.
+var _ port.AIProvider = (*Provider)(nil)
+
+// NewProvider wraps provider so its exchanges can be written to opts.Dir,
+// masked by redactor. It starts disabled unless opts.Enabled is set, in which
+// case the files older than the retention period are removed first.
+func NewProvider(provider port.AIProvider, redactor Redactor, opts Options) *Provider {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	p := &Provider{
+		AIProvider:   provider,
+		redactor:     redactor,
+		dir:          opts.Dir,
+		maxFileBytes: opts.MaxFileBytes,
+		retention:    opts.Retention,
+		now:          time.Now,
+		logger:       slog.Default(),
+	}
+	if opts.Enabled {
+		p.SetEnabled(true)
+	}
+	return p
+}
+
+// SetLogger sets the logger that failures to write an exchange are reported
+// to. A nil logger restores slog.Default().
+func (p *Provider) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logger = logger
+}
+
+// SetEnabled starts or stops writing exchanges. Enabling first removes the
+// files older than the retention period.
+func (p *Provider) SetEnabled(enabled bool) {
+	if enabled {
+		if _, err := Cleanup(p.dir, p.retention, p.now()); err != nil {
+			p.log().Warn("failed to remove old API debug files", "dir", p.dir, "error", err)
+		}
+	}
+	p.enabled.Store(enabled)
+}
+
+// Enabled reports whether exchanges are being written.
+func (p *Provider) Enabled() bool {
+	return p.enabled.Load()
+}
+
+// Dir returns the directory exchanges are written to.
+func (p *Provider) Dir() string {
+	return p.dir
+}
+
+// SupportsImageInput reports whether the wrapped provider accepts images.
+func (p *Provider) SupportsImageInput() bool {
+	return port.SupportsImageInput(p.AIProvider)
+}
+
+// ModelCapabilities reports what the wrapped provider says model supports,
+// or that it supports every feature when the provider does not say.
+func (p *Provider) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	if reporter, ok := p.AIProvider.(port.ModelCapabilityReporter); ok {
+		return reporter.ModelCapabilities(model)
+	}
+	return port.ModelCapabilities{SupportsTools: true, SupportsThinking: true, SupportsImages: true}, false
+}
+
+// SendMessage sends the message and writes the exchange if enabled.
+func (p *Provider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	start := p.now()
+	msg, toolCalls, err := p.AIProvider.SendMessage(ctx, messages, tools)
+	p.write(ctx, start, requestMeta{model: p.GetModel()}, messages, tools, msg, toolCalls, err)
+	return msg, toolCalls, err
+}
+
+// SendMessageWithOptions sends the message with opts and writes the exchange
+// if enabled.
+func (p *Provider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	start := p.now()
+	msg, toolCalls, err := p.AIProvider.SendMessageWithOptions(ctx, messages, tools, opts)
+	model := opts.Model
+	if model == "" {
+		model = p.GetModel()
+	}
+	p.write(ctx, start, requestMeta{model: model, maxTokens: opts.MaxTokens}, messages, tools, msg, toolCalls, err)
+	return msg, toolCalls, err
+}
+
+// SendMessageStreaming sends the message with streaming and writes the
+// complete exchange if enabled.
+func (p *Provider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	start := p.now()
+	msg, toolCalls, err := p.AIProvider.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+	p.write(ctx, start, requestMeta{model: p.GetModel(), streaming: true}, messages, tools, msg, toolCalls, err)
+	return msg, toolCalls, err
+}
+
+// write writes an exchange if enabled. A failure is logged rather than
+// returned, so debugging never breaks a conversation.
+func (p *Provider) write(
+	ctx context.Context,
+	start time.Time,
+	meta requestMeta,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	msg *entity.Message,
+	toolCalls []port.ToolCallInfo,
+	sendErr error,
+) {
+	if !p.Enabled() {
+		return
+	}
+	raw := newRawExchange(ctx, meta, messages, tools, msg, toolCalls, sendErr)
+	raw.time, raw.duration = start, p.now().Sub(start)
+	if _, err := writeExchange(p.dir, raw, p.seq.Add(1), p.redactor, p.maxFileBytes); err != nil {
+		p.log().Warn("failed to write API debug file", "dir", p.dir, "error", err)
+	}
+}
+
+func (p *Provider) log() *slog.Logger {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.logger
+}
@@ -0,0 +1,249 @@
+package aidebug
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubProvider answers every request with a tool call carrying a secret.
+type stubProvider struct {
+	port.AIProvider
+	reply string
+}
+
+func (p *stubProvider) SendMessage(
+	_ context.Context,
+	_ []port.MessageParam,
+	_ []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	toolCalls := []port.ToolCallInfo{{
+		ToolID:   "t1",
+		ToolName: "bash",
+		Input:    map[string]interface{}{"command": "curl -H 'Authorization: Bearer abc123' https://api.example.com"},
+	}}
+	return &entity.Message{Role: entity.RoleAssistant, Content: p.reply}, toolCalls, nil
+}
+
+func (p *stubProvider) GetModel() string { return "test-model" }
+
+// sendOne sends a request with secrets in its message and tool call input
+// through a Provider writing to dir.
+func sendOne(t *testing.T, provider *Provider, content string) {
+	t.Helper()
+	ctx := port.WithSessionID(context.Background(), "session-1")
+	messages := []port.MessageParam{
+		{Role: entity.RoleUser, Content: content},
+		{Role: entity.RoleAssistant, ToolCalls: []port.ToolCallParam{{
+			ToolID:   "t0",
+			ToolName: "write_file",
+			Input:    map[string]interface{}{"path": ".env", "password": "hunter2"},
+		}}},
+		{Role: entity.RoleUser, ToolResults: []port.ToolResultParam{{ToolID: "t0", Result: "API_TOKEN=s3cr3t written"}}},
+	}
+	if _, _, err := provider.SendMessage(ctx, messages, nil); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+}
+
+// exchangeFiles returns the exchange files written under dir.
+func exchangeFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestProvider_DisabledWritesNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "debug")
+	provider := NewProvider(&stubProvider{reply: "hi"}, ui.NewRedactor(), Options{Dir: dir})
+
+	sendOne(t, provider, "hello")
+
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("debug directory exists while disabled (err = %v)", err)
+	}
+
+	provider.SetEnabled(true)
+	sendOne(t, provider, "hello")
+	provider.SetEnabled(false)
+	sendOne(t, provider, "hello")
+	if files := exchangeFiles(t, dir); len(files) != 1 {
+		t.Errorf("wrote %d files, want 1 for the one request sent while enabled", len(files))
+	}
+}
+
+func TestNewProvider_EnabledRemovesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "session-1", "old.json")
+	if err := os.MkdirAll(filepath.Dir(old), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-8 * 24 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if NewProvider(&stubProvider{}, ui.NewRedactor(), Options{Dir: dir}).Enabled() {
+		t.Error("provider enabled without Options.Enabled")
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Errorf("old file removed by a disabled provider: %v", err)
+	}
+
+	if !NewProvider(&stubProvider{}, ui.NewRedactor(), Options{Dir: dir, Enabled: true}).Enabled() {
+		t.Error("provider disabled despite Options.Enabled")
+	}
+	if _, err := os.Stat(old); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("old file still exists after starting enabled (err = %v)", err)
+	}
+}
+
+func TestProvider_RedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewProvider(&stubProvider{reply: "Use password: opensesame"}, ui.NewRedactor(), Options{Dir: dir})
+	provider.SetEnabled(true)
+
+	sendOne(t, provider, "My key is sk-ant-REDACTED")
+
+	files := exchangeFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("wrote %d files, want 1", len(files))
+	}
+	if got := filepath.Base(filepath.Dir(files[0])); got != "session-1" {
+		t.Errorf("file written under %q, want the session's directory", got)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-ant-api03", "hunter2", "s3cr3t", "abc123", "opensesame"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("file contains secret %q:\n%s", secret, data)
+		}
+	}
+
+	var ex exchange
+	if err := json.Unmarshal(data, &ex); err != nil {
+		t.Fatalf("file is not valid JSON: %v", err)
+	}
+	if ex.Request.Model != "test-model" || ex.SessionID != "session-1" {
+		t.Errorf("model, session = %q, %q", ex.Request.Model, ex.SessionID)
+	}
+	call := ex.Request.Messages[1].ToolCalls[0]
+	input, ok := call.Input.(map[string]interface{})
+	if !ok || input["path"] != ".env" || input["password"] != "********" {
+		t.Errorf("tool call input = %#v, want the password masked and the path kept", call.Input)
+	}
+	if !strings.Contains(string(data), "\n  \"request\"") {
+		t.Error("file is not pretty-printed")
+	}
+}
+
+func TestWriteExchange_CapsFileSize(t *testing.T) {
+	dir := t.TempDir()
+	raw := rawExchange{
+		time:     time.Now(),
+		meta:     requestMeta{model: "test-model"},
+		messages: []port.MessageParam{{Role: entity.RoleUser, Content: strings.Repeat("ünïcode ", 20000)}},
+		msg:      &entity.Message{Role: entity.RoleAssistant, Content: strings.Repeat("reply ", 20000)},
+	}
+
+	path, err := writeExchange(dir, raw, 1, ui.NewRedactor(), 8192)
+	if err != nil {
+		t.Fatalf("writeExchange() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > 8192+1 {
+		t.Errorf("file is %d bytes, want at most the 8192 byte cap", len(data))
+	}
+	var ex exchange
+	if err := json.Unmarshal(data, &ex); err != nil {
+		t.Fatalf("capped file is not valid JSON: %v", err)
+	}
+	if !strings.Contains(ex.Request.Messages[0].Content, "[truncated ") {
+		t.Errorf("message content was not marked as truncated")
+	}
+	if filepath.Base(filepath.Dir(path)) != noSessionDir {
+		t.Errorf("exchange without a session written to %s", path)
+	}
+}
+
+func TestCleanup_RemovesFilesPastRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	write := func(session, name string, age time.Duration) string {
+		t.Helper()
+		path := filepath.Join(dir, session, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	oldOnly := write("old", "a.json", 8*24*time.Hour)
+	oldMixed := write("mixed", "a.json", 8*24*time.Hour)
+	recent := write("mixed", "b.json", time.Hour)
+
+	removed, err := Cleanup(dir, 7*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Cleanup() removed %d files, want 2", removed)
+	}
+	for _, path := range []string{oldOnly, oldMixed, filepath.Dir(oldOnly)} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still exists", path)
+		}
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent file removed: %v", err)
+	}
+
+	if last, err := LastExchange(dir); err != nil || last != recent {
+		t.Errorf("LastExchange() = %q, %v; want %q", last, err, recent)
+	}
+	if _, err := Cleanup(filepath.Join(dir, "missing"), time.Hour, now); err != nil {
+		t.Errorf("Cleanup() of a missing directory error = %v", err)
+	}
+	if _, err := LastExchange(t.TempDir()); !errors.Is(err, ErrNoExchanges) {
+		t.Errorf("LastExchange() of an empty directory error = %v, want ErrNoExchanges", err)
+	}
+}
+
+func TestSessionDirName(t *testing.T) {
+	tests := map[string]string{
+		"":                     noSessionDir,
+		"3f2a-b1":              "3f2a-b1",
+		"../../etc":            ".._.._etc",
+		"..":                   "__",
+		"investigation/a b:c.": "investigation_a_b_c.",
+	}
+	for sessionID, want := range tests {
+		if got := sessionDirName(sessionID); got != want {
+			t.Errorf("sessionDirName(%q) = %q, want %q", sessionID, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package aireplaytest provides test helpers for replaying golden AI
+// transcripts, kept apart from aireplay so production code never imports
+// the testing package.
+package aireplaytest
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/aireplay"
+	"path/filepath"
+	"testing"
+)
+
+// Replay loads testdata/<name> of the calling test's package and returns a
+// provider replaying it. The test fails when it ends if a request did not
+// match the recording or recorded exchanges were never asked for.
+//
+// With update set, typically from a -update test flag, the recorded
+// responses are served in order without checking the requests, and the
+// recording is rewritten with the requests actually made when the test ends:
+// run it once after an intended prompt change and review the diff.
+func Replay(tb testing.TB, name string, update bool) *aireplay.ReplayAIProvider {
+	tb.Helper()
+	path := filepath.Join("testdata", name)
+	rec, err := aireplay.LoadRecording(path)
+	if err != nil {
+		tb.Fatalf("failed to load AI recording: %v", err)
+	}
+
+	provider := aireplay.NewReplayAIProvider(rec)
+	provider.SetUpdate(update)
+	tb.Cleanup(func() {
+		if update {
+			if err := provider.Recording().Save(path); err != nil {
+				tb.Errorf("failed to update AI recording: %v", err)
+			}
+			return
+		}
+		if err := provider.Err(); err != nil {
+			tb.Errorf("AI replay of %s failed: %v", path, err)
+		}
+		if n := provider.Unserved(); n > 0 {
+			tb.Errorf("AI replay of %s: %d recorded exchange(s) were never requested", path, n)
+		}
+	})
+	return provider
+}
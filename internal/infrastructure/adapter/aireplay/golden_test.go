@@ -0,0 +1,84 @@
+package aireplay_test
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/aireplay/aireplaytest"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//nolint:gochecknoglobals // test flag
+var updateRecordings = flag.Bool("update", false, "rewrite the requests in the testdata AI recordings")
+
+// TestGolden_GenericAlertInvestigation runs a full investigation of an alert
+// without a dedicated prompt builder against a recorded transcript: the AI
+// checks the service's logs with bash, answered from a tool fixture, then
+// completes the investigation. The test fails if the prompts, tools, or
+// conversation sent to the AI differ from the recording; after an intended
+// change, rerun it with -update and review the recording's diff.
+func TestGolden_GenericAlertInvestigation(t *testing.T) {
+	provider := aireplaytest.Replay(t, "generic_alert.json", *updateRecordings)
+
+	base := tool.NewExecutorAdapter(file.NewLocalFileManager("."))
+	executor := tool.NewFixtureExecutor(base, filepath.Join("testdata", "generic_alert_fixtures"), tool.FixtureReplay)
+	convService, err := service.NewConversationService(provider, executor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+
+	registry := usecase.NewPromptBuilderRegistry()
+	if err := registry.Register(usecase.NewGenericPromptBuilder()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	investigations := usecase.NewAlertInvestigationUseCaseWithConfig(usecase.AlertInvestigationUseCaseConfig{
+		MaxActions:    10,
+		MaxDuration:   time.Minute,
+		MaxConcurrent: 1,
+		AllowedTools:  []string{"bash", "read_file", "complete_investigation", "escalate_investigation"},
+	})
+	investigations.SetConversationService(convService)
+	investigations.SetToolExecutor(executor)
+	investigations.SetPromptBuilderRegistry(registry)
+
+	alert, err := entity.NewAlert("alert-checkout-5xx", "prometheus", entity.SeverityCritical,
+		"Checkout API error rate above 5%")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	alert = alert.
+		WithDescription("5xx responses from checkout-api exceeded 5% for 10 minutes").
+		WithLabels(map[string]string{"service": "checkout-api", "namespace": "shop"})
+
+	result, err := investigations.HandleAlert(context.Background(), usecase.NewAlertForInvestigationFromEntity(alert))
+	if err != nil {
+		t.Fatalf("HandleAlert() error = %v", err)
+	}
+
+	if result.Status != "completed" {
+		t.Errorf("Status = %q, want completed (error: %v)", result.Status, result.Error)
+	}
+	wantFindings := []string{
+		"[warning] checkout-api logs show connection refused errors to payments-db:5432",
+		"[warning] Errors started at 14:02 UTC, matching the alert window",
+	}
+	if !reflect.DeepEqual(result.Findings, wantFindings) {
+		t.Errorf("Findings = %q, want %q", result.Findings, wantFindings)
+	}
+	if result.RootCause != "payments-db is refusing connections" {
+		t.Errorf("RootCause = %q, want the recorded root cause", result.RootCause)
+	}
+	if result.ActionsTaken != 1 {
+		t.Errorf("ActionsTaken = %d, want 1", result.ActionsTaken)
+	}
+	if executor.Misses() != 0 {
+		t.Errorf("%d tool call(s) had no fixture", executor.Misses())
+	}
+}
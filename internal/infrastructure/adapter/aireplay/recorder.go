@@ -0,0 +1,127 @@
+package aireplay
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RecordingAIProvider is a port.AIProvider that sends every request to the
+// wrapped provider and appends the request and its response to a recording
+// file, rewritten after each exchange so an interrupted run keeps what it
+// recorded. Methods other than the three that send go straight to the
+// wrapped provider.
+type RecordingAIProvider struct {
+	port.AIProvider
+	path string
+	mu   sync.Mutex
+	rec  Recording
+}
+
+// Compile-time check that RecordingAIProvider implements port.AIProvider.
+var _ port.AIProvider = (*RecordingAIProvider)(nil)
+
+// NewRecordingAIProvider wraps provider so its exchanges are recorded to
+// path, replacing any recording already there.
+func NewRecordingAIProvider(provider port.AIProvider, path string) *RecordingAIProvider {
+	return &RecordingAIProvider{
+		AIProvider: provider,
+		path:       path,
+		rec:        Recording{Model: provider.GetModel(), Exchanges: []Exchange{}},
+	}
+}
+
+// SupportsImageInput reports whether the wrapped provider accepts images.
+func (p *RecordingAIProvider) SupportsImageInput() bool {
+	return port.SupportsImageInput(p.AIProvider)
+}
+
+// ModelCapabilities reports what the wrapped provider says model supports,
+// or that it supports every feature when the provider does not say.
+func (p *RecordingAIProvider) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	if reporter, ok := p.AIProvider.(port.ModelCapabilityReporter); ok {
+		return reporter.ModelCapabilities(model)
+	}
+	return port.ModelCapabilities{SupportsTools: true, SupportsThinking: true, SupportsImages: true}, false
+}
+
+// SendMessage sends the message and records the exchange.
+func (p *RecordingAIProvider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	req := newRequest(ctx, p.GetModel(), 0, messages, tools)
+	msg, toolCalls, err := p.AIProvider.SendMessage(ctx, messages, tools)
+	return p.record(req, msg, toolCalls, err)
+}
+
+// SendMessageWithOptions sends the message with opts and records the exchange.
+func (p *RecordingAIProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	req := newRequest(ctx, effectiveModel(opts.Model, p.GetModel()), opts.MaxTokens, messages, tools)
+	msg, toolCalls, err := p.AIProvider.SendMessageWithOptions(ctx, messages, tools, opts)
+	return p.record(req, msg, toolCalls, err)
+}
+
+// SendMessageStreaming sends the message with streaming and records the
+// complete response.
+func (p *RecordingAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	req := newRequest(ctx, p.GetModel(), 0, messages, tools)
+	msg, toolCalls, err := p.AIProvider.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+	return p.record(req, msg, toolCalls, err)
+}
+
+// Recording returns a copy of what has been recorded so far.
+func (p *RecordingAIProvider) Recording() *Recording {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &Recording{Model: p.rec.Model, Exchanges: append([]Exchange(nil), p.rec.Exchanges...)}
+}
+
+// record appends an exchange to the recording and saves it. The provider's
+// response is returned unchanged, joined with any failure to record it.
+func (p *RecordingAIProvider) record(
+	req Request,
+	msg *entity.Message,
+	toolCalls []port.ToolCallInfo,
+	sendErr error,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	key, err := req.Key()
+	if err == nil {
+		p.mu.Lock()
+		p.rec.Exchanges = append(p.rec.Exchanges, Exchange{
+			Key:      key,
+			Request:  req,
+			Response: newResponse(msg, toolCalls, sendErr),
+		})
+		err = p.rec.Save(p.path)
+		p.mu.Unlock()
+	}
+	if err != nil {
+		return msg, toolCalls, errors.Join(sendErr, fmt.Errorf("failed to record AI exchange: %w", err))
+	}
+	return msg, toolCalls, sendErr
+}
+
+// effectiveModel returns the model a request is sent to: the requested one,
+// or the provider's default.
+func effectiveModel(requested, defaultModel string) string {
+	if requested != "" {
+		return requested
+	}
+	return defaultModel
+}
@@ -0,0 +1,142 @@
+// Package aireplay records the requests an AIProvider receives, with its
+// responses, and replays them without calling the provider, so the decision
+// flow of an investigation can be re-run deterministically against a golden
+// transcript.
+package aireplay
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Recording is an ordered transcript of requests to an AI provider and the
+// responses it gave, stored as one JSON file.
+type Recording struct {
+	Model     string     `json:"model"` // The provider's default model when recorded
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Exchange is one request and the response it received.
+type Exchange struct {
+	Key      string   `json:"key"` // Hash of Request, see Request.Key
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is everything that shapes what a provider is asked: the messages
+// and tools, the effective model and token limit, and the prompt settings the
+// conversation passes through the context. Session IDs are left out, as they
+// differ on every run, and tools are sorted by name, as executors list them
+// in no particular order.
+type Request struct {
+	Model        string              `json:"model"`
+	MaxTokens    int                 `json:"max_tokens,omitempty"`
+	SystemPrompt string              `json:"system_prompt,omitempty"` // Custom system prompt
+	PlanMode     bool                `json:"plan_mode,omitempty"`
+	Thinking     int64               `json:"thinking_budget,omitempty"` // Budget when extended thinking is on
+	Messages     []port.MessageParam `json:"messages"`
+	Tools        []port.ToolParam    `json:"tools,omitempty"`
+}
+
+// Response is a provider's answer to a request: a message and its tool
+// calls, or the error the provider returned.
+type Response struct {
+	Message   *entity.Message     `json:"message,omitempty"`
+	ToolCalls []port.ToolCallInfo `json:"tool_calls,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// newRequest captures a request sent to model with maxTokens.
+func newRequest(
+	ctx context.Context,
+	model string,
+	maxTokens int,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) Request {
+	req := Request{
+		Model:     model,
+		MaxTokens: maxTokens,
+		Messages:  messages,
+		Tools:     append([]port.ToolParam(nil), tools...),
+	}
+	if info, ok := port.CustomSystemPromptFromContext(ctx); ok {
+		req.SystemPrompt = info.Prompt
+	}
+	if info, ok := port.PlanModeFromContext(ctx); ok {
+		req.PlanMode = info.Enabled
+	}
+	if info, ok := port.ThinkingModeFromContext(ctx); ok && info.Enabled {
+		req.Thinking = info.BudgetTokens
+	}
+	sort.SliceStable(req.Tools, func(i, j int) bool { return req.Tools[i].Name < req.Tools[j].Name })
+	return req
+}
+
+// Key returns the hex SHA-256 of the request's JSON encoding, which
+// identifies the request in a recording.
+func (r Request) Key() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// newResponse captures a provider's response.
+func newResponse(msg *entity.Message, toolCalls []port.ToolCallInfo, err error) Response {
+	resp := Response{Message: msg, ToolCalls: toolCalls}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// LoadRecording reads a recording file.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", path, err)
+	}
+	return &rec, nil
+}
+
+// Save writes the recording to path through a temporary file, so a reader
+// never sees a partial recording.
+func (r *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".recording-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
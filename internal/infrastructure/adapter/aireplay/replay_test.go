@@ -0,0 +1,178 @@
+package aireplay
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// echoProvider answers every request with the content of its last message.
+type echoProvider struct {
+	calls int
+}
+
+func (p *echoProvider) SendMessage(
+	_ context.Context,
+	messages []port.MessageParam,
+	_ []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	p.calls++
+	reply := "echo: " + messages[len(messages)-1].Content
+	toolCalls := []port.ToolCallInfo{{ToolID: "t1", ToolName: "bash", Input: map[string]interface{}{"command": "df"}}}
+	return &entity.Message{Role: entity.RoleAssistant, Content: reply}, toolCalls, nil
+}
+
+func (p *echoProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessage(ctx, messages, tools)
+}
+
+func (p *echoProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessage(ctx, messages, tools)
+}
+
+func (p *echoProvider) GenerateToolSchema() port.ToolInputSchemaParam { return nil }
+func (p *echoProvider) HealthCheck(context.Context) error             { return nil }
+func (p *echoProvider) SetModel(string) error                         { return nil }
+func (p *echoProvider) GetModel() string                              { return "echo-model" }
+
+func promptContext(prompt string) context.Context {
+	return port.WithCustomSystemPrompt(context.Background(),
+		port.CustomSystemPromptInfo{Prompt: prompt, SessionID: "random-session"})
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	live := &echoProvider{}
+	recorder := NewRecordingAIProvider(live, path)
+
+	tools := []port.ToolParam{{Name: "read_file"}, {Name: "bash"}}
+	first := []port.MessageParam{{Role: entity.RoleUser, Content: "disk full"}}
+	second := append(first, port.MessageParam{Role: entity.RoleAssistant, Content: "checking"},
+		port.MessageParam{Role: entity.RoleUser, Content: "98%"})
+	ctx := promptContext("You are investigating.")
+
+	if _, _, err := recorder.SendMessage(ctx, first, tools); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if _, _, err := recorder.SendMessageWithOptions(ctx, second, tools, port.RequestOptions{MaxTokens: 64}); err != nil {
+		t.Fatalf("SendMessageWithOptions() error = %v", err)
+	}
+
+	rec, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording() error = %v", err)
+	}
+	if len(rec.Exchanges) != 2 || rec.Model != "echo-model" {
+		t.Fatalf("recording = %d exchanges for %q, want 2 for echo-model", len(rec.Exchanges), rec.Model)
+	}
+
+	// The session ID and tool order differ, and requests arrive out of
+	// order, but each still matches its recorded exchange
+	replay := NewReplayAIProvider(rec)
+	replayCtx := port.WithCustomSystemPrompt(context.Background(),
+		port.CustomSystemPromptInfo{Prompt: "You are investigating.", SessionID: "another-session"})
+	reordered := []port.ToolParam{{Name: "bash"}, {Name: "read_file"}}
+
+	msg, _, err := replay.SendMessageWithOptions(replayCtx, second, reordered, port.RequestOptions{MaxTokens: 64})
+	if err != nil {
+		t.Fatalf("replayed SendMessageWithOptions() error = %v", err)
+	}
+	if msg.Content != "echo: 98%" {
+		t.Errorf("replayed content = %q, want %q", msg.Content, "echo: 98%")
+	}
+
+	var streamed string
+	msg, toolCalls, err := replay.SendMessageStreaming(replayCtx, first, reordered,
+		func(text string) error { streamed += text; return nil }, nil)
+	if err != nil {
+		t.Fatalf("replayed SendMessageStreaming() error = %v", err)
+	}
+	if msg.Content != "echo: disk full" || streamed != msg.Content {
+		t.Errorf("replayed content = %q, streamed %q, want %q", msg.Content, streamed, "echo: disk full")
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Input["command"] != "df" {
+		t.Errorf("replayed tool calls = %+v, want one df call", toolCalls)
+	}
+
+	if live.calls != 2 {
+		t.Errorf("live provider called %d times, want 2", live.calls)
+	}
+	if replay.Err() != nil || replay.Unserved() != 0 {
+		t.Errorf("Err() = %v, Unserved() = %d, want nil and 0", replay.Err(), replay.Unserved())
+	}
+}
+
+func TestReplay_MismatchShowsFirstDifference(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	recorder := NewRecordingAIProvider(&echoProvider{}, path)
+	messages := []port.MessageParam{{Role: entity.RoleUser, Content: "disk full"}}
+	if _, _, err := recorder.SendMessage(promptContext("Step 1: look.\nStep 2: fix."), messages, nil); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	rec, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording() error = %v", err)
+	}
+
+	replay := NewReplayAIProvider(rec)
+	_, _, err = replay.SendMessage(promptContext("Step 1: look.\nStep 2: restart."), messages, nil)
+	if !errors.Is(err, ErrReplayMismatch) {
+		t.Fatalf("error = %v, want ErrReplayMismatch", err)
+	}
+	for _, want := range []string{"at system_prompt, line 2", "- Step 2: fix.", "+ Step 2: restart."} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if !errors.Is(replay.Err(), ErrReplayMismatch) || replay.Unserved() != 1 {
+		t.Errorf("Err() = %v, Unserved() = %d, want the mismatch and 1", replay.Err(), replay.Unserved())
+	}
+
+	_, _, err = replay.SendMessage(promptContext("Step 1: look.\nStep 2: fix."),
+		append(messages, port.MessageParam{Role: entity.RoleUser, Content: "more"}), nil)
+	if err == nil || !strings.Contains(err.Error(), "at messages: recorded 1 elements, got 2") {
+		t.Errorf("error = %v, want a message count difference", err)
+	}
+}
+
+func TestReplay_UpdateRewritesRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	recorder := NewRecordingAIProvider(&echoProvider{}, path)
+	if _, _, err := recorder.SendMessage(promptContext("old prompt"),
+		[]port.MessageParam{{Role: entity.RoleUser, Content: "disk full"}}, nil); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	rec, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording() error = %v", err)
+	}
+
+	replay := NewReplayAIProvider(rec)
+	replay.SetUpdate(true)
+	msg, _, err := replay.SendMessage(promptContext("new prompt"),
+		[]port.MessageParam{{Role: entity.RoleUser, Content: "disk full"}}, nil)
+	if err != nil {
+		t.Fatalf("SendMessage() in update mode error = %v", err)
+	}
+	if msg.Content != "echo: disk full" {
+		t.Errorf("content = %q, want the recorded response", msg.Content)
+	}
+	if got := replay.Recording().Exchanges[0].Request.SystemPrompt; got != "new prompt" {
+		t.Errorf("updated system prompt = %q, want %q", got, "new prompt")
+	}
+}
@@ -0,0 +1,339 @@
+package aireplay
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrReplayMismatch is returned by a ReplayAIProvider for a request that is
+// not in its recording.
+var ErrReplayMismatch = errors.New("request does not match the recording")
+
+// maxDiffValueLen caps how much of a differing value a mismatch error quotes.
+const maxDiffValueLen = 300
+
+// ReplayAIProvider is a port.AIProvider that answers requests from a
+// Recording without calling any provider. A request is answered with the
+// response of an unserved exchange with the same key, so requests may arrive
+// in a different order than recorded, as parallel subagents' do. Any other
+// request fails with ErrReplayMismatch and a diff against the first unserved
+// exchange; the first such failure is kept for Err, as the code under test
+// may swallow it.
+type ReplayAIProvider struct {
+	mu       sync.Mutex
+	model    string
+	rec      *Recording
+	served   []bool
+	requests int
+	update   bool
+	err      error
+}
+
+// Compile-time check that ReplayAIProvider implements port.AIProvider.
+var _ port.AIProvider = (*ReplayAIProvider)(nil)
+
+// NewReplayAIProvider creates a ReplayAIProvider serving rec. Its default
+// model is the one rec was recorded with.
+func NewReplayAIProvider(rec *Recording) *ReplayAIProvider {
+	return &ReplayAIProvider{model: rec.Model, rec: rec, served: make([]bool, len(rec.Exchanges))}
+}
+
+// SetUpdate sets whether the provider rewrites its recording instead of
+// checking it: in update mode the recorded responses are served in order,
+// whatever is asked, and each exchange's request is replaced by the one
+// received. Save the result of Recording to accept intended prompt changes.
+func (p *ReplayAIProvider) SetUpdate(update bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.update = update
+}
+
+// SupportsImageInput reports that recordings may contain images.
+func (p *ReplayAIProvider) SupportsImageInput() bool {
+	return true
+}
+
+// SendMessage answers the request from the recording.
+func (p *ReplayAIProvider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.serve(newRequest(ctx, p.GetModel(), 0, messages, tools))
+}
+
+// SendMessageWithOptions answers the request from the recording.
+func (p *ReplayAIProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.serve(newRequest(ctx, effectiveModel(opts.Model, p.GetModel()), opts.MaxTokens, messages, tools))
+}
+
+// SendMessageStreaming answers the request from the recording, passing the
+// recorded thinking and text to the callbacks as single chunks.
+func (p *ReplayAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	msg, toolCalls, err := p.serve(newRequest(ctx, p.GetModel(), 0, messages, tools))
+	if err != nil || msg == nil {
+		return msg, toolCalls, err
+	}
+	if thinkingCallback != nil {
+		for _, block := range msg.ThinkingBlocks {
+			if err := thinkingCallback(block.Thinking); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if textCallback != nil && msg.Content != "" {
+		if err := textCallback(msg.Content); err != nil {
+			return nil, nil, err
+		}
+	}
+	return msg, toolCalls, nil
+}
+
+// GenerateToolSchema returns an empty object schema.
+func (p *ReplayAIProvider) GenerateToolSchema() port.ToolInputSchemaParam {
+	return port.ToolInputSchemaParam{"type": "object", "properties": map[string]interface{}{}}
+}
+
+// HealthCheck always succeeds.
+func (p *ReplayAIProvider) HealthCheck(context.Context) error {
+	return nil
+}
+
+// SetModel sets the default model, which is part of every request without
+// its own model.
+func (p *ReplayAIProvider) SetModel(model string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.model = model
+	return nil
+}
+
+// GetModel returns the default model.
+func (p *ReplayAIProvider) GetModel() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.model
+}
+
+// Err returns the first mismatch, or nil if every request was answered.
+func (p *ReplayAIProvider) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Unserved returns how many recorded exchanges have not been asked for.
+func (p *ReplayAIProvider) Unserved() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, served := range p.served {
+		if !served {
+			n++
+		}
+	}
+	return n
+}
+
+// Recording returns the provider's recording, with the requests received
+// in update mode.
+func (p *ReplayAIProvider) Recording() *Recording {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &Recording{Model: p.rec.Model, Exchanges: append([]Exchange(nil), p.rec.Exchanges...)}
+}
+
+// serve answers req from the recording.
+func (p *ReplayAIProvider) serve(req Request) (*entity.Message, []port.ToolCallInfo, error) {
+	key, err := req.Key()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests++
+
+	next := -1
+	for i, served := range p.served {
+		if served {
+			continue
+		}
+		if next < 0 {
+			next = i
+		}
+		if p.rec.Exchanges[i].Key == key {
+			return p.respond(i)
+		}
+	}
+
+	if p.update && next >= 0 {
+		p.rec.Exchanges[next].Key = key
+		p.rec.Exchanges[next].Request = req
+		return p.respond(next)
+	}
+
+	if next < 0 {
+		err = fmt.Errorf("%w: request #%d is beyond the %d recorded exchanges",
+			ErrReplayMismatch, p.requests, len(p.rec.Exchanges))
+	} else {
+		err = fmt.Errorf("%w: request #%d differs from recorded exchange #%d%s",
+			ErrReplayMismatch, p.requests, next+1, diffRequests(p.rec.Exchanges[next].Request, req))
+	}
+	if p.err == nil {
+		p.err = err
+	}
+	return nil, nil, err
+}
+
+// respond marks exchange i served and returns its response. The caller
+// holds p.mu.
+func (p *ReplayAIProvider) respond(i int) (*entity.Message, []port.ToolCallInfo, error) {
+	p.served[i] = true
+	resp := p.rec.Exchanges[i].Response
+	if resp.Error != "" {
+		return nil, nil, errors.New(resp.Error)
+	}
+	var msg *entity.Message
+	if resp.Message != nil {
+		copied := *resp.Message
+		msg = &copied
+	}
+	return msg, resp.ToolCalls, nil
+}
+
+// diffRequests describes the first difference between a recorded request
+// and the actual one, with their JSON field names as the path.
+func diffRequests(recorded, actual Request) string {
+	var want, got interface{}
+	if err := roundTrip(recorded, &want); err != nil {
+		return ""
+	}
+	if err := roundTrip(actual, &got); err != nil {
+		return ""
+	}
+	diff, found := firstDifference("", want, got)
+	if !found {
+		return ""
+	}
+	return "\n" + diff
+}
+
+// roundTrip decodes the JSON encoding of v into out.
+func roundTrip(v interface{}, out *interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// firstDifference walks two decoded JSON values in key order and describes
+// the first place where they differ. Differing strings are compared line by
+// line, so a change deep in a system prompt is shown as the changed line.
+func firstDifference(path string, want, got interface{}) (string, bool) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if diff, found := firstDifference(joinPath(path, k), w[k], g[k]); found {
+				return diff, true
+			}
+		}
+		return "", false
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(w) && i < len(g); i++ {
+			if diff, found := firstDifference(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); found {
+				return diff, true
+			}
+		}
+		if len(w) != len(g) {
+			return fmt.Sprintf("at %s: recorded %d elements, got %d", path, len(w), len(g)), true
+		}
+		return "", false
+	case string:
+		g, ok := got.(string)
+		if !ok {
+			break
+		}
+		if w == g {
+			return "", false
+		}
+		return diffLines(path, w, g), true
+	}
+
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(wantJSON) == string(gotJSON) {
+		return "", false
+	}
+	return fmt.Sprintf("at %s:\n- %s\n+ %s", path, truncate(string(wantJSON)), truncate(string(gotJSON))), true
+}
+
+// diffLines shows the first differing line of two strings.
+func diffLines(path, want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	line := 0
+	for line < len(wantLines) && line < len(gotLines) && wantLines[line] == gotLines[line] {
+		line++
+	}
+	lineAt := func(lines []string) string {
+		if line < len(lines) {
+			return truncate(lines[line])
+		}
+		return "(end of text)"
+	}
+	return fmt.Sprintf("at %s, line %d:\n- %s\n+ %s", path, line+1, lineAt(wantLines), lineAt(gotLines))
+}
+
+// joinPath appends a field name to a JSON path.
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// truncate shortens s to maxDiffValueLen bytes.
+func truncate(s string) string {
+	if len(s) <= maxDiffValueLen {
+		return s
+	}
+	return s[:maxDiffValueLen] + "..."
+}
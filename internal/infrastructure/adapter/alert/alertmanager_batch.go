@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"encoding/json"
+	"errors"
+)
+
+// errNoBatchAlerts is returned for a batch without a single investigable alert.
+var errNoBatchAlerts = errors.New("no alerts with an alertname in batch")
+
+// alertmanagerStatus is an Alertmanager alert's status: a string, such as
+// "firing", in webhook payloads, and an object with a state, such as
+// {"state":"active"}, in the Alertmanager v2 API and `amtool -o json`.
+type alertmanagerStatus string
+
+// UnmarshalJSON accepts either form of the status.
+func (s *alertmanagerStatus) UnmarshalJSON(data []byte) error {
+	var status string
+	if err := json.Unmarshal(data, &status); err == nil {
+		*s = alertmanagerStatus(status)
+		return nil
+	}
+	var v2 struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(data, &v2); err != nil {
+		return err
+	}
+	*s = alertmanagerStatus(v2.State)
+	return nil
+}
+
+// ParseAlertmanagerBatch parses a batch of past alerts, for a backfill, as
+// alerts from the named source. The batch is either a JSON array of alerts,
+// as exported from the Alertmanager v2 API, or an Alertmanager or Grafana
+// alerting webhook payload. Unlike HandleWebhook, resolved alerts are kept, since past
+// incidents have usually resolved by the time they are backfilled.
+//
+// Every alert is marked historical, and alerts repeated in the batch (the same
+// alertname and start time) are kept once, in the order they first appear.
+// Returns an error if the payload is empty, invalid JSON, or has no alert with
+// an alertname.
+func ParseAlertmanagerBatch(source string, payload []byte) ([]*entity.Alert, error) {
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		return nil, errEmptyPayload
+	}
+
+	var amAlerts []alertmanagerAlert
+	if payload[0] == '[' {
+		if err := json.Unmarshal(payload, &amAlerts); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if amAlerts, err = parseWebhookAlerts(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool, len(amAlerts))
+	alerts := make([]*entity.Alert, 0, len(amAlerts))
+	for _, amAlert := range amAlerts {
+		alert := amAlert.toEntity(source)
+		if alert == nil || seen[alert.ID()] {
+			continue
+		}
+		seen[alert.ID()] = true
+		alerts = append(alerts, alert.WithHistorical(true))
+	}
+	if len(alerts) == 0 {
+		return nil, errNoBatchAlerts
+	}
+	return alerts, nil
+}
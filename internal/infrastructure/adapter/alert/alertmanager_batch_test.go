@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAlertmanagerBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantIDs []string
+	}{
+		{
+			name: "v2 API export keeps resolved alerts",
+			payload: `[
+				{"labels":{"alertname":"HighCPU","severity":"critical"},"startsAt":"2026-10-10T08:00:00Z",
+				 "status":{"state":"active"}},
+				{"labels":{"alertname":"DiskFull"},"startsAt":"2026-10-11T09:30:00Z",
+				 "status":{"state":"resolved"},"fingerprint":"abc123"}
+			]`,
+			wantIDs: []string{"HighCPU-2026-10-10T08:00:00Z", "DiskFull-2026-10-11T09:30:00Z"},
+		},
+		{
+			name: "webhook payload",
+			payload: `{"alerts":[
+				{"status":"resolved","labels":{"alertname":"HighCPU"},"startsAt":"2026-10-10T08:00:00Z"}
+			]}`,
+			wantIDs: []string{"HighCPU-2026-10-10T08:00:00Z"},
+		},
+		{
+			name: "repeated and nameless alerts are dropped",
+			payload: `[
+				{"labels":{"alertname":"HighCPU"},"startsAt":"2026-10-10T08:00:00Z"},
+				{"labels":{"job":"node"},"startsAt":"2026-10-10T08:05:00Z"},
+				{"labels":{"alertname":"HighCPU"},"startsAt":"2026-10-10T08:00:00Z"}
+			]`,
+			wantIDs: []string{"HighCPU-2026-10-10T08:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := ParseAlertmanagerBatch("backfill", []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseAlertmanagerBatch() error = %v", err)
+			}
+			if len(alerts) != len(tt.wantIDs) {
+				t.Fatalf("got %d alerts, want %d", len(alerts), len(tt.wantIDs))
+			}
+			for i, alert := range alerts {
+				if alert.ID() != tt.wantIDs[i] {
+					t.Errorf("alerts[%d].ID() = %q, want %q", i, alert.ID(), tt.wantIDs[i])
+				}
+				if alert.Source() != "backfill" || !alert.Historical() {
+					t.Errorf("alerts[%d] source = %q, historical = %v, want a historical backfill alert",
+						i, alert.Source(), alert.Historical())
+				}
+			}
+		})
+	}
+}
+
+func TestParseAlertmanagerBatch_Errors(t *testing.T) {
+	if _, err := ParseAlertmanagerBatch("backfill", []byte("  ")); !errors.Is(err, errEmptyPayload) {
+		t.Errorf("empty payload error = %v, want errEmptyPayload", err)
+	}
+	if _, err := ParseAlertmanagerBatch("backfill", []byte("[{")); err == nil {
+		t.Error("invalid JSON error = nil, want an error")
+	}
+	if _, err := ParseAlertmanagerBatch("backfill", []byte(`[{"labels":{}}]`)); !errors.Is(err, errNoBatchAlerts) {
+		t.Errorf("batch without alertnames error = %v, want errNoBatchAlerts", err)
+	}
+}
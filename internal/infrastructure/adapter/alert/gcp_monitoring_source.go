@@ -189,6 +189,14 @@ func (g *GCPMonitoringSource) HandleWebhook(_ context.Context, payload []byte) (
 
 	alert.WithLabels(labels)
 
+	// Fingerprint by policy, condition, and resource so that re-opened
+	// incidents match, whatever their observed values
+	identity := map[string]string{"policy_name": incident.PolicyName, "condition_name": incident.ConditionName}
+	for k, v := range incident.Resource.Labels {
+		identity["resource."+k] = v
+	}
+	alert.WithFingerprint(entity.AlertFingerprint(g.name, title, identity))
+
 	// Set timestamp from started_at
 	if incident.StartedAt > 0 {
 		alert.WithTimestamp(time.Unix(incident.StartedAt, 0))
@@ -244,6 +244,35 @@ func TestGCPMonitoringSource_HandleWebhook_FullData(t *testing.T) {
 	}
 }
 
+func TestGCPMonitoringSource_HandleWebhook_Fingerprint(t *testing.T) {
+	source := &GCPMonitoringSource{
+		name:        "test-gcp",
+		webhookPath: "/alerts/gcp",
+	}
+	incident := func(id, zone, observed string) []byte {
+		return []byte(`{"incident": {"incident_id": "` + id + `", "state": "open",
+			"policy_name": "High CPU Policy", "condition_name": "CPU > 80%",
+			"resource": {"type": "gce_instance", "labels": {"zone": "` + zone + `"}},
+			"observed_value": "` + observed + `"}}`)
+	}
+	fingerprint := func(payload []byte) string {
+		t.Helper()
+		alerts, err := source.HandleWebhook(context.Background(), payload)
+		if err != nil || len(alerts) != 1 {
+			t.Fatalf("HandleWebhook() = %d alerts, %v", len(alerts), err)
+		}
+		return alerts[0].Fingerprint()
+	}
+
+	first := fingerprint(incident("incident-1", "us-central1-a", "0.95"))
+	if got := fingerprint(incident("incident-2", "us-central1-a", "0.99")); got != first {
+		t.Errorf("re-opened incident fingerprint = %q, want %q", got, first)
+	}
+	if got := fingerprint(incident("incident-3", "europe-west1-b", "0.95")); got == first {
+		t.Error("incidents on different resources should have different fingerprints")
+	}
+}
+
 func TestGCPMonitoringSource_HandleWebhook_MinimalData(t *testing.T) {
 	source := &GCPMonitoringSource{
 		name:        "test-gcp",
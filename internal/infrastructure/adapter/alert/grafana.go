@@ -0,0 +1,161 @@
+package alert
+
+import (
+	"cmp"
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+)
+
+// errUnknownPayloadFormat is returned for a webhook payload in neither the
+// Alertmanager nor the Grafana alerting format.
+var errUnknownPayloadFormat = errors.New(`unrecognized alert payload: expected an Alertmanager or ` +
+	`Grafana alerting webhook, a JSON object with an "alerts" array`)
+
+// Annotations a Grafana alert's links are kept under, for the enrichers and
+// the investigation prompt.
+const (
+	dashboardURLAnnotation = "dashboard_url"
+	panelURLAnnotation     = "panel_url"
+)
+
+// grafanaAlertFields are alert fields only Grafana sends, which tell its
+// payloads apart from Alertmanager's.
+//
+//nolint:gochecknoglobals // read-only field list
+var grafanaAlertFields = []string{"dashboardURL", "panelURL", "silenceURL", "valueString", "values"}
+
+// grafanaPayload represents the JSON structure of Grafana alerting webhooks.
+// Like Alertmanager's it carries an "alerts" array, but adds a state for the
+// whole notification, and each alert's state may be given as "state".
+// See the webhook notifier in the Grafana alerting documentation.
+type grafanaPayload struct {
+	State  string         `json:"state"`
+	Alerts []grafanaAlert `json:"alerts"`
+}
+
+// grafanaAlert represents a single alert in the Grafana webhook payload.
+type grafanaAlert struct {
+	alertmanagerAlert
+	State        string `json:"state"`
+	DashboardURL string `json:"dashboardURL"`
+	PanelURL     string `json:"panelURL"`
+	ValueString  string `json:"valueString"`
+}
+
+// webhookEnvelope holds the parts of a webhook payload that tell the formats
+// apart.
+type webhookEnvelope struct {
+	State  *string                      `json:"state"`
+	OrgID  json.RawMessage              `json:"orgId"`
+	Alerts []map[string]json.RawMessage `json:"alerts"`
+}
+
+// isGrafana reports whether the payload came from Grafana: it has Grafana's
+// notification state or organization, or an alert has a Grafana-only field.
+func (e webhookEnvelope) isGrafana() bool {
+	if e.State != nil || e.OrgID != nil {
+		return true
+	}
+	for _, alert := range e.Alerts {
+		for _, field := range grafanaAlertFields {
+			if _, ok := alert[field]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseWebhookAlerts parses an Alertmanager or Grafana alerting webhook
+// payload, detecting which it is, into Alertmanager alerts. Grafana alerts are
+// normalized (see grafanaAlert.normalize), and those not yet firing, such as
+// pending ones, are left out. Returns errUnknownPayloadFormat for a JSON
+// object without an "alerts" array.
+func parseWebhookAlerts(payload []byte) ([]alertmanagerAlert, error) {
+	var envelope webhookEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Alerts == nil {
+		return nil, errUnknownPayloadFormat
+	}
+
+	if !envelope.isGrafana() {
+		var amPayload alertmanagerPayload
+		if err := json.Unmarshal(payload, &amPayload); err != nil {
+			return nil, err
+		}
+		return amPayload.Alerts, nil
+	}
+
+	var grafana grafanaPayload
+	if err := json.Unmarshal(payload, &grafana); err != nil {
+		return nil, err
+	}
+	amAlerts := make([]alertmanagerAlert, 0, len(grafana.Alerts))
+	for _, alert := range grafana.Alerts {
+		if amAlert, ok := alert.normalize(grafana.State); ok {
+			amAlerts = append(amAlerts, amAlert)
+		}
+	}
+	return amAlerts, nil
+}
+
+// normalize converts the Grafana alert to the Alertmanager alert it stands
+// for. Its status comes from its status, its state, or else the
+// notification's state, with "alerting" (and no-data and error states) as
+// firing and "ok" or "normal" as resolved. The evaluated values are added to
+// the description, and the dashboard and panel links are kept as annotations.
+// It returns false for an alert that is not firing yet.
+func (g grafanaAlert) normalize(notificationState string) (alertmanagerAlert, bool) {
+	amAlert := g.alertmanagerAlert
+	status := grafanaStatus(cmp.Or(string(amAlert.Status), g.State, notificationState))
+	if status == "" {
+		return alertmanagerAlert{}, false
+	}
+	amAlert.Status = alertmanagerStatus(status)
+
+	amAlert.Annotations = maps.Clone(amAlert.Annotations)
+	if amAlert.Annotations == nil {
+		amAlert.Annotations = make(map[string]string)
+	}
+	if values := strings.TrimSpace(g.ValueString); values != "" {
+		if description := amAlert.Annotations["description"]; description != "" {
+			amAlert.Annotations["description"] = description + "\n\nValues: " + values
+		} else {
+			amAlert.Annotations["description"] = "Values: " + values
+		}
+	}
+	if g.DashboardURL != "" {
+		amAlert.Annotations[dashboardURLAnnotation] = g.DashboardURL
+	}
+	if g.PanelURL != "" {
+		amAlert.Annotations[panelURLAnnotation] = g.PanelURL
+	}
+	return amAlert, true
+}
+
+// grafanaStatus maps a Grafana alert state to an Alertmanager status:
+// "firing", "resolved", or "" for states that have not fired, such as
+// "pending" and "paused".
+func grafanaStatus(state string) string {
+	switch strings.ToLower(state) {
+	case "firing", "alerting", "no_data", "nodata", "error":
+		return "firing"
+	case "resolved", "ok", "normal":
+		return "resolved"
+	default:
+		return ""
+	}
+}
+
+// NewGrafanaSource creates an alert source for Grafana alerting webhooks.
+// Every webhook source detects the payload format, so this is a
+// PrometheusSource registered under the grafana type for clearer
+// configuration.
+func NewGrafanaSource(config SourceConfig) (port.AlertSource, error) {
+	return NewPrometheusSource(config)
+}
@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// handleFixture posts the named testdata payload to a webhook source.
+func handleFixture(t *testing.T, name string) []*entity.Alert {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", name, err)
+	}
+	source, _ := NewGrafanaSource(SourceConfig{Name: "alerts", WebhookPath: "/alerts/any"})
+	alerts, err := source.(port.WebhookAlertSource).HandleWebhook(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("HandleWebhook(%s) error = %v", name, err)
+	}
+	return alerts
+}
+
+func TestHandleWebhook_GrafanaMatchesAlertmanager(t *testing.T) {
+	am := handleFixture(t, "alertmanager_webhook.json")
+	grafana := handleFixture(t, "grafana_webhook.json")
+
+	// Resolved alerts are skipped in both formats, and Grafana's pending ones too
+	if len(am) != 1 || len(grafana) != 1 {
+		t.Fatalf("alerts = %d from Alertmanager, %d from Grafana, want the one firing alert from each",
+			len(am), len(grafana))
+	}
+	want, got := am[0], grafana[0]
+	if got.ID() != want.ID() || got.Source() != want.Source() || got.Severity() != want.Severity() ||
+		got.Title() != want.Title() || got.Fingerprint() != want.Fingerprint() ||
+		got.GeneratorURL() != want.GeneratorURL() || !got.Timestamp().Equal(want.Timestamp()) ||
+		!maps.Equal(got.Labels(), want.Labels()) {
+		t.Errorf("Grafana alert = %+v, want the same alert as from Alertmanager, %+v", got, want)
+	}
+
+	if want := "CPU usage is 95%\n\nValues: [ var='A' labels={instance=web-1} value=95.2 ]"; got.Description() != want {
+		t.Errorf("Description() = %q, want %q", got.Description(), want)
+	}
+	annotations := got.Annotations()
+	if annotations[dashboardURLAnnotation] != "https://grafana.example.com/d/node-exporter" ||
+		annotations[panelURLAnnotation] != "https://grafana.example.com/d/node-exporter?viewPanel=3" {
+		t.Errorf("Annotations() = %v, want the dashboard and panel links", annotations)
+	}
+	if _, ok := am[0].Annotations()[dashboardURLAnnotation]; ok {
+		t.Errorf("Alertmanager alert has annotations %v, want no dashboard link", am[0].Annotations())
+	}
+}
+
+func TestGrafanaStatus(t *testing.T) {
+	for state, want := range map[string]string{
+		"alerting": "firing", "firing": "firing", "NoData": "firing", "error": "firing",
+		"ok": "resolved", "Normal": "resolved", "resolved": "resolved",
+		"pending": "", "paused": "",
+	} {
+		if got := grafanaStatus(state); got != want {
+			t.Errorf("grafanaStatus(%q) = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestHandleWebhook_GrafanaNotificationState(t *testing.T) {
+	// Legacy payloads give the state only for the whole notification
+	payload := []byte(`{"state":"alerting","alerts":[{"labels":{"alertname":"HighCPU"},
+		"startsAt":"2026-10-12T08:00:00Z","valueString":"[ var='A' value=97 ]"}]}`)
+	source, _ := NewPrometheusSource(SourceConfig{Name: "grafana", WebhookPath: "/alerts/grafana"})
+	alerts, err := source.(port.WebhookAlertSource).HandleWebhook(context.Background(), payload)
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("HandleWebhook() = %v, %v, want one firing alert", alerts, err)
+	}
+	if alerts[0].Description() != "Values: [ var='A' value=97 ]" {
+		t.Errorf("Description() = %q, want the values", alerts[0].Description())
+	}
+}
+
+func TestHandleWebhook_UnknownFormat(t *testing.T) {
+	source, _ := NewPrometheusSource(SourceConfig{Name: "prometheus", WebhookPath: "/alerts/prometheus"})
+	for _, payload := range []string{`{}`, `{"ruleName":"HighCPU","evalMatches":[]}`, `{"alerts":null}`} {
+		_, err := source.(port.WebhookAlertSource).HandleWebhook(context.Background(), []byte(payload))
+		if !errors.Is(err, errUnknownPayloadFormat) {
+			t.Errorf("HandleWebhook(%s) error = %v, want errUnknownPayloadFormat", payload, err)
+		}
+	}
+}
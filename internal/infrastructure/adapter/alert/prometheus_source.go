@@ -34,8 +34,9 @@ type SourceConfig struct {
 	Extra map[string]string
 }
 
-// PrometheusSource implements port.WebhookAlertSource for Prometheus Alertmanager.
-// It parses Alertmanager webhook payloads and converts them to domain Alert entities.
+// PrometheusSource implements port.WebhookAlertSource for Prometheus Alertmanager
+// and Grafana alerting. It parses their webhook payloads and converts them to
+// domain Alert entities.
 type PrometheusSource struct {
 	name        string
 	webhookPath string
@@ -50,11 +51,13 @@ type alertmanagerPayload struct {
 
 // alertmanagerAlert represents a single alert in the Alertmanager webhook payload.
 type alertmanagerAlert struct {
-	Status      string            `json:"status"`
-	Labels      map[string]string `json:"labels"`
-	Annotations map[string]string `json:"annotations"`
-	StartsAt    time.Time         `json:"startsAt"`
-	EndsAt      time.Time         `json:"endsAt"`
+	Status       alertmanagerStatus `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	Fingerprint  string             `json:"fingerprint"`
+	GeneratorURL string             `json:"generatorURL"`
 }
 
 // NewPrometheusSource creates a new Prometheus alert source from the given configuration.
@@ -100,67 +103,88 @@ func (p *PrometheusSource) WebhookPath() string {
 	return p.webhookPath
 }
 
-// HandleWebhook processes an Alertmanager webhook payload and returns parsed alerts.
-// Resolved alerts are skipped. Returns an error if the payload is empty or invalid JSON.
+// HandleWebhook processes an Alertmanager or Grafana alerting webhook payload,
+// telling them apart by their fields, and returns parsed alerts. Resolved
+// alerts are skipped. Returns an error if the payload is empty, invalid JSON,
+// or in neither format.
 func (p *PrometheusSource) HandleWebhook(_ context.Context, payload []byte) ([]*entity.Alert, error) {
 	if len(payload) == 0 {
 		return nil, errEmptyPayload
 	}
 
-	var amPayload alertmanagerPayload
-	if err := json.Unmarshal(payload, &amPayload); err != nil {
+	amAlerts, err := parseWebhookAlerts(payload)
+	if err != nil {
 		return nil, err
 	}
 
 	var alerts []*entity.Alert
-	for _, amAlert := range amPayload.Alerts {
+	for _, amAlert := range amAlerts {
 		// Skip resolved alerts
 		if amAlert.Status == "resolved" {
 			continue
 		}
-
-		alertName, ok := amAlert.Labels["alertname"]
-		if !ok || alertName == "" {
-			continue
+		if alert := amAlert.toEntity(p.name); alert != nil {
+			alerts = append(alerts, alert)
 		}
+	}
 
-		// Get severity, default to warning
-		severity := amAlert.Labels["severity"]
-		if severity == "" {
-			severity = entity.SeverityWarning
-		}
+	return alerts, nil
+}
 
-		// Get title from summary annotation or fall back to alertname
-		title := amAlert.Annotations["summary"]
-		if title == "" {
-			title = alertName
-		}
+// toEntity converts the Alertmanager alert to a domain alert from the named
+// source. Returns nil for an alert without an alertname or that is otherwise
+// invalid.
+func (amAlert alertmanagerAlert) toEntity(source string) *entity.Alert {
+	alertName, ok := amAlert.Labels["alertname"]
+	if !ok || alertName == "" {
+		return nil
+	}
 
-		// Create unique ID from alertname and timestamp
-		alertID := alertName + "-" + amAlert.StartsAt.Format(time.RFC3339)
+	// Get severity, default to warning
+	severity := amAlert.Labels["severity"]
+	if severity == "" {
+		severity = entity.SeverityWarning
+	}
 
-		alert, err := entity.NewAlert(alertID, p.name, severity, title)
-		if err != nil {
-			continue
-		}
+	// Get title from summary annotation or fall back to alertname
+	title := amAlert.Annotations["summary"]
+	if title == "" {
+		title = alertName
+	}
 
-		// Set description from annotations
-		if desc, ok := amAlert.Annotations["description"]; ok {
-			alert.WithDescription(desc)
-		}
+	// Create unique ID from alertname and timestamp
+	alertID := alertName + "-" + amAlert.StartsAt.Format(time.RFC3339)
+
+	alert, err := entity.NewAlert(alertID, source, severity, title)
+	if err != nil {
+		return nil
+	}
 
-		// Set labels
-		alert.WithLabels(amAlert.Labels)
+	// Set description from annotations
+	if desc, ok := amAlert.Annotations["description"]; ok {
+		alert.WithDescription(desc)
+	}
 
-		// Set timestamp
-		alert.WithTimestamp(amAlert.StartsAt)
+	// Set labels and annotations
+	alert.WithLabels(amAlert.Labels)
+	alert.WithAnnotations(amAlert.Annotations)
 
-		// Set raw payload
-		alertPayload, _ := json.Marshal(amAlert)
-		alert.WithRawPayload(alertPayload)
+	// Set timestamp
+	alert.WithTimestamp(amAlert.StartsAt)
 
-		alerts = append(alerts, alert)
+	// Keep Alertmanager's fingerprint so suppressions match its UI
+	if amAlert.Fingerprint != "" {
+		alert.WithFingerprint(amAlert.Fingerprint)
 	}
 
-	return alerts, nil
+	// Keep the link back to the Prometheus that fired the alert
+	if amAlert.GeneratorURL != "" {
+		alert.WithGeneratorURL(amAlert.GeneratorURL)
+	}
+
+	// Set raw payload
+	alertPayload, _ := json.Marshal(amAlert)
+	alert.WithRawPayload(alertPayload)
+
+	return alert
 }
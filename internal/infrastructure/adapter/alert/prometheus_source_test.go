@@ -205,10 +205,12 @@ func TestPrometheusSource_HandleWebhook(t *testing.T) {
 					},
 					"annotations": {
 						"summary": "High CPU usage detected",
-						"description": "CPU usage is above 90% for more than 5 minutes"
+						"description": "CPU usage is above 90% for more than 5 minutes",
+						"runbook_url": "https://runbooks.example.com/HighCPU"
 					},
 					"startsAt": "2024-01-15T10:30:00Z",
-					"endsAt": "0001-01-01T00:00:00Z"
+					"endsAt": "0001-01-01T00:00:00Z",
+					"generatorURL": "http://prometheus.example.com:9090/graph?g0.expr=cpu_usage+%3E+90"
 				}
 			]
 		}`)
@@ -239,6 +241,45 @@ func TestPrometheusSource_HandleWebhook(t *testing.T) {
 		if alert.Labels()["instance"] != "web-01" {
 			t.Errorf("Alert Labels()[instance] = %v, want web-01", alert.Labels()["instance"])
 		}
+		if alert.Annotations()["runbook_url"] != "https://runbooks.example.com/HighCPU" {
+			t.Errorf("Alert Annotations()[runbook_url] = %v", alert.Annotations()["runbook_url"])
+		}
+		if want := "http://prometheus.example.com:9090/graph?g0.expr=cpu_usage+%3E+90"; alert.GeneratorURL() != want {
+			t.Errorf("Alert GeneratorURL() = %v, want %v", alert.GeneratorURL(), want)
+		}
+	})
+
+	t.Run("should keep the Alertmanager fingerprint", func(t *testing.T) {
+		payload := []byte(`{
+			"alerts": [
+				{
+					"status": "firing",
+					"labels": {"alertname": "HighCPU", "instance": "web-01"},
+					"startsAt": "2024-01-15T10:30:00Z",
+					"fingerprint": "a1b2c3d4e5f60718"
+				},
+				{
+					"status": "firing",
+					"labels": {"alertname": "HighCPU", "instance": "web-01"},
+					"startsAt": "2024-01-15T11:30:00Z"
+				}
+			]
+		}`)
+
+		alerts, err := webhookSource.HandleWebhook(context.Background(), payload)
+		if err != nil {
+			t.Fatalf("HandleWebhook() error = %v", err)
+		}
+		if len(alerts) != 2 {
+			t.Fatalf("HandleWebhook() returned %d alerts, want 2", len(alerts))
+		}
+		if got := alerts[0].Fingerprint(); got != "a1b2c3d4e5f60718" {
+			t.Errorf("Alert Fingerprint() = %v, want a1b2c3d4e5f60718", got)
+		}
+		want := entity.AlertFingerprint("test-prometheus", "HighCPU", alerts[1].Labels())
+		if got := alerts[1].Fingerprint(); got != want {
+			t.Errorf("Alert Fingerprint() without one in the payload = %v, want %v", got, want)
+		}
 	})
 
 	t.Run("should skip resolved alerts", func(t *testing.T) {
@@ -64,8 +64,9 @@ func (r *SourceRegistry) SupportedTypes() []string {
 }
 
 // RegisterBuiltinFactories registers all built-in alert source factories.
-// This includes prometheus and gcp_monitoring sources.
+// This includes prometheus, grafana, and gcp_monitoring sources.
 func (r *SourceRegistry) RegisterBuiltinFactories() {
 	r.RegisterFactory("prometheus", NewPrometheusSource)
+	r.RegisterFactory("grafana", NewGrafanaSource)
 	r.RegisterFactory("gcp_monitoring", NewGCPMonitoringSource)
 }
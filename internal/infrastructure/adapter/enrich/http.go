@@ -0,0 +1,88 @@
+package enrich
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/usecase"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds how long an HTTP lookup may delay an investigation.
+const DefaultHTTPTimeout = 5 * time.Second
+
+// maxResponseBytes limits how much of a lookup response is read (1MB).
+const maxResponseBytes = 1 << 20
+
+// AlertPayload is the JSON body posted to the lookup service.
+type AlertPayload struct {
+	ID          string            `json:"id"`
+	Source      string            `json:"source"`
+	Severity    string            `json:"severity"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// HTTPEnricher implements usecase.AlertEnricher by posting each alert as JSON
+// to a lookup service, such as a service catalog or deploy tracker, which
+// answers with a JSON object of string values to add as annotations:
+//
+//	{"team": "payments", "last_deploy": "v1.4.2 at 09:12 UTC"}
+//
+// Annotations the alert already has are kept.
+type HTTPEnricher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPEnricher creates an enricher calling url. A timeout of zero or less
+// uses DefaultHTTPTimeout.
+func NewHTTPEnricher(url string, timeout time.Duration) *HTTPEnricher {
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &HTTPEnricher{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Enrich implements usecase.AlertEnricher. A request that fails, a non-2xx
+// status, or a response that is not a JSON object of strings is an error.
+func (e *HTTPEnricher) Enrich(ctx context.Context, alert *usecase.AlertView) error {
+	body, err := json.Marshal(AlertPayload{
+		ID:          alert.ID(),
+		Source:      alert.Source(),
+		Severity:    alert.Severity(),
+		Title:       alert.Title(),
+		Description: alert.Description(),
+		Labels:      alert.Labels(),
+		Annotations: alert.Annotations(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create enrichment request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("enrichment request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("enrichment request failed: %s", resp.Status)
+	}
+
+	var annotations map[string]string
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&annotations); err != nil {
+		return fmt.Errorf("invalid enrichment response: %w", err)
+	}
+	alert.AddAnnotations(annotations)
+	return nil
+}
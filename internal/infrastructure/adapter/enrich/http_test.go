@@ -0,0 +1,72 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPEnricher_Enrich(t *testing.T) {
+	var got AlertPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"team": "platform", "last_deploy": "v1.4.2"}`))
+	}))
+	defer server.Close()
+
+	alert := testAlert(t, map[string]string{"alertname": "DiskFull"})
+	alert.AddAnnotations(map[string]string{"team": "storage"})
+	if err := NewHTTPEnricher(server.URL, 0).Enrich(context.Background(), alert); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+
+	if got.ID != "DiskFull-1" || got.Labels["alertname"] != "DiskFull" || got.Annotations["team"] != "storage" {
+		t.Errorf("posted alert = %+v, want the alert with its labels and annotations", got)
+	}
+	if want := map[string]string{"team": "storage", "last_deploy": "v1.4.2"}; !maps.Equal(alert.Annotations(), want) {
+		t.Errorf("annotations = %v, want %v", alert.Annotations(), want)
+	}
+}
+
+func TestHTTPEnricher_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"error status", func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}},
+		{"non-string values", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"tier": 1}`))
+		}},
+		{"not JSON", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("team=storage"))
+		}},
+		{"timeout", func(_ http.ResponseWriter, _ *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			alert := testAlert(t, nil)
+			if err := NewHTTPEnricher(server.URL, 50*time.Millisecond).Enrich(context.Background(), alert); err == nil {
+				t.Error("Enrich() error = nil, want an error")
+			}
+			if len(alert.Annotations()) != 0 {
+				t.Errorf("annotations = %v, want none after a failure", alert.Annotations())
+			}
+		})
+	}
+}
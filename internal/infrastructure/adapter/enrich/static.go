@@ -0,0 +1,75 @@
+// Package enrich adds context to alerts before they are investigated, from a
+// static YAML mapping or an HTTP lookup service.
+package enrich
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"context"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StaticRule adds annotations to the alerts whose labels match all of Match.
+// A rule without Match applies to every alert.
+type StaticRule struct {
+	Match       map[string]string `yaml:"match"`       // Label values, e.g. alertname: DiskFull
+	Annotations map[string]string `yaml:"annotations"` // Added to matching alerts, e.g. team: storage
+}
+
+// matches reports whether the alert has every label value of the rule.
+func (r StaticRule) matches(alert *usecase.AlertView) bool {
+	for label, value := range r.Match {
+		if got, ok := alert.Labels()[label]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// StaticEnricher implements usecase.AlertEnricher with a fixed list of rules,
+// such as an ownership and runbook map. Rules apply in order; the first rule
+// setting an annotation wins, and annotations the alert already has are kept.
+type StaticEnricher struct {
+	rules []StaticRule
+}
+
+// NewStaticEnricher creates an enricher applying rules in order.
+func NewStaticEnricher(rules []StaticRule) *StaticEnricher {
+	return &StaticEnricher{rules: rules}
+}
+
+// LoadStaticEnricher reads the rules of a StaticEnricher from a YAML file:
+//
+//	rules:
+//	  - match: {alertname: DiskFull, namespace: payments}
+//	    annotations: {team: payments, tier: "1", runbook_url: https://wiki.example.com/disk}
+func LoadStaticEnricher(path string) (*StaticEnricher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enrichment rules: %w", err)
+	}
+	var file struct {
+		Rules []StaticRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse enrichment rules %s: %w", path, err)
+	}
+	for i, rule := range file.Rules {
+		if len(rule.Annotations) == 0 {
+			return nil, fmt.Errorf("enrichment rules %s: rule %d has no annotations", path, i+1)
+		}
+	}
+	return NewStaticEnricher(file.Rules), nil
+}
+
+// Enrich implements usecase.AlertEnricher.
+func (e *StaticEnricher) Enrich(_ context.Context, alert *usecase.AlertView) error {
+	for _, rule := range e.rules {
+		if rule.matches(alert) {
+			alert.AddAnnotations(rule.Annotations)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package enrich
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testAlert returns a view of a critical alert with the given labels.
+func testAlert(t *testing.T, labels map[string]string) *usecase.AlertView {
+	t.Helper()
+	alert, err := entity.NewAlert("DiskFull-1", "prometheus", entity.SeverityCritical, "Disk Full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	return usecase.NewAlertViewFromEntity(alert.WithLabels(labels))
+}
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "enrichment.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	return path
+}
+
+func TestStaticEnricher_Enrich(t *testing.T) {
+	enricher, err := LoadStaticEnricher(writeRules(t, `
+rules:
+  - match: {alertname: DiskFull, namespace: payments}
+    annotations: {team: payments, tier: "1"}
+  - match: {alertname: DiskFull}
+    annotations: {team: storage, runbook_url: https://wiki.example.com/disk}
+  - annotations: {oncall: https://oncall.example.com}
+`))
+	if err != nil {
+		t.Fatalf("LoadStaticEnricher() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]string
+	}{
+		{
+			name:   "first matching rule wins",
+			labels: map[string]string{"alertname": "DiskFull", "namespace": "payments"},
+			want: map[string]string{
+				"team": "payments", "tier": "1", "runbook_url": "https://wiki.example.com/disk",
+				"oncall": "https://oncall.example.com",
+			},
+		},
+		{
+			name:   "all match labels required",
+			labels: map[string]string{"alertname": "DiskFull", "namespace": "search"},
+			want: map[string]string{
+				"team": "storage", "runbook_url": "https://wiki.example.com/disk",
+				"oncall": "https://oncall.example.com",
+			},
+		},
+		{
+			name:   "rule without match applies to all",
+			labels: map[string]string{"alertname": "HighCPU"},
+			want:   map[string]string{"oncall": "https://oncall.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := testAlert(t, tt.labels)
+			if err := enricher.Enrich(context.Background(), alert); err != nil {
+				t.Fatalf("Enrich() error = %v", err)
+			}
+			if got := alert.Annotations(); !maps.Equal(got, tt.want) {
+				t.Errorf("annotations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticEnricher_KeepsExistingAnnotations(t *testing.T) {
+	enricher := NewStaticEnricher([]StaticRule{{Annotations: map[string]string{"team": "storage", "tier": "2"}}})
+	alert := testAlert(t, nil)
+	alert.AddAnnotations(map[string]string{"team": "payments"})
+
+	if err := enricher.Enrich(context.Background(), alert); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if want := map[string]string{"team": "payments", "tier": "2"}; !maps.Equal(alert.Annotations(), want) {
+		t.Errorf("annotations = %v, want %v", alert.Annotations(), want)
+	}
+}
+
+func TestLoadStaticEnricher_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "missing.yaml"), "failed to read"},
+		{"invalid YAML", writeRules(t, "rules: [\n"), "failed to parse"},
+		{"rule without annotations", writeRules(t, "rules:\n  - match: {alertname: DiskFull}\n"), "rule 1 has no annotations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadStaticEnricher(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadStaticEnricher() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
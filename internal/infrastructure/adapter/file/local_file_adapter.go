@@ -19,6 +19,7 @@ package file
 
 import (
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -162,18 +163,16 @@ func (fm *LocalFileManager) validatePathBounds(path string) error {
 		fullPath = filepath.Join(fm.baseDir, cleaned)
 	}
 
-	// Now check if the full path is within the base directory
-	relPath, err := filepath.Rel(fm.baseDir, fullPath)
-	if err != nil {
+	// Now check if the full path is within the base directory, comparing path
+	// elements rather than string prefixes so either separator works on Windows
+	if _, err := filepath.Rel(fm.baseDir, fullPath); err != nil {
 		return &PathValidationError{
 			Path:   path,
 			Reason: "failed to get relative path",
 			Cause:  err,
 		}
 	}
-
-	// If the relative path starts with "..", it means it's outside the base directory
-	if strings.HasPrefix(relPath, "..") || relPath == "../" {
+	if !safety.IsWithinDir(fm.baseDir, fullPath) {
 		return &PathValidationError{
 			Path:   path,
 			Reason: "path traversal attempt detected",
@@ -196,11 +195,7 @@ func (fm *LocalFileManager) validatePathBounds(path string) error {
 
 // isPathWithinBounds performs the final boundary check including symlink resolution.
 func (fm *LocalFileManager) isPathWithinBounds(fullPath string) bool {
-	if strings.HasPrefix(
-		filepath.Clean(fullPath)+string(filepath.Separator),
-		filepath.Clean(fm.baseDir)+string(filepath.Separator),
-	) ||
-		strings.HasPrefix(filepath.Clean(fullPath), filepath.Clean(fm.baseDir)) {
+	if safety.IsWithinDir(fm.baseDir, fullPath) {
 		return true
 	}
 
@@ -211,11 +206,7 @@ func (fm *LocalFileManager) isPathWithinBounds(fullPath string) bool {
 	}
 
 	// Check the resolved path as well
-	return strings.HasPrefix(
-		filepath.Clean(evaluatedPath)+string(filepath.Separator),
-		filepath.Clean(fm.baseDir)+string(filepath.Separator),
-	) ||
-		strings.HasPrefix(filepath.Clean(evaluatedPath), filepath.Clean(fm.baseDir))
+	return safety.IsWithinDir(fm.baseDir, evaluatedPath)
 }
 
 // ensureParentDirectories creates parent directories if they don't exist.
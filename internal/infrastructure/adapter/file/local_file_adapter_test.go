@@ -63,6 +63,30 @@ func TestLocalFileManager_ReadFile(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "path traversal attempt detected")
 	})
+
+	t.Run("sibling directory sharing the base prefix is outside", func(t *testing.T) {
+		parent := t.TempDir()
+		baseDir := filepath.Join(parent, "work")
+		siblingDir := filepath.Join(parent, "workshop")
+		require.NoError(t, os.Mkdir(baseDir, 0o755))
+		require.NoError(t, os.Mkdir(siblingDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(siblingDir, "secret.txt"), []byte("secret"), 0o644))
+		fm := file.NewLocalFileManager(baseDir)
+
+		_, err := fm.ReadFile(filepath.Join(siblingDir, "secret.txt"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "path traversal attempt detected")
+	})
+
+	t.Run("file name starting with dots is inside", func(t *testing.T) {
+		tempDir := t.TempDir()
+		fm := file.NewLocalFileManager(tempDir)
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "..notes"), []byte("notes"), 0o644))
+
+		content, err := fm.ReadFile(filepath.Join(tempDir, "..notes"))
+		require.NoError(t, err)
+		assert.Equal(t, "notes", content)
+	})
 }
 
 func TestLocalFileManager_WriteFile(t *testing.T) {
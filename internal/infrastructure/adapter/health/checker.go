@@ -0,0 +1,143 @@
+// Package health provides the readiness checks and liveness watchdog behind
+// the webhook server's /readyz and /healthz endpoints.
+package health
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Names of the built-in readiness checks, as used in Config.HealthOptionalChecks.
+const (
+	CheckAIProvider         = "ai_provider"
+	CheckInvestigationStore = "investigation_store"
+	CheckWorkspace          = "workspace"
+)
+
+// Check and report statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// checkTimeout bounds each check so a hanging dependency cannot stall readiness.
+const checkTimeout = 5 * time.Second
+
+// CheckNames returns the names of the built-in readiness checks.
+func CheckNames() []string {
+	return []string{CheckAIProvider, CheckInvestigationStore, CheckWorkspace}
+}
+
+// Check is a named readiness check.
+type Check struct {
+	Name string
+	// Optional checks are reported but do not fail readiness.
+	Optional bool
+	Run      func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Optional bool   `json:"optional,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks. Status is StatusFail if any required
+// check failed. Degraded is set by the server while investigations are held
+// for an AI provider outage; it does not affect Status.
+type Report struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Degraded  *port.DegradedStatus   `json:"degraded,omitempty"`
+}
+
+// Ready reports whether every required check passed.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs readiness checks, caching the report for a TTL so frequent
+// probes do not hammer the AI provider. It is safe for concurrent use.
+type Checker struct {
+	checks   []Check
+	cacheTTL time.Duration
+	now      func() time.Time
+	mu       sync.Mutex // Held while checks run, so concurrent probes share one run
+	cached   *Report
+}
+
+// NewChecker creates a Checker for checks whose report is reused for cacheTTL.
+func NewChecker(cacheTTL time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:   checks,
+		cacheTTL: cacheTTL,
+		now:      time.Now,
+	}
+}
+
+// Check returns the cached report if it is younger than the cache TTL,
+// otherwise runs every check and caches the new report.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && c.now().Sub(c.cached.CheckedAt) < c.cacheTTL {
+		return *c.cached
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		Checks:    make(map[string]CheckResult, len(c.checks)),
+		CheckedAt: c.now(),
+	}
+	for _, check := range c.checks {
+		result := CheckResult{Status: StatusOK, Optional: check.Optional}
+		if err := runCheck(ctx, check); err != nil {
+			result.Status = StatusFail
+			result.Error = err.Error()
+			if !check.Optional {
+				report.Status = StatusFail
+			}
+		}
+		report.Checks[check.Name] = result
+	}
+	c.cached = &report
+	return report
+}
+
+// runCheck runs check with checkTimeout, converting a panic into an error.
+func runCheck(ctx context.Context, check Check) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+	return check.Run(ctx)
+}
+
+// DirWritable returns a check that creates and removes a file in dir.
+func DirWritable(dir string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return fmt.Errorf("workspace is not writable: %w", err)
+		}
+		name := f.Name()
+		if err := f.Close(); err != nil {
+			_ = os.Remove(name)
+			return err
+		}
+		return os.Remove(name)
+	}
+}
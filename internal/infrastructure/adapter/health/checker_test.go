@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func passing(context.Context) error { return nil }
+
+func TestChecker_ReportsEveryCheck(t *testing.T) {
+	checker := NewChecker(0,
+		Check{Name: CheckAIProvider, Run: func(context.Context) error { return errors.New("provider unreachable") }},
+		Check{Name: CheckInvestigationStore, Run: passing},
+	)
+
+	report := checker.Check(context.Background())
+
+	if report.Ready() {
+		t.Error("a failing required check should fail readiness")
+	}
+	provider := report.Checks[CheckAIProvider]
+	if provider.Status != StatusFail || provider.Error != "provider unreachable" {
+		t.Errorf("%s = %+v, want a failure with the provider error", CheckAIProvider, provider)
+	}
+	if got := report.Checks[CheckInvestigationStore].Status; got != StatusOK {
+		t.Errorf("%s status = %q, want %q", CheckInvestigationStore, got, StatusOK)
+	}
+}
+
+func TestChecker_OptionalFailuresDoNotFailReadiness(t *testing.T) {
+	checker := NewChecker(0,
+		Check{Name: CheckAIProvider, Optional: true, Run: func(context.Context) error { return errors.New("down") }},
+		Check{Name: CheckWorkspace, Run: passing},
+	)
+
+	report := checker.Check(context.Background())
+
+	if !report.Ready() {
+		t.Errorf("an optional failure should not fail readiness, got %+v", report)
+	}
+	if got := report.Checks[CheckAIProvider]; got.Status != StatusFail || !got.Optional {
+		t.Errorf("%s = %+v, want an optional failure", CheckAIProvider, got)
+	}
+}
+
+func TestChecker_CachesReports(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	calls := 0
+	checker := NewChecker(5*time.Second, Check{Name: CheckAIProvider, Run: func(context.Context) error {
+		calls++
+		return nil
+	}})
+	checker.now = clock.Now
+
+	checker.Check(context.Background())
+	clock.now = clock.now.Add(4 * time.Second)
+	checker.Check(context.Background())
+	if calls != 1 {
+		t.Errorf("checks ran %d times within the cache TTL, want 1", calls)
+	}
+
+	clock.now = clock.now.Add(2 * time.Second)
+	checker.Check(context.Background())
+	if calls != 2 {
+		t.Errorf("checks ran %d times after the cache TTL, want 2", calls)
+	}
+}
+
+func TestChecker_RecoversPanickingChecks(t *testing.T) {
+	checker := NewChecker(0, Check{Name: CheckAIProvider, Run: func(context.Context) error { panic("boom") }})
+
+	report := checker.Check(context.Background())
+
+	if report.Ready() || report.Checks[CheckAIProvider].Error != "check panicked: boom" {
+		t.Errorf("a panicking check should fail, got %+v", report)
+	}
+}
+
+func TestDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := DirWritable(dir)(context.Background()); err != nil {
+		t.Errorf("DirWritable(%s) error = %v", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("DirWritable should clean up its probe file, found %d entries", len(entries))
+	}
+
+	if err := DirWritable(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("DirWritable should fail for a missing directory")
+	}
+}
+
+func TestWatchdog_GoesStaleWithoutHeartbeats(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	watchdog := &Watchdog{staleAfter: 30 * time.Second, now: clock.Now}
+	watchdog.Beat()
+
+	clock.now = clock.now.Add(29 * time.Second)
+	if !watchdog.Alive() {
+		t.Error("watchdog should be alive within staleAfter")
+	}
+
+	clock.now = clock.now.Add(2 * time.Second)
+	if watchdog.Alive() {
+		t.Error("watchdog should be stale after staleAfter without a heartbeat")
+	}
+
+	watchdog.Beat()
+	if !watchdog.Alive() {
+		t.Error("a heartbeat should make the watchdog alive again")
+	}
+}
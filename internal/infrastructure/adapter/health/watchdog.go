@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStaleAfter is how long without a heartbeat before the watchdog
+// reports the process as not alive.
+const DefaultStaleAfter = 30 * time.Second
+
+// Watchdog records heartbeats from the daemon's work loop. Liveness fails once
+// no heartbeat has arrived for staleAfter. It is safe for concurrent use.
+type Watchdog struct {
+	staleAfter time.Duration
+	now        func() time.Time
+	last       atomic.Int64 // Unix nanoseconds of the last heartbeat
+}
+
+// NewWatchdog creates a Watchdog that goes stale after staleAfter without a
+// heartbeat. Creating it counts as the first heartbeat.
+func NewWatchdog(staleAfter time.Duration) *Watchdog {
+	w := &Watchdog{staleAfter: staleAfter, now: time.Now}
+	w.Beat()
+	return w
+}
+
+// Beat records a heartbeat.
+func (w *Watchdog) Beat() {
+	w.last.Store(w.now().UnixNano())
+}
+
+// SinceLastBeat returns the time since the last heartbeat.
+func (w *Watchdog) SinceLastBeat() time.Duration {
+	return w.now().Sub(time.Unix(0, w.last.Load()))
+}
+
+// Alive reports whether a heartbeat arrived within staleAfter.
+func (w *Watchdog) Alive() bool {
+	return w.SinceLastBeat() < w.staleAfter
+}
+
+// Run calls beat every interval until ctx is done. beat should exercise the
+// work loop being guarded and call Beat when it succeeds.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration, beat func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			beat()
+		}
+	}
+}
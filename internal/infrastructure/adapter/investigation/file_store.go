@@ -2,11 +2,15 @@ package investigation
 
 import (
 	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -14,18 +18,59 @@ import (
 
 // investigationJSON is the JSON representation of an investigation for file storage.
 type investigationJSON struct {
-	ID             string    `json:"id"`
-	AlertID        string    `json:"alert_id"`
-	SessionID      string    `json:"session_id"`
-	Status         string    `json:"status"`
-	StartedAt      time.Time `json:"started_at"`
-	CompletedAt    time.Time `json:"completed_at,omitempty"`
-	Findings       []string  `json:"findings,omitempty"`
-	ActionsTaken   int       `json:"actions_taken,omitempty"`
-	DurationNanos  int64     `json:"duration_nanos,omitempty"`
-	Confidence     float64   `json:"confidence,omitempty"`
-	Escalated      bool      `json:"escalated,omitempty"`
-	EscalateReason string    `json:"escalate_reason,omitempty"`
+	SchemaVersion  int                         `json:"schema_version"`
+	ID             string                      `json:"id"`
+	AlertID        string                      `json:"alert_id"`
+	SessionID      string                      `json:"session_id"`
+	Status         string                      `json:"status"`
+	StartedAt      time.Time                   `json:"started_at"`
+	CompletedAt    time.Time                   `json:"completed_at,omitempty"`
+	Findings       []findingJSON               `json:"findings,omitempty"`
+	ActionsTaken   int                         `json:"actions_taken,omitempty"`
+	DurationNanos  int64                       `json:"duration_nanos,omitempty"`
+	Confidence     float64                     `json:"confidence,omitempty"`
+	Escalated      bool                        `json:"escalated,omitempty"`
+	EscalateReason string                      `json:"escalate_reason,omitempty"`
+	Error          string                      `json:"error,omitempty"`
+	ErrorKind      string                      `json:"error_kind,omitempty"`
+	Alert          *alertJSON                  `json:"alert,omitempty"`
+	RootCause      string                      `json:"root_cause,omitempty"`
+	Actions        []entity.RecommendedAction  `json:"recommended_actions,omitempty"`
+	Remediations   []entity.RemediationAttempt `json:"remediations,omitempty"`
+	Cost           float64                     `json:"cost,omitempty"`
+	InputTokens    int64                       `json:"input_tokens,omitempty"`
+	OutputTokens   int64                       `json:"output_tokens,omitempty"`
+	Version        string                      `json:"version,omitempty"` // Build of the agent that produced the record
+}
+
+// alertJSON is the JSON representation of an investigated alert.
+type alertJSON struct {
+	ID           string            `json:"id"`
+	Source       string            `json:"source"`
+	Severity     string            `json:"severity"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Fingerprint  string            `json:"fingerprint,omitempty"`
+	GeneratorURL string            `json:"generator_url,omitempty"`
+	Historical   bool              `json:"historical,omitempty"`
+}
+
+// suppressionsDir is the subdirectory of the store that holds alert suppressions.
+const suppressionsDir = "suppressions"
+
+// spendDir is the subdirectory of the store that holds what investigations
+// spent each day.
+const spendDir = "spend"
+
+// spendDayLayout is the format of the days spend is recorded under.
+const spendDayLayout = "2006-01-02"
+
+// dailySpendJSON is the JSON representation of one day's investigation spend.
+type dailySpendJSON struct {
+	Day     string  `json:"day"`
+	CostUSD float64 `json:"cost_usd"`
 }
 
 // FileInvestigationStore implements InvestigationStore with file-based persistence.
@@ -193,6 +238,11 @@ func (s *FileInvestigationStore) Delete(ctx context.Context, id string) error {
 	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	for _, logPath := range []string{s.deliveriesPath(id), s.eventsPath(id)} {
+		if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 
 	delete(s.index, id)
 	delete(s.cache, id)
@@ -243,6 +293,21 @@ func (s *FileInvestigationStore) Query(
 	return results, nil
 }
 
+// List returns a page of the investigations matching the filter criteria,
+// newest first, and the total number that match. query.Limit is ignored.
+func (s *FileInvestigationStore) List(
+	ctx context.Context,
+	query service.InvestigationQuery,
+	page service.InvestigationPage,
+) ([]*service.InvestigationRecord, int, error) {
+	query.Limit = 0
+	results, err := s.Query(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return service.PageInvestigations(results, page), len(results), nil
+}
+
 // Count returns the total number of stored investigations.
 func (s *FileInvestigationStore) Count(ctx context.Context) (int, error) {
 	if err := ctx.Err(); err != nil {
@@ -259,6 +324,345 @@ func (s *FileInvestigationStore) Count(ctx context.Context) (int, error) {
 	return len(s.index), nil
 }
 
+// Ping reports whether the store is usable: it is open and its directory exists.
+func (s *FileInvestigationStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return service.ErrInvestigationStoreShutdown
+	}
+
+	info, err := os.Stat(s.baseDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s.baseDir)
+	}
+	return nil
+}
+
+// RecordDelivery appends a result notification delivery attempt to the
+// investigation's delivery log, <id>.deliveries.jsonl, next to its record.
+func (s *FileInvestigationStore) RecordDelivery(ctx context.Context, id string, attempt service.DeliveryAttempt) error {
+	return s.appendLog(ctx, s.deliveriesPath(id), id, attempt)
+}
+
+// Deliveries returns the delivery attempts recorded for an investigation,
+// oldest first. An investigation without deliveries has an empty log.
+func (s *FileInvestigationStore) Deliveries(ctx context.Context, id string) ([]service.DeliveryAttempt, error) {
+	return readLog[service.DeliveryAttempt](ctx, s, s.deliveriesPath(id), id, "delivery")
+}
+
+// RecordEvent appends a progress event to the investigation's event log,
+// <id>.events.jsonl, next to its record.
+func (s *FileInvestigationStore) RecordEvent(ctx context.Context, id string, event port.InvestigationEvent) error {
+	return s.appendLog(ctx, s.eventsPath(id), id, event)
+}
+
+// Events returns the progress events recorded for an investigation, oldest
+// first. An investigation without events has an empty log.
+func (s *FileInvestigationStore) Events(ctx context.Context, id string) ([]port.InvestigationEvent, error) {
+	return readLog[port.InvestigationEvent](ctx, s, s.eventsPath(id), id, "event")
+}
+
+// SaveSuppression stores an alert suppression as suppressions/<fingerprint>.json,
+// replacing any earlier suppression of the fingerprint.
+func (s *FileInvestigationStore) SaveSuppression(ctx context.Context, suppression *entity.AlertSuppression) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := entity.ValidateFingerprint(suppression.Fingerprint); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(suppression)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return service.ErrInvestigationStoreShutdown
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.baseDir, suppressionsDir), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(s.suppressionPath(suppression.Fingerprint), data, 0o600)
+}
+
+// Suppression returns the suppression stored for a fingerprint, or nil if
+// there is none. It reads from disk every time, so suppressions saved by
+// another process (such as the CLI) take effect immediately. Expired
+// suppressions are returned too; callers check AlertSuppression.ActiveAt.
+func (s *FileInvestigationStore) Suppression(ctx context.Context, fingerprint string) (*entity.AlertSuppression, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := entity.ValidateFingerprint(fingerprint); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, service.ErrInvestigationStoreShutdown
+	}
+
+	data, err := os.ReadFile(s.suppressionPath(fingerprint))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var suppression entity.AlertSuppression
+	if err := json.Unmarshal(data, &suppression); err != nil {
+		return nil, fmt.Errorf("corrupt suppression for %s: %w", fingerprint, err)
+	}
+	return &suppression, nil
+}
+
+// DeleteSuppression removes the suppression for a fingerprint, if any.
+func (s *FileInvestigationStore) DeleteSuppression(ctx context.Context, fingerprint string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := entity.ValidateFingerprint(fingerprint); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return service.ErrInvestigationStoreShutdown
+	}
+
+	if err := os.Remove(s.suppressionPath(fingerprint)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DailySpend returns what investigations spent on day ("2006-01-02"), or 0
+// if nothing was recorded. It reads from disk every time, so spend recorded
+// by another process counts too.
+func (s *FileInvestigationStore) DailySpend(ctx context.Context, day string) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if _, err := time.Parse(spendDayLayout, day); err != nil {
+		return 0, fmt.Errorf("invalid spend day %q: %w", day, err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return 0, service.ErrInvestigationStoreShutdown
+	}
+	return s.readSpend(day)
+}
+
+// AddDailySpend adds cost to what investigations spent on day and returns
+// the new total, stored as spend/<day>.json.
+func (s *FileInvestigationStore) AddDailySpend(ctx context.Context, day string, cost float64) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if _, err := time.Parse(spendDayLayout, day); err != nil {
+		return 0, fmt.Errorf("invalid spend day %q: %w", day, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, service.ErrInvestigationStoreShutdown
+	}
+
+	total, err := s.readSpend(day)
+	if err != nil {
+		return 0, err
+	}
+	total += cost
+	data, err := json.Marshal(dailySpendJSON{Day: day, CostUSD: total})
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Join(s.baseDir, spendDir), 0o750); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(s.spendPath(day), data, 0o600); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// readSpend reads the spend recorded for day. The caller holds s.mu.
+func (s *FileInvestigationStore) readSpend(day string) (float64, error) {
+	data, err := os.ReadFile(s.spendPath(day))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var spend dailySpendJSON
+	if err := json.Unmarshal(data, &spend); err != nil {
+		return 0, fmt.Errorf("corrupt spend record for %s: %w", day, err)
+	}
+	return spend.CostUSD, nil
+}
+
+// spendPath returns the path of a day's spend record.
+func (s *FileInvestigationStore) spendPath(day string) string {
+	return filepath.Join(s.baseDir, spendDir, day+".json")
+}
+
+// suppressionPath returns the path of a fingerprint's suppression. The
+// suppressions directory keeps them out of the investigation index.
+func (s *FileInvestigationStore) suppressionPath(fingerprint string) string {
+	return filepath.Join(s.baseDir, suppressionsDir, fingerprint+".json")
+}
+
+// deliveriesPath returns the path of an investigation's delivery log. Its
+// .jsonl extension keeps it out of the investigation index.
+func (s *FileInvestigationStore) deliveriesPath(id string) string {
+	return filepath.Join(s.baseDir, id+".deliveries.jsonl")
+}
+
+// eventsPath returns the path of an investigation's event log.
+func (s *FileInvestigationStore) eventsPath(id string) string {
+	return filepath.Join(s.baseDir, id+".events.jsonl")
+}
+
+// appendLog appends entry as a JSON line to the log at path.
+func (s *FileInvestigationStore) appendLog(ctx context.Context, path, id string, entry any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if id == "" {
+		return service.ErrEmptyInvestigationIDStore
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return service.ErrInvestigationStoreShutdown
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readLog reads the JSON lines of the log at path, oldest first. A missing log
+// is empty; kind names the entries in corruption errors.
+func readLog[T any](ctx context.Context, s *FileInvestigationStore, path, id, kind string) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, service.ErrEmptyInvestigationIDStore
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, service.ErrInvestigationStoreShutdown
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []T{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry T
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt %s log for %s: %w", kind, id, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Migrate rewrites every investigation record written by an older version
+// in the current format and returns how many it rewrote. A record it cannot
+// read, such as one written by a newer version, is left alone and its error
+// returned with the others once the rest are migrated.
+func (s *FileInvestigationStore) Migrate(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, service.ErrInvestigationStoreShutdown
+	}
+
+	ids := make([]string, 0, len(s.index))
+	for id := range s.index {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	migrated := 0
+	var errs []error
+	for _, id := range ids {
+		inv, old, err := s.loadFile(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if old {
+			if err := s.writeFile(inv); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			migrated++
+		}
+		s.cache[id] = inv
+	}
+	return migrated, errors.Join(errs...)
+}
+
 // Close marks the store as closed.
 func (s *FileInvestigationStore) Close() error {
 	s.mu.Lock()
@@ -270,18 +674,42 @@ func (s *FileInvestigationStore) Close() error {
 // writeFile writes an investigation to disk as JSON.
 func (s *FileInvestigationStore) writeFile(inv *service.InvestigationRecord) error {
 	data := investigationJSON{
+		SchemaVersion:  investigationMigrations.Current(),
 		ID:             inv.ID(),
 		AlertID:        inv.AlertID(),
 		SessionID:      inv.SessionID(),
 		Status:         inv.Status(),
 		StartedAt:      inv.StartedAt(),
 		CompletedAt:    inv.CompletedAt(),
-		Findings:       inv.Findings(),
+		Findings:       findingsToJSON(inv.Findings()),
 		ActionsTaken:   inv.ActionsTaken(),
 		DurationNanos:  int64(inv.Duration()),
 		Confidence:     inv.Confidence(),
 		Escalated:      inv.Escalated(),
 		EscalateReason: inv.EscalateReason(),
+		Error:          inv.ErrorMessage(),
+		ErrorKind:      inv.ErrorKind(),
+		RootCause:      inv.RootCause(),
+		Actions:        inv.RecommendedActions(),
+		Remediations:   inv.Remediations(),
+		Cost:           inv.Cost(),
+		InputTokens:    inv.Usage().InputTokens,
+		OutputTokens:   inv.Usage().OutputTokens,
+		Version:        inv.Version(),
+	}
+	if alert := inv.Alert(); alert != nil {
+		data.Alert = &alertJSON{
+			ID:           alert.ID(),
+			Source:       alert.Source(),
+			Severity:     alert.Severity(),
+			Title:        alert.Title(),
+			Description:  alert.Description(),
+			Labels:       alert.Labels(),
+			Annotations:  alert.Annotations(),
+			Fingerprint:  alert.Fingerprint(),
+			GeneratorURL: alert.GeneratorURL(),
+			Historical:   alert.Historical(),
+		}
 	}
 
 	bytes, err := json.Marshal(data)
@@ -295,38 +723,68 @@ func (s *FileInvestigationStore) writeFile(inv *service.InvestigationRecord) err
 
 // readFile reads an investigation from disk.
 func (s *FileInvestigationStore) readFile(id string) (*service.InvestigationRecord, error) {
+	inv, _, err := s.loadFile(id)
+	return inv, err
+}
+
+// loadFile reads an investigation from disk, migrating a record written by an
+// older version in memory, and reports whether it did. Records written by a
+// newer version are refused with schema.ErrNewerVersion.
+func (s *FileInvestigationStore) loadFile(id string) (*service.InvestigationRecord, bool, error) {
 	filePath := filepath.Join(s.baseDir, id+".json")
 	bytes, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, service.ErrInvestigationNotFound
+			return nil, false, service.ErrInvestigationNotFound
 		}
-		return nil, err
+		return nil, false, err
 	}
 
 	if len(bytes) == 0 {
-		return nil, errors.New("empty file")
+		return nil, false, errors.New("empty file")
 	}
 
+	bytes, migrated, err := investigationMigrations.Migrate(bytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("investigation %s: %w", id, err)
+	}
 	var data investigationJSON
 	if err := json.Unmarshal(bytes, &data); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return service.NewInvestigationRecordWithResult(
+	inv := service.NewInvestigationRecordWithResult(
 		data.ID,
 		data.AlertID,
 		data.SessionID,
 		data.Status,
 		data.StartedAt,
 		data.CompletedAt,
-		data.Findings,
+		findingsFromJSON(data.Findings),
 		data.ActionsTaken,
 		time.Duration(data.DurationNanos),
 		data.Confidence,
 		data.Escalated,
 		data.EscalateReason,
-	), nil
+	).WithErrorMessage(data.Error).WithErrorKind(data.ErrorKind).WithAlert(data.Alert.toEntity()).
+		WithResolution(data.RootCause, data.Actions).WithRemediations(data.Remediations).
+		WithUsage(data.Cost, entity.TokenUsage{InputTokens: data.InputTokens, OutputTokens: data.OutputTokens}).
+		WithVersion(data.Version)
+	return inv, migrated, nil
+}
+
+// toEntity converts a stored alert back to a domain alert. It returns nil for
+// a missing or invalid alert, which the record then lacks.
+func (a *alertJSON) toEntity() *entity.Alert {
+	if a == nil {
+		return nil
+	}
+	alert, err := entity.NewAlert(a.ID, a.Source, a.Severity, a.Title)
+	if err != nil {
+		return nil
+	}
+	return alert.WithDescription(a.Description).WithLabels(a.Labels).WithAnnotations(a.Annotations).
+		WithFingerprint(a.Fingerprint).WithGeneratorURL(a.GeneratorURL).WithHistorical(a.Historical)
 }
 
 // matchesQuery checks if an investigation matches all specified query criteria.
@@ -337,6 +795,12 @@ func matchesQuery(inv *service.InvestigationRecord, query service.InvestigationQ
 	if query.SessionID != "" && inv.SessionID() != query.SessionID {
 		return false
 	}
+	if query.Severity != "" && (inv.Alert() == nil || inv.Alert().Severity() != query.Severity) {
+		return false
+	}
+	if query.ErrorKind != "" && inv.ErrorKind() != query.ErrorKind {
+		return false
+	}
 	if len(query.Status) > 0 {
 		matched := false
 		for _, status := range query.Status {
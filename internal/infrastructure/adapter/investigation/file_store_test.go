@@ -2,10 +2,13 @@ package investigation
 
 import (
 	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -1331,3 +1334,311 @@ func TestFileInvestigationStore_ImplementsInterface(t *testing.T) {
 	// Compile-time check that FileInvestigationStore implements InvestigationStore
 	var _ service.InvestigationStore = store
 }
+
+func TestFileInvestigationStore_Ping(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "investigations")
+	store, err := NewFileInvestigationStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+
+	if err := store.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v, want nil", err)
+	}
+
+	if err := os.RemoveAll(storePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Ping(context.Background()); err == nil {
+		t.Error("Ping() should fail when the store directory is gone")
+	}
+
+	_ = store.Close()
+	if err := store.Ping(context.Background()); !errors.Is(err, service.ErrInvestigationStoreShutdown) {
+		t.Errorf("Ping() after Close() error = %v, want ErrInvestigationStoreShutdown", err)
+	}
+}
+
+func TestFileInvestigationStore_RecordDelivery(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "investigations")
+	store, err := NewFileInvestigationStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Store(ctx, service.NewInvestigationRecordForTest("inv-1", "alert-1", "", "completed")); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []service.DeliveryAttempt{
+		{URL: "http://hook", Attempt: 1, At: at, StatusCode: 503, Outcome: service.DeliveryRetrying},
+		{URL: "http://hook", Attempt: 2, At: at.Add(time.Second), StatusCode: 200, Outcome: service.DeliveryDelivered},
+	}
+	for _, attempt := range want {
+		if err := store.RecordDelivery(ctx, "inv-1", attempt); err != nil {
+			t.Fatalf("RecordDelivery() error = %v", err)
+		}
+	}
+
+	got, err := store.Deliveries(ctx, "inv-1")
+	if err != nil {
+		t.Fatalf("Deliveries() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Deliveries() returned %d attempts, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("attempt %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The delivery log is not mistaken for an investigation when the store reopens
+	reopened, err := NewFileInvestigationStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	if count, _ := reopened.Count(ctx); count != 1 {
+		t.Errorf("Count() after reopening = %d, want 1", count)
+	}
+
+	// Deleting the investigation removes its delivery log
+	if err := store.Delete(ctx, "inv-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, _ := store.Deliveries(ctx, "inv-1"); len(got) != 0 {
+		t.Errorf("Deliveries() after Delete() = %+v, want none", got)
+	}
+}
+
+func TestFileInvestigationStore_RecordEvent(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "investigations")
+	store, err := NewFileInvestigationStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Store(ctx, service.NewInvestigationRecordForTest("inv-1", "alert-1", "", "completed")); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []port.InvestigationEvent{
+		{Sequence: 1, Type: port.InvestigationEventIterationStarted, InvestigationID: "inv-1", Time: at, Iteration: 1},
+		{
+			Sequence: 2, Type: port.InvestigationEventToolExecuted, InvestigationID: "inv-1", Time: at.Add(time.Second),
+			ToolName: "bash", Summary: "load average: 9.1", Duration: 250 * time.Millisecond, Actions: 1,
+		},
+		{Sequence: 3, Type: port.InvestigationEventCompleted, InvestigationID: "inv-1", Time: at.Add(2 * time.Second), Actions: 1},
+	}
+	for _, event := range want {
+		if err := store.RecordEvent(ctx, "inv-1", event); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+
+	got, err := store.Events(ctx, "inv-1")
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Events() returned %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Neither log is mistaken for an investigation when the store reopens
+	_ = store.RecordDelivery(ctx, "inv-1", service.DeliveryAttempt{URL: "http://hook", Outcome: service.DeliveryDropped})
+	reopened, err := NewFileInvestigationStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	if count, _ := reopened.Count(ctx); count != 1 {
+		t.Errorf("Count() after reopening = %d, want 1", count)
+	}
+
+	// Deleting the investigation removes its event log
+	if err := store.Delete(ctx, "inv-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, _ := store.Events(ctx, "inv-1"); len(got) != 0 {
+		t.Errorf("Events() after Delete() = %+v, want none", got)
+	}
+	if _, err := store.Events(ctx, ""); !errors.Is(err, service.ErrEmptyInvestigationIDStore) {
+		t.Errorf("Events(\"\") error = %v, want ErrEmptyInvestigationIDStore", err)
+	}
+}
+
+func TestFileInvestigationStore_PersistsAlertAndLists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	ctx := context.Background()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	alert, _ := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk Full")
+	alert = alert.WithDescription("/var is full").
+		WithLabels(map[string]string{"instance": "db-1"}).
+		WithAnnotations(map[string]string{"runbook_url": "https://runbooks/disk"}).
+		WithGeneratorURL("http://prometheus:9090/graph?g0.expr=disk").WithHistorical(true)
+	actions := []entity.RecommendedAction{
+		{Description: "Rotate logs", Command: "logrotate -f /etc/logrotate.conf", Risk: entity.RiskLow},
+		{Description: "Add a disk alert at 80%", Risk: entity.RiskMedium, RequiresApproval: true},
+	}
+	remediations := []entity.RemediationAttempt{
+		{Action: actions[0], Status: entity.RemediationSucceeded, Output: `{"exit_code":0}`, At: base.Add(time.Minute)},
+	}
+	older := service.NewInvestigationRecordForTestWithTime("inv-old", "alert-1", "", "completed", base).WithAlert(alert).
+		WithResolution("old logs were never rotated", actions).WithRemediations(remediations).
+		WithUsage(0.42, entity.TokenUsage{InputTokens: 12000, OutputTokens: 800}).WithVersion("v1.4.0")
+	newer := service.NewInvestigationRecordForTestWithTime("inv-new", "alert-2", "", "failed", base.Add(time.Hour)).
+		WithErrorMessage("failed to build prompt: no template").WithErrorKind("prompt_build")
+	for _, inv := range []*service.InvestigationRecord{older, newer} {
+		if err := store.Store(ctx, inv); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+
+	// A fresh store reads the alert back from disk
+	reopened, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	got, err := reopened.Get(ctx, "inv-old")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if a := got.Alert(); a == nil || a.Severity() != entity.SeverityCritical || a.Description() != "/var is full" ||
+		a.Labels()["instance"] != "db-1" || a.Annotations()["runbook_url"] != "https://runbooks/disk" ||
+		a.GeneratorURL() != "http://prometheus:9090/graph?g0.expr=disk" || !a.Historical() {
+		t.Errorf("Alert() = %+v, want the stored alert", got.Alert())
+	}
+	if got.RootCause() != "old logs were never rotated" || !slices.Equal(got.RecommendedActions(), actions) {
+		t.Errorf("RootCause() = %q, RecommendedActions() = %+v, want the stored resolution",
+			got.RootCause(), got.RecommendedActions())
+	}
+	if !slices.Equal(got.Remediations(), remediations) {
+		t.Errorf("Remediations() = %+v, want %+v", got.Remediations(), remediations)
+	}
+	if got.Cost() != 0.42 || got.Usage() != (entity.TokenUsage{InputTokens: 12000, OutputTokens: 800}) {
+		t.Errorf("Cost() = %v, Usage() = %+v, want the stored usage", got.Cost(), got.Usage())
+	}
+	if got.Version() != "v1.4.0" {
+		t.Errorf("Version() = %q, want the build that stored it", got.Version())
+	}
+
+	all, total, err := reopened.List(ctx, service.InvestigationQuery{}, service.InvestigationPage{Limit: 1})
+	if err != nil || total != 2 || len(all) != 1 || all[0].ID() != "inv-new" {
+		t.Errorf("List() = %v of %d (%v), want inv-new of 2", all, total, err)
+	}
+	critical, total, err := reopened.List(ctx, service.InvestigationQuery{Severity: entity.SeverityCritical},
+		service.InvestigationPage{})
+	if err != nil || total != 1 || critical[0].ID() != "inv-old" {
+		t.Errorf("List(critical) = %v of %d (%v), want inv-old", critical, total, err)
+	}
+	failed, total, err := reopened.List(ctx, service.InvestigationQuery{ErrorKind: "prompt_build"},
+		service.InvestigationPage{})
+	if err != nil || total != 1 || failed[0].ErrorKind() != "prompt_build" {
+		t.Errorf("List(prompt_build) = %v of %d (%v), want inv-new with its error kind", failed, total, err)
+	}
+}
+
+func TestFileInvestigationStore_DailySpend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+
+	if spent, err := store.DailySpend(ctx, "2026-03-04"); err != nil || spent != 0 {
+		t.Fatalf("DailySpend() before spending = %v, %v; want 0, nil", spent, err)
+	}
+	if _, err := store.AddDailySpend(ctx, "2026-03-04", 0.25); err != nil {
+		t.Fatalf("AddDailySpend() error = %v", err)
+	}
+	if total, err := store.AddDailySpend(ctx, "2026-03-04", 0.5); err != nil || total != 0.75 {
+		t.Fatalf("AddDailySpend() = %v, %v; want 0.75, nil", total, err)
+	}
+
+	// A restarted process sees the day's spend, and other days start at 0
+	other, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	if spent, err := other.DailySpend(ctx, "2026-03-04"); err != nil || spent != 0.75 {
+		t.Errorf("DailySpend() after restart = %v, %v; want 0.75, nil", spent, err)
+	}
+	if spent, _ := other.DailySpend(ctx, "2026-03-05"); spent != 0 {
+		t.Errorf("DailySpend() of the next day = %v, want 0", spent)
+	}
+	if count, _ := other.Count(ctx); count != 0 {
+		t.Errorf("Count() = %d, want spend kept out of the index", count)
+	}
+
+	if _, err := store.AddDailySpend(ctx, "../escape", 1); err == nil {
+		t.Error("AddDailySpend(../escape) error = nil, want an invalid day error")
+	}
+}
+
+func TestFileInvestigationStore_Suppressions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+
+	if got, err := store.Suppression(ctx, "a1b2c3d4e5f60718"); err != nil || got != nil {
+		t.Fatalf("Suppression() before saving = %v, %v; want nil, nil", got, err)
+	}
+
+	now := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+	suppression := &entity.AlertSuppression{
+		Fingerprint: "a1b2c3d4e5f60718", Until: now.Add(time.Hour), Reason: "maintenance", CreatedAt: now,
+	}
+	if err := store.SaveSuppression(ctx, suppression); err != nil {
+		t.Fatalf("SaveSuppression() error = %v", err)
+	}
+
+	// Another process opening the store sees the suppression, and it does
+	// not show up as an investigation.
+	other, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	got, err := other.Suppression(ctx, "a1b2c3d4e5f60718")
+	if err != nil || got == nil {
+		t.Fatalf("Suppression() = %v, %v", got, err)
+	}
+	if !got.Until.Equal(suppression.Until) || got.Reason != "maintenance" || !got.CreatedAt.Equal(now) {
+		t.Errorf("Suppression() = %+v, want %+v", got, suppression)
+	}
+	if count, _ := other.Count(ctx); count != 0 {
+		t.Errorf("Count() = %d, want suppressions kept out of the index", count)
+	}
+
+	if err := store.DeleteSuppression(ctx, "a1b2c3d4e5f60718"); err != nil {
+		t.Fatalf("DeleteSuppression() error = %v", err)
+	}
+	if got, _ := other.Suppression(ctx, "a1b2c3d4e5f60718"); got != nil {
+		t.Errorf("Suppression() after delete = %+v, want nil", got)
+	}
+	if err := store.DeleteSuppression(ctx, "a1b2c3d4e5f60718"); err != nil {
+		t.Errorf("DeleteSuppression() of a missing suppression error = %v, want nil", err)
+	}
+
+	if _, err := store.Suppression(ctx, "../escape"); !errors.Is(err, entity.ErrInvalidFingerprint) {
+		t.Errorf("Suppression(../escape) error = %v, want ErrInvalidFingerprint", err)
+	}
+	bad := &entity.AlertSuppression{Fingerprint: "a/b", Until: now.Add(time.Hour)}
+	if err := store.SaveSuppression(ctx, bad); !errors.Is(err, entity.ErrInvalidFingerprint) {
+		t.Errorf("SaveSuppression(a/b) error = %v, want ErrInvalidFingerprint", err)
+	}
+}
@@ -0,0 +1,108 @@
+package investigation
+
+import (
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/port"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatMarkdown renders an investigation record as a Markdown report: its
+// alert and outcome, escalation reason or error, findings, and a timeline
+// built from its recorded progress events (which may be empty).
+func FormatMarkdown(inv *service.InvestigationRecord, events []port.InvestigationEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Investigation %s\n\n", inv.ID())
+
+	if alert := inv.Alert(); alert != nil {
+		fmt.Fprintf(&b, "- **Alert:** %s (%s, %s severity, from %s)\n",
+			alert.Title(), alert.ID(), alert.Severity(), alert.Source())
+		fmt.Fprintf(&b, "- **Fingerprint:** %s\n", alert.Fingerprint())
+	} else {
+		fmt.Fprintf(&b, "- **Alert:** %s\n", inv.AlertID())
+	}
+	fmt.Fprintf(&b, "- **Status:** %s\n", inv.Status())
+	if inv.ErrorKind() != "" {
+		fmt.Fprintf(&b, "- **Error kind:** %s\n", inv.ErrorKind())
+	}
+	fmt.Fprintf(&b, "- **Confidence:** %.2f\n", inv.Confidence())
+	fmt.Fprintf(&b, "- **Started:** %s\n", inv.StartedAt().Format(time.RFC3339))
+	if !inv.CompletedAt().IsZero() {
+		fmt.Fprintf(&b, "- **Completed:** %s\n", inv.CompletedAt().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- **Duration:** %s\n", inv.Duration().Round(time.Millisecond))
+	fmt.Fprintf(&b, "- **Actions taken:** %d\n", inv.ActionsTaken())
+	if inv.Version() != "" {
+		fmt.Fprintf(&b, "- **Agent version:** %s\n", inv.Version())
+	}
+
+	if alert := inv.Alert(); alert != nil && alert.Description() != "" {
+		fmt.Fprintf(&b, "\n## Alert\n\n%s\n", alert.Description())
+	}
+	if inv.Escalated() {
+		reason := inv.EscalateReason()
+		if reason == "" {
+			reason = "(no reason given)"
+		}
+		fmt.Fprintf(&b, "\n## Escalation\n\n%s\n", reason)
+	}
+	if inv.ErrorMessage() != "" {
+		heading := "Error"
+		switch inv.Status() {
+		case "suppressed":
+			heading = "Suppression"
+		case "deferred", "reprocessed":
+			heading = "Deferral"
+		case "cancelled":
+			heading = "Cancellation"
+		case "rejected":
+			heading = "Rejection"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", heading, inv.ErrorMessage())
+	}
+
+	b.WriteString("\n## Findings\n\n")
+	if len(inv.Findings()) == 0 {
+		b.WriteString("None recorded.\n")
+	}
+	for _, finding := range inv.Findings() {
+		fmt.Fprintf(&b, "- %s\n", finding)
+	}
+
+	if len(events) > 0 {
+		b.WriteString("\n## Timeline\n\n")
+		for _, event := range events {
+			fmt.Fprintf(&b, "- `%s` %s\n", event.Time.Format(time.TimeOnly), formatTimelineEvent(event))
+		}
+	}
+	return b.String()
+}
+
+// formatTimelineEvent describes one progress event for the timeline.
+func formatTimelineEvent(event port.InvestigationEvent) string {
+	switch event.Type {
+	case port.InvestigationEventIterationStarted:
+		return fmt.Sprintf("iteration %d started", event.Iteration)
+	case port.InvestigationEventToolExecuted:
+		outcome := "ran"
+		if event.IsError {
+			outcome = "failed"
+		}
+		text := fmt.Sprintf("%s %s in %s", event.ToolName, outcome, event.Duration.Round(time.Millisecond))
+		if event.Summary != "" {
+			text += ": " + event.Summary
+		}
+		return text
+	case port.InvestigationEventFindingAdded:
+		return "finding: " + event.Finding
+	case port.InvestigationEventCompleted:
+		return fmt.Sprintf("completed after %d actions", event.Actions)
+	case port.InvestigationEventEscalated:
+		return fmt.Sprintf("escalated after %d actions: %s", event.Actions, event.Reason)
+	case port.InvestigationEventFailed:
+		return fmt.Sprintf("failed after %d actions: %s", event.Actions, event.Reason)
+	default:
+		return string(event.Type)
+	}
+}
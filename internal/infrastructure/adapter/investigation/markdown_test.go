@@ -0,0 +1,82 @@
+package investigation
+
+import (
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	alert, _ := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk Full")
+	alert.WithFingerprint("a1b2c3d4e5f60718")
+	inv := service.NewInvestigationRecordWithResult(
+		"inv-1", "alert-1", "", "completed", started, started.Add(90*time.Second),
+		[]string{"/var is 98% full"}, 2, 90*time.Second, 0.8, true, "confidence below threshold",
+	).WithAlert(alert.WithDescription("Disk usage above 95%"))
+	events := []port.InvestigationEvent{
+		{Type: port.InvestigationEventIterationStarted, Iteration: 1, Time: started},
+		{Type: port.InvestigationEventToolExecuted, ToolName: "bash", Summary: "/dev/sda1 98%",
+			Duration: 1500 * time.Millisecond, Time: started.Add(2 * time.Second)},
+		{Type: port.InvestigationEventEscalated, Actions: 2, Reason: "confidence below threshold",
+			Time: started.Add(90 * time.Second)},
+	}
+
+	got := FormatMarkdown(inv, events)
+
+	for _, want := range []string{
+		"# Investigation inv-1\n",
+		"- **Alert:** Disk Full (alert-1, critical severity, from prometheus)\n",
+		"- **Fingerprint:** a1b2c3d4e5f60718\n",
+		"- **Confidence:** 0.80\n",
+		"- **Duration:** 1m30s\n",
+		"## Alert\n\nDisk usage above 95%\n",
+		"## Escalation\n\nconfidence below threshold\n",
+		"## Findings\n\n- /var is 98% full\n",
+		"- `03:04:07` bash ran in 1.5s: /dev/sda1 98%\n",
+		"- `03:05:35` escalated after 2 actions: confidence below threshold\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatMarkdown() missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "## Error") {
+		t.Errorf("FormatMarkdown() has an error section without an error:\n%s", got)
+	}
+}
+
+func TestFormatMarkdown_Suppressed(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	inv := service.NewInvestigationRecordWithResult(
+		"inv-2", "alert-2", "", "suppressed", at, at, nil, 0, 0, 0, false, "",
+	).WithErrorMessage("suppressed until 2026-01-02T05:00:00Z: maintenance window")
+
+	got := FormatMarkdown(inv, nil)
+
+	if !strings.Contains(got, "## Suppression\n\nsuppressed until 2026-01-02T05:00:00Z: maintenance window\n") {
+		t.Errorf("FormatMarkdown() missing the suppression reason in:\n%s", got)
+	}
+	if strings.Contains(got, "## Error") {
+		t.Errorf("FormatMarkdown() reports a suppression as an error:\n%s", got)
+	}
+}
+
+func TestFormatMarkdown_Deferred(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	reason := `deferred: alert source "prometheus" started more than 20 investigations within 10m0s`
+	inv := service.NewInvestigationRecordWithResult(
+		"inv-3", "alert-3", "", "deferred", at, at, nil, 0, 0, 0, false, "",
+	).WithErrorMessage(reason)
+
+	got := FormatMarkdown(inv, nil)
+
+	if !strings.Contains(got, "## Deferral\n\n"+reason+"\n") {
+		t.Errorf("FormatMarkdown() missing the deferral reason in:\n%s", got)
+	}
+	if strings.Contains(got, "## Error") {
+		t.Errorf("FormatMarkdown() reports a deferral as an error:\n%s", got)
+	}
+}
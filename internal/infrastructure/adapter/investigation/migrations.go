@@ -0,0 +1,104 @@
+package investigation
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/infrastructure/schema"
+	"encoding/json"
+)
+
+// investigationMigrations migrates investigation records written by older
+// versions. Append a step to change the record format.
+//
+//nolint:gochecknoglobals // read-only migration registry
+var investigationMigrations = schema.Migrations{
+	Kind: "investigation record",
+	Steps: []schema.Migration{
+		structureFindings,           // 1 -> 2
+		structureRecommendedActions, // 2 -> 3
+	},
+}
+
+// findingJSON is the JSON representation of a finding, from schema version 2
+// on; version 1 stored findings as their rendered strings.
+type findingJSON struct {
+	Severity    string `json:"severity"`
+	Text        string `json:"text"`
+	Occurrences int    `json:"occurrences,omitempty"`
+}
+
+// findingsToJSON splits rendered findings ("[warning] Disk 91% full") into
+// their severity, text, and occurrence count. Blank findings are dropped.
+func findingsToJSON(findings []string) []findingJSON {
+	var stored []findingJSON
+	for _, s := range findings {
+		finding := usecase.ParseFinding(s)
+		if finding.Text == "" {
+			continue
+		}
+		entry := findingJSON{Severity: finding.Severity, Text: finding.Text}
+		if finding.Occurrences > 1 {
+			entry.Occurrences = finding.Occurrences
+		}
+		stored = append(stored, entry)
+	}
+	return stored
+}
+
+// findingsFromJSON renders stored findings the way the record holds them.
+func findingsFromJSON(stored []findingJSON) []string {
+	var findings []string
+	for _, entry := range stored {
+		findings = append(findings, usecase.Finding{
+			Text:        entry.Text,
+			Severity:    entry.Severity,
+			Occurrences: entry.Occurrences,
+		}.String())
+	}
+	return findings
+}
+
+// structureFindings migrates a record from version 1 to 2, replacing its list
+// of finding strings with structured findings. Untagged findings get the
+// severity their wording suggests, as usecase.ParseFinding assigns it.
+func structureFindings(record map[string]json.RawMessage) error {
+	raw, ok := record["findings"]
+	if !ok {
+		return nil
+	}
+	var findings []string
+	if err := json.Unmarshal(raw, &findings); err != nil {
+		return err
+	}
+	structured, err := json.Marshal(findingsToJSON(findings))
+	if err != nil {
+		return err
+	}
+	record["findings"] = structured
+	return nil
+}
+
+// structureRecommendedActions migrates a record from version 2 to 3,
+// replacing its list of recommended action strings with structured actions
+// that carry each string as their description, with no command and unknown
+// risk.
+func structureRecommendedActions(record map[string]json.RawMessage) error {
+	raw, ok := record["recommended_actions"]
+	if !ok {
+		return nil
+	}
+	var descriptions []string
+	if err := json.Unmarshal(raw, &descriptions); err != nil {
+		return err
+	}
+	actions := make([]entity.RecommendedAction, 0, len(descriptions))
+	for _, description := range descriptions {
+		actions = append(actions, entity.RecommendedAction{Description: description})
+	}
+	structured, err := json.Marshal(actions)
+	if err != nil {
+		return err
+	}
+	record["recommended_actions"] = structured
+	return nil
+}
@@ -0,0 +1,138 @@
+package investigation
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/infrastructure/schema"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// openGoldenStore opens a store over copies of the named testdata records.
+func openGoldenStore(t *testing.T, names ...string) (*FileInvestigationStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+	store, err := NewFileInvestigationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, dir
+}
+
+// wantV1Findings are the findings of testdata/inv-v1.json after migration.
+//
+//nolint:gochecknoglobals // test fixture
+var wantV1Findings = []string{
+	"[critical] Disk /var is 100% full",
+	"[info] Log rotation last ran 9 days ago (reported 2 times)",
+	"[warning] CPU usage is elevated",
+}
+
+func TestFileInvestigationStore_ReadsVersion1Records(t *testing.T) {
+	store, _ := openGoldenStore(t, "inv-v1.json")
+
+	inv, err := store.Get(context.Background(), "inv-v1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !slices.Equal(inv.Findings(), wantV1Findings) {
+		t.Errorf("Findings() = %q, want %q", inv.Findings(), wantV1Findings)
+	}
+	if inv.Status() != "escalated" || inv.ActionsTaken() != 4 || inv.EscalateReason() != "confidence below threshold" ||
+		inv.RootCause() != "logs were never rotated" || inv.Alert() == nil || inv.Alert().Labels()["instance"] != "web-1" {
+		t.Errorf("Get() = %+v, want the rest of the record as stored", inv)
+	}
+	if want := []entity.RecommendedAction{{Description: "Rotate logs"}}; !slices.Equal(inv.RecommendedActions(), want) {
+		t.Errorf("RecommendedActions() = %+v, want %+v", inv.RecommendedActions(), want)
+	}
+}
+
+func TestFileInvestigationStore_ReadsVersion2Records(t *testing.T) {
+	store, _ := openGoldenStore(t, "inv-v2.json")
+
+	inv, err := store.Get(context.Background(), "inv-v2")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	// Version 2 stored recommended actions as strings, which become
+	// descriptions with no command, so none of them is ever run
+	want := []entity.RecommendedAction{{Description: "Rotate logs"}, {Description: "Add a disk alert at 80%"}}
+	if !slices.Equal(inv.RecommendedActions(), want) {
+		t.Errorf("RecommendedActions() = %+v, want %+v", inv.RecommendedActions(), want)
+	}
+	if !slices.Equal(inv.Findings(), []string{"[critical] Disk /var is 100% full"}) || inv.Status() != "completed" {
+		t.Errorf("Get() = %+v, want the rest of the record as stored", inv)
+	}
+}
+
+func TestFileInvestigationStore_RefusesNewerVersion(t *testing.T) {
+	store, dir := openGoldenStore(t, "inv-future.json")
+	before, _ := os.ReadFile(filepath.Join(dir, "inv-future.json"))
+
+	_, err := store.Get(context.Background(), "inv-future")
+	if !errors.Is(err, schema.ErrNewerVersion) {
+		t.Fatalf("Get() error = %v, want ErrNewerVersion", err)
+	}
+	for _, want := range []string{"inv-future", "schema version 99", "reads up to version 3", "upgrade"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Get() error = %q, want it to mention %q", err, want)
+		}
+	}
+
+	if _, err := store.Migrate(context.Background()); !errors.Is(err, schema.ErrNewerVersion) {
+		t.Errorf("Migrate() error = %v, want ErrNewerVersion", err)
+	}
+	after, _ := os.ReadFile(filepath.Join(dir, "inv-future.json"))
+	if string(after) != string(before) {
+		t.Errorf("newer record was rewritten:\n%s", after)
+	}
+}
+
+func TestFileInvestigationStore_Migrate(t *testing.T) {
+	store, dir := openGoldenStore(t, "inv-v1.json")
+	ctx := context.Background()
+
+	migrated, err := store.Migrate(ctx)
+	if err != nil || migrated != 1 {
+		t.Fatalf("Migrate() = %d, %v, want 1 record rewritten", migrated, err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "inv-v1.json"))
+	var stored investigationJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("migrated record is not valid JSON: %v", err)
+	}
+	wantStored := []findingJSON{
+		{Severity: "critical", Text: "Disk /var is 100% full"},
+		{Severity: "info", Text: "Log rotation last ran 9 days ago", Occurrences: 2},
+		{Severity: "warning", Text: "CPU usage is elevated"},
+	}
+	if stored.SchemaVersion != investigationMigrations.Current() || !slices.Equal(stored.Findings, wantStored) {
+		t.Errorf("migrated record = version %d, findings %+v; want version %d, findings %+v",
+			stored.SchemaVersion, stored.Findings, investigationMigrations.Current(), wantStored)
+	}
+
+	if migrated, err := store.Migrate(ctx); err != nil || migrated != 0 {
+		t.Errorf("second Migrate() = %d, %v, want nothing left to migrate", migrated, err)
+	}
+	reopened, _ := NewFileInvestigationStore(dir)
+	inv, err := reopened.Get(ctx, "inv-v1")
+	if err != nil || !slices.Equal(inv.Findings(), wantV1Findings) {
+		t.Errorf("Get() after Migrate() = %v, %v, want findings %q", inv, err, wantV1Findings)
+	}
+}
@@ -0,0 +1,194 @@
+// Package mcp connects the agent to Model Context Protocol servers. Each
+// configured server is started as a subprocess speaking JSON-RPC over stdio,
+// or reached over streamable HTTP, and its tools are registered with the tool
+// executor under namespaced names.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the MCP revision the client asks servers for.
+const ProtocolVersion = "2024-11-05"
+
+// clientName identifies the agent to MCP servers.
+const clientName = "code-editing-agent"
+
+// ErrServerExited is returned for calls to a server whose process has exited.
+var ErrServerExited = errors.New("mcp server exited")
+
+// RPCError is an error response from an MCP server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// message is a JSON-RPC 2.0 request, notification, or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// isResponse reports whether m answers one of the client's requests.
+func (m *message) isResponse() bool {
+	return m.ID != nil && m.Method == ""
+}
+
+// transport carries JSON-RPC messages to and from one server.
+type transport interface {
+	// call sends a request and returns the result of its response.
+	call(ctx context.Context, method string, params any) (json.RawMessage, error)
+
+	// notify sends a notification, which has no response.
+	notify(ctx context.Context, method string, params any) error
+
+	// done is closed when the server can no longer be reached, after which
+	// err reports why. It is nil for transports without a connection to lose.
+	done() <-chan struct{}
+	err() error
+
+	close() error
+}
+
+// ToolInfo describes a tool as listed by an MCP server.
+type ToolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema,omitempty"`
+}
+
+// Client speaks MCP to one server.
+type Client struct {
+	transport transport
+}
+
+// newClient performs the MCP handshake over t.
+func newClient(ctx context.Context, t transport) (*Client, error) {
+	_, err := t.call(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": clientName, "version": "1.0.0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if err := t.notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, fmt.Errorf("initialized notification: %w", err)
+	}
+	return &Client{transport: t}, nil
+}
+
+// ListTools returns every tool the server offers, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var tools []ToolInfo
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		result, err := c.transport.call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("tools/list: %w", err)
+		}
+		var page struct {
+			Tools      []ToolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("tools/list: invalid result: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// contentItem is one part of a tool call result.
+type contentItem struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Resource *struct {
+		URI  string `json:"uri"`
+		Text string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
+
+// CallTool calls the named tool with JSON arguments and returns its content
+// as text. A result the server flags as an error is returned as an error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	result, err := c.transport.call(ctx, "tools/call", map[string]any{"name": name, "arguments": arguments})
+	if err != nil {
+		return "", err
+	}
+	var call struct {
+		Content []contentItem `json:"content"`
+		IsError bool          `json:"isError"`
+	}
+	if err := json.Unmarshal(result, &call); err != nil {
+		return "", fmt.Errorf("tools/call: invalid result: %w", err)
+	}
+
+	text := contentText(call.Content)
+	if call.IsError {
+		if text == "" {
+			text = "the tool reported an error"
+		}
+		return "", errors.New(text)
+	}
+	return text, nil
+}
+
+// contentText renders tool result content as text. Content the model cannot
+// read as text, like images, is described instead.
+func contentText(content []contentItem) string {
+	parts := make([]string, 0, len(content))
+	for _, item := range content {
+		switch {
+		case item.Type == "text":
+			parts = append(parts, item.Text)
+		case item.Type == "resource" && item.Resource != nil && item.Resource.Text != "":
+			parts = append(parts, item.Resource.Text)
+		case item.Type == "resource" && item.Resource != nil:
+			parts = append(parts, fmt.Sprintf("[resource: %s]", item.Resource.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content: %s]", item.Type, item.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Done is closed when the server can no longer be reached.
+func (c *Client) Done() <-chan struct{} {
+	return c.transport.done()
+}
+
+// Err reports why the server can no longer be reached, once Done is closed.
+func (c *Client) Err() error {
+	return c.transport.err()
+}
+
+// Close disconnects from the server, stopping its process if it has one.
+func (c *Client) Close() error {
+	return c.transport.close()
+}
@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sessionHeader carries the session a streamable HTTP server assigned.
+const sessionHeader = "Mcp-Session-Id"
+
+// httpTransport reaches a server over the streamable HTTP transport: each
+// message is POSTed to one endpoint, which answers with JSON or with a
+// server-sent event stream carrying the response.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	nextID    int64
+	sessionID string
+}
+
+// newHTTPTransport creates a transport for the endpoint at url, sending
+// headers with every request.
+func newHTTPTransport(url string, headers map[string]string) *httpTransport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{}}
+}
+
+func (t *httpTransport) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.nextID++
+	id := t.nextID
+	t.mu.Unlock()
+
+	resp, err := t.post(ctx, &message{JSONRPC: "2.0", ID: &id, Method: method, Params: raw})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	reply, err := readResponse(resp, id)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+	if method == "initialize" {
+		if sessionID := resp.Header.Get(sessionHeader); sessionID != "" {
+			t.mu.Lock()
+			t.sessionID = sessionID
+			t.mu.Unlock()
+		}
+	}
+	return reply.Result, nil
+}
+
+func (t *httpTransport) notify(ctx context.Context, method string, params any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	resp, err := t.post(ctx, &message{JSONRPC: "2.0", Method: method, Params: raw})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// post sends msg and returns the server's successful response.
+func (t *httpTransport) post(ctx context.Context, msg *message) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			return nil, cause
+		}
+		return nil, fmt.Errorf("mcp request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("mcp server returned %s: %s", resp.Status, strings.TrimSpace(string(excerpt)))
+	}
+	return resp, nil
+}
+
+// setHeaders adds the configured headers and the session to req.
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(sessionHeader, t.sessionID)
+	}
+}
+
+// readResponse reads the response to request id from a JSON body or an
+// event stream.
+func readResponse(resp *http.Response, id int64) (*message, error) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var reply message
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxMessageBytes)).Decode(&reply); err != nil {
+			return nil, fmt.Errorf("invalid mcp response: %w", err)
+		}
+		return &reply, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		// A blank line ends the event
+		var msg message
+		if err := json.Unmarshal([]byte(data.String()), &msg); err == nil && msg.isResponse() && *msg.ID == id {
+			return &msg, nil
+		}
+		data.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mcp event stream: %w", err)
+	}
+	return nil, fmt.Errorf("mcp event stream ended without a response to request %d", id)
+}
+
+// done returns nil: there is no connection to lose between requests.
+func (t *httpTransport) done() <-chan struct{} {
+	return nil
+}
+
+func (t *httpTransport) err() error {
+	return nil
+}
+
+// close ends the server session, if it assigned one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil // The server may already be gone
+	}
+	return resp.Body.Close()
+}
+
+// Compile-time checks that the transports implement transport.
+var (
+	_ transport = (*stdioTransport)(nil)
+	_ transport = (*httpTransport)(nil)
+)
@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a request to a server may take when its config
+// sets no timeout.
+const DefaultTimeout = 30 * time.Second
+
+// toolNamePrefix starts the names server tools are registered under.
+const toolNamePrefix = "mcp__"
+
+// ServerConfig configures one MCP server. Exactly one of Command and URL is set.
+type ServerConfig struct {
+	Command string            // Program started for the stdio transport
+	Args    []string          // Arguments of Command
+	Env     map[string]string // Added to the agent's environment for Command
+	URL     string            // Endpoint of the streamable HTTP transport
+	Headers map[string]string // Sent with every HTTP request, e.g. Authorization
+	Timeout time.Duration     // Longest a request may take; 0 means DefaultTimeout
+}
+
+// timeout returns the effective request timeout.
+func (c ServerConfig) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultTimeout
+}
+
+// serverNamePattern matches valid server names, which become part of tool names.
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateServerName reports whether name can name a server.
+func ValidateServerName(name string) error {
+	if !serverNamePattern.MatchString(name) || strings.Contains(name, "__") {
+		return fmt.Errorf("invalid mcp server name %q: use letters, digits, - and single _", name)
+	}
+	return nil
+}
+
+// ToolName returns the name the server's tool is registered under.
+func ToolName(server, tool string) string {
+	return toolNamePrefix + server + "__" + invalidToolNameChars.ReplaceAllString(tool, "_")
+}
+
+// invalidToolNameChars matches characters AI providers reject in tool names.
+var invalidToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// ToolHandler executes a registered tool with its JSON input.
+type ToolHandler = func(ctx context.Context, input json.RawMessage) (string, error)
+
+// ToolRegistry is the tool executor server tools are registered with.
+type ToolRegistry interface {
+	RegisterExternalTool(tool entity.Tool, handler ToolHandler) error
+	UnregisterTool(name string) error
+}
+
+// Messenger shows system messages, such as a server exiting, to the user.
+type Messenger interface {
+	DisplaySystemMessage(message string) error
+}
+
+// server is one connected MCP server.
+type server struct {
+	name   string
+	client *Client
+	tools  []string // Registered tool names
+}
+
+// Manager connects the configured MCP servers and registers their tools. When
+// a server's process exits, its tools are unregistered and the user is told.
+// It is safe for concurrent use.
+type Manager struct {
+	registry   ToolRegistry
+	workingDir string
+	messenger  Messenger
+	logger     *slog.Logger
+
+	mu         sync.Mutex
+	servers    map[string]*server
+	connecting map[string]bool // Names reserved by a Connect in progress
+}
+
+// NewManager creates a manager that registers tools with registry and starts
+// stdio servers in workingDir.
+func NewManager(registry ToolRegistry, workingDir string) *Manager {
+	return &Manager{
+		registry:   registry,
+		workingDir: workingDir,
+		logger:     slog.Default(),
+		servers:    make(map[string]*server),
+		connecting: make(map[string]bool),
+	}
+}
+
+// SetMessenger sets where servers that fail or exit are reported.
+func (m *Manager) SetMessenger(messenger Messenger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messenger = messenger
+}
+
+// SetLogger sets the logger for server lifecycle events.
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// ConnectAll connects every server in configs, in name order. A server that
+// fails to connect is reported and skipped rather than stopping the others.
+func (m *Manager) ConnectAll(ctx context.Context, configs map[string]ServerConfig) {
+	for _, name := range slices.Sorted(maps.Keys(configs)) {
+		if err := m.Connect(ctx, name, configs[name]); err != nil {
+			m.report(fmt.Sprintf("MCP server %s is unavailable: %v", name, err))
+		}
+	}
+}
+
+// Connect starts or reaches the named server, lists its tools, and registers
+// them as mcp__<name>__<tool>.
+//
+// Returns:
+//   - error: if the name is invalid or taken, or the server cannot be reached
+//     or listed within its timeout
+func (m *Manager) Connect(ctx context.Context, name string, cfg ServerConfig) error {
+	if err := ValidateServerName(name); err != nil {
+		return err
+	}
+	// Reserve the name while connecting, so a concurrent Connect of the same
+	// name fails instead of starting a second server
+	m.mu.Lock()
+	_, exists := m.servers[name]
+	if exists || m.connecting[name] {
+		m.mu.Unlock()
+		return fmt.Errorf("mcp server %s is already connected", name)
+	}
+	m.connecting[name] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.connecting, name)
+		m.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeoutCause(ctx, cfg.timeout(),
+		fmt.Errorf("mcp server %s did not start within %s", name, cfg.timeout()))
+	defer cancel()
+
+	var t transport
+	switch {
+	case cfg.Command != "":
+		stdio, err := startStdio(cfg.Command, cfg.Args, cfg.Env, m.workingDir)
+		if err != nil {
+			return err
+		}
+		t = stdio
+	case cfg.URL != "":
+		t = newHTTPTransport(cfg.URL, cfg.Headers)
+	default:
+		return fmt.Errorf("mcp server %s has neither a command nor a url", name)
+	}
+
+	client, err := newClient(ctx, t)
+	if err != nil {
+		_ = t.close()
+		return err
+	}
+	infos, err := client.ListTools(ctx)
+	if err != nil {
+		_ = client.Close()
+		return err
+	}
+
+	s := &server{name: name, client: client}
+	for _, info := range infos {
+		tool := toolEntity(name, info)
+		if err := m.registry.RegisterExternalTool(tool, m.handler(client, info.Name, cfg.timeout())); err != nil {
+			m.log().Warn("skipping mcp tool", "server", name, "tool", info.Name, "error", err)
+			continue
+		}
+		s.tools = append(s.tools, tool.Name)
+	}
+
+	m.mu.Lock()
+	m.servers[name] = s
+	m.mu.Unlock()
+	m.log().Info("connected mcp server", "server", name, "tools", len(s.tools))
+
+	if done := client.Done(); done != nil {
+		go func() {
+			<-done
+			m.serverExited(s)
+		}()
+	}
+	return nil
+}
+
+// handler returns the function that calls the server's tool, each call
+// limited to timeout.
+func (m *Manager) handler(client *Client, toolName string, timeout time.Duration) ToolHandler {
+	return func(ctx context.Context, input json.RawMessage) (string, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, timeout,
+			fmt.Errorf("mcp tool %s did not answer within %s", toolName, timeout))
+		defer cancel()
+		return client.CallTool(ctx, toolName, input)
+	}
+}
+
+// toolEntity converts a listed tool into an entity.Tool registered under its
+// namespaced name. The input schema is passed through, as an object schema.
+func toolEntity(serverName string, info ToolInfo) entity.Tool {
+	name := ToolName(serverName, info.Name)
+	description := strings.TrimSpace(info.Description)
+	if description == "" {
+		description = fmt.Sprintf("Tool %s of the %s MCP server", info.Name, serverName)
+	}
+
+	schema := make(map[string]interface{}, len(info.InputSchema)+1)
+	for key, value := range info.InputSchema {
+		if key != "$schema" {
+			schema[key] = value
+		}
+	}
+	schema["type"] = "object"
+	if _, ok := schema["properties"]; !ok {
+		schema["properties"] = map[string]interface{}{}
+	}
+
+	var required []string
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, field := range list {
+			if s, ok := field.(string); ok {
+				required = append(required, s)
+			}
+		}
+	}
+
+	return entity.Tool{
+		ID:             name,
+		Name:           name,
+		Description:    description,
+		InputSchema:    schema,
+		RequiredFields: required,
+	}
+}
+
+// serverExited unregisters the tools of a server whose process exited and
+// tells the user, unless the manager closed it.
+func (m *Manager) serverExited(s *server) {
+	m.mu.Lock()
+	if m.servers[s.name] != s {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.servers, s.name)
+	m.mu.Unlock()
+
+	for _, name := range s.tools {
+		_ = m.registry.UnregisterTool(name)
+	}
+	m.report(fmt.Sprintf("MCP server %s stopped (%v); its %d tools were removed", s.name, s.client.Err(), len(s.tools)))
+}
+
+// Servers returns the names of the connected servers and their tools.
+func (m *Manager) Servers() map[string][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	servers := make(map[string][]string, len(m.servers))
+	for name, s := range m.servers {
+		servers[name] = slices.Clone(s.tools)
+	}
+	return servers
+}
+
+// Close disconnects every server, stopping their processes, and unregisters
+// their tools.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	servers := m.servers
+	m.servers = make(map[string]*server)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, s := range servers {
+		for _, name := range s.tools {
+			_ = m.registry.UnregisterTool(name)
+		}
+		if err := s.client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("mcp server %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// report logs message and shows it to the user if a messenger is set.
+func (m *Manager) report(message string) {
+	m.log().Warn(message)
+	m.mu.Lock()
+	messenger := m.messenger
+	m.mu.Unlock()
+	if messenger != nil {
+		_ = messenger.DisplaySystemMessage(message)
+	}
+}
+
+// log returns the manager's logger.
+func (m *Manager) log() *slog.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.logger
+}
@@ -0,0 +1,352 @@
+package mcp
+
+import (
+	"bufio"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServerEnv makes the test binary act as a scripted MCP server on stdio.
+const fakeServerEnv = "MCP_FAKE_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeServerEnv) != "" {
+		runFakeServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeServer serves two pages of tools: echo and fail, then slow and crash.
+func runFakeServer() {
+	fmt.Println("fake server starting") // Stray output a client must skip
+	var writeMu sync.Mutex
+	write := func(id json.RawMessage, result any) {
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		os.Stdout.Write(append(data, '\n'))
+	}
+	text := func(s string, isError bool) map[string]any {
+		return map[string]any{"content": []any{map[string]any{"type": "text", "text": s}}, "isError": isError}
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Cursor    string            `json:"cursor"`
+				Name      string            `json:"name"`
+				Arguments map[string]string `json:"arguments"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || req.ID == nil {
+			continue // Notifications need no answer
+		}
+		switch req.Method {
+		case "initialize":
+			write(req.ID, map[string]any{
+				"protocolVersion": ProtocolVersion,
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "fake", "version": "1"},
+			})
+		case "tools/list":
+			if req.Params.Cursor == "" {
+				write(req.ID, map[string]any{"nextCursor": "page2", "tools": []any{
+					map[string]any{"name": "echo", "description": "Echoes text", "inputSchema": map[string]any{
+						"$schema":    "http://json-schema.org/draft-07/schema#",
+						"type":       "object",
+						"properties": map[string]any{"text": map[string]any{"type": "string"}},
+						"required":   []string{"text"},
+					}},
+					map[string]any{"name": "fail"},
+				}})
+				continue
+			}
+			write(req.ID, map[string]any{"tools": []any{
+				map[string]any{"name": "slow", "inputSchema": map[string]any{"type": "object"}},
+				map[string]any{"name": "crash", "inputSchema": map[string]any{"type": "object"}},
+			}})
+		case "tools/call":
+			switch req.Params.Name {
+			case "echo":
+				write(req.ID, text("echo: "+req.Params.Arguments["text"], false))
+			case "fail":
+				write(req.ID, text("disk full", true))
+			case "slow":
+				go func(id json.RawMessage) {
+					time.Sleep(5 * time.Second)
+					write(id, text("finally", false))
+				}(req.ID)
+			case "crash":
+				fmt.Fprintln(os.Stderr, "fatal: boom")
+				os.Exit(3)
+			}
+		}
+	}
+}
+
+// recordingMessenger records the system messages shown to the user.
+type recordingMessenger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recordingMessenger) DisplaySystemMessage(message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *recordingMessenger) all() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.messages, "\n")
+}
+
+// fakeServerConfig starts the test binary as the fake server.
+func fakeServerConfig(timeout time.Duration) ServerConfig {
+	return ServerConfig{Command: os.Args[0], Env: map[string]string{fakeServerEnv: "1"}, Timeout: timeout}
+}
+
+func newTestManager(t *testing.T) (*Manager, *tool.ExecutorAdapter, *recordingMessenger) {
+	t.Helper()
+	executor := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	manager := NewManager(executor, t.TempDir())
+	messenger := &recordingMessenger{}
+	manager.SetMessenger(messenger)
+	t.Cleanup(func() { _ = manager.Close() })
+	return manager, executor, messenger
+}
+
+func TestManager_RegistersAndCallsServerTools(t *testing.T) {
+	manager, executor, _ := newTestManager(t)
+	ctx := context.Background()
+
+	if err := manager.Connect(ctx, "fake", fakeServerConfig(0)); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	for _, name := range []string{"echo", "fail", "slow", "crash"} {
+		if _, ok := executor.GetTool("mcp__fake__" + name); !ok {
+			t.Errorf("tool mcp__fake__%s was not registered", name)
+		}
+	}
+	echo, _ := executor.GetTool("mcp__fake__echo")
+	if echo.Description != "Echoes text" || len(echo.RequiredFields) != 1 || echo.RequiredFields[0] != "text" {
+		t.Errorf("echo tool = %+v, want its description and required fields", echo)
+	}
+	if _, ok := echo.InputSchema["$schema"]; ok || echo.InputSchema["type"] != "object" {
+		t.Errorf("echo schema = %v, want an object schema without $schema", echo.InputSchema)
+	}
+	if fail, _ := executor.GetTool("mcp__fake__fail"); fail.Description == "" || fail.InputSchema["type"] != "object" {
+		t.Errorf("fail tool = %+v, want a default description and an object schema", fail)
+	}
+
+	result, err := executor.ExecuteTool(ctx, "mcp__fake__echo", map[string]string{"text": "hi"})
+	if err != nil || result != "echo: hi" {
+		t.Errorf("ExecuteTool(echo) = %q, %v, want the server's text", result, err)
+	}
+	// Input goes through the executor's validation before reaching the server
+	if _, err := executor.ExecuteTool(ctx, "mcp__fake__echo", map[string]string{}); err == nil ||
+		!strings.Contains(err.Error(), "missing required field: text") {
+		t.Errorf("ExecuteTool(echo without text) error = %v, want a validation error", err)
+	}
+	if _, err := executor.ExecuteTool(ctx, "mcp__fake__fail", map[string]string{}); err == nil ||
+		err.Error() != "disk full" {
+		t.Errorf("ExecuteTool(fail) error = %v, want the server's error text", err)
+	}
+
+	if err := manager.Connect(ctx, "fake", fakeServerConfig(0)); err == nil {
+		t.Error("connecting a second server with the same name should fail")
+	}
+	if tools := manager.Servers()["fake"]; len(tools) != 4 {
+		t.Errorf("Servers()[fake] = %v, want 4 tools", tools)
+	}
+}
+
+func TestManager_ConcurrentConnectsOfOneName(t *testing.T) {
+	manager, executor, _ := newTestManager(t)
+	ctx := context.Background()
+
+	start := make(chan struct{})
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			<-start
+			errs <- manager.Connect(ctx, "fake", fakeServerConfig(0))
+		}()
+	}
+	close(start)
+	var failed int
+	for range 2 {
+		if err := <-errs; err != nil {
+			if !strings.Contains(err.Error(), "already connected") {
+				t.Errorf("Connect() error = %v, want the name reported as taken", err)
+			}
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("%d of 2 concurrent Connect() calls failed, want 1", failed)
+	}
+
+	if tools := manager.Servers()["fake"]; len(tools) != 4 {
+		t.Errorf("Servers()[fake] = %v, want the 4 tools of the one server started", tools)
+	}
+	if result, err := executor.ExecuteTool(ctx, "mcp__fake__echo", map[string]string{"text": "hi"}); err != nil ||
+		result != "echo: hi" {
+		t.Errorf("ExecuteTool(echo) = %q, %v, want the tracked server to answer", result, err)
+	}
+}
+
+func TestManager_TimesOutSlowCalls(t *testing.T) {
+	manager, executor, _ := newTestManager(t)
+	ctx := context.Background()
+	if err := manager.Connect(ctx, "fake", fakeServerConfig(200*time.Millisecond)); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err := executor.ExecuteTool(ctx, "mcp__fake__slow", map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "did not answer within 200ms") {
+		t.Errorf("ExecuteTool(slow) error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ExecuteTool(slow) took %v, want it cut off at the timeout", elapsed)
+	}
+
+	// The server keeps serving after a call timed out
+	if result, err := executor.ExecuteTool(ctx, "mcp__fake__echo", map[string]string{"text": "still here"}); err != nil ||
+		result != "echo: still here" {
+		t.Errorf("ExecuteTool(echo) after a timeout = %q, %v", result, err)
+	}
+}
+
+func TestManager_ServerCrashUnregistersItsTools(t *testing.T) {
+	manager, executor, messenger := newTestManager(t)
+	ctx := context.Background()
+	if err := manager.Connect(ctx, "fake", fakeServerConfig(0)); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	_, err := executor.ExecuteTool(ctx, "mcp__fake__crash", map[string]string{})
+	if !errors.Is(err, ErrServerExited) || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("ExecuteTool(crash) error = %v, want ErrServerExited with the server's stderr", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(messenger.all(), "stopped") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := messenger.all(); !strings.Contains(got, "MCP server fake stopped") || !strings.Contains(got, "4 tools") {
+		t.Errorf("system messages = %q, want the crash reported", got)
+	}
+	if _, ok := executor.GetTool("mcp__fake__echo"); ok {
+		t.Error("the crashed server's tools should be unregistered")
+	}
+	if _, err := executor.ExecuteTool(ctx, "mcp__fake__echo", map[string]string{"text": "x"}); err == nil {
+		t.Error("calling a crashed server's tool should fail")
+	}
+	if len(manager.Servers()) != 0 {
+		t.Errorf("Servers() = %v, want none", manager.Servers())
+	}
+}
+
+func TestManager_ConnectAllSkipsUnavailableServers(t *testing.T) {
+	manager, executor, messenger := newTestManager(t)
+
+	manager.ConnectAll(context.Background(), map[string]ServerConfig{
+		"broken": {Command: "/nonexistent/mcp-server"},
+		"fake":   fakeServerConfig(0),
+		"bad.name": {
+			Command: os.Args[0],
+		},
+	})
+
+	got := messenger.all()
+	if !strings.Contains(got, "MCP server broken is unavailable") || !strings.Contains(got, "invalid mcp server name") {
+		t.Errorf("system messages = %q, want the unavailable servers reported", got)
+	}
+	if _, ok := executor.GetTool("mcp__fake__echo"); !ok {
+		t.Error("a working server should connect despite the others failing")
+	}
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, ok := executor.GetTool("mcp__fake__echo"); ok {
+		t.Error("Close() should unregister the servers' tools")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(messenger.all(), "stopped") {
+		t.Error("closing a server should not report it as stopped")
+	}
+}
+
+func TestManager_ConnectsOverStreamableHTTP(t *testing.T) {
+	var sessionHeaders []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req message
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		mu.Lock()
+		sessionHeaders = append(sessionHeaders, r.Header.Get(sessionHeader))
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method {
+		case "initialize":
+			w.Header().Set(sessionHeader, "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":%q}}`, *req.ID, ProtocolVersion)
+		case "tools/list":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"tools":[{"name":"lookup","inputSchema":{"type":"object"}}]}}`, *req.ID)
+		case "tools/call":
+			// Answered on an event stream, after an unrelated notification
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%d,\n", *req.ID)
+			fmt.Fprint(w, "data: \"result\":{\"content\":[{\"type\":\"text\",\"text\":\"found\"}]}}\n\n")
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	manager, executor, _ := newTestManager(t)
+	cfg := ServerConfig{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	if err := manager.Connect(context.Background(), "remote", cfg); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	result, err := executor.ExecuteTool(context.Background(), "mcp__remote__lookup", map[string]string{})
+	if err != nil || result != "found" {
+		t.Errorf("ExecuteTool(lookup) = %q, %v, want the streamed result", result, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sessionHeaders[0] != "" || sessionHeaders[len(sessionHeaders)-1] != "session-1" {
+		t.Errorf("session headers = %v, want the assigned session sent after initialize", sessionHeaders)
+	}
+}
@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageBytes caps one JSON-RPC message read from a stdio server.
+const maxMessageBytes = 16 << 20
+
+// maxStderrBytes is how much of a server's latest stderr output is kept to
+// explain why it exited.
+const maxStderrBytes = 2048
+
+// stopTimeout is how long a stdio server gets to exit after its stdin is
+// closed before it is killed.
+const stopTimeout = 2 * time.Second
+
+// stdioTransport runs a server as a subprocess exchanging newline-delimited
+// JSON-RPC messages on its stdin and stdout.
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+
+	exited  chan struct{} // Closed once the process has exited
+	exitErr error
+}
+
+// startStdio starts command in dir with env added to the agent's environment.
+func startStdio(command string, args []string, env map[string]string, dir string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	// Grandchildren holding stderr open must not keep Wait from returning
+	cmd.WaitDelay = time.Second
+
+	t := &stdioTransport{
+		cmd:     cmd,
+		stderr:  &tailBuffer{max: maxStderrBytes},
+		pending: make(map[int64]chan *message),
+		exited:  make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+	t.stdin = stdin
+	go t.read(stdout)
+	return t, nil
+}
+
+// read dispatches the server's responses to the pending calls until its
+// stdout closes, then records why the process exited.
+func (t *stdioTransport) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // Not JSON-RPC, such as stray logging
+		}
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[*msg.ID]
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case msg.ID != nil:
+			// The client offers no capabilities, so it answers no requests
+			_ = t.send(&message{
+				JSONRPC: "2.0",
+				ID:      msg.ID,
+				Error:   &RPCError{Code: -32601, Message: "method not found: " + msg.Method},
+			})
+		}
+	}
+	if scanner.Err() != nil {
+		// Stdout is no longer drained, so the server could block writing it
+		_ = t.cmd.Process.Kill()
+	}
+
+	err := t.cmd.Wait()
+	t.mu.Lock()
+	t.exitErr = ErrServerExited
+	if err != nil {
+		t.exitErr = fmt.Errorf("%w: %v", ErrServerExited, err)
+	}
+	if stderr := strings.TrimSpace(t.stderr.String()); stderr != "" {
+		t.exitErr = fmt.Errorf("%w; stderr: %s", t.exitErr, stderr)
+	}
+	t.mu.Unlock()
+	close(t.exited)
+}
+
+// send writes one message to the server.
+func (t *stdioTransport) send(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		select {
+		case <-t.exited:
+			return t.err()
+		default:
+			return fmt.Errorf("failed to write to mcp server: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *stdioTransport) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.nextID++
+	id := t.nextID
+	ch := make(chan *message, 1)
+	t.pending[id] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	select {
+	case <-t.exited:
+		return nil, t.err()
+	default:
+	}
+	if err := t.send(&message{JSONRPC: "2.0", ID: &id, Method: method, Params: raw}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-t.exited:
+		return nil, t.err()
+	case <-ctx.Done():
+		_ = t.notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    context.Cause(ctx).Error(),
+		})
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, method string, params any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	return t.send(&message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+func (t *stdioTransport) done() <-chan struct{} {
+	return t.exited
+}
+
+func (t *stdioTransport) err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exitErr
+}
+
+// close closes the server's stdin, which asks it to exit, and kills it if it
+// has not exited within stopTimeout.
+func (t *stdioTransport) close() error {
+	t.writeMu.Lock()
+	_ = t.stdin.Close()
+	t.writeMu.Unlock()
+
+	select {
+	case <-t.exited:
+	case <-time.After(stopTimeout):
+		if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("failed to stop mcp server: %w", err)
+		}
+		<-t.exited
+	}
+	return nil
+}
+
+// marshalParams encodes request params, leaving nil params out.
+func marshalParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode params: %w", err)
+	}
+	return raw, nil
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf bytes.Buffer
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if extra := b.buf.Len() - b.max; extra > 0 {
+		b.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,212 @@
+// Package memory loads and updates the agent's persistent memory: project
+// preferences such as "always run tests with -race" that carry over between
+// sessions. Memory is read from a global ~/.config/code-agent/AGENT.md and a
+// project-local AGENT.md (or .agent/memory.md), local last.
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultMaxBytes caps the merged memory added to the system prompt (16KB).
+const DefaultMaxBytes = 16 << 10
+
+// LearnedHeading is the section Remember appends bullets under.
+const LearnedHeading = "## Learned"
+
+// Names of the project-local memory file. The alternate is used when it
+// exists; otherwise AGENT.md is read and written.
+const (
+	localFileName     = "AGENT.md"
+	localAltFileName  = ".agent/memory.md"
+	globalFileDirName = ".config/code-agent"
+)
+
+// ErrEmptyMemory is returned by Remember for text with nothing to remember.
+var ErrEmptyMemory = errors.New("nothing to remember: text is empty")
+
+// File is one memory file that exists.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Store reads the global and project-local memory files and appends learned
+// preferences to the local one. It is safe for concurrent use.
+type Store struct {
+	globalPath string
+	workingDir string
+	maxBytes   int
+	mu         sync.Mutex // serializes Remember's read-modify-write
+}
+
+// NewStore creates a store for the global memory file at globalPath and the
+// project-local file in workingDir. An empty globalPath disables the global
+// file; maxBytes <= 0 uses DefaultMaxBytes.
+func NewStore(globalPath, workingDir string, maxBytes int) *Store {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Store{
+		globalPath: globalPath,
+		workingDir: workingDir,
+		maxBytes:   maxBytes,
+	}
+}
+
+// GlobalPath returns the global memory file under home, or an empty string
+// when home is unknown.
+func GlobalPath(home string) string {
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, globalFileDirName, localFileName)
+}
+
+// LocalPath returns the project-local memory file: .agent/memory.md when it
+// exists, otherwise AGENT.md, which need not exist yet.
+func (s *Store) LocalPath() string {
+	alt := filepath.Join(s.workingDir, localAltFileName)
+	if info, err := os.Stat(alt); err == nil && info.Mode().IsRegular() {
+		return alt
+	}
+	return filepath.Join(s.workingDir, localFileName)
+}
+
+// Files returns the memory files that exist, global first. Missing files are
+// skipped; other read errors are returned.
+func (s *Store) Files() ([]File, error) {
+	var files []File
+	for _, path := range []string{s.globalPath, s.LocalPath()} {
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read memory file %s: %w", path, err)
+		}
+		if strings.TrimSpace(string(content)) == "" {
+			continue
+		}
+		files = append(files, File{Path: path, Content: string(content)})
+	}
+	return files, nil
+}
+
+// Load returns the merged memory for the system prompt: the global file, then
+// the local one, so local preferences come last and win. Memory over the cap
+// keeps its end, dropping the start of the global file first, behind a
+// truncation notice. Load returns an empty string when there is no memory.
+func (s *Store) Load() (string, error) {
+	files, err := s.Files()
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		parts = append(parts, strings.TrimSpace(file.Content))
+	}
+	return capTail(strings.Join(parts, "\n\n"), s.maxBytes), nil
+}
+
+// capTail returns the last maxBytes of memory, starting at a line boundary
+// where one exists, behind a truncation notice.
+func capTail(memory string, maxBytes int) string {
+	if len(memory) <= maxBytes {
+		return memory
+	}
+	tail := memory[len(memory)-maxBytes:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	} else {
+		for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+			tail = tail[1:]
+		}
+	}
+	return fmt.Sprintf("[memory truncated to its last %d bytes]\n%s", len(tail), tail)
+}
+
+// Remember appends text as a bullet under the "## Learned" section of the
+// local memory file, creating the file or section when missing. It reports
+// false without writing when the file already has an identical line.
+func (s *Store) Remember(text string) (bool, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.TrimSpace(strings.TrimPrefix(text, "- "))
+	if text == "" {
+		return false, ErrEmptyMemory
+	}
+	bullet := "- " + text
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.LocalPath()
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to read memory file %s: %w", path, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	for _, line := range lines {
+		if strings.TrimSpace(line) == bullet {
+			return false, nil
+		}
+	}
+
+	updated := appendLearned(lines, bullet)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write memory file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// appendLearned inserts bullet after the last non-blank line of the Learned
+// section, adding the section at the end when there is none.
+func appendLearned(lines []string, bullet string) string {
+	section := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == LearnedHeading {
+			section = i
+			break
+		}
+	}
+	if section < 0 {
+		if len(lines) == 1 && lines[0] == "" {
+			return LearnedHeading + "\n\n" + bullet + "\n"
+		}
+		return strings.Join(lines, "\n") + "\n\n" + LearnedHeading + "\n\n" + bullet + "\n"
+	}
+
+	end := len(lines)
+	for i := section + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	insert := section + 1
+	for i := end - 1; i > section; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			insert = i + 1
+			break
+		}
+	}
+	if insert == section+1 {
+		// Leave a blank line between the heading and its first bullet
+		lines = append(lines[:insert], append([]string{"", bullet}, lines[insert:]...)...)
+	} else {
+		lines = append(lines[:insert], append([]string{bullet}, lines[insert:]...)...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
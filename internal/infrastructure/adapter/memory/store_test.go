@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates path, and its directory, with content.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_LoadMergesGlobalThenLocal(t *testing.T) {
+	home, project := t.TempDir(), t.TempDir()
+	writeFile(t, GlobalPath(home), "- we use tabs\n")
+	writeFile(t, filepath.Join(project, "AGENT.md"), "- we use spaces in YAML\n")
+
+	got, err := NewStore(GlobalPath(home), project, 0).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := "- we use tabs\n\n- we use spaces in YAML"; got != want {
+		t.Errorf("Load() = %q, want %q", got, want)
+	}
+}
+
+func TestStore_LoadPrefersAgentDirectory(t *testing.T) {
+	project := t.TempDir()
+	writeFile(t, filepath.Join(project, "AGENT.md"), "- from AGENT.md\n")
+	writeFile(t, filepath.Join(project, ".agent", "memory.md"), "- from .agent/memory.md\n")
+
+	store := NewStore("", project, 0)
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got != "- from .agent/memory.md" {
+		t.Errorf("Load() = %q, want only .agent/memory.md", got)
+	}
+	if want := filepath.Join(project, ".agent", "memory.md"); store.LocalPath() != want {
+		t.Errorf("LocalPath() = %q, want %q", store.LocalPath(), want)
+	}
+}
+
+func TestStore_LoadWithoutFiles(t *testing.T) {
+	got, err := NewStore(GlobalPath(t.TempDir()), t.TempDir(), 0).Load()
+	if err != nil || got != "" {
+		t.Errorf("Load() = %q, %v; want empty", got, err)
+	}
+}
+
+func TestStore_LoadCapKeepsLocalEnd(t *testing.T) {
+	home, project := t.TempDir(), t.TempDir()
+	writeFile(t, GlobalPath(home), strings.Repeat("- global preference\n", 20))
+	writeFile(t, filepath.Join(project, "AGENT.md"), "- always run tests with -race\n")
+
+	got, err := NewStore(GlobalPath(home), project, 64).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	notice, kept, _ := strings.Cut(got, "\n")
+	if !strings.HasPrefix(notice, "[memory truncated") {
+		t.Errorf("Load() starts with %q, want a truncation notice", notice)
+	}
+	if len(kept) > 64 {
+		t.Errorf("kept %d bytes of memory, want at most 64", len(kept))
+	}
+	if !strings.HasPrefix(kept, "- global preference\n") {
+		t.Errorf("kept memory %q does not start at a line boundary", kept)
+	}
+	if !strings.HasSuffix(kept, "- always run tests with -race") {
+		t.Errorf("kept memory %q lost the local file", kept)
+	}
+}
+
+func TestCapTail_LongLine(t *testing.T) {
+	got := capTail(strings.Repeat("é", 10), 5)
+	_, kept, _ := strings.Cut(got, "\n")
+	if kept != "éé" {
+		t.Errorf("capTail() kept %q, want whole runes", kept)
+	}
+}
+
+func TestStore_Remember(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name: "creates the file",
+			want: "## Learned\n\n- always run tests with -race\n",
+		},
+		{
+			name:     "adds the section",
+			existing: "# Project\n\n- we use tabs\n",
+			want:     "# Project\n\n- we use tabs\n\n## Learned\n\n- always run tests with -race\n",
+		},
+		{
+			name:     "appends to the section before the next heading",
+			existing: "## Learned\n\n- we use tabs\n\n## Style\n\n- short names\n",
+			want:     "## Learned\n\n- we use tabs\n- always run tests with -race\n\n## Style\n\n- short names\n",
+		},
+		{
+			name:     "fills an empty section",
+			existing: "# Project\n\n## Learned\n",
+			want:     "# Project\n\n## Learned\n\n- always run tests with -race\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := t.TempDir()
+			path := filepath.Join(project, "AGENT.md")
+			if tt.existing != "" {
+				writeFile(t, path, tt.existing)
+			}
+
+			added, err := NewStore("", project, 0).Remember("always run tests with -race")
+			if err != nil || !added {
+				t.Fatalf("Remember() = %v, %v; want added", added, err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("memory file =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_RememberDeduplicates(t *testing.T) {
+	project := t.TempDir()
+	store := NewStore("", project, 0)
+
+	for _, text := range []string{"we use tabs", "- we use tabs", "  we   use\ntabs "} {
+		if _, err := store.Remember(text); err != nil {
+			t.Fatalf("Remember(%q) error = %v", text, err)
+		}
+	}
+	added, err := store.Remember("we use tabs")
+	if err != nil || added {
+		t.Errorf("Remember() of a duplicate = %v, %v; want not added", added, err)
+	}
+	got, err := os.ReadFile(filepath.Join(project, "AGENT.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Learned\n\n- we use tabs\n"; string(got) != want {
+		t.Errorf("memory file = %q, want %q", got, want)
+	}
+
+	if _, err := store.Remember("  "); err == nil {
+		t.Error("Remember() of blank text should fail")
+	}
+}
+
+func TestStore_RememberWritesAgentDirectory(t *testing.T) {
+	project := t.TempDir()
+	alt := filepath.Join(project, ".agent", "memory.md")
+	writeFile(t, alt, "")
+
+	if _, err := NewStore("", project, 0).Remember("we use tabs"); err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "AGENT.md")); !os.IsNotExist(err) {
+		t.Error("Remember() created AGENT.md although .agent/memory.md exists")
+	}
+	if got, _ := os.ReadFile(alt); !strings.Contains(string(got), "- we use tabs") {
+		t.Errorf(".agent/memory.md = %q, want the bullet", got)
+	}
+}
@@ -0,0 +1,142 @@
+package metrics_test
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/metrics"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedAIProvider replies with one scripted set of tool calls per request.
+type scriptedAIProvider struct {
+	turns [][]port.ToolCallInfo
+	calls int
+}
+
+func (p *scriptedAIProvider) SendMessage(
+	_ context.Context,
+	_ []port.MessageParam,
+	_ []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	var toolCalls []port.ToolCallInfo
+	if p.calls < len(p.turns) {
+		toolCalls = p.turns[p.calls]
+	}
+	p.calls++
+	msg, err := entity.NewMessage(entity.RoleAssistant, "Investigating.")
+	return msg, toolCalls, err
+}
+
+func (p *scriptedAIProvider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessage(ctx, messages, tools)
+}
+
+func (p *scriptedAIProvider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	_ port.StreamCallback,
+	_ port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	return p.SendMessage(ctx, messages, tools)
+}
+
+func (p *scriptedAIProvider) GenerateToolSchema() port.ToolInputSchemaParam {
+	return port.ToolInputSchemaParam{}
+}
+func (p *scriptedAIProvider) HealthCheck(context.Context) error { return nil }
+func (p *scriptedAIProvider) SetModel(string) error             { return nil }
+func (p *scriptedAIProvider) GetModel() string                  { return "scripted" }
+
+func TestMetrics_ScrapeAfterInvestigation(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	workDir := t.TempDir()
+	executor := tool.NewExecutorAdapter(file.NewLocalFileManager(workDir))
+	executor.SetMetricsRecorder(registry)
+
+	aiProvider := &scriptedAIProvider{turns: [][]port.ToolCallInfo{
+		{{ToolID: "call_1", ToolName: "list_files", Input: map[string]interface{}{"path": "."}}},
+		{{ToolID: "call_2", ToolName: "read_file", Input: map[string]interface{}{"path": "missing.txt"}}},
+		{{ToolID: "call_3", ToolName: "complete_investigation", Input: map[string]interface{}{
+			"confidence": 0.9,
+			"findings":   []interface{}{"Disk is full"},
+			"root_cause": "Log rotation disabled",
+		}}},
+	}}
+	convService, err := service.NewConversationService(aiProvider, executor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+
+	investigations := usecase.NewAlertInvestigationUseCaseWithConfig(usecase.AlertInvestigationUseCaseConfig{
+		MaxActions:    20,
+		MaxDuration:   time.Minute,
+		MaxConcurrent: 1,
+		AllowedTools:  []string{"list_files", "read_file", "complete_investigation"},
+	})
+	investigations.SetConversationService(convService)
+	investigations.SetToolExecutor(executor)
+	prompts := usecase.NewPromptBuilderRegistry()
+	if err := prompts.Register(usecase.NewGenericPromptBuilder()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	investigations.SetPromptBuilderRegistry(prompts)
+	investigations.SetMetricsRecorder(registry)
+
+	title := "Disk full on db-7f3a9c"
+	alert, err := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, title)
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	handler := usecase.NewAlertHandler(investigations, usecase.AlertHandlerConfig{AutoInvestigateCritical: true})
+	if err := handler.HandleEntityAlert(context.Background(), alert); err != nil {
+		t.Fatalf("HandleEntityAlert() error = %v", err)
+	}
+
+	server := httptest.NewServer(registry.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading scrape: %v", err)
+	}
+	out := string(body)
+
+	for _, want := range []string{
+		`investigations_total{status="completed",severity="critical"} 1`,
+		`investigation_duration_seconds_count 1`,
+		`tool_executions_total{tool="list_files",error="false"} 1`,
+		`tool_executions_total{tool="read_file",error="true"} 1`,
+		`tool_duration_seconds_count{tool="list_files"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("scrape should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "complete_investigation") {
+		t.Errorf("complete_investigation is handled by the runner and should not be recorded as a tool, got:\n%s", out)
+	}
+	if strings.Contains(out, title) || strings.Contains(out, "db-7f3a9c") {
+		t.Errorf("alert titles must not appear in metric labels, got:\n%s", out)
+	}
+}
@@ -0,0 +1,280 @@
+// Package metrics provides a Prometheus metrics adapter that implements the
+// domain MetricsRecorder port and serves the recorded metrics over HTTP in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contentType is the Content-Type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// requestBuckets are histogram buckets, in seconds, for tool executions and AI requests.
+	requestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+	// investigationBuckets are histogram buckets, in seconds, for whole investigations.
+	investigationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 900, 1800}
+)
+
+// Registry holds the agent's metrics and implements port.MetricsRecorder.
+// It is safe for concurrent use.
+type Registry struct {
+	investigations        *family
+	investigationDuration *family
+	toolExecutions        *family
+	toolDuration          *family
+	toolOutputBytes       *family
+	aiRequests            *family
+	aiRequestDuration     *family
+	tokens                *family
+
+	families []*family // In exposition order
+}
+
+// Compile-time check that Registry implements port.MetricsRecorder.
+var _ port.MetricsRecorder = (*Registry)(nil)
+
+// NewRegistry creates a Registry with all agent metrics registered and empty.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.investigations = r.counter("investigations_total",
+		"Investigations finished, by final status and alert severity.", "status", "severity")
+	r.investigationDuration = r.histogram("investigation_duration_seconds",
+		"Duration of finished investigations in seconds.", investigationBuckets)
+	r.toolExecutions = r.counter("tool_executions_total",
+		"Tool executions, by tool and whether the tool returned an error.", "tool", "error")
+	r.toolDuration = r.histogram("tool_duration_seconds",
+		"Duration of tool executions in seconds.", requestBuckets, "tool")
+	r.toolOutputBytes = r.counter("tool_output_bytes_total",
+		"Bytes of output returned by tool executions, by tool.", "tool")
+	r.aiRequests = r.counter("ai_requests_total",
+		"Requests to AI providers, by provider, model and HTTP status code.", "provider", "model", "code")
+	r.aiRequestDuration = r.histogram("ai_request_duration_seconds",
+		"Duration of requests to AI providers in seconds.", requestBuckets)
+	r.tokens = r.counter("tokens_total",
+		"Tokens exchanged with AI providers, by direction (input or output).", "direction")
+	return r
+}
+
+// RecordInvestigation implements port.MetricsRecorder.
+func (r *Registry) RecordInvestigation(status, severity string, duration time.Duration) {
+	r.investigations.add(1, status, severity)
+	r.investigationDuration.observe(duration.Seconds())
+}
+
+// RecordToolExecution implements port.MetricsRecorder.
+func (r *Registry) RecordToolExecution(tool string, failed bool, duration time.Duration) {
+	r.toolExecutions.add(1, tool, strconv.FormatBool(failed))
+	r.toolDuration.observe(duration.Seconds(), tool)
+}
+
+// RecordToolOutput implements port.MetricsRecorder.
+func (r *Registry) RecordToolOutput(tool string, bytes int) {
+	if bytes > 0 {
+		r.toolOutputBytes.add(float64(bytes), tool)
+	}
+}
+
+// RecordAIRequest implements port.MetricsRecorder.
+func (r *Registry) RecordAIRequest(provider, model, code string, duration time.Duration) {
+	r.aiRequests.add(1, provider, model, code)
+	r.aiRequestDuration.observe(duration.Seconds())
+}
+
+// RecordTokens implements port.MetricsRecorder.
+func (r *Registry) RecordTokens(direction string, count int64) {
+	if count > 0 {
+		r.tokens.add(float64(count), direction)
+	}
+}
+
+// WriteTo writes all metrics to w in the Prometheus text exposition format.
+// Metrics without any recorded series are written with their HELP and TYPE lines only.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, f := range r.families {
+		f.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler returns an http.Handler serving the metrics for Prometheus to scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// RegisterGaugeFunc registers a gauge without labels whose value is read from
+// value at each scrape, for state owned by another component such as the AI
+// rate limiter's utilization. value must be safe for concurrent use.
+func (r *Registry) RegisterGaugeFunc(name, help string, value func() float64) {
+	r.families = append(r.families, &family{name: name, help: help, kind: "gauge", value: value})
+}
+
+// counter registers a counter with the given label names.
+func (r *Registry) counter(name, help string, labelNames ...string) *family {
+	f := &family{name: name, help: help, kind: "counter", labelNames: labelNames, series: make(map[string]*series)}
+	r.families = append(r.families, f)
+	return f
+}
+
+// histogram registers a histogram with the given buckets and label names.
+func (r *Registry) histogram(name, help string, buckets []float64, labelNames ...string) *family {
+	f := &family{
+		name: name, help: help, kind: "histogram", labelNames: labelNames,
+		buckets: buckets, series: make(map[string]*series),
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+// family is a metric and its series, one per combination of label values.
+type family struct {
+	name       string
+	help       string
+	kind       string // "counter", "histogram", or "gauge"
+	labelNames []string
+	buckets    []float64      // Upper bounds, histograms only
+	value      func() float64 // Current value, gauges only
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is the value of a metric for one combination of label values.
+type series struct {
+	labelValues []string
+	value       float64  // Counter value, or histogram sum
+	counts      []uint64 // Histogram bucket counts (non-cumulative), histograms only
+	count       uint64   // Histogram observation count, histograms only
+}
+
+// get returns the series for labelValues, creating it if needed. f.mu must be held.
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// add increments a counter series by v.
+func (f *family) add(v float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value += v
+}
+
+// observe records v in a histogram series.
+func (f *family) observe(v float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.get(labelValues)
+	s.value += v
+	s.count++
+	if i := sort.SearchFloat64s(f.buckets, v); i < len(f.buckets) {
+		s.counts[i]++
+	}
+}
+
+// write writes the family in the Prometheus text exposition format, with series
+// sorted by label values so the output is stable.
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	if f.kind == "gauge" {
+		fmt.Fprintf(b, "%s %s\n", f.name, formatValue(f.value()))
+		return
+	}
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		labels := formatLabels(f.labelNames, s.labelValues)
+		if f.kind == "counter" {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, wrapLabels(labels), formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range f.buckets {
+			cumulative += s.counts[i]
+			le := `le="` + formatValue(upper) + `"`
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, le)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="+Inf"`)), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, wrapLabels(labels), formatValue(s.value))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, wrapLabels(labels), s.count)
+	}
+}
+
+// formatLabels formats label pairs as name="value" separated by commas.
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = name + `="` + escapeLabelValue(value) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+// joinLabels appends a formatted label pair to formatted labels.
+func joinLabels(labels, pair string) string {
+	if labels == "" {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+// wrapLabels wraps formatted labels in braces, or returns "" when there are none.
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// labelValueEscaper escapes label values as required by the exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the exposition format.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// formatValue formats a sample value for the exposition format.
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
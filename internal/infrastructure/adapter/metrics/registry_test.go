@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestRegistry_EmptyWritesHelpAndType(t *testing.T) {
+	out := scrape(t, NewRegistry())
+
+	for _, name := range []string{
+		"investigations_total", "investigation_duration_seconds", "tool_executions_total",
+		"tool_duration_seconds", "tool_output_bytes_total", "ai_requests_total", "ai_request_duration_seconds", "tokens_total",
+	} {
+		if !strings.Contains(out, "# HELP "+name+" ") || !strings.Contains(out, "# TYPE "+name+" ") {
+			t.Errorf("output should declare %s, got:\n%s", name, out)
+		}
+	}
+	if strings.Contains(out, "_bucket") {
+		t.Errorf("empty registry should write no samples, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Counters(t *testing.T) {
+	r := NewRegistry()
+	r.RecordInvestigation("completed", "critical", 2*time.Second)
+	r.RecordInvestigation("completed", "critical", 3*time.Second)
+	r.RecordInvestigation("failed", "warning", time.Second)
+	r.RecordToolExecution("bash", true, 10*time.Millisecond)
+	r.RecordToolOutput("bash", 512)
+	r.RecordToolOutput("bash", 512)
+	r.RecordToolOutput("read_file", 0)
+	r.RecordAIRequest("anthropic", "claude", "429", time.Second)
+	r.RecordTokens(port.TokenDirectionInput, 100)
+	r.RecordTokens(port.TokenDirectionInput, 20)
+	r.RecordTokens(port.TokenDirectionOutput, 0)
+
+	out := scrape(t, r)
+
+	for _, want := range []string{
+		`investigations_total{status="completed",severity="critical"} 2`,
+		`investigations_total{status="failed",severity="warning"} 1`,
+		`tool_executions_total{tool="bash",error="true"} 1`,
+		`tool_output_bytes_total{tool="bash"} 1024`,
+		`ai_requests_total{provider="anthropic",model="claude",code="429"} 1`,
+		`tokens_total{direction="input"} 120`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `direction="output"`) {
+		t.Errorf("zero token counts should not create a series, got:\n%s", out)
+	}
+	if strings.Contains(out, `tool_output_bytes_total{tool="read_file"}`) {
+		t.Errorf("empty tool output should not create a series, got:\n%s", out)
+	}
+}
+
+func TestRegistry_HistogramBucketsAreCumulative(t *testing.T) {
+	r := NewRegistry()
+	r.RecordToolExecution("read_file", false, 20*time.Millisecond)
+	r.RecordToolExecution("read_file", false, 2*time.Second)
+	r.RecordToolExecution("read_file", false, 2*time.Minute)
+
+	out := scrape(t, r)
+
+	for _, want := range []string{
+		`tool_duration_seconds_bucket{tool="read_file",le="0.01"} 0`,
+		`tool_duration_seconds_bucket{tool="read_file",le="0.025"} 1`,
+		`tool_duration_seconds_bucket{tool="read_file",le="2.5"} 2`,
+		`tool_duration_seconds_bucket{tool="read_file",le="60"} 2`,
+		`tool_duration_seconds_bucket{tool="read_file",le="+Inf"} 3`,
+		`tool_duration_seconds_sum{tool="read_file"} 122.02`,
+		`tool_duration_seconds_count{tool="read_file"} 3`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistry_UnlabelledHistogram(t *testing.T) {
+	r := NewRegistry()
+	r.RecordAIRequest("anthropic", "claude", "200", 500*time.Millisecond)
+
+	out := scrape(t, r)
+
+	for _, want := range []string{
+		`ai_request_duration_seconds_bucket{le="0.5"} 1`,
+		`ai_request_duration_seconds_sum 0.5`,
+		`ai_request_duration_seconds_count 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistry_GaugeFuncReadsValueAtScrape(t *testing.T) {
+	r := NewRegistry()
+	utilization := 0.25
+	r.RegisterGaugeFunc("ai_rate_limit_request_utilization", "Fraction in use.", func() float64 { return utilization })
+
+	if out := scrape(t, r); !strings.Contains(out, "# TYPE ai_rate_limit_request_utilization gauge\n"+
+		"ai_rate_limit_request_utilization 0.25\n") {
+		t.Errorf("output should contain the gauge, got:\n%s", out)
+	}
+
+	utilization = 1
+	if out := scrape(t, r); !strings.Contains(out, "ai_rate_limit_request_utilization 1\n") {
+		t.Errorf("gauge should be read at scrape time, got:\n%s", out)
+	}
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.RecordAIRequest("anthropic", "a\"b\\c\nd", "200", time.Second)
+
+	out := scrape(t, r)
+
+	want := `ai_requests_total{provider="anthropic",model="a\"b\\c\nd",code="200"} 1`
+	if !strings.Contains(out, want+"\n") {
+		t.Errorf("output should contain %q, got:\n%s", want, out)
+	}
+}
+
+func TestRegistry_SeriesAreSorted(t *testing.T) {
+	r := NewRegistry()
+	r.RecordToolExecution("read_file", false, time.Millisecond)
+	r.RecordToolExecution("bash", false, time.Millisecond)
+
+	out := scrape(t, r)
+
+	bash := strings.Index(out, `tool_executions_total{tool="bash"`)
+	readFile := strings.Index(out, `tool_executions_total{tool="read_file"`)
+	if bash < 0 || readFile < 0 || bash > readFile {
+		t.Errorf("series should be sorted by label values, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.RecordTokens(port.TokenDirectionOutput, 7)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	if !strings.Contains(rec.Body.String(), `tokens_total{direction="output"} 7`) {
+		t.Errorf("body should contain the token count, got:\n%s", rec.Body.String())
+	}
+}
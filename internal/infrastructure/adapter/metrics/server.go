@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout is the grace period for in-flight scrapes when the server stops.
+const shutdownTimeout = 5 * time.Second
+
+// Server serves a Registry's metrics at /metrics for Prometheus to scrape.
+type Server struct {
+	server *http.Server
+}
+
+// NewServer creates a Server listening on addr (e.g. ":9090") for registry's metrics.
+func NewServer(addr string, registry *Registry) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", registry.Handler())
+	return &Server{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start serves metrics until ctx is cancelled, then shuts the server down.
+// It returns an error if the server cannot listen on its address.
+func (s *Server) Start(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return s.server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
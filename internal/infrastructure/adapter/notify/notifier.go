@@ -0,0 +1,487 @@
+// Package notify pushes finished investigation results, alert sources whose
+// circuit opened, and investigation digests to external systems as signed
+// JSON webhooks.
+package notify
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed with
+// the shared secret and prefixed with "sha256=".
+const SignatureHeader = "X-Agent-Signature-256"
+
+// EventInvestigationFinished is the event name of result notifications.
+const EventInvestigationFinished = "investigation.finished"
+
+// EventAlertCircuitOpened is the event name of notifications that an alert
+// source's circuit opened and its alerts are being deferred.
+const EventAlertCircuitOpened = "alert_source.circuit_opened"
+
+// EventInvestigationDigest is the event name of investigation digests.
+const EventInvestigationDigest = "investigation.digest"
+
+// Defaults for unset Config fields.
+const (
+	DefaultMaxAttempts    = 5
+	DefaultQueueSize      = 100
+	DefaultInitialBackoff = time.Second
+	DefaultRequestTimeout = 10 * time.Second
+)
+
+// Config configures a Notifier.
+type Config struct {
+	URLs           []string      // Endpoints every result is posted to
+	Secret         string        // Shared HMAC secret; empty sends unsigned requests
+	MaxAttempts    int           // Attempts per endpoint, including the first (default 5)
+	QueueSize      int           // Results waiting for delivery before new ones are dropped (default 100)
+	InitialBackoff time.Duration // Delay before the first retry, doubled for each later one (default 1s)
+	RequestTimeout time.Duration // Timeout of each request (default 10s)
+}
+
+// DeliveryRecorder records delivery attempts for an investigation.
+// FileInvestigationStore implements it.
+type DeliveryRecorder interface {
+	RecordDelivery(ctx context.Context, investigationID string, attempt service.DeliveryAttempt) error
+}
+
+// Payload is the JSON body posted for a finished investigation.
+type Payload struct {
+	Event           string       `json:"event"`
+	InvestigationID string       `json:"investigation_id"`
+	Title           string       `json:"title,omitempty"` // Alert rendered with the title template, if known
+	Alert           AlertSummary `json:"alert"`
+	Status          string       `json:"status"`
+	Findings        []string     `json:"findings"`
+	// FindingsBySeverity groups the findings by severity, most severe first.
+	FindingsBySeverity []FindingGroup `json:"findings_by_severity"`
+	Confidence         float64        `json:"confidence"`
+	// ConfidenceDerived is set when the AI reported no confidence and it was
+	// estimated from how many tool calls succeeded.
+	ConfidenceDerived bool     `json:"confidence_derived,omitempty"`
+	Escalated         bool     `json:"escalated"`
+	EscalateReason    string   `json:"escalate_reason,omitempty"`
+	Error             string   `json:"error,omitempty"`
+	Timeline          Timeline `json:"timeline"`
+
+	RootCause          string                     `json:"root_cause,omitempty"`
+	RecommendedActions []entity.RecommendedAction `json:"recommended_actions,omitempty"`
+	// Remediations are the recommended commands the investigation ran, or was
+	// refused, with auto-remediation enabled.
+	Remediations []entity.RemediationAttempt `json:"remediations,omitempty"`
+}
+
+// FindingGroup lists the findings of one severity: critical, warning, or info.
+type FindingGroup struct {
+	Severity string           `json:"severity"`
+	Findings []FindingSummary `json:"findings"`
+}
+
+// FindingSummary is one deduplicated finding, without its severity tag.
+type FindingSummary struct {
+	Text        string `json:"text"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// AlertSummary identifies the investigated alert.
+type AlertSummary struct {
+	ID       string `json:"id"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+}
+
+// Timeline summarizes when and how long the investigation ran.
+type Timeline struct {
+	StartedAt       time.Time `json:"started_at"`
+	CompletedAt     time.Time `json:"completed_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ActionsTaken    int       `json:"actions_taken"`
+}
+
+// CircuitPayload is the JSON body posted when an alert source's circuit opens.
+type CircuitPayload struct {
+	Event         string    `json:"event"`
+	Source        string    `json:"source"`
+	OpenedAt      time.Time `json:"opened_at"`
+	ProbeAt       time.Time `json:"probe_at"`
+	Threshold     int       `json:"threshold"`
+	WindowSeconds float64   `json:"window_seconds"`
+}
+
+// DigestPayload is the JSON body posted for an investigation digest: its
+// statistics and its Markdown rendering.
+type DigestPayload struct {
+	Event    string          `json:"event"`
+	Digest   *usecase.Digest `json:"digest"`
+	Markdown string          `json:"markdown"`
+}
+
+// job is a notification waiting in the queue. Notifications about no
+// investigation have an empty investigationID and are not recorded.
+type job struct {
+	investigationID string
+	body            []byte
+}
+
+// Notifier posts investigation results to the configured URLs from a
+// background worker, so notifying never blocks the investigation. Results
+// arriving while the queue is full are dropped and logged. It implements
+// usecase.InvestigationResultNotifier, usecase.AlertCircuitNotifier, and
+// usecase.DigestNotifier and is safe for concurrent use.
+type Notifier struct {
+	config   Config
+	client   *http.Client
+	recorder DeliveryRecorder
+	logger   *slog.Logger
+	title    *usecase.AlertTemplate
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+
+	mu     sync.Mutex // Guards recorder, logger, title, closed, and sends on queue
+	closed bool
+	queue  chan job
+	done   chan struct{}   // Closed when the worker exits
+	ctx    context.Context // Cancelled when Close gives up, abandoning requests and retries
+	stop   context.CancelFunc
+}
+
+// Compile-time checks that Notifier implements the usecase notifier interfaces.
+var (
+	_ usecase.InvestigationResultNotifier = (*Notifier)(nil)
+	_ usecase.AlertCircuitNotifier        = (*Notifier)(nil)
+	_ usecase.DigestNotifier              = (*Notifier)(nil)
+)
+
+// NewNotifier creates a Notifier and starts its delivery worker.
+// Call Close to stop it.
+func NewNotifier(config Config) *Notifier {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultInitialBackoff
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = DefaultRequestTimeout
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	n := &Notifier{
+		config: config,
+		client: &http.Client{Timeout: config.RequestTimeout},
+		logger: slog.Default(),
+		now:    time.Now,
+		sleep:  sleepContext,
+		queue:  make(chan job, config.QueueSize),
+		done:   make(chan struct{}),
+		stop:   stop,
+		ctx:    ctx,
+	}
+	go n.run()
+	return n
+}
+
+// SetRecorder sets where delivery attempts are recorded. Without a recorder
+// they are only logged.
+func (n *Notifier) SetRecorder(recorder DeliveryRecorder) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.recorder = recorder
+}
+
+// SetLogger sets the logger for delivery failures. A nil logger restores slog.Default().
+func (n *Notifier) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.logger = logger
+}
+
+// SetTitleTemplate sets the template of each result's title. A nil template
+// restores usecase.DefaultTitleTemplate.
+func (n *Notifier) SetTitleTemplate(tmpl *usecase.AlertTemplate) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.title = tmpl
+}
+
+// NotifyInvestigationResult queues the result for delivery and returns
+// immediately. If the queue is full or the notifier is closed, the result is
+// dropped, logged, and recorded as DeliveryDropped for each URL.
+func (n *Notifier) NotifyInvestigationResult(alert *usecase.AlertForInvestigation, result *usecase.InvestigationResult) {
+	if result == nil {
+		return
+	}
+	body, err := json.Marshal(n.payload(alert, result))
+	if err != nil {
+		n.log().Error("Failed to encode investigation result notification",
+			"investigation_id", result.InvestigationID, "error", err)
+		return
+	}
+
+	if !n.enqueue(job{investigationID: result.InvestigationID, body: body}) {
+		n.log().Warn("Dropped investigation result notification; queue full or closed",
+			"investigation_id", result.InvestigationID, "queue_size", n.config.QueueSize)
+		for _, url := range n.config.URLs {
+			n.record(result.InvestigationID, service.DeliveryAttempt{URL: url, Outcome: service.DeliveryDropped})
+		}
+	}
+}
+
+// NotifyCircuitOpened queues a notification that an alert source's circuit
+// opened and returns immediately. If the queue is full or the notifier is
+// closed, the notification is dropped and logged.
+func (n *Notifier) NotifyCircuitOpened(event usecase.AlertCircuitEvent) {
+	body, err := json.Marshal(CircuitPayload{
+		Event:         EventAlertCircuitOpened,
+		Source:        event.Source,
+		OpenedAt:      event.OpenedAt.UTC(),
+		ProbeAt:       event.ProbeAt.UTC(),
+		Threshold:     event.Threshold,
+		WindowSeconds: event.Window.Seconds(),
+	})
+	if err != nil {
+		n.log().Error("Failed to encode alert circuit notification", "source", event.Source, "error", err)
+		return
+	}
+	if !n.enqueue(job{body: body}) {
+		n.log().Warn("Dropped alert circuit notification; queue full or closed",
+			"source", event.Source, "queue_size", n.config.QueueSize)
+	}
+}
+
+// NotifyDigest queues an investigation digest for delivery and returns
+// immediately. If the queue is full or the notifier is closed, the digest is
+// dropped and logged.
+func (n *Notifier) NotifyDigest(digest *usecase.Digest, markdown string) {
+	if digest == nil {
+		return
+	}
+	body, err := json.Marshal(DigestPayload{Event: EventInvestigationDigest, Digest: digest, Markdown: markdown})
+	if err != nil {
+		n.log().Error("Failed to encode investigation digest", "error", err)
+		return
+	}
+	if !n.enqueue(job{body: body}) {
+		n.log().Warn("Dropped investigation digest; queue full or closed", "queue_size", n.config.QueueSize)
+	}
+}
+
+// enqueue queues a job for delivery, reporting false if the queue is full or
+// the notifier is closed.
+func (n *Notifier) enqueue(j job) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return false
+	}
+	select {
+	case n.queue <- j:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting results and waits for queued ones to be delivered.
+// If ctx ends first, remaining deliveries are abandoned and ctx.Err() is returned.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		n.stop()
+		<-n.done
+		return fmt.Errorf("abandoned pending result notifications: %w", ctx.Err())
+	}
+}
+
+// run delivers queued results until the queue is closed and drained.
+func (n *Notifier) run() {
+	defer close(n.done)
+	for j := range n.queue {
+		for _, url := range n.config.URLs {
+			n.deliver(j, url)
+		}
+	}
+}
+
+// deliver posts a result to url, retrying with exponential backoff on network
+// errors and 5xx responses. Each attempt is recorded.
+func (n *Notifier) deliver(j job, url string) {
+	backoff := n.config.InitialBackoff
+	for attempt := 1; attempt <= n.config.MaxAttempts; attempt++ {
+		statusCode, err := n.post(url, j.body)
+		record := service.DeliveryAttempt{URL: url, Attempt: attempt, At: n.now(), StatusCode: statusCode}
+		retryable := err != nil || statusCode >= http.StatusInternalServerError
+		switch {
+		case err == nil && statusCode < http.StatusMultipleChoices:
+			record.Outcome = service.DeliveryDelivered
+		case retryable && attempt < n.config.MaxAttempts && n.ctx.Err() == nil:
+			record.Outcome = service.DeliveryRetrying
+		default:
+			record.Outcome = service.DeliveryFailed
+		}
+		if err != nil {
+			record.Error = err.Error()
+		} else if record.Outcome != service.DeliveryDelivered {
+			record.Error = http.StatusText(statusCode)
+		}
+		n.record(j.investigationID, record)
+
+		if record.Outcome != service.DeliveryRetrying {
+			if record.Outcome == service.DeliveryFailed {
+				n.log().Warn("Failed to deliver investigation result notification",
+					"investigation_id", j.investigationID, "url", url, "attempts", attempt,
+					"status_code", statusCode, "error", record.Error)
+			}
+			return
+		}
+		if err := n.sleep(n.ctx, backoff); err != nil {
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one signed request and returns the response status code.
+func (n *Notifier) post(url string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.config.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// record stores a delivery attempt, logging if the store rejects it.
+func (n *Notifier) record(investigationID string, attempt service.DeliveryAttempt) {
+	if attempt.At.IsZero() {
+		attempt.At = n.now()
+	}
+	n.mu.Lock()
+	recorder := n.recorder
+	n.mu.Unlock()
+	if recorder == nil || investigationID == "" {
+		return
+	}
+	if err := recorder.RecordDelivery(context.Background(), investigationID, attempt); err != nil {
+		n.log().Error("Failed to record result notification delivery",
+			"investigation_id", investigationID, "url", attempt.URL, "error", err)
+	}
+}
+
+// log returns the current logger.
+func (n *Notifier) log() *slog.Logger {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.logger
+}
+
+// payload builds the notification body for a result.
+func (n *Notifier) payload(alert *usecase.AlertForInvestigation, result *usecase.InvestigationResult) Payload {
+	completedAt := n.now().UTC()
+	p := Payload{
+		Event:              EventInvestigationFinished,
+		InvestigationID:    result.InvestigationID,
+		Alert:              AlertSummary{ID: result.AlertID},
+		Status:             result.Status,
+		Findings:           result.Findings,
+		Confidence:         result.Confidence,
+		ConfidenceDerived:  result.ConfidenceDerived,
+		Escalated:          result.Escalated,
+		EscalateReason:     result.EscalateReason,
+		RootCause:          result.RootCause,
+		RecommendedActions: result.RecommendedActions,
+		Remediations:       result.Remediations,
+		Timeline: Timeline{
+			StartedAt:       completedAt.Add(-result.Duration),
+			CompletedAt:     completedAt,
+			DurationSeconds: result.Duration.Seconds(),
+			ActionsTaken:    result.ActionsTaken,
+		},
+	}
+	if p.Findings == nil {
+		p.Findings = []string{}
+	}
+	p.FindingsBySeverity = []FindingGroup{}
+	for _, group := range usecase.GroupFindings(result.Findings) {
+		g := FindingGroup{Severity: group.Severity}
+		for _, finding := range group.Findings {
+			g.Findings = append(g.Findings, FindingSummary{Text: finding.Text, Occurrences: finding.Occurrences})
+		}
+		p.FindingsBySeverity = append(p.FindingsBySeverity, g)
+	}
+	if result.Error != nil {
+		p.Error = result.Error.Error()
+	}
+	if alert != nil {
+		p.Alert = AlertSummary{ID: alert.ID(), Source: alert.Source(), Severity: alert.Severity(), Title: alert.Title()}
+		n.mu.Lock()
+		title := n.title
+		n.mu.Unlock()
+		p.Title = usecase.RenderTitle(title, usecase.NewAlertViewFromInvestigation(alert))
+	}
+	return p
+}
+
+// Sign returns the SignatureHeader value for body: "sha256=" followed by the
+// hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the SignatureHeader value for
+// body under secret, comparing in constant time.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
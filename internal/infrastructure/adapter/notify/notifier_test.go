@@ -0,0 +1,329 @@
+package notify
+
+import (
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryRecorder collects delivery attempts.
+type memoryRecorder struct {
+	mu       sync.Mutex
+	attempts map[string][]service.DeliveryAttempt
+}
+
+func newMemoryRecorder() *memoryRecorder {
+	return &memoryRecorder{attempts: make(map[string][]service.DeliveryAttempt)}
+}
+
+func (r *memoryRecorder) RecordDelivery(_ context.Context, id string, attempt service.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[id] = append(r.attempts[id], attempt)
+	return nil
+}
+
+func (r *memoryRecorder) get(id string) []service.DeliveryAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts[id]
+}
+
+// newTestNotifier creates a Notifier that records its backoffs instead of sleeping.
+func newTestNotifier(config Config) (*Notifier, *memoryRecorder, *[]time.Duration) {
+	n := NewNotifier(config)
+	recorder := newMemoryRecorder()
+	n.SetRecorder(recorder)
+	var mu sync.Mutex
+	backoffs := &[]time.Duration{}
+	n.sleep = func(_ context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*backoffs = append(*backoffs, d)
+		return nil
+	}
+	return n, recorder, backoffs
+}
+
+func testResult(id string) *usecase.InvestigationResult {
+	return &usecase.InvestigationResult{
+		InvestigationID: id,
+		AlertID:         "alert-1",
+		Status:          "completed",
+		Findings:        []string{"disk full on /var"},
+		ActionsTaken:    3,
+		Duration:        90 * time.Second,
+		Confidence:      0.9,
+		RootCause:       "logs were never rotated",
+		RecommendedActions: []entity.RecommendedAction{
+			{Description: "Force a log rotation", Command: "logrotate -f /etc/logrotate.conf", Risk: entity.RiskLow},
+		},
+	}
+}
+
+func TestNotifier_SignsAndRetriesUntilSuccess(t *testing.T) {
+	const secret = "s3cret"
+	var mu sync.Mutex
+	var bodies [][]byte
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		signatures = append(signatures, r.Header.Get(SignatureHeader))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n, recorder, backoffs := newTestNotifier(Config{URLs: []string{server.URL}, Secret: secret, MaxAttempts: 5})
+	entityAlert, err := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert := usecase.NewAlertForInvestigationFromEntity(entityAlert)
+	title, err := usecase.ParseAlertTemplate("title", "[{{.Severity}}] {{.Title}}{{.Labels.instance}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.SetTitleTemplate(title)
+	n.NotifyInvestigationResult(alert, testResult("inv-1"))
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("server received %d requests, want 3", len(bodies))
+	}
+	for i, body := range bodies {
+		if !VerifySignature(secret, body, signatures[i]) {
+			t.Errorf("request %d has an invalid signature %q", i, signatures[i])
+		}
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(bodies[0], &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Event != EventInvestigationFinished || payload.InvestigationID != "inv-1" ||
+		payload.Status != "completed" || payload.Confidence != 0.9 ||
+		len(payload.Findings) != 1 || payload.Alert.Severity != "critical" ||
+		payload.Timeline.ActionsTaken != 3 || payload.Timeline.DurationSeconds != 90 {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if payload.Title != "[critical] Disk full" {
+		t.Errorf("Title = %q, want the alert rendered with the title template", payload.Title)
+	}
+	if groups := payload.FindingsBySeverity; len(groups) != 1 || groups[0].Severity != "critical" ||
+		len(groups[0].Findings) != 1 || groups[0].Findings[0] != (FindingSummary{Text: "disk full on /var", Occurrences: 1}) {
+		t.Errorf("FindingsBySeverity = %+v, want the finding as critical", groups)
+	}
+	if payload.RootCause != "logs were never rotated" || len(payload.RecommendedActions) != 1 ||
+		payload.RecommendedActions[0].Command != "logrotate -f /etc/logrotate.conf" ||
+		payload.RecommendedActions[0].Risk != entity.RiskLow {
+		t.Errorf("RootCause = %q, RecommendedActions = %+v, want the result's resolution",
+			payload.RootCause, payload.RecommendedActions)
+	}
+
+	if want := []time.Duration{time.Second, 2 * time.Second}; len(*backoffs) != 2 ||
+		(*backoffs)[0] != want[0] || (*backoffs)[1] != want[1] {
+		t.Errorf("backoffs = %v, want %v", *backoffs, want)
+	}
+
+	attempts := recorder.get("inv-1")
+	wantOutcomes := []string{service.DeliveryRetrying, service.DeliveryRetrying, service.DeliveryDelivered}
+	if len(attempts) != len(wantOutcomes) {
+		t.Fatalf("recorded %d attempts, want %d: %+v", len(attempts), len(wantOutcomes), attempts)
+	}
+	for i, attempt := range attempts {
+		if attempt.Attempt != i+1 || attempt.Outcome != wantOutcomes[i] || attempt.URL != server.URL {
+			t.Errorf("attempt %d = %+v, want attempt %d with outcome %s", i, attempt, i+1, wantOutcomes[i])
+		}
+	}
+	if attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[2].StatusCode != http.StatusNoContent {
+		t.Errorf("status codes = %d, %d, want 503, 204", attempts[0].StatusCode, attempts[2].StatusCode)
+	}
+}
+
+func TestNotifier_GivesUpAfterMaxAttemptsAndOn4xx(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/bad-request" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n, recorder, _ := newTestNotifier(Config{
+		URLs:        []string{server.URL + "/down", server.URL + "/bad-request"},
+		MaxAttempts: 3,
+	})
+	n.NotifyInvestigationResult(nil, testResult("inv-1"))
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if requests["/down"] != 3 || requests["/bad-request"] != 1 {
+		t.Errorf("requests = %v, want 3 to /down and 1 to /bad-request", requests)
+	}
+	attempts := recorder.get("inv-1")
+	if len(attempts) != 4 {
+		t.Fatalf("recorded %d attempts, want 4: %+v", len(attempts), attempts)
+	}
+	if attempts[2].Outcome != service.DeliveryFailed || attempts[3].Outcome != service.DeliveryFailed {
+		t.Errorf("final attempts = %+v, %+v, want both failed", attempts[2], attempts[3])
+	}
+}
+
+func TestNotifier_DropsWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	n, recorder, _ := newTestNotifier(Config{URLs: []string{server.URL}, QueueSize: 1})
+
+	// The worker takes the first result and blocks on the server; the second
+	// fills the queue, so the third is dropped without blocking
+	n.NotifyInvestigationResult(nil, testResult("inv-1"))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(n.queue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	n.NotifyInvestigationResult(nil, testResult("inv-2"))
+
+	start := time.Now()
+	n.NotifyInvestigationResult(nil, testResult("inv-3"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("NotifyInvestigationResult blocked for %v", elapsed)
+	}
+
+	dropped := recorder.get("inv-3")
+	if len(dropped) != 1 || dropped[0].Outcome != service.DeliveryDropped {
+		t.Errorf("inv-3 attempts = %+v, want one dropped", dropped)
+	}
+
+	close(release)
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := recorder.get("inv-2"); len(got) != 1 || got[0].Outcome != service.DeliveryDelivered {
+		t.Errorf("inv-2 attempts = %+v, want one delivered", got)
+	}
+}
+
+func TestNotifier_NotifyCircuitOpened(t *testing.T) {
+	const secret = "s3cret"
+	var mu sync.Mutex
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if VerifySignature(secret, body, r.Header.Get(SignatureHeader)) {
+			bodies = append(bodies, body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n, recorder, _ := newTestNotifier(Config{URLs: []string{server.URL}, Secret: secret})
+	openedAt := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+	n.NotifyCircuitOpened(usecase.AlertCircuitEvent{
+		Source: "prometheus", OpenedAt: openedAt, ProbeAt: openedAt.Add(15 * time.Minute),
+		Threshold: 20, Window: 10 * time.Minute,
+	})
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("server received %d signed requests, want 1", len(bodies))
+	}
+	var payload CircuitPayload
+	if err := json.Unmarshal(bodies[0], &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Event != EventAlertCircuitOpened || payload.Source != "prometheus" || payload.Threshold != 20 ||
+		payload.WindowSeconds != 600 || !payload.ProbeAt.Equal(openedAt.Add(15*time.Minute)) {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if attempts := recorder.get(""); len(attempts) != 0 {
+		t.Errorf("recorded %d delivery attempts, want none for a notification about no investigation", len(attempts))
+	}
+}
+
+func TestNotifier_NotifyDigest(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n, recorder, _ := newTestNotifier(Config{URLs: []string{server.URL}})
+	until := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	n.NotifyDigest(&usecase.Digest{
+		Since: until.Add(-24 * time.Hour), Until: until, Total: 4, Escalated: 1, EscalationRate: 0.25,
+		Usage: entity.TokenUsage{InputTokens: 1200, OutputTokens: 300},
+	}, "# Investigation Digest")
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(bodies))
+	}
+	var payload DigestPayload
+	if err := json.Unmarshal(bodies[0], &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Event != EventInvestigationDigest || payload.Markdown != "# Investigation Digest" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if d := payload.Digest; d == nil || d.Total != 4 || d.Escalated != 1 || !d.Until.Equal(until) ||
+		d.Usage.InputTokens != 1200 {
+		t.Errorf("payload digest = %+v, want the notified digest", payload.Digest)
+	}
+	if attempts := recorder.get(""); len(attempts) != 0 {
+		t.Errorf("recorded %d delivery attempts, want none for a digest", len(attempts))
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"event":"investigation.finished"}`)
+	signature := Sign("secret", body)
+
+	if !VerifySignature("secret", body, signature) {
+		t.Error("VerifySignature should accept its own signature")
+	}
+	if VerifySignature("other", body, signature) {
+		t.Error("VerifySignature should reject a different secret")
+	}
+	if VerifySignature("secret", []byte(`{}`), signature) {
+		t.Error("VerifySignature should reject a different body")
+	}
+}
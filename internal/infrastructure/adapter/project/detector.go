@@ -0,0 +1,411 @@
+// Package project detects what kind of project the workspace holds: its
+// languages, module names, and build, test, and lint commands, read from the
+// manifests at the workspace root (go.mod, package.json, pyproject.toml),
+// its Makefile, Dockerfile, and CI configuration. Detection only stats and
+// reads files at known paths, never walking the tree, and is cached until one
+// of those files changes.
+package project
+
+import (
+	"bufio"
+	"code-editing-agent/internal/application/usecase"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stack is one language's view of the project, from its manifest.
+type Stack struct {
+	Language     string   // "Go", "Node.js", or "Python"
+	Manifest     string   // The manifest it was read from, e.g. "go.mod"
+	Module       string   // Module or package name, if the manifest names one
+	BuildCommand string   // Empty if there is nothing to build
+	TestCommand  string   // Empty if no test runner was found
+	LintCommand  string   // Empty if no linter was found
+	EntryPoints  []string // Paths relative to the root, e.g. "cmd/cli"
+	Scripts      []string // package.json scripts, sorted
+}
+
+// Info is what the detector found at the workspace root.
+type Info struct {
+	Root        string
+	Stacks      []Stack  // In the order Go, Node.js, Python
+	MakeTargets []string // Targets of the root Makefile, in file order
+	Containers  []string // Dockerfile and Compose files
+	CI          []string // CI configuration files, e.g. ".github/workflows/ci.yml"
+}
+
+// Empty reports whether no project files were found.
+func (i Info) Empty() bool {
+	return len(i.Stacks) == 0 && len(i.MakeTargets) == 0 && len(i.Containers) == 0 && len(i.CI) == 0
+}
+
+// Facts returns a compact Markdown list of the project's languages and
+// commands for the system prompt, or an empty string if nothing was found.
+func (i Info) Facts() string {
+	if i.Empty() {
+		return ""
+	}
+	var b strings.Builder
+	for _, stack := range i.Stacks {
+		b.WriteString("- Language: " + stack.Language)
+		if stack.Module != "" {
+			fmt.Fprintf(&b, " (%s %s)", strings.ToLower(moduleLabel(stack.Language)), stack.Module)
+		}
+		b.WriteString("\n")
+		writeFact(&b, "Build", stack.BuildCommand)
+		writeFact(&b, "Test", stack.TestCommand)
+		writeFact(&b, "Lint", stack.LintCommand)
+		writeFact(&b, "Entry points", strings.Join(stack.EntryPoints, ", "))
+	}
+	writeFact(&b, "Make targets", strings.Join(i.MakeTargets, ", "))
+	writeFact(&b, "Containers", strings.Join(i.Containers, ", "))
+	writeFact(&b, "CI", strings.Join(i.CI, ", "))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Detail returns everything the detector found, including the manifests and
+// package.json scripts, for the project_info tool.
+func (i Info) Detail() string {
+	if i.Empty() {
+		return "No project files (go.mod, package.json, pyproject.toml, Makefile, Dockerfile, CI config) found in " +
+			i.Root
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project root: %s\n", i.Root)
+	for _, stack := range i.Stacks {
+		fmt.Fprintf(&b, "\n%s (%s)\n", stack.Language, stack.Manifest)
+		writeFact(&b, moduleLabel(stack.Language), stack.Module)
+		writeFact(&b, "Build", stack.BuildCommand)
+		writeFact(&b, "Test", stack.TestCommand)
+		writeFact(&b, "Lint", stack.LintCommand)
+		writeFact(&b, "Entry points", strings.Join(stack.EntryPoints, ", "))
+		writeFact(&b, "Scripts", strings.Join(stack.Scripts, ", "))
+	}
+	if len(i.MakeTargets) > 0 || len(i.Containers) > 0 || len(i.CI) > 0 {
+		b.WriteString("\nTooling\n")
+		writeFact(&b, "Make targets", strings.Join(i.MakeTargets, ", "))
+		writeFact(&b, "Containers", strings.Join(i.Containers, ", "))
+		writeFact(&b, "CI", strings.Join(i.CI, ", "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeFact writes "- label: value" on its own line, unless value is empty.
+func writeFact(b *strings.Builder, label, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "- %s: %s\n", label, value)
+	}
+}
+
+// moduleLabel is what the language calls the name in its manifest.
+func moduleLabel(language string) string {
+	if language == "Go" {
+		return "Module"
+	}
+	return "Package"
+}
+
+// watchedPaths are the files and directories, relative to the root, whose
+// changes invalidate the cached Info. Directories are watched through their
+// modification time, which changes when entries are added or removed.
+var watchedPaths = []string{
+	"go.mod", ".golangci.yml", ".golangci.yaml", "main.go", "cmd",
+	"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+	"pyproject.toml", "setup.py", "requirements.txt", "poetry.lock", "uv.lock", ".flake8",
+	"Makefile", "Dockerfile", "docker-compose.yml", "docker-compose.yaml", "compose.yaml", "compose.yml",
+	".github/workflows", ".gitlab-ci.yml", ".circleci/config.yml", "Jenkinsfile",
+}
+
+// stamp is what Detect compares to notice a changed file.
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Detector detects the project in a workspace root and caches the result
+// until a watched file changes. It is safe for concurrent use.
+type Detector struct {
+	root string
+
+	mu     sync.Mutex
+	info   Info
+	stamps map[string]stamp // nil until the first Detect
+}
+
+// NewDetector creates a detector for the workspace at root.
+func NewDetector(root string) *Detector {
+	return &Detector{root: root}
+}
+
+// Detect returns the project at the root, detecting it again only if a
+// watched file was added, removed, or modified since the last call.
+func (d *Detector) Detect() Info {
+	stamps := make(map[string]stamp, len(watchedPaths))
+	for _, path := range watchedPaths {
+		if fi, err := os.Stat(filepath.Join(d.root, path)); err == nil {
+			stamps[path] = stamp{size: fi.Size(), modTime: fi.ModTime()}
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stamps == nil || !maps.Equal(d.stamps, stamps) {
+		d.info = d.detect()
+		d.stamps = stamps
+	}
+	return d.info
+}
+
+// PromptLayer implements usecase.PromptLayerSource with the project facts.
+func (d *Detector) PromptLayer() usecase.PromptLayer {
+	return usecase.ProjectPromptLayer(d.Detect().Facts())
+}
+
+// ProjectInfo implements tool.ProjectInfoProvider with the detailed report.
+func (d *Detector) ProjectInfo() string {
+	return d.Detect().Detail()
+}
+
+// detect reads the manifests at the root.
+func (d *Detector) detect() Info {
+	info := Info{Root: d.root}
+	for _, detectStack := range []func() (Stack, bool){d.goStack, d.nodeStack, d.pythonStack} {
+		if stack, ok := detectStack(); ok {
+			info.Stacks = append(info.Stacks, stack)
+		}
+	}
+	info.MakeTargets = d.makeTargets()
+	for _, name := range []string{
+		"Dockerfile", "docker-compose.yml", "docker-compose.yaml", "compose.yaml", "compose.yml",
+	} {
+		if d.isFile(name) {
+			info.Containers = append(info.Containers, name)
+		}
+	}
+	info.CI = d.ciConfigs()
+
+	// Make targets are what the project runs, so they win over the defaults
+	for i := range info.Stacks {
+		info.Stacks[i].BuildCommand = preferMake(info.MakeTargets, "build", info.Stacks[i].BuildCommand)
+		info.Stacks[i].TestCommand = preferMake(info.MakeTargets, "test", info.Stacks[i].TestCommand)
+		info.Stacks[i].LintCommand = preferMake(info.MakeTargets, "lint", info.Stacks[i].LintCommand)
+	}
+	return info
+}
+
+// preferMake returns "make target" when the Makefile has target, else command.
+func preferMake(targets []string, target, command string) string {
+	if slices.Contains(targets, target) {
+		return "make " + target
+	}
+	return command
+}
+
+// goStack reads go.mod.
+func (d *Detector) goStack() (Stack, bool) {
+	data, err := os.ReadFile(filepath.Join(d.root, "go.mod"))
+	if err != nil {
+		return Stack{}, false
+	}
+	stack := Stack{
+		Language:     "Go",
+		Manifest:     "go.mod",
+		BuildCommand: "go build ./...",
+		TestCommand:  "go test ./...",
+		LintCommand:  "go vet ./...",
+	}
+	for line := range strings.Lines(string(data)) {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			stack.Module = strings.Trim(strings.TrimSpace(module), `"`)
+			break
+		}
+	}
+	if d.isFile(".golangci.yml") || d.isFile(".golangci.yaml") {
+		stack.LintCommand = "golangci-lint run"
+	}
+	if d.isFile("main.go") {
+		stack.EntryPoints = append(stack.EntryPoints, ".")
+	}
+	if entries, err := os.ReadDir(filepath.Join(d.root, "cmd")); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && d.isFile(filepath.Join("cmd", entry.Name(), "main.go")) {
+				stack.EntryPoints = append(stack.EntryPoints, "cmd/"+entry.Name())
+			}
+		}
+	}
+	return stack, true
+}
+
+// nodeStack reads package.json; the lock file picks the package manager.
+func (d *Detector) nodeStack() (Stack, bool) {
+	data, err := os.ReadFile(filepath.Join(d.root, "package.json"))
+	if err != nil {
+		return Stack{}, false
+	}
+	var manifest struct {
+		Name    string            `json:"name"`
+		Main    string            `json:"main"`
+		Bin     json.RawMessage   `json:"bin"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	stack := Stack{Language: "Node.js", Manifest: "package.json"}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return stack, true
+	}
+	stack.Module = manifest.Name
+
+	manager := "npm"
+	switch {
+	case d.isFile("pnpm-lock.yaml"):
+		manager = "pnpm"
+	case d.isFile("yarn.lock"):
+		manager = "yarn"
+	}
+	run := func(script string) string {
+		if _, ok := manifest.Scripts[script]; !ok {
+			return ""
+		}
+		if script == "test" || manager == "yarn" {
+			return manager + " " + script
+		}
+		return manager + " run " + script
+	}
+	stack.BuildCommand, stack.TestCommand, stack.LintCommand = run("build"), run("test"), run("lint")
+	for script := range manifest.Scripts {
+		stack.Scripts = append(stack.Scripts, script)
+	}
+	slices.Sort(stack.Scripts)
+
+	if manifest.Main != "" {
+		stack.EntryPoints = append(stack.EntryPoints, manifest.Main)
+	}
+	var bin string
+	var bins map[string]string
+	if json.Unmarshal(manifest.Bin, &bin) == nil && bin != "" {
+		stack.EntryPoints = append(stack.EntryPoints, bin)
+	} else if json.Unmarshal(manifest.Bin, &bins) == nil {
+		for _, name := range slices.Sorted(maps.Keys(bins)) {
+			stack.EntryPoints = append(stack.EntryPoints, bins[name])
+		}
+	}
+	return stack, true
+}
+
+// pythonStack reads pyproject.toml, or notes setup.py or requirements.txt.
+// pyproject.toml is scanned line by line for the few keys used, not parsed
+// as TOML.
+func (d *Detector) pythonStack() (Stack, bool) {
+	stack := Stack{Language: "Python"}
+	for _, name := range []string{"pyproject.toml", "setup.py", "requirements.txt"} {
+		if d.isFile(name) {
+			stack.Manifest = name
+			break
+		}
+	}
+	if stack.Manifest == "" {
+		return Stack{}, false
+	}
+
+	runner := ""
+	switch {
+	case d.isFile("uv.lock"):
+		runner = "uv run "
+	case d.isFile("poetry.lock"):
+		runner = "poetry run "
+	}
+	stack.TestCommand = runner + "pytest"
+	if d.isFile(".flake8") {
+		stack.LintCommand = runner + "flake8"
+	}
+
+	file, err := os.Open(filepath.Join(d.root, "pyproject.toml"))
+	if err != nil {
+		return stack, true
+	}
+	defer file.Close()
+	var section string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			if section == "tool.ruff" || strings.HasPrefix(section, "tool.ruff.") {
+				stack.LintCommand = runner + "ruff check ."
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `"'`)
+		switch section {
+		case "project", "tool.poetry":
+			if key == "name" && stack.Module == "" {
+				stack.Module = value
+			}
+		case "project.scripts", "tool.poetry.scripts":
+			stack.EntryPoints = append(stack.EntryPoints, value)
+		}
+	}
+	return stack, true
+}
+
+// makeTargets returns the targets defined in the root Makefile, skipping
+// special targets such as .PHONY and pattern rules.
+func (d *Detector) makeTargets() []string {
+	file, err := os.Open(filepath.Join(d.root, "Makefile"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var targets []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '\t' || line[0] == '#' || line[0] == ' ' {
+			continue
+		}
+		names, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(rest, "=") || strings.ContainsAny(names, "=$%.") {
+			continue
+		}
+		for name := range strings.FieldsSeq(names) {
+			if !slices.Contains(targets, name) {
+				targets = append(targets, name)
+			}
+		}
+	}
+	return targets
+}
+
+// ciConfigs returns the CI configuration files found.
+func (d *Detector) ciConfigs() []string {
+	var configs []string
+	if entries, err := os.ReadDir(filepath.Join(d.root, ".github", "workflows")); err == nil {
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+				configs = append(configs, ".github/workflows/"+entry.Name())
+			}
+		}
+	}
+	for _, name := range []string{".gitlab-ci.yml", ".circleci/config.yml", "Jenkinsfile"} {
+		if d.isFile(name) {
+			configs = append(configs, name)
+		}
+	}
+	return configs
+}
+
+// isFile reports whether the path relative to the root is a regular file.
+func (d *Detector) isFile(name string) bool {
+	fi, err := os.Stat(filepath.Join(d.root, name))
+	return err == nil && fi.Mode().IsRegular()
+}
@@ -0,0 +1,138 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetector_Fixtures(t *testing.T) {
+	tests := []struct {
+		name string
+		want Info
+	}{
+		{
+			name: "go",
+			want: Info{
+				Stacks: []Stack{{
+					Language:     "Go",
+					Manifest:     "go.mod",
+					Module:       "example.com/agent",
+					BuildCommand: "make build",
+					TestCommand:  "make test",
+					LintCommand:  "golangci-lint run",
+					EntryPoints:  []string{"cmd/agent", "cmd/worker"},
+				}},
+				MakeTargets: []string{"build", "test"},
+				Containers:  []string{"Dockerfile"},
+				CI:          []string{".github/workflows/ci.yml"},
+			},
+		},
+		{
+			name: "node",
+			want: Info{
+				Stacks: []Stack{{
+					Language:     "Node.js",
+					Manifest:     "package.json",
+					Module:       "web-console",
+					BuildCommand: "pnpm run build",
+					TestCommand:  "pnpm test",
+					LintCommand:  "pnpm run lint",
+					EntryPoints:  []string{"dist/index.js", "bin/console.js"},
+					Scripts:      []string{"build", "dev", "lint", "test"},
+				}},
+				CI: []string{".gitlab-ci.yml"},
+			},
+		},
+		{
+			name: "python",
+			want: Info{
+				Stacks: []Stack{{
+					Language:    "Python",
+					Manifest:    "pyproject.toml",
+					Module:      "alert-tools",
+					TestCommand: "uv run pytest",
+					LintCommand: "uv run ruff check .",
+					EntryPoints: []string{"app.cli:main"},
+				}},
+				Containers: []string{"compose.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join("testdata", tt.name)
+			tt.want.Root = root
+			if got := NewDetector(root).Detect(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfo_Facts(t *testing.T) {
+	got := NewDetector(filepath.Join("testdata", "go")).Detect().Facts()
+	want := strings.Join([]string{
+		"- Language: Go (module example.com/agent)",
+		"- Build: make build",
+		"- Test: make test",
+		"- Lint: golangci-lint run",
+		"- Entry points: cmd/agent, cmd/worker",
+		"- Make targets: build, test",
+		"- Containers: Dockerfile",
+		"- CI: .github/workflows/ci.yml",
+	}, "\n")
+	if got != want {
+		t.Errorf("Facts() = %q, want %q", got, want)
+	}
+
+	empty := NewDetector(t.TempDir()).Detect()
+	if facts := empty.Facts(); facts != "" {
+		t.Errorf("Facts() of an empty workspace = %q, want empty", facts)
+	}
+	if detail := empty.Detail(); !strings.HasPrefix(detail, "No project files") {
+		t.Errorf("Detail() of an empty workspace = %q", detail)
+	}
+}
+
+func TestDetector_InvalidatesWhenWatchedFilesChange(t *testing.T) {
+	root := t.TempDir()
+	detector := NewDetector(root)
+	if info := detector.Detect(); !info.Empty() {
+		t.Fatalf("Detect() of an empty workspace = %+v", info)
+	}
+
+	writeFixture(t, filepath.Join(root, "go.mod"), "module example.com/one\n")
+	if got := detector.Detect().Stacks; len(got) != 1 || got[0].Module != "example.com/one" {
+		t.Fatalf("Stacks after adding go.mod = %+v", got)
+	}
+
+	// A rewrite with the same size is noticed through the modification time
+	writeFixture(t, filepath.Join(root, "go.mod"), "module example.com/two\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "go.mod"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := detector.Detect().Stacks[0].Module; got != "example.com/two" {
+		t.Errorf("Module after editing go.mod = %q, want example.com/two", got)
+	}
+
+	writeFixture(t, filepath.Join(root, "cmd", "server", "main.go"), "package main\n")
+	if got := detector.Detect().Stacks[0].EntryPoints; !reflect.DeepEqual(got, []string{"cmd/server"}) {
+		t.Errorf("EntryPoints after adding cmd/server = %v", got)
+	}
+}
+
+// writeFixture creates path, and its directory, with content.
+func writeFixture(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
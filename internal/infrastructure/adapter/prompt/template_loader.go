@@ -0,0 +1,81 @@
+// Package prompt loads investigation prompt and report templates from disk.
+package prompt
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateExt is the file extension of prompt template files.
+const templateExt = ".tmpl"
+
+// ReportTemplateFile is the file in the prompts directory that overrides the
+// investigation report template. LoadTemplates skips it.
+const ReportTemplateFile = "report.md.tmpl"
+
+// LoadTemplates parses every <AlertType>.tmpl file in dir and returns the
+// templates keyed by alert type (the file name without extension), for example
+// HighCPU.tmpl -> "HighCPU" and Generic.tmpl -> "Generic".
+//
+// ReportTemplateFile is not a prompt template and is skipped.
+//
+// A missing directory is not an error and yields an empty map, so the built-in
+// prompt builders are used. A template that fails to parse returns an error that
+// includes the file path and line number.
+func LoadTemplates(dir string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return templates, nil
+		}
+		return nil, fmt.Errorf("failed to read prompts directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != templateExt || entry.Name() == ReportTemplateFile {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+
+		tmpl, err := usecase.ParsePromptTemplate(entry.Name(), string(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt template %s: %w", path, err)
+		}
+		templates[strings.TrimSuffix(entry.Name(), templateExt)] = tmpl
+	}
+
+	return templates, nil
+}
+
+// LoadReportTemplate parses ReportTemplateFile in dir. It returns nil, without
+// an error, if the file does not exist, so the default report template is
+// used. A template that fails to parse returns an error that includes the
+// file path and line number.
+func LoadReportTemplate(dir string) (*template.Template, error) {
+	path := filepath.Join(dir, ReportTemplateFile)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read report template %s: %w", path, err)
+	}
+
+	tmpl, err := usecase.ParseReportTemplate(ReportTemplateFile, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
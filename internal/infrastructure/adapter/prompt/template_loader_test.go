@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func TestLoadTemplates_KeysByAlertType(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "HighCPU.tmpl", "cpu {{.Alert.Title}}")
+	writeTemplate(t, dir, "Generic.tmpl", "generic")
+	writeTemplate(t, dir, "README.md", "not a template {{")
+	writeTemplate(t, dir, ReportTemplateFile, "report {{.Result.Status}}")
+	if err := os.Mkdir(filepath.Join(dir, "nested.tmpl"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	if len(templates) != 2 {
+		t.Fatalf("LoadTemplates() loaded %d templates, want 2", len(templates))
+	}
+	for _, alertType := range []string{"HighCPU", "Generic"} {
+		if templates[alertType] == nil {
+			t.Errorf("LoadTemplates() missing template for %s", alertType)
+		}
+	}
+}
+
+func TestLoadTemplates_MissingDirectory(t *testing.T) {
+	templates, err := LoadTemplates(filepath.Join(t.TempDir(), "does-not-exist"))
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v, want nil for missing directory", err)
+	}
+	if len(templates) != 0 {
+		t.Errorf("LoadTemplates() = %v, want empty map", templates)
+	}
+}
+
+func TestLoadTemplates_ParseErrorIncludesFileAndLine(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "DiskSpace.tmpl", "ok line\n{{if .Alert.Title}}\nno end\n")
+
+	_, err := LoadTemplates(dir)
+	if err == nil {
+		t.Fatal("LoadTemplates() error = nil, want parse error")
+	}
+	if !strings.Contains(err.Error(), filepath.Join(dir, "DiskSpace.tmpl")) ||
+		!strings.Contains(err.Error(), "DiskSpace.tmpl:") {
+		t.Errorf("LoadTemplates() error = %v, want file path and line", err)
+	}
+}
+
+func TestLoadReportTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := LoadReportTemplate(dir)
+	if err != nil || tmpl != nil {
+		t.Fatalf("LoadReportTemplate() = %v, %v, want nil without a report template", tmpl, err)
+	}
+
+	writeTemplate(t, dir, ReportTemplateFile, "# {{.Result.InvestigationID}}\n{{codeBlock .Summary}}")
+	if tmpl, err = LoadReportTemplate(dir); err != nil || tmpl == nil {
+		t.Fatalf("LoadReportTemplate() = %v, %v, want the report template", tmpl, err)
+	}
+
+	writeTemplate(t, dir, ReportTemplateFile, "{{range .Checks}}\nno end\n")
+	if _, err := LoadReportTemplate(dir); err == nil || !strings.Contains(err.Error(), ReportTemplateFile+":") {
+		t.Errorf("LoadReportTemplate() error = %v, want a parse error naming the file and line", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package ratelimit provides a token-bucket rate limiter for AI requests and
+// an AIProvider decorator that applies it, so concurrent investigations share
+// one requests-per-minute and tokens-per-minute budget.
+package ratelimit
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter paces AI requests with two token buckets: one for requests per
+// minute and one for tokens per minute. Each bucket holds up to one minute of
+// its limit and refills continuously. It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	requests *bucket // nil when requests are unlimited
+	tokens   *bucket // nil when tokens are unlimited
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewLimiter creates a Limiter allowing requestsPerMinute requests and
+// tokensPerMinute tokens. A limit of zero or less disables that bucket.
+func NewLimiter(requestsPerMinute, tokensPerMinute int) *Limiter {
+	l := &Limiter{now: time.Now, after: time.After}
+	start := l.now()
+	if requestsPerMinute > 0 {
+		l.requests = newBucket(requestsPerMinute, start)
+	}
+	if tokensPerMinute > 0 {
+		l.tokens = newBucket(tokensPerMinute, start)
+	}
+	return l
+}
+
+// Wait blocks until a request of the given estimated token count may be sent,
+// and returns how long it waited.
+//
+// If ctx is done while waiting, Wait returns ctx.Err(). If the wait would end
+// after ctx's deadline or the run deadline from port.WithRunDeadline, Wait
+// returns a *port.RateLimitError immediately without waiting. Either way the
+// request's share of the budget is returned to the buckets.
+func (l *Limiter) Wait(ctx context.Context, tokens int) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	wait := max(l.requests.reserve(now, 1), l.tokens.reserve(now, tokens))
+	if wait == 0 {
+		l.mu.Unlock()
+		return 0, nil
+	}
+	if deadline, ok := earliestDeadline(ctx); ok && now.Add(wait).After(deadline) {
+		l.refund(tokens)
+		l.mu.Unlock()
+		return 0, &port.RateLimitError{Wait: wait, Remaining: max(deadline.Sub(now), 0)}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-l.after(wait):
+		return wait, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.refund(tokens)
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// Utilization returns the fraction, from 0 to 1, of the request and token
+// budgets currently in use. An unlimited bucket reports 0.
+func (l *Limiter) Utilization() (requests, tokens float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	return l.requests.utilization(now), l.tokens.utilization(now)
+}
+
+// refund returns a request's reservation to both buckets. l.mu must be held.
+func (l *Limiter) refund(tokens int) {
+	l.requests.refund(1)
+	l.tokens.refund(tokens)
+}
+
+// earliestDeadline returns the earlier of ctx's deadline and its run deadline.
+func earliestDeadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if runDeadline, hasRun := port.RunDeadlineFromContext(ctx); hasRun && (!ok || runDeadline.Before(deadline)) {
+		return runDeadline, true
+	}
+	return deadline, ok
+}
+
+// bucket is a token bucket that may go negative: reservations are taken
+// immediately and the deficit is how long the caller must wait. Methods on a
+// nil bucket behave as an unlimited bucket.
+type bucket struct {
+	capacity  float64   // One minute of the limit
+	perSecond float64   // Refill rate
+	available float64   // Tokens available; negative while callers are waiting
+	last      time.Time // When available was last refilled
+}
+
+// newBucket creates a full bucket for perMinute tokens.
+func newBucket(perMinute int, now time.Time) *bucket {
+	return &bucket{
+		capacity:  float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		available: float64(perMinute),
+		last:      now,
+	}
+}
+
+// refill adds the tokens accrued since the last refill.
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.available = min(b.capacity, b.available+elapsed.Seconds()*b.perSecond)
+		b.last = now
+	}
+}
+
+// reserve takes n tokens and returns how long until they are available.
+// Requests larger than the bucket are capped at its capacity so they can
+// eventually be served.
+func (b *bucket) reserve(now time.Time, n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	b.available -= min(float64(n), b.capacity)
+	if b.available >= 0 {
+		return 0
+	}
+	return time.Duration(-b.available / b.perSecond * float64(time.Second))
+}
+
+// refund returns n reserved tokens.
+func (b *bucket) refund(n int) {
+	if b == nil {
+		return
+	}
+	b.available = min(b.capacity, b.available+min(float64(n), b.capacity))
+}
+
+// utilization returns the fraction of the bucket in use, from 0 to 1.
+func (b *bucket) utilization(now time.Time) float64 {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	return min(1, max(0, 1-b.available/b.capacity))
+}
@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a time source whose timers fire immediately, advancing the
+// clock by the timer's duration and recording it.
+type fakeClock struct {
+	now    time.Time
+	waited []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waited = append(c.waited, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// newTestLimiter creates a Limiter driven by a fake clock. The clock starts at
+// the real time so context deadlines line up with it.
+func newTestLimiter(requestsPerMinute, tokensPerMinute int) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	l := &Limiter{now: clock.Now, after: clock.After}
+	if requestsPerMinute > 0 {
+		l.requests = newBucket(requestsPerMinute, clock.now)
+	}
+	if tokensPerMinute > 0 {
+		l.tokens = newBucket(tokensPerMinute, clock.now)
+	}
+	return l, clock
+}
+
+func TestLimiter_PacesRequests(t *testing.T) {
+	limiter, clock := newTestLimiter(2, 0)
+
+	var waits []time.Duration
+	for range 4 {
+		waited, err := limiter.Wait(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+		waits = append(waits, waited)
+	}
+
+	// Two requests fit in the bucket; after that one is allowed every 30s
+	want := []time.Duration{0, 0, 30 * time.Second, 30 * time.Second}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("request %d waited %v, want %v (all waits: %v)", i, waits[i], want[i], waits)
+		}
+	}
+	if len(clock.waited) != 2 {
+		t.Errorf("expected 2 timers, got %v", clock.waited)
+	}
+}
+
+func TestLimiter_PacesTokens(t *testing.T) {
+	limiter, _ := newTestLimiter(0, 1200)
+
+	if waited, _ := limiter.Wait(context.Background(), 1000); waited != 0 {
+		t.Errorf("first request waited %v, want 0", waited)
+	}
+	// 800 tokens short at 20 tokens/s
+	waited, err := limiter.Wait(context.Background(), 1000)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if waited != 40*time.Second {
+		t.Errorf("second request waited %v, want 40s", waited)
+	}
+
+	// A request larger than the whole bucket waits for a full bucket, not forever
+	waited, err = limiter.Wait(context.Background(), 5000)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if waited != time.Minute {
+		t.Errorf("oversized request waited %v, want 1m", waited)
+	}
+}
+
+func TestLimiter_FailsFastPastRunDeadline(t *testing.T) {
+	limiter, clock := newTestLimiter(1, 0)
+	if _, err := limiter.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx := port.WithRunDeadline(context.Background(), clock.now.Add(10*time.Second))
+	waited, err := limiter.Wait(ctx, 0)
+
+	var rateErr *port.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected a *port.RateLimitError, got %v", err)
+	}
+	if rateErr.Wait != time.Minute || rateErr.Remaining != 10*time.Second {
+		t.Errorf("RateLimitError = %+v, want Wait 1m and Remaining 10s", rateErr)
+	}
+	if waited != 0 || len(clock.waited) != 0 {
+		t.Errorf("failing fast should not wait, waited %v with timers %v", waited, clock.waited)
+	}
+
+	// The failed request's reservation was returned to the bucket
+	if waited, _ := limiter.Wait(context.Background(), 0); waited != time.Minute {
+		t.Errorf("next request waited %v, want 1m", waited)
+	}
+}
+
+func TestLimiter_FailsFastPastContextDeadline(t *testing.T) {
+	limiter, clock := newTestLimiter(1, 0)
+	if _, err := limiter.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := limiter.Wait(ctx, 0)
+
+	var rateErr *port.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected a *port.RateLimitError, got %v", err)
+	}
+	if len(clock.waited) != 0 {
+		t.Errorf("failing fast should not wait, timers %v", clock.waited)
+	}
+}
+
+func TestLimiter_WaitRespectsCancellation(t *testing.T) {
+	limiter, _ := newTestLimiter(1, 0)
+	blocked := make(chan time.Time)
+	limiter.after = func(time.Duration) <-chan time.Time { return blocked }
+	if _, err := limiter.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := limiter.Wait(ctx, 0)
+		errCh <- err
+	}()
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+	if requests, _ := limiter.Utilization(); requests != 1 {
+		t.Errorf("request utilization = %v, want 1 after the cancelled wait is refunded", requests)
+	}
+}
+
+func TestLimiter_Utilization(t *testing.T) {
+	limiter, clock := newTestLimiter(4, 1000)
+
+	if requests, tokens := limiter.Utilization(); requests != 0 || tokens != 0 {
+		t.Errorf("fresh limiter utilization = %v, %v, want 0, 0", requests, tokens)
+	}
+
+	_, _ = limiter.Wait(context.Background(), 500)
+	if requests, tokens := limiter.Utilization(); requests != 0.25 || tokens != 0.5 {
+		t.Errorf("utilization = %v, %v, want 0.25, 0.5", requests, tokens)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if requests, tokens := limiter.Utilization(); requests != 0 || tokens != 0 {
+		t.Errorf("utilization after a minute = %v, %v, want 0, 0", requests, tokens)
+	}
+
+	unlimited := NewLimiter(0, 0)
+	if waited, err := unlimited.Wait(context.Background(), 1_000_000); waited != 0 || err != nil {
+		t.Errorf("unlimited Wait() = %v, %v, want no wait", waited, err)
+	}
+}
+
+// stubProvider is a port.AIProvider that counts requests.
+type stubProvider struct {
+	port.AIProvider
+	calls int
+}
+
+func (p *stubProvider) SendMessage(
+	context.Context, []port.MessageParam, []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	p.calls++
+	return &entity.Message{Role: entity.RoleAssistant, Content: "ok"}, nil, nil
+}
+
+func TestProvider_FailsFastWithoutCallingProvider(t *testing.T) {
+	limiter, clock := newTestLimiter(1, 0)
+	inner := &stubProvider{}
+	provider := NewProvider(inner, limiter)
+	messages := []port.MessageParam{{Role: entity.RoleUser, Content: "hello"}}
+
+	if _, _, err := provider.SendMessage(context.Background(), messages, nil); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	ctx := port.WithRunDeadline(context.Background(), clock.now.Add(time.Second))
+	_, _, err := provider.SendMessage(ctx, messages, nil)
+
+	var rateErr *port.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected a *port.RateLimitError, got %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"log/slog"
+)
+
+// Provider is a port.AIProvider that waits for a shared Limiter before each
+// request. Methods other than the three that send go straight to the wrapped
+// provider.
+type Provider struct {
+	port.AIProvider
+	limiter *Limiter
+	logger  *slog.Logger
+}
+
+// Compile-time check that Provider implements port.AIProvider.
+var _ port.AIProvider = (*Provider)(nil)
+
+// NewProvider wraps provider so its requests are paced by limiter. Providers
+// that share a limiter share its budget.
+func NewProvider(provider port.AIProvider, limiter *Limiter) *Provider {
+	return &Provider{AIProvider: provider, limiter: limiter, logger: slog.Default()}
+}
+
+// SetLogger sets the fallback logger for local rate limiting, used when the
+// request context carries no run logger. A nil logger restores slog.Default().
+func (p *Provider) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	p.logger = logger
+}
+
+// SupportsImageInput reports whether the wrapped provider accepts images.
+func (p *Provider) SupportsImageInput() bool {
+	return port.SupportsImageInput(p.AIProvider)
+}
+
+// ModelCapabilities reports what the wrapped provider says model supports,
+// or that it supports every feature when the provider does not say.
+func (p *Provider) ModelCapabilities(model string) (port.ModelCapabilities, bool) {
+	if reporter, ok := p.AIProvider.(port.ModelCapabilityReporter); ok {
+		return reporter.ModelCapabilities(model)
+	}
+	return port.ModelCapabilities{SupportsTools: true, SupportsThinking: true, SupportsImages: true}, false
+}
+
+// SendMessage waits for the limiter, then sends the message.
+func (p *Provider) SendMessage(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	if err := p.wait(ctx, messages); err != nil {
+		return nil, nil, err
+	}
+	return p.AIProvider.SendMessage(ctx, messages, tools)
+}
+
+// SendMessageWithOptions waits for the limiter, then sends the message with opts.
+func (p *Provider) SendMessageWithOptions(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	opts port.RequestOptions,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	if err := p.wait(ctx, messages); err != nil {
+		return nil, nil, err
+	}
+	return p.AIProvider.SendMessageWithOptions(ctx, messages, tools, opts)
+}
+
+// SendMessageStreaming waits for the limiter, then sends the message with streaming.
+func (p *Provider) SendMessageStreaming(
+	ctx context.Context,
+	messages []port.MessageParam,
+	tools []port.ToolParam,
+	textCallback port.StreamCallback,
+	thinkingCallback port.ThinkingCallback,
+) (*entity.Message, []port.ToolCallInfo, error) {
+	if err := p.wait(ctx, messages); err != nil {
+		return nil, nil, err
+	}
+	return p.AIProvider.SendMessageStreaming(ctx, messages, tools, textCallback, thinkingCallback)
+}
+
+// wait reserves the request's estimated input tokens and logs any time spent waiting.
+func (p *Provider) wait(ctx context.Context, messages []port.MessageParam) error {
+	waited, err := p.limiter.Wait(ctx, estimateTokens(messages))
+	if waited > 0 {
+		port.LoggerFromContext(ctx, p.logger).Info("Rate limited locally", "waited", waited)
+	}
+	return err
+}
+
+// estimateTokens estimates the input tokens of a request from its messages.
+func estimateTokens(messages []port.MessageParam) int {
+	total := 0
+	for _, msg := range messages {
+		total += entity.EstimateTokens(msg.Content)
+		for _, result := range msg.ToolResults {
+			total += entity.EstimateTokens(result.Result)
+		}
+		for _, block := range msg.ThinkingBlocks {
+			total += entity.EstimateTokens(block.Thinking)
+		}
+	}
+	return total
+}
@@ -0,0 +1,168 @@
+// Package runbook loads alert runbooks from a local directory or a runbook URL.
+package runbook
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultFetchTimeout bounds how long fetching a runbook URL may delay an investigation.
+const defaultFetchTimeout = 10 * time.Second
+
+// maxRunbookBytes limits how much of a runbook file or response is read (64KB).
+const maxRunbookBytes = 64 << 10
+
+// maxFetchRedirects bounds the redirects followed when fetching a runbook URL.
+const maxFetchRedirects = 5
+
+// ErrHostNotAllowed is returned when a runbook URL's host is not in the
+// loader's allowlist.
+var ErrHostNotAllowed = errors.New("runbook host not allowed")
+
+// runbookExtensions are tried in order when looking up a local runbook by alert name.
+var runbookExtensions = []string{".md", ".txt"}
+
+// Loader implements usecase.RunbookProvider.
+//
+// It fetches the alert's runbook_url when one is set, its host is allowed and it
+// is reachable, and otherwise reads <dir>/<alertname>.md (or .txt). A missing
+// runbook is not an error.
+type Loader struct {
+	dir          string
+	allowedHosts []string
+	client       *http.Client
+}
+
+// NewLoader creates a runbook loader that reads local runbooks from dir and
+// fetches runbook URLs whose host is in allowedHosts; an entry also allows its
+// subdomains. An empty dir disables local runbook lookup, and an empty
+// allowedHosts disables fetching, since the URL comes from whoever sent the alert.
+func NewLoader(dir string, allowedHosts []string) *Loader {
+	l := &Loader{
+		dir:          dir,
+		allowedHosts: append([]string(nil), allowedHosts...),
+	}
+	l.client = &http.Client{
+		Timeout: defaultFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if err := l.checkHost(req.URL); err != nil {
+				return fmt.Errorf("redirect to %s refused: %w", req.URL.Redacted(), err)
+			}
+			return nil
+		},
+	}
+	return l
+}
+
+// LoadRunbook returns the runbook for an alert, with empty content if none exists.
+// A failed URL fetch falls back to the local runbook; the fetch error is only
+// returned when no local runbook is found either.
+func (l *Loader) LoadRunbook(ctx context.Context, alertName, runbookURL string) (usecase.Runbook, error) {
+	var fetchErr error
+	if runbookURL != "" {
+		content, err := l.fetch(ctx, runbookURL)
+		if err == nil && strings.TrimSpace(content) != "" {
+			return usecase.Runbook{Content: content, Fetched: true}, nil
+		}
+		fetchErr = err
+	}
+
+	content, err := l.readLocal(alertName)
+	if err != nil {
+		return usecase.Runbook{}, err
+	}
+	if content == "" && fetchErr != nil {
+		return usecase.Runbook{}, fetchErr
+	}
+	return usecase.Runbook{Content: content}, nil
+}
+
+// checkHost returns an error wrapping ErrHostNotAllowed unless u's host is in
+// the allowlist.
+func (l *Loader) checkHost(u *url.URL) error {
+	if u.User != nil {
+		return errors.New("runbook URL must not contain credentials")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if len(l.allowedHosts) == 0 {
+		return fmt.Errorf("%w: no hosts are allowed (configure runbook_url_allowed_domains)", ErrHostNotAllowed)
+	}
+	for _, allowed := range l.allowedHosts {
+		allowed = strings.TrimSuffix(strings.ToLower(allowed), ".")
+		if host != "" && (host == allowed || strings.HasSuffix(host, "."+allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+}
+
+// fetch downloads an http(s) runbook URL from an allowed host. Other schemes
+// are ignored.
+func (l *Loader) fetch(ctx context.Context, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", nil
+	}
+	if err := l.checkHost(parsed); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create runbook request: %w", err)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch runbook %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch runbook %s: status %d", rawURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRunbookBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read runbook %s: %w", rawURL, err)
+	}
+	return string(body), nil
+}
+
+// readLocal reads <dir>/<alertName><ext> for the first extension that exists.
+// Alert names containing path separators are rejected to keep lookups inside dir.
+func (l *Loader) readLocal(alertName string) (string, error) {
+	if l.dir == "" || alertName == "" || alertName != filepath.Base(alertName) || alertName == ".." {
+		return "", nil
+	}
+
+	for _, ext := range runbookExtensions {
+		f, err := os.Open(filepath.Join(l.dir, alertName+ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to open runbook: %w", err)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(f, maxRunbookBytes))
+		_ = f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read runbook: %w", err)
+		}
+		return string(content), nil
+	}
+
+	return "", nil
+}
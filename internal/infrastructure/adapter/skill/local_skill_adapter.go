@@ -4,11 +4,14 @@
 //
 // Skills are discovered from multiple directories in priority order:
 //   - ./skills (project root, highest priority)
+//   - ./.skills (project hidden directory)
 //   - ./.claude/skills (project .claude directory)
-//   - ~/.claude/skills (user global, lowest priority)
+//   - ~/.claude/skills (user global)
+//   - ~/.config/code-agent/skills (user config, lowest priority)
 //
 // When the same skill name exists in multiple directories, the highest priority
-// directory wins. Each skill is represented by a directory containing a SKILL.md
+// directory wins and the shadowed copy is logged. Long-running processes can call
+// Watch to pick up added, edited, or removed skills without restarting. Each skill is represented by a directory containing a SKILL.md
 // file with YAML frontmatter defining the skill's metadata.
 //
 // Example usage:
@@ -30,7 +33,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -91,17 +97,24 @@ type LocalSkillManager struct {
 
 // NewLocalSkillManager creates a new LocalSkillManager instance.
 // Skills are discovered from multiple directories in priority order:
-// ./skills, ./.claude/skills, and ~/.claude/skills.
+// ./skills, ./.skills, ./.claude/skills, ~/.claude/skills, and ~/.config/code-agent/skills.
 func NewLocalSkillManager() port.SkillManager {
 	skillsDirs := []DirConfig{
 		{Path: "./skills", SourceType: entity.SkillSourceProject},
+		{Path: "./.skills", SourceType: entity.SkillSourceProject},
 		{Path: "./.claude/skills", SourceType: entity.SkillSourceProjectClaude},
 	}
 	if homeDir, err := os.UserHomeDir(); err == nil && homeDir != "" {
-		skillsDirs = append(skillsDirs, DirConfig{
-			Path:       filepath.Join(homeDir, ".claude", "skills"),
-			SourceType: entity.SkillSourceUser,
-		})
+		skillsDirs = append(skillsDirs,
+			DirConfig{
+				Path:       filepath.Join(homeDir, ".claude", "skills"),
+				SourceType: entity.SkillSourceUser,
+			},
+			DirConfig{
+				Path:       filepath.Join(homeDir, ".config", "code-agent", "skills"),
+				SourceType: entity.SkillSourceUser,
+			},
+		)
 	}
 	return &LocalSkillManager{
 		skillsDirs: skillsDirs,
@@ -136,7 +149,7 @@ func (sm *LocalSkillManager) DiscoverSkills(_ context.Context) (*port.SkillDisco
 
 	var discoveredSkills []port.SkillInfo
 	var skillsDirs []string
-	seenSkills := make(map[string]bool)
+	seenSkills := make(map[string]string) // Skill name -> directory that provided it
 	activeCount := 0
 
 	for _, dirConfig := range dirsToSearch {
@@ -180,7 +193,7 @@ func (sm *LocalSkillManager) getDirsToSearch() []DirConfig {
 // Returns skill info for each valid skill found that has not already been seen.
 func (sm *LocalSkillManager) discoverFromDirectory(
 	dirConfig DirConfig,
-	seenSkills map[string]bool,
+	seenSkills map[string]string,
 ) []port.SkillInfo {
 	var skills []port.SkillInfo
 
@@ -220,7 +233,7 @@ func (sm *LocalSkillManager) findSkillPath(skillName string) string {
 func (sm *LocalSkillManager) processSkillFileWithSource(
 	path string,
 	sourceType entity.SkillSourceType,
-	seenSkills map[string]bool,
+	seenSkills map[string]string,
 ) *port.SkillInfo {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -241,13 +254,15 @@ func (sm *LocalSkillManager) processSkillFileWithSource(
 		return nil
 	}
 
+	dirPath := filepath.Dir(path)
+
 	// Skip if already seen (higher priority directory already discovered this skill)
-	if seenSkills[skill.Name] {
+	if winner, seen := seenSkills[skill.Name]; seen {
+		fmt.Fprintf(os.Stderr, "[SkillManager] Skill '%s' at %s is shadowed by %s\n", skill.Name, dirPath, winner)
 		return nil
 	}
-	seenSkills[skill.Name] = true
+	seenSkills[skill.Name] = dirPath
 
-	dirPath := filepath.Dir(path)
 	absPath, err := filepath.Abs(dirPath)
 	if err != nil {
 		absPath = dirPath
@@ -399,6 +414,96 @@ func (sm *LocalSkillManager) ValidateSkills(_ context.Context) (map[string]error
 	return validationErrors, nil
 }
 
+// ListSkills returns every skill found by the most recent discovery, sorted by name.
+// It does not rescan the file system; call DiscoverSkills or Watch to refresh.
+func (sm *LocalSkillManager) ListSkills() []port.SkillInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	skills := make([]port.SkillInfo, 0, len(sm.skills))
+	for _, skill := range sm.skills {
+		skills = append(skills, sm.skillToInfo(skill))
+	}
+	sort.Slice(skills, func(i, j int) bool {
+		return skills[i].Name < skills[j].Name
+	})
+	return skills
+}
+
+// GetSkillContent returns the body of a skill's SKILL.md (the content after the frontmatter),
+// loading it from disk on first use.
+func (sm *LocalSkillManager) GetSkillContent(skillName string) (string, error) {
+	skill, err := sm.LoadSkillMetadata(context.Background(), skillName)
+	if err != nil {
+		return "", err
+	}
+	return skill.RawContent, nil
+}
+
+// Watch polls the skill directories every interval in a background goroutine and
+// re-runs DiscoverSkills when a SKILL.md file is added, edited, or removed. onReload,
+// if non-nil, receives the new discovery result. The current state of the directories
+// is captured before Watch returns, so only later changes trigger a reload.
+//
+// Polling is used instead of file system notifications so the watcher also covers
+// directories that do not exist yet (e.g. ./.skills created after startup).
+//
+// The returned channel is closed once the watcher stops after ctx is cancelled.
+func (sm *LocalSkillManager) Watch(
+	ctx context.Context,
+	interval time.Duration,
+	onReload func(*port.SkillDiscoveryResult),
+) <-chan struct{} {
+	done := make(chan struct{})
+	lastFingerprint := sm.fingerprint()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current := sm.fingerprint()
+				if current == lastFingerprint {
+					continue
+				}
+				lastFingerprint = current
+
+				result, err := sm.DiscoverSkills(ctx)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[SkillManager] Warning: skill reload failed: %v\n", err)
+					continue
+				}
+				if onReload != nil {
+					onReload(result)
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// fingerprint summarizes the path, size, and modification time of every SKILL.md
+// file in the configured directories so changes can be detected cheaply.
+func (sm *LocalSkillManager) fingerprint() string {
+	var entries []string
+	for _, dirConfig := range sm.getDirsToSearch() {
+		_ = filepath.Walk(dirConfig.Path, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info == nil || info.IsDir() || info.Name() != "SKILL.md" {
+				return nil
+			}
+			entries = append(entries, fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()))
+			return nil
+		})
+	}
+	return strings.Join(entries, "\n")
+}
+
 // skillToInfo converts an entity.Skill to a port.SkillInfo, including the active state.
 func (sm *LocalSkillManager) skillToInfo(skill *entity.Skill) port.SkillInfo {
 	return port.SkillInfo{
@@ -0,0 +1,132 @@
+package skill
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSkillManager(dirs ...DirConfig) *LocalSkillManager {
+	return &LocalSkillManager{
+		skillsDirs: dirs,
+		skills:     make(map[string]*entity.Skill),
+		active:     make(map[string]bool),
+	}
+}
+
+func TestLocalSkillManager_ListSkills_SortedAndPrefersProjectLocal(t *testing.T) {
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project", ".skills")
+	userDir := filepath.Join(tempDir, "home", ".config", "code-agent", "skills")
+
+	createSkillFile(t, userDir, "shared-skill", "User config version")
+	createSkillFile(t, userDir, "alpha-skill", "Only in user config")
+	createSkillFile(t, projectDir, "shared-skill", "Project-local version")
+
+	sm := newTestSkillManager(
+		DirConfig{Path: projectDir, SourceType: entity.SkillSourceProject},
+		DirConfig{Path: userDir, SourceType: entity.SkillSourceUser},
+	)
+
+	if got := sm.ListSkills(); len(got) != 0 {
+		t.Fatalf("ListSkills() before discovery = %d skills, want 0", len(got))
+	}
+	if _, err := sm.DiscoverSkills(context.Background()); err != nil {
+		t.Fatalf("DiscoverSkills() error = %v", err)
+	}
+
+	skills := sm.ListSkills()
+	if len(skills) != 2 {
+		t.Fatalf("ListSkills() returned %d skills, want 2", len(skills))
+	}
+	if skills[0].Name != "alpha-skill" || skills[1].Name != "shared-skill" {
+		t.Errorf("ListSkills() order = [%s, %s], want sorted by name", skills[0].Name, skills[1].Name)
+	}
+	if skills[1].Description != "Project-local version" {
+		t.Errorf("shared-skill description = %q, want project-local version to win", skills[1].Description)
+	}
+}
+
+func TestLocalSkillManager_GetSkillContent(t *testing.T) {
+	skillsDir := filepath.Join(t.TempDir(), "skills")
+	createSkillFile(t, skillsDir, "content-skill", "Has content")
+
+	sm := newTestSkillManager(DirConfig{Path: skillsDir, SourceType: entity.SkillSourceProject})
+	if _, err := sm.DiscoverSkills(context.Background()); err != nil {
+		t.Fatalf("DiscoverSkills() error = %v", err)
+	}
+
+	content, err := sm.GetSkillContent("content-skill")
+	if err != nil {
+		t.Fatalf("GetSkillContent() error = %v", err)
+	}
+	if content != "Content for content-skill" {
+		t.Errorf("GetSkillContent() = %q, want skill body", content)
+	}
+
+	if _, err := sm.GetSkillContent("missing-skill"); err == nil {
+		t.Error("GetSkillContent() expected error for unknown skill")
+	}
+	if _, err := sm.GetSkillContent("../escape"); err == nil {
+		t.Error("GetSkillContent() expected error for invalid skill name")
+	}
+}
+
+func TestLocalSkillManager_Watch_ReloadsOnFileChanges(t *testing.T) {
+	skillsDir := filepath.Join(t.TempDir(), ".skills") // Does not exist yet
+	sm := newTestSkillManager(DirConfig{Path: skillsDir, SourceType: entity.SkillSourceProject})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan *port.SkillDiscoveryResult, 10)
+	done := sm.Watch(ctx, 10*time.Millisecond, func(result *port.SkillDiscoveryResult) {
+		reloads <- result
+	})
+
+	waitForReload := func() *port.SkillDiscoveryResult {
+		t.Helper()
+		select {
+		case result := <-reloads:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatal("Watch() did not reload within 5s")
+			return nil
+		}
+	}
+
+	// Adding a skill (and its directory) triggers a reload
+	createSkillFile(t, skillsDir, "watched-skill", "Original description")
+	if result := waitForReload(); result.TotalCount != 1 {
+		t.Fatalf("TotalCount after add = %d, want 1", result.TotalCount)
+	}
+
+	// Editing the skill triggers another reload with the new metadata and content
+	skillFile := filepath.Join(skillsDir, "watched-skill", "SKILL.md")
+	updated := "---\nname: watched-skill\ndescription: Updated description\n---\nUpdated body"
+	if err := os.WriteFile(skillFile, []byte(updated), 0o644); err != nil {
+		t.Fatalf("failed to update skill: %v", err)
+	}
+	// Make sure the modification time moves even on coarse-grained file systems
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(skillFile, future, future)
+
+	result := waitForReload()
+	if len(result.Skills) != 1 || result.Skills[0].Description != "Updated description" {
+		t.Fatalf("skills after edit = %+v, want updated description", result.Skills)
+	}
+	if content, err := sm.GetSkillContent("watched-skill"); err != nil || content != "Updated body" {
+		t.Errorf("GetSkillContent() after edit = %q, %v, want updated body", content, err)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not return after context cancellation")
+	}
+}
@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// askUserToolName is the name of the tool that asks the user a question.
+const askUserToolName = "ask_user"
+
+// askUserInput represents the input for the ask_user tool.
+type askUserInput struct {
+	Question string   `json:"question"`
+	Choices  []string `json:"choices,omitempty"`
+}
+
+// askUserTool returns the ask_user tool definition. It is interactive, so it
+// is never offered to headless runs such as investigations.
+func askUserTool() entity.Tool {
+	return entity.Tool{
+		ID:   askUserToolName,
+		Name: askUserToolName,
+		Description: "Asks the user a question and waits for the answer. Use it when you need a decision " +
+			"you cannot make from the code or the conversation, such as which of several files to change, " +
+			"instead of guessing. Give choices when the answer is one of a few options.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"question": map[string]interface{}{
+					"type":        "string",
+					"description": "The question, with enough context for the user to answer it",
+					"examples":    []interface{}{"Which config should I edit?"},
+				},
+				"choices": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"minItems":    1,
+					"description": "Options the user picks from by number; omit for a free-text answer",
+					"examples":    []interface{}{[]interface{}{"config/dev.yaml", "config/prod.yaml"}},
+				},
+			},
+			"required": []string{"question"},
+		},
+		RequiredFields: []string{"question"},
+		Interactive:    true,
+	}
+}
+
+// executeAskUser asks the user the model's question and returns the answer.
+func (a *ExecutorAdapter) executeAskUser(ctx context.Context, input json.RawMessage) (string, error) {
+	var in askUserInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal ask_user input: %w", err)
+	}
+	if strings.TrimSpace(in.Question) == "" {
+		return "", errors.New("question is required")
+	}
+	for i, choice := range in.Choices {
+		if strings.TrimSpace(choice) == "" {
+			return "", fmt.Errorf("choice %d is empty", i+1)
+		}
+	}
+	if port.IsHeadless(ctx) {
+		return "", errors.New("ask_user is not available in headless runs; decide from the evidence or escalate")
+	}
+
+	a.mu.RLock()
+	prompt := a.userPromptCallback
+	a.mu.RUnlock()
+	if prompt == nil {
+		return "", errors.New("no user is available to answer questions")
+	}
+
+	answer, err := prompt(ctx, in.Question, in.Choices)
+	if err != nil {
+		return "", fmt.Errorf("no answer from the user: %w", err)
+	}
+	return answer, nil
+}
@@ -0,0 +1,161 @@
+package tool
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultBashMaxOutputBytes is how many bytes of combined stdout and stderr a
+// bash command may produce before the rest is discarded.
+const DefaultBashMaxOutputBytes = 1 << 20
+
+// defaultBashEnv lists the environment variables bash commands inherit from
+// the agent. A trailing "*" matches any variable with that prefix.
+var defaultBashEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_*", "TERM", "TMPDIR", "TZ",
+}
+
+// envNamePattern matches valid environment variable names.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// unsafeBashEnv lists the environment variables a bash call may not set,
+// because they make the shell, the dynamic loader, or a program the command
+// starts run code the command text does not show. A trailing "*" matches any
+// variable with that prefix.
+var unsafeBashEnv = []string{
+	// The shell: startup files, prompts, options, functions, and word splitting
+	"BASH_ENV", "ENV", "PROMPT_COMMAND", "PS4", "SHELLOPTS", "BASHOPTS", "IFS", "CDPATH", "GLOBIGNORE",
+	"BASH_FUNC_*", "BASH_XTRACEFD", "BASH_LOADABLES_PATH", "EXECIGNORE",
+	// Where programs are found and how they are loaded
+	"PATH", "LD_*", "DYLD_*",
+	// Interpreters that load code named by the environment
+	"NODE_OPTIONS", "NODE_PATH", "PYTHONPATH", "PYTHONSTARTUP", "PYTHONHOME", "PERL5OPT", "PERL5LIB", "PERLLIB",
+	"RUBYOPT", "RUBYLIB",
+	// Programs that git and other tools run
+	"GIT_*", "PAGER", "EDITOR", "VISUAL", "SSH_ASKPASS", "LESSOPEN", "LESSCLOSE",
+}
+
+// BashOptions configures how the bash tool runs commands.
+type BashOptions struct {
+	// WorkingDir is the directory commands run in. Empty means the agent's
+	// current directory.
+	WorkingDir string
+
+	// MaxOutputBytes caps combined stdout and stderr; 0 means unlimited.
+	MaxOutputBytes int
+
+	// AllowedEnv names environment variables passed through in addition to
+	// the default allowlist (PATH, HOME, LANG, ...). A trailing "*" matches
+	// any variable with that prefix.
+	AllowedEnv []string
+}
+
+// bashEnv returns the environment for a bash command: the variables of
+// environ matching the default allowlist or allowed, followed by extra.
+func bashEnv(environ []string, allowed []string, extra map[string]string) []string {
+	patterns := slices.Concat(defaultBashEnv, allowed)
+	env := make([]string, 0, len(patterns)+len(extra))
+	for _, entry := range environ {
+		name, _, ok := strings.Cut(entry, "=")
+		if _, overridden := extra[name]; ok && !overridden && envAllowed(name, patterns) {
+			env = append(env, entry)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		env = append(env, name+"="+extra[name])
+	}
+	return env
+}
+
+// envAllowed reports whether name matches any of patterns.
+func envAllowed(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBashEnv checks the names of per-call environment variables,
+// refusing the ones in unsafeBashEnv.
+func validateBashEnv(env map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if envAllowed(name, unsafeBashEnv) {
+			return fmt.Errorf("environment variable %s may not be set for a command: "+
+				"it can make the shell or the programs it starts run other code", name)
+		}
+	}
+	return nil
+}
+
+// boundedOutput caps the bytes kept across a command's stdout and stderr.
+// Its streams accept every write, so the command never sees a short write,
+// but only keep bytes while the shared limit has room.
+type boundedOutput struct {
+	mu        sync.Mutex
+	limit     int            // 0 means unlimited
+	total     int            // bytes written across both streams
+	kept      int            // bytes kept across both streams
+	truncated *boundedStream // the stream that was cut off first
+}
+
+// boundedStream is one stream of a boundedOutput.
+type boundedStream struct {
+	out *boundedOutput
+	buf bytes.Buffer
+}
+
+// newBoundedOutput returns stdout and stderr writers sharing a limit of
+// limit bytes.
+func newBoundedOutput(limit int) (*boundedStream, *boundedStream) {
+	out := &boundedOutput{limit: limit}
+	return &boundedStream{out: out}, &boundedStream{out: out}
+}
+
+// Write keeps as much of p as the shared limit allows, never splitting a
+// UTF-8 character, and always reports p as fully written.
+func (s *boundedStream) Write(p []byte) (int, error) {
+	out := s.out
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	out.total += len(p)
+	if out.truncated != nil {
+		return len(p), nil
+	}
+	keep := p
+	if room := out.limit - out.kept; out.limit > 0 && len(p) > room {
+		keep = []byte(truncateUTF8(string(p), room))
+		out.truncated = s
+	}
+	s.buf.Write(keep)
+	out.kept += len(keep)
+	return len(p), nil
+}
+
+// String returns the stream's kept output, followed by the truncation notice
+// if this stream was the one cut off.
+func (s *boundedStream) String() string {
+	out := s.out
+	out.mu.Lock()
+	defer out.mu.Unlock()
+
+	if out.truncated != s {
+		return s.buf.String()
+	}
+	return s.buf.String() + fmt.Sprintf(
+		"\n\n[output truncated: showing %d of %d bytes of stdout and stderr]", out.kept, out.total)
+}
@@ -0,0 +1,312 @@
+package tool
+
+import (
+	"bytes"
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChangeSnapshotMaxBytes is the largest file ChangeTracker snapshots
+// and diffs by default (1MB).
+const DefaultChangeSnapshotMaxBytes = 1 << 20
+
+// fileState is a file's contents, or why they were not kept, at one point in time.
+type fileState struct {
+	exists  bool
+	content string
+	size    int64
+	modTime time.Time
+	note    string // Set when the contents were not kept
+}
+
+// trackedFile is a file a session modified and its state before the first modification.
+type trackedFile struct {
+	path   string // Absolute
+	before fileState
+}
+
+// ChangeSummary is what a session's tools changed in the workspace.
+type ChangeSummary struct {
+	Files []usecase.FileChange
+	Diff  string // Unified diff of every diffed file, in the order they were first modified
+}
+
+// ChangeTracker records the files each session's tools modify, snapshotting
+// each one before its first modification, so a session's changes can be
+// summarized from the files on disk rather than from what the model reports.
+// It tracks the path of edit_file, the modified_paths bash declares, and both
+// inside batch_tool. Files larger than the snapshot limit, and binary files,
+// are listed with a note instead of a diff. It is safe for concurrent use.
+type ChangeTracker struct {
+	root     string
+	maxBytes int
+
+	mu       sync.Mutex
+	sessions map[string][]*trackedFile // In order of first modification
+}
+
+// NewChangeTracker creates a tracker resolving relative paths against root.
+// maxBytes <= 0 uses DefaultChangeSnapshotMaxBytes.
+func NewChangeTracker(root string, maxBytes int) *ChangeTracker {
+	if maxBytes <= 0 {
+		maxBytes = DefaultChangeSnapshotMaxBytes
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &ChangeTracker{
+		root:     root,
+		maxBytes: maxBytes,
+		sessions: make(map[string][]*trackedFile),
+	}
+}
+
+// Middleware returns the ToolMiddleware that snapshots the files a tool call
+// is about to modify. Calls without a session ID in their context are not tracked.
+func (t *ChangeTracker) Middleware() ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			if sessionID, ok := port.SessionIDFromContext(ctx); ok && sessionID != "" {
+				for _, path := range modifiedPaths(name, input) {
+					t.track(sessionID, t.resolve(path))
+				}
+			}
+			return next(ctx, name, input)
+		}
+	}
+}
+
+// modifiedPaths returns the paths a tool call declares it modifies.
+func modifiedPaths(name string, input json.RawMessage) []string {
+	switch name {
+	case "edit_file":
+		var in editFileInput
+		if json.Unmarshal(input, &in) == nil && in.Path != "" {
+			return []string{in.Path}
+		}
+	case "bash":
+		var in bashInput
+		if json.Unmarshal(input, &in) == nil {
+			return in.ModifiedPaths
+		}
+	case "batch_tool":
+		var in batchToolInput
+		if json.Unmarshal(input, &in) != nil {
+			return nil
+		}
+		var paths []string
+		for _, inv := range in.Invocations {
+			if inv.ToolName != "batch_tool" {
+				paths = append(paths, modifiedPaths(inv.ToolName, inv.Arguments)...)
+			}
+		}
+		return paths
+	}
+	return nil
+}
+
+// resolve returns the absolute, cleaned form of a tool path.
+func (t *ChangeTracker) resolve(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.root, path)
+	}
+	return filepath.Clean(path)
+}
+
+// track snapshots path for the session unless it already has.
+func (t *ChangeTracker) track(sessionID, path string) {
+	t.mu.Lock()
+	for _, file := range t.sessions[sessionID] {
+		if file.path == path {
+			t.mu.Unlock()
+			return
+		}
+	}
+	t.mu.Unlock()
+
+	before, err := t.read(path)
+	if err != nil {
+		// Directories and unreadable paths are left to the tool
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, file := range t.sessions[sessionID] {
+		if file.path == path {
+			return
+		}
+	}
+	t.sessions[sessionID] = append(t.sessions[sessionID], &trackedFile{path: path, before: before})
+}
+
+// errNotAFile is returned by read for directories and other non-regular files.
+var errNotAFile = errors.New("not a regular file")
+
+// read returns the current state of path. A missing file is a state, not an error.
+func (t *ChangeTracker) read(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return fileState{}, errNotAFile
+	}
+
+	state := fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	if info.Size() > int64(t.maxBytes) {
+		state.note = fmt.Sprintf("not diffed: larger than %d bytes", t.maxBytes)
+		return state, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		state.note = "not diffed: binary file"
+		return state, nil
+	}
+	state.content = string(content)
+	return state, nil
+}
+
+// Summary compares each file the session modified with its snapshot and
+// returns the per-file line counts and a combined unified diff. Files that
+// are back to their original contents are left out.
+func (t *ChangeTracker) Summary(sessionID string) ChangeSummary {
+	t.mu.Lock()
+	files := append([]*trackedFile(nil), t.sessions[sessionID]...)
+	t.mu.Unlock()
+
+	var summary ChangeSummary
+	var diff strings.Builder
+	for _, file := range files {
+		after, err := t.read(file.path)
+		if err != nil {
+			continue
+		}
+		change, fileDiff, changed := t.compare(file, after)
+		if !changed {
+			continue
+		}
+		summary.Files = append(summary.Files, change)
+		diff.WriteString(fileDiff)
+	}
+	summary.Diff = diff.String()
+	return summary
+}
+
+// compare describes how a file changed from its snapshot, returning its
+// diff and whether it changed at all.
+func (t *ChangeTracker) compare(file *trackedFile, after fileState) (usecase.FileChange, string, bool) {
+	before := file.before
+	change := usecase.FileChange{Path: t.displayPath(file.path), Status: usecase.FileChangeModified}
+	switch {
+	case !before.exists && !after.exists:
+		return change, "", false
+	case !before.exists:
+		change.Status = usecase.FileChangeAdded
+	case !after.exists:
+		change.Status = usecase.FileChangeDeleted
+	}
+
+	if note := firstNonEmpty(before.note, after.note); note != "" {
+		if before.exists && after.exists && before.size == after.size && before.modTime.Equal(after.modTime) {
+			return change, "", false
+		}
+		change.Note = note
+		return change, "", true
+	}
+	if before.exists == after.exists && before.content == after.content {
+		return change, "", false
+	}
+
+	for _, op := range diffLines(splitLines(before.content), splitLines(after.content)) {
+		switch op.kind {
+		case diffInsert:
+			change.Added++
+		case diffDelete:
+			change.Removed++
+		case diffEqual:
+		}
+	}
+	fileDiff := unifiedDiff(change.Path, before.content, after.content, !before.exists)
+	if !after.exists {
+		fileDiff = strings.Replace(fileDiff, "+++ b/"+change.Path+"\n", "+++ /dev/null\n", 1)
+	}
+	return change, fileDiff, true
+}
+
+// displayPath returns path relative to the root when it is inside it.
+func (t *ChangeTracker) displayPath(path string) string {
+	rel, err := filepath.Rel(t.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ModifiedFiles implements usecase.WorkspaceChangeTracker.
+func (t *ChangeTracker) ModifiedFiles(sessionID string) []usecase.FileChange {
+	return t.Summary(sessionID).Files
+}
+
+// Forget drops a finished session's snapshots.
+func (t *ChangeTracker) Forget(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// String formats the summary as a list of changed files with their line
+// counts followed by the diff.
+func (s ChangeSummary) String() string {
+	if len(s.Files) == 0 {
+		return "No files changed."
+	}
+
+	var added, removed int
+	for _, file := range s.Files {
+		added += file.Added
+		removed += file.Removed
+	}
+	var buf strings.Builder
+	noun := "files"
+	if len(s.Files) == 1 {
+		noun = "file"
+	}
+	fmt.Fprintf(&buf, "%d %s changed, +%d -%d\n", len(s.Files), noun, added, removed)
+	for _, file := range s.Files {
+		if file.Note != "" {
+			fmt.Fprintf(&buf, "  %-8s %s (%s)\n", file.Status, file.Path, file.Note)
+			continue
+		}
+		fmt.Fprintf(&buf, "  %-8s %s +%d -%d\n", file.Status, file.Path, file.Added, file.Removed)
+	}
+	if s.Diff != "" {
+		buf.WriteString("\n" + s.Diff)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
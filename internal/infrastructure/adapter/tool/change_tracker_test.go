@@ -0,0 +1,119 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTrackingAdapter creates an executor with a change tracker working in a
+// temp dir holding main.go and big.txt.
+func newTrackingAdapter(t *testing.T, maxBytes int) (*tool.ExecutorAdapter, *tool.ChangeTracker, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	writeTestFile(t, filepath.Join(dir, "big.txt"), strings.Repeat("x", 100)+"\n")
+
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool { return true })
+	adapter.SetBashOptions(tool.BashOptions{WorkingDir: dir})
+	tracker := tool.NewChangeTracker(dir, maxBytes)
+	adapter.Use(tracker.Middleware())
+	return adapter, tracker, dir
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runIn executes a tool in a session, failing the test on error.
+func runIn(t *testing.T, adapter *tool.ExecutorAdapter, sessionID, name, input string) {
+	t.Helper()
+	ctx := port.WithSessionID(context.Background(), sessionID)
+	if _, err := adapter.ExecuteTool(ctx, name, input); err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+}
+
+func TestChangeTracker_SummarizesEdits(t *testing.T) {
+	adapter, tracker, _ := newTrackingAdapter(t, 0)
+
+	runIn(t, adapter, "session-1", "edit_file",
+		`{"path": "main.go", "old_str": "println(\"hi\")", "new_str": "println(\"hello\")\n\tprintln(\"bye\")"}`)
+	runIn(t, adapter, "session-1", "edit_file", `{"path": "docs/notes.md", "old_str": "", "new_str": "# Notes\n"}`)
+	// The second edit of main.go is still measured against the first snapshot
+	runIn(t, adapter, "session-1", "edit_file", `{"path": "main.go", "old_str": "package main", "new_str": "package main // entry"}`)
+
+	summary := tracker.Summary("session-1")
+	want := []usecase.FileChange{
+		{Path: "main.go", Status: usecase.FileChangeModified, Added: 3, Removed: 2},
+		{Path: "docs/notes.md", Status: usecase.FileChangeAdded, Added: 1},
+	}
+	if !reflect.DeepEqual(summary.Files, want) {
+		t.Errorf("Files = %+v, want %+v", summary.Files, want)
+	}
+	wantDiff := "--- a/main.go\n+++ b/main.go\n@@ -1,5 +1,6 @@\n" +
+		"-package main\n+package main // entry\n \n func main() {\n" +
+		"-\tprintln(\"hi\")\n+\tprintln(\"hello\")\n+\tprintln(\"bye\")\n }\n" +
+		"--- /dev/null\n+++ b/docs/notes.md\n@@ -0,0 +1,1 @@\n+# Notes\n"
+	if summary.Diff != wantDiff {
+		t.Errorf("Diff =\n%s\nwant\n%s", summary.Diff, wantDiff)
+	}
+	if !strings.HasPrefix(summary.String(), "2 files changed, +4 -2\n  modified main.go +3 -2\n  added    docs/notes.md +1 -0\n\n--- a/main.go") {
+		t.Errorf("String() =\n%s", summary.String())
+	}
+
+	if got := tracker.Summary("session-2"); len(got.Files) != 0 || got.String() != "No files changed." {
+		t.Errorf("another session's summary = %+v", got)
+	}
+	tracker.Forget("session-1")
+	if got := tracker.ModifiedFiles("session-1"); len(got) != 0 {
+		t.Errorf("ModifiedFiles() after Forget = %+v", got)
+	}
+}
+
+func TestChangeTracker_BashDeclaredPaths(t *testing.T) {
+	adapter, tracker, _ := newTrackingAdapter(t, 64)
+
+	runIn(t, adapter, "session-1", "bash",
+		`{"command": "rm main.go && echo y >> big.txt && echo new > out.txt", "dangerous": true, "modified_paths": ["main.go", "big.txt"]}`)
+	// Undeclared files, and reverted ones, are not listed
+	runIn(t, adapter, "session-1", "batch_tool", `{"invocations": [
+		{"tool_name": "edit_file", "arguments": {"path": "out.txt", "old_str": "new", "new_str": "newer"}},
+		{"tool_name": "edit_file", "arguments": {"path": "out.txt", "old_str": "newer", "new_str": "new"}}
+	]}`)
+
+	summary := tracker.Summary("session-1")
+	want := []usecase.FileChange{
+		{Path: "main.go", Status: usecase.FileChangeDeleted, Removed: 5},
+		{Path: "big.txt", Status: usecase.FileChangeModified, Note: "not diffed: larger than 64 bytes"},
+	}
+	if !reflect.DeepEqual(summary.Files, want) {
+		t.Errorf("Files = %+v, want %+v", summary.Files, want)
+	}
+	if !strings.HasPrefix(summary.Diff, "--- a/main.go\n+++ /dev/null\n@@ -1,5 +0,0 @@\n-package main\n") {
+		t.Errorf("Diff =\n%s", summary.Diff)
+	}
+}
+
+func TestChangeTracker_IgnoresCallsWithoutSession(t *testing.T) {
+	adapter, tracker, _ := newTrackingAdapter(t, 0)
+	if _, err := adapter.ExecuteTool(context.Background(), "edit_file",
+		`{"path": "main.go", "old_str": "hi", "new_str": "hey"}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.Summary(""); len(got.Files) != 0 {
+		t.Errorf("Summary() = %+v, want no tracked files", got)
+	}
+}
@@ -0,0 +1,197 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change.
+const diffContextLines = 3
+
+// maxDiffMatrixCells bounds the line-matching table. Files whose changed region
+// is larger are diffed as a single replacement of that region.
+const maxDiffMatrixCells = 4_000_000
+
+// diffOpKind identifies an edit operation on a single line.
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a single line of an edit script.
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// unifiedDiff returns a unified diff (as produced by "diff -u") that turns
+// oldContent into newContent, with "a/" and "b/" prefixed paths. A new file
+// (isNew) is shown as a diff against /dev/null. It returns "" when the contents
+// are identical.
+func unifiedDiff(path, oldContent, newContent string, isNew bool) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var buf strings.Builder
+	if isNew {
+		buf.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&buf, "--- a/%s\n", path)
+	}
+	fmt.Fprintf(&buf, "+++ b/%s\n", path)
+
+	for _, h := range buildHunks(ops) {
+		writeHunk(&buf, ops, h)
+	}
+	return buf.String()
+}
+
+// splitLines splits content into lines without their line terminators.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines computes a line edit script from a to b. Common leading and
+// trailing lines are matched directly and the remainder by longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{diffEqual, line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{diffEqual, line})
+	}
+	return ops
+}
+
+// diffMiddle diffs the changed region of two files using a longest common
+// subsequence table, falling back to delete-all/insert-all when it is too large.
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffMatrixCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{diffDelete, line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{diffInsert, line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		}
+	}
+	return ops
+}
+
+// hunk is a range of the edit script [start, end) shown together.
+type hunk struct {
+	start, end int
+}
+
+// buildHunks groups changed lines into hunks with surrounding context, merging
+// hunks whose context would overlap.
+func buildHunks(ops []diffOp) []hunk {
+	var hunks []hunk
+	for i, op := range ops {
+		if op.kind == diffEqual {
+			continue
+		}
+		start := max(i-diffContextLines, 0)
+		end := min(i+1+diffContextLines, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, hunk{start, end})
+	}
+	return hunks
+}
+
+// writeHunk writes a hunk header and its lines.
+func writeHunk(buf *strings.Builder, ops []diffOp, h hunk) {
+	// Line numbers of the hunk start in the old and new files
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:h.start] {
+		if op.kind != diffInsert {
+			oldLine++
+		}
+		if op.kind != diffDelete {
+			newLine++
+		}
+	}
+
+	var oldCount, newCount int
+	var body strings.Builder
+	for _, op := range ops[h.start:h.end] {
+		switch op.kind {
+		case diffEqual:
+			oldCount++
+			newCount++
+			body.WriteString(" " + op.line + "\n")
+		case diffDelete:
+			oldCount++
+			body.WriteString("-" + op.line + "\n")
+		case diffInsert:
+			newCount++
+			body.WriteString("+" + op.line + "\n")
+		}
+	}
+
+	// By convention an empty range starts at the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	buf.WriteString(body.String())
+}
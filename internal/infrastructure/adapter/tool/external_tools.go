@@ -0,0 +1,53 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// externalToolHandler executes an external tool with its JSON input. It is an
+// alias so that callers can register tools without importing this package.
+type externalToolHandler = func(ctx context.Context, input json.RawMessage) (string, error)
+
+// RegisterExternalTool registers a tool executed by handler rather than by the
+// executor itself, such as one served by an MCP server. Its executions go
+// through the same middleware chain, timeouts, metrics, and tracing as the
+// built-in tools. UnregisterTool removes it again.
+//
+// Returns:
+//   - error: if the tool is invalid or a tool with its name is already registered
+func (a *ExecutorAdapter) RegisterExternalTool(tool entity.Tool, handler externalToolHandler) error {
+	if err := tool.Validate(); err != nil {
+		return fmt.Errorf("invalid tool: %w", err)
+	}
+	if handler == nil {
+		return fmt.Errorf("invalid tool %s: no handler", tool.Name)
+	}
+
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+
+	if _, exists := a.tools[tool.Name]; exists {
+		return fmt.Errorf("%w: %s", port.ErrToolAlreadyRegistered, tool.Name)
+	}
+	a.setExternalHandlerLocked(tool.Name, handler)
+	a.setToolLocked(tool)
+	return nil
+}
+
+// executeExternal runs the named external tool, if there is one, with the
+// handler it had when the execution started.
+func (a *ExecutorAdapter) executeExternal(ctx context.Context, name string, input json.RawMessage) (string, error) {
+	snapshot, ok := ctx.Value(toolSnapshotKey{}).(toolSnapshot)
+	if !ok {
+		snapshot = a.snapshot()
+	}
+	handler, ok := snapshot.external[name]
+	if !ok {
+		return "", fmt.Errorf("tool not found: %s", name)
+	}
+	return handler(ctx, input)
+}
@@ -0,0 +1,328 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// fetchURLToolName is the name of the allowlisted HTTP GET tool.
+const fetchURLToolName = "fetch_url"
+
+// DefaultFetchURLMaxBytes is how much of a response body fetch_url reads
+// before truncating it.
+const DefaultFetchURLMaxBytes = 1 << 20
+
+// maxFetchURLRedirects is how many redirects fetch_url follows.
+const maxFetchURLRedirects = 5
+
+// ErrDomainNotAllowed is returned when fetch_url is asked for, or redirected
+// to, a host outside its domain allowlist.
+var ErrDomainNotAllowed = errors.New("domain not in the fetch_url allowlist")
+
+// FetchURLOptions configures the fetch_url tool.
+type FetchURLOptions struct {
+	// AllowedDomains are the hosts fetch_url may request; an entry also
+	// allows its subdomains. Empty denies every request. Allowlisted hosts
+	// are trusted, so they may resolve to internal addresses.
+	AllowedDomains []string
+
+	// MaxBytes caps how much of a response body is read.
+	MaxBytes int
+
+	// Timeout limits each request, redirects included.
+	Timeout time.Duration
+}
+
+// fetchURLInput represents the input for the fetch_url tool.
+type fetchURLInput struct {
+	URL string `json:"url"`
+	Raw bool   `json:"raw,omitempty"`
+}
+
+// fetchURLTool returns the fetch_url tool definition.
+func fetchURLTool() entity.Tool {
+	return entity.Tool{
+		ID:   fetchURLToolName,
+		Name: fetchURLToolName,
+		Description: "Fetches a URL with an HTTP GET, such as a runbook linked from an alert or a service's " +
+			"/health endpoint, and returns the status, content type, and body. Only configured domains " +
+			"can be fetched. HTML is converted to readable text with headings and code blocks kept; " +
+			"set raw for JSON APIs or to see the markup.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The http or https URL to fetch",
+					"examples":    []interface{}{"https://runbooks.example.com/disk-full", "http://api.internal.example.com/health"},
+				},
+				"raw": map[string]interface{}{
+					"type":        "boolean",
+					"default":     false,
+					"description": "Return the body exactly as received instead of converting HTML to text",
+				},
+			},
+			"required": []string{"url"},
+		},
+		RequiredFields: []string{"url"},
+	}
+}
+
+// SetFetchURLOptions sets the domain allowlist, response size cap, and
+// timeout of the fetch_url tool. Zero MaxBytes and Timeout keep the defaults.
+func (a *ExecutorAdapter) SetFetchURLOptions(opts FetchURLOptions) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultFetchURLMaxBytes
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultFetchTimeout
+	}
+	opts.AllowedDomains = slices.Clone(opts.AllowedDomains)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fetchURLOptions = opts
+}
+
+// checkFetchURL returns an error unless u is an http or https URL without
+// credentials whose host is in allowed.
+func checkFetchURL(u *url.URL, allowed []string) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL must use http or https, got %q", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("URL must not contain credentials")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("URL must have a host")
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("%w: no domains are allowed (configure tools.fetch_url.allowed_domains)", ErrDomainNotAllowed)
+	}
+	for _, domain := range allowed {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDomainNotAllowed, host)
+}
+
+// executeFetchURL fetches an allowlisted URL and returns its status, content
+// type, and body, converting HTML to text unless raw is set.
+func (a *ExecutorAdapter) executeFetchURL(ctx context.Context, input json.RawMessage) (string, error) {
+	var in fetchURLInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal fetch_url input: %w", err)
+	}
+	target, err := url.Parse(in.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	a.mu.RLock()
+	opts := a.fetchURLOptions
+	a.mu.RUnlock()
+	if err := checkFetchURL(target, opts.AllowedDomains); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("User-Agent", "code-editing-agent/1.0")
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchURLRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchURLRedirects)
+			}
+			if err := checkFetchURL(req.URL, opts.AllowedDomains); err != nil {
+				return fmt.Errorf("redirect to %s refused: %w", req.URL.Redacted(), err)
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("request timed out after %v", opts.Timeout)
+		}
+		return "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(opts.MaxBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	truncated := len(body) > opts.MaxBytes
+	if truncated {
+		body = []byte(truncateUTF8(string(body), opts.MaxBytes))
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	var content string
+	switch {
+	case !utf8.Valid(body):
+		content = fmt.Sprintf("[binary content omitted: %d bytes]", len(body))
+	case !in.Raw && strings.Contains(strings.ToLower(contentType), "text/html"):
+		content, err = htmlToReadableText(string(body))
+		if err != nil {
+			return "", err
+		}
+	default:
+		content = string(body)
+	}
+	if truncated {
+		content += fmt.Sprintf("\n\n[response truncated: only the first %d bytes were read]", opts.MaxBytes)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Status: %s\n", resp.Status)
+	if contentType != "" {
+		fmt.Fprintf(&result, "Content-Type: %s\n", contentType)
+	}
+	if final := resp.Request.URL; final.String() != target.String() {
+		fmt.Fprintf(&result, "Final URL: %s\n", final.Redacted())
+	}
+	result.WriteString("\n")
+	result.WriteString(content)
+	return result.String(), nil
+}
+
+// skippedHTMLElements hold no readable text.
+var skippedHTMLElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true, "iframe": true,
+}
+
+// blockHTMLElements start and end a paragraph of text.
+var blockHTMLElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true, "main": true,
+	"nav": true, "aside": true, "ul": true, "ol": true, "dl": true, "table": true, "blockquote": true,
+	"form": true, "figure": true, "hr": true,
+}
+
+// htmlToReadableText converts an HTML page to text for the model: scripts
+// and styles are dropped, headings become Markdown headings, list items
+// bullets, and preformatted blocks fenced code blocks with their whitespace
+// kept. Other whitespace is collapsed.
+func htmlToReadableText(page string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	var b strings.Builder
+	renderHTMLNode(&b, doc, false)
+	return tidyRenderedText(b.String()), nil
+}
+
+// renderHTMLNode writes the text of n and its children to b.
+func renderHTMLNode(b *strings.Builder, n *html.Node, inPre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if inPre {
+			b.WriteString(n.Data)
+			return
+		}
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			b.WriteString(" ")
+			return
+		}
+		if strings.TrimLeftFunc(n.Data, isHTMLSpace) != n.Data {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+		if strings.TrimRightFunc(n.Data, isHTMLSpace) != n.Data {
+			b.WriteString(" ")
+		}
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	children := func(inPre bool) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderHTMLNode(b, c, inPre)
+		}
+	}
+	switch tag := n.Data; {
+	case n.Type == html.DocumentNode:
+		children(inPre)
+	case skippedHTMLElements[tag]:
+	case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+		b.WriteString("\n\n" + strings.Repeat("#", int(tag[1]-'0')) + " ")
+		children(false)
+		b.WriteString("\n\n")
+	case tag == "pre":
+		var code strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderHTMLNode(&code, c, true)
+		}
+		b.WriteString("\n\n```\n" + strings.TrimRight(code.String(), "\n") + "\n```\n\n")
+	case tag == "code" && !inPre:
+		b.WriteString("`")
+		children(false)
+		b.WriteString("`")
+	case tag == "br":
+		b.WriteString("\n")
+	case tag == "li":
+		b.WriteString("\n- ")
+		children(inPre)
+	case tag == "tr" || tag == "dt" || tag == "dd":
+		b.WriteString("\n")
+		children(inPre)
+	case tag == "td" || tag == "th":
+		children(inPre)
+		b.WriteString(" ")
+	case blockHTMLElements[tag]:
+		b.WriteString("\n\n")
+		children(inPre)
+		b.WriteString("\n\n")
+	default:
+		children(inPre)
+	}
+}
+
+// isHTMLSpace reports whether r is whitespace.
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// tidyRenderedText collapses spaces within lines and runs of blank lines,
+// leaving fenced code blocks untouched.
+func tidyRenderedText(text string) string {
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		if !inFence {
+			line = strings.Join(strings.Fields(line), " ")
+		}
+		if line == "```" {
+			inFence = !inFence
+		}
+		if line == "" && !inFence && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,217 @@
+package tool
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoFixture is returned by a replaying FixtureExecutor for a tool call
+// that has no recorded fixture.
+var ErrNoFixture = errors.New("no fixture recorded")
+
+// FixtureMode selects what a FixtureExecutor does with tool calls.
+type FixtureMode int
+
+const (
+	// FixtureReplay answers tool calls from recorded fixtures without running
+	// any tool.
+	FixtureReplay FixtureMode = iota
+	// FixtureRecord runs tool calls and records their results as fixtures.
+	FixtureRecord
+)
+
+// ToolFixture is the recorded result of one tool call, stored as JSON in a
+// fixture directory.
+type ToolFixture struct {
+	Tool   string          `json:"tool"`
+	Input  json.RawMessage `json:"input"`
+	Output string          `json:"output"`
+	Error  string          `json:"error,omitempty"` // The tool's error, if it failed
+}
+
+// FixtureExecutor is a decorator that records tool results to, or replays
+// them from, a fixture directory, so investigations can run their full AI
+// loop against canned data. Tool definitions always come from the base
+// executor, so the AI is offered the same tools in both modes.
+//
+// Each fixture is stored as <dir>/<tool>-<hash>.json, keyed by the tool name
+// and a hash of its canonicalized input: inputs that are equal as JSON, in
+// any key order or encoding, share a fixture. Recording a call again
+// replaces its fixture.
+type FixtureExecutor struct {
+	base   port.ToolExecutor
+	dir    string
+	mode   FixtureMode
+	mu     sync.Mutex
+	misses int
+}
+
+// NewFixtureExecutor creates a FixtureExecutor over base that records to or
+// replays from dir. Recording creates dir if needed.
+func NewFixtureExecutor(base port.ToolExecutor, dir string, mode FixtureMode) *FixtureExecutor {
+	return &FixtureExecutor{base: base, dir: dir, mode: mode}
+}
+
+// RegisterTool delegates to the base executor.
+func (f *FixtureExecutor) RegisterTool(tool entity.Tool) error {
+	return f.base.RegisterTool(tool)
+}
+
+// UnregisterTool delegates to the base executor.
+func (f *FixtureExecutor) UnregisterTool(name string) error {
+	return f.base.UnregisterTool(name)
+}
+
+// ListTools delegates to the base executor.
+func (f *FixtureExecutor) ListTools() ([]entity.Tool, error) {
+	return f.base.ListTools()
+}
+
+// GetTool delegates to the base executor.
+func (f *FixtureExecutor) GetTool(name string) (entity.Tool, bool) {
+	return f.base.GetTool(name)
+}
+
+// ValidateToolInput delegates to the base executor.
+func (f *FixtureExecutor) ValidateToolInput(name string, input interface{}) error {
+	return f.base.ValidateToolInput(name, input)
+}
+
+// ExecuteTool replays the call's fixture, or runs the call on the base
+// executor and records it, depending on the mode. A replayed call without a
+// fixture fails with ErrNoFixture; a replayed failure returns the recorded
+// error message.
+func (f *FixtureExecutor) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
+	canonical, err := canonicalToolInput(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid input for %s: %w", name, err)
+	}
+	path := filepath.Join(f.dir, FixtureKey(name, canonical)+".json")
+
+	if f.mode == FixtureRecord {
+		output, execErr := f.base.ExecuteTool(ctx, name, input)
+		fixture := ToolFixture{Tool: name, Input: canonical, Output: output}
+		if execErr != nil {
+			fixture.Error = execErr.Error()
+		}
+		if err := writeFixture(path, fixture); err != nil {
+			return output, errors.Join(execErr, fmt.Errorf("failed to record fixture for %s: %w", name, err))
+		}
+		return output, execErr
+	}
+
+	fixture, err := readFixture(path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.mu.Lock()
+		f.misses++
+		f.mu.Unlock()
+		return "", fmt.Errorf("%w for %s with input %s (expected %s)", ErrNoFixture, name, canonical, path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if fixture.Error != "" {
+		return fixture.Output, errors.New(fixture.Error)
+	}
+	return fixture.Output, nil
+}
+
+// Misses returns how many replayed calls had no fixture.
+func (f *FixtureExecutor) Misses() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.misses
+}
+
+// FixtureKey returns the file name, without extension, of the fixture of a
+// call to the named tool with canonical input.
+func FixtureKey(name string, canonical json.RawMessage) string {
+	sum := sha256.Sum256(append([]byte(name+"\x00"), canonical...))
+	safeName := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	return safeName + "-" + hex.EncodeToString(sum[:8])
+}
+
+// canonicalToolInput re-encodes a tool input of any supported form as
+// compact JSON with sorted object keys and numbers kept as written.
+func canonicalToolInput(input interface{}) (json.RawMessage, error) {
+	var data []byte
+	switch in := input.(type) {
+	case nil:
+		data = []byte("{}")
+	case json.RawMessage:
+		data = in
+	case []byte:
+		data = in
+	case string:
+		data = []byte(in)
+	default:
+		var err error
+		if data, err = json.Marshal(input); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// readFixture reads a fixture file.
+func readFixture(path string) (ToolFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ToolFixture{}, err
+	}
+	var fixture ToolFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return ToolFixture{}, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// writeFixture writes a fixture file through a temporary file, so concurrent
+// recordings of the same call never leave a partial fixture.
+func writeFixture(path string, fixture ToolFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,129 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptedExecutor answers tool calls from a table keyed by tool name and
+// counts how often it ran.
+type scriptedExecutor struct {
+	outputs map[string]string
+	errs    map[string]error
+	calls   int
+}
+
+func (s *scriptedExecutor) RegisterTool(entity.Tool) error { return nil }
+func (s *scriptedExecutor) UnregisterTool(string) error    { return nil }
+func (s *scriptedExecutor) ListTools() ([]entity.Tool, error) {
+	return []entity.Tool{{Name: "bash"}, {Name: "read_file"}}, nil
+}
+func (s *scriptedExecutor) GetTool(name string) (entity.Tool, bool) {
+	return entity.Tool{Name: name}, true
+}
+func (s *scriptedExecutor) ValidateToolInput(string, interface{}) error {
+	return nil
+}
+
+func (s *scriptedExecutor) ExecuteTool(_ context.Context, name string, _ interface{}) (string, error) {
+	s.calls++
+	return s.outputs[name], s.errs[name]
+}
+
+func TestFixtureExecutor_RecordThenReplay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	ctx := context.Background()
+	base := &scriptedExecutor{
+		outputs: map[string]string{"bash": "Filesystem  Use%\n/dev/sda1   97%", "read_file": "partial"},
+		errs:    map[string]error{"read_file": errors.New("permission denied")},
+	}
+
+	type call struct {
+		name  string
+		input interface{}
+	}
+	recorded := []call{
+		{"bash", map[string]interface{}{"command": "df -h", "timeout": 30}},
+		{"read_file", map[string]interface{}{"path": "/var/log/syslog"}},
+	}
+	type outcome struct {
+		output string
+		err    string
+	}
+	var want []outcome
+	recorder := NewFixtureExecutor(base, dir, FixtureRecord)
+	for _, c := range recorded {
+		output, err := recorder.ExecuteTool(ctx, c.name, c.input)
+		o := outcome{output: output}
+		if err != nil {
+			o.err = err.Error()
+		}
+		want = append(want, o)
+	}
+	if base.calls != 2 {
+		t.Fatalf("recording ran %d tools, want 2", base.calls)
+	}
+
+	// The same inputs in other encodings and key orders replay the fixtures
+	replayed := []call{
+		{"bash", json.RawMessage(`{"timeout": 30, "command": "df -h"}`)},
+		{"read_file", `{"path":"/var/log/syslog"}`},
+	}
+	replayer := NewFixtureExecutor(base, dir, FixtureReplay)
+	for i, c := range replayed {
+		output, err := replayer.ExecuteTool(ctx, c.name, c.input)
+		got := outcome{output: output}
+		if err != nil {
+			got.err = err.Error()
+		}
+		if got != want[i] {
+			t.Errorf("replay of %s = %+v, want the recorded %+v", c.name, got, want[i])
+		}
+	}
+	if base.calls != 2 {
+		t.Errorf("replay ran %d tools, want none", base.calls-2)
+	}
+	if tools, _ := replayer.ListTools(); len(tools) != 2 {
+		t.Errorf("ListTools() = %v, want the base executor's tools", tools)
+	}
+}
+
+func TestFixtureExecutor_ReplayWithoutFixture(t *testing.T) {
+	dir := t.TempDir()
+	base := &scriptedExecutor{}
+	replayer := NewFixtureExecutor(base, dir, FixtureReplay)
+
+	_, err := replayer.ExecuteTool(context.Background(), "bash", map[string]interface{}{"command": "uptime"})
+	if !errors.Is(err, ErrNoFixture) {
+		t.Fatalf("ExecuteTool() error = %v, want ErrNoFixture", err)
+	}
+	if !strings.Contains(err.Error(), `{"command":"uptime"}`) {
+		t.Errorf("error %q should name the unmatched input", err)
+	}
+	if replayer.Misses() != 1 || base.calls != 0 {
+		t.Errorf("Misses() = %d, base calls = %d; want 1 and 0", replayer.Misses(), base.calls)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("replay wrote %d files, want none", len(entries))
+	}
+}
+
+func TestFixtureKey_Canonicalizes(t *testing.T) {
+	a, _ := canonicalToolInput(map[string]interface{}{"b": 1.5, "a": []interface{}{"x"}})
+	b, _ := canonicalToolInput(`{"a": ["x"], "b": 1.5}`)
+	if string(a) != string(b) || FixtureKey("bash", a) != FixtureKey("bash", b) {
+		t.Errorf("equal inputs got different keys: %s vs %s", a, b)
+	}
+	if FixtureKey("bash", a) == FixtureKey("read_file", a) {
+		t.Error("the same input to different tools should get different keys")
+	}
+	if key := FixtureKey("../evil", a); strings.ContainsAny(key, `/\.`) {
+		t.Errorf("FixtureKey() = %q, want a plain file name", key)
+	}
+}
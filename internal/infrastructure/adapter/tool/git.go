@@ -0,0 +1,478 @@
+package tool
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Names of the git tools.
+const (
+	gitStatusToolName = "git_status"
+	gitDiffToolName   = "git_diff"
+	gitCommitToolName = "git_commit"
+	gitPushToolName   = "git_push"
+)
+
+// DefaultGitMaxDiffBytes is how much of a diff git_diff returns unless
+// configured otherwise.
+const DefaultGitMaxDiffBytes = 64 << 10
+
+// DefaultGitProtectedBranches are the branches git_push refuses to push to
+// unless configured otherwise.
+var DefaultGitProtectedBranches = []string{"main", "master", "release/*"}
+
+// emptyTreeHash is git's hash of the empty tree, diffed against before the
+// first commit.
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// GitOptions configures the git tools.
+type GitOptions struct {
+	// WorkingDir is the workspace: git runs there, and git_commit refuses
+	// paths outside it. Empty means the agent's current directory.
+	WorkingDir string
+
+	// MaxDiffBytes caps the diff git_diff returns.
+	MaxDiffBytes int
+
+	// AllowPush registers git_push.
+	AllowPush bool
+
+	// ProtectedBranches are path.Match patterns of branches git_push
+	// refuses to push to.
+	ProtectedBranches []string
+}
+
+// GitAvailable reports whether the git tools can work in dir: git is on
+// PATH and dir is inside a git work tree.
+func GitAvailable(dir string) bool {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false
+	}
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// EnableGit registers git_status, git_diff, and git_commit, plus git_push when
+// opts.AllowPush is set. Zero MaxDiffBytes and nil ProtectedBranches keep the
+// defaults.
+func (a *ExecutorAdapter) EnableGit(opts GitOptions) {
+	if opts.MaxDiffBytes <= 0 {
+		opts.MaxDiffBytes = DefaultGitMaxDiffBytes
+	}
+	if opts.ProtectedBranches == nil {
+		opts.ProtectedBranches = DefaultGitProtectedBranches
+	}
+	opts.ProtectedBranches = slices.Clone(opts.ProtectedBranches)
+	if opts.WorkingDir == "" {
+		opts.WorkingDir = "."
+	}
+	if abs, err := filepath.Abs(opts.WorkingDir); err == nil {
+		opts.WorkingDir = abs
+	}
+
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.gitOptions = opts
+	a.setToolLocked(gitStatusTool())
+	a.setToolLocked(gitDiffTool())
+	a.setToolLocked(gitCommitTool())
+	if opts.AllowPush {
+		a.setToolLocked(gitPushTool())
+	} else {
+		a.deleteToolLocked(gitPushToolName)
+	}
+}
+
+// DisableGit unregisters the git tools, as when the workspace is no longer a
+// git work tree.
+func (a *ExecutorAdapter) DisableGit() {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	for _, name := range []string{gitStatusToolName, gitDiffToolName, gitCommitToolName, gitPushToolName} {
+		a.deleteToolLocked(name)
+	}
+}
+
+// gitDiffInput represents the input for the git_diff tool.
+type gitDiffInput struct {
+	Staged bool     `json:"staged,omitempty"`
+	Paths  []string `json:"paths,omitempty"`
+}
+
+// gitCommitInput represents the input for the git_commit tool.
+type gitCommitInput struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// gitPushInput represents the input for the git_push tool.
+type gitPushInput struct {
+	Remote string `json:"remote,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// gitStatusTool returns the git_status tool definition.
+func gitStatusTool() entity.Tool {
+	return entity.Tool{
+		ID:   gitStatusToolName,
+		Name: gitStatusToolName,
+		Description: "Shows the current branch, its upstream, and the modified, staged, and untracked files " +
+			"of the workspace's git repository in short porcelain format.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}
+}
+
+// gitDiffTool returns the git_diff tool definition.
+func gitDiffTool() entity.Tool {
+	return entity.Tool{
+		ID:   gitDiffToolName,
+		Name: gitDiffToolName,
+		Description: "Shows the unified diff of uncommitted changes to tracked files: unstaged changes by " +
+			"default, or staged ones. Large diffs are truncated; narrow them with paths.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"staged": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Diff the staged changes instead of the unstaged ones",
+				},
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only diff these files or directories",
+				},
+			},
+		},
+	}
+}
+
+// gitCommitTool returns the git_commit tool definition.
+func gitCommitTool() entity.Tool {
+	return entity.Tool{
+		ID:   gitCommitToolName,
+		Name: gitCommitToolName,
+		Description: "Commits the current contents of exactly the listed files, new and deleted ones included, " +
+			"with the given message. Other staged changes are left staged and uncommitted. The user " +
+			"reviews the diff before the commit is made.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "The commit message: a short summary line, optionally followed by a blank line and a body",
+				},
+				"files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "The files to commit, inside the workspace",
+					"examples":    []interface{}{[]interface{}{"main.go", "docs/usage.md"}},
+				},
+			},
+			"required": []string{"message", "files"},
+		},
+		RequiredFields: []string{"message", "files"},
+	}
+}
+
+// gitPushTool returns the git_push tool definition.
+func gitPushTool() entity.Tool {
+	return entity.Tool{
+		ID:   gitPushToolName,
+		Name: gitPushToolName,
+		Description: "Pushes a local branch to the branch of the same name on a remote, without force. " +
+			"Protected branches such as main are refused; push a feature branch instead.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"remote": map[string]interface{}{
+					"type":        "string",
+					"description": "The remote to push to; defaults to origin",
+				},
+				"branch": map[string]interface{}{
+					"type":        "string",
+					"description": "The branch to push; defaults to the current branch",
+				},
+			},
+		},
+	}
+}
+
+// gitOptionsSnapshot returns the git options under the read lock.
+func (a *ExecutorAdapter) gitOptionsSnapshot() GitOptions {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.gitOptions
+}
+
+// runGit runs git with args in dir and returns its trimmed standard output,
+// with standard error in the error when it fails. Pathspecs are always
+// literal, so a path can never act as a glob or magic pathspec.
+func (a *ExecutorAdapter) runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--literal-pathspecs"}, args...)...)
+	cmd.Dir = dir
+	a.mu.RLock()
+	allowedEnv := a.bashOptions.AllowedEnv
+	a.mu.RUnlock()
+	cmd.Env = bashEnv(os.Environ(), allowedEnv, map[string]string{"GIT_TERMINAL_PROMPT": "0"})
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// workspacePaths returns paths relative to root, refusing any outside it.
+func workspacePaths(root string, paths []string) ([]string, error) {
+	rel := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			return nil, errors.New("empty path")
+		}
+		abs := p
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root, abs)
+		}
+		r, err := filepath.Rel(root, filepath.Clean(abs))
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path %q is outside the workspace", p)
+		}
+		rel = append(rel, r)
+	}
+	return rel, nil
+}
+
+// executeGitStatus runs git status in short format.
+func (a *ExecutorAdapter) executeGitStatus(ctx context.Context) (string, error) {
+	opts := a.gitOptionsSnapshot()
+	out, err := a.runGit(ctx, opts.WorkingDir, "status", "--short", "--branch")
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(out, "\n") {
+		out += "\nNothing to commit, working tree clean"
+	}
+	return out, nil
+}
+
+// executeGitDiff runs git diff, truncating it to the configured size.
+func (a *ExecutorAdapter) executeGitDiff(ctx context.Context, input json.RawMessage) (string, error) {
+	var in gitDiffInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal git_diff input: %w", err)
+	}
+	opts := a.gitOptionsSnapshot()
+	paths, err := workspacePaths(opts.WorkingDir, in.Paths)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if in.Staged {
+		args = append(args, "--cached")
+	}
+	out, err := a.runGit(ctx, opts.WorkingDir, append(append(args, "--"), paths...)...)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		if in.Staged {
+			return "No staged changes", nil
+		}
+		return "No unstaged changes", nil
+	}
+	if len(out) <= opts.MaxDiffBytes {
+		return out, nil
+	}
+	cut := opts.MaxDiffBytes
+	if i := strings.LastIndexByte(out[:cut], '\n'); i > 0 {
+		cut = i
+	}
+	return fmt.Sprintf("%s\n[diff truncated: showing %d of %d bytes; narrow it with paths]", out[:cut], cut, len(out)), nil
+}
+
+// executeGitCommit commits exactly the listed files after the user approves
+// their diff.
+func (a *ExecutorAdapter) executeGitCommit(ctx context.Context, input json.RawMessage) (string, error) {
+	var in gitCommitInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal git_commit input: %w", err)
+	}
+	if strings.TrimSpace(in.Message) == "" {
+		return "", errors.New("commit message is required")
+	}
+	if len(in.Files) == 0 {
+		return "", errors.New("files is required: list the files to commit")
+	}
+	opts := a.gitOptionsSnapshot()
+	files, err := workspacePaths(opts.WorkingDir, in.Files)
+	if err != nil {
+		return "", err
+	}
+
+	untracked, err := a.untrackedFiles(ctx, opts.WorkingDir, files)
+	if err != nil {
+		return "", err
+	}
+	diff, err := a.commitDiff(ctx, opts.WorkingDir, files, untracked)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", errors.New("nothing to commit: the listed files have no changes")
+	}
+	if err := a.checkGitCommitConfirmation(in.Message, diff); err != nil {
+		return "", err
+	}
+
+	if len(untracked) > 0 {
+		if _, err := a.runGit(ctx, opts.WorkingDir, append([]string{"add", "--"}, untracked...)...); err != nil {
+			return "", err
+		}
+	}
+	// --only commits the listed paths as they are in the working tree,
+	// leaving anything else in the index alone
+	args := append([]string{"commit", "--quiet", "--only", "--message", in.Message, "--"}, files...)
+	if _, err := a.runGit(ctx, opts.WorkingDir, args...); err != nil {
+		return "", err
+	}
+	return a.runGit(ctx, opts.WorkingDir, "show", "--stat", "--no-color", "--format=Committed %h: %s", "HEAD")
+}
+
+// untrackedFiles returns the files git does not track, refusing paths that
+// are neither tracked nor regular files.
+func (a *ExecutorAdapter) untrackedFiles(ctx context.Context, root string, files []string) ([]string, error) {
+	var untracked []string
+	for _, f := range files {
+		out, err := a.runGit(ctx, root, "ls-files", "--", f)
+		if err != nil {
+			return nil, err
+		}
+		if out != "" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, f))
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is neither tracked by git nor a file", f)
+		}
+		untracked = append(untracked, f)
+	}
+	return untracked, nil
+}
+
+// commitDiff returns the diff a commit of files would make: tracked files
+// against HEAD, untracked ones as new files.
+func (a *ExecutorAdapter) commitDiff(ctx context.Context, root string, files, untracked []string) (string, error) {
+	var tracked []string
+	for _, f := range files {
+		if !slices.Contains(untracked, f) {
+			tracked = append(tracked, f)
+		}
+	}
+
+	var diff strings.Builder
+	if len(tracked) > 0 {
+		base := "HEAD"
+		if _, err := a.runGit(ctx, root, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+			base = emptyTreeHash
+		}
+		out, err := a.runGit(ctx, root, append([]string{"diff", "--no-color", "--no-ext-diff", base, "--"}, tracked...)...)
+		if err != nil {
+			return "", err
+		}
+		if out != "" {
+			diff.WriteString(out + "\n")
+		}
+	}
+	for _, f := range untracked {
+		content, err := os.ReadFile(filepath.Join(root, f))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		diff.WriteString(unifiedDiff(filepath.ToSlash(f), "", string(content), true))
+	}
+	return diff.String(), nil
+}
+
+// checkGitCommitConfirmation shows the commit's diff through the file edit
+// confirmation callback, if set, as commits change the repository.
+func (a *ExecutorAdapter) checkGitCommitConfirmation(message, diff string) error {
+	if a.fileEditConfirmCallback == nil {
+		return nil
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	if !a.fileEditConfirmCallback("git commit: "+subject, diff) {
+		return fmt.Errorf("commit denied by user: %s", subject)
+	}
+	return nil
+}
+
+// executeGitPush pushes a branch to its namesake on a remote unless the
+// branch is protected.
+func (a *ExecutorAdapter) executeGitPush(ctx context.Context, input json.RawMessage) (string, error) {
+	var in gitPushInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal git_push input: %w", err)
+	}
+	opts := a.gitOptionsSnapshot()
+	if !opts.AllowPush {
+		return "", errors.New("git_push is not enabled")
+	}
+
+	remote := in.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	remotes, err := a.runGit(ctx, opts.WorkingDir, "remote")
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(strings.Fields(remotes), remote) {
+		return "", fmt.Errorf("unknown remote %q", remote)
+	}
+
+	branch := in.Branch
+	if branch == "" {
+		if branch, err = a.runGit(ctx, opts.WorkingDir, "symbolic-ref", "--quiet", "--short", "HEAD"); err != nil {
+			return "", errors.New("HEAD is detached: name the branch to push")
+		}
+	}
+	if _, err := a.runGit(ctx, opts.WorkingDir, "check-ref-format", "--branch", branch); err != nil ||
+		strings.HasPrefix(branch, "-") {
+		return "", fmt.Errorf("invalid branch name %q", branch)
+	}
+	for _, pattern := range opts.ProtectedBranches {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return "", fmt.Errorf("refusing to push to protected branch %q (matches %q)", branch, pattern)
+		}
+	}
+
+	command := fmt.Sprintf("git push %s %s", remote, branch)
+	if err := a.checkCommandConfirmation(command, "Push "+branch+" to "+remote, false); err != nil {
+		return "", err
+	}
+	ref := "refs/heads/" + branch
+	if _, err := a.runGit(ctx, opts.WorkingDir, "push", "--quiet", remote, ref+":"+ref); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Pushed %s to %s", branch, remote), nil
+}
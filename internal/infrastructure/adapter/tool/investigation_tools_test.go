@@ -189,6 +189,8 @@ func TestCompleteInvestigationTool_SchemaRecommendedActionsProperty(t *testing.T
 
 	recommendedActionsMap := h.assertPropertyExists(properties, "recommended_actions")
 	h.assertPropertyType(recommendedActionsMap, "recommended_actions", "array")
+	items := h.assertPropertyExists(recommendedActionsMap, "items")
+	h.assertPropertyType(items, "recommended_actions items", "object")
 }
 
 func TestCompleteInvestigationTool_RequiredFields(t *testing.T) {
@@ -282,6 +284,21 @@ func TestCompleteInvestigationTool_ValidInput(t *testing.T) {
 				"recommended_actions": []string{"Action 1", "Action 2", "Action 3"},
 			},
 		},
+		{
+			name: "valid input with structured actions",
+			input: map[string]interface{}{
+				"confidence": 0.9,
+				"findings":   []string{"/var is full"},
+				"recommended_actions": []interface{}{
+					map[string]interface{}{
+						"description": "Force a log rotation",
+						"command":     "logrotate -f /etc/logrotate.conf",
+						"risk":        "low",
+					},
+					map[string]interface{}{"description": "Grow the volume", "risk": "high", "requires_approval": true},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,385 @@
+package tool
+
+import (
+	"bufio"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// k8sInspectToolName is the name of the read-only Kubernetes inspection tool.
+const k8sInspectToolName = "k8s_inspect"
+
+// k8sInspect actions.
+const (
+	k8sActionPods   = "pods"
+	k8sActionEvents = "events"
+	k8sActionLogs   = "logs"
+)
+
+// DefaultK8sMaxLogLines is how many log lines k8s_inspect returns at most
+// unless configured otherwise.
+const DefaultK8sMaxLogLines = 500
+
+// Output limits of the k8s_inspect tool.
+const (
+	defaultK8sLogLines = 100
+	maxK8sLogBytes     = 1 << 20
+	maxK8sEvents       = 50
+	maxK8sPods         = 200
+)
+
+// ErrNamespaceNotAllowed is returned when k8s_inspect is asked about a
+// namespace outside its allowlist.
+var ErrNamespaceNotAllowed = errors.New("namespace not in the k8s_inspect allowlist")
+
+// K8sInspectOptions configures the k8s_inspect tool.
+type K8sInspectOptions struct {
+	// AllowedNamespaces are the namespaces k8s_inspect may read; "*" allows
+	// every namespace. Empty denies every request.
+	AllowedNamespaces []string
+
+	// MaxLogLines caps the tail_lines of a logs request.
+	MaxLogLines int
+}
+
+// NewK8sClient returns a Kubernetes client for kubeconfig, using its current
+// context unless kubeContext is set. An empty kubeconfig uses the in-cluster
+// service account.
+func NewK8sClient(kubeconfig, kubeContext string) (kubernetes.Interface, error) {
+	var (
+		restConfig *rest.Config
+		err        error
+	)
+	if kubeconfig == "" {
+		restConfig, err = rest.InClusterConfig()
+	} else {
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+		).ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// EnableK8sInspect registers the k8s_inspect tool, which reads the cluster
+// through client. A zero MaxLogLines keeps the default.
+func (a *ExecutorAdapter) EnableK8sInspect(client kubernetes.Interface, opts K8sInspectOptions) {
+	if opts.MaxLogLines <= 0 {
+		opts.MaxLogLines = DefaultK8sMaxLogLines
+	}
+	opts.AllowedNamespaces = slices.Clone(opts.AllowedNamespaces)
+
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.k8sClient = client
+	a.k8sOptions = opts
+	a.setToolLocked(k8sInspectTool())
+}
+
+// k8sInspectInput represents the input for the k8s_inspect tool.
+type k8sInspectInput struct {
+	Action        string `json:"action"`
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	LabelSelector string `json:"label_selector,omitempty"`
+	Container     string `json:"container,omitempty"`
+	TailLines     int    `json:"tail_lines,omitempty"`
+	Previous      bool   `json:"previous,omitempty"`
+}
+
+// k8sInspectTool returns the k8s_inspect tool definition.
+func k8sInspectTool() entity.Tool {
+	return entity.Tool{
+		ID:   k8sInspectToolName,
+		Name: k8sInspectToolName,
+		Description: "Reads Kubernetes state without changing it: list pods in a namespace with their status " +
+			"and restart counts (pods), recent events in a namespace or for one object (events), or the " +
+			"last lines of a pod's container logs (logs). Only configured namespaces can be read. Prefer " +
+			"it over running kubectl through bash.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []interface{}{k8sActionPods, k8sActionEvents, k8sActionLogs},
+					"description": "What to read",
+				},
+				"namespace": map[string]interface{}{
+					"type":        "string",
+					"description": "The namespace to read",
+					"examples":    []interface{}{"production"},
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "The object whose events to show, or the pod whose logs to fetch (required for logs)",
+					"examples":    []interface{}{"web-7d9f8c6b5-x2k4q"},
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "For events: only events about objects of this kind",
+					"examples":    []interface{}{"Pod", "Deployment"},
+				},
+				"label_selector": map[string]interface{}{
+					"type":        "string",
+					"description": "For pods: only pods matching this label selector",
+					"examples":    []interface{}{"app=web"},
+				},
+				"container": map[string]interface{}{
+					"type":        "string",
+					"description": "For logs: the container, required when the pod has more than one",
+				},
+				"tail_lines": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"default":     defaultK8sLogLines,
+					"description": "For logs: how many of the most recent lines to return",
+				},
+				"previous": map[string]interface{}{
+					"type":        "boolean",
+					"default":     false,
+					"description": "For logs: read the previous, crashed instance of the container",
+				},
+			},
+			"required": []string{"action", "namespace"},
+		},
+		RequiredFields: []string{"action", "namespace"},
+	}
+}
+
+// executeK8sInspect runs one read-only k8s_inspect action.
+func (a *ExecutorAdapter) executeK8sInspect(ctx context.Context, input json.RawMessage) (string, error) {
+	var in k8sInspectInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal k8s_inspect input: %w", err)
+	}
+
+	a.mu.RLock()
+	client, opts := a.k8sClient, a.k8sOptions
+	a.mu.RUnlock()
+	if client == nil {
+		return "", errors.New("k8s_inspect is not configured")
+	}
+	if in.Namespace == "" {
+		return "", errors.New("namespace is required")
+	}
+	if !slices.Contains(opts.AllowedNamespaces, "*") && !slices.Contains(opts.AllowedNamespaces, in.Namespace) {
+		return "", fmt.Errorf("%w: %s", ErrNamespaceNotAllowed, in.Namespace)
+	}
+
+	switch in.Action {
+	case k8sActionPods:
+		return inspectPods(ctx, client, in)
+	case k8sActionEvents:
+		return inspectEvents(ctx, client, in)
+	case k8sActionLogs:
+		return inspectLogs(ctx, client, in, opts.MaxLogLines)
+	default:
+		return "", fmt.Errorf("unknown action %q (want pods, events, or logs)", in.Action)
+	}
+}
+
+// inspectPods summarizes the pods of a namespace, one block per pod.
+func inspectPods(ctx context.Context, client kubernetes.Interface, in k8sInspectInput) (string, error) {
+	pods, err := client.CoreV1().Pods(in.Namespace).List(ctx, metav1.ListOptions{LabelSelector: in.LabelSelector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Sprintf("No pods in namespace %s.", in.Namespace), nil
+	}
+	items := pods.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "namespace: %s\npods: %d\n", in.Namespace, len(items))
+	for i := range items {
+		if i == maxK8sPods {
+			fmt.Fprintf(&b, "[%d more pods omitted; use label_selector to narrow the list]\n", len(items)-maxK8sPods)
+			break
+		}
+		writePodSummary(&b, &items[i])
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// writePodSummary writes one pod as an indented YAML-like block.
+func writePodSummary(b *strings.Builder, pod *corev1.Pod) {
+	ready, restarts := 0, int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+
+	fmt.Fprintf(b, "- name: %s\n", pod.Name)
+	fmt.Fprintf(b, "  phase: %s\n", pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		fmt.Fprintf(b, "  reason: %s\n", pod.Status.Reason)
+	}
+	fmt.Fprintf(b, "  ready: %d/%d\n", ready, len(pod.Spec.Containers))
+	fmt.Fprintf(b, "  restarts: %d\n", restarts)
+	if pod.Spec.NodeName != "" {
+		fmt.Fprintf(b, "  node: %s\n", pod.Spec.NodeName)
+	}
+	if pod.Status.StartTime != nil {
+		fmt.Fprintf(b, "  started: %s\n", pod.Status.StartTime.UTC().Format(time.RFC3339))
+	}
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return
+	}
+	b.WriteString("  containers:\n")
+	for _, status := range pod.Status.ContainerStatuses {
+		fmt.Fprintf(b, "    - %s: %s", status.Name, containerStateSummary(status.State))
+		if status.RestartCount > 0 {
+			fmt.Fprintf(b, ", %d restarts", status.RestartCount)
+		}
+		if last := status.LastTerminationState.Terminated; last != nil {
+			fmt.Fprintf(b, ", last exit %s (code %d) at %s",
+				last.Reason, last.ExitCode, last.FinishedAt.UTC().Format(time.RFC3339))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// containerStateSummary describes a container state in a few words.
+func containerStateSummary(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "running"
+	case state.Waiting != nil:
+		return "waiting (" + state.Waiting.Reason + ")"
+	case state.Terminated != nil:
+		return fmt.Sprintf("terminated (%s, code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	default:
+		return "unknown"
+	}
+}
+
+// inspectEvents lists the most recent events of a namespace, optionally only
+// those about one object or kind of object, oldest first.
+func inspectEvents(ctx context.Context, client kubernetes.Interface, in k8sInspectInput) (string, error) {
+	events, err := client.CoreV1().Events(in.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var matched []corev1.Event
+	for _, event := range events.Items {
+		if in.Name != "" && event.InvolvedObject.Name != in.Name {
+			continue
+		}
+		if in.Kind != "" && !strings.EqualFold(event.InvolvedObject.Kind, in.Kind) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	if len(matched) == 0 {
+		return fmt.Sprintf("No matching events in namespace %s.", in.Namespace), nil
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return eventTime(&matched[i]).Before(eventTime(&matched[j])) })
+	omitted := 0
+	if len(matched) > maxK8sEvents {
+		omitted = len(matched) - maxK8sEvents
+		matched = matched[omitted:]
+	}
+
+	var b strings.Builder
+	if omitted > 0 {
+		fmt.Fprintf(&b, "[%d older events omitted]\n", omitted)
+	}
+	for i := range matched {
+		event := &matched[i]
+		fmt.Fprintf(&b, "- %s %s %s %s/%s", eventTime(event).UTC().Format(time.RFC3339),
+			event.Type, event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name)
+		if event.Count > 1 {
+			fmt.Fprintf(&b, " (x%d)", event.Count)
+		}
+		fmt.Fprintf(&b, ": %s\n", strings.TrimSpace(event.Message))
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// eventTime returns when an event last happened.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.FirstTimestamp.Time
+	}
+}
+
+// inspectLogs returns the last lines of a pod container's logs.
+func inspectLogs(ctx context.Context, client kubernetes.Interface, in k8sInspectInput, maxLines int) (string, error) {
+	if in.Name == "" {
+		return "", errors.New("name is required for logs")
+	}
+	tailLines := int64(in.TailLines)
+	if tailLines <= 0 {
+		tailLines = defaultK8sLogLines
+	}
+	tailLines = min(tailLines, int64(maxLines))
+
+	stream, err := client.CoreV1().Pods(in.Namespace).GetLogs(in.Name, &corev1.PodLogOptions{
+		Container: in.Container,
+		TailLines: &tailLines,
+		Previous:  in.Previous,
+	}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch logs: %w", err)
+	}
+	defer stream.Close()
+
+	// The API server applies tail_lines; the byte cap guards against huge lines
+	data, err := io.ReadAll(io.LimitReader(stream, maxK8sLogBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	truncated := len(data) > maxK8sLogBytes
+	if truncated {
+		data = data[:maxK8sLogBytes]
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(strings.ToValidUTF8(string(data), "�")))
+	scanner.Buffer(make([]byte, 64<<10), maxK8sLogBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) > int(tailLines) {
+		lines = lines[len(lines)-int(tailLines):]
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("No log lines for pod %s.", in.Name), nil
+	}
+	result := strings.Join(lines, "\n")
+	if truncated {
+		result += fmt.Sprintf("\n\n[logs truncated: only the first %d bytes were read]", maxK8sLogBytes)
+	}
+	return result, nil
+}
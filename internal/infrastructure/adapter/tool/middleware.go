@@ -0,0 +1,241 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCommandBlocked is returned by SafetyMiddleware when a bash command
+// matches a blocked pattern.
+var ErrCommandBlocked = errors.New("command blocked by safety policy")
+
+// ToolFunc executes the named tool with JSON input and returns its output.
+type ToolFunc func(ctx context.Context, name string, input json.RawMessage) (string, error)
+
+// ToolMiddleware wraps a ToolFunc to add behavior around every tool execution.
+type ToolMiddleware func(next ToolFunc) ToolFunc
+
+// Use appends middlewares to the executor's chain. Middlewares run in
+// registration order: the first one registered is the outermost. The chain
+// always starts with the executor's own logging, timing, and input validation,
+// so middlewares added here see only valid input for registered tools.
+func (a *ExecutorAdapter) Use(middlewares ...ToolMiddleware) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.middlewares = append(a.middlewares, middlewares...)
+}
+
+// chain returns the executor's tools wrapped in its middlewares.
+func (a *ExecutorAdapter) chain() ToolFunc {
+	a.mu.RLock()
+	middlewares := a.middlewares
+	a.mu.RUnlock()
+
+	fn := ToolFunc(a.executeByName)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
+
+// auditRecord collects attributes for a tool execution's log record from the
+// middlewares below the logging middleware.
+type auditRecord struct {
+	mu    sync.Mutex
+	attrs []any
+}
+
+type auditRecordKey struct{}
+
+// addAuditAttrs adds key-value pairs to the log record of the tool execution
+// in ctx. It does nothing outside the logging middleware.
+func addAuditAttrs(ctx context.Context, args ...any) {
+	if record, ok := ctx.Value(auditRecordKey{}).(*auditRecord); ok {
+		record.mu.Lock()
+		record.attrs = append(record.attrs, args...)
+		record.mu.Unlock()
+	}
+}
+
+// logExecution is the middleware that logs each tool execution, with the
+// attributes the inner middlewares added, through the execution context's
+// logger or the executor's fallback logger.
+func (a *ExecutorAdapter) logExecution(next ToolFunc) ToolFunc {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		record := &auditRecord{}
+		result, err := next(context.WithValue(ctx, auditRecordKey{}, record), name, input)
+
+		args := append([]any{"tool", name}, record.attrs...)
+		args = append(args, "error", err)
+		port.LoggerFromContext(ctx, a.logger).DebugContext(ctx, "Tool executed", args...)
+		return result, err
+	}
+}
+
+// validateInput is the middleware that rejects input not matching the tool's schema.
+func (a *ExecutorAdapter) validateInput(next ToolFunc) ToolFunc {
+	return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+		tool, exists := a.lookupTool(ctx, name)
+		if !exists {
+			return "", fmt.Errorf("tool not found: %s", name)
+		}
+		if err := tool.ValidateInput(input); err != nil {
+			return "", fmt.Errorf("invalid input for tool %s: %w", name, err)
+		}
+		return next(ctx, name, input)
+	}
+}
+
+// TimingMiddleware adds how long the rest of the chain took to the
+// execution's log record as duration_ms.
+func TimingMiddleware() ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			start := time.Now()
+			result, err := next(ctx, name, input)
+			addAuditAttrs(ctx, "duration_ms", time.Since(start).Milliseconds())
+			return result, err
+		}
+	}
+}
+
+// RecoveryMiddleware turns a panicking tool into an error result, logging the
+// panic and its stack through the execution context's logger or logger.
+// A nil logger uses slog.Default().
+func RecoveryMiddleware(logger *slog.Logger) ToolMiddleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (result string, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					port.LoggerFromContext(ctx, logger).ErrorContext(ctx, "Tool panicked",
+						"tool", name, "panic", r, "stack", string(debug.Stack()))
+					result, err = "", fmt.Errorf("tool %s panicked: %v", name, r)
+				}
+			}()
+			return next(ctx, name, input)
+		}
+	}
+}
+
+// OutputLimitMiddleware truncates tool output longer than maxBytes, noting
+// how much was cut so the model can narrow its request. A limit of zero or
+// less leaves output untouched.
+func OutputLimitMiddleware(maxBytes int) ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		if maxBytes <= 0 {
+			return next
+		}
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			result, err := next(ctx, name, input)
+			if len(result) <= maxBytes {
+				return result, err
+			}
+			addAuditAttrs(ctx, "truncated_bytes", len(result)-maxBytes)
+			return truncateUTF8(result, maxBytes) + fmt.Sprintf(
+				"\n\n[output truncated: showing %d of %d bytes]", maxBytes, len(result)), err
+		}
+	}
+}
+
+// SafetyMiddleware rejects bash commands containing any of the blocked
+// patterns with ErrCommandBlocked before they reach the shell or any
+// confirmation prompt, including bash invocations inside batch_tool.
+// Whitespace in the command is normalized to spaces before matching, as the
+// investigation safety enforcer does. A run that set its own patterns with
+// port.WithBlockedCommands is checked against those instead.
+func SafetyMiddleware(blockedCommands []string) ToolMiddleware {
+	return ReloadableSafetyMiddleware(NewBlockedCommandList(blockedCommands))
+}
+
+// ReloadableSafetyMiddleware is SafetyMiddleware checking the patterns list
+// holds when each call is made, so they can be replaced while tools run.
+func ReloadableSafetyMiddleware(list *BlockedCommandList) ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			patterns, ok := port.BlockedCommandsFromContext(ctx)
+			if !ok {
+				patterns = list.Patterns()
+			}
+			if pattern := blockedPattern(name, input, patterns); pattern != "" {
+				addAuditAttrs(ctx, "blocked_pattern", pattern)
+				return "", fmt.Errorf("%w: %q", ErrCommandBlocked, pattern)
+			}
+			return next(ctx, name, input)
+		}
+	}
+}
+
+// BlockedCommandList holds the blocked command patterns of a
+// ReloadableSafetyMiddleware. It is safe for concurrent use.
+type BlockedCommandList struct {
+	patterns atomic.Pointer[[]string]
+}
+
+// NewBlockedCommandList creates a list holding patterns.
+func NewBlockedCommandList(patterns []string) *BlockedCommandList {
+	l := &BlockedCommandList{}
+	l.Set(patterns)
+	return l
+}
+
+// Patterns returns the patterns currently blocked.
+func (l *BlockedCommandList) Patterns() []string {
+	return *l.patterns.Load()
+}
+
+// Set replaces the blocked patterns for calls made from now on.
+func (l *BlockedCommandList) Set(patterns []string) {
+	patterns = slices.Clone(patterns)
+	l.patterns.Store(&patterns)
+}
+
+// blockedPattern returns the first blocked pattern found in the bash commands
+// the tool call would run, or "".
+func blockedPattern(name string, input json.RawMessage, blockedCommands []string) string {
+	switch name {
+	case "bash":
+		var in bashInput
+		if err := json.Unmarshal(input, &in); err == nil {
+			return matchBlockedCommand(in.Command, blockedCommands)
+		}
+	case "batch_tool":
+		var in batchToolInput
+		if err := json.Unmarshal(input, &in); err == nil {
+			for _, inv := range in.Invocations {
+				if pattern := blockedPattern(inv.ToolName, inv.Arguments, blockedCommands); pattern != "" {
+					return pattern
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// matchBlockedCommand returns the first blocked pattern cmd contains, or "".
+func matchBlockedCommand(cmd string, blockedCommands []string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, cmd)
+	for _, pattern := range blockedCommands {
+		if pattern != "" && strings.Contains(normalized, pattern) {
+			return pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// recordingMiddleware appends "<label>:before" and "<label>:after" to calls
+// around the rest of the chain.
+func recordingMiddleware(label string, calls *[]string) tool.ToolMiddleware {
+	return func(next tool.ToolFunc) tool.ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			*calls = append(*calls, label+":before")
+			result, err := next(ctx, name, input)
+			*calls = append(*calls, label+":after")
+			return result, err
+		}
+	}
+}
+
+func TestExecutorAdapter_Use_RunsMiddlewaresInRegistrationOrder(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	var calls []string
+	adapter.Use(
+		recordingMiddleware("first", &calls),
+		recordingMiddleware("second", &calls),
+		recordingMiddleware("third", &calls),
+	)
+
+	if _, err := adapter.ExecuteTool(context.Background(), "list_files", `{"path": "."}`); err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+
+	want := []string{
+		"first:before", "second:before", "third:before",
+		"third:after", "second:after", "first:after",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestExecutorAdapter_Use_InvalidInputNeverReachesMiddlewares(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	var calls []string
+	adapter.Use(recordingMiddleware("only", &calls))
+
+	if _, err := adapter.ExecuteTool(context.Background(), "bash", `{}`); err == nil {
+		t.Fatal("expected a validation error for bash without a command")
+	}
+	if len(calls) != 0 {
+		t.Errorf("middlewares ran for invalid input: %v", calls)
+	}
+}
+
+func TestRecoveryMiddleware_PanicBecomesErrorResult(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	var calls []string
+	adapter.Use(
+		recordingMiddleware("outer", &calls),
+		tool.RecoveryMiddleware(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		recordingMiddleware("inner", &calls),
+		func(tool.ToolFunc) tool.ToolFunc {
+			return func(context.Context, string, json.RawMessage) (string, error) {
+				panic("boom")
+			}
+		},
+	)
+
+	result, err := adapter.ExecuteTool(context.Background(), "list_files", `{"path": "."}`)
+
+	if err == nil || !strings.Contains(err.Error(), "tool list_files panicked: boom") {
+		t.Fatalf("expected a panic error, got result %q and error %v", result, err)
+	}
+	if result != "" {
+		t.Errorf("result = %q, want empty", result)
+	}
+	// The panic skips the rest of the inner middleware but not the outer one
+	want := []string{"outer:before", "inner:before", "outer:after"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestOutputLimitMiddleware_TruncatesLongOutput(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.Use(
+		tool.OutputLimitMiddleware(5),
+		func(tool.ToolFunc) tool.ToolFunc {
+			return func(context.Context, string, json.RawMessage) (string, error) {
+				return "0123456789", nil
+			}
+		},
+	)
+
+	result, err := adapter.ExecuteTool(context.Background(), "list_files", `{"path": "."}`)
+	if err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+	if want := "01234\n\n[output truncated: showing 5 of 10 bytes]"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+}
+
+func TestSafetyMiddleware_BlocksCommandsBeforeConfirmation(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	confirmed := 0
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool {
+		confirmed++
+		return true
+	})
+	adapter.Use(tool.SafetyMiddleware([]string{"rm -rf"}))
+
+	inputs := map[string]string{
+		"bash":       `{"command": "rm\t-rf /tmp/x", "dangerous": false}`,
+		"batch_tool": `{"invocations": [{"tool_name": "bash", "arguments": {"command": "rm -rf /tmp/x", "dangerous": false}}]}`,
+	}
+	for name, input := range inputs {
+		if _, err := adapter.ExecuteTool(context.Background(), name, input); !errors.Is(err, tool.ErrCommandBlocked) {
+			t.Errorf("%s: error = %v, want ErrCommandBlocked", name, err)
+		}
+	}
+	if confirmed != 0 {
+		t.Errorf("blocked commands reached confirmation %d times", confirmed)
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "bash", `{"command": "echo ok", "dangerous": false}`); err != nil {
+		t.Errorf("allowed command failed: %v", err)
+	}
+}
+
+func TestReloadableSafetyMiddleware(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool { return true })
+	list := tool.NewBlockedCommandList([]string{"rm -rf"})
+	adapter.Use(tool.ReloadableSafetyMiddleware(list))
+
+	run := func(ctx context.Context, command string) error {
+		_, err := adapter.ExecuteTool(ctx, "bash", `{"command": "`+command+`", "dangerous": false}`)
+		return err
+	}
+	if err := run(context.Background(), "echo curl"); err != nil {
+		t.Fatalf("allowed command failed: %v", err)
+	}
+
+	// Replacing the list applies to the next call
+	list.Set([]string{"curl"})
+	if err := run(context.Background(), "echo curl"); !errors.Is(err, tool.ErrCommandBlocked) {
+		t.Errorf("after Set: error = %v, want ErrCommandBlocked", err)
+	}
+	if err := run(context.Background(), "echo rm -rf"); err != nil {
+		t.Errorf("after Set: unblocked pattern still blocked: %v", err)
+	}
+
+	// A run's own patterns take the list's place
+	runCtx := port.WithBlockedCommands(context.Background(), []string{"rm -rf"})
+	if err := run(runCtx, "echo curl"); err != nil {
+		t.Errorf("run patterns: command blocked by the list: %v", err)
+	}
+	if err := run(runCtx, "echo rm -rf"); !errors.Is(err, tool.ErrCommandBlocked) {
+		t.Errorf("run patterns: error = %v, want ErrCommandBlocked", err)
+	}
+}
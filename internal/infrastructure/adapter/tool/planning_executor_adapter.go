@@ -3,10 +3,12 @@ package tool
 import (
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -17,8 +19,9 @@ import (
 type PlanModeConfirmCallback func(reason string) bool
 
 // PlanningExecutorAdapter is a decorator that wraps a ToolExecutor and adds plan mode support.
-// In plan mode, mutating tool executions are written to plan files instead of being executed.
-// Read-only tools (read_file, list_files) are still executed normally.
+// In plan mode, mutating tool executions are refused with an error explaining plan mode, so the
+// agent writes its proposed changes to the plan file instead. Read-only tools (read_file,
+// list_files, ask_user) and read-only bash commands are still executed normally.
 type PlanningExecutorAdapter struct {
 	baseExecutor                *ExecutorAdapter
 	fileManager                 port.FileManager
@@ -54,6 +57,16 @@ func (p *PlanningExecutorAdapter) SetCommandConfirmationCallback(cb CommandConfi
 	p.baseExecutor.SetCommandConfirmationCallback(cb)
 }
 
+// SetUserPromptCallback sets the callback for the ask_user tool on the base executor.
+func (p *PlanningExecutorAdapter) SetUserPromptCallback(cb UserPromptCallback) {
+	p.baseExecutor.SetUserPromptCallback(cb)
+}
+
+// SetFileEditConfirmationCallback sets the callback for file edit confirmation on the base executor.
+func (p *PlanningExecutorAdapter) SetFileEditConfirmationCallback(cb FileEditConfirmationCallback) {
+	p.baseExecutor.SetFileEditConfirmationCallback(cb)
+}
+
 // SetPlanMode sets the plan mode for a given session.
 // When enabling plan mode, it also creates the plans directory.
 func (p *PlanningExecutorAdapter) SetPlanMode(sessionID string, enabled bool) {
@@ -85,6 +98,11 @@ func (p *PlanningExecutorAdapter) UnregisterTool(name string) error {
 	return p.baseExecutor.UnregisterTool(name)
 }
 
+// AddToolRegistryObserver delegates to the base executor.
+func (p *PlanningExecutorAdapter) AddToolRegistryObserver(observer port.ToolRegistryObserver) {
+	p.baseExecutor.AddToolRegistryObserver(observer)
+}
+
 // ListTools delegates to the base executor.
 func (p *PlanningExecutorAdapter) ListTools() ([]entity.Tool, error) {
 	return p.baseExecutor.ListTools()
@@ -103,8 +121,18 @@ func (p *PlanningExecutorAdapter) ValidateToolInput(name string, input interface
 // isReadOnlyTool returns true if the tool is read-only and should always execute.
 func isReadOnlyTool(name string) bool {
 	readOnlyTools := map[string]bool{
-		"read_file":  true,
-		"list_files": true,
+		"read_file":            true,
+		"list_files":           true,
+		fetchURLToolName:       true,
+		queryLogsToolName:      true,
+		k8sInspectToolName:     true,
+		promQLToolName:         true,
+		systemSnapshotToolName: true,
+		gitStatusToolName:      true,
+		gitDiffToolName:        true,
+		askUserToolName:        true,
+		projectInfoToolName:    true,
+		requestToolName:        true,
 	}
 	return readOnlyTools[name]
 }
@@ -122,7 +150,7 @@ func (p *PlanningExecutorAdapter) ExecuteTool(ctx context.Context, name string,
 	// Check if we're in plan mode for this session
 	if sessionID != "" && p.IsPlanMode(sessionID) {
 		if !p.isAllowedInPlanMode(name, input) {
-			return p.getPlanBlockedMessage(sessionID, name), nil
+			return "", p.planBlockedError(sessionID, name)
 		}
 	}
 
@@ -166,54 +194,161 @@ func (p *PlanningExecutorAdapter) handleEnterPlanMode(ctx context.Context, input
 	}
 
 	return fmt.Sprintf(
-		"Plan mode enabled. Reason: %s\n\nMutating tool executions will now be written to plan files instead of being executed directly. Use :mode normal to exit plan mode.",
+		"Plan mode enabled. Reason: %s\n\nMutating tools are now blocked: explore with read-only tools and write your plan to the plan file instead of making changes. Use :mode normal to exit plan mode.",
 		planInput.Reason,
 	), nil
 }
 
 // isAllowedInPlanMode checks if a tool execution is allowed in plan mode.
-// Allows read-only tools and writes to the plan file (.agent/plans/*.md).
+// Allows read-only tools, read-only bash commands, wait_for file watches and
+// read-only polls, and writes to the plan file (.agent/plans/*.md).
 func (p *PlanningExecutorAdapter) isAllowedInPlanMode(name string, input interface{}) bool {
 	if isReadOnlyTool(name) {
 		return true
 	}
 
-	// Allow edit_file to .agent/plans/*.md
-	if name == "edit_file" {
+	switch name {
+	case "edit_file":
+		// Allow edit_file to .agent/plans/*.md
 		return p.isPlanFileEdit(input)
+	case "bash":
+		// Variables set for the call are checked with the command
+		var in bashInput
+		return decodeToolInput(input, &in) && isReadOnlyBashCommand(safety.CommandWithEnv(in.Command, in.Env))
+	case waitForToolName:
+		// Watching a file only reads; a polled command must be read-only like bash's
+		var waitInput struct {
+			Mode    string `json:"mode"`
+			Command string `json:"command"`
+		}
+		return decodeToolInput(input, &waitInput) &&
+			(waitInput.Mode == waitForModeFile || isReadOnlyBashCommand(waitInput.Command))
+	}
+
+	return false
+}
+
+// readOnlyBashCommands are the programs bash may run in plan mode.
+var readOnlyBashCommands = map[string]bool{
+	"cat": true, "cut": true, "diff": true, "du": true, "echo": true, "file": true,
+	"find": true, "grep": true, "head": true, "ls": true, "pwd": true, "rg": true,
+	"stat": true, "tail": true, "tree": true, "uniq": true, "wc": true, "which": true,
+}
+
+// readOnlyGitSubcommands are the git subcommands bash may run in plan mode.
+var readOnlyGitSubcommands = map[string]bool{
+	"blame": true, "diff": true, "grep": true, "log": true,
+	"ls-files": true, "rev-parse": true, "show": true, "status": true,
+}
+
+// writingOptions are the options of plan mode's programs that write files or
+// run other programs. An option ending in "*" matches any option it prefixes;
+// the others match alone or followed by "=value".
+var writingOptions = map[string][]string{
+	"find": {"-delete", "-exec*", "-ok*", "-fprint*", "-fls"},
+	"git":  {"--output", "-O*", "--open-files-in-pager", "--ext-diff"},
+	"rg":   {"--pre", "--pre-glob"},
+	"tree": {"-o"},
+}
+
+// unquote strips the quotes and backslashes from a shell word, so that a
+// quoted option such as '--pre' or --pr""e is seen as the shell passes it.
+var unquote = strings.NewReplacer(`'`, "", `"`, "", `\`, "")
+
+// isReadOnlyBashCommand reports whether command only reads from the workspace.
+// Each stage of a pipeline must start with an allowlisted program; redirections,
+// command chaining, substitutions, subshells and options that write files or
+// run other programs are refused.
+func isReadOnlyBashCommand(command string) bool {
+	if strings.TrimSpace(command) == "" || strings.ContainsAny(command, "<>;&`()\n") ||
+		strings.Contains(command, "$(") {
+		return false
 	}
 
+	for _, stage := range strings.Split(command, "|") {
+		fields := strings.Fields(stage)
+		if len(fields) == 0 {
+			return false
+		}
+		switch fields[0] {
+		case "git":
+			if len(fields) < 2 || !readOnlyGitSubcommands[fields[1]] {
+				return false
+			}
+		default:
+			if !readOnlyBashCommands[fields[0]] {
+				return false
+			}
+		}
+		for _, arg := range fields[1:] {
+			if isWritingOption(fields[0], unquote.Replace(arg)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isWritingOption reports whether arg is one of program's writingOptions.
+func isWritingOption(program, arg string) bool {
+	for _, option := range writingOptions[program] {
+		if prefix, ok := strings.CutSuffix(option, "*"); ok {
+			if strings.HasPrefix(arg, prefix) {
+				return true
+			}
+		} else if arg == option || strings.HasPrefix(arg, option+"=") {
+			return true
+		}
+	}
 	return false
 }
 
+// decodeToolInput decodes a tool input of any supported form into v.
+func decodeToolInput(input interface{}, v interface{}) bool {
+	var data []byte
+	switch in := input.(type) {
+	case json.RawMessage:
+		data = in
+	case string:
+		data = []byte(in)
+	default:
+		var err error
+		if data, err = json.Marshal(input); err != nil {
+			return false
+		}
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
 // isPlanFileEdit checks if an edit_file input targets a plan file.
-// Plan files are identified by having ".agent/plans/" in the path and ending with ".md".
+// Plan files are identified by lying directly in a ".agent/plans" directory and ending with ".md".
 func (p *PlanningExecutorAdapter) isPlanFileEdit(input interface{}) bool {
 	var editInput struct {
 		Path string `json:"path"`
 	}
-
-	data, err := json.Marshal(input)
-	if err != nil {
+	if !decodeToolInput(input, &editInput) {
 		return false
 	}
 
-	if err := json.Unmarshal(data, &editInput); err != nil {
-		return false
-	}
-
-	// Check if path contains .agent/plans/ and ends with .md
+	// Check the cleaned path's directory is .agent/plans and it ends with .md.
+	// Cleaning first means ".agent/plans/../../x.md" does not pass, and
+	// converting to slashes handles both separators on Windows.
 	// This handles both relative paths (.agent/plans/x.md) and
 	// absolute paths (/tmp/xxx/.agent/plans/x.md)
-	return strings.Contains(editInput.Path, ".agent/plans/") &&
-		strings.HasSuffix(editInput.Path, ".md")
+	cleaned := filepath.ToSlash(filepath.Clean(filepath.FromSlash(editInput.Path)))
+	dir := path.Dir(cleaned)
+	return (dir == ".agent/plans" || strings.HasSuffix(dir, "/.agent/plans")) &&
+		strings.HasSuffix(cleaned, ".md")
 }
 
-// getPlanBlockedMessage returns a message telling the agent to write to the plan file instead.
-func (p *PlanningExecutorAdapter) getPlanBlockedMessage(sessionID, toolName string) string {
+// planBlockedError returns the error for a tool refused in plan mode, telling the agent
+// to write to the plan file instead.
+func (p *PlanningExecutorAdapter) planBlockedError(sessionID, toolName string) error {
 	planPath := fmt.Sprintf(".agent/plans/%s.md", sessionID)
-	return fmt.Sprintf(
-		"[PLAN MODE] Tool '%s' is blocked in plan mode. Write your planned changes to %s instead using edit_file.",
+	return fmt.Errorf(
+		"[PLAN MODE] Tool '%s' is blocked in plan mode: propose a plan, do not make changes. "+
+			"Only read-only tools and read-only bash commands (e.g. ls, cat, grep, git status) are allowed. "+
+			"Write your planned changes to %s instead using edit_file.",
 		toolName, planPath,
 	)
 }
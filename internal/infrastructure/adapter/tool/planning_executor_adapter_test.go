@@ -37,17 +37,17 @@ func TestPlanningExecutorAdapter_EditFileBlockedInPlanMode(t *testing.T) {
 		"new_str": "goodbye",
 	}
 
-	result, err := planningExecutor.ExecuteTool(ctx, "edit_file", input)
-	if err != nil {
-		t.Fatalf("ExecuteTool failed: %v", err)
+	_, err := planningExecutor.ExecuteTool(ctx, "edit_file", input)
+	if err == nil {
+		t.Fatal("expected edit_file to be refused in plan mode")
 	}
 
-	// Check result indicates tool was blocked
-	if !strings.Contains(result, "[PLAN MODE]") {
-		t.Errorf("expected PLAN MODE message, got: %s", result)
+	// Check the error explains that the tool was blocked
+	if !strings.Contains(err.Error(), "[PLAN MODE]") {
+		t.Errorf("expected PLAN MODE message, got: %v", err)
 	}
-	if !strings.Contains(result, "blocked") {
-		t.Errorf("expected 'blocked' in message, got: %s", result)
+	if !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("expected 'blocked' in message, got: %v", err)
 	}
 
 	// Verify that the original file was NOT modified
@@ -105,6 +105,31 @@ func TestPlanningExecutorAdapter_PlanFileAllowedInPlanMode(t *testing.T) {
 	}
 }
 
+func TestPlanningExecutorAdapter_IsPlanFileEdit(t *testing.T) {
+	planningExecutor := NewPlanningExecutorAdapter(nil, nil, t.TempDir())
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: ".agent/plans/session.md", want: true},
+		{path: filepath.Join(string(filepath.Separator)+"work", ".agent", "plans", "session.md"), want: true},
+		{path: filepath.Join(".agent", "plans", "session.md"), want: true},
+		{path: "./.agent/plans/../plans/session.md", want: true},
+		// Dot segments are resolved before matching, so they cannot escape the plans directory
+		{path: ".agent/plans/../../main.md", want: false},
+		{path: "docs/.agent/plans/../../../etc/motd.md", want: false},
+		{path: ".agent/plans/session.go", want: false},
+		{path: ".agent/plans-archive/session.md", want: false},
+		{path: "notes/session.md", want: false},
+	}
+	for _, tt := range tests {
+		if got := planningExecutor.isPlanFileEdit(map[string]interface{}{"path": tt.path}); got != tt.want {
+			t.Errorf("isPlanFileEdit(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestPlanningExecutorAdapter_EditFileWithoutPlanMode(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -208,3 +233,90 @@ func TestPlanningExecutorAdapter_SetPlanModeCreatesDirectory(t *testing.T) {
 		t.Error("plans directory should exist after enabling plan mode")
 	}
 }
+
+func TestPlanningExecutorAdapter_BashInPlanMode(t *testing.T) {
+	tempDir := t.TempDir()
+
+	fileManager := file.NewLocalFileManager(tempDir)
+	baseExecutor := NewExecutorAdapter(fileManager)
+	planningExecutor := NewPlanningExecutorAdapter(baseExecutor, fileManager, tempDir)
+
+	sessionID := "test-session-bash"
+	planningExecutor.SetPlanMode(sessionID, true)
+	ctx := port.WithSessionID(context.Background(), sessionID)
+
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{command: "ls -la", allowed: true},
+		{command: "grep -rn foo . | head -5", allowed: true},
+		{command: "git status", allowed: true},
+		{command: "find . -name '*.go'", allowed: true},
+		{command: "touch new.txt", allowed: false},
+		{command: "echo hi > out.txt", allowed: false},
+		{command: "ls && rm -rf x", allowed: false},
+		{command: "cat $(which rm)", allowed: false},
+		{command: "git commit -m x", allowed: false},
+		{command: "find . -name x -delete", allowed: false},
+		{command: "find . -exec rm {} +", allowed: false},
+		{command: "ls | xargs rm", allowed: false},
+		{command: "cat <(rm -rf x)", allowed: false},
+		{command: "ls (rm x)", allowed: false},
+		{command: "rg --pre ./run.sh foo", allowed: false},
+		{command: "rg --pre-glob '*.md' foo", allowed: false},
+		{command: "rg '--pre=./run.sh' foo", allowed: false},
+		{command: `rg --pr""e ./run.sh foo`, allowed: false},
+		{command: "rg -n --max-count 3 foo", allowed: true},
+		{command: "find . -fls out.txt", allowed: false},
+		{command: "git log --output=out.txt", allowed: false},
+		{command: "git diff --output out.txt", allowed: false},
+		{command: "git grep -O foo", allowed: false},
+		{command: "tree -o out.txt", allowed: false},
+	}
+
+	withEnv := map[string]interface{}{"command": "ls", "env": map[string]interface{}{"LANG": "C"}}
+	if planningExecutor.isAllowedInPlanMode("bash", withEnv) {
+		t.Error("isAllowedInPlanMode(bash) allowed a command run with variables set")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			input := map[string]interface{}{"command": tt.command}
+			if got := planningExecutor.isAllowedInPlanMode("bash", input); got != tt.allowed {
+				t.Errorf("isAllowedInPlanMode(bash, %q) = %v, want %v", tt.command, got, tt.allowed)
+			}
+		})
+	}
+
+	_, err := planningExecutor.ExecuteTool(ctx, "bash", map[string]interface{}{"command": "touch new.txt"})
+	if err == nil || !strings.Contains(err.Error(), "read-only bash commands") {
+		t.Errorf("expected plan mode error for mutating bash command, got: %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(tempDir, "new.txt")); !os.IsNotExist(statErr) {
+		t.Errorf("blocked bash command should not run, stat err: %v", statErr)
+	}
+}
+
+func TestPlanningExecutorAdapter_WaitForInPlanMode(t *testing.T) {
+	tempDir := t.TempDir()
+	fileManager := file.NewLocalFileManager(tempDir)
+	planningExecutor := NewPlanningExecutorAdapter(NewExecutorAdapter(fileManager), fileManager, tempDir)
+
+	tests := []struct {
+		name    string
+		input   map[string]interface{}
+		allowed bool
+	}{
+		{name: "file watch", input: map[string]interface{}{"mode": "file", "path": "app.log"}, allowed: true},
+		{name: "read-only poll", input: map[string]interface{}{"mode": "command", "command": "grep -q ready app.log"}, allowed: true},
+		{name: "mutating poll", input: map[string]interface{}{"mode": "command", "command": "touch ready"}, allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planningExecutor.isAllowedInPlanMode("wait_for", tt.input); got != tt.allowed {
+				t.Errorf("isAllowedInPlanMode(wait_for, %v) = %v, want %v", tt.input, got, tt.allowed)
+			}
+		})
+	}
+}
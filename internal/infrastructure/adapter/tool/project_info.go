@@ -0,0 +1,50 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"errors"
+)
+
+// projectInfoToolName is the name of the tool that reports the detected project.
+const projectInfoToolName = "project_info"
+
+// ProjectInfoProvider reports what kind of project the workspace holds: its
+// languages, commands, entry points, and tooling.
+type ProjectInfoProvider interface {
+	ProjectInfo() string
+}
+
+// EnableProjectInfo registers the project_info tool, which reports the
+// project detected in the workspace in more detail than the system prompt.
+func (a *ExecutorAdapter) EnableProjectInfo(provider ProjectInfoProvider) {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.projectInfo = provider
+	a.setToolLocked(projectInfoTool())
+}
+
+// projectInfoTool returns the project_info tool definition.
+func projectInfoTool() entity.Tool {
+	return entity.Tool{
+		ID:   projectInfoToolName,
+		Name: projectInfoToolName,
+		Description: "Reports the project detected at the workspace root: languages, module names, build, " +
+			"test, and lint commands, entry points, package.json scripts, Make targets, containers, and CI " +
+			"configs. Use it before guessing how to build or test the project.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}
+}
+
+// executeProjectInfo reports the detected project.
+func (a *ExecutorAdapter) executeProjectInfo() (string, error) {
+	a.mu.RLock()
+	provider := a.projectInfo
+	a.mu.RUnlock()
+	if provider == nil {
+		return "", errors.New("project detection is not enabled")
+	}
+	return provider.ProjectInfo(), nil
+}
@@ -0,0 +1,376 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// promQLToolName is the name of the Prometheus query tool.
+const promQLToolName = "promql_query"
+
+// DefaultPromQLMaxSeries is how many series promql_query shows unless
+// configured otherwise.
+const DefaultPromQLMaxSeries = 20
+
+// Limits of the promql_query tool.
+const (
+	maxPromQLResponseBytes = 16 << 20
+	maxPromQLRangePoints   = 11000 // Prometheus rejects more points per series
+	defaultPromQLPoints    = 60    // points per series when no step is given
+	promQLSampledValues    = 12    // values listed per range series
+)
+
+// PromQLOptions configures the promql_query tool.
+type PromQLOptions struct {
+	// Endpoint is the Prometheus base URL queried when the investigated alert
+	// has no usable generator URL. Empty means only alert generator URLs are
+	// queried.
+	Endpoint string
+
+	// AllowedHosts are the hosts an alert's generator URL may point at for
+	// promql_query to query that Prometheus instead of Endpoint.
+	AllowedHosts []string
+
+	// MaxSeries caps the series shown per query.
+	MaxSeries int
+
+	// Timeout limits each query.
+	Timeout time.Duration
+}
+
+// EnablePromQL registers the promql_query tool. Zero MaxSeries and Timeout
+// keep the defaults.
+func (a *ExecutorAdapter) EnablePromQL(opts PromQLOptions) {
+	if opts.MaxSeries <= 0 {
+		opts.MaxSeries = DefaultPromQLMaxSeries
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultFetchTimeout
+	}
+	opts.Endpoint = strings.TrimRight(opts.Endpoint, "/")
+	opts.AllowedHosts = slices.Clone(opts.AllowedHosts)
+
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.promQLOptions = opts
+	a.setToolLocked(promQLTool())
+}
+
+// promQLInput represents the input for the promql_query tool.
+type promQLInput struct {
+	Query string `json:"query"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	Step  string `json:"step,omitempty"`
+}
+
+// promQLTool returns the promql_query tool definition.
+func promQLTool() entity.Tool {
+	return entity.Tool{
+		ID:   promQLToolName,
+		Name: promQLToolName,
+		Description: "Runs a PromQL query against Prometheus (the one that fired the alert when known) and " +
+			"returns each series' labels and values. Without start it is an instant query; with start it " +
+			"is a range query summarized per series as min, max, avg, and last plus sampled values.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The PromQL expression",
+					"examples": []interface{}{
+						`100 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[5m])) * 100`,
+					},
+				},
+				"start": map[string]interface{}{
+					"type":        "string",
+					"description": "Start of a range query: an RFC3339 timestamp or a duration ago",
+					"examples":    []interface{}{"1h", "2024-05-01T10:00:00Z"},
+				},
+				"end": map[string]interface{}{
+					"type":        "string",
+					"description": "End of a range query, defaulting to now: an RFC3339 timestamp or a duration ago",
+				},
+				"step": map[string]interface{}{
+					"type":        "string",
+					"description": "Resolution of a range query as a duration; defaults to about 60 points",
+					"examples":    []interface{}{"1m"},
+				},
+			},
+			"required": []string{"query"},
+		},
+		RequiredFields: []string{"query"},
+	}
+}
+
+// promQLResponse is the envelope of the Prometheus HTTP API.
+type promQLResponse struct {
+	Status    string   `json:"status"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// promQLSeries is one series of a vector or matrix result.
+type promQLSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`  // instant: [time, "value"]
+	Values [][]interface{}   `json:"values"` // range: [[time, "value"], ...]
+}
+
+// executePromQL runs an instant or range query. Query failures reported by
+// Prometheus, HTTP errors, and empty results are returned as results so the
+// model can adjust the query.
+func (a *ExecutorAdapter) executePromQL(ctx context.Context, input json.RawMessage) (string, error) {
+	var in promQLInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal promql_query input: %w", err)
+	}
+	if strings.TrimSpace(in.Query) == "" {
+		return "", errors.New("query is required")
+	}
+
+	a.mu.RLock()
+	opts := a.promQLOptions
+	a.mu.RUnlock()
+	endpoint, err := promQLEndpoint(port.AlertGeneratorURLFromContext(ctx), opts)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{"query": {in.Query}}
+	path := "/api/v1/query"
+	var header string
+	if in.Start == "" {
+		if in.End != "" || in.Step != "" {
+			return "", errors.New("end and step need start; omit all three for an instant query")
+		}
+		header = "Instant query: " + in.Query
+	} else {
+		now := time.Now()
+		start, err := parseLogTime(in.Start, now)
+		if err != nil {
+			return "", fmt.Errorf("invalid start: %w", err)
+		}
+		end := now
+		if in.End != "" {
+			if end, err = parseLogTime(in.End, now); err != nil {
+				return "", fmt.Errorf("invalid end: %w", err)
+			}
+		}
+		if !end.After(start) {
+			return "", errors.New("end must be after start")
+		}
+		step, err := promQLStep(in.Step, end.Sub(start))
+		if err != nil {
+			return "", err
+		}
+		path = "/api/v1/query_range"
+		params.Set("start", start.UTC().Format(time.RFC3339))
+		params.Set("end", end.UTC().Format(time.RFC3339))
+		params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+		header = fmt.Sprintf("Range query: %s\nFrom %s to %s, step %s",
+			in.Query, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), step)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Sprintf("Prometheus at %s could not be reached: %v", endpoint, err), nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPromQLResponseBytes+1))
+	if err != nil {
+		return fmt.Sprintf("Failed to read the Prometheus response: %v", err), nil
+	}
+	if len(body) > maxPromQLResponseBytes {
+		return "The Prometheus response is too large; aggregate the query (for example with sum by or topk).", nil
+	}
+
+	var parsed promQLResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return fmt.Sprintf("Prometheus returned HTTP %s with a body that is not API JSON:\n%s",
+			resp.Status, truncateUTF8(strings.TrimSpace(string(body)), 500)), nil
+	}
+	if parsed.Status != "success" {
+		return fmt.Sprintf("Prometheus rejected the query (HTTP %s, %s): %s",
+			resp.Status, parsed.ErrorType, parsed.Error), nil
+	}
+
+	table, err := formatPromQLResult(parsed.Data.ResultType, parsed.Data.Result, opts.MaxSeries)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(header + "\n")
+	for _, warning := range parsed.Warnings {
+		b.WriteString("Warning: " + warning + "\n")
+	}
+	b.WriteString("\n" + table)
+	return b.String(), nil
+}
+
+// promQLEndpoint returns the Prometheus base URL to query: the scheme and
+// host of the alert's generator URL when that host is allowed, else the
+// configured endpoint.
+func promQLEndpoint(generatorURL string, opts PromQLOptions) (string, error) {
+	if generatorURL != "" {
+		u, err := url.Parse(generatorURL)
+		if err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+			slices.Contains(opts.AllowedHosts, u.Hostname()) {
+			return u.Scheme + "://" + u.Host, nil
+		}
+	}
+	if opts.Endpoint == "" {
+		return "", errors.New("no Prometheus endpoint is configured and the alert's generator URL is not allowed")
+	}
+	return opts.Endpoint, nil
+}
+
+// promQLStep parses step, or picks one giving about defaultPromQLPoints
+// points over span, keeping within what Prometheus accepts.
+func promQLStep(value string, span time.Duration) (time.Duration, error) {
+	if value == "" {
+		return max((span / defaultPromQLPoints).Round(time.Second), time.Second), nil
+	}
+	step, err := time.ParseDuration(value)
+	if err != nil || step <= 0 {
+		return 0, fmt.Errorf("invalid step %q: want a positive duration like 1m", value)
+	}
+	if span/step > maxPromQLRangePoints {
+		return 0, fmt.Errorf("step %s gives more than %d points; use a larger step", step, maxPromQLRangePoints)
+	}
+	return step, nil
+}
+
+// formatPromQLResult renders a query result as a compact table: one line per
+// series for vectors, and for matrices a summary line with sampled values.
+func formatPromQLResult(resultType string, result json.RawMessage, maxSeries int) (string, error) {
+	switch resultType {
+	case "scalar", "string":
+		var value []interface{}
+		if err := json.Unmarshal(result, &value); err != nil || len(value) != 2 {
+			return "", fmt.Errorf("failed to parse %s result", resultType)
+		}
+		return fmt.Sprintf("%s: %v", resultType, value[1]), nil
+	case "vector", "matrix":
+	default:
+		return "", fmt.Errorf("unsupported result type %q", resultType)
+	}
+
+	var series []promQLSeries
+	if err := json.Unmarshal(result, &series); err != nil {
+		return "", fmt.Errorf("failed to parse %s result: %w", resultType, err)
+	}
+	if len(series) == 0 {
+		return "No series matched. Check the metric name and label matchers, or widen the time range.", nil
+	}
+	sort.Slice(series, func(i, j int) bool { return formatPromLabels(series[i].Metric) < formatPromLabels(series[j].Metric) })
+
+	var b strings.Builder
+	shown := min(len(series), maxSeries)
+	if shown < len(series) {
+		fmt.Fprintf(&b, "Series: %d (showing %d; aggregate or filter the query to see the rest)\n", len(series), shown)
+	} else {
+		fmt.Fprintf(&b, "Series: %d\n", len(series))
+	}
+	for _, s := range series[:shown] {
+		if resultType == "vector" {
+			value := ""
+			if len(s.Value) == 2 {
+				value = fmt.Sprint(s.Value[1])
+			}
+			fmt.Fprintf(&b, "%s %s\n", formatPromLabels(s.Metric), value)
+			continue
+		}
+		writePromRangeSeries(&b, s)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// writePromRangeSeries writes a range series as its labels, a min/max/avg/last
+// summary, and up to promQLSampledValues evenly spaced values.
+func writePromRangeSeries(b *strings.Builder, s promQLSeries) {
+	type point struct {
+		at    time.Time
+		value float64
+	}
+	points := make([]point, 0, len(s.Values))
+	for _, pair := range s.Values {
+		if len(pair) != 2 {
+			continue
+		}
+		seconds, ok := pair[0].(float64)
+		text, isText := pair[1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if !ok || !isText || err != nil {
+			continue
+		}
+		points = append(points, point{at: time.Unix(0, int64(seconds*float64(time.Second))).UTC(), value: value})
+	}
+
+	fmt.Fprintf(b, "%s\n", formatPromLabels(s.Metric))
+	if len(points) == 0 {
+		b.WriteString("  no samples\n")
+		return
+	}
+	lo, hi, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, p := range points {
+		lo, hi, sum = math.Min(lo, p.value), math.Max(hi, p.value), sum+p.value
+	}
+	fmt.Fprintf(b, "  min=%s max=%s avg=%s last=%s (%d points)\n", formatPromValue(lo), formatPromValue(hi),
+		formatPromValue(sum/float64(len(points))), formatPromValue(points[len(points)-1].value), len(points))
+
+	samples := make([]string, 0, promQLSampledValues)
+	for i := range min(len(points), promQLSampledValues) {
+		// Spread the samples evenly, always including the first and last point
+		index := i
+		if len(points) > promQLSampledValues {
+			index = i * (len(points) - 1) / (promQLSampledValues - 1)
+		}
+		p := points[index]
+		samples = append(samples, p.at.Format(time.RFC3339)+"="+formatPromValue(p.value))
+	}
+	fmt.Fprintf(b, "  values: %s\n", strings.Join(samples, " "))
+}
+
+// formatPromLabels renders a metric as name{label="value", ...} with the
+// labels sorted.
+func formatPromLabels(metric map[string]string) string {
+	labels := make([]string, 0, len(metric))
+	for name, value := range metric {
+		if name != "__name__" {
+			labels = append(labels, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+	sort.Strings(labels)
+	return metric["__name__"] + "{" + strings.Join(labels, ", ") + "}"
+}
+
+// formatPromValue formats a sample value with up to four significant digits.
+func formatPromValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
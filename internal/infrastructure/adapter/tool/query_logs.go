@@ -0,0 +1,420 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryLogsToolName is the name of the journald and log file query tool.
+const queryLogsToolName = "query_logs"
+
+// Line limits of the query_logs tool.
+const (
+	defaultQueryLogsLines = 100
+	maxQueryLogsLines     = 1000
+)
+
+// maxLogLineBytes caps each returned log line.
+const maxLogLineBytes = 4096
+
+// maxLogScanBytes is how much of the end of a log file query_logs reads, so
+// tailing a huge file stays fast.
+const maxLogScanBytes = 64 << 20
+
+// unitNamePattern matches systemd unit names. The leading character excludes
+// "-" so a unit can never be read as a journalctl flag.
+var unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9@_.:\\][A-Za-z0-9@_.:\\-]*$`)
+
+// LogCommandRunner runs a command and returns its standard output. It lets
+// tests stand in for journalctl.
+type LogCommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// JournaldAvailable reports whether query_logs can read the systemd journal:
+// the agent runs on Linux and journalctl is on PATH.
+func JournaldAvailable() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	_, err := exec.LookPath("journalctl")
+	return err == nil
+}
+
+// EnableQueryLogs registers the query_logs tool, which runs journalctl with
+// runner; nil runs the real command. Call it only when JournaldAvailable
+// reports true, so ListTools offers the tool only where it works.
+func (a *ExecutorAdapter) EnableQueryLogs(runner LogCommandRunner) {
+	if runner == nil {
+		runner = runLogCommand
+	}
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.logCommandRunner = runner
+	a.setToolLocked(queryLogsTool())
+}
+
+// DisableQueryLogs unregisters the query_logs tool, as when journald is no
+// longer available.
+func (a *ExecutorAdapter) DisableQueryLogs() {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.deleteToolLocked(queryLogsToolName)
+}
+
+// runLogCommand runs name with args and returns its standard output, with
+// standard error in the error when it fails.
+func runLogCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = bashEnv(os.Environ(), nil, nil)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return out, fmt.Errorf("%s failed: %w: %s", name, err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	if err != nil {
+		return out, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return out, nil
+}
+
+// queryLogsInput represents the input for the query_logs tool.
+type queryLogsInput struct {
+	Unit  string `json:"unit,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	Grep  string `json:"grep,omitempty"`
+	Lines int    `json:"lines,omitempty"`
+}
+
+// queryLogsTool returns the query_logs tool definition.
+func queryLogsTool() entity.Tool {
+	return entity.Tool{
+		ID:   queryLogsToolName,
+		Name: queryLogsToolName,
+		Description: "Reads recent log lines from a systemd unit's journal or from a log file, newest last, " +
+			"with timestamps normalized to RFC3339. Give exactly one of unit or path. Prefer it over " +
+			"running journalctl or tail through bash.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"unit": map[string]interface{}{
+					"type":        "string",
+					"description": "A systemd unit whose journal to read",
+					"examples":    []interface{}{"nginx.service", "kubelet"},
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "A log file to tail, absolute or relative to the working directory",
+					"examples":    []interface{}{"/var/log/syslog"},
+				},
+				"since": map[string]interface{}{
+					"type":        "string",
+					"description": "Only lines at or after this time: an RFC3339 timestamp or a duration ago",
+					"examples":    []interface{}{"1h", "2024-05-01T10:00:00Z"},
+				},
+				"until": map[string]interface{}{
+					"type":        "string",
+					"description": "Only lines at or before this time: an RFC3339 timestamp or a duration ago",
+					"examples":    []interface{}{"10m"},
+				},
+				"grep": map[string]interface{}{
+					"type":        "string",
+					"description": "Only lines matching this regular expression",
+					"examples":    []interface{}{"(?i)out of memory|oom"},
+				},
+				"lines": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxQueryLogsLines,
+					"default":     defaultQueryLogsLines,
+					"description": "How many of the most recent matching lines to return",
+				},
+			},
+		},
+	}
+}
+
+// executeQueryLogs returns the most recent matching lines of a unit's
+// journal or a log file.
+func (a *ExecutorAdapter) executeQueryLogs(ctx context.Context, input json.RawMessage) (string, error) {
+	var in queryLogsInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal query_logs input: %w", err)
+	}
+	if (in.Unit == "") == (in.Path == "") {
+		return "", errors.New("give exactly one of unit or path")
+	}
+	if in.Lines == 0 {
+		in.Lines = defaultQueryLogsLines
+	}
+	if in.Lines < 1 || in.Lines > maxQueryLogsLines {
+		return "", fmt.Errorf("lines must be between 1 and %d, got %d", maxQueryLogsLines, in.Lines)
+	}
+
+	now := time.Now()
+	since, err := parseLogTime(in.Since, now)
+	if err != nil {
+		return "", fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseLogTime(in.Until, now)
+	if err != nil {
+		return "", fmt.Errorf("invalid until: %w", err)
+	}
+	var grep *regexp.Regexp
+	if in.Grep != "" {
+		if grep, err = regexp.Compile(in.Grep); err != nil {
+			return "", fmt.Errorf("invalid grep: %w", err)
+		}
+	}
+
+	var lines []string
+	if in.Unit != "" {
+		lines, err = a.queryJournal(ctx, in, since, until)
+	} else {
+		lines, err = a.tailLogFile(in.Path, since, until, grep, in.Lines)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "No matching log lines.", nil
+	}
+	for i, line := range lines {
+		if len(line) > maxLogLineBytes {
+			lines[i] = truncateUTF8(line, maxLogLineBytes) + " [line truncated]"
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseLogTime parses an RFC3339 timestamp or a duration before now. Empty
+// returns the zero time.
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 timestamp nor a duration ago like 1h", value)
+	}
+	return now.Add(-d), nil
+}
+
+// journalTimeLayout is a time format journalctl --since and --until accept.
+const journalTimeLayout = "2006-01-02 15:04:05 UTC"
+
+// queryJournal reads a unit's journal with journalctl.
+func (a *ExecutorAdapter) queryJournal(
+	ctx context.Context,
+	in queryLogsInput,
+	since, until time.Time,
+) ([]string, error) {
+	if !unitNamePattern.MatchString(in.Unit) {
+		return nil, fmt.Errorf("invalid unit name %q", in.Unit)
+	}
+	args := []string{"--no-pager", "--output=json", "--unit=" + in.Unit, "--lines=" + strconv.Itoa(in.Lines)}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.UTC().Format(journalTimeLayout))
+	}
+	if !until.IsZero() {
+		args = append(args, "--until="+until.UTC().Format(journalTimeLayout))
+	}
+	if in.Grep != "" {
+		args = append(args, "--grep="+in.Grep)
+	}
+
+	a.mu.RLock()
+	runner := a.logCommandRunner
+	a.mu.RUnlock()
+	out, err := runner(ctx, "journalctl", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		line, err := formatJournalEntry(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journalctl output: %w", err)
+	}
+	return lines, nil
+}
+
+// formatJournalEntry renders one entry of journalctl --output=json as
+// "<RFC3339 time> <identifier>[<pid>]: <message>".
+func formatJournalEntry(data []byte) (string, error) {
+	var entry map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", fmt.Errorf("failed to parse journal entry: %w", err)
+	}
+	field := func(name string) string {
+		var s string
+		if json.Unmarshal(entry[name], &s) == nil {
+			return s
+		}
+		// journald encodes fields that are not valid UTF-8 as byte arrays
+		var raw []byte
+		if json.Unmarshal(entry[name], &raw) == nil {
+			return strings.ToValidUTF8(string(raw), "�")
+		}
+		return ""
+	}
+
+	var b strings.Builder
+	if usec, err := strconv.ParseInt(field("__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+		b.WriteString(time.UnixMicro(usec).UTC().Format(time.RFC3339))
+		b.WriteString(" ")
+	}
+	identifier := field("SYSLOG_IDENTIFIER")
+	if identifier == "" {
+		identifier = field("_SYSTEMD_UNIT")
+	}
+	if identifier != "" {
+		b.WriteString(identifier)
+		if pid := field("_PID"); pid != "" {
+			b.WriteString("[" + pid + "]")
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(strings.TrimRight(field("MESSAGE"), "\n"))
+	return b.String(), nil
+}
+
+// tailLogFile returns the last n lines of a log file that match grep and
+// whose timestamps fall between since and until. Lines without a timestamp,
+// such as stack trace continuations, follow the line before them.
+func (a *ExecutorAdapter) tailLogFile(
+	path string,
+	since, until time.Time,
+	grep *regexp.Regexp,
+	n int,
+) ([]string, error) {
+	if !filepath.IsAbs(path) {
+		a.mu.RLock()
+		workingDir := a.bashOptions.WorkingDir
+		a.mu.RUnlock()
+		path = filepath.Join(workingDir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	skipPartial := false
+	if info.Size() > maxLogScanBytes {
+		if _, err := f.Seek(-maxLogScanBytes, io.SeekEnd); err != nil {
+			return nil, fmt.Errorf("failed to seek log file: %w", err)
+		}
+		skipPartial = true
+	}
+
+	ring := make([]string, 0, n)
+	inRange := true
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		if skipPartial {
+			// The scan started mid-line
+			skipPartial = false
+			continue
+		}
+		line := strings.ToValidUTF8(scanner.Text(), "�")
+		if t, rest, ok := parseLogLineTime(line, now); ok {
+			inRange = (since.IsZero() || !t.Before(since)) && (until.IsZero() || !t.After(until))
+			line = t.UTC().Format(time.RFC3339) + rest
+		}
+		if !inRange || (grep != nil && !grep.MatchString(line)) {
+			continue
+		}
+		if len(ring) == n {
+			ring = append(ring[:0], ring[1:]...)
+		}
+		ring = append(ring, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return ring, nil
+}
+
+// logTimeFormat is a timestamp format found at the start of log lines.
+type logTimeFormat struct {
+	pattern *regexp.Regexp // matches the timestamp, and only it
+	layout  string
+	noYear  bool // syslog timestamps omit the year
+}
+
+// logTimeFormats are the line-leading timestamps query_logs recognizes.
+// Timestamps without a zone are read as local time.
+var logTimeFormats = []logTimeFormat{
+	{pattern: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), layout: time.RFC3339Nano},
+	{pattern: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}([.,]\d+)?`), layout: "2006-01-02T15:04:05"},
+	{pattern: regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`), layout: time.Stamp, noYear: true},
+	{pattern: regexp.MustCompile(`^\[\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\]`), layout: "[02/Jan/2006:15:04:05 -0700]"},
+}
+
+// parseLogLineTime parses the timestamp at the start of line, returning it
+// and the rest of the line. Syslog timestamps are placed in the year of now,
+// or the year before when that would put them in the future.
+func parseLogLineTime(line string, now time.Time) (time.Time, string, bool) {
+	for _, format := range logTimeFormats {
+		stamp := format.pattern.FindString(line)
+		if stamp == "" {
+			continue
+		}
+		value := stamp
+		if format.layout == "2006-01-02T15:04:05" {
+			// Normalize the date-time separator and decimal comma so one layout
+			// covers "2006-01-02 15:04:05,000" and friends
+			value = strings.Replace(strings.Replace(value, " ", "T", 1), ",", ".", 1)
+		}
+		t, err := time.ParseInLocation(format.layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if format.noYear {
+			t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+		}
+		return t, line[len(stamp):], true
+	}
+	return time.Time{}, "", false
+}
@@ -0,0 +1,77 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// rememberToolName is the name of the tool that saves a preference to memory.
+const rememberToolName = "remember"
+
+// MemoryRecorder saves a learned preference to the agent's memory file,
+// reporting false when an identical line is already there.
+type MemoryRecorder interface {
+	Remember(text string) (bool, error)
+}
+
+// EnableRemember registers the remember tool, which appends preferences to
+// memory so later sessions start with them.
+func (a *ExecutorAdapter) EnableRemember(memory MemoryRecorder) {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.memory = memory
+	a.setToolLocked(rememberTool())
+}
+
+// rememberInput represents the input for the remember tool.
+type rememberInput struct {
+	Text string `json:"text"`
+}
+
+// rememberTool returns the remember tool definition.
+func rememberTool() entity.Tool {
+	return entity.Tool{
+		ID:   rememberToolName,
+		Name: rememberToolName,
+		Description: "Saves a lasting project preference or convention to the memory file (AGENT.md), " +
+			"which is loaded into the system prompt of future sessions. Use it when the user states a " +
+			"preference they will want kept, not for facts about the current task.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"text": map[string]interface{}{
+					"type":        "string",
+					"description": "The preference as one short sentence, saved as a bullet",
+					"examples":    []interface{}{"Always run Go tests with -race", "We indent with tabs"},
+				},
+			},
+			"required": []string{"text"},
+		},
+		RequiredFields: []string{"text"},
+	}
+}
+
+// executeRemember appends a preference to the memory file.
+func (a *ExecutorAdapter) executeRemember(input json.RawMessage) (string, error) {
+	var in rememberInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal remember input: %w", err)
+	}
+	a.mu.RLock()
+	memory := a.memory
+	a.mu.RUnlock()
+	if memory == nil {
+		return "", errors.New("memory is not enabled")
+	}
+
+	added, err := memory.Remember(in.Text)
+	if err != nil {
+		return "", err
+	}
+	if !added {
+		return "Already remembered: " + in.Text, nil
+	}
+	return "Remembered: " + in.Text, nil
+}
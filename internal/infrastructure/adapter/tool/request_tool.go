@@ -0,0 +1,80 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// requestToolName is the name of the tool through which the model asks for
+// tools withheld from its tool list.
+const requestToolName = "request_tool"
+
+// requestToolInput is the input of the request_tool tool.
+type requestToolInput struct {
+	Tools []string `json:"tools"`
+}
+
+// EnableToolRequests registers the request_tool tool. With a tool selector
+// narrowing the tools offered each turn, it is how the model asks for the
+// tools left out; the conversation offers them from the next turn on.
+func (a *ExecutorAdapter) EnableToolRequests() {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.setToolLocked(requestTool())
+}
+
+// requestTool returns the request_tool tool definition.
+func requestTool() entity.Tool {
+	return entity.Tool{
+		ID:   requestToolName,
+		Name: requestToolName,
+		Description: "Asks for tools that are not in your tool list. Only the tools relevant to the " +
+			"conversation are offered; the ones you request are offered from your next turn on. " +
+			"Omit tools to get the full catalog.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tools": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Names of the tools to offer; omit for every tool",
+				},
+			},
+		},
+	}
+}
+
+// executeRequestTool checks the requested tools exist and confirms they will
+// be offered; the conversation's tool selector does the offering.
+func (a *ExecutorAdapter) executeRequestTool(input json.RawMessage) (string, error) {
+	var in requestToolInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("invalid request_tool input: %w", err)
+	}
+
+	a.mu.RLock()
+	var unknown []string
+	for _, name := range in.Tools {
+		if _, ok := a.tools[strings.TrimSpace(name)]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	available := make([]string, 0, len(a.tools))
+	for name := range a.tools {
+		available = append(available, name)
+	}
+	a.mu.RUnlock()
+
+	if len(unknown) > 0 {
+		sort.Strings(available)
+		return "", fmt.Errorf("unknown tools %s; available tools: %s",
+			strings.Join(unknown, ", "), strings.Join(available, ", "))
+	}
+	if len(in.Tools) == 0 {
+		return "Every tool will be offered from your next turn on.", nil
+	}
+	return fmt.Sprintf("Offered from your next turn on: %s.", strings.Join(in.Tools, ", ")), nil
+}
@@ -0,0 +1,237 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/safety"
+	"container/list"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+)
+
+// Default ResultCache limits.
+const (
+	DefaultCacheMaxEntries = 256
+	DefaultCacheMaxBytes   = 8 << 20
+)
+
+// cachedTools are the idempotent, read-only tools whose results are cached.
+var cachedTools = map[string]bool{
+	"read_file":  true,
+	"list_files": true,
+}
+
+// pathWritingTools change the file named by their "path" input, invalidating
+// cached results for it.
+var pathWritingTools = map[string]bool{
+	"edit_file": true,
+}
+
+// cacheFlushingTools can change any file, so they flush the whole cache.
+var cacheFlushingTools = map[string]bool{
+	"bash":              true,
+	"batch_tool":        true,
+	"task":              true,
+	"delegate":          true,
+	"delegate_parallel": true,
+}
+
+// ResultCache caches results of idempotent read-only tools per session, so a
+// model re-reading a file it already read gets the earlier result without
+// touching the disk. Results are shared only within a session, but writes in
+// any session invalidate them: edit_file drops entries for the edited path and
+// its parent directories, and bash and delegating tools flush everything.
+// The least recently used entries are evicted beyond maxEntries or maxBytes.
+// It is safe for concurrent use.
+type ResultCache struct {
+	maxEntries int
+	maxBytes   int
+
+	mu         sync.Mutex
+	entries    map[cacheKey]*list.Element
+	lru        *list.List // Of *cacheEntry, most recently used first
+	bytes      int
+	generation uint64 // Incremented by every invalidation
+}
+
+// cacheKey identifies a cached result.
+type cacheKey struct {
+	sessionID string
+	tool      string
+	input     string // Canonical JSON
+}
+
+// cacheEntry is a cached result, the absolute path it was read from, and the
+// provenance the tool reported for it.
+type cacheEntry struct {
+	key        cacheKey
+	path       string
+	result     string
+	provenance []entity.Provenance
+}
+
+// NewResultCache creates a ResultCache holding at most maxEntries results
+// totalling maxBytes. Limits of zero or less use DefaultCacheMaxEntries and
+// DefaultCacheMaxBytes.
+func NewResultCache(maxEntries, maxBytes int) *ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheMaxBytes
+	}
+	return &ResultCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[cacheKey]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Len returns the number of cached results.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Middleware returns the ToolMiddleware that serves and stores cached results
+// and applies invalidations. Calls without a session ID in their context are
+// never served from the cache but still invalidate it. Cache hits are marked
+// with cached=true in the audit log and in the context's
+// port.ToolExecutionInfo.
+func (c *ResultCache) Middleware() ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			switch {
+			case cachedTools[name]:
+				return c.read(ctx, next, name, input)
+			case pathWritingTools[name]:
+				defer c.invalidate(c.inputPath(input))
+			case cacheFlushingTools[name]:
+				defer c.flush()
+			}
+			return next(ctx, name, input)
+		}
+	}
+}
+
+// read serves a cached tool's result from the cache or runs it and stores the result.
+func (c *ResultCache) read(ctx context.Context, next ToolFunc, name string, input json.RawMessage) (string, error) {
+	sessionID, ok := port.SessionIDFromContext(ctx)
+	canonical, err := canonicalJSON(input)
+	if !ok || sessionID == "" || err != nil {
+		return next(ctx, name, input)
+	}
+	key := cacheKey{sessionID: sessionID, tool: name, input: canonical}
+
+	c.mu.Lock()
+	if elem, hit := c.entries[key]; hit {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry)
+		c.mu.Unlock()
+		addAuditAttrs(ctx, "cached", true)
+		if info, ok := port.ToolExecutionInfoFromContext(ctx); ok {
+			info.Cached = true
+			info.Provenance = entry.provenance
+		}
+		return entry.result, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	// Capture the provenance the tool reports, so hits report it too
+	info, ok := port.ToolExecutionInfoFromContext(ctx)
+	if !ok {
+		info = &port.ToolExecutionInfo{}
+		ctx = port.WithToolExecutionInfo(ctx, info)
+	}
+	result, err := next(ctx, name, input)
+	if err == nil {
+		c.store(generation, &cacheEntry{key: key, path: c.inputPath(input), result: result, provenance: info.Provenance})
+	}
+	return result, err
+}
+
+// store adds an entry unless an invalidation happened since generation, in
+// which case the result may already be stale.
+func (c *ResultCache) store(generation uint64, entry *cacheEntry) {
+	size := len(entry.result)
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if elem, exists := c.entries[entry.key]; exists {
+		c.removeLocked(elem)
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.bytes += size
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// invalidate drops entries read from path or listing one of its parent directories.
+func (c *ResultCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if safety.IsWithinDir(elem.Value.(*cacheEntry).path, path) {
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+}
+
+// flush drops every entry.
+func (c *ResultCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = make(map[cacheKey]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// removeLocked removes an entry. c.mu must be held.
+func (c *ResultCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.result)
+}
+
+// inputPath returns the absolute, cleaned "path" of a tool input. Relative
+// paths are resolved against the working directory, as the file tools do.
+func (c *ResultCache) inputPath(input json.RawMessage) string {
+	var in struct {
+		Path string `json:"path"`
+	}
+	_ = json.Unmarshal(input, &in)
+	if abs, err := filepath.Abs(in.Path); err == nil {
+		return abs
+	}
+	return filepath.Clean(in.Path)
+}
+
+// canonicalJSON re-encodes input so equivalent inputs, differing only in
+// whitespace or key order, produce the same key.
+func canonicalJSON(input json.RawMessage) (string, error) {
+	var value any
+	if err := json.Unmarshal(input, &value); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(canonical), nil
+}
@@ -0,0 +1,158 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newCachingAdapter creates an executor with a result cache working in a temp
+// dir holding notes.txt.
+func newCachingAdapter(t *testing.T, maxEntries, maxBytes int) (*tool.ExecutorAdapter, *tool.ResultCache, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("original\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool { return true })
+	cache := tool.NewResultCache(maxEntries, maxBytes)
+	adapter.Use(cache.Middleware())
+	return adapter, cache, dir
+}
+
+// readNotes reads notes.txt in session-1, reporting whether the result was cached.
+func readNotes(t *testing.T, adapter *tool.ExecutorAdapter, input string) (string, bool) {
+	t.Helper()
+	info := &port.ToolExecutionInfo{}
+	ctx := port.WithToolExecutionInfo(port.WithSessionID(context.Background(), "session-1"), info)
+	result, err := adapter.ExecuteTool(ctx, "read_file", input)
+	if err != nil {
+		t.Fatalf("read_file failed: %v", err)
+	}
+	return result, info.Cached
+}
+
+func TestResultCache_ServesRepeatedReads(t *testing.T) {
+	adapter, _, dir := newCachingAdapter(t, 0, 0)
+
+	first, cached := readNotes(t, adapter, `{"path": "notes.txt"}`)
+	if cached {
+		t.Error("first read should not be cached")
+	}
+
+	// A change outside the tools is not seen: the second read, with the same
+	// input in a different key order and spacing, is served from the cache
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second, cached := readNotes(t, adapter, `{ "path":"notes.txt" }`)
+	if !cached || second != first {
+		t.Errorf("second read = %q (cached %v), want cached %q", second, cached, first)
+	}
+
+	// Other sessions do not share results
+	other, err := adapter.ExecuteTool(
+		port.WithSessionID(context.Background(), "session-2"), "read_file", `{"path": "notes.txt"}`)
+	if err != nil || !strings.Contains(other, "changed") {
+		t.Errorf("other session read = %q, %v, want the changed file", other, err)
+	}
+}
+
+func TestResultCache_HitsReportProvenance(t *testing.T) {
+	adapter, _, _ := newCachingAdapter(t, 0, 0)
+	for _, wantCached := range []bool{false, true} {
+		info := &port.ToolExecutionInfo{}
+		ctx := port.WithToolExecutionInfo(port.WithSessionID(context.Background(), "session-1"), info)
+		if _, err := adapter.ExecuteTool(ctx, "read_file", `{"path": "notes.txt"}`); err != nil {
+			t.Fatalf("read_file failed: %v", err)
+		}
+		want := []entity.Provenance{{Path: "notes.txt", StartLine: 1, EndLine: 1}}
+		if info.Cached != wantCached || !reflect.DeepEqual(info.Provenance, want) {
+			t.Errorf("read (cached %v) provenance = %+v, want %+v", info.Cached, info.Provenance, want)
+		}
+	}
+}
+
+func TestResultCache_EditInvalidatesPath(t *testing.T) {
+	adapter, cache, dir := newCachingAdapter(t, 0, 0)
+	readNotes(t, adapter, `{"path": "notes.txt"}`)
+	if _, err := adapter.ExecuteTool(port.WithSessionID(context.Background(), "session-1"),
+		"list_files", `{"path": "."}`); err != nil {
+		t.Fatalf("list_files failed: %v", err)
+	}
+	if _, err := adapter.ExecuteTool(port.WithSessionID(context.Background(), "session-1"),
+		"list_files", `{"path": "sub"}`); err == nil {
+		t.Fatal("expected list_files of a missing directory to fail")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("cache holds %d entries, want 2 (errors are not cached)", cache.Len())
+	}
+
+	// Edit by absolute path; the cached read used a relative one
+	input, _ := json.Marshal(map[string]string{
+		"path": filepath.Join(dir, "notes.txt"), "old_str": "original", "new_str": "edited",
+	})
+	if _, err := adapter.ExecuteTool(context.Background(), "edit_file", string(input)); err != nil {
+		t.Fatalf("edit_file failed: %v", err)
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("cache holds %d entries after the edit, want the file and its directory listing dropped", cache.Len())
+	}
+	result, cached := readNotes(t, adapter, `{"path": "notes.txt"}`)
+	if cached || !strings.Contains(result, "edited") {
+		t.Errorf("read after edit = %q (cached %v), want the edited file", result, cached)
+	}
+}
+
+func TestResultCache_BashFlushesEverything(t *testing.T) {
+	adapter, cache, _ := newCachingAdapter(t, 0, 0)
+	readNotes(t, adapter, `{"path": "notes.txt"}`)
+	readNotes(t, adapter, `{"path": "notes.txt", "start_line": 1}`)
+	if cache.Len() != 2 {
+		t.Fatalf("cache holds %d entries, want 2", cache.Len())
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "bash",
+		`{"command": "echo bashed > notes.txt", "dangerous": false}`); err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("cache holds %d entries after bash, want 0", cache.Len())
+	}
+}
+
+func TestResultCache_EvictsBeyondLimits(t *testing.T) {
+	adapter, cache, _ := newCachingAdapter(t, 2, 0)
+	for _, input := range []string{
+		`{"path": "notes.txt"}`,
+		`{"path": "notes.txt", "start_line": 1}`,
+		`{"path": "notes.txt", "end_line": 1}`,
+	} {
+		readNotes(t, adapter, input)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", cache.Len())
+	}
+	// The least recently used entry was evicted
+	if _, cached := readNotes(t, adapter, `{"path": "notes.txt"}`); cached {
+		t.Error("the oldest entry should have been evicted")
+	}
+
+	bytesLimited, bytesCache, _ := newCachingAdapter(t, 0, 4)
+	readNotes(t, bytesLimited, `{"path": "notes.txt"}`)
+	if bytesCache.Len() != 0 {
+		t.Errorf("a result larger than max bytes should not be cached")
+	}
+}
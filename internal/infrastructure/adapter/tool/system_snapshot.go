@@ -0,0 +1,310 @@
+package tool
+
+import (
+	"cmp"
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// systemSnapshotToolName is the name of the host resource snapshot tool.
+const systemSnapshotToolName = "system_snapshot"
+
+// Process list limits of the system_snapshot tool.
+const (
+	defaultSnapshotTop = 5
+	maxSnapshotTop     = 25
+)
+
+// Focus areas of the system_snapshot tool; an empty focus reports them all.
+const (
+	snapshotFocusCPU     = "cpu"
+	snapshotFocusMemory  = "memory"
+	snapshotFocusDisk    = "disk"
+	snapshotFocusProcess = "process"
+)
+
+// CPUStats is the CPU utilization over a short sample, in percent.
+type CPUStats struct {
+	Total   float64
+	PerCore []float64
+}
+
+// MemoryStats is the usage of physical memory or swap, in bytes.
+type MemoryStats struct {
+	Total       uint64
+	Used        uint64
+	Available   uint64 // physical memory only
+	UsedPercent float64
+}
+
+// LoadStats is the 1, 5, and 15 minute load averages.
+type LoadStats struct {
+	Load1  float64
+	Load5  float64
+	Load15 float64
+}
+
+// ProcessStats is one process with its CPU utilization over a short sample
+// and its resident memory.
+type ProcessStats struct {
+	PID        int32
+	Name       string
+	CPUPercent float64
+	RSS        uint64
+}
+
+// DiskStats is the usage of one mounted filesystem.
+type DiskStats struct {
+	Mountpoint        string
+	FSType            string
+	Total             uint64
+	Used              uint64
+	Free              uint64
+	UsedPercent       float64
+	InodesUsedPercent float64
+}
+
+// SystemStats reads the host's resource usage for system_snapshot. It lets
+// tests stand in for gopsutil.
+type SystemStats interface {
+	CPU(ctx context.Context) (CPUStats, error)
+	Memory(ctx context.Context) (memory, swap MemoryStats, err error)
+	Load(ctx context.Context) (LoadStats, error)
+	Processes(ctx context.Context) ([]ProcessStats, error)
+	Disks(ctx context.Context) ([]DiskStats, error)
+}
+
+// SystemSnapshotAvailable reports whether system_snapshot can read the host's
+// resource usage on this operating system.
+func SystemSnapshotAvailable() bool {
+	switch runtime.GOOS {
+	case "linux", "darwin", "windows", "freebsd":
+		return true
+	default:
+		return false
+	}
+}
+
+// EnableSystemSnapshot registers the system_snapshot tool, which reads the
+// host's resource usage from stats; nil reads it with gopsutil. Call it only
+// when SystemSnapshotAvailable reports true.
+func (a *ExecutorAdapter) EnableSystemSnapshot(stats SystemStats) {
+	if stats == nil {
+		stats = gopsutilStats{}
+	}
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.systemStats = stats
+	a.setToolLocked(systemSnapshotTool())
+}
+
+// systemSnapshotInput represents the input for the system_snapshot tool.
+type systemSnapshotInput struct {
+	Focus string `json:"focus,omitempty"`
+	Top   int    `json:"top,omitempty"`
+}
+
+// systemSnapshotTool returns the system_snapshot tool definition.
+func systemSnapshotTool() entity.Tool {
+	return entity.Tool{
+		ID:   systemSnapshotToolName,
+		Name: systemSnapshotToolName,
+		Description: "Reports the host's resource usage as compact tables: CPU (total and per core), load " +
+			"averages, memory and swap, the top processes by CPU and by resident memory, and disk usage " +
+			"per mount. Use focus to report one area only. Prefer it over parsing top, free, ps, or df " +
+			"output through bash.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"focus": map[string]interface{}{
+					"type": "string",
+					"enum": []interface{}{
+						snapshotFocusCPU, snapshotFocusMemory, snapshotFocusDisk, snapshotFocusProcess,
+					},
+					"description": "Only report this area: cpu (CPU, load, and top processes by CPU), " +
+						"memory (memory, swap, and top processes by memory), disk, or process (both " +
+						"process lists). Omit it for everything",
+				},
+				"top": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxSnapshotTop,
+					"default":     defaultSnapshotTop,
+					"description": "How many processes each process list shows",
+				},
+			},
+		},
+	}
+}
+
+// executeSystemSnapshot reports the areas of resource usage the input's focus
+// asks for. An area that cannot be read is reported as unavailable, so the
+// others still come through.
+func (a *ExecutorAdapter) executeSystemSnapshot(ctx context.Context, input json.RawMessage) (string, error) {
+	var in systemSnapshotInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal system_snapshot input: %w", err)
+	}
+	focus := strings.ToLower(strings.TrimSpace(in.Focus))
+	switch focus {
+	case "", snapshotFocusCPU, snapshotFocusMemory, snapshotFocusDisk, snapshotFocusProcess:
+	default:
+		return "", fmt.Errorf("unknown focus %q: use cpu, memory, disk, or process, or omit it", in.Focus)
+	}
+	top := in.Top
+	if top <= 0 {
+		top = defaultSnapshotTop
+	}
+	top = min(top, maxSnapshotTop)
+
+	a.mu.RLock()
+	stats := a.systemStats
+	a.mu.RUnlock()
+	if stats == nil {
+		return "", fmt.Errorf("%s is not enabled", systemSnapshotToolName)
+	}
+
+	all := focus == ""
+	var sections []string
+	if all || focus == snapshotFocusCPU {
+		sections = append(sections, formatCPUSection(ctx, stats))
+	}
+	if all || focus == snapshotFocusMemory {
+		sections = append(sections, formatMemorySection(ctx, stats))
+	}
+	if focus != snapshotFocusDisk {
+		byCPU := all || focus == snapshotFocusCPU || focus == snapshotFocusProcess
+		byRSS := all || focus == snapshotFocusMemory || focus == snapshotFocusProcess
+		sections = append(sections, formatProcessSections(ctx, stats, top, byCPU, byRSS)...)
+	}
+	if all || focus == snapshotFocusDisk {
+		sections = append(sections, formatDiskSection(ctx, stats))
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// formatCPUSection reports the CPU utilization and load averages.
+func formatCPUSection(ctx context.Context, stats SystemStats) string {
+	var sb strings.Builder
+	cpuStats, err := stats.CPU(ctx)
+	if err != nil {
+		fmt.Fprintf(&sb, "CPU: unavailable (%v)", err)
+	} else {
+		fmt.Fprintf(&sb, "CPU: %.1f%% used across %d cores", cpuStats.Total, len(cpuStats.PerCore))
+		if len(cpuStats.PerCore) > 0 {
+			cores := make([]string, len(cpuStats.PerCore))
+			for i, percent := range cpuStats.PerCore {
+				cores[i] = fmt.Sprintf("%d:%.0f%%", i, percent)
+			}
+			sb.WriteString("\nPer core: " + strings.Join(cores, " "))
+		}
+	}
+
+	load, err := stats.Load(ctx)
+	if err != nil {
+		fmt.Fprintf(&sb, "\nLoad average: unavailable (%v)", err)
+	} else {
+		fmt.Fprintf(&sb, "\nLoad average: %.2f %.2f %.2f (1m 5m 15m)", load.Load1, load.Load5, load.Load15)
+	}
+	return sb.String()
+}
+
+// formatMemorySection reports the memory and swap usage.
+func formatMemorySection(ctx context.Context, stats SystemStats) string {
+	memory, swap, err := stats.Memory(ctx)
+	if err != nil {
+		return fmt.Sprintf("Memory: unavailable (%v)", err)
+	}
+	return fmt.Sprintf("Memory: %s used of %s (%.1f%%), %s available\nSwap: %s used of %s (%.1f%%)",
+		formatByteSize(memory.Used), formatByteSize(memory.Total), memory.UsedPercent,
+		formatByteSize(memory.Available),
+		formatByteSize(swap.Used), formatByteSize(swap.Total), swap.UsedPercent)
+}
+
+// formatProcessSections reports the top processes by CPU, by resident
+// memory, or both, reading the process list once.
+func formatProcessSections(ctx context.Context, stats SystemStats, top int, byCPU, byRSS bool) []string {
+	processes, err := stats.Processes(ctx)
+	if err != nil {
+		return []string{fmt.Sprintf("Processes: unavailable (%v)", err)}
+	}
+
+	var sections []string
+	if byCPU {
+		sorted := slices.SortedStableFunc(slices.Values(processes), func(a, b ProcessStats) int {
+			return cmp.Compare(b.CPUPercent, a.CPUPercent)
+		})
+		sections = append(sections, formatProcessTable("Top processes by CPU:", sorted, top))
+	}
+	if byRSS {
+		sorted := slices.SortedStableFunc(slices.Values(processes), func(a, b ProcessStats) int {
+			return cmp.Compare(b.RSS, a.RSS)
+		})
+		sections = append(sections, formatProcessTable("Top processes by memory (RSS):", sorted, top))
+	}
+	return sections
+}
+
+// formatProcessTable lists the first top processes under title.
+func formatProcessTable(title string, processes []ProcessStats, top int) string {
+	var sb strings.Builder
+	sb.WriteString(title + "\n")
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PID\tNAME\tCPU\tRSS")
+	for _, p := range processes[:min(top, len(processes))] {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f%%\t%s\n", p.PID, p.Name, p.CPUPercent, formatByteSize(p.RSS))
+	}
+	_ = tw.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatDiskSection reports the usage of each mounted filesystem, fullest
+// first.
+func formatDiskSection(ctx context.Context, stats SystemStats) string {
+	disks, err := stats.Disks(ctx)
+	if err != nil {
+		return fmt.Sprintf("Disks: unavailable (%v)", err)
+	}
+	if len(disks) == 0 {
+		return "Disks: no mounted filesystems found"
+	}
+	disks = slices.SortedStableFunc(slices.Values(disks), func(a, b DiskStats) int {
+		return cmp.Compare(b.UsedPercent, a.UsedPercent)
+	})
+
+	var sb strings.Builder
+	sb.WriteString("Disks:\n")
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MOUNT\tFS\tSIZE\tUSED\tFREE\tUSE%\tINODES")
+	for _, d := range disks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.1f%%\t%.1f%%\n", d.Mountpoint, d.FSType,
+			formatByteSize(d.Total), formatByteSize(d.Used), formatByteSize(d.Free), d.UsedPercent, d.InodesUsedPercent)
+	}
+	_ = tw.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatByteSize renders a byte count with a binary unit, e.g. "1.5 GiB".
+func formatByteSize(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	units := []string{"KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
@@ -0,0 +1,138 @@
+package tool
+
+import (
+	"context"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// systemSampleInterval is how long CPU utilization is sampled for, for the
+// whole host and for each process.
+const systemSampleInterval = 500 * time.Millisecond
+
+// gopsutilStats reads the host's resource usage with gopsutil.
+type gopsutilStats struct{}
+
+// CPU samples the utilization of each core; the total is their average.
+func (gopsutilStats) CPU(ctx context.Context) (CPUStats, error) {
+	perCore, err := cpu.PercentWithContext(ctx, systemSampleInterval, true)
+	if err != nil {
+		return CPUStats{}, err
+	}
+	var sum float64
+	for _, percent := range perCore {
+		sum += percent
+	}
+	stats := CPUStats{PerCore: perCore}
+	if len(perCore) > 0 {
+		stats.Total = sum / float64(len(perCore))
+	}
+	return stats, nil
+}
+
+// Memory reads the physical memory and swap usage.
+func (gopsutilStats) Memory(ctx context.Context) (MemoryStats, MemoryStats, error) {
+	vm, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return MemoryStats{}, MemoryStats{}, err
+	}
+	memory := MemoryStats{Total: vm.Total, Used: vm.Used, Available: vm.Available, UsedPercent: vm.UsedPercent}
+	sw, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return MemoryStats{}, MemoryStats{}, err
+	}
+	return memory, MemoryStats{Total: sw.Total, Used: sw.Used, UsedPercent: sw.UsedPercent}, nil
+}
+
+// Load reads the load averages.
+func (gopsutilStats) Load(ctx context.Context) (LoadStats, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return LoadStats{}, err
+	}
+	return LoadStats{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}, nil
+}
+
+// Processes lists the running processes with the CPU time each used over a
+// sample, as a percentage of one core, and its resident memory. Processes
+// that exit or cannot be read during the sample are left out.
+func (gopsutilStats) Processes(ctx context.Context) ([]ProcessStats, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		if times, err := p.TimesWithContext(ctx); err == nil {
+			before[p.Pid] = times.User + times.System
+		}
+	}
+
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(systemSampleInterval):
+	}
+	elapsed := time.Since(start).Seconds()
+
+	stats := make([]ProcessStats, 0, len(procs))
+	for _, p := range procs {
+		cpuBefore, ok := before[p.Pid]
+		if !ok {
+			continue
+		}
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		memInfo, err := p.MemoryInfoWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		name, _ := p.NameWithContext(ctx)
+		stats = append(stats, ProcessStats{
+			PID:        p.Pid,
+			Name:       name,
+			CPUPercent: max(0, (times.User+times.System-cpuBefore)/elapsed*100),
+			RSS:        memInfo.RSS,
+		})
+	}
+	return stats, nil
+}
+
+// Disks reads the usage of each mounted physical filesystem, once per mount
+// point.
+func (gopsutilStats) Disks(ctx context.Context) ([]DiskStats, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(partitions))
+	var stats []DiskStats
+	for _, partition := range partitions {
+		if seen[partition.Mountpoint] {
+			continue
+		}
+		seen[partition.Mountpoint] = true
+		usage, err := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		stats = append(stats, DiskStats{
+			Mountpoint:        partition.Mountpoint,
+			FSType:            partition.Fstype,
+			Total:             usage.Total,
+			Used:              usage.Used,
+			Free:              usage.Free,
+			UsedPercent:       usage.UsedPercent,
+			InodesUsedPercent: usage.InodesUsedPercent,
+		})
+	}
+	return stats, nil
+}
@@ -11,19 +11,25 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	fileadapter "code-editing-agent/internal/infrastructure/adapter/file"
 
 	"golang.org/x/net/html"
+	"k8s.io/client-go/kubernetes"
 )
 
 // SubagentUseCaseInterface defines the interface for spawning subagents.
@@ -47,6 +53,7 @@ type SubagentUseCaseInterface interface {
 		config usecase.DynamicSubagentConfig,
 		taskPrompt string,
 	) (*usecase.SubagentResult, error)
+	SpawnParallel(ctx context.Context, requests []*usecase.SubagentRequest) ([]*usecase.SubagentResult, error)
 }
 
 // DangerousCommandCallback is called when a dangerous command is detected.
@@ -58,17 +65,52 @@ type DangerousCommandCallback func(command, reason string) bool
 // Returns true if execution should proceed, false to block.
 type CommandConfirmationCallback func(command string, isDangerous bool, reason string, description string) bool
 
+// FileEditConfirmationCallback is called before edit_file changes a file on disk.
+// It receives the file path and a unified diff of the proposed change.
+// Returns true if the change should be applied, false to leave the file untouched.
+type FileEditConfirmationCallback func(path string, unifiedDiff string) bool
+
+// UserPromptCallback asks the user a question for the ask_user tool and returns
+// their answer: one of choices, or free text when there are none. It must
+// return ctx.Err() if ctx ends while waiting.
+type UserPromptCallback func(ctx context.Context, question string, choices []string) (string, error)
+
+// ErrToolTimeout is returned when a tool runs longer than its timeout.
+var ErrToolTimeout = errors.New("tool timed out")
+
 // ExecutorAdapter implements the ToolExecutor port using the FileManager for file operations.
 type ExecutorAdapter struct {
 	fileManager                 port.FileManager
 	skillManager                port.SkillManager
 	subagentManager             port.SubagentManager
 	subagentUseCase             SubagentUseCaseInterface
-	tools                       map[string]entity.Tool
+	tools                       map[string]entity.Tool // replaced, never changed, once built; see toolSnapshot
 	mu                          sync.RWMutex
+	observers                   []port.ToolRegistryObserver
+	toolEvents                  []port.ToolRegistryEvent // changes to tell observers of once mu is released
 	dangerousCommandCallback    DangerousCommandCallback
 	commandConfirmationCallback CommandConfirmationCallback
-	investigationStates         map[string]string // tracks investigation_id -> status
+	fileEditConfirmCallback     FileEditConfirmationCallback
+	userPromptCallback          UserPromptCallback
+	metrics                     port.MetricsRecorder
+	tracer                      port.Tracer
+	logger                      *slog.Logger
+	middlewares                 []ToolMiddleware
+	defaultTimeout              time.Duration            // applies to tools without an entry in toolTimeouts
+	toolTimeouts                map[string]time.Duration // per-tool timeouts; 0 means unlimited
+	bashOptions                 BashOptions
+	fetchURLOptions             FetchURLOptions
+	logCommandRunner            LogCommandRunner     // set by EnableQueryLogs
+	k8sClient                   kubernetes.Interface // set by EnableK8sInspect
+	k8sOptions                  K8sInspectOptions
+	promQLOptions               PromQLOptions
+	systemStats                 SystemStats                    // set by EnableSystemSnapshot
+	memory                      MemoryRecorder                 // set by EnableRemember
+	projectInfo                 ProjectInfoProvider            // set by EnableProjectInfo
+	gitOptions                  GitOptions                     // set by EnableGit
+	externalTools               map[string]externalToolHandler // set by RegisterExternalTool; replaced like tools
+	waitUnit                    time.Duration                  // length of one of wait_for's seconds; tests shorten it
+	investigationStates         map[string]string              // tracks investigation_id -> status
 	investigationMu             sync.Mutex
 }
 
@@ -117,19 +159,27 @@ func wrapFileOperationError(operation string, err error) error {
 // NewExecutorAdapter creates a new ExecutorAdapter with the provided FileManager.
 // SkillManager can be provided via SetSkillManager for skill-related functionality.
 // SubagentManager can be provided via SetSubagentManager for subagent-related functionality.
-// It also registers the default tools (read_file, list_files, edit_file, bash, fetch, activate_skill).
+// It also registers the default tools (read_file, list_files, edit_file, bash, fetch, activate_skill, use_skill).
 func NewExecutorAdapter(fileManager port.FileManager) *ExecutorAdapter {
 	adapter := &ExecutorAdapter{
 		fileManager:         fileManager,
 		skillManager:        nil,
 		subagentManager:     nil,
 		tools:               make(map[string]entity.Tool),
+		logger:              slog.Default(),
+		bashOptions:         BashOptions{MaxOutputBytes: DefaultBashMaxOutputBytes},
+		fetchURLOptions:     FetchURLOptions{MaxBytes: DefaultFetchURLMaxBytes, Timeout: defaultFetchTimeout},
+		waitUnit:            time.Second,
 		investigationStates: make(map[string]string),
 	}
 
 	// Register default tools
 	adapter.registerDefaultTools()
 
+	// Every execution is logged with its duration and validated against the
+	// tool's schema; middlewares added with Use run after these
+	adapter.Use(adapter.logExecution, TimingMiddleware(), adapter.validateInput)
+
 	return adapter
 }
 
@@ -141,7 +191,7 @@ func NewExecutorAdapter(fileManager port.FileManager) *ExecutorAdapter {
 // Call once during initialization before starting the main execution loop.
 func (a *ExecutorAdapter) SetSkillManager(sm port.SkillManager) {
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	defer a.unlockAndNotify()
 	a.skillManager = sm
 	// Rebuild activate_skill tool with skill manager for dynamic description
 	a.rebuildActivateSkillToolLocked()
@@ -162,7 +212,7 @@ func (a *ExecutorAdapter) SetSkillManager(sm port.SkillManager) {
 // For optimal performance, configure the subagent manager before starting the main execution loop.
 func (a *ExecutorAdapter) SetSubagentManager(sm port.SubagentManager) {
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	defer a.unlockAndNotify()
 	a.subagentManager = sm
 	// Re-register the task tool with updated agent list
 	a.registerTaskTool()
@@ -193,76 +243,216 @@ func (a *ExecutorAdapter) SetCommandConfirmationCallback(cb CommandConfirmationC
 	a.commandConfirmationCallback = cb
 }
 
-// RegisterTool registers a new tool with the executor.
-func (a *ExecutorAdapter) RegisterTool(tool entity.Tool) error {
-	if err := tool.Validate(); err != nil {
-		return fmt.Errorf("invalid tool: %w", err)
+// SetFileEditConfirmationCallback sets the callback consulted before file edits.
+// Without a callback, edits are applied without confirmation (headless mode).
+func (a *ExecutorAdapter) SetFileEditConfirmationCallback(cb FileEditConfirmationCallback) {
+	a.fileEditConfirmCallback = cb
+}
+
+// SetUserPromptCallback sets the callback that asks the user questions and
+// registers the ask_user tool; a nil callback unregisters it. Leave it unset in
+// headless mode, where there is no one to answer.
+func (a *ExecutorAdapter) SetUserPromptCallback(cb UserPromptCallback) {
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+	a.userPromptCallback = cb
+	if cb == nil {
+		a.deleteToolLocked(askUserToolName)
+		return
+	}
+	a.setToolLocked(askUserTool())
+}
+
+// SetMetricsRecorder sets the recorder for tool execution counts and durations.
+// Without a recorder, no metrics are recorded.
+func (a *ExecutorAdapter) SetMetricsRecorder(recorder port.MetricsRecorder) {
+	a.metrics = recorder
+}
+
+// SetTracer sets the tracer for tool execution spans. Each span is a child of
+// the span in the execution context, so tools nest under the investigation or
+// subagent run that called them. Without a tracer, no spans are created.
+func (a *ExecutorAdapter) SetTracer(tracer port.Tracer) {
+	a.tracer = tracer
+}
+
+// SetLogger sets the fallback logger for tool executions. Executions log through
+// the logger in the execution context when there is one, so their records carry
+// the calling investigation's or subagent's correlation attributes. A nil logger
+// restores slog.Default().
+func (a *ExecutorAdapter) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
 	}
+	a.logger = logger
+}
 
+// SetToolTimeouts sets how long a single execution of each tool may run.
+// Tools without an entry in perTool use defaultTimeout; a timeout of zero
+// means unlimited. The timeout composes with the caller's context: whichever
+// deadline is sooner applies.
+func (a *ExecutorAdapter) SetToolTimeouts(defaultTimeout time.Duration, perTool map[string]time.Duration) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.defaultTimeout = defaultTimeout
+	a.toolTimeouts = maps.Clone(perTool)
+}
 
-	a.tools[tool.Name] = tool
-	return nil
+// SetBashOptions sets the working directory, output cap, and extra allowed
+// environment variables for bash commands. Commands only inherit allowlisted
+// variables, so credentials in the agent's environment stay out of them.
+func (a *ExecutorAdapter) SetBashOptions(opts BashOptions) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	opts.AllowedEnv = slices.Clone(opts.AllowedEnv)
+	a.bashOptions = opts
+}
+
+// toolTimeoutLocked returns the timeout of the named tool. a.mu must be held.
+func (a *ExecutorAdapter) toolTimeoutLocked(name string) time.Duration {
+	if timeout, ok := a.toolTimeouts[name]; ok {
+		return timeout
+	}
+	return a.defaultTimeout
+}
+
+// RegisterTool registers a new tool with the executor. Registering a name that
+// is taken fails with port.ErrToolAlreadyRegistered; ReplaceTool replaces it.
+// Calls in flight keep the tools they started with.
+func (a *ExecutorAdapter) RegisterTool(tool entity.Tool) error {
+	return a.registerTool(tool, false)
 }
 
-// UnregisterTool removes a tool from the executor by name.
+// UnregisterTool removes a tool from the executor by name, failing with
+// port.ErrToolNotRegistered if there is none. Executions of the tool already
+// in flight finish.
 func (a *ExecutorAdapter) UnregisterTool(name string) error {
 	if name == "" {
 		return errors.New("tool name cannot be empty")
 	}
 
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	defer a.unlockAndNotify()
 
-	delete(a.tools, name)
+	if !a.deleteToolLocked(name) {
+		return fmt.Errorf("%w: %s", port.ErrToolNotRegistered, name)
+	}
 	return nil
 }
 
 // ExecuteTool executes a tool with the given name and input.
 func (a *ExecutorAdapter) ExecuteTool(ctx context.Context, name string, input interface{}) (string, error) {
 	a.mu.RLock()
-	tool, exists := a.tools[name]
+	snapshot := toolSnapshot{tools: a.tools, external: a.externalTools}
+	timeout := a.toolTimeoutLocked(name)
 	a.mu.RUnlock()
 
+	tool, exists := snapshot.tools[name]
 	if !exists {
+		// Unknown names come from the model, so they are not used as metric labels
+		a.recordToolMetrics("unknown", true, 0, 0)
 		return "", fmt.Errorf("tool not found: %s", name)
 	}
+	// The rest of the execution uses the tools as registered now
+	ctx = context.WithValue(ctx, toolSnapshotKey{}, snapshot)
 
-	// Convert input to JSON for validation
 	rawInput, err := toRawMessage(input)
 	if err != nil {
 		return "", err
 	}
 
-	// Validate input against tool's schema
-	if err := tool.ValidateInput(rawInput); err != nil {
-		return "", fmt.Errorf("invalid input for tool %s: %w", name, err)
+	ctx, span := port.StartSpan(ctx, a.tracer, port.SpanToolExecution)
+	start := time.Now()
+	result, err := a.runWithTimeout(ctx, timeout, tool.Name, rawInput)
+	duration := time.Since(start)
+
+	a.recordToolMetrics(name, err != nil, duration, len(result))
+	if span.IsRecording() {
+		span.SetAttributes(
+			port.StringAttribute("tool", name),
+			port.IntAttribute("duration_ms", duration.Milliseconds()),
+			port.BoolAttribute("error", err != nil),
+		)
 	}
+	port.EndSpan(span, err)
+	return result, err
+}
 
-	// Execute the tool
-	return a.executeByName(ctx, name, rawInput)
+// recordToolMetrics records a tool execution and the bytes it returned if a
+// metrics recorder is set.
+func (a *ExecutorAdapter) recordToolMetrics(name string, failed bool, duration time.Duration, bytes int) {
+	if a.metrics != nil {
+		a.metrics.RecordToolExecution(name, failed, duration)
+		a.metrics.RecordToolOutput(name, bytes)
+	}
 }
 
-// ListTools returns a list of all registered tools.
+// runWithTimeout runs the middleware chain under the tool's timeout. Tools
+// that do not watch their context, like a read stuck on a network mount, are
+// abandoned when it expires so the caller is not held up.
+func (a *ExecutorAdapter) runWithTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+	name string,
+	input json.RawMessage,
+) (string, error) {
+	if timeout <= 0 {
+		return a.chain()(ctx, name, input)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w after %s", ErrToolTimeout, timeout))
+	defer cancel()
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := a.chain()(ctx, name, input)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		// A tool failing because its timeout expired reports the timeout
+		if cause := context.Cause(ctx); o.err != nil && errors.Is(cause, ErrToolTimeout) {
+			return "", cause
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
+
+// ListTools returns a list of all registered tools, each with its effective timeout.
 func (a *ExecutorAdapter) ListTools() ([]entity.Tool, error) {
 	a.mu.RLock()
-	defer a.mu.RUnlock()
+	registered := a.tools
+	defaultTimeout, toolTimeouts := a.defaultTimeout, a.toolTimeouts
+	a.mu.RUnlock()
 
-	tools := make([]entity.Tool, 0, len(a.tools))
-	for _, tool := range a.tools {
+	tools := make([]entity.Tool, 0, len(registered))
+	for _, tool := range registered {
+		tool.Timeout = defaultTimeout
+		if timeout, ok := toolTimeouts[tool.Name]; ok {
+			tool.Timeout = timeout
+		}
 		tools = append(tools, tool)
 	}
 	return tools, nil
 }
 
-// GetTool retrieves a specific tool by name.
+// GetTool retrieves a specific tool by name, with its effective timeout.
 // Returns the tool and a boolean indicating if it was found.
 func (a *ExecutorAdapter) GetTool(name string) (entity.Tool, bool) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	tool, exists := a.tools[name]
+	if exists {
+		tool.Timeout = a.toolTimeoutLocked(name)
+	}
 	return tool, exists
 }
 
@@ -297,21 +487,26 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 				"path": map[string]interface{}{
 					"type":        "string",
 					"description": "The relative path to the file to read in the working directory..",
+					"examples":    []interface{}{"cmd/cli/main.go"},
 				},
 				"start_line": map[string]interface{}{
 					"type":        "integer",
+					"minimum":     1,
 					"description": "The 1-based line number to start reading from. If not provided, reads from the beginning.",
+					"examples":    []interface{}{40},
 				},
 				"end_line": map[string]interface{}{
 					"type":        "integer",
+					"minimum":     1,
 					"description": "The 1-based line number to stop reading at (inclusive). If not provided, reads to the end.",
+					"examples":    []interface{}{80},
 				},
 			},
 			"required": []string{"path"},
 		},
 		RequiredFields: []string{"path"},
 	}
-	a.tools[readFileTool.Name] = readFileTool
+	a.setToolLocked(readFileTool)
 
 	// Register list_files tool
 	listFilesTool := entity.Tool{
@@ -324,12 +519,15 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 				"path": map[string]interface{}{
 					"type":        "string",
 					"description": "The relative path to the directory to list files in. If not provided, lists files in the current working directory.",
+					"default":     ".",
+					"examples":    []interface{}{"internal/domain"},
 				},
 			},
+			"required": []string{},
 		},
 		RequiredFields: []string{},
 	}
-	a.tools[listFilesTool.Name] = listFilesTool
+	a.setToolLocked(listFilesTool)
 
 	// Register edit_file tool
 	editFileTool := entity.Tool{
@@ -342,6 +540,7 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 				"path": map[string]interface{}{
 					"type":        "string",
 					"description": "The relative path to the file to edit.",
+					"examples":    []interface{}{"internal/app/server.go"},
 				},
 				"old_str": map[string]interface{}{
 					"type":        "string",
@@ -356,7 +555,7 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 		},
 		RequiredFields: []string{"path"},
 	}
-	a.tools[editFileTool.Name] = editFileTool
+	a.setToolLocked(editFileTool)
 
 	// Register bash tool
 	bashTool := entity.Tool{
@@ -369,17 +568,34 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 				"command": map[string]interface{}{
 					"type":        "string",
 					"description": "The shell command to execute",
+					"examples":    []interface{}{"go test ./...", "git status --short"},
 				},
 				"description": map[string]interface{}{
 					"type":        "string",
 					"description": "A brief description of what this command does and why it's being run",
+					"examples":    []interface{}{"Run the unit tests"},
 				},
 				"timeout_ms": map[string]interface{}{
 					"type":        "integer",
+					"minimum":     1,
+					"default":     defaultBashTimeout.Milliseconds(),
 					"description": "Timeout in milliseconds (default: 30000)",
 				},
+				"env": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+					"examples":             []interface{}{map[string]interface{}{"GOFLAGS": "-count=1"}},
+					"description":          "Extra environment variables for this command. Commands otherwise only see an allowlisted environment (PATH, HOME, LANG, ...).",
+				},
+				"modified_paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"examples":    []interface{}{[]interface{}{"go.mod", "go.sum"}},
+					"description": "Files this command creates, changes, or deletes, relative to the working directory. List them so they appear in the session's change summary.",
+				},
 				"dangerous": map[string]interface{}{
 					"type":        "boolean",
+					"examples":    []interface{}{false, true},
 					"description": "REQUIRED: You must assess if this command is potentially dangerous. Set to true for commands that: delete/modify files (rm, mv), use elevated privileges (sudo, su), modify system config, execute untrusted input, or could cause data loss. Set to false for safe read-only commands (ls, cat, grep, echo).",
 				},
 			},
@@ -387,7 +603,7 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 		},
 		RequiredFields: []string{"command", "dangerous"},
 	}
-	a.tools[bashTool.Name] = bashTool
+	a.setToolLocked(bashTool)
 
 	// Register fetch tool
 	fetchTool := entity.Tool{
@@ -400,9 +616,11 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 				"url": map[string]interface{}{
 					"type":        "string",
 					"description": "Full URL to fetch, e.g. https://...",
+					"examples":    []interface{}{"https://pkg.go.dev/net/http"},
 				},
 				"includeMarkup": map[string]interface{}{
 					"type":        "boolean",
+					"default":     false,
 					"description": "Include the HTML markup? Defaults to false. By default or when set to false, markup will be stripped and converted to plain text. Prefer markup stripping, and only set this to true if the output is confusing: otherwise you may download a massive amount of data",
 				},
 			},
@@ -410,7 +628,13 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 		},
 		RequiredFields: []string{"url"},
 	}
-	a.tools[fetchTool.Name] = fetchTool
+	a.setToolLocked(fetchTool)
+
+	// Register fetch_url tool (denies every URL until domains are allowed)
+	a.setToolLocked(fetchURLTool())
+
+	// Register wait_for tool
+	a.setToolLocked(waitForTool())
 
 	// Register activate_skill tool (will be rebuilt with dynamic description if SetSkillManager is called)
 	activateSkillTool := entity.Tool{
@@ -429,7 +653,34 @@ func (a *ExecutorAdapter) registerDefaultTools() {
 		},
 		RequiredFields: []string{"skill_name"},
 	}
-	a.tools[activateSkillTool.Name] = activateSkillTool
+	a.setToolLocked(activateSkillTool)
+
+	// Register use_skill tool
+	useSkillTool := entity.Tool{
+		ID:   "use_skill",
+		Name: "use_skill",
+		Description: "Invokes a skill by name and returns its instructions with arguments filled in. " +
+			"Occurrences of $ARGUMENTS in the skill are replaced with the full arguments string, " +
+			"and $1 through $9 with the individual whitespace-separated arguments. " +
+			"Use this to pull a skill's instructions into the conversation only when they are needed.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "The name of the skill to invoke",
+				},
+				"arguments": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional arguments substituted into the skill's $ARGUMENTS and $1..$9 placeholders",
+					"examples":    []interface{}{"internal/app v2"},
+				},
+			},
+			"required": []string{"name"},
+		},
+		RequiredFields: []string{"name"},
+	}
+	a.setToolLocked(useSkillTool)
 
 	// Register enter_plan_mode tool
 	enterPlanModeTool := entity.Tool{
@@ -474,7 +725,7 @@ In plan mode, you will:
 		},
 		RequiredFields: []string{"reason"},
 	}
-	a.tools[enterPlanModeTool.Name] = enterPlanModeTool
+	a.setToolLocked(enterPlanModeTool)
 
 	// Register batch_tool
 	batchToolTool := entity.Tool{
@@ -514,13 +765,19 @@ The tool returns aggregated results showing success/failure counts and individua
 					},
 					"maxItems": maxBatchInvocations,
 					"minItems": 1,
+					"examples": []interface{}{[]interface{}{
+						map[string]interface{}{"tool_name": "read_file", "arguments": map[string]interface{}{"path": "go.mod"}},
+						map[string]interface{}{"tool_name": "list_files", "arguments": map[string]interface{}{"path": "internal"}},
+					}},
 				},
 				"parallel": map[string]interface{}{
 					"type":        "boolean",
+					"default":     false,
 					"description": "Whether to execute invocations in parallel (default: false)",
 				},
 				"stop_on_error": map[string]interface{}{
 					"type":        "boolean",
+					"default":     false,
 					"description": "Whether to stop execution on first error (only applies to sequential mode)",
 				},
 			},
@@ -528,7 +785,7 @@ The tool returns aggregated results showing success/failure counts and individua
 		},
 		RequiredFields: []string{"invocations"},
 	}
-	a.tools[batchToolTool.Name] = batchToolTool
+	a.setToolLocked(batchToolTool)
 
 	// Register task tool (dynamically includes available agents if subagentManager is set)
 	a.registerTaskTool()
@@ -574,6 +831,7 @@ Output format: [expected structure]"`,
 				"name": map[string]interface{}{
 					"type":        "string",
 					"description": "Short identifier for the agent (3-5 words, for logging/tracking)",
+					"examples":    []interface{}{"api error audit"},
 				},
 				"system_prompt": map[string]interface{}{
 					"type":        "string",
@@ -586,23 +844,82 @@ Output format: [expected structure]"`,
 				"model": map[string]interface{}{
 					"type":        "string",
 					"enum":        []string{"haiku", "sonnet", "opus", "inherit"},
+					"default":     "inherit",
 					"description": "AI model to use. haiku=fast/cheap, sonnet=balanced, opus=complex reasoning, inherit=same as parent (default: inherit)",
 				},
 				"max_actions": map[string]interface{}{
 					"type":        "integer",
+					"minimum":     1,
+					"default":     30,
 					"description": "Maximum tool calls before stopping. Prevents runaway execution (default: 30)",
 				},
 				"allowed_tools": map[string]interface{}{
 					"type":        "array",
 					"items":       map[string]interface{}{"type": "string"},
 					"description": "Tools this agent can use. Omit for all tools, or specify a list to restrict capabilities for safety.",
+					"examples":    []interface{}{[]interface{}{"read_file", "list_files"}},
+				},
+				"verbatim": map[string]interface{}{
+					"type":        "boolean",
+					"default":     false,
+					"description": "Return the subagent's full output instead of a summary when it is long (default: false)",
 				},
 			},
 			"required": []string{"name", "system_prompt", "task"},
 		},
 		RequiredFields: []string{"name", "system_prompt", "task"},
 	}
-	a.tools[delegateTool.Name] = delegateTool
+	a.setToolLocked(delegateTool)
+
+	// Register the delegate_parallel tool for fanning out independent tasks
+	delegateParallelTool := entity.Tool{
+		ID:   "delegate_parallel",
+		Name: "delegate_parallel",
+		Description: `Run several named subagents concurrently and return all of their results.
+
+Use delegate_parallel instead of repeated task calls when the tasks are independent of each other
+(e.g., reviewing separate modules, checking several services). Concurrency is capped by the
+configured subagent limit; extra tasks wait for a free slot.
+
+Usage notes:
+- Each task runs in its own isolated conversation session
+- Results are returned as a JSON array in the same order as the input tasks
+- A failing task does not stop the others; check each result's status and error
+- Do not use for tasks that depend on each other's output - run those sequentially`,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tasks": map[string]interface{}{
+					"type":        "array",
+					"description": "Independent tasks to run concurrently",
+					"minItems":    1,
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"agent": map[string]interface{}{
+								"type":        "string",
+								"description": "Name of the subagent to spawn (e.g., 'code-reviewer')",
+								"examples":    []interface{}{"code-reviewer"},
+							},
+							"prompt": map[string]interface{}{
+								"type":        "string",
+								"description": "Task description/instructions for the subagent to execute",
+							},
+						},
+						"required": []string{"agent", "prompt"},
+					},
+				},
+				"verbatim": map[string]interface{}{
+					"type":        "boolean",
+					"default":     false,
+					"description": "Return the subagent's full output instead of a summary when it is long (default: false)",
+				},
+			},
+			"required": []string{"tasks"},
+		},
+		RequiredFields: []string{"tasks"},
+	}
+	a.setToolLocked(delegateParallelTool)
 
 	// Register investigation tools
 	a.registerInvestigationTools()
@@ -631,7 +948,7 @@ func (a *ExecutorAdapter) rebuildActivateSkillToolLocked() {
 		},
 		RequiredFields: []string{"skill_name"},
 	}
-	a.tools[activateSkillTool.Name] = activateSkillTool
+	a.setToolLocked(activateSkillTool)
 }
 
 // buildActivateSkillDescription builds the description for the activate_skill tool.
@@ -653,7 +970,7 @@ func (a *ExecutorAdapter) buildActivateSkillDescription() string {
 
 	skills, err := a.skillManager.DiscoverSkills(ctx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to discover skills for tool description: %v\n", err)
+		a.logger.Warn("Failed to discover skills for tool description", "error", err)
 		return baseDescription
 	}
 	if len(skills.Skills) == 0 {
@@ -692,7 +1009,7 @@ func (a *ExecutorAdapter) buildActivateSkillDescription() string {
 func (a *ExecutorAdapter) executeByName(ctx context.Context, name string, input json.RawMessage) (string, error) {
 	switch name {
 	case "read_file":
-		return a.executeReadFile(input)
+		return a.executeReadFile(ctx, input)
 	case "list_files":
 		return a.executeListFiles(input)
 	case "edit_file":
@@ -701,12 +1018,18 @@ func (a *ExecutorAdapter) executeByName(ctx context.Context, name string, input
 		return a.executeBash(ctx, input)
 	case "fetch":
 		return a.executeFetch(ctx, input)
+	case fetchURLToolName:
+		return a.executeFetchURL(ctx, input)
 	case "activate_skill":
 		return a.executeActivateSkill(ctx, input)
+	case "use_skill":
+		return a.executeUseSkill(ctx, input)
 	case "batch_tool":
 		return a.executeBatchTool(ctx, input)
 	case "task":
 		return a.executeTask(ctx, input)
+	case "delegate_parallel":
+		return a.executeDelegateParallel(ctx, input)
 	case "delegate":
 		return a.executeDelegate(ctx, input)
 	case "complete_investigation":
@@ -715,8 +1038,34 @@ func (a *ExecutorAdapter) executeByName(ctx context.Context, name string, input
 		return a.executeEscalateInvestigation(ctx, input)
 	case "report_investigation":
 		return a.executeReportInvestigation(ctx, input)
+	case askUserToolName:
+		return a.executeAskUser(ctx, input)
+	case queryLogsToolName:
+		return a.executeQueryLogs(ctx, input)
+	case waitForToolName:
+		return a.executeWaitFor(ctx, input)
+	case k8sInspectToolName:
+		return a.executeK8sInspect(ctx, input)
+	case promQLToolName:
+		return a.executePromQL(ctx, input)
+	case systemSnapshotToolName:
+		return a.executeSystemSnapshot(ctx, input)
+	case rememberToolName:
+		return a.executeRemember(input)
+	case projectInfoToolName:
+		return a.executeProjectInfo()
+	case requestToolName:
+		return a.executeRequestTool(input)
+	case gitStatusToolName:
+		return a.executeGitStatus(ctx)
+	case gitDiffToolName:
+		return a.executeGitDiff(ctx, input)
+	case gitCommitToolName:
+		return a.executeGitCommit(ctx, input)
+	case gitPushToolName:
+		return a.executeGitPush(ctx, input)
 	default:
-		return "", fmt.Errorf("tool not found: %s", name)
+		return a.executeExternal(ctx, name, input)
 	}
 }
 
@@ -745,34 +1094,46 @@ func (in *readFileInput) validateLineRange() error {
 // formatLinesWithNumbers formats file content as numbered lines within the specified range.
 // startLine and endLine are 1-based line numbers. If nil, they default to the beginning and end of the file.
 func formatLinesWithNumbers(content string, startLine, endLine *int) string {
+	lines := fileLines(content)
+	startIdx, endIdx := lineBounds(len(lines), startLine, endLine)
+
+	// Build output with line numbers
+	var result strings.Builder
+	for i := startIdx; i < endIdx; i++ {
+		result.WriteString(fmt.Sprintf("%d: %s\n", i+1, lines[i]))
+	}
+
+	return result.String()
+}
+
+// fileLines splits content into lines, without the empty line after a
+// trailing newline.
+func fileLines(content string) []string {
 	lines := strings.Split(content, "\n")
-	// Remove trailing empty line if content ends with newline
 	if len(lines) > 0 && lines[len(lines)-1] == "" {
 		lines = lines[:len(lines)-1]
 	}
+	return lines
+}
 
-	// Determine start and end indices (1-based to 0-based), clamped to valid range
+// lineBounds returns the 0-based, half-open index range of lines startLine
+// through endLine (1-based, inclusive, nil for the first and last line) of a
+// file of n lines, clamped to the file.
+func lineBounds(n int, startLine, endLine *int) (int, int) {
 	startIdx := 0
 	if startLine != nil {
-		startIdx = min(*startLine-1, len(lines))
+		startIdx = min(*startLine-1, n)
 	}
-
-	endIdx := len(lines)
+	endIdx := n
 	if endLine != nil {
-		endIdx = min(*endLine, len(lines))
+		endIdx = min(*endLine, n)
 	}
-
-	// Build output with line numbers
-	var result strings.Builder
-	for i := startIdx; i < endIdx; i++ {
-		result.WriteString(fmt.Sprintf("%d: %s\n", i+1, lines[i]))
-	}
-
-	return result.String()
+	return startIdx, endIdx
 }
 
-// executeReadFile executes the read_file tool.
-func (a *ExecutorAdapter) executeReadFile(input json.RawMessage) (string, error) {
+// executeReadFile executes the read_file tool, reporting the lines it read
+// as the result's provenance.
+func (a *ExecutorAdapter) executeReadFile(ctx context.Context, input json.RawMessage) (string, error) {
 	var in readFileInput
 	if err := json.Unmarshal(input, &in); err != nil {
 		return "", fmt.Errorf("failed to unmarshal read_file input: %w", err)
@@ -787,6 +1148,12 @@ func (a *ExecutorAdapter) executeReadFile(input json.RawMessage) (string, error)
 		return "", wrapFileOperationError("Failed to read file", err)
 	}
 
+	if info, ok := port.ToolExecutionInfoFromContext(ctx); ok {
+		startIdx, endIdx := lineBounds(len(fileLines(content)), in.StartLine, in.EndLine)
+		if startIdx < endIdx {
+			info.Provenance = []entity.Provenance{{Path: in.Path, StartLine: startIdx + 1, EndLine: endIdx}}
+		}
+	}
 	return formatLinesWithNumbers(content, in.StartLine, in.EndLine), nil
 }
 
@@ -858,6 +1225,9 @@ func (a *ExecutorAdapter) executeEditFile(input json.RawMessage) (string, error)
 
 	// If file doesn't exist and old_str is empty, create a new file
 	if !exists && in.OldStr == "" {
+		if err := a.checkFileEditConfirmation(in.Path, "", in.NewStr, true); err != nil {
+			return "", err
+		}
 		return a.createNewFile(in.Path, in.NewStr)
 	}
 
@@ -875,6 +1245,10 @@ func (a *ExecutorAdapter) executeEditFile(input json.RawMessage) (string, error)
 		return "", errors.New("old string not found in file")
 	}
 
+	if err := a.checkFileEditConfirmation(in.Path, oldContent, newContent, false); err != nil {
+		return "", err
+	}
+
 	// Write the modified content
 	if err := a.fileManager.WriteFile(in.Path, newContent); err != nil {
 		return "", wrapFileOperationError("Failed to write file", err)
@@ -883,6 +1257,18 @@ func (a *ExecutorAdapter) executeEditFile(input json.RawMessage) (string, error)
 	return "OK", nil
 }
 
+// checkFileEditConfirmation asks the file edit confirmation callback, if set,
+// whether a change to path may be applied, showing it as a unified diff.
+func (a *ExecutorAdapter) checkFileEditConfirmation(path, oldContent, newContent string, isNew bool) error {
+	if a.fileEditConfirmCallback == nil {
+		return nil
+	}
+	if !a.fileEditConfirmCallback(path, unifiedDiff(path, oldContent, newContent, isNew)) {
+		return fmt.Errorf("file edit denied by user: %s", path)
+	}
+	return nil
+}
+
 // createNewFile creates a new file with the given content.
 func (a *ExecutorAdapter) createNewFile(filePath, content string) (string, error) {
 	// Create directory if needed
@@ -903,10 +1289,15 @@ func (a *ExecutorAdapter) createNewFile(filePath, content string) (string, error
 
 // bashInput represents the input for the bash tool.
 type bashInput struct {
-	Command     string `json:"command"`
-	Description string `json:"description,omitempty"`
-	TimeoutMs   int    `json:"timeout_ms,omitempty"`
-	Dangerous   bool   `json:"dangerous,omitempty"`
+	Command     string            `json:"command"`
+	Description string            `json:"description,omitempty"`
+	TimeoutMs   int               `json:"timeout_ms,omitempty"`
+	Dangerous   bool              `json:"dangerous,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+
+	// ModifiedPaths are files the command declares it creates, changes, or
+	// deletes, so the ChangeTracker can snapshot them first.
+	ModifiedPaths []string `json:"modified_paths,omitempty"`
 }
 
 // fetchInput represents the input for the fetch tool.
@@ -920,6 +1311,12 @@ type activateSkillInput struct {
 	SkillName string `json:"skill_name"`
 }
 
+// useSkillInput represents the input for the use_skill tool.
+type useSkillInput struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
 // batchToolInput represents the input for the batch_tool tool.
 type batchToolInput struct {
 	Invocations []batchInvocation `json:"invocations"`
@@ -937,6 +1334,7 @@ type batchInvocation struct {
 type taskInput struct {
 	AgentName string `json:"agent_name"`
 	Prompt    string `json:"prompt"`
+	Verbatim  bool   `json:"verbatim"`
 }
 
 // delegateInput represents the input for the delegate tool.
@@ -947,6 +1345,19 @@ type delegateInput struct {
 	Model        string   `json:"model"`
 	MaxActions   int      `json:"max_actions"`
 	AllowedTools []string `json:"allowed_tools"`
+	Verbatim     bool     `json:"verbatim"`
+}
+
+// delegateParallelInput represents the input for the delegate_parallel tool.
+type delegateParallelInput struct {
+	Tasks    []delegateParallelTask `json:"tasks"`
+	Verbatim bool                   `json:"verbatim"`
+}
+
+// delegateParallelTask represents a single task in a delegate_parallel call.
+type delegateParallelTask struct {
+	Agent  string `json:"agent"`
+	Prompt string `json:"prompt"`
 }
 
 // batchToolOutput represents the output from the batch_tool tool.
@@ -978,6 +1389,9 @@ type bashOutput struct {
 // defaultBashTimeout is the default timeout for bash command execution.
 const defaultBashTimeout = 30 * time.Second
 
+// bashWaitDelay is how long a killed bash command's output is still read.
+const bashWaitDelay = time.Second
+
 // maxBatchInvocations is the maximum number of tool invocations allowed in a single batch.
 const maxBatchInvocations = 20
 
@@ -1037,12 +1451,19 @@ func (a *ExecutorAdapter) executeBash(ctx context.Context, input json.RawMessage
 	if in.Command == "" {
 		return "", errors.New("command is required")
 	}
-
-	// Check command confirmation
-	if err := a.checkCommandConfirmation(in.Command, in.Description, in.Dangerous); err != nil {
+	if err := validateBashEnv(in.Env); err != nil {
 		return "", err
 	}
 
+	// Check command confirmation, unless the user already approved the call;
+	// the prompt shows the variables set for it along with the command
+	if !port.UserApprovedFromContext(ctx) {
+		if err := a.checkCommandConfirmation(
+			safety.CommandWithEnv(in.Command, in.Env), in.Description, in.Dangerous); err != nil {
+			return "", err
+		}
+	}
+
 	// Set timeout
 	timeout := defaultBashTimeout
 	if in.TimeoutMs > 0 {
@@ -1060,9 +1481,19 @@ func (a *ExecutorAdapter) executeBash(ctx context.Context, input json.RawMessage
 		in.Command,
 	)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Once the context ends bash is killed; stop waiting for background
+	// processes it started that still hold its output open
+	cmd.WaitDelay = bashWaitDelay
+
+	a.mu.RLock()
+	opts := a.bashOptions
+	a.mu.RUnlock()
+	cmd.Dir = opts.WorkingDir
+	cmd.Env = bashEnv(os.Environ(), opts.AllowedEnv, in.Env)
+
+	stdout, stderr := newBoundedOutput(opts.MaxOutputBytes)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	err := cmd.Run()
 
@@ -1427,7 +1858,8 @@ func (a *ExecutorAdapter) executeFetch(ctx context.Context, input json.RawMessag
 // If no skill manager is set, returns an error.
 func (a *ExecutorAdapter) executeActivateSkill(ctx context.Context, input json.RawMessage) (string, error) {
 	// Check if skill manager is available
-	if a.skillManager == nil {
+	skillManager := a.skillManagerSnapshot()
+	if skillManager == nil {
 		return "", errors.New("skill manager not available")
 	}
 
@@ -1442,15 +1874,15 @@ func (a *ExecutorAdapter) executeActivateSkill(ctx context.Context, input json.R
 
 	// Try to load the skill metadata first (avoids redundant filesystem scans).
 	// If the skill is not found, refresh the discovered skills once and retry.
-	skill, err := a.skillManager.LoadSkillMetadata(ctx, in.SkillName)
+	skill, err := skillManager.LoadSkillMetadata(ctx, in.SkillName)
 	if err != nil {
 		// Attempt to refresh the skills list once
-		if _, discoverErr := a.skillManager.DiscoverSkills(ctx); discoverErr != nil {
+		if _, discoverErr := skillManager.DiscoverSkills(ctx); discoverErr != nil {
 			return "", fmt.Errorf("failed to discover skills: %w", discoverErr)
 		}
 
 		// Retry loading the skill metadata after refreshing
-		skill, err = a.skillManager.LoadSkillMetadata(ctx, in.SkillName)
+		skill, err = skillManager.LoadSkillMetadata(ctx, in.SkillName)
 		if err != nil {
 			return "", fmt.Errorf("failed to load skill '%s': %w", in.SkillName, err)
 		}
@@ -1489,6 +1921,146 @@ func (a *ExecutorAdapter) executeActivateSkill(ctx context.Context, input json.R
 	return result.String(), nil
 }
 
+// maxSkillContentSize caps the rendered skill content returned by use_skill (64KB).
+const maxSkillContentSize = 64 << 10
+
+// skillPlaceholderPattern matches $ARGUMENTS and the positional placeholders $1..$9.
+var skillPlaceholderPattern = regexp.MustCompile(`\$(ARGUMENTS|[1-9])`)
+
+// executeUseSkill renders a skill's body with the given arguments substituted.
+// Unknown skill names produce an error listing the available skills so the AI can retry.
+func (a *ExecutorAdapter) executeUseSkill(ctx context.Context, input json.RawMessage) (string, error) {
+	skillManager := a.skillManagerSnapshot()
+	if skillManager == nil {
+		return "", errors.New("skill manager not available")
+	}
+
+	var in useSkillInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal use_skill input: %w", err)
+	}
+	if in.Name == "" {
+		return "", errors.New("name parameter is required but was empty")
+	}
+
+	skill, err := skillManager.LoadSkillMetadata(ctx, in.Name)
+	if err != nil {
+		// The skill may have been added since the last discovery
+		if _, discoverErr := skillManager.DiscoverSkills(ctx); discoverErr != nil {
+			return "", fmt.Errorf("failed to discover skills: %w", discoverErr)
+		}
+		skill, err = skillManager.LoadSkillMetadata(ctx, in.Name)
+		if err != nil {
+			return "", fmt.Errorf("skill '%s' not found; available skills: %s", in.Name, availableSkillNames(skillManager))
+		}
+	}
+
+	content, err := skillManager.GetSkillContent(skill.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to load skill '%s': %w", skill.Name, err)
+	}
+
+	rendered := renderSkillArguments(content, in.Arguments)
+	if len(rendered) > maxSkillContentSize {
+		originalSize := len(rendered)
+		rendered = truncateUTF8(rendered, maxSkillContentSize)
+		rendered += fmt.Sprintf(
+			"\n\n[skill content truncated: showing %d of %d bytes]",
+			len(rendered),
+			originalSize,
+		)
+	}
+
+	if note := skillToolRestrictionNote(ctx, skill.AllowedTools); note != "" {
+		rendered = note + "\n\n" + rendered
+	}
+
+	return rendered, nil
+}
+
+// skillManagerSnapshot returns the skill manager under the read lock, since
+// SetSkillManager may replace it while a skill tool runs.
+func (a *ExecutorAdapter) skillManagerSnapshot() port.SkillManager {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.skillManager
+}
+
+// availableSkillNames returns a comma-separated list of the skill names
+// skillManager discovered.
+func availableSkillNames(skillManager port.SkillManager) string {
+	skills := skillManager.ListSkills()
+	if len(skills) == 0 {
+		return "(none)"
+	}
+	names := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		names = append(names, skill.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// renderSkillArguments substitutes $ARGUMENTS with the full arguments string and
+// $1..$9 with the whitespace-separated positional arguments. Positional placeholders
+// without a matching argument are replaced with an empty string.
+func renderSkillArguments(content, arguments string) string {
+	positional := strings.Fields(arguments)
+	return skillPlaceholderPattern.ReplaceAllStringFunc(content, func(match string) string {
+		if match == "$ARGUMENTS" {
+			return arguments
+		}
+		index := int(match[1] - '1')
+		if index < len(positional) {
+			return positional[index]
+		}
+		return ""
+	})
+}
+
+// truncateUTF8 shortens s to at most maxBytes without splitting a multi-byte rune.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// skillToolRestrictionNote returns a note when a skill's allowed-tools are narrower
+// than the tools available in the current session, or an empty string otherwise.
+func skillToolRestrictionNote(ctx context.Context, skillTools []string) string {
+	if len(skillTools) == 0 {
+		return ""
+	}
+
+	sessionTools, restricted := port.AllowedToolsFromContext(ctx)
+	if restricted {
+		allowed := make(map[string]bool, len(skillTools))
+		for _, tool := range skillTools {
+			allowed[tool] = true
+		}
+		narrower := false
+		for _, tool := range sessionTools {
+			if !allowed[tool] {
+				narrower = true
+				break
+			}
+		}
+		if !narrower {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf(
+		"Note: this skill only allows the following tools: %s. "+
+			"The current session has more tools available; restrict yourself to these while following the skill.",
+		strings.Join(skillTools, ", "),
+	)
+}
+
 // registerInvestigationTools registers the investigation-related tools.
 func (a *ExecutorAdapter) registerInvestigationTools() {
 	// Register complete_investigation tool
@@ -1508,13 +2080,17 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 					"minimum":     float64(0),
 					"maximum":     float64(1),
 					"description": "Confidence level from 0 to 1",
+					"examples":    []interface{}{0.85},
 				},
 				"findings": map[string]interface{}{
 					"type": "array",
 					"items": map[string]interface{}{
 						"type": "string",
 					},
-					"description": "List of findings from the investigation",
+					"description": "List of findings from the investigation, each starting with its severity: [critical], [warning], or [info]",
+					"examples": []interface{}{
+						[]interface{}{"[critical] Disk /var is 100% full", "[info] Log rotation last ran 9 days ago"},
+					},
 				},
 				"root_cause": map[string]interface{}{
 					"type":        "string",
@@ -1523,9 +2099,36 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 				"recommended_actions": map[string]interface{}{
 					"type": "array",
 					"items": map[string]interface{}{
-						"type": "string",
+						"type": "object",
+						"properties": map[string]interface{}{
+							"description": map[string]interface{}{
+								"type":        "string",
+								"description": "What to do and why",
+							},
+							"command": map[string]interface{}{
+								"type":        "string",
+								"description": "Shell command that carries out the action, if there is one",
+							},
+							"risk": map[string]interface{}{
+								"type":        "string",
+								"enum":        []interface{}{"low", "medium", "high"},
+								"description": "Risk of running the command; only low-risk commands may run automatically",
+							},
+							"requires_approval": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Whether a person must approve the command before it runs",
+							},
+						},
+						"required": []string{"description"},
 					},
 					"description": "List of recommended actions (optional)",
+					"examples": []interface{}{
+						[]interface{}{map[string]interface{}{
+							"description": "Force a log rotation to free /var",
+							"command":     "logrotate -f /etc/logrotate.conf",
+							"risk":        "low",
+						}},
+					},
 				},
 				"severity": map[string]interface{}{
 					"type":        "string",
@@ -1541,7 +2144,7 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 		},
 		RequiredFields: []string{"investigation_id", "confidence", "findings"},
 	}
-	a.tools[completeInvestigationTool.Name] = completeInvestigationTool
+	a.setToolLocked(completeInvestigationTool)
 
 	// Register escalate_investigation tool
 	escalateInvestigationTool := entity.Tool{
@@ -1569,14 +2172,16 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 					"items": map[string]interface{}{
 						"type": "string",
 					},
-					"description": "Partial findings gathered so far (optional)",
+					"description": "Partial findings gathered so far, each starting with its severity: [critical], [warning], or [info] (optional)",
 				},
 				"blocking": map[string]interface{}{
 					"type":        "boolean",
+					"default":     false,
 					"description": "Whether this escalation is blocking",
 				},
 				"requires_acknowledgment": map[string]interface{}{
 					"type":        "boolean",
+					"default":     false,
 					"description": "Whether acknowledgment is required",
 				},
 			},
@@ -1584,7 +2189,7 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 		},
 		RequiredFields: []string{"investigation_id", "reason", "priority"},
 	}
-	a.tools[escalateInvestigationTool.Name] = escalateInvestigationTool
+	a.setToolLocked(escalateInvestigationTool)
 
 	// Register report_investigation tool
 	reportInvestigationTool := entity.Tool{
@@ -1607,13 +2212,14 @@ func (a *ExecutorAdapter) registerInvestigationTools() {
 					"minimum":     float64(0),
 					"maximum":     float64(100),
 					"description": "Progress percentage from 0 to 100",
+					"examples":    []interface{}{50},
 				},
 			},
 			"required": []string{"investigation_id", "message"},
 		},
 		RequiredFields: []string{"investigation_id", "message"},
 	}
-	a.tools[reportInvestigationTool.Name] = reportInvestigationTool
+	a.setToolLocked(reportInvestigationTool)
 }
 
 // registerTaskTool registers the task tool with dynamic agent listing.
@@ -1646,17 +2252,23 @@ func (a *ExecutorAdapter) registerTaskTool() {
 				"agent_name": map[string]interface{}{
 					"type":        "string",
 					"description": "Name of the subagent to spawn (e.g., 'code-reviewer', 'test-writer')",
+					"examples":    []interface{}{"code-reviewer", "test-writer"},
 				},
 				"prompt": map[string]interface{}{
 					"type":        "string",
 					"description": "Task description/instructions for the subagent to execute",
 				},
+				"verbatim": map[string]interface{}{
+					"type":        "boolean",
+					"default":     false,
+					"description": "Return the subagent's full output instead of a summary when it is long (default: false)",
+				},
 			},
 			"required": []string{"agent_name", "prompt"},
 		},
 		RequiredFields: []string{"agent_name", "prompt"},
 	}
-	a.tools[taskTool.Name] = taskTool
+	a.setToolLocked(taskTool)
 }
 
 // Investigation status constants.
@@ -1704,11 +2316,13 @@ func (a *ExecutorAdapter) checkAndSetInvestigationStatus(investigationID, newSta
 
 // completeInvestigationInput represents the input for the complete_investigation tool.
 type completeInvestigationInput struct {
-	InvestigationID    string   `json:"investigation_id"`
-	Confidence         *float64 `json:"confidence"`
-	Findings           []string `json:"findings"`
-	RootCause          string   `json:"root_cause,omitempty"`
-	RecommendedActions []string `json:"recommended_actions,omitempty"`
+	InvestigationID string   `json:"investigation_id"`
+	Confidence      *float64 `json:"confidence"`
+	Findings        []string `json:"findings"`
+	RootCause       string   `json:"root_cause,omitempty"`
+	// RecommendedActions are objects, or strings from models that give only a
+	// description; the investigation runner reads them.
+	RecommendedActions []json.RawMessage `json:"recommended_actions,omitempty"`
 }
 
 // escalateInvestigationInput represents the input for the escalate_investigation tool.
@@ -1951,6 +2565,11 @@ func (a *ExecutorAdapter) executeTask(ctx context.Context, input json.RawMessage
 		return "", errors.New("prompt is required")
 	}
 
+	// Skip summarization of long output if requested
+	if params.Verbatim {
+		ctx = port.WithVerbatimOutput(ctx, true)
+	}
+
 	// Spawn subagent
 	result, err := useCase.SpawnSubagent(ctx, params.AgentName, params.Prompt)
 	if err != nil {
@@ -1962,25 +2581,56 @@ func (a *ExecutorAdapter) executeTask(ctx context.Context, input json.RawMessage
 	}
 
 	// Format result as JSON
+	resultJSON := subagentResultJSON(result)
+
+	resultBytes, err := json.MarshalIndent(resultJSON, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format result: %w", err)
+	}
+
+	return string(resultBytes), nil
+}
+
+// subagentResultJSON converts a SubagentResult into the JSON object returned by the
+// subagent tools, with the model that served the run, its token usage, and a one-line
+// summary of its cost. Summarized results also carry the model that wrote the summary
+// and a reference to the full transcript.
+func subagentResultJSON(result *usecase.SubagentResult) map[string]interface{} {
 	resultJSON := map[string]interface{}{
 		"subagent_id":   result.SubagentID,
 		"agent_name":    result.AgentName,
 		"status":        result.Status,
+		"summary":       result.Summary(),
 		"output":        result.Output,
 		"actions_taken": result.ActionsTaken,
+		"tool_errors":   result.ToolErrors,
 		"duration_ms":   result.Duration.Milliseconds(),
+		"input_tokens":  result.TokensUsed.InputTokens,
+		"output_tokens": result.TokensUsed.OutputTokens,
+	}
+
+	if result.SessionID != "" {
+		resultJSON["session_id"] = result.SessionID
 	}
 
+	if result.Model != "" {
+		resultJSON["model"] = result.Model
+	}
 	if result.Error != nil {
 		resultJSON["error"] = result.Error.Error()
 	}
 
-	resultBytes, err := json.MarshalIndent(resultJSON, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to format result: %w", err)
+	if result.Summarized {
+		resultJSON["summarized"] = true
+		if result.SummaryModel != "" {
+			resultJSON["summary_model"] = result.SummaryModel
+		}
+		if result.TranscriptRef != "" {
+			resultJSON["transcript_ref"] = result.TranscriptRef
+		}
 	}
 
-	return string(resultBytes), nil
+	return resultJSON
 }
 
 // executeDelegate executes the delegate tool to spawn a dynamic subagent.
@@ -2025,6 +2675,11 @@ func (a *ExecutorAdapter) executeDelegate(ctx context.Context, input json.RawMes
 		AllowedTools: params.AllowedTools, // nil means all tools
 	}
 
+	// Skip summarization of long output if requested
+	if params.Verbatim {
+		ctx = port.WithVerbatimOutput(ctx, true)
+	}
+
 	// Spawn dynamic subagent
 	result, err := useCase.SpawnDynamicSubagent(ctx, config, params.Task)
 	if err != nil {
@@ -2036,20 +2691,85 @@ func (a *ExecutorAdapter) executeDelegate(ctx context.Context, input json.RawMes
 	}
 
 	// Format result as JSON
-	resultJSON := map[string]interface{}{
-		"subagent_id":   result.SubagentID,
-		"agent_name":    result.AgentName,
-		"status":        result.Status,
-		"output":        result.Output,
-		"actions_taken": result.ActionsTaken,
-		"duration_ms":   result.Duration.Milliseconds(),
+	resultJSON := subagentResultJSON(result)
+
+	resultBytes, err := json.MarshalIndent(resultJSON, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format result: %w", err)
 	}
 
-	if result.Error != nil {
-		resultJSON["error"] = result.Error.Error()
+	return string(resultBytes), nil
+}
+
+// executeDelegateParallel runs several named subagents concurrently and returns their
+// results as a JSON array in input order. Per-task failures are reported in each
+// result's status and error fields rather than failing the whole call.
+func (a *ExecutorAdapter) executeDelegateParallel(ctx context.Context, input json.RawMessage) (string, error) {
+	// Check for recursion (subagents cannot spawn subagents)
+	if port.IsSubagentContext(ctx) {
+		return "", errors.New(
+			"delegate_parallel tool cannot be called from within a subagent (prevents infinite recursion)",
+		)
 	}
 
-	resultBytes, err := json.MarshalIndent(resultJSON, "", "  ")
+	// Check if use case is set
+	a.mu.RLock()
+	useCase := a.subagentUseCase
+	a.mu.RUnlock()
+
+	if useCase == nil {
+		return "", errors.New("subagent use case not available")
+	}
+
+	// Parse input
+	var params delegateParallelInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("failed to parse delegate_parallel input: %w", err)
+	}
+
+	// Validate inputs
+	if len(params.Tasks) == 0 {
+		return "", errors.New("tasks is required and must not be empty")
+	}
+	requests := make([]*usecase.SubagentRequest, len(params.Tasks))
+	for i, task := range params.Tasks {
+		if task.Agent == "" {
+			return "", fmt.Errorf("tasks[%d]: agent is required", i)
+		}
+		if task.Prompt == "" {
+			return "", fmt.Errorf("tasks[%d]: prompt is required", i)
+		}
+		requests[i] = &usecase.SubagentRequest{AgentName: task.Agent, Prompt: task.Prompt}
+	}
+
+	// Skip summarization of long output if requested
+	if params.Verbatim {
+		ctx = port.WithVerbatimOutput(ctx, true)
+	}
+
+	// Spawn subagents
+	results, err := useCase.SpawnParallel(ctx, requests)
+	if err != nil {
+		return "", fmt.Errorf("parallel subagent execution failed: %w", err)
+	}
+
+	// Format results as a JSON array, one entry per task
+	resultsJSON := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		if result == nil {
+			resultsJSON[i] = map[string]interface{}{
+				"index":  i,
+				"status": "failed",
+				"error":  "subagent execution returned nil result",
+			}
+			continue
+		}
+		entry := subagentResultJSON(result)
+		entry["index"] = i
+		resultsJSON[i] = entry
+	}
+
+	resultBytes, err := json.MarshalIndent(resultsJSON, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to format result: %w", err)
 	}
@@ -0,0 +1,98 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAskUser_OnlyRegisteredWithCallback(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	if _, ok := adapter.GetTool("ask_user"); ok {
+		t.Fatal("ask_user should not be registered without a prompt callback")
+	}
+
+	adapter.SetUserPromptCallback(func(context.Context, string, []string) (string, error) { return "", nil })
+	askUser, ok := adapter.GetTool("ask_user")
+	if !ok {
+		t.Fatal("ask_user should be registered with a prompt callback")
+	}
+	if !askUser.Interactive {
+		t.Error("ask_user should be marked interactive")
+	}
+
+	adapter.SetUserPromptCallback(nil)
+	if _, ok := adapter.GetTool("ask_user"); ok {
+		t.Error("a nil callback should unregister ask_user")
+	}
+}
+
+func TestAskUser_ReturnsAnswer(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	var gotQuestion string
+	var gotChoices []string
+	adapter.SetUserPromptCallback(func(_ context.Context, question string, choices []string) (string, error) {
+		gotQuestion, gotChoices = question, choices
+		return choices[1], nil
+	})
+
+	result, err := adapter.ExecuteTool(context.Background(), "ask_user",
+		`{"question": "Which config should I edit?", "choices": ["dev.yaml", "prod.yaml"]}`)
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if result != "prod.yaml" {
+		t.Errorf("result = %q, want %q", result, "prod.yaml")
+	}
+	if gotQuestion != "Which config should I edit?" || !slices.Equal(gotChoices, []string{"dev.yaml", "prod.yaml"}) {
+		t.Errorf("callback got (%q, %v)", gotQuestion, gotChoices)
+	}
+}
+
+func TestAskUser_CancelledWhileWaiting(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.SetUserPromptCallback(func(ctx context.Context, _ string, _ []string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := adapter.ExecuteTool(ctx, "ask_user", `{"question": "Proceed?"}`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteTool() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAskUser_RefusedInHeadlessRuns(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	called := false
+	adapter.SetUserPromptCallback(func(context.Context, string, []string) (string, error) {
+		called = true
+		return "yes", nil
+	})
+
+	_, err := adapter.ExecuteTool(port.WithHeadless(context.Background()), "ask_user", `{"question": "Proceed?"}`)
+	if err == nil || !strings.Contains(err.Error(), "headless") {
+		t.Errorf("ExecuteTool() error = %v, want a headless error", err)
+	}
+	if called {
+		t.Error("the user should not be asked in a headless run")
+	}
+}
+
+func TestAskUser_InvalidInput(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.SetUserPromptCallback(func(context.Context, string, []string) (string, error) { return "", nil })
+
+	for _, input := range []string{`{"question": "  "}`, `{"question": "Which?", "choices": ["a", ""]}`} {
+		if _, err := adapter.ExecuteTool(context.Background(), "ask_user", input); err == nil {
+			t.Errorf("ExecuteTool(%s) should fail", input)
+		}
+	}
+}
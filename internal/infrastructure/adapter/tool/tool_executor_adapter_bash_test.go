@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -658,6 +660,132 @@ func TestBashTool_LLMFailedToIdentifyDangerous(t *testing.T) {
 	}
 }
 
+// runBash runs a bash command through adapter and decodes its output.
+func runBash(t *testing.T, adapter *ExecutorAdapter, input string) bashOutputTest {
+	t.Helper()
+	result, err := adapter.ExecuteTool(context.Background(), "bash", input)
+	if err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+	var output bashOutputTest
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	return output
+}
+
+func TestBashTool_OutputCap(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetBashOptions(BashOptions{MaxOutputBytes: 100})
+
+	// 80 bytes on stdout, then 80 on stderr: the cap is shared, so stderr is cut
+	output := runBash(t, adapter,
+		`{"command": "head -c 80 /dev/zero | tr '\\0' o; head -c 80 /dev/zero | tr '\\0' e >&2", "dangerous": false}`)
+
+	if output.Stdout != strings.Repeat("o", 80) {
+		t.Errorf("Expected 80 bytes of stdout, got %q", output.Stdout)
+	}
+	wantStderr := strings.Repeat("e", 20) + "\n\n[output truncated: showing 100 of 160 bytes of stdout and stderr]"
+	if output.Stderr != wantStderr {
+		t.Errorf("Expected stderr %q, got %q", wantStderr, output.Stderr)
+	}
+}
+
+func TestBashTool_OutputCapDefault(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	output := runBash(t, adapter, `{"command": "head -c 2000000 /dev/zero", "dangerous": false}`)
+
+	notice := fmt.Sprintf("[output truncated: showing %d of 2000000 bytes of stdout and stderr]", DefaultBashMaxOutputBytes)
+	if !strings.HasSuffix(output.Stdout, notice) {
+		t.Errorf("Expected stdout to end with %q", notice)
+	}
+	if kept := len(output.Stdout) - len(notice) - 2; kept != DefaultBashMaxOutputBytes {
+		t.Errorf("Expected %d bytes of stdout, got %d", DefaultBashMaxOutputBytes, kept)
+	}
+}
+
+func TestBashTool_EnvAllowlist(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "leaked")
+	t.Setenv("ANTHROPIC_API_KEY", "leaked")
+	t.Setenv("LANG", "C.UTF-8")
+	t.Setenv("CI_JOB_ID", "42")
+
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetBashOptions(BashOptions{AllowedEnv: []string{"CI_*"}})
+
+	output := runBash(t, adapter, `{"command": "env", "dangerous": false, "env": {"GOFLAGS": "-count=1", "LANG": "C"}}`)
+
+	env := strings.Split(strings.TrimSpace(output.Stdout), "\n")
+	for _, want := range []string{"LANG=C", "CI_JOB_ID=42", "GOFLAGS=-count=1"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("Expected %s in the environment, got %v", want, env)
+		}
+	}
+	for _, entry := range env {
+		if strings.Contains(entry, "leaked") || entry == "LANG=C.UTF-8" {
+			t.Errorf("Unexpected variable in the environment: %s", entry)
+		}
+	}
+	if !slices.ContainsFunc(env, func(entry string) bool { return strings.HasPrefix(entry, "PATH=") }) {
+		t.Errorf("Expected PATH in the environment, got %v", env)
+	}
+}
+
+func TestBashTool_InvalidEnvName(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	_, err := adapter.ExecuteTool(context.Background(), "bash",
+		`{"command": "true", "dangerous": false, "env": {"BAD=NAME": "x"}}`)
+	if err == nil || !strings.Contains(err.Error(), "invalid environment variable name") {
+		t.Errorf("Expected invalid environment variable name error, got %v", err)
+	}
+}
+
+func TestBashTool_UnsafeEnvRefused(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	unsafe := []string{"BASH_ENV", "ENV", "PATH", "LD_PRELOAD", "PROMPT_COMMAND", "BASH_FUNC_ls", "GIT_SSH_COMMAND"}
+	for _, name := range unsafe {
+		input := fmt.Sprintf(`{"command": "true", "dangerous": false, "env": {%q: "x"}}`, name)
+		_, err := adapter.ExecuteTool(context.Background(), "bash", input)
+		if err == nil || !strings.Contains(err.Error(), "may not be set") {
+			t.Errorf("Expected %s to be refused, got %v", name, err)
+		}
+	}
+}
+
+func TestBashTool_ConfirmationShowsEnv(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	var confirmed string
+	adapter.SetCommandConfirmationCallback(func(command string, _ bool, _, _ string) bool {
+		confirmed = command
+		return false
+	})
+
+	_, _ = adapter.ExecuteTool(context.Background(), "bash",
+		`{"command": "ls", "dangerous": false, "env": {"LANG": "C", "GOFLAGS": "it's"}}`)
+
+	if want := `GOFLAGS='it'\''s' LANG='C' ls`; confirmed != want {
+		t.Errorf("Expected confirmation of %q, got %q", want, confirmed)
+	}
+}
+
+func TestBashTool_WorkingDir(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := NewExecutorAdapter(file.NewLocalFileManager(dir))
+	adapter.SetBashOptions(BashOptions{WorkingDir: dir})
+
+	output := runBash(t, adapter, `{"command": "pwd -P", "dangerous": false}`)
+
+	if got := strings.TrimSpace(output.Stdout); got != dir {
+		t.Errorf("Expected working directory %s, got %s", dir, got)
+	}
+}
+
 // =============================================================================
 // Tests for Fetch Tool
 // =============================================================================
@@ -0,0 +1,137 @@
+package tool
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// delegate_parallel Tool Tests
+// =============================================================================
+
+func TestDelegateParallelTool_RegisteredInDefaultTools(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	tool, exists := adapter.GetTool("delegate_parallel")
+	if !exists {
+		t.Fatal("delegate_parallel tool should be registered by default")
+	}
+	if len(tool.RequiredFields) != 1 || tool.RequiredFields[0] != "tasks" {
+		t.Errorf("RequiredFields = %v, want [tasks]", tool.RequiredFields)
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_DelegateParallelSuccess(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	var capturedRequests []*usecase.SubagentRequest
+	adapter.SetSubagentUseCase(&MockSubagentUseCase{
+		SpawnParallelFunc: func(
+			_ context.Context,
+			requests []*usecase.SubagentRequest,
+		) ([]*usecase.SubagentResult, error) {
+			capturedRequests = requests
+			return []*usecase.SubagentResult{
+				{
+					SubagentID:   "sub-1",
+					AgentName:    "reviewer",
+					Status:       "completed",
+					Output:       "looks good",
+					ActionsTaken: 2,
+					Duration:     150 * time.Millisecond,
+				},
+				{
+					SubagentID: "sub-2",
+					AgentName:  "tester",
+					Status:     "failed",
+					Error:      errors.New("agent not found"),
+				},
+			}, nil
+		},
+	})
+
+	input := `{"tasks": [{"agent": "reviewer", "prompt": "review"}, {"agent": "tester", "prompt": "test"}]}`
+	result, err := adapter.ExecuteTool(context.Background(), "delegate_parallel", input)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(capturedRequests) != 2 || capturedRequests[0].AgentName != "reviewer" ||
+		capturedRequests[1].Prompt != "test" {
+		t.Errorf("Unexpected requests passed to use case: %+v", capturedRequests)
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &results); err != nil {
+		t.Fatalf("Result should be a JSON array: %v\n%s", err, result)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0]["agent_name"] != "reviewer" || results[0]["status"] != "completed" ||
+		results[0]["duration_ms"] != float64(150) {
+		t.Errorf("Unexpected first result: %v", results[0])
+	}
+	if results[1]["status"] != "failed" || results[1]["error"] != "agent not found" {
+		t.Errorf("Expected per-task error in second result, got: %v", results[1])
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_DelegateParallelValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "missing tasks", input: `{}`, wantErr: "missing required field: tasks"},
+		{name: "empty tasks", input: `{"tasks": []}`, wantErr: "tasks is required"},
+		{name: "missing agent", input: `{"tasks": [{"prompt": "x"}]}`, wantErr: "tasks[0]: agent is required"},
+		{
+			name:    "missing prompt",
+			input:   `{"tasks": [{"agent": "a", "prompt": "x"}, {"agent": "b"}]}`,
+			wantErr: "tasks[1]: prompt is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+			adapter.SetSubagentUseCase(&MockSubagentUseCase{
+				SpawnParallelFunc: func(
+					_ context.Context,
+					_ []*usecase.SubagentRequest,
+				) ([]*usecase.SubagentResult, error) {
+					t.Error("SpawnParallel should not be called for invalid input")
+					return nil, nil
+				},
+			})
+
+			_, err := adapter.ExecuteTool(context.Background(), "delegate_parallel", tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_DelegateParallelRecursionBlockedInSubagentContext(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetSubagentUseCase(&MockSubagentUseCase{})
+
+	subagentCtx := port.WithSubagentContext(context.Background(), port.SubagentContextInfo{
+		SubagentID: "parent-subagent",
+		IsSubagent: true,
+	})
+
+	_, err := adapter.ExecuteTool(subagentCtx, "delegate_parallel", `{"tasks": [{"agent": "a", "prompt": "x"}]}`)
+	if err == nil || !strings.Contains(err.Error(), "recursion") {
+		t.Errorf("Expected recursion error in subagent context, got %v", err)
+	}
+}
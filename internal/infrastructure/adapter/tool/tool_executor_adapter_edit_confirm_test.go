@@ -0,0 +1,147 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editConfirmation records the arguments of a file edit confirmation.
+type editConfirmation struct {
+	path string
+	diff string
+}
+
+func newEditConfirmAdapter(t *testing.T, approve bool) (*tool.ExecutorAdapter, string, *[]editConfirmation) {
+	t.Helper()
+	dir := t.TempDir()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+
+	var calls []editConfirmation
+	adapter.SetFileEditConfirmationCallback(func(path, diff string) bool {
+		calls = append(calls, editConfirmation{path: path, diff: diff})
+		return approve
+	})
+	return adapter, dir, &calls
+}
+
+func editFileInput(t *testing.T, path, oldStr, newStr string) string {
+	t.Helper()
+	input, err := json.Marshal(map[string]string{"path": path, "old_str": oldStr, "new_str": newStr})
+	if err != nil {
+		t.Fatalf("failed to marshal input: %v", err)
+	}
+	return string(input)
+}
+
+func TestEditFile_Confirmation_AcceptAppliesEdit(t *testing.T) {
+	adapter, dir, calls := newEditConfirmAdapter(t, true)
+	original := "one\ntwo\nthree\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := adapter.ExecuteTool(context.Background(), "edit_file", editFileInput(t, filepath.Join(dir, "a.txt"), "two", "TWO"))
+	if err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+
+	if len(*calls) != 1 {
+		t.Fatalf("expected 1 confirmation, got %d", len(*calls))
+	}
+	path := filepath.Join(dir, "a.txt")
+	want := "--- a/" + path + "\n+++ b/" + path + "\n@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n"
+	if got := (*calls)[0].diff; got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(content) != "one\nTWO\nthree\n" {
+		t.Errorf("edit was not applied, content: %q", content)
+	}
+}
+
+func TestEditFile_Confirmation_RejectLeavesFileUntouched(t *testing.T) {
+	adapter, dir, calls := newEditConfirmAdapter(t, false)
+	original := "keep me\n"
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := adapter.ExecuteTool(context.Background(), "edit_file", editFileInput(t, filepath.Join(dir, "a.txt"), "keep", "drop"))
+	if err == nil || !strings.Contains(err.Error(), "denied by user") {
+		t.Fatalf("expected denial error, got %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("expected 1 confirmation, got %d", len(*calls))
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != original {
+		t.Errorf("file was modified after rejection: %q", content)
+	}
+}
+
+func TestEditFile_Confirmation_RejectNewFileIsNotCreated(t *testing.T) {
+	adapter, dir, calls := newEditConfirmAdapter(t, false)
+
+	_, err := adapter.ExecuteTool(context.Background(), "edit_file", editFileInput(t, filepath.Join(dir, "sub", "new.txt"), "", "hello\n"))
+	if err == nil {
+		t.Fatal("expected denial error")
+	}
+	if len(*calls) != 1 || !strings.HasPrefix((*calls)[0].diff, "--- /dev/null\n") ||
+		!strings.HasSuffix((*calls)[0].diff, "new.txt\n@@ -0,0 +1,1 @@\n+hello\n") {
+		t.Fatalf("unexpected confirmations: %+v", *calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub")); !os.IsNotExist(err) {
+		t.Errorf("rejected new file should not create anything, stat err: %v", err)
+	}
+}
+
+func TestEditFile_Confirmation_DistantChangesGetSeparateHunks(t *testing.T) {
+	adapter, dir, calls := newEditConfirmAdapter(t, true)
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines[1] = "marker a"
+	lines[27] = "marker b"
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := editFileInput(t, filepath.Join(dir, "a.txt"), "marker", "MARKER")
+	if _, err := adapter.ExecuteTool(context.Background(), "edit_file", input); err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+
+	diff := (*calls)[0].diff
+	for _, header := range []string{"@@ -1,5 +1,5 @@\n", "@@ -25,6 +25,6 @@\n"} {
+		if !strings.Contains(diff, header) {
+			t.Errorf("expected hunk header %q in diff:\n%s", header, diff)
+		}
+	}
+}
+
+func TestEditFile_NoConfirmationCallbackAppliesEdit(t *testing.T) {
+	dir := t.TempDir()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "edit_file", editFileInput(t, filepath.Join(dir, "a.txt"), "a", "b")); err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(content) != "b\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
@@ -0,0 +1,220 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// git runs git in dir, failing the test on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newGitRepo creates a repository on branch main with one commit of main.go.
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	git(t, dir, "init", "--quiet", "--initial-branch=main")
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	git(t, dir, "add", "main.go")
+	git(t, dir, "commit", "--quiet", "-m", "Initial commit")
+	return dir
+}
+
+// newGitAdapter creates an executor with the git tools enabled in dir, its
+// diff confirmations recorded in confirmed and answered with approve.
+func newGitAdapter(t *testing.T, dir string, opts tool.GitOptions, approve bool) (*tool.ExecutorAdapter, *[]string) {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+	// The git tools inherit the bash environment allowlist
+	adapter.SetBashOptions(tool.BashOptions{AllowedEnv: []string{"GIT_*"}})
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool { return true })
+	var confirmed []string
+	adapter.SetFileEditConfirmationCallback(func(path, diff string) bool {
+		confirmed = append(confirmed, path+"\n"+diff)
+		return approve
+	})
+	opts.WorkingDir = dir
+	adapter.EnableGit(opts)
+	return adapter, &confirmed
+}
+
+func TestGitStatusAndDiff(t *testing.T) {
+	dir := newGitRepo(t)
+	adapter, _ := newGitAdapter(t, dir, tool.GitOptions{MaxDiffBytes: 200}, true)
+	if _, ok := adapter.GetTool("git_push"); ok {
+		t.Error("git_push should not be registered unless AllowPush is set")
+	}
+
+	got, err := adapter.ExecuteTool(context.Background(), "git_status", `{}`)
+	if err != nil || got != "## main\nNothing to commit, working tree clean" {
+		t.Fatalf("git_status of a clean tree = %q, %v", got, err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeTestFile(t, filepath.Join(dir, "new.go"), "package main\n")
+	got, err = adapter.ExecuteTool(context.Background(), "git_status", `{}`)
+	if err != nil || got != "## main\n M main.go\n?? new.go" {
+		t.Errorf("git_status = %q, %v", got, err)
+	}
+
+	got, err = adapter.ExecuteTool(context.Background(), "git_diff", `{"paths": ["main.go"]}`)
+	if err != nil || !strings.Contains(got, "+func main() {}") {
+		t.Errorf("git_diff = %q, %v", got, err)
+	}
+	got, err = adapter.ExecuteTool(context.Background(), "git_diff", `{"staged": true}`)
+	if err != nil || got != "No staged changes" {
+		t.Errorf("git_diff staged = %q, %v", got, err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n"+strings.Repeat("// filler line\n", 20))
+	got, err = adapter.ExecuteTool(context.Background(), "git_diff", `{}`)
+	if err != nil || !strings.Contains(got, "[diff truncated: showing ") || len(got) > 300 {
+		t.Errorf("git_diff of a large change = %q, %v", got, err)
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "git_diff", `{"paths": ["../elsewhere"]}`); err == nil {
+		t.Error("git_diff outside the workspace should fail")
+	}
+}
+
+// TestGitStatus_ConcurrentBashOptions runs the git tools while the bash
+// options they take the environment allowlist from change; run with -race.
+func TestGitStatus_ConcurrentBashOptions(t *testing.T) {
+	dir := newGitRepo(t)
+	adapter, _ := newGitAdapter(t, dir, tool.GitOptions{}, true)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			adapter.SetBashOptions(tool.BashOptions{AllowedEnv: []string{"GIT_*"}})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 5 {
+			if _, err := adapter.ExecuteTool(context.Background(), "git_status", `{}`); err != nil {
+				t.Errorf("git_status error = %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestGitCommit(t *testing.T) {
+	dir := newGitRepo(t)
+	adapter, confirmed := newGitAdapter(t, dir, tool.GitOptions{}, true)
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeTestFile(t, filepath.Join(dir, "new.go"), "package main\n")
+	writeTestFile(t, filepath.Join(dir, "other.go"), "package other\n")
+	git(t, dir, "add", "other.go")
+
+	got, err := adapter.ExecuteTool(context.Background(), "git_commit",
+		`{"message": "Add main function\n\nAnd a new file.", "files": ["main.go", "new.go"]}`)
+	if err != nil {
+		t.Fatalf("git_commit failed: %v", err)
+	}
+	if !strings.HasPrefix(got, "Committed ") || !strings.Contains(got, ": Add main function\n") {
+		t.Errorf("git_commit = %q", got)
+	}
+	if files := git(t, dir, "show", "--name-only", "--format=", "HEAD"); files != "main.go\nnew.go" {
+		t.Errorf("committed files = %q, want main.go and new.go", files)
+	}
+	// Other staged changes stay staged
+	if status := git(t, dir, "status", "--short"); status != "A  other.go" {
+		t.Errorf("status after commit = %q", status)
+	}
+	if len(*confirmed) != 1 || !strings.HasPrefix((*confirmed)[0], "git commit: Add main function\n") ||
+		!strings.Contains((*confirmed)[0], "+func main() {}") || !strings.Contains((*confirmed)[0], "+++ b/new.go") {
+		t.Errorf("confirmations = %q", *confirmed)
+	}
+
+	for name, input := range map[string]string{
+		"empty message":     `{"message": "  ", "files": ["main.go"]}`,
+		"no files":          `{"message": "Change", "files": []}`,
+		"outside workspace": `{"message": "Change", "files": ["../outside.go"]}`,
+		"absolute outside":  `{"message": "Change", "files": ["/etc/passwd"]}`,
+		"no changes":        `{"message": "Change", "files": ["main.go"]}`,
+		"missing file":      `{"message": "Change", "files": ["missing.go"]}`,
+	} {
+		if _, err := adapter.ExecuteTool(context.Background(), "git_commit", input); err == nil {
+			t.Errorf("git_commit with %s should fail", name)
+		}
+	}
+}
+
+func TestGitCommit_Denied(t *testing.T) {
+	dir := newGitRepo(t)
+	adapter, _ := newGitAdapter(t, dir, tool.GitOptions{}, false)
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	writeTestFile(t, filepath.Join(dir, "new.go"), "package main\n")
+	if _, err := adapter.ExecuteTool(context.Background(), "git_commit",
+		`{"message": "Add new.go", "files": ["new.go"]}`); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("git_commit denied by the user = %v, want an error", err)
+	}
+	if git(t, dir, "rev-parse", "HEAD") != head {
+		t.Error("a denied commit was made")
+	}
+	// The untracked file is not staged either
+	if status := git(t, dir, "status", "--short"); status != "?? new.go" {
+		t.Errorf("status after a denied commit = %q", status)
+	}
+}
+
+func TestGitPush(t *testing.T) {
+	dir := newGitRepo(t)
+	remote := t.TempDir()
+	git(t, remote, "init", "--quiet", "--bare")
+	git(t, dir, "remote", "add", "origin", remote)
+	adapter, _ := newGitAdapter(t, dir, tool.GitOptions{AllowPush: true}, true)
+
+	_, err := adapter.ExecuteTool(context.Background(), "git_push", `{}`)
+	if err == nil || !strings.Contains(err.Error(), `protected branch "main"`) {
+		t.Errorf("git_push of main = %v, want a protected branch error", err)
+	}
+	if _, err := adapter.ExecuteTool(context.Background(), "git_push", `{"branch": "release/1.0"}`); err == nil {
+		t.Error("git_push matching release/* should fail")
+	}
+	if _, err := adapter.ExecuteTool(context.Background(), "git_push", `{"remote": "upstream", "branch": "feature"}`); err == nil {
+		t.Error("git_push to an unknown remote should fail")
+	}
+
+	git(t, dir, "checkout", "--quiet", "-b", "feature")
+	got, err := adapter.ExecuteTool(context.Background(), "git_push", `{}`)
+	if err != nil || got != "Pushed feature to origin" {
+		t.Fatalf("git_push of feature = %q, %v", got, err)
+	}
+	if pushed := git(t, remote, "rev-parse", "feature"); pushed != git(t, dir, "rev-parse", "HEAD") {
+		t.Errorf("remote feature = %s, want HEAD", pushed)
+	}
+	if branches := git(t, remote, "branch", "--list", "main"); branches != "" {
+		t.Errorf("main was pushed: %q", branches)
+	}
+}
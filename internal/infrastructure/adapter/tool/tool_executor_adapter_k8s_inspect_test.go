@@ -0,0 +1,199 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var k8sTestTime = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// newK8sAdapter returns an executor whose k8s_inspect tool reads objects
+// from a fake clientset and may only read the prod namespace.
+func newK8sAdapter(t *testing.T, objects ...runtime.Object) *tool.ExecutorAdapter {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.EnableK8sInspect(fake.NewClientset(objects...), tool.K8sInspectOptions{AllowedNamespaces: []string{"prod"}})
+	return adapter
+}
+
+func k8sInspect(adapter *tool.ExecutorAdapter, input string) (string, error) {
+	return adapter.ExecuteTool(context.Background(), "k8s_inspect", input)
+}
+
+func TestK8sInspect_OnlyRegisteredWhenEnabled(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	if _, ok := adapter.GetTool("k8s_inspect"); ok {
+		t.Fatal("k8s_inspect should not be registered before EnableK8sInspect")
+	}
+	if _, ok := newK8sAdapter(t).GetTool("k8s_inspect"); !ok {
+		t.Fatal("k8s_inspect should be registered after EnableK8sInspect")
+	}
+}
+
+func TestK8sInspect_Pods(t *testing.T) {
+	started := metav1.NewTime(k8sTestTime)
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "prod", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}}},
+		Status: corev1.PodStatus{
+			Phase:     corev1.PodRunning,
+			StartTime: &started,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 7,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(k8sTestTime.Add(time.Hour)),
+					}},
+				},
+				{Name: "proxy", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	healthy := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "prod", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "prod", Labels: map[string]string{"app": "db"}}}
+	adapter := newK8sAdapter(t, crashing, healthy, other)
+
+	result, err := k8sInspect(adapter, `{"action": "pods", "namespace": "prod", "label_selector": "app=web"}`)
+	if err != nil {
+		t.Fatalf("k8s_inspect error = %v", err)
+	}
+	want := `namespace: prod
+pods: 2
+- name: web-1
+  phase: Pending
+  ready: 0/1
+  restarts: 0
+- name: web-2
+  phase: Running
+  ready: 1/2
+  restarts: 7
+  node: node-1
+  started: 2024-05-01T10:00:00Z
+  containers:
+    - app: waiting (CrashLoopBackOff), 7 restarts, last exit OOMKilled (code 137) at 2024-05-01T11:00:00Z
+    - proxy: running`
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+
+	empty, err := k8sInspect(adapter, `{"action": "pods", "namespace": "prod", "label_selector": "app=cache"}`)
+	if err != nil || empty != "No pods in namespace prod." {
+		t.Errorf("empty result = %q, %v", empty, err)
+	}
+}
+
+func TestK8sInspect_Events(t *testing.T) {
+	event := func(name, kind, object, reason string, count int32, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: "prod"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        reason + " happened",
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	adapter := newK8sAdapter(t,
+		event("e1", "Pod", "web-2", "BackOff", 12, k8sTestTime.Add(2*time.Minute)),
+		event("e2", "Pod", "web-2", "OOMKilling", 1, k8sTestTime),
+		event("e3", "Deployment", "web", "ScalingReplicaSet", 1, k8sTestTime.Add(time.Minute)),
+	)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "one object, oldest first",
+			input: `{"action": "events", "namespace": "prod", "name": "web-2"}`,
+			want: "- 2024-05-01T10:00:00Z Warning OOMKilling Pod/web-2: OOMKilling happened\n" +
+				"- 2024-05-01T10:02:00Z Warning BackOff Pod/web-2 (x12): BackOff happened",
+		},
+		{
+			name:  "by kind",
+			input: `{"action": "events", "namespace": "prod", "kind": "deployment"}`,
+			want:  "- 2024-05-01T10:01:00Z Warning ScalingReplicaSet Deployment/web: ScalingReplicaSet happened",
+		},
+		{
+			name:  "no match",
+			input: `{"action": "events", "namespace": "prod", "name": "web-9"}`,
+			want:  "No matching events in namespace prod.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := k8sInspect(adapter, tt.input)
+			if err != nil {
+				t.Fatalf("k8s_inspect error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("result =\n%s\nwant\n%s", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestK8sInspect_Logs(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "prod"}}
+	adapter := newK8sAdapter(t, pod)
+
+	// The fake clientset serves every log request with "fake logs"
+	result, err := k8sInspect(adapter, `{"action": "logs", "namespace": "prod", "name": "web-2", "previous": true, "tail_lines": 5000}`)
+	if err != nil {
+		t.Fatalf("k8s_inspect error = %v", err)
+	}
+	if result != "fake logs" {
+		t.Errorf("result = %q, want %q", result, "fake logs")
+	}
+
+	if _, err := k8sInspect(adapter, `{"action": "logs", "namespace": "prod"}`); err == nil ||
+		!strings.Contains(err.Error(), "name is required") {
+		t.Errorf("logs without a name error = %v", err)
+	}
+}
+
+func TestK8sInspect_NamespaceRestriction(t *testing.T) {
+	secret := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "vault-0", Namespace: "kube-system"}}
+	adapter := newK8sAdapter(t, secret)
+
+	for _, action := range []string{"pods", "events", "logs"} {
+		t.Run(action, func(t *testing.T) {
+			_, err := k8sInspect(adapter, `{"action": "`+action+`", "namespace": "kube-system", "name": "vault-0"}`)
+			if !errors.Is(err, tool.ErrNamespaceNotAllowed) {
+				t.Errorf("error = %v, want ErrNamespaceNotAllowed", err)
+			}
+		})
+	}
+
+	wildcard := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	wildcard.EnableK8sInspect(fake.NewClientset(secret), tool.K8sInspectOptions{AllowedNamespaces: []string{"*"}})
+	result, err := k8sInspect(wildcard, `{"action": "pods", "namespace": "kube-system"}`)
+	if err != nil || !strings.Contains(result, "- name: vault-0") {
+		t.Errorf(`"*" should allow every namespace, got %q, %v`, result, err)
+	}
+}
+
+func TestK8sInspect_UnknownAction(t *testing.T) {
+	_, err := k8sInspect(newK8sAdapter(t), `{"action": "delete", "namespace": "prod"}`)
+	if err == nil {
+		t.Fatal("expected an error for an action outside the enum")
+	}
+}
@@ -0,0 +1,34 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/project"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectInfo_ReportsDetectedProject(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/svc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(root))
+	if _, ok := adapter.GetTool("project_info"); ok {
+		t.Fatal("project_info should not be registered before EnableProjectInfo")
+	}
+	adapter.EnableProjectInfo(project.NewDetector(root))
+
+	result, err := adapter.ExecuteTool(context.Background(), "project_info", `{}`)
+	if err != nil {
+		t.Fatalf("project_info error = %v", err)
+	}
+	for _, want := range []string{"Go (go.mod)", "- Module: example.com/svc", "- Test: go test ./..."} {
+		if !strings.Contains(result, want) {
+			t.Errorf("project_info = %q, want it to contain %q", result, want)
+		}
+	}
+}
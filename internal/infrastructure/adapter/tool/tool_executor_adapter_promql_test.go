@@ -0,0 +1,201 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const promInstantResponse = `{"status":"success","data":{"resultType":"vector","result":[
+{"metric":{"__name__":"node_load1","instance":"web-2:9100","job":"node"},"value":[1714557600,"7.5"]},
+{"metric":{"__name__":"node_load1","instance":"web-1:9100","job":"node"},"value":[1714557600,"0.25"]},
+{"metric":{"__name__":"node_load1","instance":"db-1:9100","job":"node"},"value":[1714557600,"1"]}]}}`
+
+const promRangeResponse = `{"status":"success","warnings":["results truncated due to limit"],"data":{"resultType":"matrix","result":[
+{"metric":{"instance":"web-1:9100"},"values":[[1714557600,"10"],[1714557660,"30"],[1714557720,"95.5"],[1714557780,"80"]]}]}}`
+
+// promServer serves canned Prometheus API responses and records the last
+// request.
+type promServer struct {
+	*httptest.Server
+	path  string
+	query url.Values
+}
+
+func newPromServer(t *testing.T, status int, body string) *promServer {
+	t.Helper()
+	s := &promServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.path, s.query = r.URL.Path, r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newPromQLAdapter(t *testing.T, opts tool.PromQLOptions) *tool.ExecutorAdapter {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.EnablePromQL(opts)
+	return adapter
+}
+
+func TestPromQLQuery_Instant(t *testing.T) {
+	server := newPromServer(t, http.StatusOK, promInstantResponse)
+	adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: server.URL + "/", MaxSeries: 2})
+
+	result, err := adapter.ExecuteTool(context.Background(), "promql_query", `{"query": "node_load1"}`)
+	if err != nil {
+		t.Fatalf("promql_query error = %v", err)
+	}
+	want := `Instant query: node_load1
+
+Series: 3 (showing 2; aggregate or filter the query to see the rest)
+node_load1{instance="db-1:9100", job="node"} 1
+node_load1{instance="web-1:9100", job="node"} 0.25`
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+	if server.path != "/api/v1/query" || server.query.Get("query") != "node_load1" {
+		t.Errorf("requested %s with %v", server.path, server.query)
+	}
+}
+
+func TestPromQLQuery_Range(t *testing.T) {
+	server := newPromServer(t, http.StatusOK, promRangeResponse)
+	adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: server.URL})
+
+	result, err := adapter.ExecuteTool(context.Background(), "promql_query",
+		`{"query": "cpu_usage", "start": "2024-05-01T10:00:00Z", "end": "2024-05-01T11:00:00Z", "step": "1m"}`)
+	if err != nil {
+		t.Fatalf("promql_query error = %v", err)
+	}
+	want := `Range query: cpu_usage
+From 2024-05-01T10:00:00Z to 2024-05-01T11:00:00Z, step 1m0s
+Warning: results truncated due to limit
+
+Series: 1
+{instance="web-1:9100"}
+  min=10 max=95.5 avg=53.88 last=80 (4 points)
+  values: 2024-05-01T10:00:00Z=10 2024-05-01T10:01:00Z=30 2024-05-01T10:02:00Z=95.5 2024-05-01T10:03:00Z=80`
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+	if server.path != "/api/v1/query_range" || server.query.Get("start") != "2024-05-01T10:00:00Z" ||
+		server.query.Get("end") != "2024-05-01T11:00:00Z" || server.query.Get("step") != "60" {
+		t.Errorf("requested %s with %v", server.path, server.query)
+	}
+}
+
+func TestPromQLQuery_DefaultStep(t *testing.T) {
+	server := newPromServer(t, http.StatusOK, promRangeResponse)
+	adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: server.URL})
+
+	_, err := adapter.ExecuteTool(context.Background(), "promql_query",
+		`{"query": "up", "start": "2024-05-01T10:00:00Z", "end": "2024-05-01T11:00:00Z"}`)
+	if err != nil {
+		t.Fatalf("promql_query error = %v", err)
+	}
+	if got := server.query.Get("step"); got != "60" {
+		t.Errorf("step = %s, want 60 for about 60 points over an hour", got)
+	}
+}
+
+func TestPromQLQuery_InformativeResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "query error",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","errorType":"bad_data","error":"parse error at char 5: unexpected end of input"}`,
+			want:   "Prometheus rejected the query (HTTP 400 Bad Request, bad_data): parse error at char 5",
+		},
+		{
+			name:   "not API JSON",
+			status: http.StatusBadGateway,
+			body:   "upstream connect error",
+			want:   "Prometheus returned HTTP 502 Bad Gateway with a body that is not API JSON:\nupstream connect error",
+		},
+		{
+			name:   "empty result",
+			status: http.StatusOK,
+			body:   `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			want:   "No series matched.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newPromServer(t, tt.status, tt.body)
+			adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: server.URL})
+
+			result, err := adapter.ExecuteTool(context.Background(), "promql_query", `{"query": "rate(x"}`)
+			if err != nil {
+				t.Fatalf("promql_query should report %s as a result, got error %v", tt.name, err)
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("result = %q, want it to contain %q", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromQLQuery_AlertGeneratorURL(t *testing.T) {
+	alertProm := newPromServer(t, http.StatusOK, promInstantResponse)
+	configured := newPromServer(t, http.StatusOK, promInstantResponse)
+	generatorURL := alertProm.URL + "/graph?g0.expr=node_load1"
+
+	tests := []struct {
+		name         string
+		allowedHosts []string
+		wantServer   *promServer
+	}{
+		{"allowed host queries the alert's Prometheus", []string{"127.0.0.1"}, alertProm},
+		{"other hosts fall back to the endpoint", []string{"prometheus.example.com"}, configured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertProm.path, configured.path = "", ""
+			adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: configured.URL, AllowedHosts: tt.allowedHosts})
+
+			ctx := port.WithAlertGeneratorURL(context.Background(), generatorURL)
+			if _, err := adapter.ExecuteTool(ctx, "promql_query", `{"query": "node_load1"}`); err != nil {
+				t.Fatalf("promql_query error = %v", err)
+			}
+			if tt.wantServer.path != "/api/v1/query" {
+				t.Error("the query went to the wrong Prometheus")
+			}
+		})
+	}
+
+	adapter := newPromQLAdapter(t, tool.PromQLOptions{AllowedHosts: []string{"prometheus.example.com"}})
+	ctx := port.WithAlertGeneratorURL(context.Background(), generatorURL)
+	if _, err := adapter.ExecuteTool(ctx, "promql_query", `{"query": "up"}`); err == nil {
+		t.Error("expected an error without an endpoint or an allowed generator URL")
+	}
+}
+
+func TestPromQLQuery_InvalidInput(t *testing.T) {
+	adapter := newPromQLAdapter(t, tool.PromQLOptions{Endpoint: "http://prometheus.invalid"})
+	for _, input := range []string{
+		`{"query": "up", "step": "1m"}`,
+		`{"query": "up", "start": "yesterday"}`,
+		`{"query": "up", "start": "1h", "end": "2h"}`,
+		`{"query": "up", "start": "30d", "step": "1s"}`,
+	} {
+		if _, err := adapter.ExecuteTool(context.Background(), "promql_query", input); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubJournal stands in for journalctl, recording the arguments it ran with.
+type stubJournal struct {
+	output string
+	err    error
+
+	name string
+	args []string
+}
+
+func (s *stubJournal) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	s.name = name
+	s.args = args
+	return []byte(s.output), s.err
+}
+
+func newQueryLogsAdapter(t *testing.T, journal *stubJournal) *tool.ExecutorAdapter {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.EnableQueryLogs(journal.run)
+	return adapter
+}
+
+func TestQueryLogs_OnlyRegisteredWhenEnabled(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	if _, ok := adapter.GetTool("query_logs"); ok {
+		t.Fatal("query_logs should not be registered before EnableQueryLogs")
+	}
+	adapter.EnableQueryLogs((&stubJournal{}).run)
+	if _, ok := adapter.GetTool("query_logs"); !ok {
+		t.Fatal("query_logs should be registered after EnableQueryLogs")
+	}
+}
+
+func TestQueryLogs_Journal(t *testing.T) {
+	journal := &stubJournal{output: `{"__REALTIME_TIMESTAMP":"1714557600123456","SYSLOG_IDENTIFIER":"nginx","_PID":"812","MESSAGE":"worker process exited on signal 9"}
+{"__REALTIME_TIMESTAMP":"1714557660000000","_SYSTEMD_UNIT":"nginx.service","MESSAGE":[104,105,10]}
+`}
+	adapter := newQueryLogsAdapter(t, journal)
+
+	result, err := adapter.ExecuteTool(context.Background(), "query_logs",
+		`{"unit": "nginx.service", "since": "2024-05-01T09:00:00Z", "until": "2024-05-01T11:00:00+01:00", "grep": "signal", "lines": 20}`)
+	if err != nil {
+		t.Fatalf("query_logs error = %v", err)
+	}
+	want := "2024-05-01T10:00:00Z nginx[812]: worker process exited on signal 9\n" +
+		"2024-05-01T10:01:00Z nginx.service: hi"
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+
+	wantArgs := []string{
+		"--no-pager", "--output=json", "--unit=nginx.service", "--lines=20",
+		"--since=2024-05-01 09:00:00 UTC", "--until=2024-05-01 10:00:00 UTC", "--grep=signal",
+	}
+	if journal.name != "journalctl" || !slices.Equal(journal.args, wantArgs) {
+		t.Errorf("ran %s %q, want journalctl %q", journal.name, journal.args, wantArgs)
+	}
+}
+
+func TestQueryLogs_JournalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		journal *stubJournal
+		wantErr string
+	}{
+		{"unit and path", `{"unit": "nginx", "path": "/var/log/syslog"}`, &stubJournal{}, "exactly one of unit or path"},
+		{"neither", `{"since": "1h"}`, &stubJournal{}, "exactly one of unit or path"},
+		{"flag as unit", `{"unit": "--directory=/etc"}`, &stubJournal{}, "invalid unit name"},
+		{"bad since", `{"unit": "nginx", "since": "yesterday"}`, &stubJournal{}, "invalid since"},
+		{"bad grep", `{"unit": "nginx", "grep": "("}`, &stubJournal{}, "invalid grep"},
+		{"too many lines", `{"unit": "nginx", "lines": 5000}`, &stubJournal{}, "lines must be between"},
+		{"journalctl fails", `{"unit": "nginx"}`, &stubJournal{err: errors.New("journalctl failed: exit status 1")}, "journalctl failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newQueryLogsAdapter(t, tt.journal).ExecuteTool(context.Background(), "query_logs", tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryLogs_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	log := strings.Join([]string{
+		"2024-05-01T09:58:00Z INFO starting",
+		"2024-05-01T09:59:30.250+02:00 ERROR connection refused",
+		"2024-05-01T10:00:00Z ERROR out of memory",
+		"  at allocate (heap.go:42)",
+		"[01/May/2024:10:01:00 +0000] ERROR upstream timed out",
+		"2024-05-01T10:02:00Z INFO recovered",
+		"2024-05-01T12:00:00Z ERROR too late",
+	}, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter := newQueryLogsAdapter(t, &stubJournal{})
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "last lines",
+			input: `{"path": "` + path + `", "lines": 2}`,
+			want:  []string{"2024-05-01T10:02:00Z INFO recovered", "2024-05-01T12:00:00Z ERROR too late"},
+		},
+		{
+			name:  "grep and time range",
+			input: `{"path": "` + path + `", "grep": "ERROR", "since": "2024-05-01T09:59:00Z", "until": "2024-05-01T11:00:00Z"}`,
+			// 09:59:30+02:00 is 07:59:30Z, before since
+			want: []string{"2024-05-01T10:00:00Z ERROR out of memory", "2024-05-01T10:01:00Z ERROR upstream timed out"},
+		},
+		{
+			name:  "continuation lines follow their entry",
+			input: `{"path": "` + path + `", "since": "2024-05-01T10:00:00Z", "until": "2024-05-01T10:00:00Z"}`,
+			want:  []string{"2024-05-01T10:00:00Z ERROR out of memory", "  at allocate (heap.go:42)"},
+		},
+		{
+			name:  "relative path",
+			input: `{"path": "app.log", "grep": "starting"}`,
+			want:  []string{"2024-05-01T09:58:00Z INFO starting"},
+		},
+	}
+	adapter.SetBashOptions(tool.BashOptions{WorkingDir: dir})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := adapter.ExecuteTool(context.Background(), "query_logs", tt.input)
+			if err != nil {
+				t.Fatalf("query_logs error = %v", err)
+			}
+			if want := strings.Join(tt.want, "\n"); result != want {
+				t.Errorf("result =\n%s\nwant\n%s", result, want)
+			}
+		})
+	}
+}
+
+func TestQueryLogs_FileNoMatchesAndRelativeSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	old := time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339) + " ERROR old failure\n"
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter := newQueryLogsAdapter(t, &stubJournal{})
+
+	result, err := adapter.ExecuteTool(context.Background(), "query_logs", `{"path": "`+path+`", "since": "1h"}`)
+	if err != nil {
+		t.Fatalf("query_logs error = %v", err)
+	}
+	if result != "No matching log lines." {
+		t.Errorf("result = %q, want no matches", result)
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "query_logs", `{"path": "`+path+`.missing"}`); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -1,12 +1,15 @@
 package tool_test
 
 import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
 	"code-editing-agent/internal/infrastructure/adapter/file"
 	"code-editing-agent/internal/infrastructure/adapter/tool"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -1257,3 +1260,37 @@ func TestReadFile_WhitespacePathWithLineParams(t *testing.T) {
 		t.Fatal("Expected error for whitespace-only path, got nil")
 	}
 }
+
+func TestReadFile_ReportsProvenance(t *testing.T) {
+	tests := []struct {
+		name               string
+		startLine, endLine *int
+		want               []entity.Provenance
+	}{
+		{name: "entire file", want: []entity.Provenance{{StartLine: 1, EndLine: 5}}},
+		{name: "line range", startLine: intPtr(2), endLine: intPtr(4), want: []entity.Provenance{{StartLine: 2, EndLine: 4}}},
+		{name: "clamped to the file", startLine: intPtr(4), endLine: intPtr(40),
+			want: []entity.Provenance{{StartLine: 4, EndLine: 5}}},
+		{name: "past the end", startLine: intPtr(9), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHelper(t)
+			h.createFile("test.txt", standardContent())
+			info := &port.ToolExecutionInfo{}
+			ctx := port.WithToolExecutionInfo(context.Background(), info)
+
+			input := h.readFileInput("test.txt", tt.startLine, tt.endLine)
+			if _, err := h.adapter.ExecuteTool(ctx, "read_file", input); err != nil {
+				t.Fatalf("ExecuteTool failed: %v", err)
+			}
+			for i := range tt.want {
+				tt.want[i].Path = h.filePath("test.txt")
+			}
+			if !reflect.DeepEqual(info.Provenance, tt.want) {
+				t.Errorf("Provenance = %+v, want %+v", info.Provenance, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/memory"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemember_AppendsToMemoryFile(t *testing.T) {
+	project := t.TempDir()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(project))
+	if _, ok := adapter.GetTool("remember"); ok {
+		t.Fatal("remember should not be registered before EnableRemember")
+	}
+	adapter.EnableRemember(memory.NewStore("", project, 0))
+
+	result, err := adapter.ExecuteTool(context.Background(), "remember", `{"text": "Always run tests with -race"}`)
+	if err != nil || result != "Remembered: Always run tests with -race" {
+		t.Fatalf("remember = %q, %v", result, err)
+	}
+	result, err = adapter.ExecuteTool(context.Background(), "remember", `{"text": "Always run tests with -race"}`)
+	if err != nil || result != "Already remembered: Always run tests with -race" {
+		t.Fatalf("remember of a duplicate = %q, %v", result, err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(project, "AGENT.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Learned\n\n- Always run tests with -race\n"; string(got) != want {
+		t.Errorf("AGENT.md = %q, want %q", got, want)
+	}
+
+	if _, err := adapter.ExecuteTool(context.Background(), "remember", `{"text": ""}`); err == nil {
+		t.Error("remember with empty text should fail")
+	}
+}
@@ -0,0 +1,42 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRequestTool(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	if _, ok := adapter.GetTool("request_tool"); ok {
+		t.Fatal("request_tool should not be registered before EnableToolRequests")
+	}
+	adapter.EnableToolRequests()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"named tools", `{"tools": ["bash", "read_file"]}`, "Offered from your next turn on: bash, read_file.", ""},
+		{"full catalog", `{}`, "Every tool will be offered from your next turn on.", ""},
+		{"unknown tool", `{"tools": ["kubectl"]}`, "", "unknown tools kubectl; available tools: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := adapter.ExecuteTool(context.Background(), "request_tool", tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("request_tool error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || result != tt.want {
+				t.Errorf("request_tool = %q, %v; want %q", result, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package tool_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// updateSchemas rewrites the golden schema files: go test ./internal/infrastructure/adapter/tool -run TestToolSchemas -update
+var updateSchemas = flag.Bool("update", false, "rewrite the golden tool schema files in testdata/schemas")
+
+// TestToolSchemas_Golden compares each built-in tool's input schema, as sent
+// to the model, with testdata/schemas/<tool>.json so schema changes show up
+// in review.
+func TestToolSchemas_Golden(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	// ask_user is only registered in interactive sessions
+	adapter.SetUserPromptCallback(func(context.Context, string, []string) (string, error) { return "", nil })
+	// query_logs is only registered where journalctl exists
+	adapter.EnableQueryLogs(func(context.Context, string, ...string) ([]byte, error) { return nil, nil })
+	// k8s_inspect and promql_query are only registered when configured
+	adapter.EnableK8sInspect(fake.NewClientset(), tool.K8sInspectOptions{})
+	adapter.EnablePromQL(tool.PromQLOptions{})
+	// remember is only registered when memory is enabled
+	adapter.EnableRemember(nil)
+	// the git tools are only registered inside a repository, git_push only when allowed
+	adapter.EnableGit(tool.GitOptions{AllowPush: true})
+	tools, err := adapter.ListTools()
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+
+	names := make(map[string]bool, len(tools))
+	for _, registered := range tools {
+		names[registered.Name+".json"] = true
+		t.Run(registered.Name, func(t *testing.T) {
+			got, err := json.MarshalIndent(registered.InputSchema, "", "  ")
+			if err != nil {
+				t.Fatalf("marshal schema: %v", err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", "schemas", registered.Name+".json")
+			if *updateSchemas {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("write golden file: %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("schema for %s differs from %s (run with -update if intended)\ngot:\n%s", registered.Name, path, got)
+			}
+		})
+	}
+
+	// A golden file without a tool means a tool was removed or renamed
+	entries, err := os.ReadDir(filepath.Join("testdata", "schemas"))
+	if err != nil {
+		t.Fatalf("read golden directory: %v", err)
+	}
+	for _, entry := range entries {
+		if !names[entry.Name()] {
+			t.Errorf("golden file %s has no matching tool", entry.Name())
+		}
+	}
+}
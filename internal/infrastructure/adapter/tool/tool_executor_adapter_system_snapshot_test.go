@@ -0,0 +1,187 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubSystemStats stands in for gopsutil, recording which areas were read.
+type stubSystemStats struct {
+	loadErr error
+	read    []string
+}
+
+func (s *stubSystemStats) CPU(context.Context) (tool.CPUStats, error) {
+	s.read = append(s.read, "cpu")
+	return tool.CPUStats{Total: 62.5, PerCore: []float64{95, 30}}, nil
+}
+
+func (s *stubSystemStats) Memory(context.Context) (tool.MemoryStats, tool.MemoryStats, error) {
+	s.read = append(s.read, "memory")
+	return tool.MemoryStats{Total: 16 << 30, Used: 12 << 30, Available: 4 << 30, UsedPercent: 75},
+		tool.MemoryStats{Total: 2 << 30, Used: 512 << 20, UsedPercent: 25}, nil
+}
+
+func (s *stubSystemStats) Load(context.Context) (tool.LoadStats, error) {
+	s.read = append(s.read, "load")
+	return tool.LoadStats{Load1: 3.5, Load5: 2.25, Load15: 1}, s.loadErr
+}
+
+func (s *stubSystemStats) Processes(context.Context) ([]tool.ProcessStats, error) {
+	s.read = append(s.read, "processes")
+	return []tool.ProcessStats{
+		{PID: 10, Name: "postgres", CPUPercent: 12, RSS: 3 << 30},
+		{PID: 20, Name: "java", CPUPercent: 180.5, RSS: 6 << 30},
+		{PID: 30, Name: "sshd", CPUPercent: 0, RSS: 4 << 20},
+	}, nil
+}
+
+func (s *stubSystemStats) Disks(context.Context) ([]tool.DiskStats, error) {
+	s.read = append(s.read, "disks")
+	return []tool.DiskStats{
+		{Mountpoint: "/", FSType: "ext4", Total: 100 << 30, Used: 40 << 30, Free: 60 << 30, UsedPercent: 40,
+			InodesUsedPercent: 5},
+		{Mountpoint: "/var", FSType: "xfs", Total: 50 << 30, Used: 47 << 30, Free: 3 << 30, UsedPercent: 94,
+			InodesUsedPercent: 12.5},
+	}, nil
+}
+
+func newSystemSnapshotAdapter(t *testing.T, stats tool.SystemStats) *tool.ExecutorAdapter {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.EnableSystemSnapshot(stats)
+	return adapter
+}
+
+func TestSystemSnapshot_OnlyRegisteredWhenEnabled(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	if _, ok := adapter.GetTool("system_snapshot"); ok {
+		t.Fatal("system_snapshot should not be registered before EnableSystemSnapshot")
+	}
+	adapter.EnableSystemSnapshot(&stubSystemStats{})
+	if _, ok := adapter.GetTool("system_snapshot"); !ok {
+		t.Fatal("system_snapshot should be registered after EnableSystemSnapshot")
+	}
+}
+
+func TestSystemSnapshot_Everything(t *testing.T) {
+	stats := &stubSystemStats{}
+	adapter := newSystemSnapshotAdapter(t, stats)
+
+	result, err := adapter.ExecuteTool(context.Background(), "system_snapshot", `{"top": 2}`)
+	if err != nil {
+		t.Fatalf("system_snapshot error = %v", err)
+	}
+	want := `CPU: 62.5% used across 2 cores
+Per core: 0:95% 1:30%
+Load average: 3.50 2.25 1.00 (1m 5m 15m)
+
+Memory: 12.0 GiB used of 16.0 GiB (75.0%), 4.0 GiB available
+Swap: 512.0 MiB used of 2.0 GiB (25.0%)
+
+Top processes by CPU:
+PID  NAME      CPU     RSS
+20   java      180.5%  6.0 GiB
+10   postgres  12.0%   3.0 GiB
+
+Top processes by memory (RSS):
+PID  NAME      CPU     RSS
+20   java      180.5%  6.0 GiB
+10   postgres  12.0%   3.0 GiB
+
+Disks:
+MOUNT  FS    SIZE       USED      FREE      USE%   INODES
+/var   xfs   50.0 GiB   47.0 GiB  3.0 GiB   94.0%  12.5%
+/      ext4  100.0 GiB  40.0 GiB  60.0 GiB  40.0%  5.0%`
+	if result != want {
+		t.Errorf("result =\n%s\nwant\n%s", result, want)
+	}
+	if !slices.Equal(stats.read, []string{"cpu", "load", "memory", "processes", "disks"}) {
+		t.Errorf("read %q, want every area once", stats.read)
+	}
+}
+
+func TestSystemSnapshot_Focus(t *testing.T) {
+	tests := []struct {
+		focus    string
+		wantRead []string
+		want     []string
+		notWant  []string
+	}{
+		{
+			focus:    "cpu",
+			wantRead: []string{"cpu", "load", "processes"},
+			want:     []string{"CPU: 62.5%", "Load average:", "Top processes by CPU:"},
+			notWant:  []string{"Memory:", "by memory", "Disks:"},
+		},
+		{
+			focus:    "memory",
+			wantRead: []string{"memory", "processes"},
+			want:     []string{"Memory: 12.0 GiB", "Swap:", "Top processes by memory (RSS):"},
+			notWant:  []string{"CPU:", "by CPU", "Disks:"},
+		},
+		{
+			focus:    "disk",
+			wantRead: []string{"disks"},
+			want:     []string{"Disks:", "/var"},
+			notWant:  []string{"CPU:", "Memory:", "PID"},
+		},
+		{
+			focus:    "PROCESS",
+			wantRead: []string{"processes"},
+			want:     []string{"Top processes by CPU:", "Top processes by memory (RSS):", "sshd"},
+			notWant:  []string{"CPU: ", "Memory:", "Disks:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.focus, func(t *testing.T) {
+			stats := &stubSystemStats{}
+			adapter := newSystemSnapshotAdapter(t, stats)
+
+			result, err := adapter.ExecuteTool(context.Background(), "system_snapshot", `{"focus": "`+tt.focus+`"}`)
+			if err != nil {
+				t.Fatalf("system_snapshot error = %v", err)
+			}
+			if !slices.Equal(stats.read, tt.wantRead) {
+				t.Errorf("read %q, want %q", stats.read, tt.wantRead)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("result missing %q:\n%s", want, result)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(result, notWant) {
+					t.Errorf("result should not contain %q:\n%s", notWant, result)
+				}
+			}
+		})
+	}
+}
+
+func TestSystemSnapshot_UnavailableAreaReported(t *testing.T) {
+	adapter := newSystemSnapshotAdapter(t, &stubSystemStats{loadErr: errors.New("not implemented yet")})
+
+	result, err := adapter.ExecuteTool(context.Background(), "system_snapshot", `{"focus": "cpu"}`)
+	if err != nil {
+		t.Fatalf("system_snapshot error = %v", err)
+	}
+	if !strings.Contains(result, "CPU: 62.5%") ||
+		!strings.Contains(result, "Load average: unavailable (not implemented yet)") {
+		t.Errorf("result =\n%s\nwant the CPU usage and the load marked unavailable", result)
+	}
+}
+
+func TestSystemSnapshot_UnknownFocus(t *testing.T) {
+	adapter := newSystemSnapshotAdapter(t, &stubSystemStats{})
+
+	_, err := adapter.ExecuteTool(context.Background(), "system_snapshot", `{"focus": "network"}`)
+	if err == nil || !strings.Contains(err.Error(), `unknown focus "network"`) {
+		t.Errorf("error = %v, want an unknown focus error", err)
+	}
+}
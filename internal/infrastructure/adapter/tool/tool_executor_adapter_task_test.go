@@ -12,6 +12,7 @@ package tool
 
 import (
 	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	"code-editing-agent/internal/infrastructure/adapter/file"
 	"context"
@@ -30,6 +31,7 @@ import (
 type MockSubagentUseCase struct {
 	SpawnSubagentFunc        func(ctx context.Context, agentName string, prompt string) (*usecase.SubagentResult, error)
 	SpawnDynamicSubagentFunc func(ctx context.Context, config usecase.DynamicSubagentConfig, taskPrompt string) (*usecase.SubagentResult, error)
+	SpawnParallelFunc        func(ctx context.Context, requests []*usecase.SubagentRequest) ([]*usecase.SubagentResult, error)
 }
 
 func (m *MockSubagentUseCase) SpawnSubagent(
@@ -54,6 +56,20 @@ func (m *MockSubagentUseCase) SpawnDynamicSubagent(
 	return &usecase.SubagentResult{Status: "completed"}, nil
 }
 
+func (m *MockSubagentUseCase) SpawnParallel(
+	ctx context.Context,
+	requests []*usecase.SubagentRequest,
+) ([]*usecase.SubagentResult, error) {
+	if m.SpawnParallelFunc != nil {
+		return m.SpawnParallelFunc(ctx, requests)
+	}
+	results := make([]*usecase.SubagentResult, len(requests))
+	for i, req := range requests {
+		results[i] = &usecase.SubagentResult{AgentName: req.AgentName, Status: "completed"}
+	}
+	return results, nil
+}
+
 // =============================================================================
 // Tool Registration Tests
 // =============================================================================
@@ -596,3 +612,132 @@ func TestExecutorAdapter_TaskTool_UnavailableIfUseCaseNotSet(t *testing.T) {
 		t.Errorf("Error should indicate use case not available, got: %v", err)
 	}
 }
+
+// =============================================================================
+// Summarized Output / verbatim Tests
+// =============================================================================
+
+func TestExecutorAdapter_SubagentTools_VerbatimFlagPropagatesToContext(t *testing.T) {
+	tests := []struct {
+		name  string
+		tool  string
+		input string
+		want  bool
+	}{
+		{name: "task default", tool: "task", input: `{"agent_name": "a", "prompt": "x"}`, want: false},
+		{name: "task verbatim", tool: "task", input: `{"agent_name": "a", "prompt": "x", "verbatim": true}`, want: true},
+		{
+			name:  "delegate verbatim",
+			tool:  "delegate",
+			input: `{"name": "a", "system_prompt": "s", "task": "x", "verbatim": true}`,
+			want:  true,
+		},
+		{
+			name:  "delegate_parallel verbatim",
+			tool:  "delegate_parallel",
+			input: `{"tasks": [{"agent": "a", "prompt": "x"}], "verbatim": true}`,
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+			adapter.SetSubagentUseCase(&MockSubagentUseCase{
+				SpawnSubagentFunc: func(ctx context.Context, _ string, _ string) (*usecase.SubagentResult, error) {
+					got = port.IsVerbatimOutput(ctx)
+					return &usecase.SubagentResult{Status: "completed"}, nil
+				},
+				SpawnDynamicSubagentFunc: func(
+					ctx context.Context,
+					_ usecase.DynamicSubagentConfig,
+					_ string,
+				) (*usecase.SubagentResult, error) {
+					got = port.IsVerbatimOutput(ctx)
+					return &usecase.SubagentResult{Status: "completed"}, nil
+				},
+				SpawnParallelFunc: func(
+					ctx context.Context,
+					requests []*usecase.SubagentRequest,
+				) ([]*usecase.SubagentResult, error) {
+					got = port.IsVerbatimOutput(ctx)
+					return []*usecase.SubagentResult{{Status: "completed"}}, nil
+				},
+			})
+
+			if _, err := adapter.ExecuteTool(context.Background(), tt.tool, tt.input); err != nil {
+				t.Fatalf("ExecuteTool() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsVerbatimOutput() in use case = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_TaskIncludesTranscriptRefWhenSummarized(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetSubagentUseCase(&MockSubagentUseCase{
+		SpawnSubagentFunc: func(_ context.Context, _ string, _ string) (*usecase.SubagentResult, error) {
+			return &usecase.SubagentResult{
+				Status:        "completed",
+				Output:        "short summary",
+				Summarized:    true,
+				TranscriptRef: ".agent/transcripts/subagent-1.json",
+				Model:         "claude-sonnet-4-5-20250929",
+				SummaryModel:  "claude-haiku-4-5",
+			}, nil
+		},
+	})
+
+	result, err := adapter.ExecuteTool(context.Background(), "task", `{"agent_name": "a", "prompt": "x"}`)
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+
+	var resultMap map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &resultMap); err != nil {
+		t.Fatalf("Result should be valid JSON: %v", err)
+	}
+	if resultMap["summarized"] != true || resultMap["transcript_ref"] != ".agent/transcripts/subagent-1.json" {
+		t.Errorf("Expected summarized flag and transcript_ref, got: %v", resultMap)
+	}
+	if resultMap["model"] != "claude-sonnet-4-5-20250929" || resultMap["summary_model"] != "claude-haiku-4-5" {
+		t.Errorf("Expected the models serving the run and the summary, got: %v", resultMap)
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_TaskIncludesUsageSummary(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetSubagentUseCase(&MockSubagentUseCase{
+		SpawnSubagentFunc: func(_ context.Context, _ string, _ string) (*usecase.SubagentResult, error) {
+			return &usecase.SubagentResult{
+				Status:       "completed",
+				Output:       "done",
+				ActionsTaken: 7,
+				Duration:     42 * time.Second,
+				SessionID:    "session-child",
+				TokensUsed:   entity.TokenUsage{InputTokens: 12000, OutputTokens: 1000},
+				ToolErrors:   1,
+			}, nil
+		},
+	})
+
+	result, err := adapter.ExecuteTool(context.Background(), "task", `{"agent_name": "a", "prompt": "x"}`)
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+
+	var resultMap map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &resultMap); err != nil {
+		t.Fatalf("Result should be valid JSON: %v", err)
+	}
+	if resultMap["summary"] != "completed in 42s, 7 actions (1 failed), 13k tokens" {
+		t.Errorf("summary = %v, want the run's duration, actions, and tokens", resultMap["summary"])
+	}
+	if resultMap["session_id"] != "session-child" || resultMap["tool_errors"] != float64(1) ||
+		resultMap["input_tokens"] != float64(12000) || resultMap["output_tokens"] != float64(1000) {
+		t.Errorf("Expected the child session, tool errors, and token usage, got: %v", resultMap)
+	}
+}
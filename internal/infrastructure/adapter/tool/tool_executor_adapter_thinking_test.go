@@ -115,6 +115,17 @@ func (m *MockSubagentUseCaseWithConfig) SpawnDynamicSubagent(
 	}, nil
 }
 
+func (m *MockSubagentUseCaseWithConfig) SpawnParallel(
+	_ context.Context,
+	requests []*usecase.SubagentRequest,
+) ([]*usecase.SubagentResult, error) {
+	results := make([]*usecase.SubagentResult, len(requests))
+	for i, req := range requests {
+		results[i] = &usecase.SubagentResult{AgentName: req.AgentName, Status: "completed"}
+	}
+	return results, nil
+}
+
 // =============================================================================
 // Task Tool - Thinking Config Propagation Tests
 // =============================================================================
@@ -0,0 +1,104 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sleepingTool is a middleware standing in for a tool that blocks without
+// watching its context, like a read stuck on a network mount.
+func sleepingTool(release <-chan struct{}) tool.ToolMiddleware {
+	return func(tool.ToolFunc) tool.ToolFunc {
+		return func(context.Context, string, json.RawMessage) (string, error) {
+			<-release
+			return "too late", nil
+		}
+	}
+}
+
+func newSleepingAdapter(t *testing.T) *tool.ExecutorAdapter {
+	t.Helper()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	adapter.Use(sleepingTool(release))
+	return adapter
+}
+
+func TestExecuteTool_TimesOutStuckTool(t *testing.T) {
+	adapter := newSleepingAdapter(t)
+	adapter.SetToolTimeouts(time.Hour, map[string]time.Duration{"list_files": 50 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := adapter.ExecuteTool(ctx, "list_files", `{"path": "."}`)
+
+	if !errors.Is(err, tool.ErrToolTimeout) {
+		t.Fatalf("expected ErrToolTimeout, got result %q and error %v", result, err)
+	}
+	if err.Error() != "tool timed out after 50ms" {
+		t.Errorf("error = %q, want %q", err.Error(), "tool timed out after 50ms")
+	}
+}
+
+func TestExecuteTool_CallerDeadlineWinsWhenSooner(t *testing.T) {
+	adapter := newSleepingAdapter(t)
+	adapter.SetToolTimeouts(10*time.Second, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := adapter.ExecuteTool(ctx, "list_files", `{"path": "."}`)
+
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, tool.ErrToolTimeout) {
+		t.Errorf("expected the caller's context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExecuteTool returned after %v, want the caller's 50ms deadline", elapsed)
+	}
+}
+
+func TestExecuteTool_TimeoutKillsBash(t *testing.T) {
+	dir := t.TempDir()
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(dir))
+	adapter.SetCommandConfirmationCallback(func(string, bool, string, string) bool { return true })
+	adapter.SetToolTimeouts(0, map[string]time.Duration{"bash": 100 * time.Millisecond})
+	marker := filepath.Join(dir, "finished")
+	input, _ := json.Marshal(map[string]any{"command": "sleep 0.5 && touch " + marker, "dangerous": false})
+
+	_, err := adapter.ExecuteTool(context.Background(), "bash", string(input))
+
+	if !errors.Is(err, tool.ErrToolTimeout) {
+		t.Fatalf("expected ErrToolTimeout, got %v", err)
+	}
+	time.Sleep(time.Second)
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("bash kept running after its timeout")
+	}
+}
+
+func TestListTools_ReportsEffectiveTimeouts(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	adapter.SetToolTimeouts(time.Minute, map[string]time.Duration{"bash": 5 * time.Minute, "task": 0})
+
+	tools, err := adapter.ListTools()
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	want := map[string]time.Duration{"bash": 5 * time.Minute, "task": 0, "read_file": time.Minute}
+	for _, listed := range tools {
+		if timeout, ok := want[listed.Name]; ok && listed.Timeout != timeout {
+			t.Errorf("%s timeout = %v, want %v", listed.Name, listed.Timeout, timeout)
+		}
+	}
+	if bash, _ := adapter.GetTool("bash"); bash.Timeout != 5*time.Minute {
+		t.Errorf("GetTool(bash) timeout = %v, want 5m", bash.Timeout)
+	}
+}
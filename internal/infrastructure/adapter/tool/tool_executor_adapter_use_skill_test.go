@@ -0,0 +1,216 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/skill"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// =============================================================================
+// use_skill Tool Tests
+// =============================================================================
+
+// newUseSkillAdapter creates an executor whose skill manager reads from a temp
+// directory containing the given skills (name -> SKILL.md content).
+func newUseSkillAdapter(t *testing.T, skills map[string]string) *ExecutorAdapter {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range skills {
+		skillDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(skillDir, 0o755); err != nil {
+			t.Fatalf("Failed to create skill directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write skill: %v", err)
+		}
+	}
+
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+	adapter.SetSkillManager(skill.NewLocalSkillManagerWithDirs([]skill.DirConfig{
+		{Path: dir, SourceType: entity.SkillSourceProject},
+	}))
+	return adapter
+}
+
+func TestUseSkillTool_RegisteredInDefaultTools(t *testing.T) {
+	adapter := NewExecutorAdapter(file.NewLocalFileManager("."))
+
+	tool, exists := adapter.GetTool("use_skill")
+	if !exists {
+		t.Fatal("use_skill tool should be registered by default")
+	}
+	if len(tool.RequiredFields) != 1 || tool.RequiredFields[0] != "name" {
+		t.Errorf("RequiredFields = %v, want [name]", tool.RequiredFields)
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_UseSkillSubstitution(t *testing.T) {
+	adapter := newUseSkillAdapter(t, map[string]string{
+		"deploy": "---\nname: deploy\ndescription: Deploy a service\n---\n" +
+			"Deploy $1 to $2.\nFull request: $ARGUMENTS\nUnused: [$3]",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "positional and full arguments",
+			input: `{"name": "deploy", "arguments": "api  staging"}`,
+			want:  "Deploy api to staging.\nFull request: api  staging\nUnused: []",
+		},
+		{
+			name:  "no arguments",
+			input: `{"name": "deploy"}`,
+			want:  "Deploy  to .\nFull request: \nUnused: []",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := adapter.ExecuteTool(context.Background(), "use_skill", tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("result = %q, want %q", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_UseSkillMissingSkillListsAvailable(t *testing.T) {
+	adapter := newUseSkillAdapter(t, map[string]string{
+		"alpha": "---\nname: alpha\ndescription: First\n---\nA",
+		"beta":  "---\nname: beta\ndescription: Second\n---\nB",
+	})
+
+	_, err := adapter.ExecuteTool(context.Background(), "use_skill", `{"name": "gamma"}`)
+	if err == nil {
+		t.Fatal("Expected error for unknown skill")
+	}
+	if !strings.Contains(err.Error(), "skill 'gamma' not found") ||
+		!strings.Contains(err.Error(), "alpha, beta") {
+		t.Errorf("Expected error listing available skills, got %v", err)
+	}
+}
+
+// TestExecutorAdapter_ExecuteTool_UseSkillConcurrentSetSkillManager runs
+// use_skill while the skill manager is replaced; run with -race.
+func TestExecutorAdapter_ExecuteTool_UseSkillConcurrentSetSkillManager(t *testing.T) {
+	adapter := newUseSkillAdapter(t, map[string]string{
+		"deploy": "---\nname: deploy\ndescription: Deploy a service\n---\nDeploy $1.",
+	})
+	replacement := newUseSkillAdapter(t, map[string]string{
+		"deploy": "---\nname: deploy\ndescription: Deploy a service\n---\nDeploy $1.",
+	}).skillManagerSnapshot()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 10 {
+			adapter.SetSkillManager(replacement)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 10 {
+			result, err := adapter.ExecuteTool(context.Background(), "use_skill", `{"name": "deploy", "arguments": "api"}`)
+			if err != nil || result != "Deploy api." {
+				t.Errorf("use_skill = %q, %v", result, err)
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestExecutorAdapter_ExecuteTool_UseSkillSizeCap(t *testing.T) {
+	body := strings.Repeat("é", maxSkillContentSize) // 2 bytes per rune, well over the cap
+	adapter := newUseSkillAdapter(t, map[string]string{
+		"huge": "---\nname: huge\ndescription: Too big\n---\n" + body,
+	})
+
+	result, err := adapter.ExecuteTool(context.Background(), "use_skill", `{"name": "huge"}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, note, found := strings.Cut(result, "\n\n[skill content truncated")
+	if !found {
+		t.Fatalf("Expected truncation note, got tail %q", result[len(result)-100:])
+	}
+	if len(content) > maxSkillContentSize {
+		t.Errorf("len(content) = %d, want at most %d", len(content), maxSkillContentSize)
+	}
+	if strings.ContainsRune(content, '\uFFFD') || !strings.HasSuffix(content, "é") {
+		t.Error("Truncation should not split a multi-byte rune")
+	}
+	if !strings.Contains(note, "of 131072 bytes") {
+		t.Errorf("Expected original size in note, got %q", note)
+	}
+}
+
+func TestExecutorAdapter_ExecuteTool_UseSkillAllowedToolsNote(t *testing.T) {
+	adapter := newUseSkillAdapter(t, map[string]string{
+		"readonly": "---\nname: readonly\ndescription: Read only\nallowed-tools: read_file list_files\n---\nBody",
+		"open":     "---\nname: open\ndescription: No restriction\n---\nBody",
+	})
+
+	tests := []struct {
+		name     string
+		skill    string
+		ctx      context.Context
+		wantNote bool
+	}{
+		{
+			name:     "unrestricted session",
+			skill:    "readonly",
+			ctx:      context.Background(),
+			wantNote: true,
+		},
+		{
+			name:     "session allows more tools",
+			skill:    "readonly",
+			ctx:      port.WithAllowedTools(context.Background(), []string{"read_file", "bash"}),
+			wantNote: true,
+		},
+		{
+			name:     "session already narrower",
+			skill:    "readonly",
+			ctx:      port.WithAllowedTools(context.Background(), []string{"read_file"}),
+			wantNote: false,
+		},
+		{
+			name:     "skill without allowed-tools",
+			skill:    "open",
+			ctx:      context.Background(),
+			wantNote: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := adapter.ExecuteTool(tt.ctx, "use_skill", `{"name": "`+tt.skill+`"}`)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			hasNote := strings.HasPrefix(result, "Note: this skill only allows the following tools: read_file, list_files")
+			if hasNote != tt.wantNote {
+				t.Errorf("note present = %v, want %v; result = %q", hasNote, tt.wantNote, result)
+			}
+			if !strings.HasSuffix(result, "Body") {
+				t.Errorf("Expected skill body in result, got %q", result)
+			}
+		})
+	}
+}
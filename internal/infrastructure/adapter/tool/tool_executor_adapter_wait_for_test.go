@@ -0,0 +1,213 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWaitUnit stands in for a second of wait_for's timeouts and intervals,
+// so waits in tests take milliseconds.
+const testWaitUnit = 20 * time.Millisecond
+
+func newWaitForAdapter(t *testing.T) (*ExecutorAdapter, string) {
+	t.Helper()
+	dir := t.TempDir()
+	adapter := NewExecutorAdapter(file.NewLocalFileManager(dir))
+	adapter.SetBashOptions(BashOptions{WorkingDir: dir})
+	adapter.waitUnit = testWaitUnit
+	return adapter, dir
+}
+
+func runWaitFor(t *testing.T, ctx context.Context, adapter *ExecutorAdapter, input map[string]interface{}) waitForOutput {
+	t.Helper()
+	result, err := adapter.ExecuteTool(ctx, "wait_for", input)
+	if err != nil {
+		t.Fatalf("wait_for error = %v", err)
+	}
+	var out waitForOutput
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("wait_for result %q is not JSON: %v", result, err)
+	}
+	if out.Elapsed == "" {
+		t.Errorf("wait_for result %q has no elapsed time", result)
+	}
+	return out
+}
+
+// appendLines appends lines to path after delay.
+func appendLines(t *testing.T, path string, delay time.Duration, lines ...string) {
+	t.Helper()
+	done := make(chan struct{})
+	t.Cleanup(func() { <-done })
+	go func() {
+		defer close(done)
+		time.Sleep(delay)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Errorf("failed to open %s: %v", path, err)
+			return
+		}
+		defer f.Close()
+		for _, line := range lines {
+			if _, err := f.WriteString(line + "\n"); err != nil {
+				t.Errorf("failed to append to %s: %v", path, err)
+			}
+		}
+	}()
+}
+
+func TestWaitFor_FileMatchesOnlyNewLines(t *testing.T) {
+	adapter, dir := newWaitForAdapter(t)
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("ERROR old failure\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, logPath, 3*testWaitUnit, "INFO started", "ERROR connection refused", "ERROR timeout", "ERROR again")
+
+	out := runWaitFor(t, context.Background(), adapter, map[string]interface{}{
+		"mode": "file", "path": "app.log", "pattern": "^ERROR", "max_lines": 2, "timeout_seconds": 50,
+	})
+	if !out.ConditionMet {
+		t.Fatalf("condition_met = false, want true: %+v", out)
+	}
+	want := []string{"ERROR connection refused", "ERROR timeout"}
+	if !slices.Equal(out.Matches, want) {
+		t.Errorf("matches = %q, want %q (lines before the wait and past max_lines left out)", out.Matches, want)
+	}
+}
+
+func TestWaitFor_FileCreatedDuringWait(t *testing.T) {
+	adapter, dir := newWaitForAdapter(t)
+	logPath := filepath.Join(dir, "late.log")
+	appendLines(t, logPath, 2*testWaitUnit, "ready")
+
+	out := runWaitFor(t, context.Background(), adapter, map[string]interface{}{
+		"mode": "file", "path": logPath, "timeout_seconds": 50,
+	})
+	if !out.ConditionMet || !slices.Equal(out.Matches, []string{"ready"}) {
+		t.Errorf("result = %+v, want the first line of the new file", out)
+	}
+}
+
+func TestWaitFor_FileTimeoutIsNotAnError(t *testing.T) {
+	adapter, dir := newWaitForAdapter(t)
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, logPath, testWaitUnit, "INFO healthy")
+
+	start := time.Now()
+	out := runWaitFor(t, context.Background(), adapter, map[string]interface{}{
+		"mode": "file", "path": "app.log", "pattern": "ERROR", "timeout_seconds": 3,
+	})
+	if out.ConditionMet || len(out.Matches) != 0 {
+		t.Errorf("result = %+v, want the condition unmet", out)
+	}
+	if elapsed := time.Since(start); elapsed < 3*testWaitUnit {
+		t.Errorf("wait returned after %v, before its timeout", elapsed)
+	}
+}
+
+func TestWaitFor_CommandRetriesUntilSuccess(t *testing.T) {
+	adapter, dir := newWaitForAdapter(t)
+
+	// Fails twice, then succeeds, counting its runs in a file
+	command := `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo "attempt $n"; [ $n -ge 3 ]`
+	out := runWaitFor(t, context.Background(), adapter, map[string]interface{}{
+		"mode": "command", "command": command, "interval_seconds": 1, "timeout_seconds": 100,
+	})
+	if !out.ConditionMet || out.Attempts != 3 {
+		t.Fatalf("result = %+v, want the condition met on attempt 3", out)
+	}
+	if out.ExitCode == nil || *out.ExitCode != 0 || strings.TrimSpace(out.Output) != "attempt 3" {
+		t.Errorf("evidence = exit code %v, output %q, want 0 and \"attempt 3\"", out.ExitCode, out.Output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "count")); strings.TrimSpace(string(data)) != "3" {
+		t.Errorf("command ran %s times, want 3", strings.TrimSpace(string(data)))
+	}
+}
+
+func TestWaitFor_CommandTimesOut(t *testing.T) {
+	adapter, _ := newWaitForAdapter(t)
+
+	out := runWaitFor(t, context.Background(), adapter, map[string]interface{}{
+		"mode": "command", "command": "echo not yet; exit 3", "interval_seconds": 1, "timeout_seconds": 4,
+	})
+	if out.ConditionMet || out.Attempts < 2 {
+		t.Errorf("result = %+v, want several failed attempts", out)
+	}
+	if out.ExitCode == nil || *out.ExitCode != 3 || strings.TrimSpace(out.Output) != "not yet" {
+		t.Errorf("evidence = exit code %v, output %q, want the last failed run", out.ExitCode, out.Output)
+	}
+}
+
+func TestWaitFor_RefusesWaitsLongerThanTheInvestigation(t *testing.T) {
+	adapter, _ := newWaitForAdapter(t)
+	ctx := port.WithRunDeadline(context.Background(), time.Now().Add(10*testWaitUnit))
+
+	_, err := adapter.ExecuteTool(ctx, "wait_for", map[string]interface{}{
+		"mode": "command", "command": "true", "timeout_seconds": 60,
+	})
+	if err == nil || !strings.Contains(err.Error(), "left in the investigation") {
+		t.Fatalf("error = %v, want a refusal naming the time left", err)
+	}
+
+	out := runWaitFor(t, ctx, adapter, map[string]interface{}{
+		"mode": "command", "command": "true", "timeout_seconds": 5,
+	})
+	if !out.ConditionMet {
+		t.Errorf("a wait within the time left should run, got %+v", out)
+	}
+}
+
+func TestWaitFor_StopsWhenContextIsCancelled(t *testing.T) {
+	adapter, dir := newWaitForAdapter(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(3*testWaitUnit, cancel)
+
+	start := time.Now()
+	_, err := adapter.ExecuteTool(ctx, "wait_for", map[string]interface{}{
+		"mode": "file", "path": filepath.Join(dir, "never.log"), "timeout_seconds": 500,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*testWaitUnit {
+		t.Errorf("wait took %v after cancellation", elapsed)
+	}
+}
+
+func TestWaitFor_InvalidInput(t *testing.T) {
+	adapter, _ := newWaitForAdapter(t)
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		want  string
+	}{
+		{"unknown mode", map[string]interface{}{"mode": "socket", "timeout_seconds": 5}, "mode must be"},
+		{"timeout too long", map[string]interface{}{"mode": "file", "path": "a.log", "timeout_seconds": 5000}, "timeout_seconds must be"},
+		{"file without path", map[string]interface{}{"mode": "file", "timeout_seconds": 5}, "path is required"},
+		{"bad pattern", map[string]interface{}{"mode": "file", "path": "a.log", "pattern": "(", "timeout_seconds": 5}, "invalid pattern"},
+		{"command without command", map[string]interface{}{"mode": "command", "timeout_seconds": 5}, "command is required"},
+		{"dangerous command", map[string]interface{}{"mode": "command", "command": "rm -rf /tmp/x", "timeout_seconds": 5}, "dangerous command blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adapter.ExecuteTool(context.Background(), "wait_for", tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
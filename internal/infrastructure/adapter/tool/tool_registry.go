@@ -0,0 +1,146 @@
+package tool
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// Compile-time check that ExecutorAdapter reports changes to its tools.
+var _ port.ToolRegistry = (*ExecutorAdapter)(nil)
+
+// toolSnapshot is the registered tools as one call found them. Registration
+// replaces the executor's maps rather than changing them, so a snapshot stays
+// valid while tools are added and removed: an in-flight execution finishes
+// with the tool it started with, and ListTools never sees a half-made change.
+type toolSnapshot struct {
+	tools    map[string]entity.Tool
+	external map[string]externalToolHandler
+}
+
+type toolSnapshotKey struct{}
+
+// AddToolRegistryObserver adds an observer told of each tool registered,
+// replaced, or unregistered from now on. Observers are called after the
+// change, outside the executor's lock, in the order they were added.
+func (a *ExecutorAdapter) AddToolRegistryObserver(observer port.ToolRegistryObserver) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.observers = append(a.observers, observer)
+}
+
+// ReplaceTool registers a tool, replacing any tool registered under its name.
+// A replaced external tool keeps its handler.
+func (a *ExecutorAdapter) ReplaceTool(tool entity.Tool) error {
+	return a.registerTool(tool, true)
+}
+
+// registerTool registers tool, failing with port.ErrToolAlreadyRegistered if
+// its name is taken unless replace is set.
+func (a *ExecutorAdapter) registerTool(tool entity.Tool, replace bool) error {
+	if err := tool.Validate(); err != nil {
+		return fmt.Errorf("invalid tool: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.unlockAndNotify()
+
+	if _, exists := a.tools[tool.Name]; exists && !replace {
+		return fmt.Errorf("%w: %s", port.ErrToolAlreadyRegistered, tool.Name)
+	}
+	a.setToolLocked(tool)
+	return nil
+}
+
+// snapshot returns the registered tools.
+func (a *ExecutorAdapter) snapshot() toolSnapshot {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return toolSnapshot{tools: a.tools, external: a.externalTools}
+}
+
+// lookupTool returns the named tool from the snapshot of the execution in
+// ctx, or from the registered tools outside an execution.
+func (a *ExecutorAdapter) lookupTool(ctx context.Context, name string) (entity.Tool, bool) {
+	if snapshot, ok := ctx.Value(toolSnapshotKey{}).(toolSnapshot); ok {
+		tool, exists := snapshot.tools[name]
+		return tool, exists
+	}
+	tool, exists := a.snapshot().tools[name]
+	return tool, exists
+}
+
+// setToolLocked registers tool under its name, queuing an event unless an
+// identical tool is already registered. a.mu must be held.
+func (a *ExecutorAdapter) setToolLocked(tool entity.Tool) {
+	old, exists := a.tools[tool.Name]
+	if exists && reflect.DeepEqual(old, tool) {
+		return
+	}
+	tools := maps.Clone(a.tools)
+	if tools == nil {
+		tools = make(map[string]entity.Tool)
+	}
+	tools[tool.Name] = tool
+	a.tools = tools
+
+	if exists {
+		a.queueToolEventLocked(port.ToolReplaced, tool.Name)
+	} else {
+		a.queueToolEventLocked(port.ToolRegistered, tool.Name)
+	}
+}
+
+// setExternalHandlerLocked sets the handler of the named external tool.
+// a.mu must be held.
+func (a *ExecutorAdapter) setExternalHandlerLocked(name string, handler externalToolHandler) {
+	external := maps.Clone(a.externalTools)
+	if external == nil {
+		external = make(map[string]externalToolHandler)
+	}
+	external[name] = handler
+	a.externalTools = external
+}
+
+// deleteToolLocked unregisters the named tool and any handler it has,
+// reporting whether it was registered. a.mu must be held.
+func (a *ExecutorAdapter) deleteToolLocked(name string) bool {
+	if _, ok := a.externalTools[name]; ok {
+		external := maps.Clone(a.externalTools)
+		delete(external, name)
+		a.externalTools = external
+	}
+	if _, exists := a.tools[name]; !exists {
+		return false
+	}
+	tools := maps.Clone(a.tools)
+	delete(tools, name)
+	a.tools = tools
+	a.queueToolEventLocked(port.ToolUnregistered, name)
+	return true
+}
+
+// queueToolEventLocked queues an event for the observers, which
+// unlockAndNotify delivers. a.mu must be held.
+func (a *ExecutorAdapter) queueToolEventLocked(eventType port.ToolRegistryEventType, name string) {
+	if len(a.observers) > 0 {
+		a.toolEvents = append(a.toolEvents, port.ToolRegistryEvent{Type: eventType, Name: name})
+	}
+}
+
+// unlockAndNotify releases a.mu and then tells the observers of the changes
+// queued while it was held, so that they may call back into the executor.
+func (a *ExecutorAdapter) unlockAndNotify() {
+	events, observers := a.toolEvents, a.observers
+	a.toolEvents = nil
+	a.mu.Unlock()
+
+	for _, event := range events {
+		for _, observer := range observers {
+			observer.ToolRegistryChanged(event)
+		}
+	}
+}
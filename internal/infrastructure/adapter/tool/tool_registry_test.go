@@ -0,0 +1,201 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// registryObserver records the tool registry events it is told of, and how
+// many tools the executor listed when told, which it may ask for as the
+// events come after the change.
+type registryObserver struct {
+	executor *tool.ExecutorAdapter
+
+	mu     sync.Mutex
+	events []port.ToolRegistryEvent
+	listed []int
+}
+
+func (o *registryObserver) ToolRegistryChanged(event port.ToolRegistryEvent) {
+	tools, _ := o.executor.ListTools()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+	o.listed = append(o.listed, len(tools))
+}
+
+func (o *registryObserver) recorded() []port.ToolRegistryEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.events)
+}
+
+// testTool returns a valid tool definition named name.
+func testTool(name, description string) entity.Tool {
+	return entity.Tool{
+		ID:          name,
+		Name:        name,
+		Description: description,
+		InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}
+}
+
+func TestRegisterTool_ConflictsUnlessReplaced(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+
+	if err := adapter.RegisterTool(testTool("lookup", "Looks things up")); err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+	err := adapter.RegisterTool(testTool("lookup", "Looks other things up"))
+	if !errors.Is(err, port.ErrToolAlreadyRegistered) {
+		t.Errorf("RegisterTool() of a taken name error = %v, want ErrToolAlreadyRegistered", err)
+	}
+	if err := adapter.RegisterTool(testTool("bash", "Not the real bash")); !errors.Is(err, port.ErrToolAlreadyRegistered) {
+		t.Errorf("RegisterTool() of a built-in name error = %v, want ErrToolAlreadyRegistered", err)
+	}
+	if got, _ := adapter.GetTool("lookup"); got.Description != "Looks things up" {
+		t.Errorf("Description = %q, want the first registration kept", got.Description)
+	}
+
+	if err := adapter.ReplaceTool(testTool("lookup", "Looks other things up")); err != nil {
+		t.Fatalf("ReplaceTool() error = %v", err)
+	}
+	if got, _ := adapter.GetTool("lookup"); got.Description != "Looks other things up" {
+		t.Errorf("Description = %q, want the replacement", got.Description)
+	}
+
+	if err := adapter.UnregisterTool("lookup"); err != nil {
+		t.Fatalf("UnregisterTool() error = %v", err)
+	}
+	if err := adapter.UnregisterTool("lookup"); !errors.Is(err, port.ErrToolNotRegistered) {
+		t.Errorf("UnregisterTool() of an unknown name error = %v, want ErrToolNotRegistered", err)
+	}
+	if err := adapter.RegisterTool(testTool("lookup", "Looks things up")); err != nil {
+		t.Errorf("RegisterTool() after unregistering error = %v", err)
+	}
+}
+
+func TestToolRegistryObserver_ToldOfEachChange(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	observer := &registryObserver{executor: adapter}
+	adapter.AddToolRegistryObserver(observer)
+	before, _ := adapter.ListTools()
+
+	_ = adapter.RegisterTool(testTool("lookup", "Looks things up"))
+	_ = adapter.ReplaceTool(testTool("lookup", "Looks things up")) // Unchanged, so no event
+	_ = adapter.ReplaceTool(testTool("lookup", "Looks other things up"))
+	_ = adapter.RegisterTool(testTool("lookup", "Conflicts")) // Refused, so no event
+	_ = adapter.UnregisterTool("lookup")
+	_ = adapter.UnregisterTool("lookup")
+	adapter.EnableToolRequests()
+	adapter.EnableToolRequests()
+
+	want := []port.ToolRegistryEvent{
+		{Type: port.ToolRegistered, Name: "lookup"},
+		{Type: port.ToolReplaced, Name: "lookup"},
+		{Type: port.ToolUnregistered, Name: "lookup"},
+		{Type: port.ToolRegistered, Name: "request_tool"},
+	}
+	if got := observer.recorded(); !slices.Equal(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+	n := len(before)
+	if want := []int{n + 1, n + 1, n, n + 1}; !slices.Equal(observer.listed, want) {
+		t.Errorf("tools listed by the observer = %v, want %v as of each change", observer.listed, want)
+	}
+}
+
+func TestUnregisterTool_InFlightExecutionFinishes(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	started, release := make(chan struct{}), make(chan struct{})
+	err := adapter.RegisterExternalTool(testTool("slow", "Answers slowly"),
+		func(_ context.Context, _ json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return "done", nil
+		})
+	if err != nil {
+		t.Fatalf("RegisterExternalTool() error = %v", err)
+	}
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome)
+	go func() {
+		result, err := adapter.ExecuteTool(context.Background(), "slow", `{}`)
+		done <- outcome{result, err}
+	}()
+	<-started
+	if err := adapter.UnregisterTool("slow"); err != nil {
+		t.Fatalf("UnregisterTool() error = %v", err)
+	}
+	close(release)
+
+	if o := <-done; o.err != nil || o.result != "done" {
+		t.Errorf("in-flight execution = %q, %v; want it to finish", o.result, o.err)
+	}
+	if _, err := adapter.ExecuteTool(context.Background(), "slow", `{}`); err == nil {
+		t.Error("ExecuteTool() after UnregisterTool() error = nil, want tool not found")
+	}
+}
+
+func TestToolRegistry_ConcurrentChanges(t *testing.T) {
+	adapter := tool.NewExecutorAdapter(file.NewLocalFileManager(t.TempDir()))
+	observer := &registryObserver{executor: adapter}
+	adapter.AddToolRegistryObserver(observer)
+	err := adapter.RegisterExternalTool(testTool("echo", "Echoes"),
+		func(_ context.Context, input json.RawMessage) (string, error) { return string(input), nil })
+	if err != nil {
+		t.Fatalf("RegisterExternalTool() error = %v", err)
+	}
+	before, _ := adapter.ListTools()
+
+	const workers, rounds = 8, 50
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("tool_%d", w)
+			for range rounds {
+				if err := adapter.RegisterTool(testTool(name, "Comes and goes")); err != nil {
+					t.Errorf("RegisterTool(%s) error = %v", name, err)
+				}
+				if err := adapter.UnregisterTool(name); err != nil {
+					t.Errorf("UnregisterTool(%s) error = %v", name, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				if _, err := adapter.ListTools(); err != nil {
+					t.Errorf("ListTools() error = %v", err)
+				}
+				if result, err := adapter.ExecuteTool(context.Background(), "echo", `{}`); err != nil || result != "{}" {
+					t.Errorf("ExecuteTool(echo) = %q, %v", result, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The echo registration plus a registration and unregistration per round
+	if got, want := len(observer.recorded()), 1+2*workers*rounds; got != want {
+		t.Errorf("observer told of %d changes, want %d", got, want)
+	}
+	if after, _ := adapter.ListTools(); len(after) != len(before) {
+		t.Errorf("ListTools() returned %d tools after the changes, want %d", len(after), len(before))
+	}
+}
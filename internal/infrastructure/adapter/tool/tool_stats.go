@@ -0,0 +1,110 @@
+package tool
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// toolCounters are one tool's counters within a session.
+type toolCounters struct {
+	calls    atomic.Int64
+	errors   atomic.Int64
+	duration atomic.Int64 // Nanoseconds
+	bytes    atomic.Int64
+}
+
+// sessionToolStats maps tool names to their counters for one session.
+type sessionToolStats struct {
+	tools sync.Map // string -> *toolCounters
+}
+
+// counters returns the counters for name, creating them on first use.
+func (s *sessionToolStats) counters(name string) *toolCounters {
+	if c, ok := s.tools.Load(name); ok {
+		return c.(*toolCounters)
+	}
+	c, _ := s.tools.LoadOrStore(name, &toolCounters{})
+	return c.(*toolCounters)
+}
+
+// ToolStatsTracker counts each session's tool calls, errors, cumulative
+// duration, and output bytes per tool. Counters are atomics in a registry of
+// sync.Maps, so recording takes no lock once a session has used a tool and
+// parallel tool calls are not serialized. It implements usecase.ToolStatsSource
+// and is safe for concurrent use.
+type ToolStatsTracker struct {
+	sessions sync.Map // string -> *sessionToolStats
+}
+
+// Compile-time check that ToolStatsTracker implements usecase.ToolStatsSource.
+var _ usecase.ToolStatsSource = (*ToolStatsTracker)(nil)
+
+// NewToolStatsTracker creates an empty tracker.
+func NewToolStatsTracker() *ToolStatsTracker {
+	return &ToolStatsTracker{}
+}
+
+// Middleware returns the ToolMiddleware that records each call through the
+// rest of the chain. Calls without a session ID in their context are not counted.
+func (t *ToolStatsTracker) Middleware() ToolMiddleware {
+	return func(next ToolFunc) ToolFunc {
+		return func(ctx context.Context, name string, input json.RawMessage) (string, error) {
+			sessionID, ok := port.SessionIDFromContext(ctx)
+			if !ok || sessionID == "" {
+				return next(ctx, name, input)
+			}
+			start := time.Now()
+			result, err := next(ctx, name, input)
+			t.record(sessionID, name, time.Since(start), len(result), err != nil)
+			return result, err
+		}
+	}
+}
+
+// record adds one call to the session's counters for the tool.
+func (t *ToolStatsTracker) record(sessionID, name string, duration time.Duration, bytes int, failed bool) {
+	session, ok := t.sessions.Load(sessionID)
+	if !ok {
+		session, _ = t.sessions.LoadOrStore(sessionID, &sessionToolStats{})
+	}
+	c := session.(*sessionToolStats).counters(name)
+	c.calls.Add(1)
+	if failed {
+		c.errors.Add(1)
+	}
+	c.duration.Add(int64(duration))
+	c.bytes.Add(int64(bytes))
+}
+
+// GetToolStats returns the session's stats per tool, most used first, or
+// nil if the session has not used a tool.
+func (t *ToolStatsTracker) GetToolStats(sessionID string) []usecase.ToolStats {
+	session, ok := t.sessions.Load(sessionID)
+	if !ok {
+		return nil
+	}
+	var stats []usecase.ToolStats
+	session.(*sessionToolStats).tools.Range(func(name, value any) bool {
+		c := value.(*toolCounters)
+		stats = append(stats, usecase.ToolStats{
+			Tool:     name.(string),
+			Calls:    c.calls.Load(),
+			Errors:   c.errors.Load(),
+			Duration: time.Duration(c.duration.Load()),
+			Bytes:    c.bytes.Load(),
+		})
+		return true
+	})
+	usecase.SortToolStats(stats)
+	return stats
+}
+
+// ResetToolStats discards the session's stats; its next call starts from zero.
+func (t *ToolStatsTracker) ResetToolStats(sessionID string) {
+	t.sessions.Delete(sessionID)
+}
@@ -0,0 +1,141 @@
+package tool_test
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedTool answers tool calls with fixed outputs, failing the tools in fail.
+func scriptedTool(outputs map[string]string, fail map[string]bool) tool.ToolFunc {
+	return func(_ context.Context, name string, _ json.RawMessage) (string, error) {
+		time.Sleep(time.Millisecond)
+		if fail[name] {
+			return outputs[name], errors.New(name + " failed")
+		}
+		return outputs[name], nil
+	}
+}
+
+func TestToolStatsTracker_AggregatesPerSessionAndTool(t *testing.T) {
+	tracker := tool.NewToolStatsTracker()
+	exec := tracker.Middleware()(scriptedTool(
+		map[string]string{"bash": "0123456789", "read_file": "abc", "list_files": ""},
+		map[string]bool{"list_files": true},
+	))
+
+	session1 := port.WithSessionID(context.Background(), "session-1")
+	session2 := port.WithSessionID(context.Background(), "session-2")
+	for _, call := range []struct {
+		ctx  context.Context
+		name string
+	}{
+		{session1, "bash"}, {session1, "read_file"}, {session1, "bash"}, {session1, "list_files"},
+		{session1, "bash"}, {session2, "read_file"},
+		{context.Background(), "bash"}, // No session: not counted
+	} {
+		_, _ = exec(call.ctx, call.name, json.RawMessage(`{}`))
+	}
+
+	stats := tracker.GetToolStats("session-1")
+	if len(stats) != 3 {
+		t.Fatalf("GetToolStats(session-1) = %+v, want 3 tools", stats)
+	}
+	bash := stats[0]
+	if bash.Tool != "bash" || bash.Calls != 3 || bash.Errors != 0 || bash.Bytes != 30 {
+		t.Errorf("bash stats = %+v, want 3 calls, 0 errors, 30 bytes, listed first", bash)
+	}
+	if bash.Duration < 3*time.Millisecond {
+		t.Errorf("bash duration = %v, want at least the 3ms of its calls", bash.Duration)
+	}
+	for _, s := range stats[1:] {
+		switch s.Tool {
+		case "list_files":
+			if s.Calls != 1 || s.Errors != 1 || s.Bytes != 0 {
+				t.Errorf("list_files stats = %+v, want 1 failed call", s)
+			}
+		case "read_file":
+			if s.Calls != 1 || s.Errors != 0 || s.Bytes != 3 {
+				t.Errorf("read_file stats = %+v, want 1 call of 3 bytes", s)
+			}
+		default:
+			t.Errorf("unexpected tool %q in session-1", s.Tool)
+		}
+	}
+
+	if other := tracker.GetToolStats("session-2"); len(other) != 1 || other[0].Tool != "read_file" || other[0].Calls != 1 {
+		t.Errorf("GetToolStats(session-2) = %+v, want only its own read_file call", other)
+	}
+}
+
+func TestToolStatsTracker_ResetStartsNewSessionFromZero(t *testing.T) {
+	tracker := tool.NewToolStatsTracker()
+	exec := tracker.Middleware()(scriptedTool(map[string]string{"bash": "ok"}, nil))
+	ctx := port.WithSessionID(context.Background(), "session-1")
+
+	_, _ = exec(ctx, "bash", nil)
+	_, _ = exec(ctx, "bash", nil)
+	tracker.ResetToolStats("session-1")
+	if stats := tracker.GetToolStats("session-1"); stats != nil {
+		t.Fatalf("GetToolStats() after reset = %+v, want nil", stats)
+	}
+
+	_, _ = exec(ctx, "bash", nil)
+	if stats := tracker.GetToolStats("session-1"); len(stats) != 1 || stats[0].Calls != 1 || stats[0].Bytes != 2 {
+		t.Errorf("GetToolStats() after reset and one call = %+v, want 1 call", stats)
+	}
+}
+
+func TestToolStatsTracker_CountsParallelCalls(t *testing.T) {
+	tracker := tool.NewToolStatsTracker()
+	exec := tracker.Middleware()(func(context.Context, string, json.RawMessage) (string, error) {
+		return "x", nil
+	})
+	ctx := port.WithSessionID(context.Background(), "session-1")
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := "bash"
+			if i%2 == 0 {
+				name = "read_file"
+			}
+			for range 20 {
+				_, _ = exec(ctx, name, nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var calls, bytes int64
+	for _, s := range tracker.GetToolStats("session-1") {
+		calls += s.Calls
+		bytes += s.Bytes
+	}
+	if calls != 1000 || bytes != 1000 {
+		t.Errorf("parallel calls counted = %d (%d bytes), want 1000 (1000 bytes)", calls, bytes)
+	}
+}
+
+func TestToolStatsTracker_RecordsThroughExecutor(t *testing.T) {
+	adapter, _, _ := newTrackingAdapter(t, 0)
+	tracker := tool.NewToolStatsTracker()
+	adapter.Use(tracker.Middleware())
+
+	ctx := port.WithSessionID(context.Background(), "session-1")
+	output, err := adapter.ExecuteTool(ctx, "read_file", `{"path": "main.go"}`)
+	if err != nil {
+		t.Fatalf("read_file failed: %v", err)
+	}
+	stats := tracker.GetToolStats("session-1")
+	if len(stats) != 1 || stats[0].Tool != "read_file" || stats[0].Calls != 1 || stats[0].Bytes != int64(len(output)) {
+		t.Errorf("GetToolStats() = %+v, want one read_file call of %d bytes", stats, len(output))
+	}
+}
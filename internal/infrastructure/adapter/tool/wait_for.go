@@ -0,0 +1,391 @@
+package tool
+
+import (
+	"bufio"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// waitForToolName is the name of the tool that waits for a log line or a
+// command to succeed.
+const waitForToolName = "wait_for"
+
+// Modes of the wait_for tool.
+const (
+	waitForModeFile    = "file"
+	waitForModeCommand = "command"
+)
+
+// Limits of the wait_for tool, in seconds and lines.
+const (
+	maxWaitForTimeoutSeconds     = 1800
+	defaultWaitForInterval       = 5
+	maxWaitForInterval           = 300
+	defaultWaitForMatchLines     = 20
+	maxWaitForMatchLines         = 200
+	maxWaitForCommandOutputBytes = 4096
+)
+
+// waitForInput represents the input for the wait_for tool.
+type waitForInput struct {
+	Mode            string `json:"mode"`
+	TimeoutSeconds  int    `json:"timeout_seconds"`
+	Path            string `json:"path,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	MaxLines        int    `json:"max_lines,omitempty"`
+	Command         string `json:"command,omitempty"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Dangerous       bool   `json:"dangerous,omitempty"`
+}
+
+// waitForOutput is the result of a wait: whether the condition was met
+// before the timeout, how long it took, and the evidence.
+type waitForOutput struct {
+	ConditionMet bool     `json:"condition_met"`
+	Elapsed      string   `json:"elapsed"`
+	Matches      []string `json:"matches,omitempty"`   // file mode: new lines that matched
+	Attempts     int      `json:"attempts,omitempty"`  // command mode: times the command ran
+	ExitCode     *int     `json:"exit_code,omitempty"` // command mode: the last run's exit code
+	Output       string   `json:"output,omitempty"`    // command mode: the last run's output
+}
+
+// waitForTool returns the wait_for tool definition.
+func waitForTool() entity.Tool {
+	return entity.Tool{
+		ID:   waitForToolName,
+		Name: waitForToolName,
+		Description: "Waits, without busy-looping bash sleeps, for a condition: in file mode, for new lines " +
+			"appended to a file that match a regular expression; in command mode, for a command run every " +
+			"interval_seconds to exit 0. Returns whether the condition was met, the elapsed time, and the " +
+			"matching lines or the last command output. A wait that times out is not an error: to confirm an " +
+			"error stopped appearing, wait for it in file mode and expect condition_met false. The timeout " +
+			"cannot exceed the time left in the investigation.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []interface{}{waitForModeFile, waitForModeCommand},
+					"description": "file watches path for new matching lines; command polls command until it exits 0",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxWaitForTimeoutSeconds,
+					"description": "How long to wait before giving up",
+					"examples":    []interface{}{60},
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "file mode: the file to watch, absolute or relative to the working directory. Only lines appended after the wait starts count; the file may not exist yet",
+					"examples":    []interface{}{"/var/log/nginx/error.log"},
+				},
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "file mode: a regular expression new lines must match. Omit to wait for any new line",
+					"examples":    []interface{}{"(?i)connection refused"},
+				},
+				"max_lines": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxWaitForMatchLines,
+					"default":     defaultWaitForMatchLines,
+					"description": "file mode: how many matching lines to return",
+				},
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "command mode: the shell command to run until it exits 0",
+					"examples":    []interface{}{"systemctl is-active --quiet nginx", "curl -sf localhost:8080/healthz"},
+				},
+				"interval_seconds": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxWaitForInterval,
+					"default":     defaultWaitForInterval,
+					"description": "command mode: seconds between the end of one run and the start of the next",
+				},
+				"description": map[string]interface{}{
+					"type":        "string",
+					"description": "command mode: a brief description of what the command checks",
+					"examples":    []interface{}{"Wait for nginx to come back up"},
+				},
+				"dangerous": map[string]interface{}{
+					"type":        "boolean",
+					"description": "command mode: whether the command is potentially dangerous, as for bash",
+				},
+			},
+			"required": []string{"mode", "timeout_seconds"},
+		},
+		RequiredFields: []string{"mode", "timeout_seconds"},
+	}
+}
+
+// executeWaitFor waits for a file or command condition.
+func (a *ExecutorAdapter) executeWaitFor(ctx context.Context, input json.RawMessage) (string, error) {
+	var in waitForInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", fmt.Errorf("failed to unmarshal wait_for input: %w", err)
+	}
+	if in.TimeoutSeconds < 1 || in.TimeoutSeconds > maxWaitForTimeoutSeconds {
+		return "", fmt.Errorf("timeout_seconds must be between 1 and %d, got %d",
+			maxWaitForTimeoutSeconds, in.TimeoutSeconds)
+	}
+
+	a.mu.RLock()
+	unit := a.waitUnit
+	a.mu.RUnlock()
+	timeout := time.Duration(in.TimeoutSeconds) * unit
+	if err := checkWaitBudget(ctx, timeout, unit); err != nil {
+		return "", err
+	}
+
+	var (
+		out waitForOutput
+		err error
+	)
+	start := time.Now()
+	switch in.Mode {
+	case waitForModeFile:
+		out, err = a.waitForFile(ctx, in, timeout, unit)
+	case waitForModeCommand:
+		out, err = a.waitForCommand(ctx, in, timeout, unit)
+	default:
+		return "", fmt.Errorf("mode must be %q or %q, got %q", waitForModeFile, waitForModeCommand, in.Mode)
+	}
+	if err != nil {
+		return "", err
+	}
+	out.Elapsed = time.Since(start).Round(time.Millisecond).String()
+
+	result, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal output: %w", err)
+	}
+	return string(result), nil
+}
+
+// checkWaitBudget refuses a wait of timeout when less than that is left
+// before the investigation's deadline (port.WithRunDeadline) or the call's
+// own deadline, so a wait never outlives the run that asked for it.
+func checkWaitBudget(ctx context.Context, timeout, unit time.Duration) error {
+	now := time.Now()
+	if deadline, ok := port.RunDeadlineFromContext(ctx); ok && now.Add(timeout).After(deadline) {
+		return fmt.Errorf("timeout_seconds is longer than the %d seconds left in the investigation",
+			int(deadline.Sub(now)/unit))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(timeout).After(deadline) {
+		return fmt.Errorf("timeout_seconds is longer than the %d seconds this tool call may run",
+			int(deadline.Sub(now)/unit))
+	}
+	return nil
+}
+
+// waitForFile waits until lines matching in.Pattern are appended to in.Path.
+// Lines already in the file when the wait starts are skipped; a file that
+// does not exist yet is read from its start once created, and a file that
+// shrinks, as when it is truncated for rotation, is read again from its start.
+func (a *ExecutorAdapter) waitForFile(
+	ctx context.Context,
+	in waitForInput,
+	timeout, unit time.Duration,
+) (waitForOutput, error) {
+	if in.Path == "" {
+		return waitForOutput{}, errors.New("path is required in file mode")
+	}
+	if in.MaxLines == 0 {
+		in.MaxLines = defaultWaitForMatchLines
+	}
+	if in.MaxLines < 1 || in.MaxLines > maxWaitForMatchLines {
+		return waitForOutput{}, fmt.Errorf("max_lines must be between 1 and %d, got %d",
+			maxWaitForMatchLines, in.MaxLines)
+	}
+	pattern, err := regexp.Compile(in.Pattern)
+	if err != nil {
+		return waitForOutput{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	path := in.Path
+	if !filepath.IsAbs(path) {
+		a.mu.RLock()
+		path = filepath.Join(a.bashOptions.WorkingDir, path)
+		a.mu.RUnlock()
+	}
+	watcher := &fileWatcher{path: path, pattern: pattern, maxLines: in.MaxLines}
+	if info, err := os.Stat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			return waitForOutput{}, fmt.Errorf("%s is not a regular file", path)
+		}
+		watcher.offset = info.Size()
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(unit / 4)
+	defer poll.Stop()
+	for {
+		if err := watcher.readNewLines(); err != nil {
+			return waitForOutput{}, err
+		}
+		if len(watcher.matches) > 0 {
+			return waitForOutput{ConditionMet: true, Matches: watcher.matches}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return waitForOutput{}, ctx.Err()
+		case <-deadline.C:
+			return waitForOutput{}, nil
+		case <-poll.C:
+		}
+	}
+}
+
+// fileWatcher reads the lines appended to a file since the last read.
+type fileWatcher struct {
+	path     string
+	pattern  *regexp.Regexp
+	maxLines int
+	offset   int64 // start of the first line not read yet
+	matches  []string
+}
+
+// readNewLines reads the complete lines appended since the last read and
+// collects those matching the pattern, up to maxLines. A line still being
+// written, without its newline, is left for the next read.
+func (w *fileWatcher) readNewLines() error {
+	f, err := os.Open(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", w.path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", w.path, err)
+	}
+	if info.Size() < w.offset {
+		w.offset = 0
+	}
+	if info.Size() == w.offset {
+		return nil
+	}
+	if _, err := f.Seek(w.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read %s: %w", w.path, err)
+	}
+
+	reader := bufio.NewReader(io.LimitReader(f, info.Size()-w.offset))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// EOF before a newline: a partial line, read again next time
+			return nil
+		}
+		w.offset += int64(len(line))
+		line = strings.TrimRight(line, "\r\n")
+		if len(w.matches) < w.maxLines && w.pattern.MatchString(line) {
+			if len(line) > maxLogLineBytes {
+				line = truncateUTF8(line, maxLogLineBytes) + " [line truncated]"
+			}
+			w.matches = append(w.matches, line)
+		}
+	}
+}
+
+// waitForCommand runs in.Command every interval until it exits 0 or the
+// timeout elapses. A run still going at the timeout is killed.
+func (a *ExecutorAdapter) waitForCommand(
+	ctx context.Context,
+	in waitForInput,
+	timeout, unit time.Duration,
+) (waitForOutput, error) {
+	if in.Command == "" {
+		return waitForOutput{}, errors.New("command is required in command mode")
+	}
+	if in.IntervalSeconds == 0 {
+		in.IntervalSeconds = defaultWaitForInterval
+	}
+	if in.IntervalSeconds < 1 || in.IntervalSeconds > maxWaitForInterval {
+		return waitForOutput{}, fmt.Errorf("interval_seconds must be between 1 and %d, got %d",
+			maxWaitForInterval, in.IntervalSeconds)
+	}
+	if err := a.checkCommandConfirmation(in.Command, in.Description, in.Dangerous); err != nil {
+		return waitForOutput{}, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	interval := time.Duration(in.IntervalSeconds) * unit
+
+	var out waitForOutput
+	for {
+		out.Attempts++
+		exitCode, output, err := a.runWaitCommand(waitCtx, in.Command)
+		if ctx.Err() != nil {
+			return waitForOutput{}, ctx.Err()
+		}
+		if err != nil && waitCtx.Err() == nil {
+			return waitForOutput{}, err
+		}
+		if err == nil {
+			out.ExitCode = &exitCode
+			out.Output = output
+			if exitCode == 0 {
+				out.ConditionMet = true
+				return out, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return waitForOutput{}, ctx.Err()
+		case <-waitCtx.Done():
+			return out, nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runWaitCommand runs command once through bash like the bash tool, and
+// returns its exit code and combined output. It fails if the command could
+// not be run or ctx ended first.
+func (a *ExecutorAdapter) runWaitCommand(ctx context.Context, command string) (int, string, error) {
+	//nolint:gosec // G204: This is intentionally executing user-provided commands (wait_for tool)
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.WaitDelay = bashWaitDelay
+
+	a.mu.RLock()
+	opts := a.bashOptions
+	a.mu.RUnlock()
+	cmd.Dir = opts.WorkingDir
+	cmd.Env = bashEnv(os.Environ(), opts.AllowedEnv, nil)
+
+	output, _ := newBoundedOutput(maxWaitForCommandOutputBytes)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return 0, "", ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), output.String(), nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to execute command: %w", err)
+	}
+	return 0, output.String(), nil
+}
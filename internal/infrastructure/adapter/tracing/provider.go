@@ -0,0 +1,50 @@
+// Package tracing builds the OpenTelemetry tracer provider that exports the
+// agent's spans (investigations, AI requests, tool executions, and subagent runs)
+// to an OTLP collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracerName is the instrumentation name of the agent's tracer.
+const TracerName = "code-editing-agent"
+
+// serviceName identifies the agent in exported traces.
+const serviceName = "code-editing-agent"
+
+// Config configures span export.
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector URL (e.g. "http://localhost:4318").
+	Endpoint string
+
+	// SampleRatio is the fraction of new traces to sample, from 0 to 1.
+	// Spans in a sampled trace are always kept.
+	SampleRatio float64
+}
+
+// NewProvider creates a tracer provider that batches spans to the OTLP/HTTP
+// collector at cfg.Endpoint. The caller must call Shutdown on the provider to
+// flush pending spans before exiting.
+func NewProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter for %s: %w", cfg.Endpoint, err)
+	}
+	return newProvider(sdktrace.NewBatchSpanProcessor(exporter), cfg.SampleRatio), nil
+}
+
+// newProvider creates a tracer provider sending spans to processor.
+func newProvider(processor sdktrace.SpanProcessor, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewProvider_SampleRatio(t *testing.T) {
+	tests := []struct {
+		name      string
+		ratio     float64
+		wantSpans int
+	}{
+		{name: "ratio 1 samples every trace", ratio: 1, wantSpans: 1},
+		{name: "ratio 0 samples no traces", ratio: 0, wantSpans: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			provider := newProvider(sdktrace.NewSimpleSpanProcessor(exporter), tt.ratio)
+			defer func() { _ = provider.Shutdown(context.Background()) }()
+
+			_, span := provider.Tracer(TracerName).Start(context.Background(), "span")
+			span.End()
+
+			if got := len(exporter.GetSpans()); got != tt.wantSpans {
+				t.Errorf("exported %d spans, want %d", got, tt.wantSpans)
+			}
+		})
+	}
+}
+
+func TestNewProvider_SetsServiceName(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1)
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	_, span := provider.Tracer(TracerName).Start(context.Background(), "span")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	for _, kv := range spans[0].Resource.Attributes() {
+		if kv.Key == "service.name" && kv.Value.AsString() == serviceName {
+			return
+		}
+	}
+	t.Errorf("resource should have service.name %q, got %v", serviceName, spans[0].Resource.Attributes())
+}
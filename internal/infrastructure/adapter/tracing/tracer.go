@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is a port.Tracer that starts OpenTelemetry spans.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// Compile-time check that Tracer implements port.Tracer.
+var _ port.Tracer = (*Tracer)(nil)
+
+// NewTracer returns a Tracer starting spans with provider's tracer named
+// TracerName.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(TracerName)}
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, port.Span) {
+	ctx, s := t.tracer.Start(ctx, name)
+	return ctx, span{span: s}
+}
+
+// span is a port.Span over an OpenTelemetry span.
+type span struct {
+	span trace.Span
+}
+
+func (s span) IsRecording() bool {
+	return s.span.IsRecording()
+}
+
+func (s span) SetAttributes(attrs ...port.SpanAttribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, keyValue(a))
+	}
+	s.span.SetAttributes(kvs...)
+}
+
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) End() {
+	s.span.End()
+}
+
+// keyValue converts a port.SpanAttribute to an OpenTelemetry attribute.
+func keyValue(a port.SpanAttribute) attribute.KeyValue {
+	switch v := a.Value.(type) {
+	case string:
+		return attribute.String(a.Key, v)
+	case int64:
+		return attribute.Int64(a.Key, v)
+	case bool:
+		return attribute.Bool(a.Key, v)
+	default:
+		return attribute.String(a.Key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer_NestsUnderContextSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ctx, parent := port.StartSpan(context.Background(), tracer, port.SpanInvestigation)
+	_, child := port.StartSpan(ctx, tracer, port.SpanToolExecution)
+	child.SetAttributes(
+		port.StringAttribute("tool", "bash"),
+		port.IntAttribute("duration_ms", 12),
+		port.BoolAttribute("error", true),
+	)
+	port.EndSpan(child, errors.New("boom"))
+	port.EndSpan(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	childStub, parentStub := spans[0], spans[1]
+	if childStub.Parent.SpanID() != parentStub.SpanContext.SpanID() {
+		t.Error("child span should be parented to the span in its context")
+	}
+	if childStub.Status.Code != codes.Error || childStub.Status.Description != "boom" {
+		t.Errorf("failed span status = %+v, want error %q", childStub.Status, "boom")
+	}
+	if parentStub.Status.Code != codes.Unset {
+		t.Errorf("successful span status = %+v, want unset", parentStub.Status)
+	}
+	want := []attribute.KeyValue{
+		attribute.String("tool", "bash"),
+		attribute.Int64("duration_ms", 12),
+		attribute.Bool("error", true),
+	}
+	if got := childStub.Attributes; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+}
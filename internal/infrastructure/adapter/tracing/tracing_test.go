@@ -0,0 +1,191 @@
+package tracing_test
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/ai"
+	"code-editing-agent/internal/infrastructure/adapter/ai/aitest"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/subagent"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/adapter/tracing"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_InvestigationSpanHierarchy(t *testing.T) {
+	server := aitest.ScriptedAnthropicServer(t,
+		// Investigation turn 1: list files and delegate to a subagent
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_1","name":"list_files","input":{"path":"."}},`+
+				`{"type":"tool_use","id":"toolu_2","name":"task","input":{"agent_name":"disk-checker","prompt":"Check disk usage"}}`,
+			100, 20),
+		// Subagent turn: read a file, then finish
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_3","name":"read_file","input":{"path":"notes.txt"}}`, 30, 5),
+		aitest.AnthropicResponse("end_turn", `{"type":"text","text":"Disk usage is normal."}`, 40, 6),
+		// Investigation turn 2: complete
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_4","name":"complete_investigation",`+
+				`"input":{"confidence":0.9,"findings":["Disk is fine"],"root_cause":"None"}}`,
+			200, 30),
+	)
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tracing.NewTracer(provider)
+
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "notes.txt"), []byte("disk ok\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	agentDir := filepath.Join(workDir, "agents", "disk-checker")
+	if err := os.MkdirAll(agentDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	agentFile := "---\nname: disk-checker\ndescription: Checks disk usage\n---\nCheck disk usage."
+	if err := os.WriteFile(filepath.Join(agentDir, "AGENT.md"), []byte(agentFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	agents := subagent.NewLocalSubagentManagerWithDirs([]subagent.DirConfig{
+		{Path: filepath.Join(workDir, "agents"), SourceType: entity.SubagentSourceProject},
+	})
+	if _, err := agents.DiscoverAgents(context.Background()); err != nil {
+		t.Fatalf("DiscoverAgents() error = %v", err)
+	}
+
+	aiProvider := ai.NewAnthropicAdapter("test-model", 1024, nil)
+	aiProvider.(interface{ SetTracer(port.Tracer) }).SetTracer(tracer)
+
+	executor := tool.NewExecutorAdapter(file.NewLocalFileManager(workDir))
+	executor.SetTracer(tracer)
+
+	convService, err := service.NewConversationService(aiProvider, executor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+
+	runner := usecase.NewSubagentRunner(convService, executor, aiProvider, nil, usecase.SubagentConfig{
+		MaxActions:  5,
+		MaxDuration: time.Minute,
+	})
+	runner.SetTracer(tracer)
+	executor.SetSubagentUseCase(usecase.NewSubagentUseCase(agents, runner))
+
+	investigations := usecase.NewAlertInvestigationUseCaseWithConfig(usecase.AlertInvestigationUseCaseConfig{
+		MaxActions:    20,
+		MaxDuration:   time.Minute,
+		MaxConcurrent: 1,
+		AllowedTools:  []string{"list_files", "read_file", "task", "complete_investigation"},
+	})
+	investigations.SetConversationService(convService)
+	investigations.SetToolExecutor(executor)
+	prompts := usecase.NewPromptBuilderRegistry()
+	if err := prompts.Register(usecase.NewGenericPromptBuilder()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	investigations.SetPromptBuilderRegistry(prompts)
+	investigations.SetTracer(tracer)
+
+	alert, err := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	handler := usecase.NewAlertHandler(investigations, usecase.AlertHandlerConfig{AutoInvestigateCritical: true})
+	if err := handler.HandleEntityAlert(context.Background(), alert); err != nil {
+		t.Fatalf("HandleEntityAlert() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	byID := make(map[trace.SpanID]tracetest.SpanStub, len(spans))
+	for _, s := range spans {
+		byID[s.SpanContext.SpanID()] = s
+	}
+	parentName := func(s tracetest.SpanStub) string {
+		if p, ok := byID[s.Parent.SpanID()]; ok {
+			return p.Name
+		}
+		return ""
+	}
+	attr := func(s tracetest.SpanStub, key string) attribute.Value {
+		for _, kv := range s.Attributes {
+			if string(kv.Key) == key {
+				return kv.Value
+			}
+		}
+		return attribute.Value{}
+	}
+
+	var root tracetest.SpanStub
+	counts := make(map[string]int)
+	for _, s := range spans {
+		key := s.Name + " < " + parentName(s)
+		if s.Name == port.SpanToolExecution {
+			key = s.Name + "(" + attr(s, "tool").AsString() + ") < " + parentName(s)
+		}
+		counts[key]++
+		if s.Name == port.SpanInvestigation {
+			root = s
+		}
+	}
+
+	want := map[string]int{
+		port.SpanInvestigation + " < ":                                      1,
+		port.SpanAIRequest + " < " + port.SpanInvestigation:                 2,
+		port.SpanToolExecution + "(list_files) < " + port.SpanInvestigation: 1,
+		port.SpanToolExecution + "(task) < " + port.SpanInvestigation:       1,
+		port.SpanSubagent + " < " + port.SpanToolExecution:                  1,
+		port.SpanAIRequest + " < " + port.SpanSubagent:                      2,
+		port.SpanToolExecution + "(read_file) < " + port.SpanSubagent:       1,
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("spans %q = %d, want %d (all spans: %v)", key, counts[key], n, counts)
+		}
+	}
+	if len(spans) != 9 {
+		t.Errorf("got %d spans, want 9 (all spans: %v)", len(spans), counts)
+	}
+
+	if got := attr(root, "investigation_id").AsString(); got == "" {
+		t.Error("investigation span should have an investigation_id")
+	}
+	if got := attr(root, "alert_id").AsString(); got != "alert-1" {
+		t.Errorf("alert_id = %q, want %q", got, "alert-1")
+	}
+	if got := attr(root, "severity").AsString(); got != entity.SeverityCritical {
+		t.Errorf("severity = %q, want %q", got, entity.SeverityCritical)
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("span %q is not in the investigation's trace", s.Name)
+		}
+		if s.Name == port.SpanAIRequest && parentName(s) == port.SpanInvestigation &&
+			attr(s, "model").AsString() != "test-model" {
+			t.Errorf("AI request span model = %q, want %q", attr(s, "model").AsString(), "test-model")
+		}
+	}
+
+	var inputTokens int64
+	for _, s := range spans {
+		if s.Name == port.SpanAIRequest {
+			inputTokens += attr(s, "input_tokens").AsInt64()
+		}
+	}
+	if inputTokens != 370 {
+		t.Errorf("total input tokens on AI request spans = %d, want 370", inputTokens)
+	}
+}
@@ -0,0 +1,259 @@
+package transcript
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// conversationJSON is the JSON representation of a stored conversation.
+type conversationJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	SessionID     string           `json:"session_id"`
+	SavedAt       time.Time        `json:"saved_at"`
+	Messages      []entity.Message `json:"messages"`
+}
+
+// metadataSuffix ends the name of each session's metadata file.
+const metadataSuffix = ".meta.json"
+
+// metadataJSON is the JSON representation of port.SessionMetadata; the
+// fields must stay in step so the two convert directly.
+type metadataJSON struct {
+	SessionID    string    `json:"session_id"`
+	Title        string    `json:"title,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Model        string    `json:"model,omitempty"`
+	MessageCount int       `json:"message_count"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	Workspace    string    `json:"workspace,omitempty"`
+}
+
+// storedMetadataJSON is a metadata file: the metadata and its schema version.
+type storedMetadataJSON struct {
+	SchemaVersion int `json:"schema_version"`
+	metadataJSON
+}
+
+// FileConversationStore implements port.ConversationStore and
+// port.SessionCatalog by keeping one JSON file per session, rewritten on every
+// save, and a small metadata file beside it.
+type FileConversationStore struct {
+	baseDir string
+}
+
+// NewFileConversationStore creates a file-based conversation store rooted at path.
+// The directory is created on first save.
+// Returns an error if path is empty.
+func NewFileConversationStore(path string) (*FileConversationStore, error) {
+	if path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	return &FileConversationStore{baseDir: path}, nil
+}
+
+// SaveConversation writes the messages to <baseDir>/<sessionID>.json. The file
+// is written to a temporary name and renamed into place, so a failed save leaves
+// the previous history intact.
+func (s *FileConversationStore) SaveConversation(
+	ctx context.Context,
+	sessionID string,
+	messages []entity.Message,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.baseDir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(conversationJSON{
+		SchemaVersion: historyMigrations.Current(),
+		SessionID:     sessionID,
+		SavedAt:       time.Now(),
+		Messages:      messages,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadConversation reads the messages stored for sessionID. A history written
+// by a newer version is refused with schema.ErrNewerVersion.
+func (s *FileConversationStore) LoadConversation(ctx context.Context, sessionID string) ([]entity.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored conversationJSON
+	if _, err := readRecord(path, historyMigrations, &stored); err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to parse conversation %s: %w", path, err)
+	}
+	return stored.Messages, nil
+}
+
+// SaveSessionMetadata writes the metadata to <baseDir>/<sessionID>.meta.json,
+// beside the history, so listing sessions never reads their histories.
+func (s *FileConversationStore) SaveSessionMetadata(ctx context.Context, metadata port.SessionMetadata) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.metadataPath(metadata.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.baseDir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(storedMetadataJSON{
+		SchemaVersion: metadataMigrations.Current(),
+		metadataJSON:  metadataJSON(metadata),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session metadata: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadSessionMetadata reads the metadata stored for sessionID.
+func (s *FileConversationStore) LoadSessionMetadata(ctx context.Context, sessionID string) (port.SessionMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return port.SessionMetadata{}, err
+	}
+	path, err := s.metadataPath(sessionID)
+	if err != nil {
+		return port.SessionMetadata{}, err
+	}
+	return readMetadata(path)
+}
+
+// ListSessions returns the metadata of every session with a metadata file,
+// most recently updated first. Unreadable metadata files are skipped.
+func (s *FileConversationStore) ListSessions(ctx context.Context) ([]port.SessionMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(s.baseDir, "*"+metadataSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]port.SessionMetadata, 0, len(paths))
+	for _, path := range paths {
+		metadata, err := readMetadata(path)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, metadata)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Migrate rewrites every session history and metadata file written by an
+// older version in the current format and returns how many it rewrote. A file
+// it cannot read, such as one written by a newer version, is left alone and
+// its error returned with the others once the rest are migrated.
+func (s *FileConversationStore) Migrate(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	paths, err := filepath.Glob(filepath.Join(s.baseDir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	var errs []error
+	for _, path := range paths {
+		var rewritten bool
+		if strings.HasSuffix(path, metadataSuffix) {
+			rewritten, err = migrateRecord[storedMetadataJSON](path, metadataMigrations)
+		} else {
+			rewritten, err = migrateRecord[conversationJSON](path, historyMigrations)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if rewritten {
+			migrated++
+		}
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// path returns the file holding sessionID's history.
+func (s *FileConversationStore) path(sessionID string) (string, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.baseDir, sessionID+".json"), nil
+}
+
+// metadataPath returns the file holding sessionID's metadata.
+func (s *FileConversationStore) metadataPath(sessionID string) (string, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.baseDir, sessionID+metadataSuffix), nil
+}
+
+// validateSessionID rejects IDs that could name a file outside the store, or
+// whose history file would be another session's metadata file.
+func validateSessionID(sessionID string) error {
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\`) || strings.Contains(sessionID, "..") ||
+		strings.HasSuffix(sessionID, strings.TrimSuffix(metadataSuffix, ".json")) {
+		return fmt.Errorf("invalid session ID: %q", sessionID)
+	}
+	return nil
+}
+
+// readMetadata reads a session metadata file.
+func readMetadata(path string) (port.SessionMetadata, error) {
+	var stored storedMetadataJSON
+	if _, err := readRecord(path, metadataMigrations, &stored); err != nil {
+		if os.IsNotExist(err) {
+			return port.SessionMetadata{}, err
+		}
+		return port.SessionMetadata{}, fmt.Errorf("failed to parse session metadata %s: %w", path, err)
+	}
+	return port.SessionMetadata(stored.metadataJSON), nil
+}
+
+// writeFileAtomic writes data to a temporary name and renames it into place,
+// so a failed write leaves the previous file intact.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
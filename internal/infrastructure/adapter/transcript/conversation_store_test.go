@@ -0,0 +1,180 @@
+package transcript
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/schema"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Compile-time checks that FileConversationStore implements the store ports.
+var (
+	_ port.ConversationStore = (*FileConversationStore)(nil)
+	_ port.SessionCatalog    = (*FileConversationStore)(nil)
+)
+
+func TestFileConversationStore_RoundTrip(t *testing.T) {
+	store, err := NewFileConversationStore(filepath.Join(t.TempDir(), "sessions"))
+	if err != nil {
+		t.Fatalf("NewFileConversationStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	user, _ := entity.NewMessage(entity.RoleUser, "Run the tests")
+	call, _ := entity.NewToolCallMessage(entity.RoleAssistant, []entity.ToolCall{
+		{ToolID: "tool-1", ToolName: "bash", Input: map[string]interface{}{"command": "go test ./..."}},
+	})
+	result, _ := entity.NewToolResultMessage(entity.RoleUser, []entity.ToolResult{{ToolID: "tool-1", Result: "ok"}})
+
+	if err := store.SaveConversation(ctx, "session-1", []entity.Message{*user, *call, *result}); err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+	// A later save replaces the history, as a rollback does
+	if err := store.SaveConversation(ctx, "session-1", []entity.Message{*user}); err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+
+	messages, err := store.LoadConversation(ctx, "session-1")
+	if err != nil {
+		t.Fatalf("LoadConversation() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].Content != "Run the tests" {
+		t.Errorf("LoadConversation() = %+v, want only the user message", messages)
+	}
+
+	if err := store.SaveConversation(ctx, "session-2", []entity.Message{*call, *result}); err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+	messages, _ = store.LoadConversation(ctx, "session-2")
+	if len(messages) != 2 || messages[0].ToolCalls[0].Input["command"] != "go test ./..." ||
+		messages[1].ToolResults[0].ToolID != "tool-1" {
+		t.Errorf("LoadConversation() = %+v, want the tool exchange", messages)
+	}
+}
+
+func TestFileConversationStore_RejectsInvalidIDs(t *testing.T) {
+	store, _ := NewFileConversationStore(t.TempDir())
+
+	for _, id := range []string{"", "../escape", "nested/id", `win\id`, "x.meta"} {
+		if err := store.SaveConversation(context.Background(), id, nil); err == nil {
+			t.Errorf("SaveConversation(%q) expected error", id)
+		}
+		if _, err := store.LoadConversation(context.Background(), id); err == nil {
+			t.Errorf("LoadConversation(%q) expected error", id)
+		}
+	}
+}
+
+func TestFileConversationStore_SessionMetadata(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store, _ := NewFileConversationStore(dir)
+	ctx := context.Background()
+
+	// A store that was never written to has no sessions
+	sessions, err := store.ListSessions(ctx)
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("ListSessions() = %v, %v, want empty list", sessions, err)
+	}
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	older := port.SessionMetadata{
+		SessionID: "older", Title: "Fix flaky test", CreatedAt: start, UpdatedAt: start.Add(time.Minute),
+		Model: "claude-haiku", MessageCount: 4, InputTokens: 1200, OutputTokens: 300, Workspace: "/srv/app",
+	}
+	newer := port.SessionMetadata{SessionID: "newer", CreatedAt: start, UpdatedAt: start.Add(time.Hour)}
+	for _, m := range []port.SessionMetadata{older, newer} {
+		if err := store.SaveSessionMetadata(ctx, m); err != nil {
+			t.Fatalf("SaveSessionMetadata(%s) error = %v", m.SessionID, err)
+		}
+	}
+	// The history file beside the metadata must not be listed as a session
+	if err := store.SaveConversation(ctx, "older", nil); err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+
+	got, err := store.LoadSessionMetadata(ctx, "older")
+	if err != nil {
+		t.Fatalf("LoadSessionMetadata() error = %v", err)
+	}
+	if got != older {
+		t.Errorf("LoadSessionMetadata() = %+v, want %+v", got, older)
+	}
+
+	sessions, err = store.ListSessions(ctx)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].SessionID != "newer" || sessions[1].SessionID != "older" {
+		t.Errorf("ListSessions() = %+v, want newer then older", sessions)
+	}
+}
+
+// copyGoldenSessions copies the named testdata files into a new session directory.
+func copyGoldenSessions(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFileConversationStore_ReadsUnversionedSessions(t *testing.T) {
+	store, _ := NewFileConversationStore(copyGoldenSessions(t, "session-v1.json", "session-v1.meta.json"))
+	ctx := context.Background()
+
+	messages, err := store.LoadConversation(ctx, "session-v1")
+	if err != nil || len(messages) != 1 || messages[0].Content != "Run the tests" {
+		t.Errorf("LoadConversation() = %+v, %v, want the stored user message", messages, err)
+	}
+	metadata, err := store.LoadSessionMetadata(ctx, "session-v1")
+	if err != nil || metadata.Title != "Run the tests" || metadata.MessageCount != 1 || metadata.InputTokens != 120 {
+		t.Errorf("LoadSessionMetadata() = %+v, %v, want the stored metadata", metadata, err)
+	}
+	if migrated, err := store.Migrate(ctx); err != nil || migrated != 0 {
+		t.Errorf("Migrate() = %d, %v, want version 1 files left as they are", migrated, err)
+	}
+}
+
+func TestFileConversationStore_RefusesNewerVersion(t *testing.T) {
+	dir := copyGoldenSessions(t, "session-future.json")
+	store, _ := NewFileConversationStore(dir)
+
+	_, err := store.LoadConversation(context.Background(), "session-future")
+	if !errors.Is(err, schema.ErrNewerVersion) || !strings.Contains(err.Error(), "schema version 7") {
+		t.Errorf("LoadConversation() error = %v, want ErrNewerVersion naming version 7", err)
+	}
+	if _, err := store.Migrate(context.Background()); !errors.Is(err, schema.ErrNewerVersion) {
+		t.Errorf("Migrate() error = %v, want ErrNewerVersion", err)
+	}
+}
+
+func TestFileConversationStore_WritesSchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFileConversationStore(dir)
+	ctx := context.Background()
+	if err := store.SaveConversation(ctx, "s", nil); err != nil {
+		t.Fatalf("SaveConversation() error = %v", err)
+	}
+	if err := store.SaveSessionMetadata(ctx, port.SessionMetadata{SessionID: "s"}); err != nil {
+		t.Fatalf("SaveSessionMetadata() error = %v", err)
+	}
+	for _, name := range []string{"s.json", "s.meta.json"} {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		if !strings.Contains(string(data), `"schema_version": 1`) {
+			t.Errorf("%s = %s, want schema_version 1", name, data)
+		}
+	}
+}
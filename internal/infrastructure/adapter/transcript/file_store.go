@@ -0,0 +1,73 @@
+// Package transcript provides persistence for subagent conversation transcripts
+// and chat session histories.
+package transcript
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// transcriptJSON is the JSON representation of a stored transcript.
+type transcriptJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	SubagentID    string           `json:"subagent_id"`
+	SavedAt       time.Time        `json:"saved_at"`
+	Messages      []entity.Message `json:"messages"`
+}
+
+// FileTranscriptStore implements port.TranscriptStore by writing one JSON file per
+// subagent run. The returned reference is the path of the written file.
+type FileTranscriptStore struct {
+	baseDir string
+}
+
+// NewFileTranscriptStore creates a file-based transcript store rooted at path.
+// The directory is created on first save.
+// Returns an error if path is empty.
+func NewFileTranscriptStore(path string) (*FileTranscriptStore, error) {
+	if path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	return &FileTranscriptStore{baseDir: path}, nil
+}
+
+// SaveTranscript writes the messages to <baseDir>/<subagentID>.json and returns the file path.
+func (s *FileTranscriptStore) SaveTranscript(
+	ctx context.Context,
+	subagentID string,
+	messages []entity.Message,
+) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if subagentID == "" || strings.ContainsAny(subagentID, `/\`) || strings.Contains(subagentID, "..") {
+		return "", fmt.Errorf("invalid subagent ID: %q", subagentID)
+	}
+
+	if err := os.MkdirAll(s.baseDir, 0o750); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(transcriptJSON{
+		SchemaVersion: transcriptMigrations.Current(),
+		SubagentID:    subagentID,
+		SavedAt:       time.Now(),
+		Messages:      messages,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal transcript: %w", err)
+	}
+
+	path := filepath.Join(s.baseDir, subagentID+".json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
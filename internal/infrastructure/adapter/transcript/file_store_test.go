@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Compile-time check that FileTranscriptStore implements port.TranscriptStore.
+var _ port.TranscriptStore = (*FileTranscriptStore)(nil)
+
+func TestNewFileTranscriptStore_EmptyPath(t *testing.T) {
+	if _, err := NewFileTranscriptStore(""); err == nil {
+		t.Error("NewFileTranscriptStore(\"\") expected error")
+	}
+}
+
+func TestFileTranscriptStore_SaveTranscript_WritesMessages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "transcripts")
+	store, err := NewFileTranscriptStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileTranscriptStore() error = %v", err)
+	}
+
+	user, _ := entity.NewMessage(entity.RoleUser, "Review auth.go")
+	assistant, _ := entity.NewMessage(entity.RoleAssistant, "Found two issues")
+
+	ref, err := store.SaveTranscript(context.Background(), "subagent-123", []entity.Message{*user, *assistant})
+	if err != nil {
+		t.Fatalf("SaveTranscript() error = %v", err)
+	}
+	if ref != filepath.Join(dir, "subagent-123.json") {
+		t.Errorf("ref = %q, want file path inside store directory", ref)
+	}
+
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		t.Fatalf("failed to read transcript: %v", err)
+	}
+	var saved transcriptJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("transcript is not valid JSON: %v", err)
+	}
+	if saved.SubagentID != "subagent-123" || len(saved.Messages) != 2 ||
+		saved.Messages[1].Content != "Found two issues" {
+		t.Errorf("unexpected saved transcript: %+v", saved)
+	}
+}
+
+func TestFileTranscriptStore_SaveTranscript_RejectsInvalidIDs(t *testing.T) {
+	store, _ := NewFileTranscriptStore(t.TempDir())
+
+	for _, id := range []string{"", "../escape", "nested/id", `win\id`} {
+		if _, err := store.SaveTranscript(context.Background(), id, nil); err == nil {
+			t.Errorf("SaveTranscript(%q) expected error", id)
+		}
+	}
+}
+
+func TestFileTranscriptStore_SaveTranscript_CancelledContext(t *testing.T) {
+	store, _ := NewFileTranscriptStore(t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.SaveTranscript(ctx, "subagent-1", nil); err == nil {
+		t.Error("SaveTranscript() expected error for cancelled context")
+	}
+}
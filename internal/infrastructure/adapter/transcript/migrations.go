@@ -0,0 +1,46 @@
+package transcript
+
+import (
+	"code-editing-agent/internal/infrastructure/schema"
+	"encoding/json"
+	"os"
+)
+
+// Migration registries of the records this package writes. Each is at
+// version 1; append a step to change a record's format.
+//
+//nolint:gochecknoglobals // read-only migration registries
+var (
+	historyMigrations    = schema.Migrations{Kind: "session history"}
+	metadataMigrations   = schema.Migrations{Kind: "session metadata"}
+	transcriptMigrations = schema.Migrations{Kind: "subagent transcript"}
+)
+
+// readRecord reads the record at path into v, migrating it in memory if an
+// older version wrote it, and reports whether it did.
+func readRecord(path string, migrations schema.Migrations, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	data, migrated, err := migrations.Migrate(data)
+	if err != nil {
+		return false, err
+	}
+	return migrated, json.Unmarshal(data, v)
+}
+
+// migrateRecord rewrites the record at path in the current format if an older
+// version wrote it, and reports whether it did. T is the record's JSON type.
+func migrateRecord[T any](path string, migrations schema.Migrations) (bool, error) {
+	var record T
+	migrated, err := readRecord(path, migrations, &record)
+	if err != nil || !migrated {
+		return false, err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, writeFileAtomic(path, data)
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// activityTickInterval is how often the activity spinner advances.
+const activityTickInterval = 100 * time.Millisecond
+
+// activityFrames are the spinner animation frames.
+var activityFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// ANSI control sequences for drawing the spinner in place. The cursor position is
+// saved before drawing and restored afterwards, so the spinner never moves the
+// cursor and any output written next starts where the spinner was.
+const (
+	ansiSaveCursor    = "\x1b7"
+	ansiRestoreCursor = "\x1b8"
+	ansiClearToEOL    = "\x1b[K"
+)
+
+// activity is a running spinner. It is owned by CLIAdapter and guarded by its mutex.
+type activity struct {
+	label   string
+	started time.Time
+	frame   int
+	stop    chan struct{}
+}
+
+// StartActivity implements port.ActivityIndicator by showing a spinner line such
+// as "⠋ Thinking… 12s" until StopActivity is called or streamed text arrives.
+// It replaces any spinner already shown, and is a no-op in non-interactive mode
+// or when output is not a terminal.
+func (c *CLIAdapter) StartActivity(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.showActivity || !c.useInteractive {
+		return
+	}
+
+	c.stopActivityLocked()
+	a := &activity{label: label, started: time.Now(), stop: make(chan struct{})}
+	c.activity = a
+	c.drawActivityLocked()
+
+	go c.tickActivity(a)
+}
+
+// StopActivity implements port.ActivityIndicator by clearing the spinner, if any.
+func (c *CLIAdapter) StopActivity() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopActivityLocked()
+}
+
+// SetActivityIndicator enables or disables the activity spinner. It is enabled
+// by default when both stdin and stdout are terminals.
+func (c *CLIAdapter) SetActivityIndicator(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.showActivity = enabled
+	if !enabled {
+		c.stopActivityLocked()
+	}
+}
+
+// tickActivity redraws the spinner until it is stopped or replaced.
+func (c *CLIAdapter) tickActivity(a *activity) {
+	ticker := time.NewTicker(activityTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.activity == a {
+				a.frame++
+				c.drawActivityLocked()
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// drawActivityLocked draws the current spinner frame. Caller must hold c.mu.
+func (c *CLIAdapter) drawActivityLocked() {
+	a := c.activity
+	if a == nil {
+		return
+	}
+	frame := activityFrames[a.frame%len(activityFrames)]
+	text := fmt.Sprintf("%s %s… %ds", frame, a.label, int(time.Since(a.started).Seconds()))
+	if c.colorEnabled() {
+		text = ansiDim + text + ansiBoldOff
+	}
+	_, _ = fmt.Fprint(c.output, ansiClearToEOL+ansiSaveCursor+text+ansiRestoreCursor)
+}
+
+// clearActivityLocked erases the spinner without stopping it, so that other output
+// can be written in its place. Caller must hold c.mu and should call
+// drawActivityLocked after writing.
+func (c *CLIAdapter) clearActivityLocked() {
+	if c.activity != nil {
+		_, _ = fmt.Fprint(c.output, ansiClearToEOL)
+	}
+}
+
+// stopActivityLocked erases and stops the spinner. Caller must hold c.mu.
+func (c *CLIAdapter) stopActivityLocked() {
+	if c.activity == nil {
+		return
+	}
+	close(c.activity.stop)
+	c.activity = nil
+	_, _ = fmt.Fprint(c.output, ansiClearToEOL)
+}
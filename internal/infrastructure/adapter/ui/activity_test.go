@@ -0,0 +1,129 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// activityTick mirrors the adapter's spinner interval.
+const activityTick = 100 * time.Millisecond
+
+// lockedBuffer is a bytes.Buffer that is safe to read while the spinner goroutine writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newActivityAdapter returns an adapter with the spinner forced on, as if attached to a terminal.
+func newActivityAdapter(output *lockedBuffer) *ui.CLIAdapter {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+	adapter.SetInteractive(true)
+	adapter.SetActivityIndicator(true)
+	return adapter
+}
+
+var _ port.ActivityIndicator = (*ui.CLIAdapter)(nil)
+
+func TestCLIAdapter_StartActivity_DrawsAndClearsSpinner(t *testing.T) {
+	output := &lockedBuffer{}
+	adapter := newActivityAdapter(output)
+
+	adapter.StartActivity("Thinking")
+	drawn := output.String()
+	assert.Contains(t, drawn, "\x1b7", "spinner should save the cursor position")
+	assert.Contains(t, drawn, "Thinking… 0s")
+	assert.True(t, strings.HasSuffix(drawn, "\x1b8"), "spinner should restore the cursor position")
+
+	adapter.StopActivity()
+	assert.True(t, strings.HasSuffix(output.String(), "\x1b[K"), "stopping should clear the spinner line")
+
+	// Stopping twice is a no-op
+	before := output.String()
+	adapter.StopActivity()
+	assert.Equal(t, before, output.String())
+}
+
+func TestCLIAdapter_StartActivity_Animates(t *testing.T) {
+	output := &lockedBuffer{}
+	adapter := newActivityAdapter(output)
+	defer adapter.StopActivity()
+
+	adapter.StartActivity("Running bash")
+	assert.Eventually(t, func() bool {
+		return strings.Count(output.String(), "Running bash") >= 2
+	}, 2*time.Second, 20*time.Millisecond, "spinner should redraw on each tick")
+}
+
+func TestCLIAdapter_StartActivity_Suppressed(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(adapter *ui.CLIAdapter)
+	}{
+		{name: "output is not a terminal", setup: func(*ui.CLIAdapter) {}},
+		{name: "non-interactive mode", setup: func(adapter *ui.CLIAdapter) {
+			adapter.SetActivityIndicator(true)
+			adapter.SetInteractive(false)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &lockedBuffer{}
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+			tt.setup(adapter)
+
+			adapter.StartActivity("Thinking")
+			adapter.StopActivity()
+			assert.Empty(t, output.String())
+		})
+	}
+}
+
+func TestCLIAdapter_StreamingTextStopsActivity(t *testing.T) {
+	output := &lockedBuffer{}
+	adapter := newActivityAdapter(output)
+
+	adapter.StartActivity("Thinking")
+	assert.NoError(t, adapter.DisplayStreamingText("hello"))
+	afterDelta := output.String()
+	assert.True(t, strings.HasSuffix(afterDelta, "\x1b[Khello"), "spinner should be cleared before the delta")
+
+	// No frames may be drawn after streamed text starts
+	time.Sleep(3 * activityTick)
+	assert.Equal(t, afterDelta, output.String())
+}
+
+func TestCLIAdapter_OutputRedrawsActivity(t *testing.T) {
+	output := &lockedBuffer{}
+	adapter := newActivityAdapter(output)
+	defer adapter.StopActivity()
+
+	adapter.StartActivity("Running task")
+	start := len(output.String())
+	assert.NoError(t, adapter.DisplaySystemMessage("subagent started"))
+
+	written := output.String()[start:]
+	assert.True(t, strings.HasPrefix(written, "\x1b[K"), "spinner should be cleared before the message")
+	message := strings.Index(written, "subagent started")
+	spinner := strings.LastIndex(written, "Running task")
+	assert.Greater(t, spinner, message, "spinner should be redrawn after the message")
+}
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// AttentionReason is why the chat wants the user's attention.
+type AttentionReason string
+
+const (
+	// AttentionInputNeeded is a confirmation or question waiting for an answer.
+	AttentionInputNeeded AttentionReason = "input_needed"
+	// AttentionTurnFinished is the end of a turn that ran longer than the threshold.
+	AttentionTurnFinished AttentionReason = "turn_finished"
+)
+
+// defaultAttentionTitle names the session in notifications until it has a title.
+const defaultAttentionTitle = "code-editing-agent"
+
+// attentionSummaryMaxChars limits the one-line summary of a notification.
+const attentionSummaryMaxChars = 100
+
+// desktopNotifyTimeout limits how long a desktop notification command may run.
+const desktopNotifyTimeout = 5 * time.Second
+
+// Attention is a request for the user's attention, such as a confirmation
+// prompt shown while they are in another window.
+type Attention struct {
+	Reason  AttentionReason
+	Title   string // The session title, or "code-editing-agent"
+	Summary string // One line, with secrets masked
+}
+
+// AttentionNotifier tells the user outside the terminal that the chat wants
+// their attention. Notify is called while output is being written, so it must
+// return without waiting for the notification to be shown.
+type AttentionNotifier interface {
+	Notify(attention Attention)
+}
+
+// DesktopNotifier shows attention requests as desktop notifications, with
+// osascript on macOS and notify-send elsewhere. Each notification runs its
+// command in the background for up to 5 seconds; failures are ignored.
+type DesktopNotifier struct {
+	command func(ctx context.Context, attention Attention) *exec.Cmd
+}
+
+// NewDesktopNotifier returns a notifier using the platform's notification
+// command, or an error if that command is not installed.
+func NewDesktopNotifier() (*DesktopNotifier, error) {
+	name := "notify-send"
+	if runtime.GOOS == "darwin" {
+		name = "osascript"
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("desktop notifications need %s: %w", name, err)
+	}
+
+	if name == "osascript" {
+		return &DesktopNotifier{command: func(ctx context.Context, attention Attention) *exec.Cmd {
+			script := "display notification " + appleScriptString(attention.Summary) +
+				" with title " + appleScriptString(attention.Title)
+			return exec.CommandContext(ctx, path, "-e", script)
+		}}, nil
+	}
+	return &DesktopNotifier{command: func(ctx context.Context, attention Attention) *exec.Cmd {
+		return exec.CommandContext(ctx, path, "--app-name="+defaultAttentionTitle, "--",
+			attention.Title, attention.Summary)
+	}}, nil
+}
+
+// Notify implements AttentionNotifier by starting the notification command
+// in the background.
+func (n *DesktopNotifier) Notify(attention Attention) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), desktopNotifyTimeout)
+		defer cancel()
+		_ = n.command(ctx, attention).Run()
+	}()
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// SetAttentionNotifier sets the notifier told when a confirmation or question
+// waits for an answer or a long turn finishes. nil, the default, sends no
+// notifications.
+func (c *CLIAdapter) SetAttentionNotifier(notifier AttentionNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attention = notifier
+}
+
+// SetBell sets whether the terminal bell rings when a confirmation or question
+// waits for an answer or a long turn finishes. It is off by default.
+func (c *CLIAdapter) SetBell(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bell = enabled
+}
+
+// SetTurnNotifyThreshold sets how long a turn must run for its end to ring the
+// bell and notify. A turn runs from the input that starts it until input is
+// read again. 0, the default, leaves turn ends unannounced.
+func (c *CLIAdapter) SetTurnNotifyThreshold(threshold time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turnThreshold = threshold
+}
+
+// SetSessionTitle sets the title that names the session in notifications. An
+// empty title uses "code-editing-agent". Thread-safe for concurrent access.
+func (c *CLIAdapter) SetSessionTitle(title string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionTitle = title
+}
+
+// requestAttention rings the bell, if enabled, and tells the notifier, if any,
+// that the chat wants the user's attention. It does nothing in non-interactive
+// mode, where nobody is waiting at the terminal.
+func (c *CLIAdapter) requestAttention(reason AttentionReason, summary string) {
+	c.mu.Lock()
+	if !c.useInteractive {
+		c.mu.Unlock()
+		return
+	}
+	if c.bell {
+		_, _ = fmt.Fprint(c.output, "\a")
+	}
+	notifier, title := c.attention, c.sessionTitle
+	c.mu.Unlock()
+
+	if notifier == nil {
+		return
+	}
+	if title == "" {
+		title = defaultAttentionTitle
+	}
+	notifier.Notify(Attention{Reason: reason, Title: title, Summary: attentionSummary(summary)})
+}
+
+// startTurn records that the input just read starts a turn.
+func (c *CLIAdapter) startTurn() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turnStarted = time.Now()
+	c.lastReply = ""
+}
+
+// finishTurn ends the running turn, if any, and requests attention if it ran
+// longer than the turn threshold. The summary is the first line of the turn's
+// last reply.
+func (c *CLIAdapter) finishTurn() {
+	c.mu.Lock()
+	started, threshold, reply := c.turnStarted, c.turnThreshold, c.lastReply
+	c.turnStarted = time.Time{}
+	c.mu.Unlock()
+
+	if started.IsZero() || threshold <= 0 {
+		return
+	}
+	elapsed := time.Since(started)
+	if elapsed <= threshold {
+		return
+	}
+	summary := "Finished after " + elapsed.Round(time.Second).String()
+	if reply = strings.TrimSpace(reply); reply != "" {
+		summary += ": " + reply
+	}
+	c.requestAttention(AttentionTurnFinished, summary)
+}
+
+// attentionSummary returns the first non-empty line of text with colors
+// removed, secrets masked, and whitespace collapsed, cut to 100 characters.
+func attentionSummary(text string) string {
+	line := ""
+	for l := range strings.Lines(stripANSI(text)) {
+		if line = collapseWhitespace(l); line != "" {
+			break
+		}
+	}
+	runes := []rune(defaultRedactor.Redact(line))
+	if len(runes) <= attentionSummaryMaxChars {
+		return string(runes)
+	}
+	return string(runes[:attentionSummaryMaxChars]) + "…"
+}
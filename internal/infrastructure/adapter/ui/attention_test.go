@@ -0,0 +1,177 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attentionRecorder records the attention requests it is told about.
+type attentionRecorder struct {
+	mu       sync.Mutex
+	requests []ui.Attention
+}
+
+func (r *attentionRecorder) Notify(attention ui.Attention) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, attention)
+}
+
+func (r *attentionRecorder) Requests() []ui.Attention {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ui.Attention(nil), r.requests...)
+}
+
+var _ ui.AttentionNotifier = (*ui.DesktopNotifier)(nil)
+
+// newAttentionAdapter returns an interactive adapter reading input, with the
+// bell on and notifications going to a recorder.
+func newAttentionAdapter(input string, output *bytes.Buffer) (*ui.CLIAdapter, *attentionRecorder) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(input), output)
+	adapter.SetColorEnabled(false)
+	adapter.SetInteractive(true)
+	adapter.SetBell(true)
+	recorder := &attentionRecorder{}
+	adapter.SetAttentionNotifier(recorder)
+	return adapter, recorder
+}
+
+func TestCLIAdapter_ConfirmBashCommand_RequestsAttention(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("y\n", &output)
+	adapter.SetSessionTitle("Fix the tests")
+
+	assert.True(t, adapter.ConfirmBashCommand("go test ./...", false, "", ""))
+
+	assert.Contains(t, output.String(), "go test ./...\n\aExecute? [y/N]: ", "the bell should ring before the prompt")
+	assert.Equal(t, []ui.Attention{{
+		Reason:  ui.AttentionInputNeeded,
+		Title:   "Fix the tests",
+		Summary: "Run go test ./...?",
+	}}, recorder.Requests())
+}
+
+func TestCLIAdapter_ConfirmFileEdit_RequestsAttention(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("n\n", &output)
+
+	assert.False(t, adapter.ConfirmFileEdit("main.go", "@@ -1 +1 @@\n-a\n+b\n"))
+
+	assert.Contains(t, output.String(), "\aApply? [y/N]: ")
+	assert.Equal(t, []ui.Attention{{
+		Reason:  ui.AttentionInputNeeded,
+		Title:   "code-editing-agent",
+		Summary: "Apply the edit to main.go?",
+	}}, recorder.Requests())
+}
+
+func TestCLIAdapter_PromptChoice_RequestsAttention(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("1\n", &output)
+
+	_, err := adapter.PromptChoice(context.Background(), "Which config?\nPick one.", []string{"dev", "prod"})
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "\aChoose 1-2: ")
+	require.Len(t, recorder.Requests(), 1)
+	assert.Equal(t, "Which config?", recorder.Requests()[0].Summary)
+}
+
+func TestCLIAdapter_RequestAttention_MasksSecretsInSummary(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("n\n", &output)
+
+	adapter.ConfirmBashCommand("curl -H 'Authorization: Bearer abcdef123456' "+strings.Repeat("x", 200), false, "", "")
+
+	require.Len(t, recorder.Requests(), 1)
+	summary := recorder.Requests()[0].Summary
+	assert.NotContains(t, summary, "abcdef123456")
+	assert.Equal(t, 101, len([]rune(summary)), "the summary should be cut to 100 characters and an ellipsis")
+}
+
+func TestCLIAdapter_RequestAttention_NonInteractive(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("y\n", &output)
+	adapter.SetInteractive(false)
+
+	adapter.ConfirmBashCommand("ls", false, "", "")
+
+	assert.NotContains(t, output.String(), "\a")
+	assert.Empty(t, recorder.Requests())
+}
+
+func TestCLIAdapter_RequestAttention_BellOff(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("y\n", &output)
+	adapter.SetBell(false)
+
+	adapter.ConfirmBashCommand("ls", false, "", "")
+
+	assert.NotContains(t, output.String(), "\a")
+	assert.Len(t, recorder.Requests(), 1, "notifications do not depend on the bell")
+}
+
+func TestCLIAdapter_TurnFinished(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		wantBell  bool
+	}{
+		{name: "past the threshold", threshold: time.Millisecond, wantBell: true},
+		{name: "within the threshold", threshold: time.Hour},
+		{name: "disabled", threshold: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			adapter, recorder := newAttentionAdapter("run the tests\nthanks\n", &output)
+			adapter.SetTurnNotifyThreshold(tt.threshold)
+
+			_, ok := adapter.GetUserInput(context.Background())
+			require.True(t, ok)
+			require.NoError(t, adapter.DisplayMessage("All tests pass.\n\nDetails follow.", "assistant"))
+			time.Sleep(5 * time.Millisecond)
+			assert.Empty(t, recorder.Requests(), "nothing should be announced while the turn runs")
+
+			_, ok = adapter.GetUserInput(context.Background())
+			require.True(t, ok)
+
+			if !tt.wantBell {
+				assert.NotContains(t, output.String(), "\a")
+				assert.Empty(t, recorder.Requests())
+				return
+			}
+			assert.Contains(t, output.String(), "\a")
+			require.Len(t, recorder.Requests(), 1)
+			got := recorder.Requests()[0]
+			assert.Equal(t, ui.AttentionTurnFinished, got.Reason)
+			assert.Regexp(t, `^Finished after \d+s: All tests pass\.$`, got.Summary)
+		})
+	}
+}
+
+func TestCLIAdapter_TurnFinished_StreamedReply(t *testing.T) {
+	var output bytes.Buffer
+	adapter, recorder := newAttentionAdapter("deploy\nok\n", &output)
+	adapter.SetTurnNotifyThreshold(time.Millisecond)
+
+	_, _ = adapter.GetUserInput(context.Background())
+	require.NoError(t, adapter.BeginStreamingResponse())
+	require.NoError(t, adapter.DisplayStreamingText("Deployed "))
+	require.NoError(t, adapter.DisplayStreamingText("v2 to staging.\nNext steps:"))
+	require.NoError(t, adapter.EndStreamingResponse())
+	time.Sleep(5 * time.Millisecond)
+	_, _ = adapter.GetUserInput(context.Background())
+
+	require.Len(t, recorder.Requests(), 1)
+	assert.Regexp(t, `: Deployed v2 to staging\.$`, recorder.Requests()[0].Summary)
+}
@@ -2,16 +2,16 @@ package ui
 
 import (
 	"bufio"
+	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/chzyer/readline"
 )
@@ -22,25 +22,51 @@ const (
 	KeyShiftTab = "shift+tab"
 )
 
+// planModePromptPrefix is shown before the input prompt while plan mode is enabled.
+const planModePromptPrefix = "[PLAN MODE] "
+
 // defaultMaxHistoryEntries is the default number of history entries to store.
 const defaultMaxHistoryEntries = 100
 
 // CLIAdapter implements the UserInterface port using the command line.
 type CLIAdapter struct {
-	input              io.Reader
-	output             io.Writer
-	prompt             string
-	colors             port.ColorScheme
-	scanner            *bufio.Scanner
-	truncationConfig   TruncationConfig
-	useInteractive     bool
-	historyFile        string
-	maxHistoryEntries  int
-	readlineInstance   *readline.Instance
-	modeToggleCallback func()
-	planMode           bool
-	sessionID          string
-	mu                 sync.RWMutex
+	input               io.Reader
+	output              io.Writer
+	prompt              string
+	colors              port.ColorScheme // Guarded by colorsMu; read it with colorScheme
+	colorsMu            sync.RWMutex     // Apart from mu, so output built under mu can be colored
+	scanner             *bufio.Scanner
+	pendingScan         chan scanResult // Scan left running by a cancelled PromptChoice
+	truncationConfig    TruncationConfig
+	jsonElidedFields    map[string][]string // Fields elided from each tool's JSON results
+	diffPreviewMaxLines int
+	thinkingExpanded    bool
+	verbose             bool // Show full tool call input; see SetVerbose
+	lastThinking        string
+	useInteractive      bool
+	historyFile         string
+	maxHistoryEntries   int
+	readlineInstance    *readline.Instance
+	history             *HistoryManager
+	search              *reverseSearch
+	completer           *Completer
+	modeToggleCallback  func()
+	planMode            bool
+	readingContinuation bool // Whether the line being read is a continuation line
+	sessionID           string
+	sessionLabel        string // Shown in the prompt; see SetSessionLabel
+	renderMarkdown      bool
+	showActivity        bool
+	activity            *activity
+	transcript          *transcriptWriter
+	streamed            strings.Builder // Streamed response text, kept for the transcript and notifications
+	attention           AttentionNotifier
+	bell                bool
+	turnThreshold       time.Duration // See SetTurnNotifyThreshold
+	turnStarted         time.Time     // Zero while no turn is running
+	lastReply           string        // The running turn's last assistant message
+	sessionTitle        string        // Names the session in notifications; see SetSessionTitle
+	mu                  sync.RWMutex
 }
 
 // defaultColorScheme returns the default ANSI color scheme for CLI output.
@@ -62,20 +88,46 @@ func NewCLIAdapter() *CLIAdapter {
 		input:            os.Stdin,
 		output:           os.Stdout,
 		prompt:           "> ",
-		colors:           defaultColorScheme(),
+		colors:           detectColorScheme(os.Stdout),
 		truncationConfig: DefaultTruncationConfig(),
 		useInteractive:   IsTerminal(os.Stdin),
+		history:          NewHistoryManager(defaultMaxHistoryEntries),
+		completer:        NewCompleter(),
+		renderMarkdown:   supportsColor(os.Stdout),
+		showActivity:     IsTerminal(os.Stdin) && supportsANSI(os.Stdout),
 	}
 }
 
 // NewCLIAdapterWithIO creates a new CLIAdapter with custom I/O for testing.
+// Colors are enabled unless NO_COLOR is set; use SetColorEnabled to override.
 func NewCLIAdapterWithIO(input io.Reader, output io.Writer) *CLIAdapter {
+	colors := defaultColorScheme()
+	if noColorRequested() {
+		colors = port.ColorScheme{}
+	}
 	return &CLIAdapter{
 		input:            input,
 		output:           output,
 		prompt:           "> ",
-		colors:           defaultColorScheme(),
+		colors:           colors,
 		truncationConfig: DefaultTruncationConfig(),
+		history:          NewHistoryManager(defaultMaxHistoryEntries),
+		completer:        NewCompleter(),
+	}
+}
+
+// NewHeadlessCLIAdapter creates a CLIAdapter for non-interactive runs that
+// writes to output, colored only when it is a terminal, and reads no input,
+// so every confirmation is declined.
+func NewHeadlessCLIAdapter(output io.Writer) *CLIAdapter {
+	return &CLIAdapter{
+		input:            strings.NewReader(""),
+		output:           output,
+		prompt:           "> ",
+		colors:           detectColorScheme(output),
+		truncationConfig: DefaultTruncationConfig(),
+		history:          NewHistoryManager(defaultMaxHistoryEntries),
+		completer:        NewCompleter(),
 	}
 }
 
@@ -90,15 +142,26 @@ func NewCLIAdapterWithHistory(historyFile string) *CLIAdapter {
 	// Expand tilde in history file path if present
 	expandedPath := expandPath(historyFile)
 
+	// Seed search and expansion with the persisted history and append to it; an
+	// unreadable file only means starting with an empty history
+	history := NewHistoryManager(defaultMaxHistoryEntries)
+	if expandedPath != "" {
+		_ = history.SetFile(expandedPath)
+	}
+
 	return &CLIAdapter{
 		input:             os.Stdin,
 		output:            os.Stdout,
 		prompt:            "> ",
-		colors:            defaultColorScheme(),
+		colors:            detectColorScheme(os.Stdout),
 		truncationConfig:  DefaultTruncationConfig(),
 		useInteractive:    true,
 		historyFile:       expandedPath,
 		maxHistoryEntries: defaultMaxHistoryEntries,
+		history:           history,
+		completer:         NewCompleter(),
+		renderMarkdown:    supportsColor(os.Stdout),
+		showActivity:      IsTerminal(os.Stdin) && supportsANSI(os.Stdout),
 	}
 }
 
@@ -143,6 +206,13 @@ func getHomeDir(fallback string) string {
 // GetUserInput gets input from the user with context support.
 // When in interactive mode, uses readline for arrow key navigation and history.
 // When in non-interactive mode, uses bufio.Scanner for simple line input.
+//
+// In both modes a message can span several lines: a trailing backslash continues
+// onto the next line, and a line starting with """ opens a block that runs until
+// a closing """. Continuation lines use the "... " prompt.
+//
+// Input of the form !! or !<prefix> is replaced by the newest matching history
+// entry, which is echoed before it is returned.
 func (c *CLIAdapter) GetUserInput(ctx context.Context) (string, bool) {
 	// Check if context is cancelled
 	select {
@@ -152,33 +222,101 @@ func (c *CLIAdapter) GetUserInput(ctx context.Context) (string, bool) {
 		// Continue
 	}
 
-	// Use readline for interactive mode with history support
+	c.StopActivity()
+	c.finishTurn()
+
+	// Use readline for interactive mode with history support,
+	// falling back to bufio.Scanner for non-interactive mode
+	readLine := c.getScannerInput
 	if c.useInteractive && c.historyFile != "" {
-		return c.getInteractiveInput(ctx)
+		readLine = func(continuation bool) (string, bool) {
+			return c.getInteractiveInput(ctx, continuation)
+		}
+	}
+
+	for {
+		input, ok := assembleMultilineInput(readLine)
+		if ctx.Err() != nil || !ok {
+			return "", false
+		}
+
+		expanded, changed, err := c.history.Expand(input)
+		if err != nil {
+			_ = c.DisplayError(err)
+			continue
+		}
+		if changed {
+			c.mu.Lock()
+			_, _ = fmt.Fprint(c.output, c.colorize(c.colorScheme().System, expanded)+"\n")
+			c.mu.Unlock()
+		}
+
+		c.recordHistory(expanded)
+		c.recordTranscript("user", expanded)
+		c.startTurn()
+		return expanded, true
 	}
+}
 
-	// Fall back to bufio.Scanner for non-interactive mode
-	return c.getScannerInput()
+// recordHistory adds input to the history, which persists it, and to
+// readline's in-memory history for the arrow keys. Multi-line input is not
+// given to readline because it recalls one line at a time.
+func (c *CLIAdapter) recordHistory(input string) {
+	c.history.Add(input)
+	if c.readlineInstance != nil && strings.TrimSpace(input) != "" && !strings.Contains(input, "\n") {
+		_ = c.readlineInstance.SaveHistory(input)
+	}
 }
 
 // getInteractiveInput uses readline for feature-rich terminal input with context support.
-func (c *CLIAdapter) getInteractiveInput(ctx context.Context) (string, bool) {
+// Continuation lines of a multi-line message use the "... " prompt.
+func (c *CLIAdapter) getInteractiveInput(ctx context.Context, continuation bool) (string, bool) {
+	prompt := c.inputPrompt(continuation)
+	c.mu.Lock()
+	c.readingContinuation = continuation
+	c.mu.Unlock()
+
 	// Initialize readline instance if not already created
 	if c.readlineInstance == nil {
+		search := newReverseSearch(c.history)
 		config := &readline.Config{
-			Prompt:          c.colors.Prompt + "Claude: " + "\x1b[0m",
-			HistoryFile:     c.historyFile,
+			Prompt:          prompt,
 			InterruptPrompt: "^C",
 			EOFPrompt:       "exit",
+			// History is saved by recordHistory after expansion and multi-line
+			// assembly. The history file is left to HistoryManager, which locks
+			// it; readline would rewrite it under other processes.
+			DisableAutoSaveHistory: true,
+			// Shift+Tab is translated by shiftTabReader and toggles the mode;
+			// Ctrl+R is handled by reverseSearch instead of readline's built-in search
+			Stdin: readline.NewCancelableStdin(newShiftTabReader(readline.Stdin)),
+			FuncFilterInputRune: func(r rune) (rune, bool) {
+				if r == shiftTabRune {
+					c.handleShiftTab()
+					return r, false
+				}
+				return search.filterRune(r)
+			},
+			Listener:     search,
+			AutoComplete: c.completer,
 		}
 
 		var err error
 		c.readlineInstance, err = readline.NewEx(config)
 		if err != nil {
 			// Fall back to scanner on error
-			return c.getScannerInput()
+			return c.getScannerInput(continuation)
+		}
+		search.attach(c.readlineInstance)
+		c.search = search
+		for _, entry := range c.history.Entries() {
+			if !strings.Contains(entry, "\n") {
+				_ = c.readlineInstance.SaveHistory(entry)
+			}
 		}
 	}
+	c.readlineInstance.SetPrompt(prompt)
+	c.search.setPrompt(prompt)
 
 	// Use a goroutine to read input and support context cancellation
 	type result struct {
@@ -209,18 +347,37 @@ func (c *CLIAdapter) getInteractiveInput(ctx context.Context) (string, bool) {
 	}
 }
 
+// readConfirmation shows prompt and reads a Y/N answer, using readline in
+// interactive mode and bufio.Scanner otherwise. It returns the trimmed, lowercased
+// answer; EOF and Ctrl+C return an empty string, which callers treat as "no".
+func (c *CLIAdapter) readConfirmation(prompt string) string {
+	var input string
+	if c.useInteractive && c.historyFile != "" {
+		input = c.getInteractiveConfirmation(prompt)
+	} else {
+		fmt.Fprint(c.output, prompt)
+		if c.scanner == nil {
+			c.scanner = bufio.NewScanner(c.input)
+		}
+		if c.scanner.Scan() {
+			input = c.scanner.Text()
+		}
+	}
+	return strings.TrimSpace(strings.ToLower(input))
+}
+
 // getInteractiveConfirmation uses readline for Y/N confirmation in interactive mode.
 // Returns the user's input string (to be checked by caller).
 // Ctrl+C returns empty string, which is treated as "no" (safe default).
-func (c *CLIAdapter) getInteractiveConfirmation() string {
+func (c *CLIAdapter) getInteractiveConfirmation(prompt string) string {
 	// Create a simple readline instance for confirmation
 	rl, err := readline.NewEx(&readline.Config{
-		Prompt:          c.colors.Error + "Execute? [y/N]: " + "\x1b[0m",
+		Prompt:          c.colorize(c.colorScheme().Error, prompt),
 		InterruptPrompt: "^C",
 	})
 	if err != nil {
 		// Fall back to simple input
-		fmt.Fprint(c.output, "Execute? [y/N]: ")
+		fmt.Fprint(c.output, prompt)
 		if c.scanner == nil {
 			c.scanner = bufio.NewScanner(c.input)
 		}
@@ -239,13 +396,21 @@ func (c *CLIAdapter) getInteractiveConfirmation() string {
 }
 
 // getScannerInput uses bufio.Scanner for non-interactive input.
-func (c *CLIAdapter) getScannerInput() (string, bool) {
+// Continuation lines of a multi-line message use the "... " prompt.
+func (c *CLIAdapter) getScannerInput(continuation bool) (string, bool) {
 	if c.scanner == nil {
 		c.scanner = bufio.NewScanner(c.input)
 	}
 
 	// Display prompt
-	if _, err := fmt.Fprint(c.output, c.colors.Prompt+"Claude"+c.colors.Prompt+": "); err != nil {
+	color := c.colorScheme().Prompt
+	prompt := color + "Claude" + color + ": "
+	if continuation {
+		prompt = color + continuationPrompt
+	} else if c.IsPlanMode() {
+		prompt = color + planModePromptPrefix + prompt
+	}
+	if _, err := fmt.Fprint(c.output, prompt); err != nil {
 		return "", false
 	}
 
@@ -259,32 +424,84 @@ func (c *CLIAdapter) getScannerInput() (string, bool) {
 }
 
 // DisplayMessage displays a message with the specified role.
+// Assistant messages are rendered as Markdown when rendering is enabled.
 func (c *CLIAdapter) DisplayMessage(message string, messageRole string) error {
 	var color string
 
 	switch strings.ToLower(messageRole) {
 	case "user":
-		color = c.colors.User
+		color = c.colorScheme().User
 	case "assistant":
-		color = c.colors.Assistant
+		color = c.colorScheme().Assistant
 	case "system":
-		color = c.colors.System
+		color = c.colorScheme().System
 	default:
 		// Default to user color for unknown roles
-		color = c.colors.User
+		color = c.colorScheme().User
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, err := fmt.Fprintf(c.output, "%s%s\x1b[0m\n", color, message)
+	c.recordTranscriptLocked(strings.ToLower(messageRole), message)
+	if strings.EqualFold(messageRole, "assistant") {
+		c.lastReply = message
+	}
+	if c.renderMarkdown && c.colorEnabled() && strings.EqualFold(messageRole, "assistant") {
+		message = NewMarkdownRenderer(terminalWidth()).Render(message, color)
+	}
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
+	_, err := fmt.Fprint(c.output, c.colorize(color, message)+"\n")
 	return err
 }
 
+// SetMarkdownRendering enables or disables Markdown rendering of assistant messages.
+// Rendering is enabled by default when stdout is a terminal and NO_COLOR is unset.
+func (c *CLIAdapter) SetMarkdownRendering(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.renderMarkdown = enabled
+}
+
+// IsMarkdownRendering reports whether assistant messages are rendered as Markdown.
+func (c *CLIAdapter) IsMarkdownRendering() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.renderMarkdown
+}
+
+// RendersReplies implements port.ReplyRenderer: replies are rendered as
+// Markdown while rendering is enabled and colors are on.
+func (c *CLIAdapter) RendersReplies() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.renderMarkdown && c.colorEnabled()
+}
+
+// DisplayReply implements port.ReplyRenderer, displaying a reply collected
+// from the stream rendered as Markdown. The reply is kept as the streamed
+// response, for the transcript and notifications.
+func (c *CLIAdapter) DisplayReply(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopActivityLocked()
+	c.streamed.WriteString(text)
+	color := c.colorScheme().Assistant
+	if c.renderMarkdown && c.colorEnabled() {
+		text = NewMarkdownRenderer(terminalWidth()).Render(text, color)
+	}
+	if _, err := fmt.Fprint(c.output, c.colorize(color, text)); err != nil {
+		return err
+	}
+	return c.flushOutput()
+}
+
 // BeginStreamingResponse starts a streaming response with color setup.
 func (c *CLIAdapter) BeginStreamingResponse() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, err := fmt.Fprint(c.output, c.colors.Assistant)
+	c.streamed.Reset()
+	_, err := fmt.Fprint(c.output, c.colorScheme().Assistant)
 	return err
 }
 
@@ -292,16 +509,29 @@ func (c *CLIAdapter) BeginStreamingResponse() error {
 func (c *CLIAdapter) EndStreamingResponse() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, err := fmt.Fprint(c.output, "\x1b[0m\n")
+	c.stopActivityLocked()
+	// The transcript gets the streamed response as a single entry
+	c.recordTranscriptLocked("assistant", c.streamed.String())
+	c.lastReply = c.streamed.String()
+	c.streamed.Reset()
+	// colorize with no color emits just the reset, clearing any color left by the stream
+	_, err := fmt.Fprint(c.output, c.colorize("", "")+"\n")
 	return err
 }
 
 // DisplayStreamingText displays a chunk of streaming text without a newline.
 // This is used to show text as it arrives in real-time from the AI provider.
 // The text is displayed without color codes - the caller should handle color setup/teardown.
+// Any activity spinner is stopped so it never interleaves with streamed text.
 func (c *CLIAdapter) DisplayStreamingText(text string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.stopActivityLocked()
+	if !c.colorEnabled() {
+		// Callers may embed color codes in streamed text; drop them when colors are off
+		text = stripANSI(text)
+	}
+	c.streamed.WriteString(text)
 	// Use direct write to avoid any potential buffering from fmt package
 	_, err := c.output.Write([]byte(text))
 	if err != nil {
@@ -340,7 +570,10 @@ func (c *CLIAdapter) DisplayError(err error) error {
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, writeErr := fmt.Fprintf(c.output, "%sError: %s\x1b[0m\n", c.colors.Error, err.Error())
+	c.recordTranscriptLocked("error", err.Error())
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
+	_, writeErr := fmt.Fprint(c.output, c.colorize(c.colorScheme().Error, "Error: "+err.Error())+"\n")
 	if writeErr != nil {
 		return writeErr
 	}
@@ -352,66 +585,148 @@ func (c *CLIAdapter) DisplayError(err error) error {
 //
 // The bash tool receives special handling: its JSON output (containing stdout, stderr,
 // and exit_code fields) is parsed, and stdout/stderr are truncated independently
-// before the JSON is reassembled. Other tools use plain text truncation.
+// before the JSON is reassembled. Other JSON results keep their structure, with
+// long arrays and strings shortened (see TruncateJSONOutput), and the rest use
+// plain text truncation.
 //
-// File read operations (read_file, list_files) display compact indicators like
-// read(path) or list(path) instead of full contents to keep the screen clean.
+// The call is shown in the compact form of FormatToolCall, such as
+// edit(main.go, +12/-3), with secrets masked; see SetVerbose. File read
+// operations (read_file, list_files) show only the call, like read(path) or
+// list(path), instead of full contents to keep the screen clean.
 func (c *CLIAdapter) DisplayToolResult(toolName string, input string, result string) error {
+	return c.displayToolResult(toolName, input, result, "")
+}
+
+// DisplayCachedToolResult displays a tool result served from the tool result
+// cache like DisplayToolResult, marking its header with "(cached)".
+func (c *CLIAdapter) DisplayCachedToolResult(toolName string, input string, result string) error {
+	return c.displayToolResult(toolName, input, result, " "+c.colorize(ansiDim, "(cached)"))
+}
+
+// displayToolResult writes a tool result with marker appended to its header line.
+func (c *CLIAdapter) displayToolResult(toolName, input, result, marker string) error {
 	// Build output string before acquiring lock to minimize lock hold time.
-	// c.colors is safe to read without lock - it's set during initialization and never modified.
-	var output string
-
-	// Compact display for file/directory read operations
-	switch toolName {
-	case "read_file":
-		output = c.buildCompactFileReadOutput(input)
-	case "list_files":
-		output = c.buildCompactListFilesOutput(input)
-	default:
-		// Default behavior for other tools
-		truncatedResult := c.truncateToolOutput(toolName, result)
-		output = fmt.Sprintf("%sTool [%s] on %s\x1b[0m\n%s\x1b[0m\n",
-			c.colors.Tool, toolName, input, truncatedResult)
+	header := FormatToolCall(toolName, input)
+	if c.IsVerbose() {
+		header = fmt.Sprintf("Tool [%s] on %s", toolName, defaultRedactor.Redact(input))
+	}
+	output := c.colorize(c.colorScheme().Tool, header) + marker + "\n"
+
+	// File and directory reads show only the call, not the contents
+	if toolName != "read_file" && toolName != "list_files" {
+		output += c.colorize("", c.truncateToolOutput(toolName, result)) + "\n"
 	}
 
 	// Lock only for single atomic write
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.recordTranscriptLocked("tool", output)
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
 	_, err := c.output.Write([]byte(output))
 	return err
 }
 
+// SetVerbose sets whether tool calls are shown with their full input instead
+// of a compact summary such as bash(go test ./...). Secrets are masked either
+// way. Compact summaries are the default.
+func (c *CLIAdapter) SetVerbose(verbose bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verbose = verbose
+}
+
+// IsVerbose reports whether tool calls are shown with their full input.
+func (c *CLIAdapter) IsVerbose() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.verbose
+}
+
 // DisplaySystemMessage displays a system message.
 func (c *CLIAdapter) DisplaySystemMessage(message string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, err := fmt.Fprintf(c.output, "%sSystem: %s\x1b[0m\n", c.colors.System, message)
+	c.recordTranscriptLocked("system", message)
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
+	_, err := fmt.Fprint(c.output, c.colorize(c.colorScheme().System, "System: "+message)+"\n")
 	return err
 }
 
 // DisplayThinking displays extended thinking content from the AI.
-// Uses thinking color from the color scheme to distinguish from regular responses.
+// By default only a dimmed one-line summary such as "(thinking… 842 tokens)" is
+// shown; the full block is shown when thinking display is expanded (see
+// SetThinkingExpanded) or on demand with ExpandLastThinking.
 func (c *CLIAdapter) DisplayThinking(content string) error {
-	// Build output string before acquiring lock to minimize lock hold time.
-	// c.colors is safe to read without lock - it's set during initialization and never modified.
-	var buf strings.Builder
-	buf.WriteString(c.colors.Thinking + "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\x1b[0m\n")
-	buf.WriteString(c.colors.Thinking + "Claude is thinking...\x1b[0m\n")
-	buf.WriteString(c.colors.Thinking + "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\x1b[0m\n")
-	// Indent the thinking content for better visual separation
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		buf.WriteString(c.colors.Thinking + "  " + line + "\x1b[0m\n")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastThinking = content
+	c.recordTranscriptLocked("thinking", content)
+
+	output := c.colorize(ansiDim, fmt.Sprintf("(thinking… %d tokens)", entity.EstimateTokens(content))) + "\n"
+	if c.thinkingExpanded {
+		output = c.renderThinkingBlock(content)
 	}
-	buf.WriteString(c.colors.Thinking + "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\x1b[0m\n\n")
 
-	// Lock only for single atomic write
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
+	_, err := c.output.Write([]byte(output))
+	return err
+}
+
+// ExpandLastThinking displays the full content of the most recent thinking block.
+func (c *CLIAdapter) ExpandLastThinking() error {
+	c.mu.RLock()
+	content := c.lastThinking
+	c.mu.RUnlock()
+
+	if content == "" {
+		return c.DisplaySystemMessage("No thinking to show yet")
+	}
+
+	output := c.renderThinkingBlock(content)
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, err := c.output.Write([]byte(buf.String()))
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
+	_, err := c.output.Write([]byte(output))
 	return err
 }
 
+// SetThinkingExpanded sets whether DisplayThinking shows full thinking blocks
+// instead of one-line summaries. Summaries are the default.
+func (c *CLIAdapter) SetThinkingExpanded(expanded bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.thinkingExpanded = expanded
+}
+
+// IsThinkingExpanded reports whether full thinking blocks are displayed.
+func (c *CLIAdapter) IsThinkingExpanded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.thinkingExpanded
+}
+
+// renderThinkingBlock formats thinking content as an indented block between separators.
+// The colors are read once, so a concurrent SetColorEnabled cannot mix schemes within the block.
+func (c *CLIAdapter) renderThinkingBlock(content string) string {
+	const separator = "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+	thinking := c.colorScheme().Thinking
+	var buf strings.Builder
+	buf.WriteString(c.colorize(thinking, separator) + "\n")
+	buf.WriteString(c.colorize(thinking, "Claude is thinking...") + "\n")
+	buf.WriteString(c.colorize(thinking, separator) + "\n")
+	// Indent the thinking content for better visual separation
+	lines := strings.Split(content, "\n")
+	for _, line := range lines {
+		buf.WriteString(c.colorize(thinking, "  "+line) + "\n")
+	}
+	buf.WriteString(c.colorize(thinking, separator) + "\n\n")
+	return buf.String()
+}
+
 // DisplaySubagentStatus displays a status message for subagent execution.
 // Uses magenta color (ANSI code 35) to distinguish from regular system messages.
 func (c *CLIAdapter) DisplaySubagentStatus(agentName string, status string, details string) error {
@@ -422,8 +737,11 @@ func (c *CLIAdapter) DisplaySubagentStatus(agentName string, status string, deta
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.recordTranscriptLocked("subagent", msg)
+	c.clearActivityLocked()
+	defer c.drawActivityLocked()
 	// Magenta color for subagent status
-	_, err := fmt.Fprintf(c.output, "\x1b[35m%s\x1b[0m\n", msg)
+	_, err := fmt.Fprint(c.output, c.colorize(ansiMagenta, msg)+"\n")
 	return err
 }
 
@@ -444,6 +762,7 @@ func (c *CLIAdapter) ClearScreen() error {
 }
 
 // SetColorScheme sets the color scheme for the interface.
+// An explicit scheme takes precedence over NO_COLOR and terminal detection.
 func (c *CLIAdapter) SetColorScheme(scheme port.ColorScheme) error {
 	// Basic validation - ensure at least one color is set
 	if scheme.User == "" && scheme.Assistant == "" && scheme.System == "" &&
@@ -451,6 +770,8 @@ func (c *CLIAdapter) SetColorScheme(scheme port.ColorScheme) error {
 		return port.ErrInvalidColor
 	}
 
+	c.colorsMu.Lock()
+	defer c.colorsMu.Unlock()
 	// Only set non-empty fields (partial scheme support)
 	if scheme.User != "" {
 		c.colors.User = scheme.User
@@ -478,59 +799,37 @@ func (c *CLIAdapter) SetColorScheme(scheme port.ColorScheme) error {
 }
 
 // truncateToolOutput applies the appropriate truncation strategy based on tool type.
-// Bash tool output uses JSON-aware truncation; other tools use plain text truncation.
+// Bash tool output has its stdout and stderr truncated; other output that is a
+// JSON object or array uses JSON-aware truncation, eliding the fields registered
+// for the tool; anything else uses plain text truncation.
 func (c *CLIAdapter) truncateToolOutput(toolName, result string) string {
 	if toolName == "bash" {
 		truncated, _ := TruncateBashOutput(result, c.truncationConfig)
 		return truncated
 	}
+	if trimmed := strings.TrimSpace(result); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		opts := DefaultJSONTruncationOptions()
+		c.mu.RLock()
+		opts.ElideFields = c.jsonElidedFields[toolName]
+		c.mu.RUnlock()
+		truncated, _ := TruncateJSONOutput(result, c.truncationConfig, opts)
+		return truncated
+	}
 	truncated, _ := TruncateOutput(result, c.truncationConfig)
 	return truncated
 }
 
-// buildCompactFileReadOutput builds a compact indicator string for file read operations.
-// Shows "read(path)" or "read(path:start-end)" for line ranges.
-// Does not acquire any locks - safe to call before locking for output.
-func (c *CLIAdapter) buildCompactFileReadOutput(input string) string {
-	var readInput struct {
-		Path      string `json:"path"`
-		StartLine *int   `json:"start_line,omitempty"`
-		EndLine   *int   `json:"end_line,omitempty"`
-	}
-
-	if err := json.Unmarshal([]byte(input), &readInput); err != nil {
-		return fmt.Sprintf("%sread(%s)\x1b[0m\n", c.colors.Tool, input)
-	}
-
-	display := readInput.Path
-	if readInput.StartLine != nil || readInput.EndLine != nil {
-		start := 1
-		end := "end"
-		if readInput.StartLine != nil {
-			start = *readInput.StartLine
-		}
-		if readInput.EndLine != nil {
-			end = strconv.Itoa(*readInput.EndLine)
-		}
-		display = fmt.Sprintf("%s:%d-%s", readInput.Path, start, end)
-	}
-
-	return fmt.Sprintf("%sread(%s)\x1b[0m\n", c.colors.Tool, display)
-}
-
-// buildCompactListFilesOutput builds a compact indicator string for directory listing operations.
-// Shows "list(path)" instead of the full directory contents.
-// Does not acquire any locks - safe to call before locking for output.
-func (c *CLIAdapter) buildCompactListFilesOutput(input string) string {
-	var listInput struct {
-		Path string `json:"path"`
-	}
-
-	if err := json.Unmarshal([]byte(input), &listInput); err != nil {
-		return fmt.Sprintf("%slist(%s)\x1b[0m\n", c.colors.Tool, input)
+// RegisterJSONElidedFields registers object fields whose values are replaced
+// by a marker when toolName's JSON results are displayed, at any depth. Use it
+// for bulky fields that say little at a glance. Fields add to those already
+// registered for the tool.
+func (c *CLIAdapter) RegisterJSONElidedFields(toolName string, fields ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.jsonElidedFields == nil {
+		c.jsonElidedFields = make(map[string][]string)
 	}
-
-	return fmt.Sprintf("%slist(%s)\x1b[0m\n", c.colors.Tool, listInput.Path)
+	c.jsonElidedFields[toolName] = append(c.jsonElidedFields[toolName], fields...)
 }
 
 // SetTruncationConfig sets the truncation configuration for tool output display.
@@ -560,7 +859,7 @@ func (c *CLIAdapter) GetTruncationConfig() TruncationConfig {
 
 // IsTerminal checks if the given io.Reader is connected to a terminal.
 // It returns true if the reader is an *os.File that represents a terminal
-// (character device), false otherwise.
+// (a character device on Unix, a console on Windows), false otherwise.
 //
 // This is used to determine whether to use interactive input (go-prompt)
 // or non-interactive input (bufio.Scanner).
@@ -572,11 +871,15 @@ func IsTerminal(r io.Reader) bool {
 	if !ok {
 		return false
 	}
-	stat, err := f.Stat()
-	if err != nil {
-		return false
-	}
-	return (stat.Mode() & os.ModeCharDevice) != 0
+	return isTerminalFile(f)
+}
+
+// supportsANSI reports whether w is a terminal that renders ANSI escape
+// sequences. On Windows this turns on virtual terminal processing for the
+// console, and reports false for older consoles that lack it.
+func supportsANSI(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminalFile(f) && enableVirtualTerminal(f)
 }
 
 // IsInteractive returns whether the adapter is in interactive mode.
@@ -627,42 +930,35 @@ func (c *CLIAdapter) GetMaxHistoryEntries() int {
 // Returns true only if the user enters "y" or "yes" (case-insensitive).
 // Returns false for any other input, empty input, or EOF (safe default).
 func (c *CLIAdapter) ConfirmBashCommand(command string, isDangerous bool, reason string, description string) bool {
+	// The spinner would overwrite the confirmation prompt while waiting for input
+	c.StopActivity()
+
 	// Display header based on danger level
 	if isDangerous {
-		fmt.Fprintf(c.output, "%s[DANGEROUS COMMAND] %s\x1b[0m\n", c.colors.Error, reason)
+		fmt.Fprint(c.output, c.colorize(c.colorScheme().Error, "[DANGEROUS COMMAND] "+reason)+"\n")
 	}
 	// Display description if provided
 	if description != "" {
-		fmt.Fprintf(c.output, "%s\x1b[0m\n", description)
+		fmt.Fprint(c.output, c.colorize("", description)+"\n")
 	}
 	// Display standard prefix for non-dangerous commands
 	if !isDangerous {
-		fmt.Fprintf(c.output, "%s[BASH COMMAND]\x1b[0m\n", c.colors.System)
+		fmt.Fprint(c.output, c.colorize(c.colorScheme().System, "[BASH COMMAND]")+"\n")
 	}
 
 	// Display command in green with indentation
-	fmt.Fprintf(c.output, "  %s%s\x1b[0m\n", c.colors.Tool, command)
+	fmt.Fprint(c.output, "  "+c.colorize(c.colorScheme().Tool, command)+"\n")
 
-	var input string
+	c.requestAttention(AttentionInputNeeded, "Run "+command+"?")
+	input := c.readConfirmation("Execute? [y/N]: ")
+	approved := input == "y" || input == "yes"
 
-	// Use go-prompt for interactive mode, bufio.Scanner for non-interactive
-	if c.useInteractive && c.historyFile != "" {
-		// Interactive mode: use go-prompt to avoid stdin conflict
-		input = c.getInteractiveConfirmation()
-	} else {
-		// Non-interactive mode: use bufio.Scanner
-		fmt.Fprint(c.output, "Execute? [y/N]: ")
-		if c.scanner == nil {
-			c.scanner = bufio.NewScanner(c.input)
-		}
-		if !c.scanner.Scan() {
-			return false
-		}
-		input = c.scanner.Text()
+	decision := "denied"
+	if approved {
+		decision = "approved"
 	}
-
-	input = strings.TrimSpace(strings.ToLower(input))
-	return input == "y" || input == "yes"
+	c.recordTranscript("bash", command+" ("+decision+")")
+	return approved
 }
 
 // GetPromptPrefix returns the current prompt prefix string displayed before user input.
@@ -682,12 +978,35 @@ func (c *CLIAdapter) SetModeToggleCallback(callback func()) {
 }
 
 // SetSessionID sets the session ID for the adapter.
-// The session ID is displayed in the prompt when set.
+// The session ID is displayed in the prompt when set, and names the transcript
+// file when its path contains {session}.
 // Thread-safe for concurrent access.
 func (c *CLIAdapter) SetSessionID(sessionID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.sessionID = sessionID
+	if c.transcript != nil {
+		c.transcript.setSession(sessionID)
+	}
+}
+
+// SetSessionLabel sets the label of the active session, such as "2: fix the
+// tests", shown in brackets in the prompt while several sessions are open. An
+// empty label hides it. Thread-safe for concurrent access.
+func (c *CLIAdapter) SetSessionLabel(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionLabel = label
+}
+
+// sessionLabelPrefix returns the session label as a prompt prefix, or "".
+func (c *CLIAdapter) sessionLabelPrefix() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.sessionLabel == "" {
+		return ""
+	}
+	return "[" + c.sessionLabel + "] "
 }
 
 // SetPlanMode sets the plan mode state for the adapter.
@@ -699,18 +1018,46 @@ func (c *CLIAdapter) SetPlanMode(enabled bool) {
 	c.planMode = enabled
 }
 
+// IsPlanMode returns whether the adapter shows the plan mode indicator.
+// Thread-safe for concurrent reads.
+func (c *CLIAdapter) IsPlanMode() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.planMode
+}
+
+// inputPrompt returns the colorized prompt for interactive input, prefixed with
+// the session label when set and "[PLAN MODE]" while plan mode is enabled.
+func (c *CLIAdapter) inputPrompt(continuation bool) string {
+	if continuation {
+		return c.colorize(c.colorScheme().Prompt, continuationPrompt)
+	}
+	prompt := c.colorize(c.colorScheme().Prompt, "Claude: ")
+	if label := c.sessionLabelPrefix(); label != "" {
+		prompt = c.colorize(c.colorScheme().System, label) + prompt
+	}
+	if c.IsPlanMode() {
+		prompt = c.colorize(c.colorScheme().System, planModePromptPrefix) + prompt
+	}
+	return prompt
+}
+
 // GetPrompt returns the current prompt string with mode indicator if applicable.
 // The prompt format depends on the current plan mode and session ID:
 //   - Normal mode: "Claude> [sessionID]"
 //   - Plan mode: "[PLAN MODE] Claude> [sessionID]"
+//   - With a session label: "[PLAN MODE] [label] Claude> [sessionID]"
 //
 // Thread-safe for concurrent reads.
 func (c *CLIAdapter) GetPrompt() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	result := c.prompt
+	if c.sessionLabel != "" {
+		result = "[" + c.sessionLabel + "] " + result
+	}
 	if c.planMode {
-		result = "[PLAN MODE] " + result
+		result = planModePromptPrefix + result
 	}
 	if c.sessionID != "" {
 		result = result + " [" + c.sessionID + "]"
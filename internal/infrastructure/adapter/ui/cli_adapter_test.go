@@ -266,8 +266,7 @@ func TestCLIAdapter_DisplayToolResult(t *testing.T) {
 		require.NoError(t, err)
 		outputStr := output.String()
 		assert.Contains(t, outputStr, "\x1b[92m", "should contain green color code for tool")
-		assert.Contains(t, outputStr, "Tool [bash]", "should contain tool name in brackets")
-		assert.Contains(t, outputStr, "echo hello", "should contain input")
+		assert.Contains(t, outputStr, "bash(echo hello)", "should contain the compact tool call")
 		assert.Contains(t, outputStr, "hello", "should contain result")
 		assert.Contains(t, outputStr, "\x1b[0m", "should reset color")
 	})
@@ -281,7 +280,7 @@ func TestCLIAdapter_DisplayToolResult(t *testing.T) {
 
 		require.NoError(t, err)
 		outputStr := output.String()
-		assert.Contains(t, outputStr, "Tool []", "should show empty tool name in brackets")
+		assert.Contains(t, outputStr, "(input)", "should show the input without a tool name")
 	})
 
 	t.Run("handles empty input path", func(t *testing.T) {
@@ -293,7 +292,7 @@ func TestCLIAdapter_DisplayToolResult(t *testing.T) {
 
 		require.NoError(t, err)
 		outputStr := output.String()
-		assert.Contains(t, outputStr, "Tool [bash]", "should display tool name")
+		assert.Contains(t, outputStr, "bash()", "should display tool name")
 		assert.Contains(t, outputStr, "result", "should display result")
 	})
 
@@ -306,7 +305,7 @@ func TestCLIAdapter_DisplayToolResult(t *testing.T) {
 
 		require.NoError(t, err)
 		outputStr := output.String()
-		assert.Contains(t, outputStr, "Tool [bash]", "should display tool name for empty result")
+		assert.Contains(t, outputStr, "bash(input)", "should display tool name for empty result")
 	})
 
 	t.Run("handles multiline result", func(t *testing.T) {
@@ -628,7 +627,7 @@ func TestCLIAdapter_IntegrationScenarios(t *testing.T) {
 		// Verify all parts are present
 		assert.Contains(t, outputStr, "Hello AI")
 		assert.Contains(t, outputStr, "Hello! How can I help?")
-		assert.Contains(t, outputStr, "Tool [bash]")
+		assert.Contains(t, outputStr, "bash(echo test)")
 		assert.Contains(t, outputStr, "System:")
 	})
 
@@ -1340,7 +1339,7 @@ func TestCLIAdapter_DisplayToolResult_CompactDisplay(t *testing.T) {
 		assert.Contains(t, outputStr, "list(not valid json)")
 	})
 
-	t.Run("other tools still show full output", func(t *testing.T) {
+	t.Run("other tools show the compact call and the result", func(t *testing.T) {
 		input := strings.NewReader("")
 		output := &strings.Builder{}
 		adapter := ui.NewCLIAdapterWithIO(input, output)
@@ -1349,11 +1348,47 @@ func TestCLIAdapter_DisplayToolResult_CompactDisplay(t *testing.T) {
 
 		require.NoError(t, err)
 		outputStr := output.String()
-		assert.Contains(t, outputStr, "Tool [edit_file]")
+		assert.Contains(t, outputStr, "edit(file.go, +0/-0)")
 		assert.Contains(t, outputStr, "edit result content")
 	})
 }
 
+// Compile-time check that CLIAdapter can mark cached tool results.
+var _ port.CachedToolResultDisplay = (*ui.CLIAdapter)(nil)
+
+func TestCLIAdapter_DisplayCachedToolResult(t *testing.T) {
+	t.Run("compact read_file output is marked cached", func(t *testing.T) {
+		output := &strings.Builder{}
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+		err := adapter.DisplayCachedToolResult("read_file", `{"path": "src/main.go"}`, "file contents here...")
+
+		require.NoError(t, err)
+		assert.Contains(t, output.String(), "read(src/main.go)")
+		assert.Contains(t, output.String(), "(cached)")
+		assert.Equal(t, 1, strings.Count(output.String(), "\n"), "the marker should stay on the read line")
+	})
+
+	t.Run("other tools mark their header line", func(t *testing.T) {
+		output := &strings.Builder{}
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+		err := adapter.DisplayCachedToolResult("fetch", `{"url": "https://example.com"}`, "page")
+
+		require.NoError(t, err)
+		header, _, _ := strings.Cut(output.String(), "\n")
+		assert.Contains(t, header, "(cached)")
+	})
+
+	t.Run("uncached results are not marked", func(t *testing.T) {
+		output := &strings.Builder{}
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+		require.NoError(t, adapter.DisplayToolResult("read_file", `{"path": "src/main.go"}`, "contents"))
+		assert.NotContains(t, output.String(), "(cached)")
+	})
+}
+
 func TestCLIAdapter_SetTruncationConfig(t *testing.T) {
 	// Test that truncation config can be set and affects truncation behavior
 	// This test will fail because SetTruncationConfig method does not exist
@@ -2071,3 +2106,23 @@ func TestCLIAdapter_GetUserInput_InteractiveMode(t *testing.T) {
 		_ = ok // Result depends on implementation
 	})
 }
+
+func TestCLIAdapter_DisplayToolResult_JSON(t *testing.T) {
+	output := &strings.Builder{}
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+	adapter.RegisterJSONElidedFields("mcp__k8s__get", "managedFields")
+
+	items := make([]string, 40)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"name":"pod-%d","managedFields":[{"manager":"kubectl"}]}`, i+1)
+	}
+	require.NoError(t, adapter.DisplayToolResult("mcp__k8s__get", "{}", "["+strings.Join(items, ",")+"]"))
+
+	got := output.String()
+	assert.Contains(t, got, `"name": "pod-1"`)
+	assert.Contains(t, got, "… 25 items omitted …")
+	assert.Contains(t, got, `"name": "pod-40"`)
+	assert.NotContains(t, got, `"name": "pod-20"`)
+	assert.Contains(t, got, `"managedFields": "… elided …"`)
+	assert.NotContains(t, got, "kubectl")
+}
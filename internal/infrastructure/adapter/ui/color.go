@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"io"
+	"os"
+)
+
+// ANSI color codes used outside of the configurable color scheme.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiMagenta = "\x1b[35m"
+)
+
+// noColorRequested reports whether the NO_COLOR environment variable is set to a
+// non-empty value (see https://no-color.org).
+func noColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// supportsColor reports whether ANSI styling should be written to w:
+// NO_COLOR must be unset and w must be a terminal that renders ANSI sequences.
+func supportsColor(w io.Writer) bool {
+	return !noColorRequested() && supportsANSI(w)
+}
+
+// detectColorScheme returns the default color scheme when w supports color,
+// and an empty scheme (plain text) otherwise.
+func detectColorScheme(w io.Writer) port.ColorScheme {
+	if supportsColor(w) {
+		return defaultColorScheme()
+	}
+	return port.ColorScheme{}
+}
+
+// colorEnabled reports whether any color is configured. Colors are disabled by
+// an empty color scheme, which SetColorScheme never produces.
+func (c *CLIAdapter) colorEnabled() bool {
+	return c.colorScheme() != port.ColorScheme{}
+}
+
+// colorScheme returns the current color scheme under colorsMu.
+func (c *CLIAdapter) colorScheme() port.ColorScheme {
+	c.colorsMu.RLock()
+	defer c.colorsMu.RUnlock()
+	return c.colors
+}
+
+// colorize wraps text in color followed by a reset. When colors are disabled it
+// returns text with any ANSI sequences removed, so no escape codes reach output
+// that is piped to a file. All colored output goes through this helper.
+func (c *CLIAdapter) colorize(color, text string) string {
+	if !c.colorEnabled() {
+		return stripANSI(text)
+	}
+	return color + text + ansiReset
+}
+
+// SetColorEnabled turns colored output on (with the default color scheme) or off.
+// Colors are enabled by default only when stdout is a terminal and NO_COLOR is unset.
+// A later SetColorScheme call re-enables the colors it sets.
+func (c *CLIAdapter) SetColorEnabled(enabled bool) {
+	c.colorsMu.Lock()
+	defer c.colorsMu.Unlock()
+	if enabled {
+		c.colors = defaultColorScheme()
+		return
+	}
+	c.colors = port.ColorScheme{}
+}
@@ -0,0 +1,128 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// displayEverything writes one of each kind of output through the adapter.
+func displayEverything(t *testing.T, adapter *ui.CLIAdapter) {
+	t.Helper()
+	require.NoError(t, adapter.DisplayMessage("# hello **there**", "assistant"))
+	require.NoError(t, adapter.DisplayMessage("user text", "user"))
+	require.NoError(t, adapter.DisplayError(errors.New("boom")))
+	require.NoError(t, adapter.DisplayToolResult("bash", `{"command":"ls"}`, "\x1b[31mcolored ls output\x1b[0m"))
+	require.NoError(t, adapter.DisplayToolResult("read_file", `{"path":"a.go"}`, "contents"))
+	require.NoError(t, adapter.DisplaySystemMessage("system text"))
+	require.NoError(t, adapter.DisplayThinking("pondering"))
+	require.NoError(t, adapter.DisplaySubagentStatus("helper", "Starting", ""))
+	adapter.OnSubagentEvent(port.SubagentEvent{Type: port.SubagentEventStarted, AgentName: "helper"})
+	require.NoError(t, adapter.BeginStreamingResponse())
+	require.NoError(t, adapter.DisplayStreamingText("\x1b[0m\x1b[93mstreamed"))
+	require.NoError(t, adapter.EndStreamingResponse())
+}
+
+func TestCLIAdapter_NoColor_EmitsNoEscapeBytes(t *testing.T) {
+	tests := []struct {
+		name       string
+		noColorEnv string
+		disable    bool
+	}{
+		{name: "NO_COLOR environment variable", noColorEnv: "1"},
+		{name: "colors disabled explicitly", disable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColorEnv)
+			var output bytes.Buffer
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+			adapter.SetMarkdownRendering(true)
+			if tt.disable {
+				adapter.SetColorEnabled(false)
+			}
+
+			displayEverything(t, adapter)
+
+			assert.NotContains(t, output.String(), "\x1b", "output should contain no escape sequences")
+			for _, want := range []string{
+				"# hello **there**", "Error: boom", "bash(ls)", "colored ls output",
+				"read(a.go)", "System: system text", "(thinking… 3 tokens)", "[SUBAGENT: helper] Starting",
+				"[helper] started", "streamed",
+			} {
+				assert.Contains(t, output.String(), want)
+			}
+		})
+	}
+}
+
+func TestCLIAdapter_NoColor_ConfirmationReadable(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("y\n"), &output)
+
+	confirmed := adapter.ConfirmBashCommand("rm -rf build", true, "destructive rm command", "Clean the build")
+
+	assert.True(t, confirmed)
+	assert.Equal(t,
+		"[DANGEROUS COMMAND] destructive rm command\n"+
+			"Clean the build\n"+
+			"  rm -rf build\n"+
+			"Execute? [y/N]: ",
+		output.String())
+}
+
+func TestCLIAdapter_SetColorScheme_WinsOverNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+
+	require.NoError(t, adapter.SetColorScheme(port.ColorScheme{System: "\x1b[96m"}))
+	require.NoError(t, adapter.DisplaySystemMessage("hello"))
+
+	assert.Equal(t, "\x1b[96mSystem: hello\x1b[0m\n", output.String())
+}
+
+func TestCLIAdapter_SetColorEnabled_RestoresDefaults(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+
+	adapter.SetColorEnabled(true)
+	require.NoError(t, adapter.DisplayError(errors.New("boom")))
+
+	assert.Equal(t, "\x1b[91mError: boom\x1b[0m\n", output.String())
+}
+
+// TestCLIAdapter_SetColorEnabled_ConcurrentOutput toggles colors while output
+// is written; run with -race.
+func TestCLIAdapter_SetColorEnabled_ConcurrentOutput(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	adapter.SetThinkingExpanded(true)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			adapter.SetColorEnabled(i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			assert.NoError(t, adapter.DisplayThinking("checking the logs"))
+			assert.NoError(t, adapter.DisplayToolResult("bash", `{"command":"ls"}`, "main.go"))
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for file path completion.
+const (
+	// defaultPathCacheTTL is how long a directory listing is reused.
+	defaultPathCacheTTL = 2 * time.Second
+
+	// pathListingWait is how long a completion waits for an uncached directory
+	// listing before giving up; the listing finishes in the background and is
+	// used by the next Tab press.
+	pathListingWait = 50 * time.Millisecond
+)
+
+// Suggestion is a completion candidate.
+type Suggestion struct {
+	// Text is the completed word, replacing the partial word being typed.
+	Text string
+	// Description optionally explains the suggestion.
+	Description string
+}
+
+// CompletionFunc returns the suggestions for a partial word. The partial word
+// excludes the trigger the function was registered for.
+type CompletionFunc func(prefix string) []Suggestion
+
+// completionSource is a registered completion function and its trigger.
+type completionSource struct {
+	trigger string
+	fn      CompletionFunc
+}
+
+// Completer provides Tab completion for interactive input from pluggable sources.
+//
+// A source is registered for a trigger, and is consulted for the word under the
+// cursor as follows:
+//
+//   - A trigger ending in a space (e.g. ":mode ") matches when the line starts
+//     with it and the cursor is in the word that follows; that word is the prefix.
+//   - Any other trigger (e.g. ":" or "@") matches when the current word starts
+//     with it; the rest of the word is the prefix.
+//
+// When several triggers match, the longest wins. Completer implements
+// readline.AutoCompleter and is safe for concurrent use.
+type Completer struct {
+	mu      sync.RWMutex
+	sources []completionSource
+}
+
+// NewCompleter creates a Completer with no sources.
+func NewCompleter() *Completer {
+	return &Completer{}
+}
+
+// Register adds a completion source for trigger, replacing any existing source
+// for the same trigger.
+func (c *Completer) Register(trigger string, fn CompletionFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range c.sources {
+		if s.trigger == trigger {
+			c.sources[i].fn = fn
+			return
+		}
+	}
+	c.sources = append(c.sources, completionSource{trigger: trigger, fn: fn})
+}
+
+// Complete returns the partial word being completed at the end of line (the
+// text before the cursor) and the suggestions for it, sorted by text.
+// Suggestions that do not extend the partial word are dropped.
+func (c *Completer) Complete(line string) (string, []Suggestion) {
+	word := line[strings.LastIndexAny(line, " \t")+1:]
+
+	c.mu.RLock()
+	var best completionSource
+	for _, s := range c.sources {
+		if len(s.trigger) <= len(best.trigger) {
+			continue
+		}
+		if strings.HasSuffix(s.trigger, " ") {
+			if line == s.trigger+word {
+				best = s
+			}
+		} else if strings.HasPrefix(word, s.trigger) {
+			best = s
+		}
+	}
+	c.mu.RUnlock()
+
+	if best.fn == nil {
+		return word, nil
+	}
+
+	partial := word
+	if !strings.HasSuffix(best.trigger, " ") {
+		partial = strings.TrimPrefix(word, best.trigger)
+	}
+
+	var suggestions []Suggestion
+	for _, s := range best.fn(partial) {
+		if strings.HasPrefix(s.Text, partial) && s.Text != partial {
+			suggestions = append(suggestions, s)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Text < suggestions[j].Text })
+	return partial, suggestions
+}
+
+// Do implements readline.AutoCompleter.
+func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
+	partial, suggestions := c.Complete(string(line[:pos]))
+
+	candidates := make([][]rune, 0, len(suggestions))
+	for _, s := range suggestions {
+		candidates = append(candidates, []rune(s.Text[len(partial):]))
+	}
+	return candidates, len([]rune(partial))
+}
+
+// StaticCompletion returns a CompletionFunc that suggests from a fixed list of words.
+func StaticCompletion(words ...string) CompletionFunc {
+	return func(prefix string) []Suggestion {
+		var suggestions []Suggestion
+		for _, w := range words {
+			if strings.HasPrefix(w, prefix) {
+				suggestions = append(suggestions, Suggestion{Text: w})
+			}
+		}
+		return suggestions
+	}
+}
+
+// dirListing is a cached directory listing.
+type dirListing struct {
+	entries []Suggestion
+	loaded  time.Time
+	done    chan struct{}
+}
+
+// pathCompleter completes file paths relative to a workspace root, caching
+// directory listings so repeated Tab presses do not touch the disk.
+type pathCompleter struct {
+	root  string
+	ttl   time.Duration
+	mu    sync.Mutex
+	cache map[string]*dirListing
+}
+
+// NewPathCompletion returns a CompletionFunc for file paths relative to root.
+// Directories are suggested with a trailing slash, and hidden entries only when
+// the partial name starts with a dot. Paths outside root are not completed.
+//
+// Listings are cached for a short time. A listing that is not cached is read in
+// the background; if it takes longer than a few milliseconds the completion
+// returns nothing rather than blocking input, and the next Tab press uses it.
+func NewPathCompletion(root string) CompletionFunc {
+	p := &pathCompleter{root: root, ttl: defaultPathCacheTTL, cache: make(map[string]*dirListing)}
+	return p.complete
+}
+
+// complete implements CompletionFunc.
+func (p *pathCompleter) complete(prefix string) []Suggestion {
+	dir, base := "", prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir, base = prefix[:i+1], prefix[i+1:]
+	}
+	if filepath.IsAbs(dir) || strings.HasPrefix(filepath.Clean(filepath.Join(".", dir)), "..") {
+		return nil
+	}
+
+	listing := p.listing(dir)
+	select {
+	case <-listing.done:
+	case <-time.After(pathListingWait):
+		return nil
+	}
+
+	var suggestions []Suggestion
+	for _, entry := range listing.entries {
+		if strings.HasPrefix(entry.Text, base) && (strings.HasPrefix(base, ".") || !strings.HasPrefix(entry.Text, ".")) {
+			suggestions = append(suggestions, Suggestion{Text: dir + entry.Text, Description: entry.Description})
+		}
+	}
+	return suggestions
+}
+
+// listing returns the cached listing of dir, starting a background read when it
+// is missing or stale.
+func (p *pathCompleter) listing(dir string) *dirListing {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if l, ok := p.cache[dir]; ok {
+		select {
+		case <-l.done:
+			if time.Since(l.loaded) < p.ttl {
+				return l
+			}
+		default:
+			// Still loading
+			return l
+		}
+	}
+
+	l := &dirListing{done: make(chan struct{})}
+	p.cache[dir] = l
+	go func() {
+		defer close(l.done)
+		defer func() { l.loaded = time.Now() }()
+
+		// An unreadable directory is cached as empty until the entry expires
+		entries, _ := os.ReadDir(filepath.Join(p.root, dir))
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() {
+				name += "/"
+			}
+			l.entries = append(l.entries, Suggestion{Text: name})
+		}
+	}()
+	return l
+}
+
+// RegisterCompleter adds a Tab completion source for interactive input; see
+// Completer for how trigger is matched. For example, RegisterCompleter("@",
+// NewPathCompletion(".")) completes file mentions such as "@internal/do".
+func (c *CLIAdapter) RegisterCompleter(trigger string, fn CompletionFunc) {
+	c.completer.Register(trigger, fn)
+}
+
+// Completer returns the completer used for Tab completion in interactive mode.
+func (c *CLIAdapter) Completer() *Completer {
+	return c.completer
+}
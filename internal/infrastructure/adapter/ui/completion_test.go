@@ -0,0 +1,103 @@
+package ui_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func suggestionTexts(suggestions []ui.Suggestion) []string {
+	var texts []string
+	for _, s := range suggestions {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+func newTestCompleter(t *testing.T) *ui.Completer {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "domain"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "internal", "doc.go"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), nil, 0o600))
+
+	completer := ui.NewCompleter()
+	completer.Register(":", ui.StaticCompletion("mode", "thinking", "q"))
+	completer.Register(":mode ", ui.StaticCompletion("plan", "normal", "toggle"))
+	completer.Register("@", ui.NewPathCompletion(root))
+	return completer
+}
+
+func TestCompleter_Complete(t *testing.T) {
+	completer := newTestCompleter(t)
+
+	tests := []struct {
+		name        string
+		line        string
+		wantPartial string
+		want        []string
+	}{
+		{name: "all commands", line: ":", wantPartial: "", want: []string{"mode", "q", "thinking"}},
+		{name: "command prefix", line: ":th", wantPartial: "th", want: []string{"thinking"}},
+		{name: "complete command has no suggestions", line: ":mode", wantPartial: "mode", want: nil},
+		{name: "command argument", line: ":mode p", wantPartial: "p", want: []string{"plan"}},
+		{name: "all command arguments", line: ":mode ", wantPartial: "", want: []string{"normal", "plan", "toggle"}},
+		{name: "argument trigger only at line start", line: "say :mode p", wantPartial: "p", want: nil},
+		{name: "file mention at root", line: "look at @m", wantPartial: "m", want: []string{"main.go"}},
+		{name: "directories get a trailing slash", line: "@int", wantPartial: "int", want: []string{"internal/"}},
+		{name: "nested path", line: "@internal/do", wantPartial: "internal/do", want: []string{
+			"internal/doc.go", "internal/docs/", "internal/domain/",
+		}},
+		{name: "hidden files need a dot", line: "@", wantPartial: "", want: []string{"internal/", "main.go"}},
+		{name: "hidden files with a dot", line: "@.e", wantPartial: ".e", want: []string{".env"}},
+		{name: "paths outside the workspace", line: "@../", wantPartial: "../", want: nil},
+		{name: "missing directory", line: "@nope/", wantPartial: "nope/", want: nil},
+		{name: "plain words", line: "hello wor", wantPartial: "wor", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partial, suggestions := completer.Complete(tt.line)
+			assert.Equal(t, tt.wantPartial, partial)
+			assert.Equal(t, tt.want, suggestionTexts(suggestions))
+		})
+	}
+}
+
+func TestCompleter_Do_ReturnsSuffixes(t *testing.T) {
+	completer := newTestCompleter(t)
+
+	line := []rune("@internal/dom and more")
+	candidates, length := completer.Do(line, len("@internal/dom"))
+
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "ain/", string(candidates[0]))
+	assert.Equal(t, len("internal/dom"), length)
+}
+
+func TestCompleter_RegisterReplacesTrigger(t *testing.T) {
+	completer := ui.NewCompleter()
+	completer.Register(":", ui.StaticCompletion("old"))
+	completer.Register(":", ui.StaticCompletion("new"))
+
+	_, suggestions := completer.Complete(":")
+	assert.Equal(t, []string{"new"}, suggestionTexts(suggestions))
+}
+
+func TestPathCompletion_CachesListings(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), nil, 0o600))
+	complete := ui.NewPathCompletion(root)
+
+	assert.Equal(t, []string{"a.txt"}, suggestionTexts(complete("")))
+
+	// A file created within the cache TTL is not listed yet
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), nil, 0o600))
+	assert.Equal(t, []string{"a.txt"}, suggestionTexts(complete("")))
+}
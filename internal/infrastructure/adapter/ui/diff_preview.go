@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultDiffPreviewMaxLines is the default number of diff lines shown before
+// the remaining hunks are summarized.
+const defaultDiffPreviewMaxLines = 200
+
+// ConfirmFileEdit implements port.UserInterface by showing the proposed change
+// to path as a colorized unified diff and asking "Apply? [y/N]".
+//
+// Additions are shown in the tool color (green), deletions in the error color
+// (red), and hunk headers in the system color. Diffs longer than the preview
+// limit (see SetDiffPreviewMaxLines) show whole hunks up to the limit followed
+// by a count of the hunks left out.
+//
+// Returns true only if the user enters "y" or "yes" (case-insensitive).
+// Returns false for any other input, empty input, or EOF (safe default).
+func (c *CLIAdapter) ConfirmFileEdit(path string, unifiedDiff string) bool {
+	// The spinner would overwrite the confirmation prompt while waiting for input
+	c.StopActivity()
+
+	fmt.Fprint(c.output, c.colorize(c.colorScheme().System, "[FILE EDIT] "+path)+"\n")
+	fmt.Fprint(c.output, c.renderDiff(unifiedDiff))
+
+	c.requestAttention(AttentionInputNeeded, "Apply the edit to "+path+"?")
+
+	input := c.readConfirmation("Apply? [y/N]: ")
+	approved := input == "y" || input == "yes"
+
+	decision := "denied"
+	if approved {
+		decision = "approved"
+	}
+	c.recordTranscript("edit", path+" ("+decision+")\n"+unifiedDiff)
+	return approved
+}
+
+// SetDiffPreviewMaxLines sets how many diff lines ConfirmFileEdit shows before
+// summarizing the remaining hunks. A non-positive value restores the default of 200.
+func (c *CLIAdapter) SetDiffPreviewMaxLines(maxLines int) {
+	if maxLines <= 0 {
+		maxLines = defaultDiffPreviewMaxLines
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diffPreviewMaxLines = maxLines
+}
+
+// renderDiff colorizes a unified diff, truncating it to the preview limit.
+func (c *CLIAdapter) renderDiff(unifiedDiff string) string {
+	c.mu.RLock()
+	maxLines := c.diffPreviewMaxLines
+	c.mu.RUnlock()
+	if maxLines <= 0 {
+		maxLines = defaultDiffPreviewMaxLines
+	}
+
+	header, hunks := splitDiffHunks(unifiedDiff)
+
+	var buf strings.Builder
+	for _, line := range header {
+		buf.WriteString(c.colorize(ansiBold, line) + "\n")
+	}
+
+	shown := 0
+	for i, h := range hunks {
+		if shown > 0 && shown+len(h) > maxLines {
+			buf.WriteString(c.colorize(ansiDim, fmt.Sprintf("... %d more hunks not shown", len(hunks)-i)) + "\n")
+			break
+		}
+		for j, line := range h {
+			// A single hunk longer than the limit is cut short
+			if shown == maxLines {
+				buf.WriteString(c.colorize(ansiDim, fmt.Sprintf("... %d more lines in this hunk", len(h)-j)) + "\n")
+				break
+			}
+			buf.WriteString(c.colorizeDiffLine(line) + "\n")
+			shown++
+		}
+	}
+	return buf.String()
+}
+
+// colorizeDiffLine colors a diff body line by its kind.
+func (c *CLIAdapter) colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return c.colorize(c.colorScheme().System, line)
+	case strings.HasPrefix(line, "+"):
+		return c.colorize(c.colorScheme().Tool, line)
+	case strings.HasPrefix(line, "-"):
+		return c.colorize(c.colorScheme().Error, line)
+	default:
+		return c.colorize("", line)
+	}
+}
+
+// splitDiffHunks splits a unified diff into its file header lines and hunks,
+// each hunk starting with its "@@" header line.
+func splitDiffHunks(unifiedDiff string) ([]string, [][]string) {
+	var header []string
+	var hunks [][]string
+	for _, line := range strings.Split(strings.TrimSuffix(unifiedDiff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, []string{line})
+		case len(hunks) > 0:
+			hunks[len(hunks)-1] = append(hunks[len(hunks)-1], line)
+		case line != "":
+			header = append(header, line)
+		}
+	}
+	return header, hunks
+}
@@ -0,0 +1,89 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleDiff = "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n package main\n-var x = 1\n+var x = 2\n func main() {}\n"
+
+func TestCLIAdapter_ConfirmFileEdit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.input), func(t *testing.T) {
+			var output bytes.Buffer
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(tt.input), &output)
+
+			assert.Equal(t, tt.want, adapter.ConfirmFileEdit("main.go", sampleDiff))
+			assert.Contains(t, output.String(), "Apply? [y/N]: ")
+		})
+	}
+}
+
+func TestCLIAdapter_ConfirmFileEdit_ColorizesDiff(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("n\n"), &output)
+
+	adapter.ConfirmFileEdit("main.go", sampleDiff)
+
+	out := output.String()
+	assert.Contains(t, out, "\x1b[92m+var x = 2\x1b[0m", "additions should be green")
+	assert.Contains(t, out, "\x1b[91m-var x = 1\x1b[0m", "deletions should be red")
+	assert.Contains(t, out, "\x1b[96m@@ -1,3 +1,3 @@\x1b[0m", "hunk headers should be cyan")
+	assert.Contains(t, out, "[FILE EDIT] main.go")
+}
+
+func TestCLIAdapter_ConfirmFileEdit_TruncatesLargeDiffs(t *testing.T) {
+	var diff strings.Builder
+	diff.WriteString("--- a/big.txt\n+++ b/big.txt\n")
+	for i := range 10 {
+		fmt.Fprintf(&diff, "@@ -%d,1 +%d,1 @@\n-old %d\n+new %d\n", i*10+1, i*10+1, i, i)
+	}
+
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("n\n"), &output)
+	adapter.SetColorEnabled(false)
+	adapter.SetDiffPreviewMaxLines(9)
+
+	adapter.ConfirmFileEdit("big.txt", diff.String())
+
+	out := output.String()
+	assert.Contains(t, out, "+new 2\n")
+	assert.NotContains(t, out, "new 3")
+	assert.Contains(t, out, "... 7 more hunks not shown")
+}
+
+func TestCLIAdapter_ConfirmFileEdit_TruncatesLongHunk(t *testing.T) {
+	var diff strings.Builder
+	diff.WriteString("--- /dev/null\n+++ b/big.txt\n@@ -0,0 +1,50 @@\n")
+	for i := range 50 {
+		fmt.Fprintf(&diff, "+line %d\n", i)
+	}
+
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("n\n"), &output)
+	adapter.SetColorEnabled(false)
+	adapter.SetDiffPreviewMaxLines(10)
+
+	adapter.ConfirmFileEdit("big.txt", diff.String())
+
+	out := output.String()
+	assert.Contains(t, out, "+line 8\n")
+	assert.NotContains(t, out, "+line 9\n")
+	assert.Contains(t, out, "... 41 more lines in this hunk")
+}
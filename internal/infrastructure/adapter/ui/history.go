@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNotInteractive is returned by history search when the adapter is not in interactive mode.
+	ErrNotInteractive = errors.New("history search requires interactive mode")
+
+	// ErrHistoryEventNotFound is returned when a !! or !<prefix> expansion matches no history entry.
+	ErrHistoryEventNotFound = errors.New("event not found")
+)
+
+// historyCompactionFactor is how many times maxEntries lines the history file
+// may hold before it is compacted to maxEntries.
+const historyCompactionFactor = 2
+
+// HistoryManager keeps the user's previous inputs for reverse search and
+// history expansion. Entries are ordered oldest first.
+//
+// With a history file set, entries are appended to it under an advisory file
+// lock, so several agent processes can share one file. Entries the other
+// processes appended are merged in before each append.
+//
+// HistoryManager is safe for concurrent use.
+type HistoryManager struct {
+	mu         sync.RWMutex
+	entries    []string
+	maxEntries int
+
+	path     string      // History file entries are appended to, or "" to keep them in memory
+	fileInfo os.FileInfo // The history file as last read, to notice it being replaced by compaction
+	offset   int64       // Bytes of the history file read so far
+	lines    int         // Entries in the history file
+}
+
+// NewHistoryManager creates an empty history that keeps at most maxEntries entries.
+// A non-positive maxEntries uses the default of 100.
+func NewHistoryManager(maxEntries int) *HistoryManager {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxHistoryEntries
+	}
+	return &HistoryManager{maxEntries: maxEntries}
+}
+
+// Load appends the entries from a history file (one entry per line, as written
+// by readline). A missing file is not an error.
+func (h *HistoryManager) Load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.Add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+	return nil
+}
+
+// SetFile replaces the entries with those of the history file at path and
+// appends later entries to it. The file is created on the first Add if it
+// does not exist; the lock is taken on path plus ".lock", and is not created
+// until there is a file to read or write.
+func (h *HistoryManager) SetFile(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.path = path
+	h.entries = nil
+	h.fileInfo, h.offset, h.lines = nil, 0, 0
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return h.withFileLock(func() error {
+		if err := h.syncLocked(); err != nil {
+			return err
+		}
+		return h.compactLocked()
+	})
+}
+
+// Add records an entry. Blank entries and repeats of the newest entry are skipped,
+// and the oldest entries are dropped beyond the configured limit.
+//
+// With a history file set, single-line entries are also appended to it, after
+// merging in the entries other processes appended. Multi-line entries, and
+// entries that cannot be written, are only kept in memory.
+func (h *HistoryManager) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.path != "" && !strings.Contains(entry, "\n") {
+		if err := h.appendLocked(entry); err == nil {
+			return
+		}
+	}
+	h.addLocked(entry)
+}
+
+// addLocked records an entry in memory, reporting false for a repeat of the
+// newest entry. The caller must hold h.mu.
+func (h *HistoryManager) addLocked(entry string) bool {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return false
+	}
+	h.entries = append(h.entries, entry)
+	if overflow := len(h.entries) - h.maxEntries; overflow > 0 {
+		h.entries = append([]string(nil), h.entries[overflow:]...)
+	}
+	return true
+}
+
+// appendLocked merges in the history file's new entries, then records entry
+// and appends it to the file, unless it repeats the newest entry. The caller
+// must hold h.mu.
+func (h *HistoryManager) appendLocked(entry string) error {
+	return h.withFileLock(func() error {
+		if err := h.syncLocked(); err != nil {
+			return err
+		}
+		if !h.addLocked(entry) {
+			return nil
+		}
+
+		f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open history file: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(entry + "\n"); err != nil {
+			return fmt.Errorf("failed to write history file: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat history file: %w", err)
+		}
+		// The lock is held, so nothing was written since syncLocked read the file
+		h.fileInfo, h.offset = info, info.Size()
+		h.lines++
+		return h.compactLocked()
+	})
+}
+
+// withFileLock runs fn holding the exclusive lock of the history file.
+func (h *HistoryManager) withFileLock(fn func() error) error {
+	lock, err := os.OpenFile(h.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history lock file: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock history file: %w", err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+	return fn()
+}
+
+// syncLocked merges in the entries appended to the history file since it was
+// last read. If another process compacted the file, the entries are read from
+// it again. The caller must hold h.mu and the file lock.
+func (h *HistoryManager) syncLocked() error {
+	f, err := os.Open(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		h.fileInfo, h.offset, h.lines = nil, 0, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat history file: %w", err)
+	}
+
+	if h.fileInfo != nil && (!os.SameFile(h.fileInfo, info) || info.Size() < h.offset) {
+		h.entries = nil
+		h.offset, h.lines = 0, 0
+	}
+	h.fileInfo = info
+	if info.Size() == h.offset {
+		return nil
+	}
+
+	if _, err := f.Seek(h.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+	// A partly written last line is left for the next read
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	for line := range strings.Lines(string(data)) {
+		h.lines++
+		if line = strings.TrimSuffix(line, "\n"); strings.TrimSpace(line) != "" {
+			h.addLocked(line)
+		}
+	}
+	h.offset += int64(len(data))
+	return nil
+}
+
+// compactLocked rewrites the history file with the single-line entries in
+// memory, without consecutive duplicates, once it holds more than
+// historyCompactionFactor times maxEntries lines. The file is replaced by
+// renaming, so it is never seen half written. The caller must hold h.mu and
+// the file lock.
+func (h *HistoryManager) compactLocked() error {
+	if h.lines <= historyCompactionFactor*h.maxEntries {
+		return nil
+	}
+
+	var b strings.Builder
+	var previous string
+	lines := 0
+	for _, entry := range h.entries {
+		if strings.Contains(entry, "\n") || (lines > 0 && entry == previous) {
+			continue
+		}
+		b.WriteString(entry + "\n")
+		previous = entry
+		lines++
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to compact history file: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to compact history file: %w", err)
+	}
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat history file: %w", err)
+	}
+	h.fileInfo, h.offset, h.lines = info, info.Size(), lines
+	return nil
+}
+
+// Len returns the number of entries.
+func (h *HistoryManager) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.entries)
+}
+
+// Entries returns a copy of all entries, oldest first.
+func (h *HistoryManager) Entries() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]string(nil), h.entries...)
+}
+
+// SearchBackward finds the newest entry containing term at or before fromIndex,
+// searching toward older entries. Pass Len()-1 (or any larger value) to start at
+// the newest entry, and one less than the previous match to find the next older one.
+// It returns the entry and its index, or found=false when nothing matches or term is empty.
+func (h *HistoryManager) SearchBackward(term string, fromIndex int) (entry string, index int, found bool) {
+	if term == "" {
+		return "", -1, false
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for i := min(fromIndex, len(h.entries)-1); i >= 0; i-- {
+		if strings.Contains(h.entries[i], term) {
+			return h.entries[i], i, true
+		}
+	}
+	return "", -1, false
+}
+
+// Expand performs history expansion on input:
+//
+//   - "!!" is replaced by the newest entry.
+//   - "!<prefix>" is replaced by the newest entry that starts with prefix.
+//
+// Only input consisting of a single expansion is expanded, so messages that merely
+// start with "!" are left alone. It returns the expanded input and whether an
+// expansion happened, or ErrHistoryEventNotFound when nothing matches.
+func (h *HistoryManager) Expand(input string) (string, bool, error) {
+	trimmed := strings.TrimSpace(input)
+	if len(trimmed) < 2 || trimmed[0] != '!' || strings.ContainsAny(trimmed, " \t\n") {
+		return input, false, nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if trimmed == "!!" {
+		if len(h.entries) == 0 {
+			return "", false, fmt.Errorf("%s: %w", trimmed, ErrHistoryEventNotFound)
+		}
+		return h.entries[len(h.entries)-1], true, nil
+	}
+
+	prefix := trimmed[1:]
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if strings.HasPrefix(h.entries[i], prefix) {
+			return h.entries[i], true, nil
+		}
+	}
+	return "", false, fmt.Errorf("%s: %w", trimmed, ErrHistoryEventNotFound)
+}
+
+// History returns the history used for reverse search and history expansion.
+func (c *CLIAdapter) History() *HistoryManager {
+	return c.history
+}
+
+// SearchHistory finds the newest history entry containing term at or before
+// fromIndex; see HistoryManager.SearchBackward. Index is -1 when nothing matches.
+// Returns ErrNotInteractive when the adapter is not in interactive mode.
+func (c *CLIAdapter) SearchHistory(term string, fromIndex int) (string, int, error) {
+	if !c.IsInteractive() {
+		return "", -1, ErrNotInteractive
+	}
+	entry, index, _ := c.history.SearchBackward(term, fromIndex)
+	return entry, index, nil
+}
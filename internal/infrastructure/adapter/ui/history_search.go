@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/chzyer/readline"
+)
+
+// reverseSearch implements incremental reverse history search (Ctrl+R) for the
+// readline input path, backed by HistoryManager.SearchBackward.
+//
+// While searching, typed characters extend the search term and the line shows the
+// newest matching entry. Ctrl+R again moves to the next older match, Enter accepts
+// the match, and Ctrl+G or Esc cancels and restores the original line. Any other
+// control key ends the search, keeping the match, and is then handled normally.
+//
+// The key handlers run on readline's input goroutine; attach and setPrompt are
+// only called between reads.
+type reverseSearch struct {
+	history  *HistoryManager
+	instance *readline.Instance
+
+	prompt   string // Normal prompt, restored when the search ends
+	line     string // Current line, tracked while not searching
+	active   bool
+	term     []rune
+	index    int  // Index of the current match
+	failed   bool // Whether the current term has no match
+	original string
+}
+
+// newReverseSearch creates a reverse search over history.
+// The readline instance is attached with attach once it has been created.
+func newReverseSearch(history *HistoryManager) *reverseSearch {
+	return &reverseSearch{history: history}
+}
+
+// attach sets the readline instance whose prompt and buffer the search updates.
+func (s *reverseSearch) attach(instance *readline.Instance) {
+	s.instance = instance
+}
+
+// setPrompt records the prompt to restore when a search ends.
+func (s *reverseSearch) setPrompt(prompt string) {
+	s.prompt = prompt
+}
+
+// OnChange implements readline.Listener to track the line being edited, so that
+// cancelling a search can restore it.
+func (s *reverseSearch) OnChange(line []rune, _ int, _ rune) ([]rune, int, bool) {
+	if !s.active {
+		s.line = string(line)
+	}
+	return nil, 0, false
+}
+
+// filterRune is readline's FuncFilterInputRune hook. It returns process=false for
+// keys consumed by the search.
+func (s *reverseSearch) filterRune(r rune) (rune, bool) {
+	if !s.active {
+		if r == readline.CharBckSearch {
+			s.start()
+			return r, false
+		}
+		return r, true
+	}
+
+	switch r {
+	case readline.CharBckSearch:
+		if !s.failed {
+			s.find(s.index - 1)
+		}
+	case readline.CharEnter, readline.CharCtrlJ:
+		s.finish()
+		return r, true
+	case readline.CharBell, readline.CharEsc:
+		s.finish()
+		s.setBuffer(s.original)
+	case readline.CharBackspace, readline.CharCtrlH:
+		if len(s.term) > 0 {
+			s.term = s.term[:len(s.term)-1]
+		}
+		s.find(s.history.Len() - 1)
+	default:
+		if !unicode.IsPrint(r) {
+			s.finish()
+			return r, true
+		}
+		s.term = append(s.term, r)
+		// The current match may still match the longer term
+		from := s.index
+		if s.failed {
+			from = s.history.Len() - 1
+		}
+		s.find(from)
+	}
+	return r, false
+}
+
+// start enters search mode.
+func (s *reverseSearch) start() {
+	s.active = true
+	s.term = nil
+	s.index = s.history.Len()
+	s.failed = false
+	s.original = s.line
+	s.render()
+}
+
+// find moves to the newest match at or before fromIndex, keeping the current
+// match when there is none.
+func (s *reverseSearch) find(fromIndex int) {
+	entry, index, found := s.history.SearchBackward(string(s.term), fromIndex)
+	s.failed = !found && len(s.term) > 0
+	if found {
+		s.index = index
+		s.setBuffer(entry)
+	}
+	s.render()
+}
+
+// finish leaves search mode and restores the normal prompt.
+func (s *reverseSearch) finish() {
+	s.active = false
+	if s.instance != nil {
+		s.instance.SetPrompt(s.prompt)
+	}
+}
+
+// render shows the search term in the prompt.
+func (s *reverseSearch) render() {
+	label := "reverse-i-search"
+	if s.failed {
+		label = "failed reverse-i-search"
+	}
+	if s.instance != nil {
+		s.instance.SetPrompt(fmt.Sprintf("(%s)`%s': ", label, string(s.term)))
+	}
+}
+
+// setBuffer replaces the line being edited.
+func (s *reverseSearch) setBuffer(line string) {
+	if s.instance != nil {
+		s.instance.Operation.SetBuffer(line)
+	}
+}
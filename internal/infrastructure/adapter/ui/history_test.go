@@ -0,0 +1,251 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHistory(entries ...string) *ui.HistoryManager {
+	history := ui.NewHistoryManager(100)
+	for _, entry := range entries {
+		history.Add(entry)
+	}
+	return history
+}
+
+func TestHistoryManager_SearchBackward(t *testing.T) {
+	// Indexes:                 0            1             2           3
+	history := newTestHistory("git status", "go test ./...", "git diff", "ls")
+
+	tests := []struct {
+		name      string
+		term      string
+		fromIndex int
+		wantEntry string
+		wantIndex int
+		wantFound bool
+	}{
+		{name: "newest match first", term: "git", fromIndex: math.MaxInt, wantEntry: "git diff", wantIndex: 2, wantFound: true},
+		{name: "from Len-1", term: "git", fromIndex: history.Len() - 1, wantEntry: "git diff", wantIndex: 2, wantFound: true},
+		{name: "cycle to older match", term: "git", fromIndex: 1, wantEntry: "git status", wantIndex: 0, wantFound: true},
+		{name: "fromIndex is inclusive", term: "git", fromIndex: 2, wantEntry: "git diff", wantIndex: 2, wantFound: true},
+		{name: "substring match", term: "test", fromIndex: math.MaxInt, wantEntry: "go test ./...", wantIndex: 1, wantFound: true},
+		{name: "no older match", term: "git", fromIndex: -1, wantIndex: -1},
+		{name: "no match", term: "docker", fromIndex: math.MaxInt, wantIndex: -1},
+		{name: "empty term", term: "", fromIndex: math.MaxInt, wantIndex: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, index, found := history.SearchBackward(tt.term, tt.fromIndex)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantEntry, entry)
+			assert.Equal(t, tt.wantIndex, index)
+		})
+	}
+}
+
+func TestHistoryManager_Expand(t *testing.T) {
+	history := newTestHistory("git status", "go test ./...", "git diff")
+
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "repeat last", input: "!!", want: "git diff", wantChanged: true},
+		{name: "prefix", input: "!go", want: "go test ./...", wantChanged: true},
+		{name: "prefix picks newest", input: "!git", want: "git diff", wantChanged: true},
+		{name: "surrounding whitespace", input: "  !!  ", want: "git diff", wantChanged: true},
+		{name: "unknown prefix", input: "!docker", wantErr: true},
+		{name: "plain input", input: "hello", want: "hello"},
+		{name: "lone bang", input: "!", want: "!"},
+		{name: "sentence starting with bang", input: "!important fix the bug", want: "!important fix the bug"},
+		{name: "bang inside text", input: "run it!!", want: "run it!!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := history.Expand(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ui.ErrHistoryEventNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantChanged, changed)
+		})
+	}
+
+	t.Run("!! with empty history", func(t *testing.T) {
+		_, _, err := ui.NewHistoryManager(10).Expand("!!")
+		assert.ErrorIs(t, err, ui.ErrHistoryEventNotFound)
+	})
+}
+
+func TestHistoryManager_Add(t *testing.T) {
+	history := ui.NewHistoryManager(3)
+	for _, entry := range []string{"a", "", "  ", "b", "b", "c", "d"} {
+		history.Add(entry)
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, history.Entries(), "blank and repeated entries are skipped and the limit is kept")
+}
+
+func TestHistoryManager_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("first\nsecond\n"), 0o600))
+
+	history := ui.NewHistoryManager(10)
+	require.NoError(t, history.Load(path))
+	assert.Equal(t, []string{"first", "second"}, history.Entries())
+
+	require.NoError(t, history.Load(filepath.Join(t.TempDir(), "missing")), "missing file is not an error")
+}
+
+func TestHistoryManager_SetFile_MergesOtherInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	first := ui.NewHistoryManager(10)
+	require.NoError(t, first.SetFile(path))
+	second := ui.NewHistoryManager(10)
+	require.NoError(t, second.SetFile(path))
+
+	first.Add("ls")
+	second.Add("ls") // repeats the newest entry once merged
+	second.Add("pwd")
+	first.Add("git status")
+	first.Add("multi\nline")
+
+	assert.Equal(t, []string{"first", "ls", "pwd", "git status", "multi\nline"}, first.Entries())
+	assert.Equal(t, []string{"first", "ls", "pwd"}, second.Entries(), "entries are merged on the next Add")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nls\npwd\ngit status\n", string(data), "multi-line entries are not persisted")
+}
+
+func TestHistoryManager_SetFile_MissingFileCreatesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := ui.NewHistoryManager(10)
+	require.NoError(t, h.SetFile(path))
+
+	_, err := os.Stat(path + ".lock")
+	assert.ErrorIs(t, err, os.ErrNotExist, "no lock file before there is a history file")
+
+	h.Add("ls")
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "the first entry creates the history file")
+}
+
+func TestHistoryManager_SetFile_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	first := ui.NewHistoryManager(3)
+	require.NoError(t, first.SetFile(path))
+	second := ui.NewHistoryManager(3)
+	require.NoError(t, second.SetFile(path))
+
+	for i := range 10 {
+		first.Add(fmt.Sprintf("cmd %d", i))
+	}
+	// Compaction replaced the file; the other instance reads it again
+	second.Add("cmd 9")
+	second.Add("cmd 10")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	assert.LessOrEqual(t, len(lines), 6, "the file is compacted beyond twice the limit")
+	assert.Equal(t, []string{"cmd 8", "cmd 9", "cmd 10"}, lines[len(lines)-3:])
+	assert.Equal(t, []string{"cmd 8", "cmd 9", "cmd 10"}, second.Entries())
+}
+
+// TestHistoryManager_SetFile_ConcurrentInstances verifies that two instances
+// sharing a history file, written from many goroutines, lose no entries and
+// write no corrupted lines.
+func TestHistoryManager_SetFile_ConcurrentInstances(t *testing.T) {
+	const writers, perWriter = 16, 25
+	path := filepath.Join(t.TempDir(), "history")
+	managers := []*ui.HistoryManager{ui.NewHistoryManager(1000), ui.NewHistoryManager(1000)}
+	for _, m := range managers {
+		require.NoError(t, m.SetFile(path))
+	}
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWriter {
+				managers[(w+i)%2].Add(fmt.Sprintf("writer %d entry %d", w, i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, writers*perWriter)
+	next := make(map[int]int)
+	for _, line := range lines {
+		var w, i int
+		_, err := fmt.Sscanf(line, "writer %d entry %d", &w, &i)
+		require.NoError(t, err, "corrupted line %q", line)
+		assert.Equal(t, next[w], i, "writer %d's entries are out of order", w)
+		next[w] = i + 1
+	}
+
+	reloaded := ui.NewHistoryManager(1000)
+	require.NoError(t, reloaded.SetFile(path))
+	assert.Equal(t, lines, reloaded.Entries())
+}
+
+func TestCLIAdapter_GetUserInput_HistoryExpansion(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("go test ./...\n!!\n!nope\n!go\n"), &output)
+	adapter.SetColorEnabled(false)
+
+	var got []string
+	for {
+		input, ok := adapter.GetUserInput(context.Background())
+		if !ok {
+			break
+		}
+		got = append(got, input)
+	}
+
+	assert.Equal(t, []string{"go test ./...", "go test ./...", "go test ./..."}, got)
+	assert.Equal(t, 2, strings.Count(output.String(), "go test ./...\n"), "expanded commands should be echoed")
+	assert.Contains(t, output.String(), "Error: !nope: event not found")
+	assert.Equal(t, []string{"go test ./..."}, adapter.History().Entries())
+}
+
+func TestCLIAdapter_SearchHistory(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("deploy staging\nls\n"), &bytes.Buffer{})
+	for range 2 {
+		_, ok := adapter.GetUserInput(context.Background())
+		require.True(t, ok)
+	}
+
+	_, _, err := adapter.SearchHistory("deploy", math.MaxInt)
+	assert.ErrorIs(t, err, ui.ErrNotInteractive)
+
+	adapter.SetInteractive(true)
+	entry, index, err := adapter.SearchHistory("deploy", math.MaxInt)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy staging", entry)
+	assert.Equal(t, 0, index)
+}
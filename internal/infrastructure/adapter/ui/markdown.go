@@ -0,0 +1,330 @@
+package ui
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chzyer/readline"
+)
+
+// ANSI sequences used by the Markdown renderer. Each style is closed with its
+// specific reset (e.g. 22 for bold) so the surrounding message color is kept.
+const (
+	ansiBold       = "\x1b[1m"
+	ansiBoldOff    = "\x1b[22m"
+	ansiItalic     = "\x1b[3m"
+	ansiItalicOff  = "\x1b[23m"
+	ansiUnderline  = "\x1b[4m"
+	ansiUnderOff   = "\x1b[24m"
+	ansiCode       = "\x1b[36m" // Cyan for inline code and code blocks
+	ansiKeyword    = "\x1b[35m" // Magenta for keywords
+	ansiString     = "\x1b[32m" // Green for string literals
+	ansiComment    = "\x1b[90m" // Gray for comments
+	ansiDefaultFg  = "\x1b[39m"
+	defaultWrapCol = 80
+)
+
+var (
+	ansiPattern       = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemPattern   = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	blockquotePattern = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	rulePattern       = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	boldPattern       = regexp.MustCompile(`\*\*([^*\s](?:[^*]*[^*\s])?)\*\*|__([^_\s](?:[^_]*[^_\s])?)__`)
+	italicStarPattern = regexp.MustCompile(`(^|[^*\w])\*([^*\s](?:[^*]*[^*\s])?)\*($|[^*\w])`)
+	italicUndPattern  = regexp.MustCompile(`(^|[^_\w])_([^_\s](?:[^_]*[^_\s])?)_($|[^_\w])`)
+)
+
+// codeKeywords are highlighted in fenced code blocks regardless of language.
+// The list covers the common keywords of Go, Python, JavaScript, and shell.
+var codeKeywords = map[string]bool{
+	"func": true, "return": true, "if": true, "else": true, "for": true, "range": true,
+	"package": true, "import": true, "var": true, "const": true, "type": true,
+	"struct": true, "interface": true, "switch": true, "case": true, "default": true,
+	"break": true, "continue": true, "go": true, "defer": true, "select": true,
+	"chan": true, "map": true, "nil": true, "true": true, "false": true,
+	"def": true, "class": true, "from": true, "as": true, "with": true, "while": true,
+	"in": true, "not": true, "and": true, "or": true, "None": true, "True": true, "False": true,
+	"function": true, "let": true, "new": true, "null": true, "undefined": true,
+	"then": true, "fi": true, "do": true, "done": true, "esac": true,
+}
+
+// hashCommentLanguages use # for line comments in code block highlighting.
+var hashCommentLanguages = map[string]bool{
+	"sh": true, "bash": true, "shell": true, "zsh": true, "python": true, "py": true,
+	"ruby": true, "rb": true, "yaml": true, "yml": true, "toml": true, "dockerfile": true,
+	"makefile": true, "make": true, "perl": true, "r": true,
+}
+
+// MarkdownRenderer formats Markdown for display in an ANSI terminal.
+//
+// Headings are bold and underlined, **bold** and *italic* spans use ANSI styles,
+// list items are indented with hanging indents, and paragraphs are word-wrapped
+// to the configured width. Fenced code blocks are never wrapped or rewritten: the
+// only change is ANSI highlighting, so stripping escape codes yields the original
+// text byte-for-byte.
+type MarkdownRenderer struct {
+	width int
+}
+
+// NewMarkdownRenderer creates a renderer that wraps text at width columns.
+// A width below 20 falls back to 80 columns.
+func NewMarkdownRenderer(width int) *MarkdownRenderer {
+	if width < 20 {
+		width = defaultWrapCol
+	}
+	return &MarkdownRenderer{width: width}
+}
+
+// Render formats markdown for the terminal. baseColor is the ANSI color of the
+// surrounding message; it is re-applied after spans that change the foreground.
+func (r *MarkdownRenderer) Render(markdown, baseColor string) string {
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+
+	fence := ""    // Opening fence marker while inside a code block
+	language := "" // Info string of the current code block
+
+	for _, line := range lines {
+		if fence != "" {
+			if isClosingFence(line, fence) {
+				out = append(out, ansiDim+line+ansiBoldOff+baseColor)
+				fence = ""
+				continue
+			}
+			out = append(out, ansiCode+highlightCode(line, language)+baseColor)
+			continue
+		}
+
+		if marker, info, ok := openingFence(line); ok {
+			fence = marker
+			language = strings.ToLower(info)
+			out = append(out, ansiDim+line+ansiBoldOff+baseColor)
+			continue
+		}
+
+		out = append(out, r.renderBlockLine(line, baseColor)...)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderBlockLine renders a single line outside of code blocks.
+func (r *MarkdownRenderer) renderBlockLine(line, baseColor string) []string {
+	switch {
+	case strings.TrimSpace(line) == "":
+		return []string{""}
+
+	case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "|"):
+		// Indented code and tables are kept verbatim
+		return []string{line}
+
+	case rulePattern.MatchString(line):
+		return []string{ansiDim + strings.Repeat("─", r.width) + ansiBoldOff + baseColor}
+	}
+
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		text := ansiBold + ansiUnderline + renderInline(m[2], baseColor) + ansiUnderOff + ansiBoldOff
+		return wrapText(text, r.width, "", "")
+	}
+
+	if m := listItemPattern.FindStringSubmatch(line); m != nil {
+		nesting := strings.Repeat("  ", 1+len(strings.ReplaceAll(m[1], "\t", "  "))/2)
+		marker := m[2]
+		if !isOrderedMarker(marker) {
+			marker = "•"
+		}
+		first := nesting + marker + " "
+		rest := nesting + strings.Repeat(" ", utf8.RuneCountInString(marker)+1)
+		return wrapText(renderInline(m[3], baseColor), r.width, first, rest)
+	}
+
+	if m := blockquotePattern.FindStringSubmatch(line); m != nil {
+		prefix := ansiDim + "│ " + ansiBoldOff
+		return wrapText(ansiItalic+renderInline(m[1], baseColor)+ansiItalicOff, r.width, prefix, prefix)
+	}
+
+	return wrapText(renderInline(line, baseColor), r.width, "", "")
+}
+
+// openingFence reports whether line opens a fenced code block and returns the
+// fence marker and info string (language).
+func openingFence(line string) (marker, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return "", "", false
+	}
+	for _, ch := range []string{"`", "~"} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == ch[0] {
+			n++
+		}
+		if n >= 3 {
+			info = strings.TrimSpace(trimmed[n:])
+			if ch == "`" && strings.Contains(info, "`") {
+				return "", "", false
+			}
+			if fields := strings.Fields(info); len(fields) > 0 {
+				info = fields[0]
+			}
+			return trimmed[:n], info, true
+		}
+	}
+	return "", "", false
+}
+
+// isClosingFence reports whether line closes a code block opened with marker.
+func isClosingFence(line, marker string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < len(marker) {
+		return false
+	}
+	return strings.Trim(trimmed, marker[:1]) == ""
+}
+
+// isOrderedMarker reports whether a list marker is numbered (e.g. "1." or "2)").
+func isOrderedMarker(marker string) bool {
+	_, err := strconv.Atoi(strings.TrimRight(marker, ".)"))
+	return err == nil
+}
+
+// renderInline applies inline code, bold, and italic styles. Text inside
+// `code spans` is not interpreted further.
+func renderInline(text, baseColor string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(text, '`')
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], '`')
+		if end == -1 {
+			break
+		}
+		end += start + 1
+
+		sb.WriteString(renderEmphasis(text[:start]))
+		sb.WriteString(ansiCode + text[start+1:end] + ansiDefaultFg + baseColor)
+		text = text[end+1:]
+	}
+	sb.WriteString(renderEmphasis(text))
+	return sb.String()
+}
+
+// renderEmphasis replaces **bold**, __bold__, *italic*, and _italic_ spans with ANSI styles.
+func renderEmphasis(text string) string {
+	text = boldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return ansiBold + match[2:len(match)-2] + ansiBoldOff
+	})
+	text = italicStarPattern.ReplaceAllString(text, "${1}"+ansiItalic+"${2}"+ansiItalicOff+"${3}")
+	return italicUndPattern.ReplaceAllString(text, "${1}"+ansiItalic+"${2}"+ansiItalicOff+"${3}")
+}
+
+// highlightCode adds ANSI colors for keywords, strings, and comments to a line of
+// code. Only escape sequences are inserted; the visible text is unchanged.
+func highlightCode(line, language string) string {
+	restore := ansiCode
+	var sb strings.Builder
+
+	i := 0
+	for i < len(line) {
+		ch := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "//") ||
+			(ch == '#' && hashCommentLanguages[language]):
+			sb.WriteString(ansiComment + line[i:] + restore)
+			return sb.String()
+
+		case ch == '"' || ch == '\'' || ch == '`':
+			end := i + 1
+			for end < len(line) && line[end] != ch {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				end = len(line) - 1
+			}
+			sb.WriteString(ansiString + line[i:end+1] + restore)
+			i = end + 1
+
+		case isIdentStart(ch):
+			end := i + 1
+			for end < len(line) && isIdentPart(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			if codeKeywords[word] {
+				sb.WriteString(ansiKeyword + word + restore)
+			} else {
+				sb.WriteString(word)
+			}
+			i = end
+
+		default:
+			sb.WriteByte(ch)
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+// wrapText word-wraps styled text to width visible columns. The first line is
+// prefixed with first and continuation lines with rest.
+func wrapText(text string, width int, first, rest string) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{first}
+	}
+
+	var lines []string
+	current := first + words[0]
+	currentWidth := visibleWidth(current)
+	for _, word := range words[1:] {
+		wordWidth := visibleWidth(word)
+		if currentWidth+1+wordWidth > width {
+			lines = append(lines, current)
+			current = rest + word
+			currentWidth = visibleWidth(rest) + wordWidth
+			continue
+		}
+		current += " " + word
+		currentWidth += 1 + wordWidth
+	}
+	return append(lines, current)
+}
+
+// visibleWidth returns the number of runes in s excluding ANSI escape sequences.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(stripANSI(s))
+}
+
+// stripANSI removes ANSI color and style sequences from s.
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// terminalWidth returns the width of the terminal in columns. It falls back to
+// the COLUMNS environment variable and then to 80 columns.
+func terminalWidth() int {
+	if width := readline.GetScreenWidth(); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultWrapCol
+}
@@ -0,0 +1,156 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testANSIPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func stripTestANSI(s string) string {
+	return testANSIPattern.ReplaceAllString(s, "")
+}
+
+const assistantColor = "\x1b[93m"
+
+func TestMarkdownRenderer_CodeFencesSurviveByteForByte(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+	}{
+		{
+			name: "go code with keywords, strings, and comments",
+			markdown: "```go\n" +
+				"func main() {\n" +
+				"\t// **not bold** and *not italic*\n" +
+				"\tfmt.Println(\"# not a heading\", `raw`)\n" +
+				"    - not a list item\n" +
+				"}\n" +
+				"```",
+		},
+		{
+			name: "shell with hash comments and a very long line",
+			markdown: "~~~bash\n" +
+				"# comment with _underscores_\n" +
+				"echo '" + strings.Repeat("x", 300) + "' | grep -E 'a|b'\n" +
+				"> not a quote\n" +
+				"~~~",
+		},
+		{
+			name:     "unterminated fence",
+			markdown: "```\nif x {\n\treturn \"unterminated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := ui.NewMarkdownRenderer(40).Render(tt.markdown, assistantColor)
+			assert.Equal(t, tt.markdown, stripTestANSI(rendered))
+		})
+	}
+}
+
+func TestMarkdownRenderer_CodeFenceInsideProse(t *testing.T) {
+	code := "x := map[string]int{\"a\": 1} // keep   spacing\n\tgo run(x)"
+	markdown := "Here is **the** fix:\n\n```go\n" + code + "\n```\n\nDone."
+
+	plain := stripTestANSI(ui.NewMarkdownRenderer(80).Render(markdown, assistantColor))
+
+	assert.Contains(t, plain, "```go\n"+code+"\n```")
+	assert.Contains(t, plain, "Here is the fix:")
+}
+
+func TestMarkdownRenderer_HeadersGainStyling(t *testing.T) {
+	for _, heading := range []string{"# Title", "## Section", "### Sub section ###"} {
+		t.Run(heading, func(t *testing.T) {
+			rendered := ui.NewMarkdownRenderer(80).Render(heading, assistantColor)
+
+			assert.Contains(t, rendered, "\x1b[1m", "heading should be bold")
+			assert.Contains(t, rendered, "\x1b[4m", "heading should be underlined")
+			assert.NotContains(t, stripTestANSI(rendered), "#", "heading markers should be removed")
+		})
+	}
+}
+
+func TestMarkdownRenderer_InlineStyles(t *testing.T) {
+	tests := []struct {
+		name      string
+		markdown  string
+		wantANSI  string
+		wantPlain string
+	}{
+		{name: "bold", markdown: "a **bold** word", wantANSI: "\x1b[1mbold\x1b[22m", wantPlain: "a bold word"},
+		{name: "italic", markdown: "an *italic* word", wantANSI: "\x1b[3mitalic\x1b[23m", wantPlain: "an italic word"},
+		{name: "underscore italic", markdown: "an _italic_ word", wantANSI: "\x1b[3mitalic\x1b[23m", wantPlain: "an italic word"},
+		{name: "inline code is not styled further", markdown: "run `a **b** c`", wantANSI: "a **b** c", wantPlain: "run a **b** c"},
+		{name: "snake_case is untouched", markdown: "call my_func_name now", wantPlain: "call my_func_name now"},
+		{name: "bullet list", markdown: "- item\n* other", wantPlain: "  • item\n  • other"},
+		{name: "ordered list", markdown: "1. first\n2. second", wantPlain: "  1. first\n  2. second"},
+		{name: "nested list", markdown: "- outer\n  - inner", wantPlain: "  • outer\n    • inner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := ui.NewMarkdownRenderer(80).Render(tt.markdown, assistantColor)
+
+			if tt.wantANSI != "" {
+				assert.Contains(t, rendered, tt.wantANSI)
+			}
+			assert.Equal(t, tt.wantPlain, stripTestANSI(rendered))
+		})
+	}
+}
+
+func TestMarkdownRenderer_WrapsToWidth(t *testing.T) {
+	const width = 30
+	markdown := strings.Repeat("word ", 40) + "\n- " + strings.Repeat("**item** ", 20)
+
+	rendered := ui.NewMarkdownRenderer(width).Render(markdown, assistantColor)
+	lines := strings.Split(stripTestANSI(rendered), "\n")
+
+	require.Greater(t, len(lines), 2, "long text should wrap onto multiple lines")
+	for _, line := range lines {
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), width, "line exceeds width: %q", line)
+	}
+	// List continuation lines use a hanging indent aligned with the item text
+	assert.Contains(t, stripTestANSI(rendered), "\n    item")
+}
+
+func TestCLIAdapter_DisplayMessage_Markdown(t *testing.T) {
+	message := "# Title\n\n```\n**raw**\n```"
+
+	t.Run("plain text when disabled", func(t *testing.T) {
+		var output bytes.Buffer
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+		assert.False(t, adapter.IsMarkdownRendering(), "non-terminal output should not render Markdown")
+
+		require.NoError(t, adapter.DisplayMessage(message, "assistant"))
+		assert.Equal(t, assistantColor+message+"\x1b[0m\n", output.String())
+	})
+
+	t.Run("rendered when enabled", func(t *testing.T) {
+		var output bytes.Buffer
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+		adapter.SetMarkdownRendering(true)
+
+		require.NoError(t, adapter.DisplayMessage(message, "assistant"))
+		assert.Contains(t, output.String(), "\x1b[4m")
+		assert.Equal(t, "Title\n\n```\n**raw**\n```\n", stripTestANSI(output.String()))
+	})
+
+	t.Run("only assistant messages are rendered", func(t *testing.T) {
+		var output bytes.Buffer
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+		adapter.SetMarkdownRendering(true)
+
+		require.NoError(t, adapter.DisplayMessage(message, "user"))
+		assert.Contains(t, output.String(), "# Title")
+	})
+}
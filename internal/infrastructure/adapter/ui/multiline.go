@@ -0,0 +1,70 @@
+package ui
+
+import "strings"
+
+// Multi-line input markers.
+const (
+	// lineContinuation at the end of a line continues the input onto the next line.
+	lineContinuation = `\`
+	// heredocDelimiter opens a block that runs until a line ending with the delimiter.
+	heredocDelimiter = `"""`
+	// continuationPrompt is shown instead of the normal prompt on continuation lines.
+	continuationPrompt = "... "
+)
+
+// lineReader reads one line of user input. continuation reports whether the line
+// continues a message, so the reader can show continuationPrompt. It returns false
+// on EOF or error.
+type lineReader func(continuation bool) (string, bool)
+
+// assembleMultilineInput reads a message that may span several lines:
+//
+//   - A line ending in a backslash continues onto the next line; the backslash is dropped.
+//   - A line starting with """ starts a block that runs until a line ending with """.
+//     Text after the opening and before the closing delimiter is part of the message.
+//
+// Lines are joined with newlines. EOF in the middle of a message returns what was
+// collected so far with ok=true; EOF before the first line returns ok=false.
+func assembleMultilineInput(readLine lineReader) (string, bool) {
+	line, ok := readLine(false)
+	if !ok {
+		return "", false
+	}
+
+	if body, isHeredoc := strings.CutPrefix(strings.TrimSpace(line), heredocDelimiter); isHeredoc {
+		return readHeredoc(body, readLine), true
+	}
+
+	var lines []string
+	for strings.HasSuffix(line, lineContinuation) {
+		lines = append(lines, strings.TrimSuffix(line, lineContinuation))
+		if line, ok = readLine(true); !ok {
+			return strings.Join(lines, "\n"), true
+		}
+	}
+	return strings.Join(append(lines, line), "\n"), true
+}
+
+// readHeredoc collects heredoc lines until the closing delimiter or EOF.
+// body is the text that followed the opening delimiter on the first line.
+func readHeredoc(body string, readLine lineReader) string {
+	var lines []string
+	line, hasLine := body, body != ""
+	for {
+		if hasLine {
+			if content, closed := strings.CutSuffix(strings.TrimRight(line, " \t"), heredocDelimiter); closed {
+				if content != "" {
+					lines = append(lines, content)
+				}
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, line)
+		}
+
+		next, ok := readLine(true)
+		if !ok {
+			return strings.Join(lines, "\n")
+		}
+		line, hasLine = next, true
+	}
+}
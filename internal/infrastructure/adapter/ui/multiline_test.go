@@ -0,0 +1,88 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLIAdapter_GetUserInput_Multiline(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantMessages  []string
+		wantContinues int // Number of "... " continuation prompts shown
+	}{
+		{
+			name:         "single line is unchanged",
+			input:        "hello\n",
+			wantMessages: []string{"hello"},
+		},
+		{
+			name:          "backslash continuation",
+			input:         "first \\\nsecond\\\nthird\nnext message\n",
+			wantMessages:  []string{"first \nsecond\nthird", "next message"},
+			wantContinues: 2,
+		},
+		{
+			name:          "heredoc block",
+			input:         "\"\"\"\nfunc main() {\n\n\tfmt.Println(\"hi\") \\\n}\n\"\"\"\nafter\n",
+			wantMessages:  []string{"func main() {\n\n\tfmt.Println(\"hi\") \\\n}", "after"},
+			wantContinues: 5,
+		},
+		{
+			name:          "heredoc with text on delimiter lines",
+			input:         "\"\"\"Review this:\ncode here\nthanks\"\"\"\n",
+			wantMessages:  []string{"Review this:\ncode here\nthanks"},
+			wantContinues: 2,
+		},
+		{
+			name:         "heredoc on a single line",
+			input:        "\"\"\"inline\"\"\"\n",
+			wantMessages: []string{"inline"},
+		},
+		{
+			name:          "EOF mid-heredoc returns collected lines",
+			input:         "\"\"\"\nline one\nline two",
+			wantMessages:  []string{"line one\nline two"},
+			wantContinues: 3,
+		},
+		{
+			name:          "EOF after backslash returns collected lines",
+			input:         "partial \\\n",
+			wantMessages:  []string{"partial "},
+			wantContinues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(tt.input), &output)
+
+			for _, want := range tt.wantMessages {
+				got, ok := adapter.GetUserInput(context.Background())
+				assert.True(t, ok)
+				assert.Equal(t, want, got)
+			}
+
+			_, ok := adapter.GetUserInput(context.Background())
+			assert.False(t, ok, "input should be exhausted")
+			assert.Equal(t, tt.wantContinues, strings.Count(output.String(), "... "))
+		})
+	}
+}
+
+func TestCLIAdapter_GetUserInput_CancelledContext(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("\"\"\"\ntext\n"), &bytes.Buffer{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, ok := adapter.GetUserInput(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,40 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLIAdapter_PlanModePrompt(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("first\nsecond\n"), &output)
+	adapter.SetColorEnabled(false)
+
+	_, ok := adapter.GetUserInput(context.Background())
+	assert.True(t, ok)
+	assert.NotContains(t, output.String(), "[PLAN MODE]")
+
+	adapter.SetPlanMode(true)
+	assert.True(t, adapter.IsPlanMode())
+	output.Reset()
+
+	_, ok = adapter.GetUserInput(context.Background())
+	assert.True(t, ok)
+	assert.Contains(t, output.String(), "[PLAN MODE] Claude: ")
+}
+
+func TestCLIAdapter_HandleKeyPress_ShiftTabInvokesToggle(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &bytes.Buffer{})
+	toggles := 0
+	adapter.SetModeToggleCallback(func() { toggles++ })
+
+	adapter.HandleKeyPress(ui.KeyTab)
+	adapter.HandleKeyPress(ui.KeyShiftTab)
+
+	assert.Equal(t, 1, toggles)
+}
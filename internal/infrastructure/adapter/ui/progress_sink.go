@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// subagentTextMaxLines limits how many lines of subagent assistant text are echoed per turn.
+const subagentTextMaxLines = 5
+
+// ansiDim is the ANSI escape code for dimmed (faint) text.
+const ansiDim = "\x1b[2m"
+
+// OnSubagentEvent implements port.ProgressSink by rendering subagent progress as
+// dimmed, indented lines prefixed with the subagent name, keeping them visually
+// distinct from the parent conversation.
+//
+// Safe to call from subagent goroutines: the write is serialized with the
+// adapter's other output through the adapter mutex. An active spinner is
+// redrawn below the event lines.
+func (c *CLIAdapter) OnSubagentEvent(event port.SubagentEvent) {
+	lines := formatSubagentEvent(event)
+	if len(lines) == 0 {
+		return
+	}
+
+	// Build output string before acquiring lock to minimize lock hold time.
+	var buf strings.Builder
+	prefix := "  [" + event.AgentName + "] "
+	for _, line := range lines {
+		buf.WriteString(c.colorize(ansiDim, prefix+line) + "\n")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordTranscriptLocked("subagent", buf.String())
+	c.clearActivityLocked()
+	_, _ = c.output.Write([]byte(buf.String()))
+	c.drawActivityLocked()
+}
+
+// formatSubagentEvent returns the display lines for a subagent event, without prefix or color.
+func formatSubagentEvent(event port.SubagentEvent) []string {
+	switch event.Type {
+	case port.SubagentEventStarted:
+		return []string{"started"}
+	case port.SubagentEventText:
+		return formatSubagentText(event.Text)
+	case port.SubagentEventToolExecuted:
+		mark := "✓"
+		if event.IsError {
+			mark = "✗"
+		}
+		return []string{fmt.Sprintf("%s %s (%s)", mark, event.ToolName, formatEventDuration(event.Duration))}
+	case port.SubagentEventCompleted:
+		return []string{
+			fmt.Sprintf("completed (%d actions, %s)", event.Actions, formatEventDuration(event.Duration)),
+		}
+	case port.SubagentEventFailed:
+		return []string{fmt.Sprintf("failed: %s (%s)", event.Text, formatEventDuration(event.Duration))}
+	case port.SubagentEventTimedOut:
+		return []string{
+			fmt.Sprintf("timed out (%d actions, %s)", event.Actions, formatEventDuration(event.Duration)),
+		}
+	default:
+		return nil
+	}
+}
+
+// OnInvestigationEvent implements port.InvestigationProgressSink by rendering
+// investigation progress as dimmed lines prefixed with the investigation ID.
+// Iteration events are not shown; the activity indicator already covers them.
+// Tool calls are shown in the compact form of FormatToolCall, with secrets masked.
+func (c *CLIAdapter) OnInvestigationEvent(event port.InvestigationEvent) {
+	line := formatInvestigationEvent(event)
+	if line == "" {
+		return
+	}
+	output := c.colorize(ansiDim, "  [investigation "+event.InvestigationID+"] "+line) + "\n"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordTranscriptLocked("investigation", output)
+	c.clearActivityLocked()
+	_, _ = c.output.Write([]byte(output))
+	c.drawActivityLocked()
+}
+
+// formatInvestigationEvent returns the display line for an investigation event,
+// without prefix or color, or "" for events that are not displayed.
+func formatInvestigationEvent(event port.InvestigationEvent) string {
+	switch event.Type {
+	case port.InvestigationEventToolExecuted:
+		mark := "✓"
+		if event.IsError {
+			mark = "✗"
+		}
+		call := event.ToolName
+		if event.Input != "" {
+			call = FormatToolCall(event.ToolName, event.Input)
+		}
+		return fmt.Sprintf("%s %s (%s)", mark, call, formatEventDuration(event.Duration))
+	case port.InvestigationEventFindingAdded:
+		return "finding: " + event.Finding
+	case port.InvestigationEventCompleted:
+		return fmt.Sprintf("completed (%d actions, %s)", event.Actions, formatEventDuration(event.Duration))
+	case port.InvestigationEventEscalated:
+		return fmt.Sprintf("escalated: %s (%d actions)", event.Reason, event.Actions)
+	case port.InvestigationEventFailed:
+		return fmt.Sprintf("failed: %s (%d actions)", event.Reason, event.Actions)
+	default:
+		return ""
+	}
+}
+
+// formatSubagentText splits assistant text into display lines, eliding anything
+// beyond subagentTextMaxLines so chatty subagents don't flood the terminal.
+func formatSubagentText(text string) []string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) <= subagentTextMaxLines {
+		return lines
+	}
+	omitted := len(lines) - subagentTextMaxLines
+	lines = lines[:subagentTextMaxLines]
+	return append(lines, fmt.Sprintf("... (%d more lines)", omitted))
+}
+
+// formatEventDuration renders a duration compactly: milliseconds below one second, else seconds.
+func formatEventDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// NoOpProgressSink is a port.ProgressSink and port.InvestigationProgressSink
+// that discards all events. Use it for headless runs where progress should not
+// be rendered.
+type NoOpProgressSink struct{}
+
+// OnSubagentEvent discards the event.
+func (NoOpProgressSink) OnSubagentEvent(port.SubagentEvent) {}
+
+// OnInvestigationEvent discards the event.
+func (NoOpProgressSink) OnInvestigationEvent(port.InvestigationEvent) {}
@@ -0,0 +1,207 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLIAdapter_OnSubagentEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event port.SubagentEvent
+		want  []string
+	}{
+		{
+			name:  "started",
+			event: port.SubagentEvent{Type: port.SubagentEventStarted, AgentName: "researcher"},
+			want:  []string{"[researcher] started"},
+		},
+		{
+			name: "tool executed",
+			event: port.SubagentEvent{
+				Type:      port.SubagentEventToolExecuted,
+				AgentName: "researcher",
+				ToolName:  "read_file",
+				Duration:  120 * time.Millisecond,
+			},
+			want: []string{"[researcher] ✓ read_file (120ms)"},
+		},
+		{
+			name: "tool failed",
+			event: port.SubagentEvent{
+				Type:      port.SubagentEventToolExecuted,
+				AgentName: "researcher",
+				ToolName:  "bash",
+				IsError:   true,
+				Duration:  1500 * time.Millisecond,
+			},
+			want: []string{"[researcher] ✗ bash (1.5s)"},
+		},
+		{
+			name: "completed",
+			event: port.SubagentEvent{
+				Type:      port.SubagentEventCompleted,
+				AgentName: "researcher",
+				Actions:   3,
+				Duration:  2 * time.Second,
+			},
+			want: []string{"[researcher] completed (3 actions, 2.0s)"},
+		},
+		{
+			name: "failed",
+			event: port.SubagentEvent{
+				Type:      port.SubagentEventFailed,
+				AgentName: "researcher",
+				Text:      "boom",
+				Duration:  10 * time.Millisecond,
+			},
+			want: []string{"[researcher] failed: boom (10ms)"},
+		},
+		{
+			name: "multi-line text is indented per line",
+			event: port.SubagentEvent{
+				Type:      port.SubagentEventText,
+				AgentName: "researcher",
+				Text:      "line one\nline two",
+			},
+			want: []string{"[researcher] line one", "[researcher] line two"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+			adapter.OnSubagentEvent(tt.event)
+
+			lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+			require.Len(t, lines, len(tt.want))
+			for i, line := range lines {
+				assert.True(t, strings.HasPrefix(line, "\x1b[2m  "), "line should be dimmed and indented: %q", line)
+				assert.Contains(t, line, tt.want[i])
+			}
+		})
+	}
+}
+
+func TestCLIAdapter_OnSubagentEvent_TruncatesLongText(t *testing.T) {
+	output := &bytes.Buffer{}
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+	adapter.OnSubagentEvent(port.SubagentEvent{
+		Type:      port.SubagentEventText,
+		AgentName: "chatty",
+		Text:      "1\n2\n3\n4\n5\n6\n7\n8",
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	require.Len(t, lines, 6)
+	assert.Contains(t, lines[5], "... (3 more lines)")
+}
+
+func TestCLIAdapter_OnSubagentEvent_ConcurrentWritesDoNotInterleave(t *testing.T) {
+	output := &bytes.Buffer{}
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			adapter.OnSubagentEvent(port.SubagentEvent{Type: port.SubagentEventStarted, AgentName: "worker"})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = adapter.DisplaySystemMessage("parent output")
+		}()
+	}
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+		assert.True(t,
+			strings.HasSuffix(line, "[worker] started\x1b[0m") || strings.HasSuffix(line, "parent output\x1b[0m"),
+			"unexpected interleaved line: %q", line)
+	}
+}
+
+func TestNoOpProgressSink_ImplementsProgressSink(_ *testing.T) {
+	var sink port.ProgressSink = ui.NoOpProgressSink{}
+	sink.OnSubagentEvent(port.SubagentEvent{Type: port.SubagentEventStarted})
+}
+
+func TestCLIAdapter_OnInvestigationEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event port.InvestigationEvent
+		want  string
+	}{
+		{
+			name: "tool executed",
+			event: port.InvestigationEvent{
+				Type:     port.InvestigationEventToolExecuted,
+				ToolName: "bash",
+				Duration: 250 * time.Millisecond,
+			},
+			want: "[investigation inv-1] ✓ bash (250ms)",
+		},
+		{
+			name: "tool executed with input is compact and redacted",
+			event: port.InvestigationEvent{
+				Type:     port.InvestigationEventToolExecuted,
+				ToolName: "bash",
+				Input:    `{"command":"curl -H 'Authorization: Bearer abc123' https://api.internal/health"}`,
+				Duration: 250 * time.Millisecond,
+			},
+			want: "[investigation inv-1] ✓ bash(curl -H 'Authorization: Bearer ********' https://api.internal/health) (250ms)",
+		},
+		{
+			name:  "finding",
+			event: port.InvestigationEvent{Type: port.InvestigationEventFindingAdded, Finding: "disk is full"},
+			want:  "[investigation inv-1] finding: disk is full",
+		},
+		{
+			name: "escalated",
+			event: port.InvestigationEvent{
+				Type:    port.InvestigationEventEscalated,
+				Reason:  "needs a human",
+				Actions: 4,
+			},
+			want: "[investigation inv-1] escalated: needs a human (4 actions)",
+		},
+		{
+			name:  "iteration started is not shown",
+			event: port.InvestigationEvent{Type: port.InvestigationEventIterationStarted, Iteration: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+			tt.event.InvestigationID = "inv-1"
+			adapter.OnInvestigationEvent(tt.event)
+
+			if tt.want == "" {
+				assert.Empty(t, output.String())
+				return
+			}
+			assert.True(t, strings.HasPrefix(output.String(), "\x1b[2m  "), "line should be dimmed and indented")
+			assert.Contains(t, output.String(), tt.want)
+		})
+	}
+}
+
+func TestNoOpProgressSink_ImplementsInvestigationProgressSink(_ *testing.T) {
+	var sink port.InvestigationProgressSink = ui.NoOpProgressSink{}
+	sink.OnInvestigationEvent(port.InvestigationEvent{Type: port.InvestigationEventCompleted})
+}
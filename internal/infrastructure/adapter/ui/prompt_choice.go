@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// errNoAnswer is returned when input ends or is interrupted before the user
+// answers a question.
+var errNoAnswer = errors.New("the user gave no answer")
+
+// scanResult is one line read by a bufio.Scanner.
+type scanResult struct {
+	line string
+	ok   bool
+}
+
+// PromptChoice asks the user a question on the AI's behalf. With choices, they
+// are listed numbered and the user answers with a number or the choice itself;
+// anything else asks again. Without choices, any non-empty answer is accepted.
+// It returns ctx.Err() if ctx ends while waiting.
+func (c *CLIAdapter) PromptChoice(ctx context.Context, question string, choices []string) (string, error) {
+	// The spinner would overwrite the question while waiting for input
+	c.StopActivity()
+
+	fmt.Fprint(c.output, c.colorize(c.colorScheme().System, "[QUESTION] "+question)+"\n")
+	for i, choice := range choices {
+		fmt.Fprintf(c.output, "  %d. %s\n", i+1, choice)
+	}
+	prompt := "Answer: "
+	if len(choices) > 0 {
+		prompt = fmt.Sprintf("Choose 1-%d: ", len(choices))
+	}
+	c.requestAttention(AttentionInputNeeded, question)
+
+	for {
+		line, err := c.readAnswer(ctx, prompt)
+		if err != nil {
+			c.recordTranscript("question", question+" (no answer)")
+			return "", err
+		}
+		if answer, ok := matchChoice(strings.TrimSpace(line), choices); ok {
+			c.recordTranscript("question", question+" (answered: "+answer+")")
+			return answer, nil
+		}
+		if len(choices) > 0 {
+			fmt.Fprint(c.output, c.colorize(c.colorScheme().Error,
+				fmt.Sprintf("Enter a number from 1 to %d.", len(choices)))+"\n")
+		}
+	}
+}
+
+// matchChoice returns the choice that input selects, by number or by text
+// (ignoring case). Without choices, any non-empty input is the answer.
+func matchChoice(input string, choices []string) (string, bool) {
+	if len(choices) == 0 {
+		return input, input != ""
+	}
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], true
+	}
+	for _, choice := range choices {
+		if strings.EqualFold(input, choice) {
+			return choice, true
+		}
+	}
+	return "", false
+}
+
+// readAnswer reads one line of input after showing prompt, giving up with
+// ctx.Err() when ctx ends first.
+func (c *CLIAdapter) readAnswer(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if c.useInteractive && c.historyFile != "" {
+		rl, err := readline.NewEx(&readline.Config{
+			Prompt:          c.colorize(c.colorScheme().Prompt, prompt),
+			InterruptPrompt: "^C",
+		})
+		if err == nil {
+			return readAnswerLine(ctx, rl)
+		}
+		// Fall back to simple input
+	}
+
+	fmt.Fprint(c.output, prompt)
+	if c.scanner == nil {
+		c.scanner = bufio.NewScanner(c.input)
+	}
+
+	// A scan abandoned by an earlier cancellation still owns the scanner, so
+	// take its line instead of starting another
+	pending := c.pendingScan
+	c.pendingScan = nil
+	if pending == nil {
+		pending = make(chan scanResult, 1)
+		go func() {
+			ok := c.scanner.Scan()
+			pending <- scanResult{line: c.scanner.Text(), ok: ok}
+		}()
+	}
+
+	select {
+	case result := <-pending:
+		if !result.ok {
+			return "", errNoAnswer
+		}
+		return result.line, nil
+	case <-ctx.Done():
+		c.pendingScan = pending
+		fmt.Fprintln(c.output)
+		return "", ctx.Err()
+	}
+}
+
+// readAnswerLine reads a line with rl, closing it to stop waiting when ctx
+// ends. Ctrl+C or Ctrl+D means no answer.
+func readAnswerLine(ctx context.Context, rl *readline.Instance) (string, error) {
+	type readResult struct {
+		line string
+		err  error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		line, err := rl.Readline()
+		done <- readResult{line: line, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		_ = rl.Close()
+		if result.err != nil {
+			return "", errNoAnswer
+		}
+		return result.line, nil
+	case <-ctx.Done():
+		_ = rl.Close()
+		<-done
+		return "", ctx.Err()
+	}
+}
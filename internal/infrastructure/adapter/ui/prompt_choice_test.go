@@ -0,0 +1,79 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ port.ChoicePrompter = (*ui.CLIAdapter)(nil)
+
+func TestCLIAdapter_PromptChoice(t *testing.T) {
+	choices := []string{"config/dev.yaml", "config/prod.yaml"}
+	tests := []struct {
+		name    string
+		input   string
+		choices []string
+		want    string
+	}{
+		{name: "number", input: "2\n", choices: choices, want: "config/prod.yaml"},
+		{name: "choice text", input: "CONFIG/DEV.YAML\n", choices: choices, want: "config/dev.yaml"},
+		{name: "asks again until valid", input: "3\nboth\n\n1\n", choices: choices, want: "config/dev.yaml"},
+		{name: "free text", input: "\n  use the staging one  \n", want: "use the staging one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(tt.input), &output)
+			adapter.SetColorEnabled(false)
+
+			got, err := adapter.PromptChoice(context.Background(), "Which config should I edit?", tt.choices)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, output.String(), "[QUESTION] Which config should I edit?\n")
+		})
+	}
+}
+
+func TestCLIAdapter_PromptChoice_RendersNumberedChoices(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("5\n1\n"), &output)
+	adapter.SetColorEnabled(false)
+
+	_, err := adapter.PromptChoice(context.Background(), "Which?", []string{"a.yaml", "b.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "[QUESTION] Which?\n  1. a.yaml\n  2. b.yaml\nChoose 1-2: Enter a number from 1 to 2.\nChoose 1-2: ",
+		output.String())
+}
+
+func TestCLIAdapter_PromptChoice_EndOfInput(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("7\n"), &bytes.Buffer{})
+
+	_, err := adapter.PromptChoice(context.Background(), "Which?", []string{"a", "b"})
+	assert.Error(t, err)
+}
+
+func TestCLIAdapter_PromptChoice_Cancelled(t *testing.T) {
+	input, writer := io.Pipe()
+	defer writer.Close()
+	adapter := ui.NewCLIAdapterWithIO(input, &bytes.Buffer{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := adapter.PromptChoice(ctx, "Proceed?", nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The line typed after cancellation answers the next question
+	go func() { _, _ = writer.Write([]byte("yes\n")) }()
+	got, err := adapter.PromptChoice(context.Background(), "Proceed now?", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "yes", got)
+}
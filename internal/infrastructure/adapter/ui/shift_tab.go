@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+)
+
+// shiftTabRune stands in for the Shift+Tab key in readline input. readline drops
+// the escape sequence terminals send for Shift+Tab, so shiftTabReader replaces it
+// with this private-use rune, which the input filter turns into a mode toggle.
+const shiftTabRune = '\uE000'
+
+var (
+	// shiftTabSequence is the escape sequence terminals send for Shift+Tab.
+	shiftTabSequence = []byte("\x1b[Z")
+	// shiftTabRuneBytes is the UTF-8 encoding of shiftTabRune. It has the same
+	// length as shiftTabSequence, so the replacement can be done in place.
+	shiftTabRuneBytes = []byte(string(shiftTabRune))
+)
+
+// shiftTabReader wraps terminal input, replacing the Shift+Tab escape sequence
+// with shiftTabRune. A sequence split across reads is passed through unchanged;
+// terminals write it in a single chunk.
+type shiftTabReader struct {
+	r io.Reader
+}
+
+// newShiftTabReader creates a shiftTabReader reading from r.
+func newShiftTabReader(r io.Reader) *shiftTabReader {
+	return &shiftTabReader{r: r}
+}
+
+// Read implements io.Reader.
+func (s *shiftTabReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 && bytes.Contains(p[:n], shiftTabSequence) {
+		copy(p, bytes.ReplaceAll(p[:n], shiftTabSequence, shiftTabRuneBytes))
+	}
+	return n, err
+}
+
+// handleShiftTab runs the mode toggle callback while readline is reading input.
+// Output written by the callback, such as the announcement of the new mode, goes
+// through readline so the prompt and the line being edited are redrawn below it,
+// and the prompt is updated to show the new mode; readline redraws the line after
+// the filtered key.
+//
+// It runs on readline's input goroutine.
+func (c *CLIAdapter) handleShiftTab() {
+	c.mu.Lock()
+	output := c.output
+	rl := c.readlineInstance
+	if rl != nil {
+		c.output = rl.Stdout()
+	}
+	c.mu.Unlock()
+
+	c.HandleKeyPress(KeyShiftTab)
+
+	c.mu.Lock()
+	c.output = output
+	continuation := c.readingContinuation
+	c.mu.Unlock()
+
+	if rl != nil && !continuation {
+		prompt := c.inputPrompt(false)
+		rl.SetPrompt(prompt)
+		c.search.setPrompt(prompt)
+	}
+}
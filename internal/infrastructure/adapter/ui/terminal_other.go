@@ -0,0 +1,19 @@
+//go:build !windows
+
+package ui
+
+import "os"
+
+// isTerminalFile reports whether f is a character device, such as a tty.
+func isTerminalFile(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
+
+// enableVirtualTerminal is a no-op: Unix terminals render ANSI sequences natively.
+func enableVirtualTerminal(_ *os.File) bool {
+	return true
+}
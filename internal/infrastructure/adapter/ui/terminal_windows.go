@@ -0,0 +1,31 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTerminalFile reports whether f is a console. Pipes, files, and NUL, which
+// are also character devices on Windows, have no console mode.
+func isTerminalFile(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+// enableVirtualTerminal turns on virtual terminal processing for the console f,
+// so it renders ANSI sequences instead of printing them. It reports false on
+// consoles older than Windows 10, which do not support it.
+func enableVirtualTerminal(f *os.File) bool {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -0,0 +1,53 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLIAdapter_DisplayThinking_CollapsedByDefault(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+	content := strings.Repeat("reasoning ", 20) // 200 characters
+
+	require.NoError(t, adapter.DisplayThinking(content))
+
+	assert.False(t, adapter.IsThinkingExpanded())
+	assert.Equal(t, "\x1b[2m(thinking… 50 tokens)\x1b[0m\n", output.String())
+}
+
+func TestCLIAdapter_DisplayThinking_Expanded(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+	adapter.SetColorEnabled(false)
+	adapter.SetThinkingExpanded(true)
+
+	require.NoError(t, adapter.DisplayThinking("first line\nsecond line"))
+
+	out := output.String()
+	assert.Contains(t, out, "Claude is thinking...")
+	assert.Contains(t, out, "  first line\n  second line\n")
+	assert.NotContains(t, out, "tokens)")
+}
+
+func TestCLIAdapter_ExpandLastThinking(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), &output)
+	adapter.SetColorEnabled(false)
+
+	require.NoError(t, adapter.ExpandLastThinking())
+	assert.Contains(t, output.String(), "No thinking to show yet")
+
+	require.NoError(t, adapter.DisplayThinking("older"))
+	require.NoError(t, adapter.DisplayThinking("latest idea"))
+	output.Reset()
+
+	require.NoError(t, adapter.ExpandLastThinking())
+	assert.Contains(t, output.String(), "  latest idea\n")
+	assert.NotContains(t, output.String(), "older")
+}
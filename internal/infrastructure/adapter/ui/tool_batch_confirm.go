@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxBatchSummaryLength is how much of a call's summary the batch list shows.
+const maxBatchSummaryLength = 100
+
+// ConfirmToolBatch implements port.UserInterface by listing the calls numbered,
+// dangerous ones marked with their reason, and asking once for all of them.
+// The answer is "a" (approve all), "n" (deny all), or the numbers of the calls
+// to approve, separated by commas or spaces, with ranges like "2-4". A
+// malformed selection asks again.
+//
+// Empty input or EOF denies every call (safe default).
+func (c *CLIAdapter) ConfirmToolBatch(calls []port.ToolConfirmation) []bool {
+	// The spinner would overwrite the confirmation prompt while waiting for input
+	c.StopActivity()
+
+	fmt.Fprint(c.output, c.colorize(c.colorScheme().System,
+		fmt.Sprintf("[TOOL BATCH] %d tool calls need approval:", len(calls)))+"\n")
+	for i, call := range calls {
+		line := fmt.Sprintf("  %d. %s: %s", i+1, call.ToolName, c.colorize(c.colorScheme().Tool, batchSummary(call.Summary)))
+		if call.Dangerous {
+			line += " " + c.colorize(c.colorScheme().Error, "[DANGEROUS: "+call.Reason+"]")
+		}
+		if call.Description != "" {
+			line += " - " + call.Description
+		}
+		fmt.Fprint(c.output, line+"\n")
+	}
+
+	c.requestAttention(AttentionInputNeeded, fmt.Sprintf("Approve %d tool calls?", len(calls)))
+	for {
+		input := c.readConfirmation("Approve [a]ll, [n]one, or numbers (e.g. 1,3): ")
+		approved, err := parseToolSelection(input, len(calls))
+		if err != nil {
+			fmt.Fprint(c.output, c.colorize(c.colorScheme().Error, err.Error())+"\n")
+			continue
+		}
+		c.recordTranscript("tool batch", batchDecisionSummary(calls, approved))
+		return approved
+	}
+}
+
+// parseToolSelection reads a batch confirmation answer for n calls: "a" or
+// "all" approves every call, "n", "none", or nothing denies every call, and a
+// list of call numbers and ranges approves those calls.
+func parseToolSelection(input string, n int) ([]bool, error) {
+	approved := make([]bool, n)
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "a", "all", "y", "yes":
+		for i := range approved {
+			approved[i] = true
+		}
+		return approved, nil
+	case "", "n", "no", "none":
+		return approved, nil
+	}
+
+	fields := strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, errors.New("enter a, n, or call numbers like 1,3")
+	}
+	for _, field := range fields {
+		first, last, isRange := strings.Cut(field, "-")
+		if !isRange {
+			last = first
+		}
+		from, errFrom := strconv.Atoi(first)
+		to, errTo := strconv.Atoi(last)
+		if errFrom != nil || errTo != nil || from > to {
+			return nil, fmt.Errorf("%q is not a call number or range; enter a, n, or numbers like 1,3", field)
+		}
+		if from < 1 || to > n {
+			return nil, fmt.Errorf("there is no call %s; enter numbers from 1 to %d", field, n)
+		}
+		for i := from; i <= to; i++ {
+			approved[i-1] = true
+		}
+	}
+	return approved, nil
+}
+
+// batchSummary shortens a call summary to one line of at most
+// maxBatchSummaryLength characters.
+func batchSummary(summary string) string {
+	summary = strings.Join(strings.Fields(summary), " ")
+	if runes := []rune(summary); len(runes) > maxBatchSummaryLength {
+		return string(runes[:maxBatchSummaryLength-3]) + "..."
+	}
+	return summary
+}
+
+// batchDecisionSummary lists each call of a batch with the user's decision,
+// for the transcript.
+func batchDecisionSummary(calls []port.ToolConfirmation, approved []bool) string {
+	lines := make([]string, len(calls))
+	for i, call := range calls {
+		decision := "denied"
+		if approved[i] {
+			decision = "approved"
+		}
+		lines[i] = fmt.Sprintf("%d. %s: %s (%s)", i+1, call.ToolName, call.Summary, decision)
+	}
+	return strings.Join(lines, "\n")
+}
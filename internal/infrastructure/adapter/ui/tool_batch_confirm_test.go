@@ -0,0 +1,59 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:gochecknoglobals // test fixture
+var batchCalls = []port.ToolConfirmation{
+	{ToolName: "bash", Summary: "go test ./...", Description: "Run the tests"},
+	{ToolName: "bash", Summary: "rm -rf build", Dangerous: true, Reason: "recursive delete"},
+	{ToolName: "bash", Summary: "git status"},
+	{ToolName: "bash", Summary: "go vet ./..."},
+}
+
+func TestCLIAdapter_ConfirmToolBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []bool
+	}{
+		{name: "all", input: "a\n", want: []bool{true, true, true, true}},
+		{name: "all spelled out", input: "ALL\n", want: []bool{true, true, true, true}},
+		{name: "none", input: "n\n", want: []bool{false, false, false, false}},
+		{name: "selection", input: "1,3\n", want: []bool{true, false, true, false}},
+		{name: "spaces and duplicates", input: " 4 1, 1\n", want: []bool{true, false, false, true}},
+		{name: "range", input: "2-4\n", want: []bool{false, true, true, true}},
+		{name: "empty denies all", input: "\n", want: []bool{false, false, false, false}},
+		{name: "end of input denies all", input: "", want: []bool{false, false, false, false}},
+		{name: "asks again until valid", input: "5\nfirst\n3-1\n1,3\n", want: []bool{true, false, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := ui.NewCLIAdapterWithIO(strings.NewReader(tt.input), &bytes.Buffer{})
+			adapter.SetColorEnabled(false)
+
+			assert.Equal(t, tt.want, adapter.ConfirmToolBatch(batchCalls))
+		})
+	}
+}
+
+func TestCLIAdapter_ConfirmToolBatch_RendersNumberedCalls(t *testing.T) {
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("9\nn\n"), &output)
+	adapter.SetColorEnabled(false)
+
+	adapter.ConfirmToolBatch(batchCalls[:2])
+	assert.Equal(t, "[TOOL BATCH] 2 tool calls need approval:\n"+
+		"  1. bash: go test ./... - Run the tests\n"+
+		"  2. bash: rm -rf build [DANGEROUS: recursive delete]\n"+
+		"Approve [a]ll, [n]one, or numbers (e.g. 1,3): "+
+		"there is no call 9; enter numbers from 1 to 2\n"+
+		"Approve [a]ll, [n]one, or numbers (e.g. 1,3): ", output.String())
+}
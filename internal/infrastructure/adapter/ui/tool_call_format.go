@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// toolCallMaxChars limits the command or arguments shown in a compact tool call.
+const toolCallMaxChars = 80
+
+// FormatToolCall returns a compact one-line description of a tool call, with
+// secrets masked:
+//
+//	read(src/main.go:10-50)
+//	list(src/)
+//	edit(src/main.go, +12/-3)
+//	bash(go test ./... -run TestFormat)
+//	fetch_url(url=https://example.com)
+//
+// The arguments are cut to 80 characters. Input that is not valid JSON is
+// shown as is, on one line.
+func FormatToolCall(toolName, input string) string {
+	var name, args string
+	switch toolName {
+	case "read_file":
+		name, args = "read", formatReadFileArgs(input)
+	case "list_files":
+		name, args = "list", formatListFilesArgs(input)
+	case "edit_file":
+		name, args = "edit", formatEditFileArgs(input)
+	case "bash":
+		name, args = toolName, formatBashArgs(input)
+	default:
+		name, args = toolName, formatToolArgs(input)
+	}
+	return name + "(" + shortenToolArgs(defaultRedactor.Redact(args)) + ")"
+}
+
+// formatReadFileArgs returns "path" or "path:start-end" for read_file input.
+func formatReadFileArgs(input string) string {
+	var readInput struct {
+		Path      string `json:"path"`
+		StartLine *int   `json:"start_line,omitempty"`
+		EndLine   *int   `json:"end_line,omitempty"`
+	}
+	if err := json.Unmarshal([]byte(input), &readInput); err != nil {
+		return collapseWhitespace(input)
+	}
+	if readInput.StartLine == nil && readInput.EndLine == nil {
+		return readInput.Path
+	}
+	start := 1
+	end := "end"
+	if readInput.StartLine != nil {
+		start = *readInput.StartLine
+	}
+	if readInput.EndLine != nil {
+		end = strconv.Itoa(*readInput.EndLine)
+	}
+	return fmt.Sprintf("%s:%d-%s", readInput.Path, start, end)
+}
+
+// formatListFilesArgs returns the path of list_files input.
+func formatListFilesArgs(input string) string {
+	var listInput struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(input), &listInput); err != nil {
+		return collapseWhitespace(input)
+	}
+	return listInput.Path
+}
+
+// formatEditFileArgs returns "path, +added/-removed" for edit_file input,
+// counting the lines of the new and old text.
+func formatEditFileArgs(input string) string {
+	var editInput struct {
+		Path   string `json:"path"`
+		OldStr string `json:"old_str"`
+		NewStr string `json:"new_str"`
+	}
+	if err := json.Unmarshal([]byte(input), &editInput); err != nil {
+		return collapseWhitespace(input)
+	}
+	return fmt.Sprintf("%s, +%d/-%d", editInput.Path, countLines(editInput.NewStr), countLines(editInput.OldStr))
+}
+
+// formatBashArgs returns the command of bash input on one line.
+func formatBashArgs(input string) string {
+	var bashInput struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(input), &bashInput); err != nil || bashInput.Command == "" {
+		return collapseWhitespace(input)
+	}
+	return collapseWhitespace(bashInput.Command)
+}
+
+// formatToolArgs returns the fields of a JSON object input as key=value pairs
+// sorted by key, or the input on one line if it is not an object.
+func formatToolArgs(input string) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(input), &fields); err != nil {
+		return collapseWhitespace(input)
+	}
+	pairs := make([]string, 0, len(fields))
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		var text string
+		if err := json.Unmarshal(fields[key], &text); err != nil {
+			text = string(fields[key])
+		}
+		pairs = append(pairs, key+"="+collapseWhitespace(text))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// shortenToolArgs cuts args to toolCallMaxChars characters, marking the cut with "…".
+func shortenToolArgs(args string) string {
+	runes := []rune(args)
+	if len(runes) <= toolCallMaxChars {
+		return args
+	}
+	return string(runes[:toolCallMaxChars]) + "…"
+}
+
+// collapseWhitespace joins the lines of s with single spaces.
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// countLines returns the number of lines in s, not counting a final newline.
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
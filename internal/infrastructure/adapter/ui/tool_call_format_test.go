@@ -0,0 +1,79 @@
+package ui_test
+
+import (
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatToolCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolName string
+		input    string
+		want     string
+	}{
+		{"read with line range", "read_file", `{"path": "main.go", "start_line": 10, "end_line": 20}`, "read(main.go:10-20)"},
+		{"read whole file", "read_file", `{"path": "main.go"}`, "read(main.go)"},
+		{"list", "list_files", `{"path": "internal/"}`, "list(internal/)"},
+		{
+			"edit counts added and removed lines",
+			"edit_file",
+			`{"path": "main.go", "old_str": "a\nb\nc\n", "new_str": "a\nb\nc\nd\ne"}`,
+			"edit(main.go, +5/-3)",
+		},
+		{"edit creating a file", "edit_file", `{"path": "new.go", "old_str": "", "new_str": "package main\n"}`, "edit(new.go, +1/-0)"},
+		{"bash", "bash", `{"command": "go test ./..."}`, "bash(go test ./...)"},
+		{"bash command on one line", "bash", `{"command": "cd /tmp &&\n  ls -la"}`, "bash(cd /tmp && ls -la)"},
+		{"other tools list their arguments", "fetch_url", `{"url": "https://example.com", "max_bytes": 100}`, "fetch_url(max_bytes=100, url=https://example.com)"},
+		{"invalid JSON is shown as is", "bash", "echo hello", "bash(echo hello)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ui.FormatToolCall(tt.toolName, tt.input))
+		})
+	}
+}
+
+func TestFormatToolCall_LongCommandIsCut(t *testing.T) {
+	command := "echo " + strings.Repeat("x", 200)
+
+	got := ui.FormatToolCall("bash", `{"command": "`+command+`"}`)
+
+	assert.Equal(t, "bash("+command[:80]+"…)", got)
+}
+
+func TestFormatToolCall_MasksSecrets(t *testing.T) {
+	got := ui.FormatToolCall("bash", `{"command": "API_TOKEN=s3cr3t-value ./deploy.sh"}`)
+
+	assert.Equal(t, "bash(API_TOKEN=******** ./deploy.sh)", got)
+}
+
+func TestCLIAdapter_DisplayToolResult_Verbose(t *testing.T) {
+	input := `{"command": "mysql --password hunter2 -e 'select 1'"}`
+
+	t.Run("compact by default", func(t *testing.T) {
+		output := &strings.Builder{}
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+
+		require.NoError(t, adapter.DisplayToolResult("bash", input, "1"))
+
+		assert.Contains(t, output.String(), "bash(mysql --password ******** -e 'select 1')")
+		assert.NotContains(t, output.String(), "hunter2")
+	})
+
+	t.Run("verbose shows the full input, still redacted", func(t *testing.T) {
+		output := &strings.Builder{}
+		adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), output)
+		adapter.SetVerbose(true)
+
+		require.NoError(t, adapter.DisplayToolResult("bash", input, "1"))
+
+		assert.True(t, adapter.IsVerbose())
+		assert.Contains(t, output.String(), `Tool [bash] on {"command": "mysql --password ******** -e 'select 1'"}`)
+		assert.NotContains(t, output.String(), "hunter2")
+	})
+}
@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTranscriptMaxBytes is the size at which a transcript file is rotated.
+const defaultTranscriptMaxBytes = 10 * 1024 * 1024
+
+// transcriptTimestampFormat is the timestamp prefixed to every transcript line.
+const transcriptTimestampFormat = "2006-01-02 15:04:05"
+
+// Placeholders supported in transcript path patterns.
+const (
+	transcriptDatePlaceholder    = "{date}"
+	transcriptSessionPlaceholder = "{session}"
+)
+
+// transcriptWriter appends a plain-text, timestamped log of the session to a file.
+//
+// The path may contain {date} (YYYY-MM-DD) and {session} placeholders; it is
+// resolved on every write, so a new file is started when the date or session
+// changes. When a file would grow beyond maxBytes it is renamed to <path>.<n>
+// and a fresh file is started.
+//
+// Write failures never reach the UI: the first one prints a warning to stderr
+// and later ones are dropped silently.
+//
+// transcriptWriter is safe for concurrent use.
+type transcriptWriter struct {
+	mu       sync.Mutex
+	pattern  string
+	session  string
+	maxBytes int64
+	file     *os.File
+	path     string
+	size     int64
+	warned   bool
+	now      func() time.Time
+}
+
+// newTranscriptWriter creates a transcript writer for the given path pattern and
+// opens its first file, so that an unusable path is reported immediately.
+func newTranscriptWriter(pattern, session string) (*transcriptWriter, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("transcript path cannot be empty")
+	}
+	t := &transcriptWriter{
+		pattern:  expandPath(pattern),
+		session:  session,
+		maxBytes: defaultTranscriptMaxBytes,
+		now:      time.Now,
+	}
+	if err := t.openLocked(t.resolvePath()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// setSession changes the value substituted for {session}.
+func (t *transcriptWriter) setSession(session string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session = session
+}
+
+// setMaxBytes sets the rotation size. A non-positive value uses the default.
+func (t *transcriptWriter) setMaxBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		maxBytes = defaultTranscriptMaxBytes
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxBytes = maxBytes
+}
+
+// write records text under label, one timestamped line per line of text, with
+// ANSI escape sequences removed. Trailing newlines are dropped; empty text is ignored.
+func (t *transcriptWriter) write(label, text string) {
+	text = strings.TrimRight(stripANSI(text), "\n")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prefix := "[" + t.now().Format(transcriptTimestampFormat) + "] " + label + ": "
+	var buf strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(prefix + line + "\n")
+	}
+
+	if err := t.appendLocked(buf.String()); err != nil && !t.warned {
+		t.warned = true
+		fmt.Fprintf(os.Stderr, "[Transcript] Warning: failed to write transcript: %v\n", err)
+	}
+}
+
+// appendLocked writes entry to the current file, switching or rotating files
+// first as needed. Caller must hold t.mu.
+func (t *transcriptWriter) appendLocked(entry string) error {
+	if path := t.resolvePath(); t.file == nil || path != t.path {
+		if err := t.openLocked(path); err != nil {
+			return err
+		}
+	}
+	if t.size > 0 && t.size+int64(len(entry)) > t.maxBytes {
+		if err := t.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := t.file.WriteString(entry)
+	t.size += int64(n)
+	return err
+}
+
+// resolvePath substitutes the path placeholders. Caller must hold t.mu.
+func (t *transcriptWriter) resolvePath() string {
+	session := t.session
+	if session == "" {
+		session = "session-" + strconv.Itoa(os.Getpid())
+	}
+	return strings.NewReplacer(
+		transcriptDatePlaceholder, t.now().Format("2006-01-02"),
+		transcriptSessionPlaceholder, session,
+	).Replace(t.pattern)
+}
+
+// openLocked closes the current file and opens path for appending, creating its
+// directory if needed. Caller must hold t.mu.
+func (t *transcriptWriter) openLocked(path string) error {
+	t.closeLocked()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat transcript file: %w", err)
+	}
+
+	t.file = f
+	t.path = path
+	t.size = info.Size()
+	return nil
+}
+
+// rotateLocked renames the current file to the first free <path>.<n> and starts
+// a new one. Caller must hold t.mu.
+func (t *transcriptWriter) rotateLocked() error {
+	path := t.path
+	t.closeLocked()
+
+	for n := 1; ; n++ {
+		rotated := path + "." + strconv.Itoa(n)
+		if _, err := os.Stat(rotated); errors.Is(err, os.ErrNotExist) {
+			if err := os.Rename(path, rotated); err != nil {
+				return fmt.Errorf("failed to rotate transcript file: %w", err)
+			}
+			break
+		}
+	}
+	return t.openLocked(path)
+}
+
+// closeLocked closes the current file, if any. Caller must hold t.mu.
+func (t *transcriptWriter) closeLocked() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
+
+// close closes the transcript file.
+func (t *transcriptWriter) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
+
+// EnableTranscript starts writing a plain-text transcript of the session to path:
+// displayed messages, tool results (after truncation), errors, and user input,
+// each line prefixed with a "[YYYY-MM-DD HH:MM:SS] <kind>: " header and stripped
+// of ANSI codes.
+//
+// The path may contain {date} and {session} placeholders (for example
+// "~/.agent/transcripts/{date}-{session}.log"); {session} is the ID passed to
+// SetSessionID, or a per-process name until one is set. Files are rotated once
+// they reach 10MB (see SetTranscriptMaxBytes). Any previous transcript is closed.
+//
+// An error is returned only if the transcript file cannot be opened; later write
+// failures print a single warning and never affect the UI.
+func (c *CLIAdapter) EnableTranscript(path string) error {
+	c.mu.RLock()
+	session := c.sessionID
+	c.mu.RUnlock()
+
+	t, err := newTranscriptWriter(path, session)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	previous := c.transcript
+	c.transcript = t
+	c.mu.Unlock()
+
+	if previous != nil {
+		_ = previous.close()
+	}
+	return nil
+}
+
+// SetTranscriptMaxBytes sets the size at which transcript files are rotated.
+// A non-positive value restores the 10MB default. It has no effect until
+// EnableTranscript has been called.
+func (c *CLIAdapter) SetTranscriptMaxBytes(maxBytes int64) {
+	if t := c.transcriptWriter(); t != nil {
+		t.setMaxBytes(maxBytes)
+	}
+}
+
+// CloseTranscript stops writing the transcript and closes its file.
+func (c *CLIAdapter) CloseTranscript() error {
+	c.mu.Lock()
+	t := c.transcript
+	c.transcript = nil
+	c.mu.Unlock()
+
+	if t == nil {
+		return nil
+	}
+	return t.close()
+}
+
+// transcriptWriter returns the active transcript, or nil.
+func (c *CLIAdapter) transcriptWriter() *transcriptWriter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.transcript
+}
+
+// recordTranscript appends text to the transcript, if one is enabled.
+func (c *CLIAdapter) recordTranscript(label, text string) {
+	if t := c.transcriptWriter(); t != nil {
+		t.write(label, text)
+	}
+}
+
+// recordTranscriptLocked is recordTranscript for callers holding c.mu.
+func (c *CLIAdapter) recordTranscriptLocked(label, text string) {
+	if c.transcript != nil {
+		c.transcript.write(label, text)
+	}
+}
@@ -0,0 +1,136 @@
+package ui_test
+
+import (
+	"bytes"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var transcriptTimestampPattern = regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\] `)
+
+func readTranscriptLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestCLIAdapter_Transcript_RecordsSessionWithoutANSI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	var output bytes.Buffer
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader("hello there\n"), &output)
+	require.NoError(t, adapter.EnableTranscript(path))
+
+	input, ok := adapter.GetUserInput(t.Context())
+	require.True(t, ok)
+	require.Equal(t, "hello there", input)
+	require.NoError(t, adapter.DisplayMessage("# Plan\n\x1b[1mbold\x1b[0m", "assistant"))
+	require.NoError(t, adapter.DisplayToolResult("bash", "ls", "\x1b[32mfile.go\x1b[0m"))
+	require.NoError(t, adapter.DisplayError(errors.New("boom")))
+	require.NoError(t, adapter.DisplaySystemMessage("saved"))
+	require.NoError(t, adapter.BeginStreamingResponse())
+	require.NoError(t, adapter.DisplayStreamingText("streamed "))
+	require.NoError(t, adapter.DisplayStreamingText("reply"))
+	require.NoError(t, adapter.EndStreamingResponse())
+	require.NoError(t, adapter.CloseTranscript())
+
+	var bodies []string
+	for _, line := range readTranscriptLines(t, path) {
+		assert.Regexp(t, transcriptTimestampPattern, line)
+		assert.NotContains(t, line, "\x1b", "transcript must not contain ANSI codes")
+		bodies = append(bodies, transcriptTimestampPattern.ReplaceAllString(line, ""))
+	}
+	assert.Equal(t, []string{
+		"user: hello there",
+		"assistant: # Plan",
+		"assistant: bold",
+		"tool: bash(ls)",
+		"tool: file.go",
+		"error: boom",
+		"system: saved",
+		"assistant: streamed reply",
+	}, bodies)
+
+	// The terminal output is unaffected by the transcript
+	assert.Contains(t, output.String(), "\x1b[")
+}
+
+func TestCLIAdapter_Transcript_SessionPattern(t *testing.T) {
+	dir := t.TempDir()
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	require.NoError(t, adapter.EnableTranscript(filepath.Join(dir, "{date}-{session}.log")))
+
+	adapter.SetSessionID("abc123")
+	require.NoError(t, adapter.DisplaySystemMessage("hi"))
+	require.NoError(t, adapter.CloseTranscript())
+
+	path := filepath.Join(dir, time.Now().Format("2006-01-02")+"-abc123.log")
+	lines := readTranscriptLines(t, path)
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasSuffix(lines[0], "] system: hi"))
+}
+
+func TestCLIAdapter_Transcript_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	require.NoError(t, adapter.EnableTranscript(path))
+	adapter.SetTranscriptMaxBytes(100)
+
+	for range 5 {
+		require.NoError(t, adapter.DisplaySystemMessage(strings.Repeat("x", 40)))
+	}
+	require.NoError(t, adapter.CloseTranscript())
+
+	assert.FileExists(t, path+".1")
+	assert.FileExists(t, path+".2")
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(100))
+	}
+}
+
+func TestCLIAdapter_Transcript_WriteFailureWarnsOnce(t *testing.T) {
+	dir := t.TempDir()
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	require.NoError(t, adapter.EnableTranscript(filepath.Join(dir, "{session}", "transcript.log")))
+
+	// A regular file where the session directory should go makes every write fail
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blocked"), nil, 0o600))
+	adapter.SetSessionID("blocked")
+
+	stderrReader, stderrWriter, err := os.Pipe()
+	require.NoError(t, err)
+	originalStderr := os.Stderr
+	os.Stderr = stderrWriter
+	t.Cleanup(func() { os.Stderr = originalStderr })
+
+	for range 3 {
+		assert.NoError(t, adapter.DisplaySystemMessage("still works"))
+	}
+
+	os.Stderr = originalStderr
+	require.NoError(t, stderrWriter.Close())
+	warnings, err := io.ReadAll(stderrReader)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(warnings), "[Transcript] Warning"))
+}
+
+func TestCLIAdapter_EnableTranscript_InvalidPath(t *testing.T) {
+	adapter := ui.NewCLIAdapterWithIO(strings.NewReader(""), io.Discard)
+	assert.Error(t, adapter.EnableTranscript(""))
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	assert.Error(t, adapter.EnableTranscript(filepath.Join(file, "transcript.log")))
+}
@@ -161,3 +161,236 @@ func TruncateBashOutput(output string, config TruncationConfig) (string, int) {
 
 	return string(result), totalRemoved
 }
+
+// Markers JSON truncation puts in place of what it leaves out.
+const (
+	jsonItemsOmittedFormat = "… %d items omitted …"
+	jsonCharsOmittedFormat = "… %d chars omitted"
+	jsonElidedValue        = "… elided …"
+)
+
+// JSONTruncationOptions controls how TruncateJSONOutput shortens a JSON value.
+type JSONTruncationOptions struct {
+	// HeadItems is the number of elements kept from the start of a long array.
+	HeadItems int
+	// TailItems is the number of elements kept from the end of a long array.
+	TailItems int
+	// MaxStringChars caps string values; longer ones are cut to this many characters.
+	MaxStringChars int
+	// ElideFields names object fields whose values are replaced by a marker
+	// wherever they appear, such as bulky metadata a tool returns.
+	ElideFields []string
+}
+
+// DefaultJSONTruncationOptions returns the default JSON truncation options:
+// arrays keep their first 10 and last 5 elements and strings are capped at
+// 200 characters.
+func DefaultJSONTruncationOptions() JSONTruncationOptions {
+	return JSONTruncationOptions{
+		HeadItems:      10,
+		TailItems:      5,
+		MaxStringChars: 200,
+	}
+}
+
+// TruncateJSONOutput truncates a tool's JSON output while keeping its
+// structure. Arrays longer than HeadItems+TailItems keep their first and last
+// elements around a "… N items omitted …" element, long strings are cut with
+// a "… N chars omitted" suffix, and ElideFields are replaced by a marker, at
+// any depth. Object keys keep their order, and a changed value is re-indented.
+//
+// If the output is not a JSON object or array, it falls back to
+// TruncateOutput. Output that needs no shortening is returned unchanged.
+//
+// Returns:
+//   - The (possibly truncated) output string
+//   - The number of array items, strings, and fields shortened or omitted
+func TruncateJSONOutput(output string, config TruncationConfig, opts JSONTruncationOptions) (string, int) {
+	if !config.Enabled || output == "" {
+		return output, 0
+	}
+	root, ok := parseJSONNode(output)
+	if !ok || (root.kind != jsonObject && root.kind != jsonArray) {
+		return TruncateOutput(output, config)
+	}
+
+	elide := make(map[string]bool, len(opts.ElideFields))
+	for _, field := range opts.ElideFields {
+		elide[field] = true
+	}
+	removed := root.truncate(opts, elide)
+	if removed == 0 {
+		return output, 0
+	}
+
+	var b strings.Builder
+	root.write(&b, "")
+	return b.String(), removed
+}
+
+// jsonKind is the kind of a parsed JSON value.
+type jsonKind int
+
+const (
+	jsonScalar jsonKind = iota // Number, boolean, or null, kept as written
+	jsonString
+	jsonObject
+	jsonArray
+)
+
+// jsonNode is a parsed JSON value that, unlike map[string]any, keeps the
+// order of object keys.
+type jsonNode struct {
+	kind   jsonKind
+	scalar string      // Literal of a jsonScalar
+	str    string      // Value of a jsonString
+	keys   []string    // Keys of a jsonObject, in order
+	values []*jsonNode // Values of a jsonObject's keys, or a jsonArray's elements
+}
+
+// parseJSONNode parses output as a single JSON value.
+func parseJSONNode(output string) (*jsonNode, bool) {
+	dec := json.NewDecoder(strings.NewReader(output))
+	dec.UseNumber()
+	node, err := decodeJSONNode(dec)
+	if err != nil {
+		return nil, false
+	}
+	// Anything after the value means the output was not one JSON document
+	if strings.TrimSpace(output[dec.InputOffset():]) != "" {
+		return nil, false
+	}
+	return node, true
+}
+
+// decodeJSONNode decodes the next value from dec.
+func decodeJSONNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		node := &jsonNode{kind: jsonArray}
+		if v == '{' {
+			node.kind = jsonObject
+		}
+		for dec.More() {
+			if node.kind == jsonObject {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				node.keys = append(node.keys, key)
+			}
+			child, err := decodeJSONNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+		}
+		// Consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &jsonNode{kind: jsonString, str: v}, nil
+	case json.Number:
+		return &jsonNode{kind: jsonScalar, scalar: v.String()}, nil
+	case bool:
+		return &jsonNode{kind: jsonScalar, scalar: fmt.Sprint(v)}, nil
+	default:
+		return &jsonNode{kind: jsonScalar, scalar: "null"}, nil
+	}
+}
+
+// truncate shortens n in place and returns how many items, strings, and
+// fields it shortened or omitted.
+func (n *jsonNode) truncate(opts JSONTruncationOptions, elide map[string]bool) int {
+	removed := 0
+	switch n.kind {
+	case jsonString:
+		runes := []rune(n.str)
+		if opts.MaxStringChars > 0 && len(runes) > opts.MaxStringChars {
+			n.str = string(runes[:opts.MaxStringChars]) +
+				fmt.Sprintf(jsonCharsOmittedFormat, len(runes)-opts.MaxStringChars)
+			removed++
+		}
+	case jsonObject:
+		for i, key := range n.keys {
+			if elide[key] {
+				n.values[i] = &jsonNode{kind: jsonString, str: jsonElidedValue}
+				removed++
+				continue
+			}
+			removed += n.values[i].truncate(opts, elide)
+		}
+	case jsonArray:
+		keep := opts.HeadItems + opts.TailItems
+		if len(n.values) <= keep {
+			for _, value := range n.values {
+				removed += value.truncate(opts, elide)
+			}
+			break
+		}
+		omitted := len(n.values) - keep
+		head, tail := n.values[:opts.HeadItems], n.values[len(n.values)-opts.TailItems:]
+		for _, value := range append(head[:len(head):len(head)], tail...) {
+			removed += value.truncate(opts, elide)
+		}
+		values := make([]*jsonNode, 0, keep+1)
+		values = append(values, head...)
+		values = append(values, &jsonNode{kind: jsonString, str: fmt.Sprintf(jsonItemsOmittedFormat, omitted)})
+		values = append(values, tail...)
+		n.values = values
+		removed += omitted
+	case jsonScalar:
+	}
+	return removed
+}
+
+// write writes n as JSON indented by two spaces per level, the first line
+// unindented and the rest prefixed with indent.
+func (n *jsonNode) write(b *strings.Builder, indent string) {
+	switch n.kind {
+	case jsonScalar:
+		b.WriteString(n.scalar)
+	case jsonString:
+		writeJSONString(b, n.str)
+	case jsonObject, jsonArray:
+		open, closing := "[", "]"
+		if n.kind == jsonObject {
+			open, closing = "{", "}"
+		}
+		if len(n.values) == 0 {
+			b.WriteString(open + closing)
+			return
+		}
+		b.WriteString(open + "\n")
+		inner := indent + "  "
+		for i, value := range n.values {
+			b.WriteString(inner)
+			if n.kind == jsonObject {
+				writeJSONString(b, n.keys[i])
+				b.WriteString(": ")
+			}
+			value.write(b, inner)
+			if i < len(n.values)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + closing)
+	}
+}
+
+// writeJSONString writes s as a JSON string literal without HTML escaping.
+func writeJSONString(b *strings.Builder, s string) {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	b.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+}
@@ -1687,3 +1687,122 @@ func TestTruncateBashOutput_EdgeCases(t *testing.T) {
 		assert.Equal(t, 0, parsed.ExitCode, "exit_code should be 0")
 	})
 }
+
+// =============================================================================
+// Test: TruncateJSONOutput
+// =============================================================================
+
+// jsonArrayOf returns a compact JSON array of count objects like {"name": "file-1"}.
+func jsonArrayOf(count int) string {
+	items := make([]map[string]string, count)
+	for i := range items {
+		items[i] = map[string]string{"name": fmt.Sprintf("file-%d", i+1)}
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
+
+func TestTruncateJSONOutput_Arrays(t *testing.T) {
+	config := ui.DefaultTruncationConfig()
+	opts := ui.JSONTruncationOptions{HeadItems: 2, TailItems: 1, MaxStringChars: 100}
+
+	t.Run("keeps the first and last elements of a long array", func(t *testing.T) {
+		result, removed := ui.TruncateJSONOutput(jsonArrayOf(10), config, opts)
+
+		assert.Equal(t, 7, removed)
+		assert.Equal(t, `[
+  {
+    "name": "file-1"
+  },
+  {
+    "name": "file-2"
+  },
+  "… 7 items omitted …",
+  {
+    "name": "file-10"
+  }
+]`, result)
+
+		var parsed []any
+		require.NoError(t, json.Unmarshal([]byte(result), &parsed), "truncated output should stay valid JSON")
+		assert.Len(t, parsed, 4)
+	})
+
+	t.Run("leaves a short array unchanged", func(t *testing.T) {
+		output := jsonArrayOf(3)
+		result, removed := ui.TruncateJSONOutput(output, config, opts)
+
+		assert.Equal(t, output, result)
+		assert.Zero(t, removed)
+	})
+}
+
+func TestTruncateJSONOutput_NestedObjects(t *testing.T) {
+	config := ui.DefaultTruncationConfig()
+	opts := ui.JSONTruncationOptions{HeadItems: 1, TailItems: 1, MaxStringChars: 10, ElideFields: []string{"managedFields"}}
+	output := `{"kind":"PodList","items":[` +
+		`{"metadata":{"name":"api-1","managedFields":[{"manager":"kubectl"}]},"status":{"message":"Back-off restarting failed container"}},` +
+		`{"metadata":{"name":"api-2"}},` +
+		`{"metadata":{"name":"api-3","managedFields":{"x":1}},"ready":true,"restarts":3,"node":null}` +
+		`]}`
+
+	result, removed := ui.TruncateJSONOutput(output, config, opts)
+
+	// One array item omitted, two managedFields elided, one string cut
+	assert.Equal(t, 4, removed)
+	assert.Equal(t, `{
+  "kind": "PodList",
+  "items": [
+    {
+      "metadata": {
+        "name": "api-1",
+        "managedFields": "… elided …"
+      },
+      "status": {
+        "message": "Back-off r… 26 chars omitted"
+      }
+    },
+    "… 1 items omitted …",
+    {
+      "metadata": {
+        "name": "api-3",
+        "managedFields": "… elided …"
+      },
+      "ready": true,
+      "restarts": 3,
+      "node": null
+    }
+  ]
+}`, result, "keys should keep their order and scalars their literals")
+}
+
+func TestTruncateJSONOutput_Fallback(t *testing.T) {
+	config := ui.TruncationConfig{HeadLines: 2, TailLines: 1, Enabled: true}
+	opts := ui.DefaultJSONTruncationOptions()
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"invalid JSON", "[1, 2,\n3\n4\n5\nnot json"},
+		{"scalar", `"just a string"`},
+		{"trailing text", "{}\nLine 2\nLine 3\nLine 4\nLine 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, removed := ui.TruncateJSONOutput(tt.output, config, opts)
+			wantResult, wantRemoved := ui.TruncateOutput(tt.output, config)
+
+			assert.Equal(t, wantResult, result, "should fall back to plain truncation")
+			assert.Equal(t, wantRemoved, removed)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		output := jsonArrayOf(100)
+		result, removed := ui.TruncateJSONOutput(output, ui.TruncationConfig{Enabled: false}, opts)
+
+		assert.Equal(t, output, result)
+		assert.Zero(t, removed)
+	})
+}
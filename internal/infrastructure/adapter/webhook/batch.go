@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"cmp"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/alert"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DefaultBatchSource is the source of batch alerts when the request names none.
+const DefaultBatchSource = "backfill"
+
+// SetBackfiller sets the backfiller behind POST /webhook/batch. Without one,
+// the endpoint returns 501.
+func (a *HTTPAdapter) SetBackfiller(backfiller port.AlertBackfiller) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.backfiller = backfiller
+}
+
+// handleBatch accepts a batch of past alerts, in the format of
+// alert.ParseAlertmanagerBatch, from the source given by the source query
+// parameter, and returns 202 once they are queued. Batches are investigated
+// in the background one alert at a time, in the order received, as
+// historical alerts; see port.AlertBackfiller.
+func (a *HTTPAdapter) handleBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.draining.Load() {
+		writeJSONError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+
+	a.mu.RLock()
+	backfiller := a.backfiller
+	a.mu.RUnlock()
+	if backfiller == nil {
+		writeJSONError(w, http.StatusNotImplemented, "batch ingestion not configured")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	source := cmp.Or(r.URL.Query().Get("source"), DefaultBatchSource)
+	alerts, err := alert.ParseAlertmanagerBatch(source, payload)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse batch: %v", err))
+		return
+	}
+
+	// Queue the batch behind the last one received
+	a.mu.Lock()
+	previous, done := a.lastBatch, make(chan struct{})
+	a.lastBatch = done
+	a.mu.Unlock()
+	a.wg.Add(1)
+	go a.runBatch(backfiller, alerts, previous, done)
+
+	w.WriteHeader(http.StatusAccepted)
+	resp, _ := json.Marshal(map[string]interface{}{
+		"status":   "accepted",
+		"received": len(alerts),
+	})
+	_, _ = w.Write(resp)
+}
+
+// runBatch investigates a batch once the previous batch, if any, is done, so
+// backfills take at most one slot between them. It closes done when finished.
+func (a *HTTPAdapter) runBatch(
+	backfiller port.AlertBackfiller,
+	alerts []*entity.Alert,
+	previous <-chan struct{},
+	done chan<- struct{},
+) {
+	defer a.wg.Done()
+	defer close(done)
+	if previous != nil {
+		<-previous
+	}
+
+	// Use investigation context so the batch can be cancelled during shutdown
+	err := backfiller.Backfill(a.invCtx, alerts, 1, func(outcome port.BackfillOutcome) {
+		if outcome.Err != nil {
+			fmt.Fprintf(os.Stderr, "[Webhook] Backfill of alert %s failed: %v\n", outcome.AlertID, outcome.Err)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Webhook] Backfill of %d alerts stopped: %v\n", len(alerts), err)
+	}
+}
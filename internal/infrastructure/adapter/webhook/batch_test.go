@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingBackfiller records the batches it is asked to backfill.
+type recordingBackfiller struct {
+	mu      sync.Mutex
+	batches [][]*entity.Alert
+}
+
+func (b *recordingBackfiller) Backfill(
+	_ context.Context,
+	alerts []*entity.Alert,
+	concurrency int,
+	_ func(port.BackfillOutcome),
+) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if concurrency == 1 {
+		b.batches = append(b.batches, alerts)
+	}
+	return nil
+}
+
+func TestHTTPAdapter_Batch(t *testing.T) {
+	backfiller := &recordingBackfiller{}
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetBackfiller(backfiller)
+
+	for _, name := range []string{"HighCPU", "DiskFull"} {
+		body := `[{"labels":{"alertname":"` + name + `"},"startsAt":"2026-10-10T08:00:00Z","status":{"state":"resolved"}}]`
+		req := httptest.NewRequest(http.MethodPost, "/webhook/batch?source=am", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		adapter.Mux().ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"received":1`) {
+			t.Fatalf("POST /webhook/batch = %d %s, want 202 with one alert received", rec.Code, rec.Body.String())
+		}
+	}
+	adapter.wg.Wait()
+
+	if len(backfiller.batches) != 2 {
+		t.Fatalf("batches = %d, want 2 backfilled one alert at a time", len(backfiller.batches))
+	}
+	for i, name := range []string{"HighCPU", "DiskFull"} {
+		alert := backfiller.batches[i][0]
+		if !strings.HasPrefix(alert.ID(), name) || alert.Source() != "am" || !alert.Historical() {
+			t.Errorf("batch %d alert = %s from %s (historical %v), want historical %s from am",
+				i, alert.ID(), alert.Source(), alert.Historical(), name)
+		}
+	}
+}
+
+func TestHTTPAdapter_BatchErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		backfiller port.AlertBackfiller
+		body       string
+		wantStatus int
+	}{
+		{"not configured", nil, `[]`, http.StatusNotImplemented},
+		{"invalid batch", &recordingBackfiller{}, `{"alerts":`, http.StatusBadRequest},
+		{"no alerts", &recordingBackfiller{}, `[]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+			if tt.backfiller != nil {
+				adapter.SetBackfiller(tt.backfiller)
+			}
+			rec := httptest.NewRecorder()
+			adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook/batch", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("POST /webhook/batch = %d %s, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// SetInvestigationCanceller sets the canceller behind DELETE
+// /investigations/{id}. Without one, the endpoint returns 501.
+func (a *HTTPAdapter) SetInvestigationCanceller(canceller port.InvestigationCanceller) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.canceller = canceller
+}
+
+// handleCancelInvestigation cancels the running investigation in the path,
+// with the "reason" and "requested_by" query parameters recorded as why and
+// by whom. It returns 202 once the investigation is asked to stop, 404 for an
+// unknown investigation, and 409 for one that has already finished.
+func (a *HTTPAdapter) handleCancelInvestigation(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	canceller := a.canceller
+	a.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if canceller == nil {
+		writeJSONError(w, http.StatusNotImplemented, "investigation cancellation not configured")
+		return
+	}
+
+	id := r.PathValue("id")
+	query := r.URL.Query()
+	if err := canceller.CancelInvestigation(r.Context(), id, query.Get("reason"), query.Get("requested_by")); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, port.ErrInvestigationNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, port.ErrInvestigationFinished):
+			status = http.StatusConflict
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	resp, _ := json.Marshal(map[string]string{"status": "cancelling", "investigation_id": id})
+	_, _ = w.Write(resp)
+}
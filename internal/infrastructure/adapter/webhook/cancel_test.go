@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeCanceller records cancellations and fails with err when set.
+type fakeCanceller struct {
+	id, reason, requestedBy string
+	err                     error
+}
+
+func (f *fakeCanceller) CancelInvestigation(_ context.Context, id, reason, requestedBy string) error {
+	f.id, f.reason, f.requestedBy = id, reason, requestedBy
+	return f.err
+}
+
+func TestHTTPAdapter_CancelInvestigation(t *testing.T) {
+	canceller := &fakeCanceller{}
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetInvestigationCanceller(canceller)
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete,
+		"/investigations/inv-1?reason=looping+on+df&requested_by=alice", nil))
+
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"status":"cancelling"`) {
+		t.Errorf("response = %d %q, want 202 cancelling", rec.Code, rec.Body.String())
+	}
+	if canceller.id != "inv-1" || canceller.reason != "looping on df" || canceller.requestedBy != "alice" {
+		t.Errorf("cancelled %q with reason %q by %q, want inv-1, looping on df, alice",
+			canceller.id, canceller.reason, canceller.requestedBy)
+	}
+}
+
+func TestHTTPAdapter_CancelInvestigationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unknown", fmt.Errorf("%w: inv-1", port.ErrInvestigationNotFound), http.StatusNotFound},
+		{"finished", fmt.Errorf("%w: inv-1 is completed", port.ErrInvestigationFinished), http.StatusConflict},
+		{"store failure", fmt.Errorf("failed to look up investigation inv-1: disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+			adapter.SetInvestigationCanceller(&fakeCanceller{err: tt.err})
+
+			rec := httptest.NewRecorder()
+			adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/investigations/inv-1", nil))
+
+			if rec.Code != tt.want || !strings.Contains(rec.Body.String(), tt.err.Error()) {
+				t.Errorf("response = %d %q, want %d with the error", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPAdapter_CancelInvestigationNotConfigured(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/investigations/inv-1", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
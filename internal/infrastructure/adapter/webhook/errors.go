@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"errors"
+	"net/http"
+)
+
+// errorStatus maps an investigation error to the HTTP status reported for
+// it: the sender's fault (an invalid alert) is 400, a refusal by safety
+// policy 403 or 422, and an unreachable AI provider 503 so senders retry.
+// Any other error is 500.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, port.ErrInvalidAlert):
+		return http.StatusBadRequest
+	case errors.Is(err, port.ErrToolBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, port.ErrActionBudgetExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, port.ErrProviderUnavailable), errors.Is(err, port.ErrConversationStart):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid alert", fmt.Errorf("%w: empty alert ID", port.ErrInvalidAlert), http.StatusBadRequest},
+		{"tool blocked", port.ErrToolBlocked, http.StatusForbidden},
+		{"action budget", fmt.Errorf("investigation inv-1: %w", port.ErrActionBudgetExceeded), http.StatusUnprocessableEntity},
+		{"provider unavailable", fmt.Errorf("%w: EOF", port.ErrProviderUnavailable), http.StatusServiceUnavailable},
+		{"conversation start", port.ErrConversationStart, http.StatusServiceUnavailable},
+		{"prompt build", port.ErrPromptBuild, http.StatusInternalServerError},
+		{"unclassified", errors.New("disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorStatus(tt.err); got != tt.want {
+				t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// newErrorTestAdapter returns an adapter whose prometheus webhook yields one alert.
+func newErrorTestAdapter() *HTTPAdapter {
+	webhookSource := &mockWebhookSource{
+		mockAlertSource: mockAlertSource{name: "prometheus", sourceType: port.SourceTypeWebhook},
+		webhookPath:     "/alerts/prometheus",
+		handleFunc: func(_ context.Context, _ []byte) ([]*entity.Alert, error) {
+			alert, _ := entity.NewAlert("alert-1", "prometheus", "critical", "Critical Alert")
+			return []*entity.Alert{alert}, nil
+		},
+	}
+	return NewHTTPAdapter(&mockSourceManager{sources: []port.AlertSource{webhookSource}}, DefaultConfig())
+}
+
+func TestHTTPAdapter_AsyncHandler_StartErrorStatus(t *testing.T) {
+	adapter := newErrorTestAdapter()
+	adapter.SetAsyncAlertHandler(
+		func(_ context.Context, _ *entity.Alert) (string, error) {
+			return "", fmt.Errorf("%w: empty alert ID", port.ErrInvalidAlert)
+		},
+		func(_ context.Context, _ *entity.Alert, _ string) error {
+			t.Error("runner should not be called when start fails")
+			return nil
+		},
+	)
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["reason"] != "invalid alert: empty alert ID" {
+		t.Errorf("expected the start error as reason, got %v", resp["reason"])
+	}
+}
+
+func TestHTTPAdapter_SyncHandler_ErrorStatus(t *testing.T) {
+	adapter := newErrorTestAdapter()
+	adapter.SetAlertHandler(func(_ context.Context, _ *entity.Alert) error {
+		return fmt.Errorf("investigation inv-1 of alert alert-1: %w: connection refused", port.ErrProviderUnavailable)
+	})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
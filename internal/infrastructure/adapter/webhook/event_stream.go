@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultEventBufferSize is how many events a stream subscriber may fall
+// behind before it is evicted.
+const DefaultEventBufferSize = 64
+
+// eventKeepAliveInterval is how often an idle event stream sends an SSE
+// comment so proxies do not close it.
+const eventKeepAliveInterval = 15 * time.Second
+
+// EventRecorder persists investigation events so streams can replay them.
+// FileInvestigationStore implements it.
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, investigationID string, event port.InvestigationEvent) error
+	Events(ctx context.Context, investigationID string) ([]port.InvestigationEvent, error)
+}
+
+// EventBroker is a port.InvestigationProgressSink that numbers, timestamps,
+// and records investigation events, then fans them out to the subscribers of
+// each investigation. Each subscriber has its own buffer; one that falls more
+// than the buffer size behind is evicted rather than slowing the investigation
+// or other subscribers.
+type EventBroker struct {
+	recorder    EventRecorder
+	bufferSize  int
+	now         func() time.Time
+	mu          sync.Mutex
+	sequences   map[string]int // Last sequence number of each running investigation
+	subscribers map[string]map[*EventSubscription]struct{}
+}
+
+// EventSubscription is one subscriber's view of an investigation's events:
+// those recorded when it subscribed, then live ones.
+type EventSubscription struct {
+	investigationID string
+	// Replay holds the events recorded before the subscription, oldest first.
+	Replay []port.InvestigationEvent
+	// Events delivers live events. It may repeat the last replayed events, so
+	// skip those with a Sequence already seen. It is closed after the terminal
+	// event, or early if the subscriber is evicted.
+	Events  <-chan port.InvestigationEvent
+	events  chan port.InvestigationEvent
+	evicted bool // Set before Events is closed early
+}
+
+// Evicted reports whether Events was closed because the subscriber fell too
+// far behind. Only meaningful once Events is closed.
+func (s *EventSubscription) Evicted() bool {
+	return s.evicted
+}
+
+// NewEventBroker creates a broker that records events with recorder. A
+// bufferSize of zero or less uses DefaultEventBufferSize.
+func NewEventBroker(recorder EventRecorder, bufferSize int) *EventBroker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultEventBufferSize
+	}
+	return &EventBroker{
+		recorder:    recorder,
+		bufferSize:  bufferSize,
+		now:         time.Now,
+		sequences:   make(map[string]int),
+		subscribers: make(map[string]map[*EventSubscription]struct{}),
+	}
+}
+
+// OnInvestigationEvent implements port.InvestigationProgressSink. It never
+// blocks on subscribers.
+func (b *EventBroker) OnInvestigationEvent(event port.InvestigationEvent) {
+	id := event.InvestigationID
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sequences[id]++
+	event.Sequence = b.sequences[id]
+	event.Time = b.now()
+
+	// Record while holding the lock so a new subscriber either replays the
+	// event or receives it live
+	if err := b.recorder.RecordEvent(context.Background(), id, event); err != nil {
+		fmt.Fprintf(os.Stderr, "[Webhook] Failed to record event %d of investigation %s: %v\n",
+			event.Sequence, id, err)
+	}
+
+	for sub := range b.subscribers[id] {
+		select {
+		case sub.events <- event:
+		default:
+			sub.evicted = true
+			b.removeLocked(sub)
+		}
+	}
+
+	if event.Type.Terminal() {
+		for sub := range b.subscribers[id] {
+			b.removeLocked(sub)
+		}
+		delete(b.sequences, id)
+	}
+}
+
+// Subscribe starts following an investigation's events. Call Unsubscribe when
+// done. Investigations that have not emitted an event yet can be subscribed to;
+// their events arrive once they start.
+func (b *EventBroker) Subscribe(ctx context.Context, investigationID string) (*EventSubscription, error) {
+	events := make(chan port.InvestigationEvent, b.bufferSize)
+	sub := &EventSubscription{investigationID: investigationID, Events: events, events: events}
+
+	b.mu.Lock()
+	if b.subscribers[investigationID] == nil {
+		b.subscribers[investigationID] = make(map[*EventSubscription]struct{})
+	}
+	b.subscribers[investigationID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	replay, err := b.recorder.Events(ctx, investigationID)
+	if err != nil {
+		b.Unsubscribe(sub)
+		return nil, err
+	}
+	sub.Replay = replay
+	return sub, nil
+}
+
+// Unsubscribe stops delivering events to sub. It is safe to call more than once.
+func (b *EventBroker) Unsubscribe(sub *EventSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub.investigationID][sub]; ok {
+		b.removeLocked(sub)
+	}
+}
+
+// SubscriberCount returns the number of subscribers following an investigation.
+func (b *EventBroker) SubscriberCount(investigationID string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[investigationID])
+}
+
+// removeLocked closes a registered subscriber's channel and forgets it. b.mu must be held.
+func (b *EventBroker) removeLocked(sub *EventSubscription) {
+	close(sub.events)
+	delete(b.subscribers[sub.investigationID], sub)
+	if len(b.subscribers[sub.investigationID]) == 0 {
+		delete(b.subscribers, sub.investigationID)
+	}
+}
+
+// SetEventBroker sets the broker behind GET /investigations/{id}/events.
+// Without one, the endpoint returns 404.
+func (a *HTTPAdapter) SetEventBroker(broker *EventBroker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.eventBroker = broker
+}
+
+// handleInvestigationEvents streams an investigation's events as Server-Sent
+// Events: first those recorded so far, then live ones, ending after the
+// terminal event. A Last-Event-ID header resumes after that sequence number.
+// Evicted clients receive an "evicted" event and can reconnect to resume.
+func (a *HTTPAdapter) handleInvestigationEvents(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	broker := a.eventBroker
+	a.mu.RUnlock()
+
+	if broker == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"event streaming is not enabled"}`))
+		return
+	}
+
+	sub, err := broker.Subscribe(r.Context(), r.PathValue("id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		resp, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("failed to load events: %v", err)})
+		_, _ = w.Write(resp)
+		return
+	}
+	defer broker.Unsubscribe(sub)
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	lastSeq, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	for _, event := range sub.Replay {
+		if event.Sequence > lastSeq {
+			writeSSEEvent(w, event)
+			lastSeq = event.Sequence
+		}
+		if event.Type.Terminal() {
+			_ = rc.Flush()
+			return
+		}
+	}
+	_ = rc.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				if sub.Evicted() {
+					_, _ = fmt.Fprintf(w, "event: evicted\ndata: {\"reason\":\"client fell too far behind\"}\n\n")
+					_ = rc.Flush()
+				}
+				return
+			}
+			if event.Sequence <= lastSeq {
+				continue
+			}
+			writeSSEEvent(w, event)
+			_ = rc.Flush()
+			lastSeq = event.Sequence
+			if event.Type.Terminal() {
+				return
+			}
+		case <-keepAlive.C:
+			_, _ = w.Write([]byte(": keep-alive\n\n"))
+			_ = rc.Flush()
+		}
+	}
+}
+
+// writeSSEEvent writes an event in Server-Sent Events format, using its
+// sequence number as the event ID and its type as the event name.
+func writeSSEEvent(w http.ResponseWriter, event port.InvestigationEvent) {
+	data, _ := json.Marshal(event)
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data)
+}
@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"bufio"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryEventRecorder is an in-memory EventRecorder.
+type memoryEventRecorder struct {
+	mu     sync.Mutex
+	events map[string][]port.InvestigationEvent
+}
+
+func (r *memoryEventRecorder) RecordEvent(_ context.Context, id string, event port.InvestigationEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make(map[string][]port.InvestigationEvent)
+	}
+	r.events[id] = append(r.events[id], event)
+	return nil
+}
+
+func (r *memoryEventRecorder) Events(_ context.Context, id string) ([]port.InvestigationEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]port.InvestigationEvent{}, r.events[id]...), nil
+}
+
+// fakeClock returns times one second apart starting at start.
+func fakeClock(start time.Time) func() time.Time {
+	var mu sync.Mutex
+	next := start
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now := next
+		next = next.Add(time.Second)
+		return now
+	}
+}
+
+var clockStart = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+// newEventStreamServer starts an adapter serving events from a broker with a
+// fake clock.
+func newEventStreamServer(t *testing.T, bufferSize int) (*EventBroker, *httptest.Server) {
+	t.Helper()
+	broker := NewEventBroker(&memoryEventRecorder{}, bufferSize)
+	broker.now = fakeClock(clockStart)
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetEventBroker(broker)
+	server := httptest.NewServer(adapter.Mux())
+	t.Cleanup(server.Close)
+	return broker, server
+}
+
+// sseEvent is an event read from an SSE stream.
+type sseEvent struct {
+	id, name string
+	data     port.InvestigationEvent
+}
+
+// sseStream reads events from an SSE response.
+type sseStream struct {
+	t      *testing.T
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+func openStream(t *testing.T, server *httptest.Server, id string, header http.Header) *sseStream {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/investigations/"+id+"/events", nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET events error = %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET events = %d %q, want 200 text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return &sseStream{t: t, body: resp.Body, reader: bufio.NewReader(resp.Body)}
+}
+
+// next returns the next event, or false at the end of the stream.
+func (s *sseStream) next() (sseEvent, bool) {
+	s.t.Helper()
+	var event sseEvent
+	for {
+		line, err := s.reader.ReadString('\n')
+		if errors.Is(err, io.EOF) && line == "" {
+			return event, false
+		}
+		if err != nil {
+			s.t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.name != "":
+			return event, true
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: ") && event.name != "evicted":
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data); err != nil {
+				s.t.Fatalf("decoding event data %q: %v", line, err)
+			}
+		}
+	}
+}
+
+// expect reads the next event and checks its sequence number and type.
+func (s *sseStream) expect(sequence int, eventType port.InvestigationEventType) sseEvent {
+	s.t.Helper()
+	event, ok := s.next()
+	if !ok {
+		s.t.Fatalf("stream ended, want event %d (%s)", sequence, eventType)
+	}
+	if event.data.Sequence != sequence || event.data.Type != eventType || event.name != string(eventType) {
+		s.t.Fatalf("event = %s %+v, want %d (%s)", event.name, event.data, sequence, eventType)
+	}
+	return event
+}
+
+// expectEnd checks that the server closed the stream.
+func (s *sseStream) expectEnd() {
+	s.t.Helper()
+	if event, ok := s.next(); ok {
+		s.t.Fatalf("got event %+v, want the stream to end", event)
+	}
+}
+
+// waitForSubscribers waits until n subscribers follow the investigation.
+func waitForSubscribers(t *testing.T, broker *EventBroker, id string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.SubscriberCount(id) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscriber count = %d, want %d", broker.SubscriberCount(id), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func publish(broker *EventBroker, id string, types ...port.InvestigationEventType) {
+	for _, eventType := range types {
+		broker.OnInvestigationEvent(port.InvestigationEvent{Type: eventType, InvestigationID: id})
+	}
+}
+
+func TestEventStream_ReplaysThenFollowsLiveEvents(t *testing.T) {
+	broker, server := newEventStreamServer(t, 0)
+	publish(broker, "inv-1", port.InvestigationEventIterationStarted, port.InvestigationEventToolExecuted)
+	publish(broker, "inv-other", port.InvestigationEventIterationStarted)
+
+	first := openStream(t, server, "inv-1", nil)
+	second := openStream(t, server, "inv-1", nil)
+	for _, stream := range []*sseStream{first, second} {
+		event := stream.expect(1, port.InvestigationEventIterationStarted)
+		if event.id != "1" || !event.data.Time.Equal(clockStart) || event.data.InvestigationID != "inv-1" {
+			t.Errorf("replayed event = %+v, want ID 1 stamped by the fake clock", event)
+		}
+		stream.expect(2, port.InvestigationEventToolExecuted)
+	}
+	waitForSubscribers(t, broker, "inv-1", 2)
+
+	publish(broker, "inv-1", port.InvestigationEventFindingAdded, port.InvestigationEventCompleted)
+	for _, stream := range []*sseStream{first, second} {
+		stream.expect(3, port.InvestigationEventFindingAdded)
+		completed := stream.expect(4, port.InvestigationEventCompleted)
+		// inv-other's event took the fake clock's third tick
+		if want := clockStart.Add(4 * time.Second); !completed.data.Time.Equal(want) {
+			t.Errorf("completed time = %v, want %v", completed.data.Time, want)
+		}
+		stream.expectEnd()
+	}
+	waitForSubscribers(t, broker, "inv-1", 0)
+}
+
+func TestEventStream_FinishedInvestigation(t *testing.T) {
+	broker, server := newEventStreamServer(t, 0)
+	publish(broker, "inv-1",
+		port.InvestigationEventIterationStarted, port.InvestigationEventEscalated)
+
+	stream := openStream(t, server, "inv-1", nil)
+	stream.expect(1, port.InvestigationEventIterationStarted)
+	stream.expect(2, port.InvestigationEventEscalated)
+	stream.expectEnd()
+
+	// A client that already saw every event is not left waiting
+	resumed := openStream(t, server, "inv-1", http.Header{"Last-Event-ID": {"2"}})
+	resumed.expectEnd()
+	waitForSubscribers(t, broker, "inv-1", 0)
+}
+
+func TestEventStream_ResumesAfterLastEventID(t *testing.T) {
+	broker, server := newEventStreamServer(t, 0)
+	publish(broker, "inv-1", port.InvestigationEventIterationStarted,
+		port.InvestigationEventToolExecuted, port.InvestigationEventIterationStarted)
+
+	stream := openStream(t, server, "inv-1", http.Header{"Last-Event-ID": {"2"}})
+	stream.expect(3, port.InvestigationEventIterationStarted)
+	waitForSubscribers(t, broker, "inv-1", 1)
+	publish(broker, "inv-1", port.InvestigationEventFailed)
+	stream.expect(4, port.InvestigationEventFailed)
+	stream.expectEnd()
+}
+
+func TestEventStream_ClientDisconnectUnsubscribes(t *testing.T) {
+	broker, server := newEventStreamServer(t, 0)
+	stream := openStream(t, server, "inv-1", nil)
+	waitForSubscribers(t, broker, "inv-1", 1)
+
+	_ = stream.body.Close()
+	waitForSubscribers(t, broker, "inv-1", 0)
+}
+
+func TestEventStream_NotEnabled(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/investigations/inv-1/events", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without an event broker", rec.Code)
+	}
+}
+
+func TestEventBroker_EvictsSlowSubscriber(t *testing.T) {
+	broker := NewEventBroker(&memoryEventRecorder{}, 2)
+	ctx := context.Background()
+	slow, _ := broker.Subscribe(ctx, "inv-1")
+	fast, _ := broker.Subscribe(ctx, "inv-1")
+
+	var received []int
+	for i := 0; i < 3; i++ {
+		publish(broker, "inv-1", port.InvestigationEventToolExecuted)
+		received = append(received, (<-fast.Events).Sequence)
+	}
+
+	// The slow subscriber got the two events that fit its buffer, then was evicted
+	var slowReceived []int
+	for event := range slow.Events {
+		slowReceived = append(slowReceived, event.Sequence)
+	}
+	if !slow.Evicted() || len(slowReceived) != 2 {
+		t.Errorf("slow subscriber evicted = %v after %v, want evicted after 2 events", slow.Evicted(), slowReceived)
+	}
+	if len(received) != 3 || received[2] != 3 || broker.SubscriberCount("inv-1") != 1 {
+		t.Errorf("fast subscriber received %v with %d subscribers, want all 3 events and to stay subscribed",
+			received, broker.SubscriberCount("inv-1"))
+	}
+
+	publish(broker, "inv-1", port.InvestigationEventCompleted)
+	if event := <-fast.Events; event.Type != port.InvestigationEventCompleted {
+		t.Errorf("event = %+v, want completed", event)
+	}
+	if _, open := <-fast.Events; open || fast.Evicted() {
+		t.Error("fast subscriber should be closed cleanly after the terminal event")
+	}
+	broker.Unsubscribe(fast) // Safe after the broker removed it
+}
+
+func TestHTTPAdapter_EvictedStreamIsTold(t *testing.T) {
+	broker, server := newEventStreamServer(t, 1)
+	stream := openStream(t, server, "inv-1", nil)
+	waitForSubscribers(t, broker, "inv-1", 1)
+
+	// The client is not reading, so the handler soon blocks on the connection
+	// and its one-event buffer fills
+	for i := 0; broker.SubscriberCount("inv-1") > 0; i++ {
+		if i > 10000 {
+			t.Fatal("subscriber was never evicted")
+		}
+		publish(broker, "inv-1", port.InvestigationEventToolExecuted)
+	}
+
+	for {
+		event, ok := stream.next()
+		if !ok {
+			t.Fatal("stream ended without an evicted event")
+		}
+		if event.name == "evicted" {
+			break
+		}
+	}
+	stream.expectEnd()
+}
@@ -4,21 +4,33 @@
 package webhook
 
 import (
+	"cmp"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/health"
+	"code-editing-agent/internal/infrastructure/version"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // maxBodySize is the maximum allowed size for webhook request bodies (10MB).
 const maxBodySize = 10 << 20
 
+// cancelGracePeriod bounds how long Shutdown waits for investigations to return
+// after cancelling them.
+const cancelGracePeriod = 5 * time.Second
+
+// heartbeatInterval is how often a running server beats its liveness watchdog.
+const heartbeatInterval = time.Second
+
 // HTTPAdapterConfig configures the webhook HTTP server.
 type HTTPAdapterConfig struct {
 	// Addr is the address to listen on (e.g., ":8080", "0.0.0.0:9090").
@@ -29,6 +41,9 @@ type HTTPAdapterConfig struct {
 	WriteTimeout time.Duration
 	// ShutdownTimeout is the grace period for graceful shutdown.
 	ShutdownTimeout time.Duration
+	// DrainTimeout is how long Shutdown waits for in-flight investigations to
+	// finish before cancelling them. Zero cancels them immediately.
+	DrainTimeout time.Duration
 }
 
 // DefaultConfig returns a configuration with sensible defaults.
@@ -38,6 +53,7 @@ func DefaultConfig() HTTPAdapterConfig {
 		ReadTimeout:     30 * time.Second,
 		WriteTimeout:    30 * time.Second,
 		ShutdownTimeout: 10 * time.Second,
+		DrainTimeout:    30 * time.Second,
 	}
 }
 
@@ -48,13 +64,25 @@ type HTTPAdapter struct {
 	alertHandler      port.AlertHandler
 	asyncAlertHandler port.AsyncAlertHandler
 	alertRunner       port.AlertRunner
+	drainHandler      func(ctx context.Context) error
+	readiness         *health.Checker
+	watchdog          *health.Watchdog
+	eventBroker       *EventBroker
+	suppressor        port.AlertSuppressor
+	reporter          port.InvestigationReporter
+	canceller         port.InvestigationCanceller
+	statusReporter    port.StatusReporter
+	configReloader    port.ConfigReloader
+	backfiller        port.AlertBackfiller
+	lastBatch         chan struct{} // closed once the last batch received is done
 	config            HTTPAdapterConfig
 	server            *http.Server
 	mux               *http.ServeMux
 	mu                sync.RWMutex
-	wg                sync.WaitGroup // tracks in-flight async investigations
+	wg                sync.WaitGroup // tracks in-flight async investigations and batches
 	invCtx            context.Context
 	invCancel         context.CancelFunc
+	draining          atomic.Bool // true once Shutdown begins; webhooks get 503
 	started           bool
 }
 
@@ -80,10 +108,34 @@ func (a *HTTPAdapter) registerRoutes() {
 	// Health endpoints
 	a.mux.HandleFunc("GET /health", a.handleHealth)
 	a.mux.HandleFunc("GET /ready", a.handleReady)
+	a.mux.HandleFunc("GET /healthz", a.handleHealthz)
+	a.mux.HandleFunc("GET /readyz", a.handleReadyz)
 
 	// Dynamic webhook routes based on registered sources
 	// Using a catch-all pattern that routes to the appropriate source
 	a.mux.HandleFunc("POST /alerts/{source...}", a.handleWebhook)
+
+	// Alert suppression by fingerprint; more specific than the webhook routes
+	a.mux.HandleFunc("POST /alerts/{fingerprint}/suppress", a.handleSuppress)
+	a.mux.HandleFunc("DELETE /alerts/{fingerprint}/suppress", a.handleUnsuppress)
+
+	// Live investigation progress as Server-Sent Events
+	a.mux.HandleFunc("GET /investigations/{id}/events", a.handleInvestigationEvents)
+
+	// Batches of past alerts to investigate in the background
+	a.mux.HandleFunc("POST /webhook/batch", a.handleBatch)
+
+	// Markdown reports of stored investigations
+	a.mux.HandleFunc("GET /investigations/{id}/report", a.handleInvestigationReport)
+
+	// Cancellation of a running investigation
+	a.mux.HandleFunc("DELETE /investigations/{id}", a.handleCancelInvestigation)
+
+	// Snapshot of running and queued investigations
+	a.mux.HandleFunc("GET /status", a.handleStatus)
+
+	// Configuration reload, like SIGHUP
+	a.mux.HandleFunc("POST /-/reload", a.handleReload)
 }
 
 // handleHealth returns 200 OK if the server is running.
@@ -93,10 +145,17 @@ func (a *HTTPAdapter) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte(`{"status":"ok"}`))
 }
 
-// handleReady returns 200 OK if at least one alert source is registered.
+// handleReady returns 200 OK if at least one alert source is registered
+// and the server is not draining.
 func (a *HTTPAdapter) handleReady(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if a.draining.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":"draining"}`))
+		return
+	}
+
 	sources := a.sourceManager.ListSources()
 	if len(sources) == 0 {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -108,10 +167,83 @@ func (a *HTTPAdapter) handleReady(w http.ResponseWriter, _ *http.Request) {
 	_, _ = fmt.Fprintf(w, `{"status":"ok","sources":%d}`, len(sources))
 }
 
+// healthzResponse is the body of GET /healthz: the liveness status and the
+// build of the running server.
+type healthzResponse struct {
+	Status                string  `json:"status"`
+	SecondsSinceHeartbeat float64 `json:"seconds_since_heartbeat,omitempty"`
+	version.Info
+}
+
+// handleHealthz is the liveness probe. It returns 200 OK unless the watchdog
+// has gone without a heartbeat for too long, with the server's version,
+// commit, and build date either way.
+func (a *HTTPAdapter) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	a.mu.RLock()
+	watchdog := a.watchdog
+	a.mu.RUnlock()
+
+	body := healthzResponse{Status: "ok", Info: version.Get()}
+	if watchdog != nil && !watchdog.Alive() {
+		body.Status = "stalled"
+		body.SecondsSinceHeartbeat = math.Round(watchdog.SinceLastBeat().Seconds())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	resp, _ := json.Marshal(body)
+	_, _ = w.Write(resp)
+}
+
+// handleReadyz is the readiness probe. It returns each check's status, with
+// 503 when a required check fails or the server is draining, and the outage
+// investigations are held for, if any. Holding does not fail readiness, so
+// alerts keep arriving to be queued.
+func (a *HTTPAdapter) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.draining.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":"draining"}`))
+		return
+	}
+
+	a.mu.RLock()
+	readiness := a.readiness
+	reporter := a.statusReporter
+	a.mu.RUnlock()
+
+	report := health.Report{Status: health.StatusOK, Checks: map[string]health.CheckResult{}}
+	if readiness != nil {
+		report = readiness.Check(r.Context())
+	}
+	if reporter != nil {
+		report.Degraded = reporter.GetStatus().Degraded
+	}
+
+	if report.Ready() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	resp, _ := json.Marshal(report)
+	_, _ = w.Write(resp)
+}
+
 // handleWebhook routes incoming webhooks to the appropriate source.
+// It returns 503 while the server is draining so senders retry elsewhere or later,
+// and, when no alert could be investigated, a status for why (see errorStatus).
 func (a *HTTPAdapter) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if a.draining.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"server is shutting down"}`))
+		return
+	}
+
 	// Reconstruct the full path from the wildcard
 	sourcePath := r.PathValue("source")
 	path := "/alerts/" + sourcePath
@@ -159,14 +291,24 @@ func (a *HTTPAdapter) handleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	// Fall back to sync dispatch
 	var handlerErrors int
+	var firstErr error
 	for _, alert := range alerts {
 		if syncHandler != nil {
 			if err := syncHandler(ctx, alert); err != nil {
 				handlerErrors++
+				firstErr = cmp.Or(firstErr, err)
 			}
 		}
 	}
 
+	// When every alert failed for a known reason, report it by status
+	if handlerErrors > 0 && handlerErrors == len(alerts) {
+		if status := errorStatus(firstErr); status != http.StatusInternalServerError {
+			writeStartError(w, status, firstErr, handlerErrors)
+			return
+		}
+	}
+
 	// Return success
 	w.WriteHeader(http.StatusOK)
 	resp, _ := json.Marshal(map[string]interface{}{
@@ -186,6 +328,7 @@ func (a *HTTPAdapter) handleWebhookAsync(
 ) {
 	var lastInvID string
 	var startErrors int
+	var firstErr error
 
 	for _, alert := range alerts {
 		// Start investigation and get ID (non-blocking)
@@ -193,6 +336,7 @@ func (a *HTTPAdapter) handleWebhookAsync(
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "[Webhook] Failed to start investigation for alert %s: %v\n", alert.ID(), err)
 			startErrors++
+			firstErr = cmp.Or(firstErr, err)
 			continue
 		}
 
@@ -227,12 +371,7 @@ func (a *HTTPAdapter) handleWebhookAsync(
 
 	// No investigations started (all filtered or errors)
 	if startErrors > 0 {
-		w.WriteHeader(http.StatusInternalServerError)
-		resp, _ := json.Marshal(map[string]interface{}{
-			"error":  "failed to start investigations",
-			"errors": startErrors,
-		})
-		_, _ = w.Write(resp)
+		writeStartError(w, errorStatus(firstErr), firstErr, startErrors)
 		return
 	}
 
@@ -245,6 +384,18 @@ func (a *HTTPAdapter) handleWebhookAsync(
 	_, _ = w.Write(resp)
 }
 
+// writeStartError reports that no investigation could be run, with the
+// status for the first error and the number of alerts that failed.
+func writeStartError(w http.ResponseWriter, status int, err error, count int) {
+	w.WriteHeader(status)
+	resp, _ := json.Marshal(map[string]interface{}{
+		"error":  "failed to start investigations",
+		"reason": err.Error(),
+		"errors": count,
+	})
+	_, _ = w.Write(resp)
+}
+
 // findWebhookSource finds a webhook source by its path.
 func (a *HTTPAdapter) findWebhookSource(path string) port.WebhookAlertSource {
 	sources := a.sourceManager.ListSources()
@@ -276,6 +427,29 @@ func (a *HTTPAdapter) SetAsyncAlertHandler(handler port.AsyncAlertHandler, runne
 	a.alertRunner = runner
 }
 
+// SetDrainHandler sets a function Shutdown calls, with a context bounded by
+// DrainTimeout, to drain in-flight investigations before cancelling them.
+func (a *HTTPAdapter) SetDrainHandler(handler func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.drainHandler = handler
+}
+
+// SetReadinessChecker sets the checks behind GET /readyz.
+func (a *HTTPAdapter) SetReadinessChecker(checker *health.Checker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.readiness = checker
+}
+
+// SetWatchdog sets the watchdog behind GET /healthz. While the server runs it
+// beats the watchdog every second, so /healthz fails if the adapter wedges.
+func (a *HTTPAdapter) SetWatchdog(watchdog *health.Watchdog) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.watchdog = watchdog
+}
+
 // Start begins listening for HTTP requests.
 // This method blocks until the context is cancelled or an error occurs.
 func (a *HTTPAdapter) Start(ctx context.Context) error {
@@ -292,8 +466,18 @@ func (a *HTTPAdapter) Start(ctx context.Context) error {
 		WriteTimeout: a.config.WriteTimeout,
 	}
 	a.started = true
+	watchdog := a.watchdog
 	a.mu.Unlock()
 
+	// Beat the watchdog while the adapter's lock can still be taken
+	if watchdog != nil {
+		go watchdog.Run(ctx, heartbeatInterval, func() {
+			a.mu.RLock()
+			defer a.mu.RUnlock()
+			watchdog.Beat()
+		})
+	}
+
 	// Start server in goroutine
 	errCh := make(chan error, 1)
 	go func() {
@@ -313,24 +497,30 @@ func (a *HTTPAdapter) Start(ctx context.Context) error {
 }
 
 // Shutdown gracefully stops the HTTP server.
-// It cancels running investigations and waits up to 5 seconds for them to complete.
+// Webhooks and the ready check return 503 from then on. It waits up to
+// DrainTimeout for in-flight investigations (calling the drain handler, if
+// set), then cancels the rest and waits briefly for them to return.
 func (a *HTTPAdapter) Shutdown() error {
-	// Cancel all running investigations
-	a.invCancel()
+	a.draining.Store(true)
 
-	// Wait for investigations with timeout
-	done := make(chan struct{})
-	go func() {
-		a.wg.Wait()
-		close(done)
-	}()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), a.config.DrainTimeout)
+	defer cancelDrain()
 
-	select {
-	case <-done:
-		// All investigations finished cleanly
-	case <-time.After(5 * time.Second):
-		// Timeout - proceed with server shutdown anyway
+	a.mu.RLock()
+	drainHandler := a.drainHandler
+	a.mu.RUnlock()
+	if drainHandler != nil {
+		if err := drainHandler(drainCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "[Webhook] Drain: %v\n", err)
+		}
 	}
+	a.waitForInvestigations(drainCtx)
+
+	// Cancel investigations still running after the drain
+	a.invCancel()
+	graceCtx, cancelGrace := context.WithTimeout(context.Background(), cancelGracePeriod)
+	defer cancelGrace()
+	a.waitForInvestigations(graceCtx)
 
 	// Shut down HTTP server
 	a.mu.Lock()
@@ -348,6 +538,21 @@ func (a *HTTPAdapter) Shutdown() error {
 	return err
 }
 
+// waitForInvestigations waits until in-flight async investigations have
+// returned or ctx is done.
+func (a *HTTPAdapter) waitForInvestigations(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
 // Addr returns the configured address.
 func (a *HTTPAdapter) Addr() string {
 	return a.config.Addr
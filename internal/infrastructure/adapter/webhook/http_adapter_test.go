@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/health"
+	"code-editing-agent/internal/infrastructure/version"
 	"context"
 	"encoding/json"
 	"errors"
@@ -381,6 +383,9 @@ func TestHTTPAdapter_Config(t *testing.T) {
 		if config.ShutdownTimeout != 10*1e9 {
 			t.Errorf("expected 10s, got %v", config.ShutdownTimeout)
 		}
+		if config.DrainTimeout != 30*1e9 {
+			t.Errorf("expected 30s, got %v", config.DrainTimeout)
+		}
 	})
 }
 
@@ -568,6 +573,123 @@ func TestHTTPAdapter_AsyncHandler_ShutdownWaits(t *testing.T) {
 	}
 }
 
+func TestHTTPAdapter_Shutdown_Returns503WhileDraining(t *testing.T) {
+	webhookSource := &mockWebhookSource{
+		mockAlertSource: mockAlertSource{name: "prometheus", sourceType: port.SourceTypeWebhook},
+		webhookPath:     "/alerts/prometheus",
+		handleFunc: func(_ context.Context, _ []byte) ([]*entity.Alert, error) {
+			alert, _ := entity.NewAlert("alert-1", "prometheus", "critical", "Critical Alert")
+			return []*entity.Alert{alert}, nil
+		},
+	}
+	manager := &mockSourceManager{sources: []port.AlertSource{webhookSource}}
+	adapter := NewHTTPAdapter(manager, DefaultConfig())
+
+	runnerStarted := make(chan struct{})
+	runnerComplete := make(chan struct{})
+	adapter.SetAsyncAlertHandler(
+		func(_ context.Context, _ *entity.Alert) (string, error) {
+			return "inv-drain-test", nil
+		},
+		func(ctx context.Context, _ *entity.Alert, _ string) error {
+			close(runnerStarted)
+			select {
+			case <-runnerComplete:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	)
+	drainStarted := make(chan struct{})
+	adapter.SetDrainHandler(func(_ context.Context) error {
+		close(drainStarted)
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	<-runnerStarted
+
+	shutdownComplete := make(chan struct{})
+	go func() {
+		_ = adapter.Shutdown()
+		close(shutdownComplete)
+	}()
+	<-drainStarted
+
+	rec = httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("webhook during drain: expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("ready during drain: expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	close(runnerComplete)
+	select {
+	case <-shutdownComplete:
+	case <-time.After(1 * time.Second):
+		t.Error("shutdown did not complete after the investigation finished")
+	}
+}
+
+func TestHTTPAdapter_Shutdown_CancelsInvestigationsAfterDrainTimeout(t *testing.T) {
+	webhookSource := &mockWebhookSource{
+		mockAlertSource: mockAlertSource{name: "prometheus", sourceType: port.SourceTypeWebhook},
+		webhookPath:     "/alerts/prometheus",
+		handleFunc: func(_ context.Context, _ []byte) ([]*entity.Alert, error) {
+			alert, _ := entity.NewAlert("alert-1", "prometheus", "critical", "Critical Alert")
+			return []*entity.Alert{alert}, nil
+		},
+	}
+	manager := &mockSourceManager{sources: []port.AlertSource{webhookSource}}
+	config := DefaultConfig()
+	config.DrainTimeout = 50 * time.Millisecond
+	adapter := NewHTTPAdapter(manager, config)
+
+	runnerStarted := make(chan struct{})
+	runnerErr := make(chan error, 1)
+	adapter.SetAsyncAlertHandler(
+		func(_ context.Context, _ *entity.Alert) (string, error) {
+			return "inv-timeout-test", nil
+		},
+		func(ctx context.Context, _ *entity.Alert, _ string) error {
+			close(runnerStarted)
+			<-ctx.Done()
+			runnerErr <- ctx.Err()
+			return ctx.Err()
+		},
+	)
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	<-runnerStarted
+
+	start := time.Now()
+	_ = adapter.Shutdown()
+	if elapsed := time.Since(start); elapsed < config.DrainTimeout {
+		t.Errorf("shutdown returned after %v, before the %v drain timeout", elapsed, config.DrainTimeout)
+	}
+	select {
+	case err := <-runnerErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("runner context error = %v, want context.Canceled", err)
+		}
+	default:
+		t.Error("investigation was not cancelled after the drain timeout")
+	}
+}
+
 func TestHTTPAdapter_AsyncAndSyncHandlerPrecedence(t *testing.T) {
 	t.Run("async handler takes precedence over sync handler", func(t *testing.T) {
 		webhookSource := &mockWebhookSource{
@@ -622,3 +744,133 @@ func TestHTTPAdapter_AsyncAndSyncHandlerPrecedence(t *testing.T) {
 		}
 	})
 }
+
+// stubProvider reports a fixed health check result, like a reachable or
+// unreachable AI provider.
+type stubProvider struct{ err error }
+
+func (p *stubProvider) HealthCheck(context.Context) error { return p.err }
+
+func TestHTTPAdapter_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   *stubProvider
+		optional   bool
+		wantCode   int
+		wantStatus string
+		wantCheck  health.CheckResult
+	}{
+		{
+			name:       "all checks pass",
+			provider:   &stubProvider{},
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusOK,
+			wantCheck:  health.CheckResult{Status: health.StatusOK},
+		},
+		{
+			name:       "failing provider fails readiness",
+			provider:   &stubProvider{err: errors.New("401 unauthorized")},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusFail,
+			wantCheck:  health.CheckResult{Status: health.StatusFail, Error: "401 unauthorized"},
+		},
+		{
+			name:       "failing optional provider is reported but ready",
+			provider:   &stubProvider{err: errors.New("401 unauthorized")},
+			optional:   true,
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusOK,
+			wantCheck:  health.CheckResult{Status: health.StatusFail, Optional: true, Error: "401 unauthorized"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+			adapter.SetReadinessChecker(health.NewChecker(time.Minute,
+				health.Check{Name: health.CheckAIProvider, Optional: tt.optional, Run: tt.provider.HealthCheck},
+				health.Check{Name: health.CheckWorkspace, Run: health.DirWritable(t.TempDir())},
+			))
+
+			rec := httptest.NewRecorder()
+			adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			var report health.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if got := report.Checks[health.CheckAIProvider]; got != tt.wantCheck {
+				t.Errorf("%s = %+v, want %+v", health.CheckAIProvider, got, tt.wantCheck)
+			}
+			if got := report.Checks[health.CheckWorkspace].Status; got != health.StatusOK {
+				t.Errorf("%s status = %q, want %q", health.CheckWorkspace, got, health.StatusOK)
+			}
+		})
+	}
+}
+
+func TestHTTPAdapter_Readyz_CachesChecks(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	provider := &stubProvider{}
+	adapter.SetReadinessChecker(health.NewChecker(time.Minute,
+		health.Check{Name: health.CheckAIProvider, Run: provider.HealthCheck},
+	))
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	// A provider failure within the cache TTL is not seen until the cache expires
+	provider.err = errors.New("down")
+	rec = httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the cached 200, got %d", rec.Code)
+	}
+}
+
+func TestHTTPAdapter_Healthz(t *testing.T) {
+	t.Run("alive watchdog returns 200", func(t *testing.T) {
+		adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+		adapter.SetWatchdog(health.NewWatchdog(time.Minute))
+
+		rec := httptest.NewRecorder()
+		adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["status"] != "ok" {
+			t.Errorf("expected an ok status, got %s", rec.Body.String())
+		}
+		if body["version"] != version.Get().Version {
+			t.Errorf("expected version %q, got %s", version.Get().Version, rec.Body.String())
+		}
+	})
+
+	t.Run("stale watchdog returns 503", func(t *testing.T) {
+		adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+		adapter.SetWatchdog(health.NewWatchdog(time.Nanosecond))
+		time.Sleep(time.Millisecond)
+
+		rec := httptest.NewRecorder()
+		adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["status"] != "stalled" {
+			t.Errorf("expected a stalled status, got %s", rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/alert"
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// postAlertFixture posts the named payload from the alert adapter's testdata
+// to a Grafana source and returns the alerts that reached the handler, or the
+// response for a rejected payload.
+func postAlertFixture(t *testing.T, payload string) ([]*entity.Alert, *httptest.ResponseRecorder) {
+	t.Helper()
+	source, err := alert.NewGrafanaSource(alert.SourceConfig{Name: "grafana", WebhookPath: "/alerts/grafana"})
+	if err != nil {
+		t.Fatalf("NewGrafanaSource() error = %v", err)
+	}
+	adapter := NewHTTPAdapter(&mockSourceManager{sources: []port.AlertSource{source}}, DefaultConfig())
+	var handled []*entity.Alert
+	adapter.SetAlertHandler(func(_ context.Context, alert *entity.Alert) error {
+		handled = append(handled, alert)
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/grafana", strings.NewReader(payload)))
+	return handled, rec
+}
+
+// readAlertFixture reads a payload from the alert adapter's testdata.
+func readAlertFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "alert", "testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", name, err)
+	}
+	return string(data)
+}
+
+func TestHTTPAdapter_GrafanaAndAlertmanagerPayloads(t *testing.T) {
+	am, amRec := postAlertFixture(t, readAlertFixture(t, "alertmanager_webhook.json"))
+	grafana, grafanaRec := postAlertFixture(t, readAlertFixture(t, "grafana_webhook.json"))
+	if amRec.Code != http.StatusOK || grafanaRec.Code != http.StatusOK {
+		t.Fatalf("POST = %d and %d, want 200 for both formats", amRec.Code, grafanaRec.Code)
+	}
+	if len(am) != 1 || len(grafana) != 1 {
+		t.Fatalf("handler got %d Alertmanager and %d Grafana alerts, want 1 of each", len(am), len(grafana))
+	}
+	want, got := am[0], grafana[0]
+	if got.ID() != want.ID() || got.Source() != want.Source() || got.Severity() != want.Severity() ||
+		got.Title() != want.Title() || got.Fingerprint() != want.Fingerprint() || !maps.Equal(got.Labels(), want.Labels()) {
+		t.Errorf("Grafana alert = %+v, want the Alertmanager alert %+v", got, want)
+	}
+}
+
+func TestHTTPAdapter_UnknownPayloadFormat(t *testing.T) {
+	handled, rec := postAlertFixture(t, `{"ruleName":"HighCPU","evalMatches":[]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `an \"alerts\" array`) {
+		t.Errorf("POST = %d %s, want 400 with a hint at the expected formats", rec.Code, rec.Body.String())
+	}
+	if len(handled) != 0 {
+		t.Errorf("handler got %d alerts, want none", len(handled))
+	}
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"net/http"
+)
+
+// SetConfigReloader sets the reloader behind POST /-/reload. Without one, the
+// endpoint returns 501.
+func (a *HTTPAdapter) SetConfigReloader(reloader port.ConfigReloader) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.configReloader = reloader
+}
+
+// handleReload re-reads the configuration and returns the applied and ignored
+// keys as JSON. An invalid configuration is rejected with 422, leaving the old
+// one active.
+func (a *HTTPAdapter) handleReload(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	reloader := a.configReloader
+	a.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if reloader == nil {
+		writeJSONError(w, http.StatusNotImplemented, "config reload not configured")
+		return
+	}
+
+	result, err := reloader.ReloadConfig(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	resp, err := json.Marshal(result)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeConfigReloader returns a fixed result and error, counting calls.
+type fakeConfigReloader struct {
+	result port.ConfigReloadResult
+	err    error
+	calls  int
+}
+
+func (f *fakeConfigReloader) ReloadConfig(context.Context) (port.ConfigReloadResult, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func TestHTTPAdapter_Reload(t *testing.T) {
+	want := port.ConfigReloadResult{Applied: []string{"tools.blocked_commands"}, Ignored: []string{"api_key"}}
+	reloader := &fakeConfigReloader{result: want}
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetConfigReloader(reloader)
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var got port.ConfigReloadResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body.String(), err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("result = %+v, want %+v", got, want)
+	}
+	if reloader.calls != 1 {
+		t.Errorf("reloader called %d times, want 1", reloader.calls)
+	}
+}
+
+func TestHTTPAdapter_ReloadInvalidConfig(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetConfigReloader(&fakeConfigReloader{err: errors.New("invalid configuration: max_tokens: must be positive")})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "max_tokens: must be positive") {
+		t.Errorf("body = %q, want the validation error", rec.Body.String())
+	}
+}
+
+func TestHTTPAdapter_ReloadNotConfigured(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// SetInvestigationReporter sets the reporter behind GET
+// /investigations/{id}/report. Without one, the endpoint returns 501.
+func (a *HTTPAdapter) SetInvestigationReporter(reporter port.InvestigationReporter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reporter = reporter
+}
+
+// handleInvestigationReport returns a stored investigation's Markdown report.
+// A "summary=true" query parameter opens the report with an AI-written
+// executive summary. Unknown investigations get 404.
+func (a *HTTPAdapter) handleInvestigationReport(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	reporter := a.reporter
+	a.mu.RUnlock()
+
+	if reporter == nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSONError(w, http.StatusNotImplemented, "investigation reports not configured")
+		return
+	}
+
+	summary := false
+	if value := r.URL.Query().Get("summary"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid summary %q: want true or false", value))
+			return
+		}
+		summary = parsed
+	}
+
+	report, err := reporter.ReportInvestigation(r.Context(), r.PathValue("id"), summary)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		status := http.StatusInternalServerError
+		if errors.Is(err, port.ErrInvestigationNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(report))
+}
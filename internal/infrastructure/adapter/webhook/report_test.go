@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeReporter reports investigation "inv-1" and records the summary flag.
+type fakeReporter struct {
+	summary bool
+}
+
+func (f *fakeReporter) ReportInvestigation(_ context.Context, id string, summary bool) (string, error) {
+	if id != "inv-1" {
+		return "", fmt.Errorf("failed to load investigation %s: %w", id, port.ErrInvestigationNotFound)
+	}
+	f.summary = summary
+	return "# Investigation Report: inv-1\n", nil
+}
+
+func TestHTTPAdapter_InvestigationReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantSummary bool
+		wantBody    string
+	}{
+		{"report", "/investigations/inv-1/report", http.StatusOK, false, "# Investigation Report: inv-1"},
+		{"with summary", "/investigations/inv-1/report?summary=true", http.StatusOK, true, "# Investigation Report"},
+		{"unknown investigation", "/investigations/inv-9/report", http.StatusNotFound, false, "investigation not found"},
+		{"bad summary", "/investigations/inv-1/report?summary=maybe", http.StatusBadRequest, false, "invalid summary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+			reporter := &fakeReporter{}
+			adapter.SetInvestigationReporter(reporter)
+
+			rec := httptest.NewRecorder()
+			adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if reporter.summary != tt.wantSummary {
+				t.Errorf("summary = %v, want %v", reporter.summary, tt.wantSummary)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") {
+				t.Errorf("Content-Type = %q, want text/markdown", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHTTPAdapter_InvestigationReportNotConfigured(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/investigations/inv-1/report", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501 without a reporter", rec.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"net/http"
+)
+
+// SetStatusReporter sets the reporter behind GET /status. Without one, the
+// endpoint returns 501.
+func (a *HTTPAdapter) SetStatusReporter(reporter port.StatusReporter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.statusReporter = reporter
+}
+
+// handleStatus returns a JSON snapshot of the running and queued
+// investigations, the alert source circuits, and concurrency use.
+func (a *HTTPAdapter) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	a.mu.RLock()
+	reporter := a.statusReporter
+	a.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if reporter == nil {
+		writeJSONError(w, http.StatusNotImplemented, "status not configured")
+		return
+	}
+
+	resp, err := json.Marshal(reporter.GetStatus())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
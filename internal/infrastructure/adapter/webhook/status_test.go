@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStatusReporter returns a fixed status.
+type fakeStatusReporter struct {
+	status port.DaemonStatus
+}
+
+func (f *fakeStatusReporter) GetStatus() port.DaemonStatus {
+	return f.status
+}
+
+func TestHTTPAdapter_Status(t *testing.T) {
+	want := port.DaemonStatus{
+		GeneratedAt: time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC),
+		Active: []port.ActiveInvestigationStatus{{
+			InvestigationID: "inv-1",
+			AlertID:         "DiskFull",
+			Elapsed:         90 * time.Second,
+			Actions:         3,
+			CurrentTool:     "bash",
+		}},
+		Queued:   []port.QueuedAlertStatus{{InvestigationID: "inv-2", AlertID: "HighCPU", Priority: "warning"}},
+		Circuits: []port.CircuitStatus{{Source: "prometheus", State: "closed", Recent: 2}},
+		Workers:  port.WorkerPoolStatus{Capacity: 4, Running: 1, Queued: 1, Utilization: 0.5},
+	}
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetStatusReporter(&fakeStatusReporter{status: want})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got port.DaemonStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body.String(), err)
+	}
+	if got.Active[0] != want.Active[0] || got.Queued[0] != want.Queued[0] ||
+		got.Circuits[0] != want.Circuits[0] || got.Workers != want.Workers || !got.GeneratedAt.Equal(want.GeneratedAt) {
+		t.Errorf("status = %+v, want %+v", got, want)
+	}
+}
+
+func TestHTTPAdapter_StatusNotConfigured(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusNotImplemented || !strings.Contains(rec.Body.String(), "status not configured") {
+		t.Errorf("response = %d %q, want 501 status not configured", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHTTPAdapter_ReadyzReportsDegraded(t *testing.T) {
+	degraded := &port.DegradedStatus{
+		Since:  time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC),
+		Reason: "AI provider health check failed",
+		Held:   3,
+	}
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	adapter.SetStatusReporter(&fakeStatusReporter{status: port.DaemonStatus{Degraded: degraded}})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	// Holding alerts does not fail readiness, so they keep arriving
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Degraded *port.DegradedStatus `json:"degraded"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body.String(), err)
+	}
+	if got.Degraded == nil || got.Degraded.Held != 3 || got.Degraded.Reason != degraded.Reason {
+		t.Errorf("degraded = %+v, want %+v", got.Degraded, degraded)
+	}
+}
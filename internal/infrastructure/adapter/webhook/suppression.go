@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxSuppressBodySize bounds suppression request bodies.
+const maxSuppressBodySize = 64 << 10
+
+// suppressRequest is the body of POST /alerts/{fingerprint}/suppress. Exactly
+// one of Until and Duration (such as "2h") must be set.
+type suppressRequest struct {
+	Until    time.Time `json:"until"`
+	Duration string    `json:"duration"`
+	Reason   string    `json:"reason"`
+}
+
+// end returns when the requested suppression ends.
+func (req suppressRequest) end(now time.Time) (time.Time, error) {
+	switch {
+	case req.Duration != "" && !req.Until.IsZero():
+		return time.Time{}, errors.New("set either until or duration, not both")
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration: %w", err)
+		}
+		return now.Add(d), nil
+	case !req.Until.IsZero():
+		return req.Until, nil
+	default:
+		return time.Time{}, errors.New("until or duration is required")
+	}
+}
+
+// SetAlertSuppressor sets the suppressor behind /alerts/{fingerprint}/suppress.
+// Without one, those endpoints return 501.
+func (a *HTTPAdapter) SetAlertSuppressor(suppressor port.AlertSuppressor) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.suppressor = suppressor
+}
+
+// handleSuppress suppresses alerts with the fingerprint in the path until the
+// time given in the body. Invalid requests get 400.
+func (a *HTTPAdapter) handleSuppress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	suppressor := a.alertSuppressor(w)
+	if suppressor == nil {
+		return
+	}
+
+	var req suppressRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSuppressBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	until, err := req.end(time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fingerprint := r.PathValue("fingerprint")
+	if err := suppressor.Suppress(r.Context(), fingerprint, until, req.Reason); err != nil {
+		writeSuppressionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp, _ := json.Marshal(map[string]interface{}{
+		"status":      "suppressed",
+		"fingerprint": fingerprint,
+		"until":       until,
+		"reason":      req.Reason,
+	})
+	_, _ = w.Write(resp)
+}
+
+// handleUnsuppress lifts the suppression of the fingerprint in the path. It
+// succeeds whether or not the fingerprint was suppressed.
+func (a *HTTPAdapter) handleUnsuppress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	suppressor := a.alertSuppressor(w)
+	if suppressor == nil {
+		return
+	}
+
+	fingerprint := r.PathValue("fingerprint")
+	if err := suppressor.Unsuppress(r.Context(), fingerprint); err != nil {
+		writeSuppressionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp, _ := json.Marshal(map[string]string{"status": "unsuppressed", "fingerprint": fingerprint})
+	_, _ = w.Write(resp)
+}
+
+// alertSuppressor returns the configured suppressor, or writes 501 and
+// returns nil if there is none.
+func (a *HTTPAdapter) alertSuppressor(w http.ResponseWriter) port.AlertSuppressor {
+	a.mu.RLock()
+	suppressor := a.suppressor
+	a.mu.RUnlock()
+	if suppressor == nil {
+		writeJSONError(w, http.StatusNotImplemented, "alert suppression not configured")
+	}
+	return suppressor
+}
+
+// writeSuppressionError writes 400 for invalid suppressions and 500 otherwise.
+func writeSuppressionError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, entity.ErrInvalidFingerprint) || errors.Is(err, entity.ErrSuppressionEnded) {
+		status = http.StatusBadRequest
+	}
+	writeJSONError(w, status, err.Error())
+}
+
+// writeJSONError writes a JSON error response with the given status.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	resp, _ := json.Marshal(map[string]string{"error": message})
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"bytes"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeSuppressor records suppressions and validates them like AlertHandler.
+type fakeSuppressor struct {
+	fingerprint string
+	until       time.Time
+	reason      string
+	lifted      string
+}
+
+func (f *fakeSuppressor) Suppress(_ context.Context, fingerprint string, until time.Time, reason string) error {
+	if _, err := entity.NewAlertSuppression(fingerprint, until, reason, time.Now()); err != nil {
+		return err
+	}
+	f.fingerprint, f.until, f.reason = fingerprint, until, reason
+	return nil
+}
+
+func (f *fakeSuppressor) Unsuppress(_ context.Context, fingerprint string) error {
+	if fingerprint == "broken" {
+		return errors.New("store unavailable")
+	}
+	f.lifted = fingerprint
+	return nil
+}
+
+func TestHTTPAdapter_Suppress(t *testing.T) {
+	until := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantUntil  func(time.Time) bool
+	}{
+		{
+			name:       "until",
+			path:       "/alerts/a1b2c3d4e5f60718/suppress",
+			body:       `{"until": "` + until.Format(time.RFC3339) + `", "reason": "maintenance window"}`,
+			wantStatus: http.StatusOK,
+			wantUntil:  until.Equal,
+		},
+		{
+			name:       "duration",
+			path:       "/alerts/a1b2c3d4e5f60718/suppress",
+			body:       `{"duration": "90m", "reason": "maintenance window"}`,
+			wantStatus: http.StatusOK,
+			wantUntil: func(got time.Time) bool {
+				want := time.Now().Add(90 * time.Minute)
+				return got.After(want.Add(-time.Minute)) && !got.After(want)
+			},
+		},
+		{"both", "/alerts/a1b2/suppress", `{"duration": "1h", "until": "` + until.Format(time.RFC3339) + `"}`,
+			http.StatusBadRequest, nil},
+		{"neither", "/alerts/a1b2/suppress", `{"reason": "forever"}`, http.StatusBadRequest, nil},
+		{"bad duration", "/alerts/a1b2/suppress", `{"duration": "soon"}`, http.StatusBadRequest, nil},
+		{"not json", "/alerts/a1b2/suppress", `maintenance`, http.StatusBadRequest, nil},
+		{"in the past", "/alerts/a1b2/suppress", `{"duration": "-1h"}`, http.StatusBadRequest, nil},
+		{"invalid fingerprint", "/alerts/.a1b2/suppress", `{"duration": "1h"}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+			suppressor := &fakeSuppressor{}
+			adapter.SetAlertSuppressor(suppressor)
+
+			rec := httptest.NewRecorder()
+			adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if suppressor.fingerprint != "" {
+					t.Errorf("invalid request suppressed %q", suppressor.fingerprint)
+				}
+				return
+			}
+			if suppressor.fingerprint != "a1b2c3d4e5f60718" || suppressor.reason != "maintenance window" {
+				t.Errorf("Suppress(%q, reason %q), want a1b2c3d4e5f60718 and the reason", suppressor.fingerprint, suppressor.reason)
+			}
+			if !tt.wantUntil(suppressor.until) {
+				t.Errorf("Suppress until = %v", suppressor.until)
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp["status"] != "suppressed" || resp["fingerprint"] != "a1b2c3d4e5f60718" {
+				t.Errorf("response = %v", resp)
+			}
+		})
+	}
+}
+
+func TestHTTPAdapter_Unsuppress(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+	suppressor := &fakeSuppressor{}
+	adapter.SetAlertSuppressor(suppressor)
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/alerts/a1b2c3d4e5f60718/suppress", nil))
+	if rec.Code != http.StatusOK || suppressor.lifted != "a1b2c3d4e5f60718" {
+		t.Errorf("DELETE status = %d, lifted %q; want 200 and a1b2c3d4e5f60718", rec.Code, suppressor.lifted)
+	}
+
+	rec = httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/alerts/broken/suppress", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("DELETE with failing store status = %d, want 500", rec.Code)
+	}
+}
+
+func TestHTTPAdapter_Suppress_NotConfigured(t *testing.T) {
+	adapter := NewHTTPAdapter(&mockSourceManager{}, DefaultConfig())
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/a1b2/suppress",
+		bytes.NewBufferString(`{"duration": "1h"}`)))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+func TestHTTPAdapter_Suppress_DoesNotShadowWebhooks(t *testing.T) {
+	manager := &mockSourceManager{sources: []port.AlertSource{&mockWebhookSource{
+		mockAlertSource: mockAlertSource{name: "prometheus", sourceType: port.SourceTypeWebhook},
+		webhookPath:     "/alerts/prometheus",
+	}}}
+	adapter := NewHTTPAdapter(manager, DefaultConfig())
+	adapter.SetAlertSuppressor(&fakeSuppressor{})
+
+	rec := httptest.NewRecorder()
+	adapter.Mux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alerts/prometheus", bytes.NewBufferString("{}")))
+	if rec.Code != http.StatusOK {
+		t.Errorf("webhook status = %d, want 200", rec.Code)
+	}
+}
@@ -1,23 +1,37 @@
 // Package config provides configuration management for the code editing agent.
-// It uses viper for loading configuration from command-line flags, environment variables,
-// and optionally config files.
+// It uses viper for loading configuration from command-line flags and environment
+// variables, and Load additionally reads a YAML config file.
 //
 // Configuration priority (highest to lowest):
 // 1. Command-line flags
-// 2. Environment variables (with AGENT_ prefix)
-// 3. Config file (if specified)
+// 2. Environment variables (with AGENT_ or CODE_AGENT_ prefix)
+// 3. Config file (config.yaml, see Load)
 // 4. Defaults
 package config
 
 import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/infrastructure/adapter/ai"
+	"code-editing-agent/internal/infrastructure/adapter/aidebug"
+	"code-editing-agent/internal/infrastructure/adapter/mcp"
+	"code-editing-agent/internal/infrastructure/version"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration values for the application.
 type Config struct {
+	// Provider is the AI provider adapter, one of ProviderNames().
+	// Defaults to "anthropic".
+	Provider string
+
+	// APIKey is the AI provider API key. It is a secret: Redacted masks it.
+	// Defaults to "" (the provider's environment variable, e.g. ANTHROPIC_API_KEY).
+	APIKey string
+
 	// AIModel is the model identifier to use for AI requests.
 	// Defaults to "hf:zai-org/GLM-4.6"
 	AIModel string
@@ -26,6 +40,31 @@ type Config struct {
 	// Defaults to 20000
 	MaxTokens int64
 
+	// MaxContinuations is how many times a response cut off at MaxTokens is
+	// continued with a follow-up request. 0 returns it truncated. Defaults to 3.
+	MaxContinuations int
+
+	// MaxRetries is how many times an AI request is retried after a retryable
+	// error such as the provider being overloaded. Defaults to 2.
+	MaxRetries int
+
+	// ModelCapabilities overrides what models support, keyed by model name or
+	// by prefix pattern ending in "*", for custom gateways and models the
+	// built-in registry does not know. Unset fields keep the built-in or
+	// conservative default values. Defaults to nil.
+	ModelCapabilities map[string]ai.CapabilityOverride
+
+	// ModelRouting sends classes of routine work to cheaper models: each task
+	// maps to a model ID or shorthand (haiku, sonnet, opus). A model without
+	// tool support is never used for subagents. Defaults to nil, which keeps
+	// AIModel for everything.
+	ModelRouting map[usecase.ModelTask]string
+
+	// Pricing adds or replaces model prices, in US dollars per million
+	// tokens, keyed by model name or by prefix pattern ending in "*", on top
+	// of usecase.DefaultPricing. Defaults to nil.
+	Pricing usecase.Pricing
+
 	// WorkingDir is the base directory for file operations.
 	// All file paths are resolved relative to this directory.
 	// Defaults to "." (current directory)
@@ -65,22 +104,512 @@ type Config struct {
 	// Dangerous commands are still blocked.
 	// Defaults to false (all commands require confirmation).
 	AutoApproveSafeCommands bool
+
+	// Headless sends the user interface's output to stderr and reads no input
+	// from it, keeping stdout for a one-shot answer. It is set by one-shot
+	// runs, not loaded from configuration.
+	Headless bool
+
+	// PromptsDir is the directory containing investigation prompt templates
+	// (<AlertType>.tmpl). Alert types without a template use the built-in prompts.
+	// Defaults to "" (the "prompts" directory under WorkingDir).
+	PromptsDir string
+
+	// RunbooksDir is the directory containing per-alert runbooks (<alertname>.md)
+	// that are appended to investigation prompts.
+	// Defaults to "" (the "runbooks" directory under WorkingDir).
+	RunbooksDir string
+
+	// RunbookURLAllowedDomains are the hosts an alert's runbook_url may be
+	// fetched from; an entry also allows its subdomains. Defaults to nil (runbook
+	// URLs are never fetched and only local runbooks are used).
+	RunbookURLAllowedDomains []string
+
+	// MemoryEnabled loads the global (~/.config/code-agent/AGENT.md) and
+	// project (AGENT.md or .agent/memory.md) memory files into the chat system
+	// prompt and registers the remember tool. Defaults to true.
+	MemoryEnabled bool
+
+	// MemoryMaxBytes caps the merged memory added to the system prompt; the
+	// start of larger memory is dropped. Defaults to 16KB.
+	MemoryMaxBytes int
+
+	// ProjectContextEnabled adds the project detected at the working directory
+	// (languages and build, test, and lint commands, from go.mod, package.json,
+	// pyproject.toml, the Makefile, and CI configs) to the chat system prompt
+	// and registers the project_info tool. Defaults to true.
+	ProjectContextEnabled bool
+
+	// ToolSelectionEnabled offers the model only the core tools plus the
+	// specialized ones (k8s_inspect, promql_query, git, query_logs,
+	// system_snapshot) the conversation mentions, and registers the
+	// request_tool tool through which it asks for the rest. Defaults to false.
+	ToolSelectionEnabled bool
+
+	// ToolSelectionRecentMessages is how many of the latest messages are
+	// scanned for keywords that offer a specialized tool. Defaults to 6.
+	ToolSelectionRecentMessages int
+
+	// DisableMarkdown turns off Markdown rendering of assistant messages.
+	// Rendering is also skipped when NO_COLOR is set or stdout is not a terminal.
+	// Defaults to false.
+	DisableMarkdown bool
+
+	// Verbose shows tool calls with their full input instead of a compact
+	// summary such as bash(go test ./...). Secrets are masked either way.
+	// Defaults to false.
+	Verbose bool
+
+	// NoColor disables ANSI colors in terminal output.
+	// Colors are also disabled when NO_COLOR is set or stdout is not a terminal.
+	// Defaults to false.
+	NoColor bool
+
+	// AttentionBell rings the terminal bell when a confirmation or question
+	// waits for an answer, or a turn longer than AttentionTurnThreshold
+	// finishes. Only in interactive chats. Defaults to true.
+	AttentionBell bool
+
+	// AttentionDesktop also shows those events as desktop notifications,
+	// with osascript on macOS and notify-send on Linux. Defaults to false.
+	AttentionDesktop bool
+
+	// AttentionTurnThreshold is how long a turn must run for its end to ring
+	// the bell and notify. Defaults to 30 seconds; 0 disables turn-end
+	// notifications.
+	AttentionTurnThreshold time.Duration
+
+	// TranscriptFile is the path of the session transcript, a timestamped
+	// plain-text log of messages, tool results, errors, and user input.
+	// The path may contain {date} and {session} placeholders.
+	// Defaults to "" (no transcript).
+	TranscriptFile string
+
+	// TranscriptMaxBytes is the size at which the transcript file is rotated.
+	// Defaults to 0 (10MB).
+	TranscriptMaxBytes int64
+
+	// SessionDir is the directory where each chat session's message history
+	// is saved as <session-id>.json after every change, including rollbacks.
+	// Defaults to "" (history is kept in memory only).
+	SessionDir string
+
+	// MetricsListenAddr is the address (e.g. ":9090") of the HTTP server that
+	// exposes Prometheus metrics at /metrics.
+	// Defaults to "" (metrics disabled).
+	MetricsListenAddr string
+
+	// TracingEndpoint is the OTLP/HTTP collector URL (e.g. "http://localhost:4318")
+	// that OpenTelemetry spans are exported to.
+	// Defaults to "" (tracing disabled).
+	TracingEndpoint string
+
+	// TracingSampleRatio is the fraction of traces to sample, from 0 to 1.
+	// Defaults to 1 (every trace).
+	TracingSampleRatio float64
+
+	// LogLevel is the minimum level logged: "debug", "info", "warn", or "error".
+	// Defaults to "info".
+	LogLevel string
+
+	// LogFormat is the log output format: "text" or "json".
+	// Defaults to "text".
+	LogFormat string
+
+	// LogFile is the path logs are appended to. WARN and above are also written
+	// to stderr. Defaults to "" (log to stderr only).
+	LogFile string
+
+	// InvestigationMaxActions is the maximum number of tool executions per investigation.
+	// Defaults to 20.
+	InvestigationMaxActions int
+
+	// InvestigationMaxDuration is the maximum duration of an investigation.
+	// Defaults to 15 minutes.
+	InvestigationMaxDuration time.Duration
+
+	// InvestigationMaxConcurrent is the maximum number of simultaneous investigations.
+	// Defaults to 5.
+	InvestigationMaxConcurrent int
+
+	// InvestigationMaxConcurrentWait is the longest an investigation waits
+	// for one of the InvestigationMaxConcurrent slots before it fails.
+	// Defaults to 0 (wait until cancelled).
+	InvestigationMaxConcurrentWait time.Duration
+
+	// InvestigationSourceLimit is how many of the InvestigationMaxConcurrent
+	// slots one alert source may take while other sources wait; their alerts
+	// get the next free slots. A source alone may still use every slot.
+	// Defaults to 0 (no per-source limit, and alerts past the limit are refused).
+	InvestigationSourceLimit int
+
+	// InvestigationSourceLimits overrides InvestigationSourceLimit per alert
+	// source; 0 exempts a source. Defaults to nil (no overrides).
+	InvestigationSourceLimits map[string]int
+
+	// InvestigationSourceLabel is the alert label naming an alert's source for
+	// the per-source limits, such as a receiver or team label; alerts without
+	// it count as their webhook source. Defaults to "team".
+	InvestigationSourceLabel string
+
+	// InvestigationSeverityOverrides replaces the investigation limits for alerts
+	// of a severity, keyed by "critical", "warning", or "info".
+	// Defaults to nil (no overrides).
+	InvestigationSeverityOverrides map[string]usecase.InvestigationLimits
+
+	// InvestigationAllowedCommandPatterns switches investigation bash and
+	// wait_for commands to allowlist mode: each segment of a command must match
+	// one of these regular expressions. Defaults to nil (blocklist only).
+	InvestigationAllowedCommandPatterns []string
+
+	// InvestigationMaxCost is the most an investigation may spend on AI turns,
+	// in US dollars, estimated from token usage and Pricing. Defaults to 0 (no cap).
+	InvestigationMaxCost float64
+
+	// InvestigationMaxSchemaRetries is how many consecutive calls of one tool
+	// with input that does not match its schema an investigation makes before
+	// the tool is disabled for the rest of the run. Defaults to 2.
+	InvestigationMaxSchemaRetries int
+
+	// InvestigationCountSubagentUsage counts the actions, tokens, and cost of
+	// the subagents an investigation spawns toward its max_actions and
+	// max_cost. Defaults to false.
+	InvestigationCountSubagentUsage bool
+
+	// InvestigationAutoRemediate lets a completed investigation run the
+	// commands of its low-risk recommended actions that did not ask for a
+	// person's approval. Defaults to false.
+	InvestigationAutoRemediate bool
+
+	// InvestigationMessageTemplate is the text/template of the user message
+	// that starts each investigation, rendered with the alert's fields and its
+	// labels and annotations. Defaults to usecase.DefaultMessageTemplate.
+	InvestigationMessageTemplate string
+
+	// InvestigationTitleTemplate is the text/template of an investigation's
+	// title, used by result notifications and as the report's heading.
+	// Defaults to usecase.DefaultTitleTemplate.
+	InvestigationTitleTemplate string
+
+	// InvestigationDailyBudget is the most investigations may spend per UTC
+	// day, in US dollars; once it is spent, alerts are recorded as deferred.
+	// Defaults to 0 (no budget).
+	InvestigationDailyBudget float64
+
+	// InvestigationMaxProviderHold is the longest an investigation waits for
+	// the AI provider to recover from an outage before it is recorded as
+	// deferred. Defaults to 30m; 0 holds investigations until it recovers.
+	InvestigationMaxProviderHold time.Duration
+
+	// SubagentMaxActions is the maximum number of tool executions per subagent run.
+	// Defaults to 20.
+	SubagentMaxActions int
+
+	// SubagentMaxDuration is the maximum duration of a subagent run, unless the
+	// agent's AGENT.md sets its own. Defaults to 5 minutes.
+	SubagentMaxDuration time.Duration
+
+	// SubagentAllowedCommandPatterns restricts subagent bash and wait_for
+	// commands like InvestigationAllowedCommandPatterns. Defaults to nil.
+	SubagentAllowedCommandPatterns []string
+
+	// DrainTimeout is how long the daemon waits on SIGTERM or SIGINT for
+	// in-flight investigations to finish before cancelling them and marking
+	// them "interrupted". Defaults to 30 seconds.
+	DrainTimeout time.Duration
+
+	// AlertCircuitThreshold is how many investigations an alert source may
+	// start per AlertCircuitWindow; its next alerts are deferred until the
+	// circuit closes. Defaults to 20; 0 disables the circuit breaker.
+	AlertCircuitThreshold int
+
+	// AlertCircuitWindow is the rolling window AlertCircuitThreshold counts
+	// investigations over. Defaults to 10 minutes.
+	AlertCircuitWindow time.Duration
+
+	// AlertCircuitCooldown is how long an open circuit defers a source's
+	// alerts before one probe investigation decides whether it closes.
+	// Defaults to 15 minutes.
+	AlertCircuitCooldown time.Duration
+
+	// RateLimitRequestsPerMinute caps requests to the AI provider across all
+	// concurrent investigations and subagents. Defaults to 0 (unlimited).
+	RateLimitRequestsPerMinute int
+
+	// RateLimitTokensPerMinute caps the estimated input tokens sent to the AI
+	// provider per minute. Defaults to 0 (unlimited).
+	RateLimitTokensPerMinute int
+
+	// NotifyURLs are the endpoints each finished investigation's result is
+	// POSTed to as JSON. Defaults to nil (no notifications).
+	NotifyURLs []string
+
+	// NotifySecret signs result notifications with HMAC-SHA256 in the
+	// X-Agent-Signature-256 header. Defaults to "" (unsigned).
+	NotifySecret string
+
+	// NotifyMaxAttempts is how many times a notification is sent to each URL
+	// before giving up on network errors and 5xx responses. Defaults to 5.
+	NotifyMaxAttempts int
+
+	// NotifyQueueSize is how many results may wait for delivery; results
+	// arriving while the queue is full are dropped. Defaults to 100.
+	NotifyQueueSize int
+
+	// EnrichmentStaticFile is a YAML file of rules adding annotations, such as
+	// the owning team or runbook, to alerts matching label selectors before
+	// they are investigated. Defaults to "" (no static enrichment).
+	EnrichmentStaticFile string
+
+	// EnrichmentHTTPURL is a lookup service each alert about to be investigated
+	// is POSTed to as JSON; the string values it returns are added as
+	// annotations. Defaults to "" (no HTTP enrichment).
+	EnrichmentHTTPURL string
+
+	// EnrichmentHTTPTimeout limits each enrichment request. Defaults to 5 seconds.
+	EnrichmentHTTPTimeout time.Duration
+
+	// DigestSchedule is when the daemon publishes a digest of investigation
+	// outcomes through the notifier: "daily HH:MM" or "weekly <weekday> HH:MM",
+	// in local time. Defaults to "" (no scheduled digests).
+	DigestSchedule string
+
+	// ToolMaxOutputBytes truncates tool output longer than this many bytes
+	// before it reaches the model. Defaults to 0 (unlimited).
+	ToolMaxOutputBytes int
+
+	// ToolBlockedCommands are substrings that make any bash command containing
+	// them fail before it runs or is confirmed, in every session. Defaults to
+	// nil (only the dangerous-command confirmation applies).
+	ToolBlockedCommands []string
+
+	// BashMaxOutputBytes caps the combined stdout and stderr kept from a bash
+	// command; the rest is replaced by a truncation notice. Defaults to 1MB;
+	// 0 means unlimited.
+	BashMaxOutputBytes int
+
+	// BashAllowedEnv names environment variables bash commands inherit in
+	// addition to PATH, HOME, LANG and the other defaults; a trailing "*"
+	// matches a prefix. Defaults to nil.
+	BashAllowedEnv []string
+
+	// FetchURLAllowedDomains are the hosts the fetch_url tool may request; an
+	// entry also allows its subdomains. Defaults to nil (every URL is refused).
+	FetchURLAllowedDomains []string
+
+	// FetchURLMaxBytes caps how much of a fetch_url response body is read;
+	// the rest is replaced by a truncation notice. Defaults to 1MB.
+	FetchURLMaxBytes int
+
+	// FetchURLTimeout limits each fetch_url request, redirects included.
+	// Defaults to 30 seconds.
+	FetchURLTimeout time.Duration
+
+	// K8sEnabled registers the read-only k8s_inspect tool. Defaults to false.
+	K8sEnabled bool
+
+	// K8sKubeconfig is the kubeconfig k8s_inspect uses; empty uses the
+	// in-cluster service account. Defaults to "".
+	K8sKubeconfig string
+
+	// K8sContext selects a kubeconfig context other than the current one.
+	// Defaults to "".
+	K8sContext string
+
+	// K8sAllowedNamespaces are the namespaces k8s_inspect may read; "*"
+	// allows all. Must be set when K8sEnabled is. Defaults to nil.
+	K8sAllowedNamespaces []string
+
+	// K8sMaxLogLines caps the log lines k8s_inspect returns. Defaults to 500.
+	K8sMaxLogLines int
+
+	// PromQLEndpoint is the Prometheus base URL the promql_query tool queries
+	// when the alert's generator URL is not allowed. Defaults to "".
+	PromQLEndpoint string
+
+	// PromQLAllowedHosts are the hosts an alert's generator URL may name for
+	// promql_query to query that Prometheus instead. Defaults to nil.
+	PromQLAllowedHosts []string
+
+	// PromQLMaxSeries caps the series promql_query shows per query. Defaults
+	// to 20.
+	PromQLMaxSeries int
+
+	// PromQLTimeout limits each promql_query request. Defaults to 30 seconds.
+	PromQLTimeout time.Duration
+
+	// GitEnabled registers git_status, git_diff, and git_commit when the
+	// working directory is inside a git repository. Defaults to true.
+	GitEnabled bool
+
+	// GitMaxDiffBytes caps the diff git_diff returns. Defaults to 64KB.
+	GitMaxDiffBytes int
+
+	// GitPushEnabled also registers git_push. Defaults to false.
+	GitPushEnabled bool
+
+	// GitProtectedBranches are the branch patterns (path.Match syntax)
+	// git_push refuses. Defaults to main, master, and release/*.
+	GitProtectedBranches []string
+
+	// ToolDefaultTimeout is how long a single tool execution may run when the
+	// tool has no entry in ToolTimeouts. Defaults to 0 (unlimited).
+	ToolDefaultTimeout time.Duration
+
+	// ToolTimeouts overrides ToolDefaultTimeout per tool name; 0 makes a tool
+	// unlimited. Defaults to nil.
+	ToolTimeouts map[string]time.Duration
+
+	// ToolCacheEnabled caches read_file and list_files results per session
+	// until a write invalidates them. Defaults to true.
+	ToolCacheEnabled bool
+
+	// ToolCacheMaxEntries caps the number of cached tool results. Defaults to 256.
+	ToolCacheMaxEntries int
+
+	// ToolCacheMaxBytes caps the total size of cached tool results. Defaults
+	// to 8 MiB.
+	ToolCacheMaxBytes int
+
+	// ToolChangesMaxSnapshotBytes is the largest file whose contents are
+	// snapshotted before a session first modifies it; larger files are listed
+	// in the change summary without a diff. Defaults to 1MB.
+	ToolChangesMaxSnapshotBytes int
+
+	// MCPServers are the Model Context Protocol servers connected at startup,
+	// keyed by server name. Their tools are registered as
+	// mcp__<server>__<tool>. Defaults to nil.
+	MCPServers map[string]mcp.ServerConfig
+
+	// HealthCacheTTL is how long a readiness report is reused before the
+	// checks run again. Defaults to 5 seconds.
+	HealthCacheTTL time.Duration
+
+	// HealthOptionalChecks names readiness checks ("ai_provider",
+	// "investigation_store", "workspace") that are reported but do not fail
+	// readiness. Defaults to nil (every check is required).
+	HealthOptionalChecks []string
+
+	// UpdateCheckEnabled makes serve check at startup whether a newer release
+	// is out, printing a notice if so. Nothing is downloaded. Defaults to false.
+	UpdateCheckEnabled bool
+
+	// UpdateCheckURL is the GitHub releases API endpoint of the latest
+	// release. Defaults to version.DefaultReleasesURL.
+	UpdateCheckURL string
+
+	// DebugAPIEnabled writes each AI provider request and response, with
+	// secrets masked, to a JSON file under DebugAPIDir. ":debug api on"
+	// enables it for the rest of a chat. Defaults to false.
+	DebugAPIEnabled bool
+
+	// DebugAPIDir is the directory of the API debug files, one subdirectory
+	// per session. Defaults to aidebug.DefaultDir() (~/.config/code-agent/debug).
+	DebugAPIDir string
+
+	// DebugAPIMaxFileBytes caps the size of one API debug file; longer texts
+	// are cut to fit. Defaults to aidebug.DefaultMaxFileBytes (1MB).
+	DebugAPIMaxFileBytes int
+
+	// DebugAPIRetention is the age at which API debug files are removed, when
+	// debug logging is enabled. Defaults to aidebug.DefaultRetention (7 days).
+	DebugAPIRetention time.Duration
+
+	// DebugAPIRecordFile, when set, records every AI provider exchange to
+	// this file as an aireplay recording, rewritten after each exchange, to
+	// be replayed in a golden test. Defaults to "" (not recorded).
+	DebugAPIRecordFile string
 }
 
 // Defaults returns a Config struct with all default values set.
 func Defaults() *Config {
 	return &Config{
-		AIModel:           "hf:zai-org/GLM-4.6",
-		MaxTokens:         20000,
-		WorkingDir:        ".",
-		WelcomeMessage:    "Chat with Claude (use 'ctrl+c' to quit)",
-		GoodbyeMessage:    "Bye!",
-		HistoryFile:       "~/.code-editing-agent-history",
-		HistoryMaxEntries: 1000,
-		ExtendedThinking:  false,
-		ThinkingBudget:    10000,
-		ShowThinking:      false,
+		Provider:           "anthropic",
+		AIModel:            "hf:zai-org/GLM-4.6",
+		MaxTokens:          20000,
+		MaxContinuations:   3,
+		MaxRetries:         2,
+		WorkingDir:         ".",
+		WelcomeMessage:     "Chat with Claude (use 'ctrl+c' to quit)",
+		GoodbyeMessage:     "Bye!",
+		HistoryFile:        "~/.code-editing-agent-history",
+		HistoryMaxEntries:  1000,
+		ExtendedThinking:   false,
+		ThinkingBudget:     10000,
+		ShowThinking:       false,
+		TracingSampleRatio: 1,
+		LogLevel:           "info",
+		LogFormat:          "text",
+
+		InvestigationMaxActions:       20,
+		InvestigationMaxDuration:      15 * time.Minute,
+		InvestigationMaxConcurrent:    5,
+		InvestigationMaxSchemaRetries: 2,
+		InvestigationSourceLabel:      "team",
+		InvestigationMaxProviderHold:  30 * time.Minute,
+		InvestigationMessageTemplate:  usecase.DefaultMessageTemplate,
+		InvestigationTitleTemplate:    usecase.DefaultTitleTemplate,
+		SubagentMaxActions:            20,
+		SubagentMaxDuration:           5 * time.Minute,
+		DrainTimeout:                  30 * time.Second,
+		AlertCircuitThreshold:         20,
+		AlertCircuitWindow:            10 * time.Minute,
+		AlertCircuitCooldown:          15 * time.Minute,
+		HealthCacheTTL:                5 * time.Second,
+		NotifyMaxAttempts:             5,
+		NotifyQueueSize:               100,
+		EnrichmentHTTPTimeout:         5 * time.Second,
+		BashMaxOutputBytes:            1 << 20,
+		FetchURLMaxBytes:              1 << 20,
+		FetchURLTimeout:               30 * time.Second,
+		K8sMaxLogLines:                500,
+		PromQLMaxSeries:               20,
+		PromQLTimeout:                 30 * time.Second,
+		GitEnabled:                    true,
+		GitMaxDiffBytes:               64 << 10,
+		GitProtectedBranches:          []string{"main", "master", "release/*"},
+		ToolCacheEnabled:              true,
+		ToolCacheMaxEntries:           256,
+		ToolCacheMaxBytes:             8 << 20,
+		ToolChangesMaxSnapshotBytes:   1 << 20,
+		MemoryEnabled:                 true,
+		MemoryMaxBytes:                16 << 10,
+		ProjectContextEnabled:         true,
+		ToolSelectionRecentMessages:   6,
+		AttentionBell:                 true,
+		AttentionTurnThreshold:        30 * time.Second,
+		UpdateCheckURL:                version.DefaultReleasesURL,
+		DebugAPIDir:                   aidebug.DefaultDir(),
+		DebugAPIMaxFileBytes:          aidebug.DefaultMaxFileBytes,
+		DebugAPIRetention:             aidebug.DefaultRetention,
+	}
+}
+
+// withUnsetDefaults returns a copy of c with the provider and investigation and
+// subagent limits set to their defaults where unset, so configs built without
+// Defaults() keep the previous fixed limits.
+func (c *Config) withUnsetDefaults() *Config {
+	filled := *c
+	defaults := Defaults()
+	if filled.Provider == "" {
+		filled.Provider = defaults.Provider
+	}
+	if filled.InvestigationMaxActions == 0 {
+		filled.InvestigationMaxActions = defaults.InvestigationMaxActions
+	}
+	if filled.InvestigationMaxDuration == 0 {
+		filled.InvestigationMaxDuration = defaults.InvestigationMaxDuration
+	}
+	if filled.InvestigationMaxConcurrent == 0 {
+		filled.InvestigationMaxConcurrent = defaults.InvestigationMaxConcurrent
 	}
+	if filled.SubagentMaxActions == 0 {
+		filled.SubagentMaxActions = defaults.SubagentMaxActions
+	}
+	if filled.SubagentMaxDuration == 0 {
+		filled.SubagentMaxDuration = defaults.SubagentMaxDuration
+	}
+	return &filled
 }
 
 // LoadConfig loads and returns the configuration from viper.
@@ -94,7 +623,12 @@ func Defaults() *Config {
 func LoadConfig() *Config {
 	// Set defaults first
 	cfg := Defaults()
+	applyViper(cfg)
+	return cfg
+}
 
+// applyViper overrides cfg with the flags and AGENT_ environment variables set in viper.
+func applyViper(cfg *Config) {
 	// Load from viper (reads flags and env vars)
 	viper.SetEnvPrefix("AGENT")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -130,6 +664,45 @@ func LoadConfig() *Config {
 	if viper.IsSet("auto_approve_safe") {
 		cfg.AutoApproveSafeCommands = viper.GetBool("auto_approve_safe")
 	}
+	if viper.IsSet("prompts_dir") {
+		cfg.PromptsDir = viper.GetString("prompts_dir")
+	}
+	if viper.IsSet("runbooks_dir") {
+		cfg.RunbooksDir = viper.GetString("runbooks_dir")
+	}
+	if viper.IsSet("no_markdown") {
+		cfg.DisableMarkdown = viper.GetBool("no_markdown")
+	}
+	if viper.IsSet("no_color") {
+		cfg.NoColor = viper.GetBool("no_color")
+	}
+	if viper.IsSet("verbose") {
+		cfg.Verbose = viper.GetBool("verbose")
+	}
+	if viper.IsSet("transcript") {
+		cfg.TranscriptFile = viper.GetString("transcript")
+	}
+	if viper.IsSet("transcript_max_bytes") {
+		cfg.TranscriptMaxBytes = viper.GetInt64("transcript_max_bytes")
+	}
+	if viper.IsSet("metrics_listen_addr") {
+		cfg.MetricsListenAddr = viper.GetString("metrics_listen_addr")
+	}
+	if viper.IsSet("tracing.endpoint") {
+		cfg.TracingEndpoint = viper.GetString("tracing.endpoint")
+	}
+	if viper.IsSet("tracing.sample_ratio") {
+		cfg.TracingSampleRatio = viper.GetFloat64("tracing.sample_ratio")
+	}
+	if viper.IsSet("log_level") {
+		cfg.LogLevel = viper.GetString("log_level")
+	}
+	if viper.IsSet("log_format") {
+		cfg.LogFormat = viper.GetString("log_format")
+	}
+	if viper.IsSet("log_file") {
+		cfg.LogFile = viper.GetString("log_file")
+	}
 	if viper.IsSet("thinking.enabled") {
 		cfg.ExtendedThinking = viper.GetBool("thinking.enabled")
 	}
@@ -147,6 +720,7 @@ func LoadConfig() *Config {
 	if viper.IsSet("thinking.show") {
 		cfg.ShowThinking = viper.GetBool("thinking.show")
 	}
-
-	return cfg
+	if viper.IsSet("drain_timeout") {
+		cfg.DrainTimeout = viper.GetDuration("drain_timeout")
+	}
 }
@@ -343,3 +343,58 @@ func TestConfig_ExtendedThinkingFieldsExist(t *testing.T) {
 		require.NotNil(t, cfg)
 	})
 }
+
+// TestConfig_Tracing verifies tracing defaults and environment variable overrides.
+func TestConfig_Tracing(t *testing.T) {
+	t.Run("tracing is disabled by default and samples every trace", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+
+		cfg := LoadConfig()
+
+		assert.Empty(t, cfg.TracingEndpoint, "tracing should be disabled without an endpoint")
+		assert.InDelta(t, 1.0, cfg.TracingSampleRatio, 0, "every trace should be sampled by default")
+	})
+
+	t.Run("AGENT_TRACING_ENDPOINT and AGENT_TRACING_SAMPLE_RATIO override defaults", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+
+		t.Setenv("AGENT_TRACING_ENDPOINT", "http://collector:4318")
+		t.Setenv("AGENT_TRACING_SAMPLE_RATIO", "0.25")
+
+		cfg := LoadConfig()
+
+		assert.Equal(t, "http://collector:4318", cfg.TracingEndpoint)
+		assert.InDelta(t, 0.25, cfg.TracingSampleRatio, 0)
+	})
+}
+
+// TestConfig_Logging verifies logging defaults and environment variable overrides.
+func TestConfig_Logging(t *testing.T) {
+	t.Run("logs text at info level to stderr by default", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+
+		cfg := LoadConfig()
+
+		assert.Equal(t, "info", cfg.LogLevel)
+		assert.Equal(t, "text", cfg.LogFormat)
+		assert.Empty(t, cfg.LogFile, "logs should go to stderr only by default")
+	})
+
+	t.Run("AGENT_LOG_* variables override defaults", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+
+		t.Setenv("AGENT_LOG_LEVEL", "debug")
+		t.Setenv("AGENT_LOG_FORMAT", "json")
+		t.Setenv("AGENT_LOG_FILE", "/var/log/agent.log")
+
+		cfg := LoadConfig()
+
+		assert.Equal(t, "debug", cfg.LogLevel)
+		assert.Equal(t, "json", cfg.LogFormat)
+		assert.Equal(t, "/var/log/agent.log", cfg.LogFile)
+	})
+}
@@ -3,26 +3,49 @@
 package config
 
 import (
+	"cmp"
 	"code-editing-agent/internal/application/usecase"
 	"code-editing-agent/internal/domain/entity"
 	"code-editing-agent/internal/domain/port"
 	"code-editing-agent/internal/domain/service"
 	"code-editing-agent/internal/infrastructure/adapter/ai"
+	"code-editing-agent/internal/infrastructure/adapter/aidebug"
+	"code-editing-agent/internal/infrastructure/adapter/aireplay"
 	"code-editing-agent/internal/infrastructure/adapter/alert"
+	"code-editing-agent/internal/infrastructure/adapter/enrich"
 	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/health"
 	"code-editing-agent/internal/infrastructure/adapter/investigation"
+	"code-editing-agent/internal/infrastructure/adapter/mcp"
+	"code-editing-agent/internal/infrastructure/adapter/memory"
+	"code-editing-agent/internal/infrastructure/adapter/metrics"
+	"code-editing-agent/internal/infrastructure/adapter/notify"
+	"code-editing-agent/internal/infrastructure/adapter/project"
+	"code-editing-agent/internal/infrastructure/adapter/prompt"
+	"code-editing-agent/internal/infrastructure/adapter/ratelimit"
+	"code-editing-agent/internal/infrastructure/adapter/runbook"
 	"code-editing-agent/internal/infrastructure/adapter/skill"
 	"code-editing-agent/internal/infrastructure/adapter/subagent"
 	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/adapter/tracing"
+	"code-editing-agent/internal/infrastructure/adapter/transcript"
 	"code-editing-agent/internal/infrastructure/adapter/ui"
 	"code-editing-agent/internal/infrastructure/adapter/webhook"
+	"code-editing-agent/internal/infrastructure/logger"
+	"code-editing-agent/internal/infrastructure/version"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	appsvc "code-editing-agent/internal/application/service"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // investigationStoreAdapter adapts FileInvestigationStore to the usecase.InvestigationStoreWriter interface.
@@ -38,7 +61,10 @@ func (a *investigationStoreAdapter) Store(ctx context.Context, inv usecase.Inves
 		inv.StartedAt(), inv.CompletedAt(),
 		inv.Findings(), inv.ActionsTaken(), inv.Duration(),
 		inv.Confidence(), inv.Escalated(), inv.EscalateReason(),
-	)
+	).WithErrorMessage(inv.ErrorMessage()).WithErrorKind(inv.ErrorKind()).WithAlert(inv.Alert()).
+		WithResolution(inv.RootCause(), inv.RecommendedActions()).WithRemediations(inv.Remediations()).
+		WithUsage(inv.Cost(), inv.Usage()).
+		WithVersion(cmp.Or(inv.Version(), version.Get().Version))
 	return a.store.Store(ctx, stub)
 }
 
@@ -46,16 +72,141 @@ func (a *investigationStoreAdapter) Get(ctx context.Context, id string) (usecase
 	return a.store.Get(ctx, id)
 }
 
+func (a *investigationStoreAdapter) Events(ctx context.Context, id string) ([]port.InvestigationEvent, error) {
+	return a.store.Events(ctx, id)
+}
+
+func (a *investigationStoreAdapter) ListByStatus(
+	ctx context.Context,
+	status string,
+) ([]usecase.InvestigationRecordData, error) {
+	records, _, err := a.store.List(ctx, appsvc.InvestigationQuery{Status: []string{status}}, appsvc.InvestigationPage{})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]usecase.InvestigationRecordData, 0, len(records))
+	for _, record := range records {
+		data = append(data, record)
+	}
+	return data, nil
+}
+
+func (a *investigationStoreAdapter) ListStarted(
+	ctx context.Context,
+	since, until time.Time,
+) ([]usecase.InvestigationRecordData, error) {
+	query := appsvc.InvestigationQuery{Since: since, Until: until}
+	records, _, err := a.store.List(ctx, query, appsvc.InvestigationPage{})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]usecase.InvestigationRecordData, 0, len(records))
+	for _, record := range records {
+		data = append(data, record)
+	}
+	return data, nil
+}
+
 func (a *investigationStoreAdapter) Update(ctx context.Context, inv usecase.InvestigationRecordData) error {
 	stub := appsvc.NewInvestigationRecordWithResult(
 		inv.ID(), inv.AlertID(), inv.SessionID(), inv.Status(),
 		inv.StartedAt(), inv.CompletedAt(),
 		inv.Findings(), inv.ActionsTaken(), inv.Duration(),
 		inv.Confidence(), inv.Escalated(), inv.EscalateReason(),
-	)
+	).WithErrorMessage(inv.ErrorMessage()).WithErrorKind(inv.ErrorKind()).WithAlert(inv.Alert()).
+		WithResolution(inv.RootCause(), inv.RecommendedActions()).WithRemediations(inv.Remediations()).
+		WithUsage(inv.Cost(), inv.Usage()).
+		WithVersion(cmp.Or(inv.Version(), version.Get().Version))
 	return a.store.Update(ctx, stub)
 }
 
+// InvestigationStoreDir returns the directory investigation records are kept in.
+func InvestigationStoreDir(cfg *Config) string {
+	return filepath.Join(cfg.WorkingDir, ".agent", "investigations")
+}
+
+// newAlertCircuitBreaker creates the circuit breaker of the configured
+// threshold, window, and cooldown, or returns nil when the threshold is 0.
+func newAlertCircuitBreaker(cfg *Config) *usecase.AlertCircuitBreaker {
+	if cfg.AlertCircuitThreshold <= 0 {
+		return nil
+	}
+	return usecase.NewAlertCircuitBreaker(usecase.AlertCircuitBreakerConfig{
+		Window:    cfg.AlertCircuitWindow,
+		Threshold: cfg.AlertCircuitThreshold,
+		Cooldown:  cfg.AlertCircuitCooldown,
+	})
+}
+
+// newAlertScheduler creates the scheduler sharing the investigation slots
+// between alert sources, or returns nil when no source has a limit.
+func newAlertScheduler(cfg *Config) *usecase.AlertScheduler {
+	if cfg.InvestigationSourceLimit <= 0 && len(cfg.InvestigationSourceLimits) == 0 {
+		return nil
+	}
+	return usecase.NewAlertScheduler(usecase.AlertSchedulerConfig{
+		Slots:        cfg.InvestigationMaxConcurrent,
+		SourceLimit:  cfg.InvestigationSourceLimit,
+		SourceLimits: cfg.InvestigationSourceLimits,
+		SourceLabel:  cfg.InvestigationSourceLabel,
+	})
+}
+
+// NewReportGenerator creates the generator of reports of the investigations
+// in store, rendered with the report template of the prompts directory, if
+// any. A nil summarizer leaves reports without executive summaries; summaries
+// are written on the model routed for summarization, if any.
+func NewReportGenerator(
+	cfg *Config,
+	store *investigation.FileInvestigationStore,
+	summarizer port.AIProvider,
+) (*usecase.ReportGenerator, error) {
+	tmpl, err := prompt.LoadReportTemplate(promptsDir(cfg))
+	if err != nil {
+		return nil, err
+	}
+	generator := usecase.NewReportGenerator()
+	generator.SetTemplate(tmpl)
+	generator.SetTitleTemplate(alertTemplate("title", cfg.InvestigationTitleTemplate))
+	generator.SetSummaryProvider(summarizer)
+	generator.SetModelRouter(usecase.NewModelRouter(summarizer, cfg.ModelRouting))
+	generator.SetInvestigationSource(&investigationStoreAdapter{store: store})
+	return generator, nil
+}
+
+// NewDigestGenerator creates the generator of digests of the investigations in
+// store, posted through notifier. A nil notifier leaves digests unpublished.
+func NewDigestGenerator(
+	store *investigation.FileInvestigationStore,
+	notifier *notify.Notifier,
+) *usecase.DigestGenerator {
+	generator := usecase.NewDigestGenerator(&investigationStoreAdapter{store: store})
+	// Avoid storing a typed nil in the interface
+	if notifier != nil {
+		generator.SetNotifier(notifier)
+	}
+	return generator
+}
+
+// newDigestScheduler creates the scheduler publishing digests on
+// cfg.DigestSchedule, or returns nil when no schedule is configured.
+func newDigestScheduler(
+	cfg *Config,
+	generator *usecase.DigestGenerator,
+	logger *slog.Logger,
+) (*usecase.DigestScheduler, error) {
+	if cfg.DigestSchedule == "" {
+		return nil, nil
+	}
+	schedule, err := usecase.ParseDigestSchedule(cfg.DigestSchedule)
+	if err != nil {
+		return nil, err
+	}
+	scheduler := usecase.NewDigestScheduler(generator, schedule)
+	scheduler.SetLogger(logger)
+	return scheduler, nil
+}
+
 // Container holds all application dependencies wired together.
 // It provides a single point of access to all services and ports,
 // following the dependency injection pattern for clean architecture.
@@ -66,19 +217,70 @@ func (a *investigationStoreAdapter) Update(ctx context.Context, inv usecase.Inve
 // - Creating application services (application layer)
 // - Providing accessors for all dependencies.
 type Container struct {
+	mu                   sync.RWMutex // Guards config and the notifiers, which Reload replaces
 	config               *Config
 	chatService          *appsvc.ChatService
 	convService          *service.ConversationService
 	fileManager          port.FileManager
 	uiAdapter            port.UserInterface
 	aiAdapter            port.AIProvider
+	providerAdapter      port.AIProvider // aiAdapter without the rate limiter, for optional setters
+	apiDebug             *aidebug.Provider
 	toolExecutor         port.ToolExecutor
+	baseExecutor         *tool.ExecutorAdapter // toolExecutor without plan mode, for ReloadTools
 	skillManager         port.SkillManager
 	alertSourceManager   port.AlertSourceManager
 	investigationUseCase *usecase.AlertInvestigationUseCase
 	webhookAdapter       *webhook.HTTPAdapter
 	subagentManager      port.SubagentManager
 	subagentUseCase      *usecase.SubagentUseCase
+	resultNotifier       *notify.Notifier
+	retiredNotifiers     []*notify.Notifier // Replaced by Reload, still used by investigations started before it
+	investigationEvents  *webhook.EventBroker
+	reportGenerator      *usecase.ReportGenerator
+	digestGenerator      *usecase.DigestGenerator
+	digestScheduler      *usecase.DigestScheduler
+	alertSuppressions    usecase.AlertSuppressionStore
+	alertCircuit         *usecase.AlertCircuitBreaker
+	investigationStore   *investigation.FileInvestigationStore
+	alertEnrichers       []usecase.AlertEnricher
+	blockedCommands      *tool.BlockedCommandList
+	memory               *memory.Store
+	changeTracker        *tool.ChangeTracker
+	toolStats            *tool.ToolStatsTracker
+	mcpManager           *mcp.Manager
+	metricsRegistry      *metrics.Registry
+	healthChecker        *health.Checker
+	tracerProvider       *sdktrace.TracerProvider
+	logger               *slog.Logger
+	closeLogger          func() error
+}
+
+// aiProviders creates the AI provider adapters selectable with Config.Provider.
+//
+//nolint:gochecknoglobals // registry of adapter constructors keyed by provider name
+var aiProviders = map[string]func(cfg *Config, subagentManager port.SubagentManager) port.AIProvider{
+	"anthropic": func(cfg *Config, subagentManager port.SubagentManager) port.AIProvider {
+		adapter := ai.NewAnthropicAdapter(cfg.AIModel, cfg.MaxTokens, subagentManager)
+		if keyed, ok := adapter.(interface{ SetAPIKey(string) }); ok {
+			keyed.SetAPIKey(cfg.APIKey)
+		}
+		if continued, ok := adapter.(interface{ SetMaxContinuations(int) }); ok {
+			continued.SetMaxContinuations(cfg.MaxContinuations)
+		}
+		if retried, ok := adapter.(interface{ SetMaxRetries(int) }); ok {
+			retried.SetMaxRetries(cfg.MaxRetries)
+		}
+		if capable, ok := adapter.(interface{ SetCapabilityRegistry(*ai.CapabilityRegistry) }); ok {
+			capable.SetCapabilityRegistry(ai.NewCapabilityRegistry(cfg.ModelCapabilities))
+		}
+		return adapter
+	},
+}
+
+// ProviderNames returns the AI providers that Config.Provider can name, sorted.
+func ProviderNames() []string {
+	return sortedKeys(aiProviders)
 }
 
 // NewContainer creates a new DI container and wires all dependencies.
@@ -98,11 +300,49 @@ func NewContainer(cfg *Config) (*Container, error) {
 	if cfg == nil {
 		return nil, errors.New("config cannot be nil")
 	}
+	cfg = cfg.withUnsetDefaults()
 
 	// Step 1: Create infrastructure adapters
+	// The logger comes first so the components created below can log through it
+	agentLogger, closeLogger, err := logger.New(logger.Config{
+		Level:  cfg.LogLevel,
+		Format: cfg.LogFormat,
+		File:   cfg.LogFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Note: order matters - skillManager and subagentManager must be created before aiAdapter
 	fileManager := file.NewLocalFileManager(cfg.WorkingDir)
 	uiAdapter := ui.NewCLIAdapterWithHistory(cfg.HistoryFile)
+	if cfg.Headless {
+		uiAdapter = ui.NewHeadlessCLIAdapter(os.Stderr)
+	}
+	if cfg.DisableMarkdown {
+		uiAdapter.SetMarkdownRendering(false)
+	}
+	if cfg.NoColor {
+		uiAdapter.SetColorEnabled(false)
+	}
+	// --verbose shows tool calls with their full input instead of compact summaries
+	uiAdapter.SetVerbose(cfg.Verbose)
+	// --show-thinking shows full thinking blocks instead of one-line summaries
+	uiAdapter.SetThinkingExpanded(cfg.ShowThinking)
+	// Complete @file mentions relative to the workspace
+	uiAdapter.RegisterCompleter("@", ui.NewPathCompletion(cfg.WorkingDir))
+	if cfg.TranscriptFile != "" {
+		if err := uiAdapter.EnableTranscript(cfg.TranscriptFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: transcript disabled: %v\n", err)
+		} else {
+			uiAdapter.SetTranscriptMaxBytes(cfg.TranscriptMaxBytes)
+		}
+	}
+	// The bell and desktop notifications are for someone at the terminal, so
+	// headless runs and piped input go without
+	if !cfg.Headless && ui.IsTerminal(os.Stdin) {
+		configureAttention(uiAdapter, cfg)
+	}
 	skillManager := skill.NewLocalSkillManager()
 
 	// Create subagentManager early for tool and system prompt integration
@@ -112,12 +352,118 @@ func NewContainer(cfg *Config) (*Container, error) {
 		{Path: filepath.Join(getUserHome(), ".claude", "agents"), SourceType: entity.SubagentSourceUser},
 	})
 
-	aiAdapter := ai.NewAnthropicAdapter(cfg.AIModel, cfg.MaxTokens, subagentManager)
+	newAIProvider, ok := aiProviders[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.Provider)
+	}
+	providerAdapter := newAIProvider(cfg, subagentManager)
+	if cfg.DebugAPIRecordFile != "" {
+		// Recorded unmasked, like a golden test transcript; opt-in only
+		providerAdapter = aireplay.NewRecordingAIProvider(providerAdapter, cfg.DebugAPIRecordFile)
+		agentLogger.Warn("recording AI exchanges unmasked", "file", cfg.DebugAPIRecordFile)
+	}
+	if reporter, ok := providerAdapter.(port.ModelCapabilityReporter); ok {
+		if caps, known := reporter.ModelCapabilities(cfg.AIModel); !known {
+			agentLogger.Warn("unknown model, assuming conservative capabilities; override them under models in the config file",
+				"model", cfg.AIModel, "context_window", caps.ContextWindow, "max_output_tokens", caps.MaxOutputTokens,
+				"supports_thinking", caps.SupportsThinking, "supports_images", caps.SupportsImages)
+		}
+	}
+	if router := usecase.NewModelRouter(providerAdapter, cfg.ModelRouting); router.Route(usecase.ModelTaskSubagent, false) != "" &&
+		router.Route(usecase.ModelTaskSubagent, true) == "" {
+		agentLogger.Warn("model routed for subagents does not support tools; subagents keep the parent's model",
+			"model", cfg.ModelRouting[usecase.ModelTaskSubagent])
+	}
+
+	// Debug logging sees every request of every provider; :debug api turns it on later
+	apiDebug := aidebug.NewProvider(providerAdapter, ui.NewRedactor(), aidebug.Options{
+		Dir:          cfg.DebugAPIDir,
+		MaxFileBytes: cfg.DebugAPIMaxFileBytes,
+		Retention:    cfg.DebugAPIRetention,
+		Enabled:      cfg.DebugAPIEnabled,
+	})
+	apiDebug.SetLogger(agentLogger)
+
+	// Share one request and token budget across all investigations and subagents
+	var aiAdapter port.AIProvider = apiDebug
+	var rateLimiter *ratelimit.Limiter
+	if cfg.RateLimitRequestsPerMinute > 0 || cfg.RateLimitTokensPerMinute > 0 {
+		rateLimiter = ratelimit.NewLimiter(cfg.RateLimitRequestsPerMinute, cfg.RateLimitTokensPerMinute)
+		limited := ratelimit.NewProvider(apiDebug, rateLimiter)
+		limited.SetLogger(agentLogger)
+		aiAdapter = limited
+	}
 
 	// Create base executor and wrap with planning decorator
 	baseExecutor := tool.NewExecutorAdapter(fileManager)
+	baseExecutor.SetLogger(agentLogger)
 	baseExecutor.SetSkillManager(skillManager)
 	baseExecutor.SetSubagentManager(subagentManager)
+	// Tool stats come first so they time the whole chain and count the bytes the model gets
+	toolStats := tool.NewToolStatsTracker()
+	// Blocked commands can be replaced by Reload
+	blockedCommands := tool.NewBlockedCommandList(cfg.ToolBlockedCommands)
+	baseExecutor.Use(
+		toolStats.Middleware(),
+		tool.RecoveryMiddleware(agentLogger),
+		tool.OutputLimitMiddleware(cfg.ToolMaxOutputBytes),
+		tool.ReloadableSafetyMiddleware(blockedCommands),
+	)
+	if cfg.ToolCacheEnabled {
+		cache := tool.NewResultCache(cfg.ToolCacheMaxEntries, cfg.ToolCacheMaxBytes)
+		baseExecutor.Use(cache.Middleware())
+	}
+	changeTracker := tool.NewChangeTracker(cfg.WorkingDir, cfg.ToolChangesMaxSnapshotBytes)
+	baseExecutor.Use(changeTracker.Middleware())
+	baseExecutor.SetToolTimeouts(cfg.ToolDefaultTimeout, cfg.ToolTimeouts)
+	baseExecutor.SetBashOptions(tool.BashOptions{
+		WorkingDir:     cfg.WorkingDir,
+		MaxOutputBytes: cfg.BashMaxOutputBytes,
+		AllowedEnv:     cfg.BashAllowedEnv,
+	})
+	baseExecutor.SetFetchURLOptions(tool.FetchURLOptions{
+		AllowedDomains: cfg.FetchURLAllowedDomains,
+		MaxBytes:       cfg.FetchURLMaxBytes,
+		Timeout:        cfg.FetchURLTimeout,
+	})
+	registerHostTools(cfg, baseExecutor)
+	if cfg.K8sEnabled {
+		k8sClient, err := tool.NewK8sClient(cfg.K8sKubeconfig, cfg.K8sContext)
+		if err != nil {
+			return nil, err
+		}
+		baseExecutor.EnableK8sInspect(k8sClient, tool.K8sInspectOptions{
+			AllowedNamespaces: cfg.K8sAllowedNamespaces,
+			MaxLogLines:       cfg.K8sMaxLogLines,
+		})
+	}
+	if cfg.PromQLEndpoint != "" || len(cfg.PromQLAllowedHosts) > 0 {
+		baseExecutor.EnablePromQL(tool.PromQLOptions{
+			Endpoint:     cfg.PromQLEndpoint,
+			AllowedHosts: cfg.PromQLAllowedHosts,
+			MaxSeries:    cfg.PromQLMaxSeries,
+			Timeout:      cfg.PromQLTimeout,
+		})
+	}
+	var memoryStore *memory.Store
+	if cfg.MemoryEnabled {
+		memoryStore = memory.NewStore(memory.GlobalPath(getUserHome()), cfg.WorkingDir, cfg.MemoryMaxBytes)
+		baseExecutor.EnableRemember(memoryStore)
+	}
+	var projectDetector *project.Detector
+	if cfg.ProjectContextEnabled {
+		projectDetector = project.NewDetector(cfg.WorkingDir)
+		baseExecutor.EnableProjectInfo(projectDetector)
+	}
+	if cfg.ToolSelectionEnabled {
+		baseExecutor.EnableToolRequests()
+	}
+	// MCP server tools go through the same middlewares and timeouts as the
+	// built-in tools; servers that fail to start are reported and skipped
+	mcpManager := mcp.NewManager(baseExecutor, cfg.WorkingDir)
+	mcpManager.SetMessenger(uiAdapter)
+	mcpManager.SetLogger(agentLogger)
+	mcpManager.ConnectAll(context.Background(), cfg.MCPServers)
 	toolExecutor := tool.NewPlanningExecutorAdapter(baseExecutor, fileManager, cfg.WorkingDir)
 
 	// Set up bash command confirmation callback
@@ -147,6 +493,18 @@ func NewContainer(cfg *Config) (*Container, error) {
 		)
 	}
 
+	// Preview file edits as a diff and ask before applying them, except in headless
+	// mode where there is no one to ask
+	if !cfg.AutoApproveSafeCommands {
+		toolExecutor.SetFileEditConfirmationCallback(uiAdapter.ConfirmFileEdit)
+	}
+
+	// Let the AI ask the user for decisions; headless sessions don't offer
+	// ask_user at all, so it can't wait on an answer that never comes
+	if !cfg.AutoApproveSafeCommands {
+		toolExecutor.SetUserPromptCallback(uiAdapter.PromptChoice)
+	}
+
 	// Set up plan mode confirmation callback
 	// This prompts the user when the agent wants to enter plan mode
 	toolExecutor.SetPlanModeConfirmCallback(func(reason string) bool {
@@ -164,6 +522,25 @@ func NewContainer(cfg *Config) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
+	convService.SetLogger(agentLogger)
+	if cfg.ToolSelectionEnabled {
+		convService.SetToolSelector(service.NewToolSelector(service.ToolSelectorConfig{
+			RecentMessages: cfg.ToolSelectionRecentMessages,
+		}))
+	}
+	if cfg.SessionDir != "" {
+		conversationStore, err := transcript.NewFileConversationStore(cfg.SessionDir)
+		if err != nil {
+			return nil, err
+		}
+		convService.SetConversationStore(conversationStore)
+		// Record the workspace absolutely so the session list means the same from any directory
+		workspace, err := filepath.Abs(cfg.WorkingDir)
+		if err != nil {
+			workspace = cfg.WorkingDir
+		}
+		convService.SetWorkspace(workspace)
+	}
 
 	// Step 3: Create application service (ChatService)
 	// NewChatServiceFromDomain directly accepts concrete adapter types
@@ -177,35 +554,207 @@ func NewContainer(cfg *Config) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Title sessions on the model routed for title generation
+	chatService.SetModelRouter(usecase.NewModelRouter(aiAdapter, cfg.ModelRouting))
+	// Ask about a turn's bash commands in one prompt, except in headless mode
+	// where there is no one to ask
+	chatService.SetToolBatchConfirmation(!cfg.AutoApproveSafeCommands)
+	// Index the discovered skills in the chat system prompt
+	if skills, err := skillManager.DiscoverSkills(context.Background()); err == nil {
+		chatService.SetPromptLayer(usecase.SkillsPromptLayer(skills.Skills))
+	}
+	// Keep the detected project facts in the chat system prompt
+	if projectDetector != nil {
+		chatService.AddPromptLayerSource(projectDetector)
+	}
 
 	// Step 4: Create investigation and alert handling components
+	investigationStore, err := investigation.NewFileInvestigationStore(InvestigationStoreDir(cfg))
+	if err != nil {
+		return nil, err
+	}
+	alertCircuit := newAlertCircuitBreaker(cfg)
+	alertEnrichers, err := newAlertEnrichers(cfg)
+	if err != nil {
+		return nil, err
+	}
 	investigationUseCase, alertSourceManager, webhookAdapter, err := createInvestigationComponents(
-		cfg, convService, toolExecutor, skillManager, uiAdapter,
+		cfg, convService, toolExecutor, skillManager, uiAdapter, investigationStore, alertCircuit, alertEnrichers,
+		agentLogger,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Record investigation progress for GET /investigations/{id}/events and
+	// show it in the terminal
+	investigationEvents := webhook.NewEventBroker(investigationStore, 0)
+	investigationUseCase.SetProgressSink(port.InvestigationProgressSinks{investigationEvents, uiAdapter})
+	investigationUseCase.SetChangeTracker(changeTracker)
+	investigationUseCase.SetToolStats(toolStats)
+	if cfg.InvestigationAutoRemediate {
+		// No one answers prompts for webhook alerts, so only actions the
+		// investigation did not mark as needing approval are run
+		investigationUseCase.SetApprovalProvider(usecase.UnattendedApprover{})
+	}
+	investigationUseCase.SetMessageTemplate(alertTemplate("message", cfg.InvestigationMessageTemplate))
+	webhookAdapter.SetEventBroker(investigationEvents)
+
+	// Price investigation turns to enforce investigation.max_cost, and charge
+	// them to the daily budget, whose spend the store keeps across restarts
+	investigationUseCase.SetPricing(usecase.DefaultPricing().WithOverrides(cfg.Pricing), aiAdapter.GetModel)
+	if cfg.InvestigationDailyBudget > 0 {
+		investigationUseCase.SetDailyBudget(usecase.NewDailyBudget(cfg.InvestigationDailyBudget, investigationStore))
+	}
+
+	// Hold investigations while the AI provider is down, releasing them
+	// through the rate limiter once it recovers
+	providerGate := usecase.NewProviderGate(aiAdapter, usecase.ProviderGateConfig{
+		CacheTTL: cfg.HealthCacheTTL,
+		MaxHold:  cfg.InvestigationMaxProviderHold,
+	})
+	providerGate.SetLogger(agentLogger)
+	if rateLimiter != nil {
+		providerGate.SetPacer(rateLimiter)
+	}
+	investigationUseCase.SetProviderGate(providerGate)
+
+	// Render stored investigations as reports for GET /investigations/{id}/report
+	reportGenerator, err := NewReportGenerator(cfg, investigationStore, aiAdapter)
+	if err != nil {
+		return nil, err
+	}
+	webhookAdapter.SetInvestigationReporter(reportGenerator)
+
+	// Push finished investigation results to external systems when configured
+	resultNotifier := NewResultNotifier(cfg, investigationStore, agentLogger)
+	if resultNotifier != nil {
+		investigationUseCase.SetResultNotifier(resultNotifier)
+		if alertCircuit != nil {
+			alertCircuit.SetNotifier(resultNotifier)
+		}
+	}
+
+	// Publish digests of investigation outcomes on the configured schedule
+	digestGenerator := NewDigestGenerator(investigationStore, resultNotifier)
+	digestScheduler, err := newDigestScheduler(cfg, digestGenerator, agentLogger)
+	if err != nil {
+		return nil, err
+	}
+
 	// Step 5: Create subagent components (pass the already-created subagentManager)
-	subagentUseCase := createSubagentComponents(
+	subagentUseCase, subagentRunner := createSubagentComponents(
 		cfg, convService, toolExecutor, aiAdapter, baseExecutor, uiAdapter, subagentManager,
 	)
+	subagentRunner.SetLogger(agentLogger)
+
+	// Step 6: Record metrics when the metrics server is enabled
+	var metricsRegistry *metrics.Registry
+	if cfg.MetricsListenAddr != "" {
+		metricsRegistry = metrics.NewRegistry()
+		if recorded, ok := providerAdapter.(interface{ SetMetricsRecorder(port.MetricsRecorder) }); ok {
+			recorded.SetMetricsRecorder(metricsRegistry)
+		}
+		if rateLimiter != nil {
+			registerRateLimitMetrics(metricsRegistry, rateLimiter)
+		}
+		baseExecutor.SetMetricsRecorder(metricsRegistry)
+		investigationUseCase.SetMetricsRecorder(metricsRegistry)
+	}
+
+	// Step 7: Export trace spans when a collector endpoint is configured
+	var tracerProvider *sdktrace.TracerProvider
+	if cfg.TracingEndpoint != "" {
+		tracerProvider, err = tracing.NewProvider(context.Background(), tracing.Config{
+			Endpoint:    cfg.TracingEndpoint,
+			SampleRatio: cfg.TracingSampleRatio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tracer := tracing.NewTracer(tracerProvider)
+		if traced, ok := providerAdapter.(interface{ SetTracer(port.Tracer) }); ok {
+			traced.SetTracer(tracer)
+		}
+		baseExecutor.SetTracer(tracer)
+		investigationUseCase.SetTracer(tracer)
+		subagentRunner.SetTracer(tracer)
+	}
 
-	return &Container{
+	// Step 8: Check readiness of the AI provider, store, and workspace
+	healthChecker := newHealthChecker(cfg, aiAdapter, investigationStore)
+
+	c := &Container{
 		config:               cfg,
 		chatService:          chatService,
 		convService:          convService,
 		fileManager:          fileManager,
 		uiAdapter:            uiAdapter,
 		aiAdapter:            aiAdapter,
+		providerAdapter:      providerAdapter,
+		apiDebug:             apiDebug,
 		toolExecutor:         toolExecutor,
+		baseExecutor:         baseExecutor,
 		skillManager:         skillManager,
 		alertSourceManager:   alertSourceManager,
 		investigationUseCase: investigationUseCase,
 		webhookAdapter:       webhookAdapter,
 		subagentManager:      subagentManager,
 		subagentUseCase:      subagentUseCase,
-	}, nil
+		resultNotifier:       resultNotifier,
+		investigationEvents:  investigationEvents,
+		reportGenerator:      reportGenerator,
+		digestGenerator:      digestGenerator,
+		digestScheduler:      digestScheduler,
+		alertSuppressions:    investigationStore,
+		alertCircuit:         alertCircuit,
+		investigationStore:   investigationStore,
+		alertEnrichers:       alertEnrichers,
+		blockedCommands:      blockedCommands,
+		memory:               memoryStore,
+		changeTracker:        changeTracker,
+		toolStats:            toolStats,
+		mcpManager:           mcpManager,
+		metricsRegistry:      metricsRegistry,
+		healthChecker:        healthChecker,
+		tracerProvider:       tracerProvider,
+		logger:               agentLogger,
+		closeLogger:          closeLogger,
+	}
+
+	// Step 9: Load persistent memory into the system prompt; unreadable
+	// memory should not stop the agent from starting
+	if err := c.ReloadMemory(); err != nil {
+		agentLogger.Warn("failed to load memory", "error", err)
+	}
+	return c, nil
+}
+
+// registerRateLimitMetrics exposes the rate limiter's utilization as gauges.
+func registerRateLimitMetrics(registry *metrics.Registry, limiter *ratelimit.Limiter) {
+	registry.RegisterGaugeFunc("ai_rate_limit_request_utilization",
+		"Fraction of the AI requests-per-minute budget in use, from 0 to 1.",
+		func() float64 { requests, _ := limiter.Utilization(); return requests })
+	registry.RegisterGaugeFunc("ai_rate_limit_token_utilization",
+		"Fraction of the AI tokens-per-minute budget in use, from 0 to 1.",
+		func() float64 { _, tokens := limiter.Utilization(); return tokens })
+}
+
+// configureAttention sets up the bell and, with attention.desktop, desktop
+// notifications for confirmations and long turns. A missing notification
+// command only leaves desktop notifications off.
+func configureAttention(uiAdapter *ui.CLIAdapter, cfg *Config) {
+	uiAdapter.SetBell(cfg.AttentionBell)
+	uiAdapter.SetTurnNotifyThreshold(cfg.AttentionTurnThreshold)
+	if !cfg.AttentionDesktop {
+		return
+	}
+	notifier, err := ui.NewDesktopNotifier()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: desktop notifications disabled: %v\n", err)
+		return
+	}
+	uiAdapter.SetAttentionNotifier(notifier)
 }
 
 // createInvestigationComponents sets up the investigation framework including
@@ -216,52 +765,43 @@ func createInvestigationComponents(
 	toolExecutor port.ToolExecutor,
 	skillManager port.SkillManager,
 	uiAdapter port.UserInterface,
+	investigationStore *investigation.FileInvestigationStore,
+	alertCircuit *usecase.AlertCircuitBreaker,
+	alertEnrichers []usecase.AlertEnricher,
+	logger *slog.Logger,
 ) (*usecase.AlertInvestigationUseCase, port.AlertSourceManager, *webhook.HTTPAdapter, error) {
-	// Configure investigation safety limits
-	invConfig := usecase.AlertInvestigationUseCaseConfig{
-		MaxActions:    20,
-		MaxDuration:   15 * time.Minute,
-		MaxConcurrent: 5,
-		AllowedTools: []string{
-			"bash", "read_file", "list_files",
-			"activate_skill", "complete_investigation", "escalate_investigation",
-			"report_investigation",
-			"task", "delegate",
-		},
-		BlockedCommands:  []string{"rm -rf", "dd if=", "mkfs"},
-		ExtendedThinking: cfg.ExtendedThinking,
-		ThinkingBudget:   cfg.ThinkingBudget,
-		ShowThinking:     cfg.ShowThinking,
-	}
-	investigationUseCase := usecase.NewAlertInvestigationUseCaseWithConfig(invConfig)
+	investigationUseCase := usecase.NewAlertInvestigationUseCaseWithConfig(investigationConfig(cfg))
 
 	// Wire core dependencies
 	investigationUseCase.SetConversationService(convService)
 	investigationUseCase.SetToolExecutor(toolExecutor)
 	investigationUseCase.SetSkillManager(skillManager)
 	investigationUseCase.SetUIAdapter(uiAdapter)
+	investigationUseCase.SetLogger(logger)
+	investigationUseCase.SetScheduler(newAlertScheduler(cfg))
 
-	// Wire prompt builder (generic builder for all alert types)
-	promptRegistry := usecase.NewPromptBuilderRegistry()
-	_ = promptRegistry.Register(usecase.NewGenericPromptBuilder())
+	// Wire prompt builders
+	promptRegistry, err := newPromptBuilderRegistry(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	investigationUseCase.SetPromptBuilderRegistry(promptRegistry)
 
 	// Wire escalation handler
 	investigationUseCase.SetEscalationHandler(usecase.NewLogEscalationHandler())
 
 	// Wire investigation store for persistence
-	storePath := filepath.Join(cfg.WorkingDir, ".agent", "investigations")
-	fileStore, err := investigation.NewFileInvestigationStore(storePath)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	investigationUseCase.SetInvestigationStore(&investigationStoreAdapter{store: fileStore})
+	investigationUseCase.SetInvestigationStore(&investigationStoreAdapter{store: investigationStore})
 
 	// Create alert handler with severity-based routing
 	alertHandler := usecase.NewAlertHandler(investigationUseCase, usecase.AlertHandlerConfig{
 		AutoInvestigateCritical: true,
 		AutoInvestigateWarning:  false,
 	})
+	alertHandler.SetLogger(logger)
+	alertHandler.SetSuppressionStore(investigationStore)
+	alertHandler.SetCircuitBreaker(alertCircuit)
+	alertHandler.SetAlertEnrichers(alertEnrichers...)
 
 	// Create alert source manager
 	alertSourceManager := alert.NewLocalAlertSourceManager()
@@ -270,43 +810,161 @@ func createInvestigationComponents(
 	// Create webhook HTTP adapter
 	webhookAdapter := webhook.NewHTTPAdapter(alertSourceManager, webhook.DefaultConfig())
 	webhookAdapter.SetAlertHandler(alertHandler.HandleEntityAlert)
+	webhookAdapter.SetAlertSuppressor(alertHandler)
 
 	return investigationUseCase, alertSourceManager, webhookAdapter, nil
 }
 
+// defaultInvestigationBlockedCommands are the command patterns investigations
+// never run, in addition to tools.blocked_commands.
+//
+//nolint:gochecknoglobals // read-only list
+var defaultInvestigationBlockedCommands = []string{"rm -rf", "dd if=", "mkfs"}
+
+// investigationConfig returns the investigation use case configuration for cfg.
+func investigationConfig(cfg *Config) usecase.AlertInvestigationUseCaseConfig {
+	return usecase.AlertInvestigationUseCaseConfig{
+		MaxActions:        cfg.InvestigationMaxActions,
+		MaxDuration:       cfg.InvestigationMaxDuration,
+		MaxConcurrent:     cfg.InvestigationMaxConcurrent,
+		MaxConcurrentWait: cfg.InvestigationMaxConcurrentWait,
+		AllowedTools: []string{
+			"bash", "read_file", "list_files", "fetch_url", "query_logs", "k8s_inspect", "promql_query", "wait_for",
+			"system_snapshot",
+			"activate_skill", "use_skill", "complete_investigation", "escalate_investigation",
+			"report_investigation",
+			"task", "delegate", "delegate_parallel",
+		},
+		BlockedCommands:        slices.Concat(defaultInvestigationBlockedCommands, cfg.ToolBlockedCommands),
+		AllowedCommandPatterns: cfg.InvestigationAllowedCommandPatterns,
+		ExtendedThinking:       cfg.ExtendedThinking,
+		ThinkingBudget:         cfg.ThinkingBudget,
+		ShowThinking:           cfg.ShowThinking,
+		SeverityOverrides:      cfg.InvestigationSeverityOverrides,
+		MaxCost:                cfg.InvestigationMaxCost,
+		MaxSchemaRetries:       cfg.InvestigationMaxSchemaRetries,
+		CountSubagentUsage:     cfg.InvestigationCountSubagentUsage,
+		AutoRemediate:          cfg.InvestigationAutoRemediate,
+	}
+}
+
+// newPromptBuilderRegistry creates the investigation prompt builders:
+// alert-type-specific builders with a generic fallback, injected with per-alert
+// runbooks and overridable by the template files in the prompts directory.
+func newPromptBuilderRegistry(cfg *Config) (usecase.PromptBuilderRegistry, error) {
+	runbooks := runbook.NewLoader(runbooksDir(cfg), cfg.RunbookURLAllowedDomains)
+	genericBuilder := usecase.NewGenericPromptBuilder()
+	genericBuilder.SetRunbookProvider(runbooks)
+	diskSpaceBuilder := usecase.NewDiskSpacePromptBuilder()
+	diskSpaceBuilder.SetRunbookProvider(runbooks)
+	highMemoryBuilder := usecase.NewHighMemoryPromptBuilder()
+	highMemoryBuilder.SetRunbookProvider(runbooks)
+	highCPUBuilder := usecase.NewHighCPUPromptBuilder()
+	highCPUBuilder.SetRunbookProvider(runbooks)
+
+	promptRegistry := usecase.NewPromptBuilderRegistry()
+	_ = promptRegistry.Register(genericBuilder)
+	_ = promptRegistry.Register(diskSpaceBuilder)
+	_ = promptRegistry.Register(highMemoryBuilder)
+	_ = promptRegistry.Register(highCPUBuilder)
+	promptTemplates, err := prompt.LoadTemplates(promptsDir(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return usecase.NewTemplatePromptRegistry(promptRegistry, promptTemplates), nil
+}
+
+// NewResultNotifier creates the notifier that pushes finished investigation
+// results and digests to cfg.NotifyURLs, recording deliveries in store, or
+// returns nil when no URL is configured. The caller must Close it.
+func NewResultNotifier(
+	cfg *Config,
+	store *investigation.FileInvestigationStore,
+	logger *slog.Logger,
+) *notify.Notifier {
+	if len(cfg.NotifyURLs) == 0 {
+		return nil
+	}
+	notifier := notify.NewNotifier(notify.Config{
+		URLs:        cfg.NotifyURLs,
+		Secret:      cfg.NotifySecret,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+		QueueSize:   cfg.NotifyQueueSize,
+	})
+	notifier.SetRecorder(store)
+	notifier.SetLogger(logger)
+	notifier.SetTitleTemplate(alertTemplate("title", cfg.InvestigationTitleTemplate))
+	return notifier
+}
+
+// newAlertEnrichers creates the alert enrichers configured in cfg: the static
+// rules first, so they take precedence, then the HTTP lookup.
+func newAlertEnrichers(cfg *Config) ([]usecase.AlertEnricher, error) {
+	var enrichers []usecase.AlertEnricher
+	if cfg.EnrichmentStaticFile != "" {
+		static, err := enrich.LoadStaticEnricher(cfg.EnrichmentStaticFile)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, static)
+	}
+	if cfg.EnrichmentHTTPURL != "" {
+		enrichers = append(enrichers, enrich.NewHTTPEnricher(cfg.EnrichmentHTTPURL, cfg.EnrichmentHTTPTimeout))
+	}
+	return enrichers, nil
+}
+
 // createSubagentComponents sets up the subagent runner and use case.
 // Accepts an already-created subagentManager (which is needed earlier for the AIAdapter).
 // Subagents are specialized AI agents that can be spawned to handle delegated tasks
 // in isolated conversation sessions.
 func createSubagentComponents(
-	_ *Config,
+	cfg *Config,
 	convService *service.ConversationService,
 	toolExecutor port.ToolExecutor,
 	aiAdapter port.AIProvider,
 	baseExecutor *tool.ExecutorAdapter,
 	uiAdapter port.UserInterface,
 	subagentManager port.SubagentManager,
-) *usecase.SubagentUseCase {
+) (*usecase.SubagentUseCase, *usecase.SubagentRunner) {
 	// Create SubagentRunner with dependencies and safety configuration
 	// SubagentRunner executes subagent tasks with resource limits to prevent runaway execution.
 	// Config values:
-	// - MaxActions: 20 (prevents infinite loops by limiting tool executions per subagent)
-	// - MaxDuration: 5 minutes (prevents hanging subagents)
+	// - MaxActions: cfg.SubagentMaxActions, default 20 (prevents infinite loops by limiting tool executions per subagent)
+	// - MaxDuration: cfg.SubagentMaxDuration, default 5 minutes (prevents hanging subagents)
 	// - MaxConcurrent: 5 (limits parallel subagent execution to control resource usage)
 	// - AllowedTools: nil (allow all tools by default; can be restricted per agent via AGENT.md)
+	// - AllowedCommandPatterns: cfg.SubagentAllowedCommandPatterns (nil = any command not otherwise blocked)
+	// - SummaryThreshold: 4000 characters (longer outputs are summarized before reaching the parent)
 	subagentRunner := usecase.NewSubagentRunner(
 		convService,
 		toolExecutor,
 		aiAdapter,
 		uiAdapter,
 		usecase.SubagentConfig{
-			MaxActions:    20,
-			MaxDuration:   5 * time.Minute,
-			MaxConcurrent: 5,
-			AllowedTools:  nil, // nil means allow all tools (can be overridden per agent)
+			MaxActions:             cfg.SubagentMaxActions,
+			MaxDuration:            cfg.SubagentMaxDuration,
+			MaxConcurrent:          5,
+			AllowedTools:           nil, // nil means allow all tools (can be overridden per agent)
+			AllowedCommandPatterns: cfg.SubagentAllowedCommandPatterns,
+			SummaryThreshold:       4000,
 		},
 	)
 
+	// Run inheriting subagents and their output summaries on the configured cheaper models
+	subagentRunner.SetModelRouter(usecase.NewModelRouter(aiAdapter, cfg.ModelRouting))
+
+	// Store full transcripts of summarized subagent runs so they can be inspected later
+	transcriptStore, err := transcript.NewFileTranscriptStore(filepath.Join(cfg.WorkingDir, ".agent", "transcripts"))
+	if err == nil {
+		subagentRunner.SetTranscriptStore(transcriptStore)
+	}
+
+	// Stream subagent progress to the terminal when the UI supports it
+	if sink, ok := uiAdapter.(port.ProgressSink); ok {
+		subagentRunner.SetProgressSink(sink)
+	}
+
 	// Create SubagentUseCase to orchestrate subagent spawning and execution
 	// This use case coordinates between the manager (discovery) and runner (execution)
 	subagentUseCase := usecase.NewSubagentUseCase(
@@ -318,7 +976,7 @@ func createSubagentComponents(
 	// This enables the 'task' tool, allowing the main AI to delegate work to subagents
 	baseExecutor.SetSubagentUseCase(subagentUseCase)
 
-	return subagentUseCase
+	return subagentUseCase, subagentRunner
 }
 
 // ChatService returns the application chat service.
@@ -329,6 +987,8 @@ func (c *Container) ChatService() *appsvc.ChatService {
 
 // Config returns the application configuration.
 func (c *Container) Config() *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.config
 }
 
@@ -386,6 +1046,124 @@ func (c *Container) WebhookAdapter() *webhook.HTTPAdapter {
 	return c.webhookAdapter
 }
 
+// InvestigationEvents returns the broker that records investigation progress
+// events and streams them to GET /investigations/{id}/events subscribers.
+func (c *Container) InvestigationEvents() *webhook.EventBroker {
+	return c.investigationEvents
+}
+
+// ReportGenerator returns the generator of investigation reports, which
+// writes executive summaries with the configured AI provider.
+func (c *Container) ReportGenerator() *usecase.ReportGenerator {
+	return c.reportGenerator
+}
+
+// DigestGenerator returns the generator of investigation digests, which
+// publishes through the result notifier.
+func (c *Container) DigestGenerator() *usecase.DigestGenerator {
+	return c.digestGenerator
+}
+
+// DigestScheduler returns the scheduler of investigation digests, or nil when
+// no digest schedule is configured. Run it to publish digests.
+func (c *Container) DigestScheduler() *usecase.DigestScheduler {
+	return c.digestScheduler
+}
+
+// AlertSuppressions returns the store of alert suppressions, which is kept
+// with the investigation records.
+func (c *Container) AlertSuppressions() usecase.AlertSuppressionStore {
+	return c.alertSuppressions
+}
+
+// AlertCircuitBreaker returns the circuit breaker that defers the alerts of a
+// source starting too many investigations, or nil when it is disabled
+// (Config.AlertCircuitThreshold is 0). Alert handlers share it so a source's
+// investigations are counted once.
+func (c *Container) AlertCircuitBreaker() *usecase.AlertCircuitBreaker {
+	return c.alertCircuit
+}
+
+// AlertEnrichers returns the configured alert enrichers, in the order they
+// run, for alert handlers created outside the container.
+func (c *Container) AlertEnrichers() []usecase.AlertEnricher {
+	return c.alertEnrichers
+}
+
+// ChangeTracker returns the tracker of the files each session's tools
+// modified, for summarizing a session's changes.
+func (c *Container) ChangeTracker() *tool.ChangeTracker {
+	return c.changeTracker
+}
+
+// ToolStats returns the tracker of each session's per-tool calls, errors,
+// durations, and output bytes, for summarizing a session's tool usage.
+func (c *Container) ToolStats() *tool.ToolStatsTracker {
+	return c.toolStats
+}
+
+// APIDebug returns the decorator that writes each AI provider request and
+// response to a debug file while enabled, for turning it on and off.
+func (c *Container) APIDebug() *aidebug.Provider {
+	return c.apiDebug
+}
+
+// Memory returns the store of the persistent memory files (AGENT.md), or nil
+// when memory is disabled (Config.MemoryEnabled is false).
+func (c *Container) Memory() *memory.Store {
+	return c.memory
+}
+
+// ReloadTools re-runs the registration of the tools that depend on the host,
+// registering the ones whose condition now holds and unregistering the rest,
+// so that, for example, git_status appears once the workspace becomes a git
+// repository. Returns the names of the tools added and removed, sorted.
+func (c *Container) ReloadTools() (added, removed []string) {
+	before := toolNames(c.baseExecutor)
+	registerHostTools(c.Config(), c.baseExecutor)
+	after := toolNames(c.baseExecutor)
+	for name := range after {
+		if !before[name] {
+			added = append(added, name)
+		}
+	}
+	for name := range before {
+		if !after[name] {
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
+
+// toolNames returns the names of the tools executor offers.
+func toolNames(executor port.ToolExecutor) map[string]bool {
+	tools, _ := executor.ListTools()
+	names := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		names[t.Name] = true
+	}
+	return names
+}
+
+// ReloadMemory reads the memory files again and puts their merged contents in
+// the chat system prompt's memory layer, so edits take effect without a restart.
+func (c *Container) ReloadMemory() error {
+	if c.memory == nil {
+		return nil
+	}
+	content, err := c.memory.Load()
+	if err != nil {
+		return err
+	}
+	if remembering, ok := c.providerAdapter.(interface{ SetMemory(string) }); ok {
+		remembering.SetMemory(content)
+	}
+	c.chatService.SetPromptLayer(usecase.MemoryPromptLayer(content))
+	return nil
+}
+
 // SubagentManager returns the subagent manager port implementation.
 // The manager is responsible for discovering and loading subagent definitions
 // from configured directories (./agents, ./.claude/agents, ~/.claude/agents).
@@ -402,6 +1180,128 @@ func (c *Container) SubagentUseCase() *usecase.SubagentUseCase {
 	return c.subagentUseCase
 }
 
+// MetricsRegistry returns the registry of recorded metrics, or nil when metrics
+// are disabled (Config.MetricsListenAddr is empty).
+// Useful for starting the metrics server.
+func (c *Container) MetricsRegistry() *metrics.Registry {
+	return c.metricsRegistry
+}
+
+// HealthChecker returns the readiness checks for the AI provider, the
+// investigation store, and the workspace directory.
+// Useful for wiring the webhook server's /readyz endpoint.
+func (c *Container) HealthChecker() *health.Checker {
+	return c.healthChecker
+}
+
+// Logger returns the structured logger configured by Config.LogLevel,
+// Config.LogFormat, and Config.LogFile.
+// Useful for wiring components created outside the container, such as alert handlers.
+func (c *Container) Logger() *slog.Logger {
+	return c.logger
+}
+
+// Shutdown drains in-flight investigations until ctx is done, marking any
+// still running then as "interrupted", delivers queued result notifications,
+// stops the MCP servers, flushes pending trace spans to the collector, and closes the log file. Call
+// it before the process exits.
+func (c *Container) Shutdown(ctx context.Context) error {
+	var errs []error
+	if c.investigationUseCase != nil {
+		errs = append(errs, c.investigationUseCase.Shutdown(ctx))
+	}
+	c.mu.RLock()
+	notifiers := append([]*notify.Notifier{c.resultNotifier}, c.retiredNotifiers...)
+	c.mu.RUnlock()
+	for _, notifier := range notifiers {
+		if notifier != nil {
+			errs = append(errs, notifier.Close(ctx))
+		}
+	}
+	if c.mcpManager != nil {
+		errs = append(errs, c.mcpManager.Close())
+	}
+	if c.tracerProvider != nil {
+		errs = append(errs, c.tracerProvider.Shutdown(ctx))
+	}
+	if c.closeLogger != nil {
+		errs = append(errs, c.closeLogger())
+	}
+	return errors.Join(errs...)
+}
+
+// newHealthChecker creates the readiness checks, marking the ones named in
+// cfg.HealthOptionalChecks optional.
+func newHealthChecker(
+	cfg *Config,
+	aiAdapter port.AIProvider,
+	investigationStore *investigation.FileInvestigationStore,
+) *health.Checker {
+	checks := []health.Check{
+		{Name: health.CheckAIProvider, Run: aiAdapter.HealthCheck},
+		{Name: health.CheckInvestigationStore, Run: investigationStore.Ping},
+		{Name: health.CheckWorkspace, Run: health.DirWritable(cfg.WorkingDir)},
+	}
+	for i := range checks {
+		checks[i].Optional = slices.Contains(cfg.HealthOptionalChecks, checks[i].Name)
+	}
+	return health.NewChecker(cfg.HealthCacheTTL, checks...)
+}
+
+// registerHostTools registers the tools that work only on some hosts:
+// query_logs where journald runs, system_snapshot where resource usage can be
+// read, and, with git enabled, the git tools in a git work tree. Tools whose
+// condition does not hold are unregistered, so it can run again to catch up
+// with the host.
+func registerHostTools(cfg *Config, executor *tool.ExecutorAdapter) {
+	if tool.JournaldAvailable() {
+		executor.EnableQueryLogs(nil)
+	} else {
+		executor.DisableQueryLogs()
+	}
+	if tool.SystemSnapshotAvailable() {
+		executor.EnableSystemSnapshot(nil)
+	}
+	if cfg.GitEnabled && tool.GitAvailable(cfg.WorkingDir) {
+		executor.EnableGit(tool.GitOptions{
+			WorkingDir:        cfg.WorkingDir,
+			MaxDiffBytes:      cfg.GitMaxDiffBytes,
+			AllowPush:         cfg.GitPushEnabled,
+			ProtectedBranches: cfg.GitProtectedBranches,
+		})
+	} else {
+		executor.DisableGit()
+	}
+}
+
+// promptsDir returns the directory containing investigation prompt templates.
+// Defaults to the "prompts" directory under the working directory.
+func promptsDir(cfg *Config) string {
+	if cfg.PromptsDir != "" {
+		return cfg.PromptsDir
+	}
+	return filepath.Join(cfg.WorkingDir, "prompts")
+}
+
+// alertTemplate parses an alert template of cfg, which Load has validated,
+// returning nil, and so the default template, if it does not parse.
+func alertTemplate(name, content string) *usecase.AlertTemplate {
+	tmpl, err := usecase.ParseAlertTemplate(name, content)
+	if err != nil {
+		return nil
+	}
+	return tmpl
+}
+
+// runbooksDir returns the directory containing per-alert runbooks.
+// Defaults to the "runbooks" directory under the working directory.
+func runbooksDir(cfg *Config) string {
+	if cfg.RunbooksDir != "" {
+		return cfg.RunbooksDir
+	}
+	return filepath.Join(cfg.WorkingDir, "runbooks")
+}
+
 // getUserHome returns the user's home directory.
 // Returns an empty string if the home directory cannot be determined.
 // This is used for resolving the global ~/.claude/agents directory.
@@ -1,7 +1,15 @@
 package config
 
 import (
+	"code-editing-agent/internal/application/service"
+	"code-editing-agent/internal/infrastructure/adapter/enrich"
+	"code-editing-agent/internal/infrastructure/adapter/investigation"
+	"code-editing-agent/internal/infrastructure/version"
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // =============================================================================
@@ -279,3 +287,108 @@ func TestContainer_AlertSourceManagerAccessor_AfterNilConfig(t *testing.T) {
 		t.Log("AlertSourceManager() returned nil for edge case container")
 	}
 }
+
+func TestContainer_InvestigationEventsAccessor_NotNil(t *testing.T) {
+	cfg := createTestConfig(t)
+	container, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+
+	if container.InvestigationEvents() == nil {
+		t.Error("InvestigationEvents() should not return nil")
+	}
+}
+
+func TestContainer_AlertCircuitBreakerAccessor(t *testing.T) {
+	container, err := NewContainer(createTestConfig(t))
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	if container.AlertCircuitBreaker() != nil {
+		t.Error("AlertCircuitBreaker() should be nil without a threshold")
+	}
+
+	cfg := createTestConfig(t)
+	cfg.AlertCircuitThreshold = 5
+	cfg.AlertCircuitWindow = time.Minute
+	cfg.AlertCircuitCooldown = time.Hour
+	container, err = NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	breaker := container.AlertCircuitBreaker()
+	if breaker == nil {
+		t.Fatal("AlertCircuitBreaker() should not return nil with a threshold")
+	}
+	if got := breaker.Config(); got.Threshold != 5 || got.Window != time.Minute || got.Cooldown != time.Hour {
+		t.Errorf("AlertCircuitBreaker().Config() = %+v", got)
+	}
+}
+
+func TestContainer_AlertEnrichersAccessor(t *testing.T) {
+	container, err := NewContainer(createTestConfig(t))
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	if got := container.AlertEnrichers(); len(got) != 0 {
+		t.Errorf("AlertEnrichers() = %v, want none when unconfigured", got)
+	}
+
+	cfg := createTestConfig(t)
+	cfg.EnrichmentStaticFile = filepath.Join(t.TempDir(), "enrichment.yaml")
+	cfg.EnrichmentHTTPURL = "https://catalog.example.com/enrich"
+	if _, err := NewContainer(cfg); err == nil {
+		t.Error("NewContainer() error = nil, want an error for a missing enrichment file")
+	}
+
+	rules := "rules:\n  - match: {alertname: DiskFull}\n    annotations: {team: storage}\n"
+	if err := os.WriteFile(cfg.EnrichmentStaticFile, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	container, err = NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	enrichers := container.AlertEnrichers()
+	if len(enrichers) != 2 {
+		t.Fatalf("AlertEnrichers() = %v, want the static and HTTP enrichers", enrichers)
+	}
+	if _, ok := enrichers[0].(*enrich.StaticEnricher); !ok {
+		t.Errorf("AlertEnrichers()[0] = %T, want the static enricher first", enrichers[0])
+	}
+	if _, ok := enrichers[1].(*enrich.HTTPEnricher); !ok {
+		t.Errorf("AlertEnrichers()[1] = %T, want the HTTP enricher", enrichers[1])
+	}
+}
+
+func TestInvestigationStoreAdapter_StampsVersion(t *testing.T) {
+	store, err := investigation.NewFileInvestigationStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileInvestigationStore() error = %v", err)
+	}
+	adapter := &investigationStoreAdapter{store: store}
+	ctx := context.Background()
+	startedAt := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+
+	// New records are stamped with the running build
+	started := service.NewInvestigationRecord("inv-1", "alert-1", "", "started", startedAt)
+	if err := adapter.Store(ctx, started); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	got, err := adapter.Get(ctx, "inv-1")
+	if err != nil || got.Version() != version.Get().Version {
+		t.Errorf("Get() version = %q (%v), want %q", got.Version(), err, version.Get().Version)
+	}
+
+	// Records keep the build that produced them, so a status change by a
+	// later build does not claim the result
+	old := service.NewInvestigationRecord("inv-1", "alert-1", "", "reprocessed", startedAt).WithVersion("v1.0.0")
+	if err := adapter.Update(ctx, old); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err = adapter.Get(ctx, "inv-1")
+	if err != nil || got.Version() != "v1.0.0" {
+		t.Errorf("Get() version = %q (%v), want v1.0.0", got.Version(), err)
+	}
+}
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/infrastructure/adapter/aireplay"
+	"code-editing-agent/internal/infrastructure/adapter/ratelimit"
 	"code-editing-agent/internal/infrastructure/adapter/ui"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +21,8 @@ import (
 func TestContainer_UsesHistoryConfig(t *testing.T) {
 	t.Run("container passes HistoryFile from config to CLIAdapter", func(t *testing.T) {
 		cfg := Defaults()
-		cfg.HistoryFile = "/tmp/test-agent-history"
+		historyFile := filepath.Join(t.TempDir(), "history")
+		cfg.HistoryFile = historyFile
 		cfg.HistoryMaxEntries = 500
 
 		container, err := NewContainer(cfg)
@@ -30,11 +37,12 @@ func TestContainer_UsesHistoryConfig(t *testing.T) {
 		require.True(t, ok, "UIAdapter should be a *ui.CLIAdapter")
 
 		// Verify history file is set from config
-		assert.Equal(t, "/tmp/test-agent-history", cliAdapter.GetHistoryFile(),
+		assert.Equal(t, historyFile, cliAdapter.GetHistoryFile(),
 			"CLIAdapter should use HistoryFile from config")
 	})
 
 	t.Run("container uses default history values when config has defaults", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
 		cfg := Defaults()
 
 		container, err := NewContainer(cfg)
@@ -72,7 +80,7 @@ func TestContainer_UsesHistoryConfig(t *testing.T) {
 func TestContainer_UIAdapterHasHistory(t *testing.T) {
 	t.Run("UIAdapter is in interactive mode when history is configured", func(t *testing.T) {
 		cfg := Defaults()
-		cfg.HistoryFile = "/tmp/interactive-test-history"
+		cfg.HistoryFile = filepath.Join(t.TempDir(), "history")
 
 		container, err := NewContainer(cfg)
 		require.NoError(t, err, "NewContainer should not return an error")
@@ -91,7 +99,8 @@ func TestContainer_UIAdapterHasHistory(t *testing.T) {
 func TestContainer_HistoryFilePath(t *testing.T) {
 	t.Run("container passes absolute path unchanged", func(t *testing.T) {
 		cfg := Defaults()
-		cfg.HistoryFile = "/var/lib/agent/history"
+		historyFile := filepath.Join(t.TempDir(), "history")
+		cfg.HistoryFile = historyFile
 
 		container, err := NewContainer(cfg)
 		require.NoError(t, err, "NewContainer should not return an error")
@@ -100,11 +109,12 @@ func TestContainer_HistoryFilePath(t *testing.T) {
 		require.True(t, ok, "UIAdapter should be a *ui.CLIAdapter")
 
 		// Absolute paths should be passed through unchanged
-		assert.Equal(t, "/var/lib/agent/history", cliAdapter.GetHistoryFile(),
+		assert.Equal(t, historyFile, cliAdapter.GetHistoryFile(),
 			"Absolute path should be preserved")
 	})
 
 	t.Run("container handles relative path", func(t *testing.T) {
+		t.Chdir(t.TempDir())
 		cfg := Defaults()
 		cfg.HistoryFile = ".agent-history"
 
@@ -125,7 +135,8 @@ func TestContainer_HistoryFilePath(t *testing.T) {
 func TestContainer_HistoryIntegrationWithChatService(t *testing.T) {
 	t.Run("ChatService uses UI adapter with history", func(t *testing.T) {
 		cfg := Defaults()
-		cfg.HistoryFile = "/tmp/chatservice-history-test"
+		historyFile := filepath.Join(t.TempDir(), "history")
+		cfg.HistoryFile = historyFile
 		cfg.HistoryMaxEntries = 200
 
 		container, err := NewContainer(cfg)
@@ -140,7 +151,95 @@ func TestContainer_HistoryIntegrationWithChatService(t *testing.T) {
 		require.True(t, ok, "UIAdapter should be a *ui.CLIAdapter")
 
 		// Verify history file is configured
-		assert.Equal(t, "/tmp/chatservice-history-test", cliAdapter.GetHistoryFile(),
+		assert.Equal(t, historyFile, cliAdapter.GetHistoryFile(),
 			"UIAdapter used by ChatService should have history file configured")
 	})
 }
+
+// TestContainer_ShutdownStopsInvestigations verifies that shutting down the
+// container drains the investigation use case so no new investigations start.
+func TestContainer_ShutdownStopsInvestigations(t *testing.T) {
+	cfg := Defaults()
+	cfg.HistoryFile = ""
+
+	container, err := NewContainer(cfg)
+	require.NoError(t, err)
+	require.NoError(t, container.Shutdown(context.Background()))
+
+	alert, err := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk full")
+	require.NoError(t, err)
+	handler := usecase.NewAlertHandler(container.InvestigationUseCase(), usecase.AlertHandlerConfig{
+		AutoInvestigateCritical: true,
+	})
+	_, err = handler.HandleEntityAlertAsync(context.Background(), alert)
+	assert.ErrorIs(t, err, usecase.ErrUseCaseShutdown)
+}
+
+// TestContainer_RateLimitsAIProvider verifies that a configured rate limit wraps
+// the shared AI provider and reports its utilization as metrics.
+func TestContainer_RateLimitsAIProvider(t *testing.T) {
+	cfg := Defaults()
+	cfg.HistoryFile = ""
+	cfg.MetricsListenAddr = ":0"
+	cfg.RateLimitRequestsPerMinute = 50
+	cfg.RateLimitTokensPerMinute = 40000
+
+	container, err := NewContainer(cfg)
+	require.NoError(t, err)
+
+	require.IsType(t, &ratelimit.Provider{}, container.AIAdapter())
+	assert.Equal(t, cfg.AIModel, container.AIAdapter().GetModel())
+
+	var out strings.Builder
+	_, err = container.MetricsRegistry().WriteTo(&out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "ai_rate_limit_request_utilization 0\n")
+	assert.Contains(t, out.String(), "ai_rate_limit_token_utilization 0\n")
+}
+
+// TestContainer_RecordsAIExchangesWhenAsked verifies that the provider is
+// wrapped in a recorder only when a record file is configured.
+func TestContainer_RecordsAIExchangesWhenAsked(t *testing.T) {
+	cfg := Defaults()
+	cfg.HistoryFile = ""
+
+	container, err := NewContainer(cfg)
+	require.NoError(t, err)
+	_, recorded := container.APIDebug().AIProvider.(*aireplay.RecordingAIProvider)
+	assert.False(t, recorded, "exchanges should not be recorded by default")
+
+	cfg.DebugAPIRecordFile = filepath.Join(t.TempDir(), "session.json")
+	container, err = NewContainer(cfg)
+	require.NoError(t, err)
+	require.IsType(t, &aireplay.RecordingAIProvider{}, container.APIDebug().AIProvider)
+	assert.Equal(t, cfg.AIModel, container.APIDebug().GetModel())
+}
+
+// TestContainer_Memory verifies that memory registers the remember tool and
+// that remembered preferences reach the memory file.
+func TestContainer_Memory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := Defaults()
+	cfg.HistoryFile = ""
+	cfg.WorkingDir = t.TempDir()
+
+	container, err := NewContainer(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, container.Memory())
+	_, ok := container.ToolExecutor().GetTool("remember")
+	assert.True(t, ok, "remember should be registered when memory is enabled")
+
+	_, err = container.ToolExecutor().ExecuteTool(context.Background(), "remember", `{"text": "we use tabs"}`)
+	require.NoError(t, err)
+	require.NoError(t, container.ReloadMemory())
+	content, err := container.Memory().Load()
+	require.NoError(t, err)
+	assert.Equal(t, "## Learned\n\n- we use tabs", content)
+
+	cfg.MemoryEnabled = false
+	container, err = NewContainer(cfg)
+	require.NoError(t, err)
+	assert.Nil(t, container.Memory())
+	_, ok = container.ToolExecutor().GetTool("remember")
+	assert.False(t, ok, "remember should not be registered when memory is disabled")
+}
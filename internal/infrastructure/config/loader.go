@@ -0,0 +1,1129 @@
+// Package config provides configuration loading and dependency injection for the application.
+// This file loads the YAML config file, overlays CODE_AGENT_ environment variables,
+// and validates the result.
+package config
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/safety"
+	"code-editing-agent/internal/infrastructure/adapter/ai"
+	"code-editing-agent/internal/infrastructure/adapter/health"
+	"code-editing-agent/internal/infrastructure/adapter/mcp"
+	"code-editing-agent/internal/infrastructure/logger"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultConfigFile is the config file read when no path is given.
+	// Unlike an explicit path, it may be missing.
+	DefaultConfigFile = "config.yaml"
+
+	// ConfigPathEnv is the environment variable holding the config file path.
+	ConfigPathEnv = "CODE_AGENT_CONFIG"
+
+	// EnvPrefix prefixes environment variables that override config file keys.
+	// Nested keys are separated by "__": CODE_AGENT_TRACING__ENDPOINT sets tracing.endpoint.
+	EnvPrefix = "CODE_AGENT_"
+)
+
+// severityOverridesKey is the config key of per-severity investigation limits,
+// followed by "<severity>.max_actions" or "<severity>.max_duration".
+const severityOverridesKey = "investigation.severity_overrides"
+
+// sourceLimitsKey is the config key of per-source investigation concurrency
+// limits, followed by the source name.
+const sourceLimitsKey = "investigation.source_limits"
+
+// toolTimeoutsKey is the config key of per-tool timeouts, followed by the tool name.
+const toolTimeoutsKey = "tools.timeouts"
+
+// modelsKey is the config key of model capability overrides, followed by
+// "<model>.<capability>". Model names may contain dots; the capability is
+// the last segment.
+const modelsKey = "models"
+
+// modelRoutingKey is the config key of the models routine tasks are routed
+// to, followed by the task name.
+const modelRoutingKey = "model_routing"
+
+// pricingKey is the config key of model prices, followed by
+// "<model>.input" or "<model>.output". Model names may contain dots.
+const pricingKey = "pricing"
+
+// mcpServersKey is the config key of MCP servers, followed by
+// "<server>.<field>"; env and headers take a further "<name>" segment.
+const mcpServersKey = "mcp.servers"
+
+// redactedValue replaces secrets in WriteRedacted output.
+const redactedValue = "********"
+
+// ValidationError lists every problem found while loading the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface, listing one problem per line.
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load builds the configuration from, in increasing priority:
+//  1. Defaults()
+//  2. The YAML config file at path, or at $CODE_AGENT_CONFIG when path is empty,
+//     or at DefaultConfigFile if it exists
+//  3. CODE_AGENT_ environment variables (see EnvPrefix)
+//  4. Flags and AGENT_ environment variables bound in viper (see LoadConfig)
+//
+// Unknown keys, unparsable values, and invalid settings are reported together
+// in a *ValidationError. An explicit config file that cannot be read is an error.
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	path, required := configPath(path)
+	settings, err := readConfigFile(path, required)
+	if err != nil {
+		return nil, err
+	}
+	settings = append(settings, envSettings(os.Environ())...)
+
+	var problems []string
+	for _, s := range settings {
+		if err := setField(cfg, s.key, s.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.source, err))
+		}
+	}
+
+	applyViper(cfg)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// validate checks settings that are well-formed but out of range, returning one
+// message per problem.
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !slices.Contains(ProviderNames(), c.Provider) {
+		add("provider: unknown provider %q (want one of: %s)", c.Provider, strings.Join(ProviderNames(), ", "))
+	}
+	if c.MaxTokens <= 0 {
+		add("max_tokens: must be positive, got %d", c.MaxTokens)
+	}
+	if c.MaxContinuations < 0 {
+		add("max_continuations: must not be negative, got %d", c.MaxContinuations)
+	}
+	if c.MaxRetries < 0 {
+		add("max_retries: must not be negative, got %d", c.MaxRetries)
+	}
+	if c.ThinkingBudget < 1024 {
+		add("thinking.budget: must be at least 1024, got %d", c.ThinkingBudget)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		add("tracing.sample_ratio: must be between 0 and 1, got %v", c.TracingSampleRatio)
+	}
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		add("log_level: %v", err)
+	}
+	if c.LogFormat != logger.FormatText && c.LogFormat != logger.FormatJSON {
+		add("log_format: unknown format %q (want %s or %s)", c.LogFormat, logger.FormatText, logger.FormatJSON)
+	}
+	if c.InvestigationMaxActions <= 0 {
+		add("investigation.max_actions: must be positive, got %d", c.InvestigationMaxActions)
+	}
+	if c.InvestigationMaxDuration <= 0 {
+		add("investigation.max_duration: must be positive, got %v", c.InvestigationMaxDuration)
+	}
+	if c.InvestigationMaxConcurrent <= 0 {
+		add("investigation.max_concurrent: must be positive, got %d", c.InvestigationMaxConcurrent)
+	}
+	if c.InvestigationMaxConcurrentWait < 0 {
+		add("investigation.max_concurrent_wait: must not be negative, got %v", c.InvestigationMaxConcurrentWait)
+	}
+	if c.InvestigationSourceLimit < 0 {
+		add("investigation.source_limit: must not be negative, got %d", c.InvestigationSourceLimit)
+	}
+	for _, source := range sortedKeys(c.InvestigationSourceLimits) {
+		if limit := c.InvestigationSourceLimits[source]; limit < 0 {
+			add("%s.%s: must not be negative, got %d", sourceLimitsKey, source, limit)
+		}
+	}
+	if c.InvestigationMaxSchemaRetries <= 0 {
+		add("investigation.max_schema_retries: must be positive, got %d", c.InvestigationMaxSchemaRetries)
+	}
+	for _, severity := range sortedKeys(c.InvestigationSeverityOverrides) {
+		if !slices.Contains(knownSeverities(), severity) {
+			add("%s: unknown severity %q (want one of: %s)",
+				severityOverridesKey, severity, strings.Join(knownSeverities(), ", "))
+		}
+		limits := c.InvestigationSeverityOverrides[severity]
+		if limits.MaxActions < 0 {
+			add("%s.%s.max_actions: must not be negative, got %d", severityOverridesKey, severity, limits.MaxActions)
+		}
+		if limits.MaxDuration < 0 {
+			add("%s.%s.max_duration: must not be negative, got %v", severityOverridesKey, severity, limits.MaxDuration)
+		}
+	}
+	for _, model := range sortedKeys(c.ModelCapabilities) {
+		override := c.ModelCapabilities[model]
+		if override.ContextWindow < 0 {
+			add("%s.%s.context_window: must not be negative, got %d", modelsKey, model, override.ContextWindow)
+		}
+		if override.MaxOutputTokens < 0 {
+			add("%s.%s.max_output_tokens: must not be negative, got %d", modelsKey, model, override.MaxOutputTokens)
+		}
+	}
+	if _, err := safety.NewCommandAllowlist(c.InvestigationAllowedCommandPatterns); err != nil {
+		add("investigation.allowed_command_patterns: %v", err)
+	}
+	if _, err := usecase.ParseAlertTemplate("message", c.InvestigationMessageTemplate); err != nil {
+		add("investigation.message_template: %v", err)
+	}
+	if _, err := usecase.ParseAlertTemplate("title", c.InvestigationTitleTemplate); err != nil {
+		add("investigation.title_template: %v", err)
+	}
+	if c.InvestigationMaxCost < 0 {
+		add("investigation.max_cost: must not be negative, got %v", c.InvestigationMaxCost)
+	}
+	if c.InvestigationDailyBudget < 0 {
+		add("investigation.daily_budget: must not be negative, got %v", c.InvestigationDailyBudget)
+	}
+	if c.InvestigationMaxProviderHold < 0 {
+		add("investigation.max_provider_hold: must not be negative, got %v", c.InvestigationMaxProviderHold)
+	}
+	for _, model := range sortedKeys(c.Pricing) {
+		price := c.Pricing[model]
+		if price.Input < 0 {
+			add("%s.%s.input: must not be negative, got %v", pricingKey, model, price.Input)
+		}
+		if price.Output < 0 {
+			add("%s.%s.output: must not be negative, got %v", pricingKey, model, price.Output)
+		}
+	}
+	if c.SubagentMaxActions <= 0 {
+		add("subagent.max_actions: must be positive, got %d", c.SubagentMaxActions)
+	}
+	if c.SubagentMaxDuration <= 0 {
+		add("subagent.max_duration: must be positive, got %v", c.SubagentMaxDuration)
+	}
+	if _, err := safety.NewCommandAllowlist(c.SubagentAllowedCommandPatterns); err != nil {
+		add("subagent.allowed_command_patterns: %v", err)
+	}
+	if c.DrainTimeout < 0 {
+		add("drain_timeout: must not be negative, got %v", c.DrainTimeout)
+	}
+	if c.AttentionTurnThreshold < 0 {
+		add("attention.turn_threshold: must not be negative, got %v", c.AttentionTurnThreshold)
+	}
+	if c.MemoryMaxBytes <= 0 {
+		add("memory.max_bytes: must be positive, got %d", c.MemoryMaxBytes)
+	}
+	if c.ToolSelectionRecentMessages <= 0 {
+		add("tool_selection.recent_messages: must be positive, got %d", c.ToolSelectionRecentMessages)
+	}
+	if c.AlertCircuitThreshold < 0 {
+		add("alert_circuit.threshold: must not be negative, got %d", c.AlertCircuitThreshold)
+	}
+	if c.AlertCircuitWindow <= 0 {
+		add("alert_circuit.window: must be positive, got %v", c.AlertCircuitWindow)
+	}
+	if c.AlertCircuitCooldown <= 0 {
+		add("alert_circuit.cooldown: must be positive, got %v", c.AlertCircuitCooldown)
+	}
+	if c.RateLimitRequestsPerMinute < 0 {
+		add("rate_limit.requests_per_minute: must not be negative, got %d", c.RateLimitRequestsPerMinute)
+	}
+	if c.RateLimitTokensPerMinute < 0 {
+		add("rate_limit.tokens_per_minute: must not be negative, got %d", c.RateLimitTokensPerMinute)
+	}
+	for _, rawURL := range c.NotifyURLs {
+		if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("notify.urls: %q is not an http or https URL", rawURL)
+		}
+	}
+	if c.NotifyMaxAttempts <= 0 {
+		add("notify.max_attempts: must be positive, got %d", c.NotifyMaxAttempts)
+	}
+	if c.NotifyQueueSize <= 0 {
+		add("notify.queue_size: must be positive, got %d", c.NotifyQueueSize)
+	}
+	if c.EnrichmentHTTPURL != "" {
+		if u, err := url.Parse(c.EnrichmentHTTPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("enrichment.http.url: %q is not an http or https URL", c.EnrichmentHTTPURL)
+		}
+	}
+	if c.EnrichmentHTTPTimeout < 0 {
+		add("enrichment.http.timeout: must not be negative, got %v", c.EnrichmentHTTPTimeout)
+	}
+	if c.DigestSchedule != "" {
+		if _, err := usecase.ParseDigestSchedule(c.DigestSchedule); err != nil {
+			add("digest.schedule: %v", err)
+		}
+	}
+	if c.ToolMaxOutputBytes < 0 {
+		add("tools.max_output_bytes: must not be negative, got %d", c.ToolMaxOutputBytes)
+	}
+	if c.BashMaxOutputBytes < 0 {
+		add("tools.bash.max_output_bytes: must not be negative, got %d", c.BashMaxOutputBytes)
+	}
+	for _, domain := range c.RunbookURLAllowedDomains {
+		if domain == "" || strings.ContainsAny(domain, "/:@ ") {
+			add("runbook_url_allowed_domains: %q is not a host name", domain)
+		}
+	}
+	for _, domain := range c.FetchURLAllowedDomains {
+		if domain == "" || strings.ContainsAny(domain, "/:@ ") {
+			add("tools.fetch_url.allowed_domains: %q is not a host name", domain)
+		}
+	}
+	if c.FetchURLMaxBytes <= 0 {
+		add("tools.fetch_url.max_bytes: must be positive, got %d", c.FetchURLMaxBytes)
+	}
+	if c.FetchURLTimeout <= 0 {
+		add("tools.fetch_url.timeout: must be positive, got %v", c.FetchURLTimeout)
+	}
+	if c.K8sEnabled && len(c.K8sAllowedNamespaces) == 0 {
+		add(`tools.k8s.allowed_namespaces: must list namespaces (or "*") when tools.k8s.enabled is set`)
+	}
+	if c.K8sMaxLogLines <= 0 {
+		add("tools.k8s.max_log_lines: must be positive, got %d", c.K8sMaxLogLines)
+	}
+	if c.PromQLEndpoint != "" {
+		if u, err := url.Parse(c.PromQLEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("tools.promql.endpoint: %q is not an http or https URL", c.PromQLEndpoint)
+		}
+	}
+	if c.PromQLMaxSeries <= 0 {
+		add("tools.promql.max_series: must be positive, got %d", c.PromQLMaxSeries)
+	}
+	if c.PromQLTimeout <= 0 {
+		add("tools.promql.timeout: must be positive, got %v", c.PromQLTimeout)
+	}
+	if c.GitMaxDiffBytes <= 0 {
+		add("tools.git.max_diff_bytes: must be positive, got %d", c.GitMaxDiffBytes)
+	}
+	for _, pattern := range c.GitProtectedBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			add("tools.git.push.protected_branches: invalid pattern %q", pattern)
+		}
+	}
+	if c.ToolDefaultTimeout < 0 {
+		add("tools.default_timeout: must not be negative, got %v", c.ToolDefaultTimeout)
+	}
+	for _, toolName := range sortedKeys(c.ToolTimeouts) {
+		if timeout := c.ToolTimeouts[toolName]; timeout < 0 {
+			add("%s.%s: must not be negative, got %v", toolTimeoutsKey, toolName, timeout)
+		}
+	}
+	if c.ToolCacheMaxEntries <= 0 {
+		add("tools.cache.max_entries: must be positive, got %d", c.ToolCacheMaxEntries)
+	}
+	if c.ToolCacheMaxBytes <= 0 {
+		add("tools.cache.max_bytes: must be positive, got %d", c.ToolCacheMaxBytes)
+	}
+	if c.ToolChangesMaxSnapshotBytes <= 0 {
+		add("tools.changes.max_snapshot_bytes: must be positive, got %d", c.ToolChangesMaxSnapshotBytes)
+	}
+	for _, name := range sortedKeys(c.MCPServers) {
+		c.validateMCPServer(name, add)
+	}
+	if c.HealthCacheTTL < 0 {
+		add("health.cache_ttl: must not be negative, got %v", c.HealthCacheTTL)
+	}
+	for _, name := range c.HealthOptionalChecks {
+		if !slices.Contains(health.CheckNames(), name) {
+			add("health.optional_checks: unknown check %q (want one of: %s)",
+				name, strings.Join(health.CheckNames(), ", "))
+		}
+	}
+	if u, err := url.Parse(c.UpdateCheckURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("update_check.url: %q is not an http or https URL", c.UpdateCheckURL)
+	}
+	if c.DebugAPIDir == "" {
+		add("debug_api.dir: must not be empty")
+	}
+	if c.DebugAPIMaxFileBytes <= 0 {
+		add("debug_api.max_file_bytes: must be positive, got %d", c.DebugAPIMaxFileBytes)
+	}
+	if c.DebugAPIRetention <= 0 {
+		add("debug_api.retention: must be positive, got %v", c.DebugAPIRetention)
+	}
+	return problems
+}
+
+// validateMCPServer checks the named MCP server's settings.
+func (c *Config) validateMCPServer(name string, add func(format string, args ...any)) {
+	server := c.MCPServers[name]
+	prefix := mcpServersKey + "." + name
+	if err := mcp.ValidateServerName(name); err != nil {
+		add("%s: %v", prefix, err)
+	}
+	if (server.Command == "") == (server.URL == "") {
+		add("%s: set exactly one of command and url", prefix)
+	}
+	if server.URL != "" {
+		if u, err := url.Parse(server.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("%s.url: %q is not an http or https URL", prefix, server.URL)
+		}
+	}
+	if server.Timeout < 0 {
+		add("%s.timeout: must not be negative, got %v", prefix, server.Timeout)
+	}
+}
+
+// WriteRedacted writes the configuration to w as YAML in the config file format,
+// with secrets masked.
+func (c *Config) WriteRedacted(w io.Writer) error {
+	tree := make(map[string]any)
+	for _, f := range configFields() {
+		value := f.display(c)
+		if f.secret && value != "" {
+			value = redactedValue
+		}
+		setNested(tree, f.key, value)
+	}
+	for severity, limits := range c.InvestigationSeverityOverrides {
+		prefix := severityOverridesKey + "." + severity
+		setNested(tree, prefix+".max_actions", limits.MaxActions)
+		setNested(tree, prefix+".max_duration", limits.MaxDuration.String())
+	}
+	for toolName, timeout := range c.ToolTimeouts {
+		setNested(tree, toolTimeoutsKey+"."+toolName, timeout.String())
+	}
+	for source, limit := range c.InvestigationSourceLimits {
+		setNested(tree, sourceLimitsKey+"."+source, limit)
+	}
+	if len(c.ModelCapabilities) > 0 {
+		// Set directly, as setNested would split model names at their dots
+		models := make(map[string]any, len(c.ModelCapabilities))
+		for model, override := range c.ModelCapabilities {
+			models[model] = modelCapabilityTree(override)
+		}
+		tree[modelsKey] = models
+	}
+	if len(c.Pricing) > 0 {
+		// Set directly, as setNested would split model names at their dots
+		pricing := make(map[string]any, len(c.Pricing))
+		for model, price := range c.Pricing {
+			pricing[model] = map[string]any{"input": price.Input, "output": price.Output}
+		}
+		tree[pricingKey] = pricing
+	}
+	for task, model := range c.ModelRouting {
+		setNested(tree, modelRoutingKey+"."+string(task), model)
+	}
+	for name, server := range c.MCPServers {
+		setNested(tree, mcpServersKey+"."+name, mcpServerTree(server))
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(tree); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return encoder.Close()
+}
+
+// setting is one configuration value and where it came from.
+type setting struct {
+	source string // File path or environment variable name
+	key    string // Dotted key, e.g. "tracing.endpoint"
+	value  any
+}
+
+// configPath resolves the config file path and whether it must exist.
+func configPath(path string) (string, bool) {
+	if path != "" {
+		return path, true
+	}
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path, true
+	}
+	return DefaultConfigFile, false
+}
+
+// readConfigFile reads the settings in the YAML file at path, sorted by key.
+// A missing file yields no settings unless required is set.
+func readConfigFile(path string, required bool) ([]setting, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flat := make(map[string]any)
+	flatten("", tree, flat)
+	settings := make([]setting, 0, len(flat))
+	for _, key := range sortedKeys(flat) {
+		settings = append(settings, setting{source: path, key: key, value: flat[key]})
+	}
+	return settings, nil
+}
+
+// flatten adds the leaves of tree to flat under dotted keys.
+func flatten(prefix string, tree map[string]any, flat map[string]any) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flatten(key, nested, flat)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+// envSettings returns the settings in CODE_AGENT_ environment variables, sorted
+// by name. ConfigPathEnv names the file, so it is not a setting.
+func envSettings(environ []string) []setting {
+	var settings []setting
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || name == ConfigPathEnv {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, EnvPrefix), "__", "."))
+		settings = append(settings, setting{source: name, key: key, value: value})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].source < settings[j].source })
+	return settings
+}
+
+// setField parses value and stores it in the field of cfg named by key.
+func setField(cfg *Config, key string, value any) error {
+	if rest, ok := strings.CutPrefix(key, severityOverridesKey+"."); ok {
+		return setSeverityOverride(cfg, rest, value)
+	}
+	if toolName, ok := strings.CutPrefix(key, toolTimeoutsKey+"."); ok {
+		timeout, err := parseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if cfg.ToolTimeouts == nil {
+			cfg.ToolTimeouts = make(map[string]time.Duration)
+		}
+		cfg.ToolTimeouts[toolName] = timeout
+		return nil
+	}
+	if source, ok := strings.CutPrefix(key, sourceLimitsKey+"."); ok {
+		limit, err := parseInt(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if cfg.InvestigationSourceLimits == nil {
+			cfg.InvestigationSourceLimits = make(map[string]int)
+		}
+		cfg.InvestigationSourceLimits[source] = int(limit)
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(key, modelsKey+"."); ok {
+		return setModelCapability(cfg, rest, value)
+	}
+	if rest, ok := strings.CutPrefix(key, pricingKey+"."); ok {
+		return setModelPrice(cfg, rest, value)
+	}
+	if task, ok := strings.CutPrefix(key, modelRoutingKey+"."); ok {
+		return setModelRoute(cfg, usecase.ModelTask(task), value)
+	}
+	if rest, ok := strings.CutPrefix(key, mcpServersKey+"."); ok {
+		return setMCPServer(cfg, rest, value)
+	}
+	for _, f := range configFields() {
+		if f.key == key {
+			if err := f.set(cfg, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+// setSeverityOverride stores a "<severity>.<limit>" value of the severity overrides.
+func setSeverityOverride(cfg *Config, key string, value any) error {
+	severity, limit, _ := strings.Cut(key, ".")
+	if !slices.Contains(knownSeverities(), severity) {
+		return fmt.Errorf("%s: unknown severity %q (want one of: %s)",
+			severityOverridesKey, severity, strings.Join(knownSeverities(), ", "))
+	}
+
+	limits := cfg.InvestigationSeverityOverrides[severity]
+	var err error
+	switch limit {
+	case "max_actions":
+		var n int64
+		n, err = parseInt(value)
+		limits.MaxActions = int(n)
+	case "max_duration":
+		limits.MaxDuration, err = parseDuration(value)
+	default:
+		return fmt.Errorf("unknown key %q", severityOverridesKey+"."+key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", severityOverridesKey, key, err)
+	}
+
+	if cfg.InvestigationSeverityOverrides == nil {
+		cfg.InvestigationSeverityOverrides = make(map[string]usecase.InvestigationLimits)
+	}
+	cfg.InvestigationSeverityOverrides[severity] = limits
+	return nil
+}
+
+// setModelCapability stores a "<model>.<capability>" value of the model
+// capability overrides.
+func setModelCapability(cfg *Config, key string, value any) error {
+	dot := strings.LastIndex(key, ".")
+	if dot <= 0 {
+		return fmt.Errorf("unknown key %q", modelsKey+"."+key)
+	}
+	model, capability := key[:dot], key[dot+1:]
+
+	override := cfg.ModelCapabilities[model]
+	var err error
+	switch capability {
+	case "context_window":
+		override.ContextWindow, err = parseInt(value)
+	case "max_output_tokens":
+		override.MaxOutputTokens, err = parseInt(value)
+	case "supports_tools":
+		override.SupportsTools, err = parseBoolPtr(value)
+	case "supports_thinking":
+		override.SupportsThinking, err = parseBoolPtr(value)
+	case "supports_images":
+		override.SupportsImages, err = parseBoolPtr(value)
+	default:
+		return fmt.Errorf("unknown key %q", modelsKey+"."+key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", modelsKey, key, err)
+	}
+
+	if cfg.ModelCapabilities == nil {
+		cfg.ModelCapabilities = make(map[string]ai.CapabilityOverride)
+	}
+	cfg.ModelCapabilities[model] = override
+	return nil
+}
+
+// setModelPrice stores a "<model>.<input|output>" value of the model prices.
+func setModelPrice(cfg *Config, key string, value any) error {
+	dot := strings.LastIndex(key, ".")
+	if dot <= 0 {
+		return fmt.Errorf("unknown key %q", pricingKey+"."+key)
+	}
+	model, side := key[:dot], key[dot+1:]
+
+	price := cfg.Pricing[model]
+	perMillion, err := parseFloat(value)
+	switch side {
+	case "input":
+		price.Input = perMillion
+	case "output":
+		price.Output = perMillion
+	default:
+		return fmt.Errorf("unknown key %q", pricingKey+"."+key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", pricingKey, key, err)
+	}
+
+	if cfg.Pricing == nil {
+		cfg.Pricing = make(usecase.Pricing)
+	}
+	cfg.Pricing[model] = price
+	return nil
+}
+
+// setModelRoute stores the model task is routed to.
+func setModelRoute(cfg *Config, task usecase.ModelTask, value any) error {
+	if !slices.Contains(usecase.ModelTasks(), task) {
+		return fmt.Errorf("%s: unknown task %q (want one of: %s)",
+			modelRoutingKey, task, strings.Join(knownModelTasks(), ", "))
+	}
+	model, err := parseString(value)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", modelRoutingKey, task, err)
+	}
+	if cfg.ModelRouting == nil {
+		cfg.ModelRouting = make(map[usecase.ModelTask]string)
+	}
+	cfg.ModelRouting[task] = model
+	return nil
+}
+
+// setMCPServer stores a "<server>.<field>" value of the MCP servers.
+func setMCPServer(cfg *Config, key string, value any) error {
+	name, field, _ := strings.Cut(key, ".")
+	field, entry, _ := strings.Cut(field, ".")
+
+	server := cfg.MCPServers[name]
+	var err error
+	switch {
+	case field == "command" && entry == "":
+		server.Command, err = parseString(value)
+	case field == "args" && entry == "":
+		server.Args, err = parseStringList(value)
+	case field == "url" && entry == "":
+		server.URL, err = parseString(value)
+	case field == "timeout" && entry == "":
+		server.Timeout, err = parseDuration(value)
+	case field == "env" && entry != "":
+		server.Env, err = setStringEntry(server.Env, entry, value)
+	case field == "headers" && entry != "":
+		server.Headers, err = setStringEntry(server.Headers, entry, value)
+	default:
+		return fmt.Errorf("unknown key %q", mcpServersKey+"."+key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", mcpServersKey, key, err)
+	}
+
+	if cfg.MCPServers == nil {
+		cfg.MCPServers = make(map[string]mcp.ServerConfig)
+	}
+	cfg.MCPServers[name] = server
+	return nil
+}
+
+// setStringEntry stores the string value under key in m, creating m if needed.
+func setStringEntry(m map[string]string, key string, value any) (map[string]string, error) {
+	s, err := parseString(value)
+	if err != nil {
+		return m, err
+	}
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[key] = s
+	return m, nil
+}
+
+// mcpServerTree returns server in config file form, with the values of its
+// environment and headers, which often hold credentials, masked.
+func mcpServerTree(server mcp.ServerConfig) map[string]any {
+	tree := make(map[string]any)
+	if server.Command != "" {
+		tree["command"] = server.Command
+	}
+	if len(server.Args) > 0 {
+		tree["args"] = server.Args
+	}
+	if server.URL != "" {
+		if u, err := url.Parse(server.URL); err == nil && u.User != nil {
+			tree["url"] = u.Redacted()
+		} else {
+			tree["url"] = server.URL
+		}
+	}
+	if server.Timeout != 0 {
+		tree["timeout"] = server.Timeout.String()
+	}
+	for field, entries := range map[string]map[string]string{"env": server.Env, "headers": server.Headers} {
+		if len(entries) == 0 {
+			continue
+		}
+		masked := make(map[string]any, len(entries))
+		for name := range entries {
+			masked[name] = redactedValue
+		}
+		tree[field] = masked
+	}
+	return tree
+}
+
+// knownModelTasks returns the names of the routable model tasks.
+func knownModelTasks() []string {
+	var names []string
+	for _, task := range usecase.ModelTasks() {
+		names = append(names, string(task))
+	}
+	return names
+}
+
+// parseBoolPtr parses a boolean that may be left unset.
+func parseBoolPtr(value any) (*bool, error) {
+	b, err := parseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// modelCapabilityTree returns the set fields of override in config file form.
+func modelCapabilityTree(override ai.CapabilityOverride) map[string]any {
+	tree := make(map[string]any)
+	if override.ContextWindow != 0 {
+		tree["context_window"] = override.ContextWindow
+	}
+	if override.MaxOutputTokens != 0 {
+		tree["max_output_tokens"] = override.MaxOutputTokens
+	}
+	for name, b := range map[string]*bool{
+		"supports_tools":    override.SupportsTools,
+		"supports_thinking": override.SupportsThinking,
+		"supports_images":   override.SupportsImages,
+	} {
+		if b != nil {
+			tree[name] = *b
+		}
+	}
+	return tree
+}
+
+// knownSeverities returns the alert severities that limits can be overridden for.
+func knownSeverities() []string {
+	return []string{entity.SeverityCritical, entity.SeverityWarning, entity.SeverityInfo}
+}
+
+// configField maps a config file key to a Config field.
+type configField struct {
+	key     string
+	secret  bool
+	display func(cfg *Config) any
+	set     func(cfg *Config, value any) error
+}
+
+// configFields returns the keys accepted in the config file and CODE_AGENT_
+// variables, except the severity overrides, per-tool timeouts, model
+// capability overrides, model prices, model routes, and MCP servers.
+func configFields() []configField {
+	return []configField{
+		stringField("provider", func(c *Config) *string { return &c.Provider }),
+		secretField("api_key", func(c *Config) *string { return &c.APIKey }),
+		stringField("model", func(c *Config) *string { return &c.AIModel }),
+		intField("max_tokens", func(c *Config) *int64 { return &c.MaxTokens }),
+		smallIntField("max_continuations", func(c *Config) *int { return &c.MaxContinuations }),
+		smallIntField("max_retries", func(c *Config) *int { return &c.MaxRetries }),
+		stringField("working_dir", func(c *Config) *string { return &c.WorkingDir }),
+		stringField("welcome_message", func(c *Config) *string { return &c.WelcomeMessage }),
+		stringField("goodbye_message", func(c *Config) *string { return &c.GoodbyeMessage }),
+		stringField("history_file", func(c *Config) *string { return &c.HistoryFile }),
+		smallIntField("history_max_entries", func(c *Config) *int { return &c.HistoryMaxEntries }),
+		boolField("auto_approve_safe", func(c *Config) *bool { return &c.AutoApproveSafeCommands }),
+		stringField("prompts_dir", func(c *Config) *string { return &c.PromptsDir }),
+		stringField("runbooks_dir", func(c *Config) *string { return &c.RunbooksDir }),
+		stringListField("runbook_url_allowed_domains", func(c *Config) *[]string { return &c.RunbookURLAllowedDomains }),
+		boolField("memory.enabled", func(c *Config) *bool { return &c.MemoryEnabled }),
+		smallIntField("memory.max_bytes", func(c *Config) *int { return &c.MemoryMaxBytes }),
+		boolField("project_context.enabled", func(c *Config) *bool { return &c.ProjectContextEnabled }),
+		boolField("tool_selection.enabled", func(c *Config) *bool { return &c.ToolSelectionEnabled }),
+		smallIntField("tool_selection.recent_messages", func(c *Config) *int { return &c.ToolSelectionRecentMessages }),
+		boolField("no_markdown", func(c *Config) *bool { return &c.DisableMarkdown }),
+		boolField("no_color", func(c *Config) *bool { return &c.NoColor }),
+		boolField("verbose", func(c *Config) *bool { return &c.Verbose }),
+		boolField("attention.bell", func(c *Config) *bool { return &c.AttentionBell }),
+		boolField("attention.desktop", func(c *Config) *bool { return &c.AttentionDesktop }),
+		durationField("attention.turn_threshold", func(c *Config) *time.Duration { return &c.AttentionTurnThreshold }),
+		stringField("transcript", func(c *Config) *string { return &c.TranscriptFile }),
+		intField("transcript_max_bytes", func(c *Config) *int64 { return &c.TranscriptMaxBytes }),
+		stringField("session_dir", func(c *Config) *string { return &c.SessionDir }),
+		stringField("metrics_listen_addr", func(c *Config) *string { return &c.MetricsListenAddr }),
+		urlField("tracing.endpoint", func(c *Config) *string { return &c.TracingEndpoint }),
+		floatField("tracing.sample_ratio", func(c *Config) *float64 { return &c.TracingSampleRatio }),
+		stringField("log_level", func(c *Config) *string { return &c.LogLevel }),
+		stringField("log_format", func(c *Config) *string { return &c.LogFormat }),
+		stringField("log_file", func(c *Config) *string { return &c.LogFile }),
+		boolField("thinking.enabled", func(c *Config) *bool { return &c.ExtendedThinking }),
+		intField("thinking.budget", func(c *Config) *int64 { return &c.ThinkingBudget }),
+		boolField("thinking.show", func(c *Config) *bool { return &c.ShowThinking }),
+		smallIntField("investigation.max_actions", func(c *Config) *int { return &c.InvestigationMaxActions }),
+		durationField("investigation.max_duration", func(c *Config) *time.Duration { return &c.InvestigationMaxDuration }),
+		smallIntField("investigation.max_concurrent", func(c *Config) *int { return &c.InvestigationMaxConcurrent }),
+		durationField("investigation.max_concurrent_wait", func(c *Config) *time.Duration {
+			return &c.InvestigationMaxConcurrentWait
+		}),
+		smallIntField("investigation.source_limit", func(c *Config) *int { return &c.InvestigationSourceLimit }),
+		stringField("investigation.source_label", func(c *Config) *string { return &c.InvestigationSourceLabel }),
+		stringListField("investigation.allowed_command_patterns", func(c *Config) *[]string {
+			return &c.InvestigationAllowedCommandPatterns
+		}),
+		floatField("investigation.max_cost", func(c *Config) *float64 { return &c.InvestigationMaxCost }),
+		smallIntField("investigation.max_schema_retries", func(c *Config) *int {
+			return &c.InvestigationMaxSchemaRetries
+		}),
+		boolField("investigation.count_subagent_usage", func(c *Config) *bool {
+			return &c.InvestigationCountSubagentUsage
+		}),
+		boolField("investigation.auto_remediate", func(c *Config) *bool { return &c.InvestigationAutoRemediate }),
+		stringField("investigation.message_template", func(c *Config) *string { return &c.InvestigationMessageTemplate }),
+		stringField("investigation.title_template", func(c *Config) *string { return &c.InvestigationTitleTemplate }),
+		floatField("investigation.daily_budget", func(c *Config) *float64 { return &c.InvestigationDailyBudget }),
+		durationField("investigation.max_provider_hold", func(c *Config) *time.Duration {
+			return &c.InvestigationMaxProviderHold
+		}),
+		smallIntField("subagent.max_actions", func(c *Config) *int { return &c.SubagentMaxActions }),
+		durationField("subagent.max_duration", func(c *Config) *time.Duration { return &c.SubagentMaxDuration }),
+		stringListField("subagent.allowed_command_patterns", func(c *Config) *[]string {
+			return &c.SubagentAllowedCommandPatterns
+		}),
+		durationField("drain_timeout", func(c *Config) *time.Duration { return &c.DrainTimeout }),
+		smallIntField("alert_circuit.threshold", func(c *Config) *int { return &c.AlertCircuitThreshold }),
+		durationField("alert_circuit.window", func(c *Config) *time.Duration { return &c.AlertCircuitWindow }),
+		durationField("alert_circuit.cooldown", func(c *Config) *time.Duration { return &c.AlertCircuitCooldown }),
+		smallIntField("rate_limit.requests_per_minute", func(c *Config) *int { return &c.RateLimitRequestsPerMinute }),
+		smallIntField("rate_limit.tokens_per_minute", func(c *Config) *int { return &c.RateLimitTokensPerMinute }),
+		stringListField("notify.urls", func(c *Config) *[]string { return &c.NotifyURLs }),
+		secretField("notify.secret", func(c *Config) *string { return &c.NotifySecret }),
+		smallIntField("notify.max_attempts", func(c *Config) *int { return &c.NotifyMaxAttempts }),
+		smallIntField("notify.queue_size", func(c *Config) *int { return &c.NotifyQueueSize }),
+		stringField("enrichment.static_file", func(c *Config) *string { return &c.EnrichmentStaticFile }),
+		urlField("enrichment.http.url", func(c *Config) *string { return &c.EnrichmentHTTPURL }),
+		durationField("enrichment.http.timeout", func(c *Config) *time.Duration { return &c.EnrichmentHTTPTimeout }),
+		stringField("digest.schedule", func(c *Config) *string { return &c.DigestSchedule }),
+		smallIntField("tools.max_output_bytes", func(c *Config) *int { return &c.ToolMaxOutputBytes }),
+		stringListField("tools.blocked_commands", func(c *Config) *[]string { return &c.ToolBlockedCommands }),
+		smallIntField("tools.bash.max_output_bytes", func(c *Config) *int { return &c.BashMaxOutputBytes }),
+		stringListField("tools.bash.allowed_env", func(c *Config) *[]string { return &c.BashAllowedEnv }),
+		stringListField("tools.fetch_url.allowed_domains", func(c *Config) *[]string { return &c.FetchURLAllowedDomains }),
+		smallIntField("tools.fetch_url.max_bytes", func(c *Config) *int { return &c.FetchURLMaxBytes }),
+		durationField("tools.fetch_url.timeout", func(c *Config) *time.Duration { return &c.FetchURLTimeout }),
+		boolField("tools.k8s.enabled", func(c *Config) *bool { return &c.K8sEnabled }),
+		stringField("tools.k8s.kubeconfig", func(c *Config) *string { return &c.K8sKubeconfig }),
+		stringField("tools.k8s.context", func(c *Config) *string { return &c.K8sContext }),
+		stringListField("tools.k8s.allowed_namespaces", func(c *Config) *[]string { return &c.K8sAllowedNamespaces }),
+		smallIntField("tools.k8s.max_log_lines", func(c *Config) *int { return &c.K8sMaxLogLines }),
+		urlField("tools.promql.endpoint", func(c *Config) *string { return &c.PromQLEndpoint }),
+		stringListField("tools.promql.allowed_hosts", func(c *Config) *[]string { return &c.PromQLAllowedHosts }),
+		smallIntField("tools.promql.max_series", func(c *Config) *int { return &c.PromQLMaxSeries }),
+		durationField("tools.promql.timeout", func(c *Config) *time.Duration { return &c.PromQLTimeout }),
+		boolField("tools.git.enabled", func(c *Config) *bool { return &c.GitEnabled }),
+		smallIntField("tools.git.max_diff_bytes", func(c *Config) *int { return &c.GitMaxDiffBytes }),
+		boolField("tools.git.push.enabled", func(c *Config) *bool { return &c.GitPushEnabled }),
+		stringListField("tools.git.push.protected_branches", func(c *Config) *[]string { return &c.GitProtectedBranches }),
+		durationField("tools.default_timeout", func(c *Config) *time.Duration { return &c.ToolDefaultTimeout }),
+		boolField("tools.cache.enabled", func(c *Config) *bool { return &c.ToolCacheEnabled }),
+		smallIntField("tools.cache.max_entries", func(c *Config) *int { return &c.ToolCacheMaxEntries }),
+		smallIntField("tools.cache.max_bytes", func(c *Config) *int { return &c.ToolCacheMaxBytes }),
+		smallIntField("tools.changes.max_snapshot_bytes", func(c *Config) *int { return &c.ToolChangesMaxSnapshotBytes }),
+		durationField("health.cache_ttl", func(c *Config) *time.Duration { return &c.HealthCacheTTL }),
+		stringListField("health.optional_checks", func(c *Config) *[]string { return &c.HealthOptionalChecks }),
+		boolField("update_check.enabled", func(c *Config) *bool { return &c.UpdateCheckEnabled }),
+		urlField("update_check.url", func(c *Config) *string { return &c.UpdateCheckURL }),
+		boolField("debug_api.enabled", func(c *Config) *bool { return &c.DebugAPIEnabled }),
+		stringField("debug_api.dir", func(c *Config) *string { return &c.DebugAPIDir }),
+		smallIntField("debug_api.max_file_bytes", func(c *Config) *int { return &c.DebugAPIMaxFileBytes }),
+		durationField("debug_api.retention", func(c *Config) *time.Duration { return &c.DebugAPIRetention }),
+		stringField("debug_api.record_file", func(c *Config) *string { return &c.DebugAPIRecordFile }),
+	}
+}
+
+// field builds a configField storing parse(value) in *ptr(cfg), displayed with format.
+func field[T any](key string, ptr func(*Config) *T, parse func(any) (T, error), format func(T) any) configField {
+	return configField{
+		key:     key,
+		display: func(cfg *Config) any { return format(*ptr(cfg)) },
+		set: func(cfg *Config, value any) error {
+			parsed, err := parse(value)
+			if err != nil {
+				return err
+			}
+			*ptr(cfg) = parsed
+			return nil
+		},
+	}
+}
+
+func identity[T any](v T) any { return v }
+
+func stringField(key string, ptr func(*Config) *string) configField {
+	return field(key, ptr, parseString, identity[string])
+}
+
+func secretField(key string, ptr func(*Config) *string) configField {
+	f := stringField(key, ptr)
+	f.secret = true
+	return f
+}
+
+// urlField is a string field whose displayed value hides URL passwords.
+func urlField(key string, ptr func(*Config) *string) configField {
+	return field(key, ptr, parseString, func(v string) any {
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+		return v
+	})
+}
+
+func intField(key string, ptr func(*Config) *int64) configField {
+	return field(key, ptr, parseInt, identity[int64])
+}
+
+func smallIntField(key string, ptr func(*Config) *int) configField {
+	parse := func(value any) (int, error) {
+		n, err := parseInt(value)
+		return int(n), err
+	}
+	return field(key, ptr, parse, identity[int])
+}
+
+func boolField(key string, ptr func(*Config) *bool) configField {
+	return field(key, ptr, parseBool, identity[bool])
+}
+
+func floatField(key string, ptr func(*Config) *float64) configField {
+	return field(key, ptr, parseFloat, identity[float64])
+}
+
+func durationField(key string, ptr func(*Config) *time.Duration) configField {
+	return field(key, ptr, parseDuration, func(d time.Duration) any { return d.String() })
+}
+
+func stringListField(key string, ptr func(*Config) *[]string) configField {
+	return field(key, ptr, parseStringList, func(v []string) any {
+		if v == nil {
+			return []string{}
+		}
+		return v
+	})
+}
+
+// parseString accepts any scalar, since YAML reads unquoted values like 8080 as numbers.
+func parseString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int, float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, got %v", value)
+	}
+}
+
+func parseInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %v", value)
+	}
+}
+
+func parseBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("expected true or false, got %q", v)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("expected true or false, got %v", value)
+	}
+}
+
+func parseFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %v", value)
+	}
+}
+
+// parseStringList accepts a YAML list of scalars or, for environment
+// variables, a comma-separated string.
+func parseStringList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := parseString(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	case string:
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("expected a list, got %v", value)
+	}
+}
+
+// parseDuration parses durations with time.ParseDuration, e.g. "90s" or "15m".
+func parseDuration(value any) (time.Duration, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected a duration such as \"90s\" or \"15m\", got %v", value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %w", err)
+	}
+	return d, nil
+}
+
+// setNested stores value in tree under a dotted key, creating nested maps.
+func setNested(tree map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := tree[part].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			tree[part] = child
+		}
+		tree = child
+	}
+	tree[parts[len(parts)-1]] = value
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/port"
+	"code-editing-agent/internal/infrastructure/adapter/notify"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// reloadableSettings maps the config keys that Reload applies to the function
+// copying their value from a newly loaded configuration. Changes to any other
+// key need a restart.
+//
+//nolint:gochecknoglobals // read-only table
+var reloadableSettings = map[string]func(dst, src *Config){
+	"prompts_dir":  func(dst, src *Config) { dst.PromptsDir = src.PromptsDir },
+	"runbooks_dir": func(dst, src *Config) { dst.RunbooksDir = src.RunbooksDir },
+	"runbook_url_allowed_domains": func(dst, src *Config) {
+		dst.RunbookURLAllowedDomains = src.RunbookURLAllowedDomains
+	},
+	"tools.blocked_commands": func(dst, src *Config) {
+		dst.ToolBlockedCommands = src.ToolBlockedCommands
+	},
+	"investigation.allowed_command_patterns": func(dst, src *Config) {
+		dst.InvestigationAllowedCommandPatterns = src.InvestigationAllowedCommandPatterns
+	},
+	"investigation.max_actions": func(dst, src *Config) {
+		dst.InvestigationMaxActions = src.InvestigationMaxActions
+	},
+	"investigation.max_duration": func(dst, src *Config) {
+		dst.InvestigationMaxDuration = src.InvestigationMaxDuration
+	},
+	"investigation.max_cost": func(dst, src *Config) { dst.InvestigationMaxCost = src.InvestigationMaxCost },
+	severityOverridesKey: func(dst, src *Config) {
+		dst.InvestigationSeverityOverrides = src.InvestigationSeverityOverrides
+	},
+	"notify.urls":         func(dst, src *Config) { dst.NotifyURLs = src.NotifyURLs },
+	"notify.secret":       func(dst, src *Config) { dst.NotifySecret = src.NotifySecret },
+	"notify.max_attempts": func(dst, src *Config) { dst.NotifyMaxAttempts = src.NotifyMaxAttempts },
+	"notify.queue_size":   func(dst, src *Config) { dst.NotifyQueueSize = src.NotifyQueueSize },
+}
+
+// changedKeys returns the sorted config keys whose values differ between old and updated.
+func changedKeys(old, updated *Config) []string {
+	var keys []string
+	for _, f := range configFields() {
+		// Compare as printed, so nil and empty lists are equal
+		if fmt.Sprint(f.display(old)) != fmt.Sprint(f.display(updated)) {
+			keys = append(keys, f.key)
+		}
+	}
+	maps := []struct {
+		key        string
+		old, value any
+		empty      bool
+	}{
+		{severityOverridesKey, old.InvestigationSeverityOverrides, updated.InvestigationSeverityOverrides,
+			len(old.InvestigationSeverityOverrides)+len(updated.InvestigationSeverityOverrides) == 0},
+		{sourceLimitsKey, old.InvestigationSourceLimits, updated.InvestigationSourceLimits,
+			len(old.InvestigationSourceLimits)+len(updated.InvestigationSourceLimits) == 0},
+		{toolTimeoutsKey, old.ToolTimeouts, updated.ToolTimeouts, len(old.ToolTimeouts)+len(updated.ToolTimeouts) == 0},
+		{modelsKey, old.ModelCapabilities, updated.ModelCapabilities,
+			len(old.ModelCapabilities)+len(updated.ModelCapabilities) == 0},
+		{pricingKey, old.Pricing, updated.Pricing, len(old.Pricing)+len(updated.Pricing) == 0},
+		{modelRoutingKey, old.ModelRouting, updated.ModelRouting, len(old.ModelRouting)+len(updated.ModelRouting) == 0},
+		{mcpServersKey, old.MCPServers, updated.MCPServers, len(old.MCPServers)+len(updated.MCPServers) == 0},
+	}
+	for _, m := range maps {
+		if !m.empty && !reflect.DeepEqual(m.old, m.value) {
+			keys = append(keys, m.key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Reload applies the reloadable settings of updated, a newly loaded
+// configuration, to the running components: prompt templates and runbooks,
+// blocked commands, investigation limits and severity overrides, and result
+// notifications. Skills are rediscovered. Changes to other settings, such as
+// listen addresses, the store path, or provider credentials, are reported as
+// ignored and need a restart.
+//
+// Investigations already running keep the configuration they started with.
+// If the new prompt templates cannot be loaded or skills cannot be
+// discovered, nothing is changed and the error is returned.
+func (c *Container) Reload(ctx context.Context, updated *Config) (port.ConfigReloadResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result port.ConfigReloadResult
+	next := *c.config
+	for _, key := range changedKeys(c.config, updated) {
+		if apply, ok := reloadableSettings[key]; ok {
+			apply(&next, updated)
+			result.Applied = append(result.Applied, key)
+		} else {
+			result.Ignored = append(result.Ignored, key)
+		}
+	}
+
+	// Prepare everything that can fail before changing anything
+	promptRegistry, err := newPromptBuilderRegistry(&next)
+	if err != nil {
+		return port.ConfigReloadResult{}, err
+	}
+	skills, err := c.skillManager.DiscoverSkills(ctx)
+	if err != nil {
+		return port.ConfigReloadResult{}, fmt.Errorf("failed to discover skills: %w", err)
+	}
+
+	c.investigationUseCase.SetConfig(reloadInvestigationConfig(c.investigationUseCase.Config(), &next))
+	c.investigationUseCase.SetPromptBuilderRegistry(promptRegistry)
+	c.blockedCommands.Set(next.ToolBlockedCommands)
+	c.chatService.SetPromptLayer(usecase.SkillsPromptLayer(skills.Skills))
+	if slices.ContainsFunc(result.Applied, isNotifyKey) {
+		c.replaceResultNotifier(NewResultNotifier(&next, c.investigationStore, c.logger))
+	}
+	c.config = &next
+	return result, nil
+}
+
+// reloadInvestigationConfig returns current with the reloadable investigation
+// settings taken from cfg.
+func reloadInvestigationConfig(
+	current usecase.AlertInvestigationUseCaseConfig,
+	cfg *Config,
+) usecase.AlertInvestigationUseCaseConfig {
+	reloaded := investigationConfig(cfg)
+	current.MaxActions = reloaded.MaxActions
+	current.MaxDuration = reloaded.MaxDuration
+	current.MaxCost = reloaded.MaxCost
+	current.BlockedCommands = reloaded.BlockedCommands
+	current.AllowedCommandPatterns = reloaded.AllowedCommandPatterns
+	current.SeverityOverrides = reloaded.SeverityOverrides
+	return current
+}
+
+// isNotifyKey reports whether key is a result notification setting.
+func isNotifyKey(key string) bool {
+	return strings.HasPrefix(key, "notify.")
+}
+
+// replaceResultNotifier makes notifier, which may be nil, the destination of
+// investigation results, circuit breaker notices, and digests. The previous notifier is
+// kept open until Shutdown, as investigations started before the reload still
+// deliver through it. The caller must hold c.mu.
+func (c *Container) replaceResultNotifier(notifier *notify.Notifier) {
+	if c.resultNotifier != nil {
+		c.retiredNotifiers = append(c.retiredNotifiers, c.resultNotifier)
+	}
+	c.resultNotifier = notifier
+
+	// Avoid storing a typed nil in the interfaces
+	if notifier == nil {
+		c.investigationUseCase.SetResultNotifier(nil)
+		if c.alertCircuit != nil {
+			c.alertCircuit.SetNotifier(nil)
+		}
+		c.digestGenerator.SetNotifier(nil)
+		return
+	}
+	c.investigationUseCase.SetResultNotifier(notifier)
+	if c.alertCircuit != nil {
+		c.alertCircuit.SetNotifier(notifier)
+	}
+	c.digestGenerator.SetNotifier(notifier)
+}
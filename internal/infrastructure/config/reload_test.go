@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newReloadTestContainer(t *testing.T) *Container {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	cfg := Defaults()
+	cfg.HistoryFile = ""
+	cfg.WorkingDir = t.TempDir()
+	cfg.ToolBlockedCommands = []string{"shutdown"}
+
+	container, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = container.Shutdown(context.Background()) })
+	return container
+}
+
+// TestContainer_Reload verifies that Reload applies reloadable settings to the
+// running components and reports, but leaves, the others.
+func TestContainer_Reload(t *testing.T) {
+	container := newReloadTestContainer(t)
+	oldConfig := container.Config()
+
+	updated := *oldConfig
+	updated.ToolBlockedCommands = []string{"curl"}
+	updated.InvestigationMaxActions = 7
+	updated.APIKey = "new-key"
+	updated.MetricsListenAddr = ":9999"
+
+	result, err := container.Reload(context.Background(), &updated)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if want := []string{"investigation.max_actions", "tools.blocked_commands"}; !slices.Equal(result.Applied, want) {
+		t.Errorf("Applied = %v, want %v", result.Applied, want)
+	}
+	if want := []string{"api_key", "metrics_listen_addr"}; !slices.Equal(result.Ignored, want) {
+		t.Errorf("Ignored = %v, want %v", result.Ignored, want)
+	}
+
+	cfg := container.Config()
+	if !slices.Equal(cfg.ToolBlockedCommands, []string{"curl"}) || cfg.InvestigationMaxActions != 7 {
+		t.Errorf("Config() = %+v, want the reloaded settings", cfg)
+	}
+	if cfg.APIKey != oldConfig.APIKey || cfg.MetricsListenAddr != oldConfig.MetricsListenAddr {
+		t.Errorf("Config() changed ignored settings: api_key %q, metrics_listen_addr %q", cfg.APIKey, cfg.MetricsListenAddr)
+	}
+	if patterns := container.blockedCommands.Patterns(); !slices.Equal(patterns, []string{"curl"}) {
+		t.Errorf("chat blocked commands = %v, want [curl]", patterns)
+	}
+	invConfig := container.InvestigationUseCase().Config()
+	if invConfig.MaxActions != 7 {
+		t.Errorf("investigation MaxActions = %d, want 7", invConfig.MaxActions)
+	}
+	if !slices.Contains(invConfig.BlockedCommands, "curl") || slices.Contains(invConfig.BlockedCommands, "shutdown") {
+		t.Errorf("investigation BlockedCommands = %v, want curl but not shutdown", invConfig.BlockedCommands)
+	}
+}
+
+// TestContainer_ReloadFailureKeepsConfig verifies that a configuration that
+// cannot be applied leaves the running one in place.
+func TestContainer_ReloadFailureKeepsConfig(t *testing.T) {
+	container := newReloadTestContainer(t)
+	oldConfig := container.Config()
+
+	promptsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(promptsDir, "Generic.tmpl"), []byte("{{ .Alert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	updated := *oldConfig
+	updated.PromptsDir = promptsDir
+	updated.ToolBlockedCommands = []string{"curl"}
+
+	if _, err := container.Reload(context.Background(), &updated); err == nil {
+		t.Fatal("Reload() error = nil, want the template error")
+	}
+	if container.Config() != oldConfig {
+		t.Error("Config() changed after a failed reload")
+	}
+	if patterns := container.blockedCommands.Patterns(); !slices.Equal(patterns, []string{"shutdown"}) {
+		t.Errorf("chat blocked commands = %v, want [shutdown]", patterns)
+	}
+	if slices.Contains(container.InvestigationUseCase().Config().BlockedCommands, "curl") {
+		t.Error("investigation blocked commands changed after a failed reload")
+	}
+}
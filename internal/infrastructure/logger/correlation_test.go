@@ -0,0 +1,201 @@
+package logger_test
+
+import (
+	"code-editing-agent/internal/application/usecase"
+	"code-editing-agent/internal/domain/entity"
+	"code-editing-agent/internal/domain/service"
+	"code-editing-agent/internal/infrastructure/adapter/ai"
+	"code-editing-agent/internal/infrastructure/adapter/ai/aitest"
+	"code-editing-agent/internal/infrastructure/adapter/file"
+	"code-editing-agent/internal/infrastructure/adapter/subagent"
+	"code-editing-agent/internal/infrastructure/adapter/tool"
+	"code-editing-agent/internal/infrastructure/logger"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capturedRecord is a log record with its attributes flattened, including those
+// added with Logger.With.
+type capturedRecord struct {
+	message string
+	attrs   map[string]string
+}
+
+// captureHandler records every log record for inspection.
+type captureHandler struct {
+	mu      *sync.Mutex
+	records *[]capturedRecord
+	attrs   []slog.Attr
+}
+
+func newCaptureHandler() *captureHandler {
+	return &captureHandler{mu: new(sync.Mutex), records: new([]capturedRecord)}
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make(map[string]string)
+	for _, a := range h.attrs {
+		attrs[a.Key] = a.Value.String()
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, capturedRecord{message: record.Message, attrs: attrs})
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *h
+	derived.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &derived
+}
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+// byMessage returns the captured records with the given message.
+func (h *captureHandler) byMessage(message string) []capturedRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var matched []capturedRecord
+	for _, r := range *h.records {
+		if r.message == message {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+func TestLogging_ToolExecutionsCarryCorrelationAttributes(t *testing.T) {
+	server := aitest.ScriptedAnthropicServer(t,
+		// Investigation turn 1: list files and delegate to a subagent
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_1","name":"list_files","input":{"path":"."}},`+
+				`{"type":"tool_use","id":"toolu_2","name":"task","input":{"agent_name":"disk-checker","prompt":"Check disk usage"}}`,
+			10, 5),
+		// Subagent turn: read a file, then finish
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_3","name":"read_file","input":{"path":"notes.txt"}}`, 10, 5),
+		aitest.AnthropicResponse("end_turn", `{"type":"text","text":"Disk usage is normal."}`, 10, 5),
+		// Investigation turn 2: complete
+		aitest.AnthropicResponse("tool_use",
+			`{"type":"tool_use","id":"toolu_4","name":"complete_investigation",`+
+				`"input":{"confidence":0.9,"findings":["Disk is fine"],"root_cause":"None"}}`,
+			10, 5),
+	)
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+	capture := newCaptureHandler()
+	log := slog.New(logger.NewSequenceHandler(capture, nil))
+
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "notes.txt"), []byte("disk ok\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	agentDir := filepath.Join(workDir, "agents", "disk-checker")
+	if err := os.MkdirAll(agentDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	agentFile := "---\nname: disk-checker\ndescription: Checks disk usage\n---\nCheck disk usage."
+	if err := os.WriteFile(filepath.Join(agentDir, "AGENT.md"), []byte(agentFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	agents := subagent.NewLocalSubagentManagerWithDirs([]subagent.DirConfig{
+		{Path: filepath.Join(workDir, "agents"), SourceType: entity.SubagentSourceProject},
+	})
+	if _, err := agents.DiscoverAgents(context.Background()); err != nil {
+		t.Fatalf("DiscoverAgents() error = %v", err)
+	}
+
+	aiProvider := ai.NewAnthropicAdapter("test-model", 1024, nil)
+	executor := tool.NewExecutorAdapter(file.NewLocalFileManager(workDir))
+	executor.SetLogger(log)
+
+	convService, err := service.NewConversationService(aiProvider, executor)
+	if err != nil {
+		t.Fatalf("NewConversationService() error = %v", err)
+	}
+
+	runner := usecase.NewSubagentRunner(convService, executor, aiProvider, nil, usecase.SubagentConfig{
+		MaxActions:  5,
+		MaxDuration: time.Minute,
+	})
+	runner.SetLogger(log)
+	executor.SetSubagentUseCase(usecase.NewSubagentUseCase(agents, runner))
+
+	investigations := usecase.NewAlertInvestigationUseCaseWithConfig(usecase.AlertInvestigationUseCaseConfig{
+		MaxActions:    20,
+		MaxDuration:   time.Minute,
+		MaxConcurrent: 1,
+		AllowedTools:  []string{"list_files", "read_file", "task", "complete_investigation"},
+	})
+	investigations.SetConversationService(convService)
+	investigations.SetToolExecutor(executor)
+	prompts := usecase.NewPromptBuilderRegistry()
+	if err := prompts.Register(usecase.NewGenericPromptBuilder()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	investigations.SetPromptBuilderRegistry(prompts)
+	investigations.SetLogger(log)
+
+	alert, err := entity.NewAlert("alert-1", "prometheus", entity.SeverityCritical, "Disk full")
+	if err != nil {
+		t.Fatalf("NewAlert() error = %v", err)
+	}
+	handler := usecase.NewAlertHandler(investigations, usecase.AlertHandlerConfig{AutoInvestigateCritical: true})
+	handler.SetLogger(log)
+	if err := handler.HandleEntityAlert(context.Background(), alert); err != nil {
+		t.Fatalf("HandleEntityAlert() error = %v", err)
+	}
+
+	completed := capture.byMessage("Investigation completed")
+	if len(completed) != 1 {
+		t.Fatalf("got %d \"Investigation completed\" records, want 1", len(completed))
+	}
+	investigationID := completed[0].attrs["investigation_id"]
+	if investigationID == "" {
+		t.Fatal("AlertHandler records should carry investigation_id")
+	}
+	if got := completed[0].attrs["alert_id"]; got != "alert-1" {
+		t.Errorf("alert_id = %q, want %q", got, "alert-1")
+	}
+
+	toolRecords := make(map[string]capturedRecord)
+	for _, r := range capture.byMessage("Tool executed") {
+		toolRecords[r.attrs["tool"]] = r
+	}
+	for _, name := range []string{"list_files", "task", "read_file"} {
+		r, ok := toolRecords[name]
+		if !ok {
+			t.Errorf("no \"Tool executed\" record for %s", name)
+			continue
+		}
+		if got := r.attrs["investigation_id"]; got != investigationID {
+			t.Errorf("%s record investigation_id = %q, want %q", name, got, investigationID)
+		}
+		if r.attrs["session_id"] == "" {
+			t.Errorf("%s record should carry session_id", name)
+		}
+		if r.attrs[logger.SequenceKey] == "" {
+			t.Errorf("%s record should carry a sequence number", name)
+		}
+	}
+
+	if got := toolRecords["read_file"].attrs["subagent_id"]; got == "" {
+		t.Error("subagent tool records should carry subagent_id")
+	}
+	if got := toolRecords["list_files"].attrs["subagent_id"]; got != "" {
+		t.Errorf("investigation tool records should not carry subagent_id, got %q", got)
+	}
+}
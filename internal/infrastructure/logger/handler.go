@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// SequenceKey is the attribute key of the sequence number added by SequenceHandler.
+const SequenceKey = "seq"
+
+// SequenceHandler wraps a slog.Handler, numbering records so their order can be
+// recovered after logs from concurrent investigations are interleaved or shipped
+// out of order. Numbers increase monotonically across all loggers derived from
+// the handler with With or WithGroup.
+//
+// When a stderr handler is set, records at WARN and above are also written to it,
+// so problems stay visible when the main log goes to a file.
+type SequenceHandler struct {
+	next   slog.Handler
+	stderr slog.Handler // Receives WARN+ duplicates (optional, can be nil)
+	seq    *atomic.Uint64
+}
+
+// NewSequenceHandler creates a SequenceHandler writing to next, duplicating
+// WARN+ records to stderr when it is not nil.
+func NewSequenceHandler(next, stderr slog.Handler) *SequenceHandler {
+	return &SequenceHandler{next: next, stderr: stderr, seq: new(atomic.Uint64)}
+}
+
+// Enabled implements slog.Handler.
+func (h *SequenceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+	return h.duplicates(level) && h.stderr.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *SequenceHandler) Handle(ctx context.Context, record slog.Record) error {
+	record = record.Clone()
+	record.AddAttrs(slog.Uint64(SequenceKey, h.seq.Add(1)))
+
+	var err error
+	if h.next.Enabled(ctx, record.Level) {
+		err = h.next.Handle(ctx, record)
+	}
+	if h.duplicates(record.Level) && h.stderr.Enabled(ctx, record.Level) {
+		if stderrErr := h.stderr.Handle(ctx, record); err == nil {
+			err = stderrErr
+		}
+	}
+	return err
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SequenceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := &SequenceHandler{next: h.next.WithAttrs(attrs), seq: h.seq}
+	if h.stderr != nil {
+		derived.stderr = h.stderr.WithAttrs(attrs)
+	}
+	return derived
+}
+
+// WithGroup implements slog.Handler.
+func (h *SequenceHandler) WithGroup(name string) slog.Handler {
+	derived := &SequenceHandler{next: h.next.WithGroup(name), seq: h.seq}
+	if h.stderr != nil {
+		derived.stderr = h.stderr.WithGroup(name)
+	}
+	return derived
+}
+
+// duplicates reports whether records at level are also written to stderr.
+func (h *SequenceHandler) duplicates(level slog.Level) bool {
+	return h.stderr != nil && level >= slog.LevelWarn
+}
@@ -0,0 +1,95 @@
+// Package logger builds the structured logger shared by the agent's components.
+//
+// Components take a *slog.Logger through SetLogger and derive per-run loggers
+// with correlation attributes (investigation_id, session_id, subagent_id), so
+// every record from one investigation can be found with a single filter.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Log formats accepted in Config.Format.
+const (
+	// FormatText writes key=value lines.
+	FormatText = "text"
+	// FormatJSON writes one JSON object per line.
+	FormatJSON = "json"
+)
+
+// Config configures the logger.
+type Config struct {
+	// Level is the minimum level logged: "debug", "info", "warn", or "error".
+	// Defaults to "info".
+	Level string
+
+	// Format is FormatText or FormatJSON.
+	// Defaults to FormatText.
+	Format string
+
+	// File is the path logs are appended to. WARN and above are also written
+	// to stderr as text. Defaults to "" (log to stderr only).
+	File string
+}
+
+// New creates a logger from cfg. The returned close function closes the log
+// file, if any, and must be called when the logger is no longer used.
+func New(cfg Config) (*slog.Logger, func() error, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.File == "" {
+		handler, err := newHandler(os.Stderr, cfg.Format, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return slog.New(NewSequenceHandler(handler, nil)), func() error { return nil }, nil
+	}
+
+	file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	handler, err := newHandler(file, cfg.Format, opts)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	stderr := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})
+	return slog.New(NewSequenceHandler(handler, stderr)), file.Close, nil
+}
+
+// ParseLevel parses a level name, case-insensitively. An empty name is "info".
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn, or error)", name)
+	}
+}
+
+// newHandler creates a handler writing format to w.
+func newHandler(w io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want %s or %s)", format, FormatText, FormatJSON)
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSequenceHandler_NumbersAcrossDerivedLoggers(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewSequenceHandler(slog.NewJSONHandler(&buf, nil), nil))
+	child := logger.With("investigation_id", "inv-1")
+	grouped := logger.WithGroup("g")
+
+	logger.Info("one")
+	child.Info("two")
+	grouped.Info("three")
+	logger.Info("four")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d records, want 4:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("record %d is not JSON: %v", i, err)
+		}
+		seq := record[SequenceKey]
+		if g, ok := record["g"].(map[string]any); ok {
+			seq = g[SequenceKey]
+		}
+		if seq != float64(i+1) {
+			t.Errorf("record %d %s = %v, want %d", i, SequenceKey, seq, i+1)
+		}
+	}
+}
+
+func TestSequenceHandler_DuplicatesWarningsToStderr(t *testing.T) {
+	var main, stderr bytes.Buffer
+	logger := slog.New(NewSequenceHandler(
+		slog.NewTextHandler(&main, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&stderr, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)).With("session_id", "s-1")
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if got := strings.Count(main.String(), "\n"); got != 4 {
+		t.Errorf("main handler got %d records, want 4:\n%s", got, main.String())
+	}
+	out := stderr.String()
+	if strings.Contains(out, "msg=debug") || strings.Contains(out, "msg=info") {
+		t.Errorf("stderr should only get WARN and above, got:\n%s", out)
+	}
+	for _, want := range []string{"msg=warn", "msg=error", "session_id=s-1", "seq=3", "seq=4"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stderr should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSequenceHandler_EnabledForStderrOnly(t *testing.T) {
+	var main, stderr bytes.Buffer
+	logger := slog.New(NewSequenceHandler(
+		slog.NewTextHandler(&main, &slog.HandlerOptions{Level: slog.LevelError}),
+		slog.NewTextHandler(&stderr, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	))
+
+	logger.Warn("warn")
+
+	if main.Len() != 0 {
+		t.Errorf("main handler should drop WARN below its level, got:\n%s", main.String())
+	}
+	if !strings.Contains(stderr.String(), "msg=warn") {
+		t.Errorf("stderr should get WARN, got:\n%s", stderr.String())
+	}
+}
+
+func TestNew_WritesJSONToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	logger, closeLogger, err := New(Config{Level: "debug", Format: FormatJSON, File: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Debug("Tool executed", "tool", "read_file")
+	if err := closeLogger(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+		t.Fatalf("log file should hold a JSON record, got %q: %v", data, err)
+	}
+	if record["msg"] != "Tool executed" || record["tool"] != "read_file" || record[SequenceKey] != float64(1) {
+		t.Errorf("unexpected record %v", record)
+	}
+}
+
+func TestNew_RejectsUnknownSettings(t *testing.T) {
+	if _, _, err := New(Config{Level: "verbose"}); err == nil {
+		t.Error("New() should reject an unknown level")
+	}
+	if _, _, err := New(Config{Format: "xml"}); err == nil {
+		t.Error("New() should reject an unknown format")
+	}
+	if _, _, err := New(Config{File: filepath.Join(t.TempDir(), "missing", "agent.log")}); err == nil {
+		t.Error("New() should fail when the log file cannot be opened")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{" error ", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
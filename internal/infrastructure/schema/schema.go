@@ -0,0 +1,82 @@
+// Package schema versions the JSON records the file stores persist. Each
+// record carries a schema_version field; records written by an older version
+// are migrated, one version at a time, when they are read, and records
+// written by a newer version are refused rather than misread.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// VersionField is the JSON field that holds a record's schema version.
+// Records without it are version 1, the format before versioning.
+const VersionField = "schema_version"
+
+// ErrNewerVersion is returned for a record written by a newer version than
+// this build can read.
+var ErrNewerVersion = errors.New("written by a newer version")
+
+// Migration rewrites a record, given as its top-level JSON fields, from one
+// schema version to the next.
+type Migration func(record map[string]json.RawMessage) error
+
+// Migrations is the migration registry of one kind of record.
+type Migrations struct {
+	// Kind names the records in errors, e.g. "investigation record".
+	Kind string
+	// Steps[i] migrates a record from version i+1 to version i+2.
+	Steps []Migration
+}
+
+// Current returns the schema version records are written at.
+func (m Migrations) Current() int {
+	return len(m.Steps) + 1
+}
+
+// Migrate returns the record in data at the current version, and whether it
+// had to be migrated. A record at the current version is returned unchanged.
+func (m Migrations) Migrate(data []byte) ([]byte, bool, error) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, err
+	}
+	version, err := versionOf(record)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > m.Current() {
+		return nil, false, fmt.Errorf("%w: %s has schema version %d, but this build reads up to version %d; "+
+			"upgrade code-editing-agent to open it", ErrNewerVersion, m.Kind, version, m.Current())
+	}
+	if version == m.Current() {
+		return data, false, nil
+	}
+
+	for ; version < m.Current(); version++ {
+		if err := m.Steps[version-1](record); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate %s from schema version %d to %d: %w",
+				m.Kind, version, version+1, err)
+		}
+	}
+	record[VersionField] = json.RawMessage(fmt.Sprint(version))
+	migrated, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+	return migrated, true, nil
+}
+
+// versionOf reads the schema version of a record's fields.
+func versionOf(record map[string]json.RawMessage) (int, error) {
+	raw, ok := record[VersionField]
+	if !ok {
+		return 1, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid %s %s", VersionField, raw)
+	}
+	return version, nil
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMigrations_Migrate(t *testing.T) {
+	rename := func(from, to string) Migration {
+		return func(record map[string]json.RawMessage) error {
+			record[to] = record[from]
+			delete(record, from)
+			return nil
+		}
+	}
+	migrations := Migrations{Kind: "widget", Steps: []Migration{rename("a", "b"), rename("b", "c")}}
+
+	tests := []struct {
+		name         string
+		in           string
+		want         string
+		wantMigrated bool
+		wantErr      error
+	}{
+		{"unversioned is version 1", `{"a":1}`, `{"c":1,"schema_version":3}`, true, nil},
+		{"version 2", `{"schema_version":2,"b":1}`, `{"c":1,"schema_version":3}`, true, nil},
+		{"current", `{"schema_version":3,"c":1}`, `{"schema_version":3,"c":1}`, false, nil},
+		{"newer", `{"schema_version":4,"d":1}`, "", false, ErrNewerVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, migrated, err := migrations.Migrate([]byte(tt.in))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Migrate() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want || migrated != tt.wantMigrated {
+				t.Errorf("Migrate() = %s, %v, want %s, %v", got, migrated, tt.want, tt.wantMigrated)
+			}
+		})
+	}
+}
+
+func TestMigrations_MigrateRejectsInvalidVersion(t *testing.T) {
+	for _, in := range []string{`{"schema_version":0}`, `{"schema_version":"2"}`, `[1]`} {
+		if _, _, err := (Migrations{Kind: "widget"}).Migrate([]byte(in)); err == nil {
+			t.Errorf("Migrate(%s) expected error", in)
+		}
+	}
+}
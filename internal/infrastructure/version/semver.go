@@ -0,0 +1,114 @@
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSemver is returned for a version that is not a semantic version,
+// such as the "dev" of builds without a release version.
+var ErrNotSemver = errors.New("not a semantic version")
+
+// semver is a parsed semantic version. Build metadata is dropped, as it does
+// not affect precedence.
+type semver struct {
+	core       [3]int   // Major, minor, and patch
+	prerelease []string // Dot-separated pre-release identifiers, if any
+}
+
+// Compare returns -1, 0, or +1 as semantic version a is older than, the same
+// as, or newer than b, by semver precedence. A leading "v" is allowed, and
+// missing minor and patch numbers count as 0, so "v1.2" equals "1.2.0".
+func Compare(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.compare(vb), nil
+}
+
+// parseSemver parses version as [v]MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD].
+func parseSemver(version string) (semver, error) {
+	invalid := fmt.Errorf("%w: %q", ErrNotSemver, version)
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, prerelease, hasPrerelease := strings.Cut(s, "-")
+
+	var v semver
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v.core) {
+		return semver{}, invalid
+	}
+	for i, part := range parts {
+		n, ok := numericIdentifier(part)
+		if !ok {
+			return semver{}, invalid
+		}
+		v.core[i] = n
+	}
+	if hasPrerelease {
+		v.prerelease = strings.Split(prerelease, ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return semver{}, invalid
+			}
+		}
+	}
+	return v, nil
+}
+
+// numericIdentifier parses a number without sign or leading zeros.
+func numericIdentifier(s string) (int, bool) {
+	if s == "" || (len(s) > 1 && s[0] == '0') || strings.ContainsAny(s, "+-") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// compare orders v and o by semver precedence: a pre-release is older than
+// its release, and pre-releases compare identifier by identifier.
+func (v semver) compare(o semver) int {
+	for i := range v.core {
+		if c := cmp.Compare(v.core[i], o.core[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+	for i := range min(len(v.prerelease), len(o.prerelease)) {
+		if c := comparePrerelease(v.prerelease[i], o.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.prerelease), len(o.prerelease))
+}
+
+// comparePrerelease orders two pre-release identifiers: numeric ones
+// numerically and before alphanumeric ones, which compare as ASCII.
+func comparePrerelease(a, b string) int {
+	na, aNumeric := numericIdentifier(a)
+	nb, bNumeric := numericIdentifier(b)
+	switch {
+	case aNumeric && bNumeric:
+		return cmp.Compare(na, nb)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
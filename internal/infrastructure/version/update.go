@@ -0,0 +1,60 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultReleasesURL is the GitHub API endpoint of the agent's latest release.
+const DefaultReleasesURL = "https://api.github.com/repos/Anthony-Bible/code-agent-demo/releases/latest"
+
+// maxReleaseBytes bounds how much of a release response is read.
+const maxReleaseBytes = 1 << 20
+
+// Release is a published release of the agent.
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"`
+}
+
+// CheckForUpdate fetches the latest release from url, a GitHub releases API
+// endpoint such as DefaultReleasesURL, and returns it if it is newer than
+// current, or nil if it is not. It only reads the release; nothing is
+// downloaded. A current version that is not a semantic version, like "dev",
+// gives an error wrapping ErrNotSemver.
+func CheckForUpdate(ctx context.Context, client *http.Client, url, current string) (*Release, error) {
+	if _, err := parseSemver(current); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create update check request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "code-editing-agent/"+current)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update check failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("update check failed: %s", resp.Status)
+	}
+
+	var latest Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReleaseBytes)).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("invalid release response: %w", err)
+	}
+	newer, err := Compare(latest.Version, current)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latest release: %w", err)
+	}
+	if newer <= 0 {
+		return nil, nil
+	}
+	return &latest, nil
+}
@@ -0,0 +1,73 @@
+// Package version identifies the build of the agent and checks whether a
+// newer release is out. Version, Commit, and Date are set at build time:
+//
+//	go build -ldflags "\
+//	  -X code-editing-agent/internal/infrastructure/version.Version=v1.4.0 \
+//	  -X code-editing-agent/internal/infrastructure/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X code-editing-agent/internal/infrastructure/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without them, Get falls back to the commit and time the Go toolchain
+// records for builds from a git checkout.
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// Build information, set with -ldflags -X.
+//
+//nolint:gochecknoglobals // set by the linker
+var (
+	Version = "dev" // Release version, such as v1.4.0
+	Commit  = ""    // Commit the build is from
+	Date    = ""    // When the build was made, in RFC 3339
+)
+
+// shortCommitLen is how many characters of a commit hash are shown.
+const shortCommitLen = 12
+
+// Info describes a build of the agent.
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"built,omitempty"`
+}
+
+// Get returns the running build's information.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date}
+	if info.Commit != "" && info.Date != "" {
+		return info
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.Date == "":
+			info.Date = setting.Value
+		}
+	}
+	return info
+}
+
+// String returns the version, followed by the commit and build date when
+// known, as in "v1.4.0 (commit 1a2b3c4d5e6f, built 2026-03-04T05:00:00Z)".
+func (i Info) String() string {
+	var details []string
+	if i.Commit != "" {
+		details = append(details, "commit "+i.Commit[:min(len(i.Commit), shortCommitLen)])
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	if len(details) == 0 {
+		return i.Version
+	}
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
@@ -0,0 +1,135 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2", "v1.2.0", 0},
+		{"v2", "v2.0.0", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.10.0", "v1.9.0", 1}, // Numbers, not strings
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.0.0+build.5", "v1.0.0+build.7", 0}, // Build metadata is ignored
+		{"v1.0.0-rc.1", "v1.0.0", -1},           // A pre-release precedes its release
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.0.0-rc.1", "v0.9.9", 1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1}, // Fewer identifiers precede more
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-alpha.beta", "v1.0.0-beta", -1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1}, // Numeric identifiers compare numerically
+		{"v1.0.0-beta.11", "v1.0.0-rc.1", -1},
+		{"v1.0.0-rc.1+build.1", "v1.0.0-rc.1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s vs %s", tt.a, tt.b), func(t *testing.T) {
+			got, err := Compare(tt.a, tt.b)
+			if err != nil || got != tt.want {
+				t.Errorf("Compare(%q, %q) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_NotSemver(t *testing.T) {
+	invalid := []string{"dev", "", "v", "1.2.3.4", "v1.02.3", "v1.-2.3", "v1.2.x", "v1.2.3-", "v1.2.3-rc..1"}
+	for _, version := range invalid {
+		if _, err := Compare(version, "v1.0.0"); !errors.Is(err, ErrNotSemver) {
+			t.Errorf("Compare(%q, v1.0.0) error = %v, want ErrNotSemver", version, err)
+		}
+	}
+}
+
+func TestInfo_String(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Version: "dev"}, "dev"},
+		{Info{Version: "v1.4.0", Commit: "abc1234"}, "v1.4.0 (commit abc1234)"},
+		{
+			Info{Version: "v1.4.0", Commit: "0123456789abcdef0123", Date: "2026-03-04T05:00:00Z"},
+			"v1.4.0 (commit 0123456789ab, built 2026-03-04T05:00:00Z)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestGet_PrefersLinkerValues(t *testing.T) {
+	defer func(version, commit, date string) { Version, Commit, Date = version, commit, date }(Version, Commit, Date)
+	Version, Commit, Date = "v1.4.0", "abc1234", "2026-03-04T05:00:00Z"
+
+	want := Info{Version: "v1.4.0", Commit: "abc1234", Date: "2026-03-04T05:00:00Z"}
+	if got := Get(); got != want {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	var latest string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "code-editing-agent/v1.4.0" {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		_, _ = fmt.Fprintf(w, `{"tag_name":%q,"html_url":"https://example.com/releases/%s","draft":false}`,
+			latest, latest)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		latest string
+		want   *Release
+	}{
+		{"v1.5.0", &Release{Version: "v1.5.0", URL: "https://example.com/releases/v1.5.0"}},
+		{"v1.4.0", nil},
+		{"v1.3.9", nil},
+		{"v1.4.0-rc.1", nil},
+	}
+	for _, tt := range tests {
+		latest = tt.latest
+		got, err := CheckForUpdate(context.Background(), server.Client(), server.URL, "v1.4.0")
+		if err != nil {
+			t.Fatalf("CheckForUpdate() with latest %s error = %v", tt.latest, err)
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("CheckForUpdate() with latest %s = %+v, want %+v", tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestCheckForUpdate_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"tag_name":"nightly"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, err := CheckForUpdate(ctx, server.Client(), server.URL, "dev"); !errors.Is(err, ErrNotSemver) {
+		t.Errorf("CheckForUpdate() of a dev build error = %v, want ErrNotSemver", err)
+	}
+	if _, err := CheckForUpdate(ctx, server.Client(), server.URL+"/missing", "v1.4.0"); err == nil {
+		t.Error("CheckForUpdate() of a 404 error = nil, want an error")
+	}
+	if _, err := CheckForUpdate(ctx, server.Client(), server.URL, "v1.4.0"); !errors.Is(err, ErrNotSemver) {
+		t.Errorf("CheckForUpdate() of a non-semver release error = %v, want ErrNotSemver", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"code-editing-agent/internal/infrastructure/adapter/ui"
 	"context"
 	"encoding/json"
 	"errors"
@@ -129,6 +130,9 @@ func (a *Agent) runInference(ctx context.Context, conversation []anthropic.Messa
 	return message, err
 }
 
+// GenerateSchema reflects T into a tool input schema. Besides descriptions,
+// jsonschema struct tags add enum, default, and example values to each
+// property; fields without omitempty are listed as required.
 func GenerateSchema[T any]() anthropic.ToolInputSchemaParam {
 	reflector := jsonschema.Reflector{
 		AllowAdditionalProperties: false,
@@ -139,6 +143,7 @@ func GenerateSchema[T any]() anthropic.ToolInputSchemaParam {
 
 	return anthropic.ToolInputSchemaParam{
 		Properties: schema.Properties,
+		Required:   schema.Required,
 	}
 }
 
@@ -155,7 +160,7 @@ func (a *Agent) executeTool(id string, name string, input json.RawMessage) anthr
 	if !found {
 		return anthropic.NewToolResultBlock(id, "tool not found", true)
 	}
-	fmt.Printf("\u001b[92mClaude\u001b[0m:  %s(%s)\n", name, input)
+	fmt.Printf("\u001b[92mClaude\u001b[0m:  %s\n", ui.FormatToolCall(name, string(input)))
 	response, err := toolDef.Function(input)
 	if err != nil {
 		return anthropic.NewToolResultBlock(id, err.Error(), true)
@@ -189,17 +194,17 @@ If the file specified with path doesn't exist, it will be created.'`,
 
 // ReadFileInput represents the input required to read a file from the working directory by specifying its relative path.
 type ReadFileInput struct {
-	Path string `json:"path" jsonschema_description:"The relative path to the file to read in the working directory.."`
+	Path string `json:"path" jsonschema:"example=main.go" jsonschema_description:"The relative path to the file to read in the working directory.."`
 }
 
 // ListFilesInput represents the input required to list files and directories in a given path. If no path is provided, lists files in the current working directory.
 type ListFilesInput struct {
-	Path string `json:"path" jsonschema_description:"The relative path to the directory to list files in. If not provided, lists files in the current working directory."`
+	Path string `json:"path,omitempty" jsonschema:"default=.,example=internal" jsonschema_description:"The relative path to the directory to list files in. If not provided, lists files in the current working directory."`
 }
 
 // EditFileInput represents the input required to edit a file by replacing occurrences of a specified string with a new string.
 type EditFileInput struct {
-	Path   string `json:"path"    jsonschema_description:"The relative path to the file to edit."`
+	Path   string `json:"path"    jsonschema:"example=main.go" jsonschema_description:"The relative path to the file to edit."`
 	OldStr string `json:"old_str" jsonschema_description:"The string to replace."`
 	NewStr string `json:"new_str" jsonschema_description:"The string to replace 'old_str' with."`
 }